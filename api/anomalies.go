@@ -0,0 +1,65 @@
+// Package api - Cost anomalies endpoint
+// Saved estimates of a project that fell outside the band of the ones before them
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"terraform-cost/decision/anomaly"
+)
+
+// handleProjectAnomalies lists a project's cost anomalies, newest first
+// GET /api/v1/projects/{id}/anomalies?environment=&window=&sigma=
+func (s *Server) handleProjectAnomalies(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/projects/"), "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] != "anomalies" {
+		s.jsonError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if r.Method != http.MethodGet {
+		s.jsonError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	project := parts[0]
+	if err := authorizeProject(r.Context(), project); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	q := r.URL.Query()
+	opts := anomaly.Options{Window: anomaly.DefaultWindow, Sigma: anomaly.DefaultSigma}
+	if v := q.Get("window"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			s.jsonError(w, http.StatusBadRequest, "window must be a positive integer")
+			return
+		}
+		opts.Window = n
+	}
+	if v := q.Get("sigma"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f <= 0 {
+			s.jsonError(w, http.StatusBadRequest, "sigma must be a positive number")
+			return
+		}
+		opts.Sigma = f
+	}
+	environment := q.Get("environment")
+
+	anomalies, err := anomaly.NewDetector(s.pricingStore).Detect(r.Context(), project, environment, opts)
+	if err != nil {
+		s.jsonError(w, http.StatusInternalServerError, fmt.Sprintf("failed to detect anomalies: %v", err))
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"project":     project,
+		"environment": environment,
+		"window":      opts.Window,
+		"sigma":       opts.Sigma,
+		"anomalies":   anomalies,
+	})
+}
@@ -0,0 +1,270 @@
+// Package api - Approvals
+// A policy deny can be overridden without editing policies: an approval is
+// requested for the denied estimate (request_approval on an estimate, or POST
+// /api/v1/approvals from the CLI), the approval channels are notified, and an
+// approver approves or rejects it: a token subject in --approver, or else a
+// token issued with the approver claim. Requesters cannot decide their own
+// approvals.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/currency"
+	"terraform-cost/decision/policy"
+	"terraform-cost/integrations"
+	"terraform-cost/integrations/notify"
+	"terraform-cost/telemetry"
+	"terraform-cost/tenant"
+)
+
+// DefaultApprovalTTL is how long an approval stays pending before it lapses
+const DefaultApprovalTTL = 7 * 24 * time.Hour
+
+// ApprovalRequest asks to override a denied estimate
+type ApprovalRequest struct {
+	Project        string          `json:"project"`
+	Environment    string          `json:"environment,omitempty"`
+	Branch         string          `json:"branch,omitempty"`
+	CommitSHA      string          `json:"commit_sha,omitempty"`
+	PullRequest    string          `json:"pull_request,omitempty"`
+	EstimationID   string          `json:"estimation_id,omitempty"`
+	Link           string          `json:"link,omitempty"` // PR or pipeline for reviewers
+	Currency       string          `json:"currency,omitempty"`
+	MonthlyCostP50 decimal.Decimal `json:"monthly_cost_p50"`
+	Violations     []string        `json:"violations"`
+}
+
+// ApprovalDecision approves or rejects an approval
+type ApprovalDecision struct {
+	Comment string `json:"comment,omitempty"`
+}
+
+// approvalStore keeps approvals; *clickhouse.Store is the production store
+type approvalStore interface {
+	CreateApproval(ctx context.Context, a *clickhouse.Approval) error
+	GetApproval(ctx context.Context, id uuid.UUID) (*clickhouse.Approval, error)
+	ListApprovals(ctx context.Context, filter clickhouse.ApprovalFilter) ([]*clickhouse.Approval, error)
+	DecideApproval(ctx context.Context, a *clickhouse.Approval, status clickhouse.ApprovalStatus, decidedBy, comment string) error
+}
+
+// requestApproval stores a pending approval and notifies the approval channels
+// Notification failures are returned as warnings.
+func (s *Server) requestApproval(ctx context.Context, req ApprovalRequest) (*clickhouse.Approval, []string, error) {
+	if s.approvalStore == nil {
+		return nil, nil, fmt.Errorf("approvals need the ClickHouse store")
+	}
+	now := time.Now().UTC()
+	a := &clickhouse.Approval{
+		Project:        req.Project,
+		Environment:    req.Environment,
+		Branch:         req.Branch,
+		CommitSHA:      req.CommitSHA,
+		PullRequest:    req.PullRequest,
+		EstimationID:   req.EstimationID,
+		Link:           req.Link,
+		Currency:       currency.Normalize(req.Currency),
+		MonthlyCostP50: req.MonthlyCostP50,
+		Violations:     req.Violations,
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(s.config.ApprovalTTL),
+	}
+	if t, ok := tenant.FromContext(ctx); ok {
+		a.RequestedBy = t.Subject
+	}
+	if err := s.approvalStore.CreateApproval(ctx, a); err != nil {
+		return nil, nil, err
+	}
+
+	var warnings []string
+	if len(s.config.ApprovalNotifiers) > 0 {
+		notifyCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		defer cancel()
+		report := &integrations.Report{
+			Title:          fmt.Sprintf("%s: approval %s requested", a.Project, a.ID),
+			Environment:    a.Environment,
+			Link:           a.Link,
+			Currency:       a.Currency,
+			MonthlyCostP50: a.MonthlyCostP50,
+			MonthlyCostP90: a.MonthlyCostP50,
+			Confidence:     1,
+			PolicyDecision: policy.DecisionDeny,
+			Violations:     a.Violations,
+		}
+		for _, err := range notify.Send(notifyCtx, s.config.ApprovalNotifiers, report) {
+			warnings = append(warnings, fmt.Sprintf("approval notification failed: %v", err))
+		}
+	}
+	return a, warnings, nil
+}
+
+// handleApprovals lists (GET) and requests (POST) approvals
+func (s *Server) handleApprovals(w http.ResponseWriter, r *http.Request) {
+	if s.approvalStore == nil {
+		s.jsonError(w, http.StatusServiceUnavailable, "approvals need the ClickHouse store")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		approvals, err := s.approvalStore.ListApprovals(r.Context(), clickhouse.ApprovalFilter{
+			Project: q.Get("project"),
+			Status:  clickhouse.ApprovalStatus(q.Get("status")),
+		})
+		if err != nil {
+			s.jsonError(w, http.StatusInternalServerError, fmt.Sprintf("failed to list approvals: %v", err))
+			return
+		}
+		// Project-scoped tokens only see their projects' approvals
+		visible := approvals[:0]
+		for _, a := range approvals {
+			if authorizeProject(r.Context(), a.Project) == nil {
+				visible = append(visible, a)
+			}
+		}
+		s.jsonResponse(w, http.StatusOK, visible)
+
+	case http.MethodPost:
+		var req ApprovalRequest
+		r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxRequestSize)
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.jsonError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+			return
+		}
+		if req.Project == "" {
+			s.writeError(w, badRequest("project is required"))
+			return
+		}
+		if err := authorizeProject(r.Context(), req.Project); err != nil {
+			s.writeError(w, err)
+			return
+		}
+		a, warnings, err := s.requestApproval(r.Context(), req)
+		if err != nil {
+			s.jsonError(w, http.StatusInternalServerError, fmt.Sprintf("failed to request approval: %v", err))
+			return
+		}
+		for _, warning := range warnings {
+			telemetry.Logger(r.Context()).Warn(warning, "approval_id", a.ID)
+		}
+		s.jsonResponse(w, http.StatusCreated, a)
+
+	default:
+		s.jsonError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handleApproval serves /api/v1/approvals/{id} (GET) and /api/v1/approvals/{id}/approve|reject (POST)
+func (s *Server) handleApproval(w http.ResponseWriter, r *http.Request) {
+	if s.approvalStore == nil {
+		s.jsonError(w, http.StatusServiceUnavailable, "approvals need the ClickHouse store")
+		return
+	}
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/approvals/"), "/")
+	idPart, action, _ := strings.Cut(path, "/")
+	id, err := uuid.Parse(idPart)
+	if err != nil {
+		s.jsonError(w, http.StatusBadRequest, "invalid approval id")
+		return
+	}
+	var status clickhouse.ApprovalStatus
+	switch action {
+	case "":
+	case "approve":
+		status = clickhouse.ApprovalApproved
+	case "reject":
+		status = clickhouse.ApprovalRejected
+	default:
+		s.jsonError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	ctx := r.Context()
+	a, err := s.approvalStore.GetApproval(ctx, id)
+	if err != nil {
+		s.jsonError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get approval: %v", err))
+		return
+	}
+	if a == nil {
+		s.jsonError(w, http.StatusNotFound, "approval not found")
+		return
+	}
+	if err := authorizeProject(ctx, a.Project); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	if status == "" {
+		if r.Method != http.MethodGet {
+			s.jsonError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		s.jsonResponse(w, http.StatusOK, a)
+		return
+	}
+	if r.Method != http.MethodPost {
+		s.jsonError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Decisions are attributed to an approver other than the requester
+	t, ok := tenant.FromContext(ctx)
+	if !ok {
+		s.writeError(w, forbidden("deciding approvals requires an authenticated server (--auth-secret)"))
+		return
+	}
+	if err := s.authorizeApprover(t); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	if t.Subject == a.RequestedBy {
+		s.writeError(w, forbidden("approvals cannot be decided by their requester"))
+		return
+	}
+	var decision ApprovalDecision
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxRequestSize)
+	if err := json.NewDecoder(r.Body).Decode(&decision); err != nil && !errors.Is(err, io.EOF) {
+		s.jsonError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	if err := s.approvalStore.DecideApproval(ctx, a, status, t.Subject, decision.Comment); err != nil {
+		if errors.Is(err, clickhouse.ErrApprovalDecided) {
+			s.jsonError(w, http.StatusConflict, fmt.Sprintf("approval is already %s", a.Status))
+			return
+		}
+		s.jsonError(w, http.StatusInternalServerError, fmt.Sprintf("failed to decide approval: %v", err))
+		return
+	}
+	s.jsonResponse(w, http.StatusOK, a)
+}
+
+// authorizeApprover checks that a tenant may decide approvals: its token names
+// a subject, listed in Approvers when configured, else with the approver claim
+func (s *Server) authorizeApprover(t tenant.Tenant) error {
+	if t.Subject == "" {
+		return forbidden("deciding approvals requires a token with a subject (terracost token --subject)")
+	}
+	if len(s.config.Approvers) > 0 {
+		for _, approver := range s.config.Approvers {
+			if approver == t.Subject {
+				return nil
+			}
+		}
+		return forbidden("%q is not an approver", t.Subject)
+	}
+	if !t.Approver {
+		return forbidden("deciding approvals requires an approver token (terracost token --approver)")
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/tenant"
+)
+
+func TestAuthorizeApprover(t *testing.T) {
+	claim := &Server{config: &Config{}}
+	listed := &Server{config: &Config{Approvers: []string{"lead"}}}
+
+	tests := []struct {
+		name   string
+		server *Server
+		tenant tenant.Tenant
+		want   bool
+	}{
+		{"approver claim", claim, tenant.Tenant{OrgID: "acme", Subject: "lead", Approver: true}, true},
+		{"no approver claim", claim, tenant.Tenant{OrgID: "acme", Subject: "ci"}, false},
+		{"claim without subject", claim, tenant.Tenant{OrgID: "acme", Approver: true}, false},
+		{"listed subject", listed, tenant.Tenant{OrgID: "acme", Subject: "lead"}, true},
+		{"unlisted subject with claim", listed, tenant.Tenant{OrgID: "acme", Subject: "ci", Approver: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.server.authorizeApprover(tt.tenant); (err == nil) != tt.want {
+				t.Errorf("err = %v, want allowed %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// fakeApprovalStore keeps approvals in memory with the store's pending check
+type fakeApprovalStore struct {
+	approvals map[uuid.UUID]clickhouse.Approval
+}
+
+func (f *fakeApprovalStore) CreateApproval(_ context.Context, a *clickhouse.Approval) error {
+	a.Status = clickhouse.ApprovalPending
+	f.approvals[a.ID] = *a
+	return nil
+}
+
+func (f *fakeApprovalStore) GetApproval(_ context.Context, id uuid.UUID) (*clickhouse.Approval, error) {
+	a, ok := f.approvals[id]
+	if !ok {
+		return nil, nil
+	}
+	return &a, nil
+}
+
+func (f *fakeApprovalStore) ListApprovals(context.Context, clickhouse.ApprovalFilter) ([]*clickhouse.Approval, error) {
+	approvals := make([]*clickhouse.Approval, 0, len(f.approvals))
+	for _, a := range f.approvals {
+		approvals = append(approvals, &a)
+	}
+	return approvals, nil
+}
+
+func (f *fakeApprovalStore) DecideApproval(_ context.Context, a *clickhouse.Approval, status clickhouse.ApprovalStatus, decidedBy, comment string) error {
+	if a.Status != clickhouse.ApprovalPending {
+		return clickhouse.ErrApprovalDecided
+	}
+	now := time.Now().UTC()
+	a.Status, a.DecidedBy, a.Comment, a.DecidedAt = status, decidedBy, comment, &now
+	f.approvals[a.ID] = *a
+	return nil
+}
+
+func TestHandleApproval(t *testing.T) {
+	store := &fakeApprovalStore{approvals: make(map[uuid.UUID]clickhouse.Approval)}
+	s := &Server{config: &Config{MaxRequestSize: 1 << 20}, approvalStore: store}
+	a := &clickhouse.Approval{ID: uuid.New(), Project: "web", RequestedBy: "ci", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := store.CreateApproval(context.Background(), a); err != nil {
+		t.Fatal(err)
+	}
+	path := "/api/v1/approvals/" + a.ID.String()
+
+	lead := tenant.Tenant{OrgID: "acme", Subject: "lead", Approver: true}
+	call := func(method, path string, t tenant.Tenant, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		if t.OrgID != "" {
+			r = r.WithContext(tenant.NewContext(r.Context(), t))
+		}
+		rec := httptest.NewRecorder()
+		s.handleApproval(rec, r)
+		return rec
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		tenant tenant.Tenant
+		status int
+	}{
+		{"get", http.MethodGet, path, tenant.Tenant{OrgID: "acme", Projects: []string{"web"}}, http.StatusOK},
+		{"get with trailing slash", http.MethodGet, path + "/", lead, http.StatusOK},
+		{"invalid id", http.MethodGet, "/api/v1/approvals/not-a-uuid", lead, http.StatusBadRequest},
+		{"unknown action", http.MethodPost, path + "/merge", lead, http.StatusNotFound},
+		{"unknown approval", http.MethodGet, "/api/v1/approvals/" + uuid.NewString(), lead, http.StatusNotFound},
+		{"decide with get", http.MethodGet, path + "/approve", lead, http.StatusMethodNotAllowed},
+		{"post without action", http.MethodPost, path, lead, http.StatusMethodNotAllowed},
+		{"other project get", http.MethodGet, path, tenant.Tenant{OrgID: "acme", Projects: []string{"billing"}}, http.StatusForbidden},
+		{"other project approver", http.MethodPost, path + "/approve",
+			tenant.Tenant{OrgID: "acme", Subject: "lead", Approver: true, Projects: []string{"billing"}}, http.StatusForbidden},
+		{"unauthenticated", http.MethodPost, path + "/approve", tenant.Tenant{}, http.StatusForbidden},
+		{"self-approval", http.MethodPost, path + "/approve", tenant.Tenant{OrgID: "acme", Subject: "ci", Approver: true}, http.StatusForbidden},
+		{"not an approver", http.MethodPost, path + "/approve", tenant.Tenant{OrgID: "acme", Subject: "dev"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := call(tt.method, tt.path, tt.tenant, ""); rec.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+		})
+	}
+	if got := store.approvals[a.ID]; got.Status != clickhouse.ApprovalPending {
+		t.Fatalf("denied decisions changed the approval to %s", got.Status)
+	}
+
+	rec := call(http.MethodPost, path+"/approve", lead, `{"comment":"budget raised"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("approve status = %d: %s", rec.Code, rec.Body)
+	}
+	var decided clickhouse.Approval
+	if err := json.NewDecoder(rec.Body).Decode(&decided); err != nil {
+		t.Fatal(err)
+	}
+	if decided.Status != clickhouse.ApprovalApproved || decided.DecidedBy != "lead" || decided.Comment != "budget raised" {
+		t.Errorf("approval = %+v", decided)
+	}
+
+	// A decided approval can't be decided again
+	rec = call(http.MethodPost, path+"/reject", tenant.Tenant{OrgID: "acme", Subject: "cfo", Approver: true}, "")
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "already approved") {
+		t.Errorf("reject after approve = %d: %s, want 409", rec.Code, rec.Body)
+	}
+
+	s.approvalStore = nil
+	if rec := call(http.MethodGet, path, lead, ""); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status without store = %d, want 503", rec.Code)
+	}
+}
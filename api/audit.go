@@ -0,0 +1,115 @@
+// Package api - Audit log endpoint
+// Signed records of each estimate and its policy decision, verified on read
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"terraform-cost/decision/audit"
+	"terraform-cost/decision/estimation"
+	"terraform-cost/decision/iac"
+	"terraform-cost/decision/policy"
+	"terraform-cost/tenant"
+)
+
+// AuditResponse is a stored audit record and the outcome of verifying it
+type AuditResponse struct {
+	Record            *audit.Entry `json:"record"`
+	Algorithm         string       `json:"algorithm"`
+	KeyID             string       `json:"key_id"`
+	Signature         string       `json:"signature"`
+	Verified          bool         `json:"verified"`
+	VerificationError string       `json:"verification_error,omitempty"`
+}
+
+// recordAudit signs and saves the audit record of an estimate
+// Requests streamed without a raw plan are identified by the parsed plan instead.
+func (s *Server) recordAudit(ctx context.Context, req EstimateRequest, plan *iac.ParsedPlan, result *estimation.EstimationResult,
+	decision *policy.EvaluationResult, policySetHash string, resourceCount int, estimationID string) (uuid.UUID, error) {
+	if s.pricingStore == nil {
+		return uuid.Nil, fmt.Errorf("audit log needs the ClickHouse store")
+	}
+	var hashed interface{} = req
+	if len(req.Plan) == 0 {
+		hashed = struct {
+			Request EstimateRequest `json:"request"`
+			Plan    *iac.ParsedPlan `json:"plan"`
+		}{req, plan}
+	}
+	requestHash, err := audit.Hash(hashed)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	entry := audit.NewEntry(result, decision, audit.Meta{
+		OrgID:         tenant.OrgID(ctx),
+		Project:       req.Project,
+		Environment:   req.Environment,
+		Source:        "api",
+		RequestHash:   requestHash,
+		PolicySetHash: policySetHash,
+		ResourceCount: resourceCount,
+		EstimationID:  estimationID,
+	})
+	record, err := audit.Seal(entry, result, s.config.AuditSigner)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if err := s.pricingStore.SaveAuditRecord(ctx, record); err != nil {
+		return uuid.Nil, err
+	}
+	return record.ID, nil
+}
+
+// handleAudit returns and verifies an audit record (GET /api/v1/audit/{id})
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.jsonError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if s.config.AuditSigner == nil {
+		s.jsonError(w, http.StatusNotImplemented, "audit log is not enabled on this server")
+		return
+	}
+	if s.pricingStore == nil {
+		s.jsonError(w, http.StatusServiceUnavailable, "audit log needs the ClickHouse store")
+		return
+	}
+	id, err := uuid.Parse(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/audit/"), "/"))
+	if err != nil {
+		s.jsonError(w, http.StatusBadRequest, "invalid audit record id")
+		return
+	}
+
+	ctx := r.Context()
+	record, err := s.pricingStore.GetAuditRecord(ctx, id)
+	if err != nil {
+		s.jsonError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get audit record: %v", err))
+		return
+	}
+	if record == nil {
+		s.jsonError(w, http.StatusNotFound, "audit record not found")
+		return
+	}
+	if err := authorizeProject(ctx, record.Project); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	resp := AuditResponse{
+		Algorithm: record.Algorithm,
+		KeyID:     record.KeyID,
+		Signature: record.Signature,
+	}
+	resp.Record, err = audit.Verify(record, s.config.AuditSigner)
+	resp.Verified = err == nil
+	if err != nil {
+		resp.VerificationError = err.Error()
+	}
+	s.jsonResponse(w, http.StatusOK, resp)
+}
@@ -0,0 +1,20 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"terraform-cost/decision/audit"
+)
+
+func TestHandleAuditWithoutStore(t *testing.T) {
+	s := &Server{config: &Config{AuditSigner: audit.NewHMACSigner([]byte("secret"))}}
+	rec := httptest.NewRecorder()
+	s.handleAudit(rec, httptest.NewRequest(http.MethodGet, "/api/v1/audit/"+uuid.NewString(), nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", rec.Code)
+	}
+}
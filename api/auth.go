@@ -0,0 +1,81 @@
+// Package api - Authentication and tenant scoping
+// With an auth verifier configured, every API request carries a bearer token
+// whose claims name the org and projects it may use; storage scopes by that org.
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"terraform-cost/decision/policy"
+	tcerrors "terraform-cost/pkg/errors"
+	"terraform-cost/pkg/terracost"
+	"terraform-cost/tenant"
+)
+
+// publicPaths are served without a token (probes, list prices)
+var publicPaths = map[string]bool{
+	"/health":                   true,
+	"/ready":                    true,
+	"/health/ready":             true,
+	"/api/v1/catalog/instances": true,
+}
+
+// authMiddleware verifies the bearer token and puts its tenant in the request context
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	if s.config.Auth == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if publicPaths[r.URL.Path] || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		token, ok := tenant.BearerToken(r.Header.Get("Authorization"))
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="terracost"`)
+			s.jsonError(w, http.StatusUnauthorized, "missing bearer token")
+			return
+		}
+		t, err := s.config.Auth.Verify(token)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="terracost", error="invalid_token"`)
+			s.jsonError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(tenant.NewContext(r.Context(), t)))
+	})
+}
+
+func forbidden(format string, args ...interface{}) error {
+	return tcerrors.New(tcerrors.CodeForbidden, format, args...)
+}
+
+// authorizeProject checks that the request's tenant may use a project; "" is the whole org
+func authorizeProject(ctx context.Context, project string) error {
+	t, ok := tenant.FromContext(ctx)
+	if !ok || t.AllowsProject(project) {
+		return nil
+	}
+	if project == "" {
+		return forbidden("token is limited to projects %v", t.Projects)
+	}
+	return forbidden("token does not grant access to project %q", project)
+}
+
+// policyEngineFor returns the policy engine of the request's org
+// Orgs with their own policies get them on top of the server-wide ones.
+func (s *Server) policyEngineFor(ctx context.Context) *policy.Engine {
+	if engine, ok := s.orgPolicyEngines[tenant.OrgID(ctx)]; ok {
+		return engine
+	}
+	return s.policyEngine
+}
+
+// estimatorFor returns the estimator evaluating the policies of the request's org
+func (s *Server) estimatorFor(ctx context.Context) *terracost.Estimator {
+	if estimator, ok := s.orgEstimators[tenant.OrgID(ctx)]; ok {
+		return estimator
+	}
+	return s.estimator
+}
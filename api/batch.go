@@ -0,0 +1,200 @@
+// Package api - Batch usage and pricing endpoints
+// Callers pricing their own components send them in one request instead of one
+// per component: usage prediction runs over the whole batch and rates resolve
+// in a single pricing query. Results are keyed by the caller's IDs.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/estimation"
+	"terraform-cost/decision/usage"
+)
+
+// MaxBatchItems bounds the components or lookups of one batch request
+const MaxBatchItems = 5000
+
+// PredictBatchRequest is a batch of billing components to predict usage for
+type PredictBatchRequest struct {
+	Environment string                     `json:"environment"`
+	Usage       *usage.File                `json:"usage,omitempty"`
+	Components  []billing.BillingComponent `json:"components"` // IDs must be unique
+}
+
+// PredictBatchResponse is the predicted usage of each component, by component ID
+type PredictBatchResponse struct {
+	Usage    map[string]billing.VarianceProfile `json:"usage"`
+	Warnings []string                           `json:"warnings,omitempty"`
+}
+
+// PriceBatchRequest is a batch of rates to resolve
+type PriceBatchRequest struct {
+	PricingDate string             `json:"pricing_date,omitempty"` // YYYY-MM-DD or RFC 3339; default is the active snapshots
+	Lookups     []PriceLookupInput `json:"lookups"`
+}
+
+// PriceLookupInput identifies one rate; ID keys its result
+type PriceLookupInput struct {
+	ID            string            `json:"id"`
+	Cloud         string            `json:"cloud"`
+	Service       string            `json:"service"`
+	ProductFamily string            `json:"product_family"`
+	Region        string            `json:"region"`
+	Attributes    map[string]string `json:"attributes"`
+	Unit          string            `json:"unit"`
+	Alias         string            `json:"alias,omitempty"` // Pricing snapshot alias, e.g. an org's negotiated rates
+}
+
+// PriceBatchResponse is the resolved rate of each lookup, by lookup ID
+type PriceBatchResponse struct {
+	Rates map[string]PriceResult `json:"rates"`
+}
+
+// PriceResult is a resolved rate; Found is false when the snapshot has no such rate
+type PriceResult struct {
+	Found      bool    `json:"found"`
+	Price      string  `json:"price,omitempty"`
+	Currency   string  `json:"currency,omitempty"`
+	Confidence float64 `json:"confidence,omitempty"`
+	SnapshotID string  `json:"snapshot_id,omitempty"`
+	Source     string  `json:"source,omitempty"`
+}
+
+// rateResolver resolves rates in one query; *clickhouse.Store is the production resolver
+type rateResolver interface {
+	ResolveRates(ctx context.Context, lookups []clickhouse.RateLookup) ([]*clickhouse.ResolvedRate, error)
+}
+
+// handlePredictBatch predicts usage for a batch of components
+// POST /api/v1/predict/batch
+func (s *Server) handlePredictBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.jsonError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxRequestSize)
+	var req PredictBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.jsonError(w, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+	if err := validateBatch(len(req.Components), func(i int) string { return req.Components[i].ID }); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	predictor := usage.NewPredictor(req.Environment)
+	for _, profile := range s.config.UsageProfiles {
+		if err := predictor.RegisterProfile(profile); err != nil {
+			s.writeError(w, internalError("invalid usage profile: %v", err))
+			return
+		}
+	}
+	if req.Usage != nil {
+		predictor.WithUsageFile(req.Usage)
+	}
+	components, warnings := predictor.Predict(req.Components)
+
+	resp := PredictBatchResponse{Usage: make(map[string]billing.VarianceProfile, len(components)), Warnings: warnings}
+	for _, c := range components {
+		resp.Usage[c.ID] = c.VarianceProfile
+	}
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
+// handlePriceBatch resolves a batch of rates in one pricing query
+// POST /api/v1/price/batch
+func (s *Server) handlePriceBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.jsonError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if s.rateResolver == nil {
+		s.jsonError(w, http.StatusServiceUnavailable, "rate resolution needs the ClickHouse store")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxRequestSize)
+	var req PriceBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.jsonError(w, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+	if err := validateBatch(len(req.Lookups), func(i int) string { return req.Lookups[i].ID }); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	pricingDate, err := estimation.ParsePricingDate(req.PricingDate)
+	if err != nil {
+		s.writeError(w, badRequest("%v", err))
+		return
+	}
+
+	lookups := make([]clickhouse.RateLookup, len(req.Lookups))
+	for i, l := range req.Lookups {
+		if l.Cloud == "" || l.Region == "" || l.Service == "" {
+			s.writeError(w, badRequest("lookup %s: cloud, region and service are required", l.ID))
+			return
+		}
+		lookups[i] = clickhouse.RateLookup{
+			Cloud:         clickhouse.CloudProvider(l.Cloud),
+			Service:       l.Service,
+			ProductFamily: l.ProductFamily,
+			Region:        l.Region,
+			Attributes:    l.Attributes,
+			Unit:          l.Unit,
+			Alias:         l.Alias,
+			At:            pricingDate,
+		}
+	}
+	rates, err := s.rateResolver.ResolveRates(r.Context(), lookups)
+	if err != nil {
+		s.writeError(w, internalError("failed to resolve rates: %v", err))
+		return
+	}
+
+	resp := PriceBatchResponse{Rates: make(map[string]PriceResult, len(rates))}
+	for i, rate := range rates {
+		result := PriceResult{}
+		if rate != nil {
+			result = PriceResult{
+				Found:      true,
+				Price:      rate.Price.String(),
+				Currency:   rate.Currency,
+				Confidence: rate.Confidence,
+				SnapshotID: rate.SnapshotID.String(),
+				Source:     rate.Source,
+			}
+		}
+		resp.Rates[req.Lookups[i].ID] = result
+	}
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
+// validateBatch checks a batch's size and that its item IDs are set and unique
+func validateBatch(n int, id func(int) string) error {
+	if n == 0 {
+		return badRequest("batch is empty")
+	}
+	if n > MaxBatchItems {
+		return badRequest("batch has %d items; the limit is %d", n, MaxBatchItems)
+	}
+	seen := make(map[string]bool, n)
+	for i := 0; i < n; i++ {
+		key := id(i)
+		if key == "" {
+			return badRequest("item %d has no id", i)
+		}
+		if seen[key] {
+			return badRequest("duplicate id %q", key)
+		}
+		seen[key] = true
+	}
+	return nil
+}
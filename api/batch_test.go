@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+)
+
+// fakeRateResolver prices EC2 lookups at 0.09 USD and finds nothing else
+type fakeRateResolver struct {
+	calls int
+}
+
+func (f *fakeRateResolver) ResolveRates(_ context.Context, lookups []clickhouse.RateLookup) ([]*clickhouse.ResolvedRate, error) {
+	f.calls++
+	rates := make([]*clickhouse.ResolvedRate, len(lookups))
+	for i, l := range lookups {
+		if l.Service == "AmazonEC2" {
+			rates[i] = &clickhouse.ResolvedRate{
+				Price: decimal.RequireFromString("0.09"), Currency: "USD", Confidence: 1, SnapshotID: uuid.New(),
+			}
+		}
+	}
+	return rates, nil
+}
+
+func postBatch(handler http.HandlerFunc, path, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+	return rec
+}
+
+// items renders n batch items from a template taking the item's ID
+func items(n int, item func(id string) string) string {
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = item(fmt.Sprintf("c%d", i))
+	}
+	return "[" + strings.Join(parts, ",") + "]"
+}
+
+func TestBatchValidation(t *testing.T) {
+	s := newTestServer(&Config{})
+	s.rateResolver = &fakeRateResolver{}
+	component := func(id string) string {
+		return fmt.Sprintf(`{"id":%q,"cloud":"aws","service":"AmazonEC2","billing_period":"hourly"}`, id)
+	}
+	lookup := func(id string) string {
+		return fmt.Sprintf(`{"id":%q,"cloud":"aws","service":"AmazonEC2","region":"us-east-1"}`, id)
+	}
+
+	tests := []struct {
+		name  string
+		items string
+		error string
+	}{
+		{"empty", "[]", "batch is empty"},
+		{"oversized", "", fmt.Sprintf("the limit is %d", MaxBatchItems)},
+		{"duplicate id", "", "duplicate id"},
+		{"missing id", "", "item 1 has no id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, endpoint := range []struct {
+				path    string
+				handler http.HandlerFunc
+				field   string
+				item    func(string) string
+			}{
+				{"/api/v1/predict/batch", s.handlePredictBatch, "components", component},
+				{"/api/v1/price/batch", s.handlePriceBatch, "lookups", lookup},
+			} {
+				batch := tt.items
+				switch tt.name {
+				case "oversized":
+					batch = items(MaxBatchItems+1, endpoint.item)
+				case "duplicate id":
+					batch = "[" + endpoint.item("c0") + "," + endpoint.item("c0") + "]"
+				case "missing id":
+					batch = "[" + endpoint.item("c0") + "," + endpoint.item("") + "]"
+				}
+				rec := postBatch(endpoint.handler, endpoint.path, fmt.Sprintf(`{%q:%s}`, endpoint.field, batch))
+				if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), tt.error) {
+					t.Errorf("%s: status = %d, body %s; want 400 with %q", endpoint.path, rec.Code, rec.Body, tt.error)
+				}
+			}
+		})
+	}
+}
+
+func TestPredictBatch(t *testing.T) {
+	s := newTestServer(&Config{})
+	body := `{"environment":"prod","components":[
+		{"id":"web","cloud":"aws","service":"AmazonEC2","billing_period":"hourly",
+		 "variance_profile":{"baseline_usage":730,"p50_usage":730,"p90_usage":730,"confidence":0.9}},
+		{"id":"data","cloud":"aws","service":"AmazonS3","billing_period":"monthly"}]}`
+	rec := postBatch(s.handlePredictBatch, "/api/v1/predict/batch", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	var resp PredictBatchResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Usage) != 2 {
+		t.Fatalf("usage = %+v, want web and data", resp.Usage)
+	}
+	if web, ok := resp.Usage["web"]; !ok || web.P50Usage <= 0 {
+		t.Errorf("web usage = %+v", web)
+	}
+}
+
+func TestPriceBatch(t *testing.T) {
+	s := newTestServer(&Config{})
+	resolver := &fakeRateResolver{}
+	s.rateResolver = resolver
+	body := `{"lookups":[
+		{"id":"web","cloud":"aws","service":"AmazonEC2","region":"us-east-1"},
+		{"id":"queue","cloud":"aws","service":"AmazonSQS","region":"us-east-1"}]}`
+	rec := postBatch(s.handlePriceBatch, "/api/v1/price/batch", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	var resp PriceBatchResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if web := resp.Rates["web"]; !web.Found || web.Price != "0.09" || web.Currency != "USD" {
+		t.Errorf("web = %+v", web)
+	}
+	if queue, ok := resp.Rates["queue"]; !ok || queue.Found {
+		t.Errorf("queue = %+v, %v; want a result that was not found", queue, ok)
+	}
+	if resolver.calls != 1 {
+		t.Errorf("pricing queries = %d, want 1", resolver.calls)
+	}
+
+	// Lookups without a cloud, region or service are rejected
+	rec = postBatch(s.handlePriceBatch, "/api/v1/price/batch", `{"lookups":[{"id":"web","cloud":"aws"}]}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("incomplete lookup status = %d, want 400", rec.Code)
+	}
+
+	s.rateResolver = nil
+	if rec := postBatch(s.handlePriceBatch, "/api/v1/price/batch", body); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status without store = %d, want 503", rec.Code)
+	}
+}
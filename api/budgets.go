@@ -0,0 +1,180 @@
+// Package api - Budget endpoints
+// CRUD for stored budgets and their remaining budget from estimation history
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/budget"
+)
+
+// BudgetRequest creates or replaces a budget
+type BudgetRequest struct {
+	Name           string          `json:"name"`
+	Project        string          `json:"project,omitempty"`
+	Environment    string          `json:"environment,omitempty"`
+	Branch         string          `json:"branch,omitempty"`
+	TagKey         string          `json:"tag_key,omitempty"`
+	TagValue       string          `json:"tag_value,omitempty"`
+	MonthlyLimit   decimal.Decimal `json:"monthly_limit"`
+	Currency       string          `json:"currency,omitempty"`
+	AlertThreshold float64         `json:"alert_threshold,omitempty"`
+}
+
+// apply copies the request onto a budget
+func (req BudgetRequest) apply(b *clickhouse.Budget) {
+	b.Name = req.Name
+	b.Project = req.Project
+	b.Environment = req.Environment
+	b.Branch = req.Branch
+	b.TagKey = req.TagKey
+	b.TagValue = req.TagValue
+	b.MonthlyLimit = req.MonthlyLimit
+	b.Currency = req.Currency
+	b.AlertThreshold = req.AlertThreshold
+}
+
+// handleBudgets lists (GET) and creates (POST) budgets
+func (s *Server) handleBudgets(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		budgets, err := s.pricingStore.ListBudgets(r.Context(), clickhouse.BudgetFilter{
+			Project:     q.Get("project"),
+			Environment: q.Get("environment"),
+		})
+		if err != nil {
+			s.jsonError(w, http.StatusInternalServerError, fmt.Sprintf("failed to list budgets: %v", err))
+			return
+		}
+		// Project-scoped tokens only see their projects' budgets
+		visible := budgets[:0]
+		for _, b := range budgets {
+			if authorizeProject(r.Context(), b.Project) == nil {
+				visible = append(visible, b)
+			}
+		}
+		s.jsonResponse(w, http.StatusOK, visible)
+
+	case http.MethodPost:
+		req, ok := s.decodeBudgetRequest(w, r)
+		if !ok {
+			return
+		}
+		var b clickhouse.Budget
+		req.apply(&b)
+		if err := b.Validate(); err != nil {
+			s.jsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := authorizeProject(r.Context(), b.Project); err != nil {
+			s.writeError(w, err)
+			return
+		}
+		if err := s.pricingStore.CreateBudget(r.Context(), &b); err != nil {
+			s.jsonError(w, http.StatusInternalServerError, fmt.Sprintf("failed to create budget: %v", err))
+			return
+		}
+		s.jsonResponse(w, http.StatusCreated, b)
+
+	default:
+		s.jsonError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handleBudget serves /api/v1/budgets/{id} (GET, PUT, DELETE) and /api/v1/budgets/{id}/status
+func (s *Server) handleBudget(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/budgets/"), "/")
+	idPart, action, _ := strings.Cut(path, "/")
+	id, err := uuid.Parse(idPart)
+	if err != nil {
+		s.jsonError(w, http.StatusBadRequest, "invalid budget id")
+		return
+	}
+	if action != "" && action != "status" {
+		s.jsonError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	ctx := r.Context()
+	b, err := s.pricingStore.GetBudget(ctx, id)
+	if err != nil {
+		s.jsonError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get budget: %v", err))
+		return
+	}
+	if b == nil {
+		s.jsonError(w, http.StatusNotFound, "budget not found")
+		return
+	}
+	if err := authorizeProject(ctx, b.Project); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	if action == "status" {
+		if r.Method != http.MethodGet {
+			s.jsonError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		status, err := budget.NewTracker(s.pricingStore).Status(ctx, b)
+		if err != nil {
+			s.jsonError(w, http.StatusInternalServerError, fmt.Sprintf("failed to compute budget status: %v", err))
+			return
+		}
+		s.jsonResponse(w, http.StatusOK, status)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.jsonResponse(w, http.StatusOK, b)
+
+	case http.MethodPut:
+		req, ok := s.decodeBudgetRequest(w, r)
+		if !ok {
+			return
+		}
+		req.apply(b)
+		if err := b.Validate(); err != nil {
+			s.jsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := authorizeProject(ctx, b.Project); err != nil {
+			s.writeError(w, err)
+			return
+		}
+		if err := s.pricingStore.UpdateBudget(ctx, b); err != nil {
+			s.jsonError(w, http.StatusInternalServerError, fmt.Sprintf("failed to update budget: %v", err))
+			return
+		}
+		s.jsonResponse(w, http.StatusOK, b)
+
+	case http.MethodDelete:
+		if err := s.pricingStore.DeleteBudget(ctx, b); err != nil {
+			s.jsonError(w, http.StatusInternalServerError, fmt.Sprintf("failed to delete budget: %v", err))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		s.jsonError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// decodeBudgetRequest reads a budget request body, writing the error response on failure
+func (s *Server) decodeBudgetRequest(w http.ResponseWriter, r *http.Request) (BudgetRequest, bool) {
+	var req BudgetRequest
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxRequestSize)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.jsonError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return req, false
+	}
+	return req, true
+}
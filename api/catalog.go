@@ -0,0 +1,63 @@
+// Package api - Instance catalog endpoint
+// List prices of a region's instance types for comparison in developer portals;
+// served without a token, from the shared pricing catalog
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"terraform-cost/decision/carbon"
+	"terraform-cost/decision/catalog"
+)
+
+// handleCatalogInstances lists the instance types of a region with their price and carbon per hour
+// GET /api/v1/catalog/instances?cloud=aws&region=us-east-1&family=m5&os=Linux&sort=price
+func (s *Server) handleCatalogInstances(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.jsonError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	q := r.URL.Query()
+	if cloud := q.Get("cloud"); cloud != "" && !strings.EqualFold(cloud, "aws") {
+		s.jsonError(w, http.StatusBadRequest, fmt.Sprintf("the instance catalog supports aws, not %s", cloud))
+		return
+	}
+	query := catalog.Query{
+		Region:          q.Get("region"),
+		Family:          q.Get("family"),
+		OperatingSystem: q.Get("os"),
+		SortBy:          q.Get("sort"),
+	}
+	if query.Region == "" {
+		s.jsonError(w, http.StatusBadRequest, "region is required")
+		return
+	}
+	if query.SortBy != "" {
+		valid := false
+		for _, key := range catalog.SortKeys {
+			valid = valid || query.SortBy == key
+		}
+		if !valid {
+			s.jsonError(w, http.StatusBadRequest, fmt.Sprintf("sort must be one of %s", strings.Join(catalog.SortKeys, ", ")))
+			return
+		}
+	}
+
+	carbonStore := s.config.CarbonStore
+	if carbonStore == nil {
+		carbonStore = carbon.NewStaticCarbonStore()
+	}
+	listing, err := catalog.New(s.pricingStore).WithCarbonStore(carbonStore).Instances(r.Context(), query)
+	if err != nil {
+		s.jsonError(w, http.StatusInternalServerError, fmt.Sprintf("failed to list instances: %v", err))
+		return
+	}
+	if listing == nil {
+		s.jsonError(w, http.StatusNotFound, fmt.Sprintf("no active pricing snapshot for aws %s", query.Region))
+		return
+	}
+	s.jsonResponse(w, http.StatusOK, listing)
+}
@@ -0,0 +1,141 @@
+// Package api - Discount endpoints
+// CRUD for an org's negotiated discounts, applied to estimates when the
+// server runs with --discounts database
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"terraform-cost/db/clickhouse"
+)
+
+// DiscountRequest creates or replaces a discount
+type DiscountRequest struct {
+	Name          string  `json:"name"`
+	Cloud         string  `json:"cloud,omitempty"`
+	Service       string  `json:"service,omitempty"`
+	ProductFamily string  `json:"product_family,omitempty"`
+	Region        string  `json:"region,omitempty"`
+	Percent       float64 `json:"percent"`
+}
+
+// apply copies the request onto a discount
+func (req DiscountRequest) apply(d *clickhouse.Discount) {
+	d.Name = req.Name
+	d.Cloud = req.Cloud
+	d.Service = req.Service
+	d.ProductFamily = req.ProductFamily
+	d.Region = req.Region
+	d.Percent = req.Percent
+}
+
+// handleDiscounts lists (GET) and creates (POST) discounts
+// Discounts apply org-wide, so changing them needs a token for every project.
+func (s *Server) handleDiscounts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		discounts, err := s.pricingStore.ListDiscounts(r.Context())
+		if err != nil {
+			s.jsonError(w, http.StatusInternalServerError, fmt.Sprintf("failed to list discounts: %v", err))
+			return
+		}
+		s.jsonResponse(w, http.StatusOK, discounts)
+
+	case http.MethodPost:
+		if err := authorizeProject(r.Context(), ""); err != nil {
+			s.writeError(w, err)
+			return
+		}
+		req, ok := s.decodeDiscountRequest(w, r)
+		if !ok {
+			return
+		}
+		var d clickhouse.Discount
+		req.apply(&d)
+		if err := d.Validate(); err != nil {
+			s.jsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := s.pricingStore.CreateDiscount(r.Context(), &d); err != nil {
+			s.jsonError(w, http.StatusInternalServerError, fmt.Sprintf("failed to create discount: %v", err))
+			return
+		}
+		s.jsonResponse(w, http.StatusCreated, d)
+
+	default:
+		s.jsonError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handleDiscount serves /api/v1/discounts/{id} (GET, PUT, DELETE)
+func (s *Server) handleDiscount(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/discounts/"), "/"))
+	if err != nil {
+		s.jsonError(w, http.StatusBadRequest, "invalid discount id")
+		return
+	}
+
+	ctx := r.Context()
+	d, err := s.pricingStore.GetDiscount(ctx, id)
+	if err != nil {
+		s.jsonError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get discount: %v", err))
+		return
+	}
+	if d == nil {
+		s.jsonError(w, http.StatusNotFound, "discount not found")
+		return
+	}
+	if r.Method != http.MethodGet {
+		if err := authorizeProject(ctx, ""); err != nil {
+			s.writeError(w, err)
+			return
+		}
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.jsonResponse(w, http.StatusOK, d)
+
+	case http.MethodPut:
+		req, ok := s.decodeDiscountRequest(w, r)
+		if !ok {
+			return
+		}
+		req.apply(d)
+		if err := d.Validate(); err != nil {
+			s.jsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := s.pricingStore.UpdateDiscount(ctx, d); err != nil {
+			s.jsonError(w, http.StatusInternalServerError, fmt.Sprintf("failed to update discount: %v", err))
+			return
+		}
+		s.jsonResponse(w, http.StatusOK, d)
+
+	case http.MethodDelete:
+		if err := s.pricingStore.DeleteDiscount(ctx, d); err != nil {
+			s.jsonError(w, http.StatusInternalServerError, fmt.Sprintf("failed to delete discount: %v", err))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		s.jsonError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// decodeDiscountRequest reads a discount request body, writing the error response on failure
+func (s *Server) decodeDiscountRequest(w http.ResponseWriter, r *http.Request) (DiscountRequest, bool) {
+	var req DiscountRequest
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxRequestSize)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.jsonError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return req, false
+	}
+	return req, true
+}
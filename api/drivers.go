@@ -0,0 +1,99 @@
+// Package api - Cost driver breakdown endpoint
+// Pages through every cost driver of a saved estimate, filtered and sorted
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/estimation"
+)
+
+// DriversResponse is one page of a saved estimate's cost drivers
+type DriversResponse struct {
+	EstimationID string               `json:"estimation_id"`
+	Currency     string               `json:"currency"`
+	Total        int                  `json:"total"` // Drivers matching the filter
+	Offset       int                  `json:"offset"`
+	Limit        int                  `json:"limit"`
+	Drivers      []CostDriverResponse `json:"drivers"`
+}
+
+// handleEstimateDrivers lists a saved estimate's cost drivers
+// (GET /api/v1/estimates/{id}/drivers?sort=&offset=&limit=&service=&region=&tag=)
+func (s *Server) handleEstimateDrivers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.jsonError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	rawID, ok := strings.CutSuffix(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/estimates/"), "/"), "/drivers")
+	if !ok {
+		s.jsonError(w, http.StatusNotFound, "not found")
+		return
+	}
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		s.jsonError(w, http.StatusBadRequest, "invalid estimation id")
+		return
+	}
+
+	q := r.URL.Query()
+	by, err := estimation.ParseDriverSort(q.Get("sort"))
+	if err != nil {
+		s.writeError(w, badRequest("%v", err))
+		return
+	}
+	offset, limit, err := parsePage(q)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	limit = clickhouse.EstimationLimit(limit)
+
+	ctx := r.Context()
+	record, err := s.pricingStore.GetEstimation(ctx, id)
+	if err != nil {
+		s.jsonError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get estimate: %v", err))
+		return
+	}
+	if record == nil {
+		s.jsonError(w, http.StatusNotFound, "estimate not found")
+		return
+	}
+	if err := authorizeProject(ctx, record.Project); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	result, err := estimation.ResultFromRecord(record)
+	if err != nil {
+		s.writeError(w, internalError("%v", err))
+		return
+	}
+
+	drivers := estimation.QueryDrivers(result.CostDrivers, estimation.DriverFilter{
+		Service: q.Get("service"),
+		Region:  q.Get("region"),
+		Tag:     q.Get("tag"),
+	}, by)
+	resp := DriversResponse{
+		EstimationID: id.String(),
+		Currency:     result.Currency,
+		Total:        len(drivers),
+		Offset:       offset,
+		Limit:        limit,
+		Drivers:      []CostDriverResponse{},
+	}
+	page := drivers[min(offset, len(drivers)):]
+	if len(page) > limit {
+		page = page[:limit]
+		setNextOffset(w, offset+limit)
+	}
+	for _, d := range page {
+		resp.Drivers = append(resp.Drivers, costDriverResponse(d))
+	}
+	s.jsonResponse(w, http.StatusOK, resp)
+}
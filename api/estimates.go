@@ -0,0 +1,124 @@
+// Package api - Saved estimates
+// Estimate requests with persist set are kept by ID, so PR comments can link
+// to a stable record instead of embedding the full response. Records expire
+// after the server's retention or when deleted.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"terraform-cost/db/clickhouse"
+)
+
+// DefaultEstimateRetention is how long persisted estimates are kept
+const DefaultEstimateRetention = 90 * 24 * time.Hour
+
+// SavedEstimateResponse is the API view of a persisted estimate
+type SavedEstimateResponse struct {
+	*clickhouse.SavedEstimate
+	Estimate *EstimateResponse `json:"estimate"`
+}
+
+// estimateStore keeps persisted estimates; *clickhouse.Store is the production store
+type estimateStore interface {
+	SaveEstimate(ctx context.Context, e *clickhouse.SavedEstimate) error
+	GetEstimate(ctx context.Context, id uuid.UUID) (*clickhouse.SavedEstimate, error)
+	DeleteEstimate(ctx context.Context, e *clickhouse.SavedEstimate) error
+}
+
+// persistEstimate saves a response under its history estimation ID, or a new
+// ID when it was not saved to history, and sets its estimate_id
+func (s *Server) persistEstimate(ctx context.Context, req EstimateRequest, resp *EstimateResponse) error {
+	if s.estimateStore == nil {
+		return fmt.Errorf("persisted estimates need the ClickHouse store")
+	}
+	id := uuid.New()
+	if resp.EstimationID != "" {
+		if historyID, err := uuid.Parse(resp.EstimationID); err == nil {
+			id = historyID
+		}
+	}
+	resp.EstimateID = id.String()
+	data, err := json.Marshal(resp)
+	if err != nil {
+		resp.EstimateID = ""
+		return err
+	}
+
+	now := time.Now().UTC()
+	saved := &clickhouse.SavedEstimate{
+		ID:           id,
+		Project:      req.Project,
+		Branch:       req.Branch,
+		CommitSHA:    req.CommitSHA,
+		PullRequest:  req.PullRequest,
+		Environment:  req.Environment,
+		ResponseJSON: string(data),
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(s.config.EstimateRetention),
+	}
+	if err := s.estimateStore.SaveEstimate(ctx, saved); err != nil {
+		resp.EstimateID = ""
+		return err
+	}
+	return nil
+}
+
+// handleEstimateRecord routes /api/v1/estimates/{id} and /api/v1/estimates/{id}/drivers
+func (s *Server) handleEstimateRecord(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(strings.TrimSuffix(r.URL.Path, "/"), "/drivers") {
+		s.handleEstimateDrivers(w, r)
+		return
+	}
+	if s.estimateStore == nil {
+		s.jsonError(w, http.StatusServiceUnavailable, "persisted estimates need the ClickHouse store")
+		return
+	}
+	id, err := uuid.Parse(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/estimates/"), "/"))
+	if err != nil {
+		s.jsonError(w, http.StatusBadRequest, "invalid estimate id")
+		return
+	}
+
+	ctx := r.Context()
+	saved, err := s.estimateStore.GetEstimate(ctx, id)
+	if err != nil {
+		s.jsonError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get estimate: %v", err))
+		return
+	}
+	if saved == nil {
+		s.jsonError(w, http.StatusNotFound, "estimate not found or expired")
+		return
+	}
+	if err := authorizeProject(ctx, saved.Project); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		resp := SavedEstimateResponse{SavedEstimate: saved, Estimate: &EstimateResponse{}}
+		if err := json.Unmarshal([]byte(saved.ResponseJSON), resp.Estimate); err != nil {
+			s.jsonError(w, http.StatusInternalServerError, fmt.Sprintf("invalid saved estimate: %v", err))
+			return
+		}
+		s.jsonResponse(w, http.StatusOK, resp)
+
+	case http.MethodDelete:
+		if err := s.estimateStore.DeleteEstimate(ctx, saved); err != nil {
+			s.jsonError(w, http.StatusInternalServerError, fmt.Sprintf("failed to delete estimate: %v", err))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		s.jsonError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/tenant"
+)
+
+// fakeEstimateStore keeps estimates in memory and, like ClickHouse, hides
+// deleted and expired ones
+type fakeEstimateStore struct {
+	estimates map[uuid.UUID]clickhouse.SavedEstimate
+	deleted   map[uuid.UUID]bool
+}
+
+func newFakeEstimateStore() *fakeEstimateStore {
+	return &fakeEstimateStore{estimates: make(map[uuid.UUID]clickhouse.SavedEstimate), deleted: make(map[uuid.UUID]bool)}
+}
+
+func (f *fakeEstimateStore) SaveEstimate(_ context.Context, e *clickhouse.SavedEstimate) error {
+	f.estimates[e.ID] = *e
+	return nil
+}
+
+func (f *fakeEstimateStore) GetEstimate(_ context.Context, id uuid.UUID) (*clickhouse.SavedEstimate, error) {
+	e, ok := f.estimates[id]
+	if !ok || f.deleted[id] || !e.ExpiresAt.After(time.Now()) {
+		return nil, nil
+	}
+	return &e, nil
+}
+
+func (f *fakeEstimateStore) DeleteEstimate(_ context.Context, e *clickhouse.SavedEstimate) error {
+	f.deleted[e.ID] = true
+	return nil
+}
+
+func newEstimateTestServer() (*Server, *fakeEstimateStore) {
+	store := newFakeEstimateStore()
+	s := newTestServer(&Config{EstimateRetention: 48 * time.Hour})
+	s.estimateStore = store
+	return s, store
+}
+
+// estimateRecord calls the saved estimate endpoint as a tenant
+func estimateRecord(s *Server, method, id string, t tenant.Tenant) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/api/v1/estimates/"+id, nil)
+	if t.OrgID != "" {
+		r = r.WithContext(tenant.NewContext(r.Context(), t))
+	}
+	rec := httptest.NewRecorder()
+	s.handleEstimateRecord(rec, r)
+	return rec
+}
+
+func TestPersistEstimate(t *testing.T) {
+	s, store := newEstimateTestServer()
+	resp, err := s.Estimate(context.Background(), EstimateRequest{
+		Plan: []byte(testPlan), Environment: "prod", Project: "web", Branch: "main", Persist: true,
+	})
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	id, err := uuid.Parse(resp.EstimateID)
+	if err != nil {
+		t.Fatalf("estimate_id = %q: %v (warnings %v)", resp.EstimateID, err, resp.EstimationWarnings)
+	}
+
+	saved := store.estimates[id]
+	if saved.Project != "web" || saved.Branch != "main" || saved.Environment != "prod" {
+		t.Errorf("saved = %+v", saved)
+	}
+	if got := saved.ExpiresAt.Sub(saved.CreatedAt); got != 48*time.Hour {
+		t.Errorf("retention = %s, want 48h", got)
+	}
+
+	// History estimation IDs are reused, so both links name the same estimate
+	resp = &EstimateResponse{EstimationID: uuid.NewString()}
+	if err := s.persistEstimate(context.Background(), EstimateRequest{}, resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.EstimateID != resp.EstimationID {
+		t.Errorf("estimate_id = %s, want estimation_id %s", resp.EstimateID, resp.EstimationID)
+	}
+}
+
+func TestEstimateRecord(t *testing.T) {
+	s, store := newEstimateTestServer()
+	resp := &EstimateResponse{MonthlyCostP50: "12.50"}
+	if err := s.persistEstimate(context.Background(), EstimateRequest{Project: "web"}, resp); err != nil {
+		t.Fatal(err)
+	}
+	id := resp.EstimateID
+	webToken := tenant.Tenant{OrgID: "acme", Projects: []string{"web"}}
+	billingToken := tenant.Tenant{OrgID: "acme", Projects: []string{"billing"}}
+
+	rec := estimateRecord(s, http.MethodGet, id, webToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET status = %d: %s", rec.Code, rec.Body)
+	}
+	var got SavedEstimateResponse
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.ID.String() != id || got.Project != "web" || got.Estimate.MonthlyCostP50 != "12.50" || got.Estimate.EstimateID != id {
+		t.Errorf("GET = %+v, estimate %+v", got.SavedEstimate, got.Estimate)
+	}
+
+	// A token for another project can neither read nor delete the estimate
+	if rec := estimateRecord(s, http.MethodGet, id, billingToken); rec.Code != http.StatusForbidden {
+		t.Errorf("other project GET status = %d, want 403", rec.Code)
+	}
+	if rec := estimateRecord(s, http.MethodDelete, id, billingToken); rec.Code != http.StatusForbidden {
+		t.Errorf("other project DELETE status = %d, want 403", rec.Code)
+	}
+
+	if rec := estimateRecord(s, http.MethodDelete, id, webToken); rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE status = %d: %s", rec.Code, rec.Body)
+	}
+	if rec := estimateRecord(s, http.MethodGet, id, webToken); rec.Code != http.StatusNotFound {
+		t.Errorf("GET after DELETE status = %d, want 404", rec.Code)
+	}
+
+	// Estimates past their retention are gone
+	expired := store.estimates[uuid.MustParse(id)]
+	expired.ID, expired.ExpiresAt = uuid.New(), time.Now().Add(-time.Minute)
+	store.estimates[expired.ID] = expired
+	if rec := estimateRecord(s, http.MethodGet, expired.ID.String(), tenant.Tenant{}); rec.Code != http.StatusNotFound {
+		t.Errorf("expired GET status = %d, want 404", rec.Code)
+	}
+
+	if rec := estimateRecord(s, http.MethodGet, "not-a-uuid", tenant.Tenant{}); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid id status = %d, want 400", rec.Code)
+	}
+}
+
+func TestEstimateRecordWithoutStore(t *testing.T) {
+	s := newTestServer(&Config{})
+	if rec := estimateRecord(s, http.MethodGet, uuid.NewString(), tenant.Tenant{}); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", rec.Code)
+	}
+	resp := &EstimateResponse{}
+	if err := s.persistEstimate(context.Background(), EstimateRequest{}, resp); err == nil || resp.EstimateID != "" {
+		t.Errorf("persist without store = %v, estimate_id %q", err, resp.EstimateID)
+	}
+}
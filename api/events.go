@@ -0,0 +1,64 @@
+// Package api - Estimate lifecycle events
+// With event publishers configured, every estimate emits estimate.requested
+// before pricing, then estimate.completed and policy.evaluated once its result
+// is saved. Events are queued and published in the background; see
+// integrations/events for the schema.
+package api
+
+import (
+	"context"
+	"time"
+
+	"terraform-cost/decision/currency"
+	"terraform-cost/integrations/events"
+	"terraform-cost/tenant"
+)
+
+// emitEvents queues lifecycle events of a request's estimate
+func (s *Server) emitEvents(ctx context.Context, req EstimateRequest, evs ...events.Event) {
+	if s.events == nil {
+		return
+	}
+	t, _ := tenant.FromContext(ctx)
+	for i := range evs {
+		evs[i].OrgID = t.OrgID
+		evs[i].Project = req.Project
+		evs[i].Branch = req.Branch
+		evs[i].CommitSHA = req.CommitSHA
+		evs[i].PullRequest = req.PullRequest
+		evs[i].Environment = req.Environment
+	}
+	s.events.Emit(evs...)
+}
+
+// requestedEvent is the estimate.requested event of a request
+func requestedEvent(requestID string, req EstimateRequest, resourceCount int) events.Event {
+	return events.New(events.EstimateRequested, requestID, events.RequestedData{
+		ResourceCount: resourceCount,
+		Currency:      currency.Normalize(req.Currency),
+		PricingDate:   req.PricingDate,
+	})
+}
+
+// resultEvents are the estimate.completed and policy.evaluated events of a response
+func resultEvents(requestID string, resp *EstimateResponse, started time.Time) []events.Event {
+	return []events.Event{
+		events.New(events.EstimateCompleted, requestID, events.CompletedData{
+			EstimationID:   resp.EstimationID,
+			Currency:       resp.Currency,
+			MonthlyCostP50: resp.MonthlyCostP50,
+			MonthlyCostP90: resp.MonthlyCostP90,
+			CarbonKgCO2:    resp.CarbonKgCO2,
+			Confidence:     resp.Confidence,
+			IsIncomplete:   resp.IsIncomplete,
+			ResourceCount:  resp.ResourceCount,
+			DurationMS:     time.Since(started).Milliseconds(),
+		}),
+		events.New(events.PolicyEvaluated, requestID, events.PolicyData{
+			EstimationID: resp.EstimationID,
+			Decision:     resp.PolicyResult,
+			Violations:   resp.Violations,
+			Warnings:     resp.Warnings,
+		}),
+	}
+}
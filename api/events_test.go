@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"terraform-cost/integrations/events"
+	"terraform-cost/telemetry"
+)
+
+// recordingPublisher keeps the events it is sent
+type recordingPublisher struct {
+	mu     sync.Mutex
+	events []events.Event
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, evs ...events.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, evs...)
+	return nil
+}
+
+func TestEstimateEmitsEvents(t *testing.T) {
+	for _, requestID := range []string{"req-123", ""} {
+		publisher := &recordingPublisher{}
+		s := newTestServer(&Config{Events: []events.Publisher{publisher}})
+
+		ctx := context.Background()
+		if requestID != "" {
+			ctx = telemetry.WithRequestID(ctx, requestID)
+		}
+		if _, err := s.Estimate(ctx, EstimateRequest{Plan: []byte(testPlan), Environment: "prod", Project: "web", Branch: "main"}); err != nil {
+			t.Fatalf("Estimate: %v", err)
+		}
+		s.events.Close(context.Background())
+
+		want := []string{events.EstimateRequested, events.EstimateCompleted, events.PolicyEvaluated}
+		if len(publisher.events) != len(want) {
+			t.Fatalf("events = %+v, want %v", publisher.events, want)
+		}
+		// Without a request ID the events still share a generated one
+		correlation := publisher.events[0].RequestID
+		if correlation == "" || (requestID != "" && correlation != requestID) {
+			t.Errorf("request_id = %q, want %q", correlation, requestID)
+		}
+		for i, e := range publisher.events {
+			if e.Type != want[i] {
+				t.Errorf("event %d = %s, want %s", i, e.Type, want[i])
+			}
+			if e.RequestID != correlation || e.Project != "web" || e.Branch != "main" || e.Environment != "prod" {
+				t.Errorf("%s event = %+v", e.Type, e)
+			}
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package grpc
+
+import (
+	"sort"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"terraform-cost/api"
+	"terraform-cost/api/grpc/terracostpb"
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/iac"
+	"terraform-cost/decision/usage"
+)
+
+// =============================================================================
+// REQUESTS
+// =============================================================================
+
+// estimateRequest converts estimate options to the REST request; the plan is left empty
+func estimateRequest(opts *terracostpb.EstimateOptions) (api.EstimateRequest, error) {
+	req := api.EstimateRequest{
+		PlanFormat:      opts.GetPlanFormat(),
+		Environment:     opts.GetEnvironment(),
+		IncludeCarbon:   opts.GetIncludeCarbon(),
+		IncludeFormulas: opts.GetIncludeFormulas(),
+		CostLimit:       optionalFloat(opts.GetCostLimit().GetValue(), opts.GetCostLimit() != nil),
+		CarbonBudget:    optionalFloat(opts.GetCarbonBudget().GetValue(), opts.GetCarbonBudget() != nil),
+		CostGrowthLimit: optionalFloat(opts.GetCostGrowthLimit().GetValue(), opts.GetCostGrowthLimit() != nil),
+		BaselineBranch:  opts.GetBaselineBranch(),
+		AllocationTags:  opts.GetAllocationTags(),
+		PricingDate:     opts.GetPricingDate(),
+		Currency:        opts.GetCurrency(),
+		Notify:          opts.GetNotify(),
+		Simulations:     int(opts.GetSimulations()),
+		Project:         opts.GetProject(),
+		Branch:          opts.GetBranch(),
+		CommitSHA:       opts.GetCommitSha(),
+		PullRequest:     opts.GetPullRequest(),
+		Workspace:       opts.GetWorkspace(),
+	}
+	if v := opts.GetVariables(); v != nil {
+		req.Variables = v.AsMap()
+	}
+	if len(opts.GetUsageFile()) > 0 {
+		f, err := usage.ParseFile(opts.GetUsageFile())
+		if err != nil {
+			return req, status.Errorf(codes.InvalidArgument, "invalid usage file: %v", err)
+		}
+		req.Usage = f
+	}
+	return req, nil
+}
+
+func optionalFloat(v float64, set bool) *float64 {
+	if !set {
+		return nil
+	}
+	return &v
+}
+
+// =============================================================================
+// RESPONSES
+// =============================================================================
+
+// estimateResponse converts the REST estimate response
+func estimateResponse(resp *api.EstimateResponse) *terracostpb.EstimateResponse {
+	out := &terracostpb.EstimateResponse{
+		Currency:       resp.Currency,
+		MonthlyCostP50: resp.MonthlyCostP50,
+		MonthlyCostP90: resp.MonthlyCostP90,
+		HourlyCostP50:  resp.HourlyCostP50,
+		CarbonKgCo2:    resp.CarbonKgCO2,
+		Confidence:     resp.Confidence,
+		ConfidenceScores: &terracostpb.ConfidenceScores{
+			Pricing:  resp.ConfidenceScores.Pricing,
+			Usage:    resp.ConfidenceScores.Usage,
+			Coverage: resp.ConfidenceScores.Coverage,
+		},
+		IsIncomplete:        resp.IsIncomplete,
+		EstimationWarnings:  resp.EstimationWarnings,
+		ResourceCount:       int32(resp.ResourceCount),
+		ComponentsEstimated: int32(resp.ComponentsEstimated),
+		ComponentsSymbolic:  int32(resp.ComponentsSymbolic),
+		Policy:              &terracostpb.PolicyResult{Decision: resp.PolicyResult},
+		CostDrivers:         make([]*terracostpb.CostDriver, len(resp.CostDrivers)),
+		CostGroups:          make([]*terracostpb.CostGroup, len(resp.CostGroups)),
+		PotentialSavings:    resp.PotentialSavings,
+		Recommendations:     make([]*terracostpb.Recommendation, len(resp.Recommendations)),
+		EstimatedAt:         resp.EstimatedAt,
+		PricingDate:         resp.PricingDate,
+		SnapshotsUsed:       resp.SnapshotsUsed,
+		EstimationId:        resp.EstimationID,
+	}
+
+	for _, v := range resp.Violations {
+		out.Policy.Violations = append(out.Policy.Violations, &terracostpb.Violation{
+			PolicyId:   v.PolicyID,
+			PolicyName: v.PolicyName,
+			Message:    v.Message,
+			Severity:   v.Severity,
+		})
+	}
+	for _, w := range resp.Warnings {
+		out.Policy.Warnings = append(out.Policy.Warnings, &terracostpb.Warning{PolicyId: w.PolicyID, Message: w.Message})
+	}
+
+	for i, d := range resp.CostDrivers {
+		out.CostDrivers[i] = &terracostpb.CostDriver{
+			Id:             d.ID,
+			ResourceAddr:   d.ResourceAddr,
+			Service:        d.Service,
+			ProductFamily:  d.ProductFamily,
+			Region:         d.Region,
+			Description:    d.Description,
+			MonthlyCostP50: d.MonthlyCostP50,
+			MonthlyCostP90: d.MonthlyCostP90,
+			Formula:        d.Formula,
+			Confidence:     d.Confidence,
+			IsSymbolic:     d.IsSymbolic,
+			Reason:         d.Reason,
+		}
+	}
+	for i, g := range resp.CostGroups {
+		out.CostGroups[i] = &terracostpb.CostGroup{
+			Key:            g.Key,
+			ResourceAddr:   g.ResourceAddr,
+			Service:        g.Service,
+			Description:    g.Description,
+			Quantity:       int32(g.Quantity),
+			UnitCostP50:    g.UnitCostP50,
+			MonthlyCostP50: g.MonthlyCostP50,
+			MonthlyCostP90: g.MonthlyCostP90,
+			IsSymbolic:     g.IsSymbolic,
+			Instances:      g.Instances,
+		}
+	}
+
+	if len(resp.CostByTag) > 0 {
+		out.CostByTag = make(map[string]*terracostpb.TagCosts, len(resp.CostByTag))
+		for key, byValue := range resp.CostByTag {
+			out.CostByTag[key] = &terracostpb.TagCosts{Values: byValue}
+		}
+	}
+
+	if sim := resp.Simulation; sim != nil {
+		out.Simulation = &terracostpb.Simulation{
+			Iterations: int32(sim.Iterations),
+			Seed:       sim.Seed,
+			Mean:       sim.Mean.StringFixed(2),
+			P10:        sim.P10.StringFixed(2),
+			P50:        sim.P50.StringFixed(2),
+			P90:        sim.P90.StringFixed(2),
+			P99:        sim.P99.StringFixed(2),
+			Histogram:  make([]*terracostpb.HistogramBucket, len(sim.Histogram)),
+		}
+		for i, b := range sim.Histogram {
+			out.Simulation.Histogram[i] = &terracostpb.HistogramBucket{
+				Lower: b.Lower.StringFixed(2),
+				Upper: b.Upper.StringFixed(2),
+				Count: int32(b.Count),
+			}
+		}
+	}
+
+	for i, r := range resp.Recommendations {
+		out.Recommendations[i] = &terracostpb.Recommendation{
+			ResourceAddr:   r.ResourceAddr,
+			ResourceType:   r.ResourceType,
+			Kind:           r.Kind,
+			Title:          r.Title,
+			Detail:         r.Detail,
+			MonthlyCost:    r.MonthlyCost.StringFixed(2),
+			MonthlySavings: r.MonthlySavings.StringFixed(2),
+		}
+	}
+	return out
+}
+
+// graphResponse summarizes a plan's infrastructure graph, resources sorted by address
+func graphResponse(plan *iac.ParsedPlan, graph *iac.Graph) *terracostpb.ParseResponse {
+	out := &terracostpb.ParseResponse{
+		FormatVersion: plan.FormatVersion,
+		ResourceCount: int32(graph.ResourceCount),
+		Resources:     make([]*terracostpb.Resource, 0, len(graph.Nodes)),
+		ProviderStats: counts(graph.ProviderStats),
+		RegionStats:   counts(graph.RegionStats),
+		ChangeStats: &terracostpb.ChangeStats{
+			Creates:  int32(graph.ChangeStats.Creates),
+			Updates:  int32(graph.ChangeStats.Updates),
+			Deletes:  int32(graph.ChangeStats.Deletes),
+			Replaces: int32(graph.ChangeStats.Replaces),
+			NoOps:    int32(graph.ChangeStats.NoOps),
+			Total:    int32(graph.ChangeStats.Total),
+		},
+		Warnings: plan.Warnings,
+	}
+
+	for _, node := range graph.Nodes {
+		r := &terracostpb.Resource{
+			Address:      node.Resource.Address,
+			Type:         node.Resource.Type,
+			Name:         node.Resource.Name,
+			Provider:     node.Provider,
+			Region:       node.Region,
+			Dependencies: node.Dependencies,
+			Dependents:   node.Dependents,
+		}
+		if node.Change != nil {
+			r.Action = string(node.Change.Action)
+		}
+		out.Resources = append(out.Resources, r)
+	}
+	sort.Slice(out.Resources, func(i, j int) bool { return out.Resources[i].Address < out.Resources[j].Address })
+	return out
+}
+
+func counts(stats map[string]int) map[string]int32 {
+	out := make(map[string]int32, len(stats))
+	for k, v := range stats {
+		out[k] = int32(v)
+	}
+	return out
+}
+
+// resolvedPrice converts a resolved rate; nil is a price that was not found
+func resolvedPrice(rate *clickhouse.ResolvedRate) *terracostpb.ResolvedPrice {
+	if rate == nil {
+		return &terracostpb.ResolvedPrice{}
+	}
+	out := &terracostpb.ResolvedPrice{
+		Found:      true,
+		Price:      rate.Price.String(),
+		Currency:   rate.Currency,
+		Confidence: rate.Confidence,
+		SnapshotId: rate.SnapshotID.String(),
+		Source:     rate.Source,
+	}
+	if rate.TierMin != nil {
+		out.TierMin = rate.TierMin.String()
+	}
+	if rate.TierMax != nil {
+		out.TierMax = rate.TierMax.String()
+	}
+	return out
+}
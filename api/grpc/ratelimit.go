@@ -0,0 +1,43 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+
+	"terraform-cost/api/grpc/terracostpb"
+)
+
+// estimateMethods run an estimate, so they count against the daily estimate quota
+var estimateMethods = map[string]bool{
+	terracostpb.TerraCost_Estimate_FullMethodName:       true,
+	terracostpb.TerraCost_StreamEstimate_FullMethodName: true,
+	terracostpb.TerraCost_EvaluatePolicy_FullMethodName: true,
+}
+
+// limit applies the REST server's rate limit and estimate quota to a call
+func (s *Server) limit(ctx context.Context, method string) error {
+	var addr string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		addr = p.Addr.String()
+	}
+	if err := s.rest.Limit(ctx, addr, estimateMethods[method]); err != nil {
+		return toStatus(err)
+	}
+	return nil
+}
+
+func (s *Server) unaryLimit(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := s.limit(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) streamLimit(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.limit(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
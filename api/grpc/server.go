@@ -0,0 +1,339 @@
+// Package grpc - gRPC API
+// Serves the TerraCost service next to the REST API. Estimates run through the
+// same pipeline as /api/v1/estimate; the Stream* RPCs parse plans as their
+// chunks arrive instead of holding the whole plan in one message.
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"terraform-cost/api"
+	"terraform-cost/api/grpc/terracostpb"
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/estimation"
+	"terraform-cost/decision/iac"
+	tcerrors "terraform-cost/pkg/errors"
+	"terraform-cost/tenant"
+)
+
+// Server implements the TerraCost gRPC service
+type Server struct {
+	terracostpb.UnimplementedTerraCostServer
+
+	rest         *api.Server
+	pricingStore *clickhouse.Store
+	config       *Config
+	grpcServer   *grpc.Server
+}
+
+// Config holds gRPC server configuration
+type Config struct {
+	Port           int
+	MaxMessageSize int              // Largest unary message; streamed plans are not limited
+	Auth           *tenant.Verifier // Requires bearer tokens in the authorization metadata
+}
+
+// DefaultConfig returns default gRPC server configuration
+func DefaultConfig() *Config {
+	return &Config{
+		Port:           9090,
+		MaxMessageSize: 16 * 1024 * 1024, // 16MB
+	}
+}
+
+// NewServer creates a gRPC server running requests through the REST server's pipeline
+func NewServer(rest *api.Server, store *clickhouse.Store, config *Config) *Server {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	s := &Server{
+		rest:         rest,
+		pricingStore: store,
+		config:       config,
+	}
+	// The REST server's rate limits and quotas apply after auth, keyed by tenant
+	unary := []grpc.UnaryServerInterceptor{s.unaryLimit}
+	stream := []grpc.StreamServerInterceptor{s.streamLimit}
+	if config.Auth != nil {
+		unary = append([]grpc.UnaryServerInterceptor{s.unaryAuth}, unary...)
+		stream = append([]grpc.StreamServerInterceptor{s.streamAuth}, stream...)
+	}
+	s.grpcServer = grpc.NewServer(
+		grpc.MaxRecvMsgSize(config.MaxMessageSize),
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	)
+	terracostpb.RegisterTerraCostServer(s.grpcServer, s)
+	return s
+}
+
+// Start listens on the configured port and serves until Stop
+func (s *Server) Start() error {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", s.config.Port))
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %d: %w", s.config.Port, err)
+	}
+	slog.Info("TerraCost gRPC server starting", "port", s.config.Port)
+	return s.Serve(lis)
+}
+
+// Serve serves on an existing listener
+func (s *Server) Serve(lis net.Listener) error {
+	return s.grpcServer.Serve(lis)
+}
+
+// Stop waits for in-flight RPCs up to the timeout, then closes all connections
+func (s *Server) Stop(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		s.grpcServer.Stop()
+	}
+}
+
+// =============================================================================
+// ESTIMATE
+// =============================================================================
+
+// Estimate prices a plan and evaluates policy
+func (s *Server) Estimate(ctx context.Context, req *terracostpb.EstimateRequest) (*terracostpb.EstimateResponse, error) {
+	estReq, err := estimateRequest(req.GetOptions())
+	if err != nil {
+		return nil, err
+	}
+	estReq.Plan = req.GetPlan()
+
+	resp, err := s.rest.Estimate(ctx, estReq)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return estimateResponse(resp), nil
+}
+
+// StreamEstimate is Estimate with the plan sent in chunks
+func (s *Server) StreamEstimate(stream terracostpb.TerraCost_StreamEstimateServer) error {
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return status.Error(codes.InvalidArgument, "empty plan stream")
+	}
+	if err != nil {
+		return err
+	}
+	estReq, err := estimateRequest(first.GetOptions())
+	if err != nil {
+		return err
+	}
+
+	ctx := stream.Context()
+	plan, err := s.parseStream(first.GetPlan(), func() ([]byte, error) {
+		chunk, err := stream.Recv()
+		return chunk.GetPlan(), err
+	}, func(plan io.Reader) (*iac.ParsedPlan, error) {
+		return s.rest.ParseEstimatePlan(ctx, estReq, plan)
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.rest.EstimatePlan(ctx, plan, estReq)
+	if err != nil {
+		return toStatus(err)
+	}
+	return stream.SendAndClose(estimateResponse(resp))
+}
+
+// EvaluatePolicy estimates a plan and returns only the policy decision
+func (s *Server) EvaluatePolicy(ctx context.Context, req *terracostpb.EvaluatePolicyRequest) (*terracostpb.EvaluatePolicyResponse, error) {
+	resp, err := s.Estimate(ctx, &terracostpb.EstimateRequest{Plan: req.GetPlan(), Options: req.GetOptions()})
+	if err != nil {
+		return nil, err
+	}
+	return &terracostpb.EvaluatePolicyResponse{
+		Policy:         resp.Policy,
+		Currency:       resp.Currency,
+		MonthlyCostP50: resp.MonthlyCostP50,
+		MonthlyCostP90: resp.MonthlyCostP90,
+		Confidence:     resp.Confidence,
+	}, nil
+}
+
+// =============================================================================
+// PARSE
+// =============================================================================
+
+// Parse returns the resources and dependencies of a plan
+func (s *Server) Parse(ctx context.Context, req *terracostpb.ParseRequest) (*terracostpb.ParseResponse, error) {
+	plan, err := s.rest.ParsePlan(ctx, req.GetPlanFormat(), bytes.NewReader(req.GetPlan()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return parseResponse(plan)
+}
+
+// StreamParse is Parse with the plan sent in chunks
+func (s *Server) StreamParse(stream terracostpb.TerraCost_StreamParseServer) error {
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return status.Error(codes.InvalidArgument, "empty plan stream")
+	}
+	if err != nil {
+		return err
+	}
+
+	plan, err := s.parseStream(first.GetPlan(), func() ([]byte, error) {
+		chunk, err := stream.Recv()
+		return chunk.GetPlan(), err
+	}, func(plan io.Reader) (*iac.ParsedPlan, error) {
+		return s.rest.ParsePlan(stream.Context(), first.GetPlanFormat(), plan)
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := parseResponse(plan)
+	if err != nil {
+		return err
+	}
+	return stream.SendAndClose(resp)
+}
+
+// parseStream parses a plan with parse while its chunks arrive
+// next returns the following chunk and io.EOF once the client is done sending.
+func (s *Server) parseStream(first []byte, next func() ([]byte, error), parse func(io.Reader) (*iac.ParsedPlan, error)) (*iac.ParsedPlan, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		chunk := first
+		for {
+			if _, err := pw.Write(chunk); err != nil {
+				return // the parser stopped reading
+			}
+			var err error
+			if chunk, err = next(); err != nil {
+				if err == io.EOF {
+					err = nil
+				}
+				pw.CloseWithError(err)
+				return
+			}
+		}
+	}()
+
+	plan, err := parse(pr)
+	pr.Close()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return plan, nil
+}
+
+// parseResponse builds the infrastructure graph of a plan and summarizes it
+func parseResponse(plan *iac.ParsedPlan) (*terracostpb.ParseResponse, error) {
+	graph, err := iac.NewGraphBuilder().Build(plan)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to build graph: %v", err)
+	}
+	return graphResponse(plan, graph), nil
+}
+
+// =============================================================================
+// PRICES
+// =============================================================================
+
+// ResolvePrices looks up unit prices from the active pricing snapshots
+func (s *Server) ResolvePrices(ctx context.Context, req *terracostpb.ResolvePricesRequest) (*terracostpb.ResolvePricesResponse, error) {
+	if s.pricingStore == nil {
+		return nil, status.Error(codes.Unavailable, "no pricing store configured")
+	}
+
+	lookups := make([]clickhouse.RateLookup, len(req.GetLookups()))
+	for i, l := range req.GetLookups() {
+		at, err := estimation.ParsePricingDate(l.GetPricingDate())
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "lookup %d: %v", i, err)
+		}
+		lookups[i] = clickhouse.RateLookup{
+			Cloud:         clickhouse.CloudProvider(l.GetCloud()),
+			Service:       l.GetService(),
+			ProductFamily: l.GetProductFamily(),
+			Region:        l.GetRegion(),
+			Attributes:    l.GetAttributes(),
+			Unit:          l.GetUnit(),
+			Alias:         l.GetAlias(),
+			At:            at,
+		}
+	}
+
+	rates, err := s.pricingStore.ResolveRatesBatch(ctx, lookups)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+
+	resp := &terracostpb.ResolvePricesResponse{Prices: make([]*terracostpb.ResolvedPrice, len(lookups))}
+	for i, l := range lookups {
+		resp.Prices[i] = resolvedPrice(rates[l.Key()])
+	}
+	return resp, nil
+}
+
+// =============================================================================
+// ERRORS
+// =============================================================================
+
+// grpcCodes maps error codes to gRPC status codes; others are internal
+var grpcCodes = map[tcerrors.Code]codes.Code{
+	tcerrors.CodeParseFailed:        codes.InvalidArgument,
+	tcerrors.CodeInvalidRequest:     codes.InvalidArgument,
+	tcerrors.CodePlanTooLarge:       codes.ResourceExhausted,
+	tcerrors.CodeRateLimited:        codes.ResourceExhausted,
+	tcerrors.CodeUnauthorized:       codes.Unauthenticated,
+	tcerrors.CodeForbidden:          codes.PermissionDenied,
+	tcerrors.CodeNotFound:           codes.NotFound,
+	tcerrors.CodeNotImplemented:     codes.Unimplemented,
+	tcerrors.CodeUnavailable:        codes.Unavailable,
+	tcerrors.CodePolicyDeny:         codes.FailedPrecondition,
+	tcerrors.CodePolicyWarn:         codes.FailedPrecondition,
+	tcerrors.CodeSnapshotStale:      codes.FailedPrecondition,
+	tcerrors.CodePriceNotFound:      codes.FailedPrecondition,
+	tcerrors.CodeEstimateIncomplete: codes.FailedPrecondition,
+	tcerrors.CodeMethodNotAllowed:   codes.Unimplemented,
+}
+
+// toStatus maps a pipeline error to a gRPC status; its error code is an ErrorInfo detail
+func toStatus(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	code := tcerrors.CodeOf(err)
+	grpcCode, ok := grpcCodes[code]
+	if !ok {
+		grpcCode = codes.Internal
+	}
+	st := status.New(grpcCode, err.Error())
+	if withCode, detailErr := st.WithDetails(&errdetails.ErrorInfo{Reason: string(code), Domain: errorDomain}); detailErr == nil {
+		st = withCode
+	}
+	return st.Err()
+}
+
+// errorDomain is the ErrorInfo domain of TerraCost error codes
+const errorDomain = "terracost"
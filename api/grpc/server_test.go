@@ -0,0 +1,202 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"terraform-cost/api"
+	"terraform-cost/api/grpc/terracostpb"
+	"terraform-cost/tenant"
+)
+
+const testPlan = `{"format_version":"1.2","terraform_version":"1.6.0",
+"resource_changes":[
+ {"address":"aws_instance.web","mode":"managed","type":"aws_instance","name":"web","provider_name":"registry.terraform.io/hashicorp/aws",
+  "change":{"actions":["create"],"before":null,"after":{"instance_type":"t3.micro"}}},
+ {"address":"aws_eip.web","mode":"managed","type":"aws_eip","name":"web","provider_name":"registry.terraform.io/hashicorp/aws",
+  "change":{"actions":["create"],"before":null,"after":{"domain":"vpc"}}}
+],
+"configuration":{"provider_config":{"aws":{"name":"aws","expressions":{"region":{"constant_value":"eu-west-1"}}}}}}`
+
+func newTestClient(t *testing.T) terracostpb.TerraCostClient {
+	t.Helper()
+	lis := bufconn.Listen(1024 * 1024)
+	srv := NewServer(api.NewServer(nil, nil), nil, nil)
+	go srv.Serve(lis)
+	t.Cleanup(srv.grpcServer.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return terracostpb.NewTerraCostClient(conn)
+}
+
+func TestStreamParse(t *testing.T) {
+	client := newTestClient(t)
+	stream, err := client.StreamParse(context.Background())
+	if err != nil {
+		t.Fatalf("StreamParse: %v", err)
+	}
+
+	// Send the plan in chunks that split tokens
+	plan := []byte(testPlan)
+	for i := 0; i < len(plan); i += 17 {
+		chunk := &terracostpb.ParseChunk{Plan: plan[i:min(i+17, len(plan))]}
+		if i == 0 {
+			chunk.PlanFormat = "terraform"
+		}
+		if err := stream.Send(chunk); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		t.Fatalf("CloseAndRecv: %v", err)
+	}
+
+	if resp.ResourceCount != 2 || len(resp.Resources) != 2 {
+		t.Fatalf("resources = %d (%v), want 2", resp.ResourceCount, resp.Resources)
+	}
+	if got := resp.Resources[0].Address; got != "aws_eip.web" {
+		t.Errorf("first resource = %s, want aws_eip.web", got)
+	}
+	if got := resp.ChangeStats.GetCreates(); got != 2 {
+		t.Errorf("creates = %d, want 2", got)
+	}
+	if got := resp.RegionStats["eu-west-1"]; got != 2 {
+		t.Errorf("eu-west-1 resources = %d, want 2", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	client := newTestClient(t)
+	tests := []struct {
+		name string
+		req  *terracostpb.ParseRequest
+	}{
+		{"invalid plan", &terracostpb.ParseRequest{Plan: []byte("{not json")}},
+		{"unknown format", &terracostpb.ParseRequest{Plan: []byte(testPlan), PlanFormat: "cdk"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.Parse(context.Background(), tt.req)
+			if code := status.Code(err); code != codes.InvalidArgument {
+				t.Errorf("code = %v (%v), want InvalidArgument", code, err)
+			}
+		})
+	}
+}
+
+func TestAuth(t *testing.T) {
+	verifier := tenant.NewVerifier([]byte("secret"))
+	token, err := verifier.Sign(tenant.Tenant{OrgID: "acme"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lis := bufconn.Listen(1024 * 1024)
+	cfg := DefaultConfig()
+	cfg.Auth = verifier
+	srv := NewServer(api.NewServer(nil, nil), nil, cfg)
+	go srv.Serve(lis)
+	t.Cleanup(srv.grpcServer.Stop)
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	client := terracostpb.NewTerraCostClient(conn)
+
+	tests := []struct {
+		name          string
+		authorization string
+		want          codes.Code
+	}{
+		{"no token", "", codes.Unauthenticated},
+		{"bad token", "Bearer abc", codes.Unauthenticated},
+		{"valid token", "Bearer " + token, codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.authorization != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", tt.authorization)
+			}
+			_, err := client.Parse(ctx, &terracostpb.ParseRequest{Plan: []byte(testPlan)})
+			if code := status.Code(err); code != tt.want {
+				t.Errorf("code = %v (%v), want %v", code, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	lis := bufconn.Listen(1024 * 1024)
+	rest := api.NewServer(nil, &api.Config{RateLimit: 0.001, RateBurst: 1})
+	srv := NewServer(rest, nil, nil)
+	go srv.Serve(lis)
+	t.Cleanup(srv.grpcServer.Stop)
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	client := terracostpb.NewTerraCostClient(conn)
+
+	req := &terracostpb.ParseRequest{Plan: []byte(testPlan)}
+	if _, err := client.Parse(context.Background(), req); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	if _, err := client.Parse(context.Background(), req); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("second call: %v, want ResourceExhausted", err)
+	}
+}
+
+func TestEstimateVariables(t *testing.T) {
+	client := newTestClient(t)
+	config := []byte("variable \"size\" {}\n\nresource \"aws_instance\" \"web\" {\n  instance_type = var.size\n}\n")
+	// Variables that leave out one the configuration references are rejected
+	owner, err := structpb.NewStruct(map[string]interface{}{"owner": "ops"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Estimate(context.Background(), &terracostpb.EstimateRequest{
+		Plan:    config,
+		Options: &terracostpb.EstimateOptions{PlanFormat: "hcl", Variables: owner},
+	})
+	if status.Code(err) != codes.InvalidArgument || !strings.Contains(err.Error(), "size") {
+		t.Errorf("err = %v, want InvalidArgument naming the unresolved variable", err)
+	}
+
+	variables, err := structpb.NewStruct(map[string]interface{}{"size": "m5.large", "disk": map[string]interface{}{"size": 200}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := estimateRequest(&terracostpb.EstimateOptions{Variables: variables, Workspace: "prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	disk, _ := req.Variables["disk"].(map[string]interface{})
+	if req.Variables["size"] != "m5.large" || disk["size"] != float64(200) || req.Workspace != "prod" {
+		t.Errorf("variables = %v, workspace %q", req.Variables, req.Workspace)
+	}
+}
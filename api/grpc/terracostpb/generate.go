@@ -0,0 +1,5 @@
+// Package terracostpb - gRPC API messages and service stubs
+// Generated from terracost.proto with protoc-gen-go and protoc-gen-go-grpc.
+package terracostpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative terracost.proto
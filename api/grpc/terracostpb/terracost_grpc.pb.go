@@ -0,0 +1,375 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: terracost.proto
+
+package terracostpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	TerraCost_Estimate_FullMethodName       = "/terracost.v1.TerraCost/Estimate"
+	TerraCost_StreamEstimate_FullMethodName = "/terracost.v1.TerraCost/StreamEstimate"
+	TerraCost_Parse_FullMethodName          = "/terracost.v1.TerraCost/Parse"
+	TerraCost_StreamParse_FullMethodName    = "/terracost.v1.TerraCost/StreamParse"
+	TerraCost_ResolvePrices_FullMethodName  = "/terracost.v1.TerraCost/ResolvePrices"
+	TerraCost_EvaluatePolicy_FullMethodName = "/terracost.v1.TerraCost/EvaluatePolicy"
+)
+
+// TerraCostClient is the client API for TerraCost service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type TerraCostClient interface {
+	// Estimate prices a plan and evaluates policy
+	Estimate(ctx context.Context, in *EstimateRequest, opts ...grpc.CallOption) (*EstimateResponse, error)
+	// StreamEstimate is Estimate with the plan sent in chunks
+	StreamEstimate(ctx context.Context, opts ...grpc.CallOption) (TerraCost_StreamEstimateClient, error)
+	// Parse returns the resources and dependencies of a plan
+	Parse(ctx context.Context, in *ParseRequest, opts ...grpc.CallOption) (*ParseResponse, error)
+	// StreamParse is Parse with the plan sent in chunks
+	StreamParse(ctx context.Context, opts ...grpc.CallOption) (TerraCost_StreamParseClient, error)
+	// ResolvePrices looks up unit prices from the active pricing snapshots
+	ResolvePrices(ctx context.Context, in *ResolvePricesRequest, opts ...grpc.CallOption) (*ResolvePricesResponse, error)
+	// EvaluatePolicy estimates a plan and returns only the policy decision
+	EvaluatePolicy(ctx context.Context, in *EvaluatePolicyRequest, opts ...grpc.CallOption) (*EvaluatePolicyResponse, error)
+}
+
+type terraCostClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTerraCostClient(cc grpc.ClientConnInterface) TerraCostClient {
+	return &terraCostClient{cc}
+}
+
+func (c *terraCostClient) Estimate(ctx context.Context, in *EstimateRequest, opts ...grpc.CallOption) (*EstimateResponse, error) {
+	out := new(EstimateResponse)
+	err := c.cc.Invoke(ctx, TerraCost_Estimate_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *terraCostClient) StreamEstimate(ctx context.Context, opts ...grpc.CallOption) (TerraCost_StreamEstimateClient, error) {
+	stream, err := c.cc.NewStream(ctx, &TerraCost_ServiceDesc.Streams[0], TerraCost_StreamEstimate_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &terraCostStreamEstimateClient{stream}
+	return x, nil
+}
+
+type TerraCost_StreamEstimateClient interface {
+	Send(*EstimateChunk) error
+	CloseAndRecv() (*EstimateResponse, error)
+	grpc.ClientStream
+}
+
+type terraCostStreamEstimateClient struct {
+	grpc.ClientStream
+}
+
+func (x *terraCostStreamEstimateClient) Send(m *EstimateChunk) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *terraCostStreamEstimateClient) CloseAndRecv() (*EstimateResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(EstimateResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *terraCostClient) Parse(ctx context.Context, in *ParseRequest, opts ...grpc.CallOption) (*ParseResponse, error) {
+	out := new(ParseResponse)
+	err := c.cc.Invoke(ctx, TerraCost_Parse_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *terraCostClient) StreamParse(ctx context.Context, opts ...grpc.CallOption) (TerraCost_StreamParseClient, error) {
+	stream, err := c.cc.NewStream(ctx, &TerraCost_ServiceDesc.Streams[1], TerraCost_StreamParse_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &terraCostStreamParseClient{stream}
+	return x, nil
+}
+
+type TerraCost_StreamParseClient interface {
+	Send(*ParseChunk) error
+	CloseAndRecv() (*ParseResponse, error)
+	grpc.ClientStream
+}
+
+type terraCostStreamParseClient struct {
+	grpc.ClientStream
+}
+
+func (x *terraCostStreamParseClient) Send(m *ParseChunk) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *terraCostStreamParseClient) CloseAndRecv() (*ParseResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ParseResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *terraCostClient) ResolvePrices(ctx context.Context, in *ResolvePricesRequest, opts ...grpc.CallOption) (*ResolvePricesResponse, error) {
+	out := new(ResolvePricesResponse)
+	err := c.cc.Invoke(ctx, TerraCost_ResolvePrices_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *terraCostClient) EvaluatePolicy(ctx context.Context, in *EvaluatePolicyRequest, opts ...grpc.CallOption) (*EvaluatePolicyResponse, error) {
+	out := new(EvaluatePolicyResponse)
+	err := c.cc.Invoke(ctx, TerraCost_EvaluatePolicy_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TerraCostServer is the server API for TerraCost service.
+// All implementations must embed UnimplementedTerraCostServer
+// for forward compatibility
+type TerraCostServer interface {
+	// Estimate prices a plan and evaluates policy
+	Estimate(context.Context, *EstimateRequest) (*EstimateResponse, error)
+	// StreamEstimate is Estimate with the plan sent in chunks
+	StreamEstimate(TerraCost_StreamEstimateServer) error
+	// Parse returns the resources and dependencies of a plan
+	Parse(context.Context, *ParseRequest) (*ParseResponse, error)
+	// StreamParse is Parse with the plan sent in chunks
+	StreamParse(TerraCost_StreamParseServer) error
+	// ResolvePrices looks up unit prices from the active pricing snapshots
+	ResolvePrices(context.Context, *ResolvePricesRequest) (*ResolvePricesResponse, error)
+	// EvaluatePolicy estimates a plan and returns only the policy decision
+	EvaluatePolicy(context.Context, *EvaluatePolicyRequest) (*EvaluatePolicyResponse, error)
+	mustEmbedUnimplementedTerraCostServer()
+}
+
+// UnimplementedTerraCostServer must be embedded to have forward compatible implementations.
+type UnimplementedTerraCostServer struct {
+}
+
+func (UnimplementedTerraCostServer) Estimate(context.Context, *EstimateRequest) (*EstimateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Estimate not implemented")
+}
+func (UnimplementedTerraCostServer) StreamEstimate(TerraCost_StreamEstimateServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamEstimate not implemented")
+}
+func (UnimplementedTerraCostServer) Parse(context.Context, *ParseRequest) (*ParseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Parse not implemented")
+}
+func (UnimplementedTerraCostServer) StreamParse(TerraCost_StreamParseServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamParse not implemented")
+}
+func (UnimplementedTerraCostServer) ResolvePrices(context.Context, *ResolvePricesRequest) (*ResolvePricesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResolvePrices not implemented")
+}
+func (UnimplementedTerraCostServer) EvaluatePolicy(context.Context, *EvaluatePolicyRequest) (*EvaluatePolicyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EvaluatePolicy not implemented")
+}
+func (UnimplementedTerraCostServer) mustEmbedUnimplementedTerraCostServer() {}
+
+// UnsafeTerraCostServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TerraCostServer will
+// result in compilation errors.
+type UnsafeTerraCostServer interface {
+	mustEmbedUnimplementedTerraCostServer()
+}
+
+func RegisterTerraCostServer(s grpc.ServiceRegistrar, srv TerraCostServer) {
+	s.RegisterService(&TerraCost_ServiceDesc, srv)
+}
+
+func _TerraCost_Estimate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EstimateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TerraCostServer).Estimate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TerraCost_Estimate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TerraCostServer).Estimate(ctx, req.(*EstimateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TerraCost_StreamEstimate_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(TerraCostServer).StreamEstimate(&terraCostStreamEstimateServer{stream})
+}
+
+type TerraCost_StreamEstimateServer interface {
+	SendAndClose(*EstimateResponse) error
+	Recv() (*EstimateChunk, error)
+	grpc.ServerStream
+}
+
+type terraCostStreamEstimateServer struct {
+	grpc.ServerStream
+}
+
+func (x *terraCostStreamEstimateServer) SendAndClose(m *EstimateResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *terraCostStreamEstimateServer) Recv() (*EstimateChunk, error) {
+	m := new(EstimateChunk)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _TerraCost_Parse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ParseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TerraCostServer).Parse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TerraCost_Parse_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TerraCostServer).Parse(ctx, req.(*ParseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TerraCost_StreamParse_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(TerraCostServer).StreamParse(&terraCostStreamParseServer{stream})
+}
+
+type TerraCost_StreamParseServer interface {
+	SendAndClose(*ParseResponse) error
+	Recv() (*ParseChunk, error)
+	grpc.ServerStream
+}
+
+type terraCostStreamParseServer struct {
+	grpc.ServerStream
+}
+
+func (x *terraCostStreamParseServer) SendAndClose(m *ParseResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *terraCostStreamParseServer) Recv() (*ParseChunk, error) {
+	m := new(ParseChunk)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _TerraCost_ResolvePrices_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResolvePricesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TerraCostServer).ResolvePrices(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TerraCost_ResolvePrices_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TerraCostServer).ResolvePrices(ctx, req.(*ResolvePricesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TerraCost_EvaluatePolicy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EvaluatePolicyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TerraCostServer).EvaluatePolicy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TerraCost_EvaluatePolicy_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TerraCostServer).EvaluatePolicy(ctx, req.(*EvaluatePolicyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// TerraCost_ServiceDesc is the grpc.ServiceDesc for TerraCost service.
+// It's only intended for direct use with grpc.RegisterTerraCostServer,
+// and not to be introspected or modified (even as a copy)
+var TerraCost_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "terracost.v1.TerraCost",
+	HandlerType: (*TerraCostServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Estimate",
+			Handler:    _TerraCost_Estimate_Handler,
+		},
+		{
+			MethodName: "Parse",
+			Handler:    _TerraCost_Parse_Handler,
+		},
+		{
+			MethodName: "ResolvePrices",
+			Handler:    _TerraCost_ResolvePrices_Handler,
+		},
+		{
+			MethodName: "EvaluatePolicy",
+			Handler:    _TerraCost_EvaluatePolicy_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamEstimate",
+			Handler:       _TerraCost_StreamEstimate_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "StreamParse",
+			Handler:       _TerraCost_StreamParse_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "terracost.proto",
+}
@@ -0,0 +1,72 @@
+// Package api - Dependency health
+// The server checks the services it depends on in the background and answers
+// /ready and /health/ready from the last check, with each dependency's status
+// and latency. It is ready while every critical dependency is up.
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/policy"
+	"terraform-cost/internal/health"
+)
+
+// ReadinessResponse is the body of /ready and /health/ready
+type ReadinessResponse struct {
+	Status       string                    `json:"status"` // ready, not_ready or draining
+	Dependencies []health.DependencyStatus `json:"dependencies"`
+}
+
+// newServiceRegistry registers ClickHouse, OPA and the configured dependencies
+// OPA is critical only when it fails closed; failing open it can't deny estimates.
+func newServiceRegistry(store *clickhouse.Store, config *Config) *health.ServiceRegistry {
+	registry := health.NewServiceRegistry(config.HealthCheckInterval, health.DefaultTimeout)
+	if store != nil {
+		registry.Register(health.Dependency{Name: "clickhouse", Critical: true, Check: store.Ping})
+	}
+	if config.OPAEndpoint != "" {
+		registry.Register(health.Dependency{
+			Name:     "opa",
+			Critical: config.OPAFailureMode == policy.OPAFailClosed,
+			Check:    health.HTTPCheck(nil, strings.TrimSuffix(config.OPAEndpoint, "/")+"/health"),
+		})
+	}
+	for _, dep := range config.Dependencies {
+		registry.Register(dep)
+	}
+	return registry
+}
+
+// startHealthChecks checks dependencies until shutdown; call after the HTTP server is created
+func (s *Server) startHealthChecks() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.health.Run(ctx)
+	}()
+	s.httpServer.OnShutdown(func(context.Context) {
+		cancel()
+		<-done
+	})
+}
+
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	resp := ReadinessResponse{Status: "ready", Dependencies: s.health.Statuses()}
+
+	// Load balancers stop routing here once shutdown begins
+	if s.httpServer != nil && s.httpServer.Draining() {
+		resp.Status = "draining"
+		s.jsonResponse(w, http.StatusServiceUnavailable, resp)
+		return
+	}
+	if !s.health.Ready() {
+		resp.Status = "not_ready"
+		s.jsonResponse(w, http.StatusServiceUnavailable, resp)
+		return
+	}
+	s.jsonResponse(w, http.StatusOK, resp)
+}
@@ -0,0 +1,291 @@
+// Package api - Async estimate jobs
+// Large plans are estimated by background workers; clients poll the job for
+// its status and result, which are kept in ClickHouse until the job expires.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"terraform-cost/db/clickhouse"
+	tcerrors "terraform-cost/pkg/errors"
+	"terraform-cost/telemetry"
+	"terraform-cost/tenant"
+)
+
+// Job defaults
+const (
+	DefaultJobWorkers   = 2
+	DefaultJobQueueSize = 100
+	DefaultJobTimeout   = 10 * time.Minute
+	DefaultJobRetention = 7 * 24 * time.Hour
+)
+
+// jobSaveTimeout bounds each job state write
+const jobSaveTimeout = 10 * time.Second
+
+// JobResponse is the API view of an async job
+type JobResponse struct {
+	*clickhouse.Job
+	StatusURL string            `json:"status_url"`
+	Result    *EstimateResponse `json:"result,omitempty"` // Set once the job succeeded
+}
+
+// jobStore keeps async job state; *clickhouse.Store is the production store
+type jobStore interface {
+	SaveJob(ctx context.Context, j *clickhouse.Job) error
+	GetJob(ctx context.Context, id uuid.UUID) (*clickhouse.Job, error)
+}
+
+// asyncJob is a queued estimate request
+type asyncJob struct {
+	job       *clickhouse.Job
+	req       EstimateRequest
+	tenant    tenant.Tenant // Empty in single-tenant mode
+	requestID string        // Of the request that queued the job
+}
+
+// jobRunner runs queued estimate jobs on a fixed pool of workers
+type jobRunner struct {
+	queue    chan asyncJob
+	ctx      context.Context // canceled to abort running jobs
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	started  bool
+	stopping bool
+}
+
+func newJobRunner(queueSize int) *jobRunner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &jobRunner{
+		queue:  make(chan asyncJob, queueSize),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// startJobWorkers starts the job workers once
+func (s *Server) startJobWorkers() {
+	s.jobs.mu.Lock()
+	defer s.jobs.mu.Unlock()
+	if s.jobs.started || s.jobStore == nil {
+		return
+	}
+	s.jobs.started = true
+	for i := 0; i < s.config.JobWorkers; i++ {
+		s.jobs.wg.Add(1)
+		go func() {
+			defer s.jobs.wg.Done()
+			for j := range s.jobs.queue {
+				s.runJob(j)
+			}
+		}()
+	}
+}
+
+// stopJobWorkers stops accepting jobs and waits for running jobs until ctx ends,
+// then aborts them; jobs still queued are failed
+func (s *Server) stopJobWorkers(ctx context.Context) {
+	s.jobs.mu.Lock()
+	if s.jobs.stopping {
+		s.jobs.mu.Unlock()
+		return
+	}
+	s.jobs.stopping = true
+	close(s.jobs.queue)
+	s.jobs.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.jobs.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.jobs.cancel()
+		<-done
+	}
+}
+
+// enqueueJob queues a job; false when the server is stopping or the queue is full
+func (s *Server) enqueueJob(j asyncJob) bool {
+	s.jobs.mu.Lock()
+	defer s.jobs.mu.Unlock()
+	if s.jobs.stopping {
+		return false
+	}
+	select {
+	case s.jobs.queue <- j:
+		return true
+	default:
+		return false
+	}
+}
+
+// runJob estimates one job and saves each state change
+func (s *Server) runJob(j asyncJob) {
+	job := j.job
+	if s.jobs.ctx.Err() != nil {
+		s.finishJob(j, nil, fmt.Errorf("server shut down before the job started"))
+		return
+	}
+
+	started := time.Now().UTC()
+	job.Status = clickhouse.JobRunning
+	job.StartedAt = &started
+	s.saveJob(j)
+
+	ctx, cancel := context.WithTimeout(tenant.NewContext(s.jobs.ctx, j.tenant), s.config.JobTimeout)
+	defer cancel()
+	if j.requestID != "" {
+		ctx = telemetry.WithLogFields(telemetry.WithRequestID(ctx, j.requestID), "request_id", j.requestID)
+	}
+	ctx = telemetry.WithLogFields(ctx, "job_id", job.ID)
+	resp, err := s.Estimate(ctx, j.req)
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		err = fmt.Errorf("estimate did not finish within %s", s.config.JobTimeout)
+	case context.Canceled:
+		err = fmt.Errorf("server shut down during the estimate")
+	}
+	s.finishJob(j, resp, err)
+}
+
+// finishJob records a job's result or error
+func (s *Server) finishJob(j asyncJob, resp *EstimateResponse, err error) {
+	job := j.job
+	finished := time.Now().UTC()
+	job.FinishedAt = &finished
+	if err == nil {
+		var data []byte
+		if data, err = json.Marshal(resp); err == nil {
+			job.Status = clickhouse.JobSucceeded
+			job.ResultJSON = string(data)
+		}
+	}
+	if err != nil {
+		job.Status = clickhouse.JobFailed
+		job.Error = err.Error()
+		job.ErrorCode = string(tcerrors.CodeOf(err))
+	}
+	s.saveJob(j)
+}
+
+// saveJob writes a job state under its tenant; a lost write leaves the job at its previous state
+func (s *Server) saveJob(j asyncJob) {
+	ctx, cancel := context.WithTimeout(tenant.NewContext(context.Background(), j.tenant), jobSaveTimeout)
+	defer cancel()
+	if err := s.jobStore.SaveJob(ctx, j.job); err != nil {
+		slog.Warn("job state not saved", "job_id", j.job.ID, "status", j.job.Status, "error", err)
+	}
+}
+
+// =============================================================================
+// JOB ENDPOINTS
+// =============================================================================
+
+// handleEstimateAsync queues an estimate and returns its job (POST /api/v1/estimate/async)
+func (s *Server) handleEstimateAsync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.jsonError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if s.jobStore == nil {
+		s.jsonError(w, http.StatusServiceUnavailable, "async estimates need the ClickHouse store")
+		return
+	}
+
+	req, ok := s.decodeEstimateRequest(w, r)
+	if !ok {
+		return
+	}
+	if _, err := s.validateEstimateRequest(r.Context(), req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	job := &clickhouse.Job{
+		ID:          uuid.New(),
+		Status:      clickhouse.JobQueued,
+		Project:     req.Project,
+		Environment: req.Environment,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.config.JobRetention),
+	}
+	if err := s.jobStore.SaveJob(r.Context(), job); err != nil {
+		s.jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	// The worker owns the job once queued, so respond with a copy
+	queued := *job
+	t, _ := tenant.FromContext(r.Context())
+	j := asyncJob{job: job, req: req, tenant: t, requestID: telemetry.RequestID(r.Context())}
+	if !s.enqueueJob(j) {
+		s.finishJob(j, nil, tcerrors.New(tcerrors.CodeUnavailable, "job queue is full"))
+		w.Header().Set("Retry-After", "30")
+		s.jsonError(w, http.StatusServiceUnavailable, "job queue is full, retry later")
+		return
+	}
+
+	resp := s.jobResponse(&queued)
+	w.Header().Set("Location", resp.StatusURL)
+	s.jsonResponse(w, http.StatusAccepted, resp)
+}
+
+// handleJob returns a job's status and, once succeeded, its result (GET /api/v1/jobs/{id})
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.jsonError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if s.jobStore == nil {
+		s.jsonError(w, http.StatusServiceUnavailable, "async estimates need the ClickHouse store")
+		return
+	}
+
+	id, err := uuid.Parse(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/jobs/"), "/"))
+	if err != nil {
+		s.jsonError(w, http.StatusBadRequest, "invalid job id")
+		return
+	}
+	job, err := s.jobStore.GetJob(r.Context(), id)
+	if err != nil {
+		s.jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if job == nil {
+		s.jsonError(w, http.StatusNotFound, "job not found or expired")
+		return
+	}
+	if job.Project != "" {
+		if err := authorizeProject(r.Context(), job.Project); err != nil {
+			s.writeError(w, err)
+			return
+		}
+	}
+
+	resp := s.jobResponse(job)
+	if job.Status == clickhouse.JobSucceeded {
+		resp.Result = &EstimateResponse{}
+		if err := json.Unmarshal([]byte(job.ResultJSON), resp.Result); err != nil {
+			s.jsonError(w, http.StatusInternalServerError, fmt.Sprintf("invalid job result: %v", err))
+			return
+		}
+	}
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
+func (s *Server) jobResponse(job *clickhouse.Job) *JobResponse {
+	return &JobResponse{Job: job, StatusURL: "/api/v1/jobs/" + job.ID.String()}
+}
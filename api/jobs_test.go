@@ -0,0 +1,234 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/awsmeta"
+	tcerrors "terraform-cost/pkg/errors"
+)
+
+// fakeJobStore keeps jobs in memory; saves store a copy as ClickHouse would
+type fakeJobStore struct {
+	mu   sync.Mutex
+	jobs map[uuid.UUID]clickhouse.Job
+}
+
+func (f *fakeJobStore) SaveJob(_ context.Context, j *clickhouse.Job) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.jobs[j.ID] = *j
+	return nil
+}
+
+func (f *fakeJobStore) GetJob(_ context.Context, id uuid.UUID) (*clickhouse.Job, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	j, ok := f.jobs[id]
+	if !ok {
+		return nil, nil
+	}
+	return &j, nil
+}
+
+// wait polls until a job reaches a status
+func (f *fakeJobStore) wait(t *testing.T, id uuid.UUID, status clickhouse.JobStatus) *clickhouse.Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if j, _ := f.GetJob(context.Background(), id); j != nil && j.Status == status {
+			return j
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	j, _ := f.GetJob(context.Background(), id)
+	t.Fatalf("job %s never reached %s: %+v", id, status, j)
+	return nil
+}
+
+// blockingSource holds estimates in AMI lookups until released or canceled
+type blockingSource struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func newBlockingSource() *blockingSource {
+	return &blockingSource{entered: make(chan struct{}, 10), release: make(chan struct{})}
+}
+
+func (b *blockingSource) Name() string { return "blocking" }
+
+func (b *blockingSource) InstanceType(context.Context, string, string) (*awsmeta.InstanceType, error) {
+	return nil, nil
+}
+
+func (b *blockingSource) Image(ctx context.Context, _, _ string) (*awsmeta.Image, error) {
+	b.entered <- struct{}{}
+	select {
+	case <-b.release:
+		return nil, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func newJobTestServer(config *Config) (*Server, *fakeJobStore) {
+	store := &fakeJobStore{jobs: make(map[uuid.UUID]clickhouse.Job)}
+	s := newTestServer(config)
+	s.jobStore = store
+	return s, store
+}
+
+// postJob queues an estimate of the test plan
+func postJob(t *testing.T, s *Server) (*httptest.ResponseRecorder, *JobResponse) {
+	t.Helper()
+	body := `{"plan":` + testPlan + `,"environment":"prod"}`
+	rec := httptest.NewRecorder()
+	s.handleEstimateAsync(rec, httptest.NewRequest(http.MethodPost, "/api/v1/estimate/async", strings.NewReader(body)))
+	if rec.Code != http.StatusAccepted {
+		return rec, nil
+	}
+	var resp JobResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode job: %v", err)
+	}
+	return rec, &resp
+}
+
+func TestEstimateAsyncJob(t *testing.T) {
+	s, store := newJobTestServer(&Config{JobWorkers: 1})
+	s.startJobWorkers()
+	defer s.stopJobWorkers(context.Background())
+
+	rec, queued := postJob(t, s)
+	if queued == nil {
+		t.Fatalf("status = %d, want 202: %s", rec.Code, rec.Body)
+	}
+	if queued.Status != clickhouse.JobQueued {
+		t.Errorf("status = %s, want queued", queued.Status)
+	}
+	if loc := rec.Header().Get("Location"); loc != queued.StatusURL || loc != "/api/v1/jobs/"+queued.ID.String() {
+		t.Errorf("Location = %q, status_url = %q", loc, queued.StatusURL)
+	}
+	store.wait(t, queued.ID, clickhouse.JobSucceeded)
+
+	rec = httptest.NewRecorder()
+	s.handleJob(rec, httptest.NewRequest(http.MethodGet, queued.StatusURL, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET status = %d: %s", rec.Code, rec.Body)
+	}
+	var got JobResponse
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode job: %v", err)
+	}
+	if got.Status != clickhouse.JobSucceeded || got.StartedAt == nil || got.FinishedAt == nil {
+		t.Errorf("job = %+v, want succeeded with start and finish times", got.Job)
+	}
+	if got.Result == nil {
+		t.Error("succeeded job has no result")
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleJob(rec, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/"+uuid.NewString(), nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown job status = %d, want 404", rec.Code)
+	}
+}
+
+func TestEstimateAsyncQueueFull(t *testing.T) {
+	// No workers run, so the second job finds the queue full
+	s, store := newJobTestServer(&Config{JobQueueSize: 1})
+
+	if rec, queued := postJob(t, s); queued == nil {
+		t.Fatalf("first job status = %d, want 202: %s", rec.Code, rec.Body)
+	}
+	rec, _ := postJob(t, s)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("second job status = %d, want 503", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("Retry-After = %q, want 30", got)
+	}
+
+	// The rejected job is recorded as failed rather than left queued
+	var failed int
+	for _, j := range store.jobs {
+		if j.Status == clickhouse.JobFailed {
+			failed++
+			if j.ErrorCode != string(tcerrors.CodeUnavailable) {
+				t.Errorf("error code = %q, want %s", j.ErrorCode, tcerrors.CodeUnavailable)
+			}
+		}
+	}
+	if failed != 1 || len(store.jobs) != 2 {
+		t.Errorf("jobs = %+v, want one queued and one failed", store.jobs)
+	}
+}
+
+func TestStopJobWorkersDrains(t *testing.T) {
+	source := newBlockingSource()
+	s, store := newJobTestServer(&Config{JobWorkers: 1, Enricher: awsmeta.NewEnricher(source)})
+	s.startJobWorkers()
+
+	_, queued := postJob(t, s)
+	if queued == nil {
+		t.Fatal("job not queued")
+	}
+	<-source.entered
+
+	stopped := make(chan struct{})
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		s.stopJobWorkers(ctx)
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("stopJobWorkers returned with a job in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// New jobs are refused while stopping
+	if rec, _ := postJob(t, s); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("job while stopping status = %d, want 503", rec.Code)
+	}
+
+	close(source.release)
+	<-stopped
+	store.wait(t, queued.ID, clickhouse.JobSucceeded)
+}
+
+func TestStopJobWorkersTimeout(t *testing.T) {
+	source := newBlockingSource()
+	s, store := newJobTestServer(&Config{JobWorkers: 1, Enricher: awsmeta.NewEnricher(source)})
+	s.startJobWorkers()
+
+	_, running := postJob(t, s)
+	_, waiting := postJob(t, s)
+	if running == nil || waiting == nil {
+		t.Fatal("jobs not queued")
+	}
+	<-source.entered
+
+	// A shutdown timeout that already ran out aborts the running job
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.stopJobWorkers(ctx)
+
+	if j := store.wait(t, running.ID, clickhouse.JobFailed); !strings.Contains(j.Error, "during the estimate") {
+		t.Errorf("running job error = %q", j.Error)
+	}
+	if j := store.wait(t, waiting.ID, clickhouse.JobFailed); !strings.Contains(j.Error, "before the job started") {
+		t.Errorf("queued job error = %q", j.Error)
+	}
+}
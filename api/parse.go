@@ -0,0 +1,125 @@
+// Package api - Parse endpoint
+// Summarizes a plan's infrastructure graph without pricing it
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"terraform-cost/decision/iac"
+)
+
+// ParseRequest is a plan to parse
+type ParseRequest struct {
+	Plan       json.RawMessage `json:"plan"`
+	PlanFormat string          `json:"plan_format,omitempty"` // terraform (default), pulumi, cloudformation
+}
+
+// ParseResponse summarizes a plan's infrastructure graph
+type ParseResponse struct {
+	FormatVersion string              `json:"format_version"`
+	ResourceCount int                 `json:"resource_count"`
+	Resources     []ResourceResponse  `json:"resources"` // Sorted by address
+	ProviderStats map[string]int      `json:"provider_stats"`
+	RegionStats   map[string]int      `json:"region_stats"`
+	ChangeStats   ChangeStatsResponse `json:"change_stats"`
+	Warnings      []string            `json:"warnings,omitempty"`
+}
+
+// ResourceResponse is one managed resource of a parsed plan
+type ResourceResponse struct {
+	Address      string   `json:"address"`
+	Type         string   `json:"type"`
+	Name         string   `json:"name"`
+	Provider     string   `json:"provider"`
+	Region       string   `json:"region"`
+	Action       string   `json:"action,omitempty"` // create, update, delete, replace, forget or no-op
+	Dependencies []string `json:"dependencies,omitempty"`
+	Dependents   []string `json:"dependents,omitempty"`
+}
+
+// ChangeStatsResponse counts planned changes by action
+type ChangeStatsResponse struct {
+	Creates  int `json:"creates"`
+	Updates  int `json:"updates"`
+	Deletes  int `json:"deletes"`
+	Replaces int `json:"replaces"`
+	Forgets  int `json:"forgets"`
+	NoOps    int `json:"no_ops"`
+	Total    int `json:"total"`
+
+	// Counted on top of each resource's action
+	Imports int `json:"imports"`
+	Moves   int `json:"moves"`
+	Deposed int `json:"deposed"`
+}
+
+func (s *Server) handleParse(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.jsonError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxRequestSize)
+	var req ParseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.jsonError(w, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+
+	plan, err := s.ParsePlan(r.Context(), req.PlanFormat, bytes.NewReader(req.Plan))
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	graph, err := iac.NewGraphBuilder().Build(plan)
+	if err != nil {
+		s.writeError(w, internalError("failed to build graph: %v", err))
+		return
+	}
+	s.jsonResponse(w, http.StatusOK, buildParseResponse(plan, graph))
+}
+
+// buildParseResponse summarizes a plan's graph
+func buildParseResponse(plan *iac.ParsedPlan, graph *iac.Graph) ParseResponse {
+	resp := ParseResponse{
+		FormatVersion: plan.FormatVersion,
+		ResourceCount: graph.ResourceCount,
+		Resources:     make([]ResourceResponse, 0, len(graph.Nodes)),
+		ProviderStats: graph.ProviderStats,
+		RegionStats:   graph.RegionStats,
+		ChangeStats: ChangeStatsResponse{
+			Creates:  graph.ChangeStats.Creates,
+			Updates:  graph.ChangeStats.Updates,
+			Deletes:  graph.ChangeStats.Deletes,
+			Replaces: graph.ChangeStats.Replaces,
+			Forgets:  graph.ChangeStats.Forgets,
+			NoOps:    graph.ChangeStats.NoOps,
+			Total:    graph.ChangeStats.Total,
+			Imports:  graph.ChangeStats.Imports,
+			Moves:    graph.ChangeStats.Moves,
+			Deposed:  graph.ChangeStats.Deposed,
+		},
+		Warnings: plan.Warnings,
+	}
+	for _, node := range graph.Nodes {
+		res := ResourceResponse{
+			Address:      node.Resource.Address,
+			Type:         node.Resource.Type,
+			Name:         node.Resource.Name,
+			Provider:     node.Provider,
+			Region:       node.Region,
+			Dependencies: node.Dependencies,
+			Dependents:   node.Dependents,
+		}
+		if node.Change != nil {
+			res.Action = string(node.Change.Action)
+		}
+		resp.Resources = append(resp.Resources, res)
+	}
+	sort.Slice(resp.Resources, func(i, j int) bool { return resp.Resources[i].Address < resp.Resources[j].Address })
+	return resp
+}
@@ -0,0 +1,159 @@
+// Package api - Rate limiting and quotas
+// Each client (verified tenant, else IP) gets a token bucket for all requests and a
+// daily estimate quota, so CI storms can't overload ClickHouse.
+package api
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tcerrors "terraform-cost/pkg/errors"
+	"terraform-cost/tenant"
+)
+
+// clientIdleTTL is how long an idle client's limiter state is kept
+const clientIdleTTL = 10 * time.Minute
+
+// rateLimiter holds per-client token buckets and daily estimate counts
+type rateLimiter struct {
+	rate      float64 // Tokens added per second
+	burst     float64
+	quota     int // Estimates per client per UTC day; 0 is unlimited
+	now       func() time.Time
+	mu        sync.Mutex
+	clients   map[string]*clientLimit
+	lastSweep time.Time
+}
+
+type clientLimit struct {
+	tokens    float64
+	last      time.Time
+	day       string // UTC date the estimate count is for
+	estimates int
+}
+
+func newRateLimiter(rate float64, burst, quota int) *rateLimiter {
+	if burst <= 0 {
+		burst = int(math.Max(1, math.Ceil(rate)))
+	}
+	return &rateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		quota:   quota,
+		now:     time.Now,
+		clients: make(map[string]*clientLimit),
+	}
+}
+
+// allow takes a token for a request, and a quota slot for an estimate;
+// when refused it returns why and how long until the client may retry
+func (l *rateLimiter) allow(key string, estimate bool) (string, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+	c, ok := l.clients[key]
+	if !ok {
+		c = &clientLimit{tokens: l.burst, last: now}
+		l.clients[key] = c
+	}
+
+	if l.rate > 0 {
+		c.tokens = math.Min(l.burst, c.tokens+now.Sub(c.last).Seconds()*l.rate)
+		c.last = now
+		if c.tokens < 1 {
+			return "rate limit exceeded", time.Duration((1 - c.tokens) / l.rate * float64(time.Second))
+		}
+	}
+
+	if estimate && l.quota > 0 {
+		today := now.UTC().Format("2006-01-02")
+		if c.day != today {
+			c.day, c.estimates = today, 0
+		}
+		if c.estimates >= l.quota {
+			midnight := now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+			return "daily estimate quota exceeded", midnight.Sub(now)
+		}
+		c.estimates++
+	}
+
+	if l.rate > 0 {
+		c.tokens--
+	}
+	return "", 0
+}
+
+// sweep drops clients idle long enough to be back at a full bucket
+// A client's quota count is dropped with it only once its day is over.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < clientIdleTTL {
+		return
+	}
+	l.lastSweep = now
+	today := now.UTC().Format("2006-01-02")
+	for key, c := range l.clients {
+		if now.Sub(c.last) > clientIdleTTL && (c.estimates == 0 || c.day != today) {
+			delete(l.clients, key)
+		}
+	}
+}
+
+// rateLimitMiddleware refuses requests over the client's rate or estimate quota with 429
+func (s *Server) rateLimitMiddleware(next http.Handler) http.Handler {
+	if s.limiter == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if publicPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if reason, retry := s.limiter.allow(clientKey(r.Context(), r.RemoteAddr), isEstimateRequest(r)); reason != "" {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+			s.jsonError(w, http.StatusTooManyRequests, reason)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isEstimateRequest reports whether a request runs an estimate (sync, async or for policy evaluation)
+func isEstimateRequest(r *http.Request) bool {
+	return r.Method == http.MethodPost &&
+		(r.URL.Path == "/api/v1/estimate" || strings.HasPrefix(r.URL.Path, "/api/v1/estimate/") ||
+			r.URL.Path == "/api/v1/policy/evaluate")
+}
+
+// Limit applies the rate limit and estimate quota to a call that did not come
+// through the HTTP handler, such as the gRPC API; peer is the caller's address
+func (s *Server) Limit(ctx context.Context, peer string, estimate bool) error {
+	if s.limiter == nil {
+		return nil
+	}
+	if reason, retry := s.limiter.allow(clientKey(ctx, peer), estimate); reason != "" {
+		return tcerrors.New(tcerrors.CodeRateLimited, "%s; retry after %ds", reason, int(math.Ceil(retry.Seconds())))
+	}
+	return nil
+}
+
+// clientKey identifies the caller: its verified tenant, else its IP
+// Tokens are only trusted once verified, so callers can't mint fresh buckets
+// by sending made-up keys.
+func clientKey(ctx context.Context, remoteAddr string) string {
+	if t, ok := tenant.FromContext(ctx); ok {
+		return "tenant:" + t.OrgID + "/" + t.Subject
+	}
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	return "ip:" + host
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"terraform-cost/tenant"
+)
+
+// fakeClock is a limiter clock moved by hand
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestLimiter(rate float64, burst, quota int) (*rateLimiter, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC)}
+	l := newRateLimiter(rate, burst, quota)
+	l.now = clock.now
+	return l, clock
+}
+
+func TestRateLimiterRefill(t *testing.T) {
+	l, clock := newTestLimiter(1, 2, 0)
+	for i := 0; i < 2; i++ {
+		if reason, _ := l.allow("a", false); reason != "" {
+			t.Fatalf("request %d refused: %s", i, reason)
+		}
+	}
+	if reason, retry := l.allow("a", false); reason == "" || retry != time.Second {
+		t.Errorf("empty bucket: reason %q, retry %v; want refused for 1s", reason, retry)
+	}
+	// Other clients have their own bucket
+	if reason, _ := l.allow("b", false); reason != "" {
+		t.Errorf("other client refused: %s", reason)
+	}
+
+	clock.advance(500 * time.Millisecond)
+	if reason, retry := l.allow("a", false); reason == "" || retry != 500*time.Millisecond {
+		t.Errorf("half a token: reason %q, retry %v; want refused for 500ms", reason, retry)
+	}
+	clock.advance(500 * time.Millisecond)
+	if reason, _ := l.allow("a", false); reason != "" {
+		t.Errorf("refilled token refused: %s", reason)
+	}
+}
+
+func TestRateLimiterQuotaReset(t *testing.T) {
+	l, clock := newTestLimiter(0, 0, 2)
+	for i := 0; i < 2; i++ {
+		if reason, _ := l.allow("a", true); reason != "" {
+			t.Fatalf("estimate %d refused: %s", i, reason)
+		}
+	}
+	if reason, retry := l.allow("a", true); reason == "" || retry != time.Hour {
+		t.Errorf("over quota: reason %q, retry %v; want refused until midnight", reason, retry)
+	}
+	if reason, _ := l.allow("a", false); reason != "" {
+		t.Errorf("non-estimate refused over quota: %s", reason)
+	}
+
+	// The count is kept across sweeps until the day is over
+	clock.advance(30 * time.Minute)
+	if reason, _ := l.allow("b", false); reason != "" {
+		t.Fatal(reason)
+	}
+	if reason, _ := l.allow("a", true); reason == "" {
+		t.Error("quota reset before midnight")
+	}
+	clock.advance(30 * time.Minute)
+	if reason, _ := l.allow("a", true); reason != "" {
+		t.Errorf("quota not reset at midnight: %s", reason)
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	l, _ := newTestLimiter(0.5, 1, 0)
+	s := &Server{limiter: l}
+	handler := s.rateLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	request := func(token string, t *tenant.Tenant) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/estimations", nil)
+		r.RemoteAddr = "10.0.0.1:5000"
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		if t != nil {
+			r = r.WithContext(tenant.NewContext(r.Context(), *t))
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := request("one", nil); w.Code != http.StatusOK {
+		t.Fatalf("first request: status %d", w.Code)
+	}
+	// An unverified token is not a new client
+	w := request("two", nil)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("unverified token: status %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want 2", got)
+	}
+	// A verified tenant is
+	if w := request("", &tenant.Tenant{OrgID: "acme", Subject: "ci"}); w.Code != http.StatusOK {
+		t.Errorf("verified tenant: status %d", w.Code)
+	}
+}
+
+func TestLimit(t *testing.T) {
+	l, _ := newTestLimiter(0, 0, 1)
+	s := &Server{limiter: l}
+	ctx := context.Background()
+	if err := s.Limit(ctx, "10.0.0.1:5000", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Limit(ctx, "10.0.0.1:6000", true); err == nil {
+		t.Error("second estimate from the same IP allowed over quota")
+	}
+	if err := (&Server{}).Limit(ctx, "", true); err != nil {
+		t.Errorf("no limiter: %v", err)
+	}
+}
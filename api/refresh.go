@@ -0,0 +1,197 @@
+// Package api - Scheduled pricing refresh
+// With a refresh schedule the server re-runs pricing ingestion for its regions
+// on a cron spec. Regions whose offers are unchanged keep their snapshot; new
+// pricing is activated only once fully ingested. The last run is reported at
+// /api/v1/pricing/status.
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"terraform-cost/db/ingestion"
+	"terraform-cost/internal/cron"
+)
+
+// Region refresh outcomes
+const (
+	RefreshUpdated  = "updated"
+	RefreshUpToDate = "up_to_date"
+	RefreshFailed   = "failed"
+)
+
+// PricingRefreshConfig schedules pricing ingestion in the server
+type PricingRefreshConfig struct {
+	Schedule   *cron.Schedule
+	Targets    []RefreshTarget
+	PriceAlert *ingestion.PriceAlert // Posts price increases of new snapshots; nil disables
+}
+
+// RefreshTarget is a provider region to refresh
+type RefreshTarget struct {
+	Provider string `json:"provider"` // aws
+	Region   string `json:"region"`
+}
+
+// RegionRefreshStatus is the outcome of refreshing one region
+type RegionRefreshStatus struct {
+	Provider   string    `json:"provider"`
+	Region     string    `json:"region"`
+	Status     string    `json:"status"`
+	SnapshotID string    `json:"snapshot_id,omitempty"` // The active snapshot after the refresh
+	Prices     int       `json:"prices,omitempty"`      // Ingested into a new snapshot
+	Error      string    `json:"error,omitempty"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// PricingRefreshRun is one scheduled refresh of every target
+type PricingRefreshRun struct {
+	StartedAt  time.Time             `json:"started_at"`
+	FinishedAt *time.Time            `json:"finished_at,omitempty"` // nil while running
+	Regions    []RegionRefreshStatus `json:"regions"`
+}
+
+// PricingStatusResponse is the response of /api/v1/pricing/status
+type PricingStatusResponse struct {
+	Enabled  bool               `json:"enabled"`
+	Schedule string             `json:"schedule,omitempty"`
+	Targets  []RefreshTarget    `json:"targets,omitempty"`
+	Running  bool               `json:"running"`
+	NextRun  *time.Time         `json:"next_run,omitempty"`
+	LastRun  *PricingRefreshRun `json:"last_run,omitempty"` // The running refresh while one runs
+}
+
+// refreshState tracks the scheduler for the status endpoint
+type refreshState struct {
+	mu      sync.Mutex
+	nextRun time.Time
+	lastRun *PricingRefreshRun
+}
+
+// status returns a copy of the refresh state
+func (r *refreshState) status() (time.Time, *PricingRefreshRun) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.lastRun == nil {
+		return r.nextRun, nil
+	}
+	run := *r.lastRun
+	run.Regions = append([]RegionRefreshStatus(nil), r.lastRun.Regions...)
+	return r.nextRun, &run
+}
+
+// startPricingRefresh schedules pricing refreshes; call after the HTTP server is created
+func (s *Server) startPricingRefresh() {
+	cfg := s.config.PricingRefresh
+	if cfg == nil || cfg.Schedule == nil || s.pricingStore == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			next := cfg.Schedule.Next(time.Now())
+			if next.IsZero() {
+				slog.Warn("pricing refresh schedule never fires", "schedule", cfg.Schedule.String())
+				return
+			}
+			s.refresh.mu.Lock()
+			s.refresh.nextRun = next
+			s.refresh.mu.Unlock()
+
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			s.refreshPricing(ctx, cfg)
+		}
+	}()
+	// Cancelling aborts a running ingestion before its snapshot is activated
+	s.httpServer.OnShutdown(func(context.Context) {
+		cancel()
+		<-done
+	})
+}
+
+// refreshPricing refreshes every target once, recording each region's outcome
+func (s *Server) refreshPricing(ctx context.Context, cfg *PricingRefreshConfig) {
+	run := &PricingRefreshRun{StartedAt: time.Now().UTC()}
+	s.refresh.mu.Lock()
+	s.refresh.nextRun = time.Time{}
+	s.refresh.lastRun = run
+	s.refresh.mu.Unlock()
+
+	adapter := ingestion.NewClickHouseAdapter(s.pricingStore)
+	if cfg.PriceAlert != nil {
+		adapter.WithPriceAlert(cfg.PriceAlert)
+	}
+	streamer := ingestion.NewAWSOfferStreamer()
+
+	for _, target := range cfg.Targets {
+		status := RegionRefreshStatus{Provider: target.Provider, Region: target.Region}
+		if target.Provider != "aws" {
+			status.Status = RefreshFailed
+			status.Error = fmt.Sprintf("pricing refresh does not support provider %q", target.Provider)
+		} else if result, err := ingestion.RefreshAWSRegion(ctx, streamer, adapter, target.Region, false); err != nil {
+			status.Status = RefreshFailed
+			status.Error = err.Error()
+			slog.Warn("pricing refresh failed", "provider", target.Provider, "region", target.Region, "error", err)
+		} else {
+			status.SnapshotID = result.SnapshotID.String()
+			status.Status = RefreshUpToDate
+			if !result.UpToDate {
+				status.Status = RefreshUpdated
+				status.Prices = result.Ingestion.PriceCount
+				slog.Info("pricing refresh activated snapshot", "provider", target.Provider, "region", target.Region,
+					"snapshot_id", result.SnapshotID, "prices", result.Ingestion.PriceCount)
+			}
+		}
+		status.FinishedAt = time.Now().UTC()
+
+		s.refresh.mu.Lock()
+		run.Regions = append(run.Regions, status)
+		s.refresh.mu.Unlock()
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	finished := time.Now().UTC()
+	s.refresh.mu.Lock()
+	run.FinishedAt = &finished
+	s.refresh.mu.Unlock()
+}
+
+// handlePricingStatus reports the refresh schedule and the last run
+func (s *Server) handlePricingStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.jsonError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	cfg := s.config.PricingRefresh
+	if cfg == nil || cfg.Schedule == nil {
+		s.jsonResponse(w, http.StatusOK, PricingStatusResponse{})
+		return
+	}
+
+	next, last := s.refresh.status()
+	resp := PricingStatusResponse{
+		Enabled:  true,
+		Schedule: cfg.Schedule.String(),
+		Targets:  cfg.Targets,
+		Running:  last != nil && last.FinishedAt == nil,
+		LastRun:  last,
+	}
+	if !next.IsZero() {
+		resp.NextRun = &next
+	}
+	s.jsonResponse(w, http.StatusOK, resp)
+}
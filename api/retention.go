@@ -0,0 +1,51 @@
+// Package api - Snapshot retention job
+// With a retention policy the server prunes old pricing snapshots on a fixed
+// interval, first one interval after it starts, until it shuts down.
+package api
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// DefaultSnapshotRetentionInterval is how often the retention job runs
+const DefaultSnapshotRetentionInterval = 24 * time.Hour
+
+// startRetention schedules the retention job; call after the HTTP server is created
+func (s *Server) startRetention() {
+	if s.config.SnapshotRetention == nil || s.pricingStore == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(s.config.SnapshotRetentionInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.pruneSnapshots(ctx)
+			}
+		}
+	}()
+	s.httpServer.OnShutdown(func(context.Context) {
+		cancel()
+		<-done
+	})
+}
+
+// pruneSnapshots runs the retention policy once
+func (s *Server) pruneSnapshots(ctx context.Context) {
+	result, err := s.pricingStore.PruneSnapshots(ctx, *s.config.SnapshotRetention, false)
+	if err != nil {
+		slog.Warn("snapshot retention failed", "error", err)
+		return
+	}
+	if len(result.Snapshots) > 0 {
+		slog.Info("pruned pricing snapshots", "snapshots", len(result.Snapshots), "rates", result.Rates)
+	}
+}
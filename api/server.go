@@ -3,34 +3,76 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 
 	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/anomaly"
+	"terraform-cost/decision/audit"
+	"terraform-cost/decision/awsmeta"
 	"terraform-cost/decision/billing"
 	"terraform-cost/decision/billing/mappers/aws"
+	"terraform-cost/decision/billing/mappers/gcp"
+	"terraform-cost/decision/budget"
+	"terraform-cost/decision/calibration"
+	"terraform-cost/decision/carbon"
+	"terraform-cost/decision/currency"
+	"terraform-cost/decision/discount"
 	"terraform-cost/decision/estimation"
 	"terraform-cost/decision/iac"
+	"terraform-cost/decision/optimize"
 	"terraform-cost/decision/policy"
+	"terraform-cost/decision/usage"
+	"terraform-cost/integrations"
+	"terraform-cost/integrations/events"
+	"terraform-cost/integrations/metrics"
+	"terraform-cost/integrations/notify"
+	"terraform-cost/internal/health"
+	"terraform-cost/internal/httpserver"
+	tcerrors "terraform-cost/pkg/errors"
+	"terraform-cost/pkg/terracost"
+	"terraform-cost/telemetry"
+	"terraform-cost/tenant"
 )
 
+// DefaultMaxPlanResources bounds the plans a server parses
+const DefaultMaxPlanResources = 50000
+
 // Server is the HTTP API server
 type Server struct {
-	httpServer    *http.Server
-	pricingStore  *clickhouse.Store
-	billingEngine *billing.Engine
-	policyEngine  *policy.Engine
-	config        *Config
+	httpServer   *httpserver.Server
+	pricingStore *clickhouse.Store
+	estimator    *terracost.Estimator
+	policyEngine *policy.Engine
+	config       *Config
+	jobs         *jobRunner
+
+	// Feature stores; pricingStore unless it is nil, fakes in tests
+	jobStore      jobStore
+	estimateStore estimateStore
+	rateResolver  rateResolver
+	approvalStore approvalStore
+
+	orgPolicyEngines map[string]*policy.Engine       // Orgs with policies of their own
+	orgEstimators    map[string]*terracost.Estimator // Estimators evaluating those policies
+	limiter          *rateLimiter                    // nil without rate limits or quotas
+	refresh          refreshState                    // Scheduled pricing refresh status
+	health           *health.ServiceRegistry         // Dependency status behind /ready
+	events           *events.Dispatcher              // nil without event publishers
 }
 
 // Config holds server configuration
@@ -38,9 +80,66 @@ type Config struct {
 	Port           int
 	ReadTimeout    time.Duration
 	WriteTimeout   time.Duration
+	IdleTimeout    time.Duration
 	MaxRequestSize int64
 	CORSOrigins    []string
 	OPAEndpoint    string
+	OPAPackage     string
+	OPAFailureMode policy.OPAFailureMode
+	Policies       []policy.Policy          // From the policy file, applied to every request
+	Waivers        []policy.Waiver          // From the waiver file, applied to every request
+	ExchangeRates  *currency.Table          // Enables non-USD currency requests
+	Discounts      discount.Provider        // Negotiated discounts taken off list prices; nil prices at list
+	PricingAliases *estimation.AliasRoutes  // Pricing alias of each environment and partition; nil prices from "default"
+	Notifiers      []integrations.Notifier  // Receive estimates from requests with notify set
+	Metrics        []metrics.Exporter       // Receive the cost of every estimate with a project
+	Events         []events.Publisher       // Receive lifecycle events of every estimate
+	CarbonStore    carbon.CarbonStore       // Carbon intensity for include_carbon requests
+	UsageProfiles  []usage.Profile          // Environments beyond dev, staging and prod
+	Calibrator     *calibration.Calibrator  // Calibrates usage of updated resources; nil disables
+	Enricher       *awsmeta.Enricher        // Resolves AMI platforms and instance hardware; nil disables
+	AuditSigner    audit.Signer             // Signs audit log records of every estimate; nil disables
+	MaxSnapshotAge time.Duration            // Estimates priced from older snapshots get a SNAPSHOT_STALE issue; 0 disables
+	Mappers        []billing.ResourceMapper // Extra mappers, e.g. from plugins; replace built-ins of the same type
+
+	MaxPlanResources int                       // Plans with more resources are rejected; 0 is unlimited
+	PlaceholderCosts *billing.PlaceholderCosts // Prices unsupported resource types; nil leaves them out
+
+	// Dependency health
+	Dependencies        []health.Dependency // Checked for readiness beyond ClickHouse and OPA
+	HealthCheckInterval time.Duration       // Between dependency checks (default: health.DefaultInterval)
+
+	// Graceful shutdown
+	DrainDelay      time.Duration // /ready reports draining this long before the listener closes
+	ShutdownTimeout time.Duration // Longest in-flight requests and jobs get to finish
+
+	// Async estimate jobs
+	JobWorkers   int           // Concurrent async estimates
+	JobQueueSize int           // Jobs waiting for a worker before requests are rejected
+	JobTimeout   time.Duration // Longest a single job may run
+	JobRetention time.Duration // How long job status and results are kept
+
+	// Persisted estimates
+	EstimateRetention time.Duration // How long estimates saved with persist are kept
+
+	// Approvals of denied estimates
+	ApprovalNotifiers []integrations.Notifier // Notified of approval requests
+	ApprovalTTL       time.Duration           // How long approvals stay pending
+	Approvers         []string                // Token subjects who may decide approvals; empty allows tokens with the approver claim
+
+	// Pricing data maintenance
+	SnapshotRetention         *clickhouse.RetentionPolicy // Prunes old snapshots on a schedule; nil disables
+	SnapshotRetentionInterval time.Duration               // Between prunes
+	PricingRefresh            *PricingRefreshConfig       // Re-ingests pricing on a schedule; nil disables
+
+	// Multi-tenancy
+	Auth        *tenant.Verifier           // Requires bearer tokens naming the org; nil serves a single tenant
+	OrgPolicies map[string][]policy.Policy // Extra policies per org, on top of Policies
+
+	// Rate limiting per client (tenant, API key or IP)
+	RateLimit          float64 // Requests per second; 0 is unlimited
+	RateBurst          int     // Requests allowed at once (default: one second's worth)
+	DailyEstimateQuota int     // Estimates per UTC day; 0 is unlimited
 }
 
 // DefaultConfig returns default server configuration
@@ -49,8 +148,23 @@ func DefaultConfig() *Config {
 		Port:           8080,
 		ReadTimeout:    30 * time.Second,
 		WriteTimeout:   60 * time.Second,
+		IdleTimeout:    120 * time.Second,
 		MaxRequestSize: 10 * 1024 * 1024, // 10MB
 		CORSOrigins:    []string{"*"},
+		JobWorkers:     DefaultJobWorkers,
+		JobQueueSize:   DefaultJobQueueSize,
+		JobTimeout:     DefaultJobTimeout,
+		JobRetention:   DefaultJobRetention,
+
+		EstimateRetention: DefaultEstimateRetention,
+		ApprovalTTL:       DefaultApprovalTTL,
+
+		SnapshotRetentionInterval: DefaultSnapshotRetentionInterval,
+
+		MaxPlanResources: DefaultMaxPlanResources,
+
+		DrainDelay:      httpserver.DefaultConfig().DrainDelay,
+		ShutdownTimeout: httpserver.DefaultConfig().ShutdownTimeout,
 	}
 }
 
@@ -59,88 +173,221 @@ func NewServer(store *clickhouse.Store, config *Config) *Server {
 	if config == nil {
 		config = DefaultConfig()
 	}
+	defaults := DefaultConfig()
+	if config.ReadTimeout <= 0 {
+		config.ReadTimeout = defaults.ReadTimeout
+	}
+	if config.WriteTimeout <= 0 {
+		config.WriteTimeout = defaults.WriteTimeout
+	}
+	if config.IdleTimeout <= 0 {
+		config.IdleTimeout = defaults.IdleTimeout
+	}
+	if config.MaxRequestSize <= 0 {
+		config.MaxRequestSize = defaults.MaxRequestSize
+	}
+	if config.ShutdownTimeout <= 0 {
+		config.ShutdownTimeout = defaults.ShutdownTimeout
+	}
+	if config.JobWorkers <= 0 {
+		config.JobWorkers = DefaultJobWorkers
+	}
+	if config.JobQueueSize <= 0 {
+		config.JobQueueSize = DefaultJobQueueSize
+	}
+	if config.JobTimeout <= 0 {
+		config.JobTimeout = DefaultJobTimeout
+	}
+	if config.JobRetention <= 0 {
+		config.JobRetention = DefaultJobRetention
+	}
+	if config.EstimateRetention <= 0 {
+		config.EstimateRetention = DefaultEstimateRetention
+	}
+	if config.SnapshotRetentionInterval <= 0 {
+		config.SnapshotRetentionInterval = DefaultSnapshotRetentionInterval
+	}
+	if config.ApprovalTTL <= 0 {
+		config.ApprovalTTL = DefaultApprovalTTL
+	}
 
 	// Initialize billing engine with AWS mappers
 	billingEngine := billing.NewEngine()
 	aws.RegisterAllMappers(billingEngine)
+	gcp.RegisterAllMappers(billingEngine)
+	billingEngine.RegisterMappers(config.Mappers...)
+	if config.PlaceholderCosts != nil {
+		billingEngine.WithFallbackMapper(billing.NewPlaceholderMapper(*config.PlaceholderCosts))
+	}
+
+	// Initialize policy engines and the estimators evaluating them
+	policyEngine := newPolicyEngine(store, config, config.Policies)
+	estimator := newEstimator(store, config, billingEngine, policyEngine)
+	orgPolicyEngines := make(map[string]*policy.Engine, len(config.OrgPolicies))
+	orgEstimators := make(map[string]*terracost.Estimator, len(config.OrgPolicies))
+	for org, policies := range config.OrgPolicies {
+		all := append(append([]policy.Policy{}, config.Policies...), policies...)
+		orgPolicyEngines[org] = newPolicyEngine(store, config, all)
+		orgEstimators[org] = newEstimator(store, config, billingEngine, orgPolicyEngines[org])
+	}
+
+	var dispatcher *events.Dispatcher
+	if len(config.Events) > 0 {
+		dispatcher = events.NewDispatcher(config.Events, events.DefaultQueueSize).
+			WithErrorHandler(func(err error) { slog.Warn("event bus publish failed", "error", err) })
+	}
+
+	var limiter *rateLimiter
+	if config.RateLimit > 0 || config.DailyEstimateQuota > 0 {
+		limiter = newRateLimiter(config.RateLimit, config.RateBurst, config.DailyEstimateQuota)
+	}
+
+	s := &Server{
+		pricingStore: store,
+		estimator:    estimator,
+		policyEngine: policyEngine,
+		config:       config,
+		jobs:         newJobRunner(config.JobQueueSize),
+
+		orgPolicyEngines: orgPolicyEngines,
+		orgEstimators:    orgEstimators,
+		limiter:          limiter,
+		health:           newServiceRegistry(store, config),
+		events:           dispatcher,
+	}
+	// A nil *clickhouse.Store must stay a nil interface for the nil-store guards
+	if store != nil {
+		s.jobStore = store
+		s.estimateStore = store
+		s.rateResolver = store
+		s.approvalStore = store
+	}
+	return s
+}
 
-	// Initialize policy engine
-	policyEngine := policy.NewEngine()
+// newPolicyEngine creates a policy engine for a set of policies with the server's budgets, history and OPA
+func newPolicyEngine(store *clickhouse.Store, config *Config, policies []policy.Policy) *policy.Engine {
+	engine := policy.NewEngine()
+	engine.LoadPolicies(policies)
+	engine.WithWaivers(config.Waivers)
+	if store != nil {
+		engine.WithBudgets(budget.NewTracker(store)).
+			WithAnomalyDetector(anomaly.NewDetector(store))
+	}
 	if config.OPAEndpoint != "" {
-		policyEngine.WithOPA(config.OPAEndpoint)
+		engine.WithOPA(config.OPAEndpoint).
+			WithOPAPackage(config.OPAPackage)
+		if config.OPAFailureMode != "" {
+			engine.WithOPAFailureMode(config.OPAFailureMode)
+		}
 	}
+	return engine
+}
 
-	return &Server{
-		pricingStore:  store,
-		billingEngine: billingEngine,
-		policyEngine:  policyEngine,
-		config:        config,
+// newEstimator creates an estimator with the server's pricing, usage and carbon data
+// Policy evaluation errors don't fail estimates served by the API.
+func newEstimator(store *clickhouse.Store, config *Config, billingEngine *billing.Engine, policyEngine *policy.Engine) *terracost.Estimator {
+	estimator := terracost.NewEstimator(store).
+		WithBillingEngine(billingEngine).
+		WithPolicyEngine(policyEngine).
+		WithPolicyFailOpen(true).
+		WithExchangeRates(config.ExchangeRates).
+		WithAliasRoutes(config.PricingAliases).
+		WithCalibrator(config.Calibrator).
+		WithEnricher(config.Enricher).
+		WithUsageProfiles(config.UsageProfiles...).
+		WithMaxResources(config.MaxPlanResources).
+		WithMaxSnapshotAge(config.MaxSnapshotAge)
+	if config.CarbonStore != nil {
+		estimator.WithCarbonStore(config.CarbonStore)
 	}
+	if config.Discounts != nil {
+		estimator.WithDiscounts(config.Discounts)
+	}
+	return estimator
 }
 
-// Start starts the HTTP server
+// Start starts the HTTP server and serves until Shutdown
 func (s *Server) Start() error {
+	s.setup()
+	slog.Info("TerraCost API server starting", "port", s.config.Port)
+	return s.httpServer.ListenAndServe()
+}
+
+// StartWithGracefulShutdown serves until SIGINT or SIGTERM, then drains: /ready
+// fails, in-flight requests finish and running async jobs get the shutdown timeout
+func (s *Server) StartWithGracefulShutdown() error {
+	s.setup()
+	slog.Info("TerraCost API server starting", "port", s.config.Port)
+	return s.httpServer.Run(context.Background())
+}
+
+// Shutdown drains the server started by Start
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
+}
+
+// setup registers the routes and job workers
+func (s *Server) setup() {
 	mux := http.NewServeMux()
 
 	// Register routes
 	mux.HandleFunc("/health", s.handleHealth)
 	mux.HandleFunc("/ready", s.handleReady)
+	mux.HandleFunc("/health/ready", s.handleReady)
 	mux.HandleFunc("/api/v1/estimate", s.handleEstimate)
 	mux.HandleFunc("/api/v1/estimate/", s.handleEstimate)
+	mux.HandleFunc("/api/v1/estimate/async", s.handleEstimateAsync)
+	mux.HandleFunc("/api/v1/jobs/", s.handleJob)
+	mux.HandleFunc("/api/v1/parse", s.handleParse)
+	mux.HandleFunc("/api/v1/predict/batch", s.handlePredictBatch)
+	mux.HandleFunc("/api/v1/price/batch", s.handlePriceBatch)
 	mux.HandleFunc("/api/v1/policy/evaluate", s.handlePolicyEvaluate)
 	mux.HandleFunc("/api/v1/snapshots", s.handleListSnapshots)
+	mux.HandleFunc("/api/v1/pricing/status", s.handlePricingStatus)
+	mux.HandleFunc("/api/v1/estimates", s.handleListEstimates)
+	mux.HandleFunc("/api/v1/estimates/", s.handleEstimateRecord)
+	mux.HandleFunc("/api/v1/estimates/trend", s.handleEstimateTrend)
+	mux.HandleFunc("/api/v1/budgets", s.handleBudgets)
+	mux.HandleFunc("/api/v1/budgets/", s.handleBudget)
+	mux.HandleFunc("/api/v1/approvals", s.handleApprovals)
+	mux.HandleFunc("/api/v1/approvals/", s.handleApproval)
+	mux.HandleFunc("/api/v1/discounts", s.handleDiscounts)
+	mux.HandleFunc("/api/v1/discounts/", s.handleDiscount)
+	mux.HandleFunc("/api/v1/audit/", s.handleAudit)
+	mux.HandleFunc("/api/v1/projects/", s.handleProjectAnomalies)
+	mux.HandleFunc("/api/v1/catalog/instances", s.handleCatalogInstances)
+
+	s.startJobWorkers()
 
 	// Wrap with middleware
-	handler := s.corsMiddleware(s.loggingMiddleware(mux))
-
-	s.httpServer = &http.Server{
-		Addr:         fmt.Sprintf(":%d", s.config.Port),
-		Handler:      handler,
-		ReadTimeout:  s.config.ReadTimeout,
-		WriteTimeout: s.config.WriteTimeout,
-	}
-
-	fmt.Printf("🚀 TerraCost API server starting on port %d\n", s.config.Port)
-	return s.httpServer.ListenAndServe()
-}
-
-// StartWithGracefulShutdown starts server with graceful shutdown handling
-func (s *Server) StartWithGracefulShutdown() error {
-	// Start server in goroutine
-	errChan := make(chan error, 1)
-	go func() {
-		if err := s.Start(); err != http.ErrServerClosed {
-			errChan <- err
-		}
-	}()
-
-	// Wait for interrupt signal
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-
-	select {
-	case err := <-errChan:
-		return err
-	case <-quit:
-		fmt.Println("\n📴 Shutting down server...")
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
-		return s.httpServer.Shutdown(ctx)
+	handler := s.corsMiddleware(telemetry.HTTPMiddleware(telemetry.LoggingMiddleware(s.authMiddleware(s.rateLimitMiddleware(mux)))))
+
+	s.httpServer = httpserver.New(handler, &httpserver.Config{
+		Addr:              fmt.Sprintf(":%d", s.config.Port),
+		ReadTimeout:       s.config.ReadTimeout,
+		ReadHeaderTimeout: httpserver.DefaultConfig().ReadHeaderTimeout,
+		WriteTimeout:      s.config.WriteTimeout,
+		IdleTimeout:       s.config.IdleTimeout,
+		MaxBodySize:       s.config.MaxRequestSize,
+		DrainDelay:        s.config.DrainDelay,
+		ShutdownTimeout:   s.config.ShutdownTimeout,
+	})
+	// Async jobs outlive their requests; they get what remains of the shutdown timeout
+	s.httpServer.OnShutdown(s.stopJobWorkers)
+	if s.events != nil {
+		// Queued events are published after the jobs that emit them finish
+		s.httpServer.OnShutdown(s.events.Close)
 	}
+	s.startRetention()
+	s.startPricingRefresh()
+	s.startHealthChecks()
 }
 
 // =============================================================================
 // MIDDLEWARE
 // =============================================================================
 
-func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		next.ServeHTTP(w, r)
-		fmt.Printf("%s %s %s %s\n", r.Method, r.URL.Path, r.RemoteAddr, time.Since(start))
-	})
-}
-
 func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		origin := r.Header.Get("Origin")
@@ -160,7 +407,8 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 		if allowed {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Request-ID, traceparent, tracestate")
+			w.Header().Set("Access-Control-Expose-Headers", telemetry.RequestIDHeader)
 			w.Header().Set("Access-Control-Max-Age", "86400")
 		}
 
@@ -184,46 +432,80 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-
-	// Check database connectivity
-	if err := s.pricingStore.Ping(ctx); err != nil {
-		s.jsonError(w, http.StatusServiceUnavailable, "database not ready")
-		return
-	}
-
-	s.jsonResponse(w, http.StatusOK, map[string]string{
-		"status": "ready",
-	})
-}
-
 // =============================================================================
 // ESTIMATE ENDPOINT
 // =============================================================================
 
+// maxSimulations caps Monte Carlo samples per API request
+const maxSimulations = 100000
+
 // EstimateRequest is the API request for cost estimation
 type EstimateRequest struct {
-	Plan            json.RawMessage `json:"plan"`
-	Environment     string          `json:"environment"`
-	IncludeCarbon   bool            `json:"include_carbon"`
-	IncludeFormulas bool            `json:"include_formulas"`
-	CostLimit       *float64        `json:"cost_limit,omitempty"`
-	CarbonBudget    *float64        `json:"carbon_budget,omitempty"`
+	Plan            json.RawMessage  `json:"plan"`
+	PlanFormat      string           `json:"plan_format,omitempty"` // terraform (default), pulumi, cloudformation, hcl
+	Environment     string           `json:"environment"`
+	IncludeCarbon   bool             `json:"include_carbon"`
+	IncludeFormulas bool             `json:"include_formulas"`
+	CostLimit       *float64         `json:"cost_limit,omitempty"`
+	CarbonBudget    *float64         `json:"carbon_budget,omitempty"`
+	CostGrowthLimit *float64         `json:"cost_growth_limit,omitempty"` // Max P50 growth (percent) over the baseline
+	Baseline        *policy.Baseline `json:"baseline,omitempty"`          // Defaults to the latest saved estimate for the project
+	BaselineBranch  string           `json:"baseline_branch,omitempty"`
+	Usage           *usage.File      `json:"usage,omitempty"`           // Per-resource usage overrides
+	AllocationTags  []string         `json:"allocation_tags,omitempty"` // Tag keys for cost_by_tag (default: team, cost-center, project)
+	PricingDate     string           `json:"pricing_date,omitempty"`    // YYYY-MM-DD or RFC 3339; prices from the snapshots valid then
+	PricingAlias    string           `json:"pricing_alias,omitempty"`   // Prices from this alias's snapshots; default routed by the server
+	Timebase        string           `json:"timebase,omitempty"`        // Hours billed per month: 730 (default), 720 or calendar
+	ProvisionedAt   string           `json:"provisioned_at,omitempty"`  // YYYY-MM-DD or RFC 3339; prorates the first month in first_month
+	Currency        string           `json:"currency,omitempty"`        // Default USD; others need server exchange rates
+	Notify          bool             `json:"notify,omitempty"`          // Send the result to the server's Slack/Teams notifiers
+	Simulations     int              `json:"simulations,omitempty"`     // Monte Carlo samples for cost bands (0: none)
+
+	// Free tier: allowances are subtracted from usage; remaining caps named
+	// allowances for accounts that use part of them elsewhere
+	IncludeFreeTier   bool               `json:"include_free_tier,omitempty"`
+	FreeTierRemaining map[string]float64 `json:"free_tier_remaining,omitempty"`
+
+	// Values for root variables the plan references but has no value for, as
+	// in plans made without their var files, or for the variables of raw HCL
+	// configuration (plan_format hcl, the plan a JSON string). Only HCL is
+	// evaluated with them: plan attributes set from a variable stay unknown.
+	// Workspace is what terraform.workspace evaluates to in HCL.
+	Variables map[string]interface{} `json:"variables,omitempty"`
+	Workspace string                 `json:"workspace,omitempty"`
+
+	// Relaxed matching prices rate keys with no exact match from the nearest
+	// rate, with a confidence penalty and matched_via on the driver
+	RelaxedMatching bool `json:"relaxed_matching,omitempty"`
+
+	// History: estimates with a project are saved for trend tracking
+	Project     string `json:"project,omitempty"`
+	Branch      string `json:"branch,omitempty"`
+	CommitSHA   string `json:"commit_sha,omitempty"`
+	PullRequest string `json:"pull_request,omitempty"`
+
+	// Persist keeps the response for GET /api/v1/estimates/{estimate_id} until
+	// the server's estimate retention expires
+	Persist bool `json:"persist,omitempty"`
+
+	// RequestApproval opens an approval when policy denies (needs a project)
+	RequestApproval bool `json:"request_approval,omitempty"`
 }
 
 // EstimateResponse is the API response for cost estimation
 type EstimateResponse struct {
 	// Cost metrics
+	Currency       string  `json:"currency"`
 	MonthlyCostP50 string  `json:"monthly_cost_p50"`
 	MonthlyCostP90 string  `json:"monthly_cost_p90"`
 	HourlyCostP50  string  `json:"hourly_cost_p50"`
 	CarbonKgCO2    float64 `json:"carbon_kg_co2"`
 
 	// Quality
-	Confidence   float64 `json:"confidence"`
-	IsIncomplete bool    `json:"is_incomplete"`
+	Confidence         float64                     `json:"confidence"`
+	ConfidenceScores   estimation.ConfidenceScores `json:"confidence_scores"`
+	IsIncomplete       bool                        `json:"is_incomplete"`
+	EstimationWarnings []string                    `json:"estimation_warnings,omitempty"`
 
 	// Statistics
 	ResourceCount       int `json:"resource_count"`
@@ -235,18 +517,85 @@ type EstimateResponse struct {
 	Violations   []policy.Violation `json:"violations"`
 	Warnings     []policy.Warning   `json:"warnings"`
 
+	// Unsupported resources, missing prices, stale snapshots and policy denials, by code
+	Issues []tcerrors.Issue `json:"issues,omitempty"`
+
 	// Cost breakdown
-	CostDrivers []CostDriverResponse `json:"cost_drivers"`
+	CostDrivers  []CostDriverResponse         `json:"cost_drivers"`
+	CostGroups   []CostGroupResponse          `json:"cost_groups"`
+	CostByTag    map[string]map[string]string `json:"cost_by_tag,omitempty"` // tag key -> value -> monthly P50
+	CostByModule []ModuleCostResponse         `json:"cost_by_module,omitempty"`
+	Simulation   *estimation.Simulation       `json:"simulation,omitempty"`
+	FreeTier     []estimation.FreeTierUsage   `json:"free_tier,omitempty"`
+	FirstMonth   *estimation.ProratedMonth    `json:"first_month,omitempty"`
+
+	// Rightsizing
+	PotentialSavings string                    `json:"potential_savings"`
+	Recommendations  []optimize.Recommendation `json:"recommendations"`
 
 	// Audit
 	EstimatedAt   string            `json:"estimated_at"`
+	RequestID     string            `json:"request_id,omitempty"`
+	PricingDate   string            `json:"pricing_date,omitempty"`
+	PricingAlias  string            `json:"pricing_alias"`
+	Timebase      string            `json:"timebase"`
+	HoursPerMonth float64           `json:"hours_per_month"`
+	RegionAliases map[string]string `json:"region_aliases,omitempty"` // Regions priced from another alias
 	SnapshotsUsed map[string]string `json:"snapshots_used"`
+	EstimationID  string            `json:"estimation_id,omitempty"` // Set when saved to history
+	AuditID       string            `json:"audit_id,omitempty"`      // Set when recorded in the audit log
+	EstimateID    string            `json:"estimate_id,omitempty"`   // Set when persisted; the estimation ID when also saved to history
+	ApprovalID    string            `json:"approval_id,omitempty"`   // Set when a deny opened an approval
+}
+
+// CostGroupResponse aggregates a component across count/for_each instances
+type CostGroupResponse struct {
+	Key            string   `json:"key"`
+	ResourceAddr   string   `json:"resource_addr"`
+	Service        string   `json:"service"`
+	Description    string   `json:"description"`
+	Quantity       int      `json:"quantity"`
+	UnitCostP50    string   `json:"unit_cost_p50"`
+	MonthlyCostP50 string   `json:"monthly_cost_p50"`
+	MonthlyCostP90 string   `json:"monthly_cost_p90"`
+	IsSymbolic     bool     `json:"is_symbolic"`
+	Instances      []string `json:"instances,omitempty"`
+}
+
+// ModuleCostResponse is a module's cost, including its child modules
+type ModuleCostResponse struct {
+	Module         string               `json:"module"`
+	Name           string               `json:"name"`
+	MonthlyCostP50 string               `json:"monthly_cost_p50"`
+	MonthlyCostP90 string               `json:"monthly_cost_p90"`
+	Resources      int                  `json:"resources"`
+	Children       []ModuleCostResponse `json:"children,omitempty"`
+}
+
+// moduleCostResponses converts a module cost tree
+func moduleCostResponses(modules []estimation.ModuleCost) []ModuleCostResponse {
+	if len(modules) == 0 {
+		return nil
+	}
+	out := make([]ModuleCostResponse, len(modules))
+	for i, m := range modules {
+		out[i] = ModuleCostResponse{
+			Module:         m.Module,
+			Name:           m.Name,
+			MonthlyCostP50: m.MonthlyCostP50.StringFixed(2),
+			MonthlyCostP90: m.MonthlyCostP90.StringFixed(2),
+			Resources:      m.Resources,
+			Children:       moduleCostResponses(m.Children),
+		}
+	}
+	return out
 }
 
 // CostDriverResponse is a single cost line item
 type CostDriverResponse struct {
 	ID             string  `json:"id"`
 	ResourceAddr   string  `json:"resource_addr"`
+	Module         string  `json:"module,omitempty"`
 	Service        string  `json:"service"`
 	ProductFamily  string  `json:"product_family"`
 	Region         string  `json:"region"`
@@ -257,6 +606,31 @@ type CostDriverResponse struct {
 	Confidence     float64 `json:"confidence"`
 	IsSymbolic     bool    `json:"is_symbolic"`
 	Reason         string  `json:"reason,omitempty"`
+	MatchedVia     string  `json:"matched_via,omitempty"`
+
+	ResolutionHints *estimation.ResolutionHints `json:"resolution_hints,omitempty"`
+}
+
+// costDriverResponse converts a cost driver
+func costDriverResponse(d estimation.CostDriver) CostDriverResponse {
+	return CostDriverResponse{
+		ID:             d.ID,
+		ResourceAddr:   d.ResourceAddr,
+		Module:         d.Module,
+		Service:        d.Service,
+		ProductFamily:  d.ProductFamily,
+		Region:         d.Region,
+		Description:    d.Description,
+		MonthlyCostP50: d.MonthlyCostP50.StringFixed(2),
+		MonthlyCostP90: d.MonthlyCostP90.StringFixed(2),
+		Formula:        d.Formula,
+		Confidence:     d.Confidence,
+		IsSymbolic:     d.IsSymbolic,
+		Reason:         d.Reason,
+		MatchedVia:     d.MatchedVia,
+
+		ResolutionHints: d.ResolutionHints,
+	}
 }
 
 func (s *Server) handleEstimate(w http.ResponseWriter, r *http.Request) {
@@ -265,63 +639,179 @@ func (s *Server) handleEstimate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Limit request size
+	req, ok := s.decodeEstimateRequest(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := s.Estimate(r.Context(), req)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
+// decodeEstimateRequest reads a size-limited estimate request body
+func (s *Server) decodeEstimateRequest(w http.ResponseWriter, r *http.Request) (EstimateRequest, bool) {
 	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxRequestSize)
 
-	// Parse request
 	var req EstimateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		s.jsonError(w, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
-		return
+		return req, false
 	}
+	return req, true
+}
 
-	ctx := r.Context()
+func badRequest(format string, args ...interface{}) error {
+	return tcerrors.New(tcerrors.CodeInvalidRequest, format, args...)
+}
+
+func internalError(format string, args ...interface{}) error {
+	return tcerrors.New(tcerrors.CodeInternal, format, args...)
+}
 
-	// Parse Terraform plan
-	parser := iac.NewParser()
-	plan, err := parser.ParseBytes(req.Plan)
+// ErrorStatus returns the HTTP status of an API error; errors without a code are internal
+func ErrorStatus(err error) int {
+	return tcerrors.CodeOf(err).HTTPStatus()
+}
+
+// ParsePlan parses a plan in the request's format
+func (s *Server) ParsePlan(ctx context.Context, format string, plan io.Reader) (*iac.ParsedPlan, error) {
+	parser, err := iac.NewParserForFormat(format)
 	if err != nil {
-		s.jsonError(w, http.StatusBadRequest, fmt.Sprintf("invalid terraform plan: %v", err))
-		return
+		return nil, badRequest("%v", err)
 	}
+	return s.parsePlan(ctx, parser, plan)
+}
 
-	// Build infrastructure graph
-	graphBuilder := iac.NewGraphBuilder()
-	graph, err := graphBuilder.Build(plan)
+// parseEstimatePlan parses an estimate request's plan; raw HCL configuration
+// arrives as a JSON string
+func (s *Server) parseEstimatePlan(ctx context.Context, req EstimateRequest) (*iac.ParsedPlan, error) {
+	source := []byte(req.Plan)
+	var text string
+	if json.Unmarshal(req.Plan, &text) == nil {
+		source = []byte(text)
+	}
+	return s.ParseEstimatePlan(ctx, req, bytes.NewReader(source))
+}
+
+// ParseEstimatePlan parses a plan for an estimate request with its variables
+// and workspace; req.Plan is ignored. Variables that resources or module calls
+// reference but that have no value fail requests that set variables, which
+// meant to supply them all; other requests get a warning naming them.
+func (s *Server) ParseEstimatePlan(ctx context.Context, req EstimateRequest, plan io.Reader) (*iac.ParsedPlan, error) {
+	parser, err := iac.NewParserForFormat(req.PlanFormat)
 	if err != nil {
-		s.jsonError(w, http.StatusInternalServerError, fmt.Sprintf("failed to build graph: %v", err))
-		return
+		return nil, badRequest("%v", err)
+	}
+	if hcl, ok := parser.(*iac.HCLParser); ok {
+		hcl.WithValues(req.Variables).WithWorkspace(req.Workspace)
+	}
+	parsed, err := s.parsePlan(ctx, parser, plan)
+	if err != nil {
+		return nil, err
+	}
+	iac.ApplyVariables(parsed, req.Variables)
+	if unresolved := strings.Join(parsed.UnresolvedVariables, ", "); unresolved != "" {
+		if len(req.Variables) > 0 {
+			return nil, badRequest("unresolved variables: %s; set them in variables", unresolved)
+		}
+		parsed.Warnings = append(parsed.Warnings,
+			fmt.Sprintf("unresolved variables: %s; what depends on them is unknown until they are set in variables", unresolved))
 	}
+	return parsed, nil
+}
 
-	// Decompose into billing components
-	decomposition, err := s.billingEngine.Decompose(graph)
+func (s *Server) parsePlan(ctx context.Context, parser iac.PlanParser, plan io.Reader) (*iac.ParsedPlan, error) {
+	parsed, err := s.estimator.Parse(ctx, parser, plan)
+	if tcerrors.CodeOf(err) == tcerrors.CodeParseFailed {
+		return nil, tcerrors.Wrap(tcerrors.CodeParseFailed, err, "invalid plan")
+	}
 	if err != nil {
-		s.jsonError(w, http.StatusInternalServerError, fmt.Sprintf("billing decomposition failed: %v", err))
-		return
+		return nil, err
+	}
+	return parsed, nil
+}
+
+// validateEstimateRequest checks the options that don't need the plan and returns the pricing date
+func (s *Server) validateEstimateRequest(ctx context.Context, req EstimateRequest) (time.Time, error) {
+	if req.Project != "" {
+		if err := authorizeProject(ctx, req.Project); err != nil {
+			return time.Time{}, err
+		}
 	}
+	pricingDate, err := estimation.ParsePricingDate(req.PricingDate)
+	if err != nil {
+		return time.Time{}, badRequest("%v", err)
+	}
+	if req.RequestApproval && req.Project == "" {
+		return time.Time{}, badRequest("request_approval needs a project")
+	}
+	if !s.config.ExchangeRates.Supports(req.Currency) {
+		return time.Time{}, badRequest("unsupported currency %q", req.Currency)
+	}
+	return pricingDate, nil
+}
+
+// Estimate runs the estimate pipeline for a request: parse, price, evaluate policy,
+// save to history and notify
+func (s *Server) Estimate(ctx context.Context, req EstimateRequest) (*EstimateResponse, error) {
+	sum := sha256.Sum256(req.Plan)
+	ctx = telemetry.WithLogFields(ctx, "plan_hash", hex.EncodeToString(sum[:8]))
+	plan, err := s.parseEstimatePlan(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.EstimatePlan(ctx, plan, req)
+}
+
+// EstimatePlan is Estimate for an already parsed plan; req.Plan is ignored
+func (s *Server) EstimatePlan(ctx context.Context, plan *iac.ParsedPlan, req EstimateRequest) (*EstimateResponse, error) {
+	pricingDate, err := s.validateEstimateRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	requestID, started := telemetry.RequestID(ctx), time.Now()
+	if requestID == "" {
+		requestID = uuid.NewString()
+	}
+	s.emitEvents(ctx, req, requestedEvent(requestID, req, len(plan.Resources)))
+	ctx = telemetry.WithLogFields(ctx, "project", req.Project, "environment", req.Environment, "resources", len(plan.Resources))
 
-	// Run estimation
-	estimationEngine := estimation.NewEngine(s.pricingStore)
-	estResult, err := estimationEngine.Estimate(ctx, estimation.EstimationRequest{
-		Components:      decomposition.Components,
+	var simulation *estimation.SimulationOptions
+	if req.Simulations > 0 {
+		simulation = &estimation.SimulationOptions{Iterations: min(req.Simulations, maxSimulations)}
+	}
+	estReq := terracost.Request{
 		Environment:     req.Environment,
+		Project:         req.Project,
+		Usage:           req.Usage,
 		IncludeCarbon:   req.IncludeCarbon,
 		IncludeFormulas: req.IncludeFormulas,
-	})
-	if err != nil {
-		s.jsonError(w, http.StatusInternalServerError, fmt.Sprintf("estimation failed: %v", err))
-		return
+		AllocationTags:  req.AllocationTags,
+		PricingDate:     pricingDate,
+		PricingAlias:    req.PricingAlias,
+		Currency:        req.Currency,
+		Simulation:      simulation,
+		RelaxedMatching: req.RelaxedMatching,
 	}
-
-	// Run policy evaluation
-	policyReq := policy.EvaluationRequest{
-		Estimation:  estResult,
-		Environment: req.Environment,
+	if estReq.Timebase, err = billing.ParseTimebase(req.Timebase); err != nil {
+		return nil, badRequest("%v", err)
+	}
+	if estReq.ProvisionedAt, err = estimation.ParseProvisionedAt(req.ProvisionedAt); err != nil {
+		return nil, badRequest("%v", err)
+	}
+	if req.IncludeFreeTier {
+		if estReq.FreeTier, err = estimation.DefaultFreeTier().WithRemaining(req.FreeTierRemaining); err != nil {
+			return nil, badRequest("%v", err)
+		}
 	}
 
 	// Add custom policies from request
 	if req.CostLimit != nil {
-		policyReq.CustomPolicies = append(policyReq.CustomPolicies, policy.Policy{
+		estReq.Policies = append(estReq.Policies, policy.Policy{
 			ID:        "api-cost-limit",
 			Name:      "Cost Limit",
 			Type:      policy.PolicyTypeCostLimit,
@@ -332,7 +822,7 @@ func (s *Server) handleEstimate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if req.CarbonBudget != nil {
-		policyReq.CustomPolicies = append(policyReq.CustomPolicies, policy.Policy{
+		estReq.Policies = append(estReq.Policies, policy.Policy{
 			ID:        "api-carbon-budget",
 			Name:      "Carbon Budget",
 			Type:      policy.PolicyTypeCarbonBudget,
@@ -342,37 +832,178 @@ func (s *Server) handleEstimate(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	policyResult, err := s.policyEngine.Evaluate(ctx, policyReq)
-	if err != nil {
-		// Policy evaluation is non-fatal
-		policyResult = &policy.EvaluationResult{
-			Decision: policy.DecisionPass,
-			Warnings: []policy.Warning{{Message: fmt.Sprintf("policy evaluation failed: %v", err)}},
+	var baselineWarnings []string
+	if req.CostGrowthLimit != nil {
+		estReq.Policies = append(estReq.Policies, policy.Policy{
+			ID:        "api-cost-growth",
+			Name:      "Cost Growth",
+			Type:      policy.PolicyTypeCostGrowth,
+			Severity:  policy.SeverityError,
+			Threshold: *req.CostGrowthLimit,
+			Enabled:   true,
+		})
+
+		estReq.Baseline = req.Baseline
+		if estReq.Baseline == nil && req.Project != "" {
+			record, err := s.pricingStore.LatestEstimation(ctx, req.Project, req.BaselineBranch)
+			if err != nil {
+				baselineWarnings = append(baselineWarnings, fmt.Sprintf("baseline lookup failed: %v", err))
+			} else if record != nil {
+				estReq.Baseline = policy.BaselineFromRecord(record)
+			}
 		}
+		if estReq.Baseline == nil {
+			baselineWarnings = append(baselineWarnings, "no baseline estimate; cost growth not evaluated")
+		}
+	}
+
+	// Decompose, predict usage, price and evaluate policy
+	run, err := s.estimatorFor(ctx).Estimate(ctx, plan, estReq)
+	if err != nil {
+		return nil, internalError("%v", err)
 	}
+	graph, estResult, policyResult := run.Graph, run.Estimation, run.Policy
+	// Plan warnings name what parsing left out, e.g. attributes set from variables
+	estResult.Warnings = append(append(estResult.Warnings, plan.Warnings...), baselineWarnings...)
 
 	// Build response
 	resp := s.buildEstimateResponse(estResult, policyResult, graph)
-	s.jsonResponse(w, http.StatusOK, resp)
+	resp.Issues = run.Issues
+	resp.PotentialSavings = run.Optimization.MonthlySavings.StringFixed(2)
+	resp.Recommendations = run.Optimization.Recommendations
+
+	// Save to history; failures are reported but don't fail the estimate
+	if req.Project != "" && s.pricingStore != nil {
+		record, err := estimation.NewEstimationRecord(estResult, estimation.HistoryMeta{
+			Project:       req.Project,
+			Branch:        req.Branch,
+			CommitSHA:     req.CommitSHA,
+			PullRequest:   req.PullRequest,
+			Environment:   req.Environment,
+			Source:        "api",
+			ResourceCount: graph.ResourceCount,
+			PolicyResult:  string(policyResult.Decision),
+		})
+		if err == nil {
+			err = s.pricingStore.SaveEstimation(ctx, record)
+		}
+		if err != nil {
+			resp.EstimationWarnings = append(resp.EstimationWarnings, fmt.Sprintf("estimation not saved to history: %v", err))
+		} else {
+			resp.EstimationID = record.ID.String()
+		}
+	}
+
+	// Record in the audit log; failures are reported but don't fail the estimate
+	if s.config.AuditSigner != nil {
+		policySetHash := s.policyEngineFor(ctx).PolicySetHash(estReq.Policies)
+		id, err := s.recordAudit(ctx, req, plan, estResult, policyResult, policySetHash, graph.ResourceCount, resp.EstimationID)
+		if err != nil {
+			resp.EstimationWarnings = append(resp.EstimationWarnings, fmt.Sprintf("estimate not recorded in audit log: %v", err))
+		} else {
+			resp.AuditID = id.String()
+		}
+	}
+
+	// Notify chat channels; failures are reported but don't fail the estimate
+	if req.Notify && len(s.config.Notifiers) > 0 {
+		notifyCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		report := integrations.NewReport(req.Project, estResult, policyResult, estReq.Baseline)
+		for _, err := range notify.Send(notifyCtx, s.config.Notifiers, report) {
+			resp.EstimationWarnings = append(resp.EstimationWarnings, fmt.Sprintf("notification failed: %v", err))
+		}
+		cancel()
+	}
+
+	// Export cost metrics; failures are reported but don't fail the estimate
+	if req.Project != "" && len(s.config.Metrics) > 0 {
+		exportCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		estimate := metrics.Estimate{Project: req.Project, Environment: req.Environment, Result: estResult}
+		for _, err := range metrics.ExportAll(exportCtx, s.config.Metrics, estimate) {
+			resp.EstimationWarnings = append(resp.EstimationWarnings, fmt.Sprintf("metrics export failed: %v", err))
+		}
+		cancel()
+	}
+
+	// Persist for retrieval by ID; failures are reported but don't fail the estimate
+	if req.Persist {
+		if err := s.persistEstimate(ctx, req, &resp); err != nil {
+			resp.EstimationWarnings = append(resp.EstimationWarnings, fmt.Sprintf("estimate not persisted: %v", err))
+		}
+	}
+
+	// Open an approval for a deny; failures are reported but don't fail the estimate
+	if req.RequestApproval && policyResult.Decision == policy.DecisionDeny {
+		violations := make([]string, len(policyResult.Violations))
+		for i, v := range policyResult.Violations {
+			violations[i] = v.Message
+		}
+		approval, warnings, err := s.requestApproval(ctx, ApprovalRequest{
+			Project:        req.Project,
+			Environment:    req.Environment,
+			Branch:         req.Branch,
+			CommitSHA:      req.CommitSHA,
+			PullRequest:    req.PullRequest,
+			EstimationID:   resp.EstimationID,
+			Currency:       estResult.Currency,
+			MonthlyCostP50: estResult.MonthlyCostP50,
+			Violations:     violations,
+		})
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("approval not requested: %v", err))
+		} else {
+			resp.ApprovalID = approval.ID.String()
+		}
+		resp.EstimationWarnings = append(resp.EstimationWarnings, warnings...)
+	}
+
+	s.emitEvents(ctx, req, resultEvents(requestID, &resp, started)...)
+	telemetry.Logger(ctx).Info("estimate completed",
+		"components", estResult.ComponentsProcessed,
+		"monthly_cost_p50", estResult.MonthlyCostP50.StringFixed(2),
+		"currency", estResult.Currency,
+		"policy_decision", resp.PolicyResult,
+		"duration_ms", time.Since(started).Milliseconds(),
+	)
+	return &resp, nil
 }
 
 func (s *Server) buildEstimateResponse(est *estimation.EstimationResult, pol *policy.EvaluationResult, graph *iac.Graph) EstimateResponse {
 	// Convert cost drivers
 	drivers := make([]CostDriverResponse, len(est.CostDrivers))
 	for i, d := range est.CostDrivers {
-		drivers[i] = CostDriverResponse{
-			ID:             d.ID,
-			ResourceAddr:   d.ResourceAddr,
-			Service:        d.Service,
-			ProductFamily:  d.ProductFamily,
-			Region:         d.Region,
-			Description:    d.Description,
-			MonthlyCostP50: d.MonthlyCostP50.StringFixed(2),
-			MonthlyCostP90: d.MonthlyCostP90.StringFixed(2),
-			Formula:        d.Formula,
-			Confidence:     d.Confidence,
-			IsSymbolic:     d.IsSymbolic,
-			Reason:         d.Reason,
+		drivers[i] = costDriverResponse(d)
+	}
+
+	groups := make([]CostGroupResponse, len(est.CostGroups))
+	for i, g := range est.CostGroups {
+		groups[i] = CostGroupResponse{
+			Key:            g.Key,
+			ResourceAddr:   g.ResourceAddr,
+			Service:        g.Service,
+			Description:    g.Description,
+			Quantity:       g.Quantity,
+			UnitCostP50:    g.UnitCostP50.StringFixed(2),
+			MonthlyCostP50: g.MonthlyCostP50.StringFixed(2),
+			MonthlyCostP90: g.MonthlyCostP90.StringFixed(2),
+			IsSymbolic:     g.IsSymbolic,
+			Instances:      g.Instances,
+		}
+	}
+
+	var pricingDate string
+	if est.AuditTrail.PricingDate != nil {
+		pricingDate = est.AuditTrail.PricingDate.Format(time.RFC3339)
+	}
+
+	var costByTag map[string]map[string]string
+	if len(est.CostByTag) > 0 {
+		costByTag = make(map[string]map[string]string, len(est.CostByTag))
+		for key, byValue := range est.CostByTag {
+			costByTag[key] = make(map[string]string, len(byValue))
+			for value, cost := range byValue {
+				costByTag[key][value] = cost.StringFixed(2)
+			}
 		}
 	}
 
@@ -388,7 +1019,10 @@ func (s *Server) buildEstimateResponse(est *estimation.EstimationResult, pol *po
 		HourlyCostP50:       est.HourlyCostP50.StringFixed(4),
 		CarbonKgCO2:         est.CarbonKgCO2,
 		Confidence:          est.Confidence,
+		ConfidenceScores:    est.ConfidenceScores,
 		IsIncomplete:        est.IsIncomplete,
+		EstimationWarnings:  est.Warnings,
+		Currency:            est.Currency,
 		ResourceCount:       graph.ResourceCount,
 		ComponentsEstimated: est.ComponentsEstimated,
 		ComponentsSymbolic:  est.ComponentsSymbolic,
@@ -396,7 +1030,19 @@ func (s *Server) buildEstimateResponse(est *estimation.EstimationResult, pol *po
 		Violations:          pol.Violations,
 		Warnings:            pol.Warnings,
 		CostDrivers:         drivers,
+		CostGroups:          groups,
+		CostByTag:           costByTag,
+		CostByModule:        moduleCostResponses(est.CostByModule),
+		Simulation:          est.Simulation,
+		FreeTier:            est.FreeTier,
+		FirstMonth:          est.FirstMonth,
 		EstimatedAt:         est.AuditTrail.EstimatedAt.Format(time.RFC3339),
+		RequestID:           est.AuditTrail.RequestID,
+		PricingDate:         pricingDate,
+		PricingAlias:        est.AuditTrail.PricingAlias,
+		Timebase:            est.AuditTrail.Timebase,
+		HoursPerMonth:       est.AuditTrail.HoursPerMonth,
+		RegionAliases:       est.AuditTrail.RegionAliases,
 		SnapshotsUsed:       snapshots,
 	}
 }
@@ -405,21 +1051,61 @@ func (s *Server) buildEstimateResponse(est *estimation.EstimationResult, pol *po
 // POLICY ENDPOINT
 // =============================================================================
 
+// PolicyEvaluationResponse is the policy decision for a plan and the costs it was based on
+type PolicyEvaluationResponse struct {
+	PolicyResult   string             `json:"policy_result"`
+	Violations     []policy.Violation `json:"violations"`
+	Warnings       []policy.Warning   `json:"warnings"`
+	Currency       string             `json:"currency"`
+	MonthlyCostP50 string             `json:"monthly_cost_p50"`
+	MonthlyCostP90 string             `json:"monthly_cost_p90"`
+	Confidence     float64            `json:"confidence"`
+}
+
+// handlePolicyEvaluate estimates a plan and returns only the policy decision
 func (s *Server) handlePolicyEvaluate(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		s.jsonError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 
-	// This endpoint evaluates policies against an existing estimation
-	// For now, redirect to /api/v1/estimate which includes policy evaluation
-	s.jsonError(w, http.StatusNotImplemented, "use /api/v1/estimate for policy evaluation")
+	req, ok := s.decodeEstimateRequest(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := s.Estimate(r.Context(), req)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	s.jsonResponse(w, http.StatusOK, PolicyEvaluationResponse{
+		PolicyResult:   resp.PolicyResult,
+		Violations:     resp.Violations,
+		Warnings:       resp.Warnings,
+		Currency:       resp.Currency,
+		MonthlyCostP50: resp.MonthlyCostP50,
+		MonthlyCostP90: resp.MonthlyCostP90,
+		Confidence:     resp.Confidence,
+	})
 }
 
 // =============================================================================
 // SNAPSHOT ENDPOINT
 // =============================================================================
 
+// SnapshotResponse is a pricing snapshot in list responses
+type SnapshotResponse struct {
+	ID        string `json:"id"`
+	Cloud     string `json:"cloud"`
+	Region    string `json:"region"`
+	Source    string `json:"source"`
+	Hash      string `json:"hash"` // Prefix of the content hash
+	IsActive  bool   `json:"is_active"`
+	FetchedAt string `json:"fetched_at"`
+	CreatedAt string `json:"created_at"`
+}
+
 func (s *Server) handleListSnapshots(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		s.jsonError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -436,6 +1122,12 @@ func (s *Server) handleListSnapshots(w http.ResponseWriter, r *http.Request) {
 		region = "us-east-1"
 	}
 
+	offset, limit, err := parsePage(r.URL.Query())
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
 	ctx := r.Context()
 	snapshots, err := s.pricingStore.ListSnapshots(ctx, clickhouse.CloudProvider(cloud), region)
 	if err != nil {
@@ -443,15 +1135,11 @@ func (s *Server) handleListSnapshots(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	type SnapshotResponse struct {
-		ID        string `json:"id"`
-		Cloud     string `json:"cloud"`
-		Region    string `json:"region"`
-		Source    string `json:"source"`
-		Hash      string `json:"hash"`
-		IsActive  bool   `json:"is_active"`
-		FetchedAt string `json:"fetched_at"`
-		CreatedAt string `json:"created_at"`
+	// Without a limit every snapshot is returned
+	snapshots = snapshots[min(offset, len(snapshots)):]
+	if limit > 0 && len(snapshots) > limit {
+		snapshots = snapshots[:limit]
+		setNextOffset(w, offset+limit)
 	}
 
 	resp := make([]SnapshotResponse, len(snapshots))
@@ -471,6 +1159,139 @@ func (s *Server) handleListSnapshots(w http.ResponseWriter, r *http.Request) {
 	s.jsonResponse(w, http.StatusOK, resp)
 }
 
+// =============================================================================
+// ESTIMATION HISTORY ENDPOINTS
+// =============================================================================
+
+// EstimationSummaryResponse is a saved estimation in list responses
+type EstimationSummaryResponse struct {
+	ID             string  `json:"id"`
+	Project        string  `json:"project"`
+	Branch         string  `json:"branch,omitempty"`
+	CommitSHA      string  `json:"commit_sha,omitempty"`
+	PullRequest    string  `json:"pull_request,omitempty"`
+	Environment    string  `json:"environment,omitempty"`
+	Source         string  `json:"source"`
+	MonthlyCostP50 string  `json:"monthly_cost_p50"`
+	MonthlyCostP90 string  `json:"monthly_cost_p90"`
+	CarbonKgCO2    float64 `json:"carbon_kg_co2"`
+	Confidence     float64 `json:"confidence"`
+	IsIncomplete   bool    `json:"is_incomplete"`
+	ResourceCount  int     `json:"resource_count"`
+	PolicyResult   string  `json:"policy_result"`
+	CreatedAt      string  `json:"created_at"`
+}
+
+func (s *Server) handleListEstimates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.jsonError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	q := r.URL.Query()
+	filter := clickhouse.EstimationFilter{
+		Project:     q.Get("project"),
+		Branch:      q.Get("branch"),
+		Environment: q.Get("environment"),
+	}
+	if filter.Project == "" {
+		s.jsonError(w, http.StatusBadRequest, "project is required")
+		return
+	}
+	if err := authorizeProject(r.Context(), filter.Project); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	offset, limit, err := parsePage(q)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	filter.Offset, filter.Limit = offset, clickhouse.EstimationLimit(limit)
+
+	records, err := s.pricingStore.ListEstimations(r.Context(), filter)
+	if err != nil {
+		s.jsonError(w, http.StatusInternalServerError, fmt.Sprintf("failed to list estimates: %v", err))
+		return
+	}
+	if len(records) == filter.Limit {
+		setNextOffset(w, offset+filter.Limit)
+	}
+
+	resp := make([]EstimationSummaryResponse, len(records))
+	for i, rec := range records {
+		resp[i] = EstimationSummaryResponse{
+			ID:             rec.ID.String(),
+			Project:        rec.Project,
+			Branch:         rec.Branch,
+			CommitSHA:      rec.CommitSHA,
+			PullRequest:    rec.PullRequest,
+			Environment:    rec.Environment,
+			Source:         rec.Source,
+			MonthlyCostP50: rec.MonthlyCostP50.StringFixed(2),
+			MonthlyCostP90: rec.MonthlyCostP90.StringFixed(2),
+			CarbonKgCO2:    rec.CarbonKgCO2,
+			Confidence:     rec.Confidence,
+			IsIncomplete:   rec.IsIncomplete,
+			ResourceCount:  rec.ResourceCount,
+			PolicyResult:   rec.PolicyResult,
+			CreatedAt:      rec.CreatedAt.Format(time.RFC3339),
+		}
+	}
+
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleEstimateTrend(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.jsonError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	q := r.URL.Query()
+	project := q.Get("project")
+	if project == "" {
+		s.jsonError(w, http.StatusBadRequest, "project is required")
+		return
+	}
+	if err := authorizeProject(r.Context(), project); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	interval := q.Get("interval")
+	switch interval {
+	case "":
+		interval = "day"
+	case "day", "week", "month":
+	default:
+		s.jsonError(w, http.StatusBadRequest, "interval must be day, week or month")
+		return
+	}
+	days := 90
+	if v := q.Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			s.jsonError(w, http.StatusBadRequest, "days must be a positive integer")
+			return
+		}
+		days = n
+	}
+
+	since := time.Now().UTC().AddDate(0, 0, -days)
+	points, err := s.pricingStore.GetEstimationTrend(r.Context(), project, interval, since)
+	if err != nil {
+		s.jsonError(w, http.StatusInternalServerError, fmt.Sprintf("failed to query trend: %v", err))
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"project":  project,
+		"interval": interval,
+		"since":    since.Format(time.RFC3339),
+		"points":   points,
+	})
+}
+
 // =============================================================================
 // HELPERS
 // =============================================================================
@@ -481,12 +1302,46 @@ func (s *Server) jsonResponse(w http.ResponseWriter, status int, data interface{
 	json.NewEncoder(w).Encode(data)
 }
 
+// NextOffsetHeader is set on paginated list responses that may have more results;
+// it is the offset of the next page
+const NextOffsetHeader = "X-Next-Offset"
+
+// parsePage reads the offset and limit query parameters of a list request; 0 is no limit
+func parsePage(q url.Values) (offset, limit int, err error) {
+	if v := q.Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			return 0, 0, badRequest("offset must be a non-negative integer")
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			return 0, 0, badRequest("limit must be a positive integer")
+		}
+	}
+	return offset, limit, nil
+}
+
+// setNextOffset tells the client where the next page starts
+func setNextOffset(w http.ResponseWriter, next int) {
+	w.Header().Set(NextOffsetHeader, strconv.Itoa(next))
+}
+
+// jsonError writes an error raised by status alone; its code follows from the status
 func (s *Server) jsonError(w http.ResponseWriter, status int, message string) {
-	s.jsonResponse(w, status, map[string]string{
-		"error": message,
+	s.jsonResponse(w, status, tcerrors.Response{
+		Error:     message,
+		Code:      tcerrors.CodeForStatus(status),
+		RequestID: w.Header().Get(telemetry.RequestIDHeader),
 	})
 }
 
+// writeError writes an error with the status and code it carries
+func (s *Server) writeError(w http.ResponseWriter, err error) {
+	resp := tcerrors.NewResponse(err)
+	resp.RequestID = w.Header().Get(telemetry.RequestIDHeader)
+	s.jsonResponse(w, ErrorStatus(err), resp)
+}
+
 // Unused but required for imports
 var _ = uuid.Nil
 var _ = decimal.Zero
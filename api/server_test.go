@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"terraform-cost/db/memory"
+	tcerrors "terraform-cost/pkg/errors"
+	"terraform-cost/pkg/terracost"
+)
+
+const testPlan = `{"format_version":"1.2","terraform_version":"1.6.0",
+"resource_changes":[
+ {"address":"aws_instance.web","mode":"managed","type":"aws_instance","name":"web","provider_name":"registry.terraform.io/hashicorp/aws",
+  "change":{"actions":["create"],"before":null,"after":{"instance_type":"t3.micro","ami":"ami-0123456789abcdef0"}}}
+],
+"configuration":{"provider_config":{"aws":{"name":"aws","expressions":{"region":{"constant_value":"eu-west-1"}}}}}}`
+
+// newTestServer creates a server without ClickHouse whose estimates price nothing
+func newTestServer(config *Config) *Server {
+	s := NewServer(nil, config)
+	s.estimator = terracost.NewEstimator(memory.New()).WithPolicyEngine(s.policyEngine).WithEnricher(config.Enricher)
+	return s
+}
+
+func TestEstimateUnresolvedVariables(t *testing.T) {
+	config := `
+variable "size" {}
+
+# Declared but referenced by nothing priced
+variable "owner" {}
+
+resource "aws_instance" "web" {
+  instance_type = var.size
+}
+`
+	plan, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newTestServer(&Config{})
+	estimate := func(variables map[string]interface{}) (*EstimateResponse, error) {
+		return s.Estimate(context.Background(), EstimateRequest{Plan: plan, PlanFormat: "hcl", Environment: "prod", Variables: variables})
+	}
+
+	// Without variables, unresolved references are a warning
+	resp, err := estimate(nil)
+	if err != nil {
+		t.Fatalf("without variables: %v", err)
+	}
+	if !hasWarning(resp.EstimationWarnings, "unresolved variables: size;") {
+		t.Errorf("warnings = %v, want one naming only size", resp.EstimationWarnings)
+	}
+
+	// Variables that leave out a referenced variable are rejected
+	_, err = estimate(map[string]interface{}{"owner": "ops"})
+	if tcerrors.CodeOf(err) != tcerrors.CodeInvalidRequest || !strings.Contains(err.Error(), "unresolved variables: size;") {
+		t.Errorf("missing size: err = %v, want an invalid request naming size", err)
+	}
+
+	// The unused variable never needs a value
+	resp, err = estimate(map[string]interface{}{"size": "m5.large"})
+	if err != nil {
+		t.Fatalf("with size: %v", err)
+	}
+	if hasWarning(resp.EstimationWarnings, "unresolved variables") {
+		t.Errorf("warnings = %v, want no unresolved variables", resp.EstimationWarnings)
+	}
+}
+
+func hasWarning(warnings []string, substr string) bool {
+	for _, w := range warnings {
+		if strings.Contains(w, substr) {
+			return true
+		}
+	}
+	return false
+}
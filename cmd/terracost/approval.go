@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"terraform-cost/api"
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/pkg/client"
+	tcerrors "terraform-cost/pkg/errors"
+)
+
+// =============================================================================
+// APPROVALS
+// With --wait-for-approval, a policy deny requests an approval from the server
+// and blocks until a reviewer decides it: approved runs pass the deny gate,
+// rejected or expired ones fail it.
+// =============================================================================
+
+// approvalPollInterval is how often --wait-for-approval checks for a decision
+const approvalPollInterval = 15 * time.Second
+
+// waitForApproval requests an approval for a denied run and waits for its decision
+// It returns the run's issues without the policy denials once approved.
+func waitForApproval(c *cli.Context, run *estimateRun) ([]tcerrors.Issue, error) {
+	project := c.String("project")
+	if project == "" {
+		return nil, fmt.Errorf("--wait-for-approval needs --project")
+	}
+	endpoint := c.String("approval-endpoint")
+	if endpoint == "" {
+		endpoint = c.String("pricing-endpoint")
+	}
+	if endpoint == "" {
+		return nil, fmt.Errorf("--wait-for-approval needs --approval-endpoint or --pricing-endpoint")
+	}
+
+	req := api.ApprovalRequest{
+		Project:        project,
+		Environment:    c.String("env"),
+		Branch:         c.String("branch"),
+		CommitSHA:      c.String("commit"),
+		PullRequest:    c.String("pr"),
+		Link:           c.String("notify-link"),
+		Currency:       run.result.Currency,
+		MonthlyCostP50: run.result.MonthlyCostP50,
+		Violations:     make([]string, 0, len(run.policyResult.Violations)),
+	}
+	for _, v := range run.policyResult.Violations {
+		req.Violations = append(req.Violations, v.Message)
+	}
+
+	server := client.New(endpoint, c.String("pricing-api-key")).WithUserAgent("terracost/" + version)
+	a, err := server.RequestApproval(c.Context, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request approval: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "⏳ Policy denied; waiting for approval %s (expires %s)\n", a.ID, a.ExpiresAt.Format(time.RFC3339))
+
+	ctx, cancel := context.WithTimeout(c.Context, c.Duration("approval-timeout"))
+	defer cancel()
+	id := a.ID
+	if a, err = server.WaitForApproval(ctx, id.String(), approvalPollInterval); err != nil {
+		return nil, tcerrors.New(tcerrors.CodePolicyDeny, "approval %s was not decided: %v", id, err)
+	}
+
+	switch a.Status {
+	case clickhouse.ApprovalApproved:
+		fmt.Fprintf(os.Stderr, "✅ Approved by %s%s\n", a.DecidedBy, approvalComment(a))
+		issues := make([]tcerrors.Issue, 0, len(run.issues))
+		for _, issue := range run.issues {
+			if issue.Code != tcerrors.CodePolicyDeny {
+				issues = append(issues, issue)
+			}
+		}
+		return issues, nil
+	case clickhouse.ApprovalRejected:
+		return nil, tcerrors.New(tcerrors.CodePolicyDeny, "approval %s rejected by %s%s", a.ID, a.DecidedBy, approvalComment(a))
+	default:
+		return nil, tcerrors.New(tcerrors.CodePolicyDeny, "approval %s %s", a.ID, a.Status)
+	}
+}
+
+// approvalComment formats a reviewer's comment for messages
+func approvalComment(a *clickhouse.Approval) string {
+	if a.Comment == "" {
+		return ""
+	}
+	return ": " + a.Comment
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/urfave/cli/v2"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/budget"
+	"terraform-cost/decision/currency"
+	"terraform-cost/decision/estimation"
+)
+
+// =============================================================================
+// BUDGET BURN-DOWN
+// With --budget, or stored budgets covering --project, the estimate's monthly
+// cost is projected over the rest of the fiscal year to show when each budget
+// runs out.
+// =============================================================================
+
+// budgetBurnDowns projects an estimate against the --budget flag and the project's stored budgets
+// Stored budget lookup failures are reported and skipped.
+func budgetBurnDowns(ctx context.Context, c *cli.Context, store *clickhouse.Store, project, env string, result *estimation.EstimationResult) ([]*budget.BurnDown, error) {
+	startMonth := c.Int("fiscal-year-start")
+	if startMonth < 1 || startMonth > 12 {
+		return nil, fmt.Errorf("invalid --fiscal-year-start %d: expected a month from 1 to 12", startMonth)
+	}
+	now := time.Now().UTC()
+	period := budget.FiscalYear(now, time.Month(startMonth))
+
+	burnDowns := make([]*budget.BurnDown, 0)
+	if amount := c.Float64("budget"); amount > 0 {
+		burnDowns = append(burnDowns, budget.NewBurnDown("--budget", result.Currency,
+			decimal.NewFromFloat(amount), decimal.NewFromFloat(c.Float64("budget-spent")),
+			result.MonthlyCostP50, period, now))
+	}
+	if store != nil && project != "" {
+		projections, notes, err := budget.NewTracker(store).Project(ctx, project, env, result)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Budget burn-down skipped stored budgets: %v\n", err)
+		}
+		for _, note := range notes {
+			fmt.Fprintf(os.Stderr, "⚠️  %s\n", note)
+		}
+		for _, p := range projections {
+			burnDowns = append(burnDowns, p.BurnDown(period, now))
+		}
+	}
+	return burnDowns, nil
+}
+
+// outputBurnDownMarkdown writes the projected spend of each budget by month
+func outputBurnDownMarkdown(burnDowns []*budget.BurnDown) {
+	for _, b := range burnDowns {
+		fmt.Println()
+		fmt.Printf("### 📉 Budget Burn-down: %s\n", b.Name)
+		fmt.Println()
+		fmt.Printf("Fiscal year %s to %s: %s budget, %s spent before %s.\n",
+			b.Period.Start.Format("2006-01-02"), b.Period.End.AddDate(0, 0, -1).Format("2006-01-02"),
+			currency.Format(b.Budget, b.Currency, 2), currency.Format(b.Spent, b.Currency, 2), b.AsOf.Format("2006-01-02"))
+		fmt.Printf("Burn rate **%s/month** (%s/day).", currency.Format(b.MonthlyBurn, b.Currency, 2),
+			currency.Format(b.DailyBurn, b.Currency, 2))
+		if b.ExhaustedOn != nil {
+			fmt.Printf(" ⚠️ Budget exhausted on **%s**, %s over by year end.\n",
+				b.ExhaustedOn.Format("2006-01-02"), currency.Format(b.ProjectedTotal.Sub(b.Budget), b.Currency, 2))
+		} else {
+			fmt.Printf(" ✅ Budget lasts the year with %s left.\n", currency.Format(b.Budget.Sub(b.ProjectedTotal), b.Currency, 2))
+		}
+		fmt.Println()
+		fmt.Println("| Month | Projected | Cumulative | Remaining |")
+		fmt.Println("|-------|-----------|------------|-----------|")
+		for _, m := range b.Months {
+			fmt.Printf("| %s | %s | %s | %s |\n", m.Month, currency.Format(m.Projected, b.Currency, 2),
+				currency.Format(m.Cumulative, b.Currency, 2), currency.Format(m.Remaining, b.Currency, 2))
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"terraform-cost/decision/iac"
+	"terraform-cost/integrations"
+	"terraform-cost/integrations/ci"
+	"terraform-cost/integrations/metrics"
+	"terraform-cost/integrations/notify"
+)
+
+// =============================================================================
+// CI COMMAND
+// One step for any pipeline: detect the CI system, find the plan, estimate and
+// evaluate policy, then annotate the job and set outputs in the system's own
+// formats. Exit codes follow --fail-on, --exit-code and --soft-fail.
+// =============================================================================
+
+// ciExcludedFlags are estimate flags that don't apply to a CI run
+var ciExcludedFlags = map[string]bool{
+	"explain": true,
+}
+
+func ciCommand() *cli.Command {
+	var flags []cli.Flag
+	for _, f := range estimateFlags() {
+		if !ciExcludedFlags[f.Names()[0]] {
+			flags = append(flags, f)
+		}
+	}
+	return &cli.Command{
+		Name:  "ci",
+		Usage: "Estimate a plan in CI: annotate the job and set step outputs (GitHub Actions, GitLab CI, CircleCI, Azure Pipelines)",
+		Flags: append(flags,
+			&cli.StringFlag{
+				Name:    "provider",
+				Usage:   "CI system (github, gitlab, circleci, azure; default: detected from the environment)",
+				EnvVars: []string{"TERRACOST_CI_PROVIDER"},
+			},
+			&cli.StringFlag{
+				Name:  "codequality-report",
+				Value: ci.DefaultCodeQualityFile,
+				Usage: "GitLab Code Quality report to write (artifacts:reports:codequality)",
+			},
+			&cli.StringFlag{
+				Name:  "dotenv-report",
+				Value: ci.DefaultDotenvFile,
+				Usage: "GitLab dotenv report of the outputs to write (artifacts:reports:dotenv)",
+			},
+			&cli.StringFlag{
+				Name:    "format",
+				Aliases: []string{"f"},
+				Value:   "table",
+				Usage:   "Log output format (table, json, markdown)",
+			},
+		),
+		Action: runCI,
+	}
+}
+
+func runCI(c *cli.Context) error {
+	env := ci.Detect(os.Getenv)
+	if name := c.String("provider"); name != "" {
+		provider, err := ci.ParseProvider(name)
+		if err != nil {
+			return err
+		}
+		env = ci.Load(provider, os.Getenv)
+	}
+	if env == nil {
+		return fmt.Errorf("no CI environment detected; pass --provider")
+	}
+	fmt.Fprintf(os.Stderr, "🤖 Running in %s\n", env.Provider)
+
+	gate, err := newExitGate(c)
+	if err != nil {
+		return err
+	}
+	notifiers, err := notify.ParseAll(c.StringSlice("notify"))
+	if err != nil {
+		return err
+	}
+	exporters, err := metrics.ParseAll(c.StringSlice("export-metrics"))
+	if err != nil {
+		return err
+	}
+
+	// Record the job's branch, commit and pull request with saved estimates
+	for flag, value := range map[string]string{"branch": env.Branch, "commit": env.Commit, "pr": env.PullRequest, "notify-link": env.Link} {
+		if !c.IsSet(flag) && value != "" {
+			if err := c.Set(flag, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	in := pipelineInput{format: c.String("plan-format"), input: c.String("plan"), project: c.String("project"), env: c.String("env")}
+	switch {
+	case c.String("path") != "":
+		in.format, in.input = iac.FormatHCL, c.String("path")
+	case in.input == "":
+		if in.input, err = ci.FindPlan(env.Workspace); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "📄 Using plan %s\n", in.input)
+	}
+	run, err := runPipelineFor(c, in)
+	if err != nil {
+		return err
+	}
+
+	title := in.project
+	if title == "" {
+		title = env.Repository
+	}
+	if title == "" {
+		title = in.input
+	}
+	report := integrations.NewReport(title, run.result, run.policyResult, run.baseline)
+	report.Link = c.String("notify-link")
+	for _, err := range notify.Send(c.Context, notifiers, report) {
+		fmt.Fprintf(os.Stderr, "⚠️  Notification failed: %v\n", err)
+	}
+	exportMetrics(c, exporters, metrics.Estimate{Project: title, Environment: in.env, Result: run.result})
+
+	switch c.String("format") {
+	case "json":
+		err = outputJSON(run, 0)
+	case "markdown":
+		err = outputMarkdown(run.result, run.policyResult, run.optimization, run.diff, run.burnDowns, 0)
+	default:
+		err = outputTable(run.result, run.policyResult, run.diff, defaultTableDrivers)
+	}
+	if err != nil {
+		return err
+	}
+
+	publisher := ci.NewPublisher(env.Provider, os.Getenv, os.Stdout)
+	if gitlab, ok := publisher.(*ci.GitLab); ok {
+		gitlab.CodeQualityFile, gitlab.DotenvFile = c.String("codequality-report"), c.String("dotenv-report")
+	}
+	if err := publisher.Publish(ci.NewResult(report, run.issues, workspacePath(env.Workspace, in.input))); err != nil {
+		return err
+	}
+	return gate.check(run.issues)
+}
+
+// outputAzureDevOps prints the table followed by Azure Pipelines logging
+// commands: task issues, output variables and an uploaded run summary
+func outputAzureDevOps(c *cli.Context, run *estimateRun, top int) error {
+	if err := outputTable(run.result, run.policyResult, run.diff, top); err != nil {
+		return err
+	}
+	env := ci.Load(ci.AzurePipelines, os.Getenv)
+	report := integrations.NewReport(reportTitle(c), run.result, run.policyResult, run.baseline)
+	report.Link = c.String("notify-link")
+	if report.Link == "" {
+		report.Link = env.Link
+	}
+	input := c.String("plan")
+	if input == "" {
+		input = c.String("path")
+	}
+	publisher := ci.NewPublisher(ci.AzurePipelines, os.Getenv, os.Stdout)
+	return publisher.Publish(ci.NewResult(report, run.issues, workspacePath(env.Workspace, input)))
+}
+
+// workspacePath returns a path relative to the workspace, as annotations
+// expect; paths outside it are returned unchanged
+func workspacePath(workspace, path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	root, err := filepath.Abs(workspace)
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
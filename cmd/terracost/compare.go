@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/urfave/cli/v2"
+
+	"terraform-cost/decision/currency"
+	"terraform-cost/decision/iac"
+)
+
+// =============================================================================
+// COMPARE COMMAND
+// Runs one plan under several environment usage profiles side by side, to show
+// what a stack would cost deployed as dev, staging or prod.
+// =============================================================================
+
+// compareExcludedFlags are estimate flags that don't apply to a comparison:
+// the environment varies, and comparisons are neither saved nor sent
+var compareExcludedFlags = map[string]bool{
+	"env":            true,
+	"project":        true,
+	"branch":         true,
+	"commit":         true,
+	"pr":             true,
+	"notify":         true,
+	"notify-link":    true,
+	"export-metrics": true,
+	"include-carbon": true,
+	"explain":        true,
+	"exit-code":      true,
+	"soft-fail":      true,
+}
+
+func compareCommand() *cli.Command {
+	var flags []cli.Flag
+	for _, f := range estimateFlags() {
+		if !compareExcludedFlags[f.Names()[0]] {
+			flags = append(flags, f)
+		}
+	}
+	return &cli.Command{
+		Name:  "compare",
+		Usage: "Compare a plan's cost and carbon across environment usage profiles",
+		Flags: append(flags,
+			&cli.StringSliceFlag{
+				Name:  "envs",
+				Value: cli.NewStringSlice("dev", "staging", "prod"),
+				Usage: "Environments to compare (comma-separated or repeatable)",
+			},
+			&cli.BoolFlag{
+				Name:  "include-carbon",
+				Value: true,
+				Usage: "Include carbon emissions per environment",
+			},
+			&cli.StringFlag{
+				Name:    "format",
+				Aliases: []string{"f"},
+				Value:   "table",
+				Usage:   "Output format (table, json, markdown)",
+			},
+		),
+		Action: runCompare,
+	}
+}
+
+// envComparison is one environment's estimate in a comparison
+type envComparison struct {
+	Environment    string          `json:"environment"`
+	MonthlyCostP50 decimal.Decimal `json:"monthly_cost_p50"`
+	MonthlyCostP90 decimal.Decimal `json:"monthly_cost_p90"`
+	CarbonKgCO2    float64         `json:"carbon_kg_co2"`
+	Confidence     float64         `json:"confidence"`
+	PolicyResult   string          `json:"policy_result,omitempty"`
+}
+
+func runCompare(c *cli.Context) error {
+	format, input := c.String("plan-format"), c.String("plan")
+	if c.String("path") != "" {
+		format, input = iac.FormatHCL, c.String("path")
+	}
+	if input == "" {
+		return fmt.Errorf("either --plan or --path is required")
+	}
+
+	var envs []string
+	for _, v := range c.StringSlice("envs") {
+		for _, env := range strings.Split(v, ",") {
+			if env = strings.TrimSpace(env); env != "" {
+				envs = append(envs, env)
+			}
+		}
+	}
+	if len(envs) == 0 {
+		return fmt.Errorf("--envs needs at least one environment")
+	}
+
+	var cur string
+	comparisons := make([]envComparison, len(envs))
+	for i, env := range envs {
+		fmt.Fprintf(os.Stderr, "\n── %s\n", env)
+		run, err := runPipelineFor(c, pipelineInput{format: format, input: input, env: env})
+		if err != nil {
+			return fmt.Errorf("environment %s: %w", env, err)
+		}
+		cur = run.result.Currency
+		comparisons[i] = envComparison{
+			Environment:    env,
+			MonthlyCostP50: run.result.MonthlyCostP50,
+			MonthlyCostP90: run.result.MonthlyCostP90,
+			CarbonKgCO2:    run.result.CarbonKgCO2,
+			Confidence:     run.result.Confidence,
+		}
+		if run.policyResult != nil {
+			comparisons[i].PolicyResult = string(run.policyResult.Decision)
+		}
+	}
+
+	switch c.String("format") {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]interface{}{
+			"currency":     cur,
+			"environments": comparisons,
+		})
+	case "markdown":
+		outputCompareMarkdown(comparisons, cur)
+	default:
+		outputCompareTable(comparisons, cur)
+	}
+	return nil
+}
+
+// relativeCost is a cost against the first environment's, e.g. "+150%"
+func relativeCost(cost, base decimal.Decimal) string {
+	if base.IsZero() {
+		return "—"
+	}
+	pct := cost.Sub(base).Div(base).Mul(decimal.NewFromInt(100))
+	if pct.IsZero() {
+		return "—"
+	}
+	return fmt.Sprintf("%+.0f%%", pct.InexactFloat64())
+}
+
+func outputCompareTable(comparisons []envComparison, cur string) {
+	base := comparisons[0]
+	fmt.Println()
+	fmt.Printf("%-14s  %14s  %14s  %12s  %8s  %10s\n", "ENVIRONMENT", "MONTHLY P50", "MONTHLY P90", "CARBON (KG)", "CONF.", "VS "+strings.ToUpper(truncate(base.Environment, 7)))
+	fmt.Println(strings.Repeat("─", 80))
+	for _, cmp := range comparisons {
+		fmt.Printf("%-14s  %14s  %14s  %12.2f  %7.0f%%  %10s\n",
+			truncate(cmp.Environment, 14),
+			currency.Format(cmp.MonthlyCostP50, cur, 2),
+			currency.Format(cmp.MonthlyCostP90, cur, 2),
+			cmp.CarbonKgCO2,
+			cmp.Confidence*100,
+			relativeCost(cmp.MonthlyCostP50, base.MonthlyCostP50))
+	}
+}
+
+func outputCompareMarkdown(comparisons []envComparison, cur string) {
+	base := comparisons[0]
+	fmt.Println("## 🔀 TerraCost Environment Comparison")
+	fmt.Println()
+	fmt.Printf("| Environment | Monthly Cost (P50) | Monthly Cost (P90) | Carbon | Confidence | vs %s |\n", base.Environment)
+	fmt.Println("|-------------|--------------------|--------------------|--------|------------|------|")
+	for _, cmp := range comparisons {
+		fmt.Printf("| %s | %s | %s | %.2f kg CO2 | %.0f%% | %s |\n", cmp.Environment,
+			currency.Format(cmp.MonthlyCostP50, cur, 2), currency.Format(cmp.MonthlyCostP90, cur, 2),
+			cmp.CarbonKgCO2, cmp.Confidence*100, relativeCost(cmp.MonthlyCostP50, base.MonthlyCostP50))
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+)
+
+// =============================================================================
+// DATABASE COMMAND
+// `terracost db migrate` applies the ClickHouse schema migrations embedded in
+// the binary; `terracost db status` lists which ones the database has.
+// =============================================================================
+
+func dbCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "db",
+		Usage: "Manage the ClickHouse schema",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "migrate",
+				Usage: "Apply pending schema migrations",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "List pending migrations without applying them",
+					},
+				},
+				Action: runDBMigrate,
+			},
+			{
+				Name:   "status",
+				Usage:  "List schema migrations and when each was applied",
+				Action: runDBStatus,
+			},
+		},
+	}
+}
+
+func runDBMigrate(c *cli.Context) error {
+	store, err := openStore(c)
+	if err != nil {
+		return fmt.Errorf("failed to connect to ClickHouse: %w", err)
+	}
+	defer store.Close()
+
+	if c.Bool("dry-run") {
+		statuses, err := store.MigrationStatus(c.Context)
+		if err != nil {
+			return err
+		}
+		pending := 0
+		for _, s := range statuses {
+			if s.AppliedAt == nil {
+				fmt.Printf("  %03d_%s\n", s.Version, s.Name)
+				pending++
+			}
+		}
+		fmt.Printf("%d pending migrations (dry run, nothing applied)\n", pending)
+		return nil
+	}
+
+	applied, err := store.Migrate(c.Context)
+	for _, m := range applied {
+		fmt.Printf("  applied %03d_%s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		fmt.Println("Schema is up to date")
+		return nil
+	}
+	fmt.Printf("Applied %d migrations\n", len(applied))
+	return nil
+}
+
+func runDBStatus(c *cli.Context) error {
+	store, err := openStore(c)
+	if err != nil {
+		return fmt.Errorf("failed to connect to ClickHouse: %w", err)
+	}
+	defer store.Close()
+
+	statuses, err := store.MigrationStatus(c.Context)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%-8s %-24s %s\n", "VERSION", "NAME", "APPLIED")
+	for _, s := range statuses {
+		applied := "pending"
+		if s.AppliedAt != nil {
+			applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			if s.Modified {
+				applied += " (file changed since)"
+			}
+		}
+		fmt.Printf("%03d      %-24s %s\n", s.Version, truncate(s.Name, 24), applied)
+	}
+	return nil
+}
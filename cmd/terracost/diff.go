@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"terraform-cost/decision/currency"
+	"terraform-cost/pkg/terracost"
+)
+
+// =============================================================================
+// DIFF OUTPUT
+// With --diff, deleted resources leave the estimate and are reported as
+// savings, next to the one-time churn of the resources the plan replaces.
+// =============================================================================
+
+// outputDiffTable writes the deletion and replacement sections of table output
+func outputDiffTable(diff *terracost.Diff) {
+	fmt.Printf("║  %-59s ║\n", "SAVINGS FROM DELETIONS")
+	fmt.Println("╠══════════════════════════════════════════════════════════════╣")
+	for _, d := range diff.Deletions {
+		fmt.Printf("║  %-35s  %-21s ║\n", truncate(d.Address, 35), "-"+currency.Format(d.MonthlyCostP50, diff.Currency, 2)+"/mo")
+	}
+	fmt.Printf("║  %-35s  %-21s ║\n", "Monthly savings", currency.Format(diff.MonthlySavingsFromDeletions, diff.Currency, 2))
+	fmt.Println("╠══════════════════════════════════════════════════════════════╣")
+
+	fmt.Printf("║  %-59s ║\n", "REPLACEMENT CHURN (ONE-TIME)")
+	fmt.Println("╠══════════════════════════════════════════════════════════════╣")
+	for _, r := range diff.Replacements {
+		fmt.Printf("║  %-35s  %-21s ║\n", truncate(r.Address, 35), currency.Format(r.OneTimeCost, diff.Currency, 2))
+		for _, charge := range r.Charges {
+			cost := currency.Format(charge.Cost, diff.Currency, 2)
+			if charge.Unpriced {
+				cost = "unpriced"
+			}
+			fmt.Printf("║    %-33s  %-21s ║\n", truncate(charge.Description, 33), cost)
+		}
+	}
+	fmt.Printf("║  %-35s  %-21s ║\n", "Replacement churn", currency.Format(diff.ReplacementChurnCost, diff.Currency, 2))
+	fmt.Println("╠══════════════════════════════════════════════════════════════╣")
+}
+
+// outputDiffMarkdown writes the deletion and replacement sections of markdown output
+func outputDiffMarkdown(diff *terracost.Diff) {
+	fmt.Println()
+	fmt.Println("### 🗑️ Savings from Deletions")
+	fmt.Println()
+	if len(diff.Deletions) == 0 {
+		fmt.Println("No resources are deleted.")
+	} else {
+		fmt.Printf("**%s/month** saved by deleting %d resource(s).\n\n", currency.Format(diff.MonthlySavingsFromDeletions, diff.Currency, 2), len(diff.Deletions))
+		fmt.Println("| Resource | Type | Monthly Cost (P50) |")
+		fmt.Println("|----------|------|--------------------|")
+		for _, d := range diff.Deletions {
+			cost := currency.Format(d.MonthlyCostP50, diff.Currency, 2)
+			if d.Unpriced > 0 {
+				cost += fmt.Sprintf(" (+%d unpriced)", d.Unpriced)
+			}
+			fmt.Printf("| %s | %s | %s |\n", d.Address, d.ResourceType, cost)
+		}
+	}
+
+	fmt.Println()
+	fmt.Println("### 🔁 Replacement Churn")
+	fmt.Println()
+	if len(diff.Replacements) == 0 {
+		fmt.Println("No resources are replaced.")
+		return
+	}
+	fmt.Printf("**%s** one-time cost of replacing %d resource(s); their monthly cost is in the estimate.\n\n",
+		currency.Format(diff.ReplacementChurnCost, diff.Currency, 2), len(diff.Replacements))
+	for _, r := range diff.Replacements {
+		order := "destroy, then create"
+		if r.CreateBeforeDestroy {
+			order = "create before destroy"
+		}
+		fmt.Printf("- **%s** (%s, %s", r.Address, r.ResourceType, order)
+		if len(r.ReplacedBy) > 0 {
+			fmt.Printf(", forced by `%s`", strings.Join(r.ReplacedBy, "`, `"))
+		}
+		fmt.Printf("): %s\n", currency.Format(r.OneTimeCost, diff.Currency, 2))
+		for _, charge := range r.Charges {
+			cost := currency.Format(charge.Cost, diff.Currency, 2)
+			if charge.Unpriced {
+				cost = "unpriced"
+			}
+			fmt.Printf("  - %s: %s — %s\n", charge.Description, cost, charge.Assumption)
+		}
+	}
+}
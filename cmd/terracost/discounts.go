@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+
+	"terraform-cost/decision/currency"
+	"terraform-cost/decision/estimation"
+)
+
+// =============================================================================
+// DISCOUNT OUTPUT
+// With --discounts, costs are net of negotiated discounts; list prices are
+// shown next to them so the savings of an agreement stay visible.
+// =============================================================================
+
+// outputDiscountsTable writes the list-price totals of table output
+func outputDiscountsTable(result *estimation.EstimationResult) {
+	fmt.Printf("║  List Cost (P50):       %-38s ║\n", currency.Format(result.Discounts.ListMonthlyCostP50, result.Currency, 2))
+	fmt.Printf("║  Discount Savings:      %-38s ║\n", currency.Format(result.Discounts.SavingsP50, result.Currency, 2))
+}
+
+// outputDiscountsMarkdown writes the list and net cost of every discounted driver
+func outputDiscountsMarkdown(result *estimation.EstimationResult) {
+	fmt.Println()
+	fmt.Println("### 🤝 Negotiated Discounts")
+	fmt.Println()
+	fmt.Printf("**%s/month** saved against list prices (%s, discounts from %s).\n\n",
+		currency.Format(result.Discounts.SavingsP50, result.Currency, 2),
+		currency.Format(result.Discounts.ListMonthlyCostP50, result.Currency, 2), result.Discounts.Source)
+	fmt.Println("| Resource | Cost Driver | Discount | List (P50) | Net (P50) |")
+	fmt.Println("|----------|-------------|----------|------------|-----------|")
+	for _, d := range result.CostDrivers {
+		if d.Discount == nil {
+			continue
+		}
+		fmt.Printf("| %s | %s | %s (%g%%) | %s | %s |\n", d.ResourceAddr, d.Description, d.Discount.Name, d.Discount.Percent,
+			currency.Format(d.Discount.ListMonthlyCostP50, result.Currency, 2), currency.Format(d.MonthlyCostP50, result.Currency, 2))
+	}
+}
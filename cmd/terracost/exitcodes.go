@@ -0,0 +1,173 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	tcerrors "terraform-cost/pkg/errors"
+)
+
+// =============================================================================
+// EXIT CODES
+// An estimate fails after its output when an issue it fails on is present.
+// --fail-on picks the gating threshold per pipeline stage (deny, warn or
+// incomplete) and any extra issue codes, --exit-code remaps the exit code of a
+// failure, and --soft-fail reports the failure but always exits 0.
+// =============================================================================
+
+// failOnLevels are the --fail-on thresholds; each fails on the stricter ones too
+var failOnLevels = map[string][]tcerrors.Code{
+	"deny":       {tcerrors.CodePolicyDeny},
+	"warn":       {tcerrors.CodePolicyDeny, tcerrors.CodePolicyWarn},
+	"incomplete": {tcerrors.CodePolicyDeny, tcerrors.CodePolicyWarn, tcerrors.CodeEstimateIncomplete},
+}
+
+// levelCodes are the issue codes --exit-code accepts decision names for
+var levelCodes = map[string]tcerrors.Code{
+	"deny":       tcerrors.CodePolicyDeny,
+	"warn":       tcerrors.CodePolicyWarn,
+	"incomplete": tcerrors.CodeEstimateIncomplete,
+}
+
+// exitGate decides which issues fail a run, and with which exit code
+type exitGate struct {
+	failOn    map[tcerrors.Code]bool
+	exitCodes map[tcerrors.Code]int // Overrides of Code.ExitCode
+	softFail  bool
+}
+
+// newExitGate builds the gate of --fail-on, --exit-code and --soft-fail
+// Policy denies fail the run unless --soft-fail or --exit-code deny=0 says otherwise.
+func newExitGate(c *cli.Context) (*exitGate, error) {
+	failOn, err := parseFailOn(c.StringSlice("fail-on"))
+	if err != nil {
+		return nil, err
+	}
+	exitCodes, err := parseExitCodes(c.StringSlice("exit-code"))
+	if err != nil {
+		return nil, err
+	}
+	return &exitGate{failOn: failOn, exitCodes: exitCodes, softFail: c.Bool("soft-fail")}, nil
+}
+
+// parseFailOn parses --fail-on thresholds and issue codes
+func parseFailOn(values []string) (map[tcerrors.Code]bool, error) {
+	known := make(map[tcerrors.Code]bool)
+	for _, code := range tcerrors.Codes() {
+		known[code] = true
+	}
+	failOn := map[tcerrors.Code]bool{tcerrors.CodePolicyDeny: true}
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if codes, ok := failOnLevels[strings.ToLower(value)]; ok {
+			for _, code := range codes {
+				failOn[code] = true
+			}
+			continue
+		}
+		code := tcerrors.Code(strings.ToUpper(value))
+		if !known[code] {
+			return nil, tcerrors.New(tcerrors.CodeInvalidRequest, "unknown --fail-on value %q (deny, warn, incomplete or an issue code)", value)
+		}
+		failOn[code] = true
+	}
+	return failOn, nil
+}
+
+// parseExitCodes parses --exit-code values: deny|warn|incomplete|CODE=N
+func parseExitCodes(values []string) (map[tcerrors.Code]int, error) {
+	known := make(map[tcerrors.Code]bool)
+	for _, code := range tcerrors.Codes() {
+		known[code] = true
+	}
+	exitCodes := make(map[tcerrors.Code]int)
+	for _, value := range values {
+		name, n, ok := strings.Cut(value, "=")
+		exit, err := strconv.Atoi(strings.TrimSpace(n))
+		if !ok || err != nil || exit < 0 || exit > 125 {
+			return nil, tcerrors.New(tcerrors.CodeInvalidRequest, "invalid --exit-code %q (deny|warn|incomplete|CODE=0..125)", value)
+		}
+		name = strings.TrimSpace(name)
+		code, ok := levelCodes[strings.ToLower(name)]
+		if !ok {
+			code = tcerrors.Code(strings.ToUpper(name))
+		}
+		if !known[code] {
+			return nil, tcerrors.New(tcerrors.CodeInvalidRequest, "unknown --exit-code %q (deny, warn, incomplete or an issue code)", name)
+		}
+		exitCodes[code] = exit
+	}
+	return exitCodes, nil
+}
+
+// failureRank orders the failures of a run: a deny decides the exit code
+// first, then issue codes in the order found, then warnings and incompleteness
+func failureRank(code tcerrors.Code) int {
+	switch code {
+	case tcerrors.CodePolicyDeny:
+		return 0
+	case tcerrors.CodePolicyWarn:
+		return 2
+	case tcerrors.CodeEstimateIncomplete:
+		return 3
+	default:
+		return 1
+	}
+}
+
+// check returns the error the run exits with, or nil to exit 0
+// Failures remapped to exit code 0 are ignored.
+func (g *exitGate) check(issues []tcerrors.Issue) error {
+	var failure *tcerrors.Issue
+	for i := range issues {
+		if g.failOn[issues[i].Code] && g.exit(issues[i].Code) != 0 &&
+			(failure == nil || failureRank(issues[i].Code) < failureRank(failure.Code)) {
+			failure = &issues[i]
+		}
+	}
+	if failure == nil {
+		return nil
+	}
+
+	exit := g.exit(failure.Code)
+	if g.softFail {
+		fmt.Fprintf(os.Stderr, "⚠️  Soft fail: would exit %d on [%s] %s\n", exit, failure.Code, failure.Message)
+		return nil
+	}
+	err := tcerrors.New(failure.Code, "%s", failure.Message)
+	if exit != failure.Code.ExitCode() {
+		return &exitError{err: err, code: exit}
+	}
+	return err
+}
+
+// exit returns the exit code of a failure
+func (g *exitGate) exit(code tcerrors.Code) int {
+	if exit, ok := g.exitCodes[code]; ok {
+		return exit
+	}
+	return code.ExitCode()
+}
+
+// exitError exits the CLI with an --exit-code instead of its code's default
+type exitError struct {
+	err  error
+	code int
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// exitCode returns the CLI exit code of an error
+func exitCode(err error) int {
+	var exit *exitError
+	if errors.As(err, &exit) {
+		return exit.code
+	}
+	return tcerrors.CodeOf(err).ExitCode()
+}
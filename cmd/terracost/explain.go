@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"terraform-cost/decision/currency"
+	"terraform-cost/pkg/terracost"
+)
+
+// =============================================================================
+// EXPLAIN OUTPUT
+// --explain <address> replaces the report with the derivation of one
+// resource's cost, stage by stage, for debugging a surprising number.
+// =============================================================================
+
+// outputExplanation writes a resource's cost derivation as JSON or text
+func outputExplanation(x *terracost.Explanation, format string) error {
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(x)
+	}
+
+	fmt.Printf("🔎 %s (%s)\n", x.Address, x.ResourceType)
+	fmt.Printf("   Provider: %s  Region: %s  Action: %s  Usage profile: %s\n",
+		orNone(x.Provider), orNone(x.Region), orNone(x.Action), orNone(x.Environment))
+
+	fmt.Println()
+	fmt.Println("1. Mapper")
+	switch {
+	case x.Mapper == "":
+		fmt.Println("   (none: resource type is not supported)")
+	case x.FallbackMapper:
+		fmt.Printf("   %s (fallback)\n", x.Mapper)
+	default:
+		fmt.Printf("   %s\n", x.Mapper)
+	}
+	for _, me := range x.MappingErrors {
+		fmt.Printf("   ⚠️  %s\n", me.Reason)
+	}
+
+	fmt.Println()
+	fmt.Println("2. Attributes extracted")
+	names := make([]string, 0, len(x.Attributes))
+	for name := range x.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("   %s = %v\n", name, x.Attributes[name])
+	}
+	if len(x.UnsetAttributes) > 0 {
+		fmt.Printf("   unset: %s\n", strings.Join(x.UnsetAttributes, ", "))
+	}
+
+	fmt.Println()
+	fmt.Printf("3. Components emitted (%d)\n", len(x.Components))
+	for _, c := range x.Components {
+		fmt.Println()
+		fmt.Printf("   ▸ %s — %s\n", c.ID, c.Description)
+		fmt.Printf("     Billing: %s", c.BillingPeriod)
+		if c.PurchaseOption != "" {
+			fmt.Printf(", %s", c.PurchaseOption)
+		}
+		fmt.Println()
+		if c.Hardware != nil {
+			fmt.Printf("     Hardware: %d vCPU, %g GiB\n", c.Hardware.VCPU, c.Hardware.MemoryGiB)
+		}
+
+		fmt.Printf("     Usage: P50 %g, P90 %g %s (confidence %.0f%%)\n",
+			c.Usage.P50Usage, c.Usage.P90Usage, c.RateKey.Unit, c.Usage.Confidence*100)
+		for _, a := range c.Usage.Assumptions {
+			fmt.Printf("       assumes: %s\n", a)
+		}
+
+		key := c.RateKey
+		fmt.Printf("     Rate key: %s/%s/%s/%s alias=%s unit=%s\n", key.Cloud, key.Service, key.ProductFamily, key.Region, key.Alias, key.Unit)
+		attrs := make([]string, 0, len(key.Attributes))
+		for k, v := range key.Attributes {
+			attrs = append(attrs, k+"="+v)
+		}
+		sort.Strings(attrs)
+		if len(attrs) > 0 {
+			fmt.Printf("       %s\n", strings.Join(attrs, " "))
+		}
+
+		d := c.Driver
+		if d == nil {
+			fmt.Println("     Not estimated")
+			continue
+		}
+		switch {
+		case d.IsSymbolic:
+			fmt.Printf("     Snapshot: none (%s)\n", orNone(d.Reason))
+		case d.SnapshotID != uuid.Nil:
+			fmt.Printf("     Snapshot: %s", d.SnapshotID)
+			if d.MatchedVia != "" {
+				fmt.Printf(" (matched via %s)", d.MatchedVia)
+			}
+			fmt.Println()
+		}
+		fmt.Printf("     Unit price: %s\n", d.UnitPrice)
+		if d.Formula != "" {
+			fmt.Printf("     Formula: %s\n", d.Formula)
+		}
+		fmt.Printf("     Monthly: %s (P90 %s)\n", currency.Format(d.MonthlyCostP50, x.Currency, 2), currency.Format(d.MonthlyCostP90, x.Currency, 2))
+		fmt.Printf("     Confidence: %.0f%% (pricing %.0f%%, usage %.0f%%), %.1f%% of the score\n",
+			d.Confidence*100, d.PricingConfidence*100, d.UsageConfidence*100, c.ConfidenceWeight*100)
+	}
+
+	fmt.Println()
+	fmt.Printf("Total: %s/month (P90 %s), %.1f%% of the estimate's confidence score\n",
+		currency.Format(x.MonthlyCostP50, x.Currency, 2), currency.Format(x.MonthlyCostP90, x.Currency, 2), x.ConfidenceWeight*100)
+	return nil
+}
+
+// orNone shows empty values as "-"
+func orNone(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/urfave/cli/v2"
+
+	"terraform-cost/decision/currency"
+	"terraform-cost/decision/estimation"
+	"terraform-cost/decision/iac"
+)
+
+// =============================================================================
+// GRAPH COMMAND
+// Renders the dependency graph with each resource's monthly cost, for
+// architecture reviews and docs
+// =============================================================================
+
+func graphCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "graph",
+		Usage: "Render the infrastructure dependency graph annotated with cost (Mermaid or DOT)",
+		Flags: append(estimateFlags(),
+			&cli.StringFlag{
+				Name:    "format",
+				Aliases: []string{"f"},
+				Value:   "mermaid",
+				Usage:   "Output format (mermaid, dot)",
+			},
+			&cli.StringFlag{
+				Name:    "out",
+				Aliases: []string{"o"},
+				Usage:   "Output file (default: stdout)",
+			},
+		),
+		Action: runGraph,
+	}
+}
+
+func runGraph(c *cli.Context) error {
+	var render func(io.Writer, *costGraph) error
+	switch c.String("format") {
+	case "mermaid":
+		render = renderMermaid
+	case "dot":
+		render = renderDOT
+	default:
+		return fmt.Errorf("unknown graph format %q (mermaid, dot)", c.String("format"))
+	}
+	// The graph only shows cost
+	if err := c.Set("skip-policy", "true"); err != nil {
+		return err
+	}
+
+	run, err := runPipeline(c)
+	if err != nil {
+		return err
+	}
+	g := buildCostGraph(run.graph, run.result)
+
+	out := io.Writer(os.Stdout)
+	if path := c.String("out"); path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create graph file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := render(out, g); err != nil {
+		return err
+	}
+	if path := c.String("out"); path != "" {
+		fmt.Fprintf(os.Stderr, "🕸️  Graph written to %s\n", path)
+	}
+	return nil
+}
+
+// costBand classifies a resource's monthly P50 cost for coloring
+type costBand string
+
+const (
+	bandUnpriced costBand = "unpriced" // No cost drivers, or none could be priced
+	bandFree     costBand = "free"
+	bandLow      costBand = "low"    // Under 10
+	bandMedium   costBand = "medium" // Under 100
+	bandHigh     costBand = "high"   // Under 1000
+	bandCritical costBand = "critical"
+)
+
+// costBands lists bands in legend order with their fill and stroke colors
+var costBands = []struct {
+	band         costBand
+	fill, stroke string
+}{
+	{bandUnpriced, "#eeeeee", "#999999"},
+	{bandFree, "#e8f5e9", "#66bb6a"},
+	{bandLow, "#c8e6c9", "#388e3c"},
+	{bandMedium, "#fff3cd", "#f0ad4e"},
+	{bandHigh, "#ffd8b1", "#e67e22"},
+	{bandCritical, "#f8d7da", "#c0392b"},
+}
+
+// bandFor returns the band of a monthly cost
+func bandFor(cost decimal.Decimal, priced bool) costBand {
+	switch {
+	case !priced:
+		return bandUnpriced
+	case cost.IsZero():
+		return bandFree
+	case cost.LessThan(decimal.NewFromInt(10)):
+		return bandLow
+	case cost.LessThan(decimal.NewFromInt(100)):
+		return bandMedium
+	case cost.LessThan(decimal.NewFromInt(1000)):
+		return bandHigh
+	default:
+		return bandCritical
+	}
+}
+
+// costGraph is the dependency graph with costs, in a stable order for rendering
+type costGraph struct {
+	currency string
+	nodes    []costNode
+	edges    [][2]string // Dependent ID -> dependency ID
+}
+
+type costNode struct {
+	id      string
+	address string
+	module  string
+	cost    decimal.Decimal
+	band    costBand
+}
+
+// label is a node's display text
+func (n costNode) label(code string) string {
+	if n.band == bandUnpriced {
+		return n.address + "\n(not priced)"
+	}
+	return n.address + "\n" + currency.Format(n.cost, code, 2) + "/mo"
+}
+
+// buildCostGraph totals each resource's cost drivers onto the dependency graph
+func buildCostGraph(graph *iac.Graph, result *estimation.EstimationResult) *costGraph {
+	type total struct {
+		cost   decimal.Decimal
+		priced bool
+	}
+	totals := make(map[string]*total)
+	for _, d := range result.CostDrivers {
+		t, ok := totals[d.ResourceAddr]
+		if !ok {
+			t = &total{}
+			totals[d.ResourceAddr] = t
+		}
+		t.cost = t.cost.Add(d.MonthlyCostP50)
+		t.priced = t.priced || !d.IsSymbolic
+	}
+
+	addrs := make([]string, 0, len(graph.Nodes))
+	for addr := range graph.Nodes {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+
+	g := &costGraph{currency: result.Currency}
+	ids := make(map[string]string, len(addrs))
+	for i, addr := range addrs {
+		ids[addr] = fmt.Sprintf("n%d", i)
+		node := costNode{id: ids[addr], address: addr, module: graph.Nodes[addr].Resource.Module, band: bandUnpriced}
+		if t, ok := totals[addr]; ok {
+			node.cost = t.cost
+			node.band = bandFor(t.cost, t.priced)
+		}
+		g.nodes = append(g.nodes, node)
+	}
+	for _, addr := range addrs {
+		deps := append([]string(nil), graph.Nodes[addr].Dependencies...)
+		sort.Strings(deps)
+		for _, dep := range deps {
+			if id, ok := ids[dep]; ok {
+				g.edges = append(g.edges, [2]string{ids[addr], id})
+			}
+		}
+	}
+	return g
+}
+
+// byModule groups nodes by module path, root module first
+func (g *costGraph) byModule() ([]string, map[string][]costNode) {
+	groups := make(map[string][]costNode)
+	for _, n := range g.nodes {
+		groups[n.module] = append(groups[n.module], n)
+	}
+	modules := make([]string, 0, len(groups))
+	for m := range groups {
+		modules = append(modules, m)
+	}
+	sort.Strings(modules) // "" (root) sorts first
+	return modules, groups
+}
+
+// renderMermaid writes a Mermaid flowchart; modules are subgraphs
+func renderMermaid(w io.Writer, g *costGraph) error {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for _, band := range costBands {
+		fmt.Fprintf(&b, "  classDef %s fill:%s,stroke:%s\n", band.band, band.fill, band.stroke)
+	}
+
+	modules, groups := g.byModule()
+	for i, module := range modules {
+		indent := "  "
+		if module != "" {
+			fmt.Fprintf(&b, "  subgraph m%d[\"%s\"]\n", i, mermaidEscape(module))
+			indent = "    "
+		}
+		for _, n := range groups[module] {
+			label := strings.ReplaceAll(mermaidEscape(n.label(g.currency)), "\n", "<br/>")
+			fmt.Fprintf(&b, "%s%s[\"%s\"]:::%s\n", indent, n.id, label, n.band)
+		}
+		if module != "" {
+			b.WriteString("  end\n")
+		}
+	}
+	for _, e := range g.edges {
+		fmt.Fprintf(&b, "  %s --> %s\n", e[0], e[1])
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mermaidEscape escapes text inside a quoted Mermaid label
+func mermaidEscape(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
+
+// renderDOT writes a Graphviz digraph; modules are clusters
+func renderDOT(w io.Writer, g *costGraph) error {
+	fills := make(map[costBand][2]string, len(costBands))
+	for _, band := range costBands {
+		fills[band.band] = [2]string{band.fill, band.stroke}
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph terracost {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=\"rounded,filled\", fontname=\"Helvetica\"];\n")
+
+	modules, groups := g.byModule()
+	for i, module := range modules {
+		indent := "  "
+		if module != "" {
+			fmt.Fprintf(&b, "  subgraph cluster_%d {\n    label=%s;\n", i, dotQuote(module))
+			indent = "    "
+		}
+		for _, n := range groups[module] {
+			colors := fills[n.band]
+			fmt.Fprintf(&b, "%s%s [label=%s, fillcolor=%q, color=%q];\n", indent, n.id, dotQuote(n.label(g.currency)), colors[0], colors[1])
+		}
+		if module != "" {
+			b.WriteString("  }\n")
+		}
+	}
+	for _, e := range g.edges {
+		fmt.Fprintf(&b, "  %s -> %s;\n", e[0], e[1])
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dotQuote quotes a DOT string; newlines become line breaks
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + strings.ReplaceAll(s, "\n", `\n`) + `"`
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"time"
+
+	"terraform-cost/decision/estimation"
+	"terraform-cost/decision/policy"
+)
+
+// =============================================================================
+// JUNIT OUTPUT
+// Policies render as test cases and unmapped resource types as skipped tests,
+// so CI systems show cost governance in their test report UIs.
+// =============================================================================
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Skipped    int              `xml:"skipped,attr"`
+	Timestamp  string           `xml:"timestamp,attr,omitempty"`
+	Properties *junitProperties `xml:"properties,omitempty"`
+	Cases      []junitTestCase  `xml:"testcase"`
+}
+
+type junitProperties struct {
+	Property []junitProperty `xml:"property"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+func outputJUnit(result *estimation.EstimationResult, policyResult *policy.EvaluationResult, uncoveredTypes []string) error {
+	policies := junitTestSuite{
+		Name:      "terracost.policies",
+		Timestamp: result.AuditTrail.EstimatedAt.Format(time.RFC3339),
+		Properties: &junitProperties{Property: []junitProperty{
+			{Name: "monthly_cost_p50", Value: result.MonthlyCostP50.StringFixed(2)},
+			{Name: "monthly_cost_p90", Value: result.MonthlyCostP90.StringFixed(2)},
+			{Name: "confidence", Value: fmt.Sprintf("%.2f", result.Confidence)},
+		}},
+	}
+	if policyResult != nil {
+		policies.Properties.Property = append(policies.Properties.Property,
+			junitProperty{Name: "decision", Value: string(policyResult.Decision)})
+		for _, check := range policyResult.Checks {
+			tc := junitTestCase{
+				Name:      fmt.Sprintf("%s (%s)", check.PolicyName, check.PolicyID),
+				ClassName: "terracost.policy",
+			}
+			switch check.Decision {
+			case policy.DecisionDeny:
+				tc.Failure = &junitMessage{Message: check.Message, Type: "PolicyViolation", Text: check.Message}
+				policies.Failures++
+			case policy.DecisionWarn:
+				// Warnings don't fail the build; keep them visible in the report
+				tc.SystemOut = "WARNING: " + check.Message
+			}
+			policies.Cases = append(policies.Cases, tc)
+		}
+	}
+	policies.Tests = len(policies.Cases)
+
+	coverage := junitTestSuite{Name: "terracost.coverage"}
+	for _, t := range uncoveredTypes {
+		coverage.Cases = append(coverage.Cases, junitTestCase{
+			Name:      t,
+			ClassName: "terracost.coverage",
+			Skipped:   &junitMessage{Message: "no mapper registered for resource type; cost not estimated"},
+		})
+	}
+	coverage.Tests = len(coverage.Cases)
+	coverage.Skipped = len(coverage.Cases)
+
+	suites := junitTestSuites{
+		Name:     "terracost",
+		Tests:    policies.Tests + coverage.Tests,
+		Failures: policies.Failures,
+		Skipped:  coverage.Skipped,
+		Suites:   []junitTestSuite{policies, coverage},
+	}
+
+	fmt.Print(xml.Header)
+	enc := xml.NewEncoder(os.Stdout)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return fmt.Errorf("failed to write JUnit XML: %w", err)
+	}
+	fmt.Println()
+	return nil
+}
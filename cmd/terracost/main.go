@@ -10,19 +10,51 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"github.com/urfave/cli/v2"
 
 	"terraform-cost/api"
+	grpcapi "terraform-cost/api/grpc"
 	"terraform-cost/db/clickhouse"
+	"terraform-cost/db/embedded"
+	"terraform-cost/db/ingestion"
+	"terraform-cost/db/memory"
+	"terraform-cost/decision/anomaly"
+	"terraform-cost/decision/audit"
+	"terraform-cost/decision/awsmeta"
 	"terraform-cost/decision/billing"
-	"terraform-cost/decision/billing/mappers/aws"
+	"terraform-cost/decision/billing/plugin"
+	"terraform-cost/decision/calibration"
+	"terraform-cost/decision/currency"
+	"terraform-cost/decision/discount"
+	"terraform-cost/decision/budget"
+	"terraform-cost/decision/carbon"
 	"terraform-cost/decision/estimation"
 	"terraform-cost/decision/iac"
+	"terraform-cost/decision/optimize"
 	"terraform-cost/decision/policy"
+	"terraform-cost/decision/usage"
+	"terraform-cost/internal/cron"
+	"terraform-cost/internal/health"
+	"terraform-cost/integrations"
+	"terraform-cost/integrations/events"
+	"terraform-cost/integrations/metrics"
+	"terraform-cost/integrations/notify"
+	"terraform-cost/pkg/client"
+	tcerrors "terraform-cost/pkg/errors"
+	"terraform-cost/pkg/terracost"
+	"terraform-cost/telemetry"
 )
 
 var (
@@ -44,6 +76,12 @@ func main() {
 				Usage:   "Log level (debug, info, warn, error)",
 				EnvVars: []string{"TERRACOST_LOG_LEVEL"},
 			},
+			&cli.StringFlag{
+				Name:    "log-format",
+				Value:   telemetry.LogFormatConsole,
+				Usage:   "Log format (console for key=value lines, json for log pipelines)",
+				EnvVars: []string{"TERRACOST_LOG_FORMAT"},
+			},
 			&cli.StringFlag{
 				Name:    "clickhouse-host",
 				Value:   "localhost",
@@ -74,20 +112,275 @@ func main() {
 				Usage:   "ClickHouse password",
 				EnvVars: []string{"CLICKHOUSE_PASSWORD"},
 			},
+			&cli.StringFlag{
+				Name:    "pricing-backend",
+				Value:   "clickhouse",
+				Usage:   "Pricing source for estimates: clickhouse, embedded (offline bundle from 'pricing export'), api (a TerraCost server; default when --pricing-endpoint is set) or fixture (prices from --pricing-fixtures)",
+				EnvVars: []string{"TERRACOST_PRICING_BACKEND"},
+			},
+			&cli.StringFlag{
+				Name:    "pricing-file",
+				Usage:   "Pricing bundle path for --pricing-backend embedded",
+				EnvVars: []string{"TERRACOST_PRICING_FILE"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "pricing-fixtures",
+				Usage:   "Fixture price files (.json or .csv) for --pricing-backend fixture, for tests and demos",
+				EnvVars: []string{"TERRACOST_PRICING_FIXTURES"},
+			},
+			&cli.StringFlag{
+				Name:    "pricing-endpoint",
+				Usage:   "TerraCost server resolving prices for --pricing-backend api (https://pricing.internal)",
+				EnvVars: []string{"TERRACOST_PRICING_ENDPOINT"},
+			},
+			&cli.StringFlag{
+				Name:    "pricing-api-key",
+				Usage:   "API key or bearer token for --pricing-endpoint",
+				EnvVars: []string{"TERRACOST_API_KEY"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "pricing-alias",
+				Usage:   "Pricing alias rates resolve under: <alias>, <environment>=<alias> or partition:<partition>=<alias>, e.g. prod-govcloud=aws-us-gov or partition:aws-cn=aws-cn (repeatable)",
+				EnvVars: []string{"TERRACOST_PRICING_ALIAS"},
+			},
+			&cli.StringFlag{
+				Name:    "pricing-cache-dir",
+				Usage:   "Disk cache of rates resolved through --pricing-endpoint (default: the user cache directory; \"off\" disables it)",
+				EnvVars: []string{"TERRACOST_PRICING_CACHE_DIR"},
+			},
+			&cli.IntFlag{
+				Name:    "rate-cache-size",
+				Value:   10000,
+				Usage:   "In-process resolved rate cache entries (0 disables caching)",
+				EnvVars: []string{"TERRACOST_RATE_CACHE_SIZE"},
+			},
+			&cli.DurationFlag{
+				Name:    "rate-cache-ttl",
+				Value:   clickhouse.DefaultRateCacheTTL,
+				Usage:   "Maximum lifetime of a cached rate",
+				EnvVars: []string{"TERRACOST_RATE_CACHE_TTL"},
+			},
+			&cli.StringFlag{
+				Name:    "redis-url",
+				Usage:   "Redis URL for a rate cache shared across processes (redis://host:6379/0)",
+				EnvVars: []string{"TERRACOST_REDIS_URL"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "carbon-source",
+				Usage:   "Carbon intensity sources in fallback order: electricitymaps, watttime, static (default: configured APIs, then static)",
+				EnvVars: []string{"TERRACOST_CARBON_SOURCES"},
+			},
+			&cli.StringFlag{
+				Name:    "electricity-maps-api-key",
+				Usage:   "Electricity Maps API key for live carbon intensity",
+				EnvVars: []string{"ELECTRICITY_MAPS_API_KEY"},
+			},
+			&cli.StringFlag{
+				Name:    "watttime-username",
+				Usage:   "WattTime account for marginal carbon intensity",
+				EnvVars: []string{"WATTTIME_USERNAME"},
+			},
+			&cli.StringFlag{
+				Name:    "watttime-password",
+				Usage:   "WattTime account password",
+				EnvVars: []string{"WATTTIME_PASSWORD"},
+			},
+			&cli.StringFlag{
+				Name:    "otlp-endpoint",
+				Usage:   "OTLP/HTTP trace collector host:port (tracing disabled when empty)",
+				EnvVars: []string{"TERRACOST_OTLP_ENDPOINT"},
+			},
+			&cli.BoolFlag{
+				Name:    "otlp-insecure",
+				Usage:   "Send traces over plain HTTP",
+				EnvVars: []string{"TERRACOST_OTLP_INSECURE"},
+			},
+			&cli.Float64Flag{
+				Name:    "trace-sample-ratio",
+				Value:   1,
+				Usage:   "Fraction of traces sampled",
+				EnvVars: []string{"TERRACOST_TRACE_SAMPLE_RATIO"},
+			},
+		},
+		
+		Before: func(c *cli.Context) error {
+			if _, err := telemetry.SetupLogging(telemetry.LogConfig{
+				Format: c.String("log-format"),
+				Level:  c.String("log-level"),
+			}); err != nil {
+				return err
+			}
+			// Calls to TerraCost servers carry the run's ID, so a failing CI run
+			// can be found in the server's logs
+			c.Context = telemetry.WithRequestID(c.Context, uuid.NewString())
+			return setupTracing(c)
+		},
+		After: func(c *cli.Context) error {
+			if shutdownTracing == nil {
+				return nil
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			return shutdownTracing(ctx)
 		},
 		
 		Commands: []*cli.Command{
 			estimateCommand(),
+			compareCommand(),
+			whatifCommand(),
+			verifyCommand(),
+			ciCommand(),
+			reportCommand(),
+			graphCommand(),
+			reconcileCommand(),
 			serveCommand(),
+			tokenCommand(),
+			redactCommand(),
 			pricingCommand(),
+			dbCommand(),
 			policyCommand(),
+			optimizeCommand(),
 		},
 	}
 	
 	if err := app.Run(os.Args); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		code := tcerrors.CodeOf(err)
+		fmt.Fprintf(os.Stderr, "Error [%s]: %v\n", code, err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// loadDiscounts loads --discounts; 'database' reads them from the ClickHouse store
+func loadDiscounts(source string, store *clickhouse.Store) (discount.Provider, error) {
+	if store == nil {
+		return discount.Load(source, nil)
+	}
+	return discount.Load(source, store)
+}
+
+// openCarbonStore composes the configured carbon intensity sources
+func openCarbonStore(c *cli.Context) (carbon.CarbonStore, error) {
+	return carbon.NewCarbonStoreFromConfig(carbon.Config{
+		Sources:               c.StringSlice("carbon-source"),
+		ElectricityMapsAPIKey: c.String("electricity-maps-api-key"),
+		WattTimeUsername:      c.String("watttime-username"),
+		WattTimePassword:      c.String("watttime-password"),
+	})
+}
+
+// openStore connects to ClickHouse with the configured rate cache
+func openStore(c *cli.Context) (*clickhouse.Store, error) {
+	store, err := clickhouse.NewStore(&clickhouse.Config{
+		Host:     c.String("clickhouse-host"),
+		Port:     c.Int("clickhouse-port"),
+		Database: c.String("clickhouse-database"),
+		Username: c.String("clickhouse-user"),
+		Password: c.String("clickhouse-password"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	size := c.Int("rate-cache-size")
+	redisURL := c.String("redis-url")
+	if size <= 0 && redisURL == "" {
+		return store, nil
+	}
+
+	var cache clickhouse.RateCache = clickhouse.NewLRUCache(size)
+	if redisURL != "" {
+		redisCache, err := clickhouse.NewRedisCache(redisURL)
+		if err != nil {
+			store.Close()
+			return nil, err
+		}
+		cache = redisCache
+		if size > 0 {
+			cache = clickhouse.NewTieredCache(clickhouse.NewLRUCache(size), redisCache)
+		}
+	}
+	return store.WithRateCache(cache, c.Duration("rate-cache-ttl")), nil
+}
+
+// errNoHistory is reported when estimation history is requested without ClickHouse
+var errNoHistory = fmt.Errorf("estimation history requires --pricing-backend clickhouse")
+
+// openPricingStore opens the pricing backend selected by --pricing-backend
+// The ClickHouse store is also returned for history; it is nil for other backends.
+func openPricingStore(c *cli.Context) (estimation.PricingStore, *clickhouse.Store, func(), error) {
+	backend := c.String("pricing-backend")
+	if !c.IsSet("pricing-backend") && c.String("pricing-endpoint") != "" {
+		backend = "api"
+	}
+	switch backend {
+	case "clickhouse", "":
+		store, err := openStore(c)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to connect to ClickHouse: %w", err)
+		}
+		return store, store, func() { store.Close() }, nil
+	case "embedded":
+		path := c.String("pricing-file")
+		if path == "" {
+			return nil, nil, nil, fmt.Errorf("--pricing-file is required with --pricing-backend embedded")
+		}
+		bundle, err := embedded.Open(path)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		info := bundle.Info()
+		fmt.Fprintf(os.Stderr, "📦 Using pricing bundle %s (%d rates, built %s)\n",
+			path, info.RateCount, info.BuiltAt.Format("2006-01-02"))
+		return bundle, nil, func() { bundle.Close() }, nil
+	case "api":
+		endpoint := c.String("pricing-endpoint")
+		if endpoint == "" {
+			return nil, nil, nil, fmt.Errorf("--pricing-endpoint is required with --pricing-backend api")
+		}
+		store := client.NewPricingStore(client.New(endpoint, c.String("pricing-api-key")).WithUserAgent("terracost/" + version))
+		switch dir := c.String("pricing-cache-dir"); dir {
+		case "off":
+		case "":
+			store.WithCacheDir(client.DefaultCacheDir(endpoint))
+		default:
+			store.WithCacheDir(dir)
+		}
+		fmt.Fprintf(os.Stderr, "🌐 Using pricing from %s\n", endpoint)
+		return store, nil, func() {}, nil
+	case "fixture":
+		paths := c.StringSlice("pricing-fixtures")
+		if len(paths) == 0 {
+			return nil, nil, nil, fmt.Errorf("--pricing-fixtures is required with --pricing-backend fixture")
+		}
+		store, err := memory.Load(paths...)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		rates, spot := store.Len()
+		fmt.Fprintf(os.Stderr, "🧪 Using pricing fixtures %s (%d rates, %d spot rates)\n",
+			strings.Join(paths, ", "), rates, spot)
+		return store, nil, func() {}, nil
+	default:
+		return nil, nil, nil, fmt.Errorf("unknown pricing backend %q (supported: clickhouse, embedded, api, fixture)", backend)
+	}
+}
+
+// shutdownTracing flushes spans when the app exits
+var shutdownTracing func(context.Context) error
+
+func setupTracing(c *cli.Context) error {
+	shutdown, err := telemetry.Setup(c.Context, telemetry.Config{
+		Endpoint:    c.String("otlp-endpoint"),
+		Insecure:    c.Bool("otlp-insecure"),
+		ServiceName: "terracost",
+		Version:     version,
+		SampleRatio: c.Float64("trace-sample-ratio"),
+	})
+	if err != nil {
+		return err
 	}
+	shutdownTracing = shutdown
+	return nil
 }
 
 // =============================================================================
@@ -98,252 +391,1140 @@ func estimateCommand() *cli.Command {
 	return &cli.Command{
 		Name:  "estimate",
 		Usage: "Estimate cost and carbon for a Terraform plan",
-		Flags: []cli.Flag{
-			&cli.StringFlag{
-				Name:     "plan",
-				Aliases:  []string{"p"},
-				Usage:    "Path to terraform plan JSON (from terraform show -json)",
-				Required: true,
-			},
-			&cli.StringFlag{
-				Name:    "env",
-				Aliases: []string{"e"},
-				Value:   "dev",
-				Usage:   "Environment (dev, staging, prod)",
-			},
+		Flags: append(estimateFlags(),
 			&cli.StringFlag{
 				Name:    "format",
 				Aliases: []string{"f"},
 				Value:   "table",
-				Usage:   "Output format (table, json, markdown)",
-			},
-			&cli.Float64Flag{
-				Name:  "cost-limit",
-				Usage: "Monthly cost limit for policy check",
+				Usage:   "Output format (table, json, markdown, junit, azure-devops)",
 			},
-			&cli.Float64Flag{
-				Name:  "carbon-budget",
-				Usage: "Carbon budget (kg CO2) for policy check",
+			&cli.IntFlag{
+				Name:  "top",
+				Usage: "Cost drivers to show, highest first (default: 5 in table output, all otherwise)",
 			},
 			&cli.BoolFlag{
-				Name:  "include-carbon",
-				Value: false,
-				Usage: "Include carbon emissions in output",
+				Name:  "show-all",
+				Usage: "Show every cost driver",
 			},
-			&cli.BoolFlag{
-				Name:  "include-formulas",
-				Value: false,
-				Usage: "Include cost formulas in output",
+			&cli.StringFlag{
+				Name:  "terragrunt-dir",
+				Usage: "Directory of plan JSON files from terragrunt run-all; estimates every stack instead of --plan",
 			},
-			&cli.BoolFlag{
-				Name:  "skip-policy",
-				Value: false,
-				Usage: "Skip policy evaluation",
+			&cli.StringFlag{
+				Name:  "terragrunt-config-dir",
+				Usage: "Terragrunt root whose terragrunt.hcl files declare stack dependencies (default: --terragrunt-dir)",
 			},
 			&cli.StringFlag{
-				Name:  "opa-endpoint",
-				Usage: "OPA endpoint for policy evaluation",
+				Name:  "plan-pattern",
+				Value: "*.json",
+				Usage: "File name pattern of the plans under --terragrunt-dir",
 			},
-		},
+			&cli.IntFlag{
+				Name:  "parallelism",
+				Value: 4,
+				Usage: "Plans estimated at once when --plan is a glob",
+			},
+		),
 		Action: runEstimate,
 	}
 }
 
+// estimateFlags are the input, usage and policy flags shared by estimate and report
+func estimateFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "plan",
+			Aliases:  []string{"p"},
+			Usage:    "Path to terraform plan JSON (from terraform show -json); estimate also takes a glob such as 'plans/*.json'",
+		},
+		&cli.StringFlag{
+			Name:  "path",
+			Usage: "Terraform configuration directory to estimate without a plan (best effort)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "var",
+			Usage: "Variable for --path estimation (name=value, repeatable)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "var-file",
+			Usage: "Variable file for --path estimation (repeatable)",
+		},
+		&cli.StringFlag{
+			Name:  "plan-format",
+			Value: "terraform",
+			Usage: "Plan input format (terraform, pulumi, cloudformation, hcl)",
+		},
+		&cli.StringFlag{
+			Name:  "changeset",
+			Usage: "CloudFormation change set JSON (describe-change-set output) applied to --plan template",
+		},
+		&cli.BoolFlag{
+			Name:  "redact",
+			Usage: "Strip sensitive values from --plan before it is parsed (see terracost redact)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "redact-attribute",
+			Usage: "Extra attribute name fragment to redact with --redact (repeatable)",
+		},
+		&cli.StringFlag{
+			Name:    "env",
+			Aliases: []string{"e"},
+			Value:   "dev",
+			Usage:   "Environment usage profile (dev, staging, prod or one from --usage-profiles)",
+		},
+		&cli.StringFlag{
+			Name:  "usage-file",
+			Usage: "YAML/JSON usage file overriding usage per resource",
+		},
+		&cli.StringFlag{
+			Name:    "usage-profiles",
+			Usage:   "YAML/JSON file whose profiles section defines extra environment usage profiles",
+			EnvVars: []string{"TERRACOST_USAGE_PROFILES"},
+		},
+		&cli.StringFlag{
+			Name:    "plugin-dir",
+			Usage:   "Directory of terracost-mapper-* plugin executables mapping extra resource types",
+			EnvVars: []string{"TERRACOST_PLUGIN_DIR"},
+		},
+		&cli.StringSliceFlag{
+			Name:  "placeholder-cost",
+			Usage: "Price unsupported resource types at an assumed monthly USD cost: AMOUNT for all, or TYPE=AMOUNT (repeatable; 0 lists them unpriced)",
+		},
+		&cli.StringSliceFlag{
+			Name:    "calibrate",
+			Usage:   "Calibrate usage of updated resources from cloudwatch and/or cost-explorer (AWS credentials from the environment)",
+			EnvVars: []string{"TERRACOST_CALIBRATE"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "aws-metadata",
+			Usage:   "Resolve AMI platforms, root volumes and instance hardware from embedded, ec2 (live API, AWS credentials from the environment) and/or dataset files",
+			EnvVars: []string{"TERRACOST_AWS_METADATA"},
+		},
+		&cli.Float64Flag{
+			Name:  "cost-limit",
+			Usage: "Monthly cost limit for policy check",
+		},
+		&cli.StringFlag{
+			Name:  "policy-file",
+			Usage: "Policy file (default: " + policy.DefaultPolicyFile + " in the working directory, if present)",
+		},
+		&cli.StringFlag{
+			Name:  "waiver-file",
+			Usage: "Policy waiver file (default: " + policy.DefaultWaiverFile + " in the working directory, if present)",
+		},
+		&cli.StringSliceFlag{
+			Name:    "notify",
+			Usage:   "Send the estimate to slack://#channel (SLACK_BOT_TOKEN), slack://hooks.slack.com/... or teams://<webhook host/path> (repeatable)",
+			EnvVars: []string{"TERRACOST_NOTIFY"},
+		},
+		&cli.StringFlag{
+			Name:  "notify-link",
+			Usage: "Link included in notifications (pull request, pipeline run)",
+		},
+		&cli.BoolFlag{
+			Name:  "wait-for-approval",
+			Usage: "When a policy denies, request an approval from the server and wait for it instead of failing (needs --project)",
+		},
+		&cli.StringFlag{
+			Name:    "approval-endpoint",
+			Usage:   "TerraCost server handling approvals (default: --pricing-endpoint); authenticates with --pricing-api-key",
+			EnvVars: []string{"TERRACOST_APPROVAL_ENDPOINT"},
+		},
+		&cli.DurationFlag{
+			Name:  "approval-timeout",
+			Value: time.Hour,
+			Usage: "How long --wait-for-approval waits for a decision",
+		},
+		&cli.StringSliceFlag{
+			Name:    "export-metrics",
+			Usage:   "Export monthly cost per service as OpenMetrics to a Pushgateway (http://host:9091) or a textfile collector file (*.prom, one per project) (repeatable)",
+			EnvVars: []string{"TERRACOST_EXPORT_METRICS"},
+		},
+		&cli.StringFlag{
+			Name:  "currency",
+			Value: currency.USD,
+			Usage: "Currency for all costs (non-USD needs --fx-rates)",
+		},
+		&cli.StringFlag{
+			Name:    "fx-rates",
+			Usage:   "Exchange rates: a YAML/JSON file (base, rates) or 'ecb' for the ECB daily reference rates",
+			EnvVars: []string{"TERRACOST_FX_RATES"},
+		},
+		&cli.StringFlag{
+			Name:    "discounts",
+			Usage:   "Negotiated discounts taken off list prices: a YAML/JSON file or 'database' for the stored discounts",
+			EnvVars: []string{"TERRACOST_DISCOUNTS"},
+		},
+		&cli.StringFlag{
+			Name:  "pricing-date",
+			Usage: "Price against the snapshots valid at this date (YYYY-MM-DD or RFC 3339)",
+		},
+		&cli.IntFlag{
+			Name:  "simulations",
+			Usage: "Monte Carlo samples for P10/P50/P90/P99 cost bands (0 disables)",
+		},
+		&cli.Int64Flag{
+			Name:  "simulation-seed",
+			Usage: "Random seed for --simulations",
+			Value: estimation.DefaultSimulationSeed,
+		},
+		&cli.StringSliceFlag{
+			Name:  "allocation-tag",
+			Usage: "Tag key to allocate cost by (repeatable, default: team, cost-center, project)",
+		},
+		&cli.Float64Flag{
+			Name:  "cost-growth",
+			Usage: "Maximum allowed monthly cost P50 growth (percent) over the baseline",
+		},
+		&cli.StringFlag{
+			Name:  "baseline",
+			Usage: "Baseline estimate JSON for --cost-growth (default: latest saved estimate for --project)",
+		},
+		&cli.StringFlag{
+			Name:  "baseline-branch",
+			Usage: "Branch whose latest saved estimate is the baseline (default: any branch)",
+		},
+		&cli.Float64Flag{
+			Name:  "budget",
+			Usage: "Fiscal year budget, in the estimate currency, to project the monthly cost against",
+		},
+		&cli.Float64Flag{
+			Name:  "budget-spent",
+			Usage: "Spend against --budget so far this fiscal year",
+		},
+		&cli.IntFlag{
+			Name:  "fiscal-year-start",
+			Value: 1,
+			Usage: "Month (1-12) the fiscal year starts for budget burn-down",
+		},
+		&cli.Float64Flag{
+			Name:  "carbon-budget",
+			Usage: "Carbon budget (kg CO2) for policy check",
+		},
+		&cli.BoolFlag{
+			Name:  "include-carbon",
+			Value: false,
+			Usage: "Include carbon emissions in output",
+		},
+		&cli.BoolFlag{
+			Name:  "include-formulas",
+			Value: false,
+			Usage: "Include cost formulas in output",
+		},
+		&cli.StringFlag{
+			Name:  "explain",
+			Usage: "Print how one resource's cost was derived (mapper, attributes, usage, rate key, snapshot, formula, confidence) instead of the report",
+		},
+		&cli.BoolFlag{
+			Name:  "deterministic",
+			Usage: "Pin timestamps, order output stably and record pricing snapshot hashes, for golden-file tests (see terracost verify)",
+		},
+		&cli.BoolFlag{
+			Name:  "relaxed-matching",
+			Usage: "Price rate keys with no exact match without low-signal attributes or from the nearest instance size, at lower confidence",
+		},
+		&cli.BoolFlag{
+			Name:  "diff",
+			Usage: "Leave deleted resources out of the estimate and report their monthly savings and the one-time churn of replacements separately",
+		},
+		&cli.BoolFlag{
+			Name:  "include-free-tier",
+			Usage: "Subtract the AWS free tier (750 t2/t3.micro hours, 1M Lambda requests, 5 GB S3, ...) from usage",
+		},
+		&cli.StringFlag{
+			Name:    "timebase",
+			Value:   string(billing.Timebase730),
+			Usage:   "Hours billed per month of always-on usage: 730 (8760/12), 720 (30 days) or calendar (the hours of the month priced)",
+			EnvVars: []string{"TERRACOST_TIMEBASE"},
+		},
+		&cli.StringFlag{
+			Name:  "provisioned-on",
+			Usage: "Date the resources are created (YYYY-MM-DD or RFC 3339), to also report the prorated cost of the first month",
+		},
+		&cli.StringSliceFlag{
+			Name:    "free-tier-remaining",
+			Usage:   "Free tier left in this account with --include-free-tier, e.g. ec2_micro_hours=0 (repeatable)",
+			EnvVars: []string{"TERRACOST_FREE_TIER_REMAINING"},
+		},
+		&cli.BoolFlag{
+			Name:  "skip-policy",
+			Value: false,
+			Usage: "Skip policy evaluation",
+		},
+		&cli.StringFlag{
+			Name:  "opa-endpoint",
+			Usage: "OPA endpoint for policy evaluation",
+		},
+		&cli.StringFlag{
+			Name:  "opa-package",
+			Value: policy.DefaultOPAPackage,
+			Usage: "Rego package whose deny/warn rules are evaluated",
+		},
+		&cli.StringFlag{
+			Name:  "opa-failure-mode",
+			Value: string(policy.OPAFailOpen),
+			Usage: "Decision when OPA is unavailable: open (warn) or closed (deny)",
+		},
+		&cli.StringSliceFlag{
+			Name:    "fail-on",
+			Usage:   "Fail the run after output at a threshold (deny, warn: also warnings, incomplete: also incomplete estimates) and on issue codes, e.g. PRICE_NOT_FOUND (default: deny)",
+			EnvVars: []string{"TERRACOST_FAIL_ON"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "exit-code",
+			Usage:   "Exit code of a failure as deny|warn|incomplete|CODE=N, e.g. warn=0 (repeatable; defaults: deny=2, warn=7, incomplete=8)",
+			EnvVars: []string{"TERRACOST_EXIT_CODES"},
+		},
+		&cli.BoolFlag{
+			Name:    "soft-fail",
+			Usage:   "Report what would fail the run but always exit 0",
+			EnvVars: []string{"TERRACOST_SOFT_FAIL"},
+		},
+		&cli.DurationFlag{
+			Name:  "max-snapshot-age",
+			Usage: "Flag current estimates priced from older snapshots as SNAPSHOT_STALE (0 disables)",
+		},
+		&cli.StringFlag{
+			Name:  "project",
+			Usage: "Project name; when set the estimate is saved to history",
+		},
+		&cli.StringFlag{
+			Name:  "branch",
+			Usage: "Branch recorded with the saved estimate",
+		},
+		&cli.StringFlag{
+			Name:  "commit",
+			Usage: "Commit SHA recorded with the saved estimate",
+		},
+		&cli.StringFlag{
+			Name:  "pr",
+			Usage: "Pull request number recorded with the saved estimate",
+		},
+	}
+}
+
 func runEstimate(c *cli.Context) error {
-	ctx := context.Background()
-	
-	// Parse Terraform plan
-	parser := iac.NewParser()
-	plan, err := parser.ParseFile(c.String("plan"))
-	if err != nil {
-		return fmt.Errorf("failed to parse terraform plan: %w", err)
+	if c.String("terragrunt-dir") != "" {
+		if c.String("explain") != "" {
+			return fmt.Errorf("--explain does not apply to --terragrunt-dir; explain the module's plan instead")
+		}
+		return runTerragrunt(c)
 	}
-	
-	// Build infrastructure graph
-	graphBuilder := iac.NewGraphBuilder()
-	graph, err := graphBuilder.Build(plan)
+	if isPlanGlob(c.String("plan")) {
+		return runMultiPlan(c)
+	}
+
+	notifiers, err := notify.ParseAll(c.StringSlice("notify"))
 	if err != nil {
-		return fmt.Errorf("failed to build infrastructure graph: %w", err)
+		return err
 	}
-	
-	fmt.Fprintf(os.Stderr, "📊 Parsed %d resources (%d creates, %d updates, %d deletes)\n",
-		graph.ResourceCount,
-		graph.ChangeStats.Creates,
-		graph.ChangeStats.Updates,
-		graph.ChangeStats.Deletes,
-	)
-	
-	// Initialize billing engine
-	billingEngine := billing.NewEngine()
-	aws.RegisterAllMappers(billingEngine)
-	
-	// Decompose resources into billing components
-	decomposition, err := billingEngine.Decompose(graph)
+	exporters, err := metrics.ParseAll(c.StringSlice("export-metrics"))
 	if err != nil {
-		return fmt.Errorf("failed to decompose resources: %w", err)
+		return err
 	}
-	
-	fmt.Fprintf(os.Stderr, "💰 Generated %d billing components from %d resources\n",
-		decomposition.ComponentsCreated,
-		decomposition.ResourcesMapped,
-	)
-	
-	if len(decomposition.UncoveredTypes) > 0 {
-		fmt.Fprintf(os.Stderr, "⚠️  Unsupported resource types: %s\n",
-			strings.Join(decomposition.UncoveredTypes, ", "))
+	gate, err := newExitGate(c)
+	if err != nil {
+		return err
 	}
-	
-	// Connect to ClickHouse
-	store, err := clickhouse.NewStore(&clickhouse.Config{
-		Host:     c.String("clickhouse-host"),
-		Port:     c.Int("clickhouse-port"),
-		Database: c.String("clickhouse-database"),
-		Username: c.String("clickhouse-user"),
-		Password: c.String("clickhouse-password"),
-	})
+	top, err := driverLimit(c)
 	if err != nil {
-		return fmt.Errorf("failed to connect to ClickHouse: %w", err)
+		return err
 	}
-	defer store.Close()
 	
-	// Run estimation
-	estimationEngine := estimation.NewEngine(store)
-	
-	result, err := estimationEngine.Estimate(ctx, estimation.EstimationRequest{
-		Components:      decomposition.Components,
-		Environment:     c.String("env"),
-		IncludeCarbon:   c.Bool("include-carbon"),
-		IncludeFormulas: c.Bool("include-formulas"),
-	})
+	run, err := runPipeline(c)
 	if err != nil {
-		return fmt.Errorf("estimation failed: %w", err)
+		return err
 	}
 	
-	// Run policy evaluation
-	var policyResult *policy.EvaluationResult
-	if !c.Bool("skip-policy") {
-		policyEngine := policy.NewEngine()
-		
-		// Add custom policies from flags
-		if limit := c.Float64("cost-limit"); limit > 0 {
-			policyEngine.AddPolicy(policy.Policy{
-				ID:        "cli-cost-limit",
-				Name:      "Cost Limit",
-				Type:      policy.PolicyTypeCostLimit,
-				Severity:  policy.SeverityError,
-				Threshold: limit,
-				Enabled:   true,
-			})
-		}
-		
-		if budget := c.Float64("carbon-budget"); budget > 0 {
-			policyEngine.AddPolicy(policy.Policy{
-				ID:        "cli-carbon-budget",
-				Name:      "Carbon Budget",
-				Type:      policy.PolicyTypeCarbonBudget,
-				Severity:  policy.SeverityError,
-				Threshold: budget,
-				Enabled:   true,
-			})
-		}
-		
-		// Configure OPA if endpoint provided
-		if opaEndpoint := c.String("opa-endpoint"); opaEndpoint != "" {
-			policyEngine.WithOPA(opaEndpoint)
-		}
-		
-		policyResult, err = policyEngine.Evaluate(ctx, policy.EvaluationRequest{
-			Estimation:  result,
-			Environment: c.String("env"),
-		})
-		if err != nil {
-			return fmt.Errorf("policy evaluation failed: %w", err)
+	// Notify before output
+	if len(notifiers) > 0 {
+		report := integrations.NewReport(reportTitle(c), run.result, run.policyResult, run.baseline)
+		report.Link = c.String("notify-link")
+		for _, err := range notify.Send(c.Context, notifiers, report) {
+			fmt.Fprintf(os.Stderr, "⚠️  Notification failed: %v\n", err)
 		}
 	}
+	exportMetrics(c, exporters, metrics.Estimate{Project: reportTitle(c), Environment: c.String("env"), Result: run.result})
 	
 	// Output results
+	if run.explanation != nil {
+		return outputExplanation(run.explanation, c.String("format"))
+	}
 	switch c.String("format") {
 	case "json":
-		return outputJSON(result, policyResult)
+		err = outputJSON(run, top)
 	case "markdown":
-		return outputMarkdown(result, policyResult)
+		err = outputMarkdown(run.result, run.policyResult, run.optimization, run.diff, run.burnDowns, top)
+	case "junit":
+		err = outputJUnit(run.result, run.policyResult, run.decomposition.UncoveredTypes)
+	case "azure-devops":
+		err = outputAzureDevOps(c, run, top)
 	default:
-		return outputTable(result, policyResult)
+		err = outputTable(run.result, run.policyResult, run.diff, top)
 	}
+	if err != nil {
+		return err
+	}
+	if c.Bool("wait-for-approval") && run.policyResult != nil && run.policyResult.Decision == policy.DecisionDeny {
+		if run.issues, err = waitForApproval(c, run); err != nil {
+			return err
+		}
+	}
+	return gate.check(run.issues)
 }
 
-// =============================================================================
-// OUTPUT FORMATTERS
-// =============================================================================
+// exportMetrics exports estimates; failures are reported but don't fail the command
+func exportMetrics(c *cli.Context, exporters []metrics.Exporter, estimates ...metrics.Estimate) {
+	for _, err := range metrics.ExportAll(c.Context, exporters, estimates...) {
+		fmt.Fprintf(os.Stderr, "⚠️  Metrics export failed: %v\n", err)
+	}
+}
 
-type JSONOutput struct {
-	MonthlyCostP50     string               `json:"monthly_cost_p50"`
-	MonthlyCostP90     string               `json:"monthly_cost_p90"`
-	CarbonKgCO2        float64              `json:"carbon_kg_co2"`
-	Confidence         float64              `json:"confidence"`
-	IsIncomplete       bool                 `json:"is_incomplete"`
-	ResourceCount      int                  `json:"resource_count"`
-	ComponentsEstimated int                 `json:"components_estimated"`
-	ComponentsSymbolic int                  `json:"components_symbolic"`
-	PolicyResult       string               `json:"policy_result"`
-	Violations         []policy.Violation   `json:"violations,omitempty"`
-	Warnings           []policy.Warning     `json:"warnings,omitempty"`
-	CostDrivers        []estimation.CostDriver `json:"cost_drivers"`
+// defaultTableDrivers is how many cost drivers table output shows without --top
+const defaultTableDrivers = 5
+
+// driverLimit returns how many cost drivers to output from --top and --show-all; 0 is all
+func driverLimit(c *cli.Context) (int, error) {
+	switch {
+	case c.Bool("show-all") && c.IsSet("top"):
+		return 0, fmt.Errorf("--top and --show-all are mutually exclusive")
+	case c.Bool("show-all"):
+		return 0, nil
+	case c.IsSet("top"):
+		if c.Int("top") <= 0 {
+			return 0, fmt.Errorf("--top must be positive")
+		}
+		return c.Int("top"), nil
+	case c.String("format") == "table", c.String("format") == "azure-devops":
+		return defaultTableDrivers, nil
+	default:
+		return 0, nil
+	}
 }
 
-func outputJSON(result *estimation.EstimationResult, policyResult *policy.EvaluationResult) error {
-	output := JSONOutput{
-		MonthlyCostP50:     result.MonthlyCostP50.StringFixed(2),
-		MonthlyCostP90:     result.MonthlyCostP90.StringFixed(2),
-		CarbonKgCO2:        result.CarbonKgCO2,
-		Confidence:         result.Confidence,
-		IsIncomplete:       result.IsIncomplete,
-		ResourceCount:      result.ComponentsProcessed,
-		ComponentsEstimated: result.ComponentsEstimated,
-		ComponentsSymbolic: result.ComponentsSymbolic,
-		CostDrivers:        result.CostDrivers,
+// topN returns the first n items; 0 is all
+func topN[T any](items []T, n int) []T {
+	if n > 0 && len(items) > n {
+		return items[:n]
 	}
-	
-	if policyResult != nil {
-		output.PolicyResult = string(policyResult.Decision)
-		output.Violations = policyResult.Violations
-		output.Warnings = policyResult.Warnings
+	return items
+}
+
+// parsePlaceholderCosts parses --placeholder-cost values: AMOUNT or TYPE=AMOUNT
+func parsePlaceholderCosts(values []string) (billing.PlaceholderCosts, error) {
+	costs := billing.PlaceholderCosts{ByType: make(map[string]float64)}
+	for _, value := range values {
+		resourceType, amount, perType := strings.Cut(value, "=")
+		if !perType {
+			amount = resourceType
+		}
+		cost, err := strconv.ParseFloat(strings.TrimSpace(amount), 64)
+		if err != nil || cost < 0 {
+			return costs, tcerrors.New(tcerrors.CodeInvalidRequest, "invalid --placeholder-cost %q (AMOUNT or TYPE=AMOUNT)", value)
+		}
+		if perType {
+			costs.ByType[strings.TrimSpace(resourceType)] = cost
+		} else {
+			costs.Default = cost
+		}
+	}
+	return costs, nil
+}
+
+// parseDependencies parses --dependency values: NAME=URL
+func parseDependencies(values []string) ([]health.Dependency, error) {
+	deps := make([]health.Dependency, 0, len(values))
+	for _, value := range values {
+		name, url, ok := strings.Cut(value, "=")
+		name, url = strings.TrimSpace(name), strings.TrimSpace(url)
+		if !ok || name == "" || !strings.HasPrefix(url, "http") {
+			return nil, tcerrors.New(tcerrors.CodeInvalidRequest, "invalid --dependency %q (NAME=URL)", value)
+		}
+		deps = append(deps, health.Dependency{Name: name, Critical: true, Check: health.HTTPCheck(nil, url)})
+	}
+	return deps, nil
+}
+
+// parseFreeTier builds the AWS free tier with --free-tier-remaining values (name=amount)
+func parseFreeTier(values []string) (*estimation.FreeTier, error) {
+	remaining := make(map[string]float64, len(values))
+	for _, value := range values {
+		name, amount, ok := strings.Cut(value, "=")
+		left, err := strconv.ParseFloat(strings.TrimSpace(amount), 64)
+		if !ok || err != nil {
+			return nil, tcerrors.New(tcerrors.CodeInvalidRequest, "invalid --free-tier-remaining %q (name=amount)", value)
+		}
+		remaining[strings.TrimSpace(name)] = left
+	}
+	freeTier, err := estimation.DefaultFreeTier().WithRemaining(remaining)
+	if err != nil {
+		return nil, tcerrors.New(tcerrors.CodeInvalidRequest, "%v", err)
+	}
+	return freeTier, nil
+}
+
+// estimateRun is the output of the estimate pipeline
+type estimateRun struct {
+	graph         *iac.Graph
+	decomposition *billing.DecompositionResult
+	result        *estimation.EstimationResult
+	policyResult  *policy.EvaluationResult
+	baseline      *policy.Baseline
+	optimization  *optimize.Report
+	issues        []tcerrors.Issue
+	snapshots     []pricingSnapshotRef // Recorded with --deterministic
+	diff          *terracost.Diff      // With --diff
+	explanation   *terracost.Explanation // With --explain
+	burnDowns     []*budget.BurnDown     // With --budget or stored budgets
+}
+
+// reportTitle names an estimate in notifications: the project, else the plan file
+func reportTitle(c *cli.Context) string {
+	if project := c.String("project"); project != "" {
+		return project
+	}
+	if path := c.String("path"); path != "" {
+		return filepath.Base(filepath.Clean(path))
+	}
+	return filepath.Base(c.String("plan"))
+}
+
+// runPipeline parses, decomposes, estimates, evaluates policy and saves history
+func runPipeline(c *cli.Context) (*estimateRun, error) {
+	// Parse IaC plan (or raw configuration with --path)
+	format, input := c.String("plan-format"), c.String("plan")
+	if c.String("path") != "" {
+		format, input = iac.FormatHCL, c.String("path")
+	}
+	if input == "" {
+		return nil, fmt.Errorf("either --plan or --path is required")
+	}
+	return runPipelineFor(c, pipelineInput{
+		format:  format,
+		input:   input,
+		project: c.String("project"),
+		env:     c.String("env"),
+	})
+}
+
+// pipelineInput is what one pipeline run estimates; other options come from the flags
+type pipelineInput struct {
+	format  string
+	input   string // Plan file or configuration directory
+	project string // History project; empty skips saving
+	env     string // Usage profile environment
+	region  string // Target region of a what-if estimate; empty keeps the plan's
+
+	deterministic bool // As with --deterministic
+}
+
+// runPipelineFor runs the pipeline for one input
+func runPipelineFor(c *cli.Context, in pipelineInput) (*estimateRun, error) {
+	format, input, project, env := in.format, in.input, in.project, in.env
+	ctx, span := telemetry.StartSpan(c.Context, "terracost.estimate")
+	defer span.End()
+	
+	pricingDate, err := estimation.ParsePricingDate(c.String("pricing-date"))
+	if err != nil {
+		return nil, err
+	}
+	if !pricingDate.IsZero() && c.String("pricing-backend") == "embedded" {
+		return nil, fmt.Errorf("--pricing-date requires --pricing-backend clickhouse: %w", embedded.ErrNoHistoricalPricing)
+	}
+	
+	parser, err := iac.NewParserForFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	if cfn, ok := parser.(*iac.CloudFormationParser); ok && c.String("changeset") != "" {
+		changeSet, err := os.ReadFile(c.String("changeset"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read change set: %w", err)
+		}
+		cfn.WithChangeSet(changeSet)
+	}
+	if hclParser, ok := parser.(*iac.HCLParser); ok {
+		vars := make(map[string]string)
+		for _, kv := range c.StringSlice("var") {
+			name, value, found := strings.Cut(kv, "=")
+			if !found {
+				return nil, fmt.Errorf("invalid --var %q: expected name=value", kv)
+			}
+			vars[name] = value
+		}
+		hclParser.WithVariables(vars).WithVarFiles(c.StringSlice("var-file")...)
+	}
+	
+	// Connect to the pricing backend; history is only available with ClickHouse
+	pricingStore, store, closeStore, err := openPricingStore(c)
+	if err != nil {
+		return nil, err
+	}
+	defer closeStore()
+	
+	estimator, err := newEstimator(ctx, c, pricingStore, store, project)
+	if err != nil {
+		return nil, err
+	}
+	
+	// Parse IaC plan
+	var plan *iac.ParsedPlan
+	if c.Bool("redact") {
+		plan, err = parseRedacted(ctx, c, estimator, parser, input)
+	} else {
+		plan, err = estimator.ParseFile(ctx, parser, input)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s input: %w", format, err)
+	}
+	for _, w := range plan.Warnings {
+		fmt.Fprintf(os.Stderr, "⚠️  %s\n", w)
+	}
+	
+	req := terracost.Request{
+		Environment:     env,
+		Project:         project,
+		IncludeCarbon:   c.Bool("include-carbon"),
+		IncludeFormulas: c.Bool("include-formulas") || c.String("explain") != "",
+		AllocationTags:  c.StringSlice("allocation-tag"),
+		PricingDate:     pricingDate,
+		Currency:        c.String("currency"),
+		RelaxedMatching: c.Bool("relaxed-matching"),
+		Region:          in.region,
+		Diff:            c.Bool("diff"),
+	}
+	if req.Timebase, err = billing.ParseTimebase(c.String("timebase")); err != nil {
+		return nil, tcerrors.New(tcerrors.CodeInvalidRequest, "%v", err)
+	}
+	if req.ProvisionedAt, err = estimation.ParseProvisionedAt(c.String("provisioned-on")); err != nil {
+		return nil, tcerrors.New(tcerrors.CodeInvalidRequest, "%v", err)
+	}
+	if path := c.String("usage-file"); path != "" {
+		if req.Usage, err = usage.LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if n := c.Int("simulations"); n > 0 {
+		req.Simulation = &estimation.SimulationOptions{Iterations: n, Seed: c.Int64("simulation-seed")}
+	}
+	if c.Bool("include-free-tier") {
+		if req.FreeTier, err = parseFreeTier(c.StringSlice("free-tier-remaining")); err != nil {
+			return nil, err
+		}
+	}
+	if !c.Bool("skip-policy") {
+		if req.Baseline, err = loadBaseline(ctx, c, store, project); err != nil {
+			return nil, err
+		}
+	}
+	if !pricingDate.IsZero() {
+		fmt.Fprintf(os.Stderr, "🕰️  Pricing as of %s\n", pricingDate.Format(time.RFC3339))
+	}
+	
+	// Decompose, predict usage, price and evaluate policy
+	run, err := estimator.Estimate(ctx, plan, req)
+	if err != nil {
+		return nil, err
+	}
+	graph, decomposition, result := run.Graph, run.Decomposition, run.Estimation
+	var explanation *terracost.Explanation
+	if addr := c.String("explain"); addr != "" {
+		if explanation, err = estimator.Explain(run, addr); err != nil {
+			return nil, err
+		}
+	}
+	
+	fmt.Fprintf(os.Stderr, "📊 Parsed %d resources (%d creates, %d updates, %d deletes)\n",
+		graph.ResourceCount,
+		graph.ChangeStats.Creates,
+		graph.ChangeStats.Updates,
+		graph.ChangeStats.Deletes,
+	)
+	if stats := graph.ChangeStats; stats.Imports+stats.Moves+stats.Forgets > 0 {
+		fmt.Fprintf(os.Stderr, "📦 %d imports, %d moves, %d removed from state\n", stats.Imports, stats.Moves, stats.Forgets)
+	}
+	fmt.Fprintf(os.Stderr, "💰 Generated %d billing components from %d resources\n",
+		decomposition.ComponentsCreated,
+		decomposition.ResourcesMapped,
+	)
+	if len(decomposition.UncoveredTypes) > 0 {
+		fmt.Fprintf(os.Stderr, "⚠️  Unsupported resource types: %s\n",
+			strings.Join(decomposition.UncoveredTypes, ", "))
+	}
+	for _, used := range result.FreeTier {
+		fmt.Fprintf(os.Stderr, "🎁 Free tier %s: %.0f of %.0f %s used\n", used.Name, used.UsedP50, used.Amount, used.Unit)
+	}
+	if first := result.FirstMonth; first != nil {
+		fmt.Fprintf(os.Stderr, "📅 First month from %s: %s (%.0f%% of the month)\n",
+			first.ProvisionedAt.Format("2006-01-02"), first.MonthlyCostP50.StringFixed(2), first.Fraction*100)
+	}
+	for _, w := range result.Warnings {
+		fmt.Fprintf(os.Stderr, "⚠️  %s\n", w)
+	}
+	
+	// Save to estimation history
+	if project != "" {
+		meta := estimation.HistoryMeta{
+			Project:       project,
+			Branch:        c.String("branch"),
+			CommitSHA:     c.String("commit"),
+			PullRequest:   c.String("pr"),
+			Environment:   env,
+			Source:        "cli",
+			ResourceCount: graph.ResourceCount,
+		}
+		if run.Policy != nil {
+			meta.PolicyResult = string(run.Policy.Decision)
+		}
+		record, err := estimation.NewEstimationRecord(result, meta)
+		if err == nil && store == nil {
+			err = errNoHistory
+		}
+		if err == nil {
+			err = store.SaveEstimation(ctx, record)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Estimate not saved to history: %v\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "🗂️  Saved estimate %s for project %s\n", record.ID, project)
+		}
+	}
+	
+	estimate := &estimateRun{
+		graph:         graph,
+		decomposition: decomposition,
+		result:        result,
+		policyResult:  run.Policy,
+		baseline:      req.Baseline,
+		optimization:  run.Optimization,
+		issues:        run.Issues,
+		diff:          run.Diff,
+		explanation:   explanation,
+	}
+	if estimate.burnDowns, err = budgetBurnDowns(ctx, c, store, project, env, result); err != nil {
+		return nil, err
+	}
+	if in.deterministic || c.Bool("deterministic") {
+		result.MakeDeterministic()
+		sort.SliceStable(estimate.issues, func(i, j int) bool {
+			a, b := estimate.issues[i], estimate.issues[j]
+			if a.Code != b.Code {
+				return a.Code < b.Code
+			}
+			if a.Resource != b.Resource {
+				return a.Resource < b.Resource
+			}
+			return a.Message < b.Message
+		})
+		if estimate.snapshots, err = pricingSnapshotRefs(ctx, store, result); err != nil {
+			return nil, err
+		}
+	}
+	return estimate, nil
+}
+
+// pricingSnapshotRef identifies the pricing a deterministic estimate used
+type pricingSnapshotRef struct {
+	Region     string    `json:"region"`
+	SnapshotID uuid.UUID `json:"snapshot_id"`
+	Hash       string    `json:"hash,omitempty"` // Content hash; unknown with the embedded backend
+}
+
+// pricingSnapshotRefs lists the snapshots an estimate used by region, with their hashes
+func pricingSnapshotRefs(ctx context.Context, store *clickhouse.Store, result *estimation.EstimationResult) ([]pricingSnapshotRef, error) {
+	regions := make([]string, 0, len(result.AuditTrail.SnapshotsUsed))
+	for region := range result.AuditTrail.SnapshotsUsed {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	
+	refs := make([]pricingSnapshotRef, 0, len(regions))
+	for _, region := range regions {
+		ref := pricingSnapshotRef{Region: region, SnapshotID: result.AuditTrail.SnapshotsUsed[region]}
+		if store != nil {
+			snapshot, err := store.GetSnapshot(ctx, ref.SnapshotID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read pricing snapshot %s: %w", ref.SnapshotID, err)
+			}
+			if snapshot != nil {
+				ref.Hash = snapshot.Hash
+			}
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// newEstimator creates an estimator with the pricing store and the usage, carbon and policy flags
+func newEstimator(ctx context.Context, c *cli.Context, pricingStore estimation.PricingStore, store *clickhouse.Store, project string) (*terracost.Estimator, error) {
+	estimator := terracost.NewEstimator(pricingStore).WithMaxSnapshotAge(c.Duration("max-snapshot-age"))
+	
+	// Replace heuristic usage of running resources with their history
+	if names := c.StringSlice("calibrate"); len(names) > 0 {
+		calibrator, err := newCalibrator(names)
+		if err != nil {
+			return nil, err
+		}
+		estimator.WithCalibrator(calibrator)
+	}
+	if names := c.StringSlice("aws-metadata"); len(names) > 0 {
+		sources, err := awsmeta.NewSources(names)
+		if err != nil {
+			return nil, err
+		}
+		estimator.WithEnricher(awsmeta.NewEnricher(sources...))
+	}
+	if dir := c.String("plugin-dir"); dir != "" {
+		mappers, err := plugin.Load(dir, plugin.DefaultTimeout)
+		if err != nil {
+			return nil, err
+		}
+		estimator.WithMappers(mappers...)
+	}
+	if values := c.StringSlice("placeholder-cost"); len(values) > 0 {
+		costs, err := parsePlaceholderCosts(values)
+		if err != nil {
+			return nil, err
+		}
+		estimator.WithPlaceholderCosts(costs)
+	}
+	if path := c.String("usage-profiles"); path != "" {
+		profiles, err := usage.LoadProfiles(path)
+		if err != nil {
+			return nil, err
+		}
+		estimator.WithUsageProfiles(profiles...)
+	}
+	if entries := c.StringSlice("pricing-alias"); len(entries) > 0 {
+		routes, err := estimation.ParseAliasRoutes(entries)
+		if err != nil {
+			return nil, err
+		}
+		estimator.WithAliasRoutes(routes)
+	}
+	if source := c.String("fx-rates"); source != "" {
+		fxRates, err := currency.Load(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+		estimator.WithExchangeRates(fxRates)
+	}
+	if source := c.String("discounts"); source != "" {
+		discounts, err := loadDiscounts(source, store)
+		if err != nil {
+			return nil, err
+		}
+		estimator.WithDiscounts(discounts)
+	}
+	if c.Bool("include-carbon") {
+		carbonStore, err := openCarbonStore(c)
+		if err != nil {
+			return nil, err
+		}
+		estimator.WithCarbonStore(carbonStore)
+	}
+	if c.Bool("skip-policy") {
+		return estimator, nil
+	}
+	
+	policyEngine := policy.NewEngine()
+	policies, err := loadPolicyFile(c.String("policy-file"))
+	if err != nil {
+		return nil, err
+	}
+	policyEngine.LoadPolicies(policies)
+	waivers, err := loadWaiverFile(c.String("waiver-file"))
+	if err != nil {
+		return nil, err
+	}
+	policyEngine.WithWaivers(waivers)
+	
+	// Add custom policies from flags
+	if limit := c.Float64("cost-limit"); limit > 0 {
+		policyEngine.AddPolicy(policy.Policy{
+			ID:        "cli-cost-limit",
+			Name:      "Cost Limit",
+			Type:      policy.PolicyTypeCostLimit,
+			Severity:  policy.SeverityError,
+			Threshold: limit,
+			Enabled:   true,
+		})
+	}
+	
+	if budget := c.Float64("carbon-budget"); budget > 0 {
+		policyEngine.AddPolicy(policy.Policy{
+			ID:        "cli-carbon-budget",
+			Name:      "Carbon Budget",
+			Type:      policy.PolicyTypeCarbonBudget,
+			Severity:  policy.SeverityError,
+			Threshold: budget,
+			Enabled:   true,
+		})
+	}
+	
+	if growth := c.Float64("cost-growth"); growth > 0 {
+		policyEngine.AddPolicy(policy.Policy{
+			ID:        "cli-cost-growth",
+			Name:      "Cost Growth",
+			Type:      policy.PolicyTypeCostGrowth,
+			Severity:  policy.SeverityError,
+			Threshold: growth,
+			Enabled:   true,
+		})
+	}
+	
+	// Stored budgets and cost anomalies are checked for the project when history is available
+	if store != nil && project != "" {
+		policyEngine.WithBudgets(budget.NewTracker(store)).
+			WithAnomalyDetector(anomaly.NewDetector(store))
+	}
+	
+	// Configure OPA if endpoint provided
+	if opaEndpoint := c.String("opa-endpoint"); opaEndpoint != "" {
+		failureMode, err := policy.ParseOPAFailureMode(c.String("opa-failure-mode"))
+		if err != nil {
+			return nil, err
+		}
+		policyEngine.WithOPA(opaEndpoint).
+			WithOPAPackage(c.String("opa-package")).
+			WithOPAFailureMode(failureMode)
+	}
+	return estimator.WithPolicyEngine(policyEngine), nil
+}
+
+// newPricingRefresh parses the serve pricing refresh schedule and its PROVIDER:REGION targets
+func newPricingRefresh(spec string, regions []string) (*api.PricingRefreshConfig, error) {
+	schedule, err := cron.Parse(spec)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &api.PricingRefreshConfig{Schedule: schedule}
+	for _, value := range regions {
+		provider, region, ok := strings.Cut(value, ":")
+		if !ok {
+			provider, region = "aws", value
+		}
+		if provider != "aws" {
+			return nil, fmt.Errorf("pricing refresh does not support provider %q yet (supported: aws)", provider)
+		}
+		if region == "all" {
+			for _, r := range ingestion.NewAWSPricingAPIFetcher().SupportedRegions() {
+				cfg.Targets = append(cfg.Targets, api.RefreshTarget{Provider: provider, Region: r})
+			}
+			continue
+		}
+		cfg.Targets = append(cfg.Targets, api.RefreshTarget{Provider: provider, Region: region})
+	}
+	if len(cfg.Targets) == 0 {
+		return nil, fmt.Errorf("pricing refresh needs at least one region")
+	}
+	return cfg, nil
+}
+
+// newCalibrator creates a usage calibrator for the named sources with AWS credentials from the environment
+func newCalibrator(names []string) (*calibration.Calibrator, error) {
+	creds, ok := calibration.CredentialsFromEnv()
+	if !ok {
+		return nil, fmt.Errorf("calibration needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	sources, err := calibration.NewSources(names, creds)
+	if err != nil {
+		return nil, err
+	}
+	return calibration.NewCalibrator(sources...), nil
+}
+
+// loadPolicyFile loads an explicit policy file, or the default file if it exists
+func loadPolicyFile(path string) ([]policy.Policy, error) {
+	if path == "" {
+		if _, err := os.Stat(policy.DefaultPolicyFile); err != nil {
+			return nil, nil
+		}
+		path = policy.DefaultPolicyFile
+	}
+	return policy.LoadPolicyFile(path)
+}
+
+// loadWaiverFile loads an explicit waiver file, or the default file if it exists
+func loadWaiverFile(path string) ([]policy.Waiver, error) {
+	if path == "" {
+		if _, err := os.Stat(policy.DefaultWaiverFile); err != nil {
+			return nil, nil
+		}
+		path = policy.DefaultWaiverFile
+	}
+	return policy.LoadWaiverFile(path)
+}
+
+// loadBaseline returns the cost growth baseline from --baseline or estimation history
+func loadBaseline(ctx context.Context, c *cli.Context, store *clickhouse.Store, project string) (*policy.Baseline, error) {
+	if path := c.String("baseline"); path != "" {
+		return policy.LoadBaseline(path)
+	}
+	// History is only consulted when something uses the baseline
+	if project == "" || (c.Float64("cost-growth") <= 0 && len(c.StringSlice("notify")) == 0) {
+		return nil, nil
+	}
+	if store == nil {
+		fmt.Fprintf(os.Stderr, "⚠️  No baseline from history: %v\n", errNoHistory)
+		return nil, nil
 	}
 	
+	record, err := store.LatestEstimation(ctx, project, c.String("baseline-branch"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  No baseline from history: %v\n", err)
+		return nil, nil
+	}
+	if record == nil {
+		fmt.Fprintf(os.Stderr, "⚠️  No saved estimate for project %s; cost growth not evaluated\n", project)
+		return nil, nil
+	}
+	return policy.BaselineFromRecord(record), nil
+}
+
+// =============================================================================
+// OUTPUT FORMATTERS
+// =============================================================================
+
+type JSONOutput struct {
+	Currency           string               `json:"currency"`
+	MonthlyCostP50     string               `json:"monthly_cost_p50"`
+	MonthlyCostP90     string               `json:"monthly_cost_p90"`
+	CarbonKgCO2        float64              `json:"carbon_kg_co2"`
+	Confidence         float64              `json:"confidence"`
+	ConfidenceScores   estimation.ConfidenceScores `json:"confidence_scores"`
+	IsIncomplete       bool                 `json:"is_incomplete"`
+	ResourceCount      int                  `json:"resource_count"`
+	ComponentsEstimated int                 `json:"components_estimated"`
+	ComponentsSymbolic int                  `json:"components_symbolic"`
+	PolicyResult       string               `json:"policy_result"`
+	Violations         []policy.Violation   `json:"violations,omitempty"`
+	Warnings           []policy.Warning     `json:"warnings,omitempty"`
+	CostDrivers        []estimation.CostDriver `json:"cost_drivers"`
+	CostGroups         []estimation.CostGroup  `json:"cost_groups"`
+	CostByTag          map[string]map[string]decimal.Decimal `json:"cost_by_tag,omitempty"`
+	CostByModule       []estimation.ModuleCost `json:"cost_by_module,omitempty"`
+	Simulation         *estimation.Simulation `json:"simulation,omitempty"`
+	FreeTier           []estimation.FreeTierUsage `json:"free_tier,omitempty"`
+	FirstMonth         *estimation.ProratedMonth `json:"first_month,omitempty"` // With --provisioned-on
+	PotentialSavings   string               `json:"potential_savings"`
+	Recommendations    []optimize.Recommendation `json:"recommendations"`
+	Issues             []tcerrors.Issue     `json:"issues,omitempty"`
+	PricingSnapshots   []pricingSnapshotRef `json:"pricing_snapshots,omitempty"` // With --deterministic
+	Diff               *terracost.Diff      `json:"diff,omitempty"`              // With --diff
+	BudgetBurnDown     []*budget.BurnDown   `json:"budget_burn_down,omitempty"`
+}
+
+func outputJSON(run *estimateRun, top int) error {
 	enc := json.NewEncoder(os.Stdout)
 	enc.SetIndent("", "  ")
-	return enc.Encode(output)
+	return enc.Encode(newJSONOutput(run, top))
+}
+
+// newJSONOutput builds the JSON output of an estimate with up to top drivers and groups; 0 is all
+func newJSONOutput(run *estimateRun, top int) JSONOutput {
+	result, policyResult, optimization := run.result, run.policyResult, run.optimization
+	output := JSONOutput{
+		Currency:           result.Currency,
+		MonthlyCostP50:     result.MonthlyCostP50.StringFixed(2),
+		MonthlyCostP90:     result.MonthlyCostP90.StringFixed(2),
+		CarbonKgCO2:        result.CarbonKgCO2,
+		Confidence:         result.Confidence,
+		ConfidenceScores:   result.ConfidenceScores,
+		IsIncomplete:       result.IsIncomplete,
+		ResourceCount:      result.ComponentsProcessed,
+		ComponentsEstimated: result.ComponentsEstimated,
+		ComponentsSymbolic: result.ComponentsSymbolic,
+		CostDrivers:        topN(result.CostDrivers, top),
+		CostGroups:         topN(result.CostGroups, top),
+		CostByTag:          result.CostByTag,
+		CostByModule:       result.CostByModule,
+		Simulation:         result.Simulation,
+		FreeTier:           result.FreeTier,
+		FirstMonth:         result.FirstMonth,
+		PotentialSavings:   optimization.MonthlySavings.StringFixed(2),
+		Recommendations:    optimization.Recommendations,
+		Issues:             run.issues,
+		PricingSnapshots:   run.snapshots,
+		Diff:               run.diff,
+		BudgetBurnDown:     run.burnDowns,
+	}
+	
+	if policyResult != nil {
+		output.PolicyResult = string(policyResult.Decision)
+		output.Violations = policyResult.Violations
+		output.Warnings = policyResult.Warnings
+	}
+	return output
 }
 
-func outputTable(result *estimation.EstimationResult, policyResult *policy.EvaluationResult) error {
+func outputTable(result *estimation.EstimationResult, policyResult *policy.EvaluationResult, diff *terracost.Diff, top int) error {
 	fmt.Println()
 	fmt.Println("╔══════════════════════════════════════════════════════════════╗")
 	fmt.Println("║                    💰 COST ESTIMATION                         ║")
 	fmt.Println("╠══════════════════════════════════════════════════════════════╣")
-	fmt.Printf("║  Monthly Cost (P50):    $%-37s ║\n", result.MonthlyCostP50.StringFixed(2))
-	fmt.Printf("║  Monthly Cost (P90):    $%-37s ║\n", result.MonthlyCostP90.StringFixed(2))
-	fmt.Printf("║  Hourly Cost:           $%-37s ║\n", result.HourlyCostP50.StringFixed(4))
+	fmt.Printf("║  Monthly Cost (P50):    %-38s ║\n", currency.Format(result.MonthlyCostP50, result.Currency, 2))
+	fmt.Printf("║  Monthly Cost (P90):    %-38s ║\n", currency.Format(result.MonthlyCostP90, result.Currency, 2))
+	fmt.Printf("║  Hourly Cost:           %-38s ║\n", currency.Format(result.HourlyCostP50, result.Currency, 4))
 	fmt.Printf("║  Confidence:            %-38s ║\n", fmt.Sprintf("%.0f%%", result.Confidence*100))
+	if result.Discounts != nil {
+		outputDiscountsTable(result)
+	}
+	if sim := result.Simulation; sim != nil {
+		fmt.Printf("║  Simulated P10–P90:     %-38s ║\n", fmt.Sprintf("%s – %s",
+			currency.Format(sim.P10, result.Currency, 2), currency.Format(sim.P90, result.Currency, 2)))
+		fmt.Printf("║  Simulated P99:         %-38s ║\n", currency.Format(sim.P99, result.Currency, 2))
+	}
 	fmt.Println("╠══════════════════════════════════════════════════════════════╣")
 	
 	// Top cost drivers
 	fmt.Println("║  TOP COST DRIVERS                                             ║")
 	fmt.Println("╠══════════════════════════════════════════════════════════════╣")
 	
-	maxDrivers := 5
-	if len(result.CostDrivers) < maxDrivers {
-		maxDrivers = len(result.CostDrivers)
+	groups := topN(result.CostGroups, top)
+	for _, group := range groups {
+		name := group.Description
+		if group.Quantity > 1 {
+			name = fmt.Sprintf("%d× %s", group.Quantity, name)
+		}
+		cost := currency.Format(group.MonthlyCostP50, result.Currency, 2)
+		fmt.Printf("║  %-35s  %-21s ║\n", truncate(name, 35), cost)
 	}
-	
-	for i := 0; i < maxDrivers; i++ {
-		driver := result.CostDrivers[i]
-		name := truncate(driver.Description, 35)
-		cost := driver.MonthlyCostP50.StringFixed(2)
-		fmt.Printf("║  %-35s  $%-20s ║\n", name, cost)
+	if hidden := len(result.CostGroups) - len(groups); hidden > 0 {
+		fmt.Printf("║  %-59s ║\n", fmt.Sprintf("… %d more (--top N or --show-all)", hidden))
 	}
 	
 	fmt.Println("╠══════════════════════════════════════════════════════════════╣")
 	
+	// Cost allocation by tag
+	if len(result.CostByTag) > 0 {
+		fmt.Println("║  COST BY TAG                                                  ║")
+		fmt.Println("╠══════════════════════════════════════════════════════════════╣")
+		for _, key := range sortedTagKeys(result.CostByTag) {
+			for _, tc := range estimation.SortedTagCosts(result.CostByTag[key]) {
+				label := fmt.Sprintf("%s=%s", key, tc.Value)
+				fmt.Printf("║  %-35s  %-21s ║\n", truncate(label, 35), currency.Format(tc.MonthlyCostP50, result.Currency, 2))
+			}
+		}
+		fmt.Println("╠══════════════════════════════════════════════════════════════╣")
+	}
+	
+	// Cost rollup by module
+	if len(result.CostByModule) > 0 {
+		fmt.Println("║  COST BY MODULE                                               ║")
+		fmt.Println("╠══════════════════════════════════════════════════════════════╣")
+		walkModules(result.CostByModule, 0, func(m estimation.ModuleCost, depth int) {
+			label := strings.Repeat("  ", depth) + m.Name
+			fmt.Printf("║  %-35s  %-21s ║\n", truncate(label, 35), currency.Format(m.MonthlyCostP50, result.Currency, 2))
+		})
+		fmt.Println("╠══════════════════════════════════════════════════════════════╣")
+	}
+	
+	// Deletions and replacements with --diff
+	if diff != nil {
+		outputDiffTable(diff)
+	}
+	
 	// Policy result
 	if policyResult != nil {
 		var policyIcon string
@@ -366,23 +1547,29 @@ func outputTable(result *estimation.EstimationResult, policyResult *policy.Evalu
 	}
 	
 	fmt.Println("╚══════════════════════════════════════════════════════════════╝")
-	
-	// Return appropriate exit code
-	if policyResult != nil && policyResult.Decision == policy.DecisionDeny {
-		os.Exit(2)
-	}
-	
 	return nil
 }
 
-func outputMarkdown(result *estimation.EstimationResult, policyResult *policy.EvaluationResult) error {
+func outputMarkdown(result *estimation.EstimationResult, policyResult *policy.EvaluationResult, optimization *optimize.Report, diff *terracost.Diff, burnDowns []*budget.BurnDown, top int) error {
 	fmt.Println("## 💰 TerraCost Estimation Report")
 	fmt.Println()
 	fmt.Println("| Metric | Value |")
 	fmt.Println("|--------|-------|")
-	fmt.Printf("| **Monthly Cost (P50)** | $%s |\n", result.MonthlyCostP50.StringFixed(2))
-	fmt.Printf("| **Monthly Cost (P90)** | $%s |\n", result.MonthlyCostP90.StringFixed(2))
-	fmt.Printf("| **Confidence** | %.0f%% |\n", result.Confidence*100)
+	fmt.Printf("| **Monthly Cost (P50)** | %s |\n", currency.Format(result.MonthlyCostP50, result.Currency, 2))
+	fmt.Printf("| **Monthly Cost (P90)** | %s |\n", currency.Format(result.MonthlyCostP90, result.Currency, 2))
+	fmt.Printf("| **Confidence** | %.0f%% (pricing %.0f%%, usage %.0f%%, coverage %.0f%%) |\n", result.Confidence*100,
+		result.ConfidenceScores.Pricing*100, result.ConfidenceScores.Usage*100, result.ConfidenceScores.Coverage*100)
+	if sim := result.Simulation; sim != nil {
+		fmt.Printf("| **Simulated P10 / P50 / P90 / P99** | %s / %s / %s / %s (%d runs) |\n",
+			currency.Format(sim.P10, result.Currency, 2), currency.Format(sim.P50, result.Currency, 2),
+			currency.Format(sim.P90, result.Currency, 2), currency.Format(sim.P99, result.Currency, 2), sim.Iterations)
+	}
+	if result.Currency != currency.USD {
+		fmt.Printf("| **Currency** | %s (rates: %s) |\n", result.Currency, result.AuditTrail.ExchangeRates)
+	}
+	if result.Discounts != nil {
+		fmt.Printf("| **List Price (P50)** | %s before negotiated discounts |\n", currency.Format(result.Discounts.ListMonthlyCostP50, result.Currency, 2))
+	}
 	
 	if result.CarbonKgCO2 > 0 {
 		fmt.Printf("| **Carbon Emissions** | %.2f kg CO2 |\n", result.CarbonKgCO2)
@@ -395,109 +1582,568 @@ func outputMarkdown(result *estimation.EstimationResult, policyResult *policy.Ev
 	fmt.Println()
 	fmt.Println("### 📊 Cost Breakdown")
 	fmt.Println()
-	fmt.Println("| Resource | Service | Monthly Cost |")
-	fmt.Println("|----------|---------|--------------|")
+	fmt.Println("| Resource | Service | Qty | Monthly Cost |")
+	fmt.Println("|----------|---------|-----|--------------|")
 	
-	for _, driver := range result.CostDrivers {
-		if driver.MonthlyCostP50.GreaterThan(decimal.Zero) || driver.IsSymbolic {
-			cost := "$" + driver.MonthlyCostP50.StringFixed(2)
-			if driver.IsSymbolic {
+	for _, group := range topN(result.CostGroups, top) {
+		if group.MonthlyCostP50.GreaterThan(decimal.Zero) || group.IsSymbolic {
+			cost := currency.Format(group.MonthlyCostP50, result.Currency, 2)
+			if group.IsSymbolic {
 				cost = "⚠️ Unknown"
 			}
-			fmt.Printf("| %s | %s | %s |\n", driver.ResourceAddr, driver.Service, cost)
+			fmt.Printf("| %s | %s | %d | %s |\n", group.Key, group.Service, group.Quantity, cost)
+		}
+	}
+	
+	var unpriced, approximate []estimation.CostDriver
+	for _, d := range result.CostDrivers {
+		if d.ResolutionHints != nil {
+			unpriced = append(unpriced, d)
+		}
+		if d.MatchedVia != "" {
+			approximate = append(approximate, d)
+		}
+	}
+	if len(approximate) > 0 {
+		fmt.Println()
+		fmt.Println("### 🔀 Approximate Prices")
+		fmt.Println()
+		for _, d := range approximate {
+			fmt.Printf("- **%s** (%s): %s, %.0f%% pricing confidence\n", d.ResourceAddr, d.Description, d.MatchedVia, d.PricingConfidence*100)
+		}
+	}
+	if len(unpriced) > 0 {
+		fmt.Println()
+		fmt.Println("### 🔍 Unpriced Resources")
+		fmt.Println()
+		for _, d := range unpriced {
+			hints := d.ResolutionHints
+			fmt.Printf("- **%s** (%s %s, %s, %s): %s\n", d.ResourceAddr, hints.RateKey.Service, hints.RateKey.ProductFamily,
+				hints.RateKey.Region, hints.RateKey.Unit, hints.Message)
+			for _, c := range hints.Candidates {
+				diffs := make([]string, 0, len(c.Mismatched))
+				for _, k := range c.Mismatched {
+					switch {
+					case k == "unit":
+						diffs = append(diffs, fmt.Sprintf("unit `%s`", c.Unit))
+					case c.Attributes[k] == "":
+						diffs = append(diffs, fmt.Sprintf("no `%s`", k))
+					case hints.RateKey.Attributes[k] == "":
+						diffs = append(diffs, fmt.Sprintf("%s `%s` (not in the rate key)", k, c.Attributes[k]))
+					default:
+						diffs = append(diffs, fmt.Sprintf("%s `%s` (want `%s`)", k, c.Attributes[k], hints.RateKey.Attributes[k]))
+					}
+				}
+				fmt.Printf("  - nearest: %s at %s/%s\n", strings.Join(diffs, ", "), c.Price.String(), c.Unit)
+			}
+			if hints.SuggestedCommand != "" {
+				fmt.Printf("  - refresh pricing: `%s`\n", hints.SuggestedCommand)
+			}
+		}
+	}
+	
+	if len(result.CostByTag) > 0 {
+		fmt.Println()
+		fmt.Println("### 🏷️ Cost by Tag")
+		fmt.Println()
+		fmt.Println("| Tag | Value | Monthly Cost |")
+		fmt.Println("|-----|-------|--------------|")
+		for _, key := range sortedTagKeys(result.CostByTag) {
+			for _, tc := range estimation.SortedTagCosts(result.CostByTag[key]) {
+				fmt.Printf("| %s | %s | %s |\n", key, tc.Value, currency.Format(tc.MonthlyCostP50, result.Currency, 2))
+			}
+		}
+	}
+	
+	if len(result.CostByModule) > 0 {
+		fmt.Println()
+		fmt.Println("### 🧩 Cost by Module")
+		fmt.Println()
+		walkModules(result.CostByModule, 0, func(m estimation.ModuleCost, depth int) {
+			fmt.Printf("%s- **%s** — %s (%d resources)\n", strings.Repeat("  ", depth), m.Name,
+				currency.Format(m.MonthlyCostP50, result.Currency, 2), m.Resources)
+		})
+	}
+	
+	if result.Discounts != nil {
+		outputDiscountsMarkdown(result)
+	}
+	
+	if diff != nil {
+		outputDiffMarkdown(diff)
+	}
+	outputBurnDownMarkdown(burnDowns)
+	
+	if len(optimization.Recommendations) > 0 {
+		fmt.Println()
+		fmt.Println("### 💡 Recommendations")
+		fmt.Println()
+		fmt.Println("| Resource | Recommendation | Monthly Savings |")
+		fmt.Println("|----------|----------------|-----------------|")
+		for _, r := range optimization.Recommendations {
+			fmt.Printf("| %s | %s: %s | %s |\n", r.ResourceAddr, r.Title, r.Detail, currency.Format(r.MonthlySavings, result.Currency, 2))
+		}
+		fmt.Println()
+		fmt.Printf("Potential savings: **%s/month**\n", currency.Format(optimization.MonthlySavings, result.Currency, 2))
+	}
+	
+	if policyResult != nil && len(policyResult.Violations) > 0 {
+		fmt.Println()
+		fmt.Println("### ❌ Policy Violations")
+		fmt.Println()
+		for _, v := range policyResult.Violations {
+			fmt.Printf("- **%s**: %s\n", v.PolicyName, v.Message)
+		}
+	}
+	
+	if policyResult != nil && len(policyResult.Warnings) > 0 {
+		fmt.Println()
+		fmt.Println("### ⚠️ Warnings")
+		fmt.Println()
+		for _, w := range policyResult.Warnings {
+			fmt.Printf("- %s\n", w.Message)
+		}
+	}
+	
+	return nil
+}
+
+// walkModules visits a module tree depth-first, parents before their children
+func walkModules(modules []estimation.ModuleCost, depth int, visit func(m estimation.ModuleCost, depth int)) {
+	for _, m := range modules {
+		visit(m, depth)
+		walkModules(m.Children, depth+1, visit)
+	}
+}
+
+// sortedTagKeys returns allocation tag keys in display order
+func sortedTagKeys(costByTag map[string]map[string]decimal.Decimal) []string {
+	keys := make([]string, 0, len(costByTag))
+	for k := range costByTag {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen-3] + "..."
+}
+
+// =============================================================================
+// PRICING COMMAND
+// =============================================================================
+
+func pricingCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "pricing",
+		Usage: "Manage pricing data",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "update",
+				Usage: "Update pricing data from cloud providers",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "provider",
+						Usage:    "Cloud provider (aws, azure, gcp)",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:  "region",
+						Value: "us-east-1",
+						Usage: "Region (or 'all' for all regions)",
+					},
+					&cli.StringFlag{
+						Name:  "memory-profile",
+						Value: "normal",
+						Usage: "Memory profile (low, normal, high)",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Value: false,
+						Usage: "Dry run (no database writes)",
+					},
+					&cli.BoolFlag{
+						Name:  "force",
+						Value: false,
+						Usage: "Re-ingest even if the offer versions are unchanged",
+					},
+					&cli.StringFlag{
+						Name:    "alert-webhook",
+						Usage:   "Webhook posted when the new snapshot raises prices above --alert-threshold",
+						EnvVars: []string{"TERRACOST_PRICE_ALERT_WEBHOOK"},
+					},
+					&cli.Float64Flag{
+						Name:  "alert-threshold",
+						Value: 5,
+						Usage: "Price increase percentage that triggers the alert webhook",
+					},
+				},
+				Action: runPricingUpdate,
+			},
+			{
+				Name:  "diff",
+				Usage: "Show rates added, removed and changed between two pricing snapshots",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "from",
+						Usage:    "Older snapshot ID",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "to",
+						Usage:    "Newer snapshot ID",
+						Required: true,
+					},
+					&cli.IntFlag{
+						Name:  "top",
+						Value: 20,
+						Usage: "Largest price changes listed in table output",
+					},
+					&cli.StringFlag{
+						Name:    "format",
+						Aliases: []string{"f"},
+						Value:   "table",
+						Usage:   "Output format (table, json)",
+					},
+				},
+				Action: runPricingDiff,
+			},
+			{
+				Name:  "prune",
+				Usage: "Delete old inactive pricing snapshots and their rates",
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:  "keep-last",
+						Value: 5,
+						Usage: "Newest snapshots kept per cloud, region and provider alias",
+					},
+					&cli.IntFlag{
+						Name:  "keep-days",
+						Value: 90,
+						Usage: "Snapshots created within this many days are kept",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Report reclaimable snapshots and rates without deleting",
+					},
+				},
+				Action: runPricingPrune,
+			},
+			{
+				Name:  "spot-import",
+				Usage: "Import spot price history (aws ec2 describe-spot-price-history --output json)",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "file",
+						Usage:    "Path to describe-spot-price-history JSON output",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "region",
+						Usage:    "Region the history was collected in",
+						Required: true,
+					},
+				},
+				Action: runSpotImport,
+			},
+			{
+				Name:  "export",
+				Usage: "Export active pricing to an offline bundle for --pricing-backend embedded",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "out",
+						Value: "prices.db",
+						Usage: "Bundle file to write",
+					},
+					&cli.StringSliceFlag{
+						Name:  "provider",
+						Value: cli.NewStringSlice("aws"),
+						Usage: "Cloud providers to include",
+					},
+					&cli.DurationFlag{
+						Name:  "spot-lookback",
+						Value: estimation.SpotLookback,
+						Usage: "Spot price history window to summarize",
+					},
+				},
+				Action: runPricingExport,
+			},
+			{
+				Name:  "validate",
+				Usage: "Validate pricing coverage",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "provider",
+						Usage:    "Cloud provider",
+						Required: true,
+					},
+					&cli.IntFlag{
+						Name:  "min-coverage",
+						Value: 80,
+						Usage: "Minimum coverage percentage",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					fmt.Println("Pricing validation not yet implemented")
+					return nil
+				},
+			},
+		},
+	}
+}
+
+func runPricingUpdate(c *cli.Context) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if provider := c.String("provider"); provider != "aws" {
+		return fmt.Errorf("pricing update does not support provider %q yet (supported: aws)", provider)
+	}
+
+	regions := []string{c.String("region")}
+	if c.String("region") == "all" {
+		regions = ingestion.NewAWSPricingAPIFetcher().SupportedRegions()
+	}
+
+	var streamCfg *ingestion.StreamingConfig
+	switch c.String("memory-profile") {
+	case "low":
+		streamCfg = ingestion.LowMemoryConfig()
+	case "high":
+		streamCfg = ingestion.HighMemoryConfig()
+	default:
+		streamCfg = ingestion.DefaultStreamingConfig()
+	}
+
+	streamer := ingestion.NewAWSOfferStreamer()
+	dryRun := c.Bool("dry-run")
+
+	var store *clickhouse.Store
+	var adapter *ingestion.ClickHouseAdapter
+	if !dryRun {
+		var err error
+		store, err = openStore(c)
+		if err != nil {
+			return fmt.Errorf("failed to connect to ClickHouse: %w", err)
+		}
+		defer store.Close()
+		adapter = ingestion.NewClickHouseAdapter(store).WithBatchSize(streamCfg.BatchSize)
+		if webhook := c.String("alert-webhook"); webhook != "" {
+			adapter.WithPriceAlert(ingestion.NewPriceAlert(webhook, c.Float64("alert-threshold")))
+		}
+	}
+
+	for _, region := range regions {
+		if dryRun {
+			refs, err := streamer.ResolveOffers(ctx, region)
+			if err != nil {
+				return fmt.Errorf("failed to resolve offers for %s: %w", region, err)
+			}
+			count := 0
+			err = streamer.StreamOffers(ctx, refs, func(ingestion.PriceEntry) error {
+				count++
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("failed to stream offers for %s: %w", region, err)
+			}
+			fmt.Printf("%s: %d prices from %d offer files (dry run, nothing written)\n", region, count, len(refs))
+			continue
+		}
+
+		refreshed, err := ingestion.RefreshAWSRegion(ctx, streamer, adapter, region, c.Bool("force"))
+		if err != nil {
+			return err
+		}
+		if refreshed.UpToDate {
+			fmt.Printf("%s: pricing is up to date (snapshot %s)\n", region, refreshed.SnapshotID)
+			continue
+		}
+		result := refreshed.Ingestion
+		fmt.Printf("%s: snapshot %s activated (%d rate keys, %d prices, %s)\n",
+			region, result.SnapshotID, result.RateKeyCount, result.PriceCount, result.Duration.Round(time.Second))
+		if n := len(result.PriceIncreases); n > 0 {
+			fmt.Printf("%s: %d prices rose more than %.1f%% since snapshot %s\n", region, n, c.Float64("alert-threshold"), result.PreviousSnapshotID)
+		}
+		if result.AlertError != "" {
+			fmt.Fprintf(os.Stderr, "⚠️  %s: price alert failed: %s\n", region, result.AlertError)
+		}
+	}
+
+	return nil
+}
+
+func runSpotImport(c *cli.Context) error {
+	ctx := context.Background()
+
+	f, err := os.Open(c.String("file"))
+	if err != nil {
+		return fmt.Errorf("failed to open spot price history: %w", err)
+	}
+	defer f.Close()
+
+	prices, err := ingestion.ParseSpotPriceHistory(f, c.String("region"))
+	if err != nil {
+		return err
+	}
+
+	store, err := openStore(c)
+	if err != nil {
+		return fmt.Errorf("failed to connect to ClickHouse: %w", err)
+	}
+	defer store.Close()
+
+	if err := store.BulkInsertSpotPrices(ctx, prices); err != nil {
+		return fmt.Errorf("failed to import spot prices: %w", err)
+	}
+	fmt.Printf("%s: imported %d spot prices\n", c.String("region"), len(prices))
+	return nil
+}
+
+func runPricingExport(c *cli.Context) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	store, err := openStore(c)
+	if err != nil {
+		return fmt.Errorf("failed to connect to ClickHouse: %w", err)
+	}
+	defer store.Close()
+
+	out := c.String("out")
+	writer, err := embedded.Create(out, "clickhouse")
+	if err != nil {
+		return err
+	}
+
+	for _, provider := range c.StringSlice("provider") {
+		cloud := clickhouse.CloudProvider(provider)
+		err := store.ExportActiveRates(ctx, cloud, writer.PutRate)
+		if err == nil {
+			err = store.ExportSpotRates(ctx, cloud, c.Duration("spot-lookback"), writer.PutSpotRate)
 		}
+		if err != nil {
+			writer.Close()
+			os.Remove(out)
+			return fmt.Errorf("failed to export %s pricing: %w", provider, err)
+		}
+	}
+
+	info, err := writer.Close()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s: %d rates, %d spot summaries (%s)\n",
+		out, info.RateCount, info.SpotRateCount, strings.Join(info.Clouds, ", "))
+	return nil
+}
+
+func runPricingDiff(c *cli.Context) error {
+	fromID, err := uuid.Parse(c.String("from"))
+	if err != nil {
+		return fmt.Errorf("invalid --from snapshot ID: %w", err)
+	}
+	toID, err := uuid.Parse(c.String("to"))
+	if err != nil {
+		return fmt.Errorf("invalid --to snapshot ID: %w", err)
+	}
+
+	store, err := openStore(c)
+	if err != nil {
+		return fmt.Errorf("failed to connect to ClickHouse: %w", err)
+	}
+	defer store.Close()
+
+	diff, err := store.DiffSnapshots(c.Context, fromID, toID)
+	if err != nil {
+		return err
 	}
-	
-	if policyResult != nil && len(policyResult.Violations) > 0 {
-		fmt.Println()
-		fmt.Println("### ❌ Policy Violations")
-		fmt.Println()
-		for _, v := range policyResult.Violations {
-			fmt.Printf("- **%s**: %s\n", v.PolicyName, v.Message)
-		}
+	if c.String("format") == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diff)
 	}
-	
-	if policyResult != nil && len(policyResult.Warnings) > 0 {
-		fmt.Println()
-		fmt.Println("### ⚠️ Warnings")
-		fmt.Println()
-		for _, w := range policyResult.Warnings {
-			fmt.Printf("- %s\n", w.Message)
+
+	fmt.Printf("Pricing diff %s/%s: %s (%s) → %s (%s)\n", diff.From.Cloud, diff.From.Region,
+		diff.From.ID, diff.From.ValidFrom.Format("2006-01-02"), diff.To.ID, diff.To.ValidFrom.Format("2006-01-02"))
+	fmt.Printf("%d added, %d removed, %d changed\n\n", len(diff.Added), len(diff.Removed), len(diff.Changed))
+	if len(diff.Services) == 0 {
+		fmt.Println("No rate differences.")
+		return nil
+	}
+
+	fmt.Printf("%-28s %7s %7s %7s %7s %9s %9s\n", "SERVICE", "ADDED", "REMOVED", "UP", "DOWN", "AVG", "MAX UP")
+	for _, s := range diff.Services {
+		fmt.Printf("%-28s %7d %7d %7d %7d %8.1f%% %8.1f%%\n", truncate(s.Service, 28),
+			s.Added, s.Removed, s.Increased, s.Decreased, s.AvgChangePercent, s.MaxIncreasePercent)
+	}
+
+	changed := append([]clickhouse.RateChange(nil), diff.Changed...)
+	sort.SliceStable(changed, func(i, j int) bool {
+		return math.Abs(changed[i].ChangePercent) > math.Abs(changed[j].ChangePercent)
+	})
+	if top := c.Int("top"); top > 0 && len(changed) > top {
+		changed = changed[:top]
+	}
+	if len(changed) > 0 {
+		fmt.Printf("\nLargest price changes:\n")
+		for _, r := range changed {
+			fmt.Printf("  %+7.1f%%  %s → %s per %s  %s\n", r.ChangePercent,
+				r.OldPrice.String(), r.NewPrice.String(), r.Unit, truncate(rateChangeLabel(r), 70))
 		}
 	}
-	
 	return nil
 }
 
-func truncate(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
+func runPricingPrune(c *cli.Context) error {
+	store, err := openStore(c)
+	if err != nil {
+		return fmt.Errorf("failed to connect to ClickHouse: %w", err)
 	}
-	return s[:maxLen-3] + "..."
-}
+	defer store.Close()
 
-// =============================================================================
-// PRICING COMMAND
-// =============================================================================
+	retention := clickhouse.RetentionPolicy{KeepLast: c.Int("keep-last"), KeepDays: c.Int("keep-days")}
+	result, err := store.PruneSnapshots(c.Context, retention, c.Bool("dry-run"))
+	if err != nil {
+		return err
+	}
+	for _, snapshot := range result.Snapshots {
+		fmt.Printf("  %s  %s/%s", snapshot.ID, snapshot.Cloud, snapshot.Region)
+		if snapshot.ProviderAlias != "" && snapshot.ProviderAlias != "default" {
+			fmt.Printf(" (%s)", snapshot.ProviderAlias)
+		}
+		if snapshot.OrgID != "" {
+			fmt.Printf("  org %s", snapshot.OrgID)
+		}
+		fmt.Printf("  created %s\n", snapshot.CreatedAt.Format("2006-01-02"))
+	}
+	verb := "Pruned"
+	if result.DryRun {
+		verb = "Would prune"
+	}
+	fmt.Printf("%s %d snapshots and %d rates; %d snapshots kept\n", verb, len(result.Snapshots), result.Rates, result.Kept)
+	return nil
+}
 
-func pricingCommand() *cli.Command {
-	return &cli.Command{
-		Name:  "pricing",
-		Usage: "Manage pricing data",
-		Subcommands: []*cli.Command{
-			{
-				Name:  "update",
-				Usage: "Update pricing data from cloud providers",
-				Flags: []cli.Flag{
-					&cli.StringFlag{
-						Name:     "provider",
-						Usage:    "Cloud provider (aws, azure, gcp)",
-						Required: true,
-					},
-					&cli.StringFlag{
-						Name:  "region",
-						Value: "us-east-1",
-						Usage: "Region (or 'all' for all regions)",
-					},
-					&cli.StringFlag{
-						Name:  "memory-profile",
-						Value: "normal",
-						Usage: "Memory profile (low, normal, high)",
-					},
-					&cli.BoolFlag{
-						Name:  "dry-run",
-						Value: false,
-						Usage: "Dry run (no database writes)",
-					},
-				},
-				Action: func(c *cli.Context) error {
-					fmt.Println("Pricing update not yet implemented in this version")
-					fmt.Println("Use the existing pricing ingestion commands")
-					return nil
-				},
-			},
-			{
-				Name:  "validate",
-				Usage: "Validate pricing coverage",
-				Flags: []cli.Flag{
-					&cli.StringFlag{
-						Name:     "provider",
-						Usage:    "Cloud provider",
-						Required: true,
-					},
-					&cli.IntFlag{
-						Name:  "min-coverage",
-						Value: 80,
-						Usage: "Minimum coverage percentage",
-					},
-				},
-				Action: func(c *cli.Context) error {
-					fmt.Println("Pricing validation not yet implemented")
-					return nil
-				},
-			},
-		},
+// rateChangeLabel names a rate by its service, product family and sorted attributes
+func rateChangeLabel(r clickhouse.RateChange) string {
+	keys := make([]string, 0, len(r.Attributes))
+	for k := range r.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := []string{r.Service, r.ProductFamily}
+	for _, k := range keys {
+		parts = append(parts, k+"="+r.Attributes[k])
 	}
+	if r.TierMin != "" {
+		parts = append(parts, "tier≥"+r.TierMin)
+	}
+	return strings.Join(parts, " ")
 }
 
 // =============================================================================
@@ -541,6 +2187,7 @@ func policyCommand() *cli.Command {
 					return nil
 				},
 			},
+			policySimulateCommand(),
 		},
 	}
 }
@@ -560,6 +2207,11 @@ func serveCommand() *cli.Command {
 				Usage:   "API server port",
 				EnvVars: []string{"TERRACOST_PORT"},
 			},
+			&cli.IntFlag{
+				Name:    "grpc-port",
+				Usage:   "Also serve the gRPC API on this port (0: disabled)",
+				EnvVars: []string{"TERRACOST_GRPC_PORT"},
+			},
 			&cli.StringFlag{
 				Name:    "cors-origins",
 				Value:   "*",
@@ -571,38 +2223,496 @@ func serveCommand() *cli.Command {
 				Usage:   "OPA endpoint for policy evaluation",
 				EnvVars: []string{"OPA_ENDPOINT"},
 			},
+			&cli.StringFlag{
+				Name:    "policy-file",
+				Usage:   "Policy file (default: " + policy.DefaultPolicyFile + " in the working directory, if present)",
+				EnvVars: []string{"TERRACOST_POLICY_FILE"},
+			},
+			&cli.StringFlag{
+				Name:    "waiver-file",
+				Usage:   "Policy waiver file (default: " + policy.DefaultWaiverFile + " in the working directory, if present)",
+				EnvVars: []string{"TERRACOST_WAIVER_FILE"},
+			},
+			&cli.StringFlag{
+				Name:    "opa-package",
+				Value:   policy.DefaultOPAPackage,
+				Usage:   "Rego package whose deny/warn rules are evaluated",
+				EnvVars: []string{"OPA_PACKAGE"},
+			},
+			&cli.StringFlag{
+				Name:    "opa-failure-mode",
+				Value:   string(policy.OPAFailOpen),
+				Usage:   "Decision when OPA is unavailable: open (warn) or closed (deny)",
+				EnvVars: []string{"OPA_FAILURE_MODE"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "dependency",
+				Usage:   "Critical service checked for readiness: NAME=URL of its health endpoint (repeatable)",
+				EnvVars: []string{"TERRACOST_DEPENDENCIES"},
+			},
+			&cli.DurationFlag{
+				Name:    "health-check-interval",
+				Value:   health.DefaultInterval,
+				Usage:   "How often ClickHouse, OPA and --dependency services are checked",
+				EnvVars: []string{"TERRACOST_HEALTH_CHECK_INTERVAL"},
+			},
+			&cli.StringFlag{
+				Name:    "fx-rates",
+				Usage:   "Exchange rates for non-USD requests: a YAML/JSON file or 'ecb' (fetched at startup)",
+				EnvVars: []string{"TERRACOST_FX_RATES"},
+			},
+			&cli.StringFlag{
+				Name:    "discounts",
+				Usage:   "Negotiated discounts: a YAML/JSON file for every org or 'database' for each org's stored discounts",
+				EnvVars: []string{"TERRACOST_DISCOUNTS"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "notify",
+				Usage:   "Slack/Teams targets for estimates requested with notify: true (see estimate --notify)",
+				EnvVars: []string{"TERRACOST_NOTIFY"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "approval-notify",
+				Usage:   "Slack/Teams targets notified when an approval is requested for a denied estimate (repeatable)",
+				EnvVars: []string{"TERRACOST_APPROVAL_NOTIFY"},
+			},
+			&cli.DurationFlag{
+				Name:  "approval-ttl",
+				Value: api.DefaultApprovalTTL,
+				Usage: "How long an approval stays pending before it expires",
+			},
+			&cli.StringSliceFlag{
+				Name:    "approver",
+				Usage:   "Token subject who may decide approvals (repeatable; default: tokens issued with --approver)",
+				EnvVars: []string{"TERRACOST_APPROVERS"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "export-metrics",
+				Usage:   "Pushgateway URLs or textfile collector files receiving the cost of every estimate with a project (see estimate --export-metrics)",
+				EnvVars: []string{"TERRACOST_EXPORT_METRICS"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "event-bus",
+				Usage:   "Publish estimate lifecycle events to NATS over plain TCP, without TLS (nats://host:4222[/subject-prefix]), or to Kafka through a Confluent REST Proxy only (kafka+http(s)://proxy:8082/topic) (repeatable)",
+				EnvVars: []string{"TERRACOST_EVENT_BUS"},
+			},
+			&cli.IntFlag{
+				Name:    "job-workers",
+				Value:   api.DefaultJobWorkers,
+				Usage:   "Concurrent async estimate jobs (POST /api/v1/estimate/async)",
+				EnvVars: []string{"TERRACOST_JOB_WORKERS"},
+			},
+			&cli.DurationFlag{
+				Name:    "job-timeout",
+				Value:   api.DefaultJobTimeout,
+				Usage:   "Longest an async estimate job may run",
+				EnvVars: []string{"TERRACOST_JOB_TIMEOUT"},
+			},
+			&cli.DurationFlag{
+				Name:    "job-retention",
+				Value:   api.DefaultJobRetention,
+				Usage:   "How long async job status and results are kept",
+				EnvVars: []string{"TERRACOST_JOB_RETENTION"},
+			},
+			&cli.DurationFlag{
+				Name:    "estimate-retention",
+				Value:   api.DefaultEstimateRetention,
+				Usage:   "How long estimates saved with persist are kept (GET /api/v1/estimates/{id})",
+				EnvVars: []string{"TERRACOST_ESTIMATE_RETENTION"},
+			},
+			&cli.IntFlag{
+				Name:    "snapshot-keep-last",
+				Usage:   "Prune pricing snapshots on a schedule, keeping this many newest per cloud, region and alias (0 with --snapshot-keep-days 0 disables)",
+				EnvVars: []string{"TERRACOST_SNAPSHOT_KEEP_LAST"},
+			},
+			&cli.IntFlag{
+				Name:    "snapshot-keep-days",
+				Usage:   "Prune pricing snapshots on a schedule, keeping those created within this many days",
+				EnvVars: []string{"TERRACOST_SNAPSHOT_KEEP_DAYS"},
+			},
+			&cli.DurationFlag{
+				Name:    "snapshot-retention-interval",
+				Value:   api.DefaultSnapshotRetentionInterval,
+				Usage:   "How often old pricing snapshots are pruned",
+				EnvVars: []string{"TERRACOST_SNAPSHOT_RETENTION_INTERVAL"},
+			},
+			&cli.BoolFlag{
+				Name:    "auto-migrate",
+				Usage:   "Apply pending ClickHouse schema migrations before serving (see terracost db migrate)",
+				EnvVars: []string{"TERRACOST_AUTO_MIGRATE"},
+			},
+			&cli.StringFlag{
+				Name:    "pricing-refresh-schedule",
+				Usage:   "Cron spec (e.g. \"0 3 * * *\", @daily, @every 12h) on which pricing is re-ingested; unset disables",
+				EnvVars: []string{"TERRACOST_PRICING_REFRESH_SCHEDULE"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "pricing-refresh-regions",
+				Value:   cli.NewStringSlice("aws:us-east-1"),
+				Usage:   "Regions refreshed on the schedule, as PROVIDER:REGION or REGION for aws ('aws:all' for every region)",
+				EnvVars: []string{"TERRACOST_PRICING_REFRESH_REGIONS"},
+			},
+			&cli.StringFlag{
+				Name:    "price-alert-webhook",
+				Usage:   "Webhook posted when a scheduled refresh raises prices above --price-alert-threshold",
+				EnvVars: []string{"TERRACOST_PRICE_ALERT_WEBHOOK"},
+			},
+			&cli.Float64Flag{
+				Name:  "price-alert-threshold",
+				Value: 5,
+				Usage: "Price increase percentage that triggers the alert webhook",
+			},
+			&cli.StringFlag{
+				Name:    "auth-secret",
+				Usage:   "Require bearer tokens signed with this secret and scope data by their org (see terracost token)",
+				EnvVars: []string{"TERRACOST_AUTH_SECRET"},
+			},
+			&cli.StringFlag{
+				Name:    "usage-profiles",
+				Usage:   "YAML/JSON file whose profiles section defines extra environment usage profiles",
+				EnvVars: []string{"TERRACOST_USAGE_PROFILES"},
+			},
+			&cli.StringFlag{
+				Name:    "plugin-dir",
+				Usage:   "Directory of terracost-mapper-* plugin executables mapping extra resource types",
+				EnvVars: []string{"TERRACOST_PLUGIN_DIR"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "placeholder-cost",
+				Usage:   "Price unsupported resource types at an assumed monthly USD cost: AMOUNT for all, or TYPE=AMOUNT (repeatable; 0 lists them unpriced)",
+				EnvVars: []string{"TERRACOST_PLACEHOLDER_COST"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "calibrate",
+				Usage:   "Calibrate usage of updated resources from cloudwatch and/or cost-explorer (AWS credentials from the environment)",
+				EnvVars: []string{"TERRACOST_CALIBRATE"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "aws-metadata",
+				Usage:   "Resolve AMI platforms, root volumes and instance hardware from embedded, ec2 (live API, AWS credentials from the environment) and/or dataset files",
+				EnvVars: []string{"TERRACOST_AWS_METADATA"},
+			},
+			&cli.StringFlag{
+				Name:    "org-policy-dir",
+				Usage:   "Directory of <org>.yaml policy files applied to that org's estimates",
+				EnvVars: []string{"TERRACOST_ORG_POLICY_DIR"},
+			},
+			&cli.IntFlag{
+				Name:    "max-plan-resources",
+				Value:   api.DefaultMaxPlanResources,
+				Usage:   "Reject plans with more resources than this; 0 is unlimited",
+				EnvVars: []string{"TERRACOST_MAX_PLAN_RESOURCES"},
+			},
+			&cli.DurationFlag{
+				Name:    "max-snapshot-age",
+				Usage:   "Estimates priced from older snapshots get a SNAPSHOT_STALE issue; 0 disables",
+				EnvVars: []string{"TERRACOST_MAX_SNAPSHOT_AGE"},
+			},
+			&cli.Int64Flag{
+				Name:    "max-request-size",
+				Value:   api.DefaultConfig().MaxRequestSize,
+				Usage:   "Largest request body in bytes",
+				EnvVars: []string{"TERRACOST_MAX_REQUEST_SIZE"},
+			},
+			&cli.DurationFlag{
+				Name:    "read-timeout",
+				Value:   api.DefaultConfig().ReadTimeout,
+				Usage:   "Longest a client may take to send a request",
+				EnvVars: []string{"TERRACOST_READ_TIMEOUT"},
+			},
+			&cli.DurationFlag{
+				Name:    "write-timeout",
+				Value:   api.DefaultConfig().WriteTimeout,
+				Usage:   "Longest a request may take to answer",
+				EnvVars: []string{"TERRACOST_WRITE_TIMEOUT"},
+			},
+			&cli.DurationFlag{
+				Name:    "idle-timeout",
+				Value:   api.DefaultConfig().IdleTimeout,
+				Usage:   "Idle keep-alive connections are closed after this",
+				EnvVars: []string{"TERRACOST_IDLE_TIMEOUT"},
+			},
+			&cli.DurationFlag{
+				Name:    "drain-delay",
+				Value:   api.DefaultConfig().DrainDelay,
+				Usage:   "On SIGTERM, how long /ready fails before the listener closes so load balancers stop routing",
+				EnvVars: []string{"TERRACOST_DRAIN_DELAY"},
+			},
+			&cli.DurationFlag{
+				Name:    "shutdown-timeout",
+				Value:   api.DefaultConfig().ShutdownTimeout,
+				Usage:   "Longest in-flight requests, RPCs and async jobs get to finish on shutdown",
+				EnvVars: []string{"TERRACOST_SHUTDOWN_TIMEOUT"},
+			},
+			&cli.Float64Flag{
+				Name:    "rate-limit",
+				Value:   10,
+				Usage:   "Requests per second per client (verified tenant, else IP), over REST and gRPC; 0 disables",
+				EnvVars: []string{"TERRACOST_RATE_LIMIT"},
+			},
+			&cli.IntFlag{
+				Name:    "rate-burst",
+				Value:   20,
+				Usage:   "Requests a client may send at once before --rate-limit applies",
+				EnvVars: []string{"TERRACOST_RATE_BURST"},
+			},
+			&cli.IntFlag{
+				Name:    "daily-estimate-quota",
+				Usage:   "Estimates per client per UTC day; 0 is unlimited",
+				EnvVars: []string{"TERRACOST_DAILY_ESTIMATE_QUOTA"},
+			},
+			&cli.StringFlag{
+				Name:    "audit-hmac-secret",
+				Usage:   "Record every estimate in the audit log, signed with HMAC-SHA256 and this secret",
+				EnvVars: []string{"TERRACOST_AUDIT_HMAC_SECRET"},
+			},
+			&cli.StringFlag{
+				Name:    "audit-ed25519-key",
+				Usage:   "Record every estimate in the audit log, signed with this base64 Ed25519 key file",
+				EnvVars: []string{"TERRACOST_AUDIT_ED25519_KEY"},
+			},
 		},
 		Action: runServe,
 	}
 }
 
+// newAuditSigner returns the audit log signer for --audit-hmac-secret or --audit-ed25519-key
+func newAuditSigner(c *cli.Context) (audit.Signer, error) {
+	secret, keyFile := c.String("audit-hmac-secret"), c.String("audit-ed25519-key")
+	switch {
+	case secret != "" && keyFile != "":
+		return nil, fmt.Errorf("--audit-hmac-secret and --audit-ed25519-key are mutually exclusive")
+	case secret != "":
+		return audit.NewHMACSigner([]byte(secret)), nil
+	case keyFile != "":
+		key, err := audit.LoadEd25519Key(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		return audit.NewEd25519Signer(key), nil
+	}
+	return nil, nil
+}
+
 func runServe(c *cli.Context) error {
 	// Connect to ClickHouse
-	store, err := clickhouse.NewStore(&clickhouse.Config{
-		Host:     c.String("clickhouse-host"),
-		Port:     c.Int("clickhouse-port"),
-		Database: c.String("clickhouse-database"),
-		Username: c.String("clickhouse-user"),
-		Password: c.String("clickhouse-password"),
-	})
+	store, err := openStore(c)
 	if err != nil {
 		return fmt.Errorf("failed to connect to ClickHouse: %w", err)
 	}
 	defer store.Close()
 
+	if c.Bool("auto-migrate") {
+		applied, err := store.Migrate(c.Context)
+		if err != nil {
+			return fmt.Errorf("schema migration failed: %w", err)
+		}
+		for _, m := range applied {
+			fmt.Printf("🗄️  Applied schema migration %03d_%s\n", m.Version, m.Name)
+		}
+	}
+
 	// Parse CORS origins
 	corsOrigins := strings.Split(c.String("cors-origins"), ",")
 	for i := range corsOrigins {
 		corsOrigins[i] = strings.TrimSpace(corsOrigins[i])
 	}
 
+	opaFailureMode, err := policy.ParseOPAFailureMode(c.String("opa-failure-mode"))
+	if err != nil {
+		return err
+	}
+
+	dependencies, err := parseDependencies(c.StringSlice("dependency"))
+	if err != nil {
+		return err
+	}
+
+	policies, err := loadPolicyFile(c.String("policy-file"))
+	if err != nil {
+		return err
+	}
+	waivers, err := loadWaiverFile(c.String("waiver-file"))
+	if err != nil {
+		return err
+	}
+
+	orgPolicies, err := loadOrgPolicies(c.String("org-policy-dir"))
+	if err != nil {
+		return err
+	}
+
+	var usageProfiles []usage.Profile
+	if path := c.String("usage-profiles"); path != "" {
+		if usageProfiles, err = usage.LoadProfiles(path); err != nil {
+			return err
+		}
+	}
+	var mappers []billing.ResourceMapper
+	if dir := c.String("plugin-dir"); dir != "" {
+		if mappers, err = plugin.Load(dir, plugin.DefaultTimeout); err != nil {
+			return err
+		}
+	}
+	var placeholderCosts *billing.PlaceholderCosts
+	if values := c.StringSlice("placeholder-cost"); len(values) > 0 {
+		costs, err := parsePlaceholderCosts(values)
+		if err != nil {
+			return err
+		}
+		placeholderCosts = &costs
+	}
+	auth := authVerifier(c)
+
+	var calibrator *calibration.Calibrator
+	if names := c.StringSlice("calibrate"); len(names) > 0 {
+		if calibrator, err = newCalibrator(names); err != nil {
+			return err
+		}
+	}
+
+	var retention *clickhouse.RetentionPolicy
+	if keepLast, keepDays := c.Int("snapshot-keep-last"), c.Int("snapshot-keep-days"); keepLast != 0 || keepDays != 0 {
+		retention = &clickhouse.RetentionPolicy{KeepLast: keepLast, KeepDays: keepDays}
+		if err := retention.Validate(); err != nil {
+			return err
+		}
+	}
+
+	var pricingRefresh *api.PricingRefreshConfig
+	if spec := c.String("pricing-refresh-schedule"); spec != "" {
+		if pricingRefresh, err = newPricingRefresh(spec, c.StringSlice("pricing-refresh-regions")); err != nil {
+			return err
+		}
+		if webhook := c.String("price-alert-webhook"); webhook != "" {
+			pricingRefresh.PriceAlert = ingestion.NewPriceAlert(webhook, c.Float64("price-alert-threshold"))
+		}
+	}
+
+	var enricher *awsmeta.Enricher
+	if names := c.StringSlice("aws-metadata"); len(names) > 0 {
+		sources, err := awsmeta.NewSources(names)
+		if err != nil {
+			return err
+		}
+		enricher = awsmeta.NewEnricher(sources...)
+	}
+
+	notifiers, err := notify.ParseAll(c.StringSlice("notify"))
+	if err != nil {
+		return err
+	}
+	exporters, err := metrics.ParseAll(c.StringSlice("export-metrics"))
+	if err != nil {
+		return err
+	}
+	publishers, err := events.ParseAll(c.StringSlice("event-bus"))
+	if err != nil {
+		return err
+	}
+	approvalNotifiers, err := notify.ParseAll(c.StringSlice("approval-notify"))
+	if err != nil {
+		return err
+	}
+	pricingAliases, err := estimation.ParseAliasRoutes(c.StringSlice("pricing-alias"))
+	if err != nil {
+		return err
+	}
+
+	var fxRates *currency.Table
+	if source := c.String("fx-rates"); source != "" {
+		if fxRates, err = currency.Load(c.Context, source); err != nil {
+			return err
+		}
+	}
+
+	var discounts discount.Provider
+	if source := c.String("discounts"); source != "" {
+		if discounts, err = loadDiscounts(source, store); err != nil {
+			return err
+		}
+	}
+
+	carbonStore, err := openCarbonStore(c)
+	if err != nil {
+		return err
+	}
+
+	auditSigner, err := newAuditSigner(c)
+	if err != nil {
+		return err
+	}
+
 	// Create and start API server
 	server := api.NewServer(store, &api.Config{
-		Port:        c.Int("port"),
-		CORSOrigins: corsOrigins,
-		OPAEndpoint: c.String("opa-endpoint"),
+		Port:           c.Int("port"),
+		CORSOrigins:    corsOrigins,
+		OPAEndpoint:    c.String("opa-endpoint"),
+		OPAPackage:     c.String("opa-package"),
+		OPAFailureMode: opaFailureMode,
+		Policies:       policies,
+		Waivers:        waivers,
+		ExchangeRates:  fxRates,
+		Discounts:      discounts,
+		PricingAliases: pricingAliases,
+		Notifiers:      notifiers,
+		ApprovalNotifiers: approvalNotifiers,
+		ApprovalTTL:    c.Duration("approval-ttl"),
+		Approvers:      c.StringSlice("approver"),
+		Metrics:        exporters,
+		Events:         publishers,
+		CarbonStore:    carbonStore,
+		UsageProfiles:  usageProfiles,
+		Calibrator:     calibrator,
+		Enricher:       enricher,
+		AuditSigner:    auditSigner,
+		JobWorkers:     c.Int("job-workers"),
+		JobTimeout:     c.Duration("job-timeout"),
+		JobRetention:   c.Duration("job-retention"),
+		Auth:           auth,
+		OrgPolicies:    orgPolicies,
+		Mappers:        mappers,
+
+		Dependencies:        dependencies,
+		HealthCheckInterval: c.Duration("health-check-interval"),
+
+		PlaceholderCosts:  placeholderCosts,
+		EstimateRetention: c.Duration("estimate-retention"),
+
+		SnapshotRetention:         retention,
+		SnapshotRetentionInterval: c.Duration("snapshot-retention-interval"),
+		PricingRefresh:            pricingRefresh,
+
+		MaxPlanResources: c.Int("max-plan-resources"),
+		MaxSnapshotAge:   c.Duration("max-snapshot-age"),
+
+		MaxRequestSize:  c.Int64("max-request-size"),
+		ReadTimeout:     c.Duration("read-timeout"),
+		WriteTimeout:    c.Duration("write-timeout"),
+		IdleTimeout:     c.Duration("idle-timeout"),
+		DrainDelay:      c.Duration("drain-delay"),
+		ShutdownTimeout: c.Duration("shutdown-timeout"),
+
+		RateLimit:          c.Float64("rate-limit"),
+		RateBurst:          c.Int("rate-burst"),
+		DailyEstimateQuota: c.Int("daily-estimate-quota"),
 	})
 
+	// gRPC shares the REST server's pipeline and stops after it
+	if port := c.Int("grpc-port"); port > 0 {
+		grpcServer := grpcapi.NewServer(server, store, &grpcapi.Config{
+			Port:           port,
+			MaxMessageSize: grpcapi.DefaultConfig().MaxMessageSize,
+			Auth:           auth,
+		})
+		go func() {
+			if err := grpcServer.Start(); err != nil {
+				fmt.Fprintf(os.Stderr, "gRPC server stopped: %v\n", err)
+			}
+		}()
+		defer grpcServer.Stop(c.Duration("shutdown-timeout"))
+	}
+
 	return server.StartWithGracefulShutdown()
 }
 
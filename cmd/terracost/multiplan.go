@@ -0,0 +1,297 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/shopspring/decimal"
+	"github.com/urfave/cli/v2"
+
+	"terraform-cost/decision/currency"
+	"terraform-cost/decision/policy"
+	"terraform-cost/integrations/metrics"
+	tcerrors "terraform-cost/pkg/errors"
+	"terraform-cost/pkg/terracost"
+)
+
+// =============================================================================
+// MULTI-PLAN ESTIMATES
+// A --plan glob estimates every matching plan concurrently. The consolidated
+// report has per-plan subtotals and a grand total, and policy is evaluated
+// against the total as well as each plan.
+// =============================================================================
+
+// multiPlanReport is the consolidated estimate of the plans matching a glob
+type multiPlanReport struct {
+	Currency       string             `json:"currency"`
+	MonthlyCostP50 decimal.Decimal    `json:"monthly_cost_p50"`
+	MonthlyCostP90 decimal.Decimal    `json:"monthly_cost_p90"`
+	CarbonKgCO2    float64            `json:"carbon_kg_co2"`
+	Confidence     float64            `json:"confidence"`
+	IsIncomplete   bool               `json:"is_incomplete"`
+	ResourceCount  int                `json:"resource_count"`
+	PolicyResult   string             `json:"policy_result,omitempty"` // Decision on the total
+	Violations     []policy.Violation `json:"violations,omitempty"`
+	Warnings       []policy.Warning   `json:"warnings,omitempty"`
+	Plans          []planSubtotal     `json:"plans"`
+}
+
+// planSubtotal is one plan's part of a multi-plan estimate
+type planSubtotal struct {
+	Name           string             `json:"name"`
+	PlanFile       string             `json:"plan_file"`
+	MonthlyCostP50 decimal.Decimal    `json:"monthly_cost_p50"`
+	MonthlyCostP90 decimal.Decimal    `json:"monthly_cost_p90"`
+	Confidence     float64            `json:"confidence"`
+	ResourceCount  int                `json:"resource_count"`
+	PolicyResult   string             `json:"policy_result,omitempty"`
+	Violations     []policy.Violation `json:"violations,omitempty"`
+	Issues         []tcerrors.Issue   `json:"issues,omitempty"`
+}
+
+// isPlanGlob reports whether --plan is a pattern rather than a file
+func isPlanGlob(plan string) bool {
+	return strings.ContainsAny(plan, "*?[")
+}
+
+func runMultiPlan(c *cli.Context) error {
+	pattern := c.String("plan")
+	switch {
+	case len(c.StringSlice("notify")) > 0:
+		return fmt.Errorf("--notify is not supported with a --plan glob")
+	case c.String("explain") != "":
+		return fmt.Errorf("--explain does not apply to a --plan glob; explain one plan instead")
+	case c.String("format") == "junit", c.String("format") == "azure-devops":
+		return fmt.Errorf("--format %s is not supported with a --plan glob", c.String("format"))
+	}
+	gate, err := newExitGate(c)
+	if err != nil {
+		return err
+	}
+	exporters, err := metrics.ParseAll(c.StringSlice("export-metrics"))
+	if err != nil {
+		return err
+	}
+	parallelism := c.Int("parallelism")
+	if parallelism < 1 {
+		return fmt.Errorf("invalid --parallelism %d: must be at least 1", parallelism)
+	}
+
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid --plan pattern %q: %w", pattern, err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no plans match %s", pattern)
+	}
+	names := planNames(files)
+	fmt.Fprintf(os.Stderr, "📄 Estimating %d plans matching %s\n", len(files), pattern)
+
+	// Estimate the plans, at most parallelism at a time
+	runs := make([]*estimateRun, len(files))
+	errs := make([]error, len(files))
+	slots := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i, file := range files {
+		wg.Add(1)
+		go func(i int, file string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			runs[i], errs[i] = runPipelineFor(c, pipelineInput{
+				format:  c.String("plan-format"),
+				input:   file,
+				project: stackProject(c.String("project"), names[i]),
+				env:     c.String("env"),
+			})
+		}(i, file)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("plan %s: %w", names[i], err)
+		}
+	}
+
+	estimates := make([]metrics.Estimate, len(runs))
+	for i, run := range runs {
+		estimates[i] = metrics.Estimate{Project: metricsProject(c, names[i]), Environment: c.String("env"), Result: run.result}
+	}
+	exportMetrics(c, exporters, estimates...)
+
+	total, err := aggregateRuns(c, runs)
+	if err != nil {
+		return err
+	}
+	report := buildMultiPlanReport(files, names, runs, total)
+	switch c.String("format") {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	case "markdown":
+		outputMultiPlanMarkdown(report)
+	default:
+		outputMultiPlanTable(report)
+	}
+	if err != nil {
+		return err
+	}
+
+	issues := append([]tcerrors.Issue{}, total.Issues...)
+	for _, run := range runs {
+		issues = append(issues, run.issues...)
+	}
+	return gate.check(issues)
+}
+
+// planNames names each plan by its file name without extension, or by its
+// path where file names repeat (plans/*/plan.json)
+func planNames(files []string) []string {
+	base := make([]string, len(files))
+	seen := make(map[string]int)
+	for i, file := range files {
+		base[i] = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		seen[base[i]]++
+	}
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = base[i]
+		if seen[base[i]] > 1 {
+			names[i] = filepath.ToSlash(strings.TrimSuffix(file, filepath.Ext(file)))
+		}
+	}
+	return names
+}
+
+// aggregateRuns merges the plans' estimates and evaluates policy against the
+// total, with the baseline of --project
+func aggregateRuns(c *cli.Context, runs []*estimateRun) (*terracost.Aggregate, error) {
+	ctx := c.Context
+	pricingStore, store, closeStore, err := openPricingStore(c)
+	if err != nil {
+		return nil, err
+	}
+	defer closeStore()
+
+	project := c.String("project")
+	estimator, err := newEstimator(ctx, c, pricingStore, store, project)
+	if err != nil {
+		return nil, err
+	}
+	req := terracost.Request{Environment: c.String("env"), Project: project}
+	if !c.Bool("skip-policy") {
+		if req.Baseline, err = loadBaseline(ctx, c, store, project); err != nil {
+			return nil, err
+		}
+	}
+	results := make([]*terracost.Result, len(runs))
+	for i, run := range runs {
+		results[i] = &terracost.Result{Decomposition: run.decomposition, Estimation: run.result}
+	}
+	return estimator.Aggregate(ctx, results, req)
+}
+
+// buildMultiPlanReport lists the plans' subtotals under the aggregate
+func buildMultiPlanReport(files, names []string, runs []*estimateRun, total *terracost.Aggregate) *multiPlanReport {
+	est := total.Estimation
+	report := &multiPlanReport{
+		Currency:       est.Currency,
+		MonthlyCostP50: est.MonthlyCostP50,
+		MonthlyCostP90: est.MonthlyCostP90,
+		CarbonKgCO2:    est.CarbonKgCO2,
+		Confidence:     est.Confidence,
+		IsIncomplete:   est.IsIncomplete,
+	}
+	if total.Policy != nil {
+		report.PolicyResult = string(total.Policy.Decision)
+		report.Violations = total.Policy.Violations
+		report.Warnings = total.Policy.Warnings
+	}
+	for i, run := range runs {
+		sub := planSubtotal{
+			Name:           names[i],
+			PlanFile:       files[i],
+			MonthlyCostP50: run.result.MonthlyCostP50,
+			MonthlyCostP90: run.result.MonthlyCostP90,
+			Confidence:     run.result.Confidence,
+			ResourceCount:  run.graph.ResourceCount,
+			Issues:         run.issues,
+		}
+		if run.policyResult != nil {
+			sub.PolicyResult = string(run.policyResult.Decision)
+			sub.Violations = run.policyResult.Violations
+		}
+		report.ResourceCount += sub.ResourceCount
+		report.Plans = append(report.Plans, sub)
+	}
+	return report
+}
+
+func outputMultiPlanTable(report *multiPlanReport) {
+	fmt.Println()
+	fmt.Println("╔══════════════════════════════════════════════════════════════╗")
+	fmt.Printf("║  📄 MULTI-PLAN ESTIMATE: %-35s ║\n", fmt.Sprintf("%d plans", len(report.Plans)))
+	fmt.Println("╠══════════════════════════════════════════════════════════════╣")
+	for _, p := range report.Plans {
+		label := p.Name
+		if p.PolicyResult != "" && p.PolicyResult != string(policy.DecisionPass) {
+			label += " (" + p.PolicyResult + ")"
+		}
+		fmt.Printf("║  %-35s  %-22s ║\n", truncate(label, 35), currency.Format(p.MonthlyCostP50, report.Currency, 2))
+	}
+	fmt.Println("╠══════════════════════════════════════════════════════════════╣")
+	fmt.Printf("║  Monthly Cost (P50):    %-38s ║\n", currency.Format(report.MonthlyCostP50, report.Currency, 2))
+	fmt.Printf("║  Monthly Cost (P90):    %-38s ║\n", currency.Format(report.MonthlyCostP90, report.Currency, 2))
+	fmt.Printf("║  Confidence:            %-38s ║\n", fmt.Sprintf("%.0f%%", report.Confidence*100))
+	fmt.Printf("║  Resources:             %-38d ║\n", report.ResourceCount)
+
+	if report.PolicyResult != "" {
+		fmt.Println("╠══════════════════════════════════════════════════════════════╣")
+		fmt.Printf("║  Policy Result (total): %-38s ║\n", strings.ToUpper(report.PolicyResult))
+		for _, v := range report.Violations {
+			fmt.Printf("║  ❌ %-57s ║\n", truncate("total: "+v.Message, 57))
+		}
+		for _, p := range report.Plans {
+			for _, v := range p.Violations {
+				fmt.Printf("║  ❌ %-57s ║\n", truncate(p.Name+": "+v.Message, 57))
+			}
+		}
+	}
+	fmt.Println("╚══════════════════════════════════════════════════════════════╝")
+}
+
+func outputMultiPlanMarkdown(report *multiPlanReport) {
+	fmt.Println("## 📄 TerraCost Multi-Plan Report")
+	fmt.Println()
+	fmt.Println("| Plan | Resources | Monthly Cost (P50) | Monthly Cost (P90) | Confidence | Policy |")
+	fmt.Println("|------|-----------|--------------------|--------------------|------------|--------|")
+	for _, p := range report.Plans {
+		fmt.Printf("| %s | %d | %s | %s | %.0f%% | %s |\n", p.Name, p.ResourceCount,
+			currency.Format(p.MonthlyCostP50, report.Currency, 2), currency.Format(p.MonthlyCostP90, report.Currency, 2),
+			p.Confidence*100, p.PolicyResult)
+	}
+	fmt.Printf("| **Total** | %d | **%s** | **%s** | %.0f%% | %s |\n", report.ResourceCount,
+		currency.Format(report.MonthlyCostP50, report.Currency, 2), currency.Format(report.MonthlyCostP90, report.Currency, 2),
+		report.Confidence*100, report.PolicyResult)
+
+	var violations []string
+	for _, v := range report.Violations {
+		violations = append(violations, fmt.Sprintf("- **%s** (total): %s", v.PolicyName, v.Message))
+	}
+	for _, p := range report.Plans {
+		for _, v := range p.Violations {
+			violations = append(violations, fmt.Sprintf("- **%s** (%s): %s", v.PolicyName, p.Name, v.Message))
+		}
+	}
+	if len(violations) > 0 {
+		fmt.Println()
+		fmt.Println("### ❌ Policy Violations")
+		fmt.Println()
+		fmt.Println(strings.Join(violations, "\n"))
+	}
+}
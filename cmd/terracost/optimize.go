@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/currency"
+	"terraform-cost/decision/estimation"
+	"terraform-cost/decision/optimize"
+)
+
+// =============================================================================
+// OPTIMIZE COMMAND
+// Savings beyond the plan itself: commitments on always-on compute
+// =============================================================================
+
+func optimizeCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "optimize",
+		Usage: "Suggest ways to reduce cost",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "commitments",
+				Usage: "Plan 1yr/3yr commitments per instance family for a plan or saved estimates",
+				Flags: append(estimateFlags(),
+					&cli.BoolFlag{
+						Name:  "history",
+						Usage: "Plan from the latest saved estimate of every project and environment (filter with --project and --env)",
+					},
+					&cli.StringSliceFlag{
+						Name:  "discount",
+						Usage: "Commitment discount override as term=percent, e.g. 3yr=62 (repeatable)",
+					},
+					&cli.StringFlag{
+						Name:    "format",
+						Aliases: []string{"f"},
+						Value:   "table",
+						Usage:   "Output format (table, json)",
+					},
+				),
+				Action: runOptimizeCommitments,
+			},
+		},
+	}
+}
+
+func runOptimizeCommitments(c *cli.Context) error {
+	opts := optimize.CommitmentOptions{Discounts: make(map[string]float64)}
+	for _, s := range c.StringSlice("discount") {
+		term, discount, err := optimize.ParseTermDiscount(s)
+		if err != nil {
+			return err
+		}
+		opts.Discounts[term] = discount
+	}
+
+	var estimates []*estimation.EstimationResult
+	if c.Bool("history") {
+		var err error
+		if estimates, err = loadSavedEstimates(c); err != nil {
+			return err
+		}
+		if len(estimates) == 0 {
+			return fmt.Errorf("no saved estimates match the filter")
+		}
+	} else {
+		run, err := runPipeline(c)
+		if err != nil {
+			return err
+		}
+		estimates = []*estimation.EstimationResult{run.result}
+	}
+
+	plan := optimize.PlanCommitments(estimates, opts)
+	if c.String("format") == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(plan)
+	}
+	outputCommitmentPlan(plan)
+	return nil
+}
+
+// loadSavedEstimates returns the latest saved estimate per project and environment
+// --env only filters when given explicitly, since it defaults to dev.
+func loadSavedEstimates(c *cli.Context) ([]*estimation.EstimationResult, error) {
+	store, err := openStore(c)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	filter := clickhouse.EstimationFilter{Project: c.String("project")}
+	if c.IsSet("env") {
+		filter.Environment = c.String("env")
+	}
+	records, err := store.LatestEstimations(c.Context, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	estimates := make([]*estimation.EstimationResult, 0, len(records))
+	for _, rec := range records {
+		result, err := estimation.ResultFromRecord(rec)
+		if err != nil {
+			return nil, err
+		}
+		estimates = append(estimates, result)
+	}
+	return estimates, nil
+}
+
+func outputCommitmentPlan(plan *optimize.CommitmentPlan) {
+	fmt.Printf("\n💼 Commitment plan (%d estimate(s), %s)\n\n", plan.Estimations, plan.Currency)
+	if len(plan.Families) == 0 {
+		fmt.Println("No priced on-demand instance hours to commit.")
+	}
+	for _, f := range plan.Families {
+		fmt.Printf("%s %s (%s): %d instance(s), %.0f%% utilized, %s/month on-demand\n",
+			f.Service, f.Family, f.Region, f.Instances, f.Utilization*100,
+			currency.Format(f.OnDemandMonthlyCost, plan.Currency, 2))
+		for _, o := range f.Options {
+			marker := ""
+			if o.Term == f.Recommended {
+				marker = "  ← recommended"
+			}
+			fmt.Printf("  %-4s %2.0f%% off: %s/month, %s upfront, saves %s/month (%s over the term), breakeven %.1f months or %.0f%% utilization%s\n",
+				o.Term, o.Discount*100,
+				currency.Format(o.MonthlyCost, plan.Currency, 2),
+				currency.Format(o.Upfront, plan.Currency, 2),
+				currency.Format(o.MonthlySavings, plan.Currency, 2),
+				currency.Format(o.TermSavings, plan.Currency, 2),
+				o.BreakevenMonths, o.BreakevenUtilization*100, marker)
+		}
+		if f.Note != "" {
+			fmt.Printf("  ⚠️  %s\n", f.Note)
+		}
+		fmt.Println()
+	}
+	fmt.Printf("On-demand instance spend: %s/month\n", currency.Format(plan.OnDemandMonthlyCost, plan.Currency, 2))
+	fmt.Printf("Recommended commitments save %s/month for %s upfront\n",
+		currency.Format(plan.MonthlySavings, plan.Currency, 2), currency.Format(plan.Upfront, plan.Currency, 2))
+	for _, note := range plan.Notes {
+		fmt.Printf("⚠️  %s\n", note)
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/db/ingestion"
+	"terraform-cost/decision/calibration"
+	"terraform-cost/decision/currency"
+	"terraform-cost/decision/estimation"
+	"terraform-cost/decision/reconcile"
+)
+
+// =============================================================================
+// RECONCILE COMMAND
+// Imports AWS Cost and Usage Reports and compares a project's latest saved
+// estimation with what its resources were actually billed, per service.
+// =============================================================================
+
+func reconcileCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "reconcile",
+		Usage: "Compare a project's saved estimate with actual cost from imported Cost and Usage Reports",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "project",
+				Usage:    "Project whose latest saved estimation is reconciled",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "env",
+				Usage: "Environment of the estimation (required when the project has several)",
+			},
+			&cli.StringFlag{
+				Name:  "from",
+				Usage: "Start of the billed period, YYYY-MM-DD (default: 30 days before --to)",
+			},
+			&cli.StringFlag{
+				Name:  "to",
+				Usage: "End of the billed period, exclusive, YYYY-MM-DD (default: today)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "match-tag",
+				Value: cli.NewStringSlice("Name"),
+				Usage: "Tag keys matching resources without a known ARN or ID to CUR line items",
+			},
+			&cli.StringFlag{
+				Name:    "format",
+				Aliases: []string{"f"},
+				Value:   "table",
+				Usage:   "Output format (table, json)",
+			},
+		},
+		Action: runReconcile,
+		Subcommands: []*cli.Command{
+			{
+				Name:      "import",
+				Usage:     "Import CUR line items from CSV files or CUR 2.0 Parquet in S3",
+				ArgsUsage: "[cur.csv[.gz] ...]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "s3-url",
+						Usage: "CUR 2.0 Parquet URL read by ClickHouse, globs allowed (AWS credentials from the environment)",
+					},
+					&cli.IntFlag{
+						Name:  "batch-size",
+						Value: ingestion.DefaultCURBatchSize,
+						Usage: "Line items inserted per batch",
+					},
+				},
+				Action: runReconcileImport,
+			},
+		},
+	}
+}
+
+func runReconcileImport(c *cli.Context) error {
+	if c.NArg() == 0 && c.String("s3-url") == "" {
+		return fmt.Errorf("give CUR CSV files or --s3-url")
+	}
+	store, err := openStore(c)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	for _, path := range c.Args().Slice() {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open CUR file: %w", err)
+		}
+		source := filepath.Base(path)
+		n, err := ingestion.ReadCURCSV(f, c.Int("batch-size"), func(items []clickhouse.CURLineItem) error {
+			return store.InsertCURLineItems(c.Context, source, items)
+		})
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		fmt.Fprintf(os.Stderr, "✅ Imported %d line items from %s\n", n, path)
+	}
+
+	if url := c.String("s3-url"); url != "" {
+		creds, _ := calibration.CredentialsFromEnv()
+		if err := store.ImportCURFromS3(c.Context, url, clickhouse.S3Credentials(creds)); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "✅ Imported %s\n", url)
+	}
+	return nil
+}
+
+func runReconcile(c *cli.Context) error {
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	if s := c.String("to"); s != "" {
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return fmt.Errorf("invalid --to: %w", err)
+		}
+		to = t
+	}
+	from := to.AddDate(0, 0, -30)
+	if s := c.String("from"); s != "" {
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return fmt.Errorf("invalid --from: %w", err)
+		}
+		from = t
+	}
+	if !from.Before(to) {
+		return fmt.Errorf("--from must be before --to")
+	}
+
+	store, err := openStore(c)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	records, err := store.LatestEstimations(c.Context, clickhouse.EstimationFilter{
+		Project:     c.String("project"),
+		Environment: c.String("env"),
+	})
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(records) == 0:
+		return fmt.Errorf("no saved estimation for project %s", c.String("project"))
+	case len(records) > 1:
+		envs := make([]string, len(records))
+		for i, r := range records {
+			envs[i] = r.Environment
+		}
+		return fmt.Errorf("project %s has estimations for several environments (%s); choose one with --env",
+			c.String("project"), strings.Join(envs, ", "))
+	}
+	result, err := estimation.ResultFromRecord(records[0])
+	if err != nil {
+		return err
+	}
+
+	actuals, err := store.ResourceActuals(c.Context, from, to)
+	if err != nil {
+		return err
+	}
+	if len(actuals) == 0 {
+		return fmt.Errorf("no CUR usage between %s and %s; import reports with 'terracost reconcile import'",
+			from.Format("2006-01-02"), to.Format("2006-01-02"))
+	}
+
+	report := reconcile.Reconcile(result, actuals, from, to, reconcile.Options{MatchTags: c.StringSlice("match-tag")})
+	for _, w := range report.Warnings {
+		fmt.Fprintf(os.Stderr, "⚠️  %s\n", w)
+	}
+	if c.String("format") == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	outputReconcileTable(report, records[0])
+	return nil
+}
+
+func outputReconcileTable(report *reconcile.Report, rec *clickhouse.EstimationRecord) {
+	period := fmt.Sprintf("%s → %s", report.From.Format("2006-01-02"), report.To.Format("2006-01-02"))
+
+	fmt.Println()
+	fmt.Println("╔══════════════════════════════════════════════════════════════╗")
+	fmt.Printf("║  🧾 RECONCILIATION: %-40s ║\n", truncate(rec.Project+" ("+rec.Environment+")", 40))
+	fmt.Printf("║  Billed period:         %-38s ║\n", period)
+	fmt.Printf("║  Estimate saved:        %-38s ║\n", rec.CreatedAt.Format("2006-01-02 15:04"))
+	fmt.Println("╠══════════════════════════════════════════════════════════════╣")
+	fmt.Println("║  SERVICE               ESTIMATED       ACTUAL        ERROR   ║")
+	for _, s := range report.Services {
+		fmt.Printf("║  %-20s %11s %12s %11s   ║\n", truncate(s.Service, 20),
+			currency.Format(s.Estimated, report.Currency, 2), currency.Format(s.Actual, report.Currency, 2), fmt.Sprintf("%+.1f%%", s.ErrorPercent))
+	}
+	fmt.Println("╠══════════════════════════════════════════════════════════════╣")
+	fmt.Printf("║  Matched resources:     %-38d ║\n", len(report.Resources))
+	fmt.Printf("║  Estimated (matched):   %-38s ║\n", currency.Format(report.Estimated, report.Currency, 2))
+	fmt.Printf("║  Actual (matched):      %-38s ║\n", currency.Format(report.Actual, report.Currency, 2))
+	fmt.Printf("║  Error:                 %-38s ║\n", fmt.Sprintf("%+.1f%% (mean absolute %.1f%%)", report.ErrorPercent, report.MeanAbsErrorPct))
+	fmt.Printf("║  Unmatched actual:      %-38s ║\n", currency.Format(report.UnmatchedActual, report.Currency, 2))
+
+	if len(report.Unmatched) > 0 {
+		fmt.Println("╠══════════════════════════════════════════════════════════════╣")
+		fmt.Printf("║  %-59s ║\n", fmt.Sprintf("%d estimated resources without billed cost:", len(report.Unmatched)))
+		for _, addr := range report.Unmatched {
+			fmt.Printf("║    %-57s ║\n", truncate(addr, 57))
+		}
+	}
+	fmt.Println("╚══════════════════════════════════════════════════════════════╝")
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"terraform-cost/decision/iac"
+	"terraform-cost/pkg/terracost"
+)
+
+// =============================================================================
+// REDACT COMMAND
+// Strips secrets from a plan before it leaves the machine, so only sanitized
+// plans reach a shared estimation server (POST /api/v1/estimate).
+// =============================================================================
+
+func redactCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "redact",
+		Usage: "Write a plan with sensitive values replaced, for sending to a shared server",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "plan",
+				Aliases:  []string{"p"},
+				Usage:    "Plan JSON to redact (terraform show -json, Pulumi preview or CloudFormation)",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "out",
+				Usage: "File to write the redacted plan to (default: stdout)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "redact-attribute",
+				Usage: "Extra attribute name fragment to redact (repeatable)",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			redacted, count, err := redactPlanFile(c, c.String("plan"))
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "🔒 Redacted %d values\n", count)
+			if out := c.String("out"); out != "" {
+				return os.WriteFile(out, redacted, 0o600)
+			}
+			_, err = os.Stdout.Write(append(redacted, '\n'))
+			return err
+		},
+	}
+}
+
+// redactPlanFile reads a plan and redacts it with the default and --redact-attribute denylist
+func redactPlanFile(c *cli.Context, path string) ([]byte, int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read plan: %w", err)
+	}
+	return iac.NewRedactor().WithAttributes(c.StringSlice("redact-attribute")...).Redact(data)
+}
+
+// parseRedacted parses a plan file after redacting it, for estimate --redact
+func parseRedacted(ctx context.Context, c *cli.Context, estimator *terracost.Estimator, parser iac.PlanParser, input string) (*iac.ParsedPlan, error) {
+	if _, ok := parser.(*iac.HCLParser); ok {
+		return nil, fmt.Errorf("--redact needs a plan file, not --path")
+	}
+	redacted, count, err := redactPlanFile(c, input)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(os.Stderr, "🔒 Redacted %d values\n", count)
+	return estimator.Parse(ctx, parser, bytes.NewReader(redacted))
+}
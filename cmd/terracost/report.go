@@ -0,0 +1,231 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/urfave/cli/v2"
+
+	"terraform-cost/decision/currency"
+	"terraform-cost/decision/estimation"
+	"terraform-cost/decision/policy"
+)
+
+// =============================================================================
+// REPORT COMMAND
+// Renders a self-contained HTML report for sharing with non-CLI stakeholders
+// =============================================================================
+
+//go:embed report.html.tmpl
+var reportTemplate string
+
+// reportMaxDrivers limits the top cost drivers chart
+const reportMaxDrivers = 10
+
+func reportCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "report",
+		Usage: "Render an HTML cost report for a Terraform plan",
+		Flags: append(estimateFlags(),
+			&cli.StringFlag{
+				Name:    "out",
+				Aliases: []string{"o"},
+				Value:   "terracost-report.html",
+				Usage:   "Output HTML file",
+			},
+		),
+		Action: runReport,
+	}
+}
+
+func runReport(c *cli.Context) error {
+	// The report always shows formulas and the carbon section
+	if err := c.Set("include-formulas", "true"); err != nil {
+		return err
+	}
+	if err := c.Set("include-carbon", "true"); err != nil {
+		return err
+	}
+
+	run, err := runPipeline(c)
+	if err != nil {
+		return err
+	}
+
+	source := c.String("plan")
+	if source == "" {
+		source = c.String("path")
+	}
+	data := buildReportData(run, source, c.String("env"))
+
+	f, err := os.Create(c.String("out"))
+	if err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+	defer f.Close()
+
+	if err := renderReport(f, data); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "📄 Report written to %s\n", c.String("out"))
+	return nil
+}
+
+// reportData is the HTML template model
+type reportData struct {
+	Source         string
+	Environment    string
+	GeneratedAt    string
+	Version        string
+	Result         *estimation.EstimationResult
+	Policy         *policy.EvaluationResult
+	TopGroups      []reportGroup
+	Services       []reportService
+	Tags           []reportTag
+	Carbon         []reportCarbon
+	Resources      []reportResource
+	UncoveredTypes []string
+}
+
+type reportGroup struct {
+	estimation.CostGroup
+	Share float64 // percent of the largest line item, for bar width
+}
+
+type reportService struct {
+	Name     string
+	P50, P90 decimal.Decimal
+	Share    float64 // percent of total P50
+}
+
+type reportTag struct {
+	Key            string
+	Value          string
+	MonthlyCostP50 decimal.Decimal
+}
+
+type reportCarbon struct {
+	Region string
+	KgCO2  float64
+	Share  float64
+}
+
+type reportResource struct {
+	Address  string
+	P50      decimal.Decimal
+	Symbolic bool
+	Drivers  []estimation.CostDriver
+}
+
+func buildReportData(run *estimateRun, source, env string) reportData {
+	result := run.result
+	data := reportData{
+		Source:         source,
+		Environment:    env,
+		GeneratedAt:    time.Now().UTC().Format("2006-01-02 15:04 MST"),
+		Version:        version,
+		Result:         result,
+		Policy:         run.policyResult,
+		UncoveredTypes: run.decomposition.UncoveredTypes,
+	}
+	total := result.MonthlyCostP50.InexactFloat64()
+
+	// Top line items, scaled against the largest
+	groups := result.CostGroups
+	if len(groups) > reportMaxDrivers {
+		groups = groups[:reportMaxDrivers]
+	}
+	var largest float64
+	if len(groups) > 0 {
+		largest = groups[0].MonthlyCostP50.InexactFloat64()
+	}
+	for _, g := range groups {
+		data.TopGroups = append(data.TopGroups, reportGroup{CostGroup: g, Share: share(g.MonthlyCostP50.InexactFloat64(), largest)})
+	}
+
+	// Per-service and per-resource totals
+	services := make(map[string]*reportService)
+	resources := make(map[string]*reportResource)
+	order := make([]string, 0)
+	for _, d := range result.CostDrivers {
+		svc, ok := services[d.Service]
+		if !ok {
+			svc = &reportService{Name: d.Service}
+			services[d.Service] = svc
+		}
+		svc.P50 = svc.P50.Add(d.MonthlyCostP50)
+		svc.P90 = svc.P90.Add(d.MonthlyCostP90)
+
+		res, ok := resources[d.ResourceAddr]
+		if !ok {
+			res = &reportResource{Address: d.ResourceAddr}
+			resources[d.ResourceAddr] = res
+			order = append(order, d.ResourceAddr)
+		}
+		res.P50 = res.P50.Add(d.MonthlyCostP50)
+		res.Symbolic = res.Symbolic || d.IsSymbolic
+		res.Drivers = append(res.Drivers, d)
+	}
+	for _, svc := range services {
+		svc.Share = share(svc.P50.InexactFloat64(), total)
+		data.Services = append(data.Services, *svc)
+	}
+	sort.Slice(data.Services, func(i, j int) bool {
+		return data.Services[i].P50.GreaterThan(data.Services[j].P50)
+	})
+	for _, addr := range order {
+		data.Resources = append(data.Resources, *resources[addr])
+	}
+	sort.SliceStable(data.Resources, func(i, j int) bool {
+		return data.Resources[i].P50.GreaterThan(data.Resources[j].P50)
+	})
+
+	for _, key := range sortedTagKeys(result.CostByTag) {
+		for _, tc := range estimation.SortedTagCosts(result.CostByTag[key]) {
+			data.Tags = append(data.Tags, reportTag{Key: key, Value: tc.Value, MonthlyCostP50: tc.MonthlyCostP50})
+		}
+	}
+
+	for region, kg := range result.CarbonByRegion {
+		data.Carbon = append(data.Carbon, reportCarbon{Region: region, KgCO2: kg, Share: share(kg, result.CarbonKgCO2)})
+	}
+	sort.Slice(data.Carbon, func(i, j int) bool { return data.Carbon[i].KgCO2 > data.Carbon[j].KgCO2 })
+
+	return data
+}
+
+// renderReport executes the embedded HTML template
+func renderReport(w io.Writer, data reportData) error {
+	tmpl, err := template.New("report").Funcs(template.FuncMap{
+		"money": func(d decimal.Decimal) string { return currency.Format(d, data.Result.Currency, 2) },
+		"moneyPlaces": func(d decimal.Decimal, places int) string {
+			return currency.Format(d, data.Result.Currency, int32(places))
+		},
+		"symbol":  func() string { return currency.Symbol(data.Result.Currency) },
+		"percent": func(f float64) string { return fmt.Sprintf("%.0f%%", f*100) },
+		"upper":   strings.ToUpper,
+	}).Parse(reportTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to parse report template: %w", err)
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+	return nil
+}
+
+// share returns part as a percentage of whole, rounded for display
+func share(part, whole float64) float64 {
+	if whole <= 0 {
+		return 0
+	}
+	return math.Round(part/whole*1000) / 10
+}
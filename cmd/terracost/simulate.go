@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/currency"
+	"terraform-cost/decision/policy"
+)
+
+// =============================================================================
+// POLICY SIMULATE
+// Replays a policy file against saved estimations before it is enforced, to
+// show how many recent changes it would have denied or warned.
+// =============================================================================
+
+func policySimulateCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "simulate",
+		Usage: "Replay a policy file against saved estimates and report what it would have denied or warned",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "policy-file",
+				Usage:    "Policy file to simulate",
+				Required: true,
+			},
+			&cli.IntFlag{
+				Name:  "last",
+				Value: 50,
+				Usage: fmt.Sprintf("Number of most recent saved estimates to replay (at most %d)", clickhouse.MaxEstimationLimit),
+			},
+			&cli.StringFlag{
+				Name:  "project",
+				Usage: "Only replay estimates of this project",
+			},
+			&cli.StringFlag{
+				Name:  "branch",
+				Usage: "Only replay estimates of this branch",
+			},
+			&cli.StringFlag{
+				Name:  "env",
+				Usage: "Only replay estimates of this environment",
+			},
+			&cli.StringFlag{
+				Name:    "format",
+				Aliases: []string{"f"},
+				Value:   "table",
+				Usage:   "Output format (table, json)",
+			},
+		},
+		Action: runPolicySimulate,
+	}
+}
+
+func runPolicySimulate(c *cli.Context) error {
+	last := c.Int("last")
+	if last <= 0 || last > clickhouse.MaxEstimationLimit {
+		return fmt.Errorf("--last must be between 1 and %d", clickhouse.MaxEstimationLimit)
+	}
+	policies, err := policy.LoadPolicyFile(c.String("policy-file"))
+	if err != nil {
+		return err
+	}
+
+	store, err := openStore(c)
+	if err != nil {
+		return fmt.Errorf("failed to connect to ClickHouse: %w", err)
+	}
+	defer store.Close()
+
+	records, err := store.ListEstimationResults(c.Context, clickhouse.EstimationFilter{
+		Project:     c.String("project"),
+		Branch:      c.String("branch"),
+		Environment: c.String("env"),
+		Limit:       last,
+	})
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("no saved estimates match the filter")
+	}
+
+	// The policy set an estimate would run: the defaults, retuned by the file
+	engine := policy.NewEngine()
+	engine.LoadPolicies(policies)
+	sim, err := engine.Simulate(c.Context, records)
+	if err != nil {
+		return err
+	}
+
+	if c.String("format") == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(sim)
+	}
+	outputSimulation(sim, c.String("policy-file"))
+	return nil
+}
+
+func outputSimulation(sim *policy.Simulation, path string) {
+	fmt.Printf("\n🧪 Policy simulation: %s against %d saved estimate(s)\n\n", path, sim.Estimates)
+	fmt.Printf("   Denied: %d   Warned: %d   Passed: %d\n", sim.Denied, sim.Warned, sim.Passed)
+	fmt.Printf("   Changed from recorded decision: %d (%d newly denied)\n", sim.Changed, sim.NewlyDenied)
+	if sim.Skipped > 0 {
+		fmt.Printf("   ⚠️  %d estimate(s) skipped: saved result could not be decoded\n", sim.Skipped)
+	}
+
+	if len(sim.Policies) > 0 {
+		fmt.Println()
+		fmt.Printf("%-32s  %8s  %8s\n", "POLICY", "DENIED", "WARNED")
+		fmt.Println(strings.Repeat("─", 52))
+		for _, p := range sim.Policies {
+			fmt.Printf("%-32s  %8d  %8d\n", truncate(p.PolicyID, 32), p.Denied, p.Warned)
+		}
+	}
+
+	changed := make([]policy.SimulatedEstimate, 0)
+	for _, est := range sim.Estimations {
+		if est.Decision != policy.DecisionPass && est.Decision != est.Recorded {
+			changed = append(changed, est)
+		}
+	}
+	if len(changed) == 0 {
+		return
+	}
+	fmt.Println()
+	fmt.Println("Estimates the policy file would newly deny or warn:")
+	fmt.Printf("%-16s  %-20s  %-8s  %12s  %-6s  %-6s\n", "SAVED", "PROJECT", "ENV", "MONTHLY P50", "WAS", "NOW")
+	fmt.Println(strings.Repeat("─", 80))
+	for _, est := range changed {
+		recorded := string(est.Recorded)
+		if recorded == "" {
+			recorded = "-"
+		}
+		fmt.Printf("%-16s  %-20s  %-8s  %12s  %-6s  %-6s\n", est.CreatedAt.Format("2006-01-02 15:04"),
+			truncate(est.Project, 20), truncate(est.Environment, 8), currency.Format(est.MonthlyCostP50, est.Currency, 2), recorded, est.Decision)
+		for _, v := range est.Violations {
+			fmt.Printf("    • %s\n", v.Message)
+		}
+		for _, w := range est.Warnings {
+			fmt.Printf("    • %s\n", w.Message)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"terraform-cost/decision/policy"
+	"terraform-cost/tenant"
+)
+
+// =============================================================================
+// MULTI-TENANCY
+// With --auth-secret, serve requires tokens naming an org; `terracost token`
+// issues them. Orgs may carry extra policies in --org-policy-dir/<org>.yaml.
+// =============================================================================
+
+func tokenCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "token",
+		Usage: "Issue an API token for an organization",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "auth-secret",
+				Usage:    "Secret the API server verifies tokens with (serve --auth-secret)",
+				EnvVars:  []string{"TERRACOST_AUTH_SECRET"},
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "org",
+				Usage:    "Organization ID",
+				Required: true,
+			},
+			&cli.StringSliceFlag{
+				Name:  "project",
+				Usage: "Project the token may use (repeatable; default: all projects)",
+			},
+			&cli.StringFlag{
+				Name:  "subject",
+				Usage: "Who the token is for, e.g. a CI pipeline",
+			},
+			&cli.BoolFlag{
+				Name:  "approver",
+				Usage: "Let the token decide approvals of denied estimates (needs --subject)",
+			},
+			&cli.DurationFlag{
+				Name:  "ttl",
+				Value: 90 * 24 * time.Hour,
+				Usage: "Token lifetime (0: never expires)",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.Bool("approver") && c.String("subject") == "" {
+				return fmt.Errorf("--approver needs --subject, decisions are attributed to it")
+			}
+			token, err := tenant.NewVerifier([]byte(c.String("auth-secret"))).Sign(tenant.Tenant{
+				OrgID:    c.String("org"),
+				Projects: c.StringSlice("project"),
+				Subject:  c.String("subject"),
+				Approver: c.Bool("approver"),
+			}, c.Duration("ttl"))
+			if err != nil {
+				return fmt.Errorf("failed to sign token: %w", err)
+			}
+			fmt.Println(token)
+			return nil
+		},
+	}
+}
+
+// authVerifier returns the token verifier for --auth-secret; nil serves a single tenant
+func authVerifier(c *cli.Context) *tenant.Verifier {
+	secret := c.String("auth-secret")
+	if secret == "" {
+		return nil
+	}
+	return tenant.NewVerifier([]byte(secret))
+}
+
+// loadOrgPolicies loads <org>.yaml policy files from a directory, keyed by org ID
+func loadOrgPolicies(dir string) (map[string][]policy.Policy, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read org policy dir: %w", err)
+	}
+	orgPolicies := make(map[string][]policy.Policy)
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		policies, err := policy.LoadPolicyFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		org := strings.TrimSuffix(e.Name(), ext)
+		orgPolicies[org] = append(orgPolicies[org], policies...)
+	}
+	return orgPolicies, nil
+}
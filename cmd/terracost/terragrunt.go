@@ -0,0 +1,319 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/urfave/cli/v2"
+
+	"terraform-cost/decision/currency"
+	"terraform-cost/decision/estimation"
+	"terraform-cost/decision/iac"
+	"terraform-cost/decision/policy"
+	"terraform-cost/integrations/metrics"
+	tcerrors "terraform-cost/pkg/errors"
+)
+
+// =============================================================================
+// TERRAGRUNT RUN-ALL
+// Every stack's plan runs through the estimate pipeline; the consolidated report
+// adds per-stack subtotals and notes shared resources (NAT gateways, load
+// balancers) whose cost serves the stacks depending on the stack defining them.
+// =============================================================================
+
+// runAllReport is the consolidated estimate of a terragrunt run-all
+type runAllReport struct {
+	Currency        string           `json:"currency"`
+	MonthlyCostP50  decimal.Decimal  `json:"monthly_cost_p50"`
+	MonthlyCostP90  decimal.Decimal  `json:"monthly_cost_p90"`
+	CarbonKgCO2     float64          `json:"carbon_kg_co2"`
+	ResourceCount   int              `json:"resource_count"`
+	PolicyResult    string           `json:"policy_result,omitempty"` // Worst stack decision
+	Stacks          []stackSubtotal  `json:"stacks"`
+	SharedResources []sharedResource `json:"shared_resources,omitempty"`
+}
+
+// stackSubtotal is one stack's part of a run-all estimate
+type stackSubtotal struct {
+	Name           string                 `json:"name"`
+	PlanFile       string                 `json:"plan_file"`
+	DependsOn      []string               `json:"depends_on,omitempty"`
+	Dependents     []string               `json:"dependents,omitempty"` // Direct and transitive
+	MonthlyCostP50 decimal.Decimal        `json:"monthly_cost_p50"`
+	MonthlyCostP90 decimal.Decimal        `json:"monthly_cost_p90"`
+	Confidence     float64                `json:"confidence"`
+	ResourceCount  int                    `json:"resource_count"`
+	PolicyResult   string                 `json:"policy_result,omitempty"`
+	Violations     []policy.Violation     `json:"violations,omitempty"`
+	CostGroups     []estimation.CostGroup `json:"cost_groups"`
+}
+
+// sharedResource is a resource in one stack whose cost serves its dependent stacks
+type sharedResource struct {
+	Stack          string          `json:"stack"`
+	ResourceAddr   string          `json:"resource_addr"`
+	ResourceType   string          `json:"resource_type"`
+	MonthlyCostP50 decimal.Decimal `json:"monthly_cost_p50"`
+	SharedWith     []string        `json:"shared_with"`
+}
+
+func runTerragrunt(c *cli.Context) error {
+	if len(c.StringSlice("notify")) > 0 {
+		return fmt.Errorf("--notify is not supported with --terragrunt-dir")
+	}
+	format := c.String("format")
+	if format == "junit" || format == "azure-devops" {
+		return fmt.Errorf("--format %s is not supported with --terragrunt-dir", format)
+	}
+	gate, err := newExitGate(c)
+	if err != nil {
+		return err
+	}
+	exporters, err := metrics.ParseAll(c.StringSlice("export-metrics"))
+	if err != nil {
+		return err
+	}
+
+	planDir := c.String("terragrunt-dir")
+	configDir := c.String("terragrunt-config-dir")
+	if configDir == "" {
+		configDir = planDir
+	}
+	stacks, err := iac.DiscoverTerragruntStacks(planDir, configDir, c.String("plan-pattern"))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "🧱 Found %d terragrunt stacks in %s\n", len(stacks), planDir)
+
+	runs := make([]*estimateRun, len(stacks))
+	for i, stack := range stacks {
+		fmt.Fprintf(os.Stderr, "\n── %s\n", stack.Name)
+		runs[i], err = runPipelineFor(c, pipelineInput{
+			format:  c.String("plan-format"),
+			input:   stack.PlanFile,
+			project: stackProject(c.String("project"), stack.Name),
+			env:     c.String("env"),
+		})
+		if err != nil {
+			return fmt.Errorf("stack %s: %w", stack.Name, err)
+		}
+	}
+
+	estimates := make([]metrics.Estimate, len(stacks))
+	for i, stack := range stacks {
+		estimates[i] = metrics.Estimate{Project: metricsProject(c, stack.Name), Environment: c.String("env"), Result: runs[i].result}
+	}
+	exportMetrics(c, exporters, estimates...)
+
+	report := buildRunAllReport(stacks, runs)
+	switch format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	case "markdown":
+		outputRunAllMarkdown(report)
+	default:
+		outputRunAllTable(report)
+	}
+	if err != nil {
+		return err
+	}
+
+	var issues []tcerrors.Issue
+	for _, run := range runs {
+		issues = append(issues, run.issues...)
+	}
+	return gate.check(issues)
+}
+
+// stackProject is the history project of a stack: <project>/<stack>, or none without --project
+func stackProject(project, stack string) string {
+	if project == "" || stack == "." {
+		return project
+	}
+	return project + "/" + stack
+}
+
+// metricsProject is the project label of a stack's or plan's metrics: its
+// history project, or its name without --project
+func metricsProject(c *cli.Context, name string) string {
+	if project := stackProject(c.String("project"), name); project != "" {
+		return project
+	}
+	return name
+}
+
+// buildRunAllReport totals the stacks and finds the shared resources
+func buildRunAllReport(stacks []iac.TerragruntStack, runs []*estimateRun) *runAllReport {
+	report := &runAllReport{}
+	dependents := stackDependents(stacks)
+	decision := policy.DecisionPass
+	for i, stack := range stacks {
+		run := runs[i]
+		result := run.result
+		sub := stackSubtotal{
+			Name:           stack.Name,
+			PlanFile:       stack.PlanFile,
+			DependsOn:      stack.DependsOn,
+			Dependents:     dependents[stack.Name],
+			MonthlyCostP50: result.MonthlyCostP50,
+			MonthlyCostP90: result.MonthlyCostP90,
+			Confidence:     result.Confidence,
+			ResourceCount:  run.graph.ResourceCount,
+			CostGroups:     result.CostGroups,
+		}
+		if run.policyResult != nil {
+			sub.PolicyResult = string(run.policyResult.Decision)
+			sub.Violations = run.policyResult.Violations
+			if run.policyResult.Decision == policy.DecisionDeny ||
+				(run.policyResult.Decision == policy.DecisionWarn && decision == policy.DecisionPass) {
+				decision = run.policyResult.Decision
+			}
+			report.PolicyResult = string(decision)
+		}
+
+		report.Currency = result.Currency
+		report.MonthlyCostP50 = report.MonthlyCostP50.Add(result.MonthlyCostP50)
+		report.MonthlyCostP90 = report.MonthlyCostP90.Add(result.MonthlyCostP90)
+		report.CarbonKgCO2 += result.CarbonKgCO2
+		report.ResourceCount += sub.ResourceCount
+		report.Stacks = append(report.Stacks, sub)
+
+		if len(sub.Dependents) > 0 {
+			report.SharedResources = append(report.SharedResources, sharedResources(sub)...)
+		}
+	}
+	return report
+}
+
+// stackDependents maps each stack to the stacks depending on it, directly or transitively
+func stackDependents(stacks []iac.TerragruntStack) map[string][]string {
+	direct := make(map[string][]string)
+	for _, s := range stacks {
+		for _, dep := range s.DependsOn {
+			direct[dep] = append(direct[dep], s.Name)
+		}
+	}
+	all := make(map[string][]string)
+	for _, s := range stacks {
+		seen := map[string]bool{s.Name: true}
+		queue := append([]string{}, direct[s.Name]...)
+		for len(queue) > 0 {
+			name := queue[0]
+			queue = queue[1:]
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			all[s.Name] = append(all[s.Name], name)
+			queue = append(queue, direct[name]...)
+		}
+		sort.Strings(all[s.Name])
+	}
+	return all
+}
+
+// sharedResources sums the cost of a stack's shared resource types by resource
+func sharedResources(sub stackSubtotal) []sharedResource {
+	byAddr := make(map[string]*sharedResource)
+	var order []string
+	for _, g := range sub.CostGroups {
+		typ := iac.ResourceType(g.ResourceAddr)
+		if !iac.SharedResourceTypes[typ] {
+			continue
+		}
+		r, ok := byAddr[g.ResourceAddr]
+		if !ok {
+			r = &sharedResource{Stack: sub.Name, ResourceAddr: g.ResourceAddr, ResourceType: typ, SharedWith: sub.Dependents}
+			byAddr[g.ResourceAddr] = r
+			order = append(order, g.ResourceAddr)
+		}
+		r.MonthlyCostP50 = r.MonthlyCostP50.Add(g.MonthlyCostP50)
+	}
+	shared := make([]sharedResource, len(order))
+	for i, addr := range order {
+		shared[i] = *byAddr[addr]
+	}
+	return shared
+}
+
+func outputRunAllTable(report *runAllReport) {
+	fmt.Println()
+	fmt.Println("╔══════════════════════════════════════════════════════════════╗")
+	fmt.Printf("║  🧱 TERRAGRUNT RUN-ALL: %-36s ║\n", fmt.Sprintf("%d stacks", len(report.Stacks)))
+	fmt.Println("╠══════════════════════════════════════════════════════════════╣")
+	for _, s := range report.Stacks {
+		fmt.Printf("║  %-35s  %-21s ║\n", truncate(s.Name, 35), currency.Format(s.MonthlyCostP50, report.Currency, 2))
+	}
+	fmt.Println("╠══════════════════════════════════════════════════════════════╣")
+	fmt.Printf("║  Monthly Cost (P50):    %-38s ║\n", currency.Format(report.MonthlyCostP50, report.Currency, 2))
+	fmt.Printf("║  Monthly Cost (P90):    %-38s ║\n", currency.Format(report.MonthlyCostP90, report.Currency, 2))
+	fmt.Printf("║  Resources:             %-38d ║\n", report.ResourceCount)
+
+	if len(report.SharedResources) > 0 {
+		fmt.Println("╠══════════════════════════════════════════════════════════════╣")
+		fmt.Println("║  SHARED ACROSS STACKS                                         ║")
+		fmt.Println("╠══════════════════════════════════════════════════════════════╣")
+		for _, r := range report.SharedResources {
+			label := r.Stack + ": " + r.ResourceAddr
+			fmt.Printf("║  %-35s  %-21s ║\n", truncate(label, 35), currency.Format(r.MonthlyCostP50, report.Currency, 2))
+			fmt.Printf("║    %-57s ║\n", truncate("used by "+strings.Join(r.SharedWith, ", "), 57))
+		}
+	}
+
+	if report.PolicyResult != "" {
+		fmt.Println("╠══════════════════════════════════════════════════════════════╣")
+		fmt.Printf("║  Policy Result:         %-38s ║\n", strings.ToUpper(report.PolicyResult))
+		for _, s := range report.Stacks {
+			for _, v := range s.Violations {
+				fmt.Printf("║  ❌ %-57s ║\n", truncate(s.Name+": "+v.Message, 57))
+			}
+		}
+	}
+	fmt.Println("╚══════════════════════════════════════════════════════════════╝")
+}
+
+func outputRunAllMarkdown(report *runAllReport) {
+	fmt.Println("## 🧱 TerraCost Terragrunt Report")
+	fmt.Println()
+	fmt.Println("| Stack | Depends on | Resources | Monthly Cost (P50) | Monthly Cost (P90) | Policy |")
+	fmt.Println("|-------|------------|-----------|--------------------|--------------------|--------|")
+	for _, s := range report.Stacks {
+		fmt.Printf("| %s | %s | %d | %s | %s | %s |\n", s.Name, strings.Join(s.DependsOn, ", "), s.ResourceCount,
+			currency.Format(s.MonthlyCostP50, report.Currency, 2), currency.Format(s.MonthlyCostP90, report.Currency, 2), s.PolicyResult)
+	}
+	fmt.Printf("| **Total** | | %d | **%s** | **%s** | %s |\n", report.ResourceCount,
+		currency.Format(report.MonthlyCostP50, report.Currency, 2), currency.Format(report.MonthlyCostP90, report.Currency, 2), report.PolicyResult)
+
+	if len(report.SharedResources) > 0 {
+		fmt.Println()
+		fmt.Println("### 🔗 Shared Across Stacks")
+		fmt.Println()
+		fmt.Println("Counted once in the defining stack; the dependent stacks rely on them.")
+		fmt.Println()
+		fmt.Println("| Stack | Resource | Monthly Cost | Used by |")
+		fmt.Println("|-------|----------|--------------|---------|")
+		for _, r := range report.SharedResources {
+			fmt.Printf("| %s | %s | %s | %s |\n", r.Stack, r.ResourceAddr,
+				currency.Format(r.MonthlyCostP50, report.Currency, 2), strings.Join(r.SharedWith, ", "))
+		}
+	}
+
+	var violations []string
+	for _, s := range report.Stacks {
+		for _, v := range s.Violations {
+			violations = append(violations, fmt.Sprintf("- **%s** (%s): %s", v.PolicyName, s.Name, v.Message))
+		}
+	}
+	if len(violations) > 0 {
+		fmt.Println()
+		fmt.Println("### ❌ Policy Violations")
+		fmt.Println()
+		fmt.Println(strings.Join(violations, "\n"))
+	}
+}
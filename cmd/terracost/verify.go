@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/shopspring/decimal"
+	"github.com/urfave/cli/v2"
+
+	"terraform-cost/decision/estimation"
+	"terraform-cost/decision/iac"
+)
+
+// =============================================================================
+// VERIFY COMMAND
+// Estimates a plan deterministically and compares it with a golden file (the
+// output of estimate --format json --deterministic), so cost regressions of
+// Terraform modules fail CI. Costs may drift within the tolerances.
+// =============================================================================
+
+// verifyExcludedFlags are estimate flags that don't apply to a verification:
+// verified estimates are neither saved nor sent, and always deterministic
+var verifyExcludedFlags = map[string]bool{
+	"project":        true,
+	"branch":         true,
+	"commit":         true,
+	"pr":             true,
+	"notify":         true,
+	"notify-link":    true,
+	"export-metrics": true,
+	"deterministic":  true,
+	"explain":        true,
+	"exit-code":      true,
+	"soft-fail":      true,
+}
+
+func verifyCommand() *cli.Command {
+	var flags []cli.Flag
+	for _, f := range estimateFlags() {
+		if !verifyExcludedFlags[f.Names()[0]] {
+			flags = append(flags, f)
+		}
+	}
+	return &cli.Command{
+		Name:  "verify",
+		Usage: "Compare a plan's estimate with a golden estimate file",
+		Flags: append(flags,
+			&cli.StringFlag{
+				Name:     "expected",
+				Usage:    "Golden estimate JSON (from estimate --format json --deterministic)",
+				Required: true,
+			},
+			&cli.Float64Flag{
+				Name:  "tolerance",
+				Value: 1,
+				Usage: "Allowed cost difference, in percent of the expected cost",
+			},
+			&cli.Float64Flag{
+				Name:  "tolerance-abs",
+				Value: 0.01,
+				Usage: "Allowed cost difference, in the estimate's currency; costs within either tolerance pass",
+			},
+			&cli.BoolFlag{
+				Name:  "update",
+				Usage: "Write the estimate to --expected instead of comparing",
+			},
+		),
+		Action: runVerify,
+	}
+}
+
+// goldenDiff is one difference from the golden estimate
+type goldenDiff struct {
+	Path    string // Total, driver ID or pricing snapshot region
+	Message string
+	Failing bool // Outside the tolerances; other differences are informational
+}
+
+// costTolerance decides whether a cost differs from its expected value
+type costTolerance struct {
+	percent  decimal.Decimal
+	absolute decimal.Decimal
+}
+
+// within reports whether actual is within either tolerance of expected
+func (t costTolerance) within(expected, actual decimal.Decimal) bool {
+	diff := actual.Sub(expected).Abs()
+	return diff.LessThanOrEqual(t.absolute) || diff.LessThanOrEqual(expected.Abs().Mul(t.percent).Div(decimal.NewFromInt(100)))
+}
+
+func runVerify(c *cli.Context) error {
+	format, input := c.String("plan-format"), c.String("plan")
+	if c.String("path") != "" {
+		format, input = iac.FormatHCL, c.String("path")
+	}
+	if input == "" {
+		return fmt.Errorf("either --plan or --path is required")
+	}
+	if c.Float64("tolerance") < 0 || c.Float64("tolerance-abs") < 0 {
+		return fmt.Errorf("--tolerance and --tolerance-abs must not be negative")
+	}
+
+	run, err := runPipelineFor(c, pipelineInput{format: format, input: input, env: c.String("env"), deterministic: true})
+	if err != nil {
+		return err
+	}
+	actual := newJSONOutput(run, 0)
+
+	path := c.String("expected")
+	if c.Bool("update") {
+		data, err := json.MarshalIndent(actual, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("failed to write golden file: %w", err)
+		}
+		fmt.Printf("✅ Wrote %s (%s/month)\n", path, actual.MonthlyCostP50)
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read golden file: %w", err)
+	}
+	var expected JSONOutput
+	if err := json.Unmarshal(data, &expected); err != nil {
+		return fmt.Errorf("invalid golden file %s: %w", path, err)
+	}
+
+	tolerance := costTolerance{
+		percent:  decimal.NewFromFloat(c.Float64("tolerance")),
+		absolute: decimal.NewFromFloat(c.Float64("tolerance-abs")),
+	}
+	diffs := diffGolden(expected, actual, tolerance)
+	failing := 0
+	for _, d := range diffs {
+		icon := "ℹ️ "
+		if d.Failing {
+			icon = "❌"
+			failing++
+		}
+		fmt.Printf("%s %s: %s\n", icon, d.Path, d.Message)
+	}
+	if failing > 0 {
+		return fmt.Errorf("estimate differs from %s in %d places", path, failing)
+	}
+	fmt.Printf("✅ Estimate matches %s (%s/month)\n", path, actual.MonthlyCostP50)
+	return nil
+}
+
+// diffGolden compares an estimate with the golden one
+func diffGolden(expected, actual JSONOutput, tolerance costTolerance) []goldenDiff {
+	var diffs []goldenDiff
+	fail := func(path, format string, args ...interface{}) {
+		diffs = append(diffs, goldenDiff{Path: path, Message: fmt.Sprintf(format, args...), Failing: true})
+	}
+
+	if expected.Currency != actual.Currency {
+		fail("currency", "%s, expected %s", actual.Currency, expected.Currency)
+		return diffs
+	}
+	compareCost := func(path, name, expected, actual string) {
+		e, errE := decimal.NewFromString(expected)
+		a, errA := decimal.NewFromString(actual)
+		if errE != nil || errA != nil {
+			if expected != actual {
+				fail(path, "%s %s, expected %s", name, actual, expected)
+			}
+			return
+		}
+		if !tolerance.within(e, a) {
+			fail(path, "%s %s, expected %s (%s)", name, a.StringFixed(2), e.StringFixed(2), percentChange(e, a))
+		}
+	}
+	compareCost("total", "monthly P50", expected.MonthlyCostP50, actual.MonthlyCostP50)
+	compareCost("total", "monthly P90", expected.MonthlyCostP90, actual.MonthlyCostP90)
+	if expected.ComponentsSymbolic != actual.ComponentsSymbolic {
+		fail("total", "%d unpriced components, expected %d", actual.ComponentsSymbolic, expected.ComponentsSymbolic)
+	}
+	if expected.PolicyResult != actual.PolicyResult {
+		fail("policy", "%s, expected %s", actual.PolicyResult, expected.PolicyResult)
+	}
+
+	expectedDrivers := make(map[string]estimation.CostDriver, len(expected.CostDrivers))
+	for _, d := range expected.CostDrivers {
+		expectedDrivers[d.ID] = d
+	}
+	seen := make(map[string]bool)
+	for _, a := range actual.CostDrivers {
+		seen[a.ID] = true
+		e, ok := expectedDrivers[a.ID]
+		if !ok {
+			fail(a.ID, "new cost driver (%s/month)", a.MonthlyCostP50.StringFixed(2))
+			continue
+		}
+		if e.IsSymbolic != a.IsSymbolic {
+			fail(a.ID, "priced %t, expected %t", !a.IsSymbolic, !e.IsSymbolic)
+			continue
+		}
+		compareCost(a.ID, "monthly P50", e.MonthlyCostP50.String(), a.MonthlyCostP50.String())
+		compareCost(a.ID, "monthly P90", e.MonthlyCostP90.String(), a.MonthlyCostP90.String())
+	}
+	for _, e := range expected.CostDrivers {
+		if !seen[e.ID] {
+			fail(e.ID, "cost driver missing (expected %s/month)", e.MonthlyCostP50.StringFixed(2))
+		}
+	}
+
+	// Changed pricing explains cost differences but is not one
+	expectedSnapshots := make(map[string]pricingSnapshotRef)
+	for _, s := range expected.PricingSnapshots {
+		expectedSnapshots[s.Region] = s
+	}
+	for _, s := range actual.PricingSnapshots {
+		if e, ok := expectedSnapshots[s.Region]; ok && e.Hash != s.Hash {
+			diffs = append(diffs, goldenDiff{
+				Path:    "pricing " + s.Region,
+				Message: fmt.Sprintf("snapshot %s (hash %s), golden file priced with %s (hash %s)", s.SnapshotID, shortHash(s.Hash), e.SnapshotID, shortHash(e.Hash)),
+			})
+		}
+	}
+	return diffs
+}
+
+// percentChange formats the change from expected to actual
+func percentChange(expected, actual decimal.Decimal) string {
+	if expected.IsZero() {
+		return "new cost"
+	}
+	return actual.Sub(expected).Div(expected).Mul(decimal.NewFromInt(100)).StringFixed(1) + "%"
+}
+
+// shortHash abbreviates a content hash for display
+func shortHash(hash string) string {
+	if hash == "" {
+		return "unknown"
+	}
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
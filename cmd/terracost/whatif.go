@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/urfave/cli/v2"
+
+	"terraform-cost/decision/currency"
+	"terraform-cost/decision/estimation"
+	"terraform-cost/decision/iac"
+	"terraform-cost/pkg/terracost"
+)
+
+// =============================================================================
+// WHATIF COMMAND
+// Re-prices one plan as if deployed in other regions, side by side with where
+// it is deployed today, to weigh a region migration on cost and carbon.
+// =============================================================================
+
+// whatifExcludedFlags are estimate flags that don't apply to a what-if:
+// hypothetical estimates are neither saved nor sent
+var whatifExcludedFlags = map[string]bool{
+	"project":        true,
+	"branch":         true,
+	"commit":         true,
+	"pr":             true,
+	"notify":         true,
+	"notify-link":    true,
+	"export-metrics": true,
+	"include-carbon": true,
+	"explain":        true,
+	"exit-code":      true,
+	"soft-fail":      true,
+}
+
+func whatifCommand() *cli.Command {
+	var flags []cli.Flag
+	for _, f := range estimateFlags() {
+		if !whatifExcludedFlags[f.Names()[0]] {
+			flags = append(flags, f)
+		}
+	}
+	return &cli.Command{
+		Name:  "whatif",
+		Usage: "Compare a plan's cost and carbon deployed in other regions",
+		Flags: append(flags,
+			&cli.StringSliceFlag{
+				Name:     "region",
+				Required: true,
+				Usage:    "Candidate region, e.g. eu-west-1 or gcp:europe-west1 (comma-separated or repeatable)",
+			},
+			&cli.BoolFlag{
+				Name:  "include-carbon",
+				Value: true,
+				Usage: "Include carbon emissions per region",
+			},
+			&cli.StringFlag{
+				Name:    "format",
+				Aliases: []string{"f"},
+				Value:   "table",
+				Usage:   "Output format (table, json, markdown)",
+			},
+		),
+		Action: runWhatif,
+	}
+}
+
+// regionComparison is the plan's estimate in one region
+type regionComparison struct {
+	Region         string          `json:"region"`
+	Current        bool            `json:"current,omitempty"` // The plan as deployed
+	MonthlyCostP50 decimal.Decimal `json:"monthly_cost_p50"`
+	MonthlyCostP90 decimal.Decimal `json:"monthly_cost_p90"`
+	CarbonKgCO2    float64         `json:"carbon_kg_co2"`
+	Confidence     float64         `json:"confidence"`
+	Unpriced       int             `json:"unpriced"` // Cost drivers without a price in the region
+	PolicyResult   string          `json:"policy_result,omitempty"`
+}
+
+func runWhatif(c *cli.Context) error {
+	format, input := c.String("plan-format"), c.String("plan")
+	if c.String("path") != "" {
+		format, input = iac.FormatHCL, c.String("path")
+	}
+	if input == "" {
+		return fmt.Errorf("either --plan or --path is required")
+	}
+
+	var regions []string
+	for _, v := range c.StringSlice("region") {
+		for _, region := range strings.Split(v, ",") {
+			if region = strings.TrimSpace(region); region == "" {
+				continue
+			}
+			if _, err := terracost.ParseRegion(region); err != nil {
+				return err
+			}
+			regions = append(regions, region)
+		}
+	}
+	if len(regions) == 0 {
+		return fmt.Errorf("--region needs at least one region")
+	}
+
+	// The plan as deployed comes first, as the baseline
+	var cur string
+	comparisons := make([]regionComparison, 0, len(regions)+1)
+	for i, region := range append([]string{""}, regions...) {
+		label := region
+		if i == 0 {
+			label = "current"
+		}
+		fmt.Fprintf(os.Stderr, "\n── %s\n", label)
+		run, err := runPipelineFor(c, pipelineInput{format: format, input: input, env: c.String("env"), region: region})
+		if err != nil {
+			return fmt.Errorf("region %s: %w", label, err)
+		}
+		cur = run.result.Currency
+		cmp := regionComparison{
+			Region:         region,
+			Current:        i == 0,
+			MonthlyCostP50: run.result.MonthlyCostP50,
+			MonthlyCostP90: run.result.MonthlyCostP90,
+			CarbonKgCO2:    run.result.CarbonKgCO2,
+			Confidence:     run.result.Confidence,
+			Unpriced:       unpricedDrivers(run.result),
+		}
+		if i == 0 {
+			cmp.Region = strings.Join(driverRegions(run.result), ",")
+		}
+		if run.policyResult != nil {
+			cmp.PolicyResult = string(run.policyResult.Decision)
+		}
+		comparisons = append(comparisons, cmp)
+	}
+
+	switch c.String("format") {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]interface{}{
+			"currency": cur,
+			"regions":  comparisons,
+		})
+	case "markdown":
+		outputWhatifMarkdown(comparisons, cur)
+	default:
+		outputWhatifTable(comparisons, cur)
+	}
+	return nil
+}
+
+// driverRegions lists the regions an estimate's cost drivers are priced in
+func driverRegions(result *estimation.EstimationResult) []string {
+	seen := make(map[string]bool)
+	var regions []string
+	for _, d := range result.CostDrivers {
+		if d.Region != "" && !seen[d.Region] {
+			seen[d.Region] = true
+			regions = append(regions, d.Region)
+		}
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+// unpricedDrivers counts the cost drivers with no price, which a region
+// comparison would otherwise show as savings
+func unpricedDrivers(result *estimation.EstimationResult) int {
+	n := 0
+	for _, d := range result.CostDrivers {
+		if d.IsSymbolic && d.Reason == estimation.ReasonNoPricing {
+			n++
+		}
+	}
+	return n
+}
+
+// whatifLabel names a comparison's region, marking the plan as deployed
+func whatifLabel(cmp regionComparison) string {
+	if cmp.Current {
+		if cmp.Region == "" {
+			return "current"
+		}
+		return cmp.Region + " (current)"
+	}
+	return cmp.Region
+}
+
+func outputWhatifTable(comparisons []regionComparison, cur string) {
+	base := comparisons[0]
+	fmt.Println()
+	fmt.Printf("%-28s  %14s  %14s  %12s  %10s  %8s  %8s  %10s\n", "REGION", "MONTHLY P50", "MONTHLY P90", "CARBON (KG)", "VS CURRENT", "CARBON", "CONF.", "UNPRICED")
+	fmt.Println(strings.Repeat("─", 118))
+	for _, cmp := range comparisons {
+		fmt.Printf("%-28s  %14s  %14s  %12.2f  %10s  %8s  %7.0f%%  %10d\n",
+			truncate(whatifLabel(cmp), 28),
+			currency.Format(cmp.MonthlyCostP50, cur, 2),
+			currency.Format(cmp.MonthlyCostP90, cur, 2),
+			cmp.CarbonKgCO2,
+			relativeCost(cmp.MonthlyCostP50, base.MonthlyCostP50),
+			relativeCarbon(cmp.CarbonKgCO2, base.CarbonKgCO2),
+			cmp.Confidence*100,
+			cmp.Unpriced)
+	}
+	for _, cmp := range comparisons[1:] {
+		if cmp.Unpriced > base.Unpriced {
+			fmt.Printf("\n⚠️  %s has %d more unpriced cost driver(s) than the current deployment; its total is understated\n",
+				cmp.Region, cmp.Unpriced-base.Unpriced)
+		}
+	}
+}
+
+func outputWhatifMarkdown(comparisons []regionComparison, cur string) {
+	base := comparisons[0]
+	fmt.Println("## 🌍 TerraCost Region Comparison")
+	fmt.Println()
+	fmt.Println("| Region | Monthly Cost (P50) | Monthly Cost (P90) | Carbon | vs current | Carbon vs current | Confidence | Unpriced |")
+	fmt.Println("|--------|--------------------|--------------------|--------|------------|-------------------|------------|----------|")
+	for _, cmp := range comparisons {
+		fmt.Printf("| %s | %s | %s | %.2f kg CO2 | %s | %s | %.0f%% | %d |\n", whatifLabel(cmp),
+			currency.Format(cmp.MonthlyCostP50, cur, 2), currency.Format(cmp.MonthlyCostP90, cur, 2),
+			cmp.CarbonKgCO2, relativeCost(cmp.MonthlyCostP50, base.MonthlyCostP50),
+			relativeCarbon(cmp.CarbonKgCO2, base.CarbonKgCO2), cmp.Confidence*100, cmp.Unpriced)
+	}
+}
+
+// relativeCarbon is emissions against the current deployment's, e.g. "-40%"
+func relativeCarbon(kg, base float64) string {
+	if base == 0 {
+		return "—"
+	}
+	pct := (kg - base) / base * 100
+	if math.Round(pct) == 0 {
+		return "—"
+	}
+	return fmt.Sprintf("%+.0f%%", pct)
+}
@@ -25,10 +25,13 @@ CREATE TABLE IF NOT EXISTS pricing_snapshots (
     
     -- Versioning for time-travel
     _version        UInt64 DEFAULT 1,
-    _deleted        UInt8 DEFAULT 0
+    _deleted        UInt8 DEFAULT 0,
+
+    -- Owning org; '' is the shared catalog every org sees
+    org_id          LowCardinality(String) DEFAULT ''
 ) ENGINE = ReplacingMergeTree(_version)
 PARTITION BY toYYYYMM(created_at)
-ORDER BY (cloud, region, provider_alias, id)
+ORDER BY (org_id, cloud, region, provider_alias, id)
 SETTINGS index_granularity = 8192;
 
 -- Materialized view for active snapshots (fast lookups)
@@ -141,6 +144,31 @@ PARTITION BY cloud
 ORDER BY (cloud, region, id)
 SETTINGS index_granularity = 8192;
 
+-- ============================================================================
+-- SPOT PRICE HISTORY
+-- Observed spot prices per AZ; resolved as average/percentiles over a window
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS spot_price_history (
+    cloud               LowCardinality(String),
+    region              LowCardinality(String),
+    availability_zone   LowCardinality(String),
+    instance_type       LowCardinality(String),
+    product_description LowCardinality(String),  -- Linux/UNIX, Windows, ...
+    price               Decimal(18, 10),
+    currency            LowCardinality(String) DEFAULT 'USD',
+    observed_at         DateTime64(3),
+    source              LowCardinality(String),  -- aws_spot_price_history
+    created_at          DateTime64(3) DEFAULT now64(3),
+    
+    _version            UInt64 DEFAULT 1,
+    _deleted            UInt8 DEFAULT 0
+) ENGINE = ReplacingMergeTree(_version)
+PARTITION BY toYYYYMM(observed_at)
+ORDER BY (cloud, region, instance_type, product_description, availability_zone, observed_at)
+TTL toDateTime(observed_at) + INTERVAL 1 YEAR
+SETTINGS index_granularity = 8192;
+
 -- ============================================================================
 -- SERVICE CATALOG (Reference Data)
 -- ============================================================================
@@ -178,13 +206,17 @@ SETTINGS index_granularity = 8192;
 
 -- ============================================================================
 -- ESTIMATION AUDIT LOG
--- Track all estimation requests for auditability
+-- Signed, tamper-evident records of estimation requests and policy decisions.
+-- The signature covers record_json; the other columns make it queryable.
 -- ============================================================================
 
 CREATE TABLE IF NOT EXISTS estimation_audit_log (
     id              UUID,
-    request_hash    String,                   -- Hash of input for deduplication
+    org_id          LowCardinality(String) DEFAULT '',
+    project         LowCardinality(String) DEFAULT '',
+    request_hash    String,                   -- SHA-256 of the estimation request
     snapshot_ids    Array(UUID),              -- All snapshots used
+    policy_set_hash String DEFAULT '',        -- SHA-256 of the policies evaluated
     resource_count  UInt32,
     monthly_cost_p50 Decimal128(4),
     monthly_cost_p90 Decimal128(4),
@@ -193,6 +225,11 @@ CREATE TABLE IF NOT EXISTS estimation_audit_log (
     is_incomplete   UInt8,
     policy_result   LowCardinality(String),   -- pass, deny, warn
     violations      Array(String),
+    estimation_id   String DEFAULT '',        -- Saved estimation, if any
+    record_json     String DEFAULT '' CODEC(ZSTD(3)), -- Signed payload
+    algorithm       LowCardinality(String) DEFAULT '', -- hmac-sha256, ed25519
+    key_id          String DEFAULT '',
+    signature       String DEFAULT '',        -- Base64 signature of record_json
     created_at      DateTime64(3) DEFAULT now64(3),
     
     -- Request metadata
@@ -200,9 +237,86 @@ CREATE TABLE IF NOT EXISTS estimation_audit_log (
     environment     LowCardinality(String),   -- dev, staging, prod
     user_agent      Nullable(String)
 ) ENGINE = MergeTree()
-PARTITION BY toYYYYMM(created_at)
-ORDER BY (created_at, id)
-TTL created_at + INTERVAL 90 DAY
+PARTITION BY (org_id, toYYYYMM(created_at))
+ORDER BY (org_id, created_at, id)
+TTL toDateTime(created_at) + INTERVAL 7 YEAR
+SETTINGS index_granularity = 8192;
+
+-- ============================================================================
+-- ESTIMATIONS
+-- Saved estimation history per project for cost-per-PR tracking and trends
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS estimations (
+    id               UUID,
+    org_id           LowCardinality(String) DEFAULT '',
+    project          LowCardinality(String),
+    branch           String,
+    commit_sha       String,
+    pull_request     String,
+    environment      LowCardinality(String),
+    source           LowCardinality(String),   -- cli, api, ci
+    monthly_cost_p50 Decimal128(4),
+    monthly_cost_p90 Decimal128(4),
+    carbon_kg_co2    Float64,
+    confidence       Float64,
+    is_incomplete    UInt8,
+    resource_count   UInt32,
+    policy_result    LowCardinality(String),
+    result_json      String CODEC(ZSTD(3)),    -- Full EstimationResult
+    created_at       DateTime64(3) DEFAULT now64(3)
+) ENGINE = MergeTree()
+PARTITION BY (org_id, toYYYYMM(created_at))
+ORDER BY (org_id, project, created_at, id)
+SETTINGS index_granularity = 8192;
+
+-- ============================================================================
+-- BUDGETS
+-- Monthly spending limits scoped by project, environment and tag
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS budgets (
+    id               UUID,
+    org_id           LowCardinality(String) DEFAULT '',
+    name             String,
+    project          LowCardinality(String),   -- '' = every project
+    environment      LowCardinality(String),   -- '' = every environment
+    branch           String,                   -- '' = latest estimate of any branch
+    tag_key          LowCardinality(String),   -- '' = whole estimate
+    tag_value        String,
+    monthly_limit    Decimal128(4),
+    currency         LowCardinality(String) DEFAULT 'USD',
+    alert_threshold  Float64 DEFAULT 80,       -- percent of the limit that warns
+    created_at       DateTime64(3) DEFAULT now64(3),
+    updated_at       DateTime64(3) DEFAULT now64(3),
+    _version         UInt64 DEFAULT 1,
+    _deleted         UInt8 DEFAULT 0
+) ENGINE = ReplacingMergeTree(_version)
+ORDER BY (org_id, id)
+SETTINGS index_granularity = 8192;
+
+-- ============================================================================
+-- ESTIMATION JOBS
+-- Async estimate requests and their results; rows expire with the server's retention
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS estimation_jobs (
+    id               UUID,
+    org_id           LowCardinality(String) DEFAULT '',
+    status           LowCardinality(String),   -- queued, running, succeeded, failed
+    project          LowCardinality(String),
+    environment      LowCardinality(String),
+    error            String,
+    error_code       LowCardinality(String) DEFAULT '',  -- pkg/errors code of a failed job
+    result_json      String CODEC(ZSTD(3)),    -- EstimateResponse of a succeeded job
+    created_at       DateTime64(3),
+    started_at       Nullable(DateTime64(3)),
+    finished_at      Nullable(DateTime64(3)),
+    expires_at       DateTime64(3),
+    _version         UInt64
+) ENGINE = ReplacingMergeTree(_version)
+ORDER BY (org_id, id)
+TTL toDateTime(expires_at)
 SETTINGS index_granularity = 8192;
 
 -- ============================================================================
@@ -0,0 +1,11 @@
+-- ============================================================================
+-- Multi-tenancy for databases created before org scoping
+-- Fresh installs get org_id from 001; these statements are then no-ops.
+-- Existing rows belong to the default org ('') and keep their old sort keys;
+-- recreate the tables from 001 to also partition existing data by org.
+-- ============================================================================
+
+ALTER TABLE pricing_snapshots ADD COLUMN IF NOT EXISTS org_id LowCardinality(String) DEFAULT '';
+ALTER TABLE estimations ADD COLUMN IF NOT EXISTS org_id LowCardinality(String) DEFAULT '';
+ALTER TABLE budgets ADD COLUMN IF NOT EXISTS org_id LowCardinality(String) DEFAULT '';
+ALTER TABLE estimation_jobs ADD COLUMN IF NOT EXISTS org_id LowCardinality(String) DEFAULT '';
@@ -0,0 +1,22 @@
+-- ============================================================================
+-- COST AND USAGE REPORT
+-- AWS CUR line items, reconciled against saved estimations by resource ID and tags
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS cur_line_items (
+    org_id           LowCardinality(String) DEFAULT '',
+    line_item_id     String,                   -- identity/LineItemId; re-imports replace the item
+    usage_start      DateTime,
+    resource_id      String,                   -- ARN or ID; '' for unattributed usage
+    product_code     LowCardinality(String),   -- AmazonEC2, AmazonS3, ...
+    usage_type       LowCardinality(String),   -- USE1-BoxUsage:t3.medium
+    line_item_type   LowCardinality(String),   -- Usage, Tax, Credit, ...
+    usage_amount     Float64,
+    unblended_cost   Decimal128(8),
+    tags             Map(String, String),      -- User tags without the user: prefix
+    source           String,                   -- File or S3 URL the item came from
+    imported_at      DateTime64(3) DEFAULT now64(3)
+) ENGINE = ReplacingMergeTree(imported_at)
+PARTITION BY (org_id, toYYYYMM(usage_start))
+ORDER BY (org_id, usage_start, line_item_id)
+SETTINGS index_granularity = 8192;
@@ -0,0 +1,15 @@
+-- ============================================================================
+-- Signed audit records for databases created before the audit log was used
+-- Fresh installs get these columns from 001; these statements are then no-ops.
+-- Audit records are kept for compliance: seven years instead of 90 days.
+-- ============================================================================
+
+ALTER TABLE estimation_audit_log ADD COLUMN IF NOT EXISTS org_id LowCardinality(String) DEFAULT '';
+ALTER TABLE estimation_audit_log ADD COLUMN IF NOT EXISTS project LowCardinality(String) DEFAULT '';
+ALTER TABLE estimation_audit_log ADD COLUMN IF NOT EXISTS policy_set_hash String DEFAULT '';
+ALTER TABLE estimation_audit_log ADD COLUMN IF NOT EXISTS estimation_id String DEFAULT '';
+ALTER TABLE estimation_audit_log ADD COLUMN IF NOT EXISTS record_json String DEFAULT '' CODEC(ZSTD(3));
+ALTER TABLE estimation_audit_log ADD COLUMN IF NOT EXISTS algorithm LowCardinality(String) DEFAULT '';
+ALTER TABLE estimation_audit_log ADD COLUMN IF NOT EXISTS key_id String DEFAULT '';
+ALTER TABLE estimation_audit_log ADD COLUMN IF NOT EXISTS signature String DEFAULT '';
+ALTER TABLE estimation_audit_log MODIFY TTL toDateTime(created_at) + INTERVAL 7 YEAR;
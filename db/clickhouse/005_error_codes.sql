@@ -0,0 +1,6 @@
+-- ============================================================================
+-- Error codes of failed async jobs, for databases created before error codes
+-- Fresh installs get the column from 001; this statement is then a no-op.
+-- ============================================================================
+
+ALTER TABLE estimation_jobs ADD COLUMN IF NOT EXISTS error_code LowCardinality(String) DEFAULT '' AFTER error;
@@ -0,0 +1,22 @@
+-- ============================================================================
+-- NEGOTIATED DISCOUNTS
+-- Private pricing of an org (EDP, private rate cards), taken off list prices;
+-- the most specific discount covering a cost driver applies
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS discounts (
+    id               UUID,
+    org_id           LowCardinality(String) DEFAULT '',
+    name             String,
+    cloud            LowCardinality(String),   -- '' = every cloud
+    service          LowCardinality(String),   -- '' = every service
+    product_family   LowCardinality(String),   -- '' = every product family
+    region           LowCardinality(String),   -- '' = every region
+    percent          Float64,                  -- off the list price, 0-100
+    created_at       DateTime64(3) DEFAULT now64(3),
+    updated_at       DateTime64(3) DEFAULT now64(3),
+    _version         UInt64 DEFAULT 1,
+    _deleted         UInt8 DEFAULT 0
+) ENGINE = ReplacingMergeTree(_version)
+ORDER BY (org_id, id)
+SETTINGS index_granularity = 8192;
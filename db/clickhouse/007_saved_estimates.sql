@@ -0,0 +1,23 @@
+-- ============================================================================
+-- SAVED ESTIMATES
+-- Estimate responses persisted on request, so PR comments can link to a stable
+-- record; rows expire with the server's retention or are deleted on request
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS saved_estimates (
+    id               UUID,                     -- Shared with the history estimation, if any
+    org_id           LowCardinality(String) DEFAULT '',
+    project          LowCardinality(String),
+    branch           String,
+    commit_sha       String,
+    pull_request     String,
+    environment      LowCardinality(String),
+    response_json    String CODEC(ZSTD(3)),    -- EstimateResponse
+    created_at       DateTime64(3),
+    expires_at       DateTime64(3),
+    _version         UInt64,
+    _deleted         UInt8 DEFAULT 0
+) ENGINE = ReplacingMergeTree(_version)
+ORDER BY (org_id, id)
+TTL toDateTime(expires_at)
+SETTINGS index_granularity = 8192;
@@ -0,0 +1,31 @@
+-- ============================================================================
+-- APPROVALS
+-- Requests to override a policy deny; finance approves or rejects them, and
+-- the CLI waiting on a request passes or fails its gate accordingly
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS approvals (
+    id                UUID,
+    org_id            LowCardinality(String) DEFAULT '',
+    project           LowCardinality(String),
+    environment       LowCardinality(String),
+    branch            String,
+    commit_sha        String,
+    pull_request      String,
+    estimation_id     String,                   -- '' when not saved to history
+    link              String,                   -- PR or pipeline the request came from
+    currency          LowCardinality(String),
+    monthly_cost_p50  Decimal(18, 6),
+    violations        Array(String),
+    status            LowCardinality(String),   -- pending, approved, rejected
+    requested_by      String,                   -- token subject
+    decided_by        String,
+    comment           String,
+    created_at        DateTime64(3),
+    decided_at        Nullable(DateTime64(3)),
+    expires_at        DateTime64(3),            -- pending requests lapse after this
+    _version          UInt64,
+    _deleted          UInt8 DEFAULT 0
+) ENGINE = ReplacingMergeTree(_version)
+ORDER BY (org_id, id)
+SETTINGS index_granularity = 8192;
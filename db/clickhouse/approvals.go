@@ -0,0 +1,228 @@
+// Package clickhouse - Approvals
+// An approval asks to override a policy deny; it is decided once, or lapses
+package clickhouse
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/tenant"
+)
+
+// ApprovalStatus is where an approval request stands
+type ApprovalStatus string
+
+const (
+	ApprovalPending  ApprovalStatus = "pending"
+	ApprovalApproved ApprovalStatus = "approved"
+	ApprovalRejected ApprovalStatus = "rejected"
+	ApprovalExpired  ApprovalStatus = "expired" // Pending past ExpiresAt; never stored
+)
+
+// ErrApprovalDecided is returned when deciding an approval that is no longer pending
+var ErrApprovalDecided = fmt.Errorf("approval is no longer pending")
+
+// Approval is a request to override a policy deny
+type Approval struct {
+	ID             uuid.UUID       `json:"id"`
+	Project        string          `json:"project"`
+	Environment    string          `json:"environment,omitempty"`
+	Branch         string          `json:"branch,omitempty"`
+	CommitSHA      string          `json:"commit_sha,omitempty"`
+	PullRequest    string          `json:"pull_request,omitempty"`
+	EstimationID   string          `json:"estimation_id,omitempty"`
+	Link           string          `json:"link,omitempty"`
+	Currency       string          `json:"currency"`
+	MonthlyCostP50 decimal.Decimal `json:"monthly_cost_p50"`
+	Violations     []string        `json:"violations"`
+	Status         ApprovalStatus  `json:"status"`
+	RequestedBy    string          `json:"requested_by,omitempty"`
+	DecidedBy      string          `json:"decided_by,omitempty"`
+	Comment        string          `json:"comment,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DecidedAt      *time.Time      `json:"decided_at,omitempty"`
+	ExpiresAt      time.Time       `json:"expires_at"`
+}
+
+// ApprovalFilter narrows ListApprovals; empty fields match everything
+type ApprovalFilter struct {
+	Project string
+	Status  ApprovalStatus
+	Limit   int // Default 100
+}
+
+// expire reports pending approvals past their expiry as expired
+func (a *Approval) expire(now time.Time) {
+	if a.Status == ApprovalPending && !now.Before(a.ExpiresAt) {
+		a.Status = ApprovalExpired
+	}
+}
+
+// CreateApproval stores a new pending approval
+func (s *Store) CreateApproval(ctx context.Context, a *Approval) error {
+	if strings.TrimSpace(a.Project) == "" {
+		return fmt.Errorf("approval project is required")
+	}
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now().UTC()
+	}
+	if a.Violations == nil {
+		a.Violations = []string{}
+	}
+	a.Status = ApprovalPending
+	return s.writeApproval(ctx, a)
+}
+
+// DecideApproval approves or rejects a pending approval
+// ClickHouse cannot make the pending check and the write atomic, so concurrent
+// decisions may both be written. The earliest decision wins the merge (see
+// approvalVersion); the approval is re-read after writing, and a decision that
+// lost gets ErrApprovalDecided with a set to the one that won. A decision whose
+// write lands only after a later decision re-read still wins without the later
+// caller learning of it.
+func (s *Store) DecideApproval(ctx context.Context, a *Approval, status ApprovalStatus, decidedBy, comment string) error {
+	if status != ApprovalApproved && status != ApprovalRejected {
+		return fmt.Errorf("invalid approval decision %q", status)
+	}
+	now := time.Now().UTC()
+	a.expire(now)
+	if a.Status != ApprovalPending {
+		return ErrApprovalDecided
+	}
+	a.Status, a.DecidedBy, a.Comment, a.DecidedAt = status, decidedBy, comment, &now
+	if err := s.writeApproval(ctx, a); err != nil {
+		return err
+	}
+
+	stored, err := s.GetApproval(ctx, a.ID)
+	if err != nil {
+		return err
+	}
+	if stored != nil && (stored.Status != status || stored.DecidedBy != decidedBy) {
+		*a = *stored
+		return ErrApprovalDecided
+	}
+	return nil
+}
+
+// approvalVersion orders an approval's rows for ReplacingMergeTree: decisions
+// replace the pending row, and the earliest decision replaces later ones
+func approvalVersion(a *Approval) uint64 {
+	if a.DecidedAt == nil {
+		return uint64(time.Now().UnixNano())
+	}
+	return 1<<63 | uint64(math.MaxInt64-a.DecidedAt.UnixNano())
+}
+
+// writeApproval inserts an approval row in the context's org; the highest _version wins on merge
+func (s *Store) writeApproval(ctx context.Context, a *Approval) error {
+	query := `
+		INSERT INTO approvals (
+			id, org_id, project, environment, branch, commit_sha, pull_request, estimation_id, link,
+			currency, monthly_cost_p50, violations, status, requested_by, decided_by, comment,
+			created_at, decided_at, expires_at, _version
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	if err := s.conn.Exec(ctx, query,
+		a.ID, tenant.OrgID(ctx), a.Project, a.Environment, a.Branch, a.CommitSHA, a.PullRequest, a.EstimationID, a.Link,
+		a.Currency, a.MonthlyCostP50, a.Violations, string(a.Status), a.RequestedBy, a.DecidedBy, a.Comment,
+		a.CreatedAt, a.DecidedAt, a.ExpiresAt, approvalVersion(a),
+	); err != nil {
+		return fmt.Errorf("failed to write approval: %w", err)
+	}
+	return nil
+}
+
+const approvalColumns = `id, project, environment, branch, commit_sha, pull_request, estimation_id, link,
+			currency, monthly_cost_p50, violations, status, requested_by, decided_by, comment,
+			created_at, decided_at, expires_at`
+
+// GetApproval returns an approval of the context's org by ID, or nil
+func (s *Store) GetApproval(ctx context.Context, id uuid.UUID) (*Approval, error) {
+	query := `SELECT ` + approvalColumns + `
+		FROM approvals FINAL
+		WHERE org_id = ? AND id = ? AND _deleted = 0
+	`
+	a, err := scanApproval(s.conn.QueryRow(ctx, query, tenant.OrgID(ctx), id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approval: %w", err)
+	}
+	return a, nil
+}
+
+// ListApprovals returns the context org's approvals, newest first
+func (s *Store) ListApprovals(ctx context.Context, filter ApprovalFilter) ([]*Approval, error) {
+	where := []string{"org_id = ?", "_deleted = 0"}
+	args := []interface{}{tenant.OrgID(ctx)}
+	if filter.Project != "" {
+		where = append(where, "project = ?")
+		args = append(args, filter.Project)
+	}
+	switch filter.Status {
+	case "":
+	case ApprovalPending:
+		where = append(where, "status = 'pending' AND expires_at > now64(3)")
+	case ApprovalExpired:
+		where = append(where, "status = 'pending' AND expires_at <= now64(3)")
+	default:
+		where = append(where, "status = ?")
+		args = append(args, string(filter.Status))
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	query := `SELECT ` + approvalColumns + `
+		FROM approvals FINAL
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY created_at DESC
+		LIMIT ?
+	`
+	rows, err := s.conn.Query(ctx, query, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list approvals: %w", err)
+	}
+	defer rows.Close()
+
+	approvals := make([]*Approval, 0)
+	for rows.Next() {
+		a, err := scanApproval(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan approval: %w", err)
+		}
+		approvals = append(approvals, a)
+	}
+	return approvals, nil
+}
+
+func scanApproval(row interface {
+	Scan(dest ...interface{}) error
+}) (*Approval, error) {
+	var a Approval
+	var status string
+	err := row.Scan(
+		&a.ID, &a.Project, &a.Environment, &a.Branch, &a.CommitSHA, &a.PullRequest, &a.EstimationID, &a.Link,
+		&a.Currency, &a.MonthlyCostP50, &a.Violations, &status, &a.RequestedBy, &a.DecidedBy, &a.Comment,
+		&a.CreatedAt, &a.DecidedAt, &a.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	a.Status = ApprovalStatus(status)
+	a.expire(time.Now())
+	return &a, nil
+}
@@ -0,0 +1,21 @@
+package clickhouse
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApprovalVersion(t *testing.T) {
+	pending := approvalVersion(&Approval{})
+	first, second := time.Now(), time.Now().Add(time.Millisecond)
+	approved := approvalVersion(&Approval{DecidedAt: &first})
+	rejected := approvalVersion(&Approval{DecidedAt: &second})
+
+	if approved <= pending || rejected <= pending {
+		t.Errorf("decisions %d, %d must replace pending %d", approved, rejected, pending)
+	}
+	// Concurrent decisions are settled in favour of the earliest
+	if approved <= rejected {
+		t.Errorf("earlier decision %d must replace later %d", approved, rejected)
+	}
+}
@@ -0,0 +1,87 @@
+// Package clickhouse - Estimation audit log
+// Signed records of what was estimated and which policies ran; the signature
+// covers RecordJSON, the remaining columns make records queryable
+package clickhouse
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/tenant"
+)
+
+// AuditRecord is a signed estimation audit log entry
+type AuditRecord struct {
+	ID             uuid.UUID       `ch:"id"`
+	Project        string          `ch:"project"`
+	Environment    string          `ch:"environment"`
+	Source         string          `ch:"source"`
+	RequestHash    string          `ch:"request_hash"`
+	SnapshotIDs    []uuid.UUID     `ch:"snapshot_ids"`
+	PolicySetHash  string          `ch:"policy_set_hash"`
+	PolicyResult   string          `ch:"policy_result"`
+	Violations     []string        `ch:"violations"`
+	ResourceCount  int             `ch:"resource_count"`
+	MonthlyCostP50 decimal.Decimal `ch:"monthly_cost_p50"`
+	MonthlyCostP90 decimal.Decimal `ch:"monthly_cost_p90"`
+	CarbonKgCO2    float64         `ch:"carbon_kg_co2"`
+	Confidence     float64         `ch:"confidence"`
+	IsIncomplete   bool            `ch:"is_incomplete"`
+	EstimationID   string          `ch:"estimation_id"`
+	RecordJSON     string          `ch:"record_json"` // Signed payload
+	Algorithm      string          `ch:"algorithm"`
+	KeyID          string          `ch:"key_id"`
+	Signature      string          `ch:"signature"` // Base64
+	CreatedAt      time.Time       `ch:"created_at"`
+}
+
+const auditColumns = `id, project, environment, source, request_hash, snapshot_ids, policy_set_hash,
+	policy_result, violations, resource_count, monthly_cost_p50, monthly_cost_p90, carbon_kg_co2,
+	confidence, is_incomplete, estimation_id, record_json, algorithm, key_id, signature, created_at`
+
+// SaveAuditRecord appends a signed record to the audit log, under the context's org
+func (s *Store) SaveAuditRecord(ctx context.Context, rec *AuditRecord) error {
+	query := `INSERT INTO estimation_audit_log (org_id, ` + auditColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	if err := s.conn.Exec(ctx, query,
+		tenant.OrgID(ctx), rec.ID, rec.Project, rec.Environment, rec.Source, rec.RequestHash, rec.SnapshotIDs,
+		rec.PolicySetHash, rec.PolicyResult, rec.Violations, uint32(rec.ResourceCount),
+		rec.MonthlyCostP50, rec.MonthlyCostP90, rec.CarbonKgCO2, rec.Confidence, boolToUInt8(rec.IsIncomplete),
+		rec.EstimationID, rec.RecordJSON, rec.Algorithm, rec.KeyID, rec.Signature, rec.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("failed to save audit record: %w", err)
+	}
+	return nil
+}
+
+// GetAuditRecord returns an audit record of the context's org, or nil if there is none
+func (s *Store) GetAuditRecord(ctx context.Context, id uuid.UUID) (*AuditRecord, error) {
+	query := `SELECT ` + auditColumns + `
+		FROM estimation_audit_log
+		WHERE org_id = ? AND id = ?
+		LIMIT 1`
+
+	var rec AuditRecord
+	var resourceCount uint32
+	var incomplete uint8
+	err := s.conn.QueryRow(ctx, query, tenant.OrgID(ctx), id).Scan(
+		&rec.ID, &rec.Project, &rec.Environment, &rec.Source, &rec.RequestHash, &rec.SnapshotIDs,
+		&rec.PolicySetHash, &rec.PolicyResult, &rec.Violations, &resourceCount,
+		&rec.MonthlyCostP50, &rec.MonthlyCostP90, &rec.CarbonKgCO2, &rec.Confidence, &incomplete,
+		&rec.EstimationID, &rec.RecordJSON, &rec.Algorithm, &rec.KeyID, &rec.Signature, &rec.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit record: %w", err)
+	}
+	rec.ResourceCount = int(resourceCount)
+	rec.IsIncomplete = incomplete == 1
+	return &rec, nil
+}
@@ -0,0 +1,195 @@
+// Package clickhouse - Budgets
+// Budgets are monthly spending limits scoped by project, environment and tag
+package clickhouse
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/tenant"
+)
+
+// DefaultBudgetAlertThreshold is the percent of a budget that triggers a warning
+const DefaultBudgetAlertThreshold = 80
+
+// Budget is a monthly spending limit; empty scope fields match everything
+type Budget struct {
+	ID             uuid.UUID       `json:"id"`
+	Name           string          `json:"name"`
+	Project        string          `json:"project,omitempty"`
+	Environment    string          `json:"environment,omitempty"`
+	Branch         string          `json:"branch,omitempty"` // only this branch's estimates count as spend
+	TagKey         string          `json:"tag_key,omitempty"`
+	TagValue       string          `json:"tag_value,omitempty"`
+	MonthlyLimit   decimal.Decimal `json:"monthly_limit"`
+	Currency       string          `json:"currency"`
+	AlertThreshold float64         `json:"alert_threshold"` // percent of the limit
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// BudgetFilter narrows ListBudgets; empty fields match everything
+type BudgetFilter struct {
+	Project     string
+	Environment string
+}
+
+// Validate checks a budget and fills defaults
+func (b *Budget) Validate() error {
+	if strings.TrimSpace(b.Name) == "" {
+		return fmt.Errorf("budget name is required")
+	}
+	if !b.MonthlyLimit.IsPositive() {
+		return fmt.Errorf("budget monthly_limit must be positive")
+	}
+	if (b.TagKey == "") != (b.TagValue == "") {
+		return fmt.Errorf("budget tag_key and tag_value must be set together")
+	}
+	if b.AlertThreshold == 0 {
+		b.AlertThreshold = DefaultBudgetAlertThreshold
+	}
+	if b.AlertThreshold < 0 || b.AlertThreshold > 100 {
+		return fmt.Errorf("budget alert_threshold must be between 0 and 100")
+	}
+	if b.Currency == "" {
+		b.Currency = "USD"
+	}
+	b.Currency = strings.ToUpper(b.Currency)
+	return nil
+}
+
+// Applies reports whether the budget covers estimates of a project and environment
+func (b *Budget) Applies(project, environment string) bool {
+	return (b.Project == "" || b.Project == project) &&
+		(b.Environment == "" || b.Environment == environment)
+}
+
+// CreateBudget stores a new budget
+func (s *Store) CreateBudget(ctx context.Context, b *Budget) error {
+	if err := b.Validate(); err != nil {
+		return err
+	}
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	b.CreatedAt, b.UpdatedAt = now, now
+	return s.writeBudget(ctx, b, false)
+}
+
+// UpdateBudget replaces a stored budget's fields
+func (s *Store) UpdateBudget(ctx context.Context, b *Budget) error {
+	if err := b.Validate(); err != nil {
+		return err
+	}
+	b.UpdatedAt = time.Now().UTC()
+	return s.writeBudget(ctx, b, false)
+}
+
+// DeleteBudget removes a budget
+func (s *Store) DeleteBudget(ctx context.Context, b *Budget) error {
+	b.UpdatedAt = time.Now().UTC()
+	return s.writeBudget(ctx, b, true)
+}
+
+// writeBudget inserts a budget row in the context's org; the newest _version wins on merge
+func (s *Store) writeBudget(ctx context.Context, b *Budget, deleted bool) error {
+	query := `
+		INSERT INTO budgets (
+			id, org_id, name, project, environment, branch, tag_key, tag_value,
+			monthly_limit, currency, alert_threshold, created_at, updated_at,
+			_version, _deleted
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	if err := s.conn.Exec(ctx, query,
+		b.ID, tenant.OrgID(ctx), b.Name, b.Project, b.Environment, b.Branch, b.TagKey, b.TagValue,
+		b.MonthlyLimit, b.Currency, b.AlertThreshold, b.CreatedAt, b.UpdatedAt,
+		uint64(b.UpdatedAt.UnixNano()), boolToUInt8(deleted),
+	); err != nil {
+		return fmt.Errorf("failed to write budget: %w", err)
+	}
+	return nil
+}
+
+const budgetColumns = `id, name, project, environment, branch, tag_key, tag_value,
+			monthly_limit, currency, alert_threshold, created_at, updated_at`
+
+// GetBudget returns a budget of the context's org by ID, or nil
+func (s *Store) GetBudget(ctx context.Context, id uuid.UUID) (*Budget, error) {
+	query := `SELECT ` + budgetColumns + `
+		FROM budgets FINAL
+		WHERE org_id = ? AND id = ? AND _deleted = 0
+	`
+	b, err := scanBudget(s.conn.QueryRow(ctx, query, tenant.OrgID(ctx), id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budget: %w", err)
+	}
+	return b, nil
+}
+
+// ListBudgets returns budgets ordered by name
+func (s *Store) ListBudgets(ctx context.Context, filter BudgetFilter) ([]*Budget, error) {
+	where := []string{"_deleted = 0"}
+	args := make([]interface{}, 0)
+	if filter.Project != "" {
+		where = append(where, "project = ?")
+		args = append(args, filter.Project)
+	}
+	if filter.Environment != "" {
+		where = append(where, "environment = ?")
+		args = append(args, filter.Environment)
+	}
+	return s.queryBudgets(ctx, strings.Join(where, " AND "), args...)
+}
+
+// BudgetsFor returns the budgets that apply to a project and environment
+func (s *Store) BudgetsFor(ctx context.Context, project, environment string) ([]*Budget, error) {
+	return s.queryBudgets(ctx, "_deleted = 0 AND project IN ('', ?) AND environment IN ('', ?)", project, environment)
+}
+
+// queryBudgets returns the context org's budgets matching a condition
+func (s *Store) queryBudgets(ctx context.Context, where string, args ...interface{}) ([]*Budget, error) {
+	query := `SELECT ` + budgetColumns + `
+		FROM budgets FINAL
+		WHERE org_id = ? AND ` + where + `
+		ORDER BY name, id
+	`
+	rows, err := s.conn.Query(ctx, query, append([]interface{}{tenant.OrgID(ctx)}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list budgets: %w", err)
+	}
+	defer rows.Close()
+
+	budgets := make([]*Budget, 0)
+	for rows.Next() {
+		b, err := scanBudget(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan budget: %w", err)
+		}
+		budgets = append(budgets, b)
+	}
+	return budgets, nil
+}
+
+func scanBudget(row interface {
+	Scan(dest ...interface{}) error
+}) (*Budget, error) {
+	var b Budget
+	err := row.Scan(
+		&b.ID, &b.Name, &b.Project, &b.Environment, &b.Branch, &b.TagKey, &b.TagValue,
+		&b.MonthlyLimit, &b.Currency, &b.AlertThreshold, &b.CreatedAt, &b.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
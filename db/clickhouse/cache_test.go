@@ -0,0 +1,54 @@
+package clickhouse
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+func TestLRUCache(t *testing.T) {
+	ctx := context.Background()
+	cache := NewLRUCache(2)
+	rate := func(p int64) *ResolvedRate { return &ResolvedRate{Price: decimal.NewFromInt(p)} }
+
+	cache.Set(ctx, "a", rate(1), time.Hour)
+	cache.Set(ctx, "b", nil, time.Hour) // known-missing
+	cache.Get(ctx, "a")                 // a is now most recent
+	cache.Set(ctx, "c", rate(3), time.Hour)
+
+	if _, ok := cache.Get(ctx, "b"); ok {
+		t.Error("b should have been evicted")
+	}
+	if got, ok := cache.Get(ctx, "a"); !ok || !got.Price.Equal(decimal.NewFromInt(1)) {
+		t.Errorf("a: got %v, %v", got, ok)
+	}
+
+	cache.Set(ctx, "miss", nil, time.Hour)
+	if got, ok := cache.Get(ctx, "miss"); !ok || got != nil {
+		t.Errorf("cached miss: got %v, %v", got, ok)
+	}
+
+	cache.Set(ctx, "expired", rate(4), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, ok := cache.Get(ctx, "expired"); ok {
+		t.Error("expired entry should not be returned")
+	}
+	if cache.Len() > 2 {
+		t.Errorf("cache grew past capacity: %d", cache.Len())
+	}
+}
+
+func TestRateCacheKeyIgnoresAttributeOrder(t *testing.T) {
+	snapshot := uuid.New()
+	a := RateLookup{Service: "AmazonEC2", Attributes: map[string]string{"instanceType": "m5.large", "tenancy": "Shared"}, Unit: "Hrs"}
+	b := RateLookup{Service: "AmazonEC2", Attributes: map[string]string{"tenancy": "Shared", "instanceType": "m5.large"}, Unit: "Hrs"}
+	if rateCacheKey(snapshot, a) != rateCacheKey(snapshot, b) {
+		t.Error("equal attribute sets should share a cache key")
+	}
+	if rateCacheKey(snapshot, a) == rateCacheKey(uuid.New(), a) {
+		t.Error("a new snapshot should change the cache key")
+	}
+}
@@ -0,0 +1,121 @@
+package clickhouse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// =============================================================================
+// NEAREST RATES
+// When a lookup finds no price, the rates of its snapshot with the same service
+// and product family are ranked by how many of its attributes (and its unit)
+// they share, to show which part of the rate key failed to match.
+// =============================================================================
+
+// RateCandidate is a priced rate near a lookup that found no price
+type RateCandidate struct {
+	Attributes map[string]string `json:"attributes"`
+	Unit       string            `json:"unit"`
+	Price      decimal.Decimal   `json:"price"`
+	Currency   string            `json:"currency"`
+	Mismatched []string          `json:"mismatched,omitempty"` // Attributes (and "unit") that differ from the lookup, sorted
+}
+
+// NearestRates returns up to limit rates of the snapshot a lookup resolves
+// against that share its service and product family, closest first.
+// The snapshot is nil when none covers the lookup's cloud, region and alias.
+func (s *Store) NearestRates(ctx context.Context, l RateLookup, limit int) (*PricingSnapshot, []RateCandidate, error) {
+	var snapshot *PricingSnapshot
+	var err error
+	if l.At.IsZero() {
+		snapshot, err = s.GetActiveSnapshot(ctx, l.Cloud, l.Region, l.Alias)
+	} else {
+		snapshot, err = s.GetSnapshotAt(ctx, l.Cloud, l.Region, l.Alias, l.At)
+	}
+	if err != nil || snapshot == nil {
+		return nil, nil, err
+	}
+
+	score, scoreArgs := candidateScore(l)
+	query := fmt.Sprintf(`
+		SELECT rk.attributes, pr.unit, pr.price, pr.currency, %s AS score
+		FROM pricing_rates pr FINAL
+		JOIN pricing_rate_keys rk FINAL ON pr.rate_key_id = rk.id
+		WHERE pr.snapshot_id = ? AND rk.service = ? AND rk.product_family = ?
+		  AND pr._deleted = 0 AND rk._deleted = 0
+		ORDER BY score DESC, pr.tier_min NULLS FIRST
+		LIMIT 1 BY rk.attributes_hash, pr.unit
+		LIMIT ?
+	`, score)
+	args := append(scoreArgs, snapshot.ID, l.Service, l.ProductFamily, limit)
+
+	rows, err := s.conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find nearest rates: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []RateCandidate
+	for rows.Next() {
+		var c RateCandidate
+		var attrsJSON string
+		var score uint64
+		if err := rows.Scan(&attrsJSON, &c.Unit, &c.Price, &c.Currency, &score); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan rate: %w", err)
+		}
+		if err := json.Unmarshal([]byte(attrsJSON), &c.Attributes); err != nil {
+			return nil, nil, fmt.Errorf("failed to unmarshal attributes: %w", err)
+		}
+		c.Mismatched = mismatchedAttributes(l, c)
+		candidates = append(candidates, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to find nearest rates: %w", err)
+	}
+	return snapshot, candidates, nil
+}
+
+// candidateScore builds the expression counting the lookup attributes and unit a rate shares
+func candidateScore(l RateLookup) (string, []interface{}) {
+	keys := make([]string, 0, len(l.Attributes))
+	for k := range l.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	terms := make([]string, 0, len(keys)+1)
+	args := make([]interface{}, 0, 2*len(keys)+1)
+	for _, k := range keys {
+		terms = append(terms, "toUInt64(JSONExtractString(rk.attributes, ?) = ?)")
+		args = append(args, k, l.Attributes[k])
+	}
+	terms = append(terms, "toUInt64(pr.unit = ?)")
+	args = append(args, l.Unit)
+	return strings.Join(terms, " + "), args
+}
+
+// mismatchedAttributes lists the attributes a candidate has other values for, or lacks
+func mismatchedAttributes(l RateLookup, c RateCandidate) []string {
+	var mismatched []string
+	for k, v := range l.Attributes {
+		if c.Attributes[k] != v {
+			mismatched = append(mismatched, k)
+		}
+	}
+	// Rate keys match on their full attribute set, so extra attributes miss too
+	for k := range c.Attributes {
+		if _, ok := l.Attributes[k]; !ok {
+			mismatched = append(mismatched, k)
+		}
+	}
+	if c.Unit != l.Unit {
+		mismatched = append(mismatched, "unit")
+	}
+	sort.Strings(mismatched)
+	return mismatched
+}
@@ -0,0 +1,36 @@
+package clickhouse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCandidateScore(t *testing.T) {
+	l := RateLookup{Attributes: map[string]string{"tenancy": "Shared", "instanceType": "m7i.large"}, Unit: "Hrs"}
+	expr, args := candidateScore(l)
+	want := "toUInt64(JSONExtractString(rk.attributes, ?) = ?) + toUInt64(JSONExtractString(rk.attributes, ?) = ?) + toUInt64(pr.unit = ?)"
+	if expr != want {
+		t.Errorf("expr = %s", expr)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"instanceType", "m7i.large", "tenancy", "Shared", "Hrs"}) {
+		t.Errorf("args = %v", args)
+	}
+}
+
+func TestMismatchedAttributes(t *testing.T) {
+	l := RateLookup{Attributes: map[string]string{"instanceType": "m7i.large", "operatingSystem": "Linux", "tenancy": "Shared"}, Unit: "Hrs"}
+	c := RateCandidate{Attributes: map[string]string{"instanceType": "m6i.large", "operatingSystem": "Linux"}, Unit: "Hrs"}
+	if got := mismatchedAttributes(l, c); !reflect.DeepEqual(got, []string{"instanceType", "tenancy"}) {
+		t.Errorf("mismatched = %v", got)
+	}
+	c.Attributes = l.Attributes
+	c.Unit = "GB-Mo"
+	if got := mismatchedAttributes(l, c); !reflect.DeepEqual(got, []string{"unit"}) {
+		t.Errorf("mismatched = %v", got)
+	}
+	c.Attributes = map[string]string{"instanceType": "m7i.large", "operatingSystem": "Linux", "tenancy": "Shared", "licenseModel": "No License required"}
+	c.Unit = "Hrs"
+	if got := mismatchedAttributes(l, c); !reflect.DeepEqual(got, []string{"licenseModel"}) {
+		t.Errorf("mismatched = %v", got)
+	}
+}
@@ -0,0 +1,78 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// =============================================================================
+// INSTANCE CATALOG
+// The on-demand hourly prices of every EC2 instance type in a region's active
+// snapshot, for browsing and comparing instance types outside of a plan.
+// =============================================================================
+
+// InstanceRateFilter selects the instance rates of a region
+type InstanceRateFilter struct {
+	Region          string
+	Alias           string // Pricing alias (default: "default")
+	Family          string // Instance family, e.g. m5; empty lists every family
+	OperatingSystem string // Price List operating system (default: Linux)
+}
+
+// InstanceRate is the on-demand hourly price of an instance type
+type InstanceRate struct {
+	InstanceType string          `json:"instance_type"`
+	Price        decimal.Decimal `json:"price"`
+	Currency     string          `json:"currency"`
+}
+
+// ListInstanceRates returns the shared-tenancy instance rates of a region's
+// active AWS snapshot, cheapest first. The snapshot is nil when none covers the region.
+func (s *Store) ListInstanceRates(ctx context.Context, filter InstanceRateFilter) (*PricingSnapshot, []InstanceRate, error) {
+	if filter.Alias == "" {
+		filter.Alias = "default"
+	}
+	if filter.OperatingSystem == "" {
+		filter.OperatingSystem = "Linux"
+	}
+	snapshot, err := s.GetActiveSnapshot(ctx, AWS, filter.Region, filter.Alias)
+	if err != nil || snapshot == nil {
+		return nil, nil, err
+	}
+
+	query := `
+		SELECT JSONExtractString(rk.attributes, 'instanceType') AS instance_type, pr.price, pr.currency
+		FROM pricing_rates pr FINAL
+		JOIN pricing_rate_keys rk FINAL ON pr.rate_key_id = rk.id
+		WHERE pr.snapshot_id = ? AND rk.service = 'AmazonEC2' AND rk.product_family = 'Compute Instance'
+		  AND pr.unit = 'hours' AND pr.tier_min IS NULL
+		  AND JSONExtractString(rk.attributes, 'operatingSystem') = ?
+		  AND JSONExtractString(rk.attributes, 'tenancy') = 'Shared'
+		  AND JSONExtractString(rk.attributes, 'preInstalledSw') = 'NA'
+		  AND JSONExtractString(rk.attributes, 'capacityStatus') = 'Used'
+		  AND (? = '' OR startsWith(instance_type, concat(?, '.')))
+		  AND pr._deleted = 0 AND rk._deleted = 0
+		ORDER BY pr.price, instance_type
+		LIMIT 1 BY instance_type
+	`
+	rows, err := s.conn.Query(ctx, query, snapshot.ID, filter.OperatingSystem, filter.Family, filter.Family)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list instance rates: %w", err)
+	}
+	defer rows.Close()
+
+	rates := make([]InstanceRate, 0)
+	for rows.Next() {
+		var r InstanceRate
+		if err := rows.Scan(&r.InstanceType, &r.Price, &r.Currency); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan instance rate: %w", err)
+		}
+		rates = append(rates, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to list instance rates: %w", err)
+	}
+	return snapshot, rates, nil
+}
@@ -0,0 +1,134 @@
+// Package clickhouse - AWS Cost and Usage Report
+// CUR line items are stored per org and summed per resource to reconcile
+// saved estimations with what was actually billed.
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/tenant"
+)
+
+// CURLineItem is one line item of an AWS Cost and Usage Report
+type CURLineItem struct {
+	LineItemID    string
+	UsageStart    time.Time
+	ResourceID    string // ARN or ID; empty for unattributed usage
+	ProductCode   string // AmazonEC2
+	UsageType     string // USE1-BoxUsage:t3.medium
+	LineItemType  string // Usage, Tax, Credit, ...
+	UsageAmount   float64
+	UnblendedCost decimal.Decimal
+	Tags          map[string]string // User tags without the user: prefix
+}
+
+// CURUsageLineItemTypes are the line item types counted as actual usage cost
+var CURUsageLineItemTypes = []string{"Usage", "DiscountedUsage", "SavingsPlanCoveredUsage"}
+
+// ResourceActual is the billed cost of one resource and service over a period
+type ResourceActual struct {
+	ResourceID  string
+	ProductCode string
+	Tags        map[string]string
+	Cost        decimal.Decimal
+	FirstUsage  time.Time
+	LastUsage   time.Time
+}
+
+// S3Credentials authenticate ClickHouse reads of a CUR bucket; empty reads unsigned
+type S3Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// InsertCURLineItems stores line items in the context's org
+func (s *Store) InsertCURLineItems(ctx context.Context, source string, items []CURLineItem) error {
+	batch, err := s.conn.PrepareBatch(ctx, `
+		INSERT INTO cur_line_items (
+			org_id, line_item_id, usage_start, resource_id, product_code, usage_type,
+			line_item_type, usage_amount, unblended_cost, tags, source
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare batch: %w", err)
+	}
+
+	org := tenant.OrgID(ctx)
+	for _, item := range items {
+		tags := item.Tags
+		if tags == nil {
+			tags = map[string]string{}
+		}
+		if err := batch.Append(
+			org, item.LineItemID, item.UsageStart, item.ResourceID, item.ProductCode, item.UsageType,
+			item.LineItemType, item.UsageAmount, item.UnblendedCost, tags, source,
+		); err != nil {
+			return fmt.Errorf("failed to append to batch: %w", err)
+		}
+	}
+	return batch.Send()
+}
+
+// ImportCURFromS3 loads CUR 2.0 Parquet files into the context's org, read by ClickHouse itself
+// The URL may use globs, e.g. https://bucket.s3.amazonaws.com/cur/data/BILLING_PERIOD=2024-05/*.parquet
+func (s *Store) ImportCURFromS3(ctx context.Context, url string, creds S3Credentials) error {
+	source := "s3(?, 'Parquet')"
+	args := []interface{}{tenant.OrgID(ctx), url, url}
+	switch {
+	case creds.SessionToken != "":
+		source = "s3(?, ?, ?, ?, 'Parquet')"
+		args = append(args, creds.AccessKeyID, creds.SecretAccessKey, creds.SessionToken)
+	case creds.AccessKeyID != "":
+		source = "s3(?, ?, ?, 'Parquet')"
+		args = append(args, creds.AccessKeyID, creds.SecretAccessKey)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO cur_line_items (
+			org_id, line_item_id, usage_start, resource_id, product_code, usage_type,
+			line_item_type, usage_amount, unblended_cost, tags, source
+		)
+		SELECT
+			?, identity_line_item_id, toDateTime(line_item_usage_start_date), line_item_resource_id,
+			line_item_product_code, line_item_usage_type, line_item_line_item_type,
+			line_item_usage_amount, toDecimal128(line_item_unblended_cost, 8),
+			mapApply((k, v) -> (replaceRegexpOne(k, '^user[:_]', ''), v),
+				mapFilter((k, v) -> match(k, '^user[:_]'), resource_tags)),
+			?
+		FROM %s
+	`, source)
+	if err := s.conn.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to import CUR from %s: %w", url, err)
+	}
+	return nil
+}
+
+// ResourceActuals sums the context org's usage cost per resource and product in [from, to)
+func (s *Store) ResourceActuals(ctx context.Context, from, to time.Time) ([]ResourceActual, error) {
+	rows, err := s.conn.Query(ctx, `
+		SELECT resource_id, product_code, any(tags), sum(unblended_cost), min(usage_start), max(usage_start)
+		FROM cur_line_items FINAL
+		WHERE org_id = ? AND usage_start >= ? AND usage_start < ? AND line_item_type IN (?)
+		GROUP BY resource_id, product_code
+		ORDER BY product_code, resource_id
+	`, tenant.OrgID(ctx), from, to, CURUsageLineItemTypes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query CUR actuals: %w", err)
+	}
+	defer rows.Close()
+
+	var actuals []ResourceActual
+	for rows.Next() {
+		var a ResourceActual
+		if err := rows.Scan(&a.ResourceID, &a.ProductCode, &a.Tags, &a.Cost, &a.FirstUsage, &a.LastUsage); err != nil {
+			return nil, fmt.Errorf("failed to scan CUR actual: %w", err)
+		}
+		actuals = append(actuals, a)
+	}
+	return actuals, rows.Err()
+}
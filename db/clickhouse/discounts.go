@@ -0,0 +1,142 @@
+// Package clickhouse - Discounts
+// Discounts are an org's negotiated pricing, a percentage off list prices
+// scoped by cloud, service, product family and region
+package clickhouse
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"terraform-cost/tenant"
+)
+
+// Discount is a negotiated percentage off list prices; empty scope fields match everything
+type Discount struct {
+	ID            uuid.UUID `json:"id"`
+	Name          string    `json:"name"`
+	Cloud         string    `json:"cloud,omitempty"`
+	Service       string    `json:"service,omitempty"`
+	ProductFamily string    `json:"product_family,omitempty"`
+	Region        string    `json:"region,omitempty"`
+	Percent       float64   `json:"percent"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Validate checks a discount
+func (d *Discount) Validate() error {
+	if strings.TrimSpace(d.Name) == "" {
+		return fmt.Errorf("discount name is required")
+	}
+	if d.Percent <= 0 || d.Percent > 100 {
+		return fmt.Errorf("discount %q: percent must be above 0 and at most 100", d.Name)
+	}
+	d.Cloud = strings.ToLower(d.Cloud)
+	return nil
+}
+
+// CreateDiscount stores a new discount
+func (s *Store) CreateDiscount(ctx context.Context, d *Discount) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	d.CreatedAt, d.UpdatedAt = now, now
+	return s.writeDiscount(ctx, d, false)
+}
+
+// UpdateDiscount replaces a stored discount's fields
+func (s *Store) UpdateDiscount(ctx context.Context, d *Discount) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	d.UpdatedAt = time.Now().UTC()
+	return s.writeDiscount(ctx, d, false)
+}
+
+// DeleteDiscount removes a discount
+func (s *Store) DeleteDiscount(ctx context.Context, d *Discount) error {
+	d.UpdatedAt = time.Now().UTC()
+	return s.writeDiscount(ctx, d, true)
+}
+
+// writeDiscount inserts a discount row in the context's org; the newest _version wins on merge
+func (s *Store) writeDiscount(ctx context.Context, d *Discount, deleted bool) error {
+	query := `
+		INSERT INTO discounts (
+			id, org_id, name, cloud, service, product_family, region, percent,
+			created_at, updated_at, _version, _deleted
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	if err := s.conn.Exec(ctx, query,
+		d.ID, tenant.OrgID(ctx), d.Name, d.Cloud, d.Service, d.ProductFamily, d.Region, d.Percent,
+		d.CreatedAt, d.UpdatedAt, uint64(d.UpdatedAt.UnixNano()), boolToUInt8(deleted),
+	); err != nil {
+		return fmt.Errorf("failed to write discount: %w", err)
+	}
+	return nil
+}
+
+const discountColumns = `id, name, cloud, service, product_family, region, percent, created_at, updated_at`
+
+// GetDiscount returns a discount of the context's org by ID, or nil
+func (s *Store) GetDiscount(ctx context.Context, id uuid.UUID) (*Discount, error) {
+	query := `SELECT ` + discountColumns + `
+		FROM discounts FINAL
+		WHERE org_id = ? AND id = ? AND _deleted = 0
+	`
+	d, err := scanDiscount(s.conn.QueryRow(ctx, query, tenant.OrgID(ctx), id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get discount: %w", err)
+	}
+	return d, nil
+}
+
+// ListDiscounts returns the context org's discounts ordered by name
+func (s *Store) ListDiscounts(ctx context.Context) ([]*Discount, error) {
+	query := `SELECT ` + discountColumns + `
+		FROM discounts FINAL
+		WHERE org_id = ? AND _deleted = 0
+		ORDER BY name, id
+	`
+	rows, err := s.conn.Query(ctx, query, tenant.OrgID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list discounts: %w", err)
+	}
+	defer rows.Close()
+
+	discounts := make([]*Discount, 0)
+	for rows.Next() {
+		d, err := scanDiscount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan discount: %w", err)
+		}
+		discounts = append(discounts, d)
+	}
+	return discounts, nil
+}
+
+func scanDiscount(row interface {
+	Scan(dest ...interface{}) error
+}) (*Discount, error) {
+	var d Discount
+	err := row.Scan(
+		&d.ID, &d.Name, &d.Cloud, &d.Service, &d.ProductFamily, &d.Region, &d.Percent,
+		&d.CreatedAt, &d.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
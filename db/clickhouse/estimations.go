@@ -0,0 +1,293 @@
+// Package clickhouse - Estimation history
+// Saved estimations let teams track cost per PR and project trends over time
+package clickhouse
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/tenant"
+)
+
+// EstimationRecord is a saved estimation
+type EstimationRecord struct {
+	ID             uuid.UUID       `ch:"id"`
+	Project        string          `ch:"project"`
+	Branch         string          `ch:"branch"`
+	CommitSHA      string          `ch:"commit_sha"`
+	PullRequest    string          `ch:"pull_request"`
+	Environment    string          `ch:"environment"`
+	Source         string          `ch:"source"`
+	MonthlyCostP50 decimal.Decimal `ch:"monthly_cost_p50"`
+	MonthlyCostP90 decimal.Decimal `ch:"monthly_cost_p90"`
+	CarbonKgCO2    float64         `ch:"carbon_kg_co2"`
+	Confidence     float64         `ch:"confidence"`
+	IsIncomplete   bool            `ch:"is_incomplete"`
+	ResourceCount  int             `ch:"resource_count"`
+	PolicyResult   string          `ch:"policy_result"`
+	ResultJSON     string          `ch:"result_json"`
+	CreatedAt      time.Time       `ch:"created_at"`
+}
+
+// EstimationFilter narrows ListEstimations; empty fields match everything
+type EstimationFilter struct {
+	Project     string
+	Branch      string
+	Environment string
+	Since       time.Time
+	Limit       int // Default DefaultEstimationLimit, at most MaxEstimationLimit
+	Offset      int // Newest estimations to skip, for pagination
+}
+
+// Page sizes of ListEstimations
+const (
+	DefaultEstimationLimit = 100
+	MaxEstimationLimit     = 1000
+)
+
+// EstimationLimit returns the page size ListEstimations uses for a requested limit
+func EstimationLimit(limit int) int {
+	if limit <= 0 || limit > MaxEstimationLimit {
+		return DefaultEstimationLimit
+	}
+	return limit
+}
+
+// TrendPoint aggregates a project's estimations over one period
+type TrendPoint struct {
+	Period    time.Time       `json:"period"`
+	Count     int             `json:"count"`
+	AvgP50    decimal.Decimal `json:"avg_monthly_cost_p50"`
+	MaxP50    decimal.Decimal `json:"max_monthly_cost_p50"`
+	LatestP50 decimal.Decimal `json:"latest_monthly_cost_p50"`
+	AvgP90    decimal.Decimal `json:"avg_monthly_cost_p90"`
+}
+
+// trendIntervals maps interval names to ClickHouse period functions
+var trendIntervals = map[string]string{
+	"day":   "toStartOfDay",
+	"week":  "toStartOfWeek",
+	"month": "toStartOfMonth",
+}
+
+// SaveEstimation stores an estimation in the history table, under the context's org
+func (s *Store) SaveEstimation(ctx context.Context, rec *EstimationRecord) error {
+	if rec.ID == uuid.Nil {
+		rec.ID = uuid.New()
+	}
+	if rec.CreatedAt.IsZero() {
+		rec.CreatedAt = time.Now().UTC()
+	}
+
+	query := `
+		INSERT INTO estimations (
+			id, org_id, project, branch, commit_sha, pull_request, environment, source,
+			monthly_cost_p50, monthly_cost_p90, carbon_kg_co2, confidence, is_incomplete,
+			resource_count, policy_result, result_json, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	if err := s.conn.Exec(ctx, query,
+		rec.ID, tenant.OrgID(ctx), rec.Project, rec.Branch, rec.CommitSHA, rec.PullRequest, rec.Environment, rec.Source,
+		rec.MonthlyCostP50, rec.MonthlyCostP90, rec.CarbonKgCO2, rec.Confidence, boolToUInt8(rec.IsIncomplete),
+		uint32(rec.ResourceCount), rec.PolicyResult, rec.ResultJSON, rec.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("failed to save estimation: %w", err)
+	}
+	return nil
+}
+
+// ListEstimations returns saved estimations, newest first, without the full result JSON
+func (s *Store) ListEstimations(ctx context.Context, filter EstimationFilter) ([]*EstimationRecord, error) {
+	where, args := estimationWhere(ctx, filter)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM estimations
+		WHERE %s
+		ORDER BY created_at DESC
+		LIMIT %d OFFSET %d
+	`, estimationColumns(false), where, EstimationLimit(filter.Limit), max(filter.Offset, 0))
+	return s.queryEstimations(ctx, query, false, args...)
+}
+
+// estimationWhere builds the WHERE clause for an estimation filter in the context's org
+func estimationWhere(ctx context.Context, filter EstimationFilter) (string, []interface{}) {
+	where := []string{"org_id = ?"}
+	args := []interface{}{tenant.OrgID(ctx)}
+	if filter.Project != "" {
+		where = append(where, "project = ?")
+		args = append(args, filter.Project)
+	}
+	if filter.Branch != "" {
+		where = append(where, "branch = ?")
+		args = append(args, filter.Branch)
+	}
+	if filter.Environment != "" {
+		where = append(where, "environment = ?")
+		args = append(args, filter.Environment)
+	}
+	if !filter.Since.IsZero() {
+		where = append(where, "created_at >= ?")
+		args = append(args, filter.Since)
+	}
+	return strings.Join(where, " AND "), args
+}
+
+// LatestEstimations returns the newest estimation per project and environment,
+// including the result JSON; it is the committed spend that budgets measure
+func (s *Store) LatestEstimations(ctx context.Context, filter EstimationFilter) ([]*EstimationRecord, error) {
+	where, args := estimationWhere(ctx, filter)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM estimations
+		WHERE %s
+		ORDER BY created_at DESC
+		LIMIT 1 BY project, environment
+	`, estimationColumns(true), where)
+	return s.queryEstimations(ctx, query, true, args...)
+}
+
+// ListEstimationResults returns saved estimations, newest first, with their result JSON
+func (s *Store) ListEstimationResults(ctx context.Context, filter EstimationFilter) ([]*EstimationRecord, error) {
+	where, args := estimationWhere(ctx, filter)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM estimations
+		WHERE %s
+		ORDER BY created_at DESC
+		LIMIT %d OFFSET %d
+	`, estimationColumns(true), where, EstimationLimit(filter.Limit), max(filter.Offset, 0))
+	return s.queryEstimations(ctx, query, true, args...)
+}
+
+// estimationColumns are the columns scanEstimation reads, with or without the result JSON
+func estimationColumns(withResult bool) string {
+	columns := `id, project, branch, commit_sha, pull_request, environment, source,
+			monthly_cost_p50, monthly_cost_p90, carbon_kg_co2, confidence, is_incomplete,
+			resource_count, policy_result`
+	if withResult {
+		columns += ", result_json"
+	}
+	return columns + ", created_at"
+}
+
+// queryEstimations runs a query selecting estimationColumns
+func (s *Store) queryEstimations(ctx context.Context, query string, withResult bool, args ...interface{}) ([]*EstimationRecord, error) {
+	rows, err := s.conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list estimations: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*EstimationRecord
+	for rows.Next() {
+		rec, err := scanEstimation(rows.Scan, withResult)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan estimation: %w", err)
+		}
+		records = append(records, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list estimations: %w", err)
+	}
+	return records, nil
+}
+
+// scanEstimation scans a row of estimationColumns
+func scanEstimation(scan func(dest ...interface{}) error, withResult bool) (*EstimationRecord, error) {
+	var rec EstimationRecord
+	var incomplete uint8
+	var resourceCount uint32
+	dest := []interface{}{
+		&rec.ID, &rec.Project, &rec.Branch, &rec.CommitSHA, &rec.PullRequest, &rec.Environment, &rec.Source,
+		&rec.MonthlyCostP50, &rec.MonthlyCostP90, &rec.CarbonKgCO2, &rec.Confidence, &incomplete,
+		&resourceCount, &rec.PolicyResult,
+	}
+	if withResult {
+		dest = append(dest, &rec.ResultJSON)
+	}
+	if err := scan(append(dest, &rec.CreatedAt)...); err != nil {
+		return nil, err
+	}
+	rec.IsIncomplete = incomplete == 1
+	rec.ResourceCount = int(resourceCount)
+	return &rec, nil
+}
+
+// GetEstimation returns a saved estimation of the context's org with its result JSON, or nil
+func (s *Store) GetEstimation(ctx context.Context, id uuid.UUID) (*EstimationRecord, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM estimations
+		WHERE org_id = ? AND id = ?
+		LIMIT 1
+	`, estimationColumns(true))
+	rec, err := scanEstimation(s.conn.QueryRow(ctx, query, tenant.OrgID(ctx), id).Scan, true)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get estimation: %w", err)
+	}
+	return rec, nil
+}
+
+// LatestEstimation returns the most recent saved estimation for a project/branch, or nil
+func (s *Store) LatestEstimation(ctx context.Context, project, branch string) (*EstimationRecord, error) {
+	records, err := s.ListEstimations(ctx, EstimationFilter{Project: project, Branch: branch, Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return records[0], nil
+}
+
+// GetEstimationTrend aggregates a project's estimations per day, week or month
+func (s *Store) GetEstimationTrend(ctx context.Context, project, interval string, since time.Time) ([]TrendPoint, error) {
+	periodFunc, ok := trendIntervals[interval]
+	if !ok {
+		return nil, fmt.Errorf("unsupported trend interval %q (day, week, month)", interval)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			%s(created_at) AS period,
+			count(),
+			sum(monthly_cost_p50) / count(),
+			max(monthly_cost_p50),
+			argMax(monthly_cost_p50, created_at),
+			sum(monthly_cost_p90) / count()
+		FROM estimations
+		WHERE org_id = ? AND project = ? AND created_at >= ?
+		GROUP BY period
+		ORDER BY period
+	`, periodFunc)
+
+	rows, err := s.conn.Query(ctx, query, tenant.OrgID(ctx), project, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query estimation trend: %w", err)
+	}
+	defer rows.Close()
+
+	points := make([]TrendPoint, 0)
+	for rows.Next() {
+		var p TrendPoint
+		var count uint64
+		if err := rows.Scan(&p.Period, &count, &p.AvgP50, &p.MaxP50, &p.LatestP50, &p.AvgP90); err != nil {
+			return nil, fmt.Errorf("failed to scan trend point: %w", err)
+		}
+		p.Count = int(count)
+		points = append(points, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query estimation trend: %w", err)
+	}
+	return points, nil
+}
@@ -0,0 +1,111 @@
+// Package clickhouse - Pricing export
+// Streams the active pricing catalog out of ClickHouse, e.g. to build an
+// offline pricing bundle (see db/embedded).
+package clickhouse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/tenant"
+)
+
+// ExportActiveRates calls fn with the lowest-tier rate of every key in the active
+// snapshots visible to the context's org, its own snapshots first
+func (s *Store) ExportActiveRates(ctx context.Context, cloud CloudProvider, fn func(RateLookup, *ResolvedRate) error) error {
+	query := `
+		SELECT ps.cloud, ps.region, ps.provider_alias, rk.service, rk.product_family, rk.attributes, pr.unit,
+			   pr.price, pr.currency, pr.confidence, pr.tier_min, pr.tier_max, pr.snapshot_id, ps.source
+		FROM pricing_rates pr FINAL
+		JOIN pricing_snapshots ps FINAL ON pr.snapshot_id = ps.id
+		JOIN pricing_rate_keys rk FINAL ON pr.rate_key_id = rk.id
+		WHERE ps.cloud = ? AND ps.is_active = 1 AND ps.org_id IN ('', ?)
+		  AND ps._deleted = 0 AND pr._deleted = 0 AND rk._deleted = 0
+		ORDER BY ps.org_id DESC, pr.tier_min NULLS FIRST
+		LIMIT 1 BY ps.region, ps.provider_alias, rk.service, rk.product_family, rk.attributes_hash, pr.unit
+	`
+	rows, err := s.conn.Query(ctx, query, string(cloud), tenant.OrgID(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to export rates: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			lookup    RateLookup
+			cloudName string
+			attrsJSON string
+			rate      ResolvedRate
+		)
+		if err := rows.Scan(&cloudName, &lookup.Region, &lookup.Alias, &lookup.Service, &lookup.ProductFamily, &attrsJSON, &lookup.Unit,
+			&rate.Price, &rate.Currency, &rate.Confidence, &rate.TierMin, &rate.TierMax, &rate.SnapshotID, &rate.Source); err != nil {
+			return fmt.Errorf("failed to scan rate: %w", err)
+		}
+		lookup.Cloud = CloudProvider(cloudName)
+		if err := json.Unmarshal([]byte(attrsJSON), &lookup.Attributes); err != nil {
+			return fmt.Errorf("failed to unmarshal attributes: %w", err)
+		}
+		if err := fn(lookup, &rate); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// SpotRateKey identifies a spot rate summary
+type SpotRateKey struct {
+	Cloud              CloudProvider
+	Region             string
+	InstanceType       string
+	ProductDescription string
+}
+
+// ExportSpotRates calls fn with the spot price summary of every instance type seen within lookback
+func (s *Store) ExportSpotRates(ctx context.Context, cloud CloudProvider, lookback time.Duration, fn func(SpotRateKey, *SpotRate) error) error {
+	query := `
+		SELECT
+			region,
+			instance_type,
+			product_description,
+			toFloat64(avg(price)),
+			quantile(0.5)(toFloat64(price)),
+			quantile(0.9)(toFloat64(price)),
+			toFloat64(max(price)),
+			any(currency),
+			count(),
+			min(observed_at),
+			max(observed_at)
+		FROM spot_price_history FINAL
+		WHERE cloud = ? AND observed_at >= ? AND _deleted = 0
+		GROUP BY region, instance_type, product_description
+	`
+	rows, err := s.conn.Query(ctx, query, string(cloud), time.Now().Add(-lookback))
+	if err != nil {
+		return fmt.Errorf("failed to export spot rates: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		key := SpotRateKey{Cloud: cloud}
+		var avg, p50, p90, maxPrice float64
+		var samples uint64
+		var rate SpotRate
+		if err := rows.Scan(&key.Region, &key.InstanceType, &key.ProductDescription,
+			&avg, &p50, &p90, &maxPrice, &rate.Currency, &samples, &rate.From, &rate.To); err != nil {
+			return fmt.Errorf("failed to scan spot rate: %w", err)
+		}
+		rate.Average = decimal.NewFromFloat(avg)
+		rate.P50 = decimal.NewFromFloat(p50)
+		rate.P90 = decimal.NewFromFloat(p90)
+		rate.Max = decimal.NewFromFloat(maxPrice)
+		rate.Samples = int(samples)
+		if err := fn(key, &rate); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
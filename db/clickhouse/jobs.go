@@ -0,0 +1,87 @@
+// Package clickhouse - Estimation jobs
+// Async estimate jobs and their results, kept until the job's retention expires
+package clickhouse
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"terraform-cost/tenant"
+)
+
+// JobStatus is the lifecycle state of an async job
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+)
+
+// Job is an async estimation and, once finished, its result or error
+type Job struct {
+	ID          uuid.UUID  `json:"id"`
+	Status      JobStatus  `json:"status"`
+	Project     string     `json:"project,omitempty"`
+	Environment string     `json:"environment,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	ErrorCode   string     `json:"error_code,omitempty"` // Code of a failed job's error (pkg/errors)
+	ResultJSON  string     `json:"-"`                    // EstimateResponse JSON of a succeeded job
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+}
+
+// SaveJob writes the job's current state in the context's org; the latest write wins on merge
+func (s *Store) SaveJob(ctx context.Context, j *Job) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	if j.CreatedAt.IsZero() {
+		j.CreatedAt = time.Now().UTC()
+	}
+
+	query := `
+		INSERT INTO estimation_jobs (
+			id, org_id, status, project, environment, error, error_code, result_json,
+			created_at, started_at, finished_at, expires_at, _version
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	if err := s.conn.Exec(ctx, query,
+		j.ID, tenant.OrgID(ctx), string(j.Status), j.Project, j.Environment, j.Error, j.ErrorCode, j.ResultJSON,
+		j.CreatedAt, j.StartedAt, j.FinishedAt, j.ExpiresAt, uint64(time.Now().UnixNano()),
+	); err != nil {
+		return fmt.Errorf("failed to save job: %w", err)
+	}
+	return nil
+}
+
+// GetJob returns a job of the context's org that has not expired, or nil
+func (s *Store) GetJob(ctx context.Context, id uuid.UUID) (*Job, error) {
+	query := `
+		SELECT id, status, project, environment, error, error_code, result_json,
+			created_at, started_at, finished_at, expires_at
+		FROM estimation_jobs FINAL
+		WHERE org_id = ? AND id = ? AND expires_at > now64(3)
+	`
+	var j Job
+	var status string
+	err := s.conn.QueryRow(ctx, query, tenant.OrgID(ctx), id).Scan(
+		&j.ID, &status, &j.Project, &j.Environment, &j.Error, &j.ErrorCode, &j.ResultJSON,
+		&j.CreatedAt, &j.StartedAt, &j.FinishedAt, &j.ExpiresAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	j.Status = JobStatus(status)
+	return &j, nil
+}
@@ -0,0 +1,200 @@
+package clickhouse
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// =============================================================================
+// SCHEMA MIGRATIONS
+// The NNN_name.sql files in this package are versioned migrations, embedded in
+// the binary. Migrate applies those newer than the schema_version table in
+// order, one statement at a time. Migrations are written to be re-runnable
+// (IF NOT EXISTS, replacing tables), so databases initialized from the SQL
+// files by hand are adopted by simply migrating them.
+// =============================================================================
+
+//go:embed *.sql
+var migrationFiles embed.FS
+
+// Migration is one versioned schema change
+type Migration struct {
+	Version  int    `json:"version"`
+	Name     string `json:"name"`
+	Checksum string `json:"checksum"` // SHA-256 of the file
+	SQL      string `json:"-"`
+}
+
+// MigrationStatus is a migration and whether the database has it
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time `json:"applied_at,omitempty"` // nil when pending
+	Modified  bool       `json:"modified,omitempty"`   // Applied from a file that has since changed
+}
+
+const createSchemaVersion = `
+	CREATE TABLE IF NOT EXISTS schema_version (
+		version    UInt32,
+		name       String,
+		checksum   String,
+		applied_at DateTime64(3) DEFAULT now64(3)
+	) ENGINE = ReplacingMergeTree(applied_at)
+	ORDER BY version
+`
+
+// Migrations returns the embedded migrations in version order
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, ".")
+	if err != nil {
+		return nil, err
+	}
+	var migrations []Migration
+	seen := make(map[int]string)
+	for _, entry := range entries {
+		prefix, name, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("migration %s: file name must be NNN_name.sql", entry.Name())
+		}
+		if other, dup := seen[version]; dup {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", other, entry.Name(), version)
+		}
+		seen[version] = entry.Name()
+
+		data, err := migrationFiles.ReadFile(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(data)
+		migrations = append(migrations, Migration{
+			Version:  version,
+			Name:     name,
+			Checksum: hex.EncodeToString(sum[:]),
+			SQL:      string(data),
+		})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// MigrationStatus lists the embedded migrations and when each was applied
+func (s *Store) MigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.conn.Exec(ctx, createSchemaVersion); err != nil {
+		return nil, fmt.Errorf("failed to create schema_version table: %w", err)
+	}
+
+	rows, err := s.conn.Query(ctx, `SELECT version, checksum, applied_at FROM schema_version FINAL`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_version: %w", err)
+	}
+	defer rows.Close()
+	type applied struct {
+		checksum string
+		at       time.Time
+	}
+	done := make(map[int]applied)
+	for rows.Next() {
+		var version uint32
+		var a applied
+		if err := rows.Scan(&version, &a.checksum, &a.at); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_version: %w", err)
+		}
+		done[int(version)] = a
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read schema_version: %w", err)
+	}
+
+	statuses := make([]MigrationStatus, len(migrations))
+	for i, m := range migrations {
+		statuses[i] = MigrationStatus{Migration: m}
+		if a, ok := done[m.Version]; ok {
+			at := a.at
+			statuses[i].AppliedAt = &at
+			statuses[i].Modified = a.checksum != m.Checksum
+		}
+	}
+	return statuses, nil
+}
+
+// Migrate applies pending migrations in order and returns them
+// A failed migration stops the run and is retried in full by the next one.
+func (s *Store) Migrate(ctx context.Context) ([]Migration, error) {
+	statuses, err := s.MigrationStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var applied []Migration
+	for _, status := range statuses {
+		if status.AppliedAt != nil {
+			continue
+		}
+		m := status.Migration
+		for i, stmt := range splitStatements(m.SQL) {
+			if err := s.conn.Exec(ctx, stmt); err != nil {
+				return applied, fmt.Errorf("migration %03d_%s statement %d failed: %w", m.Version, m.Name, i+1, err)
+			}
+		}
+		if err := s.conn.Exec(ctx, `INSERT INTO schema_version (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)`,
+			uint32(m.Version), m.Name, m.Checksum, time.Now().UTC()); err != nil {
+			return applied, fmt.Errorf("failed to record migration %03d_%s: %w", m.Version, m.Name, err)
+		}
+		applied = append(applied, m)
+	}
+	return applied, nil
+}
+
+// splitStatements splits a SQL script on semicolons outside of comments and quotes,
+// dropping comments and empty statements
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	flush := func() {
+		if stmt := strings.TrimSpace(current.String()); stmt != "" {
+			statements = append(statements, stmt)
+		}
+		current.Reset()
+	}
+
+	var quote byte // Inside a '...' or "..." or `...` literal
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case quote != 0:
+			current.WriteByte(c)
+			if c == '\\' && i+1 < len(script) {
+				i++
+				current.WriteByte(script[i])
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			current.WriteByte(c)
+		case c == '-' && i+1 < len(script) && script[i+1] == '-':
+			// Line comment: skip to the end of the line
+			for i < len(script) && script[i] != '\n' {
+				i++
+			}
+			current.WriteByte('\n')
+		case c == ';':
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+	return statements
+}
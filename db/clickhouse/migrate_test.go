@@ -0,0 +1,49 @@
+package clickhouse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMigrations(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) < 5 || migrations[0].Version != 1 || migrations[0].Name != "pricing_schema" {
+		t.Fatalf("unexpected migrations: %+v", migrations)
+	}
+	for i, m := range migrations {
+		if i > 0 && m.Version <= migrations[i-1].Version {
+			t.Errorf("migration %d out of order", m.Version)
+		}
+		for _, stmt := range splitStatements(m.SQL) {
+			if strings.HasPrefix(stmt, "--") || strings.HasSuffix(stmt, ";") {
+				t.Errorf("migration %d: unsplit statement %q", m.Version, stmt)
+			}
+		}
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `
+-- Header; with a semicolon
+CREATE TABLE t (
+    note String DEFAULT 'a;b', -- trailing; comment
+    x UInt8
+) ENGINE = MergeTree ORDER BY x;
+
+INSERT INTO t VALUES ('it''s', 1), ('c\'d;', 2);
+;
+`
+	got := splitStatements(script)
+	if len(got) != 2 {
+		t.Fatalf("got %d statements: %q", len(got), got)
+	}
+	if !strings.HasPrefix(got[0], "CREATE TABLE t") || !strings.Contains(got[0], "'a;b'") || strings.Contains(got[0], "comment") {
+		t.Errorf("statement 1 = %q", got[0])
+	}
+	if got[1] != `INSERT INTO t VALUES ('it''s', 1), ('c\'d;', 2)` {
+		t.Errorf("statement 2 = %q", got[1])
+	}
+}
@@ -0,0 +1,147 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// =============================================================================
+// SNAPSHOT RETENTION
+// Every pricing update adds a snapshot and a full copy of its rates. Retention
+// soft-deletes old inactive snapshots and their rates. It is applied to each
+// org/cloud/region/alias separately, and active snapshots are always kept.
+// =============================================================================
+
+// RetentionPolicy selects the snapshots to keep per org, cloud, region and alias
+type RetentionPolicy struct {
+	KeepLast int // Newest snapshots kept, active or not
+	KeepDays int // Snapshots created within this many days are kept
+}
+
+// Validate rejects policies that would keep only active snapshots
+func (p RetentionPolicy) Validate() error {
+	if p.KeepLast < 0 || p.KeepDays < 0 {
+		return fmt.Errorf("retention: keep-last and keep-days must not be negative")
+	}
+	if p.KeepLast == 0 && p.KeepDays == 0 {
+		return fmt.Errorf("retention: set keep-last and/or keep-days")
+	}
+	return nil
+}
+
+// PruneResult reports the snapshots a prune removed, or would remove on a dry run
+type PruneResult struct {
+	DryRun    bool               `json:"dry_run"`
+	Snapshots []*PricingSnapshot `json:"snapshots"`
+	Rates     int                `json:"rates"` // Rate rows of the pruned snapshots
+	Kept      int                `json:"kept"`  // Snapshots retained
+}
+
+// PruneSnapshots soft-deletes the snapshots of every org outside the policy, with
+// their rates. A dry run only reports what would be removed.
+func (s *Store) PruneSnapshots(ctx context.Context, policy RetentionPolicy, dryRun bool) (*PruneResult, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + snapshotColumns + `
+		FROM pricing_snapshots FINAL
+		WHERE _deleted = 0
+	`
+	rows, err := s.conn.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	defer rows.Close()
+	var snapshots []*PricingSnapshot
+	for rows.Next() {
+		snapshot, err := scanSnapshot(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan snapshot: %w", err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	prunable := selectPrunable(snapshots, policy, time.Now())
+	result := &PruneResult{DryRun: dryRun, Snapshots: prunable, Kept: len(snapshots) - len(prunable)}
+	if len(prunable) == 0 {
+		return result, nil
+	}
+	ids := make([]uuid.UUID, len(prunable))
+	for i, snapshot := range prunable {
+		ids[i] = snapshot.ID
+	}
+
+	var rates uint64
+	countQuery := `SELECT count() FROM pricing_rates FINAL WHERE snapshot_id IN (?) AND _deleted = 0`
+	if err := s.conn.QueryRow(ctx, countQuery, ids).Scan(&rates); err != nil {
+		return nil, fmt.Errorf("failed to count snapshot rates: %w", err)
+	}
+	result.Rates = int(rates)
+	if dryRun {
+		return result, nil
+	}
+
+	// Rates first: a failure part way leaves snapshots that a later prune retries.
+	// Both deletes skip snapshots activated since they were listed.
+	deleteRates := `
+		INSERT INTO pricing_rates
+		SELECT id, snapshot_id, rate_key_id, unit, price, currency, confidence,
+			   tier_min, tier_max, effective_date, created_at,
+			   cloud, region, service, product_family,
+			   _version + 1 as _version, 1 as _deleted
+		FROM pricing_rates FINAL
+		WHERE snapshot_id IN (
+			SELECT id FROM pricing_snapshots FINAL
+			WHERE id IN (?) AND is_active = 0 AND _deleted = 0
+		) AND _deleted = 0
+	`
+	if err := s.conn.Exec(ctx, deleteRates, ids); err != nil {
+		return nil, fmt.Errorf("failed to delete snapshot rates: %w", err)
+	}
+	deleteSnapshots := `
+		INSERT INTO pricing_snapshots
+		SELECT id, cloud, region, provider_alias, source, fetched_at,
+			   valid_from, valid_to, hash, version, is_active, created_at,
+			   _version + 1 as _version, 1 as _deleted, org_id
+		FROM pricing_snapshots FINAL
+		WHERE id IN (?) AND is_active = 0 AND _deleted = 0
+	`
+	if err := s.conn.Exec(ctx, deleteSnapshots, ids); err != nil {
+		return nil, fmt.Errorf("failed to delete snapshots: %w", err)
+	}
+	s.forgetActiveSnapshots()
+	return result, nil
+}
+
+// selectPrunable returns the inactive snapshots outside the policy, oldest first
+func selectPrunable(snapshots []*PricingSnapshot, policy RetentionPolicy, now time.Time) []*PricingSnapshot {
+	groups := make(map[string][]*PricingSnapshot)
+	for _, snapshot := range snapshots {
+		key := fmt.Sprintf("%s|%s|%s|%s", snapshot.OrgID, snapshot.Cloud, snapshot.Region, snapshot.ProviderAlias)
+		groups[key] = append(groups[key], snapshot)
+	}
+
+	cutoff := now.AddDate(0, 0, -policy.KeepDays)
+	var prunable []*PricingSnapshot
+	for _, group := range groups {
+		sort.Slice(group, func(i, j int) bool { return group[i].CreatedAt.After(group[j].CreatedAt) })
+		for i, snapshot := range group {
+			switch {
+			case snapshot.IsActive, i < policy.KeepLast:
+			case policy.KeepDays > 0 && snapshot.CreatedAt.After(cutoff):
+			default:
+				prunable = append(prunable, snapshot)
+			}
+		}
+	}
+	sort.Slice(prunable, func(i, j int) bool { return prunable[i].CreatedAt.Before(prunable[j].CreatedAt) })
+	return prunable
+}
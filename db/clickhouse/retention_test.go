@@ -0,0 +1,46 @@
+package clickhouse
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSelectPrunable(t *testing.T) {
+	now := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+	snapshot := func(region string, daysAgo int, active bool) *PricingSnapshot {
+		return &PricingSnapshot{
+			ID: uuid.New(), Cloud: AWS, Region: region, IsActive: active,
+			CreatedAt: now.AddDate(0, 0, -daysAgo),
+		}
+	}
+	east := []*PricingSnapshot{
+		snapshot("us-east-1", 1, false),
+		snapshot("us-east-1", 10, true), // Rolled back to an older snapshot
+		snapshot("us-east-1", 20, false),
+		snapshot("us-east-1", 40, false),
+		snapshot("us-east-1", 50, false),
+	}
+	west := []*PricingSnapshot{snapshot("us-west-2", 90, true), snapshot("us-west-2", 100, false), snapshot("us-west-2", 110, false)}
+	all := append(append([]*PricingSnapshot{}, east...), west...)
+
+	prunable := selectPrunable(all, RetentionPolicy{KeepLast: 2, KeepDays: 30}, now)
+	// Kept: the newest two, anything in the last 30 days, and active snapshots
+	want := []*PricingSnapshot{west[2], east[4], east[3]}
+	if len(prunable) != len(want) {
+		t.Fatalf("pruned %d snapshots, want %d", len(prunable), len(want))
+	}
+	for i := range want {
+		if prunable[i] != want[i] {
+			t.Errorf("prunable[%d] created %s, want %s", i, prunable[i].CreatedAt, want[i].CreatedAt)
+		}
+	}
+
+	if got := selectPrunable(all, RetentionPolicy{KeepLast: 1}, now); len(got) != 5 {
+		t.Errorf("keep-last 1 pruned %d snapshots, want 5", len(got))
+	}
+	if err := (RetentionPolicy{}).Validate(); err == nil {
+		t.Error("empty policy accepted")
+	}
+}
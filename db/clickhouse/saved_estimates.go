@@ -0,0 +1,81 @@
+// Package clickhouse - Saved estimates
+// Estimate responses persisted by ID until their retention expires or they are deleted
+package clickhouse
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"terraform-cost/tenant"
+)
+
+// SavedEstimate is a persisted estimate response
+type SavedEstimate struct {
+	ID           uuid.UUID `json:"id"`
+	Project      string    `json:"project,omitempty"`
+	Branch       string    `json:"branch,omitempty"`
+	CommitSHA    string    `json:"commit_sha,omitempty"`
+	PullRequest  string    `json:"pull_request,omitempty"`
+	Environment  string    `json:"environment,omitempty"`
+	ResponseJSON string    `json:"-"` // EstimateResponse JSON
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// SaveEstimate persists an estimate response in the context's org
+func (s *Store) SaveEstimate(ctx context.Context, e *SavedEstimate) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now().UTC()
+	}
+	return s.writeEstimate(ctx, e, false)
+}
+
+// DeleteEstimate deletes a saved estimate before it expires
+func (s *Store) DeleteEstimate(ctx context.Context, e *SavedEstimate) error {
+	return s.writeEstimate(ctx, e, true)
+}
+
+// writeEstimate inserts a saved estimate row in the context's org; the newest _version wins on merge
+func (s *Store) writeEstimate(ctx context.Context, e *SavedEstimate, deleted bool) error {
+	query := `
+		INSERT INTO saved_estimates (
+			id, org_id, project, branch, commit_sha, pull_request, environment, response_json,
+			created_at, expires_at, _version, _deleted
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	if err := s.conn.Exec(ctx, query,
+		e.ID, tenant.OrgID(ctx), e.Project, e.Branch, e.CommitSHA, e.PullRequest, e.Environment, e.ResponseJSON,
+		e.CreatedAt, e.ExpiresAt, uint64(time.Now().UnixNano()), boolToUInt8(deleted),
+	); err != nil {
+		return fmt.Errorf("failed to write saved estimate: %w", err)
+	}
+	return nil
+}
+
+// GetEstimate returns a saved estimate of the context's org that has not expired or been deleted, or nil
+func (s *Store) GetEstimate(ctx context.Context, id uuid.UUID) (*SavedEstimate, error) {
+	query := `
+		SELECT id, project, branch, commit_sha, pull_request, environment, response_json, created_at, expires_at
+		FROM saved_estimates FINAL
+		WHERE org_id = ? AND id = ? AND _deleted = 0 AND expires_at > now64(3)
+	`
+	var e SavedEstimate
+	err := s.conn.QueryRow(ctx, query, tenant.OrgID(ctx), id).Scan(
+		&e.ID, &e.Project, &e.Branch, &e.CommitSHA, &e.PullRequest, &e.Environment, &e.ResponseJSON,
+		&e.CreatedAt, &e.ExpiresAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved estimate: %w", err)
+	}
+	return &e, nil
+}
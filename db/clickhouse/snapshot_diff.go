@@ -0,0 +1,202 @@
+package clickhouse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// =============================================================================
+// SNAPSHOT DIFF
+// Compares the rates of two pricing snapshots of the same cloud and region.
+// Rates are matched by rate key, unit and tier start.
+// =============================================================================
+
+// Rate change kinds
+const (
+	RateAdded   = "added"
+	RateRemoved = "removed"
+	RateChanged = "changed"
+)
+
+// RateChange is one rate that differs between two snapshots
+type RateChange struct {
+	Kind          string            `json:"kind"`
+	RateKeyID     uuid.UUID         `json:"rate_key_id"`
+	Service       string            `json:"service"`
+	ProductFamily string            `json:"product_family"`
+	Region        string            `json:"region"`
+	Attributes    map[string]string `json:"attributes,omitempty"`
+	Unit          string            `json:"unit"`
+	TierMin       string            `json:"tier_min,omitempty"`
+	OldPrice      *decimal.Decimal  `json:"old_price,omitempty"` // nil when added
+	NewPrice      *decimal.Decimal  `json:"new_price,omitempty"` // nil when removed
+	ChangePercent float64           `json:"change_percent"`      // Changed rates with a non-zero old price
+}
+
+// ServiceDiff summarizes the rate changes of one service
+type ServiceDiff struct {
+	Service            string  `json:"service"`
+	Added              int     `json:"added"`
+	Removed            int     `json:"removed"`
+	Increased          int     `json:"increased"`
+	Decreased          int     `json:"decreased"`
+	AvgChangePercent   float64 `json:"avg_change_percent"`   // Mean over changed rates
+	MaxIncreasePercent float64 `json:"max_increase_percent"` // Largest single increase
+}
+
+// SnapshotDiff is the difference between two pricing snapshots
+type SnapshotDiff struct {
+	From     *PricingSnapshot `json:"from"`
+	To       *PricingSnapshot `json:"to"`
+	Added    []RateChange     `json:"added"`
+	Removed  []RateChange     `json:"removed"`
+	Changed  []RateChange     `json:"changed"`
+	Services []ServiceDiff    `json:"services"`
+}
+
+// Increases returns the changed rates whose price rose by more than thresholdPct percent
+func (d *SnapshotDiff) Increases(thresholdPct float64) []RateChange {
+	var increases []RateChange
+	for _, c := range d.Changed {
+		if c.ChangePercent > thresholdPct {
+			increases = append(increases, c)
+		}
+	}
+	sort.SliceStable(increases, func(i, j int) bool { return increases[i].ChangePercent > increases[j].ChangePercent })
+	return increases
+}
+
+// DiffSnapshots computes the added, removed and changed rates from one snapshot to another
+// Both snapshots must be visible to the context's org and cover the same cloud and region.
+func (s *Store) DiffSnapshots(ctx context.Context, fromID, toID uuid.UUID) (*SnapshotDiff, error) {
+	from, err := s.GetSnapshot(ctx, fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.GetSnapshot(ctx, toID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case from == nil:
+		return nil, fmt.Errorf("snapshot not found: %s", fromID)
+	case to == nil:
+		return nil, fmt.Errorf("snapshot not found: %s", toID)
+	case from.Cloud != to.Cloud || from.Region != to.Region:
+		return nil, fmt.Errorf("snapshots cover different regions (%s/%s and %s/%s)", from.Cloud, from.Region, to.Cloud, to.Region)
+	}
+
+	query := `
+		SELECT d.rate_key_id, d.service, d.product_family, d.region, d.unit, d.tier,
+			   d.in_from, d.in_to, d.from_price, d.to_price, k.attributes
+		FROM (
+			SELECT rate_key_id, unit, ifNull(toString(tier_min), '') AS tier,
+				   any(service) AS service, any(product_family) AS product_family, any(region) AS region,
+				   countIf(snapshot_id = ?) AS in_from, countIf(snapshot_id = ?) AS in_to,
+				   anyIf(price, snapshot_id = ?) AS from_price, anyIf(price, snapshot_id = ?) AS to_price
+			FROM pricing_rates FINAL
+			WHERE snapshot_id IN (?, ?) AND _deleted = 0
+			GROUP BY rate_key_id, unit, tier
+			HAVING in_from = 0 OR in_to = 0 OR from_price != to_price
+		) AS d
+		LEFT JOIN (
+			SELECT id, any(attributes) AS attributes
+			FROM pricing_rate_keys FINAL
+			WHERE _deleted = 0
+			GROUP BY id
+		) AS k ON k.id = d.rate_key_id
+		ORDER BY d.service, d.product_family, d.rate_key_id, d.unit, d.tier
+	`
+	rows, err := s.conn.Query(ctx, query, fromID, toID, fromID, toID, fromID, toID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []RateChange
+	for rows.Next() {
+		var c RateChange
+		var inFrom, inTo uint64
+		var fromPrice, toPrice decimal.Decimal
+		var attrs string
+		if err := rows.Scan(&c.RateKeyID, &c.Service, &c.ProductFamily, &c.Region, &c.Unit, &c.TierMin,
+			&inFrom, &inTo, &fromPrice, &toPrice, &attrs); err != nil {
+			return nil, fmt.Errorf("failed to scan rate change: %w", err)
+		}
+		if attrs != "" {
+			if err := json.Unmarshal([]byte(attrs), &c.Attributes); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal attributes: %w", err)
+			}
+		}
+		if inFrom > 0 {
+			c.OldPrice = &fromPrice
+		}
+		if inTo > 0 {
+			c.NewPrice = &toPrice
+		}
+		changes = append(changes, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to diff snapshots: %w", err)
+	}
+
+	diff := buildSnapshotDiff(changes)
+	diff.From, diff.To = from, to
+	return diff, nil
+}
+
+// buildSnapshotDiff classifies rate changes and summarizes them per service
+func buildSnapshotDiff(changes []RateChange) *SnapshotDiff {
+	diff := &SnapshotDiff{}
+	services := make(map[string]*ServiceDiff)
+	sums := make(map[string]float64)
+	for _, c := range changes {
+		svc, ok := services[c.Service]
+		if !ok {
+			svc = &ServiceDiff{Service: c.Service}
+			services[c.Service] = svc
+		}
+
+		switch {
+		case c.OldPrice == nil:
+			c.Kind = RateAdded
+			svc.Added++
+			diff.Added = append(diff.Added, c)
+		case c.NewPrice == nil:
+			c.Kind = RateRemoved
+			svc.Removed++
+			diff.Removed = append(diff.Removed, c)
+		default:
+			c.Kind = RateChanged
+			if !c.OldPrice.IsZero() {
+				c.ChangePercent = c.NewPrice.Sub(*c.OldPrice).Div(*c.OldPrice).Mul(decimal.NewFromInt(100)).InexactFloat64()
+			} else if c.NewPrice.IsPositive() {
+				c.ChangePercent = 100
+			}
+			if c.NewPrice.GreaterThan(*c.OldPrice) {
+				svc.Increased++
+				if c.ChangePercent > svc.MaxIncreasePercent {
+					svc.MaxIncreasePercent = c.ChangePercent
+				}
+			} else {
+				svc.Decreased++
+			}
+			sums[c.Service] += c.ChangePercent
+			diff.Changed = append(diff.Changed, c)
+		}
+	}
+
+	for name, svc := range services {
+		if changed := svc.Increased + svc.Decreased; changed > 0 {
+			svc.AvgChangePercent = sums[name] / float64(changed)
+		}
+		diff.Services = append(diff.Services, *svc)
+	}
+	sort.Slice(diff.Services, func(i, j int) bool { return diff.Services[i].Service < diff.Services[j].Service })
+	return diff
+}
@@ -0,0 +1,46 @@
+package clickhouse
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestBuildSnapshotDiff(t *testing.T) {
+	price := func(s string) *decimal.Decimal {
+		d := decimal.RequireFromString(s)
+		return &d
+	}
+	diff := buildSnapshotDiff([]RateChange{
+		{Service: "AmazonEC2", OldPrice: price("0.10"), NewPrice: price("0.12")},
+		{Service: "AmazonEC2", OldPrice: price("0.20"), NewPrice: price("0.19")},
+		{Service: "AmazonEC2", NewPrice: price("0.05")},
+		{Service: "AmazonS3", OldPrice: price("0.023")},
+	})
+
+	if len(diff.Added) != 1 || len(diff.Removed) != 1 || len(diff.Changed) != 2 {
+		t.Fatalf("unexpected classification: %d added, %d removed, %d changed", len(diff.Added), len(diff.Removed), len(diff.Changed))
+	}
+	if diff.Changed[0].Kind != RateChanged || diff.Changed[0].ChangePercent != 20 {
+		t.Errorf("unexpected change: %+v", diff.Changed[0])
+	}
+	if len(diff.Services) != 2 {
+		t.Fatalf("expected 2 services, got %+v", diff.Services)
+	}
+	// EC2: +20% and -5% average to +7.5%
+	ec2 := diff.Services[0]
+	if ec2.Service != "AmazonEC2" || ec2.Increased != 1 || ec2.Decreased != 1 || ec2.Added != 1 ||
+		ec2.AvgChangePercent != 7.5 || ec2.MaxIncreasePercent != 20 {
+		t.Errorf("unexpected EC2 summary: %+v", ec2)
+	}
+	if s3 := diff.Services[1]; s3.Removed != 1 || s3.AvgChangePercent != 0 {
+		t.Errorf("unexpected S3 summary: %+v", s3)
+	}
+
+	if got := diff.Increases(10); len(got) != 1 || got[0].ChangePercent != 20 {
+		t.Errorf("Increases(10) = %+v", got)
+	}
+	if got := diff.Increases(20); len(got) != 0 {
+		t.Errorf("Increases(20) should be empty, got %+v", got)
+	}
+}
@@ -11,11 +11,16 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2"
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel/attribute"
+
+	"terraform-cost/telemetry"
+	"terraform-cost/tenant"
 )
 
 // CloudProvider represents a cloud provider
@@ -41,6 +46,7 @@ type PricingSnapshot struct {
 	Version       string        `ch:"version"`
 	IsActive      bool          `ch:"is_active"`
 	CreatedAt     time.Time     `ch:"created_at"`
+	OrgID         string        `ch:"org_id"` // Owning org; empty for the shared catalog
 }
 
 // RateKey represents a unique pricing lookup key
@@ -85,6 +91,27 @@ type ResolvedRate struct {
 	Source     string
 }
 
+// RateLookup identifies one rate to resolve from the active snapshot
+type RateLookup struct {
+	Cloud         CloudProvider
+	Service       string
+	ProductFamily string
+	Region        string
+	Attributes    map[string]string
+	Unit          string
+	Alias         string
+	At            time.Time // Price as of this time; zero uses the active snapshot
+}
+
+// Key returns a stable identity for the lookup
+func (l RateLookup) Key() string {
+	key := fmt.Sprintf("%s|%s|%s|%s|%s|%s|%s", l.Cloud, l.Region, l.Alias, l.Service, l.ProductFamily, hashAttributes(l.Attributes), l.Unit)
+	if !l.At.IsZero() {
+		key += "@" + l.At.UTC().Format(time.RFC3339)
+	}
+	return key
+}
+
 // TieredRate represents a pricing tier
 type TieredRate struct {
 	Min        decimal.Decimal
@@ -119,6 +146,12 @@ func DefaultConfig() *Config {
 type Store struct {
 	conn clickhouse.Conn
 	cfg  *Config
+
+	// Optional rate cache, keyed by active snapshot (see WithRateCache)
+	rateCache       RateCache
+	rateCacheTTL    time.Duration
+	snapshotMu      sync.Mutex
+	activeSnapshots map[string]activeSnapshotEntry
 }
 
 // NewStore creates a new ClickHouse pricing store
@@ -164,6 +197,9 @@ func (s *Store) Ping(ctx context.Context) error {
 
 // Close closes the database connection
 func (s *Store) Close() error {
+	if closer, ok := s.rateCache.(interface{ Close() error }); ok {
+		closer.Close()
+	}
 	return s.conn.Close()
 }
 
@@ -171,13 +207,15 @@ func (s *Store) Close() error {
 // SNAPSHOT OPERATIONS
 // =============================================================================
 
-// CreateSnapshot inserts a new pricing snapshot
+// CreateSnapshot inserts a new pricing snapshot owned by the context's org
+// Snapshots created without a tenant form the shared catalog every org sees.
 func (s *Store) CreateSnapshot(ctx context.Context, snapshot *PricingSnapshot) error {
+	snapshot.OrgID = tenant.OrgID(ctx)
 	query := `
 		INSERT INTO pricing_snapshots (
 			id, cloud, region, provider_alias, source, fetched_at, 
-			valid_from, valid_to, hash, version, is_active, created_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			valid_from, valid_to, hash, version, is_active, created_at, org_id
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	return s.conn.Exec(ctx, query,
 		snapshot.ID,
@@ -192,63 +230,88 @@ func (s *Store) CreateSnapshot(ctx context.Context, snapshot *PricingSnapshot) e
 		snapshot.Version,
 		boolToUInt8(snapshot.IsActive),
 		time.Now(),
+		snapshot.OrgID,
 	)
 }
 
-// GetSnapshot retrieves a snapshot by ID
-func (s *Store) GetSnapshot(ctx context.Context, id uuid.UUID) (*PricingSnapshot, error) {
-	query := `
-		SELECT id, cloud, region, provider_alias, source, fetched_at,
-			   valid_from, valid_to, hash, version, is_active, created_at
-		FROM pricing_snapshots FINAL
-		WHERE id = ? AND _deleted = 0
-	`
-	row := s.conn.QueryRow(ctx, query, id)
+const snapshotColumns = `id, cloud, region, provider_alias, source, fetched_at,
+			   valid_from, valid_to, hash, version, is_active, created_at, org_id`
+
+// visibleSnapshots restricts a snapshot query to the shared catalog and the context's org
+const visibleSnapshots = `org_id IN ('', ?)`
 
+func scanSnapshot(row interface {
+	Scan(dest ...interface{}) error
+}) (*PricingSnapshot, error) {
 	var snapshot PricingSnapshot
 	var isActive uint8
-	err := row.Scan(
+	if err := row.Scan(
 		&snapshot.ID, &snapshot.Cloud, &snapshot.Region, &snapshot.ProviderAlias,
 		&snapshot.Source, &snapshot.FetchedAt, &snapshot.ValidFrom, &snapshot.ValidTo,
-		&snapshot.Hash, &snapshot.Version, &isActive, &snapshot.CreatedAt,
-	)
+		&snapshot.Hash, &snapshot.Version, &isActive, &snapshot.CreatedAt, &snapshot.OrgID,
+	); err != nil {
+		return nil, err
+	}
+	snapshot.IsActive = isActive == 1
+	return &snapshot, nil
+}
+
+// GetSnapshot retrieves a snapshot visible to the context's org by ID
+func (s *Store) GetSnapshot(ctx context.Context, id uuid.UUID) (*PricingSnapshot, error) {
+	query := `SELECT ` + snapshotColumns + `
+		FROM pricing_snapshots FINAL
+		WHERE id = ? AND ` + visibleSnapshots + ` AND _deleted = 0
+	`
+	snapshot, err := scanSnapshot(s.conn.QueryRow(ctx, query, id, tenant.OrgID(ctx)))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get snapshot: %w", err)
 	}
-	snapshot.IsActive = isActive == 1
-	return &snapshot, nil
+	return snapshot, nil
 }
 
 // GetActiveSnapshot retrieves the active snapshot for a cloud/region/alias
+// The context org's own snapshot wins over the shared catalog.
 func (s *Store) GetActiveSnapshot(ctx context.Context, cloud CloudProvider, region, alias string) (*PricingSnapshot, error) {
-	query := `
-		SELECT id, cloud, region, provider_alias, source, fetched_at,
-			   valid_from, valid_to, hash, version, is_active, created_at
+	query := `SELECT ` + snapshotColumns + `
 		FROM pricing_snapshots FINAL
 		WHERE cloud = ? AND region = ? AND provider_alias = ? 
-		  AND is_active = 1 AND _deleted = 0
+		  AND is_active = 1 AND ` + visibleSnapshots + ` AND _deleted = 0
+		ORDER BY org_id DESC
 		LIMIT 1
 	`
-	row := s.conn.QueryRow(ctx, query, string(cloud), region, alias)
-
-	var snapshot PricingSnapshot
-	var isActive uint8
-	err := row.Scan(
-		&snapshot.ID, &snapshot.Cloud, &snapshot.Region, &snapshot.ProviderAlias,
-		&snapshot.Source, &snapshot.FetchedAt, &snapshot.ValidFrom, &snapshot.ValidTo,
-		&snapshot.Hash, &snapshot.Version, &isActive, &snapshot.CreatedAt,
-	)
+	snapshot, err := scanSnapshot(s.conn.QueryRow(ctx, query, string(cloud), region, alias, tenant.OrgID(ctx)))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get active snapshot: %w", err)
 	}
-	snapshot.IsActive = isActive == 1
-	return &snapshot, nil
+	return snapshot, nil
+}
+
+// GetSnapshotAt retrieves the snapshot that was valid for a cloud/region/alias at a point in time
+// The context org's own snapshot wins, then the most recently started one
+// when validity windows overlap.
+func (s *Store) GetSnapshotAt(ctx context.Context, cloud CloudProvider, region, alias string, at time.Time) (*PricingSnapshot, error) {
+	query := `SELECT ` + snapshotColumns + `
+		FROM pricing_snapshots FINAL
+		WHERE cloud = ? AND region = ? AND provider_alias = ?
+		  AND valid_from <= ? AND (valid_to IS NULL OR valid_to > ?)
+		  AND ` + visibleSnapshots + ` AND _deleted = 0
+		ORDER BY org_id DESC, valid_from DESC
+		LIMIT 1
+	`
+	snapshot, err := scanSnapshot(s.conn.QueryRow(ctx, query, string(cloud), region, alias, at, at, tenant.OrgID(ctx)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshot at %s: %w", at.Format(time.RFC3339), err)
+	}
+	return snapshot, nil
 }
 
 // ActivateSnapshot activates a snapshot (marks it as active, deactivates others)
@@ -262,42 +325,43 @@ func (s *Store) ActivateSnapshot(ctx context.Context, id uuid.UUID) error {
 		return fmt.Errorf("snapshot not found: %s", id)
 	}
 
-	// Deactivate existing active snapshots for this cloud/region/alias
+	// Deactivate existing active snapshots for this cloud/region/alias,
+	// closing their validity window where the new snapshot's begins
 	deactivateQuery := `
 		INSERT INTO pricing_snapshots 
 		SELECT id, cloud, region, provider_alias, source, fetched_at,
-			   valid_from, valid_to, hash, version, 0 as is_active, created_at,
-			   _version + 1 as _version, _deleted
+			   valid_from, if(isNull(valid_to), toNullable(toDateTime64(?, 3)), valid_to) as valid_to,
+			   hash, version, 0 as is_active, created_at,
+			   _version + 1 as _version, _deleted, org_id
 		FROM pricing_snapshots FINAL
-		WHERE cloud = ? AND region = ? AND provider_alias = ? 
+		WHERE cloud = ? AND region = ? AND provider_alias = ? AND org_id = ?
 		  AND is_active = 1 AND _deleted = 0 AND id != ?
 	`
-	if err := s.conn.Exec(ctx, deactivateQuery, string(snapshot.Cloud), snapshot.Region, snapshot.ProviderAlias, id); err != nil {
+	if err := s.conn.Exec(ctx, deactivateQuery, snapshot.ValidFrom, string(snapshot.Cloud), snapshot.Region, snapshot.ProviderAlias, snapshot.OrgID, id); err != nil {
 		return fmt.Errorf("failed to deactivate snapshots: %w", err)
 	}
+	s.forgetActiveSnapshots()
 
 	// Activate the target snapshot
 	activateQuery := `
 		INSERT INTO pricing_snapshots 
 		SELECT id, cloud, region, provider_alias, source, fetched_at,
 			   valid_from, valid_to, hash, version, 1 as is_active, created_at,
-			   _version + 1 as _version, _deleted
+			   _version + 1 as _version, _deleted, org_id
 		FROM pricing_snapshots FINAL
 		WHERE id = ?
 	`
 	return s.conn.Exec(ctx, activateQuery, id)
 }
 
-// ListSnapshots lists snapshots for a cloud/region
+// ListSnapshots lists the snapshots for a cloud/region visible to the context's org
 func (s *Store) ListSnapshots(ctx context.Context, cloud CloudProvider, region string) ([]*PricingSnapshot, error) {
-	query := `
-		SELECT id, cloud, region, provider_alias, source, fetched_at,
-			   valid_from, valid_to, hash, version, is_active, created_at
+	query := `SELECT ` + snapshotColumns + `
 		FROM pricing_snapshots FINAL
-		WHERE cloud = ? AND region = ? AND _deleted = 0
+		WHERE cloud = ? AND region = ? AND ` + visibleSnapshots + ` AND _deleted = 0
 		ORDER BY created_at DESC
 	`
-	rows, err := s.conn.Query(ctx, query, string(cloud), region)
+	rows, err := s.conn.Query(ctx, query, string(cloud), region, tenant.OrgID(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to list snapshots: %w", err)
 	}
@@ -305,47 +369,30 @@ func (s *Store) ListSnapshots(ctx context.Context, cloud CloudProvider, region s
 
 	var snapshots []*PricingSnapshot
 	for rows.Next() {
-		var snapshot PricingSnapshot
-		var isActive uint8
-		if err := rows.Scan(
-			&snapshot.ID, &snapshot.Cloud, &snapshot.Region, &snapshot.ProviderAlias,
-			&snapshot.Source, &snapshot.FetchedAt, &snapshot.ValidFrom, &snapshot.ValidTo,
-			&snapshot.Hash, &snapshot.Version, &isActive, &snapshot.CreatedAt,
-		); err != nil {
+		snapshot, err := scanSnapshot(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan snapshot: %w", err)
 		}
-		snapshot.IsActive = isActive == 1
-		snapshots = append(snapshots, &snapshot)
+		snapshots = append(snapshots, snapshot)
 	}
 	return snapshots, nil
 }
 
-// FindSnapshotByHash finds a snapshot by its content hash
+// FindSnapshotByHash finds a snapshot of the context's org by its content hash
 func (s *Store) FindSnapshotByHash(ctx context.Context, cloud CloudProvider, region, alias, hash string) (*PricingSnapshot, error) {
-	query := `
-		SELECT id, cloud, region, provider_alias, source, fetched_at,
-			   valid_from, valid_to, hash, version, is_active, created_at
+	query := `SELECT ` + snapshotColumns + `
 		FROM pricing_snapshots FINAL
-		WHERE cloud = ? AND region = ? AND provider_alias = ? AND hash = ? AND _deleted = 0
+		WHERE cloud = ? AND region = ? AND provider_alias = ? AND hash = ? AND org_id = ? AND _deleted = 0
 		LIMIT 1
 	`
-	row := s.conn.QueryRow(ctx, query, string(cloud), region, alias, hash)
-
-	var snapshot PricingSnapshot
-	var isActive uint8
-	err := row.Scan(
-		&snapshot.ID, &snapshot.Cloud, &snapshot.Region, &snapshot.ProviderAlias,
-		&snapshot.Source, &snapshot.FetchedAt, &snapshot.ValidFrom, &snapshot.ValidTo,
-		&snapshot.Hash, &snapshot.Version, &isActive, &snapshot.CreatedAt,
-	)
+	snapshot, err := scanSnapshot(s.conn.QueryRow(ctx, query, string(cloud), region, alias, hash, tenant.OrgID(ctx)))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to find snapshot by hash: %w", err)
 	}
-	snapshot.IsActive = isActive == 1
-	return &snapshot, nil
+	return snapshot, nil
 }
 
 // CountRates returns the count of rates in a snapshot
@@ -498,51 +545,238 @@ func (s *Store) BulkCreateRates(ctx context.Context, rates []*PricingRate) error
 }
 
 // ResolveRate looks up a rate from the active snapshot
-func (s *Store) ResolveRate(ctx context.Context, cloud CloudProvider, service, productFamily, region string, attrs map[string]string, unit, alias string) (*ResolvedRate, error) {
-	attrsHash := hashAttributes(attrs)
+func (s *Store) ResolveRate(ctx context.Context, cloud CloudProvider, service, productFamily, region string, attrs map[string]string, unit, alias string) (resolved *ResolvedRate, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "pricing.resolve_rate",
+		attribute.String("pricing.service", service),
+		attribute.String("pricing.region", region),
+		attribute.String("pricing.unit", unit),
+	)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	rates, err := s.ResolveRates(ctx, []RateLookup{{
+		Cloud:         cloud,
+		Service:       service,
+		ProductFamily: productFamily,
+		Region:        region,
+		Attributes:    attrs,
+		Unit:          unit,
+		Alias:         alias,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	return rates[0], nil
+}
 
-	query := `
-		SELECT pr.price, pr.currency, pr.confidence, pr.tier_min, pr.tier_max, pr.snapshot_id, ps.source
-		FROM pricing_rates pr FINAL
-		JOIN pricing_snapshots ps FINAL ON pr.snapshot_id = ps.id
-		JOIN pricing_rate_keys rk FINAL ON pr.rate_key_id = rk.id
-		WHERE ps.cloud = ? AND ps.region = ? AND ps.provider_alias = ? AND ps.is_active = 1
-		  AND rk.service = ? AND rk.product_family = ? AND rk.attributes_hash = ?
-		  AND pr.unit = ?
-		  AND ps._deleted = 0 AND pr._deleted = 0 AND rk._deleted = 0
-		ORDER BY pr.tier_min NULLS FIRST
-		LIMIT 1
-	`
+// ResolveRates resolves many rates at once; results align with lookups and
+// are nil where no price exists. Cache misses are fetched in at most two
+// queries: one by active flag, one by snapshot ID.
+func (s *Store) ResolveRates(ctx context.Context, lookups []RateLookup) ([]*ResolvedRate, error) {
+	results := make([]*ResolvedRate, len(lookups))
+
+	// Identical lookups are resolved once; hits are served from the cache
+	hits := make(map[string]*ResolvedRate)
+	pending := make(map[string][]int)
+	snapshotIDs := make(map[string]uuid.UUID) // lookup key -> snapshot, when known up front
+	ttls := make(map[string]time.Duration)
+	datedSnapshots := make(map[string]activeSnapshotEntry)
+	for i, l := range lookups {
+		key := l.Key()
+		if rate, ok := hits[key]; ok {
+			results[i] = rate
+			continue
+		}
+		if _, ok := pending[key]; ok {
+			pending[key] = append(pending[key], i)
+			continue
+		}
 
-	row := s.conn.QueryRow(ctx, query, string(cloud), region, alias, service, productFamily, attrsHash, unit)
+		snap, known, err := s.lookupSnapshot(ctx, l, datedSnapshots)
+		if err != nil {
+			return nil, err
+		}
+		if known {
+			if snap.id == uuid.Nil {
+				// No snapshot covers this lookup, so nothing can resolve
+				hits[key] = nil
+				continue
+			}
+			if s.rateCache != nil {
+				if rate, ok := s.rateCache.Get(ctx, rateCacheKey(snap.id, l)); ok {
+					hits[key] = rate
+					results[i] = rate
+					continue
+				}
+				ttls[key] = s.entryTTL(snap)
+			}
+			snapshotIDs[key] = snap.id
+		}
+		pending[key] = []int{i}
+	}
 
-	var rate ResolvedRate
-	if err := row.Scan(&rate.Price, &rate.Currency, &rate.Confidence, &rate.TierMin, &rate.TierMax, &rate.SnapshotID, &rate.Source); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
+	var active, bySnapshot []clickhouse.GroupSet
+	for key, indexes := range pending {
+		l := lookups[indexes[0]]
+		if id, ok := snapshotIDs[key]; ok {
+			bySnapshot = append(bySnapshot, clickhouse.GroupSet{Value: []any{
+				id, l.Service, l.ProductFamily, hashAttributes(l.Attributes), l.Unit,
+			}})
+			continue
+		}
+		active = append(active, clickhouse.GroupSet{Value: []any{
+			string(l.Cloud), l.Region, l.Alias, l.Service, l.ProductFamily, hashAttributes(l.Attributes), l.Unit,
+		}})
+	}
+
+	fetched := make(map[string]*ResolvedRate)
+	if len(active) > 0 {
+		query := `
+			SELECT concat(ps.cloud, '|', ps.region, '|', ps.provider_alias, '|', rk.service, '|', rk.product_family, '|', rk.attributes_hash, '|', pr.unit),
+				   pr.price, pr.currency, pr.confidence, pr.tier_min, pr.tier_max, pr.snapshot_id, ps.source
+			FROM pricing_rates pr FINAL
+			JOIN pricing_snapshots ps FINAL ON pr.snapshot_id = ps.id
+			JOIN pricing_rate_keys rk FINAL ON pr.rate_key_id = rk.id
+			WHERE ps.is_active = 1 AND ps.org_id IN ('', ?)
+			  AND (ps.cloud, ps.region, ps.provider_alias, rk.service, rk.product_family, rk.attributes_hash, pr.unit) IN (?)
+			  AND ps._deleted = 0 AND pr._deleted = 0 AND rk._deleted = 0
+			ORDER BY ps.org_id DESC, pr.tier_min NULLS FIRST
+			LIMIT 1 BY ps.cloud, ps.region, ps.provider_alias, rk.service, rk.product_family, rk.attributes_hash, pr.unit
+		`
+		if err := s.queryRates(ctx, query, fetched, tenant.OrgID(ctx), active); err != nil {
+			return nil, err
 		}
-		return nil, fmt.Errorf("failed to resolve rate: %w", err)
 	}
-	return &rate, nil
+	if len(bySnapshot) > 0 {
+		query := `
+			SELECT concat(toString(pr.snapshot_id), '|', rk.service, '|', rk.product_family, '|', rk.attributes_hash, '|', pr.unit),
+				   pr.price, pr.currency, pr.confidence, pr.tier_min, pr.tier_max, pr.snapshot_id, ps.source
+			FROM pricing_rates pr FINAL
+			JOIN pricing_snapshots ps FINAL ON pr.snapshot_id = ps.id
+			JOIN pricing_rate_keys rk FINAL ON pr.rate_key_id = rk.id
+			WHERE (pr.snapshot_id, rk.service, rk.product_family, rk.attributes_hash, pr.unit) IN (?)
+			  AND ps.org_id IN ('', ?)
+			  AND ps._deleted = 0 AND pr._deleted = 0 AND rk._deleted = 0
+			ORDER BY pr.tier_min NULLS FIRST
+			LIMIT 1 BY pr.snapshot_id, rk.service, rk.product_family, rk.attributes_hash, pr.unit
+		`
+		if err := s.queryRates(ctx, query, fetched, bySnapshot, tenant.OrgID(ctx)); err != nil {
+			return nil, err
+		}
+	}
+
+	for key, indexes := range pending {
+		l := lookups[indexes[0]]
+		fetchedKey := key
+		id, known := snapshotIDs[key]
+		if known {
+			fetchedKey = rateCacheKey(id, l)
+		}
+		rate := fetched[fetchedKey]
+		for _, i := range indexes {
+			results[i] = rate
+		}
+		// Misses are cached too, so unpriced components don't re-query every run
+		if known && s.rateCache != nil {
+			s.rateCache.Set(ctx, fetchedKey, rate, ttls[key])
+		}
+	}
+	return results, nil
+}
+
+// lookupSnapshot finds the snapshot a lookup resolves against, when that can be known
+// without querying rates: dated lookups always, active lookups only when caching.
+func (s *Store) lookupSnapshot(ctx context.Context, l RateLookup, dated map[string]activeSnapshotEntry) (activeSnapshotEntry, bool, error) {
+	if l.At.IsZero() {
+		if s.rateCache == nil {
+			return activeSnapshotEntry{}, false, nil
+		}
+		snap, err := s.activeSnapshot(ctx, l.Cloud, l.Region, l.Alias)
+		return snap, true, err
+	}
+
+	key := fmt.Sprintf("%s|%s|%s|%s", l.Cloud, l.Region, l.Alias, l.At.Format(time.RFC3339Nano))
+	if snap, ok := dated[key]; ok {
+		return snap, true, nil
+	}
+	snapshot, err := s.GetSnapshotAt(ctx, l.Cloud, l.Region, l.Alias, l.At)
+	if err != nil {
+		return activeSnapshotEntry{}, false, err
+	}
+	// Historical snapshots never change, so their rates cache for the full TTL
+	var snap activeSnapshotEntry
+	if snapshot != nil {
+		snap.id = snapshot.ID
+	}
+	dated[key] = snap
+	return snap, true, nil
+}
+
+// queryRates runs a rate query whose first column is the result key
+func (s *Store) queryRates(ctx context.Context, query string, into map[string]*ResolvedRate, args ...interface{}) error {
+	rows, err := s.conn.Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to resolve rates: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		var rate ResolvedRate
+		if err := rows.Scan(&key, &rate.Price, &rate.Currency, &rate.Confidence, &rate.TierMin, &rate.TierMax, &rate.SnapshotID, &rate.Source); err != nil {
+			return fmt.Errorf("failed to scan rate: %w", err)
+		}
+		into[key] = &rate
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to resolve rates: %w", err)
+	}
+	return nil
+}
+
+// ResolveRatesBatch resolves lookups in one round-trip, keyed by RateLookup.Key
+// Lookups with no price map to nil.
+func (s *Store) ResolveRatesBatch(ctx context.Context, lookups []RateLookup) (map[string]*ResolvedRate, error) {
+	rates, err := s.ResolveRates(ctx, lookups)
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]*ResolvedRate, len(lookups))
+	for i, l := range lookups {
+		byKey[l.Key()] = rates[i]
+	}
+	return byKey, nil
 }
 
 // ResolveTieredRates returns all tiers for a rate
 func (s *Store) ResolveTieredRates(ctx context.Context, cloud CloudProvider, service, productFamily, region string, attrs map[string]string, unit, alias string) ([]TieredRate, error) {
-	attrsHash := hashAttributes(attrs)
+	// One snapshot's tiers: the org's own active snapshot, else the shared one
+	snapshot, err := s.GetActiveSnapshot(ctx, cloud, region, alias)
+	if err != nil || snapshot == nil {
+		return nil, err
+	}
+	return s.ResolveRateTiers(ctx, snapshot.ID, RateLookup{
+		Service:       service,
+		ProductFamily: productFamily,
+		Attributes:    attrs,
+		Unit:          unit,
+	})
+}
 
+// ResolveRateTiers returns every tier of a lookup's rate within one snapshot, lowest first
+func (s *Store) ResolveRateTiers(ctx context.Context, snapshotID uuid.UUID, l RateLookup) ([]TieredRate, error) {
 	query := `
 		SELECT pr.price, pr.confidence, pr.tier_min, pr.tier_max
 		FROM pricing_rates pr FINAL
 		JOIN pricing_snapshots ps FINAL ON pr.snapshot_id = ps.id
 		JOIN pricing_rate_keys rk FINAL ON pr.rate_key_id = rk.id
-		WHERE ps.cloud = ? AND ps.region = ? AND ps.provider_alias = ? AND ps.is_active = 1
+		WHERE ps.id = ? AND ps.org_id IN ('', ?)
 		  AND rk.service = ? AND rk.product_family = ? AND rk.attributes_hash = ?
 		  AND pr.unit = ?
 		  AND ps._deleted = 0 AND pr._deleted = 0 AND rk._deleted = 0
 		ORDER BY pr.tier_min NULLS FIRST
 	`
 
-	rows, err := s.conn.Query(ctx, query, string(cloud), region, alias, service, productFamily, attrsHash, unit)
+	rows, err := s.conn.Query(ctx, query, snapshotID, tenant.OrgID(ctx), l.Service, l.ProductFamily, hashAttributes(l.Attributes), l.Unit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve tiered rates: %w", err)
 	}
@@ -561,7 +795,112 @@ func (s *Store) ResolveTieredRates(ctx context.Context, cloud CloudProvider, ser
 		tier.Max = tierMax
 		tiers = append(tiers, tier)
 	}
-	return tiers, nil
+	return tiers, rows.Err()
+}
+
+// =============================================================================
+// SPOT PRICE OPERATIONS
+// =============================================================================
+
+// SpotPrice is a single observed spot price
+type SpotPrice struct {
+	Cloud              CloudProvider   `ch:"cloud"`
+	Region             string          `ch:"region"`
+	AvailabilityZone   string          `ch:"availability_zone"`
+	InstanceType       string          `ch:"instance_type"`
+	ProductDescription string          `ch:"product_description"`
+	Price              decimal.Decimal `ch:"price"`
+	Currency           string          `ch:"currency"`
+	ObservedAt         time.Time       `ch:"observed_at"`
+	Source             string          `ch:"source"`
+}
+
+// SpotRate summarizes spot price history over a lookback window
+type SpotRate struct {
+	Average  decimal.Decimal
+	P50      decimal.Decimal
+	P90      decimal.Decimal
+	Max      decimal.Decimal
+	Currency string
+	Samples  int
+	From     time.Time
+	To       time.Time
+}
+
+// BulkInsertSpotPrices appends observed spot prices
+func (s *Store) BulkInsertSpotPrices(ctx context.Context, prices []*SpotPrice) error {
+	if len(prices) == 0 {
+		return nil
+	}
+
+	batch, err := s.conn.PrepareBatch(ctx, `
+		INSERT INTO spot_price_history (
+			cloud, region, availability_zone, instance_type, product_description,
+			price, currency, observed_at, source, created_at
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare batch: %w", err)
+	}
+
+	for _, p := range prices {
+		currency := p.Currency
+		if currency == "" {
+			currency = "USD"
+		}
+		if err := batch.Append(
+			string(p.Cloud), p.Region, p.AvailabilityZone, p.InstanceType, p.ProductDescription,
+			p.Price, currency, p.ObservedAt, p.Source, time.Now(),
+		); err != nil {
+			return fmt.Errorf("failed to append to batch: %w", err)
+		}
+	}
+
+	return batch.Send()
+}
+
+// ResolveSpotRate aggregates spot price history for an instance type across all AZs of a region
+// The lookback window ends at at (zero means now); returns nil when it holds no history.
+func (s *Store) ResolveSpotRate(ctx context.Context, cloud CloudProvider, region, instanceType, productDescription string, lookback time.Duration, at time.Time) (*SpotRate, error) {
+	query := `
+		SELECT
+			toFloat64(avg(price)),
+			quantile(0.5)(toFloat64(price)),
+			quantile(0.9)(toFloat64(price)),
+			toFloat64(max(price)),
+			any(currency),
+			count(),
+			min(observed_at),
+			max(observed_at)
+		FROM spot_price_history FINAL
+		WHERE cloud = ? AND region = ? AND instance_type = ? AND product_description = ?
+		  AND observed_at >= ? AND observed_at <= ? AND _deleted = 0
+	`
+
+	if at.IsZero() {
+		at = time.Now()
+	}
+	row := s.conn.QueryRow(ctx, query, string(cloud), region, instanceType, productDescription, at.Add(-lookback), at)
+
+	var avg, p50, p90, maxPrice float64
+	var samples uint64
+	var rate SpotRate
+	if err := row.Scan(&avg, &p50, &p90, &maxPrice, &rate.Currency, &samples, &rate.From, &rate.To); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to resolve spot rate: %w", err)
+	}
+	if samples == 0 {
+		return nil, nil
+	}
+
+	rate.Average = decimal.NewFromFloat(avg)
+	rate.P50 = decimal.NewFromFloat(p50)
+	rate.P90 = decimal.NewFromFloat(p90)
+	rate.Max = decimal.NewFromFloat(maxPrice)
+	rate.Samples = int(samples)
+	return &rate, nil
 }
 
 // =============================================================================
@@ -596,35 +935,30 @@ func boolToUInt8(b bool) uint8 {
 }
 
 // CalculateTieredCost computes cost for tiered pricing
+// Tier bounds are absolute usage levels; usage below the first tier's minimum
+// or between tiers is free, since ingestion drops zero-price free tiers.
 func CalculateTieredCost(usage decimal.Decimal, tiers []TieredRate) (decimal.Decimal, float64) {
 	if len(tiers) == 0 {
 		return decimal.Zero, 0
 	}
 
 	totalCost := decimal.Zero
-	remaining := usage
 	minConfidence := 1.0
 
 	for _, tier := range tiers {
-		if remaining.LessThanOrEqual(decimal.Zero) {
+		if usage.LessThanOrEqual(tier.Min) {
 			break
 		}
 
-		var tierUsage decimal.Decimal
-		if tier.Max == nil {
-			tierUsage = remaining
-		} else {
-			tierSize := tier.Max.Sub(tier.Min)
-			if remaining.GreaterThan(tierSize) {
-				tierUsage = tierSize
-			} else {
-				tierUsage = remaining
-			}
+		upper := usage
+		if tier.Max != nil && tier.Max.LessThan(usage) {
+			upper = *tier.Max
 		}
-
-		tierCost := tierUsage.Mul(tier.Price)
-		totalCost = totalCost.Add(tierCost)
-		remaining = remaining.Sub(tierUsage)
+		tierUsage := upper.Sub(tier.Min)
+		if !tierUsage.IsPositive() {
+			continue
+		}
+		totalCost = totalCost.Add(tierUsage.Mul(tier.Price))
 
 		if tier.Confidence < minConfidence {
 			minConfidence = tier.Confidence
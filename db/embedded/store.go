@@ -0,0 +1,302 @@
+// Package embedded provides an offline pricing store backed by a single bbolt file
+// Bundles are exported from ClickHouse (terracost pricing export) and can be
+// shipped to CI runners or air-gapped machines that have no database access.
+package embedded
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"terraform-cost/db/clickhouse"
+)
+
+// FormatVersion is the bundle layout version written to new bundles
+const FormatVersion = "1"
+
+// ErrNoHistoricalPricing is returned for dated lookups; bundles hold current prices only
+var ErrNoHistoricalPricing = errors.New("pricing bundles only contain current prices")
+
+var (
+	bucketMeta  = []byte("meta")
+	bucketRates = []byte("rates")
+	bucketSpot  = []byte("spot")
+	keyInfo     = []byte("info")
+)
+
+// BundleInfo describes a pricing bundle
+type BundleInfo struct {
+	FormatVersion string    `json:"format_version"`
+	BuiltAt       time.Time `json:"built_at"`
+	Source        string    `json:"source"`
+	Clouds        []string  `json:"clouds"`
+	RateCount     int       `json:"rate_count"`
+	SpotRateCount int       `json:"spot_rate_count"`
+}
+
+// =============================================================================
+// READER
+// =============================================================================
+
+// Store serves rates from a pricing bundle
+type Store struct {
+	db   *bolt.DB
+	info BundleInfo
+}
+
+// Open opens a pricing bundle read-only
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0o444, &bolt.Options{ReadOnly: true, Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pricing bundle: %w", err)
+	}
+
+	s := &Store{db: db}
+	err = db.View(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(bucketMeta)
+		if meta == nil || tx.Bucket(bucketRates) == nil {
+			return fmt.Errorf("%s is not a pricing bundle", path)
+		}
+		if err := json.Unmarshal(meta.Get(keyInfo), &s.info); err != nil {
+			return fmt.Errorf("invalid bundle metadata: %w", err)
+		}
+		if s.info.FormatVersion != FormatVersion {
+			return fmt.Errorf("unsupported bundle format %q (expected %q)", s.info.FormatVersion, FormatVersion)
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Info returns the bundle metadata
+func (s *Store) Info() BundleInfo {
+	return s.info
+}
+
+// Close closes the bundle
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// ResolveRate looks up a single rate
+func (s *Store) ResolveRate(ctx context.Context, cloud clickhouse.CloudProvider, service, productFamily, region string, attrs map[string]string, unit, alias string) (*clickhouse.ResolvedRate, error) {
+	lookup := clickhouse.RateLookup{
+		Cloud:         cloud,
+		Service:       service,
+		ProductFamily: productFamily,
+		Region:        region,
+		Attributes:    attrs,
+		Unit:          unit,
+		Alias:         alias,
+	}
+	rates, err := s.ResolveRatesBatch(ctx, []clickhouse.RateLookup{lookup})
+	if err != nil {
+		return nil, err
+	}
+	return rates[lookup.Key()], nil
+}
+
+// ResolveRatesBatch resolves lookups in one read transaction, keyed by RateLookup.Key
+func (s *Store) ResolveRatesBatch(ctx context.Context, lookups []clickhouse.RateLookup) (map[string]*clickhouse.ResolvedRate, error) {
+	for _, l := range lookups {
+		if !l.At.IsZero() {
+			return nil, ErrNoHistoricalPricing
+		}
+	}
+	rates := make(map[string]*clickhouse.ResolvedRate, len(lookups))
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketRates)
+		for _, l := range lookups {
+			key := l.Key()
+			if _, done := rates[key]; done {
+				continue
+			}
+			rates[key] = nil
+			data := bucket.Get([]byte(key))
+			if data == nil {
+				continue
+			}
+			var rate clickhouse.ResolvedRate
+			if err := json.Unmarshal(data, &rate); err != nil {
+				return fmt.Errorf("corrupt rate %s: %w", key, err)
+			}
+			rates[key] = &rate
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// ResolveSpotRate returns the exported spot summary if its history reaches into the lookback window
+func (s *Store) ResolveSpotRate(ctx context.Context, cloud clickhouse.CloudProvider, region, instanceType, productDescription string, lookback time.Duration, at time.Time) (*clickhouse.SpotRate, error) {
+	if !at.IsZero() {
+		return nil, ErrNoHistoricalPricing
+	}
+	var rate *clickhouse.SpotRate
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketSpot)
+		if bucket == nil {
+			return nil
+		}
+		data := bucket.Get(spotKey(clickhouse.SpotRateKey{
+			Cloud:              cloud,
+			Region:             region,
+			InstanceType:       instanceType,
+			ProductDescription: productDescription,
+		}))
+		if data == nil {
+			return nil
+		}
+		rate = &clickhouse.SpotRate{}
+		return json.Unmarshal(data, rate)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve spot rate: %w", err)
+	}
+	if rate != nil && rate.To.Before(time.Now().Add(-lookback)) {
+		return nil, nil
+	}
+	return rate, nil
+}
+
+func spotKey(k clickhouse.SpotRateKey) []byte {
+	return []byte(fmt.Sprintf("%s|%s|%s|%s", k.Cloud, k.Region, k.InstanceType, k.ProductDescription))
+}
+
+// =============================================================================
+// WRITER
+// =============================================================================
+
+// writeBatchSize is the number of entries committed per write transaction
+const writeBatchSize = 10000
+
+// Writer builds a pricing bundle
+type Writer struct {
+	db      *bolt.DB
+	info    BundleInfo
+	pending []pendingEntry // flushed every writeBatchSize entries
+}
+
+type pendingEntry struct {
+	bucket []byte
+	key    []byte
+	value  []byte
+}
+
+// Create creates a new, empty pricing bundle, replacing any file at path
+func Create(path, source string) (*Writer, error) {
+	db, err := bolt.Open(path, 0o644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pricing bundle: %w", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketMeta, bucketRates, bucketSpot} {
+			if tx.Bucket(name) != nil {
+				if err := tx.DeleteBucket(name); err != nil {
+					return err
+				}
+			}
+			if _, err := tx.CreateBucket(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize pricing bundle: %w", err)
+	}
+	return &Writer{
+		db:   db,
+		info: BundleInfo{FormatVersion: FormatVersion, Source: source},
+	}, nil
+}
+
+// PutRate adds a rate for a lookup
+func (w *Writer) PutRate(lookup clickhouse.RateLookup, rate *clickhouse.ResolvedRate) error {
+	data, err := json.Marshal(rate)
+	if err != nil {
+		return fmt.Errorf("failed to encode rate: %w", err)
+	}
+	w.info.RateCount++
+	w.addCloud(string(lookup.Cloud))
+	return w.put(bucketRates, []byte(lookup.Key()), data)
+}
+
+// PutSpotRate adds a spot price summary
+func (w *Writer) PutSpotRate(key clickhouse.SpotRateKey, rate *clickhouse.SpotRate) error {
+	data, err := json.Marshal(rate)
+	if err != nil {
+		return fmt.Errorf("failed to encode spot rate: %w", err)
+	}
+	w.info.SpotRateCount++
+	return w.put(bucketSpot, spotKey(key), data)
+}
+
+// Close writes the bundle metadata and closes the file
+func (w *Writer) Close() (BundleInfo, error) {
+	defer w.db.Close()
+	if err := w.flush(); err != nil {
+		return w.info, err
+	}
+
+	w.info.BuiltAt = time.Now().UTC()
+	data, err := json.Marshal(w.info)
+	if err != nil {
+		return w.info, err
+	}
+	err = w.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketMeta).Put(keyInfo, data)
+	})
+	if err != nil {
+		return w.info, fmt.Errorf("failed to write bundle metadata: %w", err)
+	}
+	return w.info, nil
+}
+
+func (w *Writer) put(bucket, key, value []byte) error {
+	w.pending = append(w.pending, pendingEntry{bucket: bucket, key: key, value: value})
+	if len(w.pending) >= writeBatchSize {
+		return w.flush()
+	}
+	return nil
+}
+
+func (w *Writer) flush() error {
+	if len(w.pending) == 0 {
+		return nil
+	}
+	err := w.db.Update(func(tx *bolt.Tx) error {
+		for _, e := range w.pending {
+			if err := tx.Bucket(e.bucket).Put(e.key, e.value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	w.pending = w.pending[:0]
+	if err != nil {
+		return fmt.Errorf("failed to write pricing bundle: %w", err)
+	}
+	return nil
+}
+
+func (w *Writer) addCloud(cloud string) {
+	for _, c := range w.info.Clouds {
+		if c == cloud {
+			return
+		}
+	}
+	w.info.Clouds = append(w.info.Clouds, cloud)
+}
@@ -0,0 +1,76 @@
+package embedded
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+)
+
+func TestBundleRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "prices.db")
+
+	m5 := clickhouse.RateLookup{
+		Cloud:         clickhouse.AWS,
+		Service:       "AmazonEC2",
+		ProductFamily: "Compute Instance",
+		Region:        "us-east-1",
+		Attributes:    map[string]string{"instanceType": "m5.large", "tenancy": "Shared"},
+		Unit:          "Hrs",
+		Alias:         "default",
+	}
+	spot := clickhouse.SpotRateKey{Cloud: clickhouse.AWS, Region: "us-east-1", InstanceType: "m5.large", ProductDescription: "Linux/UNIX"}
+
+	w, err := Create(path, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.PutRate(m5, &clickhouse.ResolvedRate{Price: decimal.RequireFromString("0.096"), Currency: "USD", Confidence: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.PutSpotRate(spot, &clickhouse.SpotRate{P50: decimal.RequireFromString("0.035"), Samples: 12, To: time.Now().Add(-48 * time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if info := s.Info(); info.RateCount != 1 || info.SpotRateCount != 1 || info.Clouds[0] != "aws" {
+		t.Errorf("unexpected info %+v", info)
+	}
+
+	// Attribute order must not matter
+	reordered := m5
+	reordered.Attributes = map[string]string{"tenancy": "Shared", "instanceType": "m5.large"}
+	missing := m5
+	missing.Attributes = map[string]string{"instanceType": "m5.xlarge", "tenancy": "Shared"}
+
+	rates, err := s.ResolveRatesBatch(ctx, []clickhouse.RateLookup{reordered, missing})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := rates[m5.Key()]; r == nil || !r.Price.Equal(decimal.RequireFromString("0.096")) {
+		t.Errorf("m5.large: got %+v", r)
+	}
+	if r, ok := rates[missing.Key()]; !ok || r != nil {
+		t.Errorf("m5.xlarge should resolve to nil, got %+v (present %v)", r, ok)
+	}
+
+	if r, _ := s.ResolveSpotRate(ctx, clickhouse.AWS, "us-east-1", "m5.large", "Linux/UNIX", 7*24*time.Hour, time.Time{}); r == nil || r.Samples != 12 {
+		t.Errorf("spot within lookback: got %+v", r)
+	}
+	if r, _ := s.ResolveSpotRate(ctx, clickhouse.AWS, "us-east-1", "m5.large", "Linux/UNIX", 24*time.Hour, time.Time{}); r != nil {
+		t.Error("spot history older than the lookback should be ignored")
+	}
+}
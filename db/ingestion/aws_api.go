@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strings"
@@ -131,11 +132,11 @@ func (f *AWSPricingAPIFetcher) FetchRegion(ctx context.Context, region string) (
 		prices, err := f.fetchServicePricing(ctx, service, region)
 		if err != nil {
 			// Log but continue with other services
-			fmt.Printf("Warning: failed to fetch %s pricing: %v\n", service, err)
+			slog.Warn("failed to fetch AWS pricing", "service", service, "region", region, "error", err)
 			continue
 		}
 		allPrices = append(allPrices, prices...)
-		fmt.Printf("Fetched %d prices for %s\n", len(prices), service)
+		slog.Info("fetched AWS prices", "service", service, "region", region, "prices", len(prices))
 	}
 
 	return allPrices, nil
@@ -447,6 +448,7 @@ func (n *AWSPricingAPINormalizer) normalizeUnit(unit string) string {
 		"hrs":           "hours",
 		"GB-Mo":         "GB-month",
 		"GB-month":      "GB-month",
+		"GB-Hours":      "GB-hours",
 		"GB":            "GB",
 		"Requests":      "requests",
 		"requests":      "requests",
@@ -0,0 +1,635 @@
+// Package ingestion - Streaming AWS Price List offer ingestion
+// Decodes offer files token-by-token so multi-GB files (EC2) never sit in memory
+package ingestion
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultAWSOfferServices are the offer codes ingested by `pricing update`
+// EBS volumes, NAT gateways and Elastic IPs are published inside the AmazonEC2 offer
+var DefaultAWSOfferServices = []string{
+	"AmazonEC2",
+	"AmazonRDS",
+	"AmazonS3",
+	"AWSLambda",
+	"AWSELB",
+	"AmazonVPC",
+	"AmazonECS",
+}
+
+// AWSOfferStreamer downloads AWS Price List offer files and streams PriceEntry rows
+type AWSOfferStreamer struct {
+	httpClient *http.Client
+	baseURL    string
+	services   []string
+}
+
+// AWSOfferRef points at the current regional offer file for a service
+type AWSOfferRef struct {
+	Service string
+	Region  string
+	URL     string
+}
+
+// NewAWSOfferStreamer creates a streamer for the default offer services
+func NewAWSOfferStreamer() *AWSOfferStreamer {
+	return &AWSOfferStreamer{
+		// Offer files are large; rely on context cancellation rather than a fixed timeout
+		httpClient: &http.Client{},
+		baseURL:    "https://pricing.us-east-1.amazonaws.com",
+		services:   DefaultAWSOfferServices,
+	}
+}
+
+// WithServices restricts the streamer to specific offer codes
+func (s *AWSOfferStreamer) WithServices(services []string) *AWSOfferStreamer {
+	s.services = services
+	return s
+}
+
+// WithBaseURL overrides the Price List endpoint (for mirrors and tests)
+func (s *AWSOfferStreamer) WithBaseURL(baseURL string) *AWSOfferStreamer {
+	s.baseURL = strings.TrimRight(baseURL, "/")
+	return s
+}
+
+// ResolveOffers looks up the current offer file URL of every service for a region
+func (s *AWSOfferStreamer) ResolveOffers(ctx context.Context, region string) ([]AWSOfferRef, error) {
+	refs := make([]AWSOfferRef, 0, len(s.services))
+	for _, service := range s.services {
+		indexURL := fmt.Sprintf("%s/offers/v1.0/aws/%s/current/region_index.json", s.baseURL, service)
+
+		body, err := s.get(ctx, indexURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s region index: %w", service, err)
+		}
+
+		var index AWSRegionIndex
+		err = json.NewDecoder(body).Decode(&index)
+		body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s region index: %w", service, err)
+		}
+
+		regionData, ok := index.Regions[region]
+		if !ok {
+			continue // Service not offered in this region
+		}
+		refs = append(refs, AWSOfferRef{
+			Service: service,
+			Region:  region,
+			URL:     s.baseURL + regionData.CurrentVersionURL,
+		})
+	}
+	return refs, nil
+}
+
+// OffersHash returns a content hash for a set of offer versions
+// Offer URLs embed the publication version, so identical refs mean identical pricing
+func OffersHash(refs []AWSOfferRef) string {
+	h := sha256.New()
+	for _, ref := range refs {
+		h.Write([]byte(ref.URL))
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// StreamOffers downloads each offer file and emits projected price entries
+func (s *AWSOfferStreamer) StreamOffers(ctx context.Context, refs []AWSOfferRef, emit func(PriceEntry) error) error {
+	for _, ref := range refs {
+		body, err := s.get(ctx, ref.URL)
+		if err != nil {
+			return fmt.Errorf("failed to fetch %s offer: %w", ref.Service, err)
+		}
+		err = ParseAWSOfferStream(body, ref.Service, ref.Region, emit)
+		body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to parse %s offer: %w", ref.Service, err)
+		}
+	}
+	return nil
+}
+
+func (s *AWSOfferStreamer) get(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+	return resp.Body, nil
+}
+
+// =============================================================================
+// STREAMING OFFER PARSER
+// =============================================================================
+
+// ParseAWSOfferStream decodes an offer file incrementally
+// Only products matching a rate projection are retained; on-demand terms are
+// emitted as they are read, so memory is bounded by the projected product count.
+func ParseAWSOfferStream(r io.Reader, service, region string, emit func(PriceEntry) error) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+
+	projected := make(map[string][]projectedRateKey)
+	seenProducts := false
+
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		switch key {
+		case "products":
+			seenProducts = true
+			if err := streamProducts(dec, service, region, projected); err != nil {
+				return err
+			}
+		case "terms":
+			if !seenProducts {
+				return fmt.Errorf("offer file lists terms before products")
+			}
+			if err := streamTerms(dec, region, projected, emit); err != nil {
+				return err
+			}
+		default:
+			if err := skipValue(dec); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// projectedRateKey is the rate key a product SKU contributes to
+type projectedRateKey struct {
+	Service       string
+	ProductFamily string
+	Attributes    map[string]string
+}
+
+func streamProducts(dec *json.Decoder, service, region string, projected map[string][]projectedRateKey) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		if _, err := dec.Token(); err != nil { // SKU key
+			return err
+		}
+		var product AWSProduct
+		if err := dec.Decode(&product); err != nil {
+			return fmt.Errorf("failed to decode product: %w", err)
+		}
+		if code := product.Attributes["regionCode"]; code != "" && code != region {
+			continue
+		}
+		if keys := projectAWSProduct(service, product); len(keys) > 0 {
+			projected[product.SKU] = keys
+		}
+	}
+	_, err := dec.Token() // closing }
+	return err
+}
+
+func streamTerms(dec *json.Decoder, region string, projected map[string][]projectedRateKey, emit func(PriceEntry) error) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		termType, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if termType != "OnDemand" {
+			if err := skipValue(dec); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := expectDelim(dec, '{'); err != nil {
+			return err
+		}
+		for dec.More() {
+			skuTok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			keys, ok := projected[fmt.Sprint(skuTok)]
+			if !ok {
+				if err := skipValue(dec); err != nil {
+					return err
+				}
+				continue
+			}
+
+			var terms map[string]AWSTerm
+			if err := dec.Decode(&terms); err != nil {
+				return fmt.Errorf("failed to decode terms for %v: %w", skuTok, err)
+			}
+			for _, entry := range termsToEntries(terms, keys, region) {
+				if err := emit(entry); err != nil {
+					return err
+				}
+			}
+		}
+		if _, err := dec.Token(); err != nil { // closing }
+			return err
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// termsToEntries converts on-demand price dimensions into PriceEntry rows
+func termsToEntries(terms map[string]AWSTerm, keys []projectedRateKey, region string) []PriceEntry {
+	normalizer := NewAWSPricingAPINormalizer()
+	entries := make([]PriceEntry, 0)
+
+	for _, term := range terms {
+		var effective *time.Time
+		if t, err := time.Parse("2006-01-02T15:04:05Z", term.EffectiveDate); err == nil {
+			effective = &t
+		}
+
+		for _, dim := range term.PriceDimensions {
+			price, err := decimal.NewFromString(dim.PricePerUnit.USD)
+			if err != nil || price.IsZero() {
+				continue // Free tiers are implied by the next tier's minimum
+			}
+
+			var tierMin, tierMax *decimal.Decimal
+			if dim.BeginRange != "" && dim.BeginRange != "0" {
+				if d, err := decimal.NewFromString(dim.BeginRange); err == nil {
+					tierMin = &d
+				}
+			}
+			if dim.EndRange != "" && dim.EndRange != "Inf" {
+				if d, err := decimal.NewFromString(dim.EndRange); err == nil {
+					tierMax = &d
+				}
+			}
+
+			for _, key := range keys {
+				entries = append(entries, PriceEntry{
+					Service:       key.Service,
+					ProductFamily: key.ProductFamily,
+					Region:        region,
+					Attributes:    key.Attributes,
+					Unit:          normalizer.normalizeUnit(dim.Unit),
+					Price:         price,
+					Currency:      "USD",
+					Confidence:    1.0, // Direct from AWS Price List
+					TierMin:       tierMin,
+					TierMax:       tierMax,
+					EffectiveDate: effective,
+				})
+			}
+		}
+	}
+	return entries
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != want {
+		return fmt.Errorf("expected %q, got %v", want, tok)
+	}
+	return nil
+}
+
+// skipValue discards the next JSON value without materialising it
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if d, ok := tok.(json.Delim); ok {
+			switch d {
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// =============================================================================
+// RATE KEY PROJECTIONS
+// =============================================================================
+
+// awsProjection maps an offer product onto the rate key a billing mapper resolves
+// Rate keys are matched by exact attribute hash, so each projection must emit
+// exactly the attribute set (names and values) the corresponding mapper produces.
+type awsProjection func(p AWSProduct) (projectedRateKey, bool)
+
+var awsProjections = map[string][]awsProjection{
+	"AmazonEC2": {
+		projectEC2Instance,
+		projectEBSVolume,
+		projectNATGateway,
+		projectIdleEIP,
+	},
+	"AmazonRDS": {
+		projectRDSInstance,
+		projectRDSStorage,
+		projectRDSIOPS,
+	},
+	"AmazonS3": {
+		projectS3Storage,
+	},
+	"AWSLambda": {
+		projectLambda,
+	},
+	"AWSELB": {
+		projectLoadBalancer,
+	},
+	"AmazonVPC": {
+		projectVPCEndpoint,
+	},
+	"AmazonECS": {
+		projectFargate,
+	},
+}
+
+// projectAWSProduct returns every rate key a product contributes to
+func projectAWSProduct(service string, p AWSProduct) []projectedRateKey {
+	keys := make([]projectedRateKey, 0, 1)
+	for _, project := range awsProjections[service] {
+		if key, ok := project(p); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func projectEC2Instance(p AWSProduct) (projectedRateKey, bool) {
+	a := p.Attributes
+	if p.ProductFamily != "Compute Instance" || a["capacitystatus"] != "Used" ||
+		!strings.Contains(a["usagetype"], "BoxUsage") {
+		return projectedRateKey{}, false
+	}
+	return projectedRateKey{
+		Service:       "AmazonEC2",
+		ProductFamily: "Compute Instance",
+		Attributes: map[string]string{
+			"instanceType":    a["instanceType"],
+			"operatingSystem": a["operatingSystem"],
+			"tenancy":         a["tenancy"],
+			"preInstalledSw":  a["preInstalledSw"],
+			"capacityStatus":  a["capacitystatus"],
+			"licenseModel":    a["licenseModel"],
+		},
+	}, true
+}
+
+// projectEBSVolume emits the volume family key used for EC2 root/attached volumes
+func projectEBSVolume(p AWSProduct) (projectedRateKey, bool) {
+	a := p.Attributes
+	if p.ProductFamily != "Storage" || !strings.Contains(a["usagetype"], "EBS:VolumeUsage") {
+		return projectedRateKey{}, false
+	}
+	return projectedRateKey{
+		Service:       "AmazonEC2",
+		ProductFamily: "Storage",
+		Attributes:    map[string]string{"volumeType": a["volumeApiName"]},
+	}, true
+}
+
+func projectNATGateway(p AWSProduct) (projectedRateKey, bool) {
+	if p.ProductFamily != "NAT Gateway" {
+		return projectedRateKey{}, false
+	}
+	usage := p.Attributes["usagetype"]
+	if !strings.Contains(usage, "NatGateway-Hours") && !strings.Contains(usage, "NatGateway-Bytes") {
+		return projectedRateKey{}, false
+	}
+	return projectedRateKey{
+		Service:       "AmazonVPC",
+		ProductFamily: "NAT Gateway",
+		Attributes:    map[string]string{},
+	}, true
+}
+
+func projectIdleEIP(p AWSProduct) (projectedRateKey, bool) {
+	if p.ProductFamily != "IP Address" || !strings.Contains(p.Attributes["usagetype"], "IdleAddress") {
+		return projectedRateKey{}, false
+	}
+	return projectedRateKey{
+		Service:       "AmazonEC2",
+		ProductFamily: "IP Address",
+		Attributes:    map[string]string{},
+	}, true
+}
+
+// projectRDSInstance keys RDS instance hours by engine, edition and license model
+// Snapshots ingested before edition and license model joined the key have no
+// rates under it: RDS instances stay unpriced until AmazonRDS is re-ingested.
+func projectRDSInstance(p AWSProduct) (projectedRateKey, bool) {
+	a := p.Attributes
+	if p.ProductFamily != "Database Instance" || !strings.Contains(a["usagetype"], "Usage:db.") {
+		return projectedRateKey{}, false
+	}
+	return projectedRateKey{
+		Service:       "AmazonRDS",
+		ProductFamily: "Database Instance",
+		Attributes: map[string]string{
+			"instanceType":     a["instanceType"],
+			"databaseEngine":   a["databaseEngine"],
+			"databaseEdition":  a["databaseEdition"], // SQL Server, Oracle and Db2 editions; empty otherwise
+			"licenseModel":     a["licenseModel"],
+			"deploymentOption": a["deploymentOption"],
+		},
+	}, true
+}
+
+// rdsStorageTypes maps RDS storage usage types (RDS:GP2-Storage,
+// USE2-RDS:Multi-AZ-PIOPS-Storage, ...) to the storage_type they bill; io2
+// is checked before the io1 PIOPS usage types
+var rdsStorageTypes = []struct{ usage, storageType string }{
+	{"IO2", "io2"},
+	{"PIOPS-Storage", "io1"},
+	{"GP3-Storage", "gp3"},
+	{"GP2-Storage", "gp2"},
+	{"StorageUsage", "standard"}, // Magnetic
+}
+
+func projectRDSStorage(p AWSProduct) (projectedRateKey, bool) {
+	a := p.Attributes
+	if p.ProductFamily != "Database Storage" || !strings.Contains(a["usagetype"], "RDS:") {
+		return projectedRateKey{}, false // Aurora storage is billed per cluster
+	}
+	for _, st := range rdsStorageTypes {
+		if strings.Contains(a["usagetype"], st.usage) {
+			return projectedRateKey{
+				Service:       "AmazonRDS",
+				ProductFamily: "Database Storage",
+				Attributes:    map[string]string{"deploymentOption": a["deploymentOption"], "volumeType": st.storageType},
+			}, true
+		}
+	}
+	return projectedRateKey{}, false
+}
+
+// rdsIOPSTypes maps RDS provisioned IOPS usage types (RDS:PIOPS,
+// RDS:Multi-AZ-PIOPS-IO2, ...) to the storage_type they bill
+var rdsIOPSTypes = []struct{ usage, storageType string }{
+	{"IO2", "io2"},
+	{"GP3", "gp3"},
+	{"PIOPS", "io1"},
+}
+
+func projectRDSIOPS(p AWSProduct) (projectedRateKey, bool) {
+	a := p.Attributes
+	if p.ProductFamily != "Provisioned IOPS" || !strings.Contains(a["usagetype"], "RDS:") {
+		return projectedRateKey{}, false
+	}
+	for _, it := range rdsIOPSTypes {
+		if strings.Contains(a["usagetype"], it.usage) {
+			return projectedRateKey{
+				Service:       "AmazonRDS",
+				ProductFamily: "Provisioned IOPS",
+				Attributes:    map[string]string{"deploymentOption": a["deploymentOption"], "volumeType": it.storageType},
+			}, true
+		}
+	}
+	return projectedRateKey{}, false
+}
+
+// s3StorageClasses maps offer volumeType values to S3 API storage classes
+var s3StorageClasses = map[string]string{
+	"Standard":                            "STANDARD",
+	"Standard - Infrequent Access":        "STANDARD_IA",
+	"One Zone - Infrequent Access":        "ONEZONE_IA",
+	"Intelligent-Tiering Frequent Access": "INTELLIGENT_TIERING",
+	"Amazon Glacier":                      "GLACIER",
+	"Glacier Flexible Retrieval":          "GLACIER",
+	"Glacier Instant Retrieval":           "GLACIER_IR",
+	"Amazon Glacier Deep Archive":         "DEEP_ARCHIVE",
+	"Glacier Deep Archive":                "DEEP_ARCHIVE",
+}
+
+func projectS3Storage(p AWSProduct) (projectedRateKey, bool) {
+	if p.ProductFamily != "Storage" || !strings.Contains(p.Attributes["usagetype"], "TimedStorage") {
+		return projectedRateKey{}, false
+	}
+	class, ok := s3StorageClasses[p.Attributes["volumeType"]]
+	if !ok {
+		return projectedRateKey{}, false
+	}
+	return projectedRateKey{
+		Service:       "AmazonS3",
+		ProductFamily: "Storage",
+		Attributes:    map[string]string{"storageClass": class},
+	}, true
+}
+
+// projectLambda keys x86 request and duration prices by group
+func projectLambda(p AWSProduct) (projectedRateKey, bool) {
+	group := p.Attributes["group"]
+	if p.ProductFamily != "Serverless" || (group != "AWS-Lambda-Requests" && group != "AWS-Lambda-Duration") ||
+		strings.Contains(p.Attributes["usagetype"], "ARM") {
+		return projectedRateKey{}, false
+	}
+	return projectedRateKey{
+		Service:       "AWSLambda",
+		ProductFamily: "Serverless",
+		Attributes:    map[string]string{"group": group},
+	}, true
+}
+
+// projectFargate emits Linux Fargate vCPU, memory and ephemeral storage keys per architecture
+func projectFargate(p AWSProduct) (projectedRateKey, bool) {
+	usage := p.Attributes["usagetype"]
+	if !strings.Contains(usage, "Fargate-") || strings.Contains(usage, "Spot") ||
+		strings.Contains(usage, "Windows") {
+		return projectedRateKey{}, false
+	}
+
+	var resource string
+	switch {
+	case strings.Contains(usage, "EphemeralStorage-GB-Hours"):
+		resource = "ephemeralStorage"
+	case strings.Contains(usage, "vCPU-Hours"):
+		resource = "vCPU"
+	case strings.Contains(usage, "GB-Hours"):
+		resource = "memory"
+	default:
+		return projectedRateKey{}, false
+	}
+
+	arch := "x86_64"
+	if strings.Contains(usage, "-ARM-") {
+		arch = "ARM64"
+	}
+	return projectedRateKey{
+		Service:       "AmazonECS",
+		ProductFamily: "Compute",
+		Attributes:    map[string]string{"resource": resource, "cpuArchitecture": arch},
+	}, true
+}
+
+func projectLoadBalancer(p AWSProduct) (projectedRateKey, bool) {
+	if !strings.Contains(p.Attributes["usagetype"], "LoadBalancerUsage") {
+		return projectedRateKey{}, false
+	}
+	var lbType string
+	switch p.ProductFamily {
+	case "Load Balancer-Application":
+		lbType = "application"
+	case "Load Balancer-Network":
+		lbType = "network"
+	case "Load Balancer-Gateway":
+		lbType = "gateway"
+	default:
+		return projectedRateKey{}, false
+	}
+	return projectedRateKey{
+		Service:       "ElasticLoadBalancing",
+		ProductFamily: p.ProductFamily,
+		Attributes:    map[string]string{"loadBalancerType": lbType},
+	}, true
+}
+
+func projectVPCEndpoint(p AWSProduct) (projectedRateKey, bool) {
+	if p.ProductFamily != "VpcEndpoint" {
+		return projectedRateKey{}, false
+	}
+	return projectedRateKey{
+		Service:       "AmazonVPC",
+		ProductFamily: "VpcEndpoint",
+		Attributes:    map[string]string{"endpointType": p.Attributes["endpointType"]},
+	}, true
+}
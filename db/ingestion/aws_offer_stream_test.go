@@ -0,0 +1,135 @@
+// Package ingestion - Streaming AWS offer parser tests
+package ingestion
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleEC2Offer = `{
+  "formatVersion": "v1.0",
+  "publicationDate": "2024-01-01T00:00:00Z",
+  "products": {
+    "SKU1": {"sku": "SKU1", "productFamily": "Compute Instance", "attributes": {
+      "regionCode": "us-east-1", "instanceType": "t3.micro", "operatingSystem": "Linux",
+      "tenancy": "Shared", "preInstalledSw": "NA", "capacitystatus": "Used",
+      "licenseModel": "No License required", "usagetype": "BoxUsage:t3.micro"}},
+    "SKU2": {"sku": "SKU2", "productFamily": "Compute Instance", "attributes": {
+      "regionCode": "us-east-1", "instanceType": "t3.micro", "capacitystatus": "UnusedCapacityReservation",
+      "usagetype": "UnusedBox:t3.micro"}},
+    "SKU3": {"sku": "SKU3", "productFamily": "Storage", "attributes": {
+      "regionCode": "us-east-1", "volumeApiName": "gp3", "usagetype": "EBS:VolumeUsage.gp3"}},
+    "SKU4": {"sku": "SKU4", "productFamily": "Compute Instance", "attributes": {
+      "regionCode": "eu-west-1", "instanceType": "t3.micro", "capacitystatus": "Used",
+      "usagetype": "EU-BoxUsage:t3.micro"}}
+  },
+  "terms": {
+    "OnDemand": {
+      "SKU1": {"SKU1.JRTCKXETXF": {"sku": "SKU1", "effectiveDate": "2024-01-01T00:00:00Z", "priceDimensions": {
+        "SKU1.JRTCKXETXF.6YS6EN2CT7": {"unit": "Hrs", "beginRange": "0", "endRange": "Inf", "pricePerUnit": {"USD": "0.0104000000"}}}}},
+      "SKU2": {"SKU2.JRTCKXETXF": {"sku": "SKU2", "priceDimensions": {
+        "SKU2.JRTCKXETXF.6YS6EN2CT7": {"unit": "Hrs", "pricePerUnit": {"USD": "0.0104000000"}}}}},
+      "SKU3": {"SKU3.JRTCKXETXF": {"sku": "SKU3", "priceDimensions": {
+        "SKU3.JRTCKXETXF.6YS6EN2CT7": {"unit": "GB-Mo", "beginRange": "0", "endRange": "Inf", "pricePerUnit": {"USD": "0.0800000000"}}}}}
+    },
+    "Reserved": {
+      "SKU1": {"SKU1.4NA7Y494T4": {"priceDimensions": {}}}
+    }
+  }
+}`
+
+func TestParseAWSOfferStream(t *testing.T) {
+	var entries []PriceEntry
+	err := ParseAWSOfferStream(strings.NewReader(sampleEC2Offer), "AmazonEC2", "us-east-1", func(e PriceEntry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries (instance + gp3 volume), got %d: %+v", len(entries), entries)
+	}
+
+	byFamily := make(map[string]PriceEntry)
+	for _, e := range entries {
+		byFamily[e.ProductFamily] = e
+	}
+
+	compute := byFamily["Compute Instance"]
+	if compute.Unit != "hours" || compute.Price.String() != "0.0104" {
+		t.Errorf("unexpected compute entry: %+v", compute)
+	}
+	if compute.Attributes["capacityStatus"] != "Used" || compute.Attributes["instanceType"] != "t3.micro" {
+		t.Errorf("compute attributes should match EC2 mapper keys, got %v", compute.Attributes)
+	}
+	if compute.EffectiveDate == nil {
+		t.Error("expected effective date to be parsed")
+	}
+
+	storage := byFamily["Storage"]
+	if storage.Unit != "GB-month" || storage.Attributes["volumeType"] != "gp3" {
+		t.Errorf("unexpected storage entry: %+v", storage)
+	}
+}
+
+func TestParseAWSOfferStreamRejectsTermsFirst(t *testing.T) {
+	offer := `{"terms": {"OnDemand": {}}, "products": {}}`
+	err := ParseAWSOfferStream(strings.NewReader(offer), "AmazonEC2", "us-east-1", func(PriceEntry) error { return nil })
+	if err == nil {
+		t.Error("expected error when terms precede products")
+	}
+}
+
+func TestOffersHashStable(t *testing.T) {
+	refs := []AWSOfferRef{{Service: "AmazonEC2", URL: "https://example/AmazonEC2/20240101/us-east-1/index.json"}}
+	if OffersHash(refs) != OffersHash(refs) {
+		t.Error("hash should be deterministic")
+	}
+	changed := []AWSOfferRef{{Service: "AmazonEC2", URL: "https://example/AmazonEC2/20240201/us-east-1/index.json"}}
+	if OffersHash(refs) == OffersHash(changed) {
+		t.Error("hash should change with offer version")
+	}
+}
+
+func TestProjectRDSIOPS(t *testing.T) {
+	tests := []struct {
+		family, usageType, want string
+	}{
+		{"Provisioned IOPS", "RDS:PIOPS", "io1"},
+		{"Provisioned IOPS", "USE2-RDS:Multi-AZ-PIOPS", "io1"},
+		{"Provisioned IOPS", "RDS:PIOPS-IO2", "io2"},
+		{"Provisioned IOPS", "EBS:VolumeP-IOPS.piops", ""},
+		{"Database Storage", "RDS:PIOPS-Storage", ""},
+	}
+	for _, tt := range tests {
+		key, ok := projectRDSIOPS(AWSProduct{ProductFamily: tt.family, Attributes: map[string]string{
+			"usagetype": tt.usageType, "deploymentOption": "Single-AZ",
+		}})
+		if got := key.Attributes["volumeType"]; ok != (tt.want != "") || got != tt.want {
+			t.Errorf("%s = %q (%v), want %q", tt.usageType, got, ok, tt.want)
+		}
+	}
+}
+
+func TestProjectRDSStorage(t *testing.T) {
+	tests := []struct {
+		usageType, want string
+	}{
+		{"RDS:GP2-Storage", "gp2"},
+		{"USE2-RDS:GP3-Storage", "gp3"},
+		{"RDS:Multi-AZ-PIOPS-Storage", "io1"},
+		{"RDS:PIOPS-Storage-IO2", "io2"},
+		{"RDS:StorageUsage", "standard"},
+		{"Aurora:StorageUsage", ""},
+	}
+	for _, tt := range tests {
+		key, ok := projectRDSStorage(AWSProduct{ProductFamily: "Database Storage", Attributes: map[string]string{
+			"usagetype": tt.usageType, "deploymentOption": "Single-AZ",
+		}})
+		if got := key.Attributes["volumeType"]; ok != (tt.want != "") || got != tt.want {
+			t.Errorf("%s = %q (%v), want %q", tt.usageType, got, ok, tt.want)
+		}
+	}
+}
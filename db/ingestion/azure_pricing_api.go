@@ -6,11 +6,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
 
+	"github.com/shopspring/decimal"
+
 	"terraform-cost/db"
 )
 
@@ -207,7 +210,7 @@ func (c *AzurePricingAPIClient) FetchRegion(ctx context.Context, region string)
 
 		// Log progress
 		if len(allPrices)%10000 == 0 {
-			fmt.Printf("  Fetched %d Azure prices for %s...\n", len(allPrices), region)
+			slog.Info("fetching Azure prices", "region", region, "prices", len(allPrices))
 		}
 	}
 
@@ -252,7 +255,7 @@ func (c *AzurePricingAPIClient) fetchPage(ctx context.Context, pageURL string) (
 	var prices []RawPrice
 	for _, item := range response.Items {
 		// Skip zero-priced items and reservation pricing
-		if item.RetailPrice == 0 {
+		if item.RetailPrice.IsZero() {
 			continue
 		}
 
@@ -262,7 +265,7 @@ func (c *AzurePricingAPIClient) fetchPage(ctx context.Context, pageURL string) (
 			ProductFamily: item.ServiceFamily,
 			Region:        item.ArmRegionName,
 			Unit:          item.UnitOfMeasure,
-			PricePerUnit:  fmt.Sprintf("%.10f", item.RetailPrice),
+			PricePerUnit:  item.RetailPrice.String(),
 			Currency:      item.CurrencyCode,
 			Attributes:    c.buildAttributes(item),
 		}
@@ -335,8 +338,8 @@ type AzurePricingResponse struct {
 type AzurePriceItem struct {
 	CurrencyCode          string  `json:"currencyCode"`
 	TierMinimumUnits      float64 `json:"tierMinimumUnits"`
-	RetailPrice           float64 `json:"retailPrice"`
-	UnitPrice             float64 `json:"unitPrice"`
+	RetailPrice           decimal.Decimal `json:"retailPrice"` // Decoded exactly, not through float64
+	UnitPrice             decimal.Decimal `json:"unitPrice"`
 	ArmRegionName         string  `json:"armRegionName"`
 	Location              string  `json:"location"`
 	EffectiveStartDate    string  `json:"effectiveStartDate"`
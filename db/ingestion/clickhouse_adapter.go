@@ -5,21 +5,41 @@ package ingestion
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 
 	"terraform-cost/db/clickhouse"
 )
 
 // ClickHouseAdapter adapts the existing ingestion pipeline to ClickHouse
 type ClickHouseAdapter struct {
-	store *clickhouse.Store
+	store      *clickhouse.Store
+	batchSize  int
+	priceAlert *PriceAlert
 }
 
 // NewClickHouseAdapter creates a new ClickHouse adapter
 func NewClickHouseAdapter(store *clickhouse.Store) *ClickHouseAdapter {
-	return &ClickHouseAdapter{store: store}
+	return &ClickHouseAdapter{store: store, batchSize: 1000}
+}
+
+// WithBatchSize sets how many rates are buffered per bulk insert
+func (a *ClickHouseAdapter) WithBatchSize(size int) *ClickHouseAdapter {
+	if size > 0 {
+		a.batchSize = size
+	}
+	return a
+}
+
+// WithPriceAlert diffs each activated snapshot against the one it replaces
+// and posts increases above the alert's threshold
+func (a *ClickHouseAdapter) WithPriceAlert(alert *PriceAlert) *ClickHouseAdapter {
+	a.priceAlert = alert
+	return a
 }
 
 // IngestionResult tracks the result of a pricing ingestion
@@ -32,11 +52,33 @@ type IngestionResult struct {
 	Duration      time.Duration
 	Success       bool
 	ErrorMessage  string
+
+	// Set when a price alert is configured and a snapshot was replaced
+	PreviousSnapshotID uuid.UUID
+	PriceIncreases     []clickhouse.RateChange
+	AlertError         string // Alert failures do not fail the ingestion
 }
 
 // IngestPricing ingests pricing data into ClickHouse
 // This is the main entry point for the pricing pipeline
 func (a *ClickHouseAdapter) IngestPricing(ctx context.Context, input *IngestionInput) (*IngestionResult, error) {
+	return a.IngestStream(ctx, input, func(emit func(PriceEntry) error) error {
+		for _, p := range input.Prices {
+			if err := emit(p); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// PriceSource pushes price entries to emit until exhausted
+type PriceSource func(emit func(PriceEntry) error) error
+
+// IngestStream ingests prices produced by source in fixed-size batches
+// The snapshot is only activated once the source completes without error,
+// so a failed or interrupted stream never replaces the active pricing.
+func (a *ClickHouseAdapter) IngestStream(ctx context.Context, input *IngestionInput, source PriceSource) (*IngestionResult, error) {
 	startTime := time.Now()
 	result := &IngestionResult{
 		Cloud:  input.Cloud,
@@ -66,20 +108,28 @@ func (a *ClickHouseAdapter) IngestPricing(ctx context.Context, input *IngestionI
 
 	result.SnapshotID = snapshot.ID
 
-	// Batch insert rate keys and prices
-	batchSize := 1000
-	for i := 0; i < len(input.Prices); i += batchSize {
-		end := i + batchSize
-		if end > len(input.Prices) {
-			end = len(input.Prices)
-		}
+	// Rate keys are shared across snapshots; cache IDs to avoid a lookup per price
+	rateKeyIDs := make(map[string]uuid.UUID)
+	rates := make([]*clickhouse.PricingRate, 0, a.batchSize)
+	batches := 0
 
-		batch := input.Prices[i:end]
+	flush := func() error {
+		if len(rates) == 0 {
+			return nil
+		}
+		if err := a.store.BulkCreateRates(ctx, rates); err != nil {
+			return fmt.Errorf("failed to bulk insert rates at batch %d: %w", batches, err)
+		}
+		result.PriceCount += len(rates)
+		batches++
+		rates = rates[:0]
+		return nil
+	}
 
-		// Process batch
-		rates := make([]*clickhouse.PricingRate, 0, len(batch))
-		for _, p := range batch {
-			// Create or get rate key
+	emit := func(p PriceEntry) error {
+		cacheKey := rateKeyCacheKey(p)
+		rateKeyID, ok := rateKeyIDs[cacheKey]
+		if !ok {
 			rateKey := &clickhouse.RateKey{
 				ID:            uuid.New(),
 				Cloud:         clickhouse.CloudProvider(input.Cloud),
@@ -88,36 +138,48 @@ func (a *ClickHouseAdapter) IngestPricing(ctx context.Context, input *IngestionI
 				Region:        p.Region,
 				Attributes:    p.Attributes,
 			}
-
 			rateKeyResult, err := a.store.UpsertRateKey(ctx, rateKey)
 			if err != nil {
-				result.ErrorMessage = fmt.Sprintf("failed to upsert rate key at index %d: %v", i, err)
-				return result, err
+				return fmt.Errorf("failed to upsert rate key for %s/%s: %w", p.Service, p.ProductFamily, err)
 			}
+			rateKeyID = rateKeyResult.ID
+			rateKeyIDs[cacheKey] = rateKeyID
 			result.RateKeyCount++
+		}
 
-			// Create pricing rate
-			rate := &clickhouse.PricingRate{
-				ID:            uuid.New(),
-				SnapshotID:    snapshot.ID,
-				RateKeyID:     rateKeyResult.ID,
-				Unit:          p.Unit,
-				Price:         p.Price,
-				Currency:      p.Currency,
-				Confidence:    p.Confidence,
-				TierMin:       p.TierMin,
-				TierMax:       p.TierMax,
-				EffectiveDate: p.EffectiveDate,
-			}
-			rates = append(rates, rate)
+		rates = append(rates, &clickhouse.PricingRate{
+			ID:            uuid.New(),
+			SnapshotID:    snapshot.ID,
+			RateKeyID:     rateKeyID,
+			Unit:          p.Unit,
+			Price:         p.Price,
+			Currency:      p.Currency,
+			Confidence:    p.Confidence,
+			TierMin:       p.TierMin,
+			TierMax:       p.TierMax,
+			EffectiveDate: p.EffectiveDate,
+		})
+		if len(rates) >= a.batchSize {
+			return flush()
 		}
+		return nil
+	}
 
-		// Bulk insert rates
-		if err := a.store.BulkCreateRates(ctx, rates); err != nil {
-			result.ErrorMessage = fmt.Sprintf("failed to bulk insert rates at batch %d: %v", i/batchSize, err)
-			return result, err
+	if err := source(emit); err != nil {
+		result.ErrorMessage = err.Error()
+		return result, err
+	}
+	if err := flush(); err != nil {
+		result.ErrorMessage = err.Error()
+		return result, err
+	}
+
+	var previous *clickhouse.PricingSnapshot
+	if a.priceAlert != nil {
+		var err error
+		if previous, err = a.store.GetActiveSnapshot(ctx, snapshot.Cloud, snapshot.Region, snapshot.ProviderAlias); err != nil {
+			result.AlertError = err.Error()
 		}
-		result.PriceCount += len(rates)
 	}
 
 	// Activate snapshot
@@ -126,12 +188,39 @@ func (a *ClickHouseAdapter) IngestPricing(ctx context.Context, input *IngestionI
 		return result, err
 	}
 
+	if previous != nil && previous.ID != snapshot.ID {
+		result.PreviousSnapshotID = previous.ID
+		diff, err := a.store.DiffSnapshots(ctx, previous.ID, snapshot.ID)
+		if err == nil {
+			result.PriceIncreases, err = a.priceAlert.Check(ctx, diff)
+		}
+		if err != nil {
+			result.AlertError = err.Error()
+		}
+	}
+
 	result.Success = true
 	result.Duration = time.Since(startTime)
 
 	return result, nil
 }
 
+// rateKeyCacheKey identifies a rate key within a single ingestion
+func rateKeyCacheKey(p PriceEntry) string {
+	keys := make([]string, 0, len(p.Attributes))
+	for k := range p.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString(p.Service + "|" + p.ProductFamily + "|" + p.Region)
+	for _, k := range keys {
+		sb.WriteString("|" + k + "=" + p.Attributes[k])
+	}
+	return sb.String()
+}
+
 // IngestionInput contains the pricing data to ingest
 type IngestionInput struct {
 	Cloud     string
@@ -152,11 +241,11 @@ type PriceEntry struct {
 	Region        string
 	Attributes    map[string]string
 	Unit          string
-	Price         interface{} // decimal.Decimal or float64
+	Price         decimal.Decimal
 	Currency      string
 	Confidence    float64
-	TierMin       interface{} // *decimal.Decimal
-	TierMax       interface{} // *decimal.Decimal
+	TierMin       *decimal.Decimal
+	TierMax       *decimal.Decimal
 	EffectiveDate *time.Time
 }
 
@@ -0,0 +1,204 @@
+// Package ingestion - AWS Cost and Usage Report CSV reader
+// Reads legacy (lineItem/UsageStartDate) and CUR 2.0 (line_item_usage_start_date)
+// CSV exports, plain or gzipped, into line items for reconciliation.
+package ingestion
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+)
+
+// DefaultCURBatchSize is the number of line items handed to the callback at once
+const DefaultCURBatchSize = 10000
+
+// curColumns are the normalized names of the CUR columns read
+var curColumns = map[string]string{
+	"identitylineitemid":     "id",
+	"lineitemusagestartdate": "start",
+	"lineitemresourceid":     "resource",
+	"lineitemproductcode":    "product",
+	"lineitemusagetype":      "usage_type",
+	"lineitemlineitemtype":   "type",
+	"lineitemusageamount":    "amount",
+	"lineitemunblendedcost":  "cost",
+	"resourcetags":           "tags", // CUR 2.0: JSON map of user_<key> tags
+}
+
+// curTimeLayouts are the usage start formats of CUR exports
+var curTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05.000Z"}
+
+// ReadCURCSV streams a CUR CSV export in batches, returning the number of line items read
+func ReadCURCSV(r io.Reader, batchSize int, fn func([]clickhouse.CURLineItem) error) (int, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultCURBatchSize
+	}
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read gzipped CUR: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read CUR header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	tagColumns := make(map[int]string)
+	for i, name := range header {
+		if key, ok := curTagColumn(name); ok {
+			tagColumns[i] = key
+			continue
+		}
+		if col, ok := curColumns[normalizeCURColumn(name)]; ok {
+			columns[col] = i
+		}
+	}
+	for _, required := range []string{"start", "product", "type", "cost"} {
+		if _, ok := columns[required]; !ok {
+			return 0, fmt.Errorf("CUR file is missing the %s column", required)
+		}
+	}
+
+	batch := make([]clickhouse.CURLineItem, 0, batchSize)
+	total := 0
+	line := 1
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line++
+		if err != nil {
+			return total, fmt.Errorf("CUR line %d: %w", line, err)
+		}
+		item, err := parseCURRecord(record, columns, tagColumns)
+		if err != nil {
+			return total, fmt.Errorf("CUR line %d: %w", line, err)
+		}
+		batch = append(batch, item)
+		if len(batch) == batchSize {
+			if err := fn(batch); err != nil {
+				return total, err
+			}
+			total += len(batch)
+			batch = batch[:0]
+		}
+	}
+	if len(batch) > 0 {
+		if err := fn(batch); err != nil {
+			return total, err
+		}
+		total += len(batch)
+	}
+	return total, nil
+}
+
+func parseCURRecord(record []string, columns map[string]int, tagColumns map[int]string) (clickhouse.CURLineItem, error) {
+	field := func(col string) string {
+		if i, ok := columns[col]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	item := clickhouse.CURLineItem{
+		LineItemID:   field("id"),
+		ResourceID:   field("resource"),
+		ProductCode:  field("product"),
+		UsageType:    field("usage_type"),
+		LineItemType: field("type"),
+	}
+	start, err := parseCURTime(field("start"))
+	if err != nil {
+		return item, err
+	}
+	item.UsageStart = start
+	if amount := field("amount"); amount != "" {
+		if item.UsageAmount, err = strconv.ParseFloat(amount, 64); err != nil {
+			return item, fmt.Errorf("invalid usage amount %q", amount)
+		}
+	}
+	if item.UnblendedCost, err = decimal.NewFromString(field("cost")); err != nil {
+		return item, fmt.Errorf("invalid unblended cost %q", field("cost"))
+	}
+
+	item.Tags = make(map[string]string)
+	for i, key := range tagColumns {
+		if i < len(record) && record[i] != "" {
+			item.Tags[key] = record[i]
+		}
+	}
+	if raw := field("tags"); raw != "" {
+		var tags map[string]string
+		if err := json.Unmarshal([]byte(raw), &tags); err == nil {
+			for k, v := range tags {
+				if key, ok := strings.CutPrefix(k, "user_"); ok && v != "" {
+					item.Tags[key] = v
+				} else if key, ok := strings.CutPrefix(k, "user:"); ok && v != "" {
+					item.Tags[key] = v
+				}
+			}
+		}
+	}
+
+	// Exports without identity columns get a stable ID so re-imports replace rows
+	if item.LineItemID == "" {
+		sum := sha256.Sum256([]byte(strings.Join(record, "\x1f")))
+		item.LineItemID = hex.EncodeToString(sum[:16])
+	}
+	return item, nil
+}
+
+// normalizeCURColumn folds legacy (lineItem/UsageType) and CUR 2.0 (line_item_usage_type) names
+func normalizeCURColumn(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// curTagColumn returns the tag key of a legacy user tag column (resourceTags/user:Team)
+func curTagColumn(name string) (string, bool) {
+	for _, prefix := range []string{"resourceTags/user:", "resource_tags_user_"} {
+		if key, ok := strings.CutPrefix(name, prefix); ok && key != "" {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+func parseCURTime(value string) (time.Time, error) {
+	for _, layout := range curTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid usage start date %q", value)
+}
@@ -0,0 +1,63 @@
+package ingestion
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+
+	"terraform-cost/db/clickhouse"
+)
+
+func TestReadCURCSV(t *testing.T) {
+	legacy := `identity/LineItemId,lineItem/UsageStartDate,lineItem/ResourceId,lineItem/ProductCode,lineItem/UsageType,lineItem/LineItemType,lineItem/UsageAmount,lineItem/UnblendedCost,resourceTags/user:Team
+abc,2024-05-01T00:00:00Z,i-123,AmazonEC2,USE1-BoxUsage:t3.micro,Usage,1,0.0104,payments
+def,2024-05-01T01:00:00Z,,AmazonEC2,USE1-BoxUsage:t3.micro,Tax,0,0.5,
+`
+	cur2 := `line_item_usage_start_date,line_item_resource_id,line_item_product_code,line_item_usage_type,line_item_line_item_type,line_item_usage_amount,line_item_unblended_cost,resource_tags
+2024-05-01 00:00:00,logs,AmazonS3,USE1-TimedStorage-ByteHrs,Usage,3.2,0.07,"{""user_name"":""logs""}"
+`
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write([]byte(cur2))
+	w.Close()
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    int
+		checkFn func(t *testing.T, items []clickhouse.CURLineItem)
+	}{
+		{"legacy csv", []byte(legacy), 2, func(t *testing.T, items []clickhouse.CURLineItem) {
+			if it := items[0]; it.LineItemID != "abc" || it.ResourceID != "i-123" || it.Tags["Team"] != "payments" ||
+				it.UnblendedCost.String() != "0.0104" || it.UsageStart.Hour() != 0 {
+				t.Errorf("unexpected item: %+v", it)
+			}
+		}},
+		{"cur 2.0 gzip", gz.Bytes(), 1, func(t *testing.T, items []clickhouse.CURLineItem) {
+			if it := items[0]; it.LineItemID == "" || it.Tags["name"] != "logs" || it.UsageAmount != 3.2 {
+				t.Errorf("unexpected item: %+v", it)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var items []clickhouse.CURLineItem
+			n, err := ReadCURCSV(bytes.NewReader(tt.data), 1, func(batch []clickhouse.CURLineItem) error {
+				items = append(items, batch...)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != tt.want || len(items) != tt.want {
+				t.Fatalf("read %d items (%d in batches), want %d", n, len(items), tt.want)
+			}
+			tt.checkFn(t, items)
+		})
+	}
+
+	if _, err := ReadCURCSV(strings.NewReader("a,b\n1,2\n"), 0, nil); err == nil {
+		t.Error("expected an error for a file without CUR columns")
+	}
+}
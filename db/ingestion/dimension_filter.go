@@ -32,6 +32,8 @@ func (al *DimensionAllowlist) loadDefaults() {
 	al.Add(db.AWS, "AmazonEC2", "instance_type", true, 100)
 	al.Add(db.AWS, "AmazonEC2", "os", true, 90)
 	al.Add(db.AWS, "AmazonEC2", "tenancy", false, 80)
+	al.Add(db.AWS, "AmazonEC2", "software", false, 75) // SQL Server editions
+	al.Add(db.AWS, "AmazonEC2", "license", false, 65)  // BYOL
 	al.Add(db.AWS, "AmazonEC2", "volume_type", false, 70)
 	al.Add(db.AWS, "AmazonEC2", "capacity_status", false, 50)
 	al.Add(db.AWS, "AmazonEC2", "product_family", false, 60)
@@ -40,6 +42,7 @@ func (al *DimensionAllowlist) loadDefaults() {
 	al.Add(db.AWS, "AmazonRDS", "instance_type", true, 100)
 	al.Add(db.AWS, "AmazonRDS", "engine", true, 90)
 	al.Add(db.AWS, "AmazonRDS", "deployment", false, 70)
+	al.Add(db.AWS, "AmazonRDS", "edition", false, 65)
 	al.Add(db.AWS, "AmazonRDS", "license", false, 60)
 
 	// AWS Lambda
@@ -6,10 +6,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/shopspring/decimal"
+
 	"terraform-cost/db"
 )
 
@@ -211,7 +214,7 @@ func (c *GCPPricingAPIClient) FetchRegion(ctx context.Context, region string) ([
 		skus, err := c.fetchServiceSKUs(ctx, service.ServiceID, region)
 		if err != nil {
 			// Log but continue
-			fmt.Printf("Warning: failed to fetch SKUs for %s: %v\n", service.DisplayName, err)
+			slog.Warn("failed to fetch GCP SKUs", "service", service.DisplayName, "region", region, "error", err)
 			continue
 		}
 
@@ -337,11 +340,8 @@ func (c *GCPPricingAPIClient) skuToPrices(sku GCPSKU, region string) []RawPrice
 
 	for _, pricingInfo := range sku.PricingInfo {
 		for _, tierRate := range pricingInfo.PricingExpression.TieredRates {
-			// Calculate unit price in USD
-			unitPrice := float64(tierRate.UnitPrice.Units) +
-				float64(tierRate.UnitPrice.Nanos)/1e9
-
-			if unitPrice == 0 {
+			unitPrice := tierRate.UnitPrice.Decimal()
+			if unitPrice.IsZero() {
 				continue // Skip free tiers
 			}
 
@@ -351,7 +351,7 @@ func (c *GCPPricingAPIClient) skuToPrices(sku GCPSKU, region string) []RawPrice
 				ProductFamily: sku.Category.ResourceFamily,
 				Region:        region,
 				Unit:          pricingInfo.PricingExpression.UsageUnit,
-				PricePerUnit:  fmt.Sprintf("%.10f", unitPrice),
+				PricePerUnit:  unitPrice.String(),
 				Currency:      tierRate.UnitPrice.CurrencyCode,
 				Attributes:    c.buildSKUAttributes(sku),
 			}
@@ -463,6 +463,11 @@ type GCPMoney struct {
 	Nanos        int32  `json:"nanos"`
 }
 
+// Decimal returns the amount exactly: units plus nanos billionths
+func (m GCPMoney) Decimal() decimal.Decimal {
+	return decimal.New(m.Units, 0).Add(decimal.New(int64(m.Nanos), -9))
+}
+
 // GCPPricingNormalizer normalizes raw GCP pricing to canonical format
 type GCPPricingNormalizer struct{}
 
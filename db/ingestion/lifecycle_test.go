@@ -59,7 +59,7 @@ func TestProductionGuards(t *testing.T) {
 
 	lifecycle := &Lifecycle{
 		config: config,
-		state:  &IngestionState{},
+		state:  &LifecycleState{},
 	}
 
 	err := lifecycle.enforceProductionGuards()
@@ -95,8 +95,8 @@ func TestDefaultLifecycleConfig(t *testing.T) {
 	}
 }
 
-func TestIngestionStateInitialization(t *testing.T) {
-	state := &IngestionState{
+func TestLifecycleStateInitialization(t *testing.T) {
+	state := &LifecycleState{
 		Phase: PhaseInit,
 	}
 
@@ -115,7 +115,7 @@ func TestIngestionStateInitialization(t *testing.T) {
 
 func TestLifecycleFailure(t *testing.T) {
 	lifecycle := &Lifecycle{
-		state: &IngestionState{
+		state: &LifecycleState{
 			Phase:     PhaseValidating,
 			StartTime: time.Now(),
 		},
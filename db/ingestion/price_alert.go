@@ -0,0 +1,105 @@
+// Package ingestion - Price increase alerting
+// After a snapshot is activated it is diffed against the one it replaced, and
+// rate increases above a threshold are posted to a webhook as JSON.
+package ingestion
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"terraform-cost/db/clickhouse"
+)
+
+// maxAlertIncreases bounds the rate changes listed in one alert
+const maxAlertIncreases = 25
+
+// PriceAlert posts increases above a threshold percentage to a webhook
+type PriceAlert struct {
+	webhookURL   string
+	thresholdPct float64
+	client       *http.Client
+}
+
+// NewPriceAlert creates an alert for increases strictly above thresholdPct percent
+func NewPriceAlert(webhookURL string, thresholdPct float64) *PriceAlert {
+	return &PriceAlert{
+		webhookURL:   webhookURL,
+		thresholdPct: thresholdPct,
+		client:       &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// PriceAlertPayload is the JSON body posted to the webhook
+type PriceAlertPayload struct {
+	Event            string                   `json:"event"` // pricing.price_increase
+	Text             string                   `json:"text"`  // One-line summary; rendered by Slack-compatible webhooks
+	Cloud            string                   `json:"cloud"`
+	Region           string                   `json:"region"`
+	FromSnapshot     uuid.UUID                `json:"from_snapshot"`
+	ToSnapshot       uuid.UUID                `json:"to_snapshot"`
+	ThresholdPercent float64                  `json:"threshold_percent"`
+	IncreaseCount    int                      `json:"increase_count"`
+	Increases        []clickhouse.RateChange  `json:"increases"` // Largest first, at most 25
+	Services         []clickhouse.ServiceDiff `json:"services"`
+}
+
+// Check posts the diff's increases above the threshold, returning them
+// Nothing is sent when no rate rose by more than the threshold.
+func (a *PriceAlert) Check(ctx context.Context, diff *clickhouse.SnapshotDiff) ([]clickhouse.RateChange, error) {
+	increases := diff.Increases(a.thresholdPct)
+	if len(increases) == 0 {
+		return nil, nil
+	}
+
+	payload := PriceAlertPayload{
+		Event:            "pricing.price_increase",
+		Cloud:            string(diff.To.Cloud),
+		Region:           diff.To.Region,
+		FromSnapshot:     diff.From.ID,
+		ToSnapshot:       diff.To.ID,
+		ThresholdPercent: a.thresholdPct,
+		IncreaseCount:    len(increases),
+		Increases:        increases,
+	}
+	if len(payload.Increases) > maxAlertIncreases {
+		payload.Increases = payload.Increases[:maxAlertIncreases]
+	}
+	var services []string
+	for _, s := range diff.Services {
+		if s.MaxIncreasePercent > a.thresholdPct {
+			payload.Services = append(payload.Services, s)
+			services = append(services, fmt.Sprintf("%s (up to %+.1f%%)", s.Service, s.MaxIncreasePercent))
+		}
+	}
+	payload.Text = fmt.Sprintf("⚠️ %d %s/%s prices rose more than %.1f%%: %s",
+		len(increases), payload.Cloud, payload.Region, a.thresholdPct, strings.Join(services, ", "))
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return increases, fmt.Errorf("failed to encode price alert: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return increases, fmt.Errorf("failed to create price alert request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return increases, fmt.Errorf("failed to send price alert: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return increases, fmt.Errorf("price alert webhook returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return increases, nil
+}
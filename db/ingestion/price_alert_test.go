@@ -0,0 +1,63 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+)
+
+func TestPriceAlertCheck(t *testing.T) {
+	var received []PriceAlertPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p PriceAlertPayload
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+		received = append(received, p)
+	}))
+	defer srv.Close()
+
+	old, up, down := decimal.NewFromInt(10), decimal.NewFromInt(12), decimal.NewFromInt(9)
+	diff := &clickhouse.SnapshotDiff{
+		From: &clickhouse.PricingSnapshot{ID: uuid.New(), Cloud: clickhouse.AWS, Region: "us-east-1"},
+		To:   &clickhouse.PricingSnapshot{ID: uuid.New(), Cloud: clickhouse.AWS, Region: "us-east-1"},
+		Changed: []clickhouse.RateChange{
+			{Kind: clickhouse.RateChanged, Service: "AmazonEC2", OldPrice: &old, NewPrice: &up, ChangePercent: 20},
+			{Kind: clickhouse.RateChanged, Service: "AmazonRDS", OldPrice: &old, NewPrice: &down, ChangePercent: -10},
+		},
+		Services: []clickhouse.ServiceDiff{
+			{Service: "AmazonEC2", Increased: 1, MaxIncreasePercent: 20},
+			{Service: "AmazonRDS", Decreased: 1},
+		},
+	}
+
+	increases, err := NewPriceAlert(srv.URL, 25).Check(context.Background(), diff)
+	if err != nil || len(increases) != 0 || len(received) != 0 {
+		t.Fatalf("no alert expected below the threshold: %v, %v, %d sent", increases, err, len(received))
+	}
+
+	increases, err = NewPriceAlert(srv.URL, 5).Check(context.Background(), diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(increases) != 1 || len(received) != 1 {
+		t.Fatalf("expected one increase alerted, got %d increases, %d sent", len(increases), len(received))
+	}
+	if p := received[0]; p.Event != "pricing.price_increase" || p.IncreaseCount != 1 || len(p.Services) != 1 || p.Text == "" {
+		t.Errorf("unexpected payload: %+v", p)
+	}
+
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusBadGateway)
+	})
+	if _, err := NewPriceAlert(srv.URL, 5).Check(context.Background(), diff); err == nil {
+		t.Error("expected an error from a failing webhook")
+	}
+}
@@ -0,0 +1,97 @@
+// Package ingestion - Azure and GCP pricing API tests
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestAzurePricesDecodeExactly(t *testing.T) {
+	// Sub-nano meters lose digits through float64 and %.10f
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Items": [
+			{"currencyCode": "USD", "retailPrice": 0.00000000041, "skuId": "a", "serviceName": "Storage", "armRegionName": "eastus", "unitOfMeasure": "1 GB"},
+			{"currencyCode": "USD", "retailPrice": 1234567.123456789012, "skuId": "b", "serviceName": "Compute", "armRegionName": "eastus", "unitOfMeasure": "1 Hour"},
+			{"currencyCode": "USD", "retailPrice": 0, "skuId": "c", "serviceName": "Compute", "armRegionName": "eastus", "unitOfMeasure": "1 Hour"}
+		]}`))
+	}))
+	defer srv.Close()
+
+	raw, _, err := NewAzurePricingAPIClient(nil).fetchPage(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rates, err := NewAzurePricingNormalizer().Normalize(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"0.00000000041", "1234567.123456789012"}
+	if len(rates) != len(want) {
+		t.Fatalf("got %d rates, want %d (zero prices skipped)", len(rates), len(want))
+	}
+	for i, rate := range rates {
+		if got := rate.Price.String(); got != want[i] {
+			t.Errorf("rate %d price = %s, want %s", i, got, want[i])
+		}
+	}
+	assertRateRoundTrip(t, rates)
+}
+
+func TestGCPMoneyDecimal(t *testing.T) {
+	money := GCPMoney{CurrencyCode: "USD", Units: 2, Nanos: 410}
+	if got := money.Decimal().String(); got != "2.00000041" {
+		t.Errorf("Decimal() = %s, want 2.00000041", got)
+	}
+
+	// A large plan sums many small rates without drift
+	sum := decimal.Zero
+	for i := 0; i < 100000; i++ {
+		sum = sum.Add(GCPMoney{Nanos: 100}.Decimal())
+	}
+	if !sum.Equal(decimal.RequireFromString("0.01")) {
+		t.Errorf("sum = %s, want 0.01", sum)
+	}
+
+	sku := GCPSKU{
+		SkuId:    "sku",
+		Category: GCPCategory{ServiceDisplayName: "Compute Engine"},
+		PricingInfo: []GCPPricingInfo{{PricingExpression: GCPPricingExpression{
+			UsageUnit: "h",
+			TieredRates: []GCPTieredRate{
+				{UnitPrice: GCPMoney{CurrencyCode: "USD"}},
+				{StartUsageAmount: 10, UnitPrice: GCPMoney{CurrencyCode: "USD", Units: 0, Nanos: 31611}},
+			},
+		}}},
+	}
+	rates, err := (&GCPPricingNormalizer{}).Normalize((&GCPPricingAPIClient{}).skuToPrices(sku, "us-central1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 1 || rates[0].Price.String() != "0.000031611" {
+		t.Fatalf("rates = %+v, want one rate of 0.000031611 (free tier skipped)", rates)
+	}
+	assertRateRoundTrip(t, rates)
+}
+
+// assertRateRoundTrip checks rates serialize and deserialize without losing digits
+func assertRateRoundTrip(t *testing.T, rates []NormalizedRate) {
+	t.Helper()
+	data, err := json.Marshal(rates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []NormalizedRate
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	for i := range rates {
+		if !decoded[i].Price.Equal(rates[i].Price) {
+			t.Errorf("rate %d round-tripped to %s, want %s", i, decoded[i].Price, rates[i].Price)
+		}
+	}
+}
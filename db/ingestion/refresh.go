@@ -0,0 +1,67 @@
+// Package ingestion - AWS pricing refresh
+// A refresh resolves a region's current offer files and ingests them only when
+// their content hash differs from the active snapshot's. The new snapshot is
+// activated once all its rates are written, so estimates never see a partial one.
+package ingestion
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"terraform-cost/db/clickhouse"
+)
+
+// RefreshResult is the outcome of refreshing one region
+type RefreshResult struct {
+	Cloud      string           `json:"cloud"`
+	Region     string           `json:"region"`
+	Hash       string           `json:"hash"`
+	UpToDate   bool             `json:"up_to_date"` // The active snapshot already has this content
+	SnapshotID uuid.UUID        `json:"snapshot_id"`
+	Ingestion  *IngestionResult `json:"-"` // nil when up to date
+}
+
+// RefreshAWSRegion ingests a region's AWS pricing into a new active snapshot,
+// unless the active snapshot already has the current offers and force is false
+func RefreshAWSRegion(ctx context.Context, streamer *AWSOfferStreamer, adapter *ClickHouseAdapter, region string, force bool) (*RefreshResult, error) {
+	refs, err := streamer.ResolveOffers(ctx, region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve offers for %s: %w", region, err)
+	}
+	result := &RefreshResult{Cloud: "aws", Region: region, Hash: OffersHash(refs)}
+
+	if !force {
+		existing, err := adapter.store.FindSnapshotByHash(ctx, clickhouse.AWS, region, "default", result.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil && existing.IsActive {
+			result.UpToDate = true
+			result.SnapshotID = existing.ID
+			return result, nil
+		}
+	}
+
+	now := time.Now().UTC()
+	input := &IngestionInput{
+		Cloud:     "aws",
+		Region:    region,
+		Alias:     "default",
+		Source:    "aws_price_list_api",
+		FetchedAt: now,
+		ValidFrom: now,
+		Hash:      result.Hash,
+	}
+	ingested, err := adapter.IngestStream(ctx, input, func(emit func(PriceEntry) error) error {
+		return streamer.StreamOffers(ctx, refs, emit)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pricing ingestion failed for %s: %w", region, err)
+	}
+	result.SnapshotID = ingested.SnapshotID
+	result.Ingestion = ingested
+	return result, nil
+}
@@ -0,0 +1,61 @@
+// Package ingestion - Spot price history import
+// Reads `aws ec2 describe-spot-price-history --output json` output
+package ingestion
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+)
+
+// SpotPriceHistorySource identifies imported spot price history
+const SpotPriceHistorySource = "aws_spot_price_history"
+
+// awsSpotPriceHistory mirrors the DescribeSpotPriceHistory response
+type awsSpotPriceHistory struct {
+	SpotPriceHistory []struct {
+		AvailabilityZone   string `json:"AvailabilityZone"`
+		InstanceType       string `json:"InstanceType"`
+		ProductDescription string `json:"ProductDescription"`
+		SpotPrice          string `json:"SpotPrice"`
+		Timestamp          string `json:"Timestamp"`
+	} `json:"SpotPriceHistory"`
+}
+
+// ParseSpotPriceHistory decodes DescribeSpotPriceHistory JSON for a region
+func ParseSpotPriceHistory(r io.Reader, region string) ([]*clickhouse.SpotPrice, error) {
+	var history awsSpotPriceHistory
+	if err := json.NewDecoder(r).Decode(&history); err != nil {
+		return nil, fmt.Errorf("failed to decode spot price history: %w", err)
+	}
+
+	prices := make([]*clickhouse.SpotPrice, 0, len(history.SpotPriceHistory))
+	for i, h := range history.SpotPriceHistory {
+		price, err := decimal.NewFromString(h.SpotPrice)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: invalid spot price %q: %w", i, h.SpotPrice, err)
+		}
+		observedAt, err := time.Parse(time.RFC3339, h.Timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: invalid timestamp %q: %w", i, h.Timestamp, err)
+		}
+
+		prices = append(prices, &clickhouse.SpotPrice{
+			Cloud:              clickhouse.AWS,
+			Region:             region,
+			AvailabilityZone:   h.AvailabilityZone,
+			InstanceType:       h.InstanceType,
+			ProductDescription: h.ProductDescription,
+			Price:              price,
+			Currency:           "USD",
+			ObservedAt:         observedAt.UTC(),
+			Source:             SpotPriceHistorySource,
+		})
+	}
+	return prices, nil
+}
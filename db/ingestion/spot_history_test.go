@@ -0,0 +1,29 @@
+package ingestion
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSpotPriceHistory(t *testing.T) {
+	input := `{"SpotPriceHistory": [
+		{"AvailabilityZone": "us-east-1a", "InstanceType": "m5.large", "ProductDescription": "Linux/UNIX", "SpotPrice": "0.034100", "Timestamp": "2024-05-01T10:00:00+00:00"},
+		{"AvailabilityZone": "us-east-1b", "InstanceType": "m5.large", "ProductDescription": "Linux/UNIX", "SpotPrice": "0.036200", "Timestamp": "2024-05-01T11:00:00.000Z"}
+	]}`
+
+	prices, err := ParseSpotPriceHistory(strings.NewReader(input), "us-east-1")
+	if err != nil {
+		t.Fatalf("ParseSpotPriceHistory: %v", err)
+	}
+	if len(prices) != 2 {
+		t.Fatalf("expected 2 prices, got %d", len(prices))
+	}
+	if prices[0].Price.String() != "0.0341" || prices[0].AvailabilityZone != "us-east-1a" || prices[0].Region != "us-east-1" {
+		t.Errorf("unexpected first price: %+v", prices[0])
+	}
+
+	_, err = ParseSpotPriceHistory(strings.NewReader(`{"SpotPriceHistory": [{"SpotPrice": "n/a", "Timestamp": "2024-05-01T10:00:00Z"}]}`), "us-east-1")
+	if err == nil {
+		t.Error("expected error for invalid price")
+	}
+}
@@ -0,0 +1,346 @@
+// Package memory provides an in-memory pricing store loaded from fixtures
+// Fixture rates make integration tests and demo environments hermetic: no
+// database, no bundle export, just the handful of prices a plan needs.
+//
+// A fixture rate matches a lookup of its cloud, region, service and alias when
+// its attributes are a subset of the lookup's; product family and unit match
+// only when set. The most specific matching rate wins. Fixtures are timeless,
+// so dated lookups get the same prices.
+package memory
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+)
+
+// SnapshotID identifies fixture pricing in estimate audit trails
+var SnapshotID = uuid.NewSHA1(uuid.NameSpaceURL, []byte("terracost:pricing-fixtures"))
+
+// Source is the pricing source reported for fixture rates
+const Source = "fixture"
+
+// Rate is one fixture price
+type Rate struct {
+	Cloud         string            `json:"cloud"`
+	Region        string            `json:"region"`
+	Service       string            `json:"service"`
+	ProductFamily string            `json:"product_family,omitempty"`
+	Attributes    map[string]string `json:"attributes,omitempty"`
+	Unit          string            `json:"unit,omitempty"`
+	Alias         string            `json:"alias,omitempty"` // Default "default"
+	Price         decimal.Decimal   `json:"price"`
+	Currency      string            `json:"currency,omitempty"` // Default USD
+}
+
+// SpotRate is one fixture spot price
+type SpotRate struct {
+	Cloud              string          `json:"cloud"`
+	Region             string          `json:"region"`
+	InstanceType       string          `json:"instance_type"`
+	ProductDescription string          `json:"product_description,omitempty"` // Empty matches every OS
+	P50                decimal.Decimal `json:"p50"`
+	P90                decimal.Decimal `json:"p90"`
+	Samples            int             `json:"samples,omitempty"` // Default 100
+	Currency           string          `json:"currency,omitempty"`
+}
+
+// Fixtures is the JSON fixture file layout
+type Fixtures struct {
+	Rates     []Rate     `json:"rates"`
+	SpotRates []SpotRate `json:"spot_rates,omitempty"`
+}
+
+// Store serves fixture rates
+type Store struct {
+	mu    sync.RWMutex
+	rates []Rate
+	spot  []SpotRate
+}
+
+// New creates a store with rates
+func New(rates ...Rate) *Store {
+	return &Store{rates: rates}
+}
+
+// Load reads fixture files: .json (Fixtures) or .csv (see ParseCSV)
+func Load(paths ...string) (*Store, error) {
+	s := New()
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open pricing fixtures: %w", err)
+		}
+		var fixtures *Fixtures
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".json":
+			fixtures, err = ParseJSON(f)
+		case ".csv":
+			var rates []Rate
+			rates, err = ParseCSV(f)
+			fixtures = &Fixtures{Rates: rates}
+		default:
+			err = fmt.Errorf("unsupported format (expected .json or .csv)")
+		}
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("pricing fixtures %s: %w", path, err)
+		}
+		s.Add(fixtures.Rates...)
+		s.AddSpot(fixtures.SpotRates...)
+	}
+	return s, nil
+}
+
+// ParseJSON reads fixtures in the Fixtures layout
+func ParseJSON(r io.Reader) (*Fixtures, error) {
+	var fixtures Fixtures
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&fixtures); err != nil {
+		return nil, err
+	}
+	for i, rate := range fixtures.Rates {
+		if err := rate.validate(); err != nil {
+			return nil, fmt.Errorf("rate %d: %w", i, err)
+		}
+	}
+	for i, rate := range fixtures.SpotRates {
+		if rate.Cloud == "" || rate.Region == "" || rate.InstanceType == "" {
+			return nil, fmt.Errorf("spot rate %d: cloud, region and instance_type are required", i)
+		}
+	}
+	return &fixtures, nil
+}
+
+// ParseCSV reads rates from a CSV file with a header row. The columns cloud,
+// region, service and price are required; product_family, unit, alias and
+// currency are optional; any other column is an attribute, skipped when empty.
+//
+//	cloud,region,service,product_family,unit,price,instanceType
+//	aws,us-east-1,AmazonEC2,Compute Instance,Hrs,0.0104,t3.micro
+func ParseCSV(r io.Reader) ([]Rate, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("missing header row")
+	}
+	header := records[0]
+	for _, required := range []string{"cloud", "region", "service", "price"} {
+		found := false
+		for _, col := range header {
+			found = found || col == required
+		}
+		if !found {
+			return nil, fmt.Errorf("missing %s column", required)
+		}
+	}
+
+	rates := make([]Rate, 0, len(records)-1)
+	for line, record := range records[1:] {
+		rate := Rate{Attributes: make(map[string]string)}
+		for i, col := range header {
+			value := strings.TrimSpace(record[i])
+			switch col {
+			case "cloud":
+				rate.Cloud = value
+			case "region":
+				rate.Region = value
+			case "service":
+				rate.Service = value
+			case "product_family":
+				rate.ProductFamily = value
+			case "unit":
+				rate.Unit = value
+			case "alias":
+				rate.Alias = value
+			case "currency":
+				rate.Currency = value
+			case "price":
+				if rate.Price, err = decimal.NewFromString(value); err != nil {
+					return nil, fmt.Errorf("line %d: invalid price %q", line+2, value)
+				}
+			default:
+				if value != "" {
+					rate.Attributes[col] = value
+				}
+			}
+		}
+		if err := rate.validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line+2, err)
+		}
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
+
+// validate checks a rate has what matching needs
+func (r Rate) validate() error {
+	if r.Cloud == "" || r.Region == "" || r.Service == "" {
+		return fmt.Errorf("cloud, region and service are required")
+	}
+	if r.Price.IsNegative() {
+		return fmt.Errorf("price must not be negative")
+	}
+	return nil
+}
+
+// Add adds rates; later rates win ties with earlier ones
+func (s *Store) Add(rates ...Rate) *Store {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rates = append(s.rates, rates...)
+	return s
+}
+
+// AddSpot adds spot rates
+func (s *Store) AddSpot(rates ...SpotRate) *Store {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.spot = append(s.spot, rates...)
+	return s
+}
+
+// Len returns the number of rates and spot rates
+func (s *Store) Len() (rates, spot int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.rates), len(s.spot)
+}
+
+// ResolveRate resolves one rate
+func (s *Store) ResolveRate(ctx context.Context, cloud clickhouse.CloudProvider, service, productFamily, region string, attrs map[string]string, unit, alias string) (*clickhouse.ResolvedRate, error) {
+	return s.match(clickhouse.RateLookup{
+		Cloud:         cloud,
+		Service:       service,
+		ProductFamily: productFamily,
+		Region:        region,
+		Attributes:    attrs,
+		Unit:          unit,
+		Alias:         alias,
+	}), nil
+}
+
+// ResolveRatesBatch resolves lookups, keyed by RateLookup.Key
+func (s *Store) ResolveRatesBatch(ctx context.Context, lookups []clickhouse.RateLookup) (map[string]*clickhouse.ResolvedRate, error) {
+	rates := make(map[string]*clickhouse.ResolvedRate, len(lookups))
+	for _, l := range lookups {
+		rates[l.Key()] = s.match(l)
+	}
+	return rates, nil
+}
+
+// ResolveSpotRate returns the fixture spot rate of an instance type, if any
+func (s *Store) ResolveSpotRate(ctx context.Context, cloud clickhouse.CloudProvider, region, instanceType, productDescription string, lookback time.Duration, at time.Time) (*clickhouse.SpotRate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for i := len(s.spot) - 1; i >= 0; i-- {
+		r := s.spot[i]
+		if r.Cloud != string(cloud) || r.Region != region || r.InstanceType != instanceType ||
+			(r.ProductDescription != "" && r.ProductDescription != productDescription) {
+			continue
+		}
+		samples := r.Samples
+		if samples <= 0 {
+			samples = 100
+		}
+		to := at
+		if to.IsZero() {
+			to = time.Now().UTC()
+		}
+		return &clickhouse.SpotRate{
+			Average:  r.P50,
+			P50:      r.P50,
+			P90:      r.P90,
+			Max:      r.P90,
+			Currency: currencyOr(r.Currency),
+			Samples:  samples,
+			From:     to.Add(-lookback),
+			To:       to,
+		}, nil
+	}
+	return nil, nil
+}
+
+// match returns the most specific rate matching a lookup
+func (s *Store) match(l clickhouse.RateLookup) *clickhouse.ResolvedRate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var best *Rate
+	bestScore := -1
+	for i := range s.rates {
+		r := &s.rates[i]
+		score, ok := r.matches(l)
+		if ok && score >= bestScore {
+			best, bestScore = r, score
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	return &clickhouse.ResolvedRate{
+		Price:      best.Price,
+		Currency:   currencyOr(best.Currency),
+		Confidence: 1,
+		SnapshotID: SnapshotID,
+		Source:     Source,
+	}
+}
+
+// matches reports whether a rate applies to a lookup and how specific it is
+func (r *Rate) matches(l clickhouse.RateLookup) (int, bool) {
+	if r.Cloud != string(l.Cloud) || r.Region != l.Region || r.Service != l.Service || aliasOr(r.Alias) != aliasOr(l.Alias) {
+		return 0, false
+	}
+	score := 0
+	if r.ProductFamily != "" {
+		if r.ProductFamily != l.ProductFamily {
+			return 0, false
+		}
+		score++
+	}
+	if r.Unit != "" {
+		if r.Unit != l.Unit {
+			return 0, false
+		}
+		score++
+	}
+	for k, v := range r.Attributes {
+		if l.Attributes[k] != v {
+			return 0, false
+		}
+		score++
+	}
+	return score, true
+}
+
+// aliasOr defaults a pricing alias to "default", as snapshots do
+func aliasOr(alias string) string {
+	if alias == "" {
+		return "default"
+	}
+	return alias
+}
+
+// currencyOr defaults a fixture currency to USD
+func currencyOr(currency string) string {
+	if currency == "" {
+		return "USD"
+	}
+	return currency
+}
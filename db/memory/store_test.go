@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+)
+
+func m5Lookup(instanceType string) clickhouse.RateLookup {
+	return clickhouse.RateLookup{
+		Cloud:         clickhouse.AWS,
+		Service:       "AmazonEC2",
+		ProductFamily: "Compute Instance",
+		Region:        "us-east-1",
+		Attributes:    map[string]string{"instanceType": instanceType, "operatingSystem": "Linux", "tenancy": "Shared"},
+		Unit:          "hours",
+		Alias:         "default",
+	}
+}
+
+func TestLoadFixtures(t *testing.T) {
+	for _, path := range []string{"testdata/prices.json", "testdata/prices.csv"} {
+		s, err := Load(path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		rates, err := s.ResolveRatesBatch(context.Background(), []clickhouse.RateLookup{m5Lookup("m5.large"), m5Lookup("c5.large")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rate := rates[m5Lookup("m5.large").Key()]; rate == nil || !rate.Price.Equal(decimal.RequireFromString("0.096")) || rate.Source != Source {
+			t.Errorf("%s: m5.large = %+v", path, rate)
+		}
+		if rate, ok := rates[m5Lookup("c5.large").Key()]; !ok || rate != nil {
+			t.Errorf("%s: c5.large should have no rate, got %+v", path, rate)
+		}
+	}
+
+	s, _ := Load("testdata/prices.json")
+	spot, _ := s.ResolveSpotRate(context.Background(), clickhouse.AWS, "us-east-1", "m5.large", "Linux/UNIX", 0, m5Lookup("").At)
+	if spot == nil || spot.P50.String() != "0.035" {
+		t.Errorf("spot = %+v", spot)
+	}
+}
+
+func TestMostSpecificRateWins(t *testing.T) {
+	s := New(
+		Rate{Cloud: "aws", Region: "us-east-1", Service: "AmazonEC2", Price: decimal.NewFromInt(1)},
+		Rate{Cloud: "aws", Region: "us-east-1", Service: "AmazonEC2", Attributes: map[string]string{"instanceType": "m5.large"}, Price: decimal.NewFromInt(2)},
+		Rate{Cloud: "aws", Region: "us-east-1", Service: "AmazonEC2", Attributes: map[string]string{"instanceType": "m5.large", "tenancy": "Dedicated"}, Price: decimal.NewFromInt(3)},
+	)
+	cases := map[string]int64{"m5.large": 2, "t3.micro": 1}
+	for instanceType, want := range cases {
+		rate, _ := s.ResolveRate(context.Background(), clickhouse.AWS, "AmazonEC2", "Compute Instance", "us-east-1", m5Lookup(instanceType).Attributes, "hours", "")
+		if rate == nil || rate.Price.IntPart() != want {
+			t.Errorf("%s = %+v, want %d", instanceType, rate, want)
+		}
+	}
+}
+
+func TestParseCSVRejectsBadRows(t *testing.T) {
+	for name, data := range map[string]string{
+		"missing column": "cloud,region,price\naws,us-east-1,1\n",
+		"bad price":      "cloud,region,service,price\naws,us-east-1,AmazonEC2,cheap\n",
+	} {
+		if _, err := ParseCSV(strings.NewReader(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -108,36 +108,30 @@ func (r *Resolver) ResolveTiered(ctx context.Context, req ResolveRequest) ([]Tie
 }
 
 // CalculateTieredCost computes cost for tiered pricing
+// Tier bounds are absolute usage levels; usage below the first tier's minimum
+// or between tiers is free, since ingestion drops zero-price free tiers.
 func CalculateTieredCost(usage decimal.Decimal, tiers []TieredRate) (decimal.Decimal, float64) {
 	if len(tiers) == 0 {
 		return decimal.Zero, 0
 	}
 
 	totalCost := decimal.Zero
-	remaining := usage
 	minConfidence := 1.0
 
 	for _, tier := range tiers {
-		if remaining.LessThanOrEqual(decimal.Zero) {
+		if usage.LessThanOrEqual(tier.Min) {
 			break
 		}
 
-		var tierUsage decimal.Decimal
-		if tier.Max == nil {
-			// Unlimited tier
-			tierUsage = remaining
-		} else {
-			tierSize := tier.Max.Sub(tier.Min)
-			if remaining.GreaterThan(tierSize) {
-				tierUsage = tierSize
-			} else {
-				tierUsage = remaining
-			}
+		upper := usage
+		if tier.Max != nil && tier.Max.LessThan(usage) {
+			upper = *tier.Max
 		}
-
-		tierCost := tierUsage.Mul(tier.Price)
-		totalCost = totalCost.Add(tierCost)
-		remaining = remaining.Sub(tierUsage)
+		tierUsage := upper.Sub(tier.Min)
+		if !tierUsage.IsPositive() {
+			continue
+		}
+		totalCost = totalCost.Add(tierUsage.Mul(tier.Price))
 
 		if tier.Confidence < minConfidence {
 			minConfidence = tier.Confidence
@@ -0,0 +1,212 @@
+// Package anomaly flags estimated costs outside a project's statistical band
+// The band is the mean monthly P50 of the trailing saved estimates of the same
+// project and environment, plus or minus a number of standard deviations.
+package anomaly
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+)
+
+// Store is the estimation history a Detector reads
+type Store interface {
+	ListEstimations(ctx context.Context, filter clickhouse.EstimationFilter) ([]*clickhouse.EstimationRecord, error)
+}
+
+// Defaults of Options
+const (
+	DefaultWindow = 30 // Trailing estimates in the band
+	DefaultSigma  = 3  // Band half-width in standard deviations
+)
+
+// MinSamples is the history a band needs; projects with fewer estimates have no anomalies
+const MinSamples = 5
+
+// minRelativeStdDev floors the standard deviation at a fraction of the mean,
+// so a flat history doesn't flag every cent of change
+const minRelativeStdDev = 0.01
+
+// Anomaly directions
+const (
+	Spike = "spike"
+	Drop  = "drop"
+)
+
+// Options tune detection; zero fields use the defaults
+type Options struct {
+	Window int
+	Sigma  float64
+}
+
+func (o Options) withDefaults() Options {
+	if o.Window <= 0 {
+		o.Window = DefaultWindow
+	}
+	if o.Sigma <= 0 {
+		o.Sigma = DefaultSigma
+	}
+	return o
+}
+
+// Band is the expected range of a project's estimated monthly cost
+type Band struct {
+	Samples int     `json:"samples"`
+	Mean    float64 `json:"mean"`
+	StdDev  float64 `json:"std_dev"`
+	Sigma   float64 `json:"sigma"`
+	Lower   float64 `json:"lower"`
+	Upper   float64 `json:"upper"`
+}
+
+// NewBand computes the band of a cost history; ok is false with fewer than MinSamples costs
+func NewBand(costs []float64, sigma float64) (Band, bool) {
+	if len(costs) < MinSamples {
+		return Band{}, false
+	}
+	var sum float64
+	for _, c := range costs {
+		sum += c
+	}
+	mean := sum / float64(len(costs))
+	var squares float64
+	for _, c := range costs {
+		squares += (c - mean) * (c - mean)
+	}
+	stdDev := math.Max(math.Sqrt(squares/float64(len(costs))), math.Abs(mean)*minRelativeStdDev)
+	return Band{
+		Samples: len(costs),
+		Mean:    mean,
+		StdDev:  stdDev,
+		Sigma:   sigma,
+		Lower:   mean - sigma*stdDev,
+		Upper:   mean + sigma*stdDev,
+	}, true
+}
+
+// ZScore returns how many standard deviations a cost is from the mean
+func (b Band) ZScore(cost float64) float64 {
+	if b.StdDev == 0 {
+		return 0
+	}
+	return (cost - b.Mean) / b.StdDev
+}
+
+// Contains reports whether a cost is within the band
+func (b Band) Contains(cost float64) bool {
+	return cost >= b.Lower && cost <= b.Upper
+}
+
+// Anomaly is a saved estimate outside the band of the estimates before it
+type Anomaly struct {
+	EstimationID   uuid.UUID       `json:"estimation_id"`
+	Project        string          `json:"project"`
+	Environment    string          `json:"environment"`
+	Branch         string          `json:"branch,omitempty"`
+	CommitSHA      string          `json:"commit_sha,omitempty"`
+	PullRequest    string          `json:"pull_request,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	MonthlyCostP50 decimal.Decimal `json:"monthly_cost_p50"`
+	Direction      string          `json:"direction"` // spike or drop
+	ZScore         float64         `json:"z_score"`
+	Band           Band            `json:"band"`
+}
+
+// Detector finds cost anomalies in estimation history
+type Detector struct {
+	store Store
+}
+
+// NewDetector creates a detector over an estimation history store
+func NewDetector(store Store) *Detector {
+	return &Detector{store: store}
+}
+
+// Band returns the band of a project's latest saved estimates in an environment,
+// or nil when there are fewer than MinSamples of them
+func (d *Detector) Band(ctx context.Context, project, environment string, opts Options) (*Band, error) {
+	opts = opts.withDefaults()
+	records, err := d.store.ListEstimations(ctx, clickhouse.EstimationFilter{
+		Project:     project,
+		Environment: environment,
+		Limit:       opts.Window,
+	})
+	if err != nil {
+		return nil, err
+	}
+	band, ok := NewBand(costs(records), opts.Sigma)
+	if !ok {
+		return nil, nil
+	}
+	return &band, nil
+}
+
+// Detect lists a project's saved estimates, newest first, that fell outside
+// the band of the estimates before them in the same environment. An empty
+// environment checks every environment. At most MaxEstimationLimit of the
+// latest estimates are scanned.
+func (d *Detector) Detect(ctx context.Context, project, environment string, opts Options) ([]Anomaly, error) {
+	opts = opts.withDefaults()
+	records, err := d.store.ListEstimations(ctx, clickhouse.EstimationFilter{
+		Project:     project,
+		Environment: environment,
+		Limit:       clickhouse.MaxEstimationLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Oldest first per environment
+	byEnv := make(map[string][]*clickhouse.EstimationRecord)
+	for i := len(records) - 1; i >= 0; i-- {
+		rec := records[i]
+		byEnv[rec.Environment] = append(byEnv[rec.Environment], rec)
+	}
+
+	anomalies := make([]Anomaly, 0)
+	for _, history := range byEnv {
+		for i, rec := range history {
+			band, ok := NewBand(costs(history[max(0, i-opts.Window):i]), opts.Sigma)
+			cost := rec.MonthlyCostP50.InexactFloat64()
+			if !ok || band.Contains(cost) {
+				continue
+			}
+			direction := Spike
+			if cost < band.Lower {
+				direction = Drop
+			}
+			anomalies = append(anomalies, Anomaly{
+				EstimationID:   rec.ID,
+				Project:        rec.Project,
+				Environment:    rec.Environment,
+				Branch:         rec.Branch,
+				CommitSHA:      rec.CommitSHA,
+				PullRequest:    rec.PullRequest,
+				CreatedAt:      rec.CreatedAt,
+				MonthlyCostP50: rec.MonthlyCostP50,
+				Direction:      direction,
+				ZScore:         band.ZScore(cost),
+				Band:           band,
+			})
+		}
+	}
+	sort.SliceStable(anomalies, func(i, j int) bool {
+		return anomalies[i].CreatedAt.After(anomalies[j].CreatedAt)
+	})
+	return anomalies, nil
+}
+
+// costs returns the monthly P50 of estimation records
+func costs(records []*clickhouse.EstimationRecord) []float64 {
+	values := make([]float64, len(records))
+	for i, rec := range records {
+		values[i] = rec.MonthlyCostP50.InexactFloat64()
+	}
+	return values
+}
@@ -0,0 +1,94 @@
+package anomaly
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+)
+
+// fakeStore serves records newest first, like ListEstimations
+type fakeStore struct {
+	records []*clickhouse.EstimationRecord // oldest first
+}
+
+func (f *fakeStore) ListEstimations(ctx context.Context, filter clickhouse.EstimationFilter) ([]*clickhouse.EstimationRecord, error) {
+	out := make([]*clickhouse.EstimationRecord, 0)
+	for i := len(f.records) - 1; i >= 0 && len(out) < filter.Limit; i-- {
+		rec := f.records[i]
+		if (filter.Project == "" || rec.Project == filter.Project) &&
+			(filter.Environment == "" || rec.Environment == filter.Environment) {
+			out = append(out, rec)
+		}
+	}
+	return out, nil
+}
+
+// history saves one estimate per hour with the given costs
+func history(env string, costs ...int64) *fakeStore {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := &fakeStore{}
+	for i, c := range costs {
+		store.records = append(store.records, &clickhouse.EstimationRecord{
+			ID: uuid.New(), Project: "web", Environment: env,
+			MonthlyCostP50: decimal.NewFromInt(c), CreatedAt: start.Add(time.Duration(i) * time.Hour),
+		})
+	}
+	return store
+}
+
+func TestNewBand(t *testing.T) {
+	if _, ok := NewBand([]float64{1, 2, 3, 4}, 3); ok {
+		t.Error("band with fewer than MinSamples costs")
+	}
+	band, ok := NewBand([]float64{100, 102, 98, 101, 99}, 3)
+	if !ok || band.Mean != 100 {
+		t.Fatalf("band = %+v", band)
+	}
+	if want := math.Sqrt(2); math.Abs(band.StdDev-want) > 1e-9 {
+		t.Errorf("std dev = %v, want %v", band.StdDev, want)
+	}
+	if !band.Contains(104) || band.Contains(105) || band.Contains(95) {
+		t.Errorf("band %v..%v", band.Lower, band.Upper)
+	}
+
+	// A flat history still tolerates 1% per sigma
+	flat, _ := NewBand([]float64{200, 200, 200, 200, 200}, 3)
+	if flat.StdDev != 2 || !flat.Contains(205) || flat.Contains(207) {
+		t.Errorf("flat band = %+v", flat)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	store := history("prod", 100, 102, 98, 101, 99, 100, 180, 100, 101, 10)
+	// Too little dev history for a band
+	store.records = append(store.records, history("dev", 10, 10, 500).records...)
+	anomalies, err := NewDetector(store).Detect(context.Background(), "web", "", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(anomalies) != 2 {
+		t.Fatalf("got %d anomalies: %+v", len(anomalies), anomalies)
+	}
+	// Newest first: the drop to 10, then the spike to 180
+	if a := anomalies[0]; a.Direction != Drop || !a.MonthlyCostP50.Equal(decimal.NewFromInt(10)) || a.Band.Samples != 9 {
+		t.Errorf("anomaly 0 = %+v", a)
+	}
+	if a := anomalies[1]; a.Direction != Spike || a.Band.Samples != 6 || a.ZScore < 3 {
+		t.Errorf("anomaly 1 = %+v", a)
+	}
+
+	band, err := NewDetector(store).Band(context.Background(), "web", "prod", Options{Window: 4})
+	if err != nil || band != nil {
+		t.Errorf("band of 4 estimates = %+v, %v", band, err)
+	}
+	band, err = NewDetector(store).Band(context.Background(), "web", "prod", Options{Window: 5})
+	if err != nil || band == nil || band.Samples != 5 {
+		t.Errorf("band = %+v, %v", band, err)
+	}
+}
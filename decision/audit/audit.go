@@ -0,0 +1,159 @@
+// Package audit provides signed estimation audit records
+// Each record captures what was estimated (a hash of the request), the pricing
+// snapshots used, a hash of the policy set evaluated and the decision, signed
+// with HMAC-SHA256 or Ed25519 so tampering with a stored record is detectable.
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/estimation"
+	"terraform-cost/decision/policy"
+)
+
+// ErrInvalidSignature is returned when a record does not verify
+var ErrInvalidSignature = errors.New("audit record signature is invalid")
+
+// Entry is the signed content of an audit record
+type Entry struct {
+	ID             uuid.UUID            `json:"id"`
+	OrgID          string               `json:"org_id"`
+	Project        string               `json:"project,omitempty"`
+	Environment    string               `json:"environment"`
+	Source         string               `json:"source"` // cli, api, ci
+	RequestHash    string               `json:"request_hash"`
+	SnapshotIDs    map[string]uuid.UUID `json:"snapshot_ids"` // region -> snapshot ID
+	PricingDate    *time.Time           `json:"pricing_date,omitempty"`
+	PolicySetHash  string               `json:"policy_set_hash"`
+	Decision       string               `json:"decision"`
+	Violations     []string             `json:"violations"`
+	Currency       string               `json:"currency"`
+	MonthlyCostP50 string               `json:"monthly_cost_p50"`
+	MonthlyCostP90 string               `json:"monthly_cost_p90"`
+	ResourceCount  int                  `json:"resource_count"`
+	EstimationID   string               `json:"estimation_id,omitempty"` // Saved estimation, if any
+	CreatedAt      time.Time            `json:"created_at"`
+}
+
+// Meta identifies the request an audit entry records
+type Meta struct {
+	OrgID         string
+	Project       string
+	Environment   string
+	Source        string
+	RequestHash   string
+	PolicySetHash string
+	ResourceCount int
+	EstimationID  string
+}
+
+// Hash returns the hex SHA-256 of a value's JSON encoding
+func Hash(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode value for hashing: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// NewEntry records an estimation and the policy decision made on it
+func NewEntry(result *estimation.EstimationResult, decision *policy.EvaluationResult, meta Meta) Entry {
+	violations := make([]string, 0, len(decision.Violations))
+	for _, v := range decision.Violations {
+		violations = append(violations, fmt.Sprintf("%s: %s", v.PolicyID, v.Message))
+	}
+	return Entry{
+		ID:             uuid.New(),
+		OrgID:          meta.OrgID,
+		Project:        meta.Project,
+		Environment:    meta.Environment,
+		Source:         meta.Source,
+		RequestHash:    meta.RequestHash,
+		SnapshotIDs:    result.AuditTrail.SnapshotsUsed,
+		PricingDate:    result.AuditTrail.PricingDate,
+		PolicySetHash:  meta.PolicySetHash,
+		Decision:       string(decision.Decision),
+		Violations:     violations,
+		Currency:       result.Currency,
+		MonthlyCostP50: result.MonthlyCostP50.StringFixed(4),
+		MonthlyCostP90: result.MonthlyCostP90.StringFixed(4),
+		ResourceCount:  meta.ResourceCount,
+		EstimationID:   meta.EstimationID,
+		CreatedAt:      result.AuditTrail.EstimatedAt.UTC(),
+	}
+}
+
+// Seal signs an entry and converts it into an audit log record
+// The estimation result supplies the queryable columns not in the entry.
+func Seal(entry Entry, result *estimation.EstimationResult, signer Signer) (*clickhouse.AuditRecord, error) {
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	signature, err := signer.Sign(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign audit entry: %w", err)
+	}
+
+	snapshots := make([]uuid.UUID, 0, len(entry.SnapshotIDs))
+	for _, id := range entry.SnapshotIDs {
+		snapshots = append(snapshots, id)
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].String() < snapshots[j].String() })
+
+	return &clickhouse.AuditRecord{
+		ID:             entry.ID,
+		Project:        entry.Project,
+		Environment:    entry.Environment,
+		Source:         entry.Source,
+		RequestHash:    entry.RequestHash,
+		SnapshotIDs:    snapshots,
+		PolicySetHash:  entry.PolicySetHash,
+		PolicyResult:   entry.Decision,
+		Violations:     entry.Violations,
+		ResourceCount:  entry.ResourceCount,
+		MonthlyCostP50: result.MonthlyCostP50,
+		MonthlyCostP90: result.MonthlyCostP90,
+		CarbonKgCO2:    result.CarbonKgCO2,
+		Confidence:     result.Confidence,
+		IsIncomplete:   result.IsIncomplete,
+		EstimationID:   entry.EstimationID,
+		RecordJSON:     string(payload),
+		Algorithm:      signer.Algorithm(),
+		KeyID:          signer.KeyID(),
+		Signature:      base64.StdEncoding.EncodeToString(signature),
+		CreatedAt:      entry.CreatedAt,
+	}, nil
+}
+
+// Verify checks a stored record's signature and that its columns match the signed entry
+func Verify(rec *clickhouse.AuditRecord, signer Signer) (*Entry, error) {
+	var entry Entry
+	if err := json.Unmarshal([]byte(rec.RecordJSON), &entry); err != nil {
+		return nil, fmt.Errorf("failed to decode audit entry %s: %w", rec.ID, err)
+	}
+	if rec.Algorithm != signer.Algorithm() || rec.KeyID != signer.KeyID() {
+		return &entry, fmt.Errorf("audit record %s was signed with %s key %s, not the configured %s key %s",
+			rec.ID, rec.Algorithm, rec.KeyID, signer.Algorithm(), signer.KeyID())
+	}
+	signature, err := base64.StdEncoding.DecodeString(rec.Signature)
+	if err != nil || !signer.Verify([]byte(rec.RecordJSON), signature) {
+		return &entry, ErrInvalidSignature
+	}
+	if entry.ID != rec.ID || entry.RequestHash != rec.RequestHash || entry.PolicySetHash != rec.PolicySetHash ||
+		entry.Decision != rec.PolicyResult || entry.Project != rec.Project {
+		return &entry, fmt.Errorf("%w: columns of record %s differ from its signed entry", ErrInvalidSignature, rec.ID)
+	}
+	return &entry, nil
+}
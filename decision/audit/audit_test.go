@@ -0,0 +1,122 @@
+// Package audit - Audit record tests
+package audit
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/decision/estimation"
+	"terraform-cost/decision/policy"
+)
+
+func testEntry(t *testing.T) (Entry, *estimation.EstimationResult) {
+	t.Helper()
+	result := &estimation.EstimationResult{
+		Currency:       "USD",
+		MonthlyCostP50: decimal.NewFromFloat(123.45),
+		MonthlyCostP90: decimal.NewFromFloat(150),
+		AuditTrail: estimation.AuditTrail{
+			EstimatedAt:   time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+			SnapshotsUsed: map[string]uuid.UUID{"us-east-1": uuid.MustParse("5b1f7a52-4a7e-4c39-9d1c-3c6f9b0e2a11")},
+		},
+	}
+	decision := &policy.EvaluationResult{
+		Decision:   policy.DecisionDeny,
+		Violations: []policy.Violation{{PolicyID: "cost-limit", Message: "over budget"}},
+	}
+	requestHash, err := Hash(map[string]string{"plan": "{}"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entry := NewEntry(result, decision, Meta{
+		OrgID:         "acme",
+		Project:       "web",
+		Environment:   "prod",
+		Source:        "api",
+		RequestHash:   requestHash,
+		PolicySetHash: policy.NewEngine().PolicySetHash(nil),
+		ResourceCount: 3,
+	})
+	return entry, result
+}
+
+func TestSealVerify(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	signers := map[string]Signer{
+		AlgorithmHMACSHA256: NewHMACSigner([]byte("secret")),
+		AlgorithmEd25519:    NewEd25519Signer(key),
+	}
+	for name, signer := range signers {
+		t.Run(name, func(t *testing.T) {
+			entry, result := testEntry(t)
+			rec, err := Seal(entry, result, signer)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rec.PolicyResult != "deny" || len(rec.SnapshotIDs) != 1 || rec.Algorithm != name {
+				t.Errorf("unexpected record: %+v", rec)
+			}
+
+			got, err := Verify(rec, signer)
+			if err != nil {
+				t.Fatalf("untampered record does not verify: %v", err)
+			}
+			if got.Decision != "deny" || got.Violations[0] != "cost-limit: over budget" || got.MonthlyCostP50 != "123.4500" {
+				t.Errorf("unexpected entry: %+v", got)
+			}
+
+			// Flipping the signed decision breaks the signature
+			tampered := *rec
+			tampered.RecordJSON = strings.Replace(rec.RecordJSON, `"decision":"deny"`, `"decision":"pass"`, 1)
+			if _, err := Verify(&tampered, signer); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("tampered payload: err = %v, want ErrInvalidSignature", err)
+			}
+			// So does changing a column without the payload
+			tampered = *rec
+			tampered.PolicyResult = "pass"
+			if _, err := Verify(&tampered, signer); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("tampered column: err = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+func TestVerifyWithPublicKey(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	entry, result := testEntry(t)
+	rec, err := Seal(entry, result, NewEd25519Signer(key))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := Verify(rec, NewEd25519Verifier(key.Public().(ed25519.PublicKey))); err != nil {
+		t.Errorf("record does not verify with the public key: %v", err)
+	}
+	if _, err := Verify(rec, NewHMACSigner([]byte("secret"))); err == nil {
+		t.Error("record verified with a different key")
+	}
+}
+
+func TestLoadEd25519Key(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	seed[0] = 7
+	path := filepath.Join(t.TempDir(), "audit.key")
+	if err := os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(seed)+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	key, err := LoadEd25519Key(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !key.Equal(ed25519.NewKeyFromSeed(seed)) {
+		t.Error("loaded key differs from the seed's key")
+	}
+}
@@ -0,0 +1,111 @@
+// Package audit - Record signing
+package audit
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Signature algorithms
+const (
+	AlgorithmHMACSHA256 = "hmac-sha256"
+	AlgorithmEd25519    = "ed25519"
+)
+
+// Signer signs and verifies audit entries
+type Signer interface {
+	Algorithm() string
+	// KeyID identifies the key without revealing it, so rotated keys can be told apart
+	KeyID() string
+	Sign(payload []byte) ([]byte, error)
+	Verify(payload, signature []byte) bool
+}
+
+// HMACSigner signs with a shared secret; anyone who can verify can also sign
+type HMACSigner struct {
+	secret []byte
+}
+
+// NewHMACSigner creates an HMAC-SHA256 signer
+func NewHMACSigner(secret []byte) *HMACSigner {
+	return &HMACSigner{secret: secret}
+}
+
+func (s *HMACSigner) Algorithm() string { return AlgorithmHMACSHA256 }
+
+func (s *HMACSigner) KeyID() string { return keyID(s.secret) }
+
+func (s *HMACSigner) Sign(payload []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(payload)
+	return mac.Sum(nil), nil
+}
+
+func (s *HMACSigner) Verify(payload, signature []byte) bool {
+	expected, _ := s.Sign(payload)
+	return hmac.Equal(expected, signature)
+}
+
+// Ed25519Signer signs with a private key; records verify with the public key alone
+type Ed25519Signer struct {
+	private ed25519.PrivateKey
+	public  ed25519.PublicKey
+}
+
+// NewEd25519Signer creates a signer from an Ed25519 private key
+func NewEd25519Signer(key ed25519.PrivateKey) *Ed25519Signer {
+	return &Ed25519Signer{private: key, public: key.Public().(ed25519.PublicKey)}
+}
+
+// NewEd25519Verifier creates a signer that only verifies, from a public key
+func NewEd25519Verifier(key ed25519.PublicKey) *Ed25519Signer {
+	return &Ed25519Signer{public: key}
+}
+
+func (s *Ed25519Signer) Algorithm() string { return AlgorithmEd25519 }
+
+func (s *Ed25519Signer) KeyID() string { return keyID(s.public) }
+
+func (s *Ed25519Signer) Sign(payload []byte) ([]byte, error) {
+	if s.private == nil {
+		return nil, fmt.Errorf("ed25519 signer has no private key")
+	}
+	return ed25519.Sign(s.private, payload), nil
+}
+
+func (s *Ed25519Signer) Verify(payload, signature []byte) bool {
+	return ed25519.Verify(s.public, payload, signature)
+}
+
+// LoadEd25519Key reads a base64 Ed25519 private key (64 bytes) or seed (32 bytes) from a file
+func LoadEd25519Key(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit signing key: %w", err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("audit signing key %s is not base64: %w", path, err)
+	}
+	switch len(raw) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(raw), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(raw), nil
+	default:
+		return nil, fmt.Errorf("audit signing key %s has %d bytes, want a %d byte seed or %d byte private key",
+			path, len(raw), ed25519.SeedSize, ed25519.PrivateKeySize)
+	}
+}
+
+// keyID is a short fingerprint of key material
+func keyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
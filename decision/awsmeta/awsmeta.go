@@ -0,0 +1,179 @@
+// Package awsmeta resolves AWS metadata that plans leave implicit
+// The platform of an AMI decides the operating system an instance is billed for,
+// the AMI's root device sets the default root volume, and an instance type's
+// vCPUs and memory feed power and carbon models. Metadata comes from an embedded
+// dataset of common instance types, dataset files (e.g. an organization's AMIs)
+// and optionally the live EC2 API.
+package awsmeta
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/calibration"
+)
+
+// InstanceType is the hardware of an EC2 instance type
+type InstanceType struct {
+	Name         string  `json:"name" yaml:"name"`
+	VCPU         int     `json:"vcpu" yaml:"vcpu"`
+	MemoryGiB    float64 `json:"memory_gib" yaml:"memory_gib"`
+	Network      string  `json:"network,omitempty" yaml:"network,omitempty"` // Up to 12.5 Gigabit
+	Architecture string  `json:"architecture,omitempty" yaml:"architecture,omitempty"`
+
+	// Accelerated instances: GPUs, Inferentia or Trainium chips
+	Accelerators     int     `json:"accelerators,omitempty" yaml:"accelerators,omitempty"`
+	AcceleratorModel string  `json:"accelerator_model,omitempty" yaml:"accelerator_model,omitempty"` // NVIDIA A100, AWS Inferentia2, ...
+	AcceleratorWatts float64 `json:"accelerator_watts,omitempty" yaml:"accelerator_watts,omitempty"` // Board power of each accelerator
+}
+
+// Hardware returns the capacity of an instance type for billing components
+func (t *InstanceType) Hardware() billing.Hardware {
+	return billing.Hardware{
+		VCPU:             t.VCPU,
+		MemoryGiB:        t.MemoryGiB,
+		Accelerators:     t.Accelerators,
+		AcceleratorWatts: t.AcceleratorWatts,
+	}
+}
+
+// Image is an AMI
+type Image struct {
+	ID              string  `json:"id" yaml:"id"`
+	Region          string  `json:"region,omitempty" yaml:"region,omitempty"` // Empty matches any region
+	Name            string  `json:"name,omitempty" yaml:"name,omitempty"`
+	PlatformDetails string  `json:"platform_details" yaml:"platform_details"` // Linux/UNIX, Windows, Red Hat Enterprise Linux, ...
+	RootVolumeType  string  `json:"root_volume_type,omitempty" yaml:"root_volume_type,omitempty"`
+	RootVolumeGiB   float64 `json:"root_volume_gib,omitempty" yaml:"root_volume_gib,omitempty"`
+}
+
+// OperatingSystem returns the Price List operating system the image is billed as
+func (i *Image) OperatingSystem() string {
+	return OperatingSystem(i.PlatformDetails)
+}
+
+// Price List license models of EC2 instances
+const (
+	LicenseNotRequired = "No License required"
+	LicenseBYOL        = "Bring your own license"
+)
+
+// Platform is how the software of an instance is billed
+type Platform struct {
+	OperatingSystem string // Linux, Windows, RHEL, SUSE, Red Hat Enterprise Linux with HA, Ubuntu Pro
+	PreInstalledSw  string // NA, SQL Std, SQL Web or SQL Ent
+	LicenseModel    string // LicenseNotRequired or LicenseBYOL
+}
+
+// ParsePlatform maps EC2 platform details, e.g. "Windows with SQL Server
+// Standard" or "Windows BYOL", to the Price List platform. AMI names work too
+// (Windows_Server-2022-English-Full-SQL_2022_Enterprise). SQL Server Express
+// carries no license fee, and RHEL subscriptions brought through Cloud Access
+// bill as Linux.
+func ParsePlatform(details string) Platform {
+	details = strings.ToLower(details)
+	platform := Platform{OperatingSystem: "Linux", PreInstalledSw: "NA", LicenseModel: LicenseNotRequired}
+	switch {
+	case strings.Contains(details, "windows"):
+		platform.OperatingSystem = "Windows"
+		if strings.Contains(details, "byol") {
+			platform.LicenseModel = LicenseBYOL
+		}
+	case strings.Contains(details, "byol"):
+	case strings.Contains(details, "red hat") || strings.Contains(details, "rhel"):
+		platform.OperatingSystem = "RHEL"
+		if strings.Contains(details, "with ha") || strings.Contains(details, "_ha") {
+			platform.OperatingSystem = "Red Hat Enterprise Linux with HA"
+		}
+	case strings.Contains(details, "suse") || strings.Contains(details, "sles"):
+		platform.OperatingSystem = "SUSE"
+	case strings.Contains(details, "ubuntu pro") || strings.Contains(details, "ubuntu-pro"):
+		platform.OperatingSystem = "Ubuntu Pro"
+	}
+	if strings.Contains(details, "sql") && platform.LicenseModel != LicenseBYOL {
+		switch {
+		case strings.Contains(details, "enterprise"):
+			platform.PreInstalledSw = "SQL Ent"
+		case strings.Contains(details, "standard"):
+			platform.PreInstalledSw = "SQL Std"
+		case strings.Contains(details, "web"):
+			platform.PreInstalledSw = "SQL Web"
+		}
+	}
+	return platform
+}
+
+// OperatingSystem maps EC2 platform details to a Price List operating system
+func OperatingSystem(platformDetails string) string {
+	return ParsePlatform(platformDetails).OperatingSystem
+}
+
+var (
+	embeddedOnce sync.Once
+	embedded     *Dataset
+)
+
+// AcceleratedHardware returns the hardware of an accelerated instance type in
+// the embedded dataset, or nil. Accelerators dominate an instance's power, so
+// mappers attach it even when metadata enrichment is off.
+func AcceleratedHardware(instanceType string) *billing.Hardware {
+	embeddedOnce.Do(func() { embedded = Embedded() })
+	t, ok := embedded.instanceTypes[instanceType]
+	if !ok || t.Accelerators == 0 {
+		return nil
+	}
+	hw := t.Hardware()
+	return &hw
+}
+
+// Source resolves metadata; unknown names return nil, nil
+type Source interface {
+	Name() string
+	InstanceType(ctx context.Context, region, name string) (*InstanceType, error)
+	Image(ctx context.Context, region, id string) (*Image, error)
+}
+
+// NewSources creates sources from names: embedded, ec2 (live API) or the path of
+// a dataset file. Earlier sources take precedence.
+func NewSources(names []string) ([]Source, error) {
+	sources := make([]Source, 0, len(names))
+	for _, name := range names {
+		switch name {
+		case "embedded":
+			sources = append(sources, Embedded())
+		case "ec2":
+			creds, ok := calibration.CredentialsFromEnv()
+			if !ok {
+				return nil, errors.New("ec2 metadata needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+			}
+			sources = append(sources, NewEC2Source(creds))
+		default:
+			dataset, err := LoadDataset(name)
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, dataset)
+		}
+	}
+	return sources, nil
+}
+
+// resolve asks each source in turn until one knows the answer
+func resolve[T any](sources []Source, lookup func(Source) (*T, error)) (*T, error) {
+	var errs []error
+	for _, source := range sources {
+		value, err := lookup(source)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source.Name(), err))
+			continue
+		}
+		if value != nil {
+			return value, nil
+		}
+	}
+	return nil, errors.Join(errs...)
+}
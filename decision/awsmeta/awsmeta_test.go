@@ -0,0 +1,181 @@
+package awsmeta
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/calibration"
+	"terraform-cost/decision/estimation"
+	"terraform-cost/decision/iac"
+)
+
+func TestEmbeddedDataset(t *testing.T) {
+	dataset := Embedded()
+	micro, err := dataset.InstanceType(context.Background(), "us-east-1", "t3.micro")
+	if err != nil || micro == nil {
+		t.Fatalf("t3.micro = %v, %v", micro, err)
+	}
+	if micro.VCPU != 2 || micro.MemoryGiB != 1 {
+		t.Errorf("t3.micro = %d vCPU, %v GiB; want 2, 1", micro.VCPU, micro.MemoryGiB)
+	}
+	if unknown, _ := dataset.InstanceType(context.Background(), "us-east-1", "x99.huge"); unknown != nil {
+		t.Errorf("unknown instance type resolved: %+v", unknown)
+	}
+}
+
+func TestAcceleratedHardware(t *testing.T) {
+	if hw := AcceleratedHardware("m5.large"); hw != nil {
+		t.Errorf("m5.large = %+v; want nil, no accelerators", hw)
+	}
+	p4d := AcceleratedHardware("p4d.24xlarge")
+	if p4d == nil || p4d.Accelerators != 8 || p4d.AcceleratorWatts != 400 {
+		t.Fatalf("p4d.24xlarge = %+v; want 8 accelerators of 400 W", p4d)
+	}
+	// Eight A100s draw several times the host's vCPUs and memory
+	host := *p4d
+	host.Accelerators = 0
+	if got, cpu := estimation.HardwarePowerKw(*p4d), estimation.HardwarePowerKw(host); got < 3*cpu {
+		t.Errorf("p4d.24xlarge power = %.2f kW, host alone %.2f kW; want accelerators to dominate", got, cpu)
+	}
+}
+
+func TestEnrichGraph(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "amis.yaml")
+	os.WriteFile(path, []byte(`
+images:
+  - id: ami-0win
+    region: us-east-1
+    platform_details: Windows
+    root_volume_type: gp3
+    root_volume_gib: 30
+`), 0o644)
+	dataset, err := LoadDataset(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node := func(addr string, attrs map[string]interface{}) *iac.GraphNode {
+		return &iac.GraphNode{Resource: iac.ResourceNode{Address: addr, Type: "aws_instance", Attributes: attrs}, Region: "us-east-1"}
+	}
+	graph := &iac.Graph{Nodes: map[string]*iac.GraphNode{
+		"aws_instance.default": node("aws_instance.default", map[string]interface{}{"ami": "ami-0win", "instance_type": "t3.large"}),
+		"aws_instance.sized": node("aws_instance.sized", map[string]interface{}{
+			"ami": "ami-0win", "root_block_device": []interface{}{map[string]interface{}{"volume_size": float64(100)}},
+		}),
+		"aws_instance.unknown": node("aws_instance.unknown", map[string]interface{}{"ami": "ami-0other"}),
+	}}
+
+	enricher := NewEnricher(dataset, Embedded())
+	if warnings := enricher.EnrichGraph(context.Background(), graph); len(warnings) != 0 {
+		t.Errorf("warnings = %v", warnings)
+	}
+
+	attrs := graph.Nodes["aws_instance.default"].Resource.Attributes
+	if attrs[AttrPlatformDetails] != "Windows" {
+		t.Errorf("platform_details = %v, want Windows", attrs[AttrPlatformDetails])
+	}
+	root := attrs["root_block_device"].([]interface{})[0].(map[string]interface{})
+	if root["volume_size"] != float64(30) || root["volume_type"] != "gp3" {
+		t.Errorf("default root volume = %v, want 30 GiB gp3 from the AMI", root)
+	}
+	// Planned sizes win over the AMI's
+	root = graph.Nodes["aws_instance.sized"].Resource.Attributes["root_block_device"].([]interface{})[0].(map[string]interface{})
+	if root["volume_size"] != float64(100) || root["volume_type"] != "gp3" {
+		t.Errorf("planned root volume = %v, want 100 GiB gp3", root)
+	}
+	if _, ok := graph.Nodes["aws_instance.unknown"].Resource.Attributes[AttrPlatformDetails]; ok {
+		t.Error("unknown AMI enriched")
+	}
+
+	components := []billing.BillingComponent{
+		{Cloud: "aws", UsageType: "BoxUsage:t3.large", Attributes: map[string]string{"instanceType": "t3.large"}},
+		{Cloud: "aws", UsageType: "RDS:db.m5.xlarge", Attributes: map[string]string{"instanceType": "db.m5.xlarge"}},
+		{Cloud: "aws", UsageType: "EBSOptimized:t3.large", Attributes: map[string]string{"instanceType": "t3.large"}},
+	}
+	enricher.EnrichComponents(context.Background(), components)
+	if hw := components[0].Hardware; hw == nil || hw.VCPU != 2 || hw.MemoryGiB != 8 {
+		t.Errorf("t3.large hardware = %+v, want 2 vCPU, 8 GiB", hw)
+	}
+	if hw := components[1].Hardware; hw == nil || hw.VCPU != 4 || hw.MemoryGiB != 16 {
+		t.Errorf("db.m5.xlarge hardware = %+v, want 4 vCPU, 16 GiB", hw)
+	}
+	if components[2].Hardware != nil {
+		t.Error("hardware attached to a non instance-hour component")
+	}
+}
+
+func TestEC2Source(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch r.URL.Query().Get("Action") {
+		case "DescribeImages":
+			if r.URL.Query().Get("ImageId.1") != "ami-0rhel" {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `<Response><Errors><Error><Code>InvalidAMIID.NotFound</Code><Message>not found</Message></Error></Errors></Response>`)
+				return
+			}
+			fmt.Fprint(w, `<DescribeImagesResponse><imagesSet><item>
+				<imageId>ami-0rhel</imageId><name>RHEL-9</name><platformDetails>Red Hat Enterprise Linux</platformDetails>
+				<rootDeviceName>/dev/sda1</rootDeviceName>
+				<blockDeviceMapping><item><deviceName>/dev/sda1</deviceName><ebs><volumeSize>10</volumeSize><volumeType>gp3</volumeType></ebs></item></blockDeviceMapping>
+			</item></imagesSet></DescribeImagesResponse>`)
+		case "DescribeInstanceTypes":
+			fmt.Fprint(w, `<DescribeInstanceTypesResponse><instanceTypeSet><item>
+				<instanceType>x2.custom</instanceType><vCpuInfo><defaultVCpus>8</defaultVCpus></vCpuInfo>
+				<memoryInfo><sizeInMiB>65536</sizeInMiB></memoryInfo>
+				<processorInfo><supportedArchitectures><item>arm64</item></supportedArchitectures></processorInfo>
+			</item></instanceTypeSet></DescribeInstanceTypesResponse>`)
+		}
+	}))
+	defer server.Close()
+
+	source := NewEC2Source(calibration.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}).WithEndpoint(server.URL + "/")
+	ctx := context.Background()
+
+	image, err := source.Image(ctx, "us-east-1", "ami-0rhel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image.OperatingSystem() != "RHEL" || image.RootVolumeGiB != 10 || image.RootVolumeType != "gp3" {
+		t.Errorf("image = %+v", image)
+	}
+	if missing, err := source.Image(ctx, "us-east-1", "ami-0gone"); missing != nil || err != nil {
+		t.Errorf("missing AMI = %v, %v; want nil, nil", missing, err)
+	}
+	instanceType, err := source.InstanceType(ctx, "us-east-1", "x2.custom")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if instanceType.VCPU != 8 || instanceType.MemoryGiB != 64 || instanceType.Architecture != "arm64" {
+		t.Errorf("instance type = %+v", instanceType)
+	}
+
+	// Answers, including unknown AMIs, are cached
+	source.Image(ctx, "us-east-1", "ami-0rhel")
+	source.Image(ctx, "us-east-1", "ami-0gone")
+	if calls != 3 {
+		t.Errorf("EC2 calls = %d, want 3", calls)
+	}
+}
+
+func TestParsePlatformFromAMIName(t *testing.T) {
+	tests := map[string]Platform{
+		"Windows_Server-2022-English-Full-SQL_2022_Web":     {"Windows", "SQL Web", LicenseNotRequired},
+		"Windows_Server-2022-English-Full-SQL_2022_Express": {"Windows", "NA", LicenseNotRequired},
+		"RHEL_HA-9.2.0_HVM-20230503-x86_64":                 {"Red Hat Enterprise Linux with HA", "NA", LicenseNotRequired},
+		"ubuntu-pro-server/images/hvm-ssd/ubuntu-jammy":     {"Ubuntu Pro", "NA", LicenseNotRequired},
+		"Ubuntu Pro": {"Ubuntu Pro", "NA", LicenseNotRequired},
+	}
+	for name, want := range tests {
+		if got := ParsePlatform(name); got != want {
+			t.Errorf("ParsePlatform(%q) = %+v, want %+v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Package awsmeta - Offline datasets
+// The embedded dataset covers common current-generation instance types. Dataset
+// files add instance types and AMIs, such as an organization's golden images.
+package awsmeta
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed instance_types.json
+var embeddedInstanceTypes []byte
+
+// Dataset is metadata held in memory
+type Dataset struct {
+	name          string
+	instanceTypes map[string]InstanceType
+	images        map[string][]Image // AMI ID -> images (per region, or any region)
+}
+
+// datasetFile is the format of dataset files (YAML or JSON)
+type datasetFile struct {
+	InstanceTypes []InstanceType `json:"instance_types" yaml:"instance_types"`
+	Images        []Image        `json:"images" yaml:"images"`
+}
+
+// NewDataset creates a dataset from instance types and images
+func NewDataset(name string, instanceTypes []InstanceType, images []Image) *Dataset {
+	d := &Dataset{
+		name:          name,
+		instanceTypes: make(map[string]InstanceType, len(instanceTypes)),
+		images:        make(map[string][]Image, len(images)),
+	}
+	for _, t := range instanceTypes {
+		d.instanceTypes[t.Name] = t
+	}
+	for _, image := range images {
+		d.images[image.ID] = append(d.images[image.ID], image)
+	}
+	return d
+}
+
+// Embedded returns the dataset built into terracost
+func Embedded() *Dataset {
+	var instanceTypes []InstanceType
+	if err := json.Unmarshal(embeddedInstanceTypes, &instanceTypes); err != nil {
+		panic(fmt.Sprintf("awsmeta: invalid embedded dataset: %v", err))
+	}
+	return NewDataset("embedded", instanceTypes, nil)
+}
+
+// LoadDataset reads a dataset file with instance_types and images
+func LoadDataset(path string) (*Dataset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata dataset: %w", err)
+	}
+	var file datasetFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata dataset %s: %w", path, err)
+	}
+	for _, image := range file.Images {
+		if image.ID == "" {
+			return nil, fmt.Errorf("metadata dataset %s: image without id", path)
+		}
+	}
+	return NewDataset(path, file.InstanceTypes, file.Images), nil
+}
+
+// Name returns the dataset name
+func (d *Dataset) Name() string {
+	return d.name
+}
+
+// InstanceType looks up an instance type; instance types are the same in every region
+func (d *Dataset) InstanceType(ctx context.Context, region, name string) (*InstanceType, error) {
+	if t, ok := d.instanceTypes[name]; ok {
+		return &t, nil
+	}
+	return nil, nil
+}
+
+// Image looks up an AMI, preferring an entry for the region
+func (d *Dataset) Image(ctx context.Context, region, id string) (*Image, error) {
+	var anyRegion *Image
+	for _, image := range d.images[id] {
+		image := image
+		if image.Region == region {
+			return &image, nil
+		}
+		if image.Region == "" {
+			anyRegion = &image
+		}
+	}
+	return anyRegion, nil
+}
@@ -0,0 +1,200 @@
+// Package awsmeta - EC2 API source
+// DescribeInstanceTypes and DescribeImages resolve instance types and AMIs the
+// offline datasets do not know, such as private AMIs. Answers are cached, so
+// each instance type and AMI is described at most once per region.
+package awsmeta
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"terraform-cost/decision/calibration"
+)
+
+// ec2APIVersion is the EC2 query API version
+const ec2APIVersion = "2016-11-15"
+
+// notFoundCodes are EC2 error codes for names that do not exist
+var notFoundCodes = map[string]bool{
+	"InvalidInstanceType":      true,
+	"InvalidAMIID.NotFound":    true,
+	"InvalidAMIID.Malformed":   true,
+	"InvalidAMIID.Unavailable": true,
+}
+
+// EC2Source resolves metadata with the EC2 API
+type EC2Source struct {
+	client   *http.Client
+	creds    calibration.Credentials
+	endpoint string // Overrides the regional endpoint (tests)
+	now      func() time.Time
+
+	mu            sync.Mutex
+	instanceTypes map[string]*InstanceType // region/name -> type, nil when unknown
+	images        map[string]*Image        // region/id -> image, nil when unknown
+}
+
+// NewEC2Source creates an EC2 source for the given credentials
+func NewEC2Source(creds calibration.Credentials) *EC2Source {
+	return &EC2Source{
+		client:        &http.Client{Timeout: 30 * time.Second},
+		creds:         creds,
+		now:           time.Now,
+		instanceTypes: make(map[string]*InstanceType),
+		images:        make(map[string]*Image),
+	}
+}
+
+// WithEndpoint overrides the regional EC2 endpoint (proxies, tests)
+func (s *EC2Source) WithEndpoint(endpoint string) *EC2Source {
+	s.endpoint = endpoint
+	return s
+}
+
+// Name returns the source name
+func (s *EC2Source) Name() string {
+	return "EC2"
+}
+
+// InstanceType describes an instance type
+func (s *EC2Source) InstanceType(ctx context.Context, region, name string) (*InstanceType, error) {
+	key := region + "/" + name
+	s.mu.Lock()
+	cached, ok := s.instanceTypes[key]
+	s.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	var out describeInstanceTypesResponse
+	found, err := s.call(ctx, region, url.Values{"Action": {"DescribeInstanceTypes"}, "InstanceType.1": {name}}, &out)
+	if err != nil {
+		return nil, err
+	}
+	var t *InstanceType
+	if found && len(out.InstanceTypes) > 0 {
+		item := out.InstanceTypes[0]
+		t = &InstanceType{
+			Name:      item.InstanceType,
+			VCPU:      item.DefaultVCPUs,
+			MemoryGiB: float64(item.MemoryMiB) / 1024,
+			Network:   item.NetworkPerformance,
+		}
+		if len(item.Architectures) > 0 {
+			t.Architecture = item.Architectures[0]
+		}
+	}
+
+	s.mu.Lock()
+	s.instanceTypes[key] = t
+	s.mu.Unlock()
+	return t, nil
+}
+
+// Image describes an AMI
+func (s *EC2Source) Image(ctx context.Context, region, id string) (*Image, error) {
+	key := region + "/" + id
+	s.mu.Lock()
+	cached, ok := s.images[key]
+	s.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	var out describeImagesResponse
+	found, err := s.call(ctx, region, url.Values{"Action": {"DescribeImages"}, "ImageId.1": {id}}, &out)
+	if err != nil {
+		return nil, err
+	}
+	var image *Image
+	if found && len(out.Images) > 0 {
+		item := out.Images[0]
+		image = &Image{ID: item.ImageID, Region: region, Name: item.Name, PlatformDetails: item.PlatformDetails}
+		for _, mapping := range item.BlockDeviceMappings {
+			if mapping.DeviceName == item.RootDeviceName {
+				image.RootVolumeType = mapping.VolumeType
+				image.RootVolumeGiB = mapping.VolumeSize
+			}
+		}
+	}
+
+	s.mu.Lock()
+	s.images[key] = image
+	s.mu.Unlock()
+	return image, nil
+}
+
+// call runs an EC2 query API action; found is false when EC2 reports the name does not exist
+func (s *EC2Source) call(ctx context.Context, region string, q url.Values, out interface{}) (found bool, err error) {
+	q.Set("Version", ec2APIVersion)
+	endpoint := s.endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://ec2.%s.amazonaws.com/", region)
+	}
+	req, err := calibration.SignedRequest(ctx, s.creds, "ec2", region, http.MethodGet,
+		endpoint+"?"+strings.ReplaceAll(q.Encode(), "+", "%20"), nil, nil, s.now())
+	if err != nil {
+		return false, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to query EC2: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return false, fmt.Errorf("failed to read EC2 response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var e ec2ErrorResponse
+		xml.Unmarshal(body, &e) // Best effort; the status is enough
+		if notFoundCodes[e.Code] {
+			return false, nil
+		}
+		if e.Code == "" {
+			return false, fmt.Errorf("EC2 %s returned HTTP %d", q.Get("Action"), resp.StatusCode)
+		}
+		return false, fmt.Errorf("EC2 %s returned HTTP %d: %s: %s", q.Get("Action"), resp.StatusCode, e.Code, e.Message)
+	}
+	if err := xml.Unmarshal(body, out); err != nil {
+		return false, fmt.Errorf("failed to parse EC2 response: %w", err)
+	}
+	return true, nil
+}
+
+type describeInstanceTypesResponse struct {
+	InstanceTypes []struct {
+		InstanceType       string   `xml:"instanceType"`
+		DefaultVCPUs       int      `xml:"vCpuInfo>defaultVCpus"`
+		MemoryMiB          int      `xml:"memoryInfo>sizeInMiB"`
+		NetworkPerformance string   `xml:"networkInfo>networkPerformance"`
+		Architectures      []string `xml:"processorInfo>supportedArchitectures>item"`
+	} `xml:"instanceTypeSet>item"`
+}
+
+type describeImagesResponse struct {
+	Images []struct {
+		ImageID             string `xml:"imageId"`
+		Name                string `xml:"name"`
+		PlatformDetails     string `xml:"platformDetails"`
+		RootDeviceName      string `xml:"rootDeviceName"`
+		BlockDeviceMappings []struct {
+			DeviceName string  `xml:"deviceName"`
+			VolumeSize float64 `xml:"ebs>volumeSize"`
+			VolumeType string  `xml:"ebs>volumeType"`
+		} `xml:"blockDeviceMapping>item"`
+	} `xml:"imagesSet>item"`
+}
+
+type ec2ErrorResponse struct {
+	Code    string `xml:"Errors>Error>Code"`
+	Message string `xml:"Errors>Error>Message"`
+}
@@ -0,0 +1,161 @@
+// Package awsmeta - Enrichment
+// The enricher fills in what plans leave to AWS defaults before resources are
+// mapped (the AMI's platform and root volume), and attaches instance hardware to
+// compute components after they are mapped.
+package awsmeta
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/iac"
+)
+
+// AttrPlatformDetails is the resource attribute the enricher sets from the AMI
+// The EC2 mapper prefers it to guessing the operating system from the AMI ID.
+const AttrPlatformDetails = "platform_details"
+
+// instanceResourceTypes are resource types launched from an AMI
+var instanceResourceTypes = map[string]bool{
+	"aws_instance":              true,
+	"aws_spot_instance_request": true,
+}
+
+// computeUsagePrefixes are the usage types of instance-hour components
+var computeUsagePrefixes = []string{"BoxUsage:", "SpotUsage:", "RDS:"}
+
+// Enricher resolves metadata from sources in order
+type Enricher struct {
+	sources []Source
+}
+
+// NewEnricher creates an enricher; earlier sources take precedence
+func NewEnricher(sources ...Source) *Enricher {
+	return &Enricher{sources: sources}
+}
+
+// InstanceType resolves an instance type; RDS classes resolve as their EC2 type
+func (e *Enricher) InstanceType(ctx context.Context, region, name string) (*InstanceType, error) {
+	name = strings.TrimPrefix(name, "db.")
+	return resolve(e.sources, func(s Source) (*InstanceType, error) {
+		return s.InstanceType(ctx, region, name)
+	})
+}
+
+// Image resolves an AMI
+func (e *Enricher) Image(ctx context.Context, region, id string) (*Image, error) {
+	return resolve(e.sources, func(s Source) (*Image, error) {
+		return s.Image(ctx, region, id)
+	})
+}
+
+// EnrichGraph sets AMI-derived attributes on instances before they are mapped:
+// the platform details, and the root volume when the plan leaves it to the AMI.
+// Lookup failures are returned as warnings; the instances keep their defaults.
+func (e *Enricher) EnrichGraph(ctx context.Context, graph *iac.Graph) []string {
+	addrs := make([]string, 0, len(graph.Nodes))
+	for addr, node := range graph.Nodes {
+		if instanceResourceTypes[node.Resource.Type] {
+			addrs = append(addrs, addr)
+		}
+	}
+	sort.Strings(addrs)
+
+	var warnings []string
+	for _, addr := range addrs {
+		node := graph.Nodes[addr]
+		attrs := node.Resource.Attributes
+		ami := billing.ExtractAttribute(attrs, "ami")
+		if ami == "" || billing.ExtractAttribute(attrs, AttrPlatformDetails) != "" {
+			continue
+		}
+		image, err := e.Image(ctx, node.Region, ami)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: AMI %s lookup failed: %v", addr, ami, err))
+			continue
+		}
+		if image == nil {
+			continue
+		}
+
+		enriched := make(map[string]interface{}, len(attrs)+2)
+		for k, v := range attrs {
+			enriched[k] = v
+		}
+		enriched[AttrPlatformDetails] = image.PlatformDetails
+		if root := rootBlockDevice(attrs, image); root != nil {
+			enriched["root_block_device"] = []interface{}{root}
+		}
+		node.Resource.Attributes = enriched
+	}
+	return warnings
+}
+
+// rootBlockDevice returns the root volume with the AMI's defaults filled in, or
+// nil when the plan sets it completely or the AMI has no root volume details
+func rootBlockDevice(attrs map[string]interface{}, image *Image) map[string]interface{} {
+	if image.RootVolumeGiB <= 0 && image.RootVolumeType == "" {
+		return nil
+	}
+	device := make(map[string]interface{})
+	if blocks, ok := attrs["root_block_device"].([]interface{}); ok && len(blocks) > 0 {
+		if planned, ok := blocks[0].(map[string]interface{}); ok {
+			for k, v := range planned {
+				device[k] = v
+			}
+		}
+	}
+	changed := false
+	if size, ok := device["volume_size"].(float64); (!ok || size <= 0) && image.RootVolumeGiB > 0 {
+		device["volume_size"] = image.RootVolumeGiB
+		changed = true
+	}
+	if volumeType, _ := device["volume_type"].(string); volumeType == "" && image.RootVolumeType != "" {
+		device["volume_type"] = image.RootVolumeType
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return device
+}
+
+// EnrichComponents attaches instance hardware to instance-hour components.
+// Instance types no source knows are skipped; lookup failures are returned as warnings.
+func (e *Enricher) EnrichComponents(ctx context.Context, components []billing.BillingComponent) []string {
+	var warnings []string
+	failed := make(map[string]bool)
+	for i := range components {
+		comp := &components[i]
+		instanceType := comp.Attributes["instanceType"]
+		if comp.Cloud != "aws" || instanceType == "" || comp.Hardware != nil || !isInstanceHours(comp.UsageType) {
+			continue
+		}
+		t, err := e.InstanceType(ctx, comp.Region, instanceType)
+		if err != nil {
+			if !failed[instanceType] {
+				failed[instanceType] = true
+				warnings = append(warnings, fmt.Sprintf("instance type %s lookup failed: %v", instanceType, err))
+			}
+			continue
+		}
+		if t != nil {
+			hw := t.Hardware()
+			comp.Hardware = &hw
+		}
+	}
+	return warnings
+}
+
+// isInstanceHours reports whether a usage type bills instance hours
+func isInstanceHours(usageType string) bool {
+	for _, prefix := range computeUsagePrefixes {
+		if strings.HasPrefix(usageType, prefix) {
+			return true
+		}
+	}
+	return false
+}
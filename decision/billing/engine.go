@@ -14,6 +14,7 @@ type BillingPeriod string
 
 const (
 	PeriodHourly    BillingPeriod = "hourly"
+	PeriodGBHourly  BillingPeriod = "gb_hourly" // memory/storage billed per GB per hour
 	PeriodDaily     BillingPeriod = "daily"
 	PeriodMonthly   BillingPeriod = "monthly"
 	PeriodPerRequest BillingPeriod = "per_request"
@@ -21,11 +22,30 @@ const (
 	PeriodPerUnit   BillingPeriod = "per_unit"
 )
 
+// PurchaseOption represents how capacity is bought
+type PurchaseOption string
+
+const (
+	PurchaseOnDemand PurchaseOption = "on_demand"
+	PurchaseSpot     PurchaseOption = "spot"
+)
+
+// Hardware is the capacity of the instance behind a compute component
+type Hardware struct {
+	VCPU      int     `json:"vcpu"`
+	MemoryGiB float64 `json:"memory_gib"`
+	
+	// GPUs, Inferentia or Trainium chips and the board power of each
+	Accelerators     int     `json:"accelerators,omitempty"`
+	AcceleratorWatts float64 `json:"accelerator_watts,omitempty"`
+}
+
 // BillingComponent represents an atomic billable unit
 type BillingComponent struct {
 	// Identity
 	ID           string `json:"id"`
 	ResourceAddr string `json:"resource_addr"` // Source Terraform resource
+	Module       string `json:"module,omitempty"` // Module path of the source resource
 	
 	// Billing dimensions
 	Cloud         string            `json:"cloud"`          // aws, azure, gcp
@@ -36,12 +56,25 @@ type BillingComponent struct {
 	BillingPeriod BillingPeriod     `json:"billing_period"`
 	Attributes    map[string]string `json:"attributes"`     // instanceType, os, etc.
 	
+	// Purchase option; empty means on-demand. Spot components keep on-demand
+	// attributes so they can fall back to the on-demand rate.
+	PurchaseOption PurchaseOption `json:"purchase_option,omitempty"`
+	
+	// Hardware behind compute components, when metadata enrichment resolved it.
+	// Kept out of Attributes, which filter Price List products.
+	Hardware *Hardware `json:"hardware,omitempty"`
+	
 	// Variance profile for usage prediction
 	VarianceProfile VarianceProfile `json:"variance_profile"`
 	
 	// Metadata
-	Description string   `json:"description"`
-	Tags        []string `json:"tags"` // compute, storage, network, etc.
+	Description  string            `json:"description"`
+	Tags         []string          `json:"tags"`                    // compute, storage, network, etc.
+	ResourceTags map[string]string `json:"resource_tags,omitempty"` // Tags/labels on the source resource
+	ResourceID   string            `json:"resource_id,omitempty"`   // Cloud ID or ARN of an existing source resource
+	
+	// Cost-driving attributes unknown until apply; such components are left symbolic
+	UnknownAttributes []string `json:"unknown_attributes,omitempty"`
 	
 	// Dependencies
 	DependsOn []string `json:"depends_on"` // Other component IDs
@@ -60,6 +93,10 @@ type VarianceProfile struct {
 	Confidence    float64  `json:"confidence"`    // 0-1 confidence in prediction
 	VolatilityScore float64 `json:"volatility"`  // How variable is usage
 	Assumptions   []string `json:"assumptions"`   // What we assumed
+	
+	// FixedHours marks hour-billed usage set as running hours (usage file,
+	// schedule) rather than derived from HoursPerMonth; timebases leave it as is
+	FixedHours bool `json:"fixed_hours,omitempty"`
 }
 
 // MappingError represents a failure to map a resource
@@ -87,10 +124,21 @@ type ResourceMapper interface {
 	SupportedAttributes() []string
 }
 
+// GraphMapper is implemented by mappers whose pricing depends on related resources
+// (e.g. an ECS service sized by its task definition). The engine prefers
+// MapWithGraph over MapToBillingComponents when available.
+type GraphMapper interface {
+	ResourceMapper
+	
+	// MapWithGraph converts a resource to billing components using the full graph
+	MapWithGraph(node *iac.GraphNode, graph *iac.Graph) ([]BillingComponent, []MappingError)
+}
+
 // Engine is the Billing Semantic Engine
 type Engine struct {
 	mappers  map[string]ResourceMapper
 	registry *MapperRegistry
+	fallback ResourceMapper // Maps types no mapper handles; nil leaves them unmapped
 }
 
 // NewEngine creates a new Billing Semantic Engine
@@ -113,6 +161,13 @@ func (e *Engine) RegisterMappers(mappers ...ResourceMapper) {
 	}
 }
 
+// WithFallbackMapper maps resource types without a mapper, e.g. with a
+// PlaceholderMapper. Those types are still reported as uncovered.
+func (e *Engine) WithFallbackMapper(m ResourceMapper) *Engine {
+	e.fallback = m
+	return e
+}
+
 // DecompositionResult contains the result of decomposing a graph
 type DecompositionResult struct {
 	Components    []BillingComponent `json:"components"`
@@ -129,6 +184,18 @@ type DecompositionResult struct {
 	UncoveredTypes []string `json:"uncovered_types"`
 }
 
+// UnmappedResources counts resources with critical mapping errors: billable
+// resources that produced no price-able components
+func (r *DecompositionResult) UnmappedResources() int {
+	addrs := make(map[string]bool)
+	for _, e := range r.MappingErrors {
+		if e.IsCritical {
+			addrs[e.ResourceAddr] = true
+		}
+	}
+	return len(addrs)
+}
+
 // Decompose converts an infrastructure graph into billing components
 func (e *Engine) Decompose(graph *iac.Graph) (*DecompositionResult, error) {
 	result := &DecompositionResult{
@@ -160,27 +227,46 @@ func (e *Engine) Decompose(graph *iac.Graph) (*DecompositionResult, error) {
 		
 		// Find mapper for this resource type
 		mapper := e.findMapper(node.Resource.Type)
+		fallback := false
 		if mapper == nil {
 			// No mapper - record as uncovered
 			uncoveredTypesMap[node.Resource.Type] = true
+			reason := "no mapper registered for resource type"
+			if e.fallback != nil {
+				reason += "; fallback mapper used"
+			}
 			result.MappingErrors = append(result.MappingErrors, MappingError{
 				ResourceAddr: node.Resource.Address,
 				ResourceType: node.Resource.Type,
-				Reason:       "no mapper registered for resource type",
+				Reason:       reason,
 				IsCritical:   false,
 			})
-			continue
+			if e.fallback == nil {
+				continue
+			}
+			mapper, fallback = e.fallback, true
 		}
 		
 		// Map to billing components
-		components, mappingErrors := mapper.MapToBillingComponents(node)
+		var components []BillingComponent
+		var mappingErrors []MappingError
+		if gm, ok := mapper.(GraphMapper); ok {
+			components, mappingErrors = gm.MapWithGraph(node, graph)
+		} else {
+			components, mappingErrors = mapper.MapToBillingComponents(node)
+		}
+		if unknown := UnknownAttributes(node, mapper); len(unknown) > 0 {
+			components, mappingErrors = markUnknown(node, mapper, unknown, components, mappingErrors)
+		}
 		
 		// Track mapping errors
 		result.MappingErrors = append(result.MappingErrors, mappingErrors...)
 		
 		if len(components) > 0 {
 			result.ResourcesMapped++
-			coveredTypesMap[node.Resource.Type] = true
+			if !fallback {
+				coveredTypesMap[node.Resource.Type] = true
+			}
 			
 			// Process each component
 			for i := range components {
@@ -191,8 +277,15 @@ func (e *Engine) Decompose(graph *iac.Graph) (*DecompositionResult, error) {
 					comp.ID = fmt.Sprintf("%s-%d", node.Resource.Address, i)
 				}
 				
-				// Set resource address
+				// Set resource address and tags
 				comp.ResourceAddr = node.Resource.Address
+				comp.Module = node.Resource.Module
+				if comp.ResourceTags == nil {
+					comp.ResourceTags = ExtractResourceTags(node.Resource.Attributes)
+				}
+				if comp.ResourceID == "" {
+					comp.ResourceID = ExtractResourceID(node.Resource.Attributes)
+				}
 				
 				// Resolve component dependencies from resource dependencies
 				comp.DependsOn = e.resolveComponentDependencies(node, componentsByResource)
@@ -220,6 +313,15 @@ func (e *Engine) Decompose(graph *iac.Graph) (*DecompositionResult, error) {
 	return result, nil
 }
 
+// MapperFor returns the mapper Decompose uses for a resource type, and
+// whether it is the fallback mapper; nil when the type is left unmapped
+func (e *Engine) MapperFor(resourceType string) (ResourceMapper, bool) {
+	if m := e.findMapper(resourceType); m != nil {
+		return m, false
+	}
+	return e.fallback, e.fallback != nil
+}
+
 // findMapper finds the appropriate mapper for a resource type
 func (e *Engine) findMapper(resourceType string) ResourceMapper {
 	// Exact match first
@@ -352,6 +454,33 @@ func ExtractAttributeBool(attrs map[string]interface{}, key string, defaultVal b
 	return defaultVal
 }
 
+// ExtractResourceTags returns a resource's tags (AWS tags_all/tags, GCP labels)
+// tags_all includes provider default_tags, so it wins over tags.
+func ExtractResourceTags(attrs map[string]interface{}) map[string]string {
+	for _, key := range []string{"tags_all", "tags", "labels"} {
+		m, ok := attrs[key].(map[string]interface{})
+		if !ok || len(m) == 0 {
+			continue
+		}
+		tags := make(map[string]string, len(m))
+		for k, v := range m {
+			if s, ok := v.(string); ok {
+				tags[k] = s
+			}
+		}
+		return tags
+	}
+	return nil
+}
+
+// ExtractResourceID returns the ARN or ID of a resource; empty until it is created
+func ExtractResourceID(attrs map[string]interface{}) string {
+	if arn := ExtractAttribute(attrs, "arn"); arn != "" {
+		return arn
+	}
+	return ExtractAttribute(attrs, "id")
+}
+
 // ExtractNestedAttribute extracts a nested attribute using dot notation
 func ExtractNestedAttribute(attrs map[string]interface{}, path string) interface{} {
 	parts := strings.Split(path, ".")
@@ -393,6 +522,24 @@ func NewDefaultVarianceProfile(baselineHours float64) VarianceProfile {
 	}
 }
 
+// NewSpotVarianceProfile creates a variance profile for interruptible capacity
+// Interruptions and replacement gaps widen the spread versus on-demand.
+func NewSpotVarianceProfile(baselineHours float64) VarianceProfile {
+	return VarianceProfile{
+		BaselineUsage: baselineHours,
+		MinUsage:      baselineHours * 0.5,
+		MaxUsage:      baselineHours * 1.0,
+		P50Usage:      baselineHours * 0.85,
+		P90Usage:      baselineHours * 1.0,
+		Confidence:    0.6,
+		VolatilityScore: 0.4,
+		Assumptions: []string{
+			"Spot capacity: priced from spot price history when available",
+			"Interruptions may reduce running hours",
+		},
+	}
+}
+
 // NewEnvironmentVarianceProfile creates environment-aware variance profile
 func NewEnvironmentVarianceProfile(env string, fullUsage float64) VarianceProfile {
 	switch strings.ToLower(env) {
@@ -0,0 +1,412 @@
+// Package aws provides Redshift and EMR cluster mappers
+package aws
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"terraform-cost/decision/awsmeta"
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/iac"
+)
+
+// defaultRMSGBPerNode is the heuristic Redshift managed storage of each RA3 node
+const defaultRMSGBPerNode = 500
+
+// =============================================================================
+// Redshift Cluster Mapper
+// =============================================================================
+
+// RedshiftClusterMapper maps aws_redshift_cluster to node hours
+// RA3 nodes also pay for managed storage, which the plan does not size.
+type RedshiftClusterMapper struct{}
+
+// NewRedshiftClusterMapper creates a new Redshift cluster mapper
+func NewRedshiftClusterMapper() *RedshiftClusterMapper {
+	return &RedshiftClusterMapper{}
+}
+
+// ResourceType returns the Terraform resource type
+func (m *RedshiftClusterMapper) ResourceType() string {
+	return "aws_redshift_cluster"
+}
+
+// SupportedAttributes returns attributes this mapper uses
+func (m *RedshiftClusterMapper) SupportedAttributes() []string {
+	return []string{"node_type", "number_of_nodes", "cluster_type"}
+}
+
+// MapToBillingComponents converts a cluster to node hours and RA3 managed storage
+func (m *RedshiftClusterMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
+	attrs := node.Resource.Attributes
+	addr := node.Resource.Address
+
+	nodeType := billing.ExtractAttribute(attrs, "node_type")
+	if nodeType == "" {
+		return nil, []billing.MappingError{{
+			ResourceAddr: addr,
+			ResourceType: node.Resource.Type,
+			Reason:       "node_type is required to price a Redshift cluster",
+			IsCritical:   true,
+		}}
+	}
+	nodes := billing.ExtractAttributeInt(attrs, "number_of_nodes", 1)
+	if strings.EqualFold(billing.ExtractAttribute(attrs, "cluster_type"), "single-node") {
+		nodes = 1
+	}
+
+	components := []billing.BillingComponent{{
+		ID:              fmt.Sprintf("%s-nodes", addr),
+		Cloud:           "aws",
+		Service:         "AmazonRedshift",
+		ProductFamily:   "Compute Instance",
+		Region:          node.Region,
+		UsageType:       fmt.Sprintf("Node:%s", nodeType),
+		BillingPeriod:   billing.PeriodHourly,
+		Attributes:      map[string]string{"instanceType": nodeType},
+		Description:     fmt.Sprintf("Redshift %d× %s node hours", nodes, nodeType),
+		Tags:            []string{"analytics", "redshift"},
+		VarianceProfile: billing.NewDefaultVarianceProfile(float64(nodes) * billing.HoursPerMonth),
+	}}
+
+	if strings.HasPrefix(nodeType, "ra3.") {
+		gb := float64(nodes * defaultRMSGBPerNode)
+		components = append(components, billing.BillingComponent{
+			ID:            fmt.Sprintf("%s-storage", addr),
+			Cloud:         "aws",
+			Service:       "AmazonRedshift",
+			ProductFamily: "Redshift Managed Storage",
+			Region:        node.Region,
+			UsageType:     "RMS:GB-Mo",
+			BillingPeriod: billing.PeriodMonthly,
+			Attributes:    map[string]string{},
+			Description:   "Redshift RA3 managed storage",
+			Tags:          []string{"analytics", "redshift", "storage"},
+			VarianceProfile: billing.VarianceProfile{
+				BaselineUsage: gb,
+				P50Usage:      gb / 2,
+				P90Usage:      gb * 2,
+				Confidence:    0.5,
+				Assumptions:   []string{fmt.Sprintf("%d GB of managed storage per node", defaultRMSGBPerNode)},
+			},
+		})
+	}
+	return components, nil
+}
+
+// =============================================================================
+// EMR Cluster Mapper
+// =============================================================================
+
+// emrTaskTypes are the resources that add task capacity to a cluster
+var emrTaskTypes = map[string]bool{
+	"aws_emr_instance_group": true,
+	"aws_emr_instance_fleet": true,
+}
+
+// emrGroup is the capacity of one EMR instance group or fleet
+type emrGroup struct {
+	Role         string // master, core or task; distinguishes component IDs
+	InstanceType string
+	Count        int
+	Spot         bool
+	EBSType      string
+	EBSGB        float64 // Per instance
+}
+
+// EMRClusterMapper maps aws_emr_cluster to EC2 instance hours, the EMR
+// surcharge on each instance and EBS volumes
+// Task groups and fleets declared as separate resources are priced on the
+// cluster they attach to.
+type EMRClusterMapper struct{}
+
+// NewEMRClusterMapper creates a new EMR cluster mapper
+func NewEMRClusterMapper() *EMRClusterMapper {
+	return &EMRClusterMapper{}
+}
+
+// ResourceType returns the Terraform resource type
+func (m *EMRClusterMapper) ResourceType() string {
+	return "aws_emr_cluster"
+}
+
+// SupportedAttributes returns attributes this mapper uses
+func (m *EMRClusterMapper) SupportedAttributes() []string {
+	return []string{
+		"master_instance_group",
+		"core_instance_group",
+		"master_instance_fleet",
+		"core_instance_fleet",
+	}
+}
+
+// MapToBillingComponents prices the master and core capacity; task groups need the graph
+func (m *EMRClusterMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
+	return m.MapWithGraph(node, nil)
+}
+
+// MapWithGraph converts a cluster and its task groups to instance, surcharge and volume components
+func (m *EMRClusterMapper) MapWithGraph(node *iac.GraphNode, graph *iac.Graph) ([]billing.BillingComponent, []billing.MappingError) {
+	attrs := node.Resource.Attributes
+	var groups []emrGroup
+	for _, role := range []string{"master", "core"} {
+		if g := firstNested(attrs, role+"_instance_group"); g != nil {
+			groups = append(groups, emrInstanceGroup(role, g))
+		} else if f := firstNested(attrs, role+"_instance_fleet"); f != nil {
+			groups = append(groups, emrInstanceFleet(role, f)...)
+		}
+	}
+
+	for i, task := range findEMRTaskGroups(node, graph) {
+		role := "task"
+		if i > 0 {
+			role = fmt.Sprintf("task-%d", i+1)
+		}
+		groups = append(groups, emrTaskGroup(role, task)...)
+	}
+	return emrComponents(node, groups)
+}
+
+// findEMRTaskGroups returns the task groups and fleets attached to a cluster, by address
+func findEMRTaskGroups(node *iac.GraphNode, graph *iac.Graph) []*iac.GraphNode {
+	if graph == nil {
+		return nil
+	}
+	var tasks []*iac.GraphNode
+	for _, n := range graph.Nodes {
+		if emrTaskTypes[n.Resource.Type] && containsString(n.Dependencies, node.Resource.Address) {
+			tasks = append(tasks, n)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Resource.Address < tasks[j].Resource.Address })
+	return tasks
+}
+
+// emrTaskGroup reads an aws_emr_instance_group or aws_emr_instance_fleet resource
+func emrTaskGroup(role string, task *iac.GraphNode) []emrGroup {
+	if task.Resource.Type == "aws_emr_instance_fleet" {
+		return emrInstanceFleet(role, task.Resource.Attributes)
+	}
+	return []emrGroup{emrInstanceGroup(role, task.Resource.Attributes)}
+}
+
+// emrInstanceGroup reads an instance group; a bid price makes it spot capacity
+func emrInstanceGroup(role string, g map[string]interface{}) emrGroup {
+	group := emrGroup{
+		Role:         role,
+		InstanceType: billing.ExtractAttribute(g, "instance_type"),
+		Count:        billing.ExtractAttributeInt(g, "instance_count", 1),
+		Spot:         billing.ExtractAttribute(g, "bid_price") != "",
+	}
+	group.EBSType, group.EBSGB = emrEBS(g)
+	return group
+}
+
+// emrInstanceFleet reads an instance fleet as up to two groups, on-demand and
+// spot, of its first instance type sized to the fleet's target capacity
+func emrInstanceFleet(role string, f map[string]interface{}) []emrGroup {
+	config := firstNested(f, "instance_type_configs")
+	if config == nil {
+		return nil
+	}
+	instanceType := billing.ExtractAttribute(config, "instance_type")
+	weight := billing.ExtractAttributeFloat(config, "weighted_capacity", 1)
+	if weight <= 0 {
+		weight = 1
+	}
+	ebsType, ebsGB := emrEBS(config)
+
+	var groups []emrGroup
+	for _, target := range []struct {
+		attr string
+		spot bool
+	}{{"target_on_demand_capacity", false}, {"target_spot_capacity", true}} {
+		capacity := billing.ExtractAttributeFloat(f, target.attr, 0)
+		if capacity <= 0 {
+			continue
+		}
+		groups = append(groups, emrGroup{
+			Role:         role,
+			InstanceType: instanceType,
+			Count:        int(math.Ceil(capacity / weight)),
+			Spot:         target.spot,
+			EBSType:      ebsType,
+			EBSGB:        ebsGB,
+		})
+	}
+	return groups
+}
+
+// emrEBS returns the volume type and total GB attached to each instance of a group
+func emrEBS(g map[string]interface{}) (string, float64) {
+	configs, ok := g["ebs_config"].([]interface{})
+	if !ok {
+		return "", 0
+	}
+	volumeType, gb := "", 0.0
+	for _, c := range configs {
+		cm, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if volumeType == "" {
+			volumeType = billing.ExtractAttribute(cm, "type")
+		}
+		gb += billing.ExtractAttributeFloat(cm, "size", 0) *
+			billing.ExtractAttributeFloat(cm, "volumes_per_instance", 1)
+	}
+	return volumeType, gb
+}
+
+// emrComponents prices the groups of one resource
+func emrComponents(node *iac.GraphNode, groups []emrGroup) ([]billing.BillingComponent, []billing.MappingError) {
+	addr := node.Resource.Address
+	var components []billing.BillingComponent
+	var errs []billing.MappingError
+	for _, g := range groups {
+		if g.InstanceType == "" {
+			errs = append(errs, billing.MappingError{
+				ResourceAddr: addr,
+				ResourceType: node.Resource.Type,
+				Reason:       fmt.Sprintf("%s instances have no instance type", g.Role),
+				IsCritical:   false,
+			})
+			continue
+		}
+		if g.Count == 0 {
+			continue
+		}
+		hours := float64(g.Count) * billing.HoursPerMonth
+
+		purchase, label := billing.PurchaseOnDemand, "on-demand"
+		usageType := fmt.Sprintf("BoxUsage:%s", g.InstanceType)
+		profile := billing.NewDefaultVarianceProfile(hours)
+		if g.Spot {
+			purchase, label = billing.PurchaseSpot, "spot"
+			usageType = fmt.Sprintf("SpotUsage:%s", g.InstanceType)
+			profile = billing.NewSpotVarianceProfile(hours)
+		}
+		id := fmt.Sprintf("%s-%s", addr, g.Role)
+		if g.Spot {
+			id += "-spot"
+		}
+
+		components = append(components,
+			billing.BillingComponent{
+				ID:              id + "-ec2",
+				Cloud:           "aws",
+				Service:         "AmazonEC2",
+				ProductFamily:   "Compute Instance",
+				Region:          node.Region,
+				UsageType:       usageType,
+				BillingPeriod:   billing.PeriodHourly,
+				Attributes:      ec2ComputeAttributes(g.InstanceType, awsmeta.ParsePlatform("Linux/UNIX"), "Shared"),
+				PurchaseOption:  purchase,
+				Description:     fmt.Sprintf("EMR %s %d× %s %s compute hours", g.Role, g.Count, g.InstanceType, label),
+				Tags:            []string{"analytics", "emr", "ec2", label},
+				VarianceProfile: profile,
+			},
+			billing.BillingComponent{
+				ID:              id + "-emr",
+				Cloud:           "aws",
+				Service:         "ElasticMapReduce",
+				ProductFamily:   "Elastic Map Reduce Instance",
+				Region:          node.Region,
+				UsageType:       fmt.Sprintf("BoxUsage:%s", g.InstanceType),
+				BillingPeriod:   billing.PeriodHourly,
+				Attributes:      map[string]string{"instanceType": g.InstanceType, "softwareType": "EMR"},
+				Description:     fmt.Sprintf("EMR %s %d× %s surcharge", g.Role, g.Count, g.InstanceType),
+				Tags:            []string{"analytics", "emr"},
+				VarianceProfile: billing.NewDefaultVarianceProfile(hours),
+			},
+		)
+
+		if g.EBSGB > 0 {
+			volumeType := g.EBSType
+			if volumeType == "" {
+				volumeType = "gp2"
+			}
+			gb := g.EBSGB * float64(g.Count)
+			components = append(components, billing.BillingComponent{
+				ID:            id + "-ebs",
+				Cloud:         "aws",
+				Service:       "AmazonEC2",
+				ProductFamily: "Storage",
+				Region:        node.Region,
+				UsageType:     fmt.Sprintf("EBS:VolumeUsage.%s", volumeType),
+				BillingPeriod: billing.PeriodMonthly,
+				Attributes:    map[string]string{"volumeType": volumeType},
+				Description:   fmt.Sprintf("EMR %s EBS %s volumes (%.0f GB)", g.Role, volumeType, gb),
+				Tags:          []string{"analytics", "emr", "storage"},
+				VarianceProfile: billing.VarianceProfile{
+					BaselineUsage: gb,
+					MinUsage:      gb,
+					MaxUsage:      gb,
+					P50Usage:      gb,
+					P90Usage:      gb,
+					Confidence:    0.99,
+					Assumptions:   []string{"Volume size is fixed as provisioned"},
+				},
+			})
+		}
+	}
+	return components, errs
+}
+
+// =============================================================================
+// EMR Task Group Mapper
+// =============================================================================
+
+// EMRTaskGroupMapper maps aws_emr_instance_group and aws_emr_instance_fleet
+// Groups whose cluster is in the plan are priced by EMRClusterMapper; others
+// attach to an existing cluster and are priced on their own.
+type EMRTaskGroupMapper struct {
+	resourceType string
+}
+
+// NewEMRInstanceGroupMapper creates a mapper for aws_emr_instance_group
+func NewEMRInstanceGroupMapper() *EMRTaskGroupMapper {
+	return &EMRTaskGroupMapper{resourceType: "aws_emr_instance_group"}
+}
+
+// NewEMRInstanceFleetMapper creates a mapper for aws_emr_instance_fleet
+func NewEMRInstanceFleetMapper() *EMRTaskGroupMapper {
+	return &EMRTaskGroupMapper{resourceType: "aws_emr_instance_fleet"}
+}
+
+// ResourceType returns the Terraform resource type
+func (m *EMRTaskGroupMapper) ResourceType() string {
+	return m.resourceType
+}
+
+// SupportedAttributes returns attributes this mapper uses
+func (m *EMRTaskGroupMapper) SupportedAttributes() []string {
+	return []string{
+		"cluster_id",
+		"instance_type",
+		"instance_count",
+		"bid_price",
+		"instance_type_configs",
+		"target_on_demand_capacity",
+		"target_spot_capacity",
+	}
+}
+
+// MapToBillingComponents prices the group on its own
+func (m *EMRTaskGroupMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
+	return m.MapWithGraph(node, nil)
+}
+
+// MapWithGraph prices the group unless its cluster is in the plan
+func (m *EMRTaskGroupMapper) MapWithGraph(node *iac.GraphNode, graph *iac.Graph) ([]billing.BillingComponent, []billing.MappingError) {
+	if graph != nil {
+		for _, dep := range node.Dependencies {
+			if n, ok := graph.Nodes[dep]; ok && n.Resource.Type == "aws_emr_cluster" {
+				return nil, nil
+			}
+		}
+	}
+	return emrComponents(node, emrTaskGroup("task", node))
+}
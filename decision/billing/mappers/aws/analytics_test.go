@@ -0,0 +1,111 @@
+package aws
+
+import (
+	"testing"
+
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/iac"
+)
+
+func TestRedshiftClusterMapperRA3Storage(t *testing.T) {
+	cluster := &iac.GraphNode{Resource: iac.ResourceNode{
+		Address:    "aws_redshift_cluster.dw",
+		Type:       "aws_redshift_cluster",
+		Attributes: map[string]interface{}{"node_type": "ra3.xlplus", "number_of_nodes": float64(2)},
+	}}
+	components, errs := NewRedshiftClusterMapper().MapToBillingComponents(cluster)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(components) != 2 {
+		t.Fatalf("expected node hours and managed storage, got %d", len(components))
+	}
+	if got := components[0].VarianceProfile.BaselineUsage; got != 2*730 {
+		t.Errorf("node hours = %v, want %v", got, 2*730)
+	}
+	if components[1].ID != "aws_redshift_cluster.dw-storage" {
+		t.Errorf("storage component = %s", components[1].ID)
+	}
+
+	cluster.Resource.Attributes["node_type"] = "dc2.large"
+	components, _ = NewRedshiftClusterMapper().MapToBillingComponents(cluster)
+	if len(components) != 1 {
+		t.Errorf("dc2 nodes include storage, got %d components", len(components))
+	}
+}
+
+func TestEMRClusterMapperExpandsTaskGroups(t *testing.T) {
+	cluster := &iac.GraphNode{
+		Resource: iac.ResourceNode{
+			Address: "aws_emr_cluster.spark",
+			Type:    "aws_emr_cluster",
+			Attributes: map[string]interface{}{
+				"master_instance_group": []interface{}{map[string]interface{}{"instance_type": "m5.xlarge"}},
+				"core_instance_group": []interface{}{map[string]interface{}{
+					"instance_type":  "r5.2xlarge",
+					"instance_count": float64(3),
+					"ebs_config": []interface{}{map[string]interface{}{
+						"size": float64(100), "type": "gp3", "volumes_per_instance": float64(2),
+					}},
+				}},
+			},
+		},
+		Region: "us-east-1",
+	}
+	task := &iac.GraphNode{
+		Resource: iac.ResourceNode{
+			Address: "aws_emr_instance_group.task",
+			Type:    "aws_emr_instance_group",
+			Attributes: map[string]interface{}{
+				"instance_type": "r5.2xlarge", "instance_count": float64(4), "bid_price": "0.30",
+			},
+		},
+		Dependencies: []string{cluster.Resource.Address},
+	}
+	graph := &iac.Graph{Nodes: map[string]*iac.GraphNode{
+		cluster.Resource.Address: cluster,
+		task.Resource.Address:    task,
+	}}
+
+	components, errs := NewEMRClusterMapper().MapWithGraph(cluster, graph)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	byID := make(map[string]billing.BillingComponent)
+	for _, c := range components {
+		byID[c.ID] = c
+	}
+	want := map[string]float64{
+		"aws_emr_cluster.spark-master-ec2":    730,
+		"aws_emr_cluster.spark-master-emr":    730,
+		"aws_emr_cluster.spark-core-ec2":      3 * 730,
+		"aws_emr_cluster.spark-core-emr":      3 * 730,
+		"aws_emr_cluster.spark-core-ebs":      3 * 200,
+		"aws_emr_cluster.spark-task-spot-ec2": 4 * 730,
+		"aws_emr_cluster.spark-task-spot-emr": 4 * 730,
+	}
+	if len(byID) != len(want) {
+		t.Fatalf("got %d components, want %d", len(byID), len(want))
+	}
+	for id, usage := range want {
+		c, ok := byID[id]
+		if !ok {
+			t.Errorf("missing %s", id)
+			continue
+		}
+		if c.VarianceProfile.BaselineUsage != usage {
+			t.Errorf("%s usage = %v, want %v", id, c.VarianceProfile.BaselineUsage, usage)
+		}
+	}
+	if byID["aws_emr_cluster.spark-task-spot-ec2"].PurchaseOption != billing.PurchaseSpot {
+		t.Error("task group with a bid price should be spot capacity")
+	}
+
+	// Priced on the cluster, the task group has no components of its own
+	if components, _ := NewEMRInstanceGroupMapper().MapWithGraph(task, graph); len(components) != 0 {
+		t.Errorf("task group priced twice: %d components", len(components))
+	}
+	if components, _ := NewEMRInstanceGroupMapper().MapWithGraph(task, nil); len(components) != 2 {
+		t.Errorf("standalone task group: got %d components, want 2", len(components))
+	}
+}
@@ -0,0 +1,227 @@
+// Package aws provides spot and Auto Scaling mappers
+package aws
+
+import (
+	"fmt"
+	"math"
+
+	"terraform-cost/decision/awsmeta"
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/iac"
+)
+
+// =============================================================================
+// Spot Instance Request Mapper
+// =============================================================================
+
+// SpotInstanceRequestMapper maps aws_spot_instance_request using the EC2 mapper
+// The EC2 mapper treats this resource type as spot capacity.
+type SpotInstanceRequestMapper struct {
+	EC2InstanceMapper
+}
+
+// NewSpotInstanceRequestMapper creates a new spot instance request mapper
+func NewSpotInstanceRequestMapper() *SpotInstanceRequestMapper {
+	return &SpotInstanceRequestMapper{}
+}
+
+// ResourceType returns the Terraform resource type
+func (m *SpotInstanceRequestMapper) ResourceType() string {
+	return "aws_spot_instance_request"
+}
+
+// =============================================================================
+// Auto Scaling Group Mapper
+// =============================================================================
+
+// AutoscalingGroupMapper maps aws_autoscaling_group to on-demand and spot compute
+// The instance type comes from mixed_instances_policy overrides, or from the
+// launch template or launch configuration the group references.
+type AutoscalingGroupMapper struct{}
+
+// NewAutoscalingGroupMapper creates a new Auto Scaling group mapper
+func NewAutoscalingGroupMapper() *AutoscalingGroupMapper {
+	return &AutoscalingGroupMapper{}
+}
+
+// ResourceType returns the Terraform resource type
+func (m *AutoscalingGroupMapper) ResourceType() string {
+	return "aws_autoscaling_group"
+}
+
+// SupportedAttributes returns attributes this mapper uses
+func (m *AutoscalingGroupMapper) SupportedAttributes() []string {
+	return []string{
+		"desired_capacity",
+		"min_size",
+		"max_size",
+		"mixed_instances_policy",
+		"launch_template",
+		"launch_configuration",
+	}
+}
+
+// MapToBillingComponents prices groups whose mixed instances policy names the instance type
+func (m *AutoscalingGroupMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
+	return m.MapWithGraph(node, nil)
+}
+
+// MapWithGraph splits desired capacity into on-demand and spot compute hours
+func (m *AutoscalingGroupMapper) MapWithGraph(node *iac.GraphNode, graph *iac.Graph) ([]billing.BillingComponent, []billing.MappingError) {
+	attrs := node.Resource.Attributes
+
+	minSize := billing.ExtractAttributeInt(attrs, "min_size", 0)
+	maxSize := billing.ExtractAttributeInt(attrs, "max_size", minSize)
+	desired := billing.ExtractAttributeInt(attrs, "desired_capacity", minSize)
+
+	policy := firstNested(attrs, "mixed_instances_policy")
+	instanceType := asgInstanceType(policy)
+	if instanceType == "" {
+		instanceType = launchSourceInstanceType(node, graph)
+	}
+	if instanceType == "" {
+		return nil, []billing.MappingError{{
+			ResourceAddr: node.Resource.Address,
+			ResourceType: node.Resource.Type,
+			Reason:       "instance type not found: the group's launch template or configuration is not in the plan or sets no instance_type",
+			IsCritical:   true,
+		}}
+	}
+	if desired == 0 {
+		return nil, nil
+	}
+
+	onDemand, spot := splitCapacity(desired, firstNested(policy, "instances_distribution"))
+
+	components := make([]billing.BillingComponent, 0, 2)
+	if onDemand > 0 {
+		c := asgComputeComponent(node, instanceType, onDemand, desired, maxSize, billing.PurchaseOnDemand)
+		components = append(components, c)
+	}
+	if spot > 0 {
+		c := asgComputeComponent(node, instanceType, spot, desired, maxSize, billing.PurchaseSpot)
+		components = append(components, c)
+	}
+	return components, nil
+}
+
+// splitCapacity applies an instances_distribution to the desired capacity
+// AWS defaults to 0 base capacity and 100% on-demand above base.
+func splitCapacity(desired int, dist map[string]interface{}) (onDemand, spot int) {
+	if dist == nil {
+		return desired, 0
+	}
+
+	base := billing.ExtractAttributeInt(dist, "on_demand_base_capacity", 0)
+	pct := billing.ExtractAttributeInt(dist, "on_demand_percentage_above_base_capacity", 100)
+
+	if base > desired {
+		base = desired
+	}
+	above := desired - base
+	onDemand = base + int(math.Ceil(float64(above)*float64(pct)/100))
+	return onDemand, desired - onDemand
+}
+
+// asgInstanceType returns the first instance type override of a mixed instances policy
+func asgInstanceType(policy map[string]interface{}) string {
+	lt := firstNested(policy, "launch_template")
+	if lt == nil {
+		return ""
+	}
+	overrides, ok := lt["override"].([]interface{})
+	if !ok {
+		return ""
+	}
+	for _, o := range overrides {
+		if om, ok := o.(map[string]interface{}); ok {
+			if t := billing.ExtractAttribute(om, "instance_type"); t != "" {
+				return t
+			}
+		}
+	}
+	return ""
+}
+
+// launchSourceTypes are the resources that define an Auto Scaling group's instances
+var launchSourceTypes = map[string]bool{
+	"aws_launch_template":      true,
+	"aws_launch_configuration": true,
+}
+
+// launchSourceInstanceType returns the instance type of the launch template
+// or configuration a group depends on, or names literally
+func launchSourceInstanceType(node *iac.GraphNode, graph *iac.Graph) string {
+	if graph == nil {
+		return ""
+	}
+	for _, dep := range node.Dependencies {
+		if n, ok := graph.Nodes[dep]; ok && launchSourceTypes[n.Resource.Type] {
+			return billing.ExtractAttribute(n.Resource.Attributes, "instance_type")
+		}
+	}
+
+	// Sources created outside the group's configuration are referenced by name
+	attrs := node.Resource.Attributes
+	names := map[string]string{"aws_launch_configuration": billing.ExtractAttribute(attrs, "launch_configuration")}
+	if lt := firstNested(attrs, "launch_template"); lt != nil {
+		names["aws_launch_template"] = billing.ExtractAttribute(lt, "name")
+	}
+	for _, n := range graph.Nodes {
+		name := names[n.Resource.Type]
+		if name != "" && billing.ExtractAttribute(n.Resource.Attributes, "name") == name {
+			return billing.ExtractAttribute(n.Resource.Attributes, "instance_type")
+		}
+	}
+	return ""
+}
+
+func asgComputeComponent(node *iac.GraphNode, instanceType string, count, desired, maxSize int, option billing.PurchaseOption) billing.BillingComponent {
+	hours := float64(count) * billing.HoursPerMonth
+	id := fmt.Sprintf("%s-ondemand", node.Resource.Address)
+	usageType := fmt.Sprintf("BoxUsage:%s", instanceType)
+	label := "on-demand"
+	profile := billing.NewDefaultVarianceProfile(hours)
+	if option == billing.PurchaseSpot {
+		id = fmt.Sprintf("%s-spot", node.Resource.Address)
+		usageType = fmt.Sprintf("SpotUsage:%s", instanceType)
+		label = "spot"
+		profile = billing.NewSpotVarianceProfile(hours)
+	}
+
+	// Scale-out beyond desired capacity widens the upper bound
+	if maxSize > desired {
+		profile.MaxUsage = hours * float64(maxSize) / float64(desired)
+		profile.Assumptions = append(profile.Assumptions,
+			fmt.Sprintf("Group may scale from %d to %d instances", desired, maxSize))
+	}
+
+	return billing.BillingComponent{
+		ID:              id,
+		Cloud:           "aws",
+		Service:         "AmazonEC2",
+		ProductFamily:   "Compute Instance",
+		Region:          node.Region,
+		UsageType:       usageType,
+		BillingPeriod:   billing.PeriodHourly,
+		Attributes:      ec2ComputeAttributes(instanceType, awsmeta.ParsePlatform("Linux/UNIX"), "Shared"),
+		PurchaseOption:  option,
+		Hardware:        awsmeta.AcceleratedHardware(instanceType),
+		Description:     fmt.Sprintf("ASG %d× %s %s compute hours", count, instanceType, label),
+		Tags:            []string{"compute", "ec2", "autoscaling", label},
+		VarianceProfile: profile,
+	}
+}
+
+// firstNested returns the first element of a nested block
+func firstNested(attrs map[string]interface{}, key string) map[string]interface{} {
+	if attrs == nil {
+		return nil
+	}
+	if arr, ok := attrs[key].([]interface{}); ok && len(arr) > 0 {
+		if m, ok := arr[0].(map[string]interface{}); ok {
+			return m
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package aws
+
+import (
+	"testing"
+
+	"terraform-cost/decision/iac"
+)
+
+func TestAutoscalingGroupLaunchSource(t *testing.T) {
+	template := &iac.GraphNode{Resource: iac.ResourceNode{
+		Address:    "aws_launch_template.web",
+		Type:       "aws_launch_template",
+		Attributes: map[string]interface{}{"name": "web", "instance_type": "m5.large"},
+	}}
+	config := &iac.GraphNode{Resource: iac.ResourceNode{
+		Address:    "aws_launch_configuration.batch",
+		Type:       "aws_launch_configuration",
+		Attributes: map[string]interface{}{"name": "batch-v1", "instance_type": "c5.xlarge"},
+	}}
+	graph := &iac.Graph{Nodes: map[string]*iac.GraphNode{
+		template.Resource.Address: template,
+		config.Resource.Address:   config,
+	}}
+	group := func(attrs map[string]interface{}, deps ...string) *iac.GraphNode {
+		attrs["desired_capacity"] = float64(2)
+		return &iac.GraphNode{
+			Resource:     iac.ResourceNode{Address: "aws_autoscaling_group.web", Type: "aws_autoscaling_group", Attributes: attrs},
+			Dependencies: deps,
+			Region:       "us-east-1",
+		}
+	}
+
+	tests := []struct {
+		name string
+		node *iac.GraphNode
+		want string
+	}{
+		{"template dependency", group(map[string]interface{}{"launch_template": []interface{}{map[string]interface{}{"version": "$Latest"}}}, "aws_launch_template.web"), "m5.large"},
+		{"configuration by name", group(map[string]interface{}{"launch_configuration": "batch-v1"}), "c5.xlarge"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			components, errs := NewAutoscalingGroupMapper().MapWithGraph(tt.node, graph)
+			if len(errs) != 0 || len(components) != 1 {
+				t.Fatalf("got %d components, errors %v", len(components), errs)
+			}
+			if got := components[0].Attributes["instanceType"]; got != tt.want {
+				t.Errorf("instance type = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// A template outside the plan leaves the group unmapped, not free
+	_, errs := NewAutoscalingGroupMapper().MapWithGraph(group(map[string]interface{}{"launch_template": []interface{}{map[string]interface{}{"id": "lt-0abc"}}}), graph)
+	if len(errs) != 1 || !errs[0].IsCritical {
+		t.Errorf("errors = %v, want one critical error", errs)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"terraform-cost/decision/awsmeta"
 	"terraform-cost/decision/billing"
 	"terraform-cost/decision/iac"
 )
@@ -34,9 +35,21 @@ func (m *EC2InstanceMapper) SupportedAttributes() []string {
 		"root_block_device",
 		"ebs_block_device",
 		"credit_specification",
+		"instance_market_options",
 	}
 }
 
+// CostDrivingAttributes returns the attributes sizing the instance
+func (m *EC2InstanceMapper) CostDrivingAttributes() []string {
+	return []string{"instance_type"}
+}
+
+// CostDrivenBy reports whether the instance type sizes a component; the
+// instance's volumes are priced without it
+func (m *EC2InstanceMapper) CostDrivenBy(component billing.BillingComponent, path string) bool {
+	return path != "instance_type" || drivenByInstanceType(component)
+}
+
 // MapToBillingComponents converts an EC2 instance to billing components
 func (m *EC2InstanceMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
 	components := make([]billing.BillingComponent, 0)
@@ -49,15 +62,24 @@ func (m *EC2InstanceMapper) MapToBillingComponents(node *iac.GraphNode) ([]billi
 	if instanceType == "" {
 		errors = append(errors, billing.MappingError{
 			ResourceAddr: node.Resource.Address,
-			ResourceType: "aws_instance",
+			ResourceType: node.Resource.Type,
 			Reason:       "instance_type attribute is required",
 			IsCritical:   true,
 		})
-		return components, errors
+		// Volumes are priced without the instance type
+		return m.volumeComponents(node, attrs), errors
 	}
 	
-	// Determine OS from AMI (simplified - would need AMI lookup in production)
-	operatingSystem := m.inferOperatingSystem(attrs)
+	// Determine OS, SQL Server edition and license model from the AMI's
+	// platform, or guess from the AMI ID
+	platform := m.inferPlatform(attrs)
+	software := platform.OperatingSystem
+	if platform.PreInstalledSw != "NA" {
+		software += " with " + platform.PreInstalledSw
+	}
+	if platform.LicenseModel == awsmeta.LicenseBYOL {
+		software += " BYOL"
+	}
 	
 	// Tenancy
 	tenancy := billing.ExtractAttribute(attrs, "tenancy")
@@ -65,15 +87,11 @@ func (m *EC2InstanceMapper) MapToBillingComponents(node *iac.GraphNode) ([]billi
 		tenancy = "Shared"
 	}
 	
-	// Pre-installed software (simplified)
-	preInstalledSw := "NA"
-	
-	// Capacity status
-	capacityStatus := "Used"
-	
 	// ==========================================================================
 	// Component 1: EC2 Compute Hours
 	// ==========================================================================
+	spot := m.isSpot(node)
+	
 	computeComponent := billing.BillingComponent{
 		ID:            fmt.Sprintf("%s-compute", node.Resource.Address),
 		Cloud:         "aws",
@@ -82,36 +100,25 @@ func (m *EC2InstanceMapper) MapToBillingComponents(node *iac.GraphNode) ([]billi
 		Region:        node.Region,
 		UsageType:     fmt.Sprintf("BoxUsage:%s", instanceType),
 		BillingPeriod: billing.PeriodHourly,
-		Attributes: map[string]string{
-			"instanceType":       instanceType,
-			"operatingSystem":    operatingSystem,
-			"tenancy":            normalizeTenancy(tenancy),
-			"preInstalledSw":     preInstalledSw,
-			"capacityStatus":     capacityStatus,
-			"licenseModel":       "No License required",
-		},
-		Description: fmt.Sprintf("EC2 %s (%s) compute hours", instanceType, operatingSystem),
+		Attributes:    ec2ComputeAttributes(instanceType, platform, tenancy),
+		Description: fmt.Sprintf("EC2 %s (%s) compute hours", instanceType, software),
 		Tags:        []string{"compute", "ec2"},
-		VarianceProfile: billing.NewDefaultVarianceProfile(730), // 730 hours/month
+		VarianceProfile: billing.NewDefaultVarianceProfile(billing.HoursPerMonth),
 	}
-	components = append(components, computeComponent)
-	
-	// ==========================================================================
-	// Component 2: Root Block Device (EBS)
-	// ==========================================================================
-	if rootDevice := m.extractRootBlockDevice(attrs); rootDevice != nil {
-		ebsComponent := m.createEBSComponent(node, rootDevice, "root", 0)
-		components = append(components, ebsComponent)
+	computeComponent.Hardware = awsmeta.AcceleratedHardware(instanceType)
+	if spot {
+		computeComponent.UsageType = fmt.Sprintf("SpotUsage:%s", instanceType)
+		computeComponent.PurchaseOption = billing.PurchaseSpot
+		computeComponent.Description = fmt.Sprintf("EC2 %s (%s) spot compute hours", instanceType, software)
+		computeComponent.Tags = append(computeComponent.Tags, "spot")
+		computeComponent.VarianceProfile = billing.NewSpotVarianceProfile(billing.HoursPerMonth)
 	}
+	components = append(components, computeComponent)
 	
 	// ==========================================================================
-	// Component 3: Additional EBS Volumes
+	// Components 2 and 3: Root and Additional EBS Volumes
 	// ==========================================================================
-	ebsDevices := m.extractEBSBlockDevices(attrs)
-	for i, device := range ebsDevices {
-		ebsComponent := m.createEBSComponent(node, device, "ebs", i)
-		components = append(components, ebsComponent)
-	}
+	components = append(components, m.volumeComponents(node, attrs)...)
 	
 	// ==========================================================================
 	// Component 4: EBS-Optimized (if enabled)
@@ -130,7 +137,7 @@ func (m *EC2InstanceMapper) MapToBillingComponents(node *iac.GraphNode) ([]billi
 			},
 			Description:     fmt.Sprintf("EBS-optimized usage for %s", instanceType),
 			Tags:            []string{"compute", "ebs-optimized"},
-			VarianceProfile: billing.NewDefaultVarianceProfile(730),
+			VarianceProfile: billing.NewDefaultVarianceProfile(billing.HoursPerMonth),
 		}
 		components = append(components, ebsOptComponent)
 	}
@@ -163,6 +170,18 @@ func (m *EC2InstanceMapper) MapToBillingComponents(node *iac.GraphNode) ([]billi
 	return components, errors
 }
 
+// volumeComponents returns the components of the root and additional EBS volumes
+func (m *EC2InstanceMapper) volumeComponents(node *iac.GraphNode, attrs map[string]interface{}) []billing.BillingComponent {
+	var components []billing.BillingComponent
+	if rootDevice := m.extractRootBlockDevice(attrs); rootDevice != nil {
+		components = append(components, m.createEBSComponent(node, rootDevice, "root", 0))
+	}
+	for i, device := range m.extractEBSBlockDevices(attrs) {
+		components = append(components, m.createEBSComponent(node, device, "ebs", i))
+	}
+	return components
+}
+
 // extractRootBlockDevice extracts root block device configuration
 func (m *EC2InstanceMapper) extractRootBlockDevice(attrs map[string]interface{}) map[string]interface{} {
 	if rootBlock, ok := attrs["root_block_device"]; ok {
@@ -233,7 +252,7 @@ func (m *EC2InstanceMapper) createEBSComponent(node *iac.GraphNode, device map[s
 		UsageType:     fmt.Sprintf("EBS:VolumeUsage.%s", volumeType),
 		BillingPeriod: billing.PeriodMonthly,
 		Attributes: map[string]string{
-			"volumeType": normalizeVolumeType(volumeType),
+			"volumeType": volumeType, // Price List volumeApiName (gp2, gp3, ...)
 		},
 		Description: fmt.Sprintf("EBS %s volume (%.0f GB)", volumeType, volumeSize),
 		Tags:        []string{"storage", "ebs"},
@@ -262,41 +281,60 @@ func (m *EC2InstanceMapper) createEBSComponent(node *iac.GraphNode, device map[s
 	return component
 }
 
-// inferOperatingSystem attempts to determine OS from AMI or other attributes
-func (m *EC2InstanceMapper) inferOperatingSystem(attrs map[string]interface{}) string {
-	// In production, this would:
-	// 1. Look up AMI in a database
-	// 2. Use tags/naming conventions
-	// 3. Check platform attribute
+// isSpot reports whether the instance runs on spot capacity
+func (m *EC2InstanceMapper) isSpot(node *iac.GraphNode) bool {
+	if node.Resource.Type == "aws_spot_instance_request" {
+		return true
+	}
+	
+	attrs := node.Resource.Attributes
+	if opts, ok := attrs["instance_market_options"].([]interface{}); ok && len(opts) > 0 {
+		if opt, ok := opts[0].(map[string]interface{}); ok {
+			return strings.EqualFold(billing.ExtractAttribute(opt, "market_type"), "spot")
+		}
+	}
+	
+	// Legacy spot attributes on the instance itself
+	return billing.ExtractAttribute(attrs, "spot_price") != "" ||
+		billing.ExtractAttribute(attrs, "instance_lifecycle") == "spot"
+}
+
+// inferPlatform attempts to determine the billed platform from AMI or other attributes
+func (m *EC2InstanceMapper) inferPlatform(attrs map[string]interface{}) awsmeta.Platform {
+	// Platform details resolved from the AMI by metadata enrichment
+	if details := billing.ExtractAttribute(attrs, awsmeta.AttrPlatformDetails); details != "" {
+		return awsmeta.ParsePlatform(details)
+	}
 	
 	// Check platform attribute (Windows instances)
 	if platform, ok := attrs["platform"].(string); ok {
 		if strings.EqualFold(platform, "windows") {
-			return "Windows"
+			return awsmeta.ParsePlatform("Windows")
 		}
 	}
 	
-	// Check AMI name/description if available (would need API lookup)
-	ami := billing.ExtractAttribute(attrs, "ami")
-	amiLower := strings.ToLower(ami)
-	
-	// Simple heuristics based on common AMI patterns
-	switch {
-	case strings.Contains(amiLower, "windows"):
-		return "Windows"
-	case strings.Contains(amiLower, "rhel"):
-		return "RHEL"
-	case strings.Contains(amiLower, "suse"):
-		return "SUSE"
-	default:
-		return "Linux" // Default to Linux
-	}
+	// Without enrichment, fall back to heuristics on the AMI string (names
+	// like Windows_Server-2022-English-Full-SQL_2022_Standard); IDs are Linux
+	return awsmeta.ParsePlatform(billing.ExtractAttribute(attrs, "ami"))
 }
 
 // =============================================================================
 // HELPER FUNCTIONS
 // =============================================================================
 
+// ec2ComputeAttributes returns the on-demand Price List attributes for instance hours
+// Capacity status is simplified to the common case.
+func ec2ComputeAttributes(instanceType string, platform awsmeta.Platform, tenancy string) map[string]string {
+	return map[string]string{
+		"instanceType":    instanceType,
+		"operatingSystem": platform.OperatingSystem,
+		"tenancy":         normalizeTenancy(tenancy),
+		"preInstalledSw":  platform.PreInstalledSw,
+		"capacityStatus":  "Used",
+		"licenseModel":    platform.LicenseModel,
+	}
+}
+
 func normalizeTenancy(tenancy string) string {
 	switch strings.ToLower(tenancy) {
 	case "dedicated":
@@ -307,24 +345,3 @@ func normalizeTenancy(tenancy string) string {
 		return "Shared"
 	}
 }
-
-func normalizeVolumeType(volumeType string) string {
-	switch strings.ToLower(volumeType) {
-	case "gp2":
-		return "General Purpose"
-	case "gp3":
-		return "General Purpose"
-	case "io1":
-		return "Provisioned IOPS"
-	case "io2":
-		return "Provisioned IOPS"
-	case "st1":
-		return "Throughput Optimized HDD"
-	case "sc1":
-		return "Cold HDD"
-	case "standard":
-		return "Magnetic"
-	default:
-		return volumeType
-	}
-}
@@ -0,0 +1,124 @@
+package aws
+
+import (
+	"testing"
+
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/iac"
+)
+
+func TestEC2InstanceOperatingSystem(t *testing.T) {
+	tests := []struct {
+		name  string
+		attrs map[string]interface{}
+		want  string
+	}{
+		{"default", map[string]interface{}{"ami": "ami-0abc"}, "Linux"},
+		{"ami heuristic", map[string]interface{}{"ami": "windows-2022-base"}, "Windows"},
+		{"platform details", map[string]interface{}{"ami": "ami-0abc", "platform_details": "Red Hat Enterprise Linux"}, "RHEL"},
+		{"platform details win over ami", map[string]interface{}{"ami": "windows-2022-base", "platform_details": "Linux/UNIX"}, "Linux"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.attrs["instance_type"] = "t3.micro"
+			node := &iac.GraphNode{Resource: iac.ResourceNode{Address: "aws_instance.web", Type: "aws_instance", Attributes: tt.attrs}, Region: "us-east-1"}
+			components, errs := NewEC2InstanceMapper().MapToBillingComponents(node)
+			if len(errs) != 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			if got := components[0].Attributes["operatingSystem"]; got != tt.want {
+				t.Errorf("operatingSystem = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEC2InstanceLicensing(t *testing.T) {
+	tests := []struct {
+		details               string
+		os, software, license string
+	}{
+		{"Windows with SQL Server Enterprise", "Windows", "SQL Ent", "No License required"},
+		{"Windows BYOL", "Windows", "NA", "Bring your own license"},
+		{"Linux with SQL Server Standard", "Linux", "SQL Std", "No License required"},
+		{"Red Hat BYOL Linux", "Linux", "NA", "No License required"},
+		{"SUSE Linux", "SUSE", "NA", "No License required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.details, func(t *testing.T) {
+			attrs := map[string]interface{}{"instance_type": "m5.large", "ami": "ami-0abc", "platform_details": tt.details}
+			node := &iac.GraphNode{Resource: iac.ResourceNode{Address: "aws_instance.db", Type: "aws_instance", Attributes: attrs}, Region: "us-east-1"}
+			components, _ := NewEC2InstanceMapper().MapToBillingComponents(node)
+			got := components[0].Attributes
+			if got["operatingSystem"] != tt.os || got["preInstalledSw"] != tt.software || got["licenseModel"] != tt.license {
+				t.Errorf("got %s / %s / %s, want %s / %s / %s", got["operatingSystem"], got["preInstalledSw"], got["licenseModel"], tt.os, tt.software, tt.license)
+			}
+		})
+	}
+}
+
+func TestEC2InstanceAcceleratedHardware(t *testing.T) {
+	for instanceType, want := range map[string]int{"p4d.24xlarge": 8, "g5.xlarge": 1, "inf2.48xlarge": 12, "t3.micro": 0} {
+		attrs := map[string]interface{}{"instance_type": instanceType, "ami": "ami-0abc"}
+		node := &iac.GraphNode{Resource: iac.ResourceNode{Address: "aws_instance.gpu", Type: "aws_instance", Attributes: attrs}, Region: "us-east-1"}
+		components, _ := NewEC2InstanceMapper().MapToBillingComponents(node)
+		hw := components[0].Hardware
+		if want == 0 {
+			if hw != nil {
+				t.Errorf("%s hardware = %+v, want none attached", instanceType, hw)
+			}
+			continue
+		}
+		if hw == nil || hw.Accelerators != want {
+			t.Errorf("%s hardware = %+v, want %d accelerators", instanceType, hw, want)
+		}
+	}
+}
+
+func TestUnknownInstanceTypeKeepsVolumes(t *testing.T) {
+	node := func(addr, typ string, attrs, unknown map[string]interface{}) *iac.GraphNode {
+		return &iac.GraphNode{
+			Resource: iac.ResourceNode{Address: addr, Type: typ, Mode: "managed", Attributes: attrs},
+			Change:   &iac.ResourceChange{Address: addr, AfterUnknown: unknown},
+			Provider: "aws",
+			Region:   "us-east-1",
+		}
+	}
+	graph := &iac.Graph{Nodes: map[string]*iac.GraphNode{
+		"aws_instance.web": node("aws_instance.web", "aws_instance", map[string]interface{}{
+			"root_block_device": []interface{}{map[string]interface{}{"volume_type": "gp3", "volume_size": float64(50)}},
+		}, map[string]interface{}{"instance_type": true}),
+		"aws_db_instance.db": node("aws_db_instance.db", "aws_db_instance", map[string]interface{}{"engine": "postgres", "allocated_storage": float64(100)},
+			map[string]interface{}{"instance_class": true}),
+	}}
+	engine := billing.NewEngine()
+	engine.RegisterMapper(NewEC2InstanceMapper())
+	engine.RegisterMapper(NewRDSInstanceMapper())
+	decomposition, err := engine.Decompose(graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decomposition.MappingErrors) != 0 {
+		t.Errorf("mapping errors = %v, want the unknown instance type to replace them", decomposition.MappingErrors)
+	}
+
+	unknown := make(map[string][]string)
+	for _, c := range decomposition.Components {
+		unknown[c.ID] = c.UnknownAttributes
+	}
+	want := map[string][]string{
+		"aws_instance.web-unknown":     {"instance_type"},
+		"aws_instance.web-root-volume": nil,
+		"aws_db_instance.db-compute":   {"instance_class"},
+		"aws_db_instance.db-storage":   nil,
+	}
+	if len(unknown) != len(want) {
+		t.Fatalf("components = %v, want %v", unknown, want)
+	}
+	for id, paths := range want {
+		got, ok := unknown[id]
+		if !ok || len(got) != len(paths) || (len(paths) > 0 && got[0] != paths[0]) {
+			t.Errorf("%s unknown = %v (present %v), want %v", id, got, ok, paths)
+		}
+	}
+}
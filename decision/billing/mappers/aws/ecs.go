@@ -0,0 +1,366 @@
+// Package aws provides ECS service and task definition mappers
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/iac"
+)
+
+// fargateFreeEphemeralGB is the ephemeral storage included with every Fargate task
+const fargateFreeEphemeralGB = 20
+
+// =============================================================================
+// ECS Service Mapper
+// =============================================================================
+
+// ECSServiceMapper maps aws_ecs_service to Fargate task capacity
+// Task size comes from the aws_ecs_task_definition the service runs.
+type ECSServiceMapper struct{}
+
+// NewECSServiceMapper creates a new ECS service mapper
+func NewECSServiceMapper() *ECSServiceMapper {
+	return &ECSServiceMapper{}
+}
+
+// ResourceType returns the Terraform resource type
+func (m *ECSServiceMapper) ResourceType() string {
+	return "aws_ecs_service"
+}
+
+// SupportedAttributes returns attributes this mapper uses
+func (m *ECSServiceMapper) SupportedAttributes() []string {
+	return []string{
+		"desired_count",
+		"launch_type",
+		"capacity_provider_strategy",
+		"task_definition",
+	}
+}
+
+// CostDrivingAttributes returns the attributes sizing the service
+func (m *ECSServiceMapper) CostDrivingAttributes() []string {
+	return []string{"desired_count"}
+}
+
+// MapToBillingComponents cannot size tasks without the graph
+func (m *ECSServiceMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
+	return m.MapWithGraph(node, nil)
+}
+
+// MapWithGraph converts an ECS service to Fargate vCPU, memory and storage hours
+func (m *ECSServiceMapper) MapWithGraph(node *iac.GraphNode, graph *iac.Graph) ([]billing.BillingComponent, []billing.MappingError) {
+	attrs := node.Resource.Attributes
+	addr := node.Resource.Address
+
+	fargate, assumptions := ecsUsesFargate(attrs)
+	if !fargate {
+		// EC2 launch type tasks run on the cluster's container instances, which
+		// the Auto Scaling group mapper prices; pricing the tasks would count them twice
+		if findContainerInstances(node, graph) != nil {
+			return nil, nil
+		}
+		return nil, []billing.MappingError{{
+			ResourceAddr: addr,
+			ResourceType: node.Resource.Type,
+			Reason:       "EC2 launch type: the Auto Scaling group running the cluster's container instances is not in the plan",
+			IsCritical:   true,
+		}}
+	}
+
+	taskDef := findTaskDefinition(node, graph)
+	if taskDef == nil {
+		return nil, []billing.MappingError{{
+			ResourceAddr: addr,
+			ResourceType: node.Resource.Type,
+			Reason:       "task definition not found in plan; cannot size Fargate tasks",
+			IsCritical:   false,
+		}}
+	}
+
+	size, err := parseTaskSize(taskDef.Resource.Attributes)
+	if err != nil {
+		return nil, []billing.MappingError{{
+			ResourceAddr: addr,
+			ResourceType: node.Resource.Type,
+			Reason:       fmt.Sprintf("%s: %v", taskDef.Resource.Address, err),
+			IsCritical:   true,
+		}}
+	}
+
+	count := float64(billing.ExtractAttributeInt(attrs, "desired_count", 1))
+	if count == 0 {
+		return nil, nil
+	}
+	hours := billing.HoursPerMonth * count
+
+	fargateComponent := func(suffix, resource string, period billing.BillingPeriod, usage float64, desc string) billing.BillingComponent {
+		profile := billing.NewDefaultVarianceProfile(usage)
+		profile.Assumptions = append(profile.Assumptions, assumptions...)
+		return billing.BillingComponent{
+			ID:            fmt.Sprintf("%s-%s", addr, suffix),
+			Cloud:         "aws",
+			Service:       "AmazonECS",
+			ProductFamily: "Compute",
+			Region:        node.Region,
+			UsageType:     fargateUsageType(resource, size.Architecture),
+			BillingPeriod: period,
+			Attributes: map[string]string{
+				"resource":        resource,
+				"cpuArchitecture": size.Architecture,
+			},
+			Description:     desc,
+			Tags:            []string{"compute", "ecs", "fargate"},
+			VarianceProfile: profile,
+		}
+	}
+
+	components := []billing.BillingComponent{
+		fargateComponent("vcpu", "vCPU", billing.PeriodHourly, size.VCPUs*hours,
+			fmt.Sprintf("Fargate %.0f× %.4g vCPU task hours", count, size.VCPUs)),
+		fargateComponent("memory", "memory", billing.PeriodGBHourly, size.MemoryGB*hours,
+			fmt.Sprintf("Fargate %.0f× %.4g GB memory GB-hours", count, size.MemoryGB)),
+	}
+	if extra := size.EphemeralGB - fargateFreeEphemeralGB; extra > 0 {
+		components = append(components, fargateComponent("ephemeral-storage", "ephemeralStorage",
+			billing.PeriodGBHourly, extra*hours,
+			fmt.Sprintf("Fargate ephemeral storage above %d GB (%.0f GB/task)", fargateFreeEphemeralGB, size.EphemeralGB)))
+	}
+	return components, nil
+}
+
+// ecsUsesFargate reports whether a service runs on Fargate, with any pricing caveats
+func ecsUsesFargate(attrs map[string]interface{}) (bool, []string) {
+	if strategies, ok := attrs["capacity_provider_strategy"].([]interface{}); ok && len(strategies) > 0 {
+		fargate, spot := false, false
+		for _, s := range strategies {
+			sm, ok := s.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			switch billing.ExtractAttribute(sm, "capacity_provider") {
+			case "FARGATE":
+				fargate = true
+			case "FARGATE_SPOT":
+				fargate, spot = true, true
+			}
+		}
+		if spot {
+			return fargate, []string{"FARGATE_SPOT capacity priced at on-demand Fargate rates"}
+		}
+		return fargate, nil
+	}
+	return strings.EqualFold(billing.ExtractAttribute(attrs, "launch_type"), "FARGATE"), nil
+}
+
+// findTaskDefinition locates the service's task definition by dependency or family name
+func findTaskDefinition(node *iac.GraphNode, graph *iac.Graph) *iac.GraphNode {
+	if graph == nil {
+		return nil
+	}
+	for _, dep := range node.Dependencies {
+		if n, ok := graph.Nodes[dep]; ok && n.Resource.Type == "aws_ecs_task_definition" {
+			return n
+		}
+	}
+
+	// Literal "family" or "family:revision" references
+	ref := billing.ExtractAttribute(node.Resource.Attributes, "task_definition")
+	if i := strings.LastIndex(ref, "/"); i >= 0 {
+		ref = ref[i+1:]
+	}
+	family := strings.SplitN(ref, ":", 2)[0]
+	if family == "" {
+		return nil
+	}
+	for _, n := range graph.Nodes {
+		if n.Resource.Type == "aws_ecs_task_definition" &&
+			billing.ExtractAttribute(n.Resource.Attributes, "family") == family {
+			return n
+		}
+	}
+	return nil
+}
+
+// ecsCapacityLinks are the resources connecting a service to the Auto Scaling
+// group of its cluster: through a capacity provider, or a launch template
+// whose user data joins the cluster
+var ecsCapacityLinks = map[string]bool{
+	"aws_ecs_cluster":                    true,
+	"aws_ecs_capacity_provider":          true,
+	"aws_ecs_cluster_capacity_providers": true,
+	"aws_launch_template":                true,
+	"aws_launch_configuration":           true,
+}
+
+// findContainerInstances returns the Auto Scaling group providing an EC2
+// launch type service's container instances, or nil
+func findContainerInstances(node *iac.GraphNode, graph *iac.Graph) *iac.GraphNode {
+	if graph == nil {
+		return nil
+	}
+	seen := map[string]bool{node.Resource.Address: true}
+	queue := []*iac.GraphNode{node}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, addr := range append(append([]string{}, current.Dependencies...), current.Dependents...) {
+			n, ok := graph.Nodes[addr]
+			if !ok || seen[addr] {
+				continue
+			}
+			seen[addr] = true
+			switch {
+			case n.Resource.Type == "aws_autoscaling_group":
+				return n
+			case ecsCapacityLinks[n.Resource.Type]:
+				queue = append(queue, n)
+			}
+		}
+	}
+	return nil
+}
+
+// fargateUsageType returns the Price List usage type suffix for a Fargate resource
+func fargateUsageType(resource, arch string) string {
+	prefix := "Fargate-"
+	if arch == "ARM64" {
+		prefix = "Fargate-ARM-"
+	}
+	switch resource {
+	case "vCPU":
+		return prefix + "vCPU-Hours:perCPU"
+	case "ephemeralStorage":
+		return "Fargate-EphemeralStorage-GB-Hours"
+	default:
+		return prefix + "GB-Hours"
+	}
+}
+
+// =============================================================================
+// ECS Task Definition Mapper
+// =============================================================================
+
+// ECSTaskDefinitionMapper covers aws_ecs_task_definition
+// Task definitions are free; they are priced through the services that run them.
+type ECSTaskDefinitionMapper struct{}
+
+// NewECSTaskDefinitionMapper creates a new ECS task definition mapper
+func NewECSTaskDefinitionMapper() *ECSTaskDefinitionMapper {
+	return &ECSTaskDefinitionMapper{}
+}
+
+// ResourceType returns the Terraform resource type
+func (m *ECSTaskDefinitionMapper) ResourceType() string {
+	return "aws_ecs_task_definition"
+}
+
+// SupportedAttributes returns attributes this mapper uses
+func (m *ECSTaskDefinitionMapper) SupportedAttributes() []string {
+	return []string{"cpu", "memory", "container_definitions", "runtime_platform", "ephemeral_storage"}
+}
+
+// MapToBillingComponents returns no components; see ECSServiceMapper
+func (m *ECSTaskDefinitionMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
+	return nil, nil
+}
+
+// =============================================================================
+// TASK SIZING
+// =============================================================================
+
+// TaskSize is the billable size of one ECS task
+type TaskSize struct {
+	VCPUs        float64
+	MemoryGB     float64
+	EphemeralGB  float64
+	Architecture string // x86_64 or ARM64
+}
+
+// parseTaskSize reads task-level cpu/memory, falling back to the sum of container definitions
+func parseTaskSize(attrs map[string]interface{}) (TaskSize, error) {
+	size := TaskSize{
+		EphemeralGB:  fargateFreeEphemeralGB,
+		Architecture: "x86_64",
+	}
+
+	if rp := firstNested(attrs, "runtime_platform"); rp != nil {
+		if strings.EqualFold(billing.ExtractAttribute(rp, "cpu_architecture"), "ARM64") {
+			size.Architecture = "ARM64"
+		}
+	}
+	if es := firstNested(attrs, "ephemeral_storage"); es != nil {
+		size.EphemeralGB = billing.ExtractAttributeFloat(es, "size_in_gib", fargateFreeEphemeralGB)
+	}
+
+	cpuUnits, _ := parseECSQuantity(attrs["cpu"], "vcpu", 1024)
+	memoryMiB, _ := parseECSQuantity(attrs["memory"], "gb", 1024)
+
+	if cpuUnits == 0 || memoryMiB == 0 {
+		containerCPU, containerMemory := sumContainerDefinitions(attrs["container_definitions"])
+		if cpuUnits == 0 {
+			cpuUnits = containerCPU
+		}
+		if memoryMiB == 0 {
+			memoryMiB = containerMemory
+		}
+	}
+	if cpuUnits == 0 || memoryMiB == 0 {
+		return size, fmt.Errorf("task cpu and memory are required to price Fargate")
+	}
+
+	size.VCPUs = cpuUnits / 1024
+	size.MemoryGB = memoryMiB / 1024
+	return size, nil
+}
+
+// parseECSQuantity parses ECS cpu units / memory MiB, which may be numbers,
+// numeric strings ("512") or unit strings ("1 vCPU", "2 GB")
+func parseECSQuantity(v interface{}, unit string, multiplier float64) (float64, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case int:
+		return float64(val), true
+	case string:
+		s := strings.TrimSpace(strings.ToLower(val))
+		if strings.HasSuffix(s, unit) {
+			n, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(s, unit)), 64)
+			return n * multiplier, err == nil
+		}
+		n, err := strconv.ParseFloat(s, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// sumContainerDefinitions totals cpu and memory across container definitions JSON
+func sumContainerDefinitions(v interface{}) (cpuUnits, memoryMiB float64) {
+	var containers []map[string]interface{}
+	switch val := v.(type) {
+	case string:
+		if err := json.Unmarshal([]byte(val), &containers); err != nil {
+			return 0, 0
+		}
+	case []interface{}:
+		for _, c := range val {
+			if cm, ok := c.(map[string]interface{}); ok {
+				containers = append(containers, cm)
+			}
+		}
+	}
+
+	for _, c := range containers {
+		cpuUnits += billing.ExtractAttributeFloat(c, "cpu", 0)
+		memory := billing.ExtractAttributeFloat(c, "memory", 0)
+		if memory == 0 {
+			memory = billing.ExtractAttributeFloat(c, "memoryReservation", 0)
+		}
+		memoryMiB += memory
+	}
+	return cpuUnits, memoryMiB
+}
@@ -0,0 +1,110 @@
+package aws
+
+import (
+	"testing"
+
+	"terraform-cost/decision/iac"
+)
+
+func TestParseTaskSize(t *testing.T) {
+	tests := []struct {
+		name   string
+		attrs  map[string]interface{}
+		vcpus  float64
+		memory float64
+		arch   string
+	}{
+		{"task level", map[string]interface{}{"cpu": "512", "memory": "1024"}, 0.5, 1, "x86_64"},
+		{"unit strings", map[string]interface{}{"cpu": "1 vCPU", "memory": "2 GB"}, 1, 2, "x86_64"},
+		{"arm", map[string]interface{}{
+			"cpu": "256", "memory": "512",
+			"runtime_platform": []interface{}{map[string]interface{}{"cpu_architecture": "ARM64"}},
+		}, 0.25, 0.5, "ARM64"},
+		{"container definitions", map[string]interface{}{
+			"container_definitions": `[{"cpu": 256, "memory": 512}, {"cpu": 256, "memoryReservation": 256}]`,
+		}, 0.5, 0.75, "x86_64"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size, err := parseTaskSize(tt.attrs)
+			if err != nil {
+				t.Fatalf("parseTaskSize: %v", err)
+			}
+			if size.VCPUs != tt.vcpus || size.MemoryGB != tt.memory || size.Architecture != tt.arch {
+				t.Errorf("got %+v, want %.2f vCPU / %.2f GB / %s", size, tt.vcpus, tt.memory, tt.arch)
+			}
+		})
+	}
+
+	if _, err := parseTaskSize(map[string]interface{}{}); err == nil {
+		t.Error("expected error without cpu/memory")
+	}
+}
+
+func TestECSServiceMapperUsesTaskDefinition(t *testing.T) {
+	taskDef := &iac.GraphNode{Resource: iac.ResourceNode{
+		Address:    "aws_ecs_task_definition.app",
+		Type:       "aws_ecs_task_definition",
+		Attributes: map[string]interface{}{"family": "app", "cpu": "1024", "memory": "2048"},
+	}}
+	service := &iac.GraphNode{
+		Resource: iac.ResourceNode{
+			Address:    "aws_ecs_service.app",
+			Type:       "aws_ecs_service",
+			Attributes: map[string]interface{}{"launch_type": "FARGATE", "desired_count": float64(3)},
+		},
+		Dependencies: []string{"aws_ecs_task_definition.app"},
+		Region:       "us-east-1",
+	}
+	graph := &iac.Graph{Nodes: map[string]*iac.GraphNode{
+		taskDef.Resource.Address: taskDef,
+		service.Resource.Address: service,
+	}}
+
+	components, errs := NewECSServiceMapper().MapWithGraph(service, graph)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(components) != 2 {
+		t.Fatalf("expected vcpu and memory components, got %d", len(components))
+	}
+	if got := components[0].VarianceProfile.BaselineUsage; got != 3*730 {
+		t.Errorf("vCPU hours = %v, want %v", got, 3*730)
+	}
+	if got := components[1].VarianceProfile.BaselineUsage; got != 3*2*730 {
+		t.Errorf("memory GB-hours = %v, want %v", got, 3*2*730)
+	}
+}
+
+func TestECSServiceMapperEC2LaunchType(t *testing.T) {
+	node := func(addr, typ string, deps, dependents []string) *iac.GraphNode {
+		return &iac.GraphNode{
+			Resource:     iac.ResourceNode{Address: addr, Type: typ, Attributes: map[string]interface{}{}},
+			Dependencies: deps,
+			Dependents:   dependents,
+		}
+	}
+	service := node("aws_ecs_service.app", "aws_ecs_service", []string{"aws_ecs_cluster.main"}, nil)
+	service.Resource.Attributes = map[string]interface{}{"launch_type": "EC2", "desired_count": float64(2)}
+	graph := &iac.Graph{Nodes: map[string]*iac.GraphNode{
+		"aws_ecs_service.app":  service,
+		"aws_ecs_cluster.main": node("aws_ecs_cluster.main", "aws_ecs_cluster", nil, []string{"aws_ecs_service.app", "aws_ecs_cluster_capacity_providers.main"}),
+		"aws_ecs_cluster_capacity_providers.main": node("aws_ecs_cluster_capacity_providers.main", "aws_ecs_cluster_capacity_providers",
+			[]string{"aws_ecs_cluster.main", "aws_ecs_capacity_provider.asg"}, nil),
+		"aws_ecs_capacity_provider.asg": node("aws_ecs_capacity_provider.asg", "aws_ecs_capacity_provider",
+			[]string{"aws_autoscaling_group.ecs"}, []string{"aws_ecs_cluster_capacity_providers.main"}),
+		"aws_autoscaling_group.ecs": node("aws_autoscaling_group.ecs", "aws_autoscaling_group", nil, []string{"aws_ecs_capacity_provider.asg"}),
+	}}
+
+	// The group's own mapper prices the instances
+	components, errs := NewECSServiceMapper().MapWithGraph(service, graph)
+	if len(components) != 0 || len(errs) != 0 {
+		t.Errorf("got %d components, errors %v; want the cost left to the Auto Scaling group", len(components), errs)
+	}
+
+	delete(graph.Nodes, "aws_autoscaling_group.ecs")
+	if _, errs := NewECSServiceMapper().MapWithGraph(service, graph); len(errs) != 1 || !errs[0].IsCritical {
+		t.Errorf("errors = %v; want one critical error without container instances", errs)
+	}
+}
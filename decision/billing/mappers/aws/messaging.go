@@ -0,0 +1,547 @@
+// Package aws provides SQS, SNS, EventBridge and Kinesis mappers
+package aws
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/iac"
+)
+
+// Heuristic monthly volumes used until a usage file or profile says otherwise
+const (
+	defaultQueueRequests    = 3000000 // 1M messages sent, received and deleted
+	defaultTopicPublishes   = 1000000
+	defaultCustomEvents     = 1000000
+	defaultPutRecordsPerSec = 10  // Per shard, each record one 25 KB PUT payload unit
+	defaultStreamDataInGB   = 100 // On-demand streams
+	defaultStreamConsumers  = 2   // On-demand reads: every record read by each consumer
+)
+
+// Billing month conventions of hourly components
+const (
+	hoursPerMonth = billing.HoursPerMonth
+	daysPerMonth  = hoursPerMonth / 24.0
+)
+
+// Kinesis retention windows, in hours
+const (
+	kinesisDefaultRetention  = 24
+	kinesisExtendedRetention = 168 // Longer retention is billed per GB-month
+)
+
+// messagingProfile is the variance profile of a request volume the plan cannot
+// know; its low confidence lets environment profiles and usage files scale it
+func messagingProfile(baseline float64, assumptions ...string) billing.VarianceProfile {
+	return billing.VarianceProfile{
+		BaselineUsage: baseline,
+		P50Usage:      baseline * 0.5,
+		P90Usage:      baseline * 2,
+		Confidence:    0.5,
+		Assumptions:   assumptions,
+	}
+}
+
+// provisionedProfile is the variance profile of capacity billed whether used or not
+func provisionedProfile(usage float64) billing.VarianceProfile {
+	return billing.VarianceProfile{BaselineUsage: usage, P50Usage: usage, P90Usage: usage, Confidence: 0.9}
+}
+
+// =============================================================================
+// SQS Queue Mapper
+// =============================================================================
+
+// SQSQueueMapper maps aws_sqs_queue to API requests
+// Every send, receive and delete is a request, per 64 KB of payload.
+type SQSQueueMapper struct{}
+
+// NewSQSQueueMapper creates a new SQS queue mapper
+func NewSQSQueueMapper() *SQSQueueMapper {
+	return &SQSQueueMapper{}
+}
+
+// ResourceType returns the Terraform resource type
+func (m *SQSQueueMapper) ResourceType() string {
+	return "aws_sqs_queue"
+}
+
+// SupportedAttributes returns attributes this mapper uses
+func (m *SQSQueueMapper) SupportedAttributes() []string {
+	return []string{"fifo_queue"}
+}
+
+// MapToBillingComponents converts a queue to standard or FIFO requests
+func (m *SQSQueueMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
+	queueType, usageType := "Standard", "Requests"
+	if billing.ExtractAttributeBool(node.Resource.Attributes, "fifo_queue", false) {
+		queueType, usageType = "FIFO", "Requests-FIFO"
+	}
+
+	return []billing.BillingComponent{{
+		ID:            fmt.Sprintf("%s-requests", node.Resource.Address),
+		Cloud:         "aws",
+		Service:       "AWSQueueService",
+		ProductFamily: "Queue",
+		Region:        node.Region,
+		UsageType:     usageType,
+		BillingPeriod: billing.PeriodPerRequest,
+		Attributes:    map[string]string{"queueType": queueType},
+		Description:   fmt.Sprintf("SQS %s queue requests", strings.ToLower(queueType)),
+		Tags:          []string{"messaging", "sqs"},
+		VarianceProfile: messagingProfile(defaultQueueRequests,
+			"3 requests per message (send, receive, delete), messages up to 64 KB"),
+	}}, nil
+}
+
+// =============================================================================
+// SNS Topic Mapper
+// =============================================================================
+
+// snsDeliveryProtocols maps subscription protocols to billed delivery endpoints
+// Deliveries to SQS and Lambda are free and have no entry.
+var snsDeliveryProtocols = map[string]struct{ suffix, endpoint string }{
+	"http":        {"http", "HTTP"},
+	"https":       {"http", "HTTP"},
+	"email":       {"email", "Email"},
+	"email-json":  {"email", "Email"},
+	"sms":         {"sms", "SMS"},
+	"application": {"mobile", "Mobile Push"},
+	"firehose":    {"firehose", "Firehose"},
+}
+
+// SNSTopicMapper maps aws_sns_topic to publishes and deliveries
+// Deliveries are priced by protocol from the topic's subscriptions in the plan.
+type SNSTopicMapper struct{}
+
+// NewSNSTopicMapper creates a new SNS topic mapper
+func NewSNSTopicMapper() *SNSTopicMapper {
+	return &SNSTopicMapper{}
+}
+
+// ResourceType returns the Terraform resource type
+func (m *SNSTopicMapper) ResourceType() string {
+	return "aws_sns_topic"
+}
+
+// SupportedAttributes returns attributes this mapper uses
+func (m *SNSTopicMapper) SupportedAttributes() []string {
+	return []string{"fifo_topic", "arn"}
+}
+
+// MapToBillingComponents prices publishes only; deliveries need the graph
+func (m *SNSTopicMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
+	return m.MapWithGraph(node, nil)
+}
+
+// MapWithGraph converts a topic to publish requests and one delivery component per billed protocol
+func (m *SNSTopicMapper) MapWithGraph(node *iac.GraphNode, graph *iac.Graph) ([]billing.BillingComponent, []billing.MappingError) {
+	addr := node.Resource.Address
+	topicType := "Standard"
+	if billing.ExtractAttributeBool(node.Resource.Attributes, "fifo_topic", false) {
+		topicType = "FIFO"
+	}
+
+	components := []billing.BillingComponent{{
+		ID:            fmt.Sprintf("%s-requests", addr),
+		Cloud:         "aws",
+		Service:       "AmazonSNS",
+		ProductFamily: "Notification",
+		Region:        node.Region,
+		UsageType:     "PublishAPI-Requests",
+		BillingPeriod: billing.PeriodPerRequest,
+		Attributes:    map[string]string{"operation": "Publish", "topicType": topicType},
+		Description:   fmt.Sprintf("SNS %s topic publishes", strings.ToLower(topicType)),
+		Tags:          []string{"messaging", "sns"},
+		VarianceProfile: messagingProfile(defaultTopicPublishes,
+			"Messages up to 64 KB, one request each"),
+	}}
+
+	// Every publish is delivered to every subscription
+	subscriptions := make(map[string]int)
+	endpoints := make(map[string]string)
+	for _, sub := range findSubscriptions(node, graph) {
+		protocol := strings.ToLower(billing.ExtractAttribute(sub.Resource.Attributes, "protocol"))
+		if p, ok := snsDeliveryProtocols[protocol]; ok {
+			subscriptions[p.suffix]++
+			endpoints[p.suffix] = p.endpoint
+		}
+	}
+	suffixes := make([]string, 0, len(subscriptions))
+	for suffix := range subscriptions {
+		suffixes = append(suffixes, suffix)
+	}
+	sort.Strings(suffixes)
+
+	for _, suffix := range suffixes {
+		endpoint, count := endpoints[suffix], subscriptions[suffix]
+		components = append(components, billing.BillingComponent{
+			ID:            fmt.Sprintf("%s-deliveries-%s", addr, suffix),
+			Cloud:         "aws",
+			Service:       "AmazonSNS",
+			ProductFamily: "Notification",
+			Region:        node.Region,
+			UsageType:     fmt.Sprintf("DeliveryAttempts-%s", strings.ReplaceAll(endpoint, " ", "")),
+			BillingPeriod: billing.PeriodPerRequest,
+			Attributes:    map[string]string{"operation": "Deliver", "endpointType": endpoint},
+			Description:   fmt.Sprintf("SNS %s deliveries (%d subscriptions)", endpoint, count),
+			Tags:          []string{"messaging", "sns"},
+			VarianceProfile: messagingProfile(defaultTopicPublishes*float64(count),
+				fmt.Sprintf("Every publish delivered to %d %s subscriptions", count, endpoint)),
+		})
+	}
+	return components, nil
+}
+
+// findSubscriptions returns the topic's subscriptions, by dependency or literal topic ARN
+func findSubscriptions(node *iac.GraphNode, graph *iac.Graph) []*iac.GraphNode {
+	if graph == nil {
+		return nil
+	}
+	arn := billing.ExtractAttribute(node.Resource.Attributes, "arn")
+	var subs []*iac.GraphNode
+	for _, n := range graph.Nodes {
+		if n.Resource.Type != "aws_sns_topic_subscription" {
+			continue
+		}
+		if (arn != "" && billing.ExtractAttribute(n.Resource.Attributes, "topic_arn") == arn) ||
+			containsString(n.Dependencies, node.Resource.Address) {
+			subs = append(subs, n)
+		}
+	}
+	return subs
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// =============================================================================
+// SNS Topic Subscription Mapper
+// =============================================================================
+
+// SNSTopicSubscriptionMapper covers aws_sns_topic_subscription
+// Subscriptions are free; their deliveries are priced on the topic.
+type SNSTopicSubscriptionMapper struct{}
+
+// NewSNSTopicSubscriptionMapper creates a new SNS topic subscription mapper
+func NewSNSTopicSubscriptionMapper() *SNSTopicSubscriptionMapper {
+	return &SNSTopicSubscriptionMapper{}
+}
+
+// ResourceType returns the Terraform resource type
+func (m *SNSTopicSubscriptionMapper) ResourceType() string {
+	return "aws_sns_topic_subscription"
+}
+
+// SupportedAttributes returns attributes this mapper uses
+func (m *SNSTopicSubscriptionMapper) SupportedAttributes() []string {
+	return []string{"protocol", "topic_arn"}
+}
+
+// MapToBillingComponents returns no components; see SNSTopicMapper
+func (m *SNSTopicSubscriptionMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
+	return nil, nil
+}
+
+// =============================================================================
+// EventBridge Mappers
+// =============================================================================
+
+// EventBusMapper maps aws_cloudwatch_event_bus to custom events published to it
+// Events from AWS services are free; custom and partner events are billed per 64 KB.
+type EventBusMapper struct{}
+
+// NewEventBusMapper creates a new EventBridge event bus mapper
+func NewEventBusMapper() *EventBusMapper {
+	return &EventBusMapper{}
+}
+
+// ResourceType returns the Terraform resource type
+func (m *EventBusMapper) ResourceType() string {
+	return "aws_cloudwatch_event_bus"
+}
+
+// SupportedAttributes returns attributes this mapper uses
+func (m *EventBusMapper) SupportedAttributes() []string {
+	return []string{"event_source_name"}
+}
+
+// MapToBillingComponents converts an event bus to published events
+func (m *EventBusMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
+	eventType := "Custom"
+	if billing.ExtractAttribute(node.Resource.Attributes, "event_source_name") != "" {
+		eventType = "Partner"
+	}
+
+	return []billing.BillingComponent{{
+		ID:            fmt.Sprintf("%s-events", node.Resource.Address),
+		Cloud:         "aws",
+		Service:       "AWSEvents",
+		ProductFamily: "EventBridge",
+		Region:        node.Region,
+		UsageType:     "Event-64K-Chunks",
+		BillingPeriod: billing.PeriodPerRequest,
+		Attributes:    map[string]string{"eventType": eventType},
+		Description:   fmt.Sprintf("EventBridge %s events", strings.ToLower(eventType)),
+		Tags:          []string{"messaging", "eventbridge"},
+		VarianceProfile: messagingProfile(defaultCustomEvents,
+			"Events up to 64 KB, one event each"),
+	}}, nil
+}
+
+// SchedulerScheduleMapper maps aws_scheduler_schedule to invocations
+// Invocations are counted from the schedule expression where it can be read.
+type SchedulerScheduleMapper struct{}
+
+// NewSchedulerScheduleMapper creates a new EventBridge Scheduler schedule mapper
+func NewSchedulerScheduleMapper() *SchedulerScheduleMapper {
+	return &SchedulerScheduleMapper{}
+}
+
+// ResourceType returns the Terraform resource type
+func (m *SchedulerScheduleMapper) ResourceType() string {
+	return "aws_scheduler_schedule"
+}
+
+// SupportedAttributes returns attributes this mapper uses
+func (m *SchedulerScheduleMapper) SupportedAttributes() []string {
+	return []string{"schedule_expression", "state"}
+}
+
+// MapToBillingComponents converts a schedule to monthly invocations
+func (m *SchedulerScheduleMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
+	attrs := node.Resource.Attributes
+	if strings.EqualFold(billing.ExtractAttribute(attrs, "state"), "DISABLED") {
+		return nil, nil
+	}
+
+	expr := billing.ExtractAttribute(attrs, "schedule_expression")
+	var profile billing.VarianceProfile
+	if invocations, ok := scheduleInvocations(expr); ok {
+		profile = provisionedProfile(invocations)
+		profile.Assumptions = []string{fmt.Sprintf("%.0f invocations/month from %s", invocations, expr)}
+	} else {
+		profile = messagingProfile(hoursPerMonth, "Schedule expression not readable; hourly invocations assumed")
+	}
+
+	return []billing.BillingComponent{{
+		ID:              fmt.Sprintf("%s-invocations", node.Resource.Address),
+		Cloud:           "aws",
+		Service:         "AWSEvents",
+		ProductFamily:   "EventBridge Scheduler",
+		Region:          node.Region,
+		UsageType:       "Scheduler-Invocations",
+		BillingPeriod:   billing.PeriodPerRequest,
+		Attributes:      map[string]string{"eventType": "Scheduler"},
+		Description:     fmt.Sprintf("EventBridge Scheduler invocations (%s)", expr),
+		Tags:            []string{"messaging", "eventbridge"},
+		VarianceProfile: profile,
+	}}, nil
+}
+
+// scheduleInvocations counts the monthly invocations of an at(), rate() or cron() expression
+func scheduleInvocations(expr string) (float64, bool) {
+	expr = strings.TrimSpace(expr)
+	switch {
+	case strings.HasPrefix(expr, "at(") && strings.HasSuffix(expr, ")"):
+		return 1, true
+	case strings.HasPrefix(expr, "rate(") && strings.HasSuffix(expr, ")"):
+		fields := strings.Fields(expr[len("rate(") : len(expr)-1])
+		if len(fields) != 2 {
+			return 0, false
+		}
+		n, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil || n <= 0 {
+			return 0, false
+		}
+		var perMonth float64
+		switch strings.TrimSuffix(fields[1], "s") {
+		case "minute":
+			perMonth = hoursPerMonth * 60
+		case "hour":
+			perMonth = hoursPerMonth
+		case "day":
+			perMonth = daysPerMonth
+		default:
+			return 0, false
+		}
+		return perMonth / n, true
+	case strings.HasPrefix(expr, "cron(") && strings.HasSuffix(expr, ")"):
+		return cronInvocations(expr[len("cron(") : len(expr)-1])
+	}
+	return 0, false
+}
+
+// cronInvocations counts the monthly runs of "minutes hours day-of-month month day-of-week year"
+// Days of the month and week are averaged over a month rather than counted on a calendar.
+func cronInvocations(spec string) (float64, bool) {
+	fields := strings.Fields(spec)
+	if len(fields) != 6 {
+		return 0, false
+	}
+	minutes, ok1 := cronFieldCount(fields[0], 0, 59)
+	hours, ok2 := cronFieldCount(fields[1], 0, 23)
+	months, ok3 := cronFieldCount(fields[3], 1, 12)
+	if !ok1 || !ok2 || !ok3 {
+		return 0, false
+	}
+
+	days := daysPerMonth
+	switch {
+	case fields[2] != "*" && fields[2] != "?":
+		n, ok := cronFieldCount(fields[2], 1, 31)
+		if !ok {
+			return 0, false
+		}
+		days = float64(n)
+	case fields[4] != "*" && fields[4] != "?":
+		n, ok := cronFieldCount(fields[4], 1, 7)
+		if !ok {
+			return 0, false
+		}
+		days = daysPerMonth * float64(n) / 7
+	}
+	return float64(minutes*hours) * days * float64(months) / 12, true
+}
+
+// cronFieldCount counts the values a cron field matches: *, n, a-b, */n, a-b/n and lists
+// Named days and months (MON-FRI, JAN) and the L, W and # modifiers are not read.
+func cronFieldCount(field string, min, max int) (int, bool) {
+	count := 0
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, false
+			}
+			step, part = n, part[:i]
+		}
+		lo, hi := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			a, errA := strconv.Atoi(bounds[0])
+			b, errB := strconv.Atoi(bounds[1])
+			if errA != nil || errB != nil || a > b {
+				return 0, false
+			}
+			lo, hi = a, b
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return 0, false
+			}
+			lo = n
+			if step == 1 {
+				hi = n
+			}
+		}
+		if lo < min || hi > max {
+			return 0, false
+		}
+		count += (hi-lo)/step + 1
+	}
+	return count, true
+}
+
+// =============================================================================
+// Kinesis Stream Mapper
+// =============================================================================
+
+// KinesisStreamMapper maps aws_kinesis_stream to shard or stream hours and data
+// Provisioned streams pay for shards and PUT payload units (25 KB each);
+// on-demand streams pay per stream hour and per GB written and read.
+type KinesisStreamMapper struct{}
+
+// NewKinesisStreamMapper creates a new Kinesis stream mapper
+func NewKinesisStreamMapper() *KinesisStreamMapper {
+	return &KinesisStreamMapper{}
+}
+
+// ResourceType returns the Terraform resource type
+func (m *KinesisStreamMapper) ResourceType() string {
+	return "aws_kinesis_stream"
+}
+
+// SupportedAttributes returns attributes this mapper uses
+func (m *KinesisStreamMapper) SupportedAttributes() []string {
+	return []string{"shard_count", "retention_period", "stream_mode_details"}
+}
+
+// MapToBillingComponents converts a stream to its provisioned or on-demand charges
+func (m *KinesisStreamMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
+	attrs := node.Resource.Attributes
+	addr := node.Resource.Address
+
+	mode := "PROVISIONED"
+	if details := firstNested(attrs, "stream_mode_details"); details != nil {
+		if sm := strings.ToUpper(billing.ExtractAttribute(details, "stream_mode")); sm != "" {
+			mode = sm
+		}
+	}
+	retention := billing.ExtractAttributeInt(attrs, "retention_period", kinesisDefaultRetention)
+
+	component := func(suffix, usageType string, period billing.BillingPeriod, profile billing.VarianceProfile, desc string) billing.BillingComponent {
+		return billing.BillingComponent{
+			ID:              fmt.Sprintf("%s-%s", addr, suffix),
+			Cloud:           "aws",
+			Service:         "AmazonKinesis",
+			ProductFamily:   "Kinesis Streams",
+			Region:          node.Region,
+			UsageType:       usageType,
+			BillingPeriod:   period,
+			Attributes:      map[string]string{"streamMode": mode, "usage": suffix},
+			Description:     desc,
+			Tags:            []string{"messaging", "kinesis"},
+			VarianceProfile: profile,
+		}
+	}
+
+	if mode == "ON_DEMAND" {
+		dataIn := messagingProfile(defaultStreamDataInGB, "Data written to the stream, rounded up to 1 KB per record")
+		dataOut := messagingProfile(defaultStreamDataInGB*defaultStreamConsumers,
+			fmt.Sprintf("Every record read by %d consumers", defaultStreamConsumers))
+		return []billing.BillingComponent{
+			component("stream-hours", "OnDemand-StreamHour", billing.PeriodHourly,
+				provisionedProfile(hoursPerMonth), "Kinesis on-demand stream hours"),
+			component("data-in", "OnDemand-DataIngested-Bytes", billing.PeriodPerGB, dataIn,
+				"Kinesis on-demand data ingested"),
+			component("data-out", "OnDemand-DataRetrieval-Bytes", billing.PeriodPerGB, dataOut,
+				"Kinesis on-demand data retrieved"),
+		}, nil
+	}
+
+	shards := billing.ExtractAttributeInt(attrs, "shard_count", 1)
+	if shards == 0 {
+		return nil, nil
+	}
+	shardHours := float64(shards) * hoursPerMonth
+	putUnits := float64(shards) * defaultPutRecordsPerSec * 3600 * hoursPerMonth
+
+	components := []billing.BillingComponent{
+		component("shard-hours", "Storage-ShardHour", billing.PeriodHourly, provisionedProfile(shardHours),
+			fmt.Sprintf("Kinesis %d shards", shards)),
+		component("put-payload-units", "PutRequestPayloadUnits", billing.PeriodPerUnit,
+			messagingProfile(putUnits, fmt.Sprintf("%d records/s per shard, up to 25 KB each", defaultPutRecordsPerSec)),
+			"Kinesis PUT payload units"),
+	}
+	if retention > kinesisDefaultRetention {
+		profile := provisionedProfile(shardHours)
+		if retention > kinesisExtendedRetention {
+			profile.Assumptions = []string{"Retention beyond 7 days (long-term GB-month storage) not priced"}
+		}
+		components = append(components, component("extended-retention", "Extended-ShardHour",
+			billing.PeriodHourly, profile, fmt.Sprintf("Kinesis extended retention (%dh)", retention)))
+	}
+	return components, nil
+}
@@ -0,0 +1,114 @@
+package aws
+
+import (
+	"testing"
+
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/iac"
+)
+
+func TestSNSTopicMapperPricesDeliveriesByProtocol(t *testing.T) {
+	topic := &iac.GraphNode{
+		Resource: iac.ResourceNode{Address: "aws_sns_topic.alerts", Type: "aws_sns_topic"},
+		Region:   "us-east-1",
+	}
+	graph := &iac.Graph{Nodes: map[string]*iac.GraphNode{topic.Resource.Address: topic}}
+	for addr, protocol := range map[string]string{
+		"aws_sns_topic_subscription.hook":  "https",
+		"aws_sns_topic_subscription.hook2": "http",
+		"aws_sns_topic_subscription.ops":   "email",
+		"aws_sns_topic_subscription.queue": "sqs",
+	} {
+		graph.Nodes[addr] = &iac.GraphNode{
+			Resource: iac.ResourceNode{
+				Address:    addr,
+				Type:       "aws_sns_topic_subscription",
+				Attributes: map[string]interface{}{"protocol": protocol},
+			},
+			Dependencies: []string{topic.Resource.Address},
+		}
+	}
+
+	components, errs := NewSNSTopicMapper().MapWithGraph(topic, graph)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	ids := make(map[string]billing.BillingComponent)
+	for _, c := range components {
+		ids[c.ID] = c
+	}
+	if len(ids) != 3 {
+		t.Fatalf("expected publishes plus http and email deliveries, got %v", components)
+	}
+	deliveries, ok := ids["aws_sns_topic.alerts-deliveries-http"]
+	if !ok {
+		t.Fatal("missing http deliveries")
+	}
+	if got := deliveries.VarianceProfile.BaselineUsage; got != 2*defaultTopicPublishes {
+		t.Errorf("http deliveries = %v, want %v", got, 2*defaultTopicPublishes)
+	}
+	if _, ok := ids["aws_sns_topic.alerts-deliveries-email"]; !ok {
+		t.Error("missing email deliveries")
+	}
+}
+
+func TestScheduleInvocations(t *testing.T) {
+	tests := []struct {
+		expr string
+		want float64
+		ok   bool
+	}{
+		{"rate(5 minutes)", 730 * 12, true},
+		{"rate(1 hour)", 730, true},
+		{"rate(1 day)", 730.0 / 24, true},
+		{"at(2026-01-01T00:00:00)", 1, true},
+		{"cron(0 9 * * ? *)", 730.0 / 24, true},
+		{"cron(0/15 8-17 ? * 2-6 *)", 4 * 10 * (730.0 / 24) * 5 / 7, true},
+		{"cron(0 0 1 * ? *)", 1, true},
+		{"cron(0 9 ? * MON-FRI *)", 0, false},
+		{"rate(1 fortnight)", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := scheduleInvocations(tt.expr)
+		if ok != tt.ok || (ok && (got-tt.want > 1e-9 || tt.want-got > 1e-9)) {
+			t.Errorf("scheduleInvocations(%q) = %v, %v; want %v, %v", tt.expr, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestKinesisStreamMapperModes(t *testing.T) {
+	provisioned := &iac.GraphNode{Resource: iac.ResourceNode{
+		Address:    "aws_kinesis_stream.events",
+		Type:       "aws_kinesis_stream",
+		Attributes: map[string]interface{}{"shard_count": float64(4), "retention_period": float64(72)},
+	}}
+	components, _ := NewKinesisStreamMapper().MapToBillingComponents(provisioned)
+	if len(components) != 3 {
+		t.Fatalf("expected shard hours, PUT payload units and extended retention, got %d", len(components))
+	}
+	if got := components[0].VarianceProfile.BaselineUsage; got != 4*730 {
+		t.Errorf("shard hours = %v, want %v", got, 4*730)
+	}
+	if components[1].VarianceProfile.Confidence >= 0.6 {
+		t.Error("PUT payload units should be heuristic usage")
+	}
+
+	onDemand := &iac.GraphNode{Resource: iac.ResourceNode{
+		Address: "aws_kinesis_stream.clicks",
+		Type:    "aws_kinesis_stream",
+		Attributes: map[string]interface{}{
+			"stream_mode_details": []interface{}{map[string]interface{}{"stream_mode": "ON_DEMAND"}},
+		},
+	}}
+	components, _ = NewKinesisStreamMapper().MapToBillingComponents(onDemand)
+	want := []string{"stream-hours", "data-in", "data-out"}
+	if len(components) != len(want) {
+		t.Fatalf("expected %v, got %d components", want, len(components))
+	}
+	for i, suffix := range want {
+		if components[i].ID != "aws_kinesis_stream.clicks-"+suffix {
+			t.Errorf("component %d = %s, want suffix %s", i, components[i].ID, suffix)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+// Package aws provides Transit Gateway, VPN and VPC endpoint mappers
+package aws
+
+import (
+	"fmt"
+	"strings"
+
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/iac"
+)
+
+// defaultProcessedGB is the heuristic monthly GB through a gateway or endpoint
+const defaultProcessedGB = 100
+
+// defaultEndpointAZs is assumed when an interface endpoint's subnets are not known until apply
+const defaultEndpointAZs = 2
+
+// processedDataProfile is the variance profile of data a gateway or endpoint processes
+func processedDataProfile(assumptions ...string) billing.VarianceProfile {
+	return billing.VarianceProfile{
+		BaselineUsage: defaultProcessedGB,
+		P50Usage:      defaultProcessedGB / 2,
+		P90Usage:      defaultProcessedGB * 5,
+		Confidence:    0.5,
+		Assumptions:   assumptions,
+	}
+}
+
+// =============================================================================
+// Transit Gateway Mappers
+// =============================================================================
+
+// TransitGatewayMapper covers aws_ec2_transit_gateway
+// A transit gateway has no charge of its own; it is priced through its attachments.
+type TransitGatewayMapper struct{}
+
+// NewTransitGatewayMapper creates a new transit gateway mapper
+func NewTransitGatewayMapper() *TransitGatewayMapper {
+	return &TransitGatewayMapper{}
+}
+
+// ResourceType returns the Terraform resource type
+func (m *TransitGatewayMapper) ResourceType() string {
+	return "aws_ec2_transit_gateway"
+}
+
+// SupportedAttributes returns attributes this mapper uses
+func (m *TransitGatewayMapper) SupportedAttributes() []string {
+	return []string{}
+}
+
+// MapToBillingComponents returns no components; see TransitGatewayAttachmentMapper
+func (m *TransitGatewayMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
+	return nil, nil
+}
+
+// TransitGatewayAttachmentMapper maps a transit gateway attachment to attachment
+// hours and, except for peering, the data it sends through the gateway
+// Peering traffic is billed as inter-region data transfer instead.
+type TransitGatewayAttachmentMapper struct {
+	resourceType   string
+	attachmentType string
+	processesData  bool
+}
+
+// NewTransitGatewayVPCAttachmentMapper creates a mapper for aws_ec2_transit_gateway_vpc_attachment
+func NewTransitGatewayVPCAttachmentMapper() *TransitGatewayAttachmentMapper {
+	return &TransitGatewayAttachmentMapper{"aws_ec2_transit_gateway_vpc_attachment", "VPC", true}
+}
+
+// NewTransitGatewayPeeringAttachmentMapper creates a mapper for aws_ec2_transit_gateway_peering_attachment
+func NewTransitGatewayPeeringAttachmentMapper() *TransitGatewayAttachmentMapper {
+	return &TransitGatewayAttachmentMapper{"aws_ec2_transit_gateway_peering_attachment", "Peering", false}
+}
+
+// NewTransitGatewayConnectMapper creates a mapper for aws_ec2_transit_gateway_connect
+func NewTransitGatewayConnectMapper() *TransitGatewayAttachmentMapper {
+	return &TransitGatewayAttachmentMapper{"aws_ec2_transit_gateway_connect", "Connect", true}
+}
+
+// ResourceType returns the Terraform resource type
+func (m *TransitGatewayAttachmentMapper) ResourceType() string {
+	return m.resourceType
+}
+
+// SupportedAttributes returns attributes this mapper uses
+func (m *TransitGatewayAttachmentMapper) SupportedAttributes() []string {
+	return []string{"transit_gateway_id"}
+}
+
+// MapToBillingComponents converts an attachment to attachment hours and data processed
+func (m *TransitGatewayAttachmentMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
+	return transitGatewayAttachment(node, node.Resource.Address, m.attachmentType, m.processesData), nil
+}
+
+// transitGatewayAttachment returns the hours and data components of an attachment
+// id prefixes the component IDs; it differs from the resource address for
+// attachments another resource creates implicitly.
+func transitGatewayAttachment(node *iac.GraphNode, id, attachmentType string, processesData bool) []billing.BillingComponent {
+	components := []billing.BillingComponent{{
+		ID:              fmt.Sprintf("%s-hours", id),
+		Cloud:           "aws",
+		Service:         "AmazonVPC",
+		ProductFamily:   "Transit Gateway",
+		Region:          node.Region,
+		UsageType:       "TransitGateway-Hours",
+		BillingPeriod:   billing.PeriodHourly,
+		Attributes:      map[string]string{},
+		Description:     fmt.Sprintf("Transit Gateway %s attachment hours", attachmentType),
+		Tags:            []string{"networking", "transit-gateway"},
+		VarianceProfile: billing.NewDefaultVarianceProfile(billing.HoursPerMonth),
+	}}
+	if processesData {
+		components = append(components, billing.BillingComponent{
+			ID:            fmt.Sprintf("%s-data", id),
+			Cloud:         "aws",
+			Service:       "AmazonVPC",
+			ProductFamily: "Transit Gateway",
+			Region:        node.Region,
+			UsageType:     "TransitGateway-Bytes",
+			BillingPeriod: billing.PeriodPerGB,
+			Attributes:    map[string]string{},
+			Description:   fmt.Sprintf("Transit Gateway %s attachment data processed", attachmentType),
+			Tags:          []string{"networking", "data-transfer"},
+			VarianceProfile: processedDataProfile(
+				fmt.Sprintf("%d GB/month sent through the transit gateway", defaultProcessedGB)),
+		})
+	}
+	return components
+}
+
+// =============================================================================
+// VPN Connection Mapper
+// =============================================================================
+
+// VPNConnectionMapper maps aws_vpn_connection to Site-to-Site VPN connection hours
+// A VPN terminating on a transit gateway also creates a billed VPN attachment.
+type VPNConnectionMapper struct{}
+
+// NewVPNConnectionMapper creates a new VPN connection mapper
+func NewVPNConnectionMapper() *VPNConnectionMapper {
+	return &VPNConnectionMapper{}
+}
+
+// ResourceType returns the Terraform resource type
+func (m *VPNConnectionMapper) ResourceType() string {
+	return "aws_vpn_connection"
+}
+
+// SupportedAttributes returns attributes this mapper uses
+func (m *VPNConnectionMapper) SupportedAttributes() []string {
+	return []string{"transit_gateway_id", "type"}
+}
+
+// MapToBillingComponents prices the connection; a transit gateway is found by attribute only
+func (m *VPNConnectionMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
+	return m.MapWithGraph(node, nil)
+}
+
+// MapWithGraph converts a VPN connection to connection hours plus any transit gateway attachment
+func (m *VPNConnectionMapper) MapWithGraph(node *iac.GraphNode, graph *iac.Graph) ([]billing.BillingComponent, []billing.MappingError) {
+	components := []billing.BillingComponent{{
+		ID:              fmt.Sprintf("%s-hours", node.Resource.Address),
+		Cloud:           "aws",
+		Service:         "AmazonVPC",
+		ProductFamily:   "Cloud Connectivity",
+		Region:          node.Region,
+		UsageType:       "VPN-Usage-Hours:ipsec.1",
+		BillingPeriod:   billing.PeriodHourly,
+		Attributes:      map[string]string{},
+		Description:     "Site-to-Site VPN connection hours",
+		Tags:            []string{"networking", "vpn"},
+		VarianceProfile: billing.NewDefaultVarianceProfile(billing.HoursPerMonth),
+	}}
+	if usesTransitGateway(node, graph) {
+		components = append(components, transitGatewayAttachment(node, node.Resource.Address+"-tgw", "VPN", true)...)
+	}
+	return components, nil
+}
+
+// usesTransitGateway reports whether a VPN connection terminates on a transit gateway,
+// whose ID is unknown until apply when the gateway is created in the same plan
+func usesTransitGateway(node *iac.GraphNode, graph *iac.Graph) bool {
+	if billing.ExtractAttribute(node.Resource.Attributes, "transit_gateway_id") != "" {
+		return true
+	}
+	if graph == nil {
+		return false
+	}
+	for _, dep := range node.Dependencies {
+		if n, ok := graph.Nodes[dep]; ok && n.Resource.Type == "aws_ec2_transit_gateway" {
+			return true
+		}
+	}
+	return false
+}
+
+// =============================================================================
+// VPC Endpoint Mapper
+// =============================================================================
+
+// VPCEndpointMapper maps aws_vpc_endpoint to endpoint hours and data processed
+// Interface endpoints are billed per AZ they have a network interface in;
+// gateway endpoints (S3 and DynamoDB) are free.
+type VPCEndpointMapper struct{}
+
+// NewVPCEndpointMapper creates a new VPC endpoint mapper
+func NewVPCEndpointMapper() *VPCEndpointMapper {
+	return &VPCEndpointMapper{}
+}
+
+// ResourceType returns the Terraform resource type
+func (m *VPCEndpointMapper) ResourceType() string {
+	return "aws_vpc_endpoint"
+}
+
+// SupportedAttributes returns attributes this mapper uses
+func (m *VPCEndpointMapper) SupportedAttributes() []string {
+	return []string{"vpc_endpoint_type", "subnet_ids", "service_name"}
+}
+
+// MapToBillingComponents converts an interface or Gateway Load Balancer endpoint to AZ hours and data
+func (m *VPCEndpointMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
+	attrs := node.Resource.Attributes
+	addr := node.Resource.Address
+
+	var endpointType string
+	switch strings.ToLower(billing.ExtractAttribute(attrs, "vpc_endpoint_type")) {
+	case "interface":
+		endpointType = "PrivateLink"
+	case "gatewayloadbalancer":
+		endpointType = "Gateway Load Balancer Endpoint"
+	default:
+		return nil, nil
+	}
+
+	azs, assumption := 1, ""
+	if endpointType == "PrivateLink" {
+		if subnets, ok := attrs["subnet_ids"].([]interface{}); ok && len(subnets) > 0 {
+			azs = len(subnets)
+		} else {
+			azs = defaultEndpointAZs
+			assumption = fmt.Sprintf("Subnets unknown until apply; %d AZs assumed", defaultEndpointAZs)
+		}
+	}
+	hoursProfile := billing.NewDefaultVarianceProfile(billing.HoursPerMonth * float64(azs))
+	if assumption != "" {
+		hoursProfile.Assumptions = append(hoursProfile.Assumptions, assumption)
+	}
+
+	service := billing.ExtractAttribute(attrs, "service_name")
+	if i := strings.LastIndex(service, "."); i >= 0 {
+		service = service[i+1:]
+	}
+	label := "VPC endpoint"
+	if service != "" {
+		label = fmt.Sprintf("VPC endpoint (%s)", service)
+	}
+
+	return []billing.BillingComponent{
+		{
+			ID:              fmt.Sprintf("%s-hours", addr),
+			Cloud:           "aws",
+			Service:         "AmazonVPC",
+			ProductFamily:   "VpcEndpoint",
+			Region:          node.Region,
+			UsageType:       "VpcEndpoint-Hours",
+			BillingPeriod:   billing.PeriodHourly,
+			Attributes:      map[string]string{"endpointType": endpointType},
+			Description:     fmt.Sprintf("%s hours × %d AZ", label, azs),
+			Tags:            []string{"networking", "vpc-endpoint"},
+			VarianceProfile: hoursProfile,
+		},
+		{
+			ID:            fmt.Sprintf("%s-data", addr),
+			Cloud:         "aws",
+			Service:       "AmazonVPC",
+			ProductFamily: "VpcEndpoint",
+			Region:        node.Region,
+			UsageType:     "VpcEndpoint-Bytes",
+			BillingPeriod: billing.PeriodPerGB,
+			Attributes:    map[string]string{"endpointType": endpointType},
+			Description:   fmt.Sprintf("%s data processed", label),
+			Tags:          []string{"networking", "data-transfer"},
+			VarianceProfile: processedDataProfile(
+				fmt.Sprintf("%d GB/month processed by the endpoint", defaultProcessedGB)),
+		},
+	}, nil
+}
@@ -0,0 +1,79 @@
+package aws
+
+import (
+	"testing"
+
+	"terraform-cost/decision/iac"
+)
+
+func TestVPCEndpointMapperBillsInterfaceEndpointsPerAZ(t *testing.T) {
+	endpoint := func(attrs map[string]interface{}) *iac.GraphNode {
+		return &iac.GraphNode{Resource: iac.ResourceNode{
+			Address:    "aws_vpc_endpoint.ecr",
+			Type:       "aws_vpc_endpoint",
+			Attributes: attrs,
+		}}
+	}
+
+	components, _ := NewVPCEndpointMapper().MapToBillingComponents(endpoint(map[string]interface{}{
+		"vpc_endpoint_type": "Interface",
+		"service_name":      "com.amazonaws.us-east-1.ecr.dkr",
+		"subnet_ids":        []interface{}{"subnet-a", "subnet-b", "subnet-c"},
+	}))
+	if len(components) != 2 {
+		t.Fatalf("expected hours and data components, got %d", len(components))
+	}
+	if got := components[0].VarianceProfile.BaselineUsage; got != 3*730 {
+		t.Errorf("endpoint hours = %v, want %v", got, 3*730)
+	}
+	if got := components[0].Attributes["endpointType"]; got != "PrivateLink" {
+		t.Errorf("endpointType = %q, want PrivateLink", got)
+	}
+
+	components, _ = NewVPCEndpointMapper().MapToBillingComponents(endpoint(map[string]interface{}{
+		"vpc_endpoint_type": "Interface",
+	}))
+	if got := components[0].VarianceProfile.BaselineUsage; got != defaultEndpointAZs*730 {
+		t.Errorf("endpoint hours with unknown subnets = %v, want %v", got, defaultEndpointAZs*730)
+	}
+
+	components, _ = NewVPCEndpointMapper().MapToBillingComponents(endpoint(map[string]interface{}{
+		"vpc_endpoint_type": "Gateway",
+		"service_name":      "com.amazonaws.us-east-1.s3",
+	}))
+	if len(components) != 0 {
+		t.Errorf("gateway endpoints are free, got %d components", len(components))
+	}
+}
+
+func TestVPNConnectionMapperAddsTransitGatewayAttachment(t *testing.T) {
+	tgw := &iac.GraphNode{Resource: iac.ResourceNode{Address: "aws_ec2_transit_gateway.hub", Type: "aws_ec2_transit_gateway"}}
+	vpn := &iac.GraphNode{
+		Resource:     iac.ResourceNode{Address: "aws_vpn_connection.office", Type: "aws_vpn_connection"},
+		Dependencies: []string{tgw.Resource.Address},
+	}
+	graph := &iac.Graph{Nodes: map[string]*iac.GraphNode{
+		tgw.Resource.Address: tgw,
+		vpn.Resource.Address: vpn,
+	}}
+
+	components, _ := NewVPNConnectionMapper().MapWithGraph(vpn, graph)
+	want := []string{
+		"aws_vpn_connection.office-hours",
+		"aws_vpn_connection.office-tgw-hours",
+		"aws_vpn_connection.office-tgw-data",
+	}
+	if len(components) != len(want) {
+		t.Fatalf("expected %v, got %d components", want, len(components))
+	}
+	for i, id := range want {
+		if components[i].ID != id {
+			t.Errorf("component %d = %s, want %s", i, components[i].ID, id)
+		}
+	}
+
+	components, _ = NewVPNConnectionMapper().MapWithGraph(vpn, nil)
+	if len(components) != 1 {
+		t.Errorf("expected only connection hours without a transit gateway, got %d", len(components))
+	}
+}
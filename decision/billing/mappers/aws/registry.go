@@ -7,20 +7,51 @@ import "terraform-cost/decision/billing"
 func RegisterAllMappers(engine *billing.Engine) {
 	// Compute
 	engine.RegisterMapper(NewEC2InstanceMapper())
+	engine.RegisterMapper(NewSpotInstanceRequestMapper())
+	engine.RegisterMapper(NewAutoscalingGroupMapper())
 	engine.RegisterMapper(NewEBSVolumeMapper())
 	engine.RegisterMapper(NewLambdaFunctionMapper())
 	
+	// Containers
+	engine.RegisterMapper(NewECSServiceMapper())
+	engine.RegisterMapper(NewECSTaskDefinitionMapper())
+	
 	// Database
 	engine.RegisterMapper(NewRDSInstanceMapper())
 	engine.RegisterMapper(NewDynamoDBTableMapper())
 	
+	// Analytics
+	engine.RegisterMapper(NewRedshiftClusterMapper())
+	engine.RegisterMapper(NewEMRClusterMapper())
+	engine.RegisterMapper(NewEMRInstanceGroupMapper())
+	engine.RegisterMapper(NewEMRInstanceFleetMapper())
+	
+	// Machine learning
+	engine.RegisterMapper(NewSageMakerEndpointMapper())
+	engine.RegisterMapper(NewSageMakerEndpointConfigurationMapper())
+	engine.RegisterMapper(NewSageMakerNotebookInstanceMapper())
+	
 	// Storage
 	engine.RegisterMapper(NewS3BucketMapper())
 	
+	// Messaging
+	engine.RegisterMapper(NewSQSQueueMapper())
+	engine.RegisterMapper(NewSNSTopicMapper())
+	engine.RegisterMapper(NewSNSTopicSubscriptionMapper())
+	engine.RegisterMapper(NewEventBusMapper())
+	engine.RegisterMapper(NewSchedulerScheduleMapper())
+	engine.RegisterMapper(NewKinesisStreamMapper())
+	
 	// Networking
 	engine.RegisterMapper(NewNATGatewayMapper())
 	engine.RegisterMapper(NewLBMapper())
 	engine.RegisterMapper(NewEIPMapper())
+	engine.RegisterMapper(NewTransitGatewayMapper())
+	engine.RegisterMapper(NewTransitGatewayVPCAttachmentMapper())
+	engine.RegisterMapper(NewTransitGatewayPeeringAttachmentMapper())
+	engine.RegisterMapper(NewTransitGatewayConnectMapper())
+	engine.RegisterMapper(NewVPNConnectionMapper())
+	engine.RegisterMapper(NewVPCEndpointMapper())
 	
 	// TODO: Add more mappers as needed
 }
@@ -29,15 +60,38 @@ func RegisterAllMappers(engine *billing.Engine) {
 func SupportedResourceTypes() []string {
 	return []string{
 		"aws_instance",
+		"aws_spot_instance_request",
+		"aws_autoscaling_group",
 		"aws_ebs_volume",
 		"aws_lambda_function",
+		"aws_ecs_service",
+		"aws_ecs_task_definition",
 		"aws_db_instance",
 		"aws_dynamodb_table",
+		"aws_redshift_cluster",
+		"aws_emr_cluster",
+		"aws_emr_instance_group",
+		"aws_emr_instance_fleet",
+		"aws_sagemaker_endpoint",
+		"aws_sagemaker_endpoint_configuration",
+		"aws_sagemaker_notebook_instance",
 		"aws_s3_bucket",
+		"aws_sqs_queue",
+		"aws_sns_topic",
+		"aws_sns_topic_subscription",
+		"aws_cloudwatch_event_bus",
+		"aws_scheduler_schedule",
+		"aws_kinesis_stream",
 		"aws_nat_gateway",
 		"aws_lb",
 		"aws_alb",
 		"aws_elb",
 		"aws_eip",
+		"aws_ec2_transit_gateway",
+		"aws_ec2_transit_gateway_vpc_attachment",
+		"aws_ec2_transit_gateway_peering_attachment",
+		"aws_ec2_transit_gateway_connect",
+		"aws_vpn_connection",
+		"aws_vpc_endpoint",
 	}
 }
@@ -4,6 +4,7 @@ package aws
 
 import (
 	"fmt"
+	"strings"
 
 	"terraform-cost/decision/billing"
 	"terraform-cost/decision/iac"
@@ -23,6 +24,8 @@ func (m *EBSVolumeMapper) SupportedAttributes() []string {
 	return []string{"size", "type", "iops", "throughput"}
 }
 
+func (m *EBSVolumeMapper) CostDrivingAttributes() []string { return []string{"size"} }
+
 func (m *EBSVolumeMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
 	attrs := node.Resource.Attributes
 	
@@ -64,6 +67,8 @@ func (m *LambdaFunctionMapper) SupportedAttributes() []string {
 	return []string{"memory_size", "timeout", "architectures"}
 }
 
+func (m *LambdaFunctionMapper) CostDrivingAttributes() []string { return []string{"memory_size"} }
+
 func (m *LambdaFunctionMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
 	attrs := node.Resource.Attributes
 	
@@ -103,7 +108,20 @@ func NewRDSInstanceMapper() *RDSInstanceMapper { return &RDSInstanceMapper{} }
 func (m *RDSInstanceMapper) ResourceType() string { return "aws_db_instance" }
 
 func (m *RDSInstanceMapper) SupportedAttributes() []string {
-	return []string{"instance_class", "engine", "allocated_storage", "multi_az"}
+	return []string{"instance_class", "engine", "license_model", "allocated_storage", "storage_type", "iops", "multi_az"}
+}
+
+func (m *RDSInstanceMapper) CostDrivingAttributes() []string { return []string{"instance_class"} }
+
+// CostDrivenBy reports whether the instance class sizes a component; storage is priced without it
+func (m *RDSInstanceMapper) CostDrivenBy(component billing.BillingComponent, path string) bool {
+	return path != "instance_class" || drivenByInstanceType(component)
+}
+
+// drivenByInstanceType reports whether a component is priced by its instance type
+func drivenByInstanceType(component billing.BillingComponent) bool {
+	_, ok := component.Attributes["instanceType"]
+	return ok
 }
 
 func (m *RDSInstanceMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
@@ -111,9 +129,17 @@ func (m *RDSInstanceMapper) MapToBillingComponents(node *iac.GraphNode) ([]billi
 	components := make([]billing.BillingComponent, 0)
 	
 	instanceClass := billing.ExtractAttribute(attrs, "instance_class")
-	engine := billing.ExtractAttribute(attrs, "engine")
+	engine := rdsEngineOf(billing.ExtractAttribute(attrs, "engine"), billing.ExtractAttribute(attrs, "license_model"))
 	storage := billing.ExtractAttributeFloat(attrs, "allocated_storage", 20)
 	multiAZ := billing.ExtractAttributeBool(attrs, "multi_az", false)
+	storageType := billing.ExtractAttribute(attrs, "storage_type")
+	if storageType == "" {
+		// The provider's default: io1 with provisioned IOPS, gp2 otherwise
+		storageType = "gp2"
+		if billing.ExtractAttributeFloat(attrs, "iops", 0) > 0 {
+			storageType = "io1"
+		}
+	}
 	
 	deploymentOption := "Single-AZ"
 	if multiAZ {
@@ -131,12 +157,14 @@ func (m *RDSInstanceMapper) MapToBillingComponents(node *iac.GraphNode) ([]billi
 		BillingPeriod: billing.PeriodHourly,
 		Attributes: map[string]string{
 			"instanceType":     instanceClass,
-			"databaseEngine":   engine,
+			"databaseEngine":   engine.Engine,
+			"databaseEdition":  engine.Edition,
+			"licenseModel":     engine.LicenseModel,
 			"deploymentOption": deploymentOption,
 		},
 		Description:     fmt.Sprintf("RDS %s (%s, %s)", instanceClass, engine, deploymentOption),
 		Tags:            []string{"database", "rds"},
-		VarianceProfile: billing.NewDefaultVarianceProfile(730),
+		VarianceProfile: billing.NewDefaultVarianceProfile(billing.HoursPerMonth),
 	})
 	
 	// Storage component
@@ -146,19 +174,119 @@ func (m *RDSInstanceMapper) MapToBillingComponents(node *iac.GraphNode) ([]billi
 		Service:       "AmazonRDS",
 		ProductFamily: "Database Storage",
 		Region:        node.Region,
-		UsageType:     "RDS:GP3-Storage",
+		UsageType:     rdsStorageUsageTypes[storageType],
 		BillingPeriod: billing.PeriodMonthly,
 		Attributes: map[string]string{
 			"deploymentOption": deploymentOption,
+			"volumeType":       storageType,
 		},
-		Description:     fmt.Sprintf("RDS storage (%.0f GB)", storage),
+		Description:     fmt.Sprintf("RDS %s storage (%.0f GB)", storageType, storage),
 		Tags:            []string{"database", "storage"},
 		VarianceProfile: billing.VarianceProfile{BaselineUsage: storage, P50Usage: storage, Confidence: 0.95},
 	})
 	
+	// Provisioned IOPS of io1 and io2 storage, billed per IOPS-month
+	if iops := billing.ExtractAttributeFloat(attrs, "iops", 0); iops > 0 && rdsIOPSUsageTypes[storageType] != "" {
+		components = append(components, billing.BillingComponent{
+			ID:            fmt.Sprintf("%s-iops", node.Resource.Address),
+			Cloud:         "aws",
+			Service:       "AmazonRDS",
+			ProductFamily: "Provisioned IOPS",
+			Region:        node.Region,
+			UsageType:     rdsIOPSUsageTypes[storageType],
+			BillingPeriod: billing.PeriodMonthly,
+			Attributes: map[string]string{
+				"deploymentOption": deploymentOption,
+				"volumeType":       storageType,
+			},
+			Description:     fmt.Sprintf("RDS %s provisioned IOPS (%.0f)", storageType, iops),
+			Tags:            []string{"database", "storage", "iops"},
+			VarianceProfile: billing.VarianceProfile{BaselineUsage: iops, P50Usage: iops, P90Usage: iops, Confidence: 0.95},
+		})
+	}
+	
 	return components, nil
 }
 
+// rdsIOPSUsageTypes are the Price List usage types of provisioned IOPS; gp3
+// includes a baseline and is priced on storage alone
+var rdsIOPSUsageTypes = map[string]string{
+	"io1": "RDS:PIOPS",
+	"io2": "RDS:PIOPS-IO2",
+}
+
+// rdsStorageUsageTypes are the Price List usage types of RDS storage types
+var rdsStorageUsageTypes = map[string]string{
+	"gp2":      "RDS:GP2-Storage",
+	"gp3":      "RDS:GP3-Storage",
+	"io1":      "RDS:PIOPS-Storage",
+	"io2":      "RDS:PIOPS-Storage-IO2",
+	"standard": "RDS:StorageUsage",
+}
+
+// Price List license models of RDS instances
+const (
+	rdsLicenseIncluded    = "License included"
+	rdsLicenseBYOL        = "Bring your own license"
+	rdsLicenseNotRequired = "No license required"
+)
+
+// rdsEngine is how an RDS engine is billed
+type rdsEngine struct {
+	Engine       string // Price List databaseEngine: MySQL, SQL Server, Oracle, ...
+	Edition      string // Price List databaseEdition; empty for open-source engines
+	LicenseModel string
+}
+
+func (e rdsEngine) String() string {
+	name := e.Engine
+	if e.Edition != "" {
+		name += " " + e.Edition
+	}
+	if e.LicenseModel == rdsLicenseBYOL {
+		name += " BYOL"
+	}
+	return name
+}
+
+// rdsEngines maps Terraform engines to the Price List engine, edition and the
+// license model RDS defaults to. Edition and license model are part of the
+// rate key, so pricing snapshots ingested without them must be re-ingested.
+var rdsEngines = map[string]rdsEngine{
+	"mysql":             {"MySQL", "", rdsLicenseNotRequired},
+	"postgres":          {"PostgreSQL", "", rdsLicenseNotRequired},
+	"mariadb":           {"MariaDB", "", rdsLicenseNotRequired},
+	"aurora":            {"Aurora MySQL", "", rdsLicenseNotRequired},
+	"aurora-mysql":      {"Aurora MySQL", "", rdsLicenseNotRequired},
+	"aurora-postgresql": {"Aurora PostgreSQL", "", rdsLicenseNotRequired},
+	"sqlserver-ee":      {"SQL Server", "Enterprise", rdsLicenseIncluded},
+	"sqlserver-se":      {"SQL Server", "Standard", rdsLicenseIncluded},
+	"sqlserver-web":     {"SQL Server", "Web", rdsLicenseIncluded},
+	"sqlserver-ex":      {"SQL Server", "Express", rdsLicenseIncluded},
+	"oracle-ee":         {"Oracle", "Enterprise", rdsLicenseBYOL},
+	"oracle-ee-cdb":     {"Oracle", "Enterprise", rdsLicenseBYOL},
+	"oracle-se2":        {"Oracle", "Standard Two", rdsLicenseBYOL},
+	"oracle-se2-cdb":    {"Oracle", "Standard Two", rdsLicenseBYOL},
+	"db2-se":            {"Db2", "Standard", rdsLicenseBYOL},
+	"db2-ae":            {"Db2", "Advanced", rdsLicenseBYOL},
+}
+
+// rdsEngineOf returns the billed engine of a Terraform engine and license_model
+// Unknown engines keep their Terraform name, leaving them to relaxed matching.
+func rdsEngineOf(engine, licenseModel string) rdsEngine {
+	e, ok := rdsEngines[strings.ToLower(engine)]
+	if !ok {
+		return rdsEngine{Engine: engine, LicenseModel: rdsLicenseNotRequired}
+	}
+	switch licenseModel {
+	case "license-included":
+		e.LicenseModel = rdsLicenseIncluded
+	case "bring-your-own-license":
+		e.LicenseModel = rdsLicenseBYOL
+	}
+	return e
+}
+
 // =============================================================================
 // DynamoDB Table Mapper
 // =============================================================================
@@ -216,7 +344,7 @@ func (m *DynamoDBTableMapper) MapToBillingComponents(node *iac.GraphNode) ([]bil
 			Attributes:    map[string]string{},
 			Description:   fmt.Sprintf("DynamoDB %.0f RCU", rcu),
 			Tags:          []string{"database", "dynamodb"},
-			VarianceProfile: billing.VarianceProfile{BaselineUsage: rcu * 730, P50Usage: rcu * 730, Confidence: 0.9},
+			VarianceProfile: billing.VarianceProfile{BaselineUsage: rcu * billing.HoursPerMonth, P50Usage: rcu * billing.HoursPerMonth, Confidence: 0.9},
 		},
 		{
 			ID:            fmt.Sprintf("%s-wcu", node.Resource.Address),
@@ -229,7 +357,7 @@ func (m *DynamoDBTableMapper) MapToBillingComponents(node *iac.GraphNode) ([]bil
 			Attributes:    map[string]string{},
 			Description:   fmt.Sprintf("DynamoDB %.0f WCU", wcu),
 			Tags:          []string{"database", "dynamodb"},
-			VarianceProfile: billing.VarianceProfile{BaselineUsage: wcu * 730, P50Usage: wcu * 730, Confidence: 0.9},
+			VarianceProfile: billing.VarianceProfile{BaselineUsage: wcu * billing.HoursPerMonth, P50Usage: wcu * billing.HoursPerMonth, Confidence: 0.9},
 		},
 	}, nil
 }
@@ -299,7 +427,7 @@ func (m *NATGatewayMapper) MapToBillingComponents(node *iac.GraphNode) ([]billin
 			Attributes:    map[string]string{},
 			Description:   "NAT Gateway hours",
 			Tags:          []string{"networking", "nat"},
-			VarianceProfile: billing.NewDefaultVarianceProfile(730),
+			VarianceProfile: billing.NewDefaultVarianceProfile(billing.HoursPerMonth),
 		},
 		{
 			ID:            fmt.Sprintf("%s-data", node.Resource.Address),
@@ -373,7 +501,7 @@ func (m *LBMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.Billin
 			},
 			Description:     fmt.Sprintf("%s Load Balancer hours", lbType),
 			Tags:            []string{"networking", "loadbalancer"},
-			VarianceProfile: billing.NewDefaultVarianceProfile(730),
+			VarianceProfile: billing.NewDefaultVarianceProfile(billing.HoursPerMonth),
 		},
 	}, nil
 }
@@ -415,6 +543,6 @@ func (m *EIPMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.Billi
 		Attributes:    map[string]string{},
 		Description:   "Idle Elastic IP address",
 		Tags:          []string{"networking", "eip"},
-		VarianceProfile: billing.NewDefaultVarianceProfile(730),
+		VarianceProfile: billing.NewDefaultVarianceProfile(billing.HoursPerMonth),
 	}}, nil
 }
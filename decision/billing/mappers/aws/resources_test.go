@@ -0,0 +1,61 @@
+package aws
+
+import (
+	"testing"
+
+	"terraform-cost/decision/iac"
+)
+
+func TestRDSInstanceLicensing(t *testing.T) {
+	tests := []struct {
+		engine, licenseModel       string
+		dbEngine, edition, license string
+	}{
+		{"mysql", "general-public-license", "MySQL", "", "No license required"},
+		{"postgres", "", "PostgreSQL", "", "No license required"},
+		{"sqlserver-se", "", "SQL Server", "Standard", "License included"},
+		{"sqlserver-ee", "license-included", "SQL Server", "Enterprise", "License included"},
+		{"oracle-se2", "", "Oracle", "Standard Two", "Bring your own license"},
+		{"oracle-se2", "license-included", "Oracle", "Standard Two", "License included"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.engine+"/"+tt.licenseModel, func(t *testing.T) {
+			attrs := map[string]interface{}{"instance_class": "db.m5.large", "engine": tt.engine}
+			if tt.licenseModel != "" {
+				attrs["license_model"] = tt.licenseModel
+			}
+			node := &iac.GraphNode{Resource: iac.ResourceNode{Address: "aws_db_instance.main", Type: "aws_db_instance", Attributes: attrs}, Region: "us-east-1"}
+			components, _ := NewRDSInstanceMapper().MapToBillingComponents(node)
+			got := components[0].Attributes
+			if got["databaseEngine"] != tt.dbEngine || got["databaseEdition"] != tt.edition || got["licenseModel"] != tt.license {
+				t.Errorf("got %q / %q / %q, want %q / %q / %q",
+					got["databaseEngine"], got["databaseEdition"], got["licenseModel"], tt.dbEngine, tt.edition, tt.license)
+			}
+		})
+	}
+}
+
+func TestRDSInstanceStorageType(t *testing.T) {
+	tests := []struct {
+		attrs           map[string]interface{}
+		want, usageType string
+	}{
+		{map[string]interface{}{}, "gp2", "RDS:GP2-Storage"},
+		{map[string]interface{}{"iops": float64(3000)}, "io1", "RDS:PIOPS-Storage"},
+		{map[string]interface{}{"storage_type": "gp3", "iops": float64(3000)}, "gp3", "RDS:GP3-Storage"},
+	}
+	for _, tt := range tests {
+		tt.attrs["instance_class"] = "db.m5.large"
+		node := &iac.GraphNode{Resource: iac.ResourceNode{Address: "aws_db_instance.main", Type: "aws_db_instance", Attributes: tt.attrs}, Region: "us-east-1"}
+		components, _ := NewRDSInstanceMapper().MapToBillingComponents(node)
+		storage := components[1]
+		if storage.Attributes["volumeType"] != tt.want || storage.UsageType != tt.usageType {
+			t.Errorf("%v: storage = %s (%s), want %s (%s)", tt.attrs, storage.Attributes["volumeType"], storage.UsageType, tt.want, tt.usageType)
+		}
+		// Only io1 and io2 bill provisioned IOPS
+		wantIOPS := tt.want == "io1"
+		if hasIOPS := len(components) == 3 && components[2].VarianceProfile.P50Usage == 3000; hasIOPS != wantIOPS {
+			t.Errorf("%v: components = %d, want IOPS component %v", tt.attrs, len(components), wantIOPS)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+// Package aws provides SageMaker endpoint and notebook instance mappers
+package aws
+
+import (
+	"fmt"
+
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/iac"
+)
+
+// sagemakerDefaultVolumeGB is the ML storage of a notebook instance without volume_size
+const sagemakerDefaultVolumeGB = 5
+
+// =============================================================================
+// SageMaker Endpoint Mapper
+// =============================================================================
+
+// SageMakerEndpointMapper maps aws_sagemaker_endpoint to hosting instance hours
+// Instances come from the production variants of the endpoint configuration it runs.
+type SageMakerEndpointMapper struct{}
+
+// NewSageMakerEndpointMapper creates a new SageMaker endpoint mapper
+func NewSageMakerEndpointMapper() *SageMakerEndpointMapper {
+	return &SageMakerEndpointMapper{}
+}
+
+// ResourceType returns the Terraform resource type
+func (m *SageMakerEndpointMapper) ResourceType() string {
+	return "aws_sagemaker_endpoint"
+}
+
+// SupportedAttributes returns attributes this mapper uses
+func (m *SageMakerEndpointMapper) SupportedAttributes() []string {
+	return []string{"endpoint_config_name"}
+}
+
+// MapToBillingComponents cannot size the endpoint without the graph
+func (m *SageMakerEndpointMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
+	return m.MapWithGraph(node, nil)
+}
+
+// MapWithGraph converts an endpoint to instance hours per production variant
+func (m *SageMakerEndpointMapper) MapWithGraph(node *iac.GraphNode, graph *iac.Graph) ([]billing.BillingComponent, []billing.MappingError) {
+	config := findEndpointConfiguration(node, graph)
+	if config == nil {
+		return nil, []billing.MappingError{{
+			ResourceAddr: node.Resource.Address,
+			ResourceType: node.Resource.Type,
+			Reason:       "endpoint configuration not found in plan; cannot size hosting instances",
+			IsCritical:   false,
+		}}
+	}
+
+	var components []billing.BillingComponent
+	var errs []billing.MappingError
+	for _, block := range []string{"production_variants", "shadow_production_variants"} {
+		variants, _ := config.Resource.Attributes[block].([]interface{})
+		for i, v := range variants {
+			variant, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name := billing.ExtractAttribute(variant, "variant_name")
+			if name == "" {
+				name = fmt.Sprintf("variant-%d", i)
+			}
+			if block == "shadow_production_variants" {
+				name = "shadow-" + name
+			}
+
+			instanceType := billing.ExtractAttribute(variant, "instance_type")
+			if instanceType == "" {
+				reason := fmt.Sprintf("variant %s has no instance type", name)
+				// Serverless variants are billed per inference duration, which the plan doesn't say
+				if firstNested(variant, "serverless_config") != nil {
+					reason = fmt.Sprintf("variant %s is serverless; its inference duration and data processed are not estimated", name)
+				}
+				errs = append(errs, billing.MappingError{
+					ResourceAddr: node.Resource.Address,
+					ResourceType: node.Resource.Type,
+					Reason:       reason,
+					IsCritical:   false,
+				})
+				continue
+			}
+			count := billing.ExtractAttributeInt(variant, "initial_instance_count", 1)
+			if count == 0 {
+				continue
+			}
+
+			components = append(components, billing.BillingComponent{
+				ID:            fmt.Sprintf("%s-%s", node.Resource.Address, name),
+				Cloud:         "aws",
+				Service:       "AmazonSageMaker",
+				ProductFamily: "ML Instance",
+				Region:        node.Region,
+				UsageType:     fmt.Sprintf("Host:%s", instanceType),
+				BillingPeriod: billing.PeriodHourly,
+				Attributes: map[string]string{
+					"instanceType": instanceType,
+					"component":    "Hosting",
+				},
+				Description:     fmt.Sprintf("SageMaker endpoint %s %d× %s instance hours", name, count, instanceType),
+				Tags:            []string{"ml", "sagemaker"},
+				VarianceProfile: billing.NewDefaultVarianceProfile(float64(count) * billing.HoursPerMonth),
+			})
+		}
+	}
+	return components, errs
+}
+
+// findEndpointConfiguration locates the endpoint's configuration by dependency or name
+func findEndpointConfiguration(node *iac.GraphNode, graph *iac.Graph) *iac.GraphNode {
+	if graph == nil {
+		return nil
+	}
+	for _, dep := range node.Dependencies {
+		if n, ok := graph.Nodes[dep]; ok && n.Resource.Type == "aws_sagemaker_endpoint_configuration" {
+			return n
+		}
+	}
+
+	name := billing.ExtractAttribute(node.Resource.Attributes, "endpoint_config_name")
+	if name == "" {
+		return nil
+	}
+	for _, n := range graph.Nodes {
+		if n.Resource.Type == "aws_sagemaker_endpoint_configuration" &&
+			billing.ExtractAttribute(n.Resource.Attributes, "name") == name {
+			return n
+		}
+	}
+	return nil
+}
+
+// =============================================================================
+// SageMaker Endpoint Configuration Mapper
+// =============================================================================
+
+// SageMakerEndpointConfigurationMapper covers aws_sagemaker_endpoint_configuration
+// Configurations are free; they are priced through the endpoints that run them.
+type SageMakerEndpointConfigurationMapper struct{}
+
+// NewSageMakerEndpointConfigurationMapper creates a new SageMaker endpoint configuration mapper
+func NewSageMakerEndpointConfigurationMapper() *SageMakerEndpointConfigurationMapper {
+	return &SageMakerEndpointConfigurationMapper{}
+}
+
+// ResourceType returns the Terraform resource type
+func (m *SageMakerEndpointConfigurationMapper) ResourceType() string {
+	return "aws_sagemaker_endpoint_configuration"
+}
+
+// SupportedAttributes returns attributes this mapper uses
+func (m *SageMakerEndpointConfigurationMapper) SupportedAttributes() []string {
+	return []string{"production_variants", "shadow_production_variants"}
+}
+
+// MapToBillingComponents returns no components; see SageMakerEndpointMapper
+func (m *SageMakerEndpointConfigurationMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
+	return nil, nil
+}
+
+// =============================================================================
+// SageMaker Notebook Instance Mapper
+// =============================================================================
+
+// SageMakerNotebookInstanceMapper maps aws_sagemaker_notebook_instance to
+// instance hours and ML storage
+type SageMakerNotebookInstanceMapper struct{}
+
+// NewSageMakerNotebookInstanceMapper creates a new SageMaker notebook instance mapper
+func NewSageMakerNotebookInstanceMapper() *SageMakerNotebookInstanceMapper {
+	return &SageMakerNotebookInstanceMapper{}
+}
+
+// ResourceType returns the Terraform resource type
+func (m *SageMakerNotebookInstanceMapper) ResourceType() string {
+	return "aws_sagemaker_notebook_instance"
+}
+
+// SupportedAttributes returns attributes this mapper uses
+func (m *SageMakerNotebookInstanceMapper) SupportedAttributes() []string {
+	return []string{"instance_type", "volume_size"}
+}
+
+// MapToBillingComponents converts a notebook instance to instance hours and its volume
+func (m *SageMakerNotebookInstanceMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
+	attrs := node.Resource.Attributes
+	addr := node.Resource.Address
+
+	instanceType := billing.ExtractAttribute(attrs, "instance_type")
+	if instanceType == "" {
+		return nil, []billing.MappingError{{
+			ResourceAddr: addr,
+			ResourceType: node.Resource.Type,
+			Reason:       "instance_type is required to price a notebook instance",
+			IsCritical:   true,
+		}}
+	}
+	volumeGB := billing.ExtractAttributeFloat(attrs, "volume_size", sagemakerDefaultVolumeGB)
+
+	hours := billing.NewDefaultVarianceProfile(billing.HoursPerMonth)
+	hours.Assumptions = append(hours.Assumptions, "Notebook left running; stopped hours are not billed")
+
+	return []billing.BillingComponent{
+		{
+			ID:            fmt.Sprintf("%s-hours", addr),
+			Cloud:         "aws",
+			Service:       "AmazonSageMaker",
+			ProductFamily: "ML Instance",
+			Region:        node.Region,
+			UsageType:     fmt.Sprintf("Notebk:%s", instanceType),
+			BillingPeriod: billing.PeriodHourly,
+			Attributes: map[string]string{
+				"instanceType": instanceType,
+				"component":    "Notebook",
+			},
+			Description:     fmt.Sprintf("SageMaker notebook %s instance hours", instanceType),
+			Tags:            []string{"ml", "sagemaker"},
+			VarianceProfile: hours,
+		},
+		{
+			ID:            fmt.Sprintf("%s-storage", addr),
+			Cloud:         "aws",
+			Service:       "AmazonSageMaker",
+			ProductFamily: "ML Storage",
+			Region:        node.Region,
+			UsageType:     "Notebk:VolumeUsage",
+			BillingPeriod: billing.PeriodMonthly,
+			Attributes:    map[string]string{"component": "Notebook"},
+			Description:   fmt.Sprintf("SageMaker notebook ML storage (%.0f GB)", volumeGB),
+			Tags:          []string{"ml", "sagemaker", "storage"},
+			VarianceProfile: billing.VarianceProfile{
+				BaselineUsage: volumeGB,
+				MinUsage:      volumeGB,
+				MaxUsage:      volumeGB,
+				P50Usage:      volumeGB,
+				P90Usage:      volumeGB,
+				Confidence:    0.99,
+				Assumptions:   []string{"Volume size is fixed as provisioned"},
+			},
+		},
+	}, nil
+}
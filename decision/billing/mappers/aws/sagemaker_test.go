@@ -0,0 +1,56 @@
+package aws
+
+import (
+	"strings"
+	"testing"
+
+	"terraform-cost/decision/iac"
+)
+
+func TestSageMakerEndpointMapperPricesVariants(t *testing.T) {
+	config := &iac.GraphNode{Resource: iac.ResourceNode{
+		Address: "aws_sagemaker_endpoint_configuration.model",
+		Type:    "aws_sagemaker_endpoint_configuration",
+		Attributes: map[string]interface{}{
+			"name": "model-v2",
+			"production_variants": []interface{}{
+				map[string]interface{}{"variant_name": "primary", "instance_type": "ml.g5.xlarge", "initial_instance_count": float64(2)},
+				map[string]interface{}{"variant_name": "serverless", "serverless_config": []interface{}{map[string]interface{}{"memory_size_in_mb": float64(2048)}}},
+			},
+			"shadow_production_variants": []interface{}{
+				map[string]interface{}{"variant_name": "canary", "instance_type": "ml.m5.large"},
+			},
+		},
+	}}
+	endpoint := &iac.GraphNode{
+		Resource: iac.ResourceNode{
+			Address:    "aws_sagemaker_endpoint.model",
+			Type:       "aws_sagemaker_endpoint",
+			Attributes: map[string]interface{}{"endpoint_config_name": "model-v2"},
+		},
+		Region: "us-east-1",
+	}
+	graph := &iac.Graph{Nodes: map[string]*iac.GraphNode{
+		config.Resource.Address:   config,
+		endpoint.Resource.Address: endpoint,
+	}}
+
+	components, errs := NewSageMakerEndpointMapper().MapWithGraph(endpoint, graph)
+	// The serverless variant is left out with a warning
+	if len(errs) != 1 || errs[0].IsCritical || !strings.Contains(errs[0].Reason, "variant serverless is serverless") {
+		t.Fatalf("errors = %v, want one serverless warning", errs)
+	}
+	if len(components) != 2 {
+		t.Fatalf("expected primary and shadow variants, got %d", len(components))
+	}
+	if components[0].ID != "aws_sagemaker_endpoint.model-primary" || components[0].VarianceProfile.BaselineUsage != 2*730 {
+		t.Errorf("primary variant = %s with %v hours", components[0].ID, components[0].VarianceProfile.BaselineUsage)
+	}
+	if components[1].ID != "aws_sagemaker_endpoint.model-shadow-canary" {
+		t.Errorf("shadow variant = %s", components[1].ID)
+	}
+
+	if _, errs := NewSageMakerEndpointMapper().MapWithGraph(endpoint, nil); len(errs) != 1 {
+		t.Error("expected an error without the endpoint configuration")
+	}
+}
@@ -0,0 +1,274 @@
+// Package gcp provides GCP resource mappers for the Billing Semantic Engine
+package gcp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/iac"
+)
+
+// ComputeInstanceMapper maps google_compute_instance to billing components
+type ComputeInstanceMapper struct{}
+
+// NewComputeInstanceMapper creates a new Compute Engine instance mapper
+func NewComputeInstanceMapper() *ComputeInstanceMapper {
+	return &ComputeInstanceMapper{}
+}
+
+// ResourceType returns the Terraform resource type
+func (m *ComputeInstanceMapper) ResourceType() string {
+	return "google_compute_instance"
+}
+
+// SupportedAttributes returns attributes this mapper uses
+func (m *ComputeInstanceMapper) SupportedAttributes() []string {
+	return []string{
+		"machine_type",
+		"zone",
+		"scheduling",
+		"boot_disk",
+		"scratch_disk",
+		"guest_accelerator",
+	}
+}
+
+// CostDrivingAttributes returns the attributes sizing the instance
+func (m *ComputeInstanceMapper) CostDrivingAttributes() []string {
+	return []string{"machine_type"}
+}
+
+// MapToBillingComponents converts a Compute Engine instance to billing components
+func (m *ComputeInstanceMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
+	attrs := node.Resource.Attributes
+
+	machineType := billing.ExtractAttribute(attrs, "machine_type")
+	if machineType == "" {
+		return nil, []billing.MappingError{{
+			ResourceAddr: node.Resource.Address,
+			ResourceType: "google_compute_instance",
+			Reason:       "machine_type attribute is required",
+			IsCritical:   true,
+		}}
+	}
+
+	spec, ok := ParseMachineType(machineType)
+	if !ok {
+		return nil, []billing.MappingError{{
+			ResourceAddr: node.Resource.Address,
+			ResourceType: "google_compute_instance",
+			Reason:       fmt.Sprintf("unknown machine type %q", machineType),
+			IsCritical:   true,
+		}}
+	}
+
+	region := resolveRegion(node)
+	usageType := schedulingUsageType(attrs["scheduling"])
+
+	components := machineComponents(node.Resource.Address, region, spec, usageType, 1)
+
+	// Boot disk
+	if bootDisk := firstBlock(attrs["boot_disk"]); bootDisk != nil {
+		params := firstBlock(bootDisk["initialize_params"])
+		if params == nil {
+			params = map[string]interface{}{}
+		}
+		diskType := billing.ExtractAttribute(params, "type")
+		size := billing.ExtractAttributeFloat(params, "size", 10)
+		components = append(components, diskComponent(node.Resource.Address+"-boot-disk", region, diskType, size))
+	}
+
+	// Local SSD scratch disks are 375 GB each
+	if scratch, ok := attrs["scratch_disk"].([]interface{}); ok && len(scratch) > 0 {
+		size := 375 * float64(len(scratch))
+		components = append(components, billing.BillingComponent{
+			ID:            fmt.Sprintf("%s-local-ssd", node.Resource.Address),
+			Cloud:         "gcp",
+			Service:       "Compute Engine",
+			ProductFamily: "Storage",
+			Region:        region,
+			UsageType:     "LocalSSD",
+			BillingPeriod: billing.PeriodMonthly,
+			Attributes: map[string]string{
+				"resourceGroup": "LocalSSD",
+				"usageType":     usageType,
+			},
+			Description:     fmt.Sprintf("Local SSD (%d x 375 GB)", len(scratch)),
+			Tags:            []string{"storage", "local-ssd"},
+			VarianceProfile: billing.VarianceProfile{BaselineUsage: size, P50Usage: size, P90Usage: size, Confidence: 0.99},
+		})
+	}
+
+	// GPUs
+	if accels, ok := attrs["guest_accelerator"].([]interface{}); ok {
+		for i, a := range accels {
+			accel, ok := a.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			gpuType := billing.ExtractAttribute(accel, "type")
+			count := billing.ExtractAttributeFloat(accel, "count", 1)
+			if gpuType == "" || count <= 0 {
+				continue
+			}
+			components = append(components, billing.BillingComponent{
+				ID:            fmt.Sprintf("%s-gpu-%d", node.Resource.Address, i),
+				Cloud:         "gcp",
+				Service:       "Compute Engine",
+				ProductFamily: "Compute",
+				Region:        region,
+				UsageType:     fmt.Sprintf("GPU:%s", gpuType),
+				BillingPeriod: billing.PeriodHourly,
+				Attributes: map[string]string{
+					"resourceGroup": "GPU",
+					"gpuType":       gpuType,
+					"usageType":     usageType,
+				},
+				Description:     fmt.Sprintf("%.0f x %s GPU hours", count, gpuType),
+				Tags:            []string{"compute", "gpu"},
+				VarianceProfile: billing.NewDefaultVarianceProfile(billing.HoursPerMonth * count),
+			})
+		}
+	}
+
+	return components, nil
+}
+
+// =============================================================================
+// MACHINE TYPES
+// =============================================================================
+
+// MachineSpec describes the billable shape of a GCE machine type
+type MachineSpec struct {
+	Family   string  // e2, n1, n2, ...
+	VCPUs    float64 // Billable vCPUs (fractional for shared-core)
+	MemoryGB float64
+}
+
+// sharedCoreMachines are billed as fractional vCPUs
+var sharedCoreMachines = map[string]MachineSpec{
+	"e2-micro":  {Family: "e2", VCPUs: 0.25, MemoryGB: 1},
+	"e2-small":  {Family: "e2", VCPUs: 0.5, MemoryGB: 2},
+	"e2-medium": {Family: "e2", VCPUs: 1, MemoryGB: 4},
+	"f1-micro":  {Family: "f1", VCPUs: 0.2, MemoryGB: 0.6},
+	"g1-small":  {Family: "g1", VCPUs: 0.5, MemoryGB: 1.7},
+}
+
+// memoryPerVCPU is GB of memory per vCPU by family and class
+var memoryPerVCPU = map[string]map[string]float64{
+	"n1":  {"standard": 3.75, "highmem": 6.5, "highcpu": 0.9},
+	"e2":  {"standard": 4, "highmem": 8, "highcpu": 1},
+	"n2":  {"standard": 4, "highmem": 8, "highcpu": 1},
+	"n2d": {"standard": 4, "highmem": 8, "highcpu": 1},
+	"c2":  {"standard": 4},
+	"c2d": {"standard": 4, "highmem": 8, "highcpu": 2},
+	"c3":  {"standard": 4, "highmem": 8, "highcpu": 2},
+	"t2d": {"standard": 4},
+	"m1":  {"ultramem": 24.025, "megamem": 14.93},
+}
+
+// ParseMachineType resolves predefined, shared-core and custom machine types
+func ParseMachineType(machineType string) (MachineSpec, bool) {
+	// Terraform may report a full URL for machine_type
+	if idx := strings.LastIndex(machineType, "/"); idx >= 0 {
+		machineType = machineType[idx+1:]
+	}
+	machineType = strings.ToLower(machineType)
+
+	if spec, ok := sharedCoreMachines[machineType]; ok {
+		return spec, true
+	}
+
+	parts := strings.Split(machineType, "-")
+
+	// custom-CPUS-MEM_MB (N1) or FAMILY-custom-CPUS-MEM_MB[-ext]
+	family := "n1"
+	if len(parts) >= 3 && parts[0] != "custom" && parts[1] == "custom" {
+		family = parts[0]
+		parts = parts[1:]
+	}
+	if len(parts) >= 3 && parts[0] == "custom" {
+		cpus, err1 := strconv.ParseFloat(parts[1], 64)
+		memMB, err2 := strconv.ParseFloat(parts[2], 64)
+		if err1 != nil || err2 != nil {
+			return MachineSpec{}, false
+		}
+		return MachineSpec{Family: family, VCPUs: cpus, MemoryGB: memMB / 1024}, true
+	}
+
+	if len(parts) != 3 {
+		return MachineSpec{}, false
+	}
+	ratios, ok := memoryPerVCPU[parts[0]]
+	if !ok {
+		return MachineSpec{}, false
+	}
+	ratio, ok := ratios[parts[1]]
+	if !ok {
+		return MachineSpec{}, false
+	}
+	cpus, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil || cpus <= 0 {
+		return MachineSpec{}, false
+	}
+	return MachineSpec{Family: parts[0], VCPUs: cpus, MemoryGB: cpus * ratio}, true
+}
+
+// machineComponents creates vCPU and RAM components for count machines of a spec
+func machineComponents(addr, region string, spec MachineSpec, usageType string, count float64) []billing.BillingComponent {
+	family := strings.ToUpper(spec.Family)
+	vcpus := spec.VCPUs * count
+	memory := spec.MemoryGB * count
+
+	return []billing.BillingComponent{
+		{
+			ID:            fmt.Sprintf("%s-cpu", addr),
+			Cloud:         "gcp",
+			Service:       "Compute Engine",
+			ProductFamily: "Compute",
+			Region:        region,
+			UsageType:     fmt.Sprintf("%s:Core", family),
+			BillingPeriod: billing.PeriodHourly,
+			Attributes: map[string]string{
+				"resourceGroup": "CPU",
+				"machineFamily": family,
+				"usageType":     usageType,
+			},
+			Description:     fmt.Sprintf("%s vCPU hours (%.2g vCPU)", family, vcpus),
+			Tags:            []string{"compute", "gce"},
+			VarianceProfile: billing.NewDefaultVarianceProfile(billing.HoursPerMonth * vcpus),
+		},
+		{
+			ID:            fmt.Sprintf("%s-ram", addr),
+			Cloud:         "gcp",
+			Service:       "Compute Engine",
+			ProductFamily: "Compute",
+			Region:        region,
+			UsageType:     fmt.Sprintf("%s:Ram", family),
+			BillingPeriod: billing.PeriodGBHourly,
+			Attributes: map[string]string{
+				"resourceGroup": "RAM",
+				"machineFamily": family,
+				"usageType":     usageType,
+			},
+			Description:     fmt.Sprintf("%s memory GB-hours (%.4g GB)", family, memory),
+			Tags:            []string{"compute", "gce"},
+			VarianceProfile: billing.NewDefaultVarianceProfile(billing.HoursPerMonth * memory),
+		},
+	}
+}
+
+// schedulingUsageType returns the SKU usage type for a scheduling block
+func schedulingUsageType(scheduling interface{}) string {
+	block := firstBlock(scheduling)
+	if block == nil {
+		return "OnDemand"
+	}
+	if billing.ExtractAttributeBool(block, "preemptible", false) ||
+		strings.EqualFold(billing.ExtractAttribute(block, "provisioning_model"), "SPOT") {
+		return "Preemptible"
+	}
+	return "OnDemand"
+}
@@ -0,0 +1,28 @@
+// Package gcp - machine type parsing tests
+package gcp
+
+import "testing"
+
+func TestParseMachineType(t *testing.T) {
+	tests := []struct {
+		machineType string
+		want        MachineSpec
+		ok          bool
+	}{
+		{"e2-standard-4", MachineSpec{Family: "e2", VCPUs: 4, MemoryGB: 16}, true},
+		{"n1-standard-2", MachineSpec{Family: "n1", VCPUs: 2, MemoryGB: 7.5}, true},
+		{"n2-highcpu-8", MachineSpec{Family: "n2", VCPUs: 8, MemoryGB: 8}, true},
+		{"e2-micro", MachineSpec{Family: "e2", VCPUs: 0.25, MemoryGB: 1}, true},
+		{"custom-4-8192", MachineSpec{Family: "n1", VCPUs: 4, MemoryGB: 8}, true},
+		{"n2-custom-2-4096", MachineSpec{Family: "n2", VCPUs: 2, MemoryGB: 4}, true},
+		{"zones/us-central1-a/machineTypes/e2-standard-2", MachineSpec{Family: "e2", VCPUs: 2, MemoryGB: 8}, true},
+		{"x9-standard-4", MachineSpec{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseMachineType(tt.machineType)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseMachineType(%q) = %+v, %v; want %+v, %v", tt.machineType, got, ok, tt.want, tt.ok)
+		}
+	}
+}
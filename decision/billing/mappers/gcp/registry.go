@@ -0,0 +1,33 @@
+// Package gcp provides GCP resource mappers registration
+package gcp
+
+import "terraform-cost/decision/billing"
+
+// RegisterAllMappers registers all GCP resource mappers with the engine
+func RegisterAllMappers(engine *billing.Engine) {
+	// Compute
+	engine.RegisterMapper(NewComputeInstanceMapper())
+	engine.RegisterMapper(NewComputeDiskMapper())
+	engine.RegisterMapper(NewContainerClusterMapper())
+
+	// Database
+	engine.RegisterMapper(NewSQLDatabaseInstanceMapper())
+
+	// Storage
+	engine.RegisterMapper(NewStorageBucketMapper())
+
+	// Networking
+	engine.RegisterMapper(NewForwardingRuleMapper())
+}
+
+// SupportedResourceTypes returns all GCP resource types with mappers
+func SupportedResourceTypes() []string {
+	return []string{
+		"google_compute_instance",
+		"google_compute_disk",
+		"google_container_cluster",
+		"google_sql_database_instance",
+		"google_storage_bucket",
+		"google_compute_forwarding_rule",
+	}
+}
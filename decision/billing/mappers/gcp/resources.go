@@ -0,0 +1,463 @@
+// Package gcp provides mappers for GCP storage, database, GKE and networking resources
+package gcp
+
+import (
+	"fmt"
+	"strings"
+
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/iac"
+)
+
+// =============================================================================
+// Persistent Disk Mapper
+// =============================================================================
+
+type ComputeDiskMapper struct{}
+
+func NewComputeDiskMapper() *ComputeDiskMapper { return &ComputeDiskMapper{} }
+
+func (m *ComputeDiskMapper) ResourceType() string { return "google_compute_disk" }
+
+func (m *ComputeDiskMapper) SupportedAttributes() []string {
+	return []string{"type", "size", "zone"}
+}
+
+func (m *ComputeDiskMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
+	attrs := node.Resource.Attributes
+
+	diskType := billing.ExtractAttribute(attrs, "type")
+	size := billing.ExtractAttributeFloat(attrs, "size", 10)
+
+	return []billing.BillingComponent{
+		diskComponent(node.Resource.Address+"-storage", resolveRegion(node), diskType, size),
+	}, nil
+}
+
+// =============================================================================
+// Cloud SQL Instance Mapper
+// =============================================================================
+
+type SQLDatabaseInstanceMapper struct{}
+
+func NewSQLDatabaseInstanceMapper() *SQLDatabaseInstanceMapper { return &SQLDatabaseInstanceMapper{} }
+
+func (m *SQLDatabaseInstanceMapper) ResourceType() string { return "google_sql_database_instance" }
+
+func (m *SQLDatabaseInstanceMapper) SupportedAttributes() []string {
+	return []string{"database_version", "settings.tier", "settings.availability_type", "settings.disk_size", "settings.disk_type"}
+}
+
+func (m *SQLDatabaseInstanceMapper) CostDrivingAttributes() []string {
+	return []string{"settings.tier"}
+}
+
+func (m *SQLDatabaseInstanceMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
+	attrs := node.Resource.Attributes
+	settings := firstBlock(attrs["settings"])
+	if settings == nil {
+		settings = map[string]interface{}{}
+	}
+
+	tier := billing.ExtractAttribute(settings, "tier")
+	if tier == "" {
+		return nil, []billing.MappingError{{
+			ResourceAddr: node.Resource.Address,
+			ResourceType: "google_sql_database_instance",
+			Reason:       "settings.tier attribute is required",
+			IsCritical:   true,
+		}}
+	}
+
+	region := resolveRegion(node)
+	engine := sqlEngine(billing.ExtractAttribute(attrs, "database_version"))
+	availability := strings.ToUpper(billing.ExtractAttribute(settings, "availability_type"))
+	if availability == "" {
+		availability = "ZONAL"
+	}
+	diskType := strings.ToUpper(billing.ExtractAttribute(settings, "disk_type"))
+	if diskType == "" {
+		diskType = "PD_SSD"
+	}
+	diskSize := billing.ExtractAttributeFloat(settings, "disk_size", 10)
+
+	baseAttrs := func(resourceGroup string) map[string]string {
+		return map[string]string{
+			"resourceGroup":    resourceGroup,
+			"databaseEngine":   engine,
+			"availabilityType": availability,
+		}
+	}
+
+	components := make([]billing.BillingComponent, 0, 3)
+
+	// Shared-core tiers are billed per instance-hour; dedicated tiers per vCPU and GB
+	if tier == "db-f1-micro" || tier == "db-g1-small" {
+		components = append(components, billing.BillingComponent{
+			ID:              fmt.Sprintf("%s-instance", node.Resource.Address),
+			Cloud:           "gcp",
+			Service:         "Cloud SQL",
+			ProductFamily:   "ApplicationServices",
+			Region:          region,
+			UsageType:       fmt.Sprintf("SQL:%s", tier),
+			BillingPeriod:   billing.PeriodHourly,
+			Attributes:      baseAttrs(strings.TrimPrefix(tier, "db-")),
+			Description:     fmt.Sprintf("Cloud SQL %s (%s, %s)", tier, engine, availability),
+			Tags:            []string{"database", "cloudsql"},
+			VarianceProfile: billing.NewDefaultVarianceProfile(billing.HoursPerMonth),
+		})
+	} else {
+		spec, ok := ParseMachineType(strings.TrimPrefix(tier, "db-"))
+		if !ok {
+			return nil, []billing.MappingError{{
+				ResourceAddr: node.Resource.Address,
+				ResourceType: "google_sql_database_instance",
+				Reason:       fmt.Sprintf("unknown Cloud SQL tier %q", tier),
+				IsCritical:   true,
+			}}
+		}
+		components = append(components,
+			billing.BillingComponent{
+				ID:              fmt.Sprintf("%s-cpu", node.Resource.Address),
+				Cloud:           "gcp",
+				Service:         "Cloud SQL",
+				ProductFamily:   "ApplicationServices",
+				Region:          region,
+				UsageType:       "SQL:Core",
+				BillingPeriod:   billing.PeriodHourly,
+				Attributes:      baseAttrs("SQLGen2InstancesCPU"),
+				Description:     fmt.Sprintf("Cloud SQL %s vCPU hours (%.0f vCPU, %s)", engine, spec.VCPUs, availability),
+				Tags:            []string{"database", "cloudsql"},
+				VarianceProfile: billing.NewDefaultVarianceProfile(billing.HoursPerMonth * spec.VCPUs),
+			},
+			billing.BillingComponent{
+				ID:              fmt.Sprintf("%s-ram", node.Resource.Address),
+				Cloud:           "gcp",
+				Service:         "Cloud SQL",
+				ProductFamily:   "ApplicationServices",
+				Region:          region,
+				UsageType:       "SQL:Ram",
+				BillingPeriod:   billing.PeriodGBHourly,
+				Attributes:      baseAttrs("SQLGen2InstancesRAM"),
+				Description:     fmt.Sprintf("Cloud SQL %s memory GB-hours (%.4g GB, %s)", engine, spec.MemoryGB, availability),
+				Tags:            []string{"database", "cloudsql"},
+				VarianceProfile: billing.NewDefaultVarianceProfile(billing.HoursPerMonth * spec.MemoryGB),
+			},
+		)
+	}
+
+	storageAttrs := baseAttrs("SSD")
+	if diskType == "PD_HDD" {
+		storageAttrs = baseAttrs("PDStandard")
+	}
+	components = append(components, billing.BillingComponent{
+		ID:              fmt.Sprintf("%s-storage", node.Resource.Address),
+		Cloud:           "gcp",
+		Service:         "Cloud SQL",
+		ProductFamily:   "ApplicationServices",
+		Region:          region,
+		UsageType:       fmt.Sprintf("SQL:Storage-%s", diskType),
+		BillingPeriod:   billing.PeriodMonthly,
+		Attributes:      storageAttrs,
+		Description:     fmt.Sprintf("Cloud SQL %s storage (%.0f GB)", diskType, diskSize),
+		Tags:            []string{"database", "storage"},
+		VarianceProfile: billing.VarianceProfile{BaselineUsage: diskSize, P50Usage: diskSize, P90Usage: diskSize, Confidence: 0.95},
+	})
+
+	return components, nil
+}
+
+// sqlEngine maps database_version (e.g. POSTGRES_15) to an engine name
+func sqlEngine(version string) string {
+	switch {
+	case strings.HasPrefix(version, "POSTGRES"):
+		return "PostgreSQL"
+	case strings.HasPrefix(version, "SQLSERVER"):
+		return "SQL Server"
+	default:
+		return "MySQL"
+	}
+}
+
+// =============================================================================
+// Cloud Storage Bucket Mapper
+// =============================================================================
+
+type StorageBucketMapper struct{}
+
+func NewStorageBucketMapper() *StorageBucketMapper { return &StorageBucketMapper{} }
+
+func (m *StorageBucketMapper) ResourceType() string { return "google_storage_bucket" }
+
+func (m *StorageBucketMapper) SupportedAttributes() []string {
+	return []string{"location", "storage_class"}
+}
+
+func (m *StorageBucketMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
+	attrs := node.Resource.Attributes
+
+	storageClass := strings.ToUpper(billing.ExtractAttribute(attrs, "storage_class"))
+	if storageClass == "" {
+		storageClass = "STANDARD"
+	}
+	location := strings.ToLower(billing.ExtractAttribute(attrs, "location"))
+	if location == "" {
+		location = "us"
+	}
+
+	return []billing.BillingComponent{{
+		ID:            fmt.Sprintf("%s-storage", node.Resource.Address),
+		Cloud:         "gcp",
+		Service:       "Cloud Storage",
+		ProductFamily: "Storage",
+		Region:        location,
+		UsageType:     fmt.Sprintf("%s:Storage", storageClass),
+		BillingPeriod: billing.PeriodMonthly,
+		Attributes: map[string]string{
+			"storageClass": storageClass,
+		},
+		Description: fmt.Sprintf("Cloud Storage %s storage (%s)", storageClass, location),
+		Tags:        []string{"storage", "gcs"},
+		VarianceProfile: billing.VarianceProfile{
+			BaselineUsage: 100, // 100 GB estimate
+			P50Usage:      50,
+			P90Usage:      500,
+			Confidence:    0.3,
+			Assumptions:   []string{"GCS usage highly variable, using environment-based estimate"},
+		},
+	}}, nil
+}
+
+// =============================================================================
+// GKE Cluster Mapper
+// =============================================================================
+
+type ContainerClusterMapper struct{}
+
+func NewContainerClusterMapper() *ContainerClusterMapper { return &ContainerClusterMapper{} }
+
+func (m *ContainerClusterMapper) ResourceType() string { return "google_container_cluster" }
+
+func (m *ContainerClusterMapper) SupportedAttributes() []string {
+	return []string{"location", "enable_autopilot", "remove_default_node_pool", "initial_node_count", "node_config"}
+}
+
+func (m *ContainerClusterMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
+	attrs := node.Resource.Attributes
+	region := resolveRegion(node)
+	autopilot := billing.ExtractAttributeBool(attrs, "enable_autopilot", false)
+
+	mode := "Standard"
+	if autopilot {
+		mode = "Autopilot"
+	}
+
+	components := []billing.BillingComponent{{
+		ID:            fmt.Sprintf("%s-management", node.Resource.Address),
+		Cloud:         "gcp",
+		Service:       "Kubernetes Engine",
+		ProductFamily: "Compute",
+		Region:        region,
+		UsageType:     "ClusterManagementFee",
+		BillingPeriod: billing.PeriodHourly,
+		Attributes: map[string]string{
+			"clusterMode": mode,
+		},
+		Description:     fmt.Sprintf("GKE %s cluster management fee", mode),
+		Tags:            []string{"compute", "gke"},
+		VarianceProfile: billing.NewDefaultVarianceProfile(billing.HoursPerMonth),
+	}}
+
+	// Autopilot bills pod requests, which the plan doesn't describe
+	if autopilot || billing.ExtractAttributeBool(attrs, "remove_default_node_pool", false) {
+		return components, nil
+	}
+
+	nodeConfig := firstBlock(attrs["node_config"])
+	if nodeConfig == nil {
+		nodeConfig = map[string]interface{}{}
+	}
+	machineType := billing.ExtractAttribute(nodeConfig, "machine_type")
+	if machineType == "" {
+		machineType = "e2-medium"
+	}
+	spec, ok := ParseMachineType(machineType)
+	if !ok {
+		return components, []billing.MappingError{{
+			ResourceAddr: node.Resource.Address,
+			ResourceType: "google_container_cluster",
+			Reason:       fmt.Sprintf("unknown node machine type %q", machineType),
+		}}
+	}
+
+	// Regional clusters place initial_node_count nodes in each of three zones
+	nodeCount := billing.ExtractAttributeFloat(attrs, "initial_node_count", 1)
+	if location := billing.ExtractAttribute(attrs, "location"); location != "" && !isZone(location) {
+		nodeCount *= 3
+	}
+
+	usageType := "OnDemand"
+	if billing.ExtractAttributeBool(nodeConfig, "preemptible", false) || billing.ExtractAttributeBool(nodeConfig, "spot", false) {
+		usageType = "Preemptible"
+	}
+
+	poolAddr := node.Resource.Address + "-default-pool"
+	components = append(components, machineComponents(poolAddr, region, spec, usageType, nodeCount)...)
+
+	diskSize := billing.ExtractAttributeFloat(nodeConfig, "disk_size_gb", 100)
+	diskType := billing.ExtractAttribute(nodeConfig, "disk_type")
+	if diskType == "" {
+		diskType = "pd-balanced"
+	}
+	components = append(components, diskComponent(poolAddr+"-disk", region, diskType, diskSize*nodeCount))
+
+	return components, nil
+}
+
+// =============================================================================
+// Forwarding Rule Mapper
+// =============================================================================
+
+type ForwardingRuleMapper struct{}
+
+func NewForwardingRuleMapper() *ForwardingRuleMapper { return &ForwardingRuleMapper{} }
+
+func (m *ForwardingRuleMapper) ResourceType() string { return "google_compute_forwarding_rule" }
+
+func (m *ForwardingRuleMapper) SupportedAttributes() []string {
+	return []string{"load_balancing_scheme", "region"}
+}
+
+func (m *ForwardingRuleMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
+	attrs := node.Resource.Attributes
+	region := resolveRegion(node)
+
+	scheme := strings.ToUpper(billing.ExtractAttribute(attrs, "load_balancing_scheme"))
+	if scheme == "" {
+		scheme = "EXTERNAL"
+	}
+
+	return []billing.BillingComponent{
+		{
+			ID:            fmt.Sprintf("%s-hours", node.Resource.Address),
+			Cloud:         "gcp",
+			Service:       "Compute Engine",
+			ProductFamily: "Network",
+			Region:        region,
+			UsageType:     "ForwardingRule",
+			BillingPeriod: billing.PeriodHourly,
+			Attributes: map[string]string{
+				"resourceGroup":       "LoadBalancing",
+				"loadBalancingScheme": scheme,
+			},
+			Description:     fmt.Sprintf("%s forwarding rule hours", scheme),
+			Tags:            []string{"networking", "loadbalancer"},
+			VarianceProfile: billing.NewDefaultVarianceProfile(billing.HoursPerMonth),
+		},
+		{
+			ID:            fmt.Sprintf("%s-data", node.Resource.Address),
+			Cloud:         "gcp",
+			Service:       "Compute Engine",
+			ProductFamily: "Network",
+			Region:        region,
+			UsageType:     "LoadBalancing:DataProcessed",
+			BillingPeriod: billing.PeriodPerGB,
+			Attributes: map[string]string{
+				"resourceGroup":       "LoadBalancing",
+				"loadBalancingScheme": scheme,
+			},
+			Description: "Load balancer data processing",
+			Tags:        []string{"networking", "data-transfer"},
+			VarianceProfile: billing.VarianceProfile{
+				BaselineUsage: 100, // 100 GB/month estimate
+				P50Usage:      50,
+				P90Usage:      500,
+				Confidence:    0.5,
+			},
+		},
+	}, nil
+}
+
+// =============================================================================
+// HELPER FUNCTIONS
+// =============================================================================
+
+// diskComponent creates a persistent disk storage component
+func diskComponent(id, region, diskType string, sizeGB float64) billing.BillingComponent {
+	if diskType == "" {
+		diskType = "pd-standard"
+	}
+	return billing.BillingComponent{
+		ID:            id,
+		Cloud:         "gcp",
+		Service:       "Compute Engine",
+		ProductFamily: "Storage",
+		Region:        region,
+		UsageType:     fmt.Sprintf("PD:%s", diskType),
+		BillingPeriod: billing.PeriodMonthly,
+		Attributes: map[string]string{
+			"resourceGroup": diskResourceGroup(diskType),
+		},
+		Description:     fmt.Sprintf("Persistent disk %s (%.0f GB)", diskType, sizeGB),
+		Tags:            []string{"storage", "persistent-disk"},
+		VarianceProfile: billing.VarianceProfile{BaselineUsage: sizeGB, P50Usage: sizeGB, P90Usage: sizeGB, Confidence: 0.99},
+	}
+}
+
+func diskResourceGroup(diskType string) string {
+	switch strings.ToLower(diskType) {
+	case "pd-ssd":
+		return "SSD"
+	case "pd-balanced":
+		return "PDBalanced"
+	case "pd-extreme":
+		return "PDExtreme"
+	default:
+		return "PDStandard"
+	}
+}
+
+// resolveRegion derives the GCP region from zone/region/location attributes
+func resolveRegion(node *iac.GraphNode) string {
+	attrs := node.Resource.Attributes
+	if zone := billing.ExtractAttribute(attrs, "zone"); zone != "" {
+		return zoneToRegion(zone)
+	}
+	if region := billing.ExtractAttribute(attrs, "region"); region != "" {
+		return region
+	}
+	if location := billing.ExtractAttribute(attrs, "location"); strings.Contains(location, "-") {
+		return zoneToRegion(location)
+	}
+	if node.Region != "" {
+		return zoneToRegion(node.Region)
+	}
+	return "us-central1"
+}
+
+// isZone reports whether a location is a zone (us-central1-a) rather than a region
+func isZone(location string) bool {
+	return strings.Count(location, "-") >= 2
+}
+
+func zoneToRegion(location string) string {
+	if isZone(location) {
+		return location[:strings.LastIndex(location, "-")]
+	}
+	return location
+}
+
+// firstBlock returns the first element of a Terraform nested block list
+func firstBlock(v interface{}) map[string]interface{} {
+	switch val := v.(type) {
+	case []interface{}:
+		if len(val) > 0 {
+			if m, ok := val[0].(map[string]interface{}); ok {
+				return m
+			}
+		}
+	case map[string]interface{}:
+		return val
+	}
+	return nil
+}
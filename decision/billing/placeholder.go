@@ -0,0 +1,90 @@
+// Package billing - Placeholder mapper
+// Resource types without a mapper normally add nothing to an estimate, silently
+// lowering the total. The opt-in placeholder mapper gives each one an explicit
+// line item with a configured monthly cost instead, so coverage gaps show up in
+// reports.
+package billing
+
+import (
+	"fmt"
+	"strconv"
+
+	"terraform-cost/decision/iac"
+)
+
+// Placeholder component attributes
+const (
+	AttrPlaceholderCost = "placeholderMonthlyCost" // Monthly cost in USD
+	AttrResourceType    = "resourceType"
+)
+
+// ProductFamilyPlaceholder is the product family of placeholder components
+const ProductFamilyPlaceholder = "Unsupported Resource"
+
+// PlaceholderCosts are the monthly costs (USD) assumed for unsupported resources
+type PlaceholderCosts struct {
+	Default float64            // Types not in ByType; 0 gives a zero-cost symbolic line item
+	ByType  map[string]float64 // Per resource type
+}
+
+// MonthlyCost returns the placeholder cost of a resource type
+func (c PlaceholderCosts) MonthlyCost(resourceType string) float64 {
+	if cost, ok := c.ByType[resourceType]; ok {
+		return cost
+	}
+	return c.Default
+}
+
+// PlaceholderMapper maps any resource type to a single placeholder component
+type PlaceholderMapper struct {
+	costs PlaceholderCosts
+}
+
+// NewPlaceholderMapper creates a placeholder mapper
+func NewPlaceholderMapper(costs PlaceholderCosts) *PlaceholderMapper {
+	return &PlaceholderMapper{costs: costs}
+}
+
+// ResourceType returns "*"; the mapper is only used as the engine's fallback
+func (m *PlaceholderMapper) ResourceType() string {
+	return "*"
+}
+
+// SupportedAttributes returns nil; no attributes are used
+func (m *PlaceholderMapper) SupportedAttributes() []string {
+	return nil
+}
+
+// MapToBillingComponents returns the placeholder line item of a resource
+func (m *PlaceholderMapper) MapToBillingComponents(node *iac.GraphNode) ([]BillingComponent, []MappingError) {
+	cost := m.costs.MonthlyCost(node.Resource.Type)
+	return []BillingComponent{{
+		ID:            fmt.Sprintf("%s-placeholder", node.Resource.Address),
+		Cloud:         node.Provider,
+		Service:       node.Resource.Type,
+		ProductFamily: ProductFamilyPlaceholder,
+		Region:        node.Region,
+		BillingPeriod: PeriodMonthly,
+		Attributes: map[string]string{
+			AttrPlaceholderCost: strconv.FormatFloat(cost, 'f', -1, 64),
+			AttrResourceType:    node.Resource.Type,
+		},
+		VarianceProfile: VarianceProfile{
+			BaselineUsage: 1,
+			MinUsage:      1,
+			MaxUsage:      1,
+			P50Usage:      1,
+			P90Usage:      1,
+			Confidence:    0,
+			Assumptions:   []string{fmt.Sprintf("no mapper for %s; placeholder cost assumed", node.Resource.Type)},
+		},
+		Description: fmt.Sprintf("Unsupported %s (placeholder)", node.Resource.Type),
+		Tags:        []string{"placeholder"},
+	}}, nil
+}
+
+// IsPlaceholder reports whether a component came from the placeholder mapper
+func IsPlaceholder(comp BillingComponent) bool {
+	_, ok := comp.Attributes[AttrPlaceholderCost]
+	return ok
+}
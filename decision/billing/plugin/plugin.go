@@ -0,0 +1,231 @@
+// Package plugin loads resource mappers from external executables
+// Organizations with internal Terraform providers ship mappers as plugins
+// instead of patching terracost. A plugin is any executable in the plugins
+// directory named terracost-mapper-<name>, speaking JSON over stdin/stdout:
+//
+//	terracost-mapper-acme describe   # prints a Manifest
+//	terracost-mapper-acme map        # reads a MapRequest, prints a MapResponse
+//
+// Plugins written in Go can implement billing.ResourceMapper and call Serve
+// from main.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/iac"
+)
+
+// ProtocolVersion is the plugin protocol spoken by this version of terracost
+const ProtocolVersion = 1
+
+// Prefix is the file name prefix of plugin executables
+const Prefix = "terracost-mapper-"
+
+// DefaultTimeout bounds a single plugin call
+const DefaultTimeout = 30 * time.Second
+
+// Manifest describes a plugin (output of describe)
+type Manifest struct {
+	ProtocolVersion int      `json:"protocol_version"`
+	Name            string   `json:"name"`
+	ResourceTypes   []string `json:"resource_types"`
+}
+
+// MapRequest is the resource a plugin maps (input of map)
+type MapRequest struct {
+	ProtocolVersion int                 `json:"protocol_version"`
+	Resource        iac.ResourceNode    `json:"resource"`
+	Change          *iac.ResourceChange `json:"change,omitempty"`
+	Provider        string              `json:"provider"`
+	Region          string              `json:"region"`
+}
+
+// MapResponse is a plugin's billing components (output of map)
+type MapResponse struct {
+	Components []billing.BillingComponent `json:"components"`
+	Errors     []billing.MappingError     `json:"errors,omitempty"`
+}
+
+// Mapper maps one resource type by calling a plugin executable
+type Mapper struct {
+	path         string
+	plugin       string
+	resourceType string
+	timeout      time.Duration
+}
+
+// ResourceType returns the Terraform resource type
+func (m *Mapper) ResourceType() string {
+	return m.resourceType
+}
+
+// SupportedAttributes returns nil; plugins receive every attribute
+func (m *Mapper) SupportedAttributes() []string {
+	return nil
+}
+
+// Plugin returns the name of the plugin behind the mapper
+func (m *Mapper) Plugin() string {
+	return m.plugin
+}
+
+// MapToBillingComponents runs the plugin on a resource. A failing plugin is a
+// critical mapping error, like any resource that could not be priced.
+func (m *Mapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
+	req := MapRequest{
+		ProtocolVersion: ProtocolVersion,
+		Resource:        node.Resource,
+		Change:          node.Change,
+		Provider:        node.Provider,
+		Region:          node.Region,
+	}
+	var resp MapResponse
+	if err := call(m.path, m.timeout, "map", req, &resp); err != nil {
+		return nil, []billing.MappingError{{
+			ResourceAddr: node.Resource.Address,
+			ResourceType: node.Resource.Type,
+			Reason:       fmt.Sprintf("plugin %s: %v", m.plugin, err),
+			IsCritical:   true,
+		}}
+	}
+	return resp.Components, resp.Errors
+}
+
+// Load discovers the plugins in a directory and returns a mapper for every
+// resource type they handle. A missing directory has no plugins.
+func Load(dir string, timeout time.Duration) ([]billing.ResourceMapper, error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin directory: %w", err)
+	}
+
+	var mappers []billing.ResourceMapper
+	owners := make(map[string]string) // resource type -> plugin
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), Prefix) || entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if info, err := entry.Info(); err != nil || info.Mode()&0o111 == 0 {
+			continue
+		}
+
+		var manifest Manifest
+		if err := call(path, timeout, "describe", nil, &manifest); err != nil {
+			return nil, fmt.Errorf("plugin %s: %w", entry.Name(), err)
+		}
+		if manifest.ProtocolVersion != ProtocolVersion {
+			return nil, fmt.Errorf("plugin %s speaks protocol %d, terracost speaks %d", entry.Name(), manifest.ProtocolVersion, ProtocolVersion)
+		}
+		name := manifest.Name
+		if name == "" {
+			name = strings.TrimPrefix(entry.Name(), Prefix)
+		}
+		for _, resourceType := range manifest.ResourceTypes {
+			if owner, ok := owners[resourceType]; ok {
+				return nil, fmt.Errorf("plugins %s and %s both map %s", owner, name, resourceType)
+			}
+			owners[resourceType] = name
+			mappers = append(mappers, &Mapper{path: path, plugin: name, resourceType: resourceType, timeout: timeout})
+		}
+	}
+	return mappers, nil
+}
+
+// call runs one plugin command, sending in (if any) and decoding its output into out
+func call(path string, timeout time.Duration, command string, in, out interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path, command)
+	if in != nil {
+		payload, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		cmd.Stdin = bytes.NewReader(payload)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%s timed out after %s", command, timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s failed: %s", command, msg)
+		}
+		return fmt.Errorf("%s failed: %w", command, err)
+	}
+	if err := json.Unmarshal(stdout.Bytes(), out); err != nil {
+		return fmt.Errorf("invalid %s output: %w", command, err)
+	}
+	return nil
+}
+
+// Serve implements the plugin protocol for mappers, for use in a plugin's main.
+// It exits the process with status 1 on errors.
+func Serve(name string, mappers ...billing.ResourceMapper) {
+	if err := serve(os.Args[1:], os.Stdin, os.Stdout, name, mappers); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func serve(args []string, in io.Reader, out io.Writer, name string, mappers []billing.ResourceMapper) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: %s describe|map", Prefix+name)
+	}
+	byType := make(map[string]billing.ResourceMapper, len(mappers))
+	for _, m := range mappers {
+		byType[m.ResourceType()] = m
+	}
+
+	switch args[0] {
+	case "describe":
+		manifest := Manifest{ProtocolVersion: ProtocolVersion, Name: name, ResourceTypes: []string{}}
+		for resourceType := range byType {
+			manifest.ResourceTypes = append(manifest.ResourceTypes, resourceType)
+		}
+		sort.Strings(manifest.ResourceTypes)
+		return json.NewEncoder(out).Encode(manifest)
+
+	case "map":
+		var req MapRequest
+		if err := json.NewDecoder(in).Decode(&req); err != nil {
+			return fmt.Errorf("invalid map request: %w", err)
+		}
+		m, ok := byType[req.Resource.Type]
+		if !ok {
+			return fmt.Errorf("no mapper for %s", req.Resource.Type)
+		}
+		node := &iac.GraphNode{Resource: req.Resource, Change: req.Change, Provider: req.Provider, Region: req.Region}
+		components, errs := m.MapToBillingComponents(node)
+		if components == nil {
+			components = []billing.BillingComponent{}
+		}
+		return json.NewEncoder(out).Encode(MapResponse{Components: components, Errors: errs})
+
+	default:
+		return fmt.Errorf("unknown command %q (describe, map)", args[0])
+	}
+}
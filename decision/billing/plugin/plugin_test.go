@@ -0,0 +1,113 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/iac"
+)
+
+// TestMain turns the test binary into a plugin when it is run by Load
+func TestMain(m *testing.M) {
+	if os.Getenv("TERRACOST_TEST_PLUGIN") == "1" {
+		Serve("acme", acmeMapper{})
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+type acmeMapper struct{}
+
+func (acmeMapper) ResourceType() string          { return "acme_widget" }
+func (acmeMapper) SupportedAttributes() []string { return []string{"size"} }
+
+func (acmeMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
+	return []billing.BillingComponent{{
+		Cloud:         "acme",
+		Service:       "Widgets",
+		Region:        node.Region,
+		UsageType:     "Widget:" + billing.ExtractAttribute(node.Resource.Attributes, "size"),
+		BillingPeriod: billing.PeriodHourly,
+	}}, nil
+}
+
+// installPlugin links the test binary into a plugins directory
+func installPlugin(t *testing.T) string {
+	t.Helper()
+	t.Setenv("TERRACOST_TEST_PLUGIN", "1")
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Symlink(exe, filepath.Join(dir, Prefix+"acme")); err != nil {
+		t.Fatal(err)
+	}
+	// Not executable, and not a plugin name: both ignored
+	if err := os.WriteFile(filepath.Join(dir, Prefix+"notes"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("x"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestLoadAndMap(t *testing.T) {
+	mappers, err := Load(installPlugin(t), 0)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(mappers) != 1 || mappers[0].ResourceType() != "acme_widget" {
+		t.Fatalf("mappers = %v, want one acme_widget mapper", mappers)
+	}
+
+	engine := billing.NewEngine()
+	engine.RegisterMappers(mappers...)
+	graph := &iac.Graph{Nodes: map[string]*iac.GraphNode{
+		"acme_widget.a": {
+			Resource: iac.ResourceNode{Address: "acme_widget.a", Type: "acme_widget", Mode: "managed", Attributes: map[string]interface{}{"size": "large"}},
+			Region:   "eu-west-1",
+		},
+	}}
+	result, err := engine.Decompose(graph)
+	if err != nil {
+		t.Fatalf("Decompose: %v", err)
+	}
+	if len(result.Components) != 1 {
+		t.Fatalf("components = %d, want 1 (errors: %v)", len(result.Components), result.MappingErrors)
+	}
+	comp := result.Components[0]
+	if comp.UsageType != "Widget:large" || comp.Region != "eu-west-1" || comp.ResourceAddr != "acme_widget.a" {
+		t.Errorf("component = %+v", comp)
+	}
+}
+
+func TestMapFailureIsCritical(t *testing.T) {
+	mappers, err := Load(installPlugin(t), 0)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	// A type the plugin does not handle makes it exit with an error
+	m := mappers[0].(*Mapper)
+	m.resourceType = "acme_gadget"
+	node := &iac.GraphNode{Resource: iac.ResourceNode{Address: "acme_gadget.a", Type: "acme_gadget"}}
+
+	components, errs := m.MapToBillingComponents(node)
+	if len(components) != 0 || len(errs) != 1 || !errs[0].IsCritical {
+		t.Fatalf("got %v, %v; want one critical error", components, errs)
+	}
+	if !strings.Contains(errs[0].Reason, "no mapper for acme_gadget") {
+		t.Errorf("reason = %q, want plugin stderr", errs[0].Reason)
+	}
+}
+
+func TestLoadMissingDir(t *testing.T) {
+	mappers, err := Load(filepath.Join(t.TempDir(), "missing"), 0)
+	if err != nil || mappers != nil {
+		t.Fatalf("Load = %v, %v; want no mappers", mappers, err)
+	}
+}
@@ -0,0 +1,77 @@
+// Package billing - Timebase
+// Mappers and usage predictions size always-on usage in months of
+// HoursPerMonth hours. The estimation engine rescales hour-billed usage to the
+// chosen timebase, so changing the convention never touches a mapper.
+package billing
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// HoursPerMonth is the month mappers and usage predictions are expressed in:
+// 8760 hours a year over 12 months, as AWS and GCP price monthly
+const HoursPerMonth = 730
+
+// Timebase is how many hours a month of always-on usage bills
+type Timebase string
+
+const (
+	Timebase730      Timebase = "730"      // 8760/12, the cloud providers' convention (default)
+	Timebase720      Timebase = "720"      // 30-day months
+	TimebaseCalendar Timebase = "calendar" // The hours in the month being estimated, 672 to 744
+)
+
+// ParseTimebase parses a timebase; empty is Timebase730
+func ParseTimebase(value string) (Timebase, error) {
+	switch tb := Timebase(strings.ToLower(strings.TrimSpace(value))); tb {
+	case "":
+		return Timebase730, nil
+	case Timebase730, Timebase720, TimebaseCalendar:
+		return tb, nil
+	default:
+		return "", fmt.Errorf("invalid timebase %q: expected 730, 720 or calendar", value)
+	}
+}
+
+// Hours returns the hours billed for the month containing at
+func (tb Timebase) Hours(at time.Time) float64 {
+	switch tb {
+	case Timebase720:
+		return 720
+	case TimebaseCalendar:
+		at = at.UTC()
+		start := time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start.AddDate(0, 1, 0).Sub(start).Hours()
+	default:
+		return HoursPerMonth
+	}
+}
+
+// String returns the timebase name, "730" when unset
+func (tb Timebase) String() string {
+	if tb == "" {
+		return string(Timebase730)
+	}
+	return string(tb)
+}
+
+// ProratedFraction is the share of its first month a resource provisioned at a
+// time is billed for: the rest of that calendar month, from the provisioning
+// hour on
+func ProratedFraction(provisioned time.Time) float64 {
+	provisioned = provisioned.UTC()
+	start := time.Date(provisioned.Year(), provisioned.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	return end.Sub(provisioned.Truncate(time.Hour)).Hours() / end.Sub(start).Hours()
+}
+
+// Scale multiplies every usage figure of a profile, e.g. to another timebase
+func (vp *VarianceProfile) Scale(factor float64) {
+	vp.BaselineUsage *= factor
+	vp.MinUsage *= factor
+	vp.MaxUsage *= factor
+	vp.P50Usage *= factor
+	vp.P90Usage *= factor
+}
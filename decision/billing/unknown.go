@@ -0,0 +1,133 @@
+// Package billing - Unknown values
+// Plan values computed at apply time (a desired_count read from a data source,
+// an instance type from another resource's output) are absent from the plan's
+// "after" object and flagged in after_unknown. A mapper would price them at its
+// default, so the components an unknown cost-driving attribute sizes are left
+// symbolic instead, naming the unknown path.
+package billing
+
+import (
+	"fmt"
+	"strings"
+
+	"terraform-cost/decision/iac"
+)
+
+// ProductFamilyUnknown is the product family of components standing in for
+// resources that could not be mapped while attributes are unknown
+const ProductFamilyUnknown = "Unknown Until Apply"
+
+// CostDrivingMapper is implemented by mappers whose components are sized by
+// attributes that may be unknown at plan time. Attributes that are computed
+// whenever they are left unset (e.g. an EBS volume type) are not listed, or
+// every resource relying on the provider default would be symbolic.
+type CostDrivingMapper interface {
+	ResourceMapper
+
+	// CostDrivingAttributes returns the attribute paths, dot-separated for
+	// nested blocks (settings.tier), that size the resource's components
+	CostDrivingAttributes() []string
+}
+
+// ComponentCostDrivingMapper is implemented by cost-driving mappers whose
+// attributes size only some of a resource's components, e.g. an instance type
+// that prices compute but not the instance's volumes
+type ComponentCostDrivingMapper interface {
+	CostDrivingMapper
+
+	// CostDrivenBy reports whether a cost-driving attribute path sizes a component
+	CostDrivenBy(component BillingComponent, path string) bool
+}
+
+// UnknownAttributes returns the cost-driving attributes of a resource that are
+// unknown until apply; nil when the mapper declares none or the node has no
+// planned change
+func UnknownAttributes(node *iac.GraphNode, mapper ResourceMapper) []string {
+	cd, ok := mapper.(CostDrivingMapper)
+	if !ok || node.Change == nil || len(node.Change.AfterUnknown) == 0 {
+		return nil
+	}
+	var unknown []string
+	for _, path := range cd.CostDrivingAttributes() {
+		if isUnknown(node.Change.AfterUnknown, strings.Split(path, ".")) {
+			unknown = append(unknown, path)
+		}
+	}
+	return unknown
+}
+
+// isUnknown walks an after_unknown value along a path. A true on the way marks
+// the whole block unknown; nested blocks are lists, any element of which may be.
+func isUnknown(value interface{}, path []string) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case map[string]interface{}:
+		if len(path) == 0 {
+			for _, nested := range v {
+				if isUnknown(nested, nil) {
+					return true
+				}
+			}
+			return false
+		}
+		return isUnknown(v[path[0]], path[1:])
+	case []interface{}:
+		for _, elem := range v {
+			if isUnknown(elem, path) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// markUnknown flags a resource's components as depending on unknown
+// attributes; with a ComponentCostDrivingMapper, only the components those
+// attributes size. When none is left to flag, the components the missing values
+// size could not be mapped: a stand-in component takes their place, replacing
+// the mapping errors their absence caused.
+func markUnknown(node *iac.GraphNode, mapper ResourceMapper, unknown []string, components []BillingComponent, errs []MappingError) ([]BillingComponent, []MappingError) {
+	cm, partial := mapper.(ComponentCostDrivingMapper)
+	marked := 0
+	for i := range components {
+		paths := unknown
+		if partial {
+			paths = nil
+			for _, path := range unknown {
+				if cm.CostDrivenBy(components[i], path) {
+					paths = append(paths, path)
+				}
+			}
+		}
+		if len(paths) == 0 {
+			continue
+		}
+		components[i].UnknownAttributes = paths
+		components[i].VarianceProfile.Confidence = 0
+		components[i].VarianceProfile.Assumptions = append(components[i].VarianceProfile.Assumptions, unknownAssumption(paths))
+		marked++
+	}
+	if marked > 0 {
+		return components, errs
+	}
+
+	assumption := unknownAssumption(unknown)
+	return append(components, BillingComponent{
+		ID:                fmt.Sprintf("%s-unknown", node.Resource.Address),
+		Cloud:             node.Provider,
+		Service:           node.Resource.Type,
+		ProductFamily:     ProductFamilyUnknown,
+		Region:            node.Region,
+		BillingPeriod:     PeriodMonthly,
+		Attributes:        map[string]string{AttrResourceType: node.Resource.Type},
+		VarianceProfile:   VarianceProfile{Assumptions: []string{assumption}},
+		Description:       fmt.Sprintf("%s (%s)", node.Resource.Type, assumption),
+		Tags:              []string{"unknown"},
+		UnknownAttributes: unknown,
+	}), nil
+}
+
+func unknownAssumption(paths []string) string {
+	return fmt.Sprintf("%s unknown until apply; not priced", strings.Join(paths, ", "))
+}
@@ -0,0 +1,166 @@
+// Package budget tracks estimates against stored budgets
+// Committed spend is the latest saved estimate of every project and
+// environment a budget covers; a new estimate replaces its own predecessor.
+package budget
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/estimation"
+)
+
+// Store is the budget and estimation history storage a Tracker reads
+type Store interface {
+	BudgetsFor(ctx context.Context, project, environment string) ([]*clickhouse.Budget, error)
+	LatestEstimations(ctx context.Context, filter clickhouse.EstimationFilter) ([]*clickhouse.EstimationRecord, error)
+}
+
+// Status is a budget's committed spend from estimation history
+type Status struct {
+	Budget      *clickhouse.Budget `json:"budget"`
+	Spent       decimal.Decimal    `json:"spent"`
+	Remaining   decimal.Decimal    `json:"remaining_budget"`
+	UsedPercent float64            `json:"used_percent"`
+	Estimations int                `json:"estimations"` // saved estimates counted as spend
+}
+
+// Projection is a budget's status if a new estimate replaced its predecessor
+type Projection struct {
+	Status
+	Current              decimal.Decimal `json:"current"`  // the new estimate's cost within the budget scope
+	Replaced             decimal.Decimal `json:"replaced"` // the saved estimate it supersedes
+	Projected            decimal.Decimal `json:"projected"`
+	ProjectedUsedPercent float64         `json:"projected_used_percent"`
+}
+
+// Exceeded reports whether the projected spend is over the limit
+func (p Projection) Exceeded() bool {
+	return p.Projected.GreaterThan(p.Budget.MonthlyLimit)
+}
+
+// Tracker computes remaining budget from estimation history
+type Tracker struct {
+	store Store
+}
+
+// NewTracker creates a tracker over a budget store
+func NewTracker(store Store) *Tracker {
+	return &Tracker{store: store}
+}
+
+// Status returns a budget's committed spend and remaining budget
+func (t *Tracker) Status(ctx context.Context, b *clickhouse.Budget) (*Status, error) {
+	records, err := t.store.LatestEstimations(ctx, clickhouse.EstimationFilter{
+		Project:     b.Project,
+		Environment: b.Environment,
+		Branch:      b.Branch,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{Budget: b}
+	for _, rec := range records {
+		cost, err := recordCost(rec, b)
+		if err != nil {
+			return nil, err
+		}
+		status.Spent = status.Spent.Add(cost)
+		status.Estimations++
+	}
+	status.Remaining = b.MonthlyLimit.Sub(status.Spent)
+	status.UsedPercent = usedPercent(status.Spent, b.MonthlyLimit)
+	return status, nil
+}
+
+// Project checks a new estimate against every budget covering its project and environment
+// Budgets in another currency than the estimate are skipped with a note.
+func (t *Tracker) Project(ctx context.Context, project, environment string, est *estimation.EstimationResult) ([]Projection, []string, error) {
+	budgets, err := t.store.BudgetsFor(ctx, project, environment)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	estCurrency := est.Currency
+	if estCurrency == "" {
+		estCurrency = "USD"
+	}
+
+	projections := make([]Projection, 0, len(budgets))
+	notes := make([]string, 0)
+	for _, b := range budgets {
+		if !strings.EqualFold(b.Currency, estCurrency) {
+			notes = append(notes, fmt.Sprintf("budget %s is in %s but the estimate is in %s; not checked", b.Name, b.Currency, estCurrency))
+			continue
+		}
+		status, err := t.Status(ctx, b)
+		if err != nil {
+			return nil, nil, fmt.Errorf("budget %s: %w", b.Name, err)
+		}
+		replaced, err := t.replacedCost(ctx, b, project, environment)
+		if err != nil {
+			return nil, nil, fmt.Errorf("budget %s: %w", b.Name, err)
+		}
+
+		p := Projection{
+			Status:   *status,
+			Current:  ScopedCost(est, b),
+			Replaced: replaced,
+		}
+		p.Projected = status.Spent.Sub(replaced).Add(p.Current)
+		p.ProjectedUsedPercent = usedPercent(p.Projected, b.MonthlyLimit)
+		projections = append(projections, p)
+	}
+	return projections, notes, nil
+}
+
+// replacedCost is the budget-scoped cost of the saved estimate a new estimate supersedes
+func (t *Tracker) replacedCost(ctx context.Context, b *clickhouse.Budget, project, environment string) (decimal.Decimal, error) {
+	records, err := t.store.LatestEstimations(ctx, clickhouse.EstimationFilter{
+		Project:     project,
+		Environment: environment,
+		Branch:      b.Branch,
+	})
+	if err != nil {
+		return decimal.Zero, err
+	}
+	for _, rec := range records {
+		if rec.Project == project && rec.Environment == environment {
+			return recordCost(rec, b)
+		}
+	}
+	return decimal.Zero, nil
+}
+
+// ScopedCost returns the part of an estimate's monthly P50 a budget covers
+func ScopedCost(est *estimation.EstimationResult, b *clickhouse.Budget) decimal.Decimal {
+	if b.TagKey == "" {
+		return est.MonthlyCostP50
+	}
+	allocation := estimation.AllocateByTag(est.CostDrivers, []string{b.TagKey})
+	return allocation[b.TagKey][b.TagValue]
+}
+
+// recordCost returns a saved estimate's budget-scoped cost
+func recordCost(rec *clickhouse.EstimationRecord, b *clickhouse.Budget) (decimal.Decimal, error) {
+	if b.TagKey == "" {
+		return rec.MonthlyCostP50, nil
+	}
+	result, err := estimation.ResultFromRecord(rec)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return ScopedCost(result, b), nil
+}
+
+func usedPercent(spent, limit decimal.Decimal) float64 {
+	if !limit.IsPositive() {
+		return 0
+	}
+	return spent.Div(limit).Mul(decimal.NewFromInt(100)).InexactFloat64()
+}
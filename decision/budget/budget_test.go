@@ -0,0 +1,108 @@
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/estimation"
+)
+
+type fakeStore struct {
+	budgets []*clickhouse.Budget
+	records []*clickhouse.EstimationRecord
+}
+
+func (f *fakeStore) BudgetsFor(ctx context.Context, project, environment string) ([]*clickhouse.Budget, error) {
+	out := make([]*clickhouse.Budget, 0)
+	for _, b := range f.budgets {
+		if b.Applies(project, environment) {
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeStore) LatestEstimations(ctx context.Context, filter clickhouse.EstimationFilter) ([]*clickhouse.EstimationRecord, error) {
+	out := make([]*clickhouse.EstimationRecord, 0)
+	for _, rec := range f.records {
+		if (filter.Project == "" || rec.Project == filter.Project) &&
+			(filter.Environment == "" || rec.Environment == filter.Environment) &&
+			(filter.Branch == "" || rec.Branch == filter.Branch) {
+			out = append(out, rec)
+		}
+	}
+	return out, nil
+}
+
+// tagged returns an estimate whose cost is split between two teams
+func tagged(payments, other int64) *estimation.EstimationResult {
+	return &estimation.EstimationResult{
+		Currency:       "USD",
+		MonthlyCostP50: decimal.NewFromInt(payments + other),
+		CostDrivers: []estimation.CostDriver{
+			{MonthlyCostP50: decimal.NewFromInt(payments), ResourceTags: map[string]string{"team": "payments"}},
+			{MonthlyCostP50: decimal.NewFromInt(other), ResourceTags: map[string]string{"team": "search"}},
+		},
+	}
+}
+
+func record(t *testing.T, project, env string, result *estimation.EstimationResult) *clickhouse.EstimationRecord {
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &clickhouse.EstimationRecord{
+		Project:        project,
+		Environment:    env,
+		MonthlyCostP50: result.MonthlyCostP50,
+		ResultJSON:     string(data),
+	}
+}
+
+func TestTracker(t *testing.T) {
+	teamBudget := &clickhouse.Budget{Name: "payments", TagKey: "team", TagValue: "payments", MonthlyLimit: decimal.NewFromInt(1000), Currency: "USD", AlertThreshold: 80}
+	webBudget := &clickhouse.Budget{Name: "web-prod", Project: "web", Environment: "prod", MonthlyLimit: decimal.NewFromInt(500), Currency: "USD", AlertThreshold: 80}
+	euroBudget := &clickhouse.Budget{Name: "web-eur", Project: "web", MonthlyLimit: decimal.NewFromInt(500), Currency: "EUR", AlertThreshold: 80}
+	store := &fakeStore{
+		budgets: []*clickhouse.Budget{teamBudget, webBudget, euroBudget},
+		records: []*clickhouse.EstimationRecord{
+			record(t, "web", "prod", tagged(300, 100)),
+			record(t, "api", "prod", tagged(500, 100)),
+		},
+	}
+	tracker := NewTracker(store)
+	ctx := context.Background()
+
+	status, err := tracker.Status(ctx, teamBudget)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Spent.Equal(decimal.NewFromInt(800)) || !status.Remaining.Equal(decimal.NewFromInt(200)) || status.Estimations != 2 {
+		t.Errorf("status = spent %s remaining %s over %d estimates", status.Spent, status.Remaining, status.Estimations)
+	}
+
+	// The new web/prod estimate replaces the saved one
+	projections, notes, err := tracker.Project(ctx, "web", "prod", tagged(450, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 1 {
+		t.Errorf("notes = %v, want the EUR budget skipped", notes)
+	}
+	want := map[string]int64{"payments": 950, "web-prod": 550}
+	if len(projections) != len(want) {
+		t.Fatalf("got %d projections, want %d", len(projections), len(want))
+	}
+	for _, p := range projections {
+		if !p.Projected.Equal(decimal.NewFromInt(want[p.Budget.Name])) {
+			t.Errorf("%s projected = %s, want %d", p.Budget.Name, p.Projected, want[p.Budget.Name])
+		}
+		if exceeded := p.Budget.Name == "web-prod"; p.Exceeded() != exceeded {
+			t.Errorf("%s exceeded = %v", p.Budget.Name, p.Exceeded())
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Package budget - Burn-down projection
+// A burn-down spreads a monthly run rate over the rest of a fiscal period:
+// each calendar month is charged for the days left in it, and the budget is
+// exhausted on the day cumulative spend reaches it.
+package budget
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Period is a fiscal period from Start up to End
+type Period struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// FiscalYear returns the fiscal year containing at, starting on the first of startMonth
+func FiscalYear(at time.Time, startMonth time.Month) Period {
+	at = at.UTC()
+	year := at.Year()
+	if at.Month() < startMonth {
+		year--
+	}
+	start := time.Date(year, startMonth, 1, 0, 0, 0, 0, time.UTC)
+	return Period{Start: start, End: start.AddDate(1, 0, 0)}
+}
+
+// BurnDown is projected cumulative spend against a budget until the end of a period
+type BurnDown struct {
+	Name           string          `json:"name"`
+	Currency       string          `json:"currency"`
+	Period         Period          `json:"period"`
+	AsOf           time.Time       `json:"as_of"`
+	Budget         decimal.Decimal `json:"budget"` // for the whole period
+	Spent          decimal.Decimal `json:"spent"`  // before AsOf
+	MonthlyBurn    decimal.Decimal `json:"monthly_burn"`
+	DailyBurn      decimal.Decimal `json:"daily_burn"` // averaged over a year
+	ProjectedTotal decimal.Decimal `json:"projected_total"`
+	ExhaustedOn    *time.Time      `json:"exhausted_on,omitempty"` // nil when the budget lasts the period
+	Months         []BurnDownMonth `json:"months"`
+}
+
+// BurnDownMonth is one calendar month of a burn-down
+type BurnDownMonth struct {
+	Month      string          `json:"month"`      // YYYY-MM
+	Projected  decimal.Decimal `json:"projected"`  // prorated for partial months
+	Cumulative decimal.Decimal `json:"cumulative"` // since the period start
+	Remaining  decimal.Decimal `json:"remaining"`  // negative once exhausted
+}
+
+// Exceeded reports whether projected spend overruns the budget by the period end
+func (b *BurnDown) Exceeded() bool {
+	return b.ProjectedTotal.GreaterThan(b.Budget)
+}
+
+// NewBurnDown projects spend at monthlyBurn from at, a day, until the period end
+func NewBurnDown(name, currency string, amount, spent, monthlyBurn decimal.Decimal, period Period, at time.Time) *BurnDown {
+	at = clamp(at.UTC().Truncate(24*time.Hour), period)
+	b := &BurnDown{
+		Name:        name,
+		Currency:    currency,
+		Period:      period,
+		AsOf:        at,
+		Budget:      amount,
+		Spent:       spent,
+		MonthlyBurn: monthlyBurn,
+		DailyBurn:   monthlyBurn.Mul(decimal.NewFromInt(12)).Div(decimal.NewFromInt(365)).Round(2),
+		Months:      make([]BurnDownMonth, 0, 12),
+	}
+
+	cumulative := spent
+	if !cumulative.LessThan(amount) {
+		b.ExhaustedOn = &at
+	}
+	for month := monthStart(at); month.Before(period.End); month = month.AddDate(0, 1, 0) {
+		from, to := month, month.AddDate(0, 1, 0)
+		if at.After(from) {
+			from = at
+		}
+		if period.End.Before(to) {
+			to = period.End
+		}
+		monthHours := hoursIn(month, month.AddDate(0, 1, 0))
+		spend := monthlyBurn.Mul(hoursIn(from, to)).Div(monthHours)
+
+		if b.ExhaustedOn == nil && cumulative.Add(spend).GreaterThan(amount) {
+			hours := amount.Sub(cumulative).Mul(monthHours).Div(monthlyBurn).IntPart()
+			exhausted := from.Add(time.Duration(hours) * time.Hour).Truncate(24 * time.Hour)
+			b.ExhaustedOn = &exhausted
+		}
+		cumulative = cumulative.Add(spend)
+		b.Months = append(b.Months, BurnDownMonth{
+			Month:      month.Format("2006-01"),
+			Projected:  spend.Round(2),
+			Cumulative: cumulative.Round(2),
+			Remaining:  amount.Sub(cumulative).Round(2),
+		})
+	}
+	b.ProjectedTotal = cumulative.Round(2)
+	return b
+}
+
+// BurnDown projects a stored budget over a period at its projected monthly spend
+// Monthly budgets carry nothing over, so months already past count as fully used.
+func (p Projection) BurnDown(period Period, at time.Time) *BurnDown {
+	limit := p.Budget.MonthlyLimit
+	at = clamp(at.UTC().Truncate(24*time.Hour), period)
+	return NewBurnDown(p.Budget.Name, p.Budget.Currency,
+		limit.Mul(months(period.Start, period.End)), limit.Mul(months(period.Start, at)).Round(2),
+		p.Projected, period, at)
+}
+
+// months counts the calendar months from one time to another, prorating partial months by the hour
+func months(from, to time.Time) decimal.Decimal {
+	total := decimal.Zero
+	for month := monthStart(from); month.Before(to); month = month.AddDate(0, 1, 0) {
+		start, end := month, month.AddDate(0, 1, 0)
+		if from.After(start) {
+			start = from
+		}
+		if to.Before(end) {
+			end = to
+		}
+		total = total.Add(hoursIn(start, end).Div(hoursIn(month, month.AddDate(0, 1, 0))))
+	}
+	return total
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func hoursIn(from, to time.Time) decimal.Decimal {
+	return decimal.NewFromInt(int64(to.Sub(from) / time.Hour))
+}
+
+func clamp(t time.Time, period Period) time.Time {
+	if t.Before(period.Start) {
+		return period.Start
+	}
+	if t.After(period.End) {
+		return period.End
+	}
+	return t
+}
@@ -0,0 +1,55 @@
+package budget
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+)
+
+func TestFiscalYear(t *testing.T) {
+	p := FiscalYear(time.Date(2026, time.February, 10, 0, 0, 0, 0, time.UTC), time.April)
+	if !p.Start.Equal(time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC)) || !p.End.Equal(time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("fiscal year = %s – %s", p.Start, p.End)
+	}
+}
+
+func TestBurnDownExhaustion(t *testing.T) {
+	period := FiscalYear(time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC), time.January)
+	at := time.Date(2026, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	// 1,000 left at 400/month lasts until mid-December
+	b := NewBurnDown("platform", "USD", decimal.NewFromInt(10000), decimal.NewFromInt(9000), decimal.NewFromInt(400), period, at)
+	if len(b.Months) != 3 || b.Months[0].Month != "2026-10" || !b.Months[0].Cumulative.Equal(decimal.NewFromInt(9400)) {
+		t.Fatalf("months = %+v", b.Months)
+	}
+	if !b.ProjectedTotal.Equal(decimal.NewFromInt(10200)) || !b.Exceeded() {
+		t.Errorf("projected total = %s", b.ProjectedTotal)
+	}
+	if b.ExhaustedOn == nil || b.ExhaustedOn.Format("2006-01-02") != "2026-12-16" {
+		t.Errorf("exhausted on %v, want 2026-12-16", b.ExhaustedOn)
+	}
+
+	// A budget that lasts the period is never exhausted
+	b = NewBurnDown("platform", "USD", decimal.NewFromInt(10000), decimal.NewFromInt(1000), decimal.NewFromInt(400), period, at)
+	if b.ExhaustedOn != nil || b.Exceeded() {
+		t.Errorf("exhausted on %v with %s projected", b.ExhaustedOn, b.ProjectedTotal)
+	}
+}
+
+func TestProjectionBurnDown(t *testing.T) {
+	period := FiscalYear(time.Date(2026, time.July, 1, 0, 0, 0, 0, time.UTC), time.January)
+	p := Projection{
+		Status:    Status{Budget: &clickhouse.Budget{Name: "platform", MonthlyLimit: decimal.NewFromInt(1000), Currency: "USD"}},
+		Projected: decimal.NewFromInt(1200),
+	}
+	b := p.BurnDown(period, time.Date(2026, time.July, 1, 0, 0, 0, 0, time.UTC))
+	if !b.Budget.Equal(decimal.NewFromInt(12000)) || !b.Spent.Equal(decimal.NewFromInt(6000)) {
+		t.Fatalf("budget %s, spent %s", b.Budget, b.Spent)
+	}
+	if !b.ProjectedTotal.Equal(decimal.NewFromInt(13200)) || b.ExhaustedOn == nil || b.ExhaustedOn.Month() != time.December {
+		t.Errorf("projected %s, exhausted on %v", b.ProjectedTotal, b.ExhaustedOn)
+	}
+}
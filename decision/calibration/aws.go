@@ -0,0 +1,163 @@
+// Package calibration - AWS request signing
+// CloudWatch and Cost Explorer are called over their HTTP APIs with Signature
+// Version 4, using the standard AWS credential environment variables.
+package calibration
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Credentials are static AWS credentials
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Set for temporary credentials
+}
+
+// CredentialsFromEnv reads AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+func CredentialsFromEnv() (Credentials, bool) {
+	c := Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	return c, c.AccessKeyID != "" && c.SecretAccessKey != ""
+}
+
+// apiError is an error response of an AWS API
+type apiError struct {
+	Service string
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *apiError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("%s returned HTTP %d", e.Service, e.Status)
+	}
+	return fmt.Sprintf("%s returned HTTP %d: %s: %s", e.Service, e.Status, e.Code, e.Message)
+}
+
+// isAccessError reports whether an error rejects the credentials rather than a resource
+func isAccessError(err error) bool {
+	var apiErr *apiError
+	return errors.As(err, &apiErr) && (apiErr.Status == http.StatusUnauthorized || apiErr.Status == http.StatusForbidden)
+}
+
+// signedRequest creates a request signed for an AWS service and region
+func signedRequest(ctx context.Context, creds Credentials, service, region, method, url string, headers map[string]string, body []byte, now time.Time) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	sign(req, creds, service, region, body, now)
+	return req, nil
+}
+
+// SignedRequest creates a request signed for an AWS service and region, for
+// packages outside calibration that call AWS query APIs
+func SignedRequest(ctx context.Context, creds Credentials, service, region, method, url string, headers map[string]string, body []byte, now time.Time) (*http.Request, error) {
+	return signedRequest(ctx, creds, service, region, method, url, headers, body, now)
+}
+
+// sign adds Signature Version 4 headers to a request
+func sign(req *http.Request, creds Credentials, service, region string, body []byte, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	// Canonical headers: host plus every header set on the request
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(req.URL.RawQuery),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hashHex(body),
+	}, "\n")
+
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hashHex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	for _, part := range []string{region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalQuery is the signed form of an encoded query string
+// Parameters sort by name, then value; spaces are escaped as %20.
+func canonicalQuery(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	params := strings.Split(strings.ReplaceAll(raw, "+", "%20"), "&")
+	sort.Slice(params, func(i, j int) bool {
+		ki, vi, _ := strings.Cut(params[i], "=")
+		kj, vj, _ := strings.Cut(params[j], "=")
+		if ki != kj {
+			return ki < kj
+		}
+		return vi < vj
+	})
+	return strings.Join(params, "&")
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// readBody reads a response body, bounded to keep error pages from flooding memory
+func readBody(resp *http.Response) ([]byte, error) {
+	return io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+}
@@ -0,0 +1,254 @@
+// Package calibration - Historical usage calibration
+// Updates and replacements touch resources that already run: their recent
+// CloudWatch metrics or Cost Explorer usage replace heuristic usage guesses,
+// which is where brownfield estimates gain the most.
+package calibration
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/iac"
+)
+
+// daysPerMonth converts daily usage to the monthly convention of billing.HoursPerMonth hours
+const daysPerMonth = billing.HoursPerMonth / 24.0
+
+// Confidence of calibrated usage; short histories are trusted less
+const (
+	calibratedConfidence = 0.85
+	shortHistoryDays     = 7
+	shortHistoryPenalty  = 0.15
+)
+
+// Target is a component of an existing resource to calibrate
+type Target struct {
+	Component  *billing.BillingComponent
+	Key        string                 // Resource type and component suffix, e.g. aws_lambda_function/invocations
+	Attributes map[string]interface{} // Current (prior state) attributes of the resource
+}
+
+// Observation is the measured monthly usage of a component
+type Observation struct {
+	Min, P50, P90, Max float64
+	Days               int    // Days of history observed
+	Source             string // e.g. CloudWatch AWS/Lambda Invocations
+}
+
+// Source measures recent usage of existing resources
+type Source interface {
+	Name() string
+	// Observe returns nil without error when the source has no data for the target
+	Observe(ctx context.Context, target Target) (*Observation, error)
+}
+
+// Calibrator replaces heuristic usage of existing resources with observed usage
+type Calibrator struct {
+	sources []Source
+}
+
+// NewCalibrator creates a calibrator trying sources in order
+func NewCalibrator(sources ...Source) *Calibrator {
+	return &Calibrator{sources: sources}
+}
+
+// NewSources creates the named sources (cloudwatch, cost-explorer) for credentials
+func NewSources(names []string, creds Credentials) ([]Source, error) {
+	sources := make([]Source, 0, len(names))
+	for _, name := range names {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "cloudwatch":
+			sources = append(sources, NewCloudWatchSource(creds))
+		case "cost-explorer", "costexplorer":
+			sources = append(sources, NewCostExplorerSource(creds))
+		default:
+			return nil, fmt.Errorf("unknown calibration source %q (cloudwatch, cost-explorer)", name)
+		}
+	}
+	return sources, nil
+}
+
+// Calibrate returns components with observed usage applied, plus warnings for failed lookups
+// Only usage-based components of updated or replaced resources are calibrated;
+// provisioned capacity (hourly components) is known from the plan.
+func (c *Calibrator) Calibrate(ctx context.Context, graph *iac.Graph, components []billing.BillingComponent) ([]billing.BillingComponent, []string) {
+	out := make([]billing.BillingComponent, len(components))
+	copy(out, components)
+	warnings := make([]string, 0)
+	failed := make(map[string]bool) // Sources failing for reasons unrelated to the target are reported once
+
+	for i := range out {
+		comp := &out[i]
+		if comp.BillingPeriod == billing.PeriodHourly || comp.BillingPeriod == billing.PeriodGBHourly {
+			continue
+		}
+		node, ok := graph.Nodes[comp.ResourceAddr]
+		if !ok || !existing(node) {
+			continue
+		}
+		key := node.Resource.Type + "/" + strings.TrimPrefix(comp.ID, comp.ResourceAddr+"-")
+		if _, ok := usageMetrics[key]; !ok {
+			continue
+		}
+
+		target := Target{Component: comp, Key: key, Attributes: priorAttributes(node)}
+		for _, src := range c.sources {
+			if failed[src.Name()] {
+				continue
+			}
+			obs, err := src.Observe(ctx, target)
+			if err != nil {
+				if ctx.Err() != nil {
+					return out, append(warnings, fmt.Sprintf("calibration: %v", ctx.Err()))
+				}
+				warnings = append(warnings, fmt.Sprintf("calibration: %s: %s: %v", src.Name(), comp.ResourceAddr, err))
+				if isAccessError(err) {
+					failed[src.Name()] = true
+				}
+				continue
+			}
+			if obs != nil {
+				obs.apply(&comp.VarianceProfile)
+				break
+			}
+		}
+	}
+	return out, warnings
+}
+
+// existing reports whether a node is a resource already running in the cloud
+func existing(node *iac.GraphNode) bool {
+	return node.Change != nil && (node.Change.Action == iac.ActionUpdate || node.Change.Action == iac.ActionReplace)
+}
+
+// priorAttributes are the attributes identifying the running resource
+// A replacement's prior state names the resource whose history applies.
+func priorAttributes(node *iac.GraphNode) map[string]interface{} {
+	if node.Change != nil && len(node.Change.Before) > 0 {
+		return node.Change.Before
+	}
+	return node.Resource.Attributes
+}
+
+// apply replaces a variance profile with the observed usage
+func (o Observation) apply(vp *billing.VarianceProfile) {
+	vp.BaselineUsage = o.P50
+	vp.MinUsage = o.Min
+	vp.P50Usage = o.P50
+	vp.P90Usage = math.Max(o.P90, o.P50)
+	vp.MaxUsage = math.Max(o.Max, vp.P90Usage)
+	vp.Confidence = calibratedConfidence
+	if o.Days < shortHistoryDays {
+		vp.Confidence -= shortHistoryPenalty
+	}
+	vp.VolatilityScore = 0
+	if vp.P90Usage > 0 {
+		vp.VolatilityScore = (vp.P90Usage - vp.P50Usage) / vp.P90Usage
+	}
+	vp.Assumptions = []string{fmt.Sprintf("Calibrated from %s over the last %d days", o.Source, o.Days)}
+}
+
+// newObservation summarizes daily values as monthly usage
+// Flows (requests, bytes) are summed over a month; levels (stored GB) are not.
+func newObservation(source string, daily []float64, level bool) *Observation {
+	if len(daily) == 0 {
+		return nil
+	}
+	values := append([]float64{}, daily...)
+	if !level {
+		for i := range values {
+			values[i] *= daysPerMonth
+		}
+	}
+	sort.Float64s(values)
+	return &Observation{
+		Min:    values[0],
+		P50:    percentile(values, 0.5),
+		P90:    percentile(values, 0.9),
+		Max:    values[len(values)-1],
+		Days:   len(values),
+		Source: source,
+	}
+}
+
+// percentile interpolates a percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	rank := p * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(rank-float64(lo))
+}
+
+// =============================================================================
+// USAGE METRICS
+// =============================================================================
+
+// usageMetric describes how a kind of component is measured
+type usageMetric struct {
+	// CloudWatch
+	namespace  string
+	metrics    []string // Summed per day
+	level      bool     // Averaged level (stored bytes) rather than a daily flow
+	scale      float64  // Metric unit to component unit; 0 is 1
+	dimensions func(attrs map[string]interface{}) map[string]string
+
+	// Cost Explorer
+	resourceID string   // Attribute holding the Cost Explorer resource ID
+	usageTypes []string // Usage type suffixes; default the component's usage type
+}
+
+const bytesPerGB = 1 << 30
+
+// usageMetrics are the measurable components, by resource type and component suffix
+var usageMetrics = map[string]usageMetric{
+	"aws_lambda_function/invocations": {
+		namespace:  "AWS/Lambda",
+		metrics:    []string{"Invocations"},
+		dimensions: attrDimension("FunctionName", "function_name"),
+		resourceID: "arn",
+		usageTypes: []string{"Request"},
+	},
+	"aws_dynamodb_table/ondemand": {
+		namespace:  "AWS/DynamoDB",
+		metrics:    []string{"ConsumedReadCapacityUnits", "ConsumedWriteCapacityUnits"},
+		dimensions: attrDimension("TableName", "name"),
+		resourceID: "arn",
+		usageTypes: []string{"ReadRequestUnits", "WriteRequestUnits"},
+	},
+	"aws_s3_bucket/storage": {
+		namespace: "AWS/S3",
+		metrics:   []string{"BucketSizeBytes"},
+		level:     true,
+		scale:     1.0 / bytesPerGB,
+		dimensions: func(attrs map[string]interface{}) map[string]string {
+			bucket := billing.ExtractAttribute(attrs, "bucket")
+			if bucket == "" {
+				return nil
+			}
+			return map[string]string{"BucketName": bucket, "StorageType": "StandardStorage"}
+		},
+		resourceID: "bucket",
+	},
+	"aws_nat_gateway/data": {
+		namespace:  "AWS/NATGateway",
+		metrics:    []string{"BytesInFromSource", "BytesInFromDestination"},
+		scale:      1.0 / bytesPerGB,
+		dimensions: attrDimension("NatGatewayId", "id"),
+		resourceID: "id",
+	},
+}
+
+// attrDimension is a single CloudWatch dimension read from an attribute
+func attrDimension(name, attr string) func(map[string]interface{}) map[string]string {
+	return func(attrs map[string]interface{}) map[string]string {
+		value := billing.ExtractAttribute(attrs, attr)
+		if value == "" {
+			return nil
+		}
+		return map[string]string{name: value}
+	}
+}
@@ -0,0 +1,113 @@
+// Package calibration - Calibration tests
+package calibration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/iac"
+)
+
+func TestSign(t *testing.T) {
+	// AWS Signature Version 4 test suite: get-vanilla
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	creds := Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	sign(req, creds, "service", "us-east-1", nil, time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization = %s\nwant %s", got, want)
+	}
+}
+
+func TestCalibrate(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	var queried []string
+	cw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/20240601/us-east-1/monitoring/") {
+			t.Errorf("unsigned request: %q", r.Header.Get("Authorization"))
+		}
+		queried = append(queried, r.URL.Query().Get("Dimensions.member.1.Value"))
+		// 10 days of 1000, 2000, ... 10000 invocations
+		var points strings.Builder
+		for day := 1; day <= 10; day++ {
+			fmt.Fprintf(&points, "<member><Timestamp>%s</Timestamp><Sum>%d</Sum></member>",
+				now.AddDate(0, 0, -day).Format(time.RFC3339), day*1000)
+		}
+		fmt.Fprintf(w, `<GetMetricStatisticsResponse><GetMetricStatisticsResult><Datapoints>%s</Datapoints></GetMetricStatisticsResult></GetMetricStatisticsResponse>`, points.String())
+	}))
+	defer cw.Close()
+	ceCalls := 0
+	ce := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ceCalls++
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"__type":"com.amazon#AccessDeniedException","message":"not authorized"}`)
+	}))
+	defer ce.Close()
+
+	creds := Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}
+	cloudWatch := NewCloudWatchSource(creds).WithEndpoint(cw.URL + "/")
+	cloudWatch.now = func() time.Time { return now }
+	costExplorer := NewCostExplorerSource(creds).WithEndpoint(ce.URL + "/")
+	costExplorer.now = cloudWatch.now
+
+	lambda := func(name string, action iac.ChangeAction) *iac.GraphNode {
+		attrs := map[string]interface{}{"function_name": name, "arn": "arn:aws:lambda:us-east-1:1:function:" + name}
+		return &iac.GraphNode{
+			Resource: iac.ResourceNode{Type: "aws_lambda_function", Attributes: attrs},
+			Change:   &iac.ResourceChange{Action: action, Before: attrs},
+		}
+	}
+	graph := &iac.Graph{Nodes: map[string]*iac.GraphNode{
+		"aws_lambda_function.api":    lambda("api", iac.ActionUpdate),
+		"aws_lambda_function.worker": lambda("worker", iac.ActionReplace),
+		"aws_lambda_function.new":    lambda("new", iac.ActionCreate),
+	}}
+	component := func(addr string) billing.BillingComponent {
+		return billing.BillingComponent{
+			ID: addr + "-invocations", ResourceAddr: addr, Region: "us-east-1",
+			BillingPeriod:   billing.PeriodPerRequest,
+			VarianceProfile: billing.VarianceProfile{BaselineUsage: 1000000, P50Usage: 500000, P90Usage: 2000000, Confidence: 0.5},
+		}
+	}
+	components := []billing.BillingComponent{
+		component("aws_lambda_function.api"),
+		component("aws_lambda_function.worker"),
+		component("aws_lambda_function.new"),
+	}
+
+	out, warnings := NewCalibrator(costExplorer, cloudWatch).Calibrate(context.Background(), graph, components)
+
+	// Cost Explorer rejects the credentials once and is not asked again
+	if ceCalls != 1 || len(warnings) != 1 || !strings.Contains(warnings[0], "AccessDeniedException") {
+		t.Errorf("expected one access warning, got %d calls and %v", ceCalls, warnings)
+	}
+	if strings.Join(queried, ",") != "api,worker" {
+		t.Errorf("expected only existing functions queried, got %v", queried)
+	}
+
+	// Median day 5500 and P90 day 9100 invocations, scaled to a month
+	vp := out[0].VarianceProfile
+	if want := 5500 * daysPerMonth; vp.P50Usage < want-1e-6 || vp.P50Usage > want+1e-6 {
+		t.Errorf("P50 = %v, want %v", vp.P50Usage, want)
+	}
+	if want := 9100 * daysPerMonth; vp.P90Usage < want-1e-6 || vp.P90Usage > want+1e-6 {
+		t.Errorf("P90 = %v, want %v", vp.P90Usage, want)
+	}
+	if vp.Confidence != calibratedConfidence || len(vp.Assumptions) != 1 || !strings.Contains(vp.Assumptions[0], "10 days") {
+		t.Errorf("unexpected calibrated profile: %+v", vp)
+	}
+	if out[2].VarianceProfile.P50Usage != 500000 {
+		t.Errorf("new resources must keep heuristic usage, got %+v", out[2].VarianceProfile)
+	}
+	if components[0].VarianceProfile.P50Usage != 500000 {
+		t.Error("Calibrate should not mutate its input")
+	}
+}
@@ -0,0 +1,168 @@
+// Package calibration - CloudWatch source
+// Daily metric statistics of the resource (Lambda invocations, NAT bytes,
+// bucket size) over a lookback window give the observed usage distribution.
+package calibration
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCloudWatchLookback is the history CloudWatch calibration reads
+const DefaultCloudWatchLookback = 30 * 24 * time.Hour
+
+// CloudWatchSource reads daily metric statistics from CloudWatch
+type CloudWatchSource struct {
+	client   *http.Client
+	creds    Credentials
+	endpoint string // Overrides the regional endpoint (tests)
+	lookback time.Duration
+	now      func() time.Time
+}
+
+// NewCloudWatchSource creates a CloudWatch source for the given credentials
+func NewCloudWatchSource(creds Credentials) *CloudWatchSource {
+	return &CloudWatchSource{
+		client:   &http.Client{Timeout: 30 * time.Second},
+		creds:    creds,
+		lookback: DefaultCloudWatchLookback,
+		now:      time.Now,
+	}
+}
+
+// WithEndpoint overrides the regional CloudWatch endpoint (proxies, tests)
+func (s *CloudWatchSource) WithEndpoint(endpoint string) *CloudWatchSource {
+	s.endpoint = endpoint
+	return s
+}
+
+// WithLookback sets the history read, rounded down to whole days
+func (s *CloudWatchSource) WithLookback(d time.Duration) *CloudWatchSource {
+	s.lookback = d
+	return s
+}
+
+// Name returns the source name
+func (s *CloudWatchSource) Name() string {
+	return "CloudWatch"
+}
+
+// Observe sums the target's metrics per day over the lookback window
+func (s *CloudWatchSource) Observe(ctx context.Context, t Target) (*Observation, error) {
+	spec := usageMetrics[t.Key]
+	if spec.namespace == "" || spec.dimensions == nil {
+		return nil, nil
+	}
+	dims := spec.dimensions(t.Attributes)
+	if dims == nil || t.Component.Region == "" {
+		return nil, nil
+	}
+
+	end := s.now().UTC().Truncate(24 * time.Hour)
+	start := end.Add(-s.lookback).Truncate(24 * time.Hour)
+	statistic := "Sum"
+	if spec.level {
+		statistic = "Average"
+	}
+	scale := spec.scale
+	if scale == 0 {
+		scale = 1
+	}
+
+	byDay := make(map[time.Time]float64)
+	for _, metric := range spec.metrics {
+		points, err := s.getMetricStatistics(ctx, t.Component.Region, spec.namespace, metric, statistic, dims, start, end)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range points {
+			v := p.Sum
+			if spec.level {
+				v = p.Average
+			}
+			byDay[p.Timestamp.UTC().Truncate(24*time.Hour)] += v * scale
+		}
+	}
+
+	daily := make([]float64, 0, len(byDay))
+	for _, v := range byDay {
+		daily = append(daily, v)
+	}
+	source := fmt.Sprintf("CloudWatch %s %s", spec.namespace, strings.Join(spec.metrics, "+"))
+	return newObservation(source, daily, spec.level), nil
+}
+
+type cloudWatchDatapoint struct {
+	Timestamp time.Time `xml:"Timestamp"`
+	Sum       float64   `xml:"Sum"`
+	Average   float64   `xml:"Average"`
+}
+
+type getMetricStatisticsResponse struct {
+	Datapoints []cloudWatchDatapoint `xml:"GetMetricStatisticsResult>Datapoints>member"`
+}
+
+type cloudWatchErrorResponse struct {
+	Code    string `xml:"Error>Code"`
+	Message string `xml:"Error>Message"`
+}
+
+// getMetricStatistics calls the GetMetricStatistics query API with daily periods
+func (s *CloudWatchSource) getMetricStatistics(ctx context.Context, region, namespace, metric, statistic string, dims map[string]string, start, end time.Time) ([]cloudWatchDatapoint, error) {
+	q := url.Values{}
+	q.Set("Action", "GetMetricStatistics")
+	q.Set("Version", "2010-08-01")
+	q.Set("Namespace", namespace)
+	q.Set("MetricName", metric)
+	q.Set("StartTime", start.Format(time.RFC3339))
+	q.Set("EndTime", end.Format(time.RFC3339))
+	q.Set("Period", "86400")
+	q.Set("Statistics.member.1", statistic)
+	names := make([]string, 0, len(dims))
+	for name := range dims {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		n := strconv.Itoa(i + 1)
+		q.Set("Dimensions.member."+n+".Name", name)
+		q.Set("Dimensions.member."+n+".Value", dims[name])
+	}
+
+	endpoint := s.endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://monitoring.%s.amazonaws.com/", region)
+	}
+	req, err := signedRequest(ctx, s.creds, "monitoring", region, http.MethodGet,
+		endpoint+"?"+strings.ReplaceAll(q.Encode(), "+", "%20"), nil, nil, s.now())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query CloudWatch: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := readBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CloudWatch response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var e cloudWatchErrorResponse
+		xml.Unmarshal(body, &e) // Best effort; the status is enough
+		return nil, &apiError{Service: "CloudWatch", Status: resp.StatusCode, Code: e.Code, Message: e.Message}
+	}
+	var out getMetricStatisticsResponse
+	if err := xml.Unmarshal(body, &out); err != nil {
+		return nil, fmt.Errorf("failed to parse CloudWatch response: %w", err)
+	}
+	return out.Datapoints, nil
+}
@@ -0,0 +1,195 @@
+// Package calibration - Cost Explorer source
+// Resource-level Cost Explorer data (opt-in, last 14 days) gives the daily
+// usage quantity billed to a resource per usage type.
+package calibration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"terraform-cost/decision/billing"
+)
+
+// DefaultCostExplorerLookback is the resource-level history Cost Explorer keeps
+const DefaultCostExplorerLookback = 14 * 24 * time.Hour
+
+// costExplorerEndpoint is the global Cost Explorer endpoint
+const costExplorerEndpoint = "https://ce.us-east-1.amazonaws.com/"
+
+// CostExplorerSource reads daily resource usage from Cost Explorer
+type CostExplorerSource struct {
+	client   *http.Client
+	creds    Credentials
+	endpoint string
+	lookback time.Duration
+	now      func() time.Time
+}
+
+// NewCostExplorerSource creates a Cost Explorer source for the given credentials
+func NewCostExplorerSource(creds Credentials) *CostExplorerSource {
+	return &CostExplorerSource{
+		client:   &http.Client{Timeout: 30 * time.Second},
+		creds:    creds,
+		endpoint: costExplorerEndpoint,
+		lookback: DefaultCostExplorerLookback,
+		now:      time.Now,
+	}
+}
+
+// WithEndpoint overrides the Cost Explorer endpoint (proxies, tests)
+func (s *CostExplorerSource) WithEndpoint(endpoint string) *CostExplorerSource {
+	s.endpoint = endpoint
+	return s
+}
+
+// Name returns the source name
+func (s *CostExplorerSource) Name() string {
+	return "Cost Explorer"
+}
+
+type ceRequest struct {
+	TimePeriod  ceTimePeriod `json:"TimePeriod"`
+	Granularity string       `json:"Granularity"`
+	Metrics     []string     `json:"Metrics"`
+	Filter      ceFilter     `json:"Filter"`
+	GroupBy     []ceGroupBy  `json:"GroupBy"`
+}
+
+type ceTimePeriod struct {
+	Start string `json:"Start"`
+	End   string `json:"End"`
+}
+
+type ceFilter struct {
+	Dimensions struct {
+		Key    string   `json:"Key"`
+		Values []string `json:"Values"`
+	} `json:"Dimensions"`
+}
+
+type ceGroupBy struct {
+	Type string `json:"Type"`
+	Key  string `json:"Key"`
+}
+
+type ceResponse struct {
+	ResultsByTime []struct {
+		Groups []struct {
+			Keys    []string `json:"Keys"`
+			Metrics map[string]struct {
+				Amount string `json:"Amount"`
+			} `json:"Metrics"`
+		} `json:"Groups"`
+	} `json:"ResultsByTime"`
+}
+
+type ceErrorResponse struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+// Observe sums the target's usage types per day over the lookback window
+func (s *CostExplorerSource) Observe(ctx context.Context, t Target) (*Observation, error) {
+	spec := usageMetrics[t.Key]
+	resourceID := billing.ExtractAttribute(t.Attributes, spec.resourceID)
+	if spec.resourceID == "" || resourceID == "" {
+		return nil, nil
+	}
+	usageTypes := spec.usageTypes
+	if len(usageTypes) == 0 {
+		usageTypes = []string{t.Component.UsageType}
+	}
+
+	end := s.now().UTC().Truncate(24 * time.Hour)
+	start := end.Add(-s.lookback)
+	in := ceRequest{
+		TimePeriod:  ceTimePeriod{Start: start.Format("2006-01-02"), End: end.Format("2006-01-02")},
+		Granularity: "DAILY",
+		Metrics:     []string{"UsageQuantity"},
+		GroupBy:     []ceGroupBy{{Type: "DIMENSION", Key: "USAGE_TYPE"}},
+	}
+	in.Filter.Dimensions.Key = "RESOURCE_ID"
+	in.Filter.Dimensions.Values = []string{resourceID}
+
+	out, err := s.getCostAndUsage(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	daily := make([]float64, 0, len(out.ResultsByTime))
+	matched := false
+	for _, day := range out.ResultsByTime {
+		total := 0.0
+		for _, g := range day.Groups {
+			if len(g.Keys) == 0 || !matchesUsageType(g.Keys[0], usageTypes) {
+				continue
+			}
+			amount, err := strconv.ParseFloat(g.Metrics["UsageQuantity"].Amount, 64)
+			if err != nil {
+				continue
+			}
+			total += amount
+			matched = true
+		}
+		daily = append(daily, total)
+	}
+	if !matched {
+		return nil, nil
+	}
+	// Daily quantities of monthly units (GB-Mo) add up over a month like flows do
+	return newObservation("Cost Explorer "+strings.Join(usageTypes, "+"), daily, false), nil
+}
+
+// matchesUsageType compares a billed usage type, which carries a region prefix (USE1-), with suffixes
+func matchesUsageType(billed string, usageTypes []string) bool {
+	for _, ut := range usageTypes {
+		if billed == ut || strings.HasSuffix(billed, "-"+ut) {
+			return true
+		}
+	}
+	return false
+}
+
+// getCostAndUsage calls GetCostAndUsageWithResources
+func (s *CostExplorerSource) getCostAndUsage(ctx context.Context, in ceRequest) (*ceResponse, error) {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	req, err := signedRequest(ctx, s.creds, "ce", "us-east-1", http.MethodPost, s.endpoint, map[string]string{
+		"Content-Type": "application/x-amz-json-1.1",
+		"X-Amz-Target": "AWSInsightsIndexService.GetCostAndUsageWithResources",
+	}, body, s.now())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Cost Explorer: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := readBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Cost Explorer response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var e ceErrorResponse
+		json.Unmarshal(data, &e) // Best effort; the status is enough
+		code := e.Type
+		if i := strings.LastIndex(code, "#"); i >= 0 {
+			code = code[i+1:]
+		}
+		return nil, &apiError{Service: "Cost Explorer", Status: resp.StatusCode, Code: code, Message: e.Message}
+	}
+	var out ceResponse
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to parse Cost Explorer response: %w", err)
+	}
+	return &out, nil
+}
@@ -7,8 +7,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
+
+	"terraform-cost/internal/httpclient"
 )
 
 // CarbonStore provides carbon intensity data for regions
@@ -37,12 +40,10 @@ type cachedIntensity struct {
 // NewElectricityMapsClient creates a new Electricity Maps client
 func NewElectricityMapsClient(apiKey string) *ElectricityMapsClient {
 	return &ElectricityMapsClient{
-		apiKey: apiKey,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-		cache:    make(map[string]cachedIntensity),
-		cacheTTL: 15 * time.Minute,
+		apiKey:     apiKey,
+		httpClient: httpclient.New(httpclient.DefaultConfig()),
+		cache:      make(map[string]cachedIntensity),
+		cacheTTL:   15 * time.Minute,
 	}
 }
 
@@ -298,23 +299,66 @@ func (c *ComposedCarbonStore) GetIntensity(ctx context.Context, cloud, region st
 // FACTORY
 // =============================================================================
 
+// Carbon data source names for Config.Sources
+const (
+	SourceElectricityMaps = "electricitymaps"
+	SourceWattTime        = "watttime"
+	SourceStatic          = "static"
+)
+
+// Config selects and orders carbon intensity sources
+type Config struct {
+	// Sources are tried in order; empty uses every configured API, then static data
+	Sources []string
+
+	ElectricityMapsAPIKey string
+	WattTimeUsername      string
+	WattTimePassword      string
+}
+
 // NewCarbonStore creates the appropriate carbon store based on configuration
 func NewCarbonStore(electricityMapsAPIKey string) CarbonStore {
-	stores := make([]CarbonStore, 0)
+	store, _ := NewCarbonStoreFromConfig(Config{ElectricityMapsAPIKey: electricityMapsAPIKey})
+	return store
+}
 
-	// Add Electricity Maps if API key provided
-	if electricityMapsAPIKey != "" {
-		stores = append(stores, NewElectricityMapsClient(electricityMapsAPIKey))
+// NewCarbonStoreFromConfig composes the configured sources in order
+func NewCarbonStoreFromConfig(cfg Config) (CarbonStore, error) {
+	sources := cfg.Sources
+	if len(sources) == 0 {
+		if cfg.ElectricityMapsAPIKey != "" {
+			sources = append(sources, SourceElectricityMaps)
+		}
+		if cfg.WattTimeUsername != "" {
+			sources = append(sources, SourceWattTime)
+		}
+		sources = append(sources, SourceStatic)
 	}
 
-	// Always add static fallback
-	stores = append(stores, NewStaticCarbonStore())
+	stores := make([]CarbonStore, 0, len(sources))
+	for _, source := range sources {
+		switch strings.ToLower(strings.TrimSpace(source)) {
+		case SourceElectricityMaps:
+			if cfg.ElectricityMapsAPIKey == "" {
+				return nil, fmt.Errorf("carbon source %s needs an API key", SourceElectricityMaps)
+			}
+			stores = append(stores, NewElectricityMapsClient(cfg.ElectricityMapsAPIKey))
+		case SourceWattTime:
+			if cfg.WattTimeUsername == "" || cfg.WattTimePassword == "" {
+				return nil, fmt.Errorf("carbon source %s needs a username and password", SourceWattTime)
+			}
+			stores = append(stores, NewWattTimeClient(cfg.WattTimeUsername, cfg.WattTimePassword))
+		case SourceStatic:
+			stores = append(stores, NewStaticCarbonStore())
+		default:
+			return nil, fmt.Errorf("unknown carbon source %q (%s, %s, %s)", source, SourceElectricityMaps, SourceWattTime, SourceStatic)
+		}
+	}
 
 	if len(stores) == 1 {
-		return stores[0]
+		return stores[0], nil
 	}
-
-	return NewComposedCarbonStore(stores...)
+	return NewComposedCarbonStore(stores...), nil
 }
 
 // GetLowCarbonRegions returns regions with carbon intensity below threshold
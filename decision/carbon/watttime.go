@@ -0,0 +1,251 @@
+// Package carbon - WattTime client
+// WattTime publishes the marginal operating emissions rate (MOER): the
+// emissions of the generator that responds to extra load right now.
+package carbon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"terraform-cost/internal/httpclient"
+)
+
+// WattTimeBaseURL is the WattTime v3 API
+const WattTimeBaseURL = "https://api.watttime.org"
+
+// wattTimeTokenTTL is kept below WattTime's 30 minute token lifetime
+const wattTimeTokenTTL = 25 * time.Minute
+
+// lbsPerMWhToGramsPerKWh converts WattTime MOER units to gCO2/kWh
+const lbsPerMWhToGramsPerKWh = 0.453592
+
+// WattTimeClient fetches marginal emissions from WattTime
+type WattTimeClient struct {
+	username   string
+	password   string
+	baseURL    string
+	httpClient *http.Client
+	cacheTTL   time.Duration
+
+	tokenMu     sync.Mutex
+	token       string
+	tokenExpiry time.Time
+
+	cacheMu sync.RWMutex
+	cache   map[string]cachedIntensity
+	regions map[string]string // cloud:region -> WattTime grid region
+}
+
+// NewWattTimeClient creates a WattTime client from account credentials
+func NewWattTimeClient(username, password string) *WattTimeClient {
+	return &WattTimeClient{
+		username:   username,
+		password:   password,
+		baseURL:    WattTimeBaseURL,
+		httpClient: httpclient.New(httpclient.DefaultConfig()),
+		cacheTTL:   5 * time.Minute, // MOER is published every five minutes
+		cache:      make(map[string]cachedIntensity),
+		regions:    make(map[string]string),
+	}
+}
+
+// WithBaseURL overrides the API endpoint
+func (c *WattTimeClient) WithBaseURL(baseURL string) *WattTimeClient {
+	c.baseURL = baseURL
+	return c
+}
+
+// GetIntensity returns the current marginal carbon intensity (gCO2/kWh) of a cloud region
+func (c *WattTimeClient) GetIntensity(ctx context.Context, cloud, region string) (float64, error) {
+	gridRegion, err := c.gridRegion(ctx, cloud, region)
+	if err != nil {
+		return 0, err
+	}
+
+	c.cacheMu.RLock()
+	if cached, ok := c.cache[gridRegion]; ok && time.Now().Before(cached.expiresAt) {
+		c.cacheMu.RUnlock()
+		return cached.value, nil
+	}
+	c.cacheMu.RUnlock()
+
+	var forecast struct {
+		Data []struct {
+			PointTime string  `json:"point_time"`
+			Value     float64 `json:"value"`
+		} `json:"data"`
+		Meta struct {
+			Units string `json:"units"`
+		} `json:"meta"`
+	}
+	query := url.Values{"region": {gridRegion}, "signal_type": {"co2_moer"}, "horizon_hours": {"0"}}
+	if err := c.get(ctx, "/v3/forecast", query, &forecast); err != nil {
+		return 0, err
+	}
+	if len(forecast.Data) == 0 {
+		return 0, fmt.Errorf("watttime returned no data for %s", gridRegion)
+	}
+
+	intensity := forecast.Data[0].Value
+	if forecast.Meta.Units == "" || forecast.Meta.Units == "lbs_co2_per_mwh" {
+		intensity *= lbsPerMWhToGramsPerKWh
+	}
+
+	c.cacheMu.Lock()
+	c.cache[gridRegion] = cachedIntensity{value: intensity, expiresAt: time.Now().Add(c.cacheTTL)}
+	c.cacheMu.Unlock()
+	return intensity, nil
+}
+
+// gridRegion resolves a cloud region to its WattTime grid region by location
+func (c *WattTimeClient) gridRegion(ctx context.Context, cloud, region string) (string, error) {
+	key := cloud + ":" + region
+	c.cacheMu.RLock()
+	gridRegion, ok := c.regions[key]
+	c.cacheMu.RUnlock()
+	if ok {
+		return gridRegion, nil
+	}
+
+	loc, ok := regionLocations[key]
+	if !ok {
+		return "", fmt.Errorf("unknown region location: %s/%s", cloud, region)
+	}
+
+	var resp struct {
+		Region string `json:"region"`
+	}
+	query := url.Values{
+		"latitude":    {fmt.Sprintf("%.4f", loc[0])},
+		"longitude":   {fmt.Sprintf("%.4f", loc[1])},
+		"signal_type": {"co2_moer"},
+	}
+	if err := c.get(ctx, "/v3/region-from-loc", query, &resp); err != nil {
+		return "", err
+	}
+	if resp.Region == "" {
+		return "", fmt.Errorf("watttime has no grid region for %s/%s", cloud, region)
+	}
+
+	c.cacheMu.Lock()
+	c.regions[key] = resp.Region
+	c.cacheMu.Unlock()
+	return resp.Region, nil
+}
+
+// get calls an authenticated endpoint, logging in again once if the token was rejected
+func (c *WattTimeClient) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	for attempt := 0; ; attempt++ {
+		token, err := c.accessToken(ctx, attempt > 0)
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path+"?"+query.Encode(), nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("watttime request failed: %w", err)
+		}
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			resp.Body.Close()
+			continue
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("watttime %s returned status %d", path, resp.StatusCode)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode watttime response: %w", err)
+		}
+		return nil
+	}
+}
+
+// accessToken returns a cached login token, logging in when it is missing, stale or rejected
+func (c *WattTimeClient) accessToken(ctx context.Context, refresh bool) (string, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if !refresh && c.token != "" && time.Now().Before(c.tokenExpiry) {
+		return c.token, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/login", nil)
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(c.username, c.password)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("watttime login failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("watttime login returned status %d", resp.StatusCode)
+	}
+
+	var login struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&login); err != nil || login.Token == "" {
+		return "", fmt.Errorf("watttime login returned no token")
+	}
+	c.token = login.Token
+	c.tokenExpiry = time.Now().Add(wattTimeTokenTTL)
+	return c.token, nil
+}
+
+// regionLocations are approximate data center coordinates (latitude, longitude)
+var regionLocations = map[string][2]float64{
+	// AWS
+	"aws:us-east-1":      {38.94, -77.46},
+	"aws:us-east-2":      {40.10, -83.11},
+	"aws:us-west-1":      {37.35, -121.96},
+	"aws:us-west-2":      {45.84, -119.70},
+	"aws:ca-central-1":   {45.50, -73.57},
+	"aws:eu-west-1":      {53.35, -6.26},
+	"aws:eu-west-2":      {51.51, -0.13},
+	"aws:eu-west-3":      {48.86, 2.35},
+	"aws:eu-central-1":   {50.11, 8.68},
+	"aws:eu-north-1":     {59.33, 18.07},
+	"aws:ap-northeast-1": {35.68, 139.69},
+	"aws:ap-southeast-1": {1.35, 103.82},
+	"aws:ap-southeast-2": {-33.87, 151.21},
+	"aws:ap-south-1":     {19.08, 72.88},
+	"aws:sa-east-1":      {-23.55, -46.63},
+
+	// Azure
+	"azure:eastus":        {37.37, -79.82},
+	"azure:eastus2":       {36.67, -78.39},
+	"azure:westus":        {37.78, -122.42},
+	"azure:westus2":       {47.23, -119.85},
+	"azure:centralus":     {41.59, -93.60},
+	"azure:westeurope":    {52.37, 4.90},
+	"azure:northeurope":   {53.35, -6.26},
+	"azure:uksouth":       {51.51, -0.13},
+	"azure:francecentral": {46.38, 2.37},
+
+	// GCP
+	"gcp:us-east1":     {33.20, -80.01},
+	"gcp:us-east4":     {39.04, -77.49},
+	"gcp:us-central1":  {41.26, -95.86},
+	"gcp:us-west1":     {45.60, -121.18},
+	"gcp:us-west2":     {34.05, -118.24},
+	"gcp:europe-west1": {50.45, 3.82},
+	"gcp:europe-west2": {51.51, -0.13},
+	"gcp:europe-west3": {50.11, 8.68},
+	"gcp:europe-west4": {53.44, 6.84},
+	"gcp:asia-east1":   {24.05, 120.52},
+}
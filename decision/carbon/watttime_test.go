@@ -0,0 +1,87 @@
+package carbon
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWattTimeClient(t *testing.T) {
+	logins, forecasts := 0, 0
+	expired := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			logins++
+			fmt.Fprintf(w, `{"token": "token-%d"}`, logins)
+			return
+		}
+		// The first token is revoked after its first use
+		if r.Header.Get("Authorization") == "Bearer token-1" && expired {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		expired = true
+		switch r.URL.Path {
+		case "/v3/region-from-loc":
+			fmt.Fprint(w, `{"region": "CAISO_NORTH"}`)
+		case "/v3/forecast":
+			forecasts++
+			if r.URL.Query().Get("region") != "CAISO_NORTH" || r.URL.Query().Get("signal_type") != "co2_moer" {
+				t.Errorf("unexpected query %s", r.URL.RawQuery)
+			}
+			fmt.Fprint(w, `{"data": [{"point_time": "2024-06-01T00:00:00Z", "value": 1000}], "meta": {"units": "lbs_co2_per_mwh"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewWattTimeClient("user", "secret").WithBaseURL(srv.URL)
+	for i := 0; i < 2; i++ {
+		intensity, err := client.GetIntensity(context.Background(), "aws", "us-west-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(intensity-453.592) > 1e-6 {
+			t.Errorf("intensity = %f, want 453.592 gCO2/kWh", intensity)
+		}
+	}
+	if logins != 2 {
+		t.Errorf("logins = %d, want a refresh after the rejected token", logins)
+	}
+	if forecasts != 1 {
+		t.Errorf("forecasts = %d, want the second lookup cached", forecasts)
+	}
+
+	if _, err := client.GetIntensity(context.Background(), "aws", "mars-1"); err == nil {
+		t.Error("expected error for unknown region")
+	}
+}
+
+func TestNewCarbonStoreFromConfig(t *testing.T) {
+	if _, err := NewCarbonStoreFromConfig(Config{Sources: []string{"watttime"}}); err == nil {
+		t.Error("expected error for watttime without credentials")
+	}
+	if _, err := NewCarbonStoreFromConfig(Config{Sources: []string{"sundial"}}); err == nil {
+		t.Error("expected error for unknown source")
+	}
+
+	store, err := NewCarbonStoreFromConfig(Config{WattTimeUsername: "u", WattTimePassword: "p"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	composed, ok := store.(*ComposedCarbonStore)
+	if !ok || len(composed.stores) != 2 {
+		t.Fatalf("got %T, want WattTime then static", store)
+	}
+	if _, ok := composed.stores[0].(*WattTimeClient); !ok {
+		t.Errorf("first source is %T", composed.stores[0])
+	}
+}
@@ -0,0 +1,176 @@
+// Package catalog lists instance prices for side-by-side comparison
+// Prices are the on-demand rates of a region's active pricing snapshot. Each
+// instance type's vCPUs and memory come from instance metadata, and its carbon
+// per hour from the estimation power model and the region's grid intensity.
+package catalog
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/awsmeta"
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/carbon"
+	"terraform-cost/decision/estimation"
+)
+
+// HoursPerMonth prices a month of an instance running around the clock
+const HoursPerMonth = billing.HoursPerMonth
+
+// Store lists the instance rates of a region
+type Store interface {
+	ListInstanceRates(ctx context.Context, filter clickhouse.InstanceRateFilter) (*clickhouse.PricingSnapshot, []clickhouse.InstanceRate, error)
+}
+
+// HardwareSource resolves the vCPUs and memory of an instance type; unknown types return nil, nil
+// Implemented by awsmeta datasets and enrichers.
+type HardwareSource interface {
+	InstanceType(ctx context.Context, region, name string) (*awsmeta.InstanceType, error)
+}
+
+// Query selects the instances of a catalog listing
+type Query struct {
+	Region          string
+	Family          string // e.g. m5; empty lists every family
+	OperatingSystem string // Price List operating system (default: Linux)
+	SortBy          string // One of SortKeys (default: price)
+}
+
+// SortKeys are the accepted values of Query.SortBy
+var SortKeys = []string{"price", "vcpu", "memory", "carbon"}
+
+// Instance is an instance type's price, hardware and emissions
+type Instance struct {
+	InstanceType       string          `json:"instance_type"`
+	VCPU               int             `json:"vcpu,omitempty"`
+	MemoryGiB          float64         `json:"memory_gib,omitempty"`
+	HourlyPrice        decimal.Decimal `json:"hourly_price"`
+	MonthlyPrice       decimal.Decimal `json:"monthly_price"` // HoursPerMonth hours
+	PowerWatts         float64         `json:"power_watts,omitempty"`
+	CarbonKgCO2PerHour float64         `json:"carbon_kg_co2_per_hour,omitempty"` // Zero when the hardware or intensity is unknown
+}
+
+// Listing is the instances of a region
+type Listing struct {
+	Cloud           string     `json:"cloud"`
+	Region          string     `json:"region"`
+	OperatingSystem string     `json:"operating_system"`
+	Currency        string     `json:"currency"`
+	SnapshotID      uuid.UUID  `json:"snapshot_id"`
+	CarbonIntensity float64    `json:"carbon_intensity_gco2_kwh,omitempty"`
+	Instances       []Instance `json:"instances"`
+}
+
+// Catalog lists instance prices from a pricing store
+type Catalog struct {
+	store    Store
+	hardware HardwareSource
+	carbon   carbon.CarbonStore
+}
+
+// New creates a catalog over a pricing store, with the embedded instance metadata
+func New(store Store) *Catalog {
+	return &Catalog{store: store, hardware: awsmeta.Embedded()}
+}
+
+// WithHardware replaces the instance metadata, e.g. with an enricher that also asks the EC2 API
+func (c *Catalog) WithHardware(source HardwareSource) *Catalog {
+	c.hardware = source
+	return c
+}
+
+// WithCarbonStore adds carbon per hour from a region's grid intensity
+func (c *Catalog) WithCarbonStore(store carbon.CarbonStore) *Catalog {
+	c.carbon = store
+	return c
+}
+
+// Instances lists the AWS instance types of a region; nil when no snapshot covers it
+func (c *Catalog) Instances(ctx context.Context, q Query) (*Listing, error) {
+	if q.OperatingSystem == "" {
+		q.OperatingSystem = "Linux"
+	}
+	snapshot, rates, err := c.store.ListInstanceRates(ctx, clickhouse.InstanceRateFilter{
+		Region:          q.Region,
+		Family:          strings.ToLower(q.Family),
+		OperatingSystem: q.OperatingSystem,
+	})
+	if err != nil || snapshot == nil {
+		return nil, err
+	}
+
+	listing := &Listing{
+		Cloud:           string(clickhouse.AWS),
+		Region:          q.Region,
+		OperatingSystem: q.OperatingSystem,
+		Currency:        "USD",
+		SnapshotID:      snapshot.ID,
+		Instances:       make([]Instance, 0, len(rates)),
+	}
+	if c.carbon != nil {
+		// Without an intensity the listing has prices only
+		if intensity, err := c.carbon.GetIntensity(ctx, listing.Cloud, q.Region); err == nil {
+			listing.CarbonIntensity = intensity
+		}
+	}
+
+	for _, rate := range rates {
+		if rate.Currency != "" {
+			listing.Currency = rate.Currency
+		}
+		inst := Instance{
+			InstanceType: rate.InstanceType,
+			HourlyPrice:  rate.Price,
+			MonthlyPrice: rate.Price.Mul(decimal.NewFromInt(HoursPerMonth)).Round(4),
+		}
+		// Instance types without metadata are listed with their price only
+		if c.hardware != nil {
+			if hw, err := c.hardware.InstanceType(ctx, q.Region, rate.InstanceType); err == nil && hw != nil {
+				inst.VCPU, inst.MemoryGiB = hw.VCPU, hw.MemoryGiB
+				powerKw := estimation.HardwarePowerKw(hw.Hardware())
+				inst.PowerWatts = powerKw * 1000
+				inst.CarbonKgCO2PerHour = powerKw * listing.CarbonIntensity / 1000
+			}
+		}
+		listing.Instances = append(listing.Instances, inst)
+	}
+	sortInstances(listing.Instances, q.SortBy)
+	return listing, nil
+}
+
+// sortInstances orders instances by a sort key, then by name
+func sortInstances(instances []Instance, by string) {
+	compare := func(a, b Instance) int {
+		switch by {
+		case "vcpu":
+			return a.VCPU - b.VCPU
+		case "memory":
+			return compareFloats(a.MemoryGiB, b.MemoryGiB)
+		case "carbon":
+			return compareFloats(a.CarbonKgCO2PerHour, b.CarbonKgCO2PerHour)
+		default:
+			return a.HourlyPrice.Cmp(b.HourlyPrice)
+		}
+	}
+	sort.SliceStable(instances, func(i, j int) bool {
+		if c := compare(instances[i], instances[j]); c != 0 {
+			return c < 0
+		}
+		return instances[i].InstanceType < instances[j].InstanceType
+	})
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,82 @@
+package catalog
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+)
+
+// rateStore serves fixed instance rates and records the filter it got
+type rateStore struct {
+	rates  []clickhouse.InstanceRate
+	filter clickhouse.InstanceRateFilter
+}
+
+func (s *rateStore) ListInstanceRates(ctx context.Context, filter clickhouse.InstanceRateFilter) (*clickhouse.PricingSnapshot, []clickhouse.InstanceRate, error) {
+	s.filter = filter
+	if filter.Region != "us-east-1" {
+		return nil, nil, nil
+	}
+	return &clickhouse.PricingSnapshot{ID: uuid.New()}, s.rates, nil
+}
+
+// flatIntensity is a grid intensity in gCO2/kWh
+type flatIntensity float64
+
+func (f flatIntensity) GetIntensity(ctx context.Context, cloud, region string) (float64, error) {
+	return float64(f), nil
+}
+
+func TestInstances(t *testing.T) {
+	store := &rateStore{rates: []clickhouse.InstanceRate{
+		{InstanceType: "m5.xlarge", Price: decimal.NewFromFloat(0.192), Currency: "USD"},
+		{InstanceType: "m5.large", Price: decimal.NewFromFloat(0.096), Currency: "USD"},
+		{InstanceType: "m5.custom", Price: decimal.NewFromFloat(0.05), Currency: "USD"},
+	}}
+	c := New(store).WithCarbonStore(flatIntensity(400))
+
+	listing, err := c.Instances(context.Background(), Query{Region: "us-east-1", Family: "M5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.filter.Family != "m5" || store.filter.OperatingSystem != "Linux" {
+		t.Errorf("filter = %+v, want family m5 on Linux", store.filter)
+	}
+	if len(listing.Instances) != 3 || listing.Instances[0].InstanceType != "m5.custom" || listing.Instances[1].InstanceType != "m5.large" {
+		t.Fatalf("instances = %+v, want cheapest first", listing.Instances)
+	}
+
+	large := listing.Instances[1]
+	if large.VCPU != 2 || large.MemoryGiB != 8 {
+		t.Errorf("m5.large hardware = %d vCPU %v GiB, want 2 and 8", large.VCPU, large.MemoryGiB)
+	}
+	if !large.MonthlyPrice.Equal(decimal.NewFromFloat(70.08)) {
+		t.Errorf("m5.large monthly = %s, want 70.08", large.MonthlyPrice)
+	}
+	// (2 × 2.12 W + 8 × 0.392 W) × 1.135 PUE at 400 gCO2/kWh
+	watts := (2*2.12 + 8*0.392) * 1.135
+	if math.Abs(large.PowerWatts-watts) > 1e-9 || math.Abs(large.CarbonKgCO2PerHour-watts*400/1e6) > 1e-12 {
+		t.Errorf("m5.large power = %v W, carbon = %v kg/h", large.PowerWatts, large.CarbonKgCO2PerHour)
+	}
+	// Unknown hardware is listed with its price only
+	if custom := listing.Instances[0]; custom.VCPU != 0 || custom.CarbonKgCO2PerHour != 0 {
+		t.Errorf("m5.custom = %+v, want no hardware or carbon", custom)
+	}
+
+	listing, err = c.Instances(context.Background(), Query{Region: "us-east-1", SortBy: "vcpu"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listing.Instances[0].InstanceType != "m5.custom" || listing.Instances[2].InstanceType != "m5.xlarge" {
+		t.Errorf("instances by vCPU = %+v", listing.Instances)
+	}
+
+	if listing, err := c.Instances(context.Background(), Query{Region: "mars-1"}); err != nil || listing != nil {
+		t.Errorf("uncovered region = %+v, %v; want nil", listing, err)
+	}
+}
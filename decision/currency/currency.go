@@ -0,0 +1,241 @@
+// Package currency converts prices between currencies
+// Exchange rates come from a static FX file or the ECB daily reference rates.
+package currency
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+)
+
+// USD is the currency cloud price lists are published in
+const USD = "USD"
+
+// ECBDailyURL is the European Central Bank daily reference rate feed (EUR base)
+const ECBDailyURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+
+// SourceECB selects the ECB feed wherever an FX source is configured
+const SourceECB = "ecb"
+
+// Table holds exchange rates relative to a base currency
+type Table struct {
+	Base   string
+	Rates  map[string]decimal.Decimal // units of currency per 1 base
+	AsOf   time.Time
+	Source string
+}
+
+// tableFile is the on-disk FX table
+//
+//	base: USD
+//	as_of: 2024-06-03
+//	rates:
+//	  EUR: 0.92
+//	  GBP: 0.79
+type tableFile struct {
+	Base  string             `yaml:"base" json:"base"`
+	AsOf  string             `yaml:"as_of,omitempty" json:"as_of,omitempty"`
+	Rates map[string]float64 `yaml:"rates" json:"rates"`
+}
+
+// Normalize returns the canonical form of a currency code (default USD)
+func Normalize(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return USD
+	}
+	return code
+}
+
+// Load returns an FX table from SourceECB or a YAML/JSON file path
+func Load(ctx context.Context, source string) (*Table, error) {
+	if strings.EqualFold(source, SourceECB) {
+		return NewECBFetcher().Fetch(ctx)
+	}
+	return LoadFile(source)
+}
+
+// LoadFile reads an FX table from a YAML or JSON file
+func LoadFile(path string) (*Table, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read FX rates: %w", err)
+	}
+	t, err := ParseFile(data)
+	if err != nil {
+		return nil, err
+	}
+	t.Source = path
+	return t, nil
+}
+
+// ParseFile parses YAML or JSON FX table content
+func ParseFile(data []byte) (*Table, error) {
+	var f tableFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse FX rates: %w", err)
+	}
+	if f.Base == "" {
+		return nil, fmt.Errorf("FX rates: base currency is required")
+	}
+
+	t := &Table{Base: Normalize(f.Base), Rates: make(map[string]decimal.Decimal, len(f.Rates))}
+	for code, rate := range f.Rates {
+		if rate <= 0 {
+			return nil, fmt.Errorf("FX rates: %s rate must be positive", code)
+		}
+		t.Rates[Normalize(code)] = decimal.NewFromFloat(rate)
+	}
+	if f.AsOf != "" {
+		asOf, err := time.Parse("2006-01-02", f.AsOf)
+		if err != nil {
+			return nil, fmt.Errorf("FX rates: invalid as_of %q: %w", f.AsOf, err)
+		}
+		t.AsOf = asOf
+	}
+	return t, nil
+}
+
+// rate returns units of code per 1 base
+func (t *Table) rate(code string) (decimal.Decimal, bool) {
+	if code == t.Base {
+		return decimal.NewFromInt(1), true
+	}
+	r, ok := t.Rates[code]
+	return r, ok
+}
+
+// Convert converts an amount between currencies through the table's base
+func (t *Table) Convert(amount decimal.Decimal, from, to string) (decimal.Decimal, error) {
+	from, to = Normalize(from), Normalize(to)
+	if from == to {
+		return amount, nil
+	}
+	if t == nil {
+		return decimal.Zero, fmt.Errorf("no exchange rates configured for %s → %s", from, to)
+	}
+	fromRate, ok := t.rate(from)
+	if !ok {
+		return decimal.Zero, fmt.Errorf("no exchange rate for %s", from)
+	}
+	toRate, ok := t.rate(to)
+	if !ok {
+		return decimal.Zero, fmt.Errorf("no exchange rate for %s", to)
+	}
+	return amount.Div(fromRate).Mul(toRate), nil
+}
+
+// Supports reports whether the table can convert to or from a currency
+func (t *Table) Supports(code string) bool {
+	if t == nil {
+		return Normalize(code) == USD
+	}
+	_, ok := t.rate(Normalize(code))
+	return ok
+}
+
+// =============================================================================
+// FORMATTING
+// =============================================================================
+
+var symbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+	"INR": "₹",
+}
+
+// Symbol returns the display prefix for a currency ("$", "€", or "CHF ")
+func Symbol(code string) string {
+	code = Normalize(code)
+	if s, ok := symbols[code]; ok {
+		return s
+	}
+	return code + " "
+}
+
+// Format renders an amount with its currency symbol
+func Format(amount decimal.Decimal, code string, places int32) string {
+	return Symbol(code) + amount.StringFixed(places)
+}
+
+// =============================================================================
+// ECB FETCHER
+// =============================================================================
+
+// ECBFetcher downloads the ECB daily reference rates
+type ECBFetcher struct {
+	client *http.Client
+	url    string
+}
+
+// NewECBFetcher creates a fetcher for the ECB daily feed
+func NewECBFetcher() *ECBFetcher {
+	return &ECBFetcher{
+		client: &http.Client{Timeout: 30 * time.Second},
+		url:    ECBDailyURL,
+	}
+}
+
+// WithURL overrides the feed URL (mirrors, tests)
+func (f *ECBFetcher) WithURL(url string) *ECBFetcher {
+	f.url = url
+	return f
+}
+
+type ecbEnvelope struct {
+	Cube struct {
+		Cube struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string `xml:"currency,attr"`
+				Rate     string `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+// Fetch downloads and parses the latest reference rates
+func (f *ECBFetcher) Fetch(ctx context.Context) (*Table, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch ECB rates: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ECB rates returned HTTP %d", resp.StatusCode)
+	}
+
+	var env ecbEnvelope
+	if err := xml.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return nil, fmt.Errorf("failed to parse ECB rates: %w", err)
+	}
+
+	t := &Table{Base: "EUR", Rates: make(map[string]decimal.Decimal), Source: SourceECB}
+	for _, r := range env.Cube.Cube.Rates {
+		rate, err := decimal.NewFromString(r.Rate)
+		if err != nil || !rate.IsPositive() {
+			return nil, fmt.Errorf("ECB rates: invalid %s rate %q", r.Currency, r.Rate)
+		}
+		t.Rates[Normalize(r.Currency)] = rate
+	}
+	if len(t.Rates) == 0 {
+		return nil, fmt.Errorf("ECB rates: feed contained no rates")
+	}
+	if asOf, err := time.Parse("2006-01-02", env.Cube.Cube.Time); err == nil {
+		t.AsOf = asOf
+	}
+	return t, nil
+}
@@ -0,0 +1,72 @@
+package currency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestConvert(t *testing.T) {
+	table, err := ParseFile([]byte("base: USD\nrates:\n  EUR: 0.9\n  gbp: 0.8\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		from, to string
+		want     string
+	}{
+		{"USD", "EUR", "90"},
+		{"EUR", "USD", "100"},
+		{"EUR", "GBP", "80"},
+		{"", "usd", "100"},
+	}
+	for _, tt := range tests {
+		amount := decimal.NewFromInt(100)
+		if tt.from == "EUR" {
+			amount = decimal.NewFromInt(90)
+		}
+		got, err := table.Convert(amount, tt.from, tt.to)
+		if err != nil {
+			t.Fatalf("%s→%s: %v", tt.from, tt.to, err)
+		}
+		if !got.Round(6).Equal(decimal.RequireFromString(tt.want)) {
+			t.Errorf("%s→%s: got %s, want %s", tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	if _, err := table.Convert(decimal.NewFromInt(1), "USD", "CHF"); err == nil {
+		t.Error("expected an error for an unknown currency")
+	}
+	if Format(decimal.NewFromInt(5), "chf", 2) != "CHF 5.00" || Format(decimal.NewFromInt(5), "EUR", 2) != "€5.00" {
+		t.Error("unexpected formatting")
+	}
+}
+
+func TestECBFetcher(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<Cube><Cube time="2024-06-03">
+		<Cube currency="USD" rate="1.0850"/>
+		<Cube currency="GBP" rate="0.8500"/>
+	</Cube></Cube>
+</gesmes:Envelope>`))
+	}))
+	defer srv.Close()
+
+	table, err := NewECBFetcher().WithURL(srv.URL).Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Base != "EUR" || table.AsOf.Format("2006-01-02") != "2024-06-03" || len(table.Rates) != 2 {
+		t.Fatalf("unexpected table %+v", table)
+	}
+	got, _ := table.Convert(decimal.RequireFromString("108.50"), "USD", "EUR")
+	if !got.Round(4).Equal(decimal.NewFromInt(100)) {
+		t.Errorf("USD→EUR: got %s", got)
+	}
+}
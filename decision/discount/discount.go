@@ -0,0 +1,175 @@
+// Package discount applies negotiated pricing on top of list-price estimates
+// Enterprise agreements (EDP, private rate cards) take a percentage off list
+// prices, account-wide or per service. Discounts come from a YAML/JSON file or
+// an org's stored discounts. The most specific discount covering a cost driver
+// applies: a service's private rate replaces an account-wide discount rather
+// than stacking on it, so a rate negotiated on top of an EDP is entered as the
+// combined percentage.
+package discount
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+
+	"terraform-cost/db/clickhouse"
+)
+
+// SourceDatabase is the Source of sets read from stored discounts
+const SourceDatabase = "database"
+
+// Set is the discounts an estimate applies
+type Set struct {
+	Discounts []*clickhouse.Discount
+	Source    string // File path or SourceDatabase
+}
+
+// Provider resolves the discounts of an estimate; a Set provides itself
+type Provider interface {
+	Resolve(ctx context.Context) (*Set, error)
+}
+
+// Store is the discount storage a stored Provider reads
+type Store interface {
+	ListDiscounts(ctx context.Context) ([]*clickhouse.Discount, error)
+}
+
+// setFile is the on-disk discount set
+//
+//	discounts:
+//	  - name: EDP
+//	    percent: 8
+//	  - name: EC2 private rate card
+//	    cloud: aws
+//	    service: AmazonEC2
+//	    percent: 22
+type setFile struct {
+	Discounts []discountFile `yaml:"discounts" json:"discounts"`
+}
+
+type discountFile struct {
+	Name          string  `yaml:"name" json:"name"`
+	Cloud         string  `yaml:"cloud,omitempty" json:"cloud,omitempty"`
+	Service       string  `yaml:"service,omitempty" json:"service,omitempty"`
+	ProductFamily string  `yaml:"product_family,omitempty" json:"product_family,omitempty"`
+	Region        string  `yaml:"region,omitempty" json:"region,omitempty"`
+	Percent       float64 `yaml:"percent" json:"percent"`
+}
+
+// Load returns the discounts of SourceDatabase, read from a store for each
+// estimate, or of a YAML/JSON file path
+func Load(source string, store Store) (Provider, error) {
+	if strings.EqualFold(source, SourceDatabase) {
+		if store == nil {
+			return nil, fmt.Errorf("stored discounts need the ClickHouse pricing backend")
+		}
+		return FromStore(store), nil
+	}
+	return LoadFile(source)
+}
+
+// LoadFile reads a discount set from a YAML or JSON file
+func LoadFile(path string) (*Set, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read discounts: %w", err)
+	}
+	s, err := ParseFile(data)
+	if err != nil {
+		return nil, err
+	}
+	s.Source = path
+	return s, nil
+}
+
+// ParseFile parses YAML or JSON discount set content
+func ParseFile(data []byte) (*Set, error) {
+	var f setFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse discounts: %w", err)
+	}
+	s := &Set{Discounts: make([]*clickhouse.Discount, 0, len(f.Discounts))}
+	for _, d := range f.Discounts {
+		discount := &clickhouse.Discount{
+			Name:          d.Name,
+			Cloud:         d.Cloud,
+			Service:       d.Service,
+			ProductFamily: d.ProductFamily,
+			Region:        d.Region,
+			Percent:       d.Percent,
+		}
+		if err := discount.Validate(); err != nil {
+			return nil, err
+		}
+		s.Discounts = append(s.Discounts, discount)
+	}
+	return s, nil
+}
+
+// Resolve returns the set itself
+func (s *Set) Resolve(ctx context.Context) (*Set, error) {
+	return s, nil
+}
+
+// Match returns the most specific discount covering a cost driver, or nil
+// Specificity is the number of scope fields set; ties go to the discount listed first.
+func (s *Set) Match(cloud, service, productFamily, region string) *clickhouse.Discount {
+	if s == nil {
+		return nil
+	}
+	var best *clickhouse.Discount
+	bestScore := -1
+	for _, d := range s.Discounts {
+		if !matches(d.Cloud, cloud) || !matches(d.Service, service) ||
+			!matches(d.ProductFamily, productFamily) || !matches(d.Region, region) {
+			continue
+		}
+		if score := specificity(d); score > bestScore {
+			best, bestScore = d, score
+		}
+	}
+	return best
+}
+
+// matches reports whether a scope field covers a value; empty covers everything
+func matches(scope, value string) bool {
+	return scope == "" || strings.EqualFold(scope, value)
+}
+
+// specificity counts a discount's scope fields
+func specificity(d *clickhouse.Discount) int {
+	n := 0
+	for _, scope := range []string{d.Cloud, d.Service, d.ProductFamily, d.Region} {
+		if scope != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// Net takes a discount percentage off a list amount
+func Net(list decimal.Decimal, percent float64) decimal.Decimal {
+	return list.Mul(decimal.NewFromInt(1).Sub(decimal.NewFromFloat(percent).Div(decimal.NewFromInt(100))))
+}
+
+// FromStore returns a Provider of the stored discounts of the estimate context's org
+func FromStore(store Store) Provider {
+	return storeProvider{store: store}
+}
+
+type storeProvider struct {
+	store Store
+}
+
+// Resolve lists the context org's discounts
+func (p storeProvider) Resolve(ctx context.Context) (*Set, error) {
+	discounts, err := p.store.ListDiscounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Set{Discounts: discounts, Source: SourceDatabase}, nil
+}
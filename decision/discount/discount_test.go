@@ -0,0 +1,67 @@
+package discount
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestParseFile(t *testing.T) {
+	set, err := ParseFile([]byte(`discounts:
+  - name: EDP
+    percent: 8
+  - name: EC2 private rates
+    cloud: AWS
+    service: AmazonEC2
+    percent: 22
+  - name: EC2 Frankfurt
+    cloud: aws
+    service: AmazonEC2
+    region: eu-central-1
+    percent: 30
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set.Discounts) != 3 || set.Discounts[1].Cloud != "aws" {
+		t.Fatalf("discounts = %+v", set.Discounts)
+	}
+
+	tests := []struct {
+		service, region string
+		want            string
+	}{
+		{"AmazonEC2", "eu-central-1", "EC2 Frankfurt"},
+		{"AmazonEC2", "us-east-1", "EC2 private rates"},
+		{"amazonec2", "us-east-1", "EC2 private rates"},
+		{"AmazonS3", "eu-central-1", "EDP"},
+	}
+	for _, tt := range tests {
+		got := set.Match("aws", tt.service, "Compute Instance", tt.region)
+		if got == nil || got.Name != tt.want {
+			t.Errorf("Match(%s, %s) = %+v, want %s", tt.service, tt.region, got, tt.want)
+		}
+	}
+	if set.Match("gcp", "Compute Engine", "", "europe-west1").Name != "EDP" {
+		t.Error("the EDP should cover every cloud")
+	}
+	if (*Set)(nil).Match("aws", "AmazonEC2", "", "us-east-1") != nil {
+		t.Error("a nil set should match nothing")
+	}
+
+	for _, bad := range []string{
+		"discounts:\n  - percent: 10\n",
+		"discounts:\n  - name: free\n    percent: 0\n",
+		"discounts:\n  - name: paid\n    percent: 120\n",
+	} {
+		if _, err := ParseFile([]byte(bad)); err == nil {
+			t.Errorf("ParseFile(%q): want error", bad)
+		}
+	}
+}
+
+func TestNet(t *testing.T) {
+	if got := Net(decimal.NewFromInt(200), 12.5); !got.Equal(decimal.NewFromInt(175)) {
+		t.Errorf("Net = %s, want 175", got)
+	}
+}
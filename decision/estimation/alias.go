@@ -0,0 +1,114 @@
+// Package estimation - Pricing alias routing
+// Rates resolve against the snapshots of a pricing alias. Partitions with
+// their own price lists (AWS GovCloud and China, Azure Government and China)
+// and environments priced from separate snapshots are routed to their alias:
+//
+//	--pricing-alias prod-govcloud=aws-us-gov --pricing-alias partition:aws-cn=aws-cn
+package estimation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultPricingAlias is the alias of snapshots ingested without one
+const DefaultPricingAlias = "default"
+
+// AliasRoutes maps environments and provider partitions to pricing aliases
+type AliasRoutes struct {
+	Default      string            `json:"default,omitempty"`      // Alias of everything not routed
+	Environments map[string]string `json:"environments,omitempty"` // environment -> alias
+	Partitions   map[string]string `json:"partitions,omitempty"`   // partition (see Partition) -> alias
+}
+
+// ParseAliasRoutes parses --pricing-alias entries: a bare alias is the default,
+// <environment>=<alias> routes an environment and partition:<partition>=<alias>
+// a provider partition
+func ParseAliasRoutes(entries []string) (*AliasRoutes, error) {
+	routes := &AliasRoutes{Environments: map[string]string{}, Partitions: map[string]string{}}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		key, alias, routed := strings.Cut(entry, "=")
+		key, alias = strings.TrimSpace(key), strings.TrimSpace(alias)
+		switch {
+		case !routed:
+			if entry == "" {
+				return nil, fmt.Errorf("empty pricing alias")
+			}
+			if routes.Default != "" && routes.Default != entry {
+				return nil, fmt.Errorf("pricing alias set twice: %s and %s", routes.Default, entry)
+			}
+			routes.Default = entry
+		case key == "" || alias == "":
+			return nil, fmt.Errorf("invalid pricing alias route %q: expected <environment>=<alias> or partition:<partition>=<alias>", entry)
+		case strings.HasPrefix(key, "partition:"):
+			partition := strings.TrimPrefix(key, "partition:")
+			if !knownPartitions[partition] {
+				return nil, fmt.Errorf("unknown partition %q in pricing alias route %q", partition, entry)
+			}
+			routes.Partitions[partition] = alias
+		default:
+			routes.Environments[key] = alias
+		}
+	}
+	return routes, nil
+}
+
+// Resolve returns the alias a component's rates resolve under: an explicit
+// alias, else its partition's route, its environment's route and the default
+func (r *AliasRoutes) Resolve(alias, env, cloud, region string) string {
+	if alias != "" {
+		return alias
+	}
+	if r != nil {
+		if routed := r.Partitions[Partition(cloud, region)]; routed != "" {
+			return routed
+		}
+		if routed := r.Environments[env]; routed != "" {
+			return routed
+		}
+		if r.Default != "" {
+			return r.Default
+		}
+	}
+	return DefaultPricingAlias
+}
+
+var knownPartitions = map[string]bool{
+	"aws": true, "aws-us-gov": true, "aws-cn": true, "aws-iso": true, "aws-iso-b": true,
+	"azure": true, "azure-us-gov": true, "azure-china": true,
+	"gcp": true,
+}
+
+// Partition returns the provider partition of a region, e.g. aws-us-gov for
+// us-gov-west-1; empty for unknown clouds and regionless components
+func Partition(cloud, region string) string {
+	if region == "" {
+		return ""
+	}
+	switch cloud {
+	case "aws":
+		switch {
+		case strings.HasPrefix(region, "us-gov-"):
+			return "aws-us-gov"
+		case strings.HasPrefix(region, "cn-"):
+			return "aws-cn"
+		case strings.HasPrefix(region, "us-isob-"):
+			return "aws-iso-b"
+		case strings.HasPrefix(region, "us-iso-"):
+			return "aws-iso"
+		}
+		return "aws"
+	case "azure":
+		switch {
+		case strings.HasPrefix(region, "usgov"), strings.HasPrefix(region, "usdod"):
+			return "azure-us-gov"
+		case strings.HasPrefix(region, "china"):
+			return "azure-china"
+		}
+		return "azure"
+	case "gcp":
+		return "gcp"
+	}
+	return ""
+}
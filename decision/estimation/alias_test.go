@@ -0,0 +1,54 @@
+package estimation
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAliasRoutesResolve(t *testing.T) {
+	routes, err := ParseAliasRoutes([]string{"negotiated", "prod-govcloud=aws-us-gov", "partition:aws-cn=aws-cn"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		alias, env, cloud, region string
+		want                      string
+	}{
+		{"", "prod", "aws", "us-east-1", "negotiated"},
+		{"", "prod-govcloud", "aws", "us-gov-west-1", "aws-us-gov"},
+		{"", "prod", "aws", "cn-north-1", "aws-cn"},
+		{"", "prod-govcloud", "aws", "cn-northwest-1", "aws-cn"}, // The partition is more specific
+		{"pinned", "prod-govcloud", "aws", "cn-north-1", "pinned"},
+	}
+	for _, tt := range tests {
+		if got := routes.Resolve(tt.alias, tt.env, tt.cloud, tt.region); got != tt.want {
+			t.Errorf("Resolve(%q, %q, %s/%s) = %s, want %s", tt.alias, tt.env, tt.cloud, tt.region, got, tt.want)
+		}
+	}
+	var none *AliasRoutes
+	if got := none.Resolve("", "prod", "aws", "us-gov-east-1"); got != DefaultPricingAlias {
+		t.Errorf("nil routes resolved %s", got)
+	}
+}
+
+func TestParseAliasRoutesRejectsUnknownPartitions(t *testing.T) {
+	for _, entries := range [][]string{{"partition:aws-gov=aws-us-gov"}, {"prod="}, {"a", "b"}} {
+		if _, err := ParseAliasRoutes(entries); err == nil {
+			t.Errorf("%s: expected an error", strings.Join(entries, " "))
+		}
+	}
+}
+
+func TestPartition(t *testing.T) {
+	for region, want := range map[string]string{
+		"us-gov-west-1": "aws-us-gov", "cn-north-1": "aws-cn", "us-iso-east-1": "aws-iso",
+		"us-isob-east-1": "aws-iso-b", "eu-west-1": "aws",
+	} {
+		if got := Partition("aws", region); got != want {
+			t.Errorf("Partition(aws, %s) = %s, want %s", region, got, want)
+		}
+	}
+	if got := Partition("azure", "usgovvirginia"); got != "azure-us-gov" {
+		t.Errorf("Partition(azure, usgovvirginia) = %s", got)
+	}
+}
@@ -0,0 +1,59 @@
+// Package estimation - Tag-based cost allocation
+// Totals monthly P50 cost per resource tag value (team, cost-center, ...)
+package estimation
+
+import (
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultAllocationTags are the tag keys costs are allocated by
+var DefaultAllocationTags = []string{"team", "cost-center", "project"}
+
+// UntaggedValue collects cost from resources missing an allocation tag
+const UntaggedValue = "(untagged)"
+
+// AllocateByTag totals P50 cost per value of each tag key
+// Keys no resource carries are omitted; cost without the tag is UntaggedValue.
+func AllocateByTag(drivers []CostDriver, keys []string) map[string]map[string]decimal.Decimal {
+	allocation := make(map[string]map[string]decimal.Decimal)
+	for _, key := range keys {
+		byValue := make(map[string]decimal.Decimal)
+		tagged := false
+		for _, d := range drivers {
+			value, ok := d.ResourceTags[key]
+			if ok && value != "" {
+				tagged = true
+			} else {
+				value = UntaggedValue
+			}
+			byValue[value] = byValue[value].Add(d.MonthlyCostP50)
+		}
+		if tagged {
+			allocation[key] = byValue
+		}
+	}
+	return allocation
+}
+
+// TagCost is one tag value's share of the total
+type TagCost struct {
+	Value          string
+	MonthlyCostP50 decimal.Decimal
+}
+
+// SortedTagCosts returns a tag key's allocation ordered by cost, highest first
+func SortedTagCosts(byValue map[string]decimal.Decimal) []TagCost {
+	costs := make([]TagCost, 0, len(byValue))
+	for v, c := range byValue {
+		costs = append(costs, TagCost{Value: v, MonthlyCostP50: c})
+	}
+	sort.Slice(costs, func(i, j int) bool {
+		if !costs[i].MonthlyCostP50.Equal(costs[j].MonthlyCostP50) {
+			return costs[i].MonthlyCostP50.GreaterThan(costs[j].MonthlyCostP50)
+		}
+		return costs[i].Value < costs[j].Value
+	})
+	return costs
+}
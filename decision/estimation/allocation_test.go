@@ -0,0 +1,41 @@
+package estimation
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestAllocateByTag(t *testing.T) {
+	drivers := []CostDriver{
+		{ResourceTags: map[string]string{"team": "payments", "project": "checkout"}, MonthlyCostP50: decimal.NewFromInt(100)},
+		{ResourceTags: map[string]string{"team": "payments"}, MonthlyCostP50: decimal.NewFromInt(50)},
+		{ResourceTags: map[string]string{"team": "web"}, MonthlyCostP50: decimal.NewFromInt(30)},
+		{MonthlyCostP50: decimal.NewFromInt(20)},
+	}
+
+	got := AllocateByTag(drivers, DefaultAllocationTags)
+
+	if _, ok := got["cost-center"]; ok {
+		t.Error("cost-center has no tagged resources and should be omitted")
+	}
+	want := map[string]map[string]int64{
+		"team":    {"payments": 150, "web": 30, UntaggedValue: 20},
+		"project": {"checkout": 100, UntaggedValue: 100},
+	}
+	for key, values := range want {
+		if len(got[key]) != len(values) {
+			t.Errorf("%s: got %v", key, got[key])
+		}
+		for value, cost := range values {
+			if !got[key][value].Equal(decimal.NewFromInt(cost)) {
+				t.Errorf("%s=%s: got %s, want %d", key, value, got[key][value], cost)
+			}
+		}
+	}
+
+	sorted := SortedTagCosts(got["team"])
+	if sorted[0].Value != "payments" || sorted[len(sorted)-1].Value != UntaggedValue {
+		t.Errorf("unexpected order %v", sorted)
+	}
+}
@@ -0,0 +1,111 @@
+// Package estimation - Confidence model
+// Confidence is weighted by cost so a cheap, poorly understood resource
+// doesn't drag down an estimate dominated by well-known costs.
+package estimation
+
+// ConfidenceScores breaks an estimate's confidence into independent dimensions
+type ConfidenceScores struct {
+	Pricing  float64 `json:"pricing"`  // cost-weighted confidence in the resolved prices
+	Usage    float64 `json:"usage"`    // cost-weighted confidence in the usage assumptions
+	Coverage float64 `json:"coverage"` // share of billable components that could be priced
+}
+
+// Confidence dimensions policies can target
+const (
+	ConfidenceOverall  = "overall"
+	ConfidencePricing  = "pricing"
+	ConfidenceUsage    = "usage"
+	ConfidenceCoverage = "coverage"
+)
+
+// Dimension returns one score by name; overall is the combined confidence
+func (s ConfidenceScores) Dimension(name string, overall float64) (float64, bool) {
+	switch name {
+	case "", ConfidenceOverall:
+		return overall, true
+	case ConfidencePricing:
+		return s.Pricing, true
+	case ConfidenceUsage:
+		return s.Usage, true
+	case ConfidenceCoverage:
+		return s.Coverage, true
+	}
+	return 0, false
+}
+
+// ScoreConfidence returns the overall confidence and its dimensions
+//
+// Pricing and usage confidence are averaged over priced drivers weighted by
+// monthly P50 (evenly when all are free). Coverage is the share of
+// components that were priced, counting unmapped resources as unpriced.
+// The overall score is the weighted driver confidence scaled by coverage.
+func ScoreConfidence(drivers []CostDriver, unmapped int) (float64, ConfidenceScores) {
+	total := len(drivers) + unmapped
+	if total == 0 {
+		return 1, ConfidenceScores{Pricing: 1, Usage: 1, Coverage: 1}
+	}
+
+	priced := 0
+	for _, d := range drivers {
+		if !d.IsSymbolic {
+			priced++
+		}
+	}
+
+	var weight, pricing, usage, overall float64
+	for i, w := range confidenceWeights(drivers) {
+		if w == 0 {
+			continue
+		}
+		d := drivers[i]
+		weight += w
+		pricing += w * d.PricingConfidence
+		usage += w * d.UsageConfidence
+		overall += w * d.Confidence
+	}
+
+	scores := ConfidenceScores{Coverage: float64(priced) / float64(total)}
+	if weight == 0 {
+		return 0, scores
+	}
+	scores.Pricing = pricing / weight
+	scores.Usage = usage / weight
+	return overall / weight * scores.Coverage, scores
+}
+
+// ConfidenceWeights returns each driver's share of the weight ScoreConfidence
+// gives it; symbolic and free drivers carry none unless every priced driver is free
+func ConfidenceWeights(drivers []CostDriver) []float64 {
+	weights := confidenceWeights(drivers)
+	var total float64
+	for _, w := range weights {
+		total += w
+	}
+	if total > 0 {
+		for i := range weights {
+			weights[i] /= total
+		}
+	}
+	return weights
+}
+
+// confidenceWeights weights priced drivers by monthly P50, or evenly when all are free
+func confidenceWeights(drivers []CostDriver) []float64 {
+	evenly := true
+	for _, d := range drivers {
+		if !d.IsSymbolic && d.MonthlyCostP50.IsPositive() {
+			evenly = false
+		}
+	}
+	weights := make([]float64, len(drivers))
+	for i, d := range drivers {
+		switch {
+		case d.IsSymbolic:
+		case evenly:
+			weights[i] = 1
+		case d.MonthlyCostP50.IsPositive():
+			weights[i] = d.MonthlyCostP50.InexactFloat64()
+		}
+	}
+	return weights
+}
@@ -0,0 +1,64 @@
+package estimation
+
+import (
+	"math"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestScoreConfidence(t *testing.T) {
+	driver := func(cost int64, pricing, usage float64) CostDriver {
+		return CostDriver{
+			MonthlyCostP50:    decimal.NewFromInt(cost),
+			PricingConfidence: pricing,
+			UsageConfidence:   usage,
+			Confidence:        math.Min(pricing, usage),
+		}
+	}
+
+	tests := []struct {
+		name     string
+		drivers  []CostDriver
+		unmapped int
+		overall  float64
+		scores   ConfidenceScores
+	}{
+		{
+			name:    "empty",
+			overall: 1,
+			scores:  ConfidenceScores{Pricing: 1, Usage: 1, Coverage: 1},
+		},
+		{
+			// A cheap, uncertain bucket barely moves a $900 database
+			name:    "weighted by cost",
+			drivers: []CostDriver{driver(900, 1, 0.9), driver(100, 1, 0.4)},
+			overall: 0.85,
+			scores:  ConfidenceScores{Pricing: 1, Usage: 0.85, Coverage: 1},
+		},
+		{
+			name:     "symbolic and unmapped reduce coverage",
+			drivers:  []CostDriver{driver(100, 0.8, 1), {IsSymbolic: true}},
+			unmapped: 2,
+			overall:  0.2,
+			scores:   ConfidenceScores{Pricing: 0.8, Usage: 1, Coverage: 0.25},
+		},
+		{
+			name:    "free drivers weigh evenly",
+			drivers: []CostDriver{driver(0, 1, 1), driver(0, 0.5, 0.5)},
+			overall: 0.75,
+			scores:  ConfidenceScores{Pricing: 0.75, Usage: 0.75, Coverage: 1},
+		},
+	}
+
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overall, scores := ScoreConfidence(tt.drivers, tt.unmapped)
+			if !near(overall, tt.overall) || !near(scores.Pricing, tt.scores.Pricing) ||
+				!near(scores.Usage, tt.scores.Usage) || !near(scores.Coverage, tt.scores.Coverage) {
+				t.Errorf("got %.3f %+v, want %.3f %+v", overall, scores, tt.overall, tt.scores)
+			}
+		})
+	}
+}
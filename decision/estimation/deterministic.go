@@ -0,0 +1,47 @@
+// Package estimation - Deterministic results
+// Golden-file tests compare estimates byte for byte, so deterministic results
+// pin their timestamps and break every ordering tie.
+package estimation
+
+import (
+	"sort"
+	"time"
+)
+
+// DeterministicTime is the estimation time of deterministic results
+var DeterministicTime = time.Unix(0, 0).UTC()
+
+// MakeDeterministic pins a result's timestamps and orders its arrays stably,
+// so the same plan, usage and pricing always give identical output
+func (r *EstimationResult) MakeDeterministic() {
+	r.AuditTrail.EstimatedAt = DeterministicTime
+
+	// Highest cost first, as estimated; ties by address and driver ID
+	sort.SliceStable(r.CostDrivers, func(i, j int) bool {
+		di, dj := r.CostDrivers[i], r.CostDrivers[j]
+		if !di.MonthlyCostP50.Equal(dj.MonthlyCostP50) {
+			return di.MonthlyCostP50.GreaterThan(dj.MonthlyCostP50)
+		}
+		if di.ResourceAddr != dj.ResourceAddr {
+			return di.ResourceAddr < dj.ResourceAddr
+		}
+		return di.ID < dj.ID
+	})
+	sort.SliceStable(r.CostGroups, func(i, j int) bool {
+		gi, gj := r.CostGroups[i], r.CostGroups[j]
+		if !gi.MonthlyCostP50.Equal(gj.MonthlyCostP50) {
+			return gi.MonthlyCostP50.GreaterThan(gj.MonthlyCostP50)
+		}
+		if gi.Key != gj.Key {
+			return gi.Key < gj.Key
+		}
+		return gi.Service < gj.Service
+	})
+	sort.SliceStable(r.Errors, func(i, j int) bool {
+		if r.Errors[i].ResourceAddr != r.Errors[j].ResourceAddr {
+			return r.Errors[i].ResourceAddr < r.Errors[j].ResourceAddr
+		}
+		return r.Errors[i].ComponentID < r.Errors[j].ComponentID
+	})
+	sort.Strings(r.Warnings)
+}
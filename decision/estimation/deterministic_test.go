@@ -0,0 +1,49 @@
+package estimation
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestMakeDeterministic(t *testing.T) {
+	driver := func(id, addr, cost string) CostDriver {
+		return CostDriver{ID: id, ComponentID: strings.TrimPrefix(id, "driver-"), ResourceAddr: addr, MonthlyCostP50: decimal.RequireFromString(cost)}
+	}
+	result := &EstimationResult{
+		AuditTrail: AuditTrail{EstimatedAt: time.Now()},
+		CostDrivers: []CostDriver{
+			driver("driver-b-compute", "b", "0"),
+			driver("driver-a-storage", "a", "0"),
+			driver("driver-c-compute", "c", "5"),
+			driver("driver-a-compute", "a", "0"),
+		},
+		Warnings: []string{"z", "a"},
+	}
+	result.CostGroups = GroupCostDrivers(result.CostDrivers)
+	result.MakeDeterministic()
+
+	if !result.AuditTrail.EstimatedAt.Equal(DeterministicTime) {
+		t.Errorf("estimated at = %v", result.AuditTrail.EstimatedAt)
+	}
+	var ids []string
+	for _, d := range result.CostDrivers {
+		ids = append(ids, d.ID)
+	}
+	if want := []string{"driver-c-compute", "driver-a-compute", "driver-a-storage", "driver-b-compute"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("drivers = %v, want %v", ids, want)
+	}
+	var keys []string
+	for _, g := range result.CostGroups {
+		keys = append(keys, g.Key)
+	}
+	if want := []string{"c-compute", "a-compute", "a-storage", "b-compute"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("groups = %v, want %v", keys, want)
+	}
+	if !reflect.DeepEqual(result.Warnings, []string{"a", "z"}) {
+		t.Errorf("warnings = %v", result.Warnings)
+	}
+}
@@ -0,0 +1,67 @@
+// Package estimation - Negotiated discounts
+// Drivers are priced at list price, then the most specific negotiated discount
+// covering each one is taken off. A discounted driver keeps its list price and
+// cost next to the net ones, and the estimate its list-price totals.
+package estimation
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/decision/currency"
+	"terraform-cost/decision/discount"
+)
+
+// DriverDiscount is the negotiated discount of a cost driver
+type DriverDiscount struct {
+	Name               string          `json:"name"`
+	Percent            float64         `json:"percent"`
+	ListUnitPrice      decimal.Decimal `json:"list_unit_price"`
+	ListMonthlyCostP50 decimal.Decimal `json:"list_monthly_cost_p50"`
+	ListMonthlyCostP90 decimal.Decimal `json:"list_monthly_cost_p90"`
+}
+
+// DiscountSummary is an estimate's totals at list price
+type DiscountSummary struct {
+	Source             string          `json:"source"` // Discount file path, or "database"
+	ListMonthlyCostP50 decimal.Decimal `json:"list_monthly_cost_p50"`
+	ListMonthlyCostP90 decimal.Decimal `json:"list_monthly_cost_p90"`
+	SavingsP50         decimal.Decimal `json:"savings_p50"`
+}
+
+// ListMonthlyCost is the driver's monthly cost before any negotiated discount
+func (d CostDriver) ListMonthlyCost() (p50, p90 decimal.Decimal) {
+	if d.Discount != nil {
+		return d.Discount.ListMonthlyCostP50, d.Discount.ListMonthlyCostP90
+	}
+	return d.MonthlyCostP50, d.MonthlyCostP90
+}
+
+// applyDiscount takes the matching discount off a priced driver
+// Placeholder costs are assumptions rather than list prices and are left as is.
+func applyDiscount(driver CostDriver, discounts *discount.Set, includeFormulas bool) CostDriver {
+	if driver.IsSymbolic || driver.Source == "placeholder" || (driver.MonthlyCostP50.IsZero() && driver.MonthlyCostP90.IsZero()) {
+		return driver
+	}
+	d := discounts.Match(driver.Cloud, driver.Service, driver.ProductFamily, driver.Region)
+	if d == nil {
+		return driver
+	}
+
+	driver.Discount = &DriverDiscount{
+		Name:               d.Name,
+		Percent:            d.Percent,
+		ListUnitPrice:      driver.UnitPrice,
+		ListMonthlyCostP50: driver.MonthlyCostP50,
+		ListMonthlyCostP90: driver.MonthlyCostP90,
+	}
+	driver.UnitPrice = discount.Net(driver.UnitPrice, d.Percent)
+	driver.MonthlyCostP50 = discount.Net(driver.MonthlyCostP50, d.Percent).Round(4)
+	driver.MonthlyCostP90 = discount.Net(driver.MonthlyCostP90, d.Percent).Round(4)
+	if includeFormulas && driver.Formula != "" {
+		driver.Formula += fmt.Sprintf(" − %g%% %s = %s", d.Percent, d.Name,
+			currency.Format(driver.MonthlyCostP50, driver.Currency, 2))
+	}
+	return driver
+}
@@ -0,0 +1,73 @@
+package estimation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/discount"
+)
+
+func TestEstimateDiscounts(t *testing.T) {
+	store := &tieredStore{tiers: []clickhouse.TieredRate{{Price: decimal.NewFromFloat(0.1), Confidence: 1}}}
+	component := func(id, service string) billing.BillingComponent {
+		return billing.BillingComponent{
+			ID: id, ResourceAddr: "aws_instance." + id, Cloud: "aws", Service: service,
+			ProductFamily: "Compute Instance", Region: "us-east-1", BillingPeriod: billing.PeriodHourly,
+			VarianceProfile: billing.VarianceProfile{P50Usage: 100, P90Usage: 200, Confidence: 1},
+		}
+	}
+	set := &discount.Set{Source: "test", Discounts: []*clickhouse.Discount{
+		{Name: "EDP", Percent: 10},
+		{Name: "EC2 private rates", Cloud: "aws", Service: "AmazonEC2", Percent: 25},
+	}}
+
+	result, err := NewEngine(store).WithDiscounts(set).Estimate(context.Background(), EstimationRequest{
+		Components:      []billing.BillingComponent{component("ec2", "AmazonEC2"), component("rds", "AmazonRDS")},
+		IncludeFormulas: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	drivers := make(map[string]CostDriver)
+	for _, d := range result.CostDrivers {
+		drivers[d.ComponentID] = d
+	}
+
+	// The service's private rate replaces the EDP rather than stacking on it
+	ec2 := drivers["ec2"]
+	if ec2.Discount == nil || ec2.Discount.Name != "EC2 private rates" {
+		t.Fatalf("ec2 discount = %+v, want EC2 private rates", ec2.Discount)
+	}
+	if !ec2.Discount.ListMonthlyCostP50.Equal(decimal.NewFromInt(10)) || !ec2.MonthlyCostP50.Equal(decimal.NewFromFloat(7.5)) {
+		t.Errorf("ec2 list/net = %s/%s, want 10/7.5", ec2.Discount.ListMonthlyCostP50, ec2.MonthlyCostP50)
+	}
+	if !ec2.UnitPrice.Equal(decimal.NewFromFloat(0.075)) || !ec2.MonthlyCostP90.Equal(decimal.NewFromInt(15)) {
+		t.Errorf("ec2 unit price = %s, P90 = %s; want net 0.075 and 15", ec2.UnitPrice, ec2.MonthlyCostP90)
+	}
+	if rds := drivers["rds"]; rds.Discount == nil || rds.Discount.Name != "EDP" || !rds.MonthlyCostP50.Equal(decimal.NewFromInt(9)) {
+		t.Errorf("rds = %s %+v, want 9 with the EDP", rds.MonthlyCostP50, rds.Discount)
+	}
+
+	if !result.MonthlyCostP50.Equal(decimal.NewFromFloat(16.5)) {
+		t.Errorf("total = %s, want net 16.5", result.MonthlyCostP50)
+	}
+	summary := result.Discounts
+	if summary == nil || summary.Source != "test" || !summary.ListMonthlyCostP50.Equal(decimal.NewFromInt(20)) || !summary.SavingsP50.Equal(decimal.NewFromFloat(3.5)) {
+		t.Errorf("discounts = %+v, want list 20 and savings 3.5", summary)
+	}
+
+	// No discounts: no summary, list prices
+	result, err = NewEngine(store).WithDiscounts(&discount.Set{}).Estimate(context.Background(), EstimationRequest{
+		Components: []billing.BillingComponent{component("ec2", "AmazonEC2")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Discounts != nil || result.CostDrivers[0].Discount != nil || !result.MonthlyCostP50.Equal(decimal.NewFromInt(10)) {
+		t.Errorf("undiscounted = %s %+v, want list price 10", result.MonthlyCostP50, result.Discounts)
+	}
+}
@@ -0,0 +1,91 @@
+// Package estimation - Cost driver queries
+// Filters and orders the full driver breakdown of an estimate for paginated listings
+package estimation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DriverFilter selects cost drivers; empty fields match everything
+type DriverFilter struct {
+	Service string // Exact service, case-insensitive
+	Region  string
+	Tag     string // key=value, or key alone for any value
+}
+
+// Matches reports whether a driver passes the filter
+func (f DriverFilter) Matches(d CostDriver) bool {
+	if f.Service != "" && !strings.EqualFold(d.Service, f.Service) {
+		return false
+	}
+	if f.Region != "" && d.Region != f.Region {
+		return false
+	}
+	if f.Tag != "" {
+		key, value, hasValue := strings.Cut(f.Tag, "=")
+		tagValue, ok := d.ResourceTags[key]
+		if !ok || (hasValue && tagValue != value) {
+			return false
+		}
+	}
+	return true
+}
+
+// DriverSort orders cost drivers
+type DriverSort string
+
+const (
+	SortByCost     DriverSort = "cost"     // Highest monthly P50 first
+	SortByService  DriverSort = "service"  // By service, then cost
+	SortByRegion   DriverSort = "region"   // By region, then cost
+	SortByResource DriverSort = "resource" // By resource address
+)
+
+// ParseDriverSort parses a sort order; empty is SortByCost
+func ParseDriverSort(s string) (DriverSort, error) {
+	switch by := DriverSort(strings.ToLower(s)); by {
+	case "":
+		return SortByCost, nil
+	case SortByCost, SortByService, SortByRegion, SortByResource:
+		return by, nil
+	default:
+		return "", fmt.Errorf("unknown sort %q (cost, service, region, resource)", s)
+	}
+}
+
+// QueryDrivers returns the drivers passing a filter in the given order
+func QueryDrivers(drivers []CostDriver, filter DriverFilter, by DriverSort) []CostDriver {
+	matched := make([]CostDriver, 0, len(drivers))
+	for _, d := range drivers {
+		if filter.Matches(d) {
+			matched = append(matched, d)
+		}
+	}
+
+	byCost := func(i, j int) bool {
+		if !matched[i].MonthlyCostP50.Equal(matched[j].MonthlyCostP50) {
+			return matched[i].MonthlyCostP50.GreaterThan(matched[j].MonthlyCostP50)
+		}
+		return matched[i].ID < matched[j].ID
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		switch by {
+		case SortByService:
+			if matched[i].Service != matched[j].Service {
+				return matched[i].Service < matched[j].Service
+			}
+		case SortByRegion:
+			if matched[i].Region != matched[j].Region {
+				return matched[i].Region < matched[j].Region
+			}
+		case SortByResource:
+			if matched[i].ResourceAddr != matched[j].ResourceAddr {
+				return matched[i].ResourceAddr < matched[j].ResourceAddr
+			}
+		}
+		return byCost(i, j)
+	})
+	return matched
+}
@@ -0,0 +1,47 @@
+package estimation
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestQueryDrivers(t *testing.T) {
+	drivers := []CostDriver{
+		{ID: "a", Service: "AmazonEC2", Region: "us-east-1", MonthlyCostP50: decimal.NewFromInt(10), ResourceTags: map[string]string{"team": "web"}},
+		{ID: "b", Service: "AmazonRDS", Region: "us-east-1", MonthlyCostP50: decimal.NewFromInt(50)},
+		{ID: "c", Service: "AmazonEC2", Region: "eu-west-1", MonthlyCostP50: decimal.NewFromInt(30), ResourceTags: map[string]string{"team": "data"}},
+	}
+
+	tests := []struct {
+		name   string
+		filter DriverFilter
+		by     DriverSort
+		want   []string
+	}{
+		{"by cost", DriverFilter{}, SortByCost, []string{"b", "c", "a"}},
+		{"by service", DriverFilter{}, SortByService, []string{"c", "a", "b"}},
+		{"by region", DriverFilter{}, SortByRegion, []string{"c", "b", "a"}},
+		{"service filter", DriverFilter{Service: "amazonec2"}, SortByCost, []string{"c", "a"}},
+		{"region filter", DriverFilter{Region: "us-east-1"}, SortByCost, []string{"b", "a"}},
+		{"tag key", DriverFilter{Tag: "team"}, SortByCost, []string{"c", "a"}},
+		{"tag value", DriverFilter{Tag: "team=web"}, SortByCost, []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := QueryDrivers(drivers, tt.filter, tt.by)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d drivers, want %v", len(got), tt.want)
+			}
+			for i, d := range got {
+				if d.ID != tt.want[i] {
+					t.Errorf("driver %d = %s, want %s", i, d.ID, tt.want[i])
+				}
+			}
+		})
+	}
+
+	if _, err := ParseDriverSort("price"); err == nil {
+		t.Error("ParseDriverSort(price) should fail")
+	}
+}
@@ -6,19 +6,47 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel/attribute"
 
 	"terraform-cost/db/clickhouse"
 	"terraform-cost/decision/billing"
+	"terraform-cost/decision/currency"
+	"terraform-cost/decision/discount"
+	"terraform-cost/telemetry"
 )
 
+// ReasonNoPricing is the reason of cost drivers with no price in the snapshots used
+const ReasonNoPricing = "no pricing data available"
+
+// ReasonPlaceholder is the reason of cost drivers of unsupported resources priced by placeholder
+const ReasonPlaceholder = "unsupported resource type; placeholder cost"
+
 // Engine is the Cost & Carbon Estimation Engine
 type Engine struct {
-	pricingStore *clickhouse.Store
-	carbonStore  CarbonStore // Interface for carbon intensity data
+	pricingStore PricingStore
+	carbonStore  CarbonStore     // Interface for carbon intensity data
+	fxRates      *currency.Table // Exchange rates for non-USD estimates
+	discounts    discount.Provider // Negotiated pricing taken off list prices
+	aliasRoutes  *AliasRoutes      // Pricing alias of each environment and partition
+}
+
+// PricingStore resolves unit prices for billing components
+// Implemented by clickhouse.Store and the offline bundle in db/embedded.
+type PricingStore interface {
+	ResolveRate(ctx context.Context, cloud clickhouse.CloudProvider, service, productFamily, region string, attrs map[string]string, unit, alias string) (*clickhouse.ResolvedRate, error)
+	ResolveRatesBatch(ctx context.Context, lookups []clickhouse.RateLookup) (map[string]*clickhouse.ResolvedRate, error)
+	ResolveSpotRate(ctx context.Context, cloud clickhouse.CloudProvider, region, instanceType, productDescription string, lookback time.Duration, at time.Time) (*clickhouse.SpotRate, error)
+}
+
+// TieredPricingStore resolves every tier of a rate (S3 storage, data transfer,
+// free allowances priced at zero); stores without it price tiered usage at the first tier
+type TieredPricingStore interface {
+	ResolveRateTiers(ctx context.Context, snapshotID uuid.UUID, lookup clickhouse.RateLookup) ([]clickhouse.TieredRate, error)
 }
 
 // CarbonStore provides carbon intensity data
@@ -27,7 +55,7 @@ type CarbonStore interface {
 }
 
 // NewEngine creates a new estimation engine
-func NewEngine(pricingStore *clickhouse.Store) *Engine {
+func NewEngine(pricingStore PricingStore) *Engine {
 	return &Engine{
 		pricingStore: pricingStore,
 	}
@@ -39,22 +67,81 @@ func (e *Engine) WithCarbonStore(store CarbonStore) *Engine {
 	return e
 }
 
+// WithExchangeRates enables estimates in currencies other than USD
+func (e *Engine) WithExchangeRates(table *currency.Table) *Engine {
+	e.fxRates = table
+	return e
+}
+
+// WithDiscounts takes negotiated discounts off the list price of every cost driver
+func (e *Engine) WithDiscounts(provider discount.Provider) *Engine {
+	e.discounts = provider
+	return e
+}
+
+// WithAliasRoutes resolves rates of requests without a pricing alias under their routed alias
+func (e *Engine) WithAliasRoutes(routes *AliasRoutes) *Engine {
+	e.aliasRoutes = routes
+	return e
+}
+
 // EstimationRequest contains inputs for cost estimation
 type EstimationRequest struct {
 	Components   []billing.BillingComponent
 	Environment  string // dev, staging, prod
-	PricingAlias string // Pricing version alias (default: "default")
+	PricingAlias string // Pricing version alias (default: routed by the engine's AliasRoutes, else "default")
+	PricingDate  time.Time // Price against the snapshots valid at this time (zero: active snapshots)
+	Timebase     billing.Timebase // Hours billed per month (default: 730)
+	ProvisionedAt time.Time       // Mid-month provisioning: the first month is prorated in FirstMonth
+	Currency     string    // Output currency (default: USD); needs exchange rates unless USD
 	
 	// Carbon options
 	IncludeCarbon bool
 	
 	// Explainability
 	IncludeFormulas bool
+	
+	// Cost allocation tag keys (default: DefaultAllocationTags)
+	AllocationTags []string
+	
+	// Monte Carlo simulation of total cost (nil: skipped)
+	Simulation *SimulationOptions
+	
+	// Free tier allowances subtracted from usage (nil: none)
+	FreeTier *FreeTier
+	
+	// Retry rate keys with no exact price relaxed (see relaxed.go) instead
+	// of leaving them symbolic
+	RelaxedMatching bool
+	
+	// Billable resources the mappers could not size (critical mapping
+	// errors); they count against mapping coverage
+	UnmappedResources int
+	
+	hoursPerMonth float64 // Of the timebase, in the month estimated
+}
+
+// ParsePricingDate parses a pricing date (2006-01-02, midnight UTC, or RFC 3339)
+func ParsePricingDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		if t, err = time.Parse(time.RFC3339, value); err != nil {
+			return time.Time{}, fmt.Errorf("invalid pricing date %q: use YYYY-MM-DD or RFC 3339", value)
+		}
+	}
+	if t.After(time.Now()) {
+		return time.Time{}, fmt.Errorf("pricing date %s is in the future", value)
+	}
+	return t, nil
 }
 
 // EstimationResult contains the complete estimation output
 type EstimationResult struct {
-	// Cost totals
+	// Cost totals, all in Currency
+	Currency       string          `json:"currency"`
 	MonthlyCostP50 decimal.Decimal `json:"monthly_cost_p50"`
 	MonthlyCostP90 decimal.Decimal `json:"monthly_cost_p90"`
 	HourlyCostP50  decimal.Decimal `json:"hourly_cost_p50"`
@@ -65,10 +152,30 @@ type EstimationResult struct {
 	
 	// Cost breakdown
 	CostDrivers []CostDriver `json:"cost_drivers"`
+	CostGroups  []CostGroup  `json:"cost_groups"` // drivers grouped across count/for_each instances
+	
+	// Cost allocation: tag key -> tag value -> monthly P50
+	CostByTag map[string]map[string]decimal.Decimal `json:"cost_by_tag,omitempty"`
+	
+	// Cost rollup by module, as a tree; omitted when every resource is in the root module
+	CostByModule []ModuleCost `json:"cost_by_module,omitempty"`
 	
-	// Quality metrics
-	Confidence   float64 `json:"confidence"`
-	IsIncomplete bool    `json:"is_incomplete"`
+	// List-price totals, when negotiated discounts apply; the totals above are net
+	Discounts *DiscountSummary `json:"discounts,omitempty"`
+	
+	// Simulated distribution of the monthly total, when requested
+	Simulation *Simulation `json:"simulation,omitempty"`
+	
+	// Free tier allowances the estimate used, when requested
+	FreeTier []FreeTierUsage `json:"free_tier,omitempty"`
+	
+	// First, partial month, with ProvisionedAt
+	FirstMonth *ProratedMonth `json:"first_month,omitempty"`
+	
+	// Quality metrics: Confidence is the cost-weighted score, see ScoreConfidence
+	Confidence       float64          `json:"confidence"`
+	ConfidenceScores ConfidenceScores `json:"confidence_scores"`
+	IsIncomplete     bool             `json:"is_incomplete"`
 	
 	// Errors and warnings
 	Errors   []EstimationError `json:"errors"`
@@ -89,17 +196,22 @@ type CostDriver struct {
 	ID           string `json:"id"`
 	ComponentID  string `json:"component_id"`
 	ResourceAddr string `json:"resource_addr"`
+	Module       string `json:"module,omitempty"` // Module path of the resource; empty in the root module
 	
 	// Classification
 	Cloud         string `json:"cloud"`
 	Service       string `json:"service"`
 	ProductFamily string `json:"product_family"`
+	UsageType     string `json:"usage_type,omitempty"`
 	Region        string `json:"region"`
 	
 	// Description
-	Description string `json:"description"`
+	Description  string            `json:"description"`
+	ResourceTags map[string]string `json:"resource_tags,omitempty"`
+	ResourceID   string            `json:"resource_id,omitempty"` // ARN or ID of an existing resource
 	
 	// Cost calculation
+	Currency       string          `json:"currency"`
 	MonthlyCostP50 decimal.Decimal `json:"monthly_cost_p50"`
 	MonthlyCostP90 decimal.Decimal `json:"monthly_cost_p90"`
 	
@@ -109,14 +221,23 @@ type CostDriver struct {
 	UsageP50    float64         `json:"usage_p50"`
 	UsageP90    float64         `json:"usage_p90"`
 	UsageUnit   string          `json:"usage_unit"`
+	FreeTierUsage float64       `json:"free_tier_usage,omitempty"` // P50 usage covered by the free tier
+	
+	// Negotiated discount; the cost and unit price above are then net of it
+	Discount *DriverDiscount `json:"discount,omitempty"`
 	
 	// Carbon
 	CarbonKgCO2 float64 `json:"carbon_kg_co2"`
 	
-	// Quality
-	Confidence float64 `json:"confidence"`
-	IsSymbolic bool    `json:"is_symbolic"`
+	// Quality: Confidence is the lower of the pricing and usage confidence
+	Confidence        float64 `json:"confidence"`
+	PricingConfidence float64 `json:"pricing_confidence"`
+	UsageConfidence   float64 `json:"usage_confidence"`
+	IsSymbolic        bool    `json:"is_symbolic"`
 	Reason     string  `json:"reason,omitempty"`
+	MatchedVia string  `json:"matched_via,omitempty"` // How relaxed matching found the rate; empty for exact matches
+	ResolutionHints *ResolutionHints `json:"resolution_hints,omitempty"` // Drivers with no pricing data
+	UnknownAttributes []string       `json:"unknown_attributes,omitempty"` // Cost-driving attributes unknown until apply
 	
 	// Pricing reference
 	SnapshotID uuid.UUID `json:"snapshot_id,omitempty"`
@@ -134,13 +255,36 @@ type EstimationError struct {
 // AuditTrail provides reproducibility information
 type AuditTrail struct {
 	EstimatedAt   time.Time          `json:"estimated_at"`
+	RequestID     string             `json:"request_id,omitempty"` // API request or CLI run that estimated
 	Environment   string             `json:"environment"`
 	PricingAlias  string             `json:"pricing_alias"`
+	PricingDate   *time.Time         `json:"pricing_date,omitempty"`
+	Timebase      string             `json:"timebase"`        // 730, 720 or calendar
+	HoursPerMonth float64            `json:"hours_per_month"` // Hour-billed usage is priced over this many hours
+	ExchangeRates string             `json:"exchange_rates,omitempty"` // FX source and date for non-USD estimates
 	SnapshotsUsed map[string]uuid.UUID `json:"snapshots_used"` // region -> snapshot ID
+	RegionAliases map[string]string    `json:"region_aliases,omitempty"` // region -> pricing alias, where routed away from PricingAlias
+}
+
+// AliasFor returns the pricing alias a region's rates resolved under
+func (a AuditTrail) AliasFor(region string) string {
+	if alias := a.RegionAliases[region]; alias != "" {
+		return alias
+	}
+	if a.PricingAlias != "" {
+		return a.PricingAlias
+	}
+	return DefaultPricingAlias
 }
 
 // Estimate performs cost and carbon estimation
 func (e *Engine) Estimate(ctx context.Context, req EstimationRequest) (*EstimationResult, error) {
+	ctx, span := telemetry.StartSpan(ctx, "estimation.estimate",
+		attribute.Int("estimation.components", len(req.Components)),
+		attribute.String("estimation.environment", req.Environment),
+	)
+	defer span.End()
+	
 	result := &EstimationResult{
 		MonthlyCostP50: decimal.Zero,
 		MonthlyCostP90: decimal.Zero,
@@ -153,24 +297,70 @@ func (e *Engine) Estimate(ctx context.Context, req EstimationRequest) (*Estimati
 		Warnings:       make([]string, 0),
 		AuditTrail: AuditTrail{
 			EstimatedAt:   time.Now(),
+			RequestID:     telemetry.RequestID(ctx),
 			Environment:   req.Environment,
-			PricingAlias:  req.PricingAlias,
+			PricingAlias:  e.aliasRoutes.Resolve(req.PricingAlias, req.Environment, "", ""),
 			SnapshotsUsed: make(map[string]uuid.UUID),
 		},
 	}
 	
-	if req.PricingAlias == "" {
-		req.PricingAlias = "default"
+	req.Currency = currency.Normalize(req.Currency)
+	if !e.fxRates.Supports(req.Currency) {
+		return nil, fmt.Errorf("no exchange rate for %s", req.Currency)
+	}
+	result.Currency = req.Currency
+	if req.Currency != currency.USD && e.fxRates != nil {
+		result.AuditTrail.ExchangeRates = e.fxRates.Source
+		if !e.fxRates.AsOf.IsZero() {
+			result.AuditTrail.ExchangeRates += " " + e.fxRates.AsOf.Format("2006-01-02")
+		}
+	}
+	if !req.PricingDate.IsZero() {
+		pricingDate := req.PricingDate
+		result.AuditTrail.PricingDate = &pricingDate
 	}
 	
-	// Track minimum confidence across all components
-	minConfidence := 1.0
+	// Mappers size hour-billed usage in billing.HoursPerMonth; rescale it to
+	// the timebase of the month estimated
+	month := req.PricingDate
+	if month.IsZero() {
+		month = result.AuditTrail.EstimatedAt
+	}
+	req.hoursPerMonth = req.Timebase.Hours(month)
+	result.AuditTrail.Timebase = req.Timebase.String()
+	result.AuditTrail.HoursPerMonth = req.hoursPerMonth
+	if req.hoursPerMonth != billing.HoursPerMonth {
+		req.Components = rescaleHourly(req.Components, req.hoursPerMonth/billing.HoursPerMonth)
+	}
+	
+	var discounts *discount.Set
+	if e.discounts != nil {
+		set, err := e.discounts.Resolve(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve discounts: %w", err)
+		}
+		if set != nil && len(set.Discounts) > 0 {
+			discounts = set
+			result.Discounts = &DiscountSummary{Source: set.Source}
+		}
+	}
+	
+	// Subtract free tier allowances from usage before pricing
+	var freeTierUsage map[string]float64
+	req.Components, freeTierUsage, result.FreeTier = req.FreeTier.apply(req.Components)
+	
+	// Resolve every component's rate in one query; on failure each
+	// component falls back to its own lookup
+	rates, err := e.prefetchRates(ctx, req)
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("batch rate resolution failed, resolving individually: %v", err))
+	}
 	
 	// Process each billing component
 	for _, comp := range req.Components {
 		result.ComponentsProcessed++
 		
-		driver, err := e.estimateComponent(ctx, comp, req)
+		driver, err := e.estimateComponent(ctx, comp, req, rates)
 		if err != nil {
 			result.Errors = append(result.Errors, EstimationError{
 				ComponentID:  comp.ID,
@@ -183,6 +373,16 @@ func (e *Engine) Estimate(ctx context.Context, req EstimationRequest) (*Estimati
 			// Add symbolic driver
 			driver = e.createSymbolicDriver(comp, err.Error())
 		}
+		driver.Currency = req.Currency
+		driver.FreeTierUsage = freeTierUsage[comp.ID]
+		
+		// Take negotiated discounts off the list price
+		if discounts != nil {
+			driver = applyDiscount(driver, discounts, req.IncludeFormulas)
+			listP50, listP90 := driver.ListMonthlyCost()
+			result.Discounts.ListMonthlyCostP50 = result.Discounts.ListMonthlyCostP50.Add(listP50)
+			result.Discounts.ListMonthlyCostP90 = result.Discounts.ListMonthlyCostP90.Add(listP90)
+		}
 		
 		// Add to totals
 		result.MonthlyCostP50 = result.MonthlyCostP50.Add(driver.MonthlyCostP50)
@@ -193,15 +393,16 @@ func (e *Engine) Estimate(ctx context.Context, req EstimationRequest) (*Estimati
 			result.CarbonByRegion[driver.Region] += driver.CarbonKgCO2
 		}
 		
-		// Track confidence
-		if driver.Confidence < minConfidence {
-			minConfidence = driver.Confidence
-		}
-		
 		// Track snapshot usage
 		if driver.SnapshotID != uuid.Nil {
 			result.AuditTrail.SnapshotsUsed[driver.Region] = driver.SnapshotID
 		}
+		if alias := e.aliasRoutes.Resolve(req.PricingAlias, req.Environment, driver.Cloud, driver.Region); alias != result.AuditTrail.PricingAlias {
+			if result.AuditTrail.RegionAliases == nil {
+				result.AuditTrail.RegionAliases = make(map[string]string)
+			}
+			result.AuditTrail.RegionAliases[driver.Region] = alias
+		}
 		
 		if !driver.IsSymbolic {
 			result.ComponentsEstimated++
@@ -210,13 +411,20 @@ func (e *Engine) Estimate(ctx context.Context, req EstimationRequest) (*Estimati
 		result.CostDrivers = append(result.CostDrivers, driver)
 	}
 	
+	if result.Discounts != nil {
+		result.Discounts.SavingsP50 = result.Discounts.ListMonthlyCostP50.Sub(result.MonthlyCostP50)
+	}
+	
 	// Calculate hourly cost
 	if !result.MonthlyCostP50.IsZero() {
-		result.HourlyCostP50 = result.MonthlyCostP50.Div(decimal.NewFromFloat(730))
+		result.HourlyCostP50 = result.MonthlyCostP50.Div(decimal.NewFromFloat(req.hoursPerMonth))
+	}
+	if !req.ProvisionedAt.IsZero() {
+		result.FirstMonth = prorate(result, req.ProvisionedAt)
 	}
 	
-	// Set final confidence
-	result.Confidence = minConfidence
+	// Weight confidence by each driver's share of the cost
+	result.Confidence, result.ConfidenceScores = ScoreConfidence(result.CostDrivers, req.UnmappedResources)
 	
 	// Mark as incomplete if any symbolic costs
 	if result.ComponentsSymbolic > 0 {
@@ -236,84 +444,310 @@ func (e *Engine) Estimate(ctx context.Context, req EstimationRequest) (*Estimati
 	sort.Slice(result.CostDrivers, func(i, j int) bool {
 		return result.CostDrivers[i].MonthlyCostP50.GreaterThan(result.CostDrivers[j].MonthlyCostP50)
 	})
+	result.CostGroups = GroupCostDrivers(result.CostDrivers)
+	
+	allocationTags := req.AllocationTags
+	if allocationTags == nil {
+		allocationTags = DefaultAllocationTags
+	}
+	result.CostByTag = AllocateByTag(result.CostDrivers, allocationTags)
+	result.CostByModule = RollupByModule(result.CostDrivers)
+	
+	if req.Simulation != nil {
+		_, simSpan := telemetry.StartSpan(ctx, "estimation.simulate")
+		result.Simulation = Simulate(result.CostDrivers, req.Components, *req.Simulation)
+		simSpan.End()
+	}
+	
+	span.SetAttributes(
+		attribute.Int("estimation.components_symbolic", result.ComponentsSymbolic),
+		attribute.Float64("estimation.monthly_cost_p50", result.MonthlyCostP50.InexactFloat64()),
+	)
 	
 	return result, nil
 }
 
-// estimateComponent estimates a single billing component
-func (e *Engine) estimateComponent(ctx context.Context, comp billing.BillingComponent, req EstimationRequest) (CostDriver, error) {
-	driver := CostDriver{
-		ID:            fmt.Sprintf("driver-%s", comp.ID),
-		ComponentID:   comp.ID,
-		ResourceAddr:  comp.ResourceAddr,
-		Cloud:         comp.Cloud,
+// rateLookup returns the on-demand rate lookup for a component
+func (e *Engine) rateLookup(comp billing.BillingComponent, req EstimationRequest) clickhouse.RateLookup {
+	return clickhouse.RateLookup{
+		Cloud:         clickhouse.CloudProvider(comp.Cloud),
 		Service:       comp.Service,
 		ProductFamily: comp.ProductFamily,
 		Region:        comp.Region,
-		Description:   comp.Description,
-		UsageP50:      comp.VarianceProfile.P50Usage,
-		UsageP90:      comp.VarianceProfile.P90Usage,
-		Confidence:    comp.VarianceProfile.Confidence,
+		Attributes:    comp.Attributes,
+		Unit:          UsageUnit(comp.BillingPeriod),
+		Alias:         e.aliasRoutes.Resolve(req.PricingAlias, req.Environment, comp.Cloud, comp.Region),
+		At:            req.PricingDate,
+	}
+}
+
+// prefetchRates resolves the on-demand rates of all components in one batch
+func (e *Engine) prefetchRates(ctx context.Context, req EstimationRequest) (map[string]*clickhouse.ResolvedRate, error) {
+	if len(req.Components) == 0 {
+		return nil, nil
+	}
+	lookups := make([]clickhouse.RateLookup, 0, len(req.Components))
+	for _, comp := range req.Components {
+		if !billing.IsPlaceholder(comp) {
+			lookups = append(lookups, e.rateLookup(comp, req))
+		}
+	}
+	if len(lookups) == 0 {
+		return nil, nil
+	}
+	return e.pricingStore.ResolveRatesBatch(ctx, lookups)
+}
+
+// estimateComponent estimates a single billing component
+// rates holds prefetched on-demand rates; components missing from it are resolved directly.
+func (e *Engine) estimateComponent(ctx context.Context, comp billing.BillingComponent, req EstimationRequest, rates map[string]*clickhouse.ResolvedRate) (CostDriver, error) {
+	driver := CostDriver{
+		ID:              fmt.Sprintf("driver-%s", comp.ID),
+		ComponentID:     comp.ID,
+		ResourceAddr:    comp.ResourceAddr,
+		Module:          comp.Module,
+		Cloud:           comp.Cloud,
+		Service:         comp.Service,
+		ProductFamily:   comp.ProductFamily,
+		UsageType:       comp.UsageType,
+		Region:          comp.Region,
+		Description:     comp.Description,
+		ResourceTags:    comp.ResourceTags,
+		ResourceID:      comp.ResourceID,
+		UsageP50:        comp.VarianceProfile.P50Usage,
+		UsageP90:        comp.VarianceProfile.P90Usage,
+		Confidence:      comp.VarianceProfile.Confidence,
+		UsageConfidence: comp.VarianceProfile.Confidence,
+	}
+	
+	// Components sized by values computed at apply time are not priced at a
+	// guessed default; they become symbolic, naming the unknown attributes
+	if len(comp.UnknownAttributes) > 0 {
+		return driver, fmt.Errorf("%s unknown until apply", strings.Join(comp.UnknownAttributes, ", "))
+	}
+	
+	// Unsupported resources carry an assumed cost instead of a rate
+	if billing.IsPlaceholder(comp) {
+		return e.pricePlaceholder(comp, req, driver)
+	}
+	
+	// Spot capacity is priced from observed history; the on-demand rate is the fallback ceiling
+	if comp.PurchaseOption == billing.PurchaseSpot {
+		spot, err := e.pricingStore.ResolveSpotRate(
+			ctx,
+			clickhouse.CloudProvider(comp.Cloud),
+			comp.Region,
+			comp.Attributes["instanceType"],
+			spotProductDescription(comp.Attributes["operatingSystem"]),
+			SpotLookback,
+			req.PricingDate,
+		)
+		if err != nil {
+			return driver, fmt.Errorf("spot pricing resolution failed: %w", err)
+		}
+		if spot != nil {
+			driver.Source = "spot_price_history"
+			driver.PricingConfidence = spotConfidence(spot.Samples)
+			driver.Confidence = min(driver.UsageConfidence, driver.PricingConfidence)
+			p50, err := e.fxRates.Convert(spot.P50, spot.Currency, req.Currency)
+			if err != nil {
+				return driver, err
+			}
+			p90, err := e.fxRates.Convert(spot.P90, spot.Currency, req.Currency)
+			if err != nil {
+				return driver, err
+			}
+			return e.priceDriver(ctx, comp, req, driver, p50, p90), nil
+		}
 	}
 	
 	// Resolve pricing
-	rate, err := e.pricingStore.ResolveRate(
-		ctx,
-		clickhouse.CloudProvider(comp.Cloud),
-		comp.Service,
-		comp.ProductFamily,
-		comp.Region,
-		comp.Attributes,
-		e.billingPeriodToUnit(comp.BillingPeriod),
-		req.PricingAlias,
-	)
+	lookup := e.rateLookup(comp, req)
+	rate, ok := rates[lookup.Key()]
+	if !ok {
+		resolved, err := e.pricingStore.ResolveRatesBatch(ctx, []clickhouse.RateLookup{lookup})
+		if err != nil {
+			return driver, fmt.Errorf("pricing resolution failed: %w", err)
+		}
+		rate = resolved[lookup.Key()]
+	}
 	
-	if err != nil {
-		return driver, fmt.Errorf("pricing resolution failed: %w", err)
+	// Relaxed matching before giving up on the component
+	if rate == nil && req.RelaxedMatching {
+		relaxed, relaxedLookup, via, err := e.relaxedRate(ctx, lookup)
+		if err != nil {
+			return driver, err
+		}
+		if relaxed != nil {
+			rate, lookup = relaxed, relaxedLookup
+			driver.MatchedVia = via
+		}
 	}
 	
 	if rate == nil {
 		driver.IsSymbolic = true
-		driver.Reason = "no pricing data available"
+		driver.Reason = ReasonNoPricing
+		driver.ResolutionHints = e.resolutionHints(ctx, lookup)
 		return driver, nil
 	}
 	
 	// Calculate costs
-	driver.UnitPrice = rate.Price
 	driver.SnapshotID = rate.SnapshotID
 	driver.Source = rate.Source
-	driver.Confidence = min(driver.Confidence, rate.Confidence)
+	driver.PricingConfidence = rate.Confidence
+	driver.Confidence = min(driver.UsageConfidence, driver.PricingConfidence)
+	if comp.PurchaseOption == billing.PurchaseSpot {
+		driver.Reason = "no spot price history; priced at on-demand rate"
+	}
+	
+	// The first priced tier is bounded when the rate is tiered, and starts
+	// above zero when a free allowance precedes it
+	if tiered, ok := e.pricingStore.(TieredPricingStore); ok && (rate.TierMax != nil || rate.TierMin != nil) {
+		tiers, err := tiered.ResolveRateTiers(ctx, rate.SnapshotID, lookup)
+		if err != nil {
+			return driver, fmt.Errorf("tiered pricing resolution failed: %w", err)
+		}
+		if len(tiers) > 1 || (len(tiers) == 1 && tiers[0].Min.IsPositive()) {
+			return e.priceTieredDriver(ctx, comp, req, driver, tiers, rate.Currency)
+		}
+	}
+	
+	price, err := e.fxRates.Convert(rate.Price, rate.Currency, req.Currency)
+	if err != nil {
+		return driver, err
+	}
+	return e.priceDriver(ctx, comp, req, driver, price, price), nil
+}
+
+// priceTieredDriver prices P50 and P90 usage across a rate's tiers
+// UnitPrice is the blended price at P50 usage, so the simulation stays linear around it.
+func (e *Engine) priceTieredDriver(ctx context.Context, comp billing.BillingComponent, req EstimationRequest, driver CostDriver, tiers []clickhouse.TieredRate, rateCurrency string) (CostDriver, error) {
+	converted := make([]clickhouse.TieredRate, len(tiers))
+	for i, t := range tiers {
+		price, err := e.fxRates.Convert(t.Price, rateCurrency, req.Currency)
+		if err != nil {
+			return driver, err
+		}
+		converted[i] = t
+		converted[i].Price = price
+	}
+	
+	usageP50 := decimal.NewFromFloat(comp.VarianceProfile.P50Usage)
+	usageP90 := decimal.NewFromFloat(comp.VarianceProfile.P90Usage)
+	costP50, confidence := clickhouse.CalculateTieredCost(usageP50, converted)
+	costP90, _ := clickhouse.CalculateTieredCost(usageP90, converted)
+	
+	driver.MonthlyCostP50 = costP50.Round(4)
+	driver.MonthlyCostP90 = costP90.Round(4)
+	driver.UnitPrice = converted[0].Price
+	if usageP50.IsPositive() {
+		driver.UnitPrice = costP50.Div(usageP50)
+	}
+	driver.PricingConfidence = min(driver.PricingConfidence, confidence)
+	driver.Confidence = min(driver.UsageConfidence, driver.PricingConfidence)
+	
+	driver.UsageUnit = UsageUnit(comp.BillingPeriod)
+	if req.IncludeFormulas {
+		driver.Formula = fmt.Sprintf("%.2f %s across tiers %s = %s",
+			comp.VarianceProfile.P50Usage,
+			driver.UsageUnit,
+			formatTiers(converted, usageP50, req.Currency),
+			currency.Format(driver.MonthlyCostP50, req.Currency, 2),
+		)
+	}
+	
+	return e.addCarbon(ctx, comp, req, driver), nil
+}
+
+// formatTiers lists the tiers usage reaches, e.g. "[≤51200 @ $0.023000, $0.022000]"
+func formatTiers(tiers []clickhouse.TieredRate, usage decimal.Decimal, code string) string {
+	parts := make([]string, 0, len(tiers)+1)
+	if tiers[0].Min.IsPositive() {
+		parts = append(parts, fmt.Sprintf("≤%s free", tiers[0].Min.String()))
+	}
+	for i, t := range tiers {
+		if i > 0 && usage.LessThanOrEqual(t.Min) {
+			break
+		}
+		if t.Max != nil {
+			parts = append(parts, fmt.Sprintf("≤%s @ %s", t.Max.String(), currency.Format(t.Price, code, 6)))
+		} else {
+			parts = append(parts, currency.Format(t.Price, code, 6))
+		}
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// priceDriver applies P50/P90 unit prices to the component's usage profile
+func (e *Engine) priceDriver(ctx context.Context, comp billing.BillingComponent, req EstimationRequest, driver CostDriver, priceP50, priceP90 decimal.Decimal) CostDriver {
+	driver.UnitPrice = priceP50
 	
 	// Apply usage to get monthly cost
 	usageP50 := decimal.NewFromFloat(comp.VarianceProfile.P50Usage)
 	usageP90 := decimal.NewFromFloat(comp.VarianceProfile.P90Usage)
 	
-	driver.MonthlyCostP50 = rate.Price.Mul(usageP50).Round(4)
-	driver.MonthlyCostP90 = rate.Price.Mul(usageP90).Round(4)
+	driver.MonthlyCostP50 = priceP50.Mul(usageP50).Round(4)
+	driver.MonthlyCostP90 = priceP90.Mul(usageP90).Round(4)
 	
 	// Generate formula
-	driver.UsageUnit = e.billingPeriodToUnit(comp.BillingPeriod)
+	driver.UsageUnit = UsageUnit(comp.BillingPeriod)
 	if req.IncludeFormulas {
-		driver.Formula = fmt.Sprintf("%.2f %s × $%s/%s = $%s",
+		driver.Formula = fmt.Sprintf("%.2f %s × %s/%s = %s",
 			comp.VarianceProfile.P50Usage,
 			driver.UsageUnit,
-			rate.Price.StringFixed(6),
+			currency.Format(priceP50, req.Currency, 6),
 			driver.UsageUnit,
-			driver.MonthlyCostP50.StringFixed(2),
+			currency.Format(driver.MonthlyCostP50, req.Currency, 2),
 		)
 	}
 	
-	// Calculate carbon if enabled
+	return e.addCarbon(ctx, comp, req, driver)
+}
+
+// pricePlaceholder prices a placeholder component at its configured monthly
+// cost; a zero cost leaves a symbolic line item
+func (e *Engine) pricePlaceholder(comp billing.BillingComponent, req EstimationRequest, driver CostDriver) (CostDriver, error) {
+	driver.Reason = ReasonPlaceholder
+	driver.Source = "placeholder"
+	driver.Confidence = 0
+	driver.PricingConfidence = 0
+	
+	cost, err := decimal.NewFromString(comp.Attributes[billing.AttrPlaceholderCost])
+	if err != nil {
+		return driver, fmt.Errorf("invalid placeholder cost: %w", err)
+	}
+	if cost.IsZero() {
+		driver.IsSymbolic = true
+		return driver, nil
+	}
+	cost, err = e.fxRates.Convert(cost, currency.USD, req.Currency)
+	if err != nil {
+		return driver, err
+	}
+	
+	driver.UnitPrice = cost
+	driver.UsageUnit = "months"
+	driver.MonthlyCostP50 = cost.Round(4)
+	driver.MonthlyCostP90 = cost.Round(4)
+	if req.IncludeFormulas {
+		driver.Formula = fmt.Sprintf("placeholder for %s = %s/month",
+			comp.Attributes[billing.AttrResourceType], currency.Format(cost, req.Currency, 2))
+	}
+	return driver, nil
+}
+
+// addCarbon estimates the driver's emissions when carbon is requested
+func (e *Engine) addCarbon(ctx context.Context, comp billing.BillingComponent, req EstimationRequest, driver CostDriver) CostDriver {
 	if req.IncludeCarbon && e.carbonStore != nil {
 		carbonIntensity, err := e.carbonStore.GetIntensity(ctx, comp.Cloud, comp.Region)
 		if err == nil && carbonIntensity > 0 {
 			// Estimate based on compute hours and regional intensity
 			// This is a simplified model - real implementation would be more sophisticated
-			driver.CarbonKgCO2 = e.estimateCarbonForComponent(comp, carbonIntensity)
+			driver.CarbonKgCO2 = e.estimateCarbonForComponent(comp, carbonIntensity, req.hoursPerMonth)
 		}
 	}
 	
-	return driver, nil
+	return driver
 }
 
 // createSymbolicDriver creates a driver for unpriced components
@@ -322,27 +756,63 @@ func (e *Engine) createSymbolicDriver(comp billing.BillingComponent, reason stri
 		ID:            fmt.Sprintf("driver-%s", comp.ID),
 		ComponentID:   comp.ID,
 		ResourceAddr:  comp.ResourceAddr,
+		Module:        comp.Module,
 		Cloud:         comp.Cloud,
 		Service:       comp.Service,
 		ProductFamily: comp.ProductFamily,
 		Region:        comp.Region,
 		Description:   comp.Description,
+		ResourceTags:  comp.ResourceTags,
+		ResourceID:    comp.ResourceID,
 		MonthlyCostP50: decimal.Zero,
 		MonthlyCostP90: decimal.Zero,
 		Confidence:    0,
 		IsSymbolic:    true,
 		Reason:        reason,
+		UnknownAttributes: comp.UnknownAttributes,
 	}
 }
 
 // estimateCarbonForComponent estimates carbon emissions for a component
-func (e *Engine) estimateCarbonForComponent(comp billing.BillingComponent, intensityGCO2 float64) float64 {
-	// Simplified carbon model based on service type
-	// In production, this would use actual power consumption models
+func (e *Engine) estimateCarbonForComponent(comp billing.BillingComponent, intensityGCO2, hoursPerMonth float64) float64 {
+	// Power from the instance's vCPUs and memory when metadata enrichment
+	// resolved them; otherwise a flat estimate per service
+	powerKw := servicePowerKw(comp.Service)
+	if comp.Hardware != nil {
+		powerKw = HardwarePowerKw(*comp.Hardware)
+	}
+	
+	// Calculate monthly energy (kWh) = power (kW) × hours
+	energyKwh := powerKw * hoursPerMonth
+	
+	// Convert to kg CO2 (intensity is in gCO2/kWh)
+	carbonKg := energyKwh * intensityGCO2 / 1000.0
 	
+	return carbonKg
+}
+
+// Average power of cloud hardware at typical utilization, after the Cloud
+// Carbon Footprint coefficients for AWS
+const (
+	wattsPerVCPU            = 2.12  // Midpoint of 0.74 W idle and 3.5 W at full load
+	wattsPerGiB             = 0.392 // Memory
+	powerUsageEffectiveness = 1.135 // Data center overhead
+	acceleratorLoad         = 0.6   // Typical draw of GPUs and AI chips as a share of board power
+)
+
+// HardwarePowerKw estimates the power drawn by an instance from its vCPUs,
+// memory and accelerators
+func HardwarePowerKw(hw billing.Hardware) float64 {
+	watts := float64(hw.VCPU)*wattsPerVCPU + hw.MemoryGiB*wattsPerGiB
+	watts += float64(hw.Accelerators) * hw.AcceleratorWatts * acceleratorLoad
+	return watts * powerUsageEffectiveness / 1000.0
+}
+
+// servicePowerKw is the flat power assumed per service when hardware is unknown
+func servicePowerKw(service string) float64 {
 	var powerKw float64
 	
-	switch comp.Service {
+	switch service {
 	case "AmazonEC2":
 		// Estimate based on instance type (simplified)
 		powerKw = 0.1 // 100W average for small instance
@@ -354,21 +824,16 @@ func (e *Engine) estimateCarbonForComponent(comp billing.BillingComponent, inten
 		powerKw = 0.05 // Default estimate
 	}
 	
-	// Calculate monthly energy (kWh) = power (kW) × hours
-	hoursPerMonth := 730.0
-	energyKwh := powerKw * hoursPerMonth
-	
-	// Convert to kg CO2 (intensity is in gCO2/kWh)
-	carbonKg := energyKwh * intensityGCO2 / 1000.0
-	
-	return carbonKg
+	return powerKw
 }
 
-// billingPeriodToUnit converts billing period to pricing unit
-func (e *Engine) billingPeriodToUnit(period billing.BillingPeriod) string {
+// UsageUnit is the pricing unit of a billing period
+func UsageUnit(period billing.BillingPeriod) string {
 	switch period {
 	case billing.PeriodHourly:
 		return "hours"
+	case billing.PeriodGBHourly:
+		return "GB-hours"
 	case billing.PeriodMonthly:
 		return "GB-month"
 	case billing.PeriodPerRequest:
@@ -380,6 +845,35 @@ func (e *Engine) billingPeriodToUnit(period billing.BillingPeriod) string {
 	}
 }
 
+// SpotLookback is the spot price history window used for estimation
+const SpotLookback = 30 * 24 * time.Hour
+
+// spotProductDescription maps Price List operating systems to spot product descriptions
+func spotProductDescription(operatingSystem string) string {
+	switch operatingSystem {
+	case "Windows":
+		return "Windows"
+	case "RHEL":
+		return "Red Hat Enterprise Linux"
+	case "SUSE":
+		return "SUSE Linux"
+	default:
+		return "Linux/UNIX"
+	}
+}
+
+// spotConfidence scales confidence with the amount of observed history
+func spotConfidence(samples int) float64 {
+	switch {
+	case samples >= 100:
+		return 0.75
+	case samples >= 10:
+		return 0.65
+	default:
+		return 0.5
+	}
+}
+
 func min(a, b float64) float64 {
 	if a < b {
 		return a
@@ -0,0 +1,30 @@
+package estimation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePricingDate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{in: "", want: time.Time{}},
+		{in: "2024-06-01", want: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{in: "2024-06-01T12:30:00Z", want: time.Date(2024, 6, 1, 12, 30, 0, 0, time.UTC)},
+		{in: "06/01/2024", wantErr: true},
+		{in: time.Now().AddDate(1, 0, 0).Format("2006-01-02"), wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePricingDate(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: err = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !got.Equal(tt.want) {
+			t.Errorf("%q: got %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Package estimation - Free tier offsets
+// AWS does not charge for a monthly allowance of some usage. With a free tier
+// the allowance is subtracted from the predicted usage of matching components
+// before they are priced. Allowances are pooled per account, so shared accounts
+// that use up the free tier elsewhere set how much of each remains.
+package estimation
+
+import (
+	"fmt"
+	"sort"
+
+	"terraform-cost/decision/billing"
+)
+
+// FreeTierAllowance is usage free each month, in the usage unit of the components it matches
+type FreeTierAllowance struct {
+	Name        string   `json:"name"` // ec2_micro_hours
+	Cloud       string   `json:"cloud"`
+	Service     string   `json:"service"`
+	UsageTypes  []string `json:"usage_types"` // Components with any of these usage types share the allowance
+	Amount      float64  `json:"amount"`
+	Unit        string   `json:"unit"`
+	Description string   `json:"description"`
+}
+
+// matches reports whether a component draws on the allowance
+func (a FreeTierAllowance) matches(comp billing.BillingComponent) bool {
+	if comp.Cloud != a.Cloud || comp.Service != a.Service || comp.PurchaseOption == billing.PurchaseSpot {
+		return false
+	}
+	for _, usageType := range a.UsageTypes {
+		if comp.UsageType == usageType {
+			return true
+		}
+	}
+	return false
+}
+
+// FreeTier is the allowances available to an account
+type FreeTier struct {
+	Allowances []FreeTierAllowance
+}
+
+// DefaultFreeTier returns the AWS free tier allowances, all unused
+func DefaultFreeTier() *FreeTier {
+	return &FreeTier{Allowances: []FreeTierAllowance{
+		{
+			Name: "ec2_micro_hours", Cloud: "aws", Service: "AmazonEC2",
+			UsageTypes: []string{"BoxUsage:t2.micro", "BoxUsage:t3.micro"},
+			Amount:     750, Unit: "hours", Description: "750 hours of t2.micro/t3.micro instances",
+		},
+		{
+			Name: "ebs_storage_gb", Cloud: "aws", Service: "AmazonEC2",
+			UsageTypes: []string{"EBS:VolumeUsage.gp2", "EBS:VolumeUsage.gp3", "EBS:VolumeUsage"},
+			Amount:     30, Unit: "GB-month", Description: "30 GB of EBS general purpose storage",
+		},
+		{
+			Name: "lambda_requests", Cloud: "aws", Service: "AWSLambda",
+			UsageTypes: []string{"Lambda-GB-Second"},
+			Amount:     1000000, Unit: "requests", Description: "1M Lambda requests",
+		},
+		{
+			Name: "s3_standard_gb", Cloud: "aws", Service: "AmazonS3",
+			UsageTypes: []string{"TimedStorage-ByteHrs"},
+			Amount:     5, Unit: "GB-month", Description: "5 GB of S3 Standard storage",
+		},
+		{
+			Name: "rds_micro_hours", Cloud: "aws", Service: "AmazonRDS",
+			UsageTypes: []string{"RDS:db.t2.micro", "RDS:db.t3.micro", "RDS:db.t4g.micro"},
+			Amount:     750, Unit: "hours", Description: "750 hours of db.t2/t3/t4g.micro instances",
+		},
+		{
+			Name: "rds_storage_gb", Cloud: "aws", Service: "AmazonRDS",
+			UsageTypes: []string{"RDS:GP3-Storage", "RDS:GP2-Storage"},
+			Amount:     20, Unit: "GB-month", Description: "20 GB of RDS general purpose storage",
+		},
+	}}
+}
+
+// WithRemaining sets how much of named allowances the account has left, e.g.
+// {"ec2_micro_hours": 0} when other workloads use it up
+func (f *FreeTier) WithRemaining(remaining map[string]float64) (*FreeTier, error) {
+	names := make([]string, 0, len(remaining))
+	for name := range remaining {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		amount := remaining[name]
+		if amount < 0 {
+			return nil, fmt.Errorf("free tier %s: remaining amount must not be negative", name)
+		}
+		found := false
+		for i := range f.Allowances {
+			if f.Allowances[i].Name == name {
+				f.Allowances[i].Amount = min(amount, f.Allowances[i].Amount)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown free tier allowance %q", name)
+		}
+	}
+	return f, nil
+}
+
+// FreeTierUsage is how much of an allowance an estimate used
+type FreeTierUsage struct {
+	Name    string  `json:"name"`
+	Amount  float64 `json:"amount"`   // Available
+	UsedP50 float64 `json:"used_p50"` // Offset from P50 usage
+	UsedP90 float64 `json:"used_p90"`
+	Unit    string  `json:"unit"`
+}
+
+// apply returns the components with the free tier subtracted from their usage,
+// the P50 usage offset per component ID, and what each allowance covered.
+// Allowances are drawn down in component order.
+func (f *FreeTier) apply(components []billing.BillingComponent) ([]billing.BillingComponent, map[string]float64, []FreeTierUsage) {
+	if f == nil {
+		return components, nil, nil
+	}
+	offset := make([]billing.BillingComponent, len(components))
+	copy(offset, components)
+	offsets := make(map[string]float64)
+
+	var usages []FreeTierUsage
+	for _, allowance := range f.Allowances {
+		used := FreeTierUsage{Name: allowance.Name, Amount: allowance.Amount, Unit: allowance.Unit}
+		for i := range offset {
+			comp := &offset[i]
+			if !allowance.matches(*comp) {
+				continue
+			}
+			p50 := min(comp.VarianceProfile.P50Usage, allowance.Amount-used.UsedP50)
+			p90 := min(comp.VarianceProfile.P90Usage, allowance.Amount-used.UsedP90)
+			if p50 <= 0 && p90 <= 0 {
+				continue
+			}
+			p50, p90 = max(p50, 0), max(p90, 0)
+			used.UsedP50 += p50
+			used.UsedP90 += p90
+			offsets[comp.ID] += p50
+
+			profile := comp.VarianceProfile
+			profile.P50Usage -= p50
+			profile.P90Usage -= p90
+			profile.Assumptions = append(append([]string(nil), profile.Assumptions...),
+				fmt.Sprintf("free tier: %.0f %s of %s", p50, allowance.Unit, allowance.Description))
+			comp.VarianceProfile = profile
+		}
+		if used.UsedP50 > 0 || used.UsedP90 > 0 {
+			usages = append(usages, used)
+		}
+	}
+	return offset, offsets, usages
+}
@@ -0,0 +1,56 @@
+package estimation
+
+import (
+	"testing"
+
+	"terraform-cost/decision/billing"
+)
+
+func micro(id string) billing.BillingComponent {
+	return billing.BillingComponent{
+		ID: id, Cloud: "aws", Service: "AmazonEC2", UsageType: "BoxUsage:t3.micro",
+		VarianceProfile: billing.VarianceProfile{P50Usage: 730, P90Usage: 730},
+	}
+}
+
+func TestFreeTierPooledAcrossComponents(t *testing.T) {
+	large := micro("large")
+	large.UsageType = "BoxUsage:t3.large"
+	components := []billing.BillingComponent{micro("a"), micro("b"), large}
+
+	offset, usage, used := DefaultFreeTier().apply(components)
+	if got := offset[0].VarianceProfile.P50Usage; got != 0 {
+		t.Errorf("first instance usage = %v, want 0 (fully free)", got)
+	}
+	if got := offset[1].VarianceProfile.P50Usage; got != 710 {
+		t.Errorf("second instance usage = %v, want 710 (20 free hours left)", got)
+	}
+	if got := offset[2].VarianceProfile.P50Usage; got != 730 {
+		t.Errorf("t3.large usage = %v, want 730 (not free tier)", got)
+	}
+	if usage["a"] != 730 || usage["b"] != 20 {
+		t.Errorf("offsets = %v", usage)
+	}
+	if len(used) != 1 || used[0].Name != "ec2_micro_hours" || used[0].UsedP50 != 750 {
+		t.Errorf("used = %+v, want all 750 ec2_micro_hours", used)
+	}
+	// The input components are not modified
+	if components[0].VarianceProfile.P50Usage != 730 {
+		t.Error("apply modified its input")
+	}
+}
+
+func TestFreeTierRemaining(t *testing.T) {
+	freeTier, err := DefaultFreeTier().WithRemaining(map[string]float64{"ec2_micro_hours": 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	offset, _, used := freeTier.apply([]billing.BillingComponent{micro("a")})
+	if offset[0].VarianceProfile.P50Usage != 730 || len(used) != 0 {
+		t.Errorf("usage = %v, used = %v; want no free tier left", offset[0].VarianceProfile.P50Usage, used)
+	}
+
+	if _, err := DefaultFreeTier().WithRemaining(map[string]float64{"gpu_hours": 10}); err == nil {
+		t.Error("unknown allowance accepted")
+	}
+}
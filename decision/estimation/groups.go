@@ -0,0 +1,96 @@
+// Package estimation - Cost driver aggregation
+// Collapses count/for_each instances into one line with a quantity
+package estimation
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/decision/iac"
+)
+
+// CostGroup aggregates identical cost drivers across resource instances
+type CostGroup struct {
+	// Identity
+	Key          string `json:"key"`           // base address + component suffix
+	ResourceAddr string `json:"resource_addr"` // base address without instance keys
+	Component    string `json:"component"`     // component suffix (compute, root-volume, ...)
+
+	// Classification
+	Service     string `json:"service"`
+	Region      string `json:"region"`
+	Description string `json:"description"`
+
+	// Aggregation
+	Quantity       int             `json:"quantity"`
+	Currency       string          `json:"currency"`
+	UnitCostP50    decimal.Decimal `json:"unit_cost_p50"` // per instance
+	MonthlyCostP50 decimal.Decimal `json:"monthly_cost_p50"`
+	MonthlyCostP90 decimal.Decimal `json:"monthly_cost_p90"`
+
+	// Quality
+	Confidence float64 `json:"confidence"`
+	IsSymbolic bool    `json:"is_symbolic"`
+
+	Instances []string `json:"instances,omitempty"`
+}
+
+// GroupCostDrivers groups drivers of indexed instances (aws_instance.web[0..9])
+// under their base address. Drivers are grouped per component, so each group
+// line still describes a single billable dimension.
+func GroupCostDrivers(drivers []CostDriver) []CostGroup {
+	groups := make([]CostGroup, 0)
+	index := make(map[string]int)
+
+	for _, d := range drivers {
+		base := iac.BaseAddress(d.ResourceAddr)
+		component := strings.TrimPrefix(strings.TrimPrefix(d.ComponentID, d.ResourceAddr), "-")
+		key := base
+		if component != "" {
+			key = base + "-" + component
+		}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, CostGroup{
+				Key:            key,
+				ResourceAddr:   base,
+				Component:      component,
+				Service:        d.Service,
+				Region:         d.Region,
+				Description:    d.Description,
+				Currency:       d.Currency,
+				MonthlyCostP50: decimal.Zero,
+				MonthlyCostP90: decimal.Zero,
+				Confidence:     d.Confidence,
+			})
+		}
+
+		g := &groups[i]
+		g.Quantity++
+		g.MonthlyCostP50 = g.MonthlyCostP50.Add(d.MonthlyCostP50)
+		g.MonthlyCostP90 = g.MonthlyCostP90.Add(d.MonthlyCostP90)
+		g.IsSymbolic = g.IsSymbolic || d.IsSymbolic
+		if d.Confidence < g.Confidence {
+			g.Confidence = d.Confidence
+		}
+		if base != d.ResourceAddr {
+			g.Instances = append(g.Instances, d.ResourceAddr)
+		}
+	}
+
+	for i := range groups {
+		g := &groups[i]
+		g.UnitCostP50 = g.MonthlyCostP50.Div(decimal.NewFromInt(int64(g.Quantity))).Round(4)
+		sort.Strings(g.Instances)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].MonthlyCostP50.GreaterThan(groups[j].MonthlyCostP50)
+	})
+	return groups
+}
@@ -0,0 +1,33 @@
+package estimation
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestGroupCostDrivers(t *testing.T) {
+	drivers := []CostDriver{
+		{ComponentID: "aws_instance.web[0]-compute", ResourceAddr: "aws_instance.web[0]", MonthlyCostP50: decimal.NewFromInt(30)},
+		{ComponentID: "aws_instance.web[1]-compute", ResourceAddr: "aws_instance.web[1]", MonthlyCostP50: decimal.NewFromInt(30)},
+		{ComponentID: `aws_instance.web["a.b"]-compute`, ResourceAddr: `aws_instance.web["a.b"]`, MonthlyCostP50: decimal.NewFromInt(30)},
+		{ComponentID: "aws_instance.web[0]-root-volume", ResourceAddr: "aws_instance.web[0]", MonthlyCostP50: decimal.NewFromInt(1)},
+		{ComponentID: "aws_nat_gateway.main-hours", ResourceAddr: "aws_nat_gateway.main", MonthlyCostP50: decimal.NewFromInt(32)},
+	}
+
+	groups := GroupCostDrivers(drivers)
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 groups, got %d: %+v", len(groups), groups)
+	}
+
+	compute := groups[0]
+	if compute.Key != "aws_instance.web-compute" || compute.Quantity != 3 {
+		t.Errorf("unexpected compute group: %+v", compute)
+	}
+	if !compute.MonthlyCostP50.Equal(decimal.NewFromInt(90)) || !compute.UnitCostP50.Equal(decimal.NewFromInt(30)) {
+		t.Errorf("compute cost = %s (unit %s), want 90 (unit 30)", compute.MonthlyCostP50, compute.UnitCostP50)
+	}
+	if groups[1].ResourceAddr != "aws_nat_gateway.main" || groups[1].Quantity != 1 || len(groups[1].Instances) != 0 {
+		t.Errorf("unexpected single-instance group: %+v", groups[1])
+	}
+}
@@ -0,0 +1,80 @@
+// Package estimation - Resolution hints
+// Drivers left symbolic for lack of a price explain why: the rate key that
+// failed to match, the nearest rates the pricing store has, and the command
+// that ingests the region's pricing.
+package estimation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"terraform-cost/db/clickhouse"
+)
+
+// MaxRateCandidates bounds the nearest rates listed in resolution hints
+const MaxRateCandidates = 3
+
+// CandidatePricingStore finds the rates closest to a lookup that found no price
+// Stores without it give hints without candidates.
+type CandidatePricingStore interface {
+	NearestRates(ctx context.Context, l clickhouse.RateLookup, limit int) (*clickhouse.PricingSnapshot, []clickhouse.RateCandidate, error)
+}
+
+// ResolutionHints explain how to price a symbolic driver
+type ResolutionHints struct {
+	RateKey             RateKeyHint                `json:"rate_key"`
+	Message             string                     `json:"message"`
+	UnmatchedAttributes []string                   `json:"unmatched_attributes,omitempty"` // Where the closest rate differs
+	Candidates          []clickhouse.RateCandidate `json:"candidates,omitempty"`           // Closest first
+	SuggestedCommand    string                     `json:"suggested_command,omitempty"`
+}
+
+// RateKeyHint is the rate key a lookup failed to match
+type RateKeyHint struct {
+	Cloud         string            `json:"cloud"`
+	Service       string            `json:"service"`
+	ProductFamily string            `json:"product_family"`
+	Region        string            `json:"region"`
+	Alias         string            `json:"alias,omitempty"`
+	Attributes    map[string]string `json:"attributes,omitempty"`
+	Unit          string            `json:"unit"`
+}
+
+// resolutionHints explains a lookup that found no price
+func (e *Engine) resolutionHints(ctx context.Context, l clickhouse.RateLookup) *ResolutionHints {
+	hints := &ResolutionHints{
+		RateKey: RateKeyHint{
+			Cloud:         string(l.Cloud),
+			Service:       l.Service,
+			ProductFamily: l.ProductFamily,
+			Region:        l.Region,
+			Alias:         l.Alias,
+			Attributes:    l.Attributes,
+			Unit:          l.Unit,
+		},
+		Message:          fmt.Sprintf("no %s %s rate matches the rate key in %s", l.Service, l.ProductFamily, l.Region),
+		SuggestedCommand: fmt.Sprintf("terracost pricing update --provider %s --region %s", l.Cloud, l.Region),
+	}
+
+	store, ok := e.pricingStore.(CandidatePricingStore)
+	if !ok {
+		return hints
+	}
+	snapshot, candidates, err := store.NearestRates(ctx, l, MaxRateCandidates)
+	switch {
+	case err != nil:
+		hints.Message += fmt.Sprintf(" (nearest rates unavailable: %v)", err)
+	case snapshot == nil:
+		hints.Message = fmt.Sprintf("no pricing snapshot covers %s %s; ingest its pricing", l.Cloud, l.Region)
+	case len(candidates) == 0:
+		hints.Message = fmt.Sprintf("snapshot %s has no %s %s rates in %s", snapshot.ID, l.Service, l.ProductFamily, l.Region)
+	default:
+		hints.Candidates = candidates
+		hints.UnmatchedAttributes = candidates[0].Mismatched
+		if len(hints.UnmatchedAttributes) > 0 {
+			hints.Message = fmt.Sprintf("the closest %s %s rate differs on %s", l.Service, l.ProductFamily, strings.Join(hints.UnmatchedAttributes, ", "))
+		}
+	}
+	return hints
+}
@@ -0,0 +1,83 @@
+package estimation
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/billing"
+)
+
+// candidateStore prices nothing and offers fixed nearest rates
+type candidateStore struct {
+	snapshot   *clickhouse.PricingSnapshot
+	candidates []clickhouse.RateCandidate
+}
+
+func (s *candidateStore) ResolveRate(ctx context.Context, cloud clickhouse.CloudProvider, service, productFamily, region string, attrs map[string]string, unit, alias string) (*clickhouse.ResolvedRate, error) {
+	return nil, nil
+}
+
+func (s *candidateStore) ResolveRatesBatch(ctx context.Context, lookups []clickhouse.RateLookup) (map[string]*clickhouse.ResolvedRate, error) {
+	return map[string]*clickhouse.ResolvedRate{}, nil
+}
+
+func (s *candidateStore) ResolveSpotRate(ctx context.Context, cloud clickhouse.CloudProvider, region, instanceType, productDescription string, lookback time.Duration, at time.Time) (*clickhouse.SpotRate, error) {
+	return nil, nil
+}
+
+func (s *candidateStore) NearestRates(ctx context.Context, l clickhouse.RateLookup, limit int) (*clickhouse.PricingSnapshot, []clickhouse.RateCandidate, error) {
+	return s.snapshot, s.candidates, nil
+}
+
+func TestResolutionHints(t *testing.T) {
+	comp := billing.BillingComponent{
+		ID: "c1", ResourceAddr: "aws_instance.web", Cloud: "aws", Service: "AmazonEC2",
+		ProductFamily: "Compute Instance", Region: "eu-west-3", BillingPeriod: billing.PeriodHourly,
+		Attributes:      map[string]string{"instanceType": "m9.large", "tenancy": "Shared"},
+		VarianceProfile: billing.VarianceProfile{P50Usage: 730, P90Usage: 730, Confidence: 1},
+	}
+	estimate := func(store PricingStore) *ResolutionHints {
+		t.Helper()
+		result, err := NewEngine(store).Estimate(context.Background(), EstimationRequest{Components: []billing.BillingComponent{comp}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		d := result.CostDrivers[0]
+		if !d.IsSymbolic || d.Reason != ReasonNoPricing || d.ResolutionHints == nil {
+			t.Fatalf("unexpected driver: %+v", d)
+		}
+		return d.ResolutionHints
+	}
+
+	store := &candidateStore{
+		snapshot: &clickhouse.PricingSnapshot{ID: uuid.New()},
+		candidates: []clickhouse.RateCandidate{{
+			Attributes: map[string]string{"instanceType": "m7i.large", "tenancy": "Shared"},
+			Unit:       "hours", Price: decimal.RequireFromString("0.1008"), Currency: "USD",
+			Mismatched: []string{"instanceType"},
+		}},
+	}
+	hints := estimate(store)
+	if !reflect.DeepEqual(hints.UnmatchedAttributes, []string{"instanceType"}) || len(hints.Candidates) != 1 {
+		t.Errorf("unexpected hints: %+v", hints)
+	}
+	if hints.RateKey.Service != "AmazonEC2" || hints.RateKey.Attributes["instanceType"] != "m9.large" || hints.RateKey.Unit != "hours" {
+		t.Errorf("rate key = %+v", hints.RateKey)
+	}
+	if hints.SuggestedCommand != "terracost pricing update --provider aws --region eu-west-3" {
+		t.Errorf("command = %q", hints.SuggestedCommand)
+	}
+
+	// No snapshot for the region at all
+	hints = estimate(&candidateStore{})
+	if !strings.Contains(hints.Message, "no pricing snapshot") || hints.Candidates != nil {
+		t.Errorf("unexpected hints: %+v", hints)
+	}
+}
@@ -0,0 +1,56 @@
+// Package estimation - Estimation history
+// Converts results into records for the ClickHouse estimation history
+package estimation
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"terraform-cost/db/clickhouse"
+)
+
+// HistoryMeta identifies where a saved estimation came from
+type HistoryMeta struct {
+	Project       string
+	Branch        string
+	CommitSHA     string
+	PullRequest   string
+	Environment   string
+	Source        string // cli, api, ci
+	ResourceCount int
+	PolicyResult  string
+}
+
+// NewEstimationRecord converts an estimation result into a history record
+func NewEstimationRecord(result *EstimationResult, meta HistoryMeta) (*clickhouse.EstimationRecord, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode estimation result: %w", err)
+	}
+	return &clickhouse.EstimationRecord{
+		Project:        meta.Project,
+		Branch:         meta.Branch,
+		CommitSHA:      meta.CommitSHA,
+		PullRequest:    meta.PullRequest,
+		Environment:    meta.Environment,
+		Source:         meta.Source,
+		MonthlyCostP50: result.MonthlyCostP50,
+		MonthlyCostP90: result.MonthlyCostP90,
+		CarbonKgCO2:    result.CarbonKgCO2,
+		Confidence:     result.Confidence,
+		IsIncomplete:   result.IsIncomplete,
+		ResourceCount:  meta.ResourceCount,
+		PolicyResult:   meta.PolicyResult,
+		ResultJSON:     string(data),
+		CreatedAt:      result.AuditTrail.EstimatedAt,
+	}, nil
+}
+
+// ResultFromRecord decodes the estimation result saved with a history record
+func ResultFromRecord(rec *clickhouse.EstimationRecord) (*EstimationResult, error) {
+	var result EstimationResult
+	if err := json.Unmarshal([]byte(rec.ResultJSON), &result); err != nil {
+		return nil, fmt.Errorf("failed to decode estimation %s: %w", rec.ID, err)
+	}
+	return &result, nil
+}
@@ -0,0 +1,100 @@
+// Package estimation - Merging estimates
+// Several plans deployed together (one per workspace or stack) are judged as a
+// whole by merging their estimates into one result.
+package estimation
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// Merge combines estimates of the same currency into one
+// Totals, drivers and statistics are summed; confidence is rescored over all
+// drivers, with unmapped the resources the plans could not map. Simulations
+// and free tier usage are per plan and are not carried over.
+func Merge(unmapped int, results ...*EstimationResult) (*EstimationResult, error) {
+	merged := &EstimationResult{
+		CarbonByRegion: make(map[string]float64),
+		CostDrivers:    make([]CostDriver, 0),
+		Errors:         make([]EstimationError, 0),
+		Warnings:       make([]string, 0),
+		AuditTrail:     AuditTrail{SnapshotsUsed: make(map[string]uuid.UUID)},
+	}
+	var allocationTags []string
+	for i, r := range results {
+		if i == 0 {
+			merged.Currency = r.Currency
+			merged.AuditTrail = r.AuditTrail
+			merged.AuditTrail.SnapshotsUsed = make(map[string]uuid.UUID)
+			merged.AuditTrail.RegionAliases = nil
+		} else if r.Currency != merged.Currency {
+			return nil, fmt.Errorf("cannot merge %s and %s estimates", merged.Currency, r.Currency)
+		}
+		merged.MonthlyCostP50 = merged.MonthlyCostP50.Add(r.MonthlyCostP50)
+		merged.MonthlyCostP90 = merged.MonthlyCostP90.Add(r.MonthlyCostP90)
+		merged.HourlyCostP50 = merged.HourlyCostP50.Add(r.HourlyCostP50)
+		merged.CarbonKgCO2 += r.CarbonKgCO2
+		for region, kg := range r.CarbonByRegion {
+			merged.CarbonByRegion[region] += kg
+		}
+		merged.CostDrivers = append(merged.CostDrivers, r.CostDrivers...)
+		merged.Errors = append(merged.Errors, r.Errors...)
+		merged.Warnings = append(merged.Warnings, r.Warnings...)
+		merged.IsIncomplete = merged.IsIncomplete || r.IsIncomplete
+		merged.ComponentsProcessed += r.ComponentsProcessed
+		merged.ComponentsEstimated += r.ComponentsEstimated
+		merged.ComponentsSymbolic += r.ComponentsSymbolic
+		for region, id := range r.AuditTrail.SnapshotsUsed {
+			merged.AuditTrail.SnapshotsUsed[region] = id
+		}
+		for region, alias := range r.AuditTrail.RegionAliases {
+			if merged.AuditTrail.RegionAliases == nil {
+				merged.AuditTrail.RegionAliases = make(map[string]string)
+			}
+			merged.AuditTrail.RegionAliases[region] = alias
+		}
+		if r.FirstMonth != nil {
+			if merged.FirstMonth == nil {
+				merged.FirstMonth = &ProratedMonth{ProvisionedAt: r.FirstMonth.ProvisionedAt, Fraction: r.FirstMonth.Fraction}
+			}
+			merged.FirstMonth.MonthlyCostP50 = merged.FirstMonth.MonthlyCostP50.Add(r.FirstMonth.MonthlyCostP50)
+			merged.FirstMonth.MonthlyCostP90 = merged.FirstMonth.MonthlyCostP90.Add(r.FirstMonth.MonthlyCostP90)
+		}
+		if r.Discounts != nil {
+			if merged.Discounts == nil {
+				merged.Discounts = &DiscountSummary{Source: r.Discounts.Source}
+			}
+			merged.Discounts.ListMonthlyCostP50 = merged.Discounts.ListMonthlyCostP50.Add(r.Discounts.ListMonthlyCostP50)
+			merged.Discounts.ListMonthlyCostP90 = merged.Discounts.ListMonthlyCostP90.Add(r.Discounts.ListMonthlyCostP90)
+			merged.Discounts.SavingsP50 = merged.Discounts.SavingsP50.Add(r.Discounts.SavingsP50)
+		}
+		for key := range r.CostByTag {
+			allocationTags = append(allocationTags, key)
+		}
+	}
+
+	sort.SliceStable(merged.CostDrivers, func(i, j int) bool {
+		return merged.CostDrivers[i].MonthlyCostP50.GreaterThan(merged.CostDrivers[j].MonthlyCostP50)
+	})
+	merged.CostGroups = GroupCostDrivers(merged.CostDrivers)
+	if len(allocationTags) > 0 {
+		sort.Strings(allocationTags)
+		merged.CostByTag = AllocateByTag(merged.CostDrivers, dedupe(allocationTags))
+	}
+	merged.CostByModule = RollupByModule(merged.CostDrivers)
+	merged.Confidence, merged.ConfidenceScores = ScoreConfidence(merged.CostDrivers, unmapped)
+	return merged, nil
+}
+
+// dedupe removes adjacent duplicates from a sorted slice
+func dedupe(values []string) []string {
+	out := values[:0]
+	for i, v := range values {
+		if i == 0 || v != values[i-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
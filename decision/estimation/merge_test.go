@@ -0,0 +1,66 @@
+package estimation
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestMerge(t *testing.T) {
+	a := &EstimationResult{
+		Currency:       "USD",
+		MonthlyCostP50: decimal.NewFromInt(100),
+		MonthlyCostP90: decimal.NewFromInt(120),
+		CarbonKgCO2:    2,
+		CarbonByRegion: map[string]float64{"us-east-1": 2},
+		CostDrivers: []CostDriver{
+			{ComponentID: "aws_instance.web-compute", ResourceAddr: "aws_instance.web", MonthlyCostP50: decimal.NewFromInt(100), ResourceTags: map[string]string{"team": "web"}},
+		},
+		CostByTag:           map[string]map[string]decimal.Decimal{"team": {"web": decimal.NewFromInt(100)}},
+		ComponentsProcessed: 1,
+		ComponentsEstimated: 1,
+	}
+	b := &EstimationResult{
+		Currency:       "USD",
+		MonthlyCostP50: decimal.NewFromInt(50),
+		MonthlyCostP90: decimal.NewFromInt(60),
+		CarbonKgCO2:    1,
+		CarbonByRegion: map[string]float64{"us-east-1": 1},
+		CostDrivers: []CostDriver{
+			{ComponentID: "aws_db_instance.db-compute", ResourceAddr: "aws_db_instance.db", MonthlyCostP50: decimal.NewFromInt(50)},
+			{ComponentID: "aws_lambda_function.fn-requests", ResourceAddr: "aws_lambda_function.fn", IsSymbolic: true},
+		},
+		IsIncomplete:        true,
+		ComponentsProcessed: 2,
+		ComponentsEstimated: 1,
+		ComponentsSymbolic:  1,
+	}
+
+	merged, err := Merge(1, a, b)
+	if err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+	if !merged.MonthlyCostP50.Equal(decimal.NewFromInt(150)) || !merged.MonthlyCostP90.Equal(decimal.NewFromInt(180)) {
+		t.Errorf("totals = %s / %s, want 150 / 180", merged.MonthlyCostP50, merged.MonthlyCostP90)
+	}
+	if merged.CarbonKgCO2 != 3 || merged.CarbonByRegion["us-east-1"] != 3 {
+		t.Errorf("carbon = %v %v, want 3", merged.CarbonKgCO2, merged.CarbonByRegion)
+	}
+	if !merged.IsIncomplete || merged.ComponentsProcessed != 3 || merged.ComponentsSymbolic != 1 {
+		t.Errorf("stats = incomplete %v, processed %d, symbolic %d", merged.IsIncomplete, merged.ComponentsProcessed, merged.ComponentsSymbolic)
+	}
+	if len(merged.CostDrivers) != 3 || merged.CostDrivers[0].ResourceAddr != "aws_instance.web" {
+		t.Errorf("drivers not merged highest first: %+v", merged.CostDrivers)
+	}
+	if got := merged.CostByTag["team"][UntaggedValue]; !got.Equal(decimal.NewFromInt(50)) {
+		t.Errorf("untagged team cost = %s, want 50", got)
+	}
+	// 2 of 3 drivers priced plus one unmapped resource
+	if merged.ConfidenceScores.Coverage != 0.5 {
+		t.Errorf("coverage = %v, want 0.5", merged.ConfidenceScores.Coverage)
+	}
+
+	if _, err := Merge(0, a, &EstimationResult{Currency: "EUR"}); err == nil {
+		t.Error("expected an error merging USD and EUR estimates")
+	}
+}
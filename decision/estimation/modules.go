@@ -0,0 +1,111 @@
+// Package estimation - Module cost rollup
+// Teams budget by Terraform module, so cost is rolled up along the module tree:
+// a module's cost includes its child modules, and count/for_each instances of a
+// module are combined.
+package estimation
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/decision/iac"
+)
+
+// RootModule labels the cost of resources outside any module
+const RootModule = "(root)"
+
+// ModuleCost is a module's cost, including its child modules
+type ModuleCost struct {
+	Module         string          `json:"module"` // module.vpc.module.subnets, or RootModule
+	Name           string          `json:"name"`   // subnets
+	MonthlyCostP50 decimal.Decimal `json:"monthly_cost_p50"`
+	MonthlyCostP90 decimal.Decimal `json:"monthly_cost_p90"`
+	Resources      int             `json:"resources"`
+	Children       []ModuleCost    `json:"children,omitempty"`
+}
+
+// RollupByModule totals cost along the module tree, highest cost first at each level
+// Plans without modules have no rollup (nil); otherwise resources outside modules
+// are a top-level RootModule entry.
+func RollupByModule(drivers []CostDriver) []ModuleCost {
+	type rollup struct {
+		cost      ModuleCost
+		resources map[string]bool
+		children  []string
+	}
+	modules := make(map[string]*rollup)
+	var top []string
+	var get func(path string) *rollup
+	get = func(path string) *rollup {
+		if m, ok := modules[path]; ok {
+			return m
+		}
+		m := &rollup{cost: ModuleCost{Module: path, Name: moduleName(path)}, resources: make(map[string]bool)}
+		modules[path] = m
+		if parent := parentModule(path); parent != "" {
+			p := get(parent)
+			p.children = append(p.children, path)
+		} else {
+			top = append(top, path)
+		}
+		return m
+	}
+
+	nested := false
+	for _, d := range drivers {
+		path := iac.BaseAddress(d.Module)
+		if path == "" {
+			path = RootModule
+		} else {
+			nested = true
+		}
+		for ; path != ""; path = parentModule(path) {
+			m := get(path)
+			m.cost.MonthlyCostP50 = m.cost.MonthlyCostP50.Add(d.MonthlyCostP50)
+			m.cost.MonthlyCostP90 = m.cost.MonthlyCostP90.Add(d.MonthlyCostP90)
+			m.resources[d.ResourceAddr] = true
+		}
+	}
+	if !nested {
+		return nil
+	}
+
+	var build func(paths []string) []ModuleCost
+	build = func(paths []string) []ModuleCost {
+		costs := make([]ModuleCost, 0, len(paths))
+		for _, path := range paths {
+			m := modules[path]
+			m.cost.Resources = len(m.resources)
+			if len(m.children) > 0 {
+				m.cost.Children = build(m.children)
+			}
+			costs = append(costs, m.cost)
+		}
+		sort.Slice(costs, func(i, j int) bool {
+			if !costs[i].MonthlyCostP50.Equal(costs[j].MonthlyCostP50) {
+				return costs[i].MonthlyCostP50.GreaterThan(costs[j].MonthlyCostP50)
+			}
+			return costs[i].Module < costs[j].Module
+		})
+		return costs
+	}
+	return build(top)
+}
+
+// parentModule returns the module containing a module, or "" for top-level modules
+func parentModule(path string) string {
+	if i := strings.LastIndex(path, ".module."); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+// moduleName returns a module's name within its parent
+func moduleName(path string) string {
+	if i := strings.LastIndex(path, ".module."); i >= 0 {
+		path = path[i+1:]
+	}
+	return strings.TrimPrefix(path, "module.")
+}
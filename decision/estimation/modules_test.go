@@ -0,0 +1,44 @@
+package estimation
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestRollupByModule(t *testing.T) {
+	drivers := []CostDriver{
+		{ResourceAddr: "aws_instance.web", MonthlyCostP50: decimal.NewFromInt(10)},
+		{ResourceAddr: "module.vpc.aws_nat_gateway.this", Module: "module.vpc", MonthlyCostP50: decimal.NewFromInt(30)},
+		{ResourceAddr: `module.vpc.module.subnets["a"].aws_eip.nat`, Module: `module.vpc.module.subnets["a"]`, MonthlyCostP50: decimal.NewFromInt(4)},
+		{ResourceAddr: `module.vpc.module.subnets["b"].aws_eip.nat`, Module: `module.vpc.module.subnets["b"]`, MonthlyCostP50: decimal.NewFromInt(4)},
+		{ResourceAddr: "module.db.aws_db_instance.main", Module: "module.db", MonthlyCostP50: decimal.NewFromInt(20)},
+	}
+
+	got := RollupByModule(drivers)
+
+	if len(got) != 3 {
+		t.Fatalf("got %d top-level entries, want vpc, db and root: %+v", len(got), got)
+	}
+	vpc, db, root := got[0], got[1], got[2]
+	if vpc.Module != "module.vpc" || !vpc.MonthlyCostP50.Equal(decimal.NewFromInt(38)) || vpc.Resources != 3 {
+		t.Errorf("vpc = %+v, want module.vpc at 38 with 3 resources", vpc)
+	}
+	if db.Name != "db" || root.Module != RootModule || !root.MonthlyCostP50.Equal(decimal.NewFromInt(10)) {
+		t.Errorf("db = %+v, root = %+v", db, root)
+	}
+	if len(vpc.Children) != 1 {
+		t.Fatalf("vpc children = %+v, want subnets instances combined", vpc.Children)
+	}
+	subnets := vpc.Children[0]
+	if subnets.Module != "module.vpc.module.subnets" || subnets.Name != "subnets" || !subnets.MonthlyCostP50.Equal(decimal.NewFromInt(8)) {
+		t.Errorf("subnets = %+v", subnets)
+	}
+}
+
+func TestRollupByModuleWithoutModules(t *testing.T) {
+	drivers := []CostDriver{{ResourceAddr: "aws_instance.web", MonthlyCostP50: decimal.NewFromInt(10)}}
+	if got := RollupByModule(drivers); got != nil {
+		t.Errorf("got %+v, want no rollup for a plan without modules", got)
+	}
+}
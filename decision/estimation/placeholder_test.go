@@ -0,0 +1,51 @@
+package estimation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/iac"
+)
+
+func TestEstimatePlaceholderCosts(t *testing.T) {
+	graph := &iac.Graph{Nodes: map[string]*iac.GraphNode{
+		"acme_widget.a": {Resource: iac.ResourceNode{Address: "acme_widget.a", Type: "acme_widget", Mode: "managed"}, Provider: "acme"},
+		"acme_gadget.b": {Resource: iac.ResourceNode{Address: "acme_gadget.b", Type: "acme_gadget", Mode: "managed"}, Provider: "acme"},
+	}}
+	engine := billing.NewEngine().WithFallbackMapper(billing.NewPlaceholderMapper(billing.PlaceholderCosts{
+		ByType: map[string]float64{"acme_widget": 25},
+	}))
+	decomposition, err := engine.Decompose(graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decomposition.Components) != 2 || len(decomposition.UncoveredTypes) != 2 {
+		t.Fatalf("got %d components, uncovered %v; want 2 placeholders still reported uncovered",
+			len(decomposition.Components), decomposition.UncoveredTypes)
+	}
+
+	result, err := NewEngine(&tieredStore{}).Estimate(context.Background(), EstimationRequest{Components: decomposition.Components})
+	if err != nil {
+		t.Fatal(err)
+	}
+	drivers := make(map[string]CostDriver)
+	for _, d := range result.CostDrivers {
+		drivers[d.ResourceAddr] = d
+	}
+
+	widget := drivers["acme_widget.a"]
+	if !widget.MonthlyCostP50.Equal(decimal.NewFromInt(25)) || widget.IsSymbolic || widget.Reason != ReasonPlaceholder {
+		t.Errorf("widget = %s symbolic=%v reason=%q; want 25 priced placeholder", widget.MonthlyCostP50, widget.IsSymbolic, widget.Reason)
+	}
+	// The default cost is zero: listed, but unpriced
+	gadget := drivers["acme_gadget.b"]
+	if !gadget.MonthlyCostP50.IsZero() || !gadget.IsSymbolic || gadget.Reason != ReasonPlaceholder {
+		t.Errorf("gadget = %s symbolic=%v reason=%q; want symbolic placeholder", gadget.MonthlyCostP50, gadget.IsSymbolic, gadget.Reason)
+	}
+	if !result.MonthlyCostP50.Equal(decimal.NewFromInt(25)) {
+		t.Errorf("total = %s, want 25", result.MonthlyCostP50)
+	}
+}
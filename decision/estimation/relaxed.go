@@ -0,0 +1,169 @@
+// Package estimation - Relaxed rate matching
+// With RelaxedMatching, a lookup whose exact rate key has no price is retried
+// without low-signal attributes, then against the nearest size of the same
+// instance family with the price scaled by AWS normalization factors. Prices
+// found this way carry a confidence penalty and say how they matched.
+package estimation
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+)
+
+// LowSignalAttributes are dropped one at a time, in order, by relaxed matching
+var LowSignalAttributes = []string{"capacityStatus", "licenseModel"}
+
+// Relaxed matching confidence penalties, multiplied into the rate's confidence
+const (
+	DroppedAttributePenalty = 0.9 // Per low-signal attribute dropped
+	NearestSizePenalty      = 0.6 // Priced from another size of the family
+)
+
+// instanceSizes are the sizes tried for a nearest-size match
+var instanceSizes = []string{
+	"nano", "micro", "small", "medium", "large", "xlarge", "2xlarge", "3xlarge", "4xlarge", "6xlarge",
+	"8xlarge", "9xlarge", "12xlarge", "16xlarge", "18xlarge", "24xlarge", "32xlarge", "48xlarge",
+}
+
+// relaxedRate resolves a lookup with no exact price by relaxing it
+// It returns the lookup that matched and how, or a nil rate when nothing did.
+func (e *Engine) relaxedRate(ctx context.Context, lookup clickhouse.RateLookup) (*clickhouse.ResolvedRate, clickhouse.RateLookup, string, error) {
+	// Drop low-signal attributes progressively
+	relaxed := lookup
+	relaxed.Attributes = copyAttributes(lookup.Attributes)
+	var dropped []string
+	for _, attr := range LowSignalAttributes {
+		if _, ok := relaxed.Attributes[attr]; !ok {
+			continue
+		}
+		delete(relaxed.Attributes, attr)
+		dropped = append(dropped, attr)
+
+		rates, err := e.pricingStore.ResolveRatesBatch(ctx, []clickhouse.RateLookup{relaxed})
+		if err != nil {
+			return nil, lookup, "", fmt.Errorf("pricing resolution failed: %w", err)
+		}
+		if rate := rates[relaxed.Key()]; rate != nil {
+			penalized := *rate
+			penalized.Confidence *= math.Pow(DroppedAttributePenalty, float64(len(dropped)))
+			return &penalized, relaxed, "rate key without " + strings.Join(dropped, ", "), nil
+		}
+	}
+
+	// Nearest size of the same instance family, without the dropped attributes
+	instanceType := relaxed.Attributes["instanceType"]
+	family, size := splitInstanceType(instanceType)
+	want, ok := sizeFactor(size)
+	if !ok || isAccelerated(family) {
+		return nil, lookup, "", nil
+	}
+	var lookups []clickhouse.RateLookup
+	for _, s := range instanceSizes {
+		if s == size {
+			continue
+		}
+		l := relaxed
+		l.Attributes = copyAttributes(relaxed.Attributes)
+		l.Attributes["instanceType"] = family + "." + s
+		lookups = append(lookups, l)
+	}
+	rates, err := e.pricingStore.ResolveRatesBatch(ctx, lookups)
+	if err != nil {
+		return nil, lookup, "", fmt.Errorf("pricing resolution failed: %w", err)
+	}
+
+	var best *clickhouse.ResolvedRate
+	var bestLookup clickhouse.RateLookup
+	var bestFactor, bestDistance float64
+	for _, l := range lookups {
+		rate := rates[l.Key()]
+		if rate == nil {
+			continue
+		}
+		_, s := splitInstanceType(l.Attributes["instanceType"])
+		factor, _ := sizeFactor(s)
+		// Sizes are tried smallest first, so ties go to the smaller size
+		if distance := math.Abs(math.Log2(factor / want)); best == nil || distance < bestDistance {
+			best, bestLookup, bestFactor, bestDistance = rate, l, factor, distance
+		}
+	}
+	if best == nil {
+		return nil, lookup, "", nil
+	}
+
+	scale := want / bestFactor
+	scaled := *best
+	scaled.Price = best.Price.Mul(decimal.NewFromFloat(scale))
+	scaled.Confidence *= NearestSizePenalty * math.Pow(DroppedAttributePenalty, float64(len(dropped)))
+	// The scaled price is a single flat rate
+	scaled.TierMin, scaled.TierMax = nil, nil
+	via := fmt.Sprintf("%s price scaled ×%s by normalization factor",
+		bestLookup.Attributes["instanceType"], strconv.FormatFloat(scale, 'f', -1, 64))
+	if len(dropped) > 0 {
+		via += " and rate key without " + strings.Join(dropped, ", ")
+	}
+	return &scaled, bestLookup, via, nil
+}
+
+// sizeFactor returns the AWS normalization factor of an instance size (small = 1)
+func sizeFactor(size string) (float64, bool) {
+	switch size {
+	case "nano":
+		return 0.25, true
+	case "micro":
+		return 0.5, true
+	case "small":
+		return 1, true
+	case "medium":
+		return 2, true
+	case "large":
+		return 4, true
+	case "xlarge":
+		return 8, true
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(size, "xlarge"))
+	if err != nil || !strings.HasSuffix(size, "xlarge") || n <= 0 {
+		return 0, false
+	}
+	return float64(8 * n), true
+}
+
+// acceleratedFamilies are the prefixes of GPU, Inferentia, Trainium and FPGA
+// families, which are priced by their accelerators rather than by size: a
+// g5.12xlarge has 12 times the vCPUs of a g5.xlarge but 4 GPUs to its 1
+var acceleratedFamilies = []string{"p2", "p3", "p4", "p5", "g3", "g4", "g5", "g6", "gr6", "inf", "trn", "dl1", "dl2", "f1", "f2", "vt1"}
+
+// isAccelerated reports whether an instance family (g5, ml.g5) is accelerated
+func isAccelerated(family string) bool {
+	family = family[strings.LastIndex(family, ".")+1:]
+	for _, prefix := range acceleratedFamilies {
+		if strings.HasPrefix(family, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitInstanceType splits m5.large into (m5, large) and db.r5.large into (db.r5, large)
+func splitInstanceType(instanceType string) (family, size string) {
+	i := strings.LastIndex(instanceType, ".")
+	if i < 0 {
+		return instanceType, ""
+	}
+	return instanceType[:i], instanceType[i+1:]
+}
+
+func copyAttributes(attrs map[string]string) map[string]string {
+	copied := make(map[string]string, len(attrs))
+	for k, v := range attrs {
+		copied[k] = v
+	}
+	return copied
+}
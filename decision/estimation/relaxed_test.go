@@ -0,0 +1,119 @@
+package estimation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/billing"
+)
+
+// keyedStore prices only the lookups whose keys it holds
+type keyedStore map[string]*clickhouse.ResolvedRate
+
+func (s keyedStore) ResolveRate(ctx context.Context, cloud clickhouse.CloudProvider, service, productFamily, region string, attrs map[string]string, unit, alias string) (*clickhouse.ResolvedRate, error) {
+	return nil, nil
+}
+
+func (s keyedStore) ResolveRatesBatch(ctx context.Context, lookups []clickhouse.RateLookup) (map[string]*clickhouse.ResolvedRate, error) {
+	rates := make(map[string]*clickhouse.ResolvedRate)
+	for _, l := range lookups {
+		rates[l.Key()] = s[l.Key()]
+	}
+	return rates, nil
+}
+
+func (s keyedStore) ResolveSpotRate(ctx context.Context, cloud clickhouse.CloudProvider, region, instanceType, productDescription string, lookback time.Duration, at time.Time) (*clickhouse.SpotRate, error) {
+	return nil, nil
+}
+
+func TestRelaxedMatching(t *testing.T) {
+	instance := func(instanceType string, attrs map[string]string) billing.BillingComponent {
+		a := map[string]string{"instanceType": instanceType, "operatingSystem": "Linux", "tenancy": "Shared"}
+		for k, v := range attrs {
+			a[k] = v
+		}
+		return billing.BillingComponent{
+			ID: instanceType, ResourceAddr: "aws_instance.web", Cloud: "aws", Service: "AmazonEC2",
+			ProductFamily: "Compute Instance", Region: "us-east-1", BillingPeriod: billing.PeriodHourly,
+			Attributes:      a,
+			VarianceProfile: billing.VarianceProfile{P50Usage: 100, P90Usage: 100, Confidence: 1},
+		}
+	}
+	engine := NewEngine(nil)
+	store := keyedStore{}
+	price := func(comp billing.BillingComponent, p string) {
+		store[engine.rateLookup(comp, EstimationRequest{PricingAlias: "default"}).Key()] = &clickhouse.ResolvedRate{
+			Price: decimal.RequireFromString(p), Currency: "USD", Confidence: 1, SnapshotID: uuid.New(),
+		}
+	}
+	price(instance("m7i.large", nil), "0.1")
+	price(instance("c7i.large", nil), "0.09")
+	price(instance("g5.xlarge", nil), "1.006")
+	engine.pricingStore = store
+
+	tests := []struct {
+		name       string
+		comp       billing.BillingComponent
+		cost       string
+		confidence float64
+		matchedVia string
+	}{
+		{"exact", instance("m7i.large", nil), "10", 1, ""},
+		{"drops capacity status", instance("m7i.large", map[string]string{"capacityStatus": "Used"}), "10", 0.9, "rate key without capacityStatus"},
+		{"drops both", instance("m7i.large", map[string]string{"capacityStatus": "Used", "licenseModel": "No License required"}), "10", 0.81, "rate key without capacityStatus, licenseModel"},
+		{"nearest size", instance("m7i.2xlarge", nil), "40", 0.6, "m7i.large price scaled ×4 by normalization factor"},
+		{"nearest size without capacity status", instance("m7i.2xlarge", map[string]string{"capacityStatus": "Used"}), "40", 0.54,
+			"m7i.large price scaled ×4 by normalization factor and rate key without capacityStatus"},
+		{"other family", instance("r7i.large", nil), "0", 0, ""},
+		{"accelerated family", instance("g5.12xlarge", nil), "0", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := engine.Estimate(context.Background(), EstimationRequest{
+				Components: []billing.BillingComponent{tt.comp}, PricingAlias: "default", RelaxedMatching: true,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			d := result.CostDrivers[0]
+			if !d.MonthlyCostP50.Equal(decimal.RequireFromString(tt.cost)) {
+				t.Errorf("cost = %s, want %s", d.MonthlyCostP50, tt.cost)
+			}
+			if d.PricingConfidence < tt.confidence-1e-9 || d.PricingConfidence > tt.confidence+1e-9 {
+				t.Errorf("pricing confidence = %v, want %v", d.PricingConfidence, tt.confidence)
+			}
+			if d.MatchedVia != tt.matchedVia {
+				t.Errorf("matched via = %q, want %q", d.MatchedVia, tt.matchedVia)
+			}
+			if tt.cost == "0" && !d.IsSymbolic {
+				t.Error("want symbolic driver")
+			}
+		})
+	}
+}
+
+func TestIsAccelerated(t *testing.T) {
+	for family, want := range map[string]bool{"p4d": true, "g5": true, "ml.g5": true, "inf2": true, "trn1": true, "m7i": false, "ml.m5": false, "t3": false} {
+		if got := isAccelerated(family); got != want {
+			t.Errorf("isAccelerated(%s) = %v, want %v", family, got, want)
+		}
+	}
+}
+
+func TestSizeFactor(t *testing.T) {
+	for size, want := range map[string]float64{"nano": 0.25, "large": 4, "xlarge": 8, "12xlarge": 96} {
+		if got, ok := sizeFactor(size); !ok || got != want {
+			t.Errorf("sizeFactor(%s) = %v, %v", size, got, ok)
+		}
+	}
+	for _, size := range []string{"metal", "xxlarge", ""} {
+		if _, ok := sizeFactor(size); ok {
+			t.Errorf("sizeFactor(%s) ok", size)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+// Package estimation - Monte Carlo simulation
+// Samples every component's usage distribution to produce cost percentiles
+// and a histogram, instead of summing per-component P50/P90 values.
+package estimation
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/decision/billing"
+)
+
+// DefaultSimulationIterations is the number of samples when none is requested
+const DefaultSimulationIterations = 10000
+
+// DefaultSimulationSeed keeps repeated runs on the same plan identical
+const DefaultSimulationSeed = 1
+
+// defaultHistogramBuckets is the number of histogram buckets in a simulation
+const defaultHistogramBuckets = 20
+
+// SimulationOptions configures a Monte Carlo run
+type SimulationOptions struct {
+	Iterations int   // samples (default DefaultSimulationIterations)
+	Seed       int64 // random seed (default DefaultSimulationSeed)
+	Buckets    int   // histogram buckets (default 20)
+}
+
+// Simulation is the distribution of total monthly cost across samples
+type Simulation struct {
+	Iterations int               `json:"iterations"`
+	Seed       int64             `json:"seed"`
+	Mean       decimal.Decimal   `json:"mean"`
+	P10        decimal.Decimal   `json:"p10"`
+	P50        decimal.Decimal   `json:"p50"`
+	P90        decimal.Decimal   `json:"p90"`
+	P99        decimal.Decimal   `json:"p99"`
+	Histogram  []HistogramBucket `json:"histogram"`
+}
+
+// HistogramBucket counts samples with a total in [Lower, Upper)
+type HistogramBucket struct {
+	Lower decimal.Decimal `json:"lower"`
+	Upper decimal.Decimal `json:"upper"`
+	Count int             `json:"count"`
+}
+
+// simulatedDriver is a priced driver's sampling inputs
+type simulatedDriver struct {
+	resource   string
+	unitPrice  float64
+	lo, mode   float64
+	hi         float64
+	volatility float64
+}
+
+// Simulate samples the usage of every priced driver and aggregates the totals
+//
+// Components of one resource share a draw, so an instance's compute and storage
+// move together. Volatile, demand-driven usage is also correlated with a
+// plan-wide demand factor in proportion to its volatility score; fixed
+// capacity (min == max) is constant. Usage is triangular over [min, max]
+// with the P50 as its mode.
+func Simulate(drivers []CostDriver, components []billing.BillingComponent, opts SimulationOptions) *Simulation {
+	if opts.Iterations <= 0 {
+		opts.Iterations = DefaultSimulationIterations
+	}
+	if opts.Seed == 0 {
+		opts.Seed = DefaultSimulationSeed
+	}
+	if opts.Buckets <= 0 {
+		opts.Buckets = defaultHistogramBuckets
+	}
+
+	profiles := make(map[string]billing.VarianceProfile, len(components))
+	for _, c := range components {
+		profiles[c.ID] = c.VarianceProfile
+	}
+
+	inputs := make([]simulatedDriver, 0, len(drivers))
+	resources := make(map[string]int)
+	for _, d := range drivers {
+		vp, ok := profiles[d.ComponentID]
+		if d.IsSymbolic || !ok {
+			continue
+		}
+		lo, mode, hi := usageBounds(vp)
+		inputs = append(inputs, simulatedDriver{
+			resource:   d.ResourceAddr,
+			unitPrice:  d.UnitPrice.InexactFloat64(),
+			lo:         lo,
+			mode:       mode,
+			hi:         hi,
+			volatility: math.Max(0, math.Min(1, vp.VolatilityScore)),
+		})
+		if _, ok := resources[d.ResourceAddr]; !ok {
+			resources[d.ResourceAddr] = len(resources)
+		}
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	totals := make([]float64, opts.Iterations)
+	resourceDraws := make([]float64, len(resources))
+	sum := 0.0
+	for i := range totals {
+		demand := rng.NormFloat64()
+		for r := range resourceDraws {
+			resourceDraws[r] = rng.NormFloat64()
+		}
+		total := 0.0
+		for _, in := range inputs {
+			usage := in.mode
+			if in.hi > in.lo {
+				z := math.Sqrt(in.volatility)*demand + math.Sqrt(1-in.volatility)*resourceDraws[resources[in.resource]]
+				usage = triangular(normalCDF(z), in.lo, in.mode, in.hi)
+			}
+			total += usage * in.unitPrice
+		}
+		totals[i] = total
+		sum += total
+	}
+	sort.Float64s(totals)
+
+	return &Simulation{
+		Iterations: opts.Iterations,
+		Seed:       opts.Seed,
+		Mean:       money(sum / float64(opts.Iterations)),
+		P10:        money(quantile(totals, 0.10)),
+		P50:        money(quantile(totals, 0.50)),
+		P90:        money(quantile(totals, 0.90)),
+		P99:        money(quantile(totals, 0.99)),
+		Histogram:  histogram(totals, opts.Buckets),
+	}
+}
+
+// usageBounds returns the triangular distribution of a usage profile
+// A P90 above the max widens the range so the P90 stays reachable.
+func usageBounds(vp billing.VarianceProfile) (lo, mode, hi float64) {
+	mode = vp.P50Usage
+	lo = math.Min(vp.MinUsage, mode)
+	hi = math.Max(vp.MaxUsage, mode)
+	if vp.P90Usage > hi {
+		hi = vp.P90Usage + (vp.P90Usage - mode)
+	}
+	return lo, mode, hi
+}
+
+// triangular is the inverse CDF of a triangular distribution
+func triangular(u, lo, mode, hi float64) float64 {
+	split := (mode - lo) / (hi - lo)
+	if u < split {
+		return lo + math.Sqrt(u*(hi-lo)*(mode-lo))
+	}
+	return hi - math.Sqrt((1-u)*(hi-lo)*(hi-mode))
+}
+
+func normalCDF(z float64) float64 {
+	return 0.5 * (1 + math.Erf(z/math.Sqrt2))
+}
+
+// quantile returns the q-th quantile of sorted values by linear interpolation
+func quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	pos := q * float64(len(sorted)-1)
+	i := int(pos)
+	if i+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (pos-float64(i))*(sorted[i+1]-sorted[i])
+}
+
+// histogram buckets sorted totals into equal-width ranges
+func histogram(sorted []float64, buckets int) []HistogramBucket {
+	if len(sorted) == 0 {
+		return nil
+	}
+	lo, hi := sorted[0], sorted[len(sorted)-1]
+	if hi == lo {
+		return []HistogramBucket{{Lower: money(lo), Upper: money(hi), Count: len(sorted)}}
+	}
+
+	width := (hi - lo) / float64(buckets)
+	out := make([]HistogramBucket, buckets)
+	for i := range out {
+		out[i].Lower = money(lo + float64(i)*width)
+		out[i].Upper = money(lo + float64(i+1)*width)
+	}
+	for _, v := range sorted {
+		i := int((v - lo) / width)
+		if i >= buckets {
+			i = buckets - 1 // the maximum belongs to the last bucket
+		}
+		out[i].Count++
+	}
+	return out
+}
+
+func money(v float64) decimal.Decimal {
+	return decimal.NewFromFloat(v).Round(2)
+}
@@ -0,0 +1,51 @@
+package estimation
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/decision/billing"
+)
+
+func TestSimulate(t *testing.T) {
+	components := []billing.BillingComponent{
+		{ID: "web-compute", ResourceAddr: "aws_instance.web", VarianceProfile: billing.VarianceProfile{
+			MinUsage: 730, MaxUsage: 730, P50Usage: 730, P90Usage: 730,
+		}},
+		{ID: "api-requests", ResourceAddr: "aws_lambda_function.api", VarianceProfile: billing.VarianceProfile{
+			MinUsage: 0, MaxUsage: 4000000, P50Usage: 1000000, P90Usage: 2500000, VolatilityScore: 0.6,
+		}},
+	}
+	drivers := []CostDriver{
+		{ComponentID: "web-compute", ResourceAddr: "aws_instance.web", UnitPrice: decimal.RequireFromString("0.1")},
+		{ComponentID: "api-requests", ResourceAddr: "aws_lambda_function.api", UnitPrice: decimal.RequireFromString("0.0001")},
+		{ComponentID: "unpriced", ResourceAddr: "aws_foo.bar", IsSymbolic: true},
+	}
+
+	sim := Simulate(drivers, components, SimulationOptions{Iterations: 5000})
+	if !(sim.P10.LessThan(sim.P50) && sim.P50.LessThan(sim.P90) && sim.P90.LessThan(sim.P99)) {
+		t.Errorf("percentiles not increasing: %s %s %s %s", sim.P10, sim.P50, sim.P90, sim.P99)
+	}
+	// Fixed compute ($73) is the floor of every sample
+	if sim.P10.LessThan(decimal.NewFromInt(73)) {
+		t.Errorf("P10 = %s, below fixed cost", sim.P10)
+	}
+	count := 0
+	for _, b := range sim.Histogram {
+		count += b.Count
+	}
+	if len(sim.Histogram) != defaultHistogramBuckets || count != 5000 {
+		t.Errorf("histogram has %d buckets and %d samples", len(sim.Histogram), count)
+	}
+
+	again := Simulate(drivers, components, SimulationOptions{Iterations: 5000})
+	if !again.P90.Equal(sim.P90) {
+		t.Errorf("same seed gave P90 %s and %s", sim.P90, again.P90)
+	}
+
+	fixed := Simulate(drivers[:1], components, SimulationOptions{Iterations: 100})
+	if !fixed.P10.Equal(decimal.NewFromInt(73)) || !fixed.P99.Equal(decimal.NewFromInt(73)) || len(fixed.Histogram) != 1 {
+		t.Errorf("fixed capacity simulated as %s..%s", fixed.P10, fixed.P99)
+	}
+}
@@ -0,0 +1,95 @@
+package estimation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/billing"
+)
+
+// tieredStore serves the same tiers for every lookup
+type tieredStore struct{ tiers []clickhouse.TieredRate }
+
+func (s *tieredStore) ResolveRate(ctx context.Context, cloud clickhouse.CloudProvider, service, productFamily, region string, attrs map[string]string, unit, alias string) (*clickhouse.ResolvedRate, error) {
+	return nil, nil
+}
+
+func (s *tieredStore) ResolveRatesBatch(ctx context.Context, lookups []clickhouse.RateLookup) (map[string]*clickhouse.ResolvedRate, error) {
+	first := s.tiers[0]
+	rates := make(map[string]*clickhouse.ResolvedRate)
+	for _, l := range lookups {
+		rates[l.Key()] = &clickhouse.ResolvedRate{Price: first.Price, Currency: "USD", Confidence: 1, TierMin: &first.Min, TierMax: first.Max, SnapshotID: uuid.New()}
+	}
+	return rates, nil
+}
+
+func (s *tieredStore) ResolveSpotRate(ctx context.Context, cloud clickhouse.CloudProvider, region, instanceType, productDescription string, lookback time.Duration, at time.Time) (*clickhouse.SpotRate, error) {
+	return nil, nil
+}
+
+func (s *tieredStore) ResolveRateTiers(ctx context.Context, snapshotID uuid.UUID, lookup clickhouse.RateLookup) ([]clickhouse.TieredRate, error) {
+	return s.tiers, nil
+}
+
+func TestEstimateTieredPricing(t *testing.T) {
+	d := func(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+	max1, max2 := d("51200"), d("512000")
+	store := &tieredStore{tiers: []clickhouse.TieredRate{
+		{Min: d("0"), Max: &max1, Price: d("0.023"), Confidence: 1},
+		{Min: max1, Max: &max2, Price: d("0.022"), Confidence: 1},
+		{Min: max2, Price: d("0.021"), Confidence: 0.9},
+	}}
+	comp := billing.BillingComponent{
+		ID: "c1", ResourceAddr: "aws_s3_bucket.data", Cloud: "aws", Service: "AmazonS3",
+		ProductFamily: "Storage", Region: "us-east-1", BillingPeriod: billing.PeriodMonthly,
+		VarianceProfile: billing.VarianceProfile{P50Usage: 100000, P90Usage: 600000, Confidence: 1},
+	}
+
+	result, err := NewEngine(store).Estimate(context.Background(), EstimationRequest{Components: []billing.BillingComponent{comp}, IncludeFormulas: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	driver := result.CostDrivers[0]
+	// P50: 51200 × 0.023 + 48800 × 0.022 = 1177.6 + 1073.6
+	if want := d("2251.2"); !driver.MonthlyCostP50.Equal(want) {
+		t.Errorf("P50 = %s, want %s", driver.MonthlyCostP50, want)
+	}
+	// P90: 1177.6 + 460800 × 0.022 + 88000 × 0.021
+	if want := d("13163.2"); !driver.MonthlyCostP90.Equal(want) {
+		t.Errorf("P90 = %s, want %s", driver.MonthlyCostP90, want)
+	}
+	if driver.PricingConfidence != 1 || driver.Formula == "" {
+		t.Errorf("unexpected driver: confidence %v, formula %q", driver.PricingConfidence, driver.Formula)
+	}
+	if want := d("0.022512"); !driver.UnitPrice.Equal(want) {
+		t.Errorf("blended unit price = %s, want %s", driver.UnitPrice, want)
+	}
+}
+
+func TestEstimateFreeAllowance(t *testing.T) {
+	// Ingestion drops the zero-price first million requests, leaving one tier above it
+	allowance := decimal.NewFromInt(1000000)
+	store := &tieredStore{tiers: []clickhouse.TieredRate{{Min: allowance, Price: decimal.RequireFromString("0.0000002"), Confidence: 1}}}
+	comp := billing.BillingComponent{
+		ID: "c1", ResourceAddr: "aws_lambda_function.api", Cloud: "aws", Service: "AWSLambda",
+		Region: "us-east-1", BillingPeriod: billing.PeriodPerRequest,
+		VarianceProfile: billing.VarianceProfile{P50Usage: 500000, P90Usage: 3000000, Confidence: 1},
+	}
+
+	result, err := NewEngine(store).Estimate(context.Background(), EstimationRequest{Components: []billing.BillingComponent{comp}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	driver := result.CostDrivers[0]
+	if !driver.MonthlyCostP50.IsZero() {
+		t.Errorf("P50 within the free allowance = %s, want 0", driver.MonthlyCostP50)
+	}
+	if want := decimal.RequireFromString("0.4"); !driver.MonthlyCostP90.Equal(want) {
+		t.Errorf("P90 = %s, want %s", driver.MonthlyCostP90, want)
+	}
+}
@@ -0,0 +1,60 @@
+// Package estimation - Timebase and proration
+package estimation
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/decision/billing"
+)
+
+// ProratedMonth is the cost of the first, partial month of resources
+// provisioned mid-month; the monthly totals stay those of a full month
+type ProratedMonth struct {
+	ProvisionedAt  time.Time       `json:"provisioned_at"`
+	Fraction       float64         `json:"fraction"` // Share of the month billed
+	MonthlyCostP50 decimal.Decimal `json:"monthly_cost_p50"`
+	MonthlyCostP90 decimal.Decimal `json:"monthly_cost_p90"`
+}
+
+// ParseProvisionedAt parses a provisioning date, YYYY-MM-DD or RFC 3339; empty
+// is the zero time. Unlike pricing dates it may be in the future.
+func ParseProvisionedAt(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		if t, err = time.Parse(time.RFC3339, value); err != nil {
+			return time.Time{}, fmt.Errorf("invalid provisioning date %q: use YYYY-MM-DD or RFC 3339", value)
+		}
+	}
+	return t, nil
+}
+
+// prorate returns the first month of an estimate provisioned at a time
+func prorate(result *EstimationResult, provisioned time.Time) *ProratedMonth {
+	fraction := billing.ProratedFraction(provisioned)
+	return &ProratedMonth{
+		ProvisionedAt:  provisioned.UTC(),
+		Fraction:       fraction,
+		MonthlyCostP50: result.MonthlyCostP50.Mul(decimal.NewFromFloat(fraction)).Round(2),
+		MonthlyCostP90: result.MonthlyCostP90.Mul(decimal.NewFromFloat(fraction)).Round(2),
+	}
+}
+
+// rescaleHourly rescales hour-billed usage by a factor, e.g. from
+// billing.HoursPerMonth to the request's timebase; usage billed by the month,
+// request or GB, and running hours set by a usage file or schedule, are unchanged
+func rescaleHourly(components []billing.BillingComponent, factor float64) []billing.BillingComponent {
+	scaled := make([]billing.BillingComponent, len(components))
+	for i, c := range components {
+		if (c.BillingPeriod == billing.PeriodHourly || c.BillingPeriod == billing.PeriodGBHourly) && !c.VarianceProfile.FixedHours {
+			c.VarianceProfile.Scale(factor)
+		}
+		scaled[i] = c
+	}
+	return scaled
+}
@@ -0,0 +1,86 @@
+package estimation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/decision/billing"
+)
+
+func TestTimebaseHours(t *testing.T) {
+	feb := time.Date(2024, time.February, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  float64
+	}{
+		{"", 730},
+		{"730", 730},
+		{"720", 720},
+		{"calendar", 696}, // 29 days of a leap February
+	}
+	for _, tt := range tests {
+		tb, err := billing.ParseTimebase(tt.value)
+		if err != nil {
+			t.Fatalf("ParseTimebase(%q): %v", tt.value, err)
+		}
+		if got := tb.Hours(feb); got != tt.want {
+			t.Errorf("%s hours = %v, want %v", tb, got, tt.want)
+		}
+	}
+	if _, err := billing.ParseTimebase("365"); err == nil {
+		t.Error("invalid timebase accepted")
+	}
+}
+
+func TestRescaleHourly(t *testing.T) {
+	storage := micro("volume")
+	storage.BillingPeriod = billing.PeriodMonthly
+	storage.VarianceProfile = billing.VarianceProfile{P50Usage: 100, P90Usage: 100}
+	compute := micro("instance")
+	compute.BillingPeriod = billing.PeriodHourly
+	scheduled := micro("scheduled")
+	scheduled.BillingPeriod = billing.PeriodHourly
+	scheduled.VarianceProfile = billing.VarianceProfile{P50Usage: 200, P90Usage: 200, FixedHours: true}
+	components := []billing.BillingComponent{compute, storage, scheduled}
+
+	scaled := rescaleHourly(components, 720.0/billing.HoursPerMonth)
+	if got := scaled[0].VarianceProfile.P50Usage; got != 720 {
+		t.Errorf("hourly usage = %v, want 720", got)
+	}
+	if got := scaled[1].VarianceProfile.P50Usage; got != 100 {
+		t.Errorf("monthly usage = %v, want 100 (not hour-billed)", got)
+	}
+	if got := scaled[2].VarianceProfile.P50Usage; got != 200 {
+		t.Errorf("scheduled usage = %v, want 200 (fixed hours)", got)
+	}
+	if components[0].VarianceProfile.P50Usage != 730 {
+		t.Error("rescaleHourly modified its input")
+	}
+}
+
+func TestProrate(t *testing.T) {
+	result := &EstimationResult{
+		MonthlyCostP50: decimal.NewFromInt(300),
+		MonthlyCostP90: decimal.NewFromInt(600),
+	}
+	// 20 of April's 30 days remain from the 11th
+	first := prorate(result, time.Date(2024, time.April, 11, 0, 0, 0, 0, time.UTC))
+	if got := first.MonthlyCostP50.StringFixed(2); got != "200.00" {
+		t.Errorf("first month P50 = %s, want 200.00", got)
+	}
+	if got := first.MonthlyCostP90.StringFixed(2); got != "400.00" {
+		t.Errorf("first month P90 = %s, want 400.00", got)
+	}
+	if got := billing.ProratedFraction(time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)); got != 1 {
+		t.Errorf("fraction from the 1st = %v, want 1", got)
+	}
+
+	if _, err := ParseProvisionedAt("2999-01-01"); err != nil {
+		t.Errorf("future provisioning date rejected: %v", err)
+	}
+	if _, err := ParseProvisionedAt("next week"); err == nil {
+		t.Error("invalid provisioning date accepted")
+	}
+}
@@ -0,0 +1,90 @@
+package estimation
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/iac"
+)
+
+// poolMapper sizes acme_pool by its size, defaulting the tier like a mapper would
+type poolMapper struct{}
+
+func (poolMapper) ResourceType() string            { return "acme_pool" }
+func (poolMapper) SupportedAttributes() []string   { return []string{"size", "settings.tier", "name"} }
+func (poolMapper) CostDrivingAttributes() []string { return []string{"size", "settings.tier"} }
+func (poolMapper) MapToBillingComponents(node *iac.GraphNode) ([]billing.BillingComponent, []billing.MappingError) {
+	if _, ok := node.Resource.Attributes["size"]; !ok {
+		return nil, []billing.MappingError{{ResourceAddr: node.Resource.Address, Reason: "size is required", IsCritical: true}}
+	}
+	return []billing.BillingComponent{{
+		Cloud: "acme", Service: "Pools", BillingPeriod: billing.PeriodHourly,
+		VarianceProfile: billing.VarianceProfile{P50Usage: 730, P90Usage: 730, Confidence: 0.9},
+	}}, nil
+}
+
+func pool(addr string, attrs, afterUnknown map[string]interface{}) *iac.GraphNode {
+	return &iac.GraphNode{
+		Resource: iac.ResourceNode{Address: addr, Type: "acme_pool", Mode: "managed", Attributes: attrs},
+		Change:   &iac.ResourceChange{Address: addr, AfterUnknown: afterUnknown},
+		Provider: "acme",
+	}
+}
+
+func TestUnknownCostDrivingAttributes(t *testing.T) {
+	graph := &iac.Graph{Nodes: map[string]*iac.GraphNode{
+		// Only computed, non-cost attributes unknown
+		"acme_pool.known": pool("acme_pool.known", map[string]interface{}{"size": float64(2)}, map[string]interface{}{"id": true, "name": true}),
+		// Size from a data source: the mapper cannot map it at all
+		"acme_pool.sized": pool("acme_pool.sized", map[string]interface{}{}, map[string]interface{}{"size": true}),
+		// Tier in a nested block: the mapper would price its default
+		"acme_pool.tiered": pool("acme_pool.tiered", map[string]interface{}{"size": float64(1)}, map[string]interface{}{
+			"settings": []interface{}{map[string]interface{}{"tier": true}},
+		}),
+	}}
+	engine := billing.NewEngine()
+	engine.RegisterMapper(poolMapper{})
+	decomposition, err := engine.Decompose(graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decomposition.MappingErrors) != 0 {
+		t.Errorf("mapping errors = %v; want the unknown size to replace the missing size error", decomposition.MappingErrors)
+	}
+	unknown := make(map[string][]string)
+	for _, c := range decomposition.Components {
+		unknown[c.ResourceAddr] = c.UnknownAttributes
+	}
+	if len(unknown) != 3 || unknown["acme_pool.known"] != nil {
+		t.Fatalf("unknown attributes = %v; want a component per pool, none unknown for acme_pool.known", unknown)
+	}
+
+	store := &tieredStore{tiers: []clickhouse.TieredRate{{Price: decimal.RequireFromString("0.1"), Confidence: 1}}}
+	result, err := NewEngine(store).Estimate(context.Background(), EstimationRequest{Components: decomposition.Components})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range result.CostDrivers {
+		want := map[string]string{"acme_pool.sized": "size", "acme_pool.tiered": "settings.tier"}[d.ResourceAddr]
+		if want == "" {
+			if d.IsSymbolic || !d.MonthlyCostP50.Equal(decimal.NewFromInt(73)) {
+				t.Errorf("%s = %s symbolic=%v; want priced at 73", d.ResourceAddr, d.MonthlyCostP50, d.IsSymbolic)
+			}
+			continue
+		}
+		if !d.IsSymbolic || d.Confidence != 0 || !d.MonthlyCostP50.IsZero() {
+			t.Errorf("%s = %s confidence %v symbolic=%v; want an unpriced symbolic driver", d.ResourceAddr, d.MonthlyCostP50, d.Confidence, d.IsSymbolic)
+		}
+		if !strings.Contains(d.Reason, want) || len(d.UnknownAttributes) != 1 || d.UnknownAttributes[0] != want {
+			t.Errorf("%s reason %q, unknown %v; want %s named", d.ResourceAddr, d.Reason, d.UnknownAttributes, want)
+		}
+	}
+	if result.ComponentsSymbolic != 2 || !result.IsIncomplete {
+		t.Errorf("symbolic = %d, incomplete = %v; want 2 and incomplete", result.ComponentsSymbolic, result.IsIncomplete)
+	}
+}
@@ -0,0 +1,908 @@
+// Package iac - CloudFormation template and change set parsing
+// Converts CFN templates (JSON/YAML) and describe-change-set output into the ParsedPlan model
+package iac
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FormatCloudFormation is the plan format name for CloudFormation inputs
+const FormatCloudFormation = "cloudformation"
+
+// CloudFormationParser parses CloudFormation templates and change sets
+type CloudFormationParser struct {
+	// Region substituted for AWS::Region and used for all resources
+	Region string
+
+	// AccountID substituted for AWS::AccountId; empty unless set, as no
+	// price depends on it
+	AccountID string
+
+	// StackName substituted for AWS::StackName
+	StackName string
+
+	// Parameters override template parameter defaults
+	Parameters map[string]string
+
+	// ChangeSet optionally supplies per-resource actions for a template
+	ChangeSet []byte
+}
+
+// NewCloudFormationParser creates a new CloudFormation parser
+func NewCloudFormationParser() *CloudFormationParser {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	return &CloudFormationParser{
+		Region:     region,
+		StackName:  "stack",
+		Parameters: make(map[string]string),
+	}
+}
+
+// WithChangeSet attaches describe-change-set output to a template parse
+func (p *CloudFormationParser) WithChangeSet(data []byte) *CloudFormationParser {
+	p.ChangeSet = data
+	return p
+}
+
+// ParseFile parses a CloudFormation template or change set file
+func (p *CloudFormationParser) ParseFile(path string) (*ParsedPlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open template file: %w", err)
+	}
+	return p.ParseBytes(data)
+}
+
+// Parse parses a CloudFormation template or change set from a reader
+func (p *CloudFormationParser) Parse(r io.Reader) (*ParsedPlan, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+	return p.ParseBytes(data)
+}
+
+// ParseBytes parses a CloudFormation template (JSON or YAML) or a change set (JSON)
+func (p *CloudFormationParser) ParseBytes(data []byte) (*ParsedPlan, error) {
+	doc, err := decodeCFNDocument(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// A describe-change-set document has no Resources section
+	if _, ok := doc["Changes"]; ok {
+		if _, hasResources := doc["Resources"]; !hasResources {
+			var cs CFNChangeSet
+			if err := json.Unmarshal(data, &cs); err != nil {
+				return nil, fmt.Errorf("failed to decode change set: %w", err)
+			}
+			return p.transformChangeSet(&cs, nil)
+		}
+	}
+
+	template := CFNTemplate{
+		Parameters: toMap(doc["Parameters"]),
+		Mappings:   toMap(doc["Mappings"]),
+		Conditions: toMap(doc["Conditions"]),
+		Resources:  toMap(doc["Resources"]),
+		Outputs:    toMap(doc["Outputs"]),
+	}
+	if len(template.Resources) == 0 {
+		return nil, fmt.Errorf("cloudformation template has no Resources")
+	}
+
+	if len(p.ChangeSet) > 0 {
+		var cs CFNChangeSet
+		if err := json.Unmarshal(p.ChangeSet, &cs); err != nil {
+			return nil, fmt.Errorf("failed to decode change set: %w", err)
+		}
+		return p.transformChangeSet(&cs, &template)
+	}
+
+	return p.transformTemplate(&template, nil)
+}
+
+// transformTemplate converts template resources into the plan model
+// actions maps logical IDs to change actions; nil means every resource is a create
+func (p *CloudFormationParser) transformTemplate(t *CFNTemplate, actions map[string]ChangeAction) (*ParsedPlan, error) {
+	plan := p.newPlan()
+	resolver := p.newResolver(t)
+
+	for name, raw := range resolver.params {
+		plan.Variables[name] = raw
+	}
+
+	logicalIDs := make([]string, 0, len(t.Resources))
+	for id := range t.Resources {
+		logicalIDs = append(logicalIDs, id)
+	}
+	sort.Strings(logicalIDs)
+
+	for _, logicalID := range logicalIDs {
+		def := toMap(t.Resources[logicalID])
+		cfnType, _ := def["Type"].(string)
+		if cfnType == "" {
+			return nil, fmt.Errorf("resource %s has no Type", logicalID)
+		}
+
+		// Skip resources whose condition evaluates false
+		if cond, ok := def["Condition"].(string); ok && !resolver.condition(cond) {
+			continue
+		}
+
+		action := ActionCreate
+		if actions != nil {
+			a, ok := actions[logicalID]
+			if !ok {
+				a = ActionNoOp
+			}
+			action = a
+		}
+
+		props := toMap(resolver.resolve(def["Properties"]))
+		deps := resolver.references(def["Properties"])
+		for _, d := range toStringSlice(def["DependsOn"]) {
+			deps = appendUnique(deps, d)
+		}
+
+		p.addResource(plan, logicalID, cfnType, props, deps, action, t.Resources)
+	}
+
+	for name, out := range t.Outputs {
+		plan.Outputs[name] = OutputValue{Value: resolver.resolve(toMap(out)["Value"])}
+	}
+
+	return plan, nil
+}
+
+// transformChangeSet converts a change set, optionally joined with its template
+func (p *CloudFormationParser) transformChangeSet(cs *CFNChangeSet, t *CFNTemplate) (*ParsedPlan, error) {
+	// The change set's parameters and stack apply to this parse only
+	parser := *p
+	parser.Parameters = make(map[string]string, len(p.Parameters)+len(cs.Parameters))
+	for k, v := range p.Parameters {
+		parser.Parameters[k] = v
+	}
+	for _, param := range cs.Parameters {
+		if _, overridden := parser.Parameters[param.ParameterKey]; !overridden {
+			parser.Parameters[param.ParameterKey] = param.ParameterValue
+		}
+	}
+	if cs.StackName != "" {
+		parser.StackName = cs.StackName
+	}
+	p = &parser
+
+	actions := make(map[string]ChangeAction)
+	for _, change := range cs.Changes {
+		rc := change.ResourceChange
+		if change.Type != "Resource" || rc.LogicalResourceID == "" {
+			continue
+		}
+		actions[rc.LogicalResourceID] = cfnActionToChangeAction(rc.Action, rc.Replacement)
+	}
+
+	if t != nil {
+		plan, err := p.transformTemplate(t, actions)
+		if err != nil {
+			return nil, err
+		}
+		// Removed resources are no longer in the template; their properties
+		// come from the change set's before context, when it was included
+		for _, change := range cs.Changes {
+			rc := change.ResourceChange
+			if change.Type != "Resource" || rc.LogicalResourceID == "" {
+				continue
+			}
+			if _, inTemplate := t.Resources[rc.LogicalResourceID]; inTemplate {
+				continue
+			}
+			p.addResource(plan, rc.LogicalResourceID, rc.ResourceType, changeSetProperties(rc.BeforeContext), nil, ActionDelete, t.Resources)
+		}
+		return plan, nil
+	}
+
+	// Without a template, property values come from --include-property-values contexts
+	plan := p.newPlan()
+	resources := make(map[string]interface{})
+	for _, change := range cs.Changes {
+		rc := change.ResourceChange
+		if rc.LogicalResourceID != "" {
+			resources[rc.LogicalResourceID] = map[string]interface{}{"Type": rc.ResourceType}
+		}
+	}
+
+	for _, change := range cs.Changes {
+		rc := change.ResourceChange
+		if change.Type != "Resource" || rc.LogicalResourceID == "" {
+			continue
+		}
+
+		context := rc.AfterContext
+		if context == "" {
+			context = rc.BeforeContext
+		}
+		p.addResource(plan, rc.LogicalResourceID, rc.ResourceType, changeSetProperties(context), nil, actions[rc.LogicalResourceID], resources)
+	}
+
+	return plan, nil
+}
+
+// changeSetProperties decodes the properties of a change set before or after
+// context (describe-change-set --include-property-values); empty without one
+func changeSetProperties(context string) map[string]interface{} {
+	props := make(map[string]interface{})
+	if context == "" {
+		return props
+	}
+	var ctxDoc map[string]interface{}
+	if err := json.Unmarshal([]byte(context), &ctxDoc); err == nil {
+		if inner, ok := ctxDoc["Properties"].(map[string]interface{}); ok {
+			props = inner
+		} else {
+			props = ctxDoc
+		}
+	}
+	return props
+}
+
+// addResource appends a node and change for a single CFN resource
+func (p *CloudFormationParser) addResource(plan *ParsedPlan, logicalID, cfnType string, props map[string]interface{}, deps []string, action ChangeAction, all map[string]interface{}) {
+	tfType := cfnTypeToTerraform(cfnType)
+	address := tfType + "." + logicalID
+	attrs := cfnPropertiesToAttributes(tfType, props)
+
+	depAddrs := make([]string, 0, len(deps))
+	for _, dep := range deps {
+		if depDef, ok := all[dep]; ok {
+			if depType, _ := toMap(depDef)["Type"].(string); depType != "" {
+				depAddrs = append(depAddrs, cfnTypeToTerraform(depType)+"."+dep)
+			}
+		}
+	}
+
+	var before, after map[string]interface{}
+	switch action {
+	case ActionDelete:
+		before = attrs
+	case ActionCreate:
+		after = attrs
+	default:
+		before, after = attrs, attrs
+	}
+
+	plan.Changes = append(plan.Changes, ResourceChange{
+		Address:           address,
+		Type:              tfType,
+		Name:              logicalID,
+		Provider:          "aws",
+		Action:            action,
+		Actions:           []string{string(action)},
+		Before:            before,
+		After:             after,
+		ChangedAttributes: make([]string, 0),
+	})
+
+	node := ResourceNode{
+		Address:      address,
+		Type:         tfType,
+		Name:         logicalID,
+		Provider:     "aws",
+		ProviderName: cfnType,
+		Mode:         "managed",
+		Attributes:   attrs,
+		Sensitive:    make(map[string]bool),
+		Dependencies: depAddrs,
+	}
+	node.Region = p.Region
+	if az, ok := attrs["availability_zone"].(string); ok && len(az) > 1 {
+		node.Region = az[:len(az)-1]
+	}
+
+	plan.Resources = append(plan.Resources, node)
+	if len(depAddrs) > 0 {
+		plan.Dependencies[address] = depAddrs
+	}
+}
+
+func (p *CloudFormationParser) newPlan() *ParsedPlan {
+	return &ParsedPlan{
+		FormatVersion: FormatCloudFormation,
+		Resources:     make([]ResourceNode, 0),
+		Dependencies:  make(map[string][]string),
+		Changes:       make([]ResourceChange, 0),
+		Providers: map[string]ProviderConfig{
+			"aws": {Name: "aws", Region: p.Region, Attributes: make(map[string]interface{})},
+		},
+		Variables: make(map[string]interface{}),
+		Outputs:   make(map[string]OutputValue),
+	}
+}
+
+// =============================================================================
+// INTRINSIC FUNCTION RESOLUTION
+// =============================================================================
+
+// cfnResolver evaluates the subset of intrinsic functions that affect pricing
+type cfnResolver struct {
+	params     map[string]interface{}
+	mappings   map[string]interface{}
+	conditions map[string]interface{}
+	resources  map[string]interface{}
+}
+
+func (p *CloudFormationParser) newResolver(t *CFNTemplate) *cfnResolver {
+	r := &cfnResolver{
+		params: map[string]interface{}{
+			"AWS::Region":    p.Region,
+			"AWS::AccountId": p.AccountID,
+			"AWS::StackName": p.StackName,
+			"AWS::Partition": "aws",
+			"AWS::URLSuffix": "amazonaws.com",
+			"AWS::NoValue":   nil,
+		},
+		mappings:   t.Mappings,
+		conditions: t.Conditions,
+		resources:  t.Resources,
+	}
+	for name, def := range t.Parameters {
+		if v, ok := p.Parameters[name]; ok {
+			r.params[name] = v
+			continue
+		}
+		if d, ok := toMap(def)["Default"]; ok {
+			r.params[name] = d
+		}
+	}
+	return r
+}
+
+// resolve walks a value and replaces resolvable intrinsics with literals
+func (r *cfnResolver) resolve(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if len(val) == 1 {
+			for fn, arg := range val {
+				if resolved, ok := r.intrinsic(fn, arg); ok {
+					return resolved
+				}
+			}
+		}
+		out := make(map[string]interface{}, len(val))
+		for k, vv := range val {
+			out[k] = r.resolve(vv)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, vv := range val {
+			out[i] = r.resolve(vv)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// intrinsic evaluates a single intrinsic function; ok=false leaves it untouched
+func (r *cfnResolver) intrinsic(fn string, arg interface{}) (interface{}, bool) {
+	switch fn {
+	case "Ref":
+		name, _ := arg.(string)
+		if v, ok := r.params[name]; ok {
+			return v, true
+		}
+		if _, ok := r.resources[name]; ok {
+			return name, true // Physical IDs are unknown; keep the logical ID
+		}
+		return nil, false
+
+	case "Fn::GetAtt":
+		parts := toStringSlice(arg)
+		if s, ok := arg.(string); ok {
+			parts = strings.SplitN(s, ".", 2)
+		}
+		if len(parts) == 2 {
+			return parts[0] + "." + parts[1], true
+		}
+		return nil, false
+
+	case "Fn::Sub":
+		var tmpl string
+		vars := map[string]interface{}{}
+		switch a := arg.(type) {
+		case string:
+			tmpl = a
+		case []interface{}:
+			if len(a) > 0 {
+				tmpl, _ = a[0].(string)
+			}
+			if len(a) > 1 {
+				vars = toMap(a[1])
+			}
+		}
+		return r.sub(tmpl, vars), true
+
+	case "Fn::Join":
+		a, ok := arg.([]interface{})
+		if !ok || len(a) != 2 {
+			return nil, false
+		}
+		sep, _ := a[0].(string)
+		items, ok := r.resolve(a[1]).([]interface{})
+		if !ok {
+			return nil, false
+		}
+		strs := make([]string, 0, len(items))
+		for _, item := range items {
+			strs = append(strs, fmt.Sprintf("%v", item))
+		}
+		return strings.Join(strs, sep), true
+
+	case "Fn::Select":
+		a, ok := arg.([]interface{})
+		if !ok || len(a) != 2 {
+			return nil, false
+		}
+		idx, err := strconv.Atoi(fmt.Sprintf("%v", r.resolve(a[0])))
+		items, isList := r.resolve(a[1]).([]interface{})
+		if err != nil || !isList || idx < 0 || idx >= len(items) {
+			return nil, false
+		}
+		return items[idx], true
+
+	case "Fn::GetAZs":
+		region, _ := r.resolve(arg).(string)
+		if region == "" {
+			region, _ = r.params["AWS::Region"].(string)
+		}
+		return []interface{}{region + "a", region + "b", region + "c"}, true
+
+	case "Fn::FindInMap":
+		a, ok := arg.([]interface{})
+		if !ok || len(a) < 3 {
+			return nil, false
+		}
+		top := toMap(r.mappings[fmt.Sprintf("%v", r.resolve(a[0]))])
+		second := toMap(top[fmt.Sprintf("%v", r.resolve(a[1]))])
+		if v, ok := second[fmt.Sprintf("%v", r.resolve(a[2]))]; ok {
+			return v, true
+		}
+		if len(a) > 3 {
+			if def, ok := toMap(a[3])["DefaultValue"]; ok {
+				return def, true
+			}
+		}
+		return nil, false
+
+	case "Fn::If":
+		a, ok := arg.([]interface{})
+		if !ok || len(a) != 3 {
+			return nil, false
+		}
+		cond, _ := a[0].(string)
+		if r.condition(cond) {
+			return r.resolve(a[1]), true
+		}
+		return r.resolve(a[2]), true
+
+	case "Fn::Base64":
+		return r.resolve(arg), true
+	}
+	return nil, false
+}
+
+// sub performs ${Var} substitution for Fn::Sub
+func (r *cfnResolver) sub(tmpl string, vars map[string]interface{}) string {
+	var sb strings.Builder
+	for {
+		start := strings.Index(tmpl, "${")
+		if start < 0 {
+			sb.WriteString(tmpl)
+			break
+		}
+		end := strings.Index(tmpl[start:], "}")
+		if end < 0 {
+			sb.WriteString(tmpl)
+			break
+		}
+		sb.WriteString(tmpl[:start])
+		name := tmpl[start+2 : start+end]
+		switch {
+		case strings.HasPrefix(name, "!"):
+			sb.WriteString("${" + name[1:] + "}") // Literal escape
+		case vars[name] != nil:
+			sb.WriteString(fmt.Sprintf("%v", r.resolve(vars[name])))
+		case r.params[name] != nil:
+			sb.WriteString(fmt.Sprintf("%v", r.params[name]))
+		default:
+			sb.WriteString(name) // Resource refs and attributes keep their logical name
+		}
+		tmpl = tmpl[start+end+1:]
+	}
+	return sb.String()
+}
+
+// condition evaluates a named condition; unknown conditions are treated as true
+func (r *cfnResolver) condition(name string) bool {
+	def, ok := r.conditions[name]
+	if !ok {
+		return true
+	}
+	result, known := r.evalCondition(def)
+	return !known || result
+}
+
+func (r *cfnResolver) evalCondition(v interface{}) (bool, bool) {
+	m := toMap(v)
+	if len(m) != 1 {
+		if b, ok := v.(bool); ok {
+			return b, true
+		}
+		return false, false
+	}
+	for fn, arg := range m {
+		args, _ := arg.([]interface{})
+		switch fn {
+		case "Fn::Equals":
+			if len(args) != 2 {
+				return false, false
+			}
+			return fmt.Sprintf("%v", r.resolve(args[0])) == fmt.Sprintf("%v", r.resolve(args[1])), true
+		case "Fn::Not":
+			if len(args) != 1 {
+				return false, false
+			}
+			b, known := r.evalCondition(args[0])
+			return !b, known
+		case "Fn::And", "Fn::Or":
+			result := fn == "Fn::And"
+			for _, a := range args {
+				b, known := r.evalCondition(a)
+				if !known {
+					return false, false
+				}
+				if fn == "Fn::And" {
+					result = result && b
+				} else {
+					result = result || b
+				}
+			}
+			return result, true
+		case "Condition":
+			name, _ := arg.(string)
+			return r.condition(name), true
+		}
+	}
+	return false, false
+}
+
+// references collects logical IDs referenced via Ref/GetAtt/Sub
+func (r *cfnResolver) references(v interface{}) []string {
+	refs := make([]string, 0)
+	var walk func(interface{})
+	walk = func(v interface{}) {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			for k, vv := range val {
+				switch k {
+				case "Ref":
+					if name, ok := vv.(string); ok {
+						if _, isRes := r.resources[name]; isRes {
+							refs = appendUnique(refs, name)
+						}
+					}
+				case "Fn::GetAtt":
+					parts := toStringSlice(vv)
+					if s, ok := vv.(string); ok {
+						parts = strings.SplitN(s, ".", 2)
+					}
+					if len(parts) > 0 {
+						if _, isRes := r.resources[parts[0]]; isRes {
+							refs = appendUnique(refs, parts[0])
+						}
+					}
+				case "Fn::Sub":
+					tmpl, _ := vv.(string)
+					if a, ok := vv.([]interface{}); ok && len(a) > 0 {
+						tmpl, _ = a[0].(string)
+					}
+					for name := range r.resources {
+						if strings.Contains(tmpl, "${"+name+"}") || strings.Contains(tmpl, "${"+name+".") {
+							refs = appendUnique(refs, name)
+						}
+					}
+				}
+				walk(vv)
+			}
+		case []interface{}:
+			for _, vv := range val {
+				walk(vv)
+			}
+		}
+	}
+	walk(v)
+	sort.Strings(refs)
+	return refs
+}
+
+// =============================================================================
+// TYPE AND PROPERTY MAPPING
+// =============================================================================
+
+// cfnTypeOverrides covers CFN types whose Terraform names don't follow aws_<service>_<type>
+var cfnTypeOverrides = map[string]string{
+	"AWS::EC2::Volume":                          "aws_ebs_volume",
+	"AWS::RDS::DBInstance":                      "aws_db_instance",
+	"AWS::RDS::DBCluster":                       "aws_rds_cluster",
+	"AWS::ElasticLoadBalancingV2::LoadBalancer": "aws_lb",
+	"AWS::ElasticLoadBalancing::LoadBalancer":   "aws_elb",
+	"AWS::EC2::TransitGateway":                  "aws_ec2_transit_gateway",
+	"AWS::EC2::TransitGatewayAttachment":        "aws_ec2_transit_gateway_vpc_attachment",
+	"AWS::AutoScaling::AutoScalingGroup":        "aws_autoscaling_group",
+	"AWS::ElastiCache::CacheCluster":            "aws_elasticache_cluster",
+	"AWS::Events::Rule":                         "aws_cloudwatch_event_rule",
+	"AWS::Logs::LogGroup":                       "aws_cloudwatch_log_group",
+}
+
+// cfnTypeToTerraform maps AWS::Service::Type to the Terraform resource type
+func cfnTypeToTerraform(cfnType string) string {
+	if tf, ok := cfnTypeOverrides[cfnType]; ok {
+		return tf
+	}
+	parts := strings.Split(cfnType, "::")
+	if len(parts) != 3 || parts[0] != "AWS" {
+		return strings.ToLower(strings.ReplaceAll(cfnType, "::", "_"))
+	}
+	service := strings.ToLower(parts[1])
+	name := toSnake(parts[2])
+	if service == "ec2" || strings.HasPrefix(name, service+"_") {
+		return "aws_" + name
+	}
+	return "aws_" + service + "_" + name
+}
+
+// cfnPropertyRenames maps snake_cased CFN property names to Terraform attribute names
+var cfnPropertyRenames = map[string]map[string]string{
+	"aws_instance": {
+		"image_id": "ami",
+	},
+	"aws_ebs_volume": {
+		"volume_type": "type",
+	},
+	"aws_db_instance": {
+		"db_instance_class": "instance_class",
+	},
+	"aws_lb": {
+		"type": "load_balancer_type",
+	},
+	"aws_eip": {
+		"instance_id": "instance",
+	},
+}
+
+// cfnNumericProperties lists CFN properties that are strings in templates but numbers in Terraform
+var cfnNumericProperties = map[string]bool{
+	"allocated_storage": true,
+	"iops":              true,
+	"size":              true,
+	"memory_size":       true,
+	"desired_count":     true,
+	"read_capacity":     true,
+	"write_capacity":    true,
+	"volume_size":       true,
+}
+
+// cfnPropertiesToAttributes converts PascalCase CFN properties to Terraform-style attributes
+func cfnPropertiesToAttributes(tfType string, props map[string]interface{}) map[string]interface{} {
+	attrs := snakeCaseKeys(props)
+	for from, to := range cfnPropertyRenames[tfType] {
+		if v, ok := attrs[from]; ok {
+			attrs[to] = v
+			delete(attrs, from)
+		}
+	}
+
+	// DynamoDB nests throughput; Terraform flattens it
+	if pt, ok := attrs["provisioned_throughput"].(map[string]interface{}); ok {
+		attrs["read_capacity"] = pt["read_capacity_units"]
+		attrs["write_capacity"] = pt["write_capacity_units"]
+	}
+
+	// EC2 block device mappings -> ebs_block_device
+	if bdms, ok := attrs["block_device_mappings"].([]interface{}); ok {
+		devices := make([]interface{}, 0, len(bdms))
+		for _, bdm := range bdms {
+			if ebs, ok := toMap(bdm)["ebs"].(map[string]interface{}); ok {
+				devices = append(devices, ebs)
+			}
+		}
+		attrs["ebs_block_device"] = devices
+	}
+
+	for key := range cfnNumericProperties {
+		if s, ok := attrs[key].(string); ok {
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				attrs[key] = f
+			}
+		}
+	}
+	return attrs
+}
+
+// cfnActionToChangeAction maps change set actions to ChangeAction
+func cfnActionToChangeAction(action, replacement string) ChangeAction {
+	switch action {
+	case "Add", "Import":
+		return ActionCreate
+	case "Remove":
+		return ActionDelete
+	case "Modify":
+		if replacement == "True" {
+			return ActionReplace
+		}
+		return ActionUpdate
+	case "Dynamic":
+		return ActionUpdate
+	default:
+		return ActionNoOp
+	}
+}
+
+// =============================================================================
+// RAW CLOUDFORMATION STRUCTURES
+// =============================================================================
+
+// CFNTemplate holds the sections of a template relevant to estimation
+type CFNTemplate struct {
+	Parameters map[string]interface{}
+	Mappings   map[string]interface{}
+	Conditions map[string]interface{}
+	Resources  map[string]interface{}
+	Outputs    map[string]interface{}
+}
+
+// CFNChangeSet represents `aws cloudformation describe-change-set` output
+type CFNChangeSet struct {
+	StackName  string         `json:"StackName"`
+	Changes    []CFNChange    `json:"Changes"`
+	Parameters []CFNParameter `json:"Parameters"`
+}
+
+// CFNChange is a single change set entry
+type CFNChange struct {
+	Type           string            `json:"Type"`
+	ResourceChange CFNResourceChange `json:"ResourceChange"`
+}
+
+// CFNResourceChange describes a planned resource change
+type CFNResourceChange struct {
+	Action            string `json:"Action"`
+	LogicalResourceID string `json:"LogicalResourceId"`
+	ResourceType      string `json:"ResourceType"`
+	Replacement       string `json:"Replacement"`
+	BeforeContext     string `json:"BeforeContext,omitempty"`
+	AfterContext      string `json:"AfterContext,omitempty"`
+}
+
+// CFNParameter is a resolved stack parameter
+type CFNParameter struct {
+	ParameterKey   string `json:"ParameterKey"`
+	ParameterValue string `json:"ParameterValue"`
+}
+
+// decodeCFNDocument decodes JSON or YAML (including short-form intrinsic tags)
+func decodeCFNDocument(data []byte) (map[string]interface{}, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		var doc map[string]interface{}
+		if err := json.Unmarshal(trimmed, &doc); err != nil {
+			return nil, fmt.Errorf("failed to decode cloudformation JSON: %w", err)
+		}
+		return doc, nil
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to decode cloudformation YAML: %w", err)
+	}
+	if len(root.Content) == 0 {
+		return nil, fmt.Errorf("empty cloudformation template")
+	}
+	doc, ok := yamlNodeToValue(root.Content[0]).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cloudformation template must be a mapping")
+	}
+	return doc, nil
+}
+
+// yamlNodeToValue converts a YAML node, expanding !Ref/!Sub/!GetAtt style tags
+func yamlNodeToValue(n *yaml.Node) interface{} {
+	var value interface{}
+	switch n.Kind {
+	case yaml.MappingNode:
+		m := make(map[string]interface{}, len(n.Content)/2)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			m[n.Content[i].Value] = yamlNodeToValue(n.Content[i+1])
+		}
+		value = m
+	case yaml.SequenceNode:
+		s := make([]interface{}, 0, len(n.Content))
+		for _, c := range n.Content {
+			s = append(s, yamlNodeToValue(c))
+		}
+		value = s
+	case yaml.AliasNode:
+		return yamlNodeToValue(n.Alias)
+	default:
+		var scalar interface{}
+		if err := n.Decode(&scalar); err != nil || strings.HasPrefix(n.Tag, "!") {
+			scalar = n.Value
+		}
+		value = scalar
+	}
+
+	if !strings.HasPrefix(n.Tag, "!") || strings.HasPrefix(n.Tag, "!!") {
+		return value
+	}
+	tag := strings.TrimPrefix(n.Tag, "!")
+	switch tag {
+	case "Ref", "Condition":
+		return map[string]interface{}{tag: value}
+	case "GetAtt":
+		if s, ok := value.(string); ok {
+			parts := strings.SplitN(s, ".", 2)
+			return map[string]interface{}{"Fn::GetAtt": []interface{}{parts[0], parts[len(parts)-1]}}
+		}
+		return map[string]interface{}{"Fn::GetAtt": value}
+	default:
+		return map[string]interface{}{"Fn::" + tag: value}
+	}
+}
+
+func toMap(v interface{}) map[string]interface{} {
+	if m, ok := v.(map[string]interface{}); ok {
+		return m
+	}
+	return map[string]interface{}{}
+}
+
+func toStringSlice(v interface{}) []string {
+	switch val := v.(type) {
+	case string:
+		return []string{val}
+	case []interface{}:
+		out := make([]string, 0, len(val))
+		for _, item := range val {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func appendUnique(slice []string, item string) []string {
+	for _, s := range slice {
+		if s == item {
+			return slice
+		}
+	}
+	return append(slice, item)
+}
@@ -0,0 +1,149 @@
+// Package iac - CloudFormation parser tests
+package iac
+
+import (
+	"testing"
+)
+
+const sampleCFNTemplate = `
+AWSTemplateFormatVersion: "2010-09-09"
+Parameters:
+  Env:
+    Type: String
+    Default: prod
+  InstanceType:
+    Type: String
+    Default: t3.large
+Conditions:
+  IsProd: !Equals [!Ref Env, prod]
+  IsDev: !Equals [!Ref Env, dev]
+Mappings:
+  Sizes:
+    prod:
+      Storage: "100"
+Resources:
+  Web:
+    Type: AWS::EC2::Instance
+    Properties:
+      InstanceType: !Ref InstanceType
+      ImageId: ami-123
+      AvailabilityZone: !Select [1, !GetAZs ""]
+      Tags:
+        - Key: Name
+          Value: !Sub "${AWS::StackName}-${Env}-web"
+  Db:
+    Type: AWS::RDS::DBInstance
+    DependsOn: Web
+    Properties:
+      DBInstanceClass: !If [IsProd, db.r5.large, db.t3.micro]
+      AllocatedStorage: !FindInMap [Sizes, !Ref Env, Storage]
+      MultiAZ: true
+  DevOnly:
+    Type: AWS::EC2::NatGateway
+    Condition: IsDev
+  Ip:
+    Type: AWS::EC2::EIP
+    Properties:
+      InstanceId: !Ref Web
+`
+
+func TestCloudFormationParserResolvesIntrinsics(t *testing.T) {
+	parser := NewCloudFormationParser()
+	parser.Region = "eu-west-1"
+
+	plan, err := parser.ParseBytes([]byte(sampleCFNTemplate))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	byAddr := make(map[string]ResourceNode)
+	for _, r := range plan.Resources {
+		byAddr[r.Address] = r
+	}
+	if len(byAddr) != 3 {
+		t.Fatalf("expected 3 resources (DevOnly excluded by condition), got %v", byAddr)
+	}
+
+	web := byAddr["aws_instance.Web"]
+	if web.Attributes["instance_type"] != "t3.large" || web.Attributes["ami"] != "ami-123" {
+		t.Errorf("unexpected web attributes: %v", web.Attributes)
+	}
+	if web.Attributes["availability_zone"] != "eu-west-1b" || web.Region != "eu-west-1" {
+		t.Errorf("expected AZ eu-west-1b, got %v (region %s)", web.Attributes["availability_zone"], web.Region)
+	}
+
+	db := byAddr["aws_db_instance.Db"]
+	if db.Attributes["instance_class"] != "db.r5.large" {
+		t.Errorf("expected Fn::If to pick db.r5.large, got %v", db.Attributes["instance_class"])
+	}
+	if db.Attributes["allocated_storage"] != float64(100) {
+		t.Errorf("expected numeric allocated_storage, got %#v", db.Attributes["allocated_storage"])
+	}
+	if deps := plan.Dependencies["aws_db_instance.Db"]; len(deps) != 1 || deps[0] != "aws_instance.Web" {
+		t.Errorf("expected DependsOn web, got %v", deps)
+	}
+	if deps := plan.Dependencies["aws_eip.Ip"]; len(deps) != 1 || deps[0] != "aws_instance.Web" {
+		t.Errorf("expected Ref dependency on web, got %v", deps)
+	}
+}
+
+func TestCloudFormationParserChangeSet(t *testing.T) {
+	changeSet := `{
+	  "StackName": "app",
+	  "Parameters": [{"ParameterKey": "Env", "ParameterValue": "dev"}],
+	  "Changes": [
+	    {"Type": "Resource", "ResourceChange": {"Action": "Add", "LogicalResourceId": "DevOnly", "ResourceType": "AWS::EC2::NatGateway"}},
+	    {"Type": "Resource", "ResourceChange": {"Action": "Modify", "LogicalResourceId": "Db", "ResourceType": "AWS::RDS::DBInstance", "Replacement": "True"}},
+	    {"Type": "Resource", "ResourceChange": {"Action": "Remove", "LogicalResourceId": "Ip", "ResourceType": "AWS::EC2::EIP"}},
+	    {"Type": "Resource", "ResourceChange": {"Action": "Remove", "LogicalResourceId": "Cache", "ResourceType": "AWS::ElastiCache::CacheCluster",
+	     "BeforeContext": "{\"Properties\": {\"CacheNodeType\": \"cache.r6g.large\", \"NumCacheNodes\": \"2\"}}"}}
+	  ]
+	}`
+
+	parser := NewCloudFormationParser().WithChangeSet([]byte(changeSet))
+	plan, err := parser.ParseBytes([]byte(sampleCFNTemplate))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	graph, err := NewGraphBuilder().Build(plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stats := graph.ChangeStats
+	// Ip is still in the template; Cache was removed from it
+	if stats.Creates != 1 || stats.Replaces != 1 || stats.Deletes != 2 || stats.NoOps != 1 {
+		t.Errorf("unexpected change stats: %+v", stats)
+	}
+
+	for _, r := range plan.Resources {
+		if r.Address == "aws_db_instance.Db" && r.Attributes["instance_class"] != "db.t3.micro" {
+			t.Errorf("expected change set parameter Env=dev to select db.t3.micro, got %v", r.Attributes["instance_class"])
+		}
+	}
+	cache := graph.Nodes["aws_elasticache_cluster.Cache"]
+	if cache == nil || cache.Change == nil || cache.Change.Action != ActionDelete {
+		t.Fatalf("expected removed Cache as a delete, got %+v", cache)
+	}
+	if len(parser.Parameters) != 0 || parser.StackName != "stack" {
+		t.Errorf("change set leaked into the parser: parameters %v, stack %q", parser.Parameters, parser.StackName)
+	}
+}
+
+func TestCFNTypeToTerraform(t *testing.T) {
+	tests := map[string]string{
+		"AWS::EC2::Instance":       "aws_instance",
+		"AWS::EC2::VPCEndpoint":    "aws_vpc_endpoint",
+		"AWS::SQS::Queue":          "aws_sqs_queue",
+		"AWS::ECS::Service":        "aws_ecs_service",
+		"AWS::Lambda::Function":    "aws_lambda_function",
+		"AWS::DynamoDB::Table":     "aws_dynamodb_table",
+		"AWS::RDS::DBInstance":     "aws_db_instance",
+		"AWS::EC2::TransitGateway": "aws_ec2_transit_gateway",
+	}
+	for cfnType, want := range tests {
+		if got := cfnTypeToTerraform(cfnType); got != want {
+			t.Errorf("cfnTypeToTerraform(%s) = %s, want %s", cfnType, got, want)
+		}
+	}
+}
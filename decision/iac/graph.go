@@ -13,6 +13,9 @@ type Graph struct {
 	Roots    []string            // Nodes with no dependencies
 	Leaves   []string            // Nodes with no dependents
 	
+	// Expansions maps count/for_each base addresses to their instance addresses
+	Expansions map[string][]string
+	
 	// Computed properties
 	ResourceCount int
 	ProviderStats map[string]int // provider -> count
@@ -37,8 +40,14 @@ type ChangeStatistics struct {
 	Updates  int
 	Deletes  int
 	Replaces int
+	Forgets  int // Removed from state without being destroyed
 	NoOps    int
 	Total    int
+	
+	// Counted on top of each resource's action
+	Imports int // Adopted by import blocks
+	Moves   int // Renamed by moved blocks
+	Deposed int // Deposed objects being destroyed; they are not graph nodes
 }
 
 // GraphBuilder builds infrastructure graphs from parsed plans
@@ -70,11 +79,17 @@ func (b *GraphBuilder) Build(plan *ParsedPlan) (*Graph, error) {
 		Leaves:        make([]string, 0),
 		ProviderStats: make(map[string]int),
 		RegionStats:   make(map[string]int),
+		Expansions:    make(map[string][]string),
 	}
 	
 	// Build change lookup
 	changeByAddr := make(map[string]*ResourceChange)
+	deposed := 0
 	for i := range plan.Changes {
+		if plan.Changes[i].Deposed != "" {
+			deposed++
+			continue
+		}
 		changeByAddr[plan.Changes[i].Address] = &plan.Changes[i]
 	}
 	
@@ -97,6 +112,11 @@ func (b *GraphBuilder) Build(plan *ParsedPlan) (*Graph, error) {
 		g.Nodes[resource.Address] = node
 		g.ResourceCount++
 		
+		// Track count/for_each instances under their base address
+		if base := BaseAddress(resource.Address); base != resource.Address {
+			g.Expansions[base] = append(g.Expansions[base], resource.Address)
+		}
+		
 		// Track statistics
 		g.ProviderStats[resource.Provider]++
 		if resource.Region != "" {
@@ -146,6 +166,7 @@ func (b *GraphBuilder) Build(plan *ParsedPlan) (*Graph, error) {
 	
 	// Calculate change statistics
 	g.ChangeStats = b.calculateChangeStats(g)
+	g.ChangeStats.Deposed = deposed
 	
 	return g, nil
 }
@@ -271,15 +292,35 @@ func (b *GraphBuilder) calculateChangeStats(g *Graph) ChangeStatistics {
 			stats.Deletes++
 		case ActionReplace:
 			stats.Replaces++
+		case ActionForget:
+			stats.Forgets++
 		default:
 			stats.NoOps++
 		}
+		if node.Change.Importing {
+			stats.Imports++
+		}
+		if node.Change.IsMove() {
+			stats.Moves++
+		}
 	}
 	
-	stats.Total = stats.Creates + stats.Updates + stats.Deletes + stats.Replaces + stats.NoOps
+	stats.Total = stats.Creates + stats.Updates + stats.Deletes + stats.Replaces + stats.Forgets + stats.NoOps
 	return stats
 }
 
+// InstanceCount returns how many instances a base address expands to
+// Resources without count/for_each count as one instance.
+func (g *Graph) InstanceCount(base string) int {
+	if instances, ok := g.Expansions[base]; ok {
+		return len(instances)
+	}
+	if _, ok := g.Nodes[base]; ok {
+		return 1
+	}
+	return 0
+}
+
 // GetResourcesByProvider groups resources by provider
 func (g *Graph) GetResourcesByProvider() map[string][]*GraphNode {
 	result := make(map[string][]*GraphNode)
@@ -0,0 +1,867 @@
+// Package iac - HCL configuration parser
+// Builds a best-effort plan from raw .tf files when no plan JSON is available
+package iac
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// FormatHCL identifies raw Terraform configuration input
+const FormatHCL = "hcl"
+
+// maxModuleDepth bounds local module recursion
+const maxModuleDepth = 10
+
+// resourceMetaArguments are resource arguments that are not provider attributes
+var resourceMetaArguments = map[string]bool{
+	"count":      true,
+	"for_each":   true,
+	"provider":   true,
+	"depends_on": true,
+}
+
+// resourceMetaBlocks are resource blocks that are not provider attributes
+var resourceMetaBlocks = map[string]bool{
+	"lifecycle":   true,
+	"provisioner": true,
+	"connection":  true,
+}
+
+// HCLParser parses Terraform configuration directories without running terraform plan
+// Values that depend on other resources or remote modules are unknown and omitted.
+type HCLParser struct {
+	// Variables are -var values; they take precedence over var files and defaults
+	Variables map[string]string
+	// VarFiles are -var-file paths, applied after terraform.tfvars and *.auto.tfvars
+	VarFiles []string
+	// Workspace is terraform.workspace; empty is "default"
+	Workspace string
+}
+
+// NewHCLParser creates a new HCL configuration parser
+func NewHCLParser() *HCLParser {
+	return &HCLParser{
+		Variables: make(map[string]string),
+	}
+}
+
+// WithVariables sets -var style variable values
+func (p *HCLParser) WithVariables(vars map[string]string) *HCLParser {
+	for k, v := range vars {
+		p.Variables[k] = v
+	}
+	return p
+}
+
+// WithValues sets variable values decoded from JSON: strings are taken as
+// they are, lists and objects in HCL syntax as -var values would be. Nulls
+// leave a variable unset.
+func (p *HCLParser) WithValues(values map[string]interface{}) *HCLParser {
+	for k, v := range values {
+		switch val := v.(type) {
+		case nil:
+		case string:
+			p.Variables[k] = val
+		default:
+			if data, err := json.Marshal(val); err == nil {
+				p.Variables[k] = string(data)
+			}
+		}
+	}
+	return p
+}
+
+// WithVarFiles adds -var-file paths
+func (p *HCLParser) WithVarFiles(paths ...string) *HCLParser {
+	p.VarFiles = append(p.VarFiles, paths...)
+	return p
+}
+
+// WithWorkspace sets the workspace terraform.workspace evaluates to
+func (p *HCLParser) WithWorkspace(workspace string) *HCLParser {
+	p.Workspace = workspace
+	return p
+}
+
+// ParseFile parses a configuration directory, or a single .tf file
+func (p *HCLParser) ParseFile(path string) (*ParsedPlan, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open configuration: %w", err)
+	}
+	if info.IsDir() {
+		return p.ParseDir(path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration: %w", err)
+	}
+	return p.parseSources(filepath.Dir(path), map[string][]byte{path: data})
+}
+
+// Parse parses a single .tf file from a reader
+func (p *HCLParser) Parse(r io.Reader) (*ParsedPlan, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration: %w", err)
+	}
+	return p.ParseBytes(data)
+}
+
+// ParseBytes parses a single .tf file's content
+func (p *HCLParser) ParseBytes(data []byte) (*ParsedPlan, error) {
+	return p.parseSources(".", map[string][]byte{"main.tf": data})
+}
+
+// ParseDir parses every .tf file in a directory (not recursive; local modules are followed)
+func (p *HCLParser) ParseDir(dir string) (*ParsedPlan, error) {
+	sources, err := readTerraformSources(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no .tf files found in %s", dir)
+	}
+	return p.parseSources(dir, sources)
+}
+
+func (p *HCLParser) parseSources(dir string, sources map[string][]byte) (*ParsedPlan, error) {
+	plan := &ParsedPlan{
+		FormatVersion: FormatHCL,
+		Resources:     make([]ResourceNode, 0),
+		Dependencies:  make(map[string][]string),
+		Changes:       make([]ResourceChange, 0),
+		Providers:     make(map[string]ProviderConfig),
+		Variables:     make(map[string]interface{}),
+		Outputs:       make(map[string]OutputValue),
+	}
+
+	root, err := parseHCLModule(sources)
+	if err != nil {
+		return nil, err
+	}
+
+	inputs, err := p.rootInputs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &hclPlanBuilder{plan: plan, workspace: p.Workspace}
+	if b.workspace == "" {
+		b.workspace = "default"
+	}
+	if err := b.buildModule(root, dir, "", inputs, 0, true); err != nil {
+		return nil, err
+	}
+
+	for name, v := range b.rootVars {
+		plan.Variables[name] = map[string]interface{}{"value": ctyToInterface(v)}
+	}
+	for addr, deps := range b.deps {
+		plan.Dependencies[addr] = deps
+	}
+	sort.Strings(b.warnings)
+	plan.Warnings = b.warnings
+	sort.Strings(b.unresolved)
+	plan.UnresolvedVariables = b.unresolved
+	return plan, nil
+}
+
+// rootInputs collects root variable values from tfvars files and -var flags
+func (p *HCLParser) rootInputs(dir string) (map[string]cty.Value, error) {
+	inputs := make(map[string]cty.Value)
+
+	files := make([]string, 0)
+	if _, err := os.Stat(filepath.Join(dir, "terraform.tfvars")); err == nil {
+		files = append(files, filepath.Join(dir, "terraform.tfvars"))
+	}
+	auto, _ := filepath.Glob(filepath.Join(dir, "*.auto.tfvars"))
+	sort.Strings(auto)
+	files = append(files, auto...)
+	files = append(files, p.VarFiles...)
+
+	parser := hclparse.NewParser()
+	for _, path := range files {
+		f, diags := parser.ParseHCLFile(path)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("failed to parse var file %s: %s", path, diags.Error())
+		}
+		attrs, diags := f.Body.JustAttributes()
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("invalid var file %s: %s", path, diags.Error())
+		}
+		for name, attr := range attrs {
+			v, diags := attr.Expr.Value(nil)
+			if diags.HasErrors() {
+				return nil, fmt.Errorf("var file %s: %s: %s", path, name, diags.Error())
+			}
+			inputs[name] = v
+		}
+	}
+
+	for name, raw := range p.Variables {
+		inputs[name] = parseVarFlag(raw)
+	}
+	return inputs, nil
+}
+
+// parseVarFlag interprets a -var value; collections use HCL syntax, everything else is a string
+func parseVarFlag(raw string) cty.Value {
+	trimmed := strings.TrimSpace(raw)
+	if strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{") {
+		expr, diags := hclsyntax.ParseExpression([]byte(trimmed), "<var>", hcl.InitialPos)
+		if !diags.HasErrors() {
+			if v, diags := expr.Value(nil); !diags.HasErrors() {
+				return v
+			}
+		}
+	}
+	return cty.StringVal(raw)
+}
+
+// =============================================================================
+// MODULE EVALUATION
+// =============================================================================
+
+// hclModule holds the blocks of one configuration directory
+type hclModule struct {
+	variables []*hclsyntax.Block
+	locals    []*hclsyntax.Block
+	providers []*hclsyntax.Block
+	resources []*hclsyntax.Block
+	modules   []*hclsyntax.Block
+}
+
+func readTerraformSources(dir string) (map[string][]byte, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+	sources := make(map[string][]byte, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		sources[path] = data
+	}
+	return sources, nil
+}
+
+func parseHCLModule(sources map[string][]byte) (*hclModule, error) {
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parser := hclparse.NewParser()
+	m := &hclModule{}
+	for _, name := range names {
+		f, diags := parser.ParseHCL(sources[name], name)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("failed to parse %s: %s", name, diags.Error())
+		}
+		body, ok := f.Body.(*hclsyntax.Body)
+		if !ok {
+			continue
+		}
+		for _, block := range body.Blocks {
+			switch block.Type {
+			case "variable":
+				m.variables = append(m.variables, block)
+			case "locals":
+				m.locals = append(m.locals, block)
+			case "provider":
+				m.providers = append(m.providers, block)
+			case "resource":
+				m.resources = append(m.resources, block)
+			case "module":
+				m.modules = append(m.modules, block)
+			}
+		}
+	}
+	return m, nil
+}
+
+// hclPlanBuilder accumulates resources across the root and local child modules
+type hclPlanBuilder struct {
+	plan       *ParsedPlan
+	deps       map[string][]string
+	rootVars   map[string]cty.Value
+	warnings   []string
+	workspace  string
+	unresolved []string // Root variables with no value that resources or module calls reference
+}
+
+func (b *hclPlanBuilder) warn(format string, args ...interface{}) {
+	b.warnings = append(b.warnings, fmt.Sprintf(format, args...))
+}
+
+func (b *hclPlanBuilder) buildModule(m *hclModule, dir, prefix string, inputs map[string]cty.Value, depth int, root bool) error {
+	if b.deps == nil {
+		b.deps = make(map[string][]string)
+	}
+
+	// Variables: inputs override defaults; undeclared inputs are ignored.
+	// Root variables without a value are unresolved when something priced references them.
+	var referenced map[string]bool
+	if root {
+		referenced = referencedVariables(m)
+	}
+	vars := make(map[string]cty.Value)
+	for _, block := range m.variables {
+		if len(block.Labels) != 1 {
+			continue
+		}
+		name := block.Labels[0]
+		v := cty.DynamicVal
+		if attr, ok := block.Body.Attributes["default"]; ok {
+			if dv, diags := attr.Expr.Value(nil); !diags.HasErrors() {
+				v = dv
+			}
+		}
+		if in, ok := inputs[name]; ok {
+			v = in
+		} else if !v.IsKnown() {
+			b.warn("%svariable %q has no value; dependent attributes are unknown", prefix, name)
+			if referenced[name] {
+				b.unresolved = append(b.unresolved, name)
+			}
+		}
+		if attr, ok := block.Body.Attributes["type"]; ok && v.IsKnown() {
+			if ty, diags := typeexpr.TypeConstraint(attr.Expr); !diags.HasErrors() {
+				if converted, err := convert.Convert(v, ty); err == nil {
+					v = converted
+				}
+			}
+		}
+		vars[name] = v
+	}
+	if root {
+		b.rootVars = vars
+	}
+
+	// Resources and modules are unknown to expressions; references yield unknown values
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var":       cty.ObjectVal(vars),
+			"local":     cty.EmptyObjectVal,
+			"data":      cty.DynamicVal,
+			"module":    cty.DynamicVal,
+			"path":      cty.ObjectVal(map[string]cty.Value{"module": cty.StringVal(dir), "root": cty.StringVal(dir), "cwd": cty.StringVal(".")}),
+			"terraform": cty.ObjectVal(map[string]cty.Value{"workspace": cty.StringVal(b.workspace)}),
+		},
+		Functions: hclFunctions(),
+	}
+	for _, block := range m.resources {
+		if len(block.Labels) == 2 {
+			ctx.Variables[block.Labels[0]] = cty.DynamicVal
+		}
+	}
+
+	// Locals may reference each other; evaluate until values stop changing
+	localExprs := make(map[string]hclsyntax.Expression)
+	for _, block := range m.locals {
+		for name, attr := range block.Body.Attributes {
+			localExprs[name] = attr.Expr
+		}
+	}
+	locals := make(map[string]cty.Value, len(localExprs))
+	for name := range localExprs {
+		locals[name] = cty.DynamicVal
+	}
+	for pass := 0; pass <= len(localExprs); pass++ {
+		ctx.Variables["local"] = cty.ObjectVal(locals)
+		changed := false
+		for name, expr := range localExprs {
+			v, _ := expr.Value(ctx)
+			if !v.RawEquals(locals[name]) {
+				locals[name] = v
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	ctx.Variables["local"] = cty.ObjectVal(locals)
+
+	// Provider configurations (root module only; child modules inherit)
+	if root {
+		for _, block := range m.providers {
+			if len(block.Labels) != 1 {
+				continue
+			}
+			pc := ProviderConfig{Name: block.Labels[0], Attributes: make(map[string]interface{})}
+			if attr, ok := block.Body.Attributes["alias"]; ok {
+				if v, diags := attr.Expr.Value(ctx); !diags.HasErrors() && v.Type() == cty.String && v.IsKnown() {
+					pc.Alias = v.AsString()
+				}
+			}
+			if attr, ok := block.Body.Attributes["region"]; ok {
+				if v, diags := attr.Expr.Value(ctx); !diags.HasErrors() && v.Type() == cty.String && v.IsKnown() {
+					pc.Region = v.AsString()
+				}
+			}
+			key := pc.Name
+			if pc.Alias != "" {
+				key = pc.Name + "." + pc.Alias
+			}
+			b.plan.Providers[key] = pc
+		}
+	}
+
+	for _, block := range m.resources {
+		if len(block.Labels) != 2 {
+			continue
+		}
+		b.buildResource(block, prefix, ctx)
+	}
+
+	for _, block := range m.modules {
+		if len(block.Labels) != 1 {
+			continue
+		}
+		if err := b.buildChildModule(block, dir, prefix, ctx, depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildResource expands count/for_each and records each instance as a planned create
+func (b *hclPlanBuilder) buildResource(block *hclsyntax.Block, prefix string, ctx *hcl.EvalContext) {
+	resourceType, name := block.Labels[0], block.Labels[1]
+	base := prefix + resourceType + "." + name
+
+	for _, inst := range b.expand(block.Body, base, ctx) {
+		attrs := bodyToAttributes(block.Body, inst.ctx, true)
+		addr := base + inst.suffix
+
+		provider := providerLocalName(resourceType)
+		providerKey := provider
+		if attr, ok := block.Body.Attributes["provider"]; ok {
+			if trav, diags := hcl.AbsTraversalForExpr(attr.Expr); !diags.HasErrors() {
+				providerKey = traversalString(trav)
+			}
+		}
+
+		node := ResourceNode{
+			Address:      addr,
+			Type:         resourceType,
+			Name:         name,
+			Mode:         "managed",
+			Provider:     provider,
+			ProviderName: "registry.terraform.io/hashicorp/" + provider,
+			Attributes:   attrs,
+			Sensitive:    make(map[string]bool),
+			Dependencies: make([]string, 0),
+			Index:        inst.index,
+			IndexKey:     inst.key,
+			Module:       strings.TrimSuffix(prefix, "."),
+		}
+		if providerKey != provider {
+			node.ProviderKey = providerKey
+		}
+		node.Region = (&Parser{ResolveRegions: true}).resolveRegion(node, b.plan.Providers)
+
+		b.plan.Resources = append(b.plan.Resources, node)
+		b.plan.Changes = append(b.plan.Changes, ResourceChange{
+			Address:  addr,
+			Type:     resourceType,
+			Name:     name,
+			Provider: provider,
+			Action:   ActionCreate,
+			Actions:  []string{"create"},
+			After:    attrs,
+		})
+
+		for _, ref := range bodyReferences(block.Body) {
+			b.deps[addr] = appendUnique(b.deps[addr], prefix+ref)
+		}
+	}
+}
+
+// buildChildModule follows local module sources with the module block's arguments as inputs
+func (b *hclPlanBuilder) buildChildModule(block *hclsyntax.Block, dir, prefix string, ctx *hcl.EvalContext, depth int) error {
+	name := block.Labels[0]
+	base := prefix + "module." + name
+
+	source := ""
+	if attr, ok := block.Body.Attributes["source"]; ok {
+		if v, diags := attr.Expr.Value(nil); !diags.HasErrors() && v.Type() == cty.String {
+			source = v.AsString()
+		}
+	}
+	if !strings.HasPrefix(source, "./") && !strings.HasPrefix(source, "../") {
+		b.warn("%s: remote module source %q is not evaluated", base, source)
+		return nil
+	}
+	if depth >= maxModuleDepth {
+		b.warn("%s: module nesting deeper than %d is not evaluated", base, maxModuleDepth)
+		return nil
+	}
+
+	moduleDir := filepath.Join(dir, source)
+	sources, err := readTerraformSources(moduleDir)
+	if err != nil {
+		return err
+	}
+	child, err := parseHCLModule(sources)
+	if err != nil {
+		return fmt.Errorf("%s: %w", base, err)
+	}
+
+	for _, inst := range b.expand(block.Body, base, ctx) {
+		inputs := make(map[string]cty.Value)
+		for argName, attr := range block.Body.Attributes {
+			switch argName {
+			case "source", "version", "providers", "count", "for_each", "depends_on":
+				continue
+			}
+			v, _ := attr.Expr.Value(inst.ctx)
+			inputs[argName] = v
+		}
+		if err := b.buildModule(child, moduleDir, base+inst.suffix+".", inputs, depth+1, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hclInstance is one count/for_each instance of a resource or module
+type hclInstance struct {
+	suffix string
+	index  *int
+	key    string
+	ctx    *hcl.EvalContext
+}
+
+// expand evaluates count/for_each; unknown expansions fall back to a single instance
+func (b *hclPlanBuilder) expand(body *hclsyntax.Body, base string, ctx *hcl.EvalContext) []hclInstance {
+	if attr, ok := body.Attributes["count"]; ok {
+		v, diags := attr.Expr.Value(ctx)
+		if !diags.HasErrors() && v.IsKnown() && !v.IsNull() {
+			if n, err := convert.Convert(v, cty.Number); err == nil {
+				count, _ := n.AsBigFloat().Int64()
+				instances := make([]hclInstance, 0, count)
+				for i := 0; i < int(count); i++ {
+					idx := i
+					child := ctx.NewChild()
+					child.Variables = map[string]cty.Value{
+						"count": cty.ObjectVal(map[string]cty.Value{"index": cty.NumberIntVal(int64(i))}),
+					}
+					instances = append(instances, hclInstance{suffix: fmt.Sprintf("[%d]", i), index: &idx, ctx: child})
+				}
+				return instances
+			}
+		}
+		b.warn("%s: count is unknown before apply; assuming 1 instance", base)
+		idx := 0
+		child := ctx.NewChild()
+		child.Variables = map[string]cty.Value{
+			"count": cty.ObjectVal(map[string]cty.Value{"index": cty.NumberIntVal(0)}),
+		}
+		return []hclInstance{{suffix: "[0]", index: &idx, ctx: child}}
+	}
+
+	if attr, ok := body.Attributes["for_each"]; ok {
+		v, diags := attr.Expr.Value(ctx)
+		if !diags.HasErrors() && v.IsWhollyKnown() && !v.IsNull() && v.CanIterateElements() {
+			instances := make([]hclInstance, 0, v.LengthInt())
+			for it := v.ElementIterator(); it.Next(); {
+				k, val := it.Element()
+				if v.Type().IsSetType() {
+					k = val
+				}
+				if k.Type() != cty.String {
+					if converted, err := convert.Convert(k, cty.String); err == nil {
+						k = converted
+					}
+				}
+				if k.Type() != cty.String || k.IsNull() {
+					continue
+				}
+				key := k.AsString()
+				child := ctx.NewChild()
+				child.Variables = map[string]cty.Value{
+					"each": cty.ObjectVal(map[string]cty.Value{"key": k, "value": val}),
+				}
+				instances = append(instances, hclInstance{suffix: fmt.Sprintf("[%q]", key), key: key, ctx: child})
+			}
+			return instances
+		}
+		b.warn("%s: for_each is unknown before apply; assuming 1 instance", base)
+		child := ctx.NewChild()
+		child.Variables = map[string]cty.Value{
+			"each": cty.ObjectVal(map[string]cty.Value{"key": cty.UnknownVal(cty.String), "value": cty.DynamicVal}),
+		}
+		return []hclInstance{{suffix: `["unknown"]`, key: "unknown", ctx: child}}
+	}
+
+	return []hclInstance{{ctx: ctx}}
+}
+
+// =============================================================================
+// VALUE CONVERSION
+// =============================================================================
+
+// bodyToAttributes evaluates a block body into plan-JSON shaped attributes
+// Nested blocks become lists of objects; dynamic blocks are expanded.
+func bodyToAttributes(body *hclsyntax.Body, ctx *hcl.EvalContext, resource bool) map[string]interface{} {
+	attrs := make(map[string]interface{})
+
+	for name, attr := range body.Attributes {
+		if resource && resourceMetaArguments[name] {
+			continue
+		}
+		v, diags := attr.Expr.Value(ctx)
+		if diags.HasErrors() || !v.IsWhollyKnown() {
+			continue // unknown until apply
+		}
+		if converted := ctyToInterface(v); converted != nil {
+			attrs[name] = converted
+		}
+	}
+
+	for _, block := range body.Blocks {
+		if resource && resourceMetaBlocks[block.Type] {
+			continue
+		}
+		if block.Type == "dynamic" && len(block.Labels) == 1 {
+			name := block.Labels[0]
+			for _, item := range expandDynamicBlock(block, ctx) {
+				attrs[name] = append(toInterfaceSlice(attrs[name]), item)
+			}
+			continue
+		}
+		attrs[block.Type] = append(toInterfaceSlice(attrs[block.Type]), bodyToAttributes(block.Body, ctx, false))
+	}
+	return attrs
+}
+
+// expandDynamicBlock evaluates a dynamic block's content once per for_each element
+func expandDynamicBlock(block *hclsyntax.Block, ctx *hcl.EvalContext) []interface{} {
+	forEach, ok := block.Body.Attributes["for_each"]
+	if !ok {
+		return nil
+	}
+	v, diags := forEach.Expr.Value(ctx)
+	if diags.HasErrors() || !v.IsWhollyKnown() || v.IsNull() || !v.CanIterateElements() {
+		return nil
+	}
+
+	iterator := block.Labels[0]
+	if attr, ok := block.Body.Attributes["iterator"]; ok {
+		if trav, diags := hcl.AbsTraversalForExpr(attr.Expr); !diags.HasErrors() {
+			iterator = trav.RootName()
+		}
+	}
+
+	var content *hclsyntax.Block
+	for _, b := range block.Body.Blocks {
+		if b.Type == "content" {
+			content = b
+		}
+	}
+	if content == nil {
+		return nil
+	}
+
+	items := make([]interface{}, 0)
+	for it := v.ElementIterator(); it.Next(); {
+		k, val := it.Element()
+		child := ctx.NewChild()
+		child.Variables = map[string]cty.Value{
+			iterator: cty.ObjectVal(map[string]cty.Value{"key": k, "value": val}),
+		}
+		items = append(items, bodyToAttributes(content.Body, child, false))
+	}
+	return items
+}
+
+// ctyToInterface converts a known cty value to plan-JSON shaped Go values
+func ctyToInterface(v cty.Value) interface{} {
+	if v.IsNull() || !v.IsKnown() {
+		return nil
+	}
+	ty := v.Type()
+	switch {
+	case ty == cty.String:
+		return v.AsString()
+	case ty == cty.Number:
+		f, _ := v.AsBigFloat().Float64()
+		return f
+	case ty == cty.Bool:
+		return v.True()
+	case ty.IsListType() || ty.IsSetType() || ty.IsTupleType():
+		out := make([]interface{}, 0, v.LengthInt())
+		for it := v.ElementIterator(); it.Next(); {
+			_, ev := it.Element()
+			out = append(out, ctyToInterface(ev))
+		}
+		return out
+	case ty.IsMapType() || ty.IsObjectType():
+		out := make(map[string]interface{})
+		for it := v.ElementIterator(); it.Next(); {
+			k, ev := it.Element()
+			out[k.AsString()] = ctyToInterface(ev)
+		}
+		return out
+	}
+	return nil
+}
+
+func toInterfaceSlice(v interface{}) []interface{} {
+	if s, ok := v.([]interface{}); ok {
+		return s
+	}
+	return make([]interface{}, 0)
+}
+
+// bodyReferences returns resource addresses referenced anywhere in a body
+func bodyReferences(body *hclsyntax.Body) []string {
+	refs := make([]string, 0)
+	var walk func(b *hclsyntax.Body)
+	walk = func(b *hclsyntax.Body) {
+		for _, attr := range b.Attributes {
+			for _, trav := range attr.Expr.Variables() {
+				if target := referenceTarget(traversalString(trav)); target != "" {
+					refs = appendUnique(refs, target)
+				}
+			}
+		}
+		for _, block := range b.Blocks {
+			walk(block.Body)
+		}
+	}
+	walk(body)
+	return refs
+}
+
+// referencedVariables returns the variables a module's resources and module
+// calls reference, directly or through locals; the rest cannot affect cost
+func referencedVariables(m *hclModule) map[string]bool {
+	locals := make(map[string]hclsyntax.Expression)
+	for _, block := range m.locals {
+		for name, attr := range block.Body.Attributes {
+			locals[name] = attr.Expr
+		}
+	}
+
+	names := make(map[string]bool)
+	seenLocals := make(map[string]bool)
+	var visit func(expr hclsyntax.Expression)
+	visit = func(expr hclsyntax.Expression) {
+		for _, trav := range expr.Variables() {
+			ref := traversalString(trav)
+			if name, ok := variableName(ref); ok {
+				names[name] = true
+				continue
+			}
+			if rest, ok := strings.CutPrefix(ref, "local."); ok {
+				name, _, _ := strings.Cut(rest, ".")
+				if expr, ok := locals[name]; ok && !seenLocals[name] {
+					seenLocals[name] = true
+					visit(expr)
+				}
+			}
+		}
+	}
+	var walk func(b *hclsyntax.Body)
+	walk = func(b *hclsyntax.Body) {
+		for _, attr := range b.Attributes {
+			visit(attr.Expr)
+		}
+		for _, block := range b.Blocks {
+			walk(block.Body)
+		}
+	}
+	for _, block := range m.resources {
+		walk(block.Body)
+	}
+	for _, block := range m.modules {
+		walk(block.Body)
+	}
+	return names
+}
+
+// traversalString renders the attribute path of a traversal (aws_instance.web.id)
+func traversalString(trav hcl.Traversal) string {
+	parts := make([]string, 0, len(trav))
+	for _, step := range trav {
+		switch s := step.(type) {
+		case hcl.TraverseRoot:
+			parts = append(parts, s.Name)
+		case hcl.TraverseAttr:
+			parts = append(parts, s.Name)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// providerLocalName derives the provider from a resource type (aws_instance -> aws)
+func providerLocalName(resourceType string) string {
+	if i := strings.Index(resourceType, "_"); i > 0 {
+		return resourceType[:i]
+	}
+	return resourceType
+}
+
+// hclFunctions is the subset of Terraform's functions that needs no provider or filesystem
+func hclFunctions() map[string]function.Function {
+	return map[string]function.Function{
+		"abs":        stdlib.AbsoluteFunc,
+		"ceil":       stdlib.CeilFunc,
+		"coalesce":   stdlib.CoalesceFunc,
+		"compact":    stdlib.CompactFunc,
+		"concat":     stdlib.ConcatFunc,
+		"contains":   stdlib.ContainsFunc,
+		"distinct":   stdlib.DistinctFunc,
+		"element":    stdlib.ElementFunc,
+		"flatten":    stdlib.FlattenFunc,
+		"floor":      stdlib.FloorFunc,
+		"format":     stdlib.FormatFunc,
+		"join":       stdlib.JoinFunc,
+		"jsonencode": stdlib.JSONEncodeFunc,
+		"jsondecode": stdlib.JSONDecodeFunc,
+		"keys":       stdlib.KeysFunc,
+		"length":     stdlib.LengthFunc,
+		"lookup":     stdlib.LookupFunc,
+		"lower":      stdlib.LowerFunc,
+		"max":        stdlib.MaxFunc,
+		"merge":      stdlib.MergeFunc,
+		"min":        stdlib.MinFunc,
+		"range":      stdlib.RangeFunc,
+		"replace":    stdlib.ReplaceFunc,
+		"split":      stdlib.SplitFunc,
+		"substr":     stdlib.SubstrFunc,
+		"title":      stdlib.TitleFunc,
+		"tolist":     stdlib.MakeToFunc(cty.List(cty.DynamicPseudoType)),
+		"tomap":      stdlib.MakeToFunc(cty.Map(cty.DynamicPseudoType)),
+		"tonumber":   stdlib.MakeToFunc(cty.Number),
+		"toset":      stdlib.MakeToFunc(cty.Set(cty.DynamicPseudoType)),
+		"tostring":   stdlib.MakeToFunc(cty.String),
+		"trimspace":  stdlib.TrimSpaceFunc,
+		"upper":      stdlib.UpperFunc,
+		"values":     stdlib.ValuesFunc,
+		"zipmap":     stdlib.ZipmapFunc,
+	}
+}
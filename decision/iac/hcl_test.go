@@ -0,0 +1,119 @@
+package iac
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const hclRootConfig = `
+provider "aws" {
+  region = var.region
+}
+
+variable "region" {
+  default = "us-east-1"
+}
+
+variable "web_count" {
+  type    = number
+  default = 1
+}
+
+locals {
+  instance_type = "t3.${var.size}"
+}
+
+variable "size" {
+  default = "small"
+}
+
+resource "aws_vpc" "main" {
+  cidr_block = "10.0.0.0/16"
+}
+
+resource "aws_instance" "web" {
+  count         = var.web_count
+  instance_type = local.instance_type
+  subnet_id     = aws_vpc.main.id
+
+  root_block_device {
+    volume_size = 20
+  }
+}
+
+module "cache" {
+  source = "./modules/cache"
+  nodes  = { a = "cache.t3.micro", b = "cache.t3.small" }
+}
+
+module "remote" {
+  source = "terraform-aws-modules/vpc/aws"
+}
+`
+
+const hclModuleConfig = `
+variable "nodes" {}
+
+resource "aws_elasticache_cluster" "node" {
+  for_each  = var.nodes
+  node_type = each.value
+}
+`
+
+func TestHCLParserParseDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(hclRootConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "modules", "cache"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "modules", "cache", "main.tf"), []byte(hclModuleConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := NewHCLParser().WithVariables(map[string]string{
+		"web_count": "3",
+		"region":    "eu-west-1",
+	}).ParseDir(dir)
+	if err != nil {
+		t.Fatalf("ParseDir: %v", err)
+	}
+
+	byAddr := make(map[string]ResourceNode)
+	for _, r := range plan.Resources {
+		byAddr[r.Address] = r
+	}
+	if len(plan.Resources) != 6 {
+		t.Fatalf("expected 6 resources, got %d: %v", len(plan.Resources), plan.Resources)
+	}
+
+	web, ok := byAddr["aws_instance.web[2]"]
+	if !ok {
+		t.Fatal("aws_instance.web[2] not expanded")
+	}
+	if web.Attributes["instance_type"] != "t3.small" || web.Region != "eu-west-1" {
+		t.Errorf("unexpected web instance: %+v", web)
+	}
+	if _, ok := web.Attributes["subnet_id"]; ok {
+		t.Error("reference to another resource should be unknown")
+	}
+	if blocks, ok := web.Attributes["root_block_device"].([]interface{}); !ok || len(blocks) != 1 {
+		t.Errorf("root_block_device not converted: %v", web.Attributes["root_block_device"])
+	}
+	if deps := plan.Dependencies["aws_instance.web[0]"]; len(deps) != 1 || deps[0] != "aws_vpc.main" {
+		t.Errorf("unexpected dependencies: %v", deps)
+	}
+
+	node, ok := byAddr[`module.cache.aws_elasticache_cluster.node["b"]`]
+	if !ok || node.Attributes["node_type"] != "cache.t3.small" {
+		t.Errorf("module for_each not expanded: %+v", node)
+	}
+	if node.Module != "module.cache" {
+		t.Errorf("module = %q, want module.cache", node.Module)
+	}
+	if len(plan.Warnings) != 1 {
+		t.Errorf("expected remote module warning, got %v", plan.Warnings)
+	}
+}
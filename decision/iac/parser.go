@@ -3,10 +3,13 @@
 package iac
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -20,8 +23,20 @@ const (
 	ActionNoOp    ChangeAction = "no-op"
 	ActionRead    ChangeAction = "read"
 	ActionReplace ChangeAction = "replace"
+	ActionForget  ChangeAction = "forget" // Removed from state without being destroyed
 )
 
+// Plan JSON format versions the parser understands: 0.x (Terraform 0.12 to 1.0)
+// and 1.0 to 1.2 (Terraform 1.1+ and OpenTofu). Newer 1.x minor versions only add
+// fields, so they are parsed with a warning; a new major version is rejected.
+const (
+	MaxFormatMajor = 1
+	MaxFormatMinor = 2
+)
+
+// ErrUnsupportedFormat is returned for plans in an incompatible format version
+var ErrUnsupportedFormat = errors.New("unsupported plan format version")
+
 // ParsedPlan represents a fully parsed Terraform plan
 type ParsedPlan struct {
 	// Metadata
@@ -40,9 +55,17 @@ type ParsedPlan struct {
 	
 	// Variables
 	Variables map[string]interface{} `json:"variables"`
+
+	// Root variables resources refer to that have no value, e.g. in plans made
+	// without their var files; see ApplyVariables
+	UnresolvedVariables []string            `json:"unresolved_variables,omitempty"`
+	VariableReferences  []VariableReference `json:"variable_references,omitempty"`
 	
 	// Outputs
 	Outputs map[string]OutputValue `json:"outputs"`
+	
+	// Warnings from best-effort parsing (e.g. unknown count in HCL input)
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 // ResourceNode represents a single infrastructure resource
@@ -53,10 +76,12 @@ type ResourceNode struct {
 	Name         string `json:"name"`          // web
 	Index        *int   `json:"index"`         // 0 (for count/for_each)
 	IndexKey     string `json:"index_key"`     // key for for_each
+	Module       string `json:"module,omitempty"` // module.vpc.module.subnets["a"]; empty in the root module
 	
 	// Provider
 	Provider     string `json:"provider"`      // aws
 	ProviderName string `json:"provider_name"` // hashicorp/aws
+	ProviderKey  string `json:"provider_key,omitempty"` // aws.use2; empty for the default configuration
 	
 	// Location
 	Region       string `json:"region"`        // Resolved from provider or resource
@@ -85,6 +110,19 @@ type ResourceChange struct {
 	
 	// Computed
 	ChangedAttributes []string `json:"changed_attributes"`
+	
+	// Lifecycle details from newer plan formats
+	PreviousAddress string          `json:"previous_address,omitempty"` // Address before a moved block
+	Importing       bool            `json:"importing,omitempty"`        // Adopted into state by an import block
+	ImportID        string          `json:"import_id,omitempty"`        // Empty when the ID is unknown until apply
+	Deposed         string          `json:"deposed,omitempty"`          // Key of the deposed object this change destroys
+	ReplacePaths    [][]interface{} `json:"replace_paths,omitempty"`    // Attributes forcing replacement
+	ActionReason    string          `json:"action_reason,omitempty"`
+}
+
+// IsMove reports whether the resource changes address through a moved block
+func (c *ResourceChange) IsMove() bool {
+	return c.PreviousAddress != "" && c.PreviousAddress != c.Address
 }
 
 // ProviderConfig represents provider configuration
@@ -105,8 +143,12 @@ type OutputValue struct {
 type Parser struct {
 	// Configuration
 	ResolveRegions bool // Attempt to resolve regions from provider/resource config
+	MaxResources   int  // Fail plans with more resource changes than this; 0 is unlimited
 }
 
+// ErrTooManyResources is returned for plans over the parser's resource limit
+var ErrTooManyResources = errors.New("too many resources in plan")
+
 // NewParser creates a new Terraform plan parser
 func NewParser() *Parser {
 	return &Parser{
@@ -125,67 +167,346 @@ func (p *Parser) ParseFile(path string) (*ParsedPlan, error) {
 }
 
 // Parse parses Terraform plan JSON from a reader
+// The plan is streamed: resource changes are decoded one at a time and sections
+// the model doesn't use (prior_state, planned resource values) are skipped, so
+// memory grows with the resources kept rather than with the file.
 func (p *Parser) Parse(r io.Reader) (*ParsedPlan, error) {
-	var rawPlan TerraformPlanJSON
-	decoder := json.NewDecoder(r)
-	if err := decoder.Decode(&rawPlan); err != nil {
+	dec := json.NewDecoder(r)
+	plan := &ParsedPlan{
+		Resources:    make([]ResourceNode, 0),
+		Dependencies: make(map[string][]string),
+		Changes:      make([]ResourceChange, 0),
+		Providers:    make(map[string]ProviderConfig),
+		Outputs:      make(map[string]OutputValue),
+	}
+	var config RawConfiguration
+
+	if ok, err := enterValue(dec, '{'); err != nil || !ok {
+		if err == nil {
+			err = fmt.Errorf("plan is null")
+		}
 		return nil, fmt.Errorf("failed to decode plan JSON: %w", err)
 	}
-	
-	return p.transform(&rawPlan)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode plan JSON: %w", err)
+		}
+		key, _ := tok.(string)
+		switch key {
+		case "format_version":
+			if err = dec.Decode(&plan.FormatVersion); err == nil {
+				err = checkFormatVersion(plan)
+			}
+		case "terraform_version":
+			err = dec.Decode(&plan.TerraformVersion)
+		case "variables":
+			err = dec.Decode(&plan.Variables)
+		case "planned_values":
+			err = p.decodePlannedValues(dec, plan)
+		case "resource_changes":
+			err = p.decodeResourceChanges(dec, plan)
+		case "configuration":
+			err = dec.Decode(&config)
+		default:
+			err = skipValue(dec)
+		}
+		if errors.Is(err, ErrTooManyResources) || errors.Is(err, ErrUnsupportedFormat) {
+			return nil, err
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode plan JSON: %s: %w", key, err)
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("failed to decode plan JSON: %w", err)
+	}
+
+	p.link(plan, config)
+	return plan, nil
 }
 
 // ParseBytes parses Terraform plan JSON from bytes
 func (p *Parser) ParseBytes(data []byte) (*ParsedPlan, error) {
-	var rawPlan TerraformPlanJSON
-	if err := json.Unmarshal(data, &rawPlan); err != nil {
-		return nil, fmt.Errorf("failed to decode plan JSON: %w", err)
+	return p.Parse(bytes.NewReader(data))
+}
+
+// checkFormatVersion rejects plans of an unknown major format version and warns
+// about newer minor versions
+func checkFormatVersion(plan *ParsedPlan) error {
+	majorPart, minorPart, _ := strings.Cut(plan.FormatVersion, ".")
+	major, err := strconv.Atoi(majorPart)
+	if err != nil {
+		return fmt.Errorf("%w: %q", ErrUnsupportedFormat, plan.FormatVersion)
+	}
+	minor, _ := strconv.Atoi(minorPart)
+	if major > MaxFormatMajor {
+		return fmt.Errorf("%w: %s (supported: up to %d.x)", ErrUnsupportedFormat, plan.FormatVersion, MaxFormatMajor)
 	}
-	return p.transform(&rawPlan)
+	if major == MaxFormatMajor && minor > MaxFormatMinor {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf(
+			"plan format %s is newer than %d.%d; fields it adds are ignored", plan.FormatVersion, MaxFormatMajor, MaxFormatMinor))
+	}
+	return nil
 }
 
-// transform converts raw Terraform JSON to our domain model
-func (p *Parser) transform(raw *TerraformPlanJSON) (*ParsedPlan, error) {
-	plan := &ParsedPlan{
-		FormatVersion:    raw.FormatVersion,
-		TerraformVersion: raw.TerraformVersion,
-		Resources:        make([]ResourceNode, 0),
-		Dependencies:     make(map[string][]string),
-		Changes:          make([]ResourceChange, 0),
-		Providers:        make(map[string]ProviderConfig),
-		Variables:        raw.Variables,
-		Outputs:          make(map[string]OutputValue),
+// decodePlannedValues reads the plan outputs; planned resource values repeat resource_changes
+func (p *Parser) decodePlannedValues(dec *json.Decoder, plan *ParsedPlan) error {
+	if ok, err := enterValue(dec, '{'); err != nil || !ok {
+		return err
 	}
-	
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if tok != "outputs" {
+			if err := skipValue(dec); err != nil {
+				return err
+			}
+			continue
+		}
+		var outputs map[string]RawOutput
+		if err := dec.Decode(&outputs); err != nil {
+			return err
+		}
+		for name, out := range outputs {
+			plan.Outputs[name] = OutputValue{
+				Value:     out.Value,
+				Sensitive: out.Sensitive,
+			}
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// decodeResourceChanges converts resource changes as they are decoded
+func (p *Parser) decodeResourceChanges(dec *json.Decoder, plan *ParsedPlan) error {
+	if ok, err := enterValue(dec, '['); err != nil || !ok {
+		return err
+	}
+	for dec.More() {
+		if p.MaxResources > 0 && len(plan.Changes) >= p.MaxResources {
+			return fmt.Errorf("%w: more than %d resource changes, the configured limit", ErrTooManyResources, p.MaxResources)
+		}
+		var rc RawResourceChange
+		if err := dec.Decode(&rc); err != nil {
+			return err
+		}
+		plan.Changes = append(plan.Changes, p.parseResourceChange(rc))
+		// A deposed object shares its address with the current object, which is the resource
+		if rc.Deposed != "" {
+			continue
+		}
+		plan.Resources = append(plan.Resources, p.buildResourceNode(rc))
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// link resolves what needs the configuration, which follows resource_changes in the
+// plan: provider regions, dependencies between resources and the variables they reference
+func (p *Parser) link(plan *ParsedPlan, config RawConfiguration) {
 	// Parse provider configurations
-	for name, cfg := range raw.Configuration.ProviderConfig {
+	for name, cfg := range config.ProviderConfig {
 		plan.Providers[name] = p.parseProviderConfig(name, cfg)
 	}
-	
-	// Parse resource changes
-	for _, rc := range raw.ResourceChanges {
-		change := p.parseResourceChange(rc)
-		plan.Changes = append(plan.Changes, change)
-		
-		// Build resource node from change
-		node := p.buildResourceNode(rc, plan.Providers)
-		plan.Resources = append(plan.Resources, node)
-		
+
+	// References from configuration expressions, keyed by resource address without index
+	configRefs := configurationReferences(config.RootModule, "")
+	instances := make(map[string][]string)
+	for _, node := range plan.Resources {
+		base := BaseAddress(node.Address)
+		instances[base] = append(instances[base], node.Address)
+	}
+
+	providerKeys := configurationProviderKeys(config.RootModule, "")
+
+	var referenced []string
+	referenced, plan.VariableReferences = configurationVariables(config.RootModule)
+	plan.UnresolvedVariables = unresolvedVariables(referenced, plan.Variables)
+
+	for i := range plan.Resources {
+		node := &plan.Resources[i]
+		if key := providerKeys[BaseAddress(node.Address)]; key != node.Provider {
+			node.ProviderKey = key
+		}
+		if p.ResolveRegions {
+			node.Region = p.resolveRegion(*node, plan.Providers)
+		}
+		for _, ref := range configRefs[BaseAddress(node.Address)] {
+			for _, addr := range instances[ref] {
+				if addr != node.Address && !contains(node.Dependencies, addr) {
+					node.Dependencies = append(node.Dependencies, addr)
+				}
+			}
+		}
+
 		// Track dependencies
 		if len(node.Dependencies) > 0 {
 			plan.Dependencies[node.Address] = node.Dependencies
 		}
 	}
-	
-	// Parse outputs
-	for name, out := range raw.PlannedValues.Outputs {
-		plan.Outputs[name] = OutputValue{
-			Value:     out.Value,
-			Sensitive: out.Sensitive,
+}
+
+// CheckResourceLimit fails plans with more than max resources; 0 is unlimited
+// For formats parsed whole, where the limit can only be checked afterwards.
+func CheckResourceLimit(plan *ParsedPlan, max int) error {
+	if max > 0 && len(plan.Resources) > max {
+		return fmt.Errorf("%w: %d resources, the configured limit is %d", ErrTooManyResources, len(plan.Resources), max)
+	}
+	return nil
+}
+
+// configurationReferences collects the resources each configured resource refers to,
+// in the module and the modules it calls. Values at plan time may be unknown (e.g. an
+// ARN), so references are the only link. References in a module are relative to it.
+func configurationReferences(module RawConfigModule, prefix string) map[string][]string {
+	refs := make(map[string][]string)
+	
+	var walk func(v interface{}, addr string)
+	walk = func(v interface{}, addr string) {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			if list, ok := val["references"].([]interface{}); ok {
+				for _, r := range list {
+					if s, ok := r.(string); ok {
+						if target := referenceTarget(s); target != "" && !contains(refs[addr], prefix+target) {
+							refs[addr] = append(refs[addr], prefix+target)
+						}
+					}
+				}
+			}
+			for _, vv := range val {
+				walk(vv, addr)
+			}
+		case []interface{}:
+			for _, vv := range val {
+				walk(vv, addr)
+			}
 		}
 	}
 	
-	return plan, nil
+	for _, r := range module.Resources {
+		addr := prefix + r.Address
+		walk(r.Expressions, addr)
+		for _, dep := range r.DependsOn {
+			if target := referenceTarget(dep); target != "" && !contains(refs[addr], prefix+target) {
+				refs[addr] = append(refs[addr], prefix+target)
+			}
+		}
+	}
+	for name, call := range module.ModuleCalls {
+		for addr, targets := range configurationReferences(call.Module, prefix+"module."+name+".") {
+			refs[addr] = targets
+		}
+	}
+	return refs
+}
+
+// configurationProviderKeys maps the resources of a configuration module and its
+// calls, by address without index, to the provider configuration they use
+func configurationProviderKeys(module RawConfigModule, prefix string) map[string]string {
+	keys := make(map[string]string)
+	for _, r := range module.Resources {
+		if r.ProviderConfigKey != "" {
+			keys[prefix+r.Address] = r.ProviderConfigKey
+		}
+	}
+	for name, call := range module.ModuleCalls {
+		for addr, key := range configurationProviderKeys(call.Module, prefix+"module."+name+".") {
+			keys[addr] = key
+		}
+	}
+	return keys
+}
+
+// lookupProvider finds a provider configuration by key. Older Terraform versions
+// key providers inherited by a module as module:provider, e.g. "vpc:aws"; when the
+// module has no configuration of its own, the inherited one is used.
+func lookupProvider(providers map[string]ProviderConfig, key string) (ProviderConfig, bool) {
+	if provider, ok := providers[key]; ok {
+		return provider, true
+	}
+	if _, inherited, found := strings.Cut(key, ":"); found {
+		provider, ok := providers[inherited]
+		return provider, ok
+	}
+	return ProviderConfig{}, false
+}
+
+// referenceTarget reduces a reference like "aws_ecs_task_definition.app.arn" to a resource address
+func referenceTarget(ref string) string {
+	parts := strings.Split(BaseAddress(ref), ".")
+	switch parts[0] {
+	case "var", "local", "module", "each", "count", "path", "self", "terraform":
+		return ""
+	case "data":
+		if len(parts) < 3 {
+			return ""
+		}
+		return strings.Join(parts[:3], ".")
+	}
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[0] + "." + parts[1]
+}
+
+// BaseAddress removes count/for_each instance keys from an address
+// aws_instance.web[0] -> aws_instance.web; module.app["a"].aws_s3_bucket.b -> module.app.aws_s3_bucket.b
+func BaseAddress(addr string) string {
+	var sb strings.Builder
+	depth := 0
+	inQuote := false
+	for i, r := range addr {
+		switch {
+		case depth > 0 && r == '"' && (i == 0 || addr[i-1] != '\\'):
+			inQuote = !inQuote
+		case inQuote:
+		case r == '[':
+			depth++
+		case r == ']' && depth > 0:
+			depth--
+		case depth == 0:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// ModuleAddress returns the module path of a resource address, or "" in the root module
+// module.vpc.module.subnets["a"].aws_subnet.this[0] -> module.vpc.module.subnets["a"]
+func ModuleAddress(addr string) string {
+	segments := splitAddress(addr)
+	n := 0
+	for n+1 < len(segments) && segments[n] == "module" {
+		n += 2
+	}
+	return strings.Join(segments[:n], ".")
+}
+
+// splitAddress splits an address at dots outside instance keys
+func splitAddress(addr string) []string {
+	var segments []string
+	depth, start := 0, 0
+	inQuote := false
+	for i, r := range addr {
+		switch {
+		case depth > 0 && r == '"' && (i == 0 || addr[i-1] != '\\'):
+			inQuote = !inQuote
+		case inQuote:
+		case r == '[':
+			depth++
+		case r == ']' && depth > 0:
+			depth--
+		case r == '.' && depth == 0:
+			segments = append(segments, addr[start:i])
+			start = i + 1
+		}
+	}
+	return append(segments, addr[start:])
 }
 
 // parseProviderConfig extracts provider configuration
@@ -219,6 +540,15 @@ func (p *Parser) parseResourceChange(rc RawResourceChange) ResourceChange {
 		Before:   rc.Change.Before,
 		After:    rc.Change.After,
 		AfterUnknown: rc.Change.AfterUnknown,
+		
+		PreviousAddress: rc.PreviousAddress,
+		Importing:       rc.Change.Importing != nil,
+		Deposed:         rc.Deposed,
+		ReplacePaths:    rc.Change.ReplacePaths,
+		ActionReason:    rc.ActionReason,
+	}
+	if rc.Change.Importing != nil {
+		change.ImportID = rc.Change.Importing.ID
 	}
 	
 	// Determine primary action
@@ -230,12 +560,13 @@ func (p *Parser) parseResourceChange(rc RawResourceChange) ResourceChange {
 	return change
 }
 
-// buildResourceNode creates a ResourceNode from change data
-func (p *Parser) buildResourceNode(rc RawResourceChange, providers map[string]ProviderConfig) ResourceNode {
+// buildResourceNode creates a ResourceNode from change data; regions are resolved in link
+func (p *Parser) buildResourceNode(rc RawResourceChange) ResourceNode {
 	node := ResourceNode{
 		Address:      rc.Address,
 		Type:         rc.Type,
 		Name:         rc.Name,
+		Module:       rc.ModuleAddress,
 		Mode:         rc.Mode,
 		Provider:     extractProviderFromAddress(rc.ProviderName),
 		ProviderName: rc.ProviderName,
@@ -244,6 +575,11 @@ func (p *Parser) buildResourceNode(rc RawResourceChange, providers map[string]Pr
 		Dependencies: make([]string, 0),
 	}
 	
+	// Older plans omit module_address
+	if node.Module == "" {
+		node.Module = ModuleAddress(rc.Address)
+	}
+	
 	// Handle no after state (delete)
 	if node.Attributes == nil {
 		node.Attributes = rc.Change.Before
@@ -260,11 +596,6 @@ func (p *Parser) buildResourceNode(rc RawResourceChange, providers map[string]Pr
 		}
 	}
 	
-	// Resolve region
-	if p.ResolveRegions {
-		node.Region = p.resolveRegion(node, providers)
-	}
-	
 	return node
 }
 
@@ -288,7 +619,12 @@ func (p *Parser) resolveRegion(node ResourceNode, providers map[string]ProviderC
 		return location
 	}
 	
-	// 4. Check provider config
+	// 4. Check provider config, the resource's alias before the default
+	if node.ProviderKey != "" {
+		if provider, ok := lookupProvider(providers, node.ProviderKey); ok && provider.Region != "" {
+			return provider.Region
+		}
+	}
 	if provider, ok := providers[node.Provider]; ok && provider.Region != "" {
 		return provider.Region
 	}
@@ -317,6 +653,7 @@ func (p *Parser) determineAction(actions []string) ChangeAction {
 	hasDelete := contains(actions, "delete")
 	hasUpdate := contains(actions, "update")
 	hasRead := contains(actions, "read")
+	hasForget := contains(actions, "forget")
 	
 	if hasCreate && hasDelete {
 		return ActionReplace
@@ -333,6 +670,9 @@ func (p *Parser) determineAction(actions []string) ChangeAction {
 	if hasRead {
 		return ActionRead
 	}
+	if hasForget {
+		return ActionForget
+	}
 	
 	return ActionNoOp
 }
@@ -414,6 +754,13 @@ type RawResourceChange struct {
 	Index        interface{} `json:"index,omitempty"`
 	ProviderName string      `json:"provider_name"`
 	Change       RawChange   `json:"change"`
+
+	ModuleAddress string `json:"module_address,omitempty"` // Absent in the root module
+
+	// Terraform 1.1+ / OpenTofu
+	PreviousAddress string `json:"previous_address,omitempty"` // moved blocks
+	Deposed         string `json:"deposed,omitempty"`          // Deposed object key
+	ActionReason    string `json:"action_reason,omitempty"`
 }
 
 type RawChange struct {
@@ -421,6 +768,15 @@ type RawChange struct {
 	Before       map[string]interface{} `json:"before"`
 	After        map[string]interface{} `json:"after"`
 	AfterUnknown map[string]interface{} `json:"after_unknown"`
+
+	// Terraform 1.2+ / OpenTofu
+	ReplacePaths [][]interface{} `json:"replace_paths,omitempty"`
+	Importing    *RawImporting   `json:"importing,omitempty"` // import blocks (1.5+)
+}
+
+// RawImporting describes an import; ID is absent when it is only known at apply
+type RawImporting struct {
+	ID string `json:"id,omitempty"`
 }
 
 type RawConfiguration struct {
@@ -435,7 +791,13 @@ type RawProviderConfig struct {
 }
 
 type RawConfigModule struct {
-	Resources []RawConfigResource `json:"resources"`
+	Resources   []RawConfigResource      `json:"resources"`
+	ModuleCalls map[string]RawModuleCall `json:"module_calls,omitempty"`
+}
+
+type RawModuleCall struct {
+	Module      RawConfigModule        `json:"module"`
+	Expressions map[string]interface{} `json:"expressions,omitempty"`
 }
 
 type RawConfigResource struct {
@@ -444,7 +806,7 @@ type RawConfigResource struct {
 	Type              string                            `json:"type"`
 	Name              string                            `json:"name"`
 	ProviderConfigKey string                            `json:"provider_config_key"`
-	Expressions       map[string]map[string]interface{} `json:"expressions"`
+	Expressions       map[string]interface{}            `json:"expressions"` // nested blocks are arrays
 	DependsOn         []string                          `json:"depends_on,omitempty"`
 }
 
@@ -466,6 +828,41 @@ func extractProviderFromAddress(providerName string) string {
 	return providerName
 }
 
+// enterValue reads the opening delimiter of an object or array; false for null
+func enterValue(dec *json.Decoder, open json.Delim) (bool, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return false, err
+	}
+	if tok == nil {
+		return false, nil
+	}
+	if tok != open {
+		return false, fmt.Errorf("expected %v, got %v", open, tok)
+	}
+	return true, nil
+}
+
+// skipValue consumes the next value token by token, without buffering it
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
 		if s == item {
@@ -0,0 +1,304 @@
+// Package iac - Terraform plan parser tests
+package iac
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Configuration follows resource_changes, as in `terraform show -json`
+const sampleTerraformPlan = `{
+  "format_version": "1.2",
+  "terraform_version": "1.6.0",
+  "planned_values": {
+    "outputs": {"ip": {"sensitive": false, "value": "10.0.0.1"}},
+    "root_module": {"resources": [{"address": "aws_instance.web[0]", "values": {"instance_type": "t3.micro"}}]}
+  },
+  "resource_changes": [
+    {"address": "aws_instance.web[0]", "mode": "managed", "type": "aws_instance", "name": "web", "index": 0,
+     "provider_name": "registry.terraform.io/hashicorp/aws",
+     "change": {"actions": ["create"], "before": null, "after": {"instance_type": "t3.micro"}}},
+    {"address": "aws_eip.web", "mode": "managed", "type": "aws_eip", "name": "web",
+     "provider_name": "registry.terraform.io/hashicorp/aws",
+     "change": {"actions": ["create"], "before": null, "after": {"domain": "vpc"}}}
+  ],
+  "prior_state": {"format_version": "1.0", "values": {"root_module": {"resources": [{"values": {"tags": [1, {"a": [null]}]}}]}}},
+  "configuration": {
+    "provider_config": {"aws": {"name": "aws", "expressions": {"region": {"constant_value": "eu-west-1"}}}},
+    "root_module": {"resources": [
+      {"address": "aws_eip.web", "expressions": {"instance": {"references": ["aws_instance.web[0].id", "aws_instance.web"]}}}
+    ]}
+  }
+}`
+
+func TestParserStreamsPlan(t *testing.T) {
+	plan, err := NewParser().Parse(strings.NewReader(sampleTerraformPlan))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if plan.FormatVersion != "1.2" || plan.TerraformVersion != "1.6.0" {
+		t.Errorf("versions = %q, %q", plan.FormatVersion, plan.TerraformVersion)
+	}
+	if len(plan.Resources) != 2 || len(plan.Changes) != 2 {
+		t.Fatalf("expected 2 resources and changes, got %d and %d", len(plan.Resources), len(plan.Changes))
+	}
+	web := plan.Resources[0]
+	if web.Region != "eu-west-1" || web.Index == nil || *web.Index != 0 {
+		t.Errorf("web = region %q index %v, want eu-west-1 and 0", web.Region, web.Index)
+	}
+	if deps := plan.Dependencies["aws_eip.web"]; len(deps) != 1 || deps[0] != "aws_instance.web[0]" {
+		t.Errorf("aws_eip.web dependencies = %v", deps)
+	}
+	if out := plan.Outputs["ip"]; out.Value != "10.0.0.1" {
+		t.Errorf("ip output = %v", out.Value)
+	}
+	if plan.Changes[1].Action != ActionCreate {
+		t.Errorf("aws_eip.web action = %s", plan.Changes[1].Action)
+	}
+}
+
+func TestParserResourceLimit(t *testing.T) {
+	tests := []struct {
+		max     int
+		wantErr bool
+	}{
+		{0, false},
+		{2, false},
+		{1, true},
+	}
+	for _, tt := range tests {
+		p := NewParser()
+		p.MaxResources = tt.max
+		_, err := p.ParseBytes([]byte(sampleTerraformPlan))
+		if got := errors.Is(err, ErrTooManyResources); got != tt.wantErr {
+			t.Errorf("MaxResources %d: err = %v, want limit error %v", tt.max, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParserRejectsInvalidPlans(t *testing.T) {
+	for _, input := range []string{
+		`{not json`,
+		`null`,
+		`{"resource_changes": {}}`,
+		`{"resource_changes": [{"address": 1}]}`,
+		`{"format_version": "1.2"`,
+	} {
+		if _, err := NewParser().ParseBytes([]byte(input)); err == nil {
+			t.Errorf("Parse(%s) succeeded, want error", input)
+		}
+	}
+}
+
+func TestModuleAddress(t *testing.T) {
+	tests := map[string]string{
+		"aws_instance.web[0]":                               "",
+		"data.aws_ami.ubuntu":                               "",
+		"module.vpc.aws_nat_gateway.this[0]":                "module.vpc",
+		`module.vpc.module.subnets["a.b"].aws_subnet.this`:  `module.vpc.module.subnets["a.b"]`,
+		`module.app[1].data.aws_iam_policy_document.assume`: "module.app[1]",
+	}
+	for addr, want := range tests {
+		if got := ModuleAddress(addr); got != want {
+			t.Errorf("ModuleAddress(%q) = %q, want %q", addr, got, want)
+		}
+	}
+}
+
+func TestParserFormatVersions(t *testing.T) {
+	tests := []struct {
+		version string
+		warning bool
+		err     bool
+	}{
+		{version: "0.1"}, // Terraform 0.12
+		{version: "0.2"},
+		{version: "1.0"},
+		{version: "1.2"}, // Terraform 1.3+, OpenTofu
+		{version: "1.3", warning: true},
+		{version: "2.0", err: true},
+		{version: "x", err: true},
+	}
+	for _, tt := range tests {
+		plan, err := NewParser().Parse(strings.NewReader(`{"format_version": "` + tt.version + `", "resource_changes": []}`))
+		if tt.err {
+			if !errors.Is(err, ErrUnsupportedFormat) {
+				t.Errorf("%s: err = %v, want ErrUnsupportedFormat", tt.version, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.version, err)
+			continue
+		}
+		if got := len(plan.Warnings) > 0; got != tt.warning {
+			t.Errorf("%s: warnings = %v, want warning %v", tt.version, plan.Warnings, tt.warning)
+		}
+	}
+}
+
+func TestParserLifecycleChanges(t *testing.T) {
+	const lifecyclePlan = `{
+  "format_version": "1.2",
+  "resource_changes": [
+    {"address": "aws_instance.web", "previous_address": "aws_instance.app", "mode": "managed", "type": "aws_instance", "name": "web",
+     "provider_name": "registry.opentofu.org/hashicorp/aws",
+     "change": {"actions": ["no-op"], "before": {"instance_type": "t3.micro"}, "after": {"instance_type": "t3.micro"}}},
+    {"address": "aws_instance.web", "deposed": "00000001", "mode": "managed", "type": "aws_instance", "name": "web",
+     "provider_name": "registry.opentofu.org/hashicorp/aws",
+     "change": {"actions": ["delete"], "before": {"instance_type": "t3.large"}, "after": null}},
+    {"address": "aws_s3_bucket.logs", "mode": "managed", "type": "aws_s3_bucket", "name": "logs",
+     "provider_name": "registry.terraform.io/hashicorp/aws",
+     "change": {"actions": ["no-op"], "before": {}, "after": {}, "importing": {"id": "logs-bucket"}}},
+    {"address": "aws_db_instance.db", "mode": "managed", "type": "aws_db_instance", "name": "db",
+     "provider_name": "registry.terraform.io/hashicorp/aws", "action_reason": "replace_because_cannot_update",
+     "change": {"actions": ["delete", "create"], "before": {}, "after": {}, "replace_paths": [["engine"]]}},
+    {"address": "aws_eip.old", "mode": "managed", "type": "aws_eip", "name": "old",
+     "provider_name": "registry.terraform.io/hashicorp/aws",
+     "change": {"actions": ["forget"], "before": {}, "after": null}}
+  ]
+}`
+	plan, err := NewParser().Parse(strings.NewReader(lifecyclePlan))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Changes) != 5 || len(plan.Resources) != 4 {
+		t.Fatalf("got %d changes and %d resources, want 5 and 4 (deposed object is not a resource)", len(plan.Changes), len(plan.Resources))
+	}
+	if web := plan.Resources[0]; web.Attributes["instance_type"] != "t3.micro" || web.Provider != "aws" {
+		t.Errorf("web = %v (%s), want the current object", web.Attributes, web.Provider)
+	}
+	if c := plan.Changes[2]; !c.Importing || c.ImportID != "logs-bucket" {
+		t.Errorf("logs import = %v %q", c.Importing, c.ImportID)
+	}
+	if c := plan.Changes[3]; c.Action != ActionReplace || len(c.ReplacePaths) != 1 || c.ActionReason != "replace_because_cannot_update" {
+		t.Errorf("db change = %+v", c)
+	}
+
+	graph, err := NewGraphBuilder().Build(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := graph.ChangeStats
+	want := ChangeStatistics{Replaces: 1, Forgets: 1, NoOps: 2, Total: 4, Imports: 1, Moves: 1, Deposed: 1}
+	if stats != want {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+	if graph.Nodes["aws_instance.web"].Change.Deposed != "" {
+		t.Error("web node got the deposed object's change")
+	}
+}
+
+func TestParserProviderAliasRegions(t *testing.T) {
+	const aliasPlan = `{
+  "format_version": "1.2",
+  "resource_changes": [
+    {"address": "aws_instance.default", "mode": "managed", "type": "aws_instance", "name": "default",
+     "provider_name": "registry.terraform.io/hashicorp/aws",
+     "change": {"actions": ["create"], "before": null, "after": {"instance_type": "t3.micro"}}},
+    {"address": "aws_instance.ohio[0]", "mode": "managed", "type": "aws_instance", "name": "ohio", "index": 0,
+     "provider_name": "registry.terraform.io/hashicorp/aws",
+     "change": {"actions": ["create"], "before": null, "after": {"instance_type": "t3.micro"}}},
+    {"address": "aws_ebs_volume.oregon", "mode": "managed", "type": "aws_ebs_volume", "name": "oregon",
+     "provider_name": "registry.terraform.io/hashicorp/aws",
+     "change": {"actions": ["create"], "before": null, "after": {"availability_zone": "us-west-2a"}}},
+    {"address": "module.replica[\"eu\"].aws_instance.db", "mode": "managed", "type": "aws_instance", "name": "db",
+     "module_address": "module.replica[\"eu\"]", "provider_name": "registry.terraform.io/hashicorp/aws",
+     "change": {"actions": ["create"], "before": null, "after": {"instance_type": "t3.micro"}}},
+    {"address": "module.local.aws_instance.app", "mode": "managed", "type": "aws_instance", "name": "app",
+     "module_address": "module.local", "provider_name": "registry.terraform.io/hashicorp/aws",
+     "change": {"actions": ["create"], "before": null, "after": {"instance_type": "t3.micro"}}},
+    {"address": "module.legacy.aws_instance.app", "mode": "managed", "type": "aws_instance", "name": "app",
+     "module_address": "module.legacy", "provider_name": "registry.terraform.io/hashicorp/aws",
+     "change": {"actions": ["create"], "before": null, "after": {"instance_type": "t3.micro"}}}
+  ],
+  "configuration": {
+    "provider_config": {
+      "aws": {"name": "aws", "expressions": {"region": {"constant_value": "us-east-1"}}},
+      "aws.use2": {"name": "aws", "alias": "use2", "expressions": {"region": {"constant_value": "us-east-2"}}},
+      "aws.euw1": {"name": "aws", "alias": "euw1", "expressions": {"region": {"constant_value": "eu-west-1"}}},
+      "module.local:aws": {"name": "aws", "expressions": {"region": {"constant_value": "ap-south-1"}}}
+    },
+    "root_module": {
+      "resources": [
+        {"address": "aws_instance.default", "provider_config_key": "aws"},
+        {"address": "aws_instance.ohio", "provider_config_key": "aws.use2"},
+        {"address": "aws_ebs_volume.oregon", "provider_config_key": "aws.use2"}
+      ],
+      "module_calls": {
+        "replica": {"module": {"resources": [{"address": "aws_instance.db", "provider_config_key": "aws.euw1"}]}},
+        "local": {"module": {"resources": [{"address": "aws_instance.app", "provider_config_key": "module.local:aws"}]}},
+        "legacy": {"module": {"resources": [{"address": "aws_instance.app", "provider_config_key": "legacy:aws"}]}}
+      }
+    }
+  }
+}`
+	plan, err := NewParser().Parse(strings.NewReader(aliasPlan))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]struct{ region, key string }{
+		"aws_instance.default":                 {"us-east-1", ""},
+		"aws_instance.ohio[0]":                 {"us-east-2", "aws.use2"},
+		"aws_ebs_volume.oregon":                {"us-west-2", "aws.use2"}, // The resource's zone wins
+		`module.replica["eu"].aws_instance.db`: {"eu-west-1", "aws.euw1"},
+		"module.local.aws_instance.app":        {"ap-south-1", "module.local:aws"},
+		"module.legacy.aws_instance.app":       {"us-east-1", "legacy:aws"},
+	}
+	if len(plan.Resources) != len(want) {
+		t.Fatalf("got %d resources, want %d", len(plan.Resources), len(want))
+	}
+	for _, r := range plan.Resources {
+		w := want[r.Address]
+		if r.Region != w.region || r.ProviderKey != w.key {
+			t.Errorf("%s = region %q provider key %q, want %q and %q", r.Address, r.Region, r.ProviderKey, w.region, w.key)
+		}
+	}
+}
+
+func TestParserChildModuleReferences(t *testing.T) {
+	const modulePlan = `{
+  "format_version": "1.2",
+  "resource_changes": [
+    {"address": "module.app[0].aws_lb.this", "mode": "managed", "type": "aws_lb", "name": "this",
+     "module_address": "module.app[0]", "provider_name": "registry.terraform.io/hashicorp/aws",
+     "change": {"actions": ["create"], "before": null, "after": {}}},
+    {"address": "module.app[0].module.nodes.aws_instance.node[1]", "mode": "managed", "type": "aws_instance", "name": "node", "index": 1,
+     "module_address": "module.app[0].module.nodes", "provider_name": "registry.terraform.io/hashicorp/aws",
+     "change": {"actions": ["create"], "before": null, "after": {"instance_type": "t3.micro"}}},
+    {"address": "module.app[0].module.nodes.aws_eip.node", "mode": "managed", "type": "aws_eip", "name": "node",
+     "module_address": "module.app[0].module.nodes", "provider_name": "registry.terraform.io/hashicorp/aws",
+     "change": {"actions": ["create"], "before": null, "after": {}}}
+  ],
+  "configuration": {
+    "root_module": {
+      "module_calls": {"app": {"module": {
+        "resources": [{"address": "aws_lb.this", "depends_on": ["module.nodes"]}],
+        "module_calls": {"nodes": {"module": {"resources": [
+          {"address": "aws_eip.node", "expressions": {"instance": {"references": ["aws_instance.node[1].id", "aws_instance.node"]}}}
+        ]}}}
+      }}}
+    }
+  }
+}`
+	plan, err := NewParser().Parse(strings.NewReader(modulePlan))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Resources) != 3 {
+		t.Fatalf("got %d resources, want 3", len(plan.Resources))
+	}
+	if m := plan.Resources[1].Module; m != "module.app[0].module.nodes" {
+		t.Errorf("node module = %q", m)
+	}
+	deps := plan.Dependencies["module.app[0].module.nodes.aws_eip.node"]
+	if len(deps) != 1 || deps[0] != "module.app[0].module.nodes.aws_instance.node[1]" {
+		t.Errorf("eip dependencies = %v, want the node in its module", deps)
+	}
+	// Module outputs are not resources
+	if deps := plan.Dependencies["module.app[0].aws_lb.this"]; len(deps) != 0 {
+		t.Errorf("lb dependencies = %v, want none", deps)
+	}
+}
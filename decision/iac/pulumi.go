@@ -0,0 +1,483 @@
+// Package iac - Pulumi preview JSON parsing
+// Converts `pulumi preview --json` output into the same ParsedPlan model as Terraform
+package iac
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// PlanParser is implemented by every IaC input format
+type PlanParser interface {
+	ParseFile(path string) (*ParsedPlan, error)
+	Parse(r io.Reader) (*ParsedPlan, error)
+	ParseBytes(data []byte) (*ParsedPlan, error)
+}
+
+// Supported plan formats
+const (
+	FormatTerraform = "terraform"
+	FormatPulumi    = "pulumi"
+)
+
+// NewParserForFormat returns the parser for a plan format name
+func NewParserForFormat(format string) (PlanParser, error) {
+	switch strings.ToLower(format) {
+	case "", FormatTerraform, "tofu", "opentofu":
+		return NewParser(), nil
+	case FormatPulumi:
+		return NewPulumiParser(), nil
+	case FormatCloudFormation, "cfn":
+		return NewCloudFormationParser(), nil
+	case FormatHCL, "tf":
+		return NewHCLParser(), nil
+	default:
+		return nil, fmt.Errorf("unsupported plan format: %s", format)
+	}
+}
+
+// PulumiParser parses Pulumi preview JSON output
+type PulumiParser struct {
+	// DefaultRegions is used when neither the resource nor the stack config declares a region
+	DefaultRegions map[string]string
+}
+
+// NewPulumiParser creates a new Pulumi preview parser
+func NewPulumiParser() *PulumiParser {
+	return &PulumiParser{
+		DefaultRegions: map[string]string{
+			"aws":     "us-east-1",
+			"google":  "us-central1",
+			"azurerm": "eastus",
+		},
+	}
+}
+
+// ParseFile parses a Pulumi preview JSON file
+func (p *PulumiParser) ParseFile(path string) (*ParsedPlan, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open preview file: %w", err)
+	}
+	defer f.Close()
+	return p.Parse(f)
+}
+
+// Parse parses Pulumi preview JSON from a reader
+func (p *PulumiParser) Parse(r io.Reader) (*ParsedPlan, error) {
+	var raw PulumiPreviewJSON
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to decode pulumi preview JSON: %w", err)
+	}
+	return p.transform(&raw)
+}
+
+// ParseBytes parses Pulumi preview JSON from bytes
+func (p *PulumiParser) ParseBytes(data []byte) (*ParsedPlan, error) {
+	var raw PulumiPreviewJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to decode pulumi preview JSON: %w", err)
+	}
+	return p.transform(&raw)
+}
+
+// transform converts the Pulumi step list into our domain model
+func (p *PulumiParser) transform(raw *PulumiPreviewJSON) (*ParsedPlan, error) {
+	if raw.Steps == nil {
+		return nil, fmt.Errorf("pulumi preview has no steps (was it produced with --json?)")
+	}
+
+	plan := &ParsedPlan{
+		FormatVersion:    "pulumi",
+		TerraformVersion: "",
+		Resources:        make([]ResourceNode, 0),
+		Dependencies:     make(map[string][]string),
+		Changes:          make([]ResourceChange, 0),
+		Providers:        make(map[string]ProviderConfig),
+		Variables:        make(map[string]interface{}),
+		Outputs:          make(map[string]OutputValue),
+	}
+
+	// Stack config carries provider regions (aws:region, gcp:region, azure:location)
+	for key, val := range raw.Config {
+		plan.Variables[key] = val
+		parts := strings.SplitN(key, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		region, _ := val.(string)
+		if region == "" || (parts[1] != "region" && parts[1] != "location") {
+			continue
+		}
+		provider := pulumiPackageToProvider(parts[0])
+		plan.Providers[provider] = ProviderConfig{
+			Name:       provider,
+			Region:     region,
+			Attributes: make(map[string]interface{}),
+		}
+	}
+
+	// Explicit provider resources override stack config
+	urnToAddress := make(map[string]string)
+	for _, step := range raw.Steps {
+		state := step.state()
+		if state == nil {
+			continue
+		}
+		if strings.HasPrefix(state.Type, "pulumi:providers:") {
+			provider := pulumiPackageToProvider(strings.TrimPrefix(state.Type, "pulumi:providers:"))
+			inputs := state.Inputs
+			if region, ok := inputs["region"].(string); ok && region != "" {
+				plan.Providers[pulumiProviderKey(step.URN, provider)] = ProviderConfig{
+					Name:       provider,
+					Alias:      pulumiURNName(step.URN),
+					Region:     region,
+					Attributes: make(map[string]interface{}),
+				}
+			}
+			continue
+		}
+		urnToAddress[step.URN] = pulumiAddress(state.Type, step.URN)
+	}
+
+	steps, ops := collapseReplacements(raw.Steps)
+	for _, step := range steps {
+		state := step.state()
+		if state == nil || strings.HasPrefix(state.Type, "pulumi:") {
+			continue // Providers and stack resources have no cost
+		}
+
+		address := urnToAddress[step.URN]
+		resourceType := pulumiTypeToTerraform(state.Type)
+		provider := pulumiPackageToProvider(strings.SplitN(state.Type, ":", 2)[0])
+
+		var before, after map[string]interface{}
+		if step.OldState != nil {
+			before = snakeCaseKeys(step.OldState.attributes())
+		}
+		if step.NewState != nil {
+			after = snakeCaseKeys(step.NewState.attributes())
+		}
+
+		change := ResourceChange{
+			Address:  address,
+			Type:     resourceType,
+			Name:     pulumiURNName(step.URN),
+			Provider: provider,
+			Action:   pulumiOpToAction(step.Op),
+			Actions:  ops[step.URN],
+			Before:   before,
+			After:    after,
+		}
+		change.ChangedAttributes = NewParser().computeChangedAttributes(before, after)
+		plan.Changes = append(plan.Changes, change)
+
+		attrs := after
+		if attrs == nil {
+			attrs = before
+		}
+		mode := "managed"
+		if step.Op == "read" || step.Op == "refresh" {
+			mode = "data"
+		}
+
+		node := ResourceNode{
+			Address:      address,
+			Type:         resourceType,
+			Name:         change.Name,
+			Provider:     provider,
+			ProviderName: state.Type,
+			Mode:         mode,
+			Attributes:   attrs,
+			Sensitive:    make(map[string]bool),
+			Dependencies: make([]string, 0),
+		}
+		if node.Attributes == nil {
+			node.Attributes = make(map[string]interface{})
+		}
+
+		for _, depURN := range state.Dependencies {
+			if depAddr, ok := urnToAddress[depURN]; ok {
+				node.Dependencies = append(node.Dependencies, depAddr)
+			}
+		}
+		node.Region = p.resolveRegion(node, state.Provider, plan.Providers)
+
+		plan.Resources = append(plan.Resources, node)
+		if len(node.Dependencies) > 0 {
+			plan.Dependencies[node.Address] = node.Dependencies
+		}
+	}
+
+	return plan, nil
+}
+
+// collapseReplacements merges the steps of each replaced resource into one
+// replace step. A replacement appears as create-replacement, replace and
+// delete-replaced steps for the same URN; the new state comes from the first
+// two, the last only carries the state being deleted. Steps keep the position
+// of their URN's first step; ops returns each URN's ops in order.
+func collapseReplacements(steps []PulumiStep) ([]PulumiStep, map[string][]string) {
+	ops := make(map[string][]string)
+	replaced := make(map[string]int)
+	out := make([]PulumiStep, 0, len(steps))
+	for _, step := range steps {
+		ops[step.URN] = append(ops[step.URN], step.Op)
+		switch step.Op {
+		case "replace", "create-replacement", "delete-replaced":
+		default:
+			out = append(out, step)
+			continue
+		}
+
+		i, ok := replaced[step.URN]
+		if !ok {
+			replaced[step.URN] = len(out)
+			out = append(out, PulumiStep{Op: "replace", URN: step.URN, Provider: step.Provider, Diffs: step.Diffs})
+			i = len(out) - 1
+		}
+		merged := &out[i]
+		if merged.OldState == nil {
+			merged.OldState = step.OldState
+		}
+		if step.Op == "delete-replaced" {
+			continue // Old state only; a lone delete-replaced keeps it as the replace's state
+		}
+		if step.NewState != nil {
+			merged.NewState = step.NewState
+		}
+		if merged.Provider == "" {
+			merged.Provider = step.Provider
+		}
+	}
+	return out, ops
+}
+
+// resolveRegion determines the region for a Pulumi resource
+func (p *PulumiParser) resolveRegion(node ResourceNode, providerRef string, providers map[string]ProviderConfig) string {
+	if region, ok := node.Attributes["region"].(string); ok && region != "" {
+		return region
+	}
+	if az, ok := node.Attributes["availability_zone"].(string); ok && len(az) > 1 {
+		return az[:len(az)-1]
+	}
+	if location, ok := node.Attributes["location"].(string); ok && location != "" {
+		return location
+	}
+
+	// Explicit provider reference: urn::id
+	if providerRef != "" {
+		urn := providerRef
+		if idx := strings.LastIndex(providerRef, "::"); idx > 0 {
+			urn = providerRef[:idx]
+		}
+		if cfg, ok := providers[pulumiProviderKey(urn, node.Provider)]; ok && cfg.Region != "" {
+			return cfg.Region
+		}
+	}
+	if cfg, ok := providers[node.Provider]; ok && cfg.Region != "" {
+		return cfg.Region
+	}
+	return p.DefaultRegions[node.Provider]
+}
+
+// =============================================================================
+// RAW PULUMI JSON STRUCTURES
+// =============================================================================
+
+// PulumiPreviewJSON represents the raw `pulumi preview --json` output
+type PulumiPreviewJSON struct {
+	Config        map[string]interface{} `json:"config"`
+	Steps         []PulumiStep           `json:"steps"`
+	ChangeSummary map[string]int         `json:"changeSummary"`
+}
+
+// PulumiStep is a single planned resource operation
+type PulumiStep struct {
+	Op       string          `json:"op"`
+	URN      string          `json:"urn"`
+	Provider string          `json:"provider,omitempty"`
+	OldState *PulumiResource `json:"oldState,omitempty"`
+	NewState *PulumiResource `json:"newState,omitempty"`
+	Diffs    []string        `json:"diffs,omitempty"`
+}
+
+// PulumiResource is the resource state embedded in a step
+type PulumiResource struct {
+	URN          string                 `json:"urn"`
+	Type         string                 `json:"type"`
+	Custom       bool                   `json:"custom"`
+	Inputs       map[string]interface{} `json:"inputs"`
+	Outputs      map[string]interface{} `json:"outputs"`
+	Parent       string                 `json:"parent,omitempty"`
+	Provider     string                 `json:"provider,omitempty"`
+	Dependencies []string               `json:"dependencies,omitempty"`
+}
+
+// state returns the most relevant state for a step
+func (s PulumiStep) state() *PulumiResource {
+	if s.NewState != nil {
+		if s.NewState.Provider == "" {
+			s.NewState.Provider = s.Provider
+		}
+		return s.NewState
+	}
+	if s.OldState != nil && s.OldState.Provider == "" {
+		s.OldState.Provider = s.Provider
+	}
+	return s.OldState
+}
+
+// attributes merges inputs with known outputs (inputs win for planned values)
+func (r *PulumiResource) attributes() map[string]interface{} {
+	attrs := make(map[string]interface{}, len(r.Inputs)+len(r.Outputs))
+	for k, v := range r.Outputs {
+		attrs[k] = v
+	}
+	for k, v := range r.Inputs {
+		attrs[k] = v
+	}
+	return attrs
+}
+
+// =============================================================================
+// HELPER FUNCTIONS
+// =============================================================================
+
+// pulumiTypeOverrides covers tokens whose names don't follow the provider_module_name convention
+var pulumiTypeOverrides = map[string]string{
+	"aws:ec2/instance:Instance":                 "aws_instance",
+	"aws:ec2/eip:Eip":                           "aws_eip",
+	"aws:ec2/natGateway:NatGateway":             "aws_nat_gateway",
+	"aws:ebs/volume:Volume":                     "aws_ebs_volume",
+	"aws:rds/instance:Instance":                 "aws_db_instance",
+	"aws:s3/bucket:Bucket":                      "aws_s3_bucket",
+	"aws:s3/bucketV2:BucketV2":                  "aws_s3_bucket",
+	"aws:lambda/function:Function":              "aws_lambda_function",
+	"aws:dynamodb/table:Table":                  "aws_dynamodb_table",
+	"aws:lb/loadBalancer:LoadBalancer":          "aws_lb",
+	"aws:alb/loadBalancer:LoadBalancer":         "aws_lb",
+	"aws:elb/loadBalancer:LoadBalancer":         "aws_elb",
+	"gcp:compute/instance:Instance":             "google_compute_instance",
+	"gcp:compute/disk:Disk":                     "google_compute_disk",
+	"gcp:sql/databaseInstance:DatabaseInstance": "google_sql_database_instance",
+	"gcp:storage/bucket:Bucket":                 "google_storage_bucket",
+	"gcp:container/cluster:Cluster":             "google_container_cluster",
+}
+
+// pulumiTypeToTerraform maps a Pulumi type token to the equivalent Terraform resource type
+// aws:ec2/launchTemplate:LaunchTemplate -> aws_launch_template
+// aws:ecs/service:Service -> aws_ecs_service
+func pulumiTypeToTerraform(token string) string {
+	if tf, ok := pulumiTypeOverrides[token]; ok {
+		return tf
+	}
+	parts := strings.Split(token, ":")
+	if len(parts) != 3 {
+		return token
+	}
+	provider := pulumiPackageToProvider(parts[0])
+	module := strings.SplitN(parts[1], "/", 2)[0]
+	name := toSnake(parts[2])
+
+	// The bridged AWS provider files most VPC-level types under ec2 without a prefix,
+	// and names usually omit the module when the type already contains it
+	if (provider == "aws" && module == "ec2") || module == "index" || strings.HasPrefix(name, module+"_") {
+		return provider + "_" + name
+	}
+	return provider + "_" + module + "_" + name
+}
+
+// pulumiPackageToProvider maps a Pulumi package name to the Terraform provider name
+func pulumiPackageToProvider(pkg string) string {
+	switch pkg {
+	case "gcp":
+		return "google"
+	case "azure", "azure-native":
+		return "azurerm"
+	default:
+		return pkg
+	}
+}
+
+// pulumiAddress builds a Terraform-like address from a type token and URN
+func pulumiAddress(token, urn string) string {
+	return pulumiTypeToTerraform(token) + "." + pulumiURNName(urn)
+}
+
+// pulumiURNName extracts the resource name from a URN
+// urn:pulumi:dev::proj::aws:ec2/instance:Instance::web -> web
+func pulumiURNName(urn string) string {
+	if idx := strings.LastIndex(urn, "::"); idx >= 0 {
+		return urn[idx+2:]
+	}
+	return urn
+}
+
+// pulumiProviderKey builds the providers map key for an explicit provider resource
+func pulumiProviderKey(urn, provider string) string {
+	return provider + "." + pulumiURNName(urn)
+}
+
+// pulumiOpToAction maps a Pulumi step op to our ChangeAction
+func pulumiOpToAction(op string) ChangeAction {
+	switch op {
+	case "create", "import":
+		return ActionCreate
+	case "update":
+		return ActionUpdate
+	case "delete", "discard":
+		return ActionDelete
+	case "replace", "create-replacement", "delete-replaced":
+		return ActionReplace
+	case "read", "refresh":
+		return ActionRead
+	default:
+		return ActionNoOp
+	}
+}
+
+// snakeCaseKeys recursively converts camelCase map keys to snake_case
+func snakeCaseKeys(attrs map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(attrs))
+	for k, v := range attrs {
+		result[toSnake(k)] = snakeCaseValue(v)
+	}
+	return result
+}
+
+func snakeCaseValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return snakeCaseKeys(val)
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = snakeCaseValue(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// toSnake converts camelCase or PascalCase to snake_case
+func toSnake(s string) string {
+	var sb strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				sb.WriteRune('_')
+			}
+			sb.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
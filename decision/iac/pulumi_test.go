@@ -0,0 +1,164 @@
+// Package iac - Pulumi parser tests
+package iac
+
+import (
+	"testing"
+)
+
+const samplePulumiPreview = `{
+  "config": {"aws:region": "eu-west-1"},
+  "steps": [
+    {
+      "op": "same",
+      "urn": "urn:pulumi:dev::app::pulumi:providers:aws::useast2",
+      "newState": {
+        "urn": "urn:pulumi:dev::app::pulumi:providers:aws::useast2",
+        "type": "pulumi:providers:aws",
+        "inputs": {"region": "us-east-2"}
+      }
+    },
+    {
+      "op": "create",
+      "urn": "urn:pulumi:dev::app::aws:ec2/instance:Instance::web",
+      "newState": {
+        "type": "aws:ec2/instance:Instance",
+        "inputs": {"instanceType": "t3.medium", "rootBlockDevice": {"volumeSize": 20}},
+        "dependencies": ["urn:pulumi:dev::app::aws:ec2/natGateway:NatGateway::nat"]
+      }
+    },
+    {
+      "op": "create",
+      "urn": "urn:pulumi:dev::app::aws:ec2/natGateway:NatGateway::nat",
+      "provider": "urn:pulumi:dev::app::pulumi:providers:aws::useast2::04da6b54",
+      "newState": {
+        "type": "aws:ec2/natGateway:NatGateway",
+        "inputs": {}
+      }
+    },
+    {
+      "op": "delete",
+      "urn": "urn:pulumi:dev::app::aws:ecs/taskDefinition:TaskDefinition::old",
+      "oldState": {
+        "type": "aws:ecs/taskDefinition:TaskDefinition",
+        "inputs": {"cpu": "256"}
+      }
+    }
+  ]
+}`
+
+func TestPulumiParserBuildsPlan(t *testing.T) {
+	plan, err := NewPulumiParser().ParseBytes([]byte(samplePulumiPreview))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(plan.Resources) != 3 {
+		t.Fatalf("expected 3 resources, got %d", len(plan.Resources))
+	}
+
+	byAddr := make(map[string]ResourceNode)
+	for _, r := range plan.Resources {
+		byAddr[r.Address] = r
+	}
+
+	web, ok := byAddr["aws_instance.web"]
+	if !ok {
+		t.Fatalf("expected aws_instance.web, got %v", byAddr)
+	}
+	if web.Attributes["instance_type"] != "t3.medium" {
+		t.Errorf("expected snake_cased instance_type, got %v", web.Attributes)
+	}
+	if web.Region != "eu-west-1" {
+		t.Errorf("expected stack config region eu-west-1, got %s", web.Region)
+	}
+	if deps := plan.Dependencies["aws_instance.web"]; len(deps) != 1 || deps[0] != "aws_nat_gateway.nat" {
+		t.Errorf("expected dependency on aws_nat_gateway.nat, got %v", deps)
+	}
+
+	if nat := byAddr["aws_nat_gateway.nat"]; nat.Region != "us-east-2" {
+		t.Errorf("expected explicit provider region us-east-2, got %s", nat.Region)
+	}
+
+	if _, ok := byAddr["aws_ecs_task_definition.old"]; !ok {
+		t.Errorf("expected aws_ecs_task_definition.old from deleted step")
+	}
+}
+
+func TestPulumiParserGraphStats(t *testing.T) {
+	plan, err := NewPulumiParser().ParseBytes([]byte(samplePulumiPreview))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	graph, err := NewGraphBuilder().Build(plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if graph.ChangeStats.Creates != 2 || graph.ChangeStats.Deletes != 1 {
+		t.Errorf("unexpected change stats: %+v", graph.ChangeStats)
+	}
+}
+
+// A replacement as `pulumi preview --json` reports it: create-replacement, replace, delete-replaced
+const pulumiReplacePreview = `{
+  "config": {"aws:region": "us-east-1"},
+  "steps": [
+    {"op": "create-replacement", "urn": "urn:pulumi:dev::app::aws:ec2/instance:Instance::web",
+     "oldState": {"type": "aws:ec2/instance:Instance", "inputs": {"instanceType": "t3.small", "ami": "ami-1"}},
+     "newState": {"type": "aws:ec2/instance:Instance", "inputs": {"instanceType": "t3.large", "ami": "ami-2"}}},
+    {"op": "replace", "urn": "urn:pulumi:dev::app::aws:ec2/instance:Instance::web",
+     "oldState": {"type": "aws:ec2/instance:Instance", "inputs": {"instanceType": "t3.small", "ami": "ami-1"}},
+     "newState": {"type": "aws:ec2/instance:Instance", "inputs": {"instanceType": "t3.large", "ami": "ami-2"}}},
+    {"op": "delete-replaced", "urn": "urn:pulumi:dev::app::aws:ec2/instance:Instance::web",
+     "oldState": {"type": "aws:ec2/instance:Instance", "inputs": {"instanceType": "t3.small", "ami": "ami-1"}}}
+  ]
+}`
+
+func TestPulumiParserCollapsesReplacements(t *testing.T) {
+	plan, err := NewPulumiParser().ParseBytes([]byte(pulumiReplacePreview))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Resources) != 1 || len(plan.Changes) != 1 {
+		t.Fatalf("expected one resource and change, got %d and %d", len(plan.Resources), len(plan.Changes))
+	}
+	change := plan.Changes[0]
+	if change.Action != ActionReplace || change.Before["instance_type"] != "t3.small" || change.After["instance_type"] != "t3.large" {
+		t.Errorf("change = %s %v -> %v, want replace t3.small -> t3.large", change.Action, change.Before["instance_type"], change.After["instance_type"])
+	}
+	if len(change.Actions) != 3 {
+		t.Errorf("actions = %v, want the three steps", change.Actions)
+	}
+
+	graph, err := NewGraphBuilder().Build(plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if web := graph.Nodes["aws_instance.web"]; web == nil || web.Resource.Attributes["instance_type"] != "t3.large" {
+		t.Errorf("priced node = %+v, want the t3.large replacement", web)
+	}
+}
+
+func TestPulumiTypeToTerraform(t *testing.T) {
+	tests := map[string]string{
+		"aws:ec2/instance:Instance":             "aws_instance",
+		"aws:ec2/launchTemplate:LaunchTemplate": "aws_launch_template",
+		"aws:ecs/service:Service":               "aws_ecs_service",
+		"aws:sqs/queue:Queue":                   "aws_sqs_queue",
+		"gcp:compute/instance:Instance":         "google_compute_instance",
+	}
+	for token, want := range tests {
+		if got := pulumiTypeToTerraform(token); got != want {
+			t.Errorf("pulumiTypeToTerraform(%s) = %s, want %s", token, got, want)
+		}
+	}
+}
+
+func TestNewParserForFormat(t *testing.T) {
+	if _, err := NewParserForFormat("pulumi"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := NewParserForFormat("bicep"); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
@@ -0,0 +1,252 @@
+// Package iac - Plan redaction
+// Plan JSON carries secrets in resource values and before/after change objects.
+// Redaction replaces them before a plan is parsed or sent to a shared estimation
+// server: values Terraform marks sensitive (sensitive_values, before_sensitive,
+// after_sensitive, sensitive outputs and variables) and values of attributes
+// whose names match a denylist, at any depth and in any plan format.
+package iac
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// RedactedValue replaces every redacted value
+const RedactedValue = "(redacted)"
+
+// DefaultRedactAttributes are attribute name fragments always redacted
+// Names match case-insensitively when they contain a fragment (e.g. master_password).
+var DefaultRedactAttributes = []string{
+	"password",
+	"secret",
+	"token",
+	"private_key",
+	"access_key",
+	"api_key",
+	"credentials",
+	"connection_string",
+	"user_data",
+}
+
+// Redactor strips sensitive values from plan JSON
+type Redactor struct {
+	attributes []string
+}
+
+// NewRedactor creates a redactor with the default attribute denylist
+func NewRedactor() *Redactor {
+	return &Redactor{attributes: append([]string{}, DefaultRedactAttributes...)}
+}
+
+// WithAttributes adds attribute name fragments to the denylist
+func (r *Redactor) WithAttributes(names ...string) *Redactor {
+	for _, name := range names {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			r.attributes = append(r.attributes, name)
+		}
+	}
+	return r
+}
+
+// Redact returns the plan JSON with sensitive values replaced, and how many were replaced
+// The whole document is held in memory; numbers keep their original precision.
+func (r *Redactor) Redact(data []byte) ([]byte, int, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode plan JSON for redaction: %w", err)
+	}
+
+	count := 0
+	if plan, ok := doc.(map[string]interface{}); ok {
+		count += r.redactMarked(plan)
+	}
+	count += r.redactDenied(doc, false)
+
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to encode redacted plan: %w", err)
+	}
+	return out, count, nil
+}
+
+// redactMarked replaces the values Terraform marks sensitive in a plan
+func (r *Redactor) redactMarked(plan map[string]interface{}) int {
+	count := 0
+
+	for _, rc := range asSlice(plan["resource_changes"]) {
+		change, ok := asMap(rc)["change"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		count += maskInto(change, "before", change["before_sensitive"])
+		count += maskInto(change, "after", change["after_sensitive"])
+	}
+
+	if planned, ok := plan["planned_values"].(map[string]interface{}); ok {
+		count += maskModule(planned["root_module"])
+		count += maskOutputs(planned["outputs"])
+	}
+	if prior, ok := plan["prior_state"].(map[string]interface{}); ok {
+		if values, ok := prior["values"].(map[string]interface{}); ok {
+			count += maskModule(values["root_module"])
+			count += maskOutputs(values["outputs"])
+		}
+	}
+
+	for _, oc := range asMap(plan["output_changes"]) {
+		change := asMap(oc)
+		count += maskInto(change, "before", change["before_sensitive"])
+		count += maskInto(change, "after", change["after_sensitive"])
+	}
+
+	// Variables declared sensitive in the root module configuration
+	configRoot := asMap(asMap(plan["configuration"])["root_module"])
+	variables := asMap(plan["variables"])
+	for name, decl := range asMap(configRoot["variables"]) {
+		if sensitive, _ := asMap(decl)["sensitive"].(bool); sensitive {
+			count += maskInto(asMap(variables[name]), "value", true)
+		}
+	}
+	return count
+}
+
+// maskModule masks resource values by their sensitive_values markers, including child modules
+func maskModule(module interface{}) int {
+	m := asMap(module)
+	count := 0
+	for _, res := range asSlice(m["resources"]) {
+		resource := asMap(res)
+		count += maskInto(resource, "values", resource["sensitive_values"])
+	}
+	for _, child := range asSlice(m["child_modules"]) {
+		count += maskModule(child)
+	}
+	return count
+}
+
+// maskOutputs masks the values of outputs declared sensitive
+func maskOutputs(outputs interface{}) int {
+	count := 0
+	for _, out := range asMap(outputs) {
+		output := asMap(out)
+		if sensitive, _ := output["sensitive"].(bool); sensitive {
+			count += maskInto(output, "value", true)
+		}
+	}
+	return count
+}
+
+// maskInto masks parent[key] by a sensitivity marker
+func maskInto(parent map[string]interface{}, key string, marker interface{}) int {
+	if parent == nil {
+		return 0
+	}
+	value, ok := parent[key]
+	if !ok {
+		return 0
+	}
+	masked, count := mask(value, marker)
+	parent[key] = masked
+	return count
+}
+
+// mask replaces the parts of a value its marker flags: true for the whole value,
+// or an object/array mirroring the value's structure
+func mask(value, marker interface{}) (interface{}, int) {
+	if value == nil {
+		return nil, 0
+	}
+	switch m := marker.(type) {
+	case bool:
+		if m {
+			return RedactedValue, 1
+		}
+	case map[string]interface{}:
+		if v, ok := value.(map[string]interface{}); ok {
+			count := 0
+			for k, km := range m {
+				if _, exists := v[k]; exists {
+					var n int
+					v[k], n = mask(v[k], km)
+					count += n
+				}
+			}
+			return v, count
+		}
+	case []interface{}:
+		if v, ok := value.([]interface{}); ok {
+			count := 0
+			for i := range v {
+				if i < len(m) {
+					var n int
+					v[i], n = mask(v[i], m[i])
+					count += n
+				}
+			}
+			return v, count
+		}
+	}
+	return value, 0
+}
+
+// redactDenied replaces string and number values under denylisted attribute names
+// Booleans are kept: sensitivity and unknown markers mirror attribute names.
+func (r *Redactor) redactDenied(v interface{}, denied bool) int {
+	count := 0
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			if k == "references" {
+				continue // Configuration references are addresses, not values
+			}
+			childDenied := denied || r.denied(k)
+			switch child.(type) {
+			case string, json.Number:
+				if childDenied && child != RedactedValue {
+					val[k] = RedactedValue
+					count++
+				}
+			default:
+				count += r.redactDenied(child, childDenied)
+			}
+		}
+	case []interface{}:
+		for i, child := range val {
+			switch child.(type) {
+			case string, json.Number:
+				if denied && child != RedactedValue {
+					val[i] = RedactedValue
+					count++
+				}
+			default:
+				count += r.redactDenied(child, denied)
+			}
+		}
+	}
+	return count
+}
+
+// denied reports whether an attribute name matches the denylist
+func (r *Redactor) denied(name string) bool {
+	name = strings.ToLower(name)
+	for _, fragment := range r.attributes {
+		if strings.Contains(name, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+func asMap(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+func asSlice(v interface{}) []interface{} {
+	s, _ := v.([]interface{})
+	return s
+}
@@ -0,0 +1,78 @@
+// Package iac - Redaction tests
+package iac
+
+import (
+	"strings"
+	"testing"
+)
+
+const sensitivePlan = `{
+  "format_version": "1.2",
+  "variables": {"db_password": {"value": "hunter2"}, "region": {"value": "us-east-1"}},
+  "planned_values": {
+    "outputs": {"conn": {"sensitive": true, "value": "postgres://admin:hunter2@db"}},
+    "root_module": {"resources": [{
+      "address": "aws_db_instance.main", "mode": "managed", "type": "aws_db_instance", "name": "main",
+      "provider_name": "registry.terraform.io/hashicorp/aws",
+      "values": {"instance_class": "db.t3.micro", "allocated_storage": 20, "password": "hunter2", "custom": "internal-only"},
+      "sensitive_values": {"password": true}
+    }]}
+  },
+  "resource_changes": [{
+    "address": "aws_db_instance.main", "mode": "managed", "type": "aws_db_instance", "name": "main",
+    "provider_name": "registry.terraform.io/hashicorp/aws",
+    "change": {
+      "actions": ["create"], "before": null,
+      "after": {"instance_class": "db.t3.micro", "allocated_storage": 20, "password": "hunter2",
+        "custom": "internal-only", "tags": {"Team": "data", "Notes": "rotate yearly"}},
+      "after_sensitive": {"password": true, "custom": true, "tags": {"Notes": true}},
+      "after_unknown": {"password": false}
+    }
+  }],
+  "configuration": {"root_module": {
+    "variables": {"db_password": {"sensitive": true}, "region": {}},
+    "resources": [{"address": "aws_db_instance.main", "mode": "managed", "type": "aws_db_instance", "name": "main",
+      "expressions": {"password": {"references": ["var.db_password"]}, "api_token": {"constant_value": "abc123"}}}]
+  }}
+}`
+
+func TestRedact(t *testing.T) {
+	out, count, err := NewRedactor().WithAttributes("Custom").Redact([]byte(sensitivePlan))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	redacted := string(out)
+	for _, secret := range []string{"hunter2", "internal-only", "rotate yearly", "abc123"} {
+		if strings.Contains(redacted, secret) {
+			t.Errorf("redacted plan still contains %q: %s", secret, redacted)
+		}
+	}
+	for _, kept := range []string{"db.t3.micro", `"allocated_storage":20`, "var.db_password", `"Team":"data"`, `"after_unknown":{"password":false}`} {
+		if !strings.Contains(redacted, kept) {
+			t.Errorf("redacted plan lost %q: %s", kept, redacted)
+		}
+	}
+	// password and custom in values and after, tags.Notes, the output, the variable and api_token
+	if count != 8 {
+		t.Errorf("redacted %d values, want 8", count)
+	}
+
+	plan, err := NewParser().ParseBytes(out)
+	if err != nil {
+		t.Fatalf("redacted plan does not parse: %v", err)
+	}
+	if len(plan.Resources) != 1 || plan.Resources[0].Attributes["instance_class"] != "db.t3.micro" {
+		t.Errorf("unexpected resources after redaction: %+v", plan.Resources)
+	}
+}
+
+func TestRedactDenylistOnly(t *testing.T) {
+	// Formats without sensitivity markers are redacted by attribute name
+	out, count, err := NewRedactor().Redact([]byte(`{"steps": [{"new": {"inputs": {"masterPassword": "s3cret", "instanceClass": "db.t3.micro"}}}]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 1 || strings.Contains(string(out), "s3cret") || !strings.Contains(string(out), "db.t3.micro") {
+		t.Errorf("unexpected redaction (%d values): %s", count, out)
+	}
+}
@@ -0,0 +1,170 @@
+// Package iac - Terragrunt run-all input
+// Finds the plan JSON of each stack in a `terragrunt run-all` tree and the
+// dependencies between stacks declared in their terragrunt.hcl files.
+package iac
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// TerragruntConfigFile is the per-stack terragrunt configuration
+const TerragruntConfigFile = "terragrunt.hcl"
+
+// terragruntSkipDirs hold provider caches and working copies, never stacks
+var terragruntSkipDirs = map[string]bool{
+	".terragrunt-cache": true,
+	".terraform":        true,
+	".git":              true,
+}
+
+// TerragruntStack is one module of a terragrunt run-all
+type TerragruntStack struct {
+	Name      string   `json:"name"` // Path relative to the plan directory
+	PlanFile  string   `json:"plan_file"`
+	DependsOn []string `json:"depends_on"` // Stacks whose outputs this stack reads
+}
+
+// DiscoverTerragruntStacks finds plan files matching pattern (e.g. "*.json") under planDir
+// Each plan's stack is its directory; dependencies come from the terragrunt.hcl at the
+// same relative path under configDir, which may be planDir itself.
+func DiscoverTerragruntStacks(planDir, configDir, pattern string) ([]TerragruntStack, error) {
+	plansByDir := make(map[string][]string)
+	err := filepath.WalkDir(planDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if terragruntSkipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ok, _ := filepath.Match(pattern, d.Name()); !ok {
+			return nil
+		}
+		rel, err := filepath.Rel(planDir, path)
+		if err != nil {
+			return err
+		}
+		dir := filepath.ToSlash(filepath.Dir(rel))
+		plansByDir[dir] = append(plansByDir[dir], path)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan plan directory: %w", err)
+	}
+	if len(plansByDir) == 0 {
+		return nil, fmt.Errorf("no plan files matching %q under %s", pattern, planDir)
+	}
+
+	var stacks []TerragruntStack
+	for dir, plans := range plansByDir {
+		deps, err := terragruntDependencies(configDir, dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, plan := range plans {
+			// A directory with several plans is several stacks
+			name := dir
+			if len(plans) > 1 {
+				name = strings.TrimPrefix(dir+"/"+strings.TrimSuffix(filepath.Base(plan), filepath.Ext(plan)), "./")
+			}
+			stacks = append(stacks, TerragruntStack{Name: name, PlanFile: plan, DependsOn: deps})
+		}
+	}
+	sort.Slice(stacks, func(i, j int) bool { return stacks[i].Name < stacks[j].Name })
+	return stacks, nil
+}
+
+// terragruntDependencies reads the dependency and dependencies blocks of a stack's
+// terragrunt.hcl; paths are returned relative to configDir, like stack names.
+// Paths built with functions (find_in_parent_folders, ...) can't be resolved and are skipped.
+func terragruntDependencies(configDir, stack string) ([]string, error) {
+	stackDir := filepath.Join(configDir, filepath.FromSlash(stack))
+	path := filepath.Join(stackDir, TerragruntConfigFile)
+	src, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	file, diags := hclsyntax.ParseConfig(src, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse %s: %s", path, diags.Error())
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, nil
+	}
+
+	var paths []string
+	for _, block := range body.Blocks {
+		switch block.Type {
+		case "dependency":
+			if attr, ok := block.Body.Attributes["config_path"]; ok {
+				if v, diags := attr.Expr.Value(nil); !diags.HasErrors() && v.Type() == cty.String && v.IsKnown() {
+					paths = append(paths, v.AsString())
+				}
+			}
+		case "dependencies":
+			if attr, ok := block.Body.Attributes["paths"]; ok {
+				if v, diags := attr.Expr.Value(nil); !diags.HasErrors() && v.CanIterateElements() {
+					for it := v.ElementIterator(); it.Next(); {
+						if _, p := it.Element(); p.Type() == cty.String && p.IsKnown() {
+							paths = append(paths, p.AsString())
+						}
+					}
+				}
+			}
+		}
+	}
+
+	var deps []string
+	for _, p := range paths {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(stackDir, p)
+		}
+		rel, err := filepath.Rel(configDir, p)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		if dep := filepath.ToSlash(rel); !contains(deps, dep) {
+			deps = append(deps, dep)
+		}
+	}
+	sort.Strings(deps)
+	return deps, nil
+}
+
+// SharedResourceTypes are resources typically consumed by the stacks that depend on
+// the stack defining them, so their cost belongs to those stacks as much as its own
+var SharedResourceTypes = map[string]bool{
+	"aws_nat_gateway":                true,
+	"aws_lb":                         true,
+	"aws_alb":                        true,
+	"aws_elb":                        true,
+	"aws_ec2_transit_gateway":        true,
+	"aws_vpc_endpoint":               true,
+	"google_compute_router_nat":      true,
+	"google_compute_forwarding_rule": true,
+}
+
+// ResourceType returns the type of a resource address, ignoring module path and instance keys
+// module.vpc.aws_nat_gateway.this[0] -> aws_nat_gateway
+func ResourceType(addr string) string {
+	parts := strings.Split(BaseAddress(addr), ".")
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[len(parts)-2]
+}
@@ -0,0 +1,66 @@
+// Package iac - Terragrunt discovery tests
+package iac
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDiscoverTerragruntStacks(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"vpc/tfplan.json":                    `{}`,
+		"app/tfplan.json":                    `{}`,
+		"app/terragrunt.hcl":                 `dependency "vpc" { config_path = "../vpc" }` + "\n" + `dependencies { paths = ["../db", "../../outside"] }`,
+		"db/terragrunt.hcl":                  `dependency "vpc" { config_path = find_in_parent_folders("vpc") }`,
+		"db/tfplan.json":                     `{}`,
+		"db/.terragrunt-cache/x/tfplan.json": `{}`,
+		"vpc/README.md":                      `not a plan`,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stacks, err := DiscoverTerragruntStacks(root, root, "*.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := make(map[string][]string)
+	for _, s := range stacks {
+		got[s.Name] = s.DependsOn
+	}
+	want := map[string][]string{
+		"app": {"db", "vpc"},
+		"db":  nil,
+		"vpc": nil,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("stacks = %v, want %v", got, want)
+	}
+
+	if _, err := DiscoverTerragruntStacks(root, root, "*.plan"); err == nil {
+		t.Error("expected an error when no plans match")
+	}
+}
+
+func TestResourceType(t *testing.T) {
+	tests := map[string]string{
+		"aws_nat_gateway.main":                    "aws_nat_gateway",
+		`module.vpc["a"].aws_nat_gateway.this[0]`: "aws_nat_gateway",
+		"data.aws_ami.ubuntu":                     "aws_ami",
+		"invalid":                                 "",
+	}
+	for addr, want := range tests {
+		if got := ResourceType(addr); got != want {
+			t.Errorf("ResourceType(%q) = %q, want %q", addr, got, want)
+		}
+	}
+}
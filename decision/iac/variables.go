@@ -0,0 +1,184 @@
+// Package iac - Plan variables
+// Plans uploaded without their var files leave root variables unset, and
+// attributes set from them missing or unknown. Callers supply the values
+// afterwards. Plan JSON records which variables an expression references but
+// not the expression, so only raw HCL configuration is evaluated with them;
+// in plans the attributes stay unknown, named in warnings.
+package iac
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// referenceWorkspace is how configuration expressions refer to the workspace
+const referenceWorkspace = "terraform.workspace"
+
+// VariableReference is a root module resource attribute whose expression only
+// references a variable (var.size) or the workspace (terraform.workspace).
+// Plan JSON cannot tell "t3.${var.size}" from var.size, so the value the
+// attribute gets from it is unknown.
+type VariableReference struct {
+	Address   string `json:"address"` // Resource address without index
+	Attribute string `json:"attribute"`
+	Reference string `json:"reference"` // var.size or terraform.workspace
+}
+
+// configurationVariables collects the root variables a root module references,
+// from resources and module call arguments, and the resource attributes set
+// directly from a variable or the workspace
+func configurationVariables(module RawConfigModule) ([]string, []VariableReference) {
+	seen := make(map[string]bool)
+	var names []string
+	var collect func(v interface{})
+	collect = func(v interface{}) {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			for _, ref := range stringList(val["references"]) {
+				if name, ok := variableName(ref); ok && !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+			for _, vv := range val {
+				collect(vv)
+			}
+		case []interface{}:
+			for _, vv := range val {
+				collect(vv)
+			}
+		}
+	}
+
+	var refs []VariableReference
+	for _, r := range module.Resources {
+		collect(r.Expressions)
+		for attr, expr := range r.Expressions {
+			if ref, ok := directReference(expr); ok {
+				refs = append(refs, VariableReference{Address: r.Address, Attribute: attr, Reference: ref})
+			}
+		}
+	}
+	for _, call := range module.ModuleCalls {
+		collect(call.Expressions)
+	}
+	sort.Strings(names)
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Address != refs[j].Address {
+			return refs[i].Address < refs[j].Address
+		}
+		return refs[i].Attribute < refs[j].Attribute
+	})
+	return names, refs
+}
+
+// directReference returns the variable or workspace reference an attribute
+// expression consists of; nested blocks and constants have none
+func directReference(expr interface{}) (string, bool) {
+	m, ok := expr.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	if _, constant := m["constant_value"]; constant {
+		return "", false
+	}
+	refs := stringList(m["references"])
+	if len(refs) != 1 {
+		return "", false
+	}
+	if _, ok := variableName(refs[0]); ok && !strings.Contains(strings.TrimPrefix(refs[0], "var."), ".") {
+		return refs[0], true
+	}
+	return refs[0], refs[0] == referenceWorkspace
+}
+
+// variableName returns the variable a var.name reference (or var.name.field) refers to
+func variableName(ref string) (string, bool) {
+	rest, ok := strings.CutPrefix(ref, "var.")
+	if !ok || rest == "" {
+		return "", false
+	}
+	name, _, _ := strings.Cut(BaseAddress(rest), ".")
+	return name, true
+}
+
+func stringList(v interface{}) []string {
+	list, _ := v.([]interface{})
+	out := make([]string, 0, len(list))
+	for _, item := range list {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// unresolvedVariables returns the referenced variables the plan has no value for
+func unresolvedVariables(names []string, values map[string]interface{}) []string {
+	var unresolved []string
+	for _, name := range names {
+		if v, ok := values[name].(map[string]interface{}); ok {
+			if _, set := v["value"]; set {
+				continue
+			}
+		}
+		unresolved = append(unresolved, name)
+	}
+	return unresolved
+}
+
+// ApplyVariables sets unresolved root variables from values. Attributes of
+// the plan's resources set from a variable keep their planned value; the ones
+// the plan left missing or unknown stay unknown until apply, with a warning
+// naming them, since only the configuration (HCLParser.WithValues) says how
+// they are built from the variable.
+func ApplyVariables(plan *ParsedPlan, values map[string]interface{}) {
+	if plan.Variables == nil {
+		plan.Variables = make(map[string]interface{})
+	}
+	remaining := plan.UnresolvedVariables[:0]
+	for _, name := range plan.UnresolvedVariables {
+		if v, ok := values[name]; ok {
+			plan.Variables[name] = map[string]interface{}{"value": v}
+		} else {
+			remaining = append(remaining, name)
+		}
+	}
+	if len(remaining) == 0 {
+		remaining = nil
+	}
+	plan.UnresolvedVariables = remaining
+
+	references := make(map[string][]VariableReference)
+	for _, ref := range plan.VariableReferences {
+		if name, ok := variableName(ref.Reference); ok {
+			if _, set := values[name]; set {
+				references[ref.Address] = append(references[ref.Address], ref)
+			}
+		}
+	}
+	for i := range plan.Changes {
+		change := &plan.Changes[i]
+		for _, ref := range references[BaseAddress(change.Address)] {
+			if change.After == nil || !attributeUnset(change.After, change.AfterUnknown, ref.Attribute) {
+				continue
+			}
+			if change.AfterUnknown == nil {
+				change.AfterUnknown = make(map[string]interface{})
+			}
+			change.AfterUnknown[ref.Attribute] = true
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf(
+				"%s.%s is set from %s, which plan JSON cannot evaluate; it stays unknown until apply (estimate the configuration with plan_format hcl to price it)",
+				change.Address, ref.Attribute, ref.Reference))
+		}
+	}
+}
+
+// attributeUnset reports whether a planned attribute is missing, null or unknown
+func attributeUnset(after, afterUnknown map[string]interface{}, attr string) bool {
+	if unknown, _ := afterUnknown[attr].(bool); unknown {
+		return true
+	}
+	return after[attr] == nil
+}
@@ -0,0 +1,130 @@
+package iac
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// A plan made without a var file: instance_type is unknown, the region known
+const planWithoutVarFile = `{
+  "format_version": "1.2",
+  "variables": {"region": {"value": "us-east-1"}},
+  "resource_changes": [
+    {"address": "aws_instance.web[0]", "mode": "managed", "type": "aws_instance", "name": "web", "index": 0,
+     "provider_name": "registry.terraform.io/hashicorp/aws",
+     "change": {"actions": ["create"], "after": {"ami": "ami-0abc", "tags": {"env": "staging"}}, "after_unknown": {"instance_type": true, "id": true}}},
+    {"address": "aws_ebs_volume.data", "mode": "managed", "type": "aws_ebs_volume", "name": "data",
+     "provider_name": "registry.terraform.io/hashicorp/aws",
+     "change": {"actions": ["create"], "after": {"size": 100}, "after_unknown": {"id": true}}}
+  ],
+  "configuration": {
+    "root_module": {
+      "resources": [
+        {"address": "aws_instance.web", "expressions": {
+          "ami": {"constant_value": "ami-0abc"},
+          "instance_type": {"references": ["var.instance_type"]},
+          "tags": {"references": ["terraform.workspace"]}
+        }},
+        {"address": "aws_ebs_volume.data", "expressions": {
+          "size": {"references": ["var.volume.size", "var.volume"]},
+          "availability_zone": {"references": ["var.region"]}
+        }}
+      ],
+      "module_calls": {"cache": {"expressions": {"nodes": {"references": ["var.cache_nodes"]}}, "module": {}}}
+    }
+  }
+}`
+
+func TestApplyVariables(t *testing.T) {
+	plan, err := NewParser().Parse(strings.NewReader(planWithoutVarFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"cache_nodes", "instance_type", "volume"}; !reflect.DeepEqual(plan.UnresolvedVariables, want) {
+		t.Fatalf("unresolved = %v, want %v", plan.UnresolvedVariables, want)
+	}
+
+	ApplyVariables(plan, map[string]interface{}{"instance_type": "m5.large", "volume": map[string]interface{}{"size": 500}})
+	if want := []string{"cache_nodes"}; !reflect.DeepEqual(plan.UnresolvedVariables, want) {
+		t.Errorf("unresolved = %v, want %v", plan.UnresolvedVariables, want)
+	}
+	if v, _ := plan.Variables["instance_type"].(map[string]interface{}); v["value"] != "m5.large" {
+		t.Errorf("instance_type variable = %v", plan.Variables["instance_type"])
+	}
+	// Plan JSON can't say how instance_type is built from the variable, so it stays unknown
+	web, volume := plan.Resources[0], plan.Resources[1]
+	if web.Attributes["instance_type"] != nil || plan.Changes[0].AfterUnknown["instance_type"] != true {
+		t.Errorf("instance_type = %v, unknown %v; want it left unknown", web.Attributes["instance_type"], plan.Changes[0].AfterUnknown["instance_type"])
+	}
+	if len(plan.Warnings) != 1 || !strings.Contains(plan.Warnings[0], "aws_instance.web[0].instance_type") {
+		t.Errorf("warnings = %v, want one naming aws_instance.web[0].instance_type", plan.Warnings)
+	}
+	// Known values are kept
+	if tags, _ := web.Attributes["tags"].(map[string]interface{}); tags["env"] != "staging" {
+		t.Errorf("tags = %v, want the planned tags kept", web.Attributes["tags"])
+	}
+	if volume.Attributes["size"] != float64(100) {
+		t.Errorf("size = %v, want the planned 100", volume.Attributes["size"])
+	}
+}
+
+func TestHCLWorkspaceAndValues(t *testing.T) {
+	config := `
+variable "instance_type" {}
+
+variable "disk" {
+  type = object({ size = number })
+}
+
+variable "team" {}
+
+# Declared but referenced by nothing priced
+variable "owner" {}
+
+locals {
+  team_tags = { Team = var.team }
+}
+
+resource "aws_instance" "web" {
+  instance_type = var.instance_type
+  tags          = merge(local.team_tags, { Environment = terraform.workspace })
+}
+
+resource "aws_ebs_volume" "data" {
+  size = var.disk.size
+}
+`
+	plan, err := NewHCLParser().ParseBytes([]byte(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// owner is referenced by nothing; team only through a local
+	if want := []string{"disk", "instance_type", "team"}; !reflect.DeepEqual(plan.UnresolvedVariables, want) {
+		t.Errorf("unresolved = %v, want %v", plan.UnresolvedVariables, want)
+	}
+
+	plan, err = NewHCLParser().
+		WithValues(map[string]interface{}{"instance_type": "m5.large", "disk": map[string]interface{}{"size": 200}, "team": "web"}).
+		WithWorkspace("prod").
+		ParseBytes([]byte(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.UnresolvedVariables) != 0 {
+		t.Errorf("unresolved = %v, want none", plan.UnresolvedVariables)
+	}
+	attrs := make(map[string]map[string]interface{})
+	for _, r := range plan.Resources {
+		attrs[r.Address] = r.Attributes
+	}
+	if attrs["aws_instance.web"]["instance_type"] != "m5.large" {
+		t.Errorf("instance_type = %v", attrs["aws_instance.web"]["instance_type"])
+	}
+	if tags, _ := attrs["aws_instance.web"]["tags"].(map[string]interface{}); tags["Environment"] != "prod" {
+		t.Errorf("tags = %v, want the prod workspace", attrs["aws_instance.web"]["tags"])
+	}
+	if attrs["aws_ebs_volume.data"]["size"] != float64(200) {
+		t.Errorf("size = %v, want 200", attrs["aws_ebs_volume.data"]["size"])
+	}
+}
@@ -0,0 +1,417 @@
+// Package network - Data transfer cost modeling
+// Transfer is billed per GB moved between availability zones, between regions
+// and out to the internet. Plans carry no traffic figures, so flows are inferred
+// from the dependency graph (an app talking to a database in another zone or
+// region, targets behind a cross-zone load balancer, NAT and internet-facing
+// load balancer egress) and priced with topology-driven volume assumptions.
+package network
+
+import (
+	"fmt"
+	"sort"
+
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/iac"
+)
+
+// FlowKind classifies a data transfer flow by what it crosses
+type FlowKind string
+
+const (
+	FlowCrossAZ        FlowKind = "cross_az"
+	FlowCrossRegion    FlowKind = "cross_region"
+	FlowInternetEgress FlowKind = "internet_egress"
+)
+
+// DefaultVolumes are the assumed monthly GB exchanged by one flow of each kind
+var DefaultVolumes = map[FlowKind]float64{
+	FlowCrossAZ:        100,
+	FlowCrossRegion:    50,
+	FlowInternetEgress: 100,
+}
+
+// Flow is an inferred data transfer between two resources, or out to the internet
+type Flow struct {
+	Kind       FlowKind
+	Source     string  // Address of the resource the transfer is billed to
+	Target     string  // Address of the other end; empty for the internet
+	Region     string  // Region the transfer is billed in
+	Share      float64 // Fraction of the flow's volume that crosses the boundary
+	MonthlyGB  float64 // Billable GB per month
+	Assumption string
+}
+
+// trafficTypes are resources that exchange application traffic with their dependencies
+var trafficTypes = map[string]bool{
+	"aws_instance":              true,
+	"aws_spot_instance_request": true,
+	"aws_autoscaling_group":     true,
+	"aws_ecs_service":           true,
+	"aws_lambda_function":       true,
+	"aws_db_instance":           true,
+	"aws_rds_cluster":           true,
+	"aws_elasticache_cluster":   true,
+	"aws_lb":                    true,
+	"aws_alb":                   true,
+	"aws_elb":                   true,
+}
+
+// loadBalancerTypes front targets registered through target groups
+var loadBalancerTypes = map[string]bool{
+	"aws_lb":  true,
+	"aws_alb": true,
+	"aws_elb": true,
+}
+
+// Model infers data transfer flows from a graph and prices them as billing components
+type Model struct {
+	volumes map[FlowKind]float64
+}
+
+// NewModel creates a model with the default flow volumes
+func NewModel() *Model {
+	volumes := make(map[FlowKind]float64, len(DefaultVolumes))
+	for k, v := range DefaultVolumes {
+		volumes[k] = v
+	}
+	return &Model{volumes: volumes}
+}
+
+// WithVolume sets the assumed monthly GB of one flow of a kind
+func (m *Model) WithVolume(kind FlowKind, gb float64) *Model {
+	m.volumes[kind] = gb
+	return m
+}
+
+// Flows returns the data transfer flows inferred from the graph, ordered by source and target
+func (m *Model) Flows(graph *iac.Graph) []Flow {
+	flows := make([]Flow, 0)
+
+	addrs := make([]string, 0, len(graph.Nodes))
+	for addr := range graph.Nodes {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+
+	for _, addr := range addrs {
+		node := graph.Nodes[addr]
+		if node.Resource.Mode == "data" {
+			continue
+		}
+
+		switch {
+		case node.Resource.Type == "aws_nat_gateway":
+			flows = append(flows, m.egress(node, "NAT gateway egress to the internet"))
+		case loadBalancerTypes[node.Resource.Type]:
+			if !billing.ExtractAttributeBool(node.Resource.Attributes, "internal", false) {
+				flows = append(flows, m.egress(node, "Internet-facing load balancer responses to clients"))
+			}
+			if flow, ok := m.crossZoneTargets(graph, node); ok {
+				flows = append(flows, flow)
+			}
+		}
+
+		if !trafficTypes[node.Resource.Type] {
+			continue
+		}
+		for _, depAddr := range node.Dependencies {
+			dep := graph.Nodes[depAddr]
+			if dep == nil || !trafficTypes[dep.Resource.Type] {
+				continue
+			}
+			if flow, ok := m.dependencyFlow(graph, node, dep); ok {
+				flows = append(flows, flow)
+			}
+		}
+	}
+
+	sort.SliceStable(flows, func(i, j int) bool {
+		if flows[i].Source != flows[j].Source {
+			return flows[i].Source < flows[j].Source
+		}
+		return flows[i].Target < flows[j].Target
+	})
+	return flows
+}
+
+// egress is the internet egress flow of a resource
+func (m *Model) egress(node *iac.GraphNode, assumption string) Flow {
+	return Flow{
+		Kind:       FlowInternetEgress,
+		Source:     node.Resource.Address,
+		Region:     node.Region,
+		Share:      1,
+		MonthlyGB:  m.volumes[FlowInternetEgress],
+		Assumption: assumption,
+	}
+}
+
+// dependencyFlow is the flow between a resource and a dependency it talks to
+// Responses from the dependency dominate, so the dependency's region is billed.
+func (m *Model) dependencyFlow(graph *iac.Graph, node, dep *iac.GraphNode) (Flow, bool) {
+	if node.Region != "" && dep.Region != "" && node.Region != dep.Region {
+		return Flow{
+			Kind:      FlowCrossRegion,
+			Source:    dep.Resource.Address,
+			Target:    node.Resource.Address,
+			Region:    dep.Region,
+			Share:     1,
+			MonthlyGB: m.volumes[FlowCrossRegion],
+			Assumption: fmt.Sprintf("%s in %s talks to %s in %s",
+				node.Resource.Address, node.Region, dep.Resource.Address, dep.Region),
+		}, true
+	}
+	share := crossZoneShare(zones(graph, node), zones(graph, dep))
+	if share == 0 {
+		return Flow{}, false
+	}
+	return Flow{
+		Kind:   FlowCrossAZ,
+		Source: dep.Resource.Address,
+		Target: node.Resource.Address,
+		Region: dep.Region,
+		Share:  share,
+		// Cross-AZ transfer is charged on both sides
+		MonthlyGB: 2 * share * m.volumes[FlowCrossAZ],
+		Assumption: fmt.Sprintf("%.0f%% of traffic between %s and %s crosses availability zones, billed in both directions",
+			share*100, node.Resource.Address, dep.Resource.Address),
+	}, true
+}
+
+// crossZoneTargets is the cross-AZ flow of a load balancer forwarding to targets in other zones
+// Application load balancers don't charge for cross-zone traffic; network and
+// gateway load balancers do once cross-zone load balancing is enabled.
+func (m *Model) crossZoneTargets(graph *iac.Graph, lb *iac.GraphNode) (Flow, bool) {
+	attrs := lb.Resource.Attributes
+	lbType := billing.ExtractAttribute(attrs, "load_balancer_type")
+	if lb.Resource.Type != "aws_lb" || lbType == "" || lbType == "application" {
+		return Flow{}, false
+	}
+	if !billing.ExtractAttributeBool(attrs, "enable_cross_zone_load_balancing", false) {
+		return Flow{}, false
+	}
+	targetZones := make(map[string]bool)
+	for _, target := range targets(graph, lb) {
+		for _, z := range zones(graph, target) {
+			targetZones[z] = true
+		}
+	}
+	share := crossZoneShare(zones(graph, lb), sortedKeys(targetZones))
+	if share == 0 {
+		return Flow{}, false
+	}
+	return Flow{
+		Kind:      FlowCrossAZ,
+		Source:    lb.Resource.Address,
+		Region:    lb.Region,
+		Share:     share,
+		MonthlyGB: share * m.volumes[FlowCrossAZ],
+		Assumption: fmt.Sprintf("Cross-zone load balancing sends %.0f%% of traffic to targets in other availability zones",
+			share*100),
+	}, true
+}
+
+// targets returns the resources registered with a load balancer's target groups
+// lb <- listener -> target group <- attachment -> instance, or target group <- ASG/ECS service
+func targets(graph *iac.Graph, lb *iac.GraphNode) []*iac.GraphNode {
+	seen := make(map[string]bool)
+	result := make([]*iac.GraphNode, 0)
+	add := func(n *iac.GraphNode) {
+		if n != nil && trafficTypes[n.Resource.Type] && !seen[n.Resource.Address] {
+			seen[n.Resource.Address] = true
+			result = append(result, n)
+		}
+	}
+	for _, listener := range related(graph, lb.Dependents, "aws_lb_listener", "aws_alb_listener") {
+		for _, tg := range related(graph, listener.Dependencies, "aws_lb_target_group", "aws_alb_target_group") {
+			for _, addr := range tg.Dependents {
+				dependent := graph.Nodes[addr]
+				if dependent == nil {
+					continue
+				}
+				if dependent.Resource.Type == "aws_lb_target_group_attachment" || dependent.Resource.Type == "aws_alb_target_group_attachment" {
+					for _, target := range dependent.Dependencies {
+						add(graph.Nodes[target])
+					}
+					continue
+				}
+				add(dependent)
+			}
+		}
+	}
+	return result
+}
+
+// related returns the nodes among addrs with one of the given types
+func related(graph *iac.Graph, addrs []string, types ...string) []*iac.GraphNode {
+	result := make([]*iac.GraphNode, 0)
+	for _, addr := range addrs {
+		n := graph.Nodes[addr]
+		if n == nil {
+			continue
+		}
+		for _, t := range types {
+			if n.Resource.Type == t {
+				result = append(result, n)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// zones returns the availability zones a resource spreads its traffic over
+// Zones come from the resource itself or from the subnets it depends on; a
+// Multi-AZ database serves from its primary, whose zone is not known.
+func zones(graph *iac.Graph, node *iac.GraphNode) []string {
+	attrs := node.Resource.Attributes
+	if billing.ExtractAttributeBool(attrs, "multi_az", false) {
+		return nil
+	}
+	set := make(map[string]bool)
+	if az := billing.ExtractAttribute(attrs, "availability_zone"); az != "" {
+		set[az] = true
+	}
+	if list, ok := attrs["availability_zones"].([]interface{}); ok {
+		for _, v := range list {
+			if az, ok := v.(string); ok && az != "" {
+				set[az] = true
+			}
+		}
+	}
+	if len(set) == 0 {
+		for _, subnet := range related(graph, node.Dependencies, "aws_subnet") {
+			if az := billing.ExtractAttribute(subnet.Resource.Attributes, "availability_zone"); az != "" {
+				set[az] = true
+			}
+		}
+	}
+	return sortedKeys(set)
+}
+
+// crossZoneShare is the fraction of evenly spread traffic between two zone sets
+// that lands in a different zone; zero when either side's zones are unknown
+func crossZoneShare(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	common := 0
+	for _, za := range a {
+		for _, zb := range b {
+			if za == zb {
+				common++
+			}
+		}
+	}
+	return 1 - float64(common)/float64(len(a)*len(b))
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Components returns the data transfer billing components of the graph's flows
+func (m *Model) Components(graph *iac.Graph) []billing.BillingComponent {
+	flows := m.Flows(graph)
+	components := make([]billing.BillingComponent, 0, len(flows))
+	for _, flow := range flows {
+		if flow.MonthlyGB <= 0 {
+			continue
+		}
+		comp := billing.BillingComponent{
+			ID:              componentID(flow),
+			ResourceAddr:    flow.Source,
+			Cloud:           "aws",
+			Service:         "AWSDataTransfer",
+			ProductFamily:   "Data Transfer",
+			Region:          flow.Region,
+			UsageType:       usageType(flow.Kind),
+			BillingPeriod:   billing.PeriodPerGB,
+			Attributes:      transferAttributes(flow.Kind),
+			Description:     description(flow),
+			Tags:            []string{"networking", "data-transfer"},
+			VarianceProfile: transferProfile(flow),
+			DependsOn:       []string{},
+		}
+		if node, ok := graph.Nodes[flow.Source]; ok {
+			comp.Module = node.Resource.Module
+			comp.ResourceTags = billing.ExtractResourceTags(node.Resource.Attributes)
+			comp.ResourceID = billing.ExtractResourceID(node.Resource.Attributes)
+		}
+		components = append(components, comp)
+	}
+	return components
+}
+
+// componentID names a flow's component: <source>-egress or <source>-transfer[-<target>]
+func componentID(flow Flow) string {
+	switch {
+	case flow.Kind == FlowInternetEgress:
+		return flow.Source + "-egress"
+	case flow.Target == "":
+		return flow.Source + "-transfer"
+	default:
+		return flow.Source + "-transfer-" + flow.Target
+	}
+}
+
+func usageType(kind FlowKind) string {
+	switch kind {
+	case FlowCrossRegion:
+		return "DataTransfer-InterRegion-Out-Bytes"
+	case FlowInternetEgress:
+		return "DataTransfer-Out-Bytes"
+	default:
+		return "DataTransfer-Regional-Bytes"
+	}
+}
+
+// transferAttributes are the AWSDataTransfer rate attributes of a flow kind
+func transferAttributes(kind FlowKind) map[string]string {
+	switch kind {
+	case FlowCrossRegion:
+		return map[string]string{"transferType": "InterRegion Outbound"}
+	case FlowInternetEgress:
+		return map[string]string{"transferType": "AWS Outbound", "toLocation": "External"}
+	default:
+		return map[string]string{"transferType": "IntraRegion"}
+	}
+}
+
+func description(flow Flow) string {
+	switch flow.Kind {
+	case FlowCrossRegion:
+		return fmt.Sprintf("Inter-region data transfer to %s", flow.Target)
+	case FlowInternetEgress:
+		return "Data transfer out to the internet"
+	default:
+		if flow.Target == "" {
+			return "Cross-AZ data transfer to load balancer targets"
+		}
+		return fmt.Sprintf("Cross-AZ data transfer with %s", flow.Target)
+	}
+}
+
+// transferProfile is the usage profile of an inferred flow
+// Traffic is unknown from the plan alone: confidence is low and the range wide.
+func transferProfile(flow Flow) billing.VarianceProfile {
+	gb := flow.MonthlyGB
+	return billing.VarianceProfile{
+		BaselineUsage:   gb,
+		MinUsage:        gb * 0.1,
+		MaxUsage:        gb * 10,
+		P50Usage:        gb,
+		P90Usage:        gb * 5,
+		Confidence:      0.4,
+		VolatilityScore: 0.6,
+		Assumptions: []string{
+			fmt.Sprintf("Data transfer inferred from topology: %.0f GB/month", gb),
+			flow.Assumption,
+		},
+	}
+}
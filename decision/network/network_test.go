@@ -0,0 +1,108 @@
+// Package network - Data transfer tests
+package network
+
+import (
+	"math"
+	"testing"
+
+	"terraform-cost/decision/iac"
+)
+
+func testGraph() *iac.Graph {
+	g := &iac.Graph{Nodes: make(map[string]*iac.GraphNode)}
+	add := func(addr, typ, region string, attrs map[string]interface{}, deps ...string) {
+		g.Nodes[addr] = &iac.GraphNode{
+			Resource:     iac.ResourceNode{Address: addr, Type: typ, Mode: "managed", Provider: "aws", Region: region, Attributes: attrs},
+			Dependencies: deps,
+			Region:       region,
+		}
+		for _, dep := range deps {
+			if n, ok := g.Nodes[dep]; ok {
+				n.Dependents = append(n.Dependents, addr)
+			}
+		}
+	}
+	add("aws_subnet.a", "aws_subnet", "us-east-1", map[string]interface{}{"availability_zone": "us-east-1a"})
+	add("aws_subnet.b", "aws_subnet", "us-east-1", map[string]interface{}{"availability_zone": "us-east-1b"})
+	add("aws_db_instance.main", "aws_db_instance", "us-east-1", map[string]interface{}{"availability_zone": "us-east-1a"})
+	add("aws_db_instance.replica", "aws_db_instance", "eu-west-1", map[string]interface{}{})
+	// Web tier in both zones: half its database traffic crosses zones
+	add("aws_autoscaling_group.web", "aws_autoscaling_group", "us-east-1", map[string]interface{}{},
+		"aws_subnet.a", "aws_subnet.b", "aws_db_instance.main")
+	add("aws_instance.report", "aws_instance", "us-east-1", map[string]interface{}{"availability_zone": "us-east-1a"},
+		"aws_db_instance.main", "aws_db_instance.replica")
+	add("aws_nat_gateway.main", "aws_nat_gateway", "us-east-1", map[string]interface{}{})
+	add("aws_lb.web", "aws_lb", "us-east-1", map[string]interface{}{"load_balancer_type": "application", "internal": false},
+		"aws_subnet.a", "aws_subnet.b")
+	add("aws_lb.internal", "aws_lb", "us-east-1", map[string]interface{}{
+		"load_balancer_type": "network", "internal": true, "enable_cross_zone_load_balancing": true,
+	}, "aws_subnet.a", "aws_subnet.b")
+	add("aws_lb_target_group.internal", "aws_lb_target_group", "us-east-1", map[string]interface{}{})
+	add("aws_lb_listener.internal", "aws_lb_listener", "us-east-1", map[string]interface{}{},
+		"aws_lb.internal", "aws_lb_target_group.internal")
+	add("aws_lb_target_group_attachment.report", "aws_lb_target_group_attachment", "us-east-1", map[string]interface{}{},
+		"aws_lb_target_group.internal", "aws_instance.report")
+	return g
+}
+
+func TestFlows(t *testing.T) {
+	flows := NewModel().Flows(testGraph())
+
+	type key struct {
+		kind           FlowKind
+		source, target string
+	}
+	got := make(map[key]Flow)
+	for _, f := range flows {
+		got[key{f.Kind, f.Source, f.Target}] = f
+	}
+
+	tests := []struct {
+		key    key
+		region string
+		gb     float64
+	}{
+		{key{FlowCrossAZ, "aws_db_instance.main", "aws_autoscaling_group.web"}, "us-east-1", 2 * 0.5 * 100},
+		{key{FlowCrossRegion, "aws_db_instance.replica", "aws_instance.report"}, "eu-west-1", 50},
+		{key{FlowInternetEgress, "aws_nat_gateway.main", ""}, "us-east-1", 100},
+		{key{FlowInternetEgress, "aws_lb.web", ""}, "us-east-1", 100},
+		// NLB in two zones, its only target in one of them
+		{key{FlowCrossAZ, "aws_lb.internal", ""}, "us-east-1", 0.5 * 100},
+	}
+	for _, tt := range tests {
+		f, ok := got[tt.key]
+		if !ok {
+			t.Errorf("missing flow %+v in %+v", tt.key, flows)
+			continue
+		}
+		if f.Region != tt.region || math.Abs(f.MonthlyGB-tt.gb) > 1e-9 {
+			t.Errorf("flow %+v: region %s, %v GB; want %s, %v GB", tt.key, f.Region, f.MonthlyGB, tt.region, tt.gb)
+		}
+	}
+	if len(flows) != len(tests) {
+		t.Errorf("got %d flows, want %d: %+v", len(flows), len(tests), flows)
+	}
+}
+
+func TestComponents(t *testing.T) {
+	components := NewModel().WithVolume(FlowInternetEgress, 400).Components(testGraph())
+
+	byID := make(map[string]int)
+	for i, c := range components {
+		byID[c.ID] = i
+	}
+	i, ok := byID["aws_nat_gateway.main-egress"]
+	if !ok {
+		t.Fatalf("no NAT egress component: %+v", components)
+	}
+	nat := components[i]
+	if nat.Service != "AWSDataTransfer" || nat.Attributes["transferType"] != "AWS Outbound" || nat.Attributes["toLocation"] != "External" {
+		t.Errorf("unexpected NAT egress rate key: %+v", nat)
+	}
+	if nat.VarianceProfile.P50Usage != 400 {
+		t.Errorf("NAT egress P50 = %v, want 400", nat.VarianceProfile.P50Usage)
+	}
+	if i, ok := byID["aws_db_instance.replica-transfer-aws_instance.report"]; !ok || components[i].Attributes["transferType"] != "InterRegion Outbound" {
+		t.Errorf("missing inter-region component: %+v", components)
+	}
+}
@@ -0,0 +1,253 @@
+// Package optimize - Commitment planning
+// Projects the savings and breakeven of 1 and 3 year commitments (reserved
+// instances, savings plans) on the always-on compute of one or more estimates.
+package optimize
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/estimation"
+)
+
+// Commitment terms
+const (
+	TermOneYear   = "1yr"
+	TermThreeYear = "3yr"
+)
+
+// hoursPerMonth is the committed capacity of one instance
+const hoursPerMonth = billing.HoursPerMonth
+
+// CommitmentTerm is a commitment length and its discount over on-demand
+type CommitmentTerm struct {
+	Name     string
+	Months   int
+	Discount float64 // fraction of the on-demand hourly price saved
+}
+
+// DefaultCommitmentTerms are typical standard, all-upfront reservation discounts by service
+var DefaultCommitmentTerms = map[string][]CommitmentTerm{
+	"AmazonEC2": {{TermOneYear, 12, 0.40}, {TermThreeYear, 36, 0.60}},
+	"AmazonRDS": {{TermOneYear, 12, 0.35}, {TermThreeYear, 36, 0.55}},
+}
+
+// CommitmentOptions tunes a commitment plan
+type CommitmentOptions struct {
+	Discounts map[string]float64 // term name -> discount, overriding the service defaults
+}
+
+// CommitmentPlan is the suggested commitment per instance family
+type CommitmentPlan struct {
+	Currency            string             `json:"currency"`
+	Estimations         int                `json:"estimations"`
+	OnDemandMonthlyCost decimal.Decimal    `json:"on_demand_monthly_cost"` // instance hours that could be committed
+	MonthlySavings      decimal.Decimal    `json:"monthly_savings"`        // with every family's recommended term
+	Upfront             decimal.Decimal    `json:"upfront"`                // all-upfront payment of the recommended terms
+	Families            []FamilyCommitment `json:"families"`
+	Notes               []string           `json:"notes,omitempty"`
+}
+
+// FamilyCommitment is the on-demand usage of one instance family and its commitment options
+type FamilyCommitment struct {
+	Service             string             `json:"service"`
+	Region              string             `json:"region"`
+	Family              string             `json:"family"`
+	InstanceTypes       []string           `json:"instance_types"`
+	Instances           int                `json:"instances"`   // instances to commit, usage rounded to whole instances
+	Utilization         float64            `json:"utilization"` // estimated running cost over the committed capacity
+	OnDemandMonthlyCost decimal.Decimal    `json:"on_demand_monthly_cost"`
+	Options             []CommitmentOption `json:"options"`
+	Recommended         string             `json:"recommended,omitempty"` // empty when no term saves money
+	Note                string             `json:"note,omitempty"`
+}
+
+// CommitmentOption is the outcome of committing a family for one term
+type CommitmentOption struct {
+	Term                 string          `json:"term"`
+	Months               int             `json:"months"`
+	Discount             float64         `json:"discount"`
+	MonthlyCost          decimal.Decimal `json:"monthly_cost"` // effective monthly cost of the commitment
+	Upfront              decimal.Decimal `json:"upfront"`      // all-upfront payment for the term
+	MonthlySavings       decimal.Decimal `json:"monthly_savings"`
+	TermSavings          decimal.Decimal `json:"term_savings"`
+	BreakevenMonths      float64         `json:"breakeven_months"`      // months of on-demand spend the upfront payment buys
+	BreakevenUtilization float64         `json:"breakeven_utilization"` // below this utilization on-demand is cheaper
+}
+
+// familyUsage accumulates the on-demand instance hours of one family
+type familyUsage struct {
+	service, region, family string
+	types                   map[string]bool
+	instances               int
+	onDemand                decimal.Decimal // estimated monthly cost
+	capacity                decimal.Decimal // monthly cost of the instances running full time
+}
+
+// PlanCommitments sums the on-demand instance hours of the estimates per
+// service, region and instance family, and prices each commitment term
+//
+// Every instance counts as committed full time: an instance estimated at 90%
+// of the month is 90% utilized. Spot, serverless and unpriced usage are
+// left out, as are estimates in another currency than the first.
+func PlanCommitments(estimates []*estimation.EstimationResult, opts CommitmentOptions) *CommitmentPlan {
+	plan := &CommitmentPlan{
+		OnDemandMonthlyCost: decimal.Zero,
+		MonthlySavings:      decimal.Zero,
+		Upfront:             decimal.Zero,
+		Families:            []FamilyCommitment{},
+	}
+
+	families := make(map[string]*familyUsage)
+	for _, est := range estimates {
+		if plan.Currency == "" {
+			plan.Currency = est.Currency
+		}
+		if est.Currency != plan.Currency {
+			plan.Notes = append(plan.Notes, fmt.Sprintf("Skipped an estimate in %s; the plan is in %s", est.Currency, plan.Currency))
+			continue
+		}
+		plan.Estimations++
+
+		for _, d := range est.CostDrivers {
+			instanceType, ok := instanceHours(d.UsageType)
+			if !ok || strings.HasPrefix(d.UsageType, "SpotUsage:") || d.IsSymbolic || d.UsageP50 <= 0 {
+				continue
+			}
+			if _, ok := DefaultCommitmentTerms[d.Service]; !ok {
+				continue
+			}
+			family, _ := splitInstanceType(instanceType)
+			key := d.Service + "|" + d.Region + "|" + family
+			f, ok := families[key]
+			if !ok {
+				f = &familyUsage{service: d.Service, region: d.Region, family: family, types: make(map[string]bool)}
+				families[key] = f
+			}
+
+			// Whole instances: 0.9 of a month is one instance, 2.7 is three
+			instances := int(d.UsageP50/hoursPerMonth + 0.5)
+			if instances < 1 {
+				instances = 1
+			}
+			f.types[instanceType] = true
+			f.instances += instances
+			f.onDemand = f.onDemand.Add(d.MonthlyCostP50)
+			f.capacity = f.capacity.Add(d.UnitPrice.Mul(decimal.NewFromInt(int64(instances * hoursPerMonth))))
+		}
+	}
+
+	for _, f := range families {
+		if !f.capacity.IsPositive() {
+			continue
+		}
+		fc := planFamily(f, termsFor(f.service, opts))
+		plan.OnDemandMonthlyCost = plan.OnDemandMonthlyCost.Add(fc.OnDemandMonthlyCost)
+		for _, o := range fc.Options {
+			if o.Term == fc.Recommended {
+				plan.MonthlySavings = plan.MonthlySavings.Add(o.MonthlySavings)
+				plan.Upfront = plan.Upfront.Add(o.Upfront)
+			}
+		}
+		plan.Families = append(plan.Families, fc)
+	}
+
+	sort.Slice(plan.Families, func(i, j int) bool {
+		fi, fj := plan.Families[i], plan.Families[j]
+		if !fi.OnDemandMonthlyCost.Equal(fj.OnDemandMonthlyCost) {
+			return fi.OnDemandMonthlyCost.GreaterThan(fj.OnDemandMonthlyCost)
+		}
+		return fi.Service+fi.Region+fi.Family < fj.Service+fj.Region+fj.Family
+	})
+	return plan
+}
+
+// planFamily prices every term for one family and picks the best
+// Previous-generation families get the shortest saving term so the
+// commitment doesn't outlive an upgrade.
+func planFamily(f *familyUsage, terms []CommitmentTerm) FamilyCommitment {
+	fc := FamilyCommitment{
+		Service:             f.service,
+		Region:              f.region,
+		Family:              f.family,
+		InstanceTypes:       make([]string, 0, len(f.types)),
+		Instances:           f.instances,
+		Utilization:         f.onDemand.Div(f.capacity).InexactFloat64(),
+		OnDemandMonthlyCost: f.onDemand.Round(2),
+		Options:             make([]CommitmentOption, 0, len(terms)),
+	}
+	for t := range f.types {
+		fc.InstanceTypes = append(fc.InstanceTypes, t)
+	}
+	sort.Strings(fc.InstanceTypes)
+
+	_, previousGeneration := previousGenerations[strings.TrimPrefix(f.family, "db.")]
+	if previousGeneration {
+		fc.Note = "Previous-generation family: commit for the shortest term and upgrade when it ends"
+	}
+
+	best := -1
+	for _, term := range terms {
+		rate := decimal.NewFromFloat(1 - term.Discount)
+		months := decimal.NewFromInt(int64(term.Months))
+		monthly := f.capacity.Mul(rate)
+		savings := f.onDemand.Sub(monthly)
+		option := CommitmentOption{
+			Term:                 term.Name,
+			Months:               term.Months,
+			Discount:             term.Discount,
+			MonthlyCost:          monthly.Round(2),
+			Upfront:              monthly.Mul(months).Round(2),
+			MonthlySavings:       savings.Round(2),
+			TermSavings:          savings.Mul(months).Round(2),
+			BreakevenUtilization: 1 - term.Discount,
+		}
+		if f.onDemand.IsPositive() {
+			option.BreakevenMonths = monthly.Mul(months).Div(f.onDemand).InexactFloat64()
+		}
+		fc.Options = append(fc.Options, option)
+
+		if !savings.IsPositive() || (previousGeneration && best >= 0) {
+			continue
+		}
+		if best < 0 || option.MonthlySavings.GreaterThan(fc.Options[best].MonthlySavings) {
+			best = len(fc.Options) - 1
+		}
+	}
+	if best >= 0 {
+		fc.Recommended = fc.Options[best].Term
+	}
+	return fc
+}
+
+// termsFor returns a service's commitment terms, shortest first, with discount overrides applied
+func termsFor(service string, opts CommitmentOptions) []CommitmentTerm {
+	defaults := DefaultCommitmentTerms[service]
+	terms := make([]CommitmentTerm, len(defaults))
+	copy(terms, defaults)
+	for i := range terms {
+		if d, ok := opts.Discounts[terms[i].Name]; ok {
+			terms[i].Discount = d
+		}
+	}
+	sort.Slice(terms, func(i, j int) bool { return terms[i].Months < terms[j].Months })
+	return terms
+}
+
+// ParseTermDiscount parses a term=percent discount override such as 3yr=62
+func ParseTermDiscount(s string) (string, float64, error) {
+	term, value, ok := strings.Cut(s, "=")
+	if !ok || (term != TermOneYear && term != TermThreeYear) {
+		return "", 0, fmt.Errorf("invalid discount %q: expected %s=<percent> or %s=<percent>", s, TermOneYear, TermThreeYear)
+	}
+	percent, err := strconv.ParseFloat(value, 64)
+	if err != nil || percent <= 0 || percent >= 100 {
+		return "", 0, fmt.Errorf("invalid discount %q: percent must be between 0 and 100", s)
+	}
+	return term, percent / 100, nil
+}
@@ -0,0 +1,94 @@
+package optimize
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/decision/estimation"
+)
+
+func instanceDriver(service, usageType string, hours, price float64) estimation.CostDriver {
+	return estimation.CostDriver{
+		Service:        service,
+		Region:         "us-east-1",
+		UsageType:      usageType,
+		UsageP50:       hours,
+		UnitPrice:      decimal.NewFromFloat(price),
+		MonthlyCostP50: decimal.NewFromFloat(hours * price),
+	}
+}
+
+func TestPlanCommitments(t *testing.T) {
+	estimates := []*estimation.EstimationResult{
+		{Currency: "USD", CostDrivers: []estimation.CostDriver{
+			instanceDriver("AmazonEC2", "BoxUsage:m5.large", 730, 0.1),
+			instanceDriver("AmazonEC2", "BoxUsage:m5.xlarge", 730, 0.2),
+			instanceDriver("AmazonEC2", "SpotUsage:m5.large", 730, 0.03),
+			instanceDriver("AmazonEC2", "EBS:VolumeUsage.gp3", 100, 0.08),
+		}},
+		{Currency: "USD", CostDrivers: []estimation.CostDriver{
+			instanceDriver("AmazonEC2", "BoxUsage:t3.micro", 219, 0.01), // 30% utilized
+			instanceDriver("AmazonRDS", "RDS:db.r4.large", 730, 0.25),
+		}},
+		{Currency: "EUR", CostDrivers: []estimation.CostDriver{
+			instanceDriver("AmazonEC2", "BoxUsage:m5.large", 730, 0.1),
+		}},
+	}
+
+	plan := PlanCommitments(estimates, CommitmentOptions{})
+	if plan.Estimations != 2 || len(plan.Notes) != 1 {
+		t.Fatalf("Estimations = %d, notes = %v; want 2 estimates and the EUR one skipped", plan.Estimations, plan.Notes)
+	}
+
+	want := map[string]struct {
+		instances   int
+		onDemand    string
+		recommended string
+		savings     string // monthly savings of the recommended term
+	}{
+		"m5":    {2, "219", TermThreeYear, "131.4"}, // 219 × 0.6
+		"db.r4": {1, "182.5", TermOneYear, "63.88"}, // previous generation: shortest term
+		"t3":    {1, "2.19", "", ""},                // 30% utilized never breaks even
+	}
+	if len(plan.Families) != len(want) {
+		t.Fatalf("families = %+v", plan.Families)
+	}
+	for _, f := range plan.Families {
+		w, ok := want[f.Family]
+		if !ok {
+			t.Fatalf("unexpected family %s", f.Family)
+		}
+		if f.Instances != w.instances || f.OnDemandMonthlyCost.String() != w.onDemand || f.Recommended != w.recommended {
+			t.Errorf("%s = %d instances, %s on-demand, recommended %q", f.Family, f.Instances, f.OnDemandMonthlyCost, f.Recommended)
+		}
+		for _, o := range f.Options {
+			if o.Term == f.Recommended && o.MonthlySavings.String() != w.savings {
+				t.Errorf("%s %s savings = %s, want %s", f.Family, o.Term, o.MonthlySavings, w.savings)
+			}
+		}
+	}
+	if plan.Families[0].Family != "m5" {
+		t.Errorf("families not sorted by on-demand cost: first is %s", plan.Families[0].Family)
+	}
+	if got := plan.MonthlySavings.String(); got != "195.28" {
+		t.Errorf("MonthlySavings = %s, want 195.28", got)
+	}
+
+	m5 := plan.Families[0].Options[0] // 1yr at 40%
+	if m5.BreakevenMonths != 7.2 || m5.BreakevenUtilization != 0.6 {
+		t.Errorf("1yr breakeven = %.2f months at %.2f utilization, want 7.2 at 0.6", m5.BreakevenMonths, m5.BreakevenUtilization)
+	}
+}
+
+func TestParseTermDiscount(t *testing.T) {
+	term, discount, err := ParseTermDiscount("3yr=62")
+	if err != nil || term != TermThreeYear || discount != 0.62 {
+		t.Errorf("ParseTermDiscount(3yr=62) = %s, %v, %v", term, discount, err)
+	}
+	for _, bad := range []string{"5yr=40", "1yr", "1yr=0", "1yr=100", "1yr=40%"} {
+		if _, _, err := ParseTermDiscount(bad); err == nil {
+			t.Errorf("ParseTermDiscount(%q) succeeded", bad)
+		}
+	}
+}
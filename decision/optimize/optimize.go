@@ -0,0 +1,327 @@
+// Package optimize - Rightsizing recommendations
+// Flags obviously oversized or wasteful resources from plan attributes and
+// estimates the monthly saving of fixing each one.
+package optimize
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/estimation"
+	"terraform-cost/decision/iac"
+)
+
+// Recommendation kinds
+const (
+	KindGP3Migration       = "gp2_to_gp3"
+	KindPreviousGeneration = "previous_generation"
+	KindMultiAZ            = "multi_az_non_production"
+	KindUnattachedEIP      = "unattached_eip"
+	KindIdleNATGateway     = "idle_nat_gateway"
+	KindSharedNATGateway   = "shared_nat_gateway"
+)
+
+// gp3Discount is gp3's saving over gp2 at the same size (0.08 vs 0.10 per GB-month)
+var gp3Discount = decimal.NewFromFloat(0.20)
+
+// half is the saving of dropping a Multi-AZ standby
+var half = decimal.NewFromFloat(0.5)
+
+// successor is the current-generation replacement of an instance family
+type successor struct {
+	family  string
+	savings float64 // fraction of the on-demand price saved at the same size
+}
+
+// previousGenerations maps previous-generation families to their replacements
+// Savings compare large sizes in us-east-1; RDS classes use the same table.
+var previousGenerations = map[string]successor{
+	"t2": {"t3", 0.10},
+	"m3": {"m5", 0.28},
+	"m4": {"m5", 0.04},
+	"c3": {"c5", 0.19},
+	"c4": {"c5", 0.15},
+	"r3": {"r5", 0.24},
+	"r4": {"r5", 0.05},
+	"i2": {"i3", 0.63},
+}
+
+// Recommendation is one suggested change and its estimated saving
+type Recommendation struct {
+	ResourceAddr   string          `json:"resource_addr"`
+	ResourceType   string          `json:"resource_type"`
+	Kind           string          `json:"kind"`
+	Title          string          `json:"title"`
+	Detail         string          `json:"detail"`
+	MonthlyCost    decimal.Decimal `json:"monthly_cost"`    // current cost of the affected components
+	MonthlySavings decimal.Decimal `json:"monthly_savings"` // zero when the components were not priced
+}
+
+// Report is the set of recommendations for one estimate
+type Report struct {
+	Currency        string           `json:"currency"`
+	MonthlySavings  decimal.Decimal  `json:"monthly_savings"`
+	Recommendations []Recommendation `json:"recommendations"`
+}
+
+// Analyze checks the estimated components of a plan for savings
+//
+// Costs come from the estimate's P50 drivers, so savings are in its currency.
+// The graph is used for relationships (EIP associations, NAT routes) and may
+// be nil. Environment-specific checks only run for non-production environments.
+func Analyze(graph *iac.Graph, components []billing.BillingComponent, est *estimation.EstimationResult, environment string) *Report {
+	a := &analyzer{
+		graph:       graph,
+		environment: environment,
+		costs:       make(map[string]decimal.Decimal),
+	}
+	for _, d := range est.CostDrivers {
+		if !d.IsSymbolic {
+			a.costs[d.ComponentID] = a.costs[d.ComponentID].Add(d.MonthlyCostP50)
+		}
+	}
+
+	multiAZ := make(map[string][]billing.BillingComponent)
+	for _, c := range components {
+		a.checkVolumeType(c)
+		a.checkInstanceGeneration(c)
+		a.checkIdleEIP(c)
+		if c.Service == "AmazonRDS" && c.Attributes["deploymentOption"] == "Multi-AZ" {
+			multiAZ[c.ResourceAddr] = append(multiAZ[c.ResourceAddr], c)
+		}
+	}
+	if !isProduction(environment) {
+		for addr, group := range multiAZ {
+			a.checkMultiAZ(addr, group)
+		}
+	}
+	a.checkNATGateways(components)
+
+	sort.SliceStable(a.recommendations, func(i, j int) bool {
+		ri, rj := a.recommendations[i], a.recommendations[j]
+		if !ri.MonthlySavings.Equal(rj.MonthlySavings) {
+			return ri.MonthlySavings.GreaterThan(rj.MonthlySavings)
+		}
+		if ri.ResourceAddr != rj.ResourceAddr {
+			return ri.ResourceAddr < rj.ResourceAddr
+		}
+		return ri.Kind < rj.Kind
+	})
+
+	report := &Report{
+		Currency:        est.Currency,
+		MonthlySavings:  decimal.Zero,
+		Recommendations: a.recommendations,
+	}
+	if report.Recommendations == nil {
+		report.Recommendations = []Recommendation{}
+	}
+	for _, r := range report.Recommendations {
+		report.MonthlySavings = report.MonthlySavings.Add(r.MonthlySavings)
+	}
+	return report
+}
+
+// analyzer accumulates recommendations for one estimate
+type analyzer struct {
+	graph           *iac.Graph
+	environment     string
+	costs           map[string]decimal.Decimal // component ID -> monthly P50
+	recommendations []Recommendation
+}
+
+func (a *analyzer) add(c billing.BillingComponent, kind, title, detail string, cost, savings decimal.Decimal) {
+	a.recommendations = append(a.recommendations, Recommendation{
+		ResourceAddr:   c.ResourceAddr,
+		ResourceType:   a.resourceType(c.ResourceAddr),
+		Kind:           kind,
+		Title:          title,
+		Detail:         detail,
+		MonthlyCost:    cost.Round(2),
+		MonthlySavings: savings.Round(2),
+	})
+}
+
+// checkVolumeType recommends gp3 for gp2 EBS volumes
+func (a *analyzer) checkVolumeType(c billing.BillingComponent) {
+	if c.Service != "AmazonEC2" || c.Attributes["volumeType"] != "gp2" {
+		return
+	}
+	cost := a.costs[c.ID]
+	a.add(c, KindGP3Migration, "Migrate gp2 volume to gp3",
+		fmt.Sprintf("%s: gp3 costs 20%% less per GB with the same baseline performance", c.Description),
+		cost, cost.Mul(gp3Discount))
+}
+
+// checkInstanceGeneration recommends the current generation of an instance family
+func (a *analyzer) checkInstanceGeneration(c billing.BillingComponent) {
+	instanceType, ok := instanceHours(c.UsageType)
+	if !ok {
+		return
+	}
+	family, size := splitInstanceType(instanceType)
+	bare := strings.TrimPrefix(family, "db.")
+	next, ok := previousGenerations[bare]
+	if !ok {
+		return
+	}
+	replacement := strings.TrimSuffix(family, bare) + next.family + "." + size
+	cost := a.costs[c.ID]
+	a.add(c, KindPreviousGeneration, fmt.Sprintf("Upgrade %s to %s", instanceType, replacement),
+		fmt.Sprintf("%s is a previous-generation family; %s is about %.0f%% cheaper at the same size",
+			bare, next.family, next.savings*100),
+		cost, cost.Mul(decimal.NewFromFloat(next.savings)))
+}
+
+// checkIdleEIP flags Elastic IPs that are not attached to anything
+func (a *analyzer) checkIdleEIP(c billing.BillingComponent) {
+	if c.UsageType != "ElasticIP:IdleAddress" || a.hasDependent(c.ResourceAddr, "aws_eip_association") {
+		return
+	}
+	cost := a.costs[c.ID]
+	a.add(c, KindUnattachedEIP, "Release unattached Elastic IP",
+		"Elastic IPs are billed hourly while not associated with a running instance or interface",
+		cost, cost)
+}
+
+// checkMultiAZ recommends Single-AZ databases outside production
+func (a *analyzer) checkMultiAZ(addr string, group []billing.BillingComponent) {
+	cost := decimal.Zero
+	for _, c := range group {
+		cost = cost.Add(a.costs[c.ID])
+	}
+	a.add(group[0], KindMultiAZ, "Disable Multi-AZ outside production",
+		fmt.Sprintf("A Multi-AZ standby doubles instance and storage cost; %s rarely needs it", a.environment),
+		cost, cost.Mul(half))
+}
+
+// checkNATGateways flags NAT gateways no route uses, and one-per-subnet NAT outside production
+//
+// A NAT gateway is idle only when the plan has routes and none of them
+// reference it; routes managed elsewhere are invisible to the plan.
+func (a *analyzer) checkNATGateways(components []billing.BillingComponent) {
+	gateways := make(map[string][]billing.BillingComponent)
+	for _, c := range components {
+		if c.UsageType == "NatGateway-Hours" || c.UsageType == "NatGateway-Bytes" {
+			gateways[c.ResourceAddr] = append(gateways[c.ResourceAddr], c)
+		}
+	}
+	addrs := make([]string, 0, len(gateways))
+	for addr := range gateways {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+
+	routed := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		group := gateways[addr]
+		if a.hasRoutes() && !a.hasDependent(addr, "aws_route", "aws_route_table") {
+			cost := decimal.Zero
+			for _, c := range group {
+				cost = cost.Add(a.costs[c.ID])
+			}
+			a.add(group[0], KindIdleNATGateway, "Remove NAT gateway without routes",
+				"No route in the plan sends traffic through this NAT gateway", cost, cost)
+			continue
+		}
+		routed = append(routed, addr)
+	}
+
+	if isProduction(a.environment) || len(routed) < 2 {
+		return
+	}
+	// Every gateway after the first could share one NAT; data processing still applies
+	for _, addr := range routed[1:] {
+		for _, c := range gateways[addr] {
+			if c.UsageType != "NatGateway-Hours" {
+				continue
+			}
+			cost := a.costs[c.ID]
+			a.add(c, KindSharedNATGateway, "Share one NAT gateway outside production",
+				fmt.Sprintf("%d NAT gateways in %s; one shared gateway avoids the hourly charge of the others",
+					len(routed), a.environment),
+				cost, cost)
+		}
+	}
+}
+
+// hasDependent reports whether a resource is referenced by a resource of one of the types
+func (a *analyzer) hasDependent(addr string, types ...string) bool {
+	if a.graph == nil {
+		return false
+	}
+	node, ok := a.graph.Nodes[addr]
+	if !ok {
+		return false
+	}
+	for _, dep := range node.Dependents {
+		if depNode, ok := a.graph.Nodes[dep]; ok {
+			for _, t := range types {
+				if depNode.Resource.Type == t {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// hasRoutes reports whether the plan manages any routes
+func (a *analyzer) hasRoutes() bool {
+	if a.graph == nil {
+		return false
+	}
+	for _, node := range a.graph.Nodes {
+		if node.Resource.Type == "aws_route" || node.Resource.Type == "aws_route_table" {
+			return true
+		}
+	}
+	return false
+}
+
+// resourceType returns a resource's Terraform type
+func (a *analyzer) resourceType(addr string) string {
+	if a.graph != nil {
+		if node, ok := a.graph.Nodes[addr]; ok {
+			return node.Resource.Type
+		}
+	}
+	parts := strings.Split(iac.BaseAddress(addr), ".")
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[len(parts)-2]
+}
+
+// instanceHours returns the instance type of an instance-hours usage type
+// (BoxUsage:m5.large, SpotUsage:m5.large, RDS:db.r5.large)
+func instanceHours(usageType string) (string, bool) {
+	for _, prefix := range []string{"BoxUsage:", "SpotUsage:", "RDS:"} {
+		if t, ok := strings.CutPrefix(usageType, prefix); ok && strings.Contains(t, ".") {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+// splitInstanceType splits m5.large into (m5, large) and db.r5.large into (db.r5, large)
+func splitInstanceType(instanceType string) (family, size string) {
+	i := strings.LastIndex(instanceType, ".")
+	if i < 0 {
+		return instanceType, ""
+	}
+	return instanceType[:i], instanceType[i+1:]
+}
+
+// isProduction reports whether an environment is production; unset counts as production
+func isProduction(env string) bool {
+	switch strings.ToLower(env) {
+	case "", "prod", "production":
+		return true
+	}
+	return false
+}
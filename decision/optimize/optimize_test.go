@@ -0,0 +1,140 @@
+package optimize
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/estimation"
+	"terraform-cost/decision/iac"
+)
+
+func component(id, addr, service, usageType string, attrs map[string]string) billing.BillingComponent {
+	return billing.BillingComponent{ID: id, ResourceAddr: addr, Service: service, UsageType: usageType, Attributes: attrs}
+}
+
+func driver(componentID string, cost float64) estimation.CostDriver {
+	return estimation.CostDriver{ComponentID: componentID, MonthlyCostP50: decimal.NewFromFloat(cost)}
+}
+
+func graphOf(resources map[string][]string) *iac.Graph {
+	g := &iac.Graph{Nodes: make(map[string]*iac.GraphNode)}
+	for addr := range resources {
+		g.Nodes[addr] = &iac.GraphNode{Resource: iac.ResourceNode{Address: addr, Type: strings.Split(addr, ".")[0]}}
+	}
+	for addr, deps := range resources {
+		for _, dep := range deps {
+			g.Nodes[dep].Dependents = append(g.Nodes[dep].Dependents, addr)
+		}
+	}
+	return g
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name        string
+		graph       *iac.Graph
+		components  []billing.BillingComponent
+		drivers     []estimation.CostDriver
+		environment string
+		want        map[string]string // kind -> savings
+	}{
+		{
+			name: "gp2 volume",
+			components: []billing.BillingComponent{
+				component("aws_ebs_volume.x-storage", "aws_ebs_volume.x", "AmazonEC2", "EBS:VolumeUsage.gp2", map[string]string{"volumeType": "gp2"}),
+				component("aws_ebs_volume.y-storage", "aws_ebs_volume.y", "AmazonEC2", "EBS:VolumeUsage.gp3", map[string]string{"volumeType": "gp3"}),
+			},
+			drivers:     []estimation.CostDriver{driver("aws_ebs_volume.x-storage", 10), driver("aws_ebs_volume.y-storage", 8)},
+			environment: "prod",
+			want:        map[string]string{KindGP3Migration: "2"},
+		},
+		{
+			name: "previous generation instance and database",
+			components: []billing.BillingComponent{
+				component("aws_instance.x-compute", "aws_instance.x", "AmazonEC2", "BoxUsage:c4.large", map[string]string{"instanceType": "c4.large"}),
+				component("aws_instance.y-compute", "aws_instance.y", "AmazonEC2", "BoxUsage:m5.large", map[string]string{"instanceType": "m5.large"}),
+			},
+			drivers:     []estimation.CostDriver{driver("aws_instance.x-compute", 100)},
+			environment: "prod",
+			want:        map[string]string{KindPreviousGeneration: "15"},
+		},
+		{
+			name: "multi-AZ in dev",
+			components: []billing.BillingComponent{
+				component("aws_db_instance.x-compute", "aws_db_instance.x", "AmazonRDS", "RDS:db.m5.large", map[string]string{"instanceType": "db.m5.large", "deploymentOption": "Multi-AZ"}),
+				component("aws_db_instance.x-storage", "aws_db_instance.x", "AmazonRDS", "RDS:GP3-Storage", map[string]string{"deploymentOption": "Multi-AZ"}),
+			},
+			drivers:     []estimation.CostDriver{driver("aws_db_instance.x-compute", 200), driver("aws_db_instance.x-storage", 20)},
+			environment: "dev",
+			want:        map[string]string{KindMultiAZ: "110"},
+		},
+		{
+			name: "multi-AZ in production is kept",
+			components: []billing.BillingComponent{
+				component("aws_db_instance.x-compute", "aws_db_instance.x", "AmazonRDS", "RDS:db.m5.large", map[string]string{"instanceType": "db.m5.large", "deploymentOption": "Multi-AZ"}),
+			},
+			drivers:     []estimation.CostDriver{driver("aws_db_instance.x-compute", 200)},
+			environment: "production",
+			want:        map[string]string{},
+		},
+		{
+			name:  "idle EIP unless associated",
+			graph: graphOf(map[string][]string{"aws_eip.x": nil, "aws_eip.y": nil, "aws_eip_association.x": {"aws_eip.y"}}),
+			components: []billing.BillingComponent{
+				component("aws_eip.x-idle", "aws_eip.x", "AmazonEC2", "ElasticIP:IdleAddress", nil),
+				component("aws_eip.y-idle", "aws_eip.y", "AmazonEC2", "ElasticIP:IdleAddress", nil),
+			},
+			drivers:     []estimation.CostDriver{driver("aws_eip.x-idle", 3.65), driver("aws_eip.y-idle", 3.65)},
+			environment: "prod",
+			want:        map[string]string{KindUnattachedEIP: "3.65"},
+		},
+		{
+			name: "NAT without routes and one per subnet in dev",
+			graph: graphOf(map[string][]string{
+				"aws_nat_gateway.a": nil, "aws_nat_gateway.b": nil, "aws_nat_gateway.c": nil,
+				"aws_route.a": {"aws_nat_gateway.a"}, "aws_route.b": {"aws_nat_gateway.b"},
+			}),
+			components: []billing.BillingComponent{
+				component("aws_nat_gateway.a-hours", "aws_nat_gateway.a", "AmazonVPC", "NatGateway-Hours", nil),
+				component("aws_nat_gateway.b-hours", "aws_nat_gateway.b", "AmazonVPC", "NatGateway-Hours", nil),
+				component("aws_nat_gateway.b-data", "aws_nat_gateway.b", "AmazonVPC", "NatGateway-Bytes", nil),
+				component("aws_nat_gateway.c-hours", "aws_nat_gateway.c", "AmazonVPC", "NatGateway-Hours", nil),
+				component("aws_nat_gateway.c-data", "aws_nat_gateway.c", "AmazonVPC", "NatGateway-Bytes", nil),
+			},
+			drivers: []estimation.CostDriver{
+				driver("aws_nat_gateway.a-hours", 32.85), driver("aws_nat_gateway.b-hours", 32.85),
+				driver("aws_nat_gateway.b-data", 2.25), driver("aws_nat_gateway.c-hours", 32.85), driver("aws_nat_gateway.c-data", 2.25),
+			},
+			environment: "dev",
+			want:        map[string]string{KindIdleNATGateway: "35.1", KindSharedNATGateway: "32.85"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			est := &estimation.EstimationResult{Currency: "USD", CostDrivers: tt.drivers}
+			report := Analyze(tt.graph, tt.components, est, tt.environment)
+
+			got := make(map[string]string)
+			total := decimal.Zero
+			for _, r := range report.Recommendations {
+				got[r.Kind] = r.MonthlySavings.String()
+				total = total.Add(r.MonthlySavings)
+			}
+			if len(got) != len(tt.want) || len(report.Recommendations) != len(tt.want) {
+				t.Fatalf("recommendations = %+v, want %v", report.Recommendations, tt.want)
+			}
+			for kind, savings := range tt.want {
+				if got[kind] != savings {
+					t.Errorf("%s savings = %s, want %s", kind, got[kind], savings)
+				}
+			}
+			if !report.MonthlySavings.Equal(total) {
+				t.Errorf("MonthlySavings = %s, want %s", report.MonthlySavings, total)
+			}
+		})
+	}
+}
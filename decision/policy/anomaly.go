@@ -0,0 +1,45 @@
+// Package policy - Cost anomalies
+// cost_anomaly compares an estimate with the band of its project's trailing
+// saved estimates in the same environment
+package policy
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"terraform-cost/decision/anomaly"
+	"terraform-cost/decision/estimation"
+)
+
+// evaluateAnomaly checks an estimate against its project's cost band
+// Threshold is the band half-width in standard deviations (default 3).
+// Projects with too little history pass; lookup failures warn rather than block.
+func (e *Engine) evaluateAnomaly(ctx context.Context, p Policy, est *estimation.EstimationResult, req EvaluationRequest) (*Violation, *Warning) {
+	if e.anomalies == nil || req.Project == "" {
+		return nil, nil
+	}
+	band, err := e.anomalies.Band(ctx, req.Project, req.Environment, anomaly.Options{Window: p.Window, Sigma: p.Threshold})
+	if err != nil {
+		return nil, &Warning{
+			PolicyID: p.ID,
+			Message:  fmt.Sprintf("Cost anomalies not checked: %v", err),
+		}
+	}
+	cost := est.MonthlyCostP50.InexactFloat64()
+	if band == nil || band.Contains(cost) {
+		return nil, nil
+	}
+
+	direction := "above"
+	if cost < band.Lower {
+		direction = "below"
+	}
+	return &Violation{
+		PolicyID:   p.ID,
+		PolicyName: p.Name,
+		Message: fmt.Sprintf("Monthly cost P50 $%s is %.1fσ %s the mean of the last %d estimates ($%.2f ± $%.2f)",
+			est.MonthlyCostP50.StringFixed(2), math.Abs(band.ZScore(cost)), direction, band.Samples, band.Mean, band.StdDev),
+		Severity: string(p.Severity),
+	}, nil
+}
@@ -0,0 +1,62 @@
+package policy
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/anomaly"
+	"terraform-cost/decision/estimation"
+)
+
+// historyStore returns the same saved estimates for every query
+type historyStore []*clickhouse.EstimationRecord
+
+func (s historyStore) ListEstimations(ctx context.Context, filter clickhouse.EstimationFilter) ([]*clickhouse.EstimationRecord, error) {
+	return s, nil
+}
+
+func TestCostAnomalyPolicy(t *testing.T) {
+	var store historyStore
+	for _, cost := range []int64{1000, 1020, 980, 1010, 990} {
+		store = append(store, &clickhouse.EstimationRecord{Project: "web", Environment: "prod", MonthlyCostP50: decimal.NewFromInt(cost)})
+	}
+
+	tests := []struct {
+		name     string
+		project  string
+		history  historyStore
+		current  int64
+		decision Decision
+		message  string
+	}{
+		{"no project", "", store, 5000, DecisionPass, ""},
+		{"too little history", "web", store[:3], 5000, DecisionPass, ""},
+		{"within band", "web", store, 1030, DecisionPass, ""},
+		{"spike", "web", store, 1500, DecisionDeny, "above the mean of the last 5 estimates"},
+		{"drop", "web", store, 200, DecisionDeny, "below"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := (&Engine{}).WithAnomalyDetector(anomaly.NewDetector(tt.history))
+			engine.policies = []Policy{{ID: "cost-anomaly", Type: PolicyTypeCostAnomaly, Severity: SeverityError, Threshold: 3, Enabled: true}}
+			result, err := engine.Evaluate(context.Background(), EvaluationRequest{
+				Estimation:  &estimation.EstimationResult{MonthlyCostP50: decimal.NewFromInt(tt.current), Confidence: 1},
+				Environment: "prod",
+				Project:     tt.project,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Decision != tt.decision {
+				t.Fatalf("decision = %s, want %s (%+v)", result.Decision, tt.decision, result.Violations)
+			}
+			if tt.message != "" && !strings.Contains(result.Violations[0].Message, tt.message) {
+				t.Errorf("message = %q", result.Violations[0].Message)
+			}
+		})
+	}
+}
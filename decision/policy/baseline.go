@@ -0,0 +1,75 @@
+// Package policy - Cost growth baselines
+// A baseline is the previous estimate that cost growth policies compare against
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+)
+
+// Baseline is a previous estimate used by cost growth policies
+type Baseline struct {
+	MonthlyCostP50 decimal.Decimal `json:"monthly_cost_p50"`
+	MonthlyCostP90 decimal.Decimal `json:"monthly_cost_p90"`
+	Source         string          `json:"source,omitempty"` // where the baseline came from, for messages
+}
+
+// LoadBaseline reads a baseline from estimate JSON (CLI --format json, API response or EstimationResult)
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+	b, err := ParseBaseline(data)
+	if err != nil {
+		return nil, err
+	}
+	b.Source = path
+	return b, nil
+}
+
+// ParseBaseline parses estimate JSON into a baseline
+func ParseBaseline(data []byte) (*Baseline, error) {
+	var raw struct {
+		MonthlyCostP50 *decimal.Decimal `json:"monthly_cost_p50"`
+		MonthlyCostP90 *decimal.Decimal `json:"monthly_cost_p90"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline: %w", err)
+	}
+	if raw.MonthlyCostP50 == nil {
+		return nil, fmt.Errorf("baseline has no monthly_cost_p50")
+	}
+	b := &Baseline{MonthlyCostP50: *raw.MonthlyCostP50, MonthlyCostP90: *raw.MonthlyCostP50}
+	if raw.MonthlyCostP90 != nil {
+		b.MonthlyCostP90 = *raw.MonthlyCostP90
+	}
+	return b, nil
+}
+
+// BaselineFromRecord uses a saved estimation as the baseline
+func BaselineFromRecord(rec *clickhouse.EstimationRecord) *Baseline {
+	source := fmt.Sprintf("estimate %s", rec.ID)
+	if rec.Branch != "" {
+		source = fmt.Sprintf("estimate %s on %s", rec.ID, rec.Branch)
+	}
+	return &Baseline{
+		MonthlyCostP50: rec.MonthlyCostP50,
+		MonthlyCostP90: rec.MonthlyCostP90,
+		Source:         source,
+	}
+}
+
+// Growth returns the percentage change from the baseline P50 to current
+// ok is false when the baseline is zero and growth is undefined.
+func (b *Baseline) Growth(current decimal.Decimal) (pct float64, ok bool) {
+	if b.MonthlyCostP50.IsZero() {
+		return 0, false
+	}
+	return current.Sub(b.MonthlyCostP50).Div(b.MonthlyCostP50).Mul(decimal.NewFromInt(100)).InexactFloat64(), true
+}
@@ -0,0 +1,66 @@
+package policy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/decision/estimation"
+)
+
+func TestCostGrowthPolicy(t *testing.T) {
+	growth := Policy{
+		ID:        "growth",
+		Name:      "Cost Growth",
+		Type:      PolicyTypeCostGrowth,
+		Severity:  SeverityError,
+		Threshold: 20,
+		Enabled:   true,
+	}
+
+	tests := []struct {
+		name      string
+		current   string
+		baseline  *Baseline
+		violation bool
+	}{
+		{"no baseline", "500", nil, false},
+		{"within limit", "115", &Baseline{MonthlyCostP50: decimal.NewFromInt(100)}, false},
+		{"above limit", "125", &Baseline{MonthlyCostP50: decimal.NewFromInt(100)}, true},
+		{"decrease", "50", &Baseline{MonthlyCostP50: decimal.NewFromInt(100)}, false},
+		{"from zero", "10", &Baseline{}, true},
+		{"zero to zero", "0", &Baseline{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := &Engine{policies: []Policy{growth}}
+			result, err := engine.Evaluate(context.Background(), EvaluationRequest{
+				Estimation: &estimation.EstimationResult{MonthlyCostP50: decimal.RequireFromString(tt.current), Confidence: 1},
+				Baseline:   tt.baseline,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(result.Violations) > 0; got != tt.violation {
+				t.Errorf("violation = %v, want %v (%v)", got, tt.violation, result.Violations)
+			}
+		})
+	}
+}
+
+func TestParseBaseline(t *testing.T) {
+	// CLI --format json writes costs as strings
+	b, err := ParseBaseline([]byte(`{"monthly_cost_p50": "120.50", "monthly_cost_p90": "150.00"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b.MonthlyCostP50.Equal(decimal.RequireFromString("120.5")) || !b.MonthlyCostP90.Equal(decimal.NewFromInt(150)) {
+		t.Errorf("got %s / %s", b.MonthlyCostP50, b.MonthlyCostP90)
+	}
+
+	if _, err := ParseBaseline([]byte(`{"resources": []}`)); err == nil {
+		t.Error("expected error for estimate without monthly_cost_p50")
+	}
+}
@@ -0,0 +1,69 @@
+// Package policy - Stored budgets
+// budget_exceeded projects an estimate onto the budgets covering its project
+package policy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"terraform-cost/decision/currency"
+	"terraform-cost/decision/estimation"
+)
+
+// evaluateBudgets checks an estimate against stored budgets
+// Threshold is the percent of a budget that violates (default 100); a
+// budget's own alert threshold only warns. Lookup failures warn rather than block.
+func (e *Engine) evaluateBudgets(ctx context.Context, p Policy, est *estimation.EstimationResult, req EvaluationRequest) (*Violation, *Warning) {
+	if e.budgets == nil || req.Project == "" {
+		return nil, nil
+	}
+
+	projections, notes, err := e.budgets.Project(ctx, req.Project, req.Environment, est)
+	if err != nil {
+		return nil, &Warning{
+			PolicyID: p.ID,
+			Message:  fmt.Sprintf("Budgets not checked: %v", err),
+		}
+	}
+
+	limit := p.Threshold
+	if limit <= 0 {
+		limit = 100
+	}
+
+	over := make([]string, 0)
+	near := make([]string, 0)
+	for _, pr := range projections {
+		b := pr.Budget
+		summary := fmt.Sprintf("%s at %s of %s (%.0f%%)", b.Name,
+			currency.Format(pr.Projected, b.Currency, 2), currency.Format(b.MonthlyLimit, b.Currency, 2),
+			pr.ProjectedUsedPercent)
+		switch {
+		case pr.ProjectedUsedPercent > limit:
+			over = append(over, summary)
+		case pr.ProjectedUsedPercent >= b.AlertThreshold:
+			near = append(near, summary)
+		}
+	}
+
+	if len(over) > 0 {
+		return &Violation{
+			PolicyID:   p.ID,
+			PolicyName: p.Name,
+			Message:    "Budget exceeded: " + strings.Join(over, "; "),
+			Severity:   string(p.Severity),
+		}, nil
+	}
+	messages := notes
+	if len(near) > 0 {
+		messages = append([]string{"Budget nearly used: " + strings.Join(near, "; ")}, notes...)
+	}
+	if len(messages) > 0 {
+		return nil, &Warning{
+			PolicyID: p.ID,
+			Message:  strings.Join(messages, "; "),
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,69 @@
+package policy
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/budget"
+	"terraform-cost/decision/estimation"
+)
+
+type budgetStore struct {
+	budgets []*clickhouse.Budget
+	saved   *clickhouse.EstimationRecord
+}
+
+func (s *budgetStore) BudgetsFor(ctx context.Context, project, environment string) ([]*clickhouse.Budget, error) {
+	return s.budgets, nil
+}
+
+func (s *budgetStore) LatestEstimations(ctx context.Context, filter clickhouse.EstimationFilter) ([]*clickhouse.EstimationRecord, error) {
+	if s.saved == nil {
+		return nil, nil
+	}
+	return []*clickhouse.EstimationRecord{s.saved}, nil
+}
+
+func TestBudgetExceededPolicy(t *testing.T) {
+	store := &budgetStore{
+		budgets: []*clickhouse.Budget{{Name: "web", Project: "web", MonthlyLimit: decimal.NewFromInt(1000), Currency: "USD", AlertThreshold: 80}},
+		saved:   &clickhouse.EstimationRecord{Project: "web", Environment: "prod", MonthlyCostP50: decimal.NewFromInt(700)},
+	}
+
+	tests := []struct {
+		name     string
+		project  string
+		current  int64
+		decision Decision
+	}{
+		{"no project", "", 5000, DecisionPass},
+		{"under budget", "web", 500, DecisionPass},
+		{"near alert threshold", "web", 900, DecisionWarn},
+		{"over budget", "web", 1200, DecisionDeny},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := (&Engine{}).WithBudgets(budget.NewTracker(store))
+			engine.policies = []Policy{{ID: "budget-exceeded", Type: PolicyTypeBudgetExceeded, Severity: SeverityError, Enabled: true}}
+			result, err := engine.Evaluate(context.Background(), EvaluationRequest{
+				Estimation:  &estimation.EstimationResult{MonthlyCostP50: decimal.NewFromInt(tt.current), Confidence: 1},
+				Environment: "prod",
+				Project:     tt.project,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Decision != tt.decision {
+				t.Errorf("decision = %s, want %s (%+v)", result.Decision, tt.decision, result.Checks)
+			}
+			if tt.decision == DecisionDeny && !strings.Contains(result.Violations[0].Message, "$1200.00 of $1000.00") {
+				t.Errorf("message = %q", result.Violations[0].Message)
+			}
+		})
+	}
+}
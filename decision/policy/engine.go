@@ -4,12 +4,21 @@ package policy
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+
+	"terraform-cost/decision/anomaly"
+	"terraform-cost/decision/budget"
 	"terraform-cost/decision/estimation"
+	"terraform-cost/telemetry"
 )
 
 // PolicyType defines the type of policy
@@ -21,6 +30,9 @@ const (
 	PolicyTypeConfidenceThreshold PolicyType = "confidence_threshold"
 	PolicyTypeCarbonBudget        PolicyType = "carbon_budget"
 	PolicyTypeIncompleteEstimate  PolicyType = "incomplete_estimate"
+	PolicyTypeTagBudget           PolicyType = "tag_budget" // monthly P50 limit per value of TagKey
+	PolicyTypeBudgetExceeded      PolicyType = "budget_exceeded" // stored budgets, see WithBudgets
+	PolicyTypeCostAnomaly         PolicyType = "cost_anomaly" // outside Threshold σ of the project's history, see WithAnomalyDetector
 	PolicyTypeCustom              PolicyType = "custom"
 )
 
@@ -51,6 +63,22 @@ type Policy struct {
 	Severity    Severity   `json:"severity"`
 	Threshold   float64    `json:"threshold"`
 	Enabled     bool       `json:"enabled"`
+
+	// Scope: empty Environments applies everywhere; a Selector evaluates
+	// the policy against only the matching cost drivers
+	Environments []string  `json:"environments,omitempty"`
+	Selector     *Selector `json:"selector,omitempty"`
+
+	// TagKey is the allocation tag a tag_budget policy limits (e.g. team)
+	TagKey string `json:"tag_key,omitempty"`
+
+	// Dimension is the confidence score a confidence_threshold policy checks:
+	// overall (default), pricing, usage or coverage
+	Dimension string `json:"dimension,omitempty"`
+
+	// Window is how many trailing estimates a cost_anomaly policy's band
+	// covers (default anomaly.DefaultWindow)
+	Window int `json:"window,omitempty"`
 }
 
 // Violation represents a policy violation
@@ -65,13 +93,16 @@ type Violation struct {
 type Warning struct {
 	PolicyID string `json:"policy_id"`
 	Message  string `json:"message"`
+	WaivedBy string `json:"waived_by,omitempty"` // Waiver ID when a violation was waived
 }
 
 // EvaluationRequest contains the input for policy evaluation
 type EvaluationRequest struct {
 	Estimation     *estimation.EstimationResult
 	Environment    string
+	Project        string // budget_exceeded checks the budgets covering this project
 	CustomPolicies []Policy
+	Baseline       *Baseline // previous estimate for cost growth policies
 }
 
 // EvaluationResult contains the policy evaluation outcome
@@ -80,29 +111,75 @@ type EvaluationResult struct {
 	Violations     []Violation `json:"violations"`
 	Warnings       []Warning   `json:"warnings"`
 	PoliciesRan    int         `json:"policies_ran"`
+	Checks         []Check     `json:"checks"`
 	EvaluatedAt    time.Time   `json:"evaluated_at"`
 }
 
+// Check is the outcome of one policy, including policies that passed
+type Check struct {
+	PolicyID   string   `json:"policy_id"`
+	PolicyName string   `json:"policy_name"`
+	Decision   Decision `json:"decision"`
+	Message    string   `json:"message,omitempty"`
+}
+
 // Engine evaluates policies against estimations
 type Engine struct {
-	policies    []Policy
-	opaEndpoint string
-	httpClient  *http.Client
+	policies       []Policy
+	opaEndpoint    string
+	opaPackage     string
+	opaFailureMode OPAFailureMode
+	httpClient     *http.Client
+	budgets        *budget.Tracker
+	anomalies      *anomaly.Detector
+	waivers        []Waiver
 }
 
 // NewEngine creates a new policy engine
 func NewEngine() *Engine {
 	return &Engine{
-		policies: defaultPolicies(),
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		policies:       defaultPolicies(),
+		opaPackage:     DefaultOPAPackage,
+		opaFailureMode: OPAFailOpen,
+		httpClient:     newOPAClient(),
 	}
 }
 
 // WithOPA configures OPA integration
 func (e *Engine) WithOPA(endpoint string) *Engine {
-	e.opaEndpoint = endpoint
+	e.opaEndpoint = strings.TrimRight(endpoint, "/")
+	return e
+}
+
+// WithOPAPackage sets the Rego package queried for deny/warn rules (e.g. "terracost.policy")
+func (e *Engine) WithOPAPackage(pkg string) *Engine {
+	if pkg != "" {
+		e.opaPackage = pkg
+	}
+	return e
+}
+
+// WithOPAFailureMode sets how OPA errors affect the decision
+func (e *Engine) WithOPAFailureMode(mode OPAFailureMode) *Engine {
+	e.opaFailureMode = mode
+	return e
+}
+
+// WithBudgets enables budget_exceeded policies against stored budgets
+func (e *Engine) WithBudgets(tracker *budget.Tracker) *Engine {
+	e.budgets = tracker
+	return e
+}
+
+// WithAnomalyDetector enables cost_anomaly policies over estimation history
+func (e *Engine) WithAnomalyDetector(detector *anomaly.Detector) *Engine {
+	e.anomalies = detector
+	return e
+}
+
+// WithWaivers downgrades waived violations to warnings; expired waivers deny
+func (e *Engine) WithWaivers(waivers []Waiver) *Engine {
+	e.waivers = waivers
 	return e
 }
 
@@ -111,30 +188,81 @@ func (e *Engine) AddPolicy(p Policy) {
 	e.policies = append(e.policies, p)
 }
 
+// LoadPolicies adds policies, replacing any existing policy with the same ID
+// A policy file can disable or retune the built-in defaults this way.
+func (e *Engine) LoadPolicies(policies []Policy) {
+	for _, p := range policies {
+		replaced := false
+		for i := range e.policies {
+			if e.policies[i].ID == p.ID {
+				e.policies[i] = p
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			e.policies = append(e.policies, p)
+		}
+	}
+}
+
+// PolicySetHash returns a SHA-256 fingerprint of the policies an evaluation runs:
+// the engine's policies, a request's custom policies and the OPA configuration
+func (e *Engine) PolicySetHash(custom []Policy) string {
+	data, _ := json.Marshal(struct {
+		Policies       []Policy       `json:"policies"`
+		OPAEndpoint    string         `json:"opa_endpoint,omitempty"`
+		OPAPackage     string         `json:"opa_package,omitempty"`
+		OPAFailureMode OPAFailureMode `json:"opa_failure_mode,omitempty"`
+		Waivers        []Waiver       `json:"waivers,omitempty"`
+	}{
+		Policies:       append(append([]Policy{}, e.policies...), custom...),
+		Waivers:        e.waivers,
+		OPAEndpoint:    e.opaEndpoint,
+		OPAPackage:     e.opaPackage,
+		OPAFailureMode: e.opaFailureMode,
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
 // Evaluate runs all policies against the estimation
 func (e *Engine) Evaluate(ctx context.Context, req EvaluationRequest) (*EvaluationResult, error) {
+	ctx, span := telemetry.StartSpan(ctx, "policy.evaluate")
+	defer span.End()
+
 	result := &EvaluationResult{
 		Decision:    DecisionPass,
 		Violations:  make([]Violation, 0),
 		Warnings:    make([]Warning, 0),
+		Checks:      make([]Check, 0),
 		EvaluatedAt: time.Now(),
 	}
 
-	// Combine built-in and custom policies
-	allPolicies := append(e.policies, req.CustomPolicies...)
+	// Combine built-in and custom policies; the engine is shared across
+	// requests, so its slice must not be appended to in place
+	allPolicies := append(slices.Clip(e.policies), req.CustomPolicies...)
 
 	for _, policy := range allPolicies {
-		if !policy.Enabled {
+		if !policy.Enabled || !policy.appliesTo(req.Environment) {
 			continue
 		}
 
 		result.PoliciesRan++
-		violation, warning := e.evaluatePolicy(policy, req.Estimation, req.Environment)
+		violation, warning := e.evaluatePolicy(ctx, policy, req)
+		check := Check{PolicyID: policy.ID, PolicyName: policy.Name, Decision: DecisionPass}
+		if violation != nil {
+			if w := e.waive(ctx, policy, req, result.EvaluatedAt); w != nil {
+				violation, warning = nil, waivedWarning(violation, w)
+			}
+		}
 
 		if violation != nil {
 			result.Violations = append(result.Violations, *violation)
+			check.Decision, check.Message = DecisionWarn, violation.Message
 			if policy.Severity == SeverityError {
 				result.Decision = DecisionDeny
+				check.Decision = DecisionDeny
 			} else if result.Decision != DecisionDeny {
 				result.Decision = DecisionWarn
 			}
@@ -142,28 +270,56 @@ func (e *Engine) Evaluate(ctx context.Context, req EvaluationRequest) (*Evaluati
 
 		if warning != nil {
 			result.Warnings = append(result.Warnings, *warning)
+			check.Decision, check.Message = DecisionWarn, warning.Message
 			if result.Decision == DecisionPass {
 				result.Decision = DecisionWarn
 			}
 		}
+		result.Checks = append(result.Checks, check)
+	}
+
+	// Expired waivers deny until they are renewed or removed
+	for _, v := range e.expiredWaivers(req, result.EvaluatedAt) {
+		result.Violations = append(result.Violations, v)
+		result.Decision = DecisionDeny
+		result.Checks = append(result.Checks, Check{PolicyID: v.PolicyID, PolicyName: v.PolicyName, Decision: DecisionDeny, Message: v.Message})
 	}
 
 	// Run OPA policies if configured
 	if e.opaEndpoint != "" {
-		opaResult, err := e.evaluateOPA(ctx, req)
-		if err == nil && opaResult != nil {
-			result.Violations = append(result.Violations, opaResult.Violations...)
-			result.Warnings = append(result.Warnings, opaResult.Warnings...)
-			if len(opaResult.Violations) > 0 {
+		violations, warnings, err := e.evaluateOPA(ctx, req)
+		if err != nil {
+			violations, warnings = e.opaFailure(err)
+		}
+		result.Violations = append(result.Violations, violations...)
+		result.Warnings = append(result.Warnings, warnings...)
+		for _, v := range violations {
+			if v.Severity == string(SeverityError) {
 				result.Decision = DecisionDeny
+			} else if result.Decision != DecisionDeny {
+				result.Decision = DecisionWarn
 			}
 		}
+		if len(warnings) > 0 && result.Decision == DecisionPass {
+			result.Decision = DecisionWarn
+		}
+		result.Checks = append(result.Checks, opaCheck(violations, warnings))
 	}
 
+	span.SetAttributes(
+		attribute.String("policy.decision", string(result.Decision)),
+		attribute.Int("policy.violations", len(result.Violations)),
+	)
+
 	return result, nil
 }
 
-func (e *Engine) evaluatePolicy(p Policy, est *estimation.EstimationResult, env string) (*Violation, *Warning) {
+func (e *Engine) evaluatePolicy(ctx context.Context, p Policy, req EvaluationRequest) (*Violation, *Warning) {
+	est, env := req.Estimation, req.Environment
+	if p.Selector != nil {
+		est = scopeEstimation(est, p.Selector)
+	}
+
 	switch p.Type {
 	case PolicyTypeCostLimit:
 		costP90, _ := est.MonthlyCostP90.Float64()
@@ -176,21 +332,79 @@ func (e *Engine) evaluatePolicy(p Policy, est *estimation.EstimationResult, env
 			}, nil
 		}
 
-	case PolicyTypeConfidenceThreshold:
-		if est.Confidence < p.Threshold/100 {
-			if p.Severity == SeverityError {
-				return &Violation{
-					PolicyID:   p.ID,
-					PolicyName: p.Name,
-					Message:    fmt.Sprintf("Estimation confidence (%.0f%%) below threshold (%.0f%%)", est.Confidence*100, p.Threshold),
-					Severity:   string(p.Severity),
-				}, nil
+	case PolicyTypeCostGrowth:
+		// Threshold is the allowed P50 growth in percent
+		if req.Baseline == nil {
+			return nil, nil
+		}
+		base := req.Baseline
+		growth, ok := base.Growth(est.MonthlyCostP50)
+		if !ok {
+			if !est.MonthlyCostP50.IsPositive() {
+				return nil, nil
 			}
-			return nil, &Warning{
-				PolicyID: p.ID,
-				Message:  fmt.Sprintf("Estimation confidence (%.0f%%) below recommended (%.0f%%)", est.Confidence*100, p.Threshold),
+			return &Violation{
+				PolicyID:   p.ID,
+				PolicyName: p.Name,
+				Message: fmt.Sprintf("Monthly cost P50 grew from $0.00 to $%s (baseline: %s)",
+					est.MonthlyCostP50.StringFixed(2), base.Source),
+				Severity: string(p.Severity),
+			}, nil
+		}
+		if growth > p.Threshold {
+			return &Violation{
+				PolicyID:   p.ID,
+				PolicyName: p.Name,
+				Message: fmt.Sprintf("Monthly cost P50 grew %.1f%% ($%s → $%s), above the %.1f%% limit (baseline: %s)",
+					growth, base.MonthlyCostP50.StringFixed(2), est.MonthlyCostP50.StringFixed(2), p.Threshold, base.Source),
+				Severity: string(p.Severity),
+			}, nil
+		}
+
+	case PolicyTypeTagBudget:
+		allocation := estimation.AllocateByTag(est.CostDrivers, []string{p.TagKey})[p.TagKey]
+		over := make([]string, 0)
+		for _, tc := range estimation.SortedTagCosts(allocation) {
+			if tc.Value != estimation.UntaggedValue && tc.MonthlyCostP50.InexactFloat64() > p.Threshold {
+				over = append(over, fmt.Sprintf("%s=%s ($%s)", p.TagKey, tc.Value, tc.MonthlyCostP50.StringFixed(2)))
 			}
 		}
+		if len(over) > 0 {
+			return &Violation{
+				PolicyID:   p.ID,
+				PolicyName: p.Name,
+				Message:    fmt.Sprintf("Monthly cost exceeds $%.2f budget for %s", p.Threshold, strings.Join(over, ", ")),
+				Severity:   string(p.Severity),
+			}, nil
+		}
+
+	case PolicyTypeBudgetExceeded:
+		return e.evaluateBudgets(ctx, p, est, req)
+
+	case PolicyTypeCostAnomaly:
+		return e.evaluateAnomaly(ctx, p, est, req)
+
+	case PolicyTypeConfidenceThreshold:
+		score, ok := est.ConfidenceScores.Dimension(p.Dimension, est.Confidence)
+		if !ok || score >= p.Threshold/100 {
+			return nil, nil
+		}
+		label := "Estimation confidence"
+		if p.Dimension != "" && p.Dimension != estimation.ConfidenceOverall {
+			label = fmt.Sprintf("Estimation %s confidence", p.Dimension)
+		}
+		if p.Severity == SeverityError {
+			return &Violation{
+				PolicyID:   p.ID,
+				PolicyName: p.Name,
+				Message:    fmt.Sprintf("%s (%.0f%%) below threshold (%.0f%%)", label, score*100, p.Threshold),
+				Severity:   string(p.Severity),
+			}, nil
+		}
+		return nil, &Warning{
+			PolicyID: p.ID,
+			Message:  fmt.Sprintf("%s (%.0f%%) below recommended (%.0f%%)", label, score*100, p.Threshold),
+		}
 
 	case PolicyTypeCarbonBudget:
 		if est.CarbonKgCO2 > p.Threshold {
@@ -216,45 +430,6 @@ func (e *Engine) evaluatePolicy(p Policy, est *estimation.EstimationResult, env
 	return nil, nil
 }
 
-func (e *Engine) evaluateOPA(ctx context.Context, req EvaluationRequest) (*EvaluationResult, error) {
-	if e.opaEndpoint == "" {
-		return nil, nil
-	}
-
-	// Build OPA input
-	input := map[string]interface{}{
-		"monthly_cost_p50": req.Estimation.MonthlyCostP50.InexactFloat64(),
-		"monthly_cost_p90": req.Estimation.MonthlyCostP90.InexactFloat64(),
-		"carbon_kg_co2":    req.Estimation.CarbonKgCO2,
-		"confidence":       req.Estimation.Confidence,
-		"is_incomplete":    req.Estimation.IsIncomplete,
-		"environment":      req.Environment,
-	}
-
-	body, _ := json.Marshal(map[string]interface{}{"input": input})
-	
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", e.opaEndpoint+"/v1/data/terracost/deny", nil)
-	if err != nil {
-		return nil, err
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	
-	resp, err := e.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	// Parse OPA response - simplified
-	_ = body // Used in actual implementation
-	
-	return &EvaluationResult{
-		Decision:   DecisionPass,
-		Violations: []Violation{},
-		Warnings:   []Warning{},
-	}, nil
-}
-
 func defaultPolicies() []Policy {
 	return []Policy{
 		{
@@ -266,6 +441,15 @@ func defaultPolicies() []Policy {
 			Threshold:   70,
 			Enabled:     true,
 		},
+		{
+			ID:          "budget-exceeded",
+			Name:        "Budget Exceeded",
+			Description: "Block estimates that would exceed a stored budget",
+			Type:        PolicyTypeBudgetExceeded,
+			Severity:    SeverityError,
+			Threshold:   100,
+			Enabled:     true,
+		},
 		{
 			ID:          "prod-incomplete",
 			Name:        "No Incomplete in Prod",
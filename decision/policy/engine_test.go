@@ -0,0 +1,44 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestEvaluateCustomPoliciesConcurrently(t *testing.T) {
+	// Spare capacity in the engine's policies is what an in-place append would reuse
+	policies := make([]Policy, 1, 8)
+	policies[0] = Policy{ID: "cost-limit", Name: "Cost limit", Type: PolicyTypeCostLimit, Severity: SeverityError, Threshold: 1000, Enabled: true}
+	engine := &Engine{policies: policies}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for _, id := range []string{"custom-a", "custom-b"} {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			custom := []Policy{{ID: id, Name: id, Type: PolicyTypeCostLimit, Severity: SeverityWarning, Threshold: 1000, Enabled: true}}
+			for i := 0; i < 200; i++ {
+				result, err := engine.Evaluate(context.Background(), EvaluationRequest{Estimation: waiverEstimate(), CustomPolicies: custom})
+				if err != nil {
+					errs <- err
+					return
+				}
+				if len(result.Checks) != 2 || result.Checks[1].PolicyID != id {
+					errs <- fmt.Errorf("%s: checks = %+v", id, result.Checks)
+					return
+				}
+			}
+		}(id)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if len(engine.policies) != 1 {
+		t.Errorf("engine policies = %d, want 1", len(engine.policies))
+	}
+}
@@ -0,0 +1,255 @@
+// Package policy - Policy files
+// terracost.policies.yaml declares built-in policy instances with environment
+// scoping and selectors, so policies aren't limited to CLI flags.
+package policy
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+
+	"terraform-cost/decision/estimation"
+)
+
+// DefaultPolicyFile is loaded from the working directory when present
+const DefaultPolicyFile = "terracost.policies.yaml"
+
+// PolicyFile is a declarative policy file
+//
+//	version: "1"
+//	policies:
+//	  - id: prod-cost-limit
+//	    type: cost_limit
+//	    threshold: 5000
+//	    environments: [prod]
+//	  - id: payments-budget
+//	    type: cost_limit
+//	    threshold: 1200
+//	    selector:
+//	      tags: {team: payments}
+//	  - id: team-budget
+//	    type: tag_budget
+//	    tag_key: team
+//	    threshold: 2000
+//	  - id: prod-pricing-confidence
+//	    type: confidence_threshold
+//	    dimension: pricing
+//	    threshold: 90
+//	    environments: [prod]
+//	  - id: budget-exceeded
+//	    type: budget_exceeded
+//	    severity: warning
+//	  - id: cost-anomaly
+//	    type: cost_anomaly
+//	    threshold: 3
+//	    window: 30
+type PolicyFile struct {
+	Version  string       `yaml:"version"`
+	Policies []policySpec `yaml:"policies"`
+}
+
+// policySpec is a policy as written in a file; enabled defaults to true
+type policySpec struct {
+	ID           string     `yaml:"id"`
+	Name         string     `yaml:"name"`
+	Description  string     `yaml:"description"`
+	Type         PolicyType `yaml:"type"`
+	Severity     Severity   `yaml:"severity"`
+	Threshold    float64    `yaml:"threshold"`
+	Enabled      *bool      `yaml:"enabled"`
+	Environments []string   `yaml:"environments"`
+	Selector     *Selector  `yaml:"selector"`
+	TagKey       string     `yaml:"tag_key"`
+	Dimension    string     `yaml:"dimension"`
+	Window       int        `yaml:"window"`
+}
+
+// Selector limits a policy to matching cost drivers; all set fields must match
+type Selector struct {
+	Tags      map[string]string `yaml:"tags,omitempty" json:"tags,omitempty"`           // resource tags, exact values ("*" matches any value)
+	Services  []string          `yaml:"services,omitempty" json:"services,omitempty"`   // e.g. AmazonEC2
+	Regions   []string          `yaml:"regions,omitempty" json:"regions,omitempty"`     // e.g. us-east-1
+	Resources []string          `yaml:"resources,omitempty" json:"resources,omitempty"` // address prefixes, e.g. module.db
+}
+
+// LoadPolicyFile reads policies from a YAML policy file
+func LoadPolicyFile(path string) ([]Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+	policies, err := ParsePolicyFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return policies, nil
+}
+
+// ParsePolicyFile parses and validates policy file content
+func ParsePolicyFile(data []byte) ([]Policy, error) {
+	var f PolicyFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse policy file: %w", err)
+	}
+
+	policies := make([]Policy, 0, len(f.Policies))
+	seen := make(map[string]bool)
+	for i, spec := range f.Policies {
+		if spec.ID == "" {
+			return nil, fmt.Errorf("policy %d: id is required", i+1)
+		}
+		if seen[spec.ID] {
+			return nil, fmt.Errorf("policy %s: duplicate id", spec.ID)
+		}
+		seen[spec.ID] = true
+
+		switch spec.Type {
+		case PolicyTypeCostLimit, PolicyTypeCostGrowth, PolicyTypeCarbonBudget, PolicyTypeConfidenceThreshold:
+			if spec.Threshold <= 0 {
+				return nil, fmt.Errorf("policy %s: %s requires a positive threshold", spec.ID, spec.Type)
+			}
+		case PolicyTypeTagBudget:
+			if spec.Threshold <= 0 || spec.TagKey == "" {
+				return nil, fmt.Errorf("policy %s: tag_budget requires tag_key and a positive threshold", spec.ID)
+			}
+		case PolicyTypeBudgetExceeded:
+			if spec.Threshold < 0 {
+				return nil, fmt.Errorf("policy %s: budget_exceeded threshold is a percent of the budget and cannot be negative", spec.ID)
+			}
+		case PolicyTypeCostAnomaly:
+			if spec.Threshold < 0 || spec.Window < 0 {
+				return nil, fmt.Errorf("policy %s: cost_anomaly threshold (σ) and window cannot be negative", spec.ID)
+			}
+		case PolicyTypeIncompleteEstimate:
+		default:
+			return nil, fmt.Errorf("policy %s: unsupported type %q", spec.ID, spec.Type)
+		}
+
+		if spec.Dimension != "" {
+			if _, ok := (estimation.ConfidenceScores{}).Dimension(spec.Dimension, 0); !ok || spec.Type != PolicyTypeConfidenceThreshold {
+				return nil, fmt.Errorf("policy %s: dimension must be overall, pricing, usage or coverage on a confidence_threshold policy", spec.ID)
+			}
+		}
+
+		if spec.Window != 0 && spec.Type != PolicyTypeCostAnomaly {
+			return nil, fmt.Errorf("policy %s: window applies to cost_anomaly policies only", spec.ID)
+		}
+
+		if (spec.Type == PolicyTypeCostGrowth || spec.Type == PolicyTypeBudgetExceeded || spec.Type == PolicyTypeCostAnomaly) && spec.Selector != nil {
+			return nil, fmt.Errorf("policy %s: %s compares project totals and cannot use a selector", spec.ID, spec.Type)
+		}
+
+		switch spec.Severity {
+		case "":
+			spec.Severity = SeverityError
+		case SeverityError, SeverityWarning, SeverityInfo:
+		default:
+			return nil, fmt.Errorf("policy %s: invalid severity %q", spec.ID, spec.Severity)
+		}
+
+		name := spec.Name
+		if name == "" {
+			name = spec.ID
+		}
+		policies = append(policies, Policy{
+			ID:           spec.ID,
+			Name:         name,
+			Description:  spec.Description,
+			Type:         spec.Type,
+			Severity:     spec.Severity,
+			Threshold:    spec.Threshold,
+			Enabled:      spec.Enabled == nil || *spec.Enabled,
+			Environments: spec.Environments,
+			Selector:     spec.Selector,
+			TagKey:       spec.TagKey,
+			Dimension:    spec.Dimension,
+			Window:       spec.Window,
+		})
+	}
+	return policies, nil
+}
+
+// appliesTo reports whether a policy is scoped to an environment
+func (p Policy) appliesTo(env string) bool {
+	if len(p.Environments) == 0 {
+		return true
+	}
+	for _, e := range p.Environments {
+		if strings.EqualFold(e, env) {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches reports whether a cost driver is selected
+func (s *Selector) Matches(d estimation.CostDriver) bool {
+	for k, v := range s.Tags {
+		got, ok := d.ResourceTags[k]
+		if !ok || (v != "*" && got != v) {
+			return false
+		}
+	}
+	if len(s.Services) > 0 && !containsFold(s.Services, d.Service) {
+		return false
+	}
+	if len(s.Regions) > 0 && !containsFold(s.Regions, d.Region) {
+		return false
+	}
+	if len(s.Resources) > 0 {
+		matched := false
+		for _, prefix := range s.Resources {
+			if d.ResourceAddr == prefix || strings.HasPrefix(d.ResourceAddr, prefix+".") ||
+				strings.HasPrefix(d.ResourceAddr, prefix+"[") {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// scopeEstimation returns the totals of only the drivers a selector matches
+func scopeEstimation(est *estimation.EstimationResult, s *Selector) *estimation.EstimationResult {
+	return filterEstimation(est, s.Matches)
+}
+
+// filterEstimation returns the totals of only the drivers to keep
+func filterEstimation(est *estimation.EstimationResult, keep func(estimation.CostDriver) bool) *estimation.EstimationResult {
+	scoped := &estimation.EstimationResult{
+		MonthlyCostP50: decimal.Zero,
+		MonthlyCostP90: decimal.Zero,
+	}
+	for _, d := range est.CostDrivers {
+		if !keep(d) {
+			continue
+		}
+		scoped.CostDrivers = append(scoped.CostDrivers, d)
+		scoped.MonthlyCostP50 = scoped.MonthlyCostP50.Add(d.MonthlyCostP50)
+		scoped.MonthlyCostP90 = scoped.MonthlyCostP90.Add(d.MonthlyCostP90)
+		scoped.CarbonKgCO2 += d.CarbonKgCO2
+		if d.IsSymbolic {
+			scoped.ComponentsSymbolic++
+			scoped.IsIncomplete = true
+		} else {
+			scoped.ComponentsEstimated++
+		}
+	}
+	scoped.Confidence, scoped.ConfidenceScores = estimation.ScoreConfidence(scoped.CostDrivers, 0)
+	return scoped
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package policy
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/decision/estimation"
+)
+
+const testPolicyFile = `
+version: "1"
+policies:
+  - id: prod-cost-limit
+    type: cost_limit
+    threshold: 250
+    environments: [prod]
+  - id: payments-budget
+    name: Payments budget
+    type: cost_limit
+    threshold: 100
+    selector:
+      tags: {team: payments}
+  - id: default-confidence
+    type: confidence_threshold
+    threshold: 70
+    enabled: false
+`
+
+func TestParsePolicyFile(t *testing.T) {
+	policies, err := ParsePolicyFile([]byte(testPolicyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(policies) != 3 {
+		t.Fatalf("got %d policies, want 3", len(policies))
+	}
+	if p := policies[0]; p.Severity != SeverityError || !p.Enabled || p.Name != "prod-cost-limit" {
+		t.Errorf("defaults not applied: %+v", p)
+	}
+	if policies[2].Enabled {
+		t.Error("enabled: false was ignored")
+	}
+
+	invalid := []string{
+		`policies: [{type: cost_limit, threshold: 1}]`,
+		`policies: [{id: a, type: nope}]`,
+		`policies: [{id: a, type: cost_limit}]`,
+		`policies: [{id: a, type: cost_growth, threshold: 10, selector: {services: [AmazonEC2]}}]`,
+		`policies: [{id: a, type: confidence_threshold, threshold: 80, dimension: vibes}]`,
+		`policies: [{id: a, type: cost_limit, threshold: 80, dimension: pricing}]`,
+	}
+	for _, data := range invalid {
+		if _, err := ParsePolicyFile([]byte(data)); err == nil {
+			t.Errorf("expected error for %s", data)
+		}
+	}
+}
+
+func TestPolicyScoping(t *testing.T) {
+	policies, err := ParsePolicyFile([]byte(testPolicyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	est := &estimation.EstimationResult{
+		MonthlyCostP50: decimal.NewFromInt(300),
+		MonthlyCostP90: decimal.NewFromInt(300),
+		Confidence:     0.5,
+		CostDrivers: []estimation.CostDriver{
+			{ResourceAddr: "aws_instance.pay", ResourceTags: map[string]string{"team": "payments"},
+				MonthlyCostP50: decimal.NewFromInt(150), MonthlyCostP90: decimal.NewFromInt(150), Confidence: 1},
+			{ResourceAddr: "aws_instance.web", ResourceTags: map[string]string{"team": "web"},
+				MonthlyCostP50: decimal.NewFromInt(150), MonthlyCostP90: decimal.NewFromInt(150), Confidence: 0.5},
+		},
+	}
+
+	tests := []struct {
+		env  string
+		want []string
+	}{
+		{"dev", []string{"payments-budget"}},
+		{"prod", []string{"prod-cost-limit", "payments-budget"}},
+	}
+	for _, tt := range tests {
+		engine := NewEngine()
+		engine.LoadPolicies(policies)
+		result, err := engine.Evaluate(context.Background(), EvaluationRequest{Estimation: est, Environment: tt.env})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, v := range result.Violations {
+			got = append(got, v.PolicyID)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: violations = %v, want %v", tt.env, got, tt.want)
+		}
+		// The default confidence policy was disabled by the file
+		if len(result.Warnings) != 0 {
+			t.Errorf("%s: unexpected warnings %v", tt.env, result.Warnings)
+		}
+	}
+}
+
+func TestTagBudgetPolicy(t *testing.T) {
+	policies, err := ParsePolicyFile([]byte(`
+policies:
+  - id: team-budget
+    type: tag_budget
+    tag_key: team
+    threshold: 100
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	engine := &Engine{}
+	engine.LoadPolicies(policies)
+	result, err := engine.Evaluate(context.Background(), EvaluationRequest{Estimation: &estimation.EstimationResult{
+		CostDrivers: []estimation.CostDriver{
+			{ResourceTags: map[string]string{"team": "payments"}, MonthlyCostP50: decimal.NewFromInt(150)},
+			{ResourceTags: map[string]string{"team": "web"}, MonthlyCostP50: decimal.NewFromInt(50)},
+			{MonthlyCostP50: decimal.NewFromInt(500)}, // untagged cost is not a team's budget
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Violations) != 1 || !strings.Contains(result.Violations[0].Message, "team=payments ($150.00)") ||
+		strings.Contains(result.Violations[0].Message, "web") {
+		t.Errorf("violations = %+v", result.Violations)
+	}
+}
+
+func TestConfidenceDimensionPolicy(t *testing.T) {
+	policies, err := ParsePolicyFile([]byte(`
+policies:
+  - id: pricing-confidence
+    type: confidence_threshold
+    dimension: pricing
+    threshold: 90
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := &Engine{policies: policies}
+
+	// Overall confidence is fine, but the prices themselves are uncertain
+	result, err := engine.Evaluate(context.Background(), EvaluationRequest{
+		Estimation: &estimation.EstimationResult{
+			Confidence:       0.95,
+			ConfidenceScores: estimation.ConfidenceScores{Pricing: 0.6, Usage: 1, Coverage: 1},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Violations) != 1 || !strings.Contains(result.Violations[0].Message, "pricing confidence (60%)") {
+		t.Errorf("violations = %+v", result.Violations)
+	}
+}
@@ -0,0 +1,259 @@
+// Package policy - OPA integration
+// Queries a Rego package's deny/warn sets through the OPA Data API
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel/attribute"
+
+	"terraform-cost/decision/estimation"
+	"terraform-cost/internal/httpclient"
+	"terraform-cost/telemetry"
+)
+
+// DefaultOPAPackage is the Rego package shipped in decision/policy/opa
+const DefaultOPAPackage = "terracost.policy"
+
+// OPAFailureMode controls the decision when OPA can't be evaluated
+type OPAFailureMode string
+
+const (
+	// OPAFailOpen records a warning and lets built-in policies decide
+	OPAFailOpen OPAFailureMode = "open"
+	// OPAFailClosed denies the estimate
+	OPAFailClosed OPAFailureMode = "closed"
+)
+
+// ParseOPAFailureMode parses "open" or "closed"
+func ParseOPAFailureMode(s string) (OPAFailureMode, error) {
+	switch OPAFailureMode(strings.ToLower(s)) {
+	case "", OPAFailOpen:
+		return OPAFailOpen, nil
+	case OPAFailClosed:
+		return OPAFailClosed, nil
+	}
+	return "", fmt.Errorf("invalid OPA failure mode %q (open, closed)", s)
+}
+
+// opaPolicyID identifies violations and warnings produced by OPA
+const opaPolicyID = "opa"
+
+// opaResponse is the OPA Data API response for a package
+type opaResponse struct {
+	Result *struct {
+		Deny []json.RawMessage `json:"deny"`
+		Warn []json.RawMessage `json:"warn"`
+	} `json:"result"`
+}
+
+// opaMessage is a structured deny/warn entry; rules may also return plain strings
+type opaMessage struct {
+	Msg      string `json:"msg"`
+	PolicyID string `json:"policy_id"`
+	Severity string `json:"severity"`
+}
+
+// newOPAClient creates the client querying OPA; decisions are read-only, so
+// failed queries are retried, and an unreachable OPA fails fast once its
+// circuit opens instead of holding every estimate for the timeout
+func newOPAClient() *http.Client {
+	config := httpclient.DefaultConfig()
+	config.RetryPOST = true
+	return httpclient.New(config)
+}
+
+// evaluateOPA POSTs the estimation to OPA and maps deny/warn into violations and warnings
+func (e *Engine) evaluateOPA(ctx context.Context, req EvaluationRequest) (violations []Violation, warnings []Warning, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "policy.opa", attribute.String("opa.package", e.opaPackage))
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	body, err := json.Marshal(map[string]interface{}{"input": opaInput(req)})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode OPA input: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/v1/data/%s", e.opaEndpoint, strings.ReplaceAll(e.opaPackage, ".", "/"))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	telemetry.InjectHTTP(ctx, httpReq)
+
+	resp, err := e.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, nil, fmt.Errorf("OPA request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, nil, fmt.Errorf("OPA returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var out opaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode OPA response: %w", err)
+	}
+	if out.Result == nil {
+		return nil, nil, fmt.Errorf("OPA package %s is undefined", e.opaPackage)
+	}
+
+	violations = make([]Violation, 0, len(out.Result.Deny))
+	for _, raw := range out.Result.Deny {
+		m, err := parseOPAMessage(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		severity := m.Severity
+		if severity == "" {
+			severity = string(SeverityError)
+		}
+		violations = append(violations, Violation{
+			PolicyID:   m.PolicyID,
+			PolicyName: "OPA",
+			Message:    m.Msg,
+			Severity:   severity,
+		})
+	}
+
+	warnings = make([]Warning, 0, len(out.Result.Warn))
+	for _, raw := range out.Result.Warn {
+		m, err := parseOPAMessage(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		warnings = append(warnings, Warning{PolicyID: m.PolicyID, Message: m.Msg})
+	}
+	return violations, warnings, nil
+}
+
+// opaFailure converts an OPA error into a warning or violation per the failure mode
+func (e *Engine) opaFailure(err error) ([]Violation, []Warning) {
+	if e.opaFailureMode == OPAFailClosed {
+		return []Violation{{
+			PolicyID:   opaPolicyID,
+			PolicyName: "OPA",
+			Message:    fmt.Sprintf("OPA evaluation failed (fail-closed): %v", err),
+			Severity:   string(SeverityError),
+		}}, nil
+	}
+	return nil, []Warning{{
+		PolicyID: opaPolicyID,
+		Message:  fmt.Sprintf("OPA evaluation failed (fail-open): %v", err),
+	}}
+}
+
+// opaCheck summarizes OPA results as a single check
+func opaCheck(violations []Violation, warnings []Warning) Check {
+	check := Check{PolicyID: opaPolicyID, PolicyName: "OPA", Decision: DecisionPass}
+	messages := make([]string, 0, len(violations)+len(warnings))
+	for _, v := range violations {
+		messages = append(messages, v.Message)
+		if v.Severity == string(SeverityError) {
+			check.Decision = DecisionDeny
+		} else if check.Decision != DecisionDeny {
+			check.Decision = DecisionWarn
+		}
+	}
+	for _, w := range warnings {
+		messages = append(messages, w.Message)
+		if check.Decision == DecisionPass {
+			check.Decision = DecisionWarn
+		}
+	}
+	check.Message = strings.Join(messages, "; ")
+	return check
+}
+
+// parseOPAMessage accepts a string or {msg, policy_id, severity} object
+func parseOPAMessage(raw json.RawMessage) (opaMessage, error) {
+	var m opaMessage
+	if err := json.Unmarshal(raw, &m.Msg); err == nil {
+		m.PolicyID = opaPolicyID
+		return m, nil
+	}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return m, fmt.Errorf("unexpected OPA rule result %s: %w", string(raw), err)
+	}
+	if m.PolicyID == "" {
+		m.PolicyID = opaPolicyID
+	}
+	return m, nil
+}
+
+// opaInput builds the input document evaluated by Rego policies
+func opaInput(req EvaluationRequest) map[string]interface{} {
+	est := req.Estimation
+
+	byService := make(map[string]decimal.Decimal)
+	regionSet := make(map[string]bool)
+	for _, d := range est.CostDrivers {
+		byService[d.Service] = byService[d.Service].Add(d.MonthlyCostP50)
+		if d.Region != "" {
+			regionSet[d.Region] = true
+		}
+	}
+	costsByService := make(map[string]float64, len(byService))
+	for svc, cost := range byService {
+		costsByService[svc] = cost.InexactFloat64()
+	}
+	regions := make([]string, 0, len(regionSet))
+	for r := range regionSet {
+		regions = append(regions, r)
+	}
+	sort.Strings(regions)
+
+	input := map[string]interface{}{
+		"monthly_cost_p50": est.MonthlyCostP50.InexactFloat64(),
+		"monthly_cost_p90": est.MonthlyCostP90.InexactFloat64(),
+		"carbon_kg_co2":    est.CarbonKgCO2,
+		"confidence":       est.Confidence,
+		"confidence_scores": map[string]float64{
+			"pricing":  est.ConfidenceScores.Pricing,
+			"usage":    est.ConfidenceScores.Usage,
+			"coverage": est.ConfidenceScores.Coverage,
+		},
+		"is_incomplete":    est.IsIncomplete,
+		"symbolic_count":   est.ComponentsSymbolic,
+		"regions":          regions,
+		"costs_by_service": costsByService,
+		"environment":      req.Environment,
+	}
+	if len(est.CostByTag) > 0 {
+		costByTag := make(map[string]map[string]float64, len(est.CostByTag))
+		for key, byValue := range est.CostByTag {
+			costByTag[key] = make(map[string]float64, len(byValue))
+			for value, cost := range byValue {
+				costByTag[key][value] = cost.InexactFloat64()
+			}
+		}
+		input["cost_by_tag"] = costByTag
+	}
+	if len(est.CostByModule) > 0 {
+		// Flattened so rules can look modules up by path: input.cost_by_module["module.vpc"]
+		costByModule := make(map[string]float64)
+		var flatten func(modules []estimation.ModuleCost)
+		flatten = func(modules []estimation.ModuleCost) {
+			for _, m := range modules {
+				costByModule[m.Module] = m.MonthlyCostP50.InexactFloat64()
+				flatten(m.Children)
+			}
+		}
+		flatten(est.CostByModule)
+		input["cost_by_module"] = costByModule
+	}
+	if req.Baseline != nil {
+		input["baseline_monthly_cost_p50"] = req.Baseline.MonthlyCostP50.InexactFloat64()
+	}
+	return input
+}
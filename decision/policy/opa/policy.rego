@@ -2,6 +2,9 @@
 #
 # This Rego package defines policies for infrastructure cost governance.
 # Policies evaluate estimation results and can deny, warn, or allow deployments.
+#
+# TerraCost queries POST /v1/data/terracost/policy and reads the deny and warn
+# sets. Entries may be strings or {"msg", "policy_id", "severity"} objects.
 
 package terracost.policy
 
@@ -18,15 +21,15 @@ default allow := true
 
 # Deny if monthly cost exceeds absolute limit
 deny contains msg if {
-    input.monthly_cost_p90 > data.limits.max_monthly_cost
-    msg := sprintf("Monthly cost P90 ($%.2f) exceeds limit ($%.2f)", [input.monthly_cost_p90, data.limits.max_monthly_cost])
+    input.monthly_cost_p90 > limits.max_monthly_cost
+    msg := sprintf("Monthly cost P90 ($%.2f) exceeds limit ($%.2f)", [input.monthly_cost_p90, limits.max_monthly_cost])
 }
 
 # Deny if carbon emissions exceed budget
 deny contains msg if {
-    data.limits.carbon_budget_kg != null
-    input.carbon_kg_co2 > data.limits.carbon_budget_kg
-    msg := sprintf("Carbon emissions (%.2f kg CO2) exceed budget (%.2f kg)", [input.carbon_kg_co2, data.limits.carbon_budget_kg])
+    limits.carbon_budget_kg != null
+    input.carbon_kg_co2 > limits.carbon_budget_kg
+    msg := sprintf("Carbon emissions (%.2f kg CO2) exceed budget (%.2f kg)", [input.carbon_kg_co2, limits.carbon_budget_kg])
 }
 
 # Deny if confidence is too low for production
@@ -38,8 +41,8 @@ deny contains msg if {
 
 # Deny if too many resources cannot be priced
 deny contains msg if {
-    input.symbolic_count > data.limits.max_symbolic_resources
-    msg := sprintf("Too many unpriced resources (%d exceeds limit of %d)", [input.symbolic_count, data.limits.max_symbolic_resources])
+    input.symbolic_count > limits.max_symbolic_resources
+    msg := sprintf("Too many unpriced resources (%d exceeds limit of %d)", [input.symbolic_count, limits.max_symbolic_resources])
 }
 
 # =============================================================================
@@ -48,19 +51,20 @@ deny contains msg if {
 
 # Warn if cost is high even if under limit
 warn contains msg if {
-    input.monthly_cost_p50 > data.thresholds.cost_review_threshold
-    msg := sprintf("Monthly cost ($%.2f) is above review threshold ($%.2f) - consider cost optimization", [input.monthly_cost_p50, data.thresholds.cost_review_threshold])
+    input.monthly_cost_p50 > thresholds.cost_review_threshold
+    msg := sprintf("Monthly cost ($%.2f) is above review threshold ($%.2f) - consider cost optimization", [input.monthly_cost_p50, thresholds.cost_review_threshold])
 }
 
 # Warn if using high-cost regions
 warn contains msg if {
     some region in input.regions
-    region in data.high_cost_regions
+    region in high_cost_regions
     msg := sprintf("Using high-cost region: %s - consider alternatives", [region])
 }
 
 # Warn if single service dominates cost
 warn contains msg if {
+    input.monthly_cost_p50 > 0
     some service, cost in input.costs_by_service
     cost / input.monthly_cost_p50 > 0.8
     msg := sprintf("Service %s accounts for >80%% of costs - review for optimization", [service])
@@ -0,0 +1,95 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/decision/estimation"
+)
+
+func TestEvaluateOPA(t *testing.T) {
+	var gotPath string
+	var gotInput map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		var body struct {
+			Input map[string]interface{} `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		gotInput = body.Input
+		w.Write([]byte(`{"result": {
+			"deny": ["too expensive", {"msg": "tagged", "policy_id": "tags", "severity": "warning"}],
+			"warn": ["review"]
+		}}`))
+	}))
+	defer srv.Close()
+
+	engine := &Engine{httpClient: srv.Client()}
+	engine.WithOPA(srv.URL + "/").WithOPAPackage(DefaultOPAPackage)
+
+	result, err := engine.Evaluate(context.Background(), EvaluationRequest{
+		Estimation: &estimation.EstimationResult{
+			MonthlyCostP50: decimal.NewFromInt(100),
+			Confidence:     1,
+			CostDrivers: []estimation.CostDriver{
+				{Service: "AmazonEC2", Region: "us-east-1", MonthlyCostP50: decimal.NewFromInt(100)},
+			},
+		},
+		Environment: "prod",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if gotPath != "/v1/data/terracost/policy" {
+		t.Errorf("path = %s", gotPath)
+	}
+	if gotInput["monthly_cost_p50"] != 100.0 || gotInput["environment"] != "prod" {
+		t.Errorf("input = %v", gotInput)
+	}
+	if result.Decision != DecisionDeny {
+		t.Errorf("decision = %s, want deny", result.Decision)
+	}
+	if len(result.Violations) != 2 || result.Violations[1].PolicyID != "tags" || result.Violations[1].Severity != "warning" {
+		t.Errorf("violations = %+v", result.Violations)
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0].Message != "review" {
+		t.Errorf("warnings = %+v", result.Warnings)
+	}
+}
+
+func TestOPAFailureMode(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		mode OPAFailureMode
+		want Decision
+	}{
+		{OPAFailOpen, DecisionWarn},
+		{OPAFailClosed, DecisionDeny},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			engine := &Engine{httpClient: srv.Client(), opaPackage: DefaultOPAPackage}
+			engine.WithOPA(srv.URL).WithOPAFailureMode(tt.mode)
+
+			result, err := engine.Evaluate(context.Background(), EvaluationRequest{
+				Estimation: &estimation.EstimationResult{Confidence: 1},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Decision != tt.want {
+				t.Errorf("decision = %s, want %s", result.Decision, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+// Package policy - Policy simulation
+// A simulation replays a policy set against saved estimations, so a platform
+// team can see how many past changes a new policy would have denied or warned
+// before enforcing it.
+package policy
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/estimation"
+)
+
+// Simulation is the outcome of replaying a policy set against saved estimations
+type Simulation struct {
+	Estimates int `json:"estimates"` // Estimations replayed
+	Skipped   int `json:"skipped"`   // Estimations whose saved result could not be decoded
+
+	Denied int `json:"denied"`
+	Warned int `json:"warned"`
+	Passed int `json:"passed"`
+
+	// Changed counts estimations whose simulated decision differs from the one
+	// recorded when they were made; NewlyDenied those now denied that were not
+	Changed     int `json:"changed"`
+	NewlyDenied int `json:"newly_denied"`
+
+	Policies    []PolicyTally       `json:"policies"`
+	Estimations []SimulatedEstimate `json:"estimations"`
+}
+
+// PolicyTally counts the estimations one policy denied or warned
+type PolicyTally struct {
+	PolicyID   string `json:"policy_id"`
+	PolicyName string `json:"policy_name"`
+	Denied     int    `json:"denied"`
+	Warned     int    `json:"warned"`
+}
+
+// SimulatedEstimate is the simulated decision on one saved estimation
+type SimulatedEstimate struct {
+	ID             uuid.UUID       `json:"id"`
+	Project        string          `json:"project"`
+	Branch         string          `json:"branch,omitempty"`
+	PullRequest    string          `json:"pull_request,omitempty"`
+	Environment    string          `json:"environment,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Currency       string          `json:"currency"`
+	MonthlyCostP50 decimal.Decimal `json:"monthly_cost_p50"`
+	Recorded       Decision        `json:"recorded,omitempty"` // Decision when the estimate was made; empty when policy was skipped
+	Decision       Decision        `json:"decision"`
+	Violations     []Violation     `json:"violations"`
+	Warnings       []Warning       `json:"warnings"`
+}
+
+// Simulate evaluates the engine's policies against saved estimations, newest first
+// Cost growth policies compare each estimation with the previous one of its
+// project among records; the oldest of each project has no baseline. Stored
+// budgets and cost anomalies judge current spend rather than a past change, so
+// the engine is expected to run without them.
+func (e *Engine) Simulate(ctx context.Context, records []*clickhouse.EstimationRecord) (*Simulation, error) {
+	sim := &Simulation{
+		Policies:    make([]PolicyTally, 0),
+		Estimations: make([]SimulatedEstimate, 0, len(records)),
+	}
+	tallies := make(map[string]*PolicyTally)
+
+	for i, rec := range records {
+		result, err := estimation.ResultFromRecord(rec)
+		if err != nil {
+			sim.Skipped++
+			continue
+		}
+		req := EvaluationRequest{
+			Estimation:  result,
+			Environment: rec.Environment,
+			Project:     rec.Project,
+		}
+		for _, prev := range records[i+1:] {
+			if prev.Project == rec.Project {
+				req.Baseline = BaselineFromRecord(prev)
+				break
+			}
+		}
+
+		eval, err := e.Evaluate(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		sim.Estimates++
+		switch eval.Decision {
+		case DecisionDeny:
+			sim.Denied++
+		case DecisionWarn:
+			sim.Warned++
+		default:
+			sim.Passed++
+		}
+		recorded := Decision(rec.PolicyResult)
+		if recorded != "" && recorded != eval.Decision {
+			sim.Changed++
+		}
+		if eval.Decision == DecisionDeny && recorded != DecisionDeny {
+			sim.NewlyDenied++
+		}
+
+		for _, check := range eval.Checks {
+			if check.Decision == DecisionPass {
+				continue
+			}
+			tally := tallies[check.PolicyID]
+			if tally == nil {
+				tally = &PolicyTally{PolicyID: check.PolicyID, PolicyName: check.PolicyName}
+				tallies[check.PolicyID] = tally
+			}
+			if check.Decision == DecisionDeny {
+				tally.Denied++
+			} else {
+				tally.Warned++
+			}
+		}
+
+		sim.Estimations = append(sim.Estimations, SimulatedEstimate{
+			ID:             rec.ID,
+			Project:        rec.Project,
+			Branch:         rec.Branch,
+			PullRequest:    rec.PullRequest,
+			Environment:    rec.Environment,
+			CreatedAt:      rec.CreatedAt,
+			Currency:       result.Currency,
+			MonthlyCostP50: rec.MonthlyCostP50,
+			Recorded:       recorded,
+			Decision:       eval.Decision,
+			Violations:     eval.Violations,
+			Warnings:       eval.Warnings,
+		})
+	}
+
+	for _, tally := range tallies {
+		sim.Policies = append(sim.Policies, *tally)
+	}
+	sort.Slice(sim.Policies, func(i, j int) bool {
+		a, b := sim.Policies[i], sim.Policies[j]
+		if a.Denied+a.Warned != b.Denied+b.Warned {
+			return a.Denied+a.Warned > b.Denied+b.Warned
+		}
+		return a.PolicyID < b.PolicyID
+	})
+	return sim, nil
+}
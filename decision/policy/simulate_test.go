@@ -0,0 +1,71 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/estimation"
+)
+
+func simulatedRecord(t *testing.T, project, cost, recorded string) *clickhouse.EstimationRecord {
+	t.Helper()
+	p50 := decimal.RequireFromString(cost)
+	data, err := json.Marshal(&estimation.EstimationResult{MonthlyCostP50: p50, MonthlyCostP90: p50, Confidence: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &clickhouse.EstimationRecord{
+		ID: uuid.New(), Project: project, MonthlyCostP50: p50, MonthlyCostP90: p50,
+		PolicyResult: recorded, ResultJSON: string(data),
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	engine := &Engine{policies: []Policy{
+		{ID: "limit", Name: "Cost Limit", Type: PolicyTypeCostLimit, Severity: SeverityError, Threshold: 1180, Enabled: true},
+		{ID: "growth", Name: "Cost Growth", Type: PolicyTypeCostGrowth, Severity: SeverityWarning, Threshold: 20, Enabled: true},
+	}}
+	// Newest first: api grew 50% on its previous estimate, web crossed the limit
+	records := []*clickhouse.EstimationRecord{
+		simulatedRecord(t, "api", "150", "pass"),
+		simulatedRecord(t, "web", "1200", "pass"),
+		simulatedRecord(t, "api", "100", "pass"),
+		simulatedRecord(t, "web", "1150", ""),
+		{ID: uuid.New(), Project: "db", ResultJSON: "not json"},
+	}
+
+	sim, err := engine.Simulate(context.Background(), records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sim.Estimates != 4 || sim.Skipped != 1 {
+		t.Errorf("estimates = %d, skipped = %d, want 4 and 1", sim.Estimates, sim.Skipped)
+	}
+	if sim.Denied != 1 || sim.Warned != 1 || sim.Passed != 2 {
+		t.Errorf("denied/warned/passed = %d/%d/%d, want 1/1/2", sim.Denied, sim.Warned, sim.Passed)
+	}
+	if sim.Changed != 2 || sim.NewlyDenied != 1 {
+		t.Errorf("changed = %d, newly denied = %d, want 2 and 1", sim.Changed, sim.NewlyDenied)
+	}
+	if got := sim.Estimations[1]; got.Project != "web" || got.Decision != DecisionDeny || len(got.Violations) != 1 {
+		t.Errorf("web = %+v, want denied by the cost limit", got)
+	}
+
+	want := []PolicyTally{
+		{PolicyID: "growth", PolicyName: "Cost Growth", Warned: 1},
+		{PolicyID: "limit", PolicyName: "Cost Limit", Denied: 1},
+	}
+	if len(sim.Policies) != len(want) {
+		t.Fatalf("policies = %+v, want %+v", sim.Policies, want)
+	}
+	for i := range want {
+		if sim.Policies[i] != want[i] {
+			t.Errorf("policies[%d] = %+v, want %+v", i, sim.Policies[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,180 @@
+// Package policy - Waivers
+// terracost.waivers.yaml grants time-boxed exceptions to policies: a waived
+// violation is reported as a warning instead. Expired waivers deny, so
+// exceptions are renewed or removed rather than forgotten.
+package policy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"terraform-cost/decision/estimation"
+)
+
+// DefaultWaiverFile is loaded from the working directory when present
+const DefaultWaiverFile = "terracost.waivers.yaml"
+
+// WaiverFile is a declarative waiver file
+//
+//	version: "1"
+//	waivers:
+//	  - id: payments-db-migration
+//	    policy_id: prod-cost-limit
+//	    projects: [payments]
+//	    environments: [prod]
+//	    resources: [module.db]
+//	    expires: 2026-12-31
+//	    justification: Old and new clusters run side by side during the migration
+//	    approved_by: finops@example.com
+type WaiverFile struct {
+	Version string       `yaml:"version"`
+	Waivers []waiverSpec `yaml:"waivers"`
+}
+
+// waiverSpec is a waiver as written in a file
+type waiverSpec struct {
+	ID            string   `yaml:"id"`
+	PolicyID      string   `yaml:"policy_id"`
+	Projects      []string `yaml:"projects"`
+	Environments  []string `yaml:"environments"`
+	Resources     []string `yaml:"resources"`
+	Expires       string   `yaml:"expires"` // YYYY-MM-DD, the last day the waiver applies
+	Justification string   `yaml:"justification"`
+	ApprovedBy    string   `yaml:"approved_by"`
+}
+
+// Waiver exempts a policy's violations within a scope until it expires
+// Empty Projects and Environments match everything. With Resources, only
+// violations those resources cause are waived: the policy must pass without them.
+type Waiver struct {
+	ID            string    `json:"id"`
+	PolicyID      string    `json:"policy_id"`
+	Projects      []string  `json:"projects,omitempty"`
+	Environments  []string  `json:"environments,omitempty"`
+	Resources     []string  `json:"resources,omitempty"` // address prefixes, as in selectors
+	Expires       time.Time `json:"expires"`             // end of the last day the waiver applies
+	Justification string    `json:"justification"`
+	ApprovedBy    string    `json:"approved_by,omitempty"`
+}
+
+// LoadWaiverFile reads waivers from a YAML waiver file
+func LoadWaiverFile(path string) ([]Waiver, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read waiver file: %w", err)
+	}
+	waivers, err := ParseWaiverFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return waivers, nil
+}
+
+// ParseWaiverFile parses and validates waiver file content
+func ParseWaiverFile(data []byte) ([]Waiver, error) {
+	var f WaiverFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse waiver file: %w", err)
+	}
+
+	waivers := make([]Waiver, 0, len(f.Waivers))
+	seen := make(map[string]bool)
+	for i, spec := range f.Waivers {
+		if spec.ID == "" {
+			return nil, fmt.Errorf("waiver %d: id is required", i+1)
+		}
+		if seen[spec.ID] {
+			return nil, fmt.Errorf("waiver %s: duplicate id", spec.ID)
+		}
+		seen[spec.ID] = true
+		if spec.PolicyID == "" {
+			return nil, fmt.Errorf("waiver %s: policy_id is required", spec.ID)
+		}
+		if strings.TrimSpace(spec.Justification) == "" {
+			return nil, fmt.Errorf("waiver %s: justification is required", spec.ID)
+		}
+		expires, err := time.Parse("2006-01-02", spec.Expires)
+		if err != nil {
+			return nil, fmt.Errorf("waiver %s: expires must be a YYYY-MM-DD date", spec.ID)
+		}
+
+		waivers = append(waivers, Waiver{
+			ID:            spec.ID,
+			PolicyID:      spec.PolicyID,
+			Projects:      spec.Projects,
+			Environments:  spec.Environments,
+			Resources:     spec.Resources,
+			Expires:       expires.AddDate(0, 0, 1),
+			Justification: spec.Justification,
+			ApprovedBy:    spec.ApprovedBy,
+		})
+	}
+	return waivers, nil
+}
+
+// Expired reports whether a waiver no longer applies at a time
+func (w Waiver) Expired(at time.Time) bool {
+	return !at.Before(w.Expires)
+}
+
+// appliesTo reports whether a waiver covers a project and environment
+func (w Waiver) appliesTo(project, env string) bool {
+	return (len(w.Projects) == 0 || containsFold(w.Projects, project)) &&
+		(len(w.Environments) == 0 || containsFold(w.Environments, env))
+}
+
+// lastDay is the last day a waiver applies, as written in the waiver file
+func (w Waiver) lastDay() string {
+	return w.Expires.AddDate(0, 0, -1).Format("2006-01-02")
+}
+
+// waive returns the waiver exempting a policy's violation, if any
+func (e *Engine) waive(ctx context.Context, p Policy, req EvaluationRequest, at time.Time) *Waiver {
+	for i := range e.waivers {
+		w := &e.waivers[i]
+		if w.PolicyID != p.ID || w.Expired(at) || !w.appliesTo(req.Project, req.Environment) {
+			continue
+		}
+		if len(w.Resources) == 0 {
+			return w
+		}
+		waived := &Selector{Resources: w.Resources}
+		without := req
+		without.Estimation = filterEstimation(req.Estimation, func(d estimation.CostDriver) bool { return !waived.Matches(d) })
+		if violation, _ := e.evaluatePolicy(ctx, p, without); violation == nil {
+			return w
+		}
+	}
+	return nil
+}
+
+// waivedWarning reports a waived violation
+func waivedWarning(v *Violation, w *Waiver) *Warning {
+	return &Warning{
+		PolicyID: v.PolicyID,
+		Message:  fmt.Sprintf("Waived by %s until %s (%s): %s", w.ID, w.lastDay(), w.Justification, v.Message),
+		WaivedBy: w.ID,
+	}
+}
+
+// expiredWaivers returns a violation for each expired waiver covering a request
+func (e *Engine) expiredWaivers(req EvaluationRequest, at time.Time) []Violation {
+	violations := make([]Violation, 0)
+	for _, w := range e.waivers {
+		if !w.Expired(at) || !w.appliesTo(req.Project, req.Environment) {
+			continue
+		}
+		violations = append(violations, Violation{
+			PolicyID:   w.PolicyID,
+			PolicyName: "Expired waiver",
+			Message:    fmt.Sprintf("Waiver %s for policy %s expired after %s; renew or remove it", w.ID, w.PolicyID, w.lastDay()),
+			Severity:   string(SeverityError),
+		})
+	}
+	return violations
+}
@@ -0,0 +1,112 @@
+package policy
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/decision/estimation"
+)
+
+// waiverEstimate costs 300/month, 200 of it in module.db
+func waiverEstimate() *estimation.EstimationResult {
+	return &estimation.EstimationResult{
+		MonthlyCostP50: decimal.NewFromInt(300),
+		MonthlyCostP90: decimal.NewFromInt(300),
+		Confidence:     1,
+		CostDrivers: []estimation.CostDriver{
+			{ResourceAddr: "module.db.aws_db_instance.main", MonthlyCostP50: decimal.NewFromInt(200), MonthlyCostP90: decimal.NewFromInt(200)},
+			{ResourceAddr: "aws_instance.web", MonthlyCostP50: decimal.NewFromInt(100), MonthlyCostP90: decimal.NewFromInt(100)},
+		},
+	}
+}
+
+func waiverEngine(waivers string) (*Engine, error) {
+	parsed, err := ParseWaiverFile([]byte(waivers))
+	if err != nil {
+		return nil, err
+	}
+	engine := &Engine{policies: []Policy{
+		{ID: "cost-limit", Name: "Cost limit", Type: PolicyTypeCostLimit, Severity: SeverityError, Threshold: 150, Enabled: true},
+	}}
+	return engine.WithWaivers(parsed), nil
+}
+
+func TestWaiverDowngradesViolation(t *testing.T) {
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format("2006-01-02")
+	tests := []struct {
+		name    string
+		waiver  string
+		project string
+		want    Decision
+	}{
+		{"whole policy", "approved_by: finops@example.com", "payments", DecisionWarn},
+		{"other project", "projects: [search]", "payments", DecisionDeny},
+		// Without module.db the estimate is 100; without the web instance it is still 200
+		{"resource scope", "resources: [module.db]", "payments", DecisionWarn},
+		{"resource not at fault", "resources: [aws_instance.web]", "payments", DecisionDeny},
+	}
+	for _, tt := range tests {
+		engine, err := waiverEngine(`
+waivers:
+  - id: db-migration
+    policy_id: cost-limit
+    ` + tt.waiver + `
+    expires: ` + tomorrow + `
+    justification: Migration overlap
+`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := engine.Evaluate(context.Background(), EvaluationRequest{Estimation: waiverEstimate(), Project: tt.project})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Decision != tt.want {
+			t.Errorf("%s: decision = %s, want %s", tt.name, result.Decision, tt.want)
+		}
+		if tt.want == DecisionWarn {
+			if len(result.Violations) != 0 || len(result.Warnings) != 1 || result.Warnings[0].WaivedBy != "db-migration" ||
+				!strings.Contains(result.Warnings[0].Message, "Migration overlap") {
+				t.Errorf("%s: violations %+v, warnings %+v", tt.name, result.Violations, result.Warnings)
+			}
+		}
+	}
+}
+
+func TestExpiredWaiverDenies(t *testing.T) {
+	engine, err := waiverEngine(`
+waivers:
+  - id: old-exception
+    policy_id: cost-limit
+    expires: 2020-01-31
+    justification: Launch week
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	est := waiverEstimate()
+	est.MonthlyCostP90 = decimal.NewFromInt(10)
+	result, err := engine.Evaluate(context.Background(), EvaluationRequest{Estimation: est})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Decision != DecisionDeny || len(result.Violations) != 1 || !strings.Contains(result.Violations[0].Message, "expired after 2020-01-31") {
+		t.Errorf("decision %s, violations %+v", result.Decision, result.Violations)
+	}
+}
+
+func TestParseWaiverFileRequiresJustification(t *testing.T) {
+	_, err := ParseWaiverFile([]byte(`
+waivers:
+  - id: no-reason
+    policy_id: cost-limit
+    expires: 2030-01-01
+`))
+	if err == nil || !strings.Contains(err.Error(), "justification") {
+		t.Errorf("err = %v", err)
+	}
+}
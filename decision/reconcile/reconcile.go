@@ -0,0 +1,270 @@
+// Package reconcile compares saved estimations with billed cost
+// Estimate cost drivers are matched to Cost and Usage Report actuals by resource
+// ID (ARN or ID) or by a shared tag value; the error per service shows where
+// the usage predictor over- or under-estimates.
+package reconcile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/estimation"
+)
+
+// hoursPerMonth is the monthly convention estimates are expressed in
+const hoursPerMonth = billing.HoursPerMonth
+
+// Options tune how estimates are matched to actuals
+type Options struct {
+	MatchTags []string // Tag keys naming a resource in both the plan and the CUR, e.g. Name
+}
+
+// Report is the accuracy of an estimation against billed cost
+type Report struct {
+	Currency        string            `json:"currency"`
+	From            time.Time         `json:"from"`
+	To              time.Time         `json:"to"`
+	Estimated       decimal.Decimal   `json:"estimated_monthly"`  // Matched resources only
+	Actual          decimal.Decimal   `json:"actual_monthly"`     // Matched resources only
+	ErrorPercent    float64           `json:"error_percent"`      // (estimated - actual) / actual
+	MeanAbsErrorPct float64           `json:"mean_abs_error_pct"` // Across matched resources
+	Services        []ServiceAccuracy `json:"services"`
+	Resources       []ResourceMatch   `json:"resources"`
+	Unmatched       []string          `json:"unmatched_resources,omitempty"` // Estimated resources without billed cost
+	UnmatchedActual decimal.Decimal   `json:"unmatched_actual_monthly"`      // Billed cost no estimated resource explains
+	Warnings        []string          `json:"warnings,omitempty"`
+}
+
+// ServiceAccuracy is the estimate error of one service
+type ServiceAccuracy struct {
+	Service      string          `json:"service"`
+	Resources    int             `json:"resources"`
+	Estimated    decimal.Decimal `json:"estimated_monthly"`
+	Actual       decimal.Decimal `json:"actual_monthly"`
+	ErrorPercent float64         `json:"error_percent"`
+}
+
+// ResourceMatch is one estimated resource and its billed cost
+type ResourceMatch struct {
+	ResourceAddr string          `json:"resource_addr"`
+	MatchedBy    string          `json:"matched_by"` // id or tag:<key>
+	ResourceIDs  []string        `json:"resource_ids"`
+	Estimated    decimal.Decimal `json:"estimated_monthly"`
+	Actual       decimal.Decimal `json:"actual_monthly"`
+	ErrorPercent float64         `json:"error_percent"`
+}
+
+// estimatedResource is an estimate's cost drivers of one resource
+type estimatedResource struct {
+	addr      string
+	ids       []string
+	tags      map[string]string
+	byService map[string]decimal.Decimal
+	total     decimal.Decimal
+}
+
+// Reconcile matches an estimation's resources to actuals billed over [from, to)
+// Actual cost is scaled to the estimate's month of hoursPerMonth hours.
+func Reconcile(result *estimation.EstimationResult, actuals []clickhouse.ResourceActual, from, to time.Time, opts Options) *Report {
+	report := &Report{Currency: "USD", From: from, To: to}
+	if result.Currency != "" && result.Currency != "USD" {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("estimate is in %s but CUR costs are USD; compare with a USD estimate", result.Currency))
+	}
+	scale := decimal.NewFromFloat(hoursPerMonth / to.Sub(from).Hours())
+
+	resources := groupDrivers(result.CostDrivers)
+	used := make([]bool, len(actuals))
+	byID := make(map[string][]int)
+	for i, a := range actuals {
+		if a.ResourceID == "" {
+			continue
+		}
+		byID[a.ResourceID] = append(byID[a.ResourceID], i)
+		if short := shortID(a.ResourceID); short != a.ResourceID {
+			byID[short] = append(byID[short], i)
+		}
+	}
+
+	services := make(map[string]*ServiceAccuracy)
+	service := func(name string) *ServiceAccuracy {
+		if s, ok := services[name]; ok {
+			return s
+		}
+		s := &ServiceAccuracy{Service: name}
+		services[name] = s
+		return s
+	}
+
+	var absErrSum float64
+	for _, r := range resources {
+		matched, by := matchResource(r, actuals, byID, used, opts.MatchTags)
+		if len(matched) == 0 {
+			report.Unmatched = append(report.Unmatched, r.addr)
+			continue
+		}
+
+		m := ResourceMatch{ResourceAddr: r.addr, MatchedBy: by, Estimated: r.total}
+		seen := make(map[string]bool)
+		for _, i := range matched {
+			used[i] = true
+			monthly := actuals[i].Cost.Mul(scale)
+			m.Actual = m.Actual.Add(monthly)
+			service(actuals[i].ProductCode).Actual = service(actuals[i].ProductCode).Actual.Add(monthly)
+			if !seen[actuals[i].ResourceID] {
+				seen[actuals[i].ResourceID] = true
+				m.ResourceIDs = append(m.ResourceIDs, actuals[i].ResourceID)
+			}
+		}
+		for name, cost := range r.byService {
+			s := service(name)
+			s.Estimated = s.Estimated.Add(cost)
+			s.Resources++
+		}
+		m.ErrorPercent = errorPercent(m.Estimated, m.Actual)
+		absErrSum += abs(m.ErrorPercent)
+		report.Estimated = report.Estimated.Add(m.Estimated)
+		report.Actual = report.Actual.Add(m.Actual)
+		report.Resources = append(report.Resources, m)
+	}
+
+	for i, a := range actuals {
+		if !used[i] {
+			report.UnmatchedActual = report.UnmatchedActual.Add(a.Cost.Mul(scale))
+		}
+	}
+	for _, s := range services {
+		s.ErrorPercent = errorPercent(s.Estimated, s.Actual)
+		report.Services = append(report.Services, *s)
+	}
+	sort.Slice(report.Services, func(i, j int) bool { return report.Services[i].Service < report.Services[j].Service })
+
+	report.ErrorPercent = errorPercent(report.Estimated, report.Actual)
+	if len(report.Resources) > 0 {
+		report.MeanAbsErrorPct = absErrSum / float64(len(report.Resources))
+	}
+	return report
+}
+
+// groupDrivers sums cost drivers per resource, in address order
+func groupDrivers(drivers []estimation.CostDriver) []*estimatedResource {
+	byAddr := make(map[string]*estimatedResource)
+	for _, d := range drivers {
+		r, ok := byAddr[d.ResourceAddr]
+		if !ok {
+			r = &estimatedResource{addr: d.ResourceAddr, tags: d.ResourceTags, byService: make(map[string]decimal.Decimal)}
+			byAddr[d.ResourceAddr] = r
+		}
+		if d.ResourceID != "" && !contains(r.ids, d.ResourceID) {
+			r.ids = append(r.ids, d.ResourceID)
+		}
+		r.byService[d.Service] = r.byService[d.Service].Add(d.MonthlyCostP50)
+		r.total = r.total.Add(d.MonthlyCostP50)
+	}
+
+	resources := make([]*estimatedResource, 0, len(byAddr))
+	for _, r := range byAddr {
+		resources = append(resources, r)
+	}
+	sort.Slice(resources, func(i, j int) bool { return resources[i].addr < resources[j].addr })
+	return resources
+}
+
+// matchResource finds the unused actuals of a resource, by ID first, then by match tag
+func matchResource(r *estimatedResource, actuals []clickhouse.ResourceActual, byID map[string][]int, used []bool, matchTags []string) ([]int, string) {
+	var matched []int
+	for _, id := range r.ids {
+		for _, key := range []string{id, shortID(id)} {
+			for _, i := range byID[key] {
+				if !used[i] && !containsInt(matched, i) {
+					matched = append(matched, i)
+				}
+			}
+		}
+	}
+	if len(matched) > 0 {
+		return matched, "id"
+	}
+
+	for _, key := range matchTags {
+		value := tagValue(r.tags, key)
+		if value == "" {
+			continue
+		}
+		for i, a := range actuals {
+			if !used[i] && tagValue(a.Tags, key) == value {
+				matched = append(matched, i)
+			}
+		}
+		if len(matched) > 0 {
+			return matched, "tag:" + key
+		}
+	}
+	return nil, ""
+}
+
+// shortID is the trailing ID of an ARN (arn:aws:ec2:...:instance/i-123 → i-123)
+func shortID(id string) string {
+	if !strings.HasPrefix(id, "arn:") {
+		return id
+	}
+	if i := strings.LastIndexAny(id, "/:"); i >= 0 {
+		return id[i+1:]
+	}
+	return id
+}
+
+// tagValue looks a tag up case-insensitively; CUR 2.0 lowercases tag keys
+func tagValue(tags map[string]string, key string) string {
+	if v, ok := tags[key]; ok {
+		return v
+	}
+	for k, v := range tags {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
+
+// errorPercent is the estimate's error relative to the actual cost
+func errorPercent(estimated, actual decimal.Decimal) float64 {
+	if actual.IsZero() {
+		if estimated.IsZero() {
+			return 0
+		}
+		return 100
+	}
+	pct, _ := estimated.Sub(actual).Div(actual).Mul(decimal.NewFromInt(100)).Float64()
+	return pct
+}
+
+func abs(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
+
+func contains(values []string, v string) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(values []int, v int) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
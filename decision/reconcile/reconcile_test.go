@@ -0,0 +1,55 @@
+package reconcile
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/estimation"
+)
+
+func TestReconcile(t *testing.T) {
+	result := &estimation.EstimationResult{
+		Currency: "USD",
+		CostDrivers: []estimation.CostDriver{
+			{ResourceAddr: "aws_instance.web", Service: "AmazonEC2", MonthlyCostP50: decimal.NewFromInt(100),
+				ResourceID: "arn:aws:ec2:us-east-1:123:instance/i-web"},
+			{ResourceAddr: "aws_s3_bucket.logs", Service: "AmazonS3", MonthlyCostP50: decimal.NewFromInt(10),
+				ResourceTags: map[string]string{"Name": "logs"}},
+			{ResourceAddr: "aws_nat_gateway.main", Service: "AmazonVPC", MonthlyCostP50: decimal.NewFromInt(40)},
+		},
+	}
+	// Actuals cover two 730-hour months and are halved to monthly cost
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(2 * hoursPerMonth * time.Hour)
+	actuals := []clickhouse.ResourceActual{
+		{ResourceID: "i-web", ProductCode: "AmazonEC2", Cost: decimal.NewFromInt(160)},
+		{ResourceID: "logs-bucket", ProductCode: "AmazonS3", Tags: map[string]string{"name": "logs"}, Cost: decimal.NewFromInt(40)},
+		{ResourceID: "i-other", ProductCode: "AmazonEC2", Cost: decimal.NewFromInt(60)},
+	}
+
+	report := Reconcile(result, actuals, from, to, Options{MatchTags: []string{"Name"}})
+
+	if len(report.Resources) != 2 || report.Resources[0].MatchedBy != "id" || report.Resources[1].MatchedBy != "tag:Name" {
+		t.Fatalf("unexpected matches: %+v", report.Resources)
+	}
+	// EC2: estimated 100 against 80/month billed, +25%
+	if web := report.Resources[0]; !web.Actual.Equal(decimal.NewFromInt(80)) || web.ErrorPercent != 25 {
+		t.Errorf("unexpected web match: %+v", web)
+	}
+	// S3: estimated 10 against 20/month billed, -50%
+	if len(report.Services) != 2 || report.Services[1].Service != "AmazonS3" || report.Services[1].ErrorPercent != -50 {
+		t.Errorf("unexpected services: %+v", report.Services)
+	}
+	if len(report.Unmatched) != 1 || report.Unmatched[0] != "aws_nat_gateway.main" {
+		t.Errorf("expected the NAT gateway unmatched, got %v", report.Unmatched)
+	}
+	if !report.UnmatchedActual.Equal(decimal.NewFromInt(30)) {
+		t.Errorf("unmatched actual = %s, want 30", report.UnmatchedActual)
+	}
+	if report.MeanAbsErrorPct != 37.5 {
+		t.Errorf("mean absolute error = %v, want 37.5", report.MeanAbsErrorPct)
+	}
+}
@@ -0,0 +1,116 @@
+// Package usage provides usage prediction for billing components
+// Usage files let users replace heuristic usage with known values per resource
+package usage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is a usage file (YAML or JSON)
+//
+//	version: "0.1"
+//	resource_usage:
+//	  aws_lambda_function.api:
+//	    monthly_requests: 5000000
+//	  aws_s3_bucket.assets:
+//	    storage: {p50: 1500, p90: 2500}
+//	  aws_instance.batch:
+//	    monthly_hours: 200
+//	profiles:
+//	  preview: {utilization: 0.1}
+//	schedules:
+//	  on-call: {hours_per_day: 16, days_per_week: 7}
+type File struct {
+	Version       string                         `yaml:"version" json:"version"`
+	ResourceUsage map[string]map[string]Override `yaml:"resource_usage" json:"resource_usage"`
+	Profiles      map[string]Profile             `yaml:"profiles,omitempty" json:"profiles,omitempty"`   // Usage profiles by name, see Profile
+	Schedules     map[string]Schedule            `yaml:"schedules,omitempty" json:"schedules,omitempty"` // Named schedules for schedule tags
+}
+
+// Override replaces some or all of a component's usage profile
+// A bare number sets every field to the same value.
+type Override struct {
+	Baseline   *float64 `yaml:"baseline,omitempty" json:"baseline,omitempty"`
+	Min        *float64 `yaml:"min,omitempty" json:"min,omitempty"`
+	Max        *float64 `yaml:"max,omitempty" json:"max,omitempty"`
+	P50        *float64 `yaml:"p50,omitempty" json:"p50,omitempty"`
+	P90        *float64 `yaml:"p90,omitempty" json:"p90,omitempty"`
+	Assumption string   `yaml:"assumption,omitempty" json:"assumption,omitempty"`
+}
+
+// LoadFile reads a usage file from disk
+func LoadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage file: %w", err)
+	}
+	return ParseFile(data)
+}
+
+// ParseFile parses YAML or JSON usage file content
+func ParseFile(data []byte) (*File, error) {
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse usage file: %w", err)
+	}
+	if f.ResourceUsage == nil {
+		f.ResourceUsage = make(map[string]map[string]Override)
+	}
+	for name, p := range f.Profiles {
+		p.Name = name
+		if err := p.Validate(); err != nil {
+			return nil, fmt.Errorf("failed to parse usage file: %w", err)
+		}
+		f.Profiles[name] = p
+	}
+	for name, sched := range f.Schedules {
+		if err := sched.validate(); err != nil {
+			return nil, fmt.Errorf("failed to parse usage file: schedule %s: %w", name, err)
+		}
+	}
+	return &f, nil
+}
+
+// ProfileList returns the file's profiles sorted by name
+func (f *File) ProfileList() []Profile {
+	profiles := make([]Profile, 0, len(f.Profiles))
+	for _, p := range f.Profiles {
+		profiles = append(profiles, p)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	return profiles
+}
+
+// UnmarshalYAML accepts either a number or a mapping
+func (o *Override) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		var v float64
+		if err := node.Decode(&v); err != nil {
+			return fmt.Errorf("line %d: usage value must be a number: %w", node.Line, err)
+		}
+		*o = scalarOverride(v)
+		return nil
+	}
+	type plain Override
+	return node.Decode((*plain)(o))
+}
+
+// UnmarshalJSON accepts either a number or an object
+func (o *Override) UnmarshalJSON(data []byte) error {
+	var v float64
+	if err := json.Unmarshal(data, &v); err == nil {
+		*o = scalarOverride(v)
+		return nil
+	}
+	type plain Override
+	return json.Unmarshal(data, (*plain)(o))
+}
+
+func scalarOverride(v float64) Override {
+	return Override{Baseline: &v, Min: &v, Max: &v, P50: &v, P90: &v}
+}
@@ -0,0 +1,273 @@
+// Package usage - Usage predictor
+// Merges environment heuristics with user-supplied usage overrides
+package usage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/iac"
+)
+
+// HoursPerMonth is the month usage is predicted in; the estimation engine
+// rescales it to the estimate's timebase
+const HoursPerMonth = billing.HoursPerMonth
+
+// heuristicConfidence marks mapper profiles that are guesses rather than provisioned capacity
+const heuristicConfidence = 0.6
+
+// overrideConfidence is the floor applied to components with user-supplied usage
+const overrideConfidence = 0.9
+
+// keyAliases maps infracost-style usage keys to component ID suffixes
+var keyAliases = map[string][]string{
+	"monthly_requests":          {"invocations", "ondemand", "requests"},
+	"storage_gb":                {"storage"},
+	"monthly_data_processed_gb": {"data"},
+	"monthly_egress_gb":         {"egress"},
+	"monthly_deliveries":        {"deliveries-http", "deliveries-email", "deliveries-sms", "deliveries-mobile", "deliveries-firehose"},
+	"monthly_custom_events":     {"events"},
+	"monthly_put_payload_units": {"put-payload-units"},
+	"monthly_data_in_gb":        {"data-in"},
+	"monthly_data_out_gb":       {"data-out"},
+}
+
+// Predictor adjusts component variance profiles before estimation
+type Predictor struct {
+	environment string
+	file        *File
+	profiles    profileRegistry
+	schedules   map[string]Schedule         // Named schedules for schedule tags
+	detected    map[string]ResourceSchedule // Schedules found in the plan, by resource address
+}
+
+// NewPredictor creates a predictor for an environment: dev, staging, prod or a registered profile
+func NewPredictor(environment string) *Predictor {
+	schedules := make(map[string]Schedule, len(DefaultSchedules))
+	for name, s := range DefaultSchedules {
+		schedules[name] = s
+	}
+	return &Predictor{environment: environment, profiles: newProfileRegistry(), schedules: schedules}
+}
+
+// WithUsageFile adds user-supplied usage overrides and registers the file's profiles
+func (p *Predictor) WithUsageFile(f *File) *Predictor {
+	p.file = f
+	// Profiles were validated when the file was parsed
+	for _, profile := range f.ProfileList() {
+		p.profiles.register(profile)
+	}
+	for name, s := range f.Schedules {
+		p.schedules[strings.ToLower(name)] = s
+	}
+	return p
+}
+
+// WithSchedules adds schedules detected in the plan, such as Auto Scaling scheduled actions
+// They take precedence over schedule tags.
+func (p *Predictor) WithSchedules(schedules map[string]ResourceSchedule) *Predictor {
+	p.detected = schedules
+	return p
+}
+
+// RegisterProfile adds or replaces a usage profile; its name and aliases become valid environments
+func (p *Predictor) RegisterProfile(profile Profile) error {
+	return p.profiles.register(profile)
+}
+
+// profile returns the environment's profile; unknown environments use production usage
+func (p *Predictor) profile() (Profile, error) {
+	if p.environment == "" {
+		return p.profiles["prod"], nil
+	}
+	if profile, ok := p.profiles[strings.ToLower(p.environment)]; ok {
+		return profile, nil
+	}
+	return p.profiles["prod"], fmt.Errorf("unknown usage profile %q, production usage assumed (known: %s)",
+		p.environment, strings.Join(p.profiles.names(), ", "))
+}
+
+// Predict returns components with usage applied, plus warnings for unmatched usage entries
+func (p *Predictor) Predict(components []billing.BillingComponent) ([]billing.BillingComponent, []string) {
+	out := make([]billing.BillingComponent, len(components))
+	copy(out, components)
+
+	overridden := make(map[string]bool)
+	warnings := make([]string, 0)
+
+	if p.file != nil {
+		byResource := make(map[string][]int)
+		for i, c := range out {
+			byResource[c.ResourceAddr] = append(byResource[c.ResourceAddr], i)
+			// A base address applies to every count/for_each instance
+			if base := iac.BaseAddress(c.ResourceAddr); base != c.ResourceAddr {
+				byResource[base] = append(byResource[base], i)
+			}
+		}
+
+		addrs := make([]string, 0, len(p.file.ResourceUsage))
+		for addr := range p.file.ResourceUsage {
+			addrs = append(addrs, addr)
+		}
+		sort.Strings(addrs)
+
+		for _, addr := range addrs {
+			indexes, ok := byResource[addr]
+			if !ok {
+				warnings = append(warnings, fmt.Sprintf("usage file: resource %s not found in plan", addr))
+				continue
+			}
+			for key, override := range p.file.ResourceUsage[addr] {
+				matched := p.applyKey(out, indexes, key, override, overridden)
+				if !matched {
+					warnings = append(warnings, fmt.Sprintf("usage file: %s has no component matching %q", addr, key))
+				}
+			}
+		}
+	}
+
+	// Environment profiles only touch usage the user didn't specify
+	profile, err := p.profile()
+	if err != nil {
+		warnings = append(warnings, err.Error())
+	}
+	unscheduled := profile
+	unscheduled.Schedule = nil
+	for i := range out {
+		if overridden[out[i].ID] {
+			continue
+		}
+		sched, ok, err := p.resourceSchedule(&out[i])
+		if err != nil && !containsWarning(warnings, err.Error()) {
+			warnings = append(warnings, err.Error())
+		}
+		if !ok {
+			profile.apply(&out[i])
+			continue
+		}
+		// A resource's own schedule replaces the environment's
+		unscheduled.apply(&out[i])
+		vp := &out[i].VarianceProfile
+		vp.Scale(sched.Hours / HoursPerMonth)
+		vp.FixedHours = true
+		vp.Assumptions = append(append([]string{}, vp.Assumptions...), sched.assumption())
+	}
+
+	sort.Strings(warnings)
+	return out, warnings
+}
+
+// resourceSchedule returns the schedule of an hourly component's resource, if any
+func (p *Predictor) resourceSchedule(c *billing.BillingComponent) (ResourceSchedule, bool, error) {
+	if c.BillingPeriod != billing.PeriodHourly && c.BillingPeriod != billing.PeriodGBHourly {
+		return ResourceSchedule{}, false, nil
+	}
+	if s, ok := p.detected[c.ResourceAddr]; ok {
+		return s, true, nil
+	}
+	return tagSchedule(c, p.schedules)
+}
+
+func containsWarning(warnings []string, w string) bool {
+	for _, existing := range warnings {
+		if existing == w {
+			return true
+		}
+	}
+	return false
+}
+
+// applyKey applies one usage entry to the matching components of a resource
+func (p *Predictor) applyKey(components []billing.BillingComponent, indexes []int, key string, o Override, overridden map[string]bool) bool {
+	// monthly_hours scales every hourly component of the resource
+	if key == "monthly_hours" {
+		hours := firstSet(o.P50, o.Baseline)
+		if hours == nil {
+			return false
+		}
+		matched := false
+		for _, i := range indexes {
+			c := &components[i]
+			if c.BillingPeriod != billing.PeriodHourly && c.BillingPeriod != billing.PeriodGBHourly {
+				continue
+			}
+			c.VarianceProfile.Scale(*hours / HoursPerMonth)
+			markOverridden(&c.VarianceProfile, fmt.Sprintf("Runs %.0f hours/month (usage file)", *hours))
+			c.VarianceProfile.FixedHours = true
+			overridden[c.ID] = true
+			matched = true
+		}
+		return matched
+	}
+
+	suffixes := []string{key}
+	if aliases, ok := keyAliases[key]; ok {
+		suffixes = aliases
+	}
+
+	matched := false
+	for _, i := range indexes {
+		c := &components[i]
+		for _, suffix := range suffixes {
+			if c.ID == c.ResourceAddr+"-"+suffix || c.ID == key {
+				applyOverride(&c.VarianceProfile, o)
+				// Hour-billed usage from the file is the hours it runs
+				c.VarianceProfile.FixedHours = c.BillingPeriod == billing.PeriodHourly || c.BillingPeriod == billing.PeriodGBHourly
+				overridden[c.ID] = true
+				matched = true
+			}
+		}
+	}
+	return matched
+}
+
+// applyOverride merges an override into a profile; unset fields follow the baseline
+func applyOverride(vp *billing.VarianceProfile, o Override) {
+	baseline := firstSet(o.Baseline, o.P50)
+	if baseline == nil {
+		baseline = &vp.BaselineUsage
+	}
+	b := *baseline
+
+	vp.BaselineUsage = b
+	vp.P50Usage = valueOr(o.P50, b)
+	vp.P90Usage = valueOr(o.P90, vp.P50Usage)
+	vp.MinUsage = valueOr(o.Min, vp.P50Usage)
+	vp.MaxUsage = valueOr(o.Max, vp.P90Usage)
+
+	assumption := "Usage from usage file"
+	if o.Assumption != "" {
+		assumption = o.Assumption
+	}
+	markOverridden(vp, assumption)
+}
+
+// markOverridden raises confidence and resets assumptions for user-supplied usage
+func markOverridden(vp *billing.VarianceProfile, assumption string) {
+	if vp.Confidence < overrideConfidence {
+		vp.Confidence = overrideConfidence
+	}
+	vp.VolatilityScore = 0
+	if vp.P90Usage > 0 && vp.P90Usage > vp.P50Usage {
+		vp.VolatilityScore = (vp.P90Usage - vp.P50Usage) / vp.P90Usage
+	}
+	vp.Assumptions = []string{assumption}
+}
+
+func firstSet(values ...*float64) *float64 {
+	for _, v := range values {
+		if v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+func valueOr(v *float64, def float64) float64 {
+	if v != nil {
+		return *v
+	}
+	return def
+}
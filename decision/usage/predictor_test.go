@@ -0,0 +1,149 @@
+// Package usage - Predictor tests
+package usage
+
+import (
+	"testing"
+
+	"terraform-cost/decision/billing"
+)
+
+const sampleUsageFile = `
+version: "0.1"
+resource_usage:
+  aws_lambda_function.api:
+    monthly_requests: 5000000
+  aws_s3_bucket.assets:
+    storage: {p50: 1500, p90: 2500}
+  aws_instance.batch:
+    monthly_hours: 200
+  aws_instance.missing:
+    monthly_hours: 10
+`
+
+func testComponents() []billing.BillingComponent {
+	return []billing.BillingComponent{
+		{
+			ID: "aws_lambda_function.api-invocations", ResourceAddr: "aws_lambda_function.api",
+			BillingPeriod:   billing.PeriodPerRequest,
+			VarianceProfile: billing.VarianceProfile{BaselineUsage: 1000000, P50Usage: 500000, P90Usage: 2000000, Confidence: 0.5},
+		},
+		{
+			ID: "aws_s3_bucket.assets-storage", ResourceAddr: "aws_s3_bucket.assets",
+			BillingPeriod:   billing.PeriodMonthly,
+			VarianceProfile: billing.VarianceProfile{BaselineUsage: 100, P50Usage: 50, P90Usage: 500, Confidence: 0.3},
+		},
+		{
+			ID: "aws_instance.batch-compute", ResourceAddr: "aws_instance.batch",
+			BillingPeriod:   billing.PeriodHourly,
+			VarianceProfile: billing.NewDefaultVarianceProfile(730),
+		},
+		{
+			ID: "aws_nat_gateway.main-data", ResourceAddr: "aws_nat_gateway.main",
+			BillingPeriod:   billing.PeriodPerGB,
+			VarianceProfile: billing.VarianceProfile{BaselineUsage: 100, P50Usage: 50, P90Usage: 500, Confidence: 0.5},
+		},
+	}
+}
+
+func TestPredictorAppliesUsageFile(t *testing.T) {
+	f, err := ParseFile([]byte(sampleUsageFile))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out, warnings := NewPredictor("prod").WithUsageFile(f).Predict(testComponents())
+
+	lambda := out[0].VarianceProfile
+	if lambda.P50Usage != 5000000 || lambda.P90Usage != 5000000 || lambda.Confidence < overrideConfidence {
+		t.Errorf("unexpected lambda profile: %+v", lambda)
+	}
+
+	s3 := out[1].VarianceProfile
+	if s3.BaselineUsage != 1500 || s3.P50Usage != 1500 || s3.P90Usage != 2500 {
+		t.Errorf("unexpected s3 profile: %+v", s3)
+	}
+
+	compute := out[2].VarianceProfile
+	if compute.P90Usage != 200 || !compute.FixedHours {
+		t.Errorf("expected monthly_hours to fix P90 at 200 hours, got %+v", compute)
+	}
+
+	if len(warnings) != 1 {
+		t.Errorf("expected one warning for missing resource, got %v", warnings)
+	}
+}
+
+func TestPredictorEnvironmentHeuristics(t *testing.T) {
+	f, _ := ParseFile([]byte(sampleUsageFile))
+	in := testComponents()
+	out, _ := NewPredictor("dev").WithUsageFile(f).Predict(in)
+
+	// NAT data is a heuristic guess and not overridden: scaled for dev
+	if got := out[3].VarianceProfile.P50Usage; got != 10 {
+		t.Errorf("expected dev NAT P50 scaled to 10, got %v", got)
+	}
+	// Overridden usage is never scaled
+	if got := out[0].VarianceProfile.P50Usage; got != 5000000 {
+		t.Errorf("expected overridden lambda usage untouched, got %v", got)
+	}
+	// Input slice is not mutated
+	if in[3].VarianceProfile.P50Usage != 50 {
+		t.Error("Predict should not mutate its input")
+	}
+}
+
+func TestParseFileJSON(t *testing.T) {
+	f, err := ParseFile([]byte(`{"resource_usage": {"aws_s3_bucket.a": {"storage": 10}}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if o := f.ResourceUsage["aws_s3_bucket.a"]["storage"]; o.P90 == nil || *o.P90 != 10 {
+		t.Errorf("expected scalar shorthand to set p90, got %+v", o)
+	}
+}
+
+func TestPredictorCustomProfiles(t *testing.T) {
+	f, err := ParseFile([]byte(`
+profiles:
+  preview:
+    aliases: [pr]
+    utilization: 0.1
+    growth: 0.5
+    schedule: {hours_per_day: 12, days_per_week: 5}
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out, warnings := NewPredictor("PR").WithUsageFile(f).Predict(testComponents())
+	if len(warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+	// Heuristic NAT data: 10% of production, P90 grown by 50%
+	if nat := out[3].VarianceProfile; nat.P50Usage != 5 || nat.P90Usage != 75 {
+		t.Errorf("unexpected NAT profile: %+v", nat)
+	}
+	// Provisioned compute only follows the schedule: 12h x 5d = 260 hours/month
+	if compute := out[2].VarianceProfile; compute.P90Usage != 260 {
+		t.Errorf("expected scheduled compute P90 of 260 hours, got %+v", compute)
+	}
+
+	if _, err := ParseFile([]byte(`profiles: {bad: {utilization: -1}}`)); err == nil {
+		t.Error("expected an error for a negative utilization")
+	}
+}
+
+func TestPredictorUnknownProfile(t *testing.T) {
+	p := NewPredictor("qa")
+	if _, warnings := p.Predict(testComponents()); len(warnings) != 1 {
+		t.Errorf("expected an unknown profile warning, got %v", warnings)
+	}
+
+	if err := p.RegisterProfile(Profile{Name: "qa", Utilization: 0.3}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, warnings := p.Predict(testComponents())
+	if len(warnings) != 0 || out[3].VarianceProfile.P50Usage != 15 {
+		t.Errorf("expected qa NAT P50 of 15 without warnings, got %v (%v)", out[3].VarianceProfile.P50Usage, warnings)
+	}
+}
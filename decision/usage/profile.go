@@ -0,0 +1,154 @@
+// Package usage - Usage profiles
+// A profile describes how an environment uses what it provisions: how much of
+// production usage it sees, how variable and growing that usage is, and the
+// hours its always-on resources actually run.
+package usage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"terraform-cost/decision/billing"
+)
+
+// Profile adjusts heuristic usage for an environment
+//
+//	profiles:
+//	  preview:
+//	    aliases: [pr]
+//	    utilization: 0.1
+//	    variance: 0.5
+//	    schedule: {hours_per_day: 10, days_per_week: 5}
+type Profile struct {
+	Name        string    `yaml:"-" json:"-"`
+	Aliases     []string  `yaml:"aliases,omitempty" json:"aliases,omitempty"`
+	Description string    `yaml:"description,omitempty" json:"description,omitempty"` // Assumption noted on scaled usage
+	Utilization float64   `yaml:"utilization,omitempty" json:"utilization,omitempty"` // Share of production usage (default 1)
+	Variance    float64   `yaml:"variance,omitempty" json:"variance,omitempty"`       // Scales the P50 to P90 spread (default 1)
+	Growth      float64   `yaml:"growth,omitempty" json:"growth,omitempty"`           // Expected usage growth added to P90, e.g. 0.3
+	Schedule    *Schedule `yaml:"schedule,omitempty" json:"schedule,omitempty"`       // Running hours of hourly resources
+}
+
+// Schedule is when an environment's hourly resources run, e.g. business hours
+type Schedule struct {
+	HoursPerDay float64 `yaml:"hours_per_day" json:"hours_per_day"`
+	DaysPerWeek float64 `yaml:"days_per_week" json:"days_per_week"`
+}
+
+// MonthlyHours is the schedule's running hours in an average month
+func (s Schedule) MonthlyHours() float64 {
+	return s.HoursPerDay * s.DaysPerWeek * 52 / 12
+}
+
+// DefaultProfiles are the built-in environment profiles
+var DefaultProfiles = []Profile{
+	{Name: "prod", Aliases: []string{"production"}, Utilization: 1},
+	{Name: "staging", Aliases: []string{"stage"}, Utilization: 0.5, Description: "Staging: ~50% of production usage assumed"},
+	{Name: "dev", Aliases: []string{"development"}, Utilization: 0.2, Description: "Development: ~20% of production usage assumed"},
+}
+
+// Validate checks a profile and fills defaults
+func (p *Profile) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("usage profile name is required")
+	}
+	if p.Utilization == 0 {
+		p.Utilization = 1
+	}
+	if p.Variance == 0 {
+		p.Variance = 1
+	}
+	switch {
+	case p.Utilization < 0:
+		return fmt.Errorf("usage profile %s: utilization must be positive", p.Name)
+	case p.Variance < 0:
+		return fmt.Errorf("usage profile %s: variance must be positive", p.Name)
+	case p.Growth < 0:
+		return fmt.Errorf("usage profile %s: growth must not be negative", p.Name)
+	}
+	if p.Schedule != nil {
+		if err := p.Schedule.validate(); err != nil {
+			return fmt.Errorf("usage profile %s: %w", p.Name, err)
+		}
+	}
+	return nil
+}
+
+// apply adjusts one component's usage; provisioned capacity only follows the schedule
+func (p Profile) apply(c *billing.BillingComponent) {
+	vp := &c.VarianceProfile
+	var notes []string
+	heuristic := vp.Confidence < heuristicConfidence
+
+	if heuristic && p.Utilization != 1 {
+		vp.Scale(p.Utilization)
+		note := p.Description
+		if note == "" {
+			note = fmt.Sprintf("%s: ~%.0f%% of production usage assumed", p.Name, p.Utilization*100)
+		}
+		notes = append(notes, note)
+	}
+	if heuristic && p.Variance != 1 {
+		vp.P90Usage = vp.P50Usage + (vp.P90Usage-vp.P50Usage)*p.Variance
+		vp.MaxUsage = vp.P50Usage + (vp.MaxUsage-vp.P50Usage)*p.Variance
+		vp.MinUsage = vp.P50Usage - (vp.P50Usage-vp.MinUsage)*p.Variance
+		notes = append(notes, fmt.Sprintf("%s: usage variance ×%.2g", p.Name, p.Variance))
+	}
+	if heuristic && p.Growth > 0 {
+		vp.P90Usage *= 1 + p.Growth
+		vp.MaxUsage *= 1 + p.Growth
+		notes = append(notes, fmt.Sprintf("%s: %.0f%% usage growth in P90", p.Name, p.Growth*100))
+	}
+	if s := p.Schedule; s != nil && (c.BillingPeriod == billing.PeriodHourly || c.BillingPeriod == billing.PeriodGBHourly) {
+		vp.Scale(s.MonthlyHours() / HoursPerMonth)
+		vp.FixedHours = true
+		notes = append(notes, fmt.Sprintf("%s: runs %.0fh/day, %.0f days/week (%.0f hours/month)",
+			p.Name, s.HoursPerDay, s.DaysPerWeek, s.MonthlyHours()))
+	}
+
+	if len(notes) > 0 {
+		vp.Assumptions = append(append([]string{}, vp.Assumptions...), notes...)
+	}
+}
+
+// profileRegistry resolves environment names to profiles
+type profileRegistry map[string]Profile
+
+func newProfileRegistry() profileRegistry {
+	r := make(profileRegistry)
+	for _, p := range DefaultProfiles {
+		r.register(p) // Built-in profiles are valid
+	}
+	return r
+}
+
+func (r profileRegistry) register(p Profile) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	r[strings.ToLower(p.Name)] = p
+	for _, alias := range p.Aliases {
+		r[strings.ToLower(alias)] = p
+	}
+	return nil
+}
+
+// names lists the registered profile names and aliases
+func (r profileRegistry) names() []string {
+	names := make([]string, 0, len(r))
+	for name := range r {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadProfiles reads the profiles of a usage file
+func LoadProfiles(path string) ([]Profile, error) {
+	f, err := LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return f.ProfileList(), nil
+}
@@ -0,0 +1,303 @@
+// Package usage - Resource schedules
+// Resources that only run part of the week are priced for the hours they run:
+// a schedule tag (schedule=office-hours, schedule=12x5) or the scheduled actions
+// of an Auto Scaling group replace the flat always-on assumption.
+package usage
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/iac"
+)
+
+// ScheduleTag is the resource tag naming a resource's schedule
+const ScheduleTag = "schedule"
+
+// DefaultSchedules are the built-in schedule names a schedule tag may use
+var DefaultSchedules = map[string]Schedule{
+	"office-hours":   {HoursPerDay: 12, DaysPerWeek: 5},
+	"business-hours": {HoursPerDay: 12, DaysPerWeek: 5},
+	"weekdays":       {HoursPerDay: 24, DaysPerWeek: 5},
+	"always-on":      {HoursPerDay: 24, DaysPerWeek: 7},
+	"24x7":           {HoursPerDay: 24, DaysPerWeek: 7},
+}
+
+// validate checks a schedule's hours and days
+func (s Schedule) validate() error {
+	if s.HoursPerDay <= 0 || s.HoursPerDay > 24 || s.DaysPerWeek <= 0 || s.DaysPerWeek > 7 {
+		return fmt.Errorf("schedule needs 0-24 hours_per_day and 0-7 days_per_week")
+	}
+	return nil
+}
+
+func (s Schedule) String() string {
+	return fmt.Sprintf("%gh/day × %g days/week", s.HoursPerDay, s.DaysPerWeek)
+}
+
+// ParseSchedule parses an hours-per-day by days-per-week value such as 12x5
+func ParseSchedule(value string) (Schedule, error) {
+	hours, days, ok := strings.Cut(strings.ToLower(strings.TrimSpace(value)), "x")
+	if !ok {
+		hours, days, ok = strings.Cut(value, "×")
+	}
+	if !ok {
+		return Schedule{}, fmt.Errorf("invalid schedule %q: expected <hours per day>x<days per week>", value)
+	}
+	var s Schedule
+	var err error
+	if s.HoursPerDay, err = strconv.ParseFloat(strings.TrimSpace(hours), 64); err != nil {
+		return Schedule{}, fmt.Errorf("invalid schedule %q: %w", value, err)
+	}
+	if s.DaysPerWeek, err = strconv.ParseFloat(strings.TrimSpace(days), 64); err != nil {
+		return Schedule{}, fmt.Errorf("invalid schedule %q: %w", value, err)
+	}
+	if err := s.validate(); err != nil {
+		return Schedule{}, fmt.Errorf("invalid schedule %q: %w", value, err)
+	}
+	return s, nil
+}
+
+// ResourceSchedule is the running time of one resource's hourly capacity
+type ResourceSchedule struct {
+	Source string  // Tag value or scheduled actions the schedule comes from
+	Hours  float64 // Full-capacity hours per month
+}
+
+// assumption describes the schedule on the usage it scales
+func (r ResourceSchedule) assumption() string {
+	return fmt.Sprintf("Runs on schedule %s (%.0f hours/month)", r.Source, r.Hours)
+}
+
+// tagSchedule resolves a component's schedule tag against the named schedules
+func tagSchedule(c *billing.BillingComponent, named map[string]Schedule) (ResourceSchedule, bool, error) {
+	var value string
+	for k, v := range c.ResourceTags {
+		if strings.EqualFold(k, ScheduleTag) {
+			value = strings.TrimSpace(v)
+			break
+		}
+	}
+	if value == "" {
+		return ResourceSchedule{}, false, nil
+	}
+
+	s, ok := named[strings.ToLower(value)]
+	if !ok {
+		var err error
+		if s, err = ParseSchedule(value); err != nil {
+			return ResourceSchedule{}, false, fmt.Errorf("%s: unknown schedule tag %q, always-on assumed", c.ResourceAddr, value)
+		}
+	}
+	return ResourceSchedule{Source: fmt.Sprintf("%s (%s)", value, s), Hours: s.MonthlyHours()}, true, nil
+}
+
+// =============================================================================
+// AUTO SCALING SCHEDULED ACTIONS
+// =============================================================================
+
+const hoursPerWeek = 7 * 24
+
+// scheduledAction is one aws_autoscaling_schedule of a group
+type scheduledAction struct {
+	address  string
+	capacity int
+	slots    []int // Hours of the week (0 = Sunday 00:00) the action fires
+}
+
+// DetectSchedules derives schedules from Auto Scaling scheduled actions
+// A group's capacity over a week follows its recurring actions; the result is
+// keyed by group address and expressed as hours at the group's desired capacity.
+func DetectSchedules(graph *iac.Graph) map[string]ResourceSchedule {
+	groups := make(map[string]*iac.GraphNode)
+	byName := make(map[string]string)
+	for addr, node := range graph.Nodes {
+		if node.Resource.Type != "aws_autoscaling_group" {
+			continue
+		}
+		groups[addr] = node
+		if name := billing.ExtractAttribute(node.Resource.Attributes, "name"); name != "" {
+			byName[name] = addr
+		}
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+
+	actions := make(map[string][]scheduledAction)
+	for addr, node := range graph.Nodes {
+		if node.Resource.Type != "aws_autoscaling_schedule" {
+			continue
+		}
+		group := scheduleGroup(node, groups, byName)
+		if group == "" {
+			continue
+		}
+		action, ok := parseScheduledAction(node)
+		if !ok {
+			continue
+		}
+		action.address = addr
+		actions[group] = append(actions[group], action)
+	}
+
+	schedules := make(map[string]ResourceSchedule)
+	for group, acts := range actions {
+		desired := billing.ExtractAttributeInt(groups[group].Resource.Attributes, "desired_capacity",
+			billing.ExtractAttributeInt(groups[group].Resource.Attributes, "min_size", 0))
+		if desired <= 0 {
+			continue
+		}
+		sort.Slice(acts, func(i, j int) bool { return acts[i].address < acts[j].address })
+		sources := make([]string, len(acts))
+		for i, a := range acts {
+			sources[i] = a.address
+		}
+		schedules[group] = ResourceSchedule{
+			Source: strings.Join(sources, ", "),
+			Hours:  weeklyCapacity(acts, desired) / float64(hoursPerWeek*desired) * HoursPerMonth,
+		}
+	}
+	return schedules
+}
+
+// scheduleGroup finds the group an action scales, by name or by dependency
+func scheduleGroup(node *iac.GraphNode, groups map[string]*iac.GraphNode, byName map[string]string) string {
+	if addr, ok := byName[billing.ExtractAttribute(node.Resource.Attributes, "autoscaling_group_name")]; ok {
+		return addr
+	}
+	for _, dep := range node.Dependencies {
+		if _, ok := groups[dep]; ok {
+			return dep
+		}
+	}
+	return ""
+}
+
+// parseScheduledAction reads an action's recurrence and target capacity
+// One-off actions (no recurrence) don't shape a typical week and are skipped.
+func parseScheduledAction(node *iac.GraphNode) (scheduledAction, bool) {
+	attrs := node.Resource.Attributes
+	capacity := billing.ExtractAttributeInt(attrs, "desired_capacity", -1)
+	if capacity < 0 {
+		capacity = billing.ExtractAttributeInt(attrs, "min_size", -1)
+	}
+	if capacity < 0 {
+		return scheduledAction{}, false
+	}
+	slots, ok := cronSlots(billing.ExtractAttribute(attrs, "recurrence"))
+	if !ok {
+		return scheduledAction{}, false
+	}
+	return scheduledAction{capacity: capacity, slots: slots}, true
+}
+
+// weeklyCapacity sums the group's capacity over the hours of a week
+// Capacity holds from one action to the next, wrapping around the week.
+func weeklyCapacity(actions []scheduledAction, desired int) float64 {
+	var at [hoursPerWeek]int
+	var fires [hoursPerWeek]bool
+	for _, a := range actions {
+		for _, slot := range a.slots {
+			at[slot] = a.capacity
+			fires[slot] = true
+		}
+	}
+
+	// The capacity entering the week is that of the week's last action
+	current := desired
+	for slot := hoursPerWeek - 1; slot >= 0; slot-- {
+		if fires[slot] {
+			current = at[slot]
+			break
+		}
+	}
+	total := 0
+	for slot := 0; slot < hoursPerWeek; slot++ {
+		if fires[slot] {
+			current = at[slot]
+		}
+		total += current
+	}
+	return float64(total)
+}
+
+// cronSlots returns the hours of the week a cron recurrence fires in
+// Only the hour and day-of-week fields shape the week; minutes round down.
+func cronSlots(recurrence string) ([]int, bool) {
+	fields := strings.Fields(recurrence)
+	if len(fields) != 5 {
+		return nil, false
+	}
+	hours, ok := cronField(fields[1], 0, 23, nil)
+	if !ok {
+		return nil, false
+	}
+	days, ok := cronField(fields[4], 0, 7, weekdayNames)
+	if !ok {
+		return nil, false
+	}
+
+	var slots []int
+	for day := 0; day < 7; day++ {
+		if !days[day] && !(day == 0 && days[7]) {
+			continue
+		}
+		for hour := 0; hour < 24; hour++ {
+			if hours[hour] {
+				slots = append(slots, day*24+hour)
+			}
+		}
+	}
+	return slots, len(slots) > 0
+}
+
+var weekdayNames = map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+
+// cronField expands a cron field (*, lists, ranges and steps) into the values it matches
+func cronField(field string, lo, hi int, names map[string]int) (map[int]bool, bool) {
+	value := func(s string) (int, bool) {
+		if n, ok := names[strings.ToLower(s)]; ok {
+			return n, true
+		}
+		n, err := strconv.Atoi(s)
+		return n, err == nil && n >= lo && n <= hi
+	}
+
+	matched := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return nil, false
+			}
+			step = n
+		}
+
+		from, to := lo, hi
+		if rng != "*" {
+			start, end, isRange := strings.Cut(rng, "-")
+			var ok bool
+			if from, ok = value(start); !ok {
+				return nil, false
+			}
+			to = from
+			if isRange {
+				if to, ok = value(end); !ok || to < from {
+					return nil, false
+				}
+			} else if hasStep {
+				to = hi
+			}
+		}
+		for v := from; v <= to; v += step {
+			matched[v] = true
+		}
+	}
+	return matched, true
+}
@@ -0,0 +1,86 @@
+// Package usage - Schedule tests
+package usage
+
+import (
+	"math"
+	"testing"
+
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/iac"
+)
+
+func TestPredictorScheduleTags(t *testing.T) {
+	f, err := ParseFile([]byte(`schedules: {on-call: {hours_per_day: 16, days_per_week: 7}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		tag      string
+		wantP90  float64
+		wantWarn bool
+	}{
+		{"office-hours", 260, false},
+		{"8x5", 8 * 5 * 52.0 / 12, false},
+		{"On-Call", 16 * 7 * 52.0 / 12, false},
+		{"sometimes", 730, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			components := testComponents()
+			components[2].ResourceTags = map[string]string{"Schedule": tt.tag}
+
+			out, warnings := NewPredictor("prod").WithUsageFile(f).Predict(components)
+			if got := out[2].VarianceProfile.P90Usage; math.Abs(got-tt.wantP90) > 1e-9 {
+				t.Errorf("compute P90 = %v, want %v", got, tt.wantP90)
+			}
+			if out[2].VarianceProfile.FixedHours == tt.wantWarn {
+				t.Errorf("fixed hours = %v, want %v", out[2].VarianceProfile.FixedHours, !tt.wantWarn)
+			}
+			if (len(warnings) > 0) != tt.wantWarn {
+				t.Errorf("unexpected warnings: %v", warnings)
+			}
+		})
+	}
+}
+
+func TestDetectSchedules(t *testing.T) {
+	node := func(typ string, attrs map[string]interface{}, deps ...string) *iac.GraphNode {
+		return &iac.GraphNode{Resource: iac.ResourceNode{Type: typ, Attributes: attrs}, Dependencies: deps}
+	}
+	graph := &iac.Graph{Nodes: map[string]*iac.GraphNode{
+		"aws_autoscaling_group.web": node("aws_autoscaling_group", map[string]interface{}{"name": "web", "desired_capacity": 4.0}),
+		// Scale to 4 at 08:00 and to 0 at 20:00 on weekdays: 12h x 5d at full capacity
+		"aws_autoscaling_schedule.up": node("aws_autoscaling_schedule", map[string]interface{}{
+			"autoscaling_group_name": "web", "desired_capacity": 4.0, "recurrence": "0 8 * * MON-FRI",
+		}),
+		"aws_autoscaling_schedule.down": node("aws_autoscaling_schedule", map[string]interface{}{
+			"desired_capacity": 0.0, "recurrence": "0 20 * * 1-5",
+		}, "aws_autoscaling_group.web"),
+		"aws_autoscaling_schedule.once": node("aws_autoscaling_schedule", map[string]interface{}{
+			"autoscaling_group_name": "web", "desired_capacity": 10.0,
+		}),
+	}}
+
+	schedules := DetectSchedules(graph)
+	web, ok := schedules["aws_autoscaling_group.web"]
+	if !ok {
+		t.Fatalf("expected a schedule for the web group, got %v", schedules)
+	}
+	if want := 60.0 / 168 * HoursPerMonth; math.Abs(web.Hours-want) > 1e-9 {
+		t.Errorf("hours = %v, want %v", web.Hours, want)
+	}
+	if web.Source != "aws_autoscaling_schedule.down, aws_autoscaling_schedule.up" {
+		t.Errorf("source = %q", web.Source)
+	}
+
+	// Detected schedules replace the flat always-on hours of the group's compute
+	components := []billing.BillingComponent{{
+		ID: "aws_autoscaling_group.web-ondemand", ResourceAddr: "aws_autoscaling_group.web",
+		BillingPeriod: billing.PeriodHourly, VarianceProfile: billing.NewDefaultVarianceProfile(4 * 730),
+	}}
+	out, _ := NewPredictor("prod").WithSchedules(schedules).Predict(components)
+	if got, want := out[0].VarianceProfile.P90Usage, 4*web.Hours; math.Abs(got-want) > 1e-6 {
+		t.Errorf("group P90 = %v, want %v", got, want)
+	}
+}
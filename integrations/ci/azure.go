@@ -0,0 +1,63 @@
+package ci
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultSummaryFile is the Markdown summary Azure Pipelines uploads to the run's summary tab
+const DefaultSummaryFile = "terracost-summary.md"
+
+// Azure publishes to Azure Pipelines through logging commands: findings as
+// task issues, outputs as output variables and the summary as an uploaded
+// Markdown file shown on the run's Extensions tab
+type Azure struct {
+	Stdout      io.Writer
+	SummaryFile string // Empty skips the summary
+}
+
+// Publish writes the logging commands and the summary
+func (a *Azure) Publish(r *Result) error {
+	for _, an := range r.Annotations {
+		if an.Level == LevelNotice {
+			// Task issues are errors or warnings only
+			fmt.Fprintf(a.Stdout, "%s: %s\n", an.Title, an.Message)
+			continue
+		}
+		props := []string{"type=" + string(an.Level), "code=" + escapeAzureProperty(an.Title)}
+		if an.File != "" {
+			props = append(props, "sourcepath="+escapeAzureProperty(an.File))
+		}
+		fmt.Fprintf(a.Stdout, "##vso[task.logissue %s]%s\n", strings.Join(props, ";"), escapeAzureData(an.Message))
+	}
+
+	for _, o := range r.Outputs {
+		fmt.Fprintf(a.Stdout, "##vso[task.setvariable variable=%s;isOutput=true]%s\n", o.Name, escapeAzureData(o.Value))
+	}
+
+	if a.SummaryFile == "" {
+		return nil
+	}
+	if err := os.WriteFile(a.SummaryFile, []byte(r.Summary()), 0o644); err != nil {
+		return fmt.Errorf("failed to write run summary: %w", err)
+	}
+	path, err := filepath.Abs(a.SummaryFile)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.Stdout, "##vso[task.uploadsummary]%s\n", escapeAzureData(path))
+	return nil
+}
+
+// escapeAzureData escapes a logging command message
+func escapeAzureData(s string) string {
+	return strings.NewReplacer("%", "%AZP25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeAzureProperty escapes a logging command property value
+func escapeAzureProperty(s string) string {
+	return strings.NewReplacer("%", "%AZP25", "\r", "%0D", "\n", "%0A", ";", "%3B", "]", "%5D").Replace(s)
+}
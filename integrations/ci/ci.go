@@ -0,0 +1,325 @@
+// Package ci publishes estimation reports in the native formats of CI systems
+// Each provider is detected from the variables its runners set. A run's
+// issues become annotations (GitHub workflow commands, a GitLab Code Quality
+// report, Azure Pipelines task issues, CircleCI log lines) and its totals
+// become step outputs that later jobs read, such as monthly_cost_p50.
+package ci
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"terraform-cost/integrations"
+	tcerrors "terraform-cost/pkg/errors"
+)
+
+// Provider is a CI system
+type Provider string
+
+const (
+	GitHubActions  Provider = "github"
+	GitLabCI       Provider = "gitlab"
+	CircleCI       Provider = "circleci"
+	AzurePipelines Provider = "azure"
+)
+
+// Providers lists the supported providers
+var Providers = []Provider{GitHubActions, GitLabCI, CircleCI, AzurePipelines}
+
+// Environment is the CI job an estimate runs in
+type Environment struct {
+	Provider    Provider
+	Workspace   string // Checkout directory, searched for plan files
+	Repository  string // e.g. org/repo; the default project
+	Branch      string
+	Commit      string
+	PullRequest string // Pull or merge request number; empty on branch builds
+	Link        string // Job or pipeline URL
+}
+
+// Detect returns the CI environment described by getenv, or nil outside CI
+func Detect(getenv func(string) string) *Environment {
+	switch {
+	case getenv("GITHUB_ACTIONS") == "true":
+		return Load(GitHubActions, getenv)
+	case getenv("GITLAB_CI") == "true":
+		return Load(GitLabCI, getenv)
+	case getenv("CIRCLECI") == "true":
+		return Load(CircleCI, getenv)
+	case strings.EqualFold(getenv("TF_BUILD"), "true"):
+		return Load(AzurePipelines, getenv)
+	}
+	return nil
+}
+
+// Load reads the environment of a provider, for runs that name it explicitly
+func Load(provider Provider, getenv func(string) string) *Environment {
+	env := &Environment{Provider: provider}
+	switch provider {
+	case GitHubActions:
+		env.Workspace = getenv("GITHUB_WORKSPACE")
+		env.Repository = getenv("GITHUB_REPOSITORY")
+		env.Commit = getenv("GITHUB_SHA")
+		env.Branch = getenv("GITHUB_HEAD_REF") // Set on pull requests only
+		if env.Branch == "" {
+			env.Branch = getenv("GITHUB_REF_NAME")
+		}
+		// refs/pull/123/merge
+		if ref := getenv("GITHUB_REF"); strings.HasPrefix(ref, "refs/pull/") {
+			env.PullRequest = strings.Split(strings.TrimPrefix(ref, "refs/pull/"), "/")[0]
+		}
+		if server, run := getenv("GITHUB_SERVER_URL"), getenv("GITHUB_RUN_ID"); server != "" && run != "" {
+			env.Link = fmt.Sprintf("%s/%s/actions/runs/%s", server, env.Repository, run)
+		}
+	case GitLabCI:
+		env.Workspace = getenv("CI_PROJECT_DIR")
+		env.Repository = getenv("CI_PROJECT_PATH")
+		env.Commit = getenv("CI_COMMIT_SHA")
+		env.Branch = getenv("CI_MERGE_REQUEST_SOURCE_BRANCH_NAME")
+		if env.Branch == "" {
+			env.Branch = getenv("CI_COMMIT_REF_NAME")
+		}
+		env.PullRequest = getenv("CI_MERGE_REQUEST_IID")
+		env.Link = getenv("CI_PIPELINE_URL")
+	case CircleCI:
+		env.Workspace = getenv("CIRCLE_WORKING_DIRECTORY")
+		if user, repo := getenv("CIRCLE_PROJECT_USERNAME"), getenv("CIRCLE_PROJECT_REPONAME"); repo != "" {
+			env.Repository = strings.TrimPrefix(user+"/"+repo, "/")
+		}
+		env.Commit = getenv("CIRCLE_SHA1")
+		env.Branch = getenv("CIRCLE_BRANCH")
+		env.PullRequest = getenv("CIRCLE_PR_NUMBER")
+		if env.PullRequest == "" {
+			// https://github.com/org/repo/pull/123
+			if pr := getenv("CIRCLE_PULL_REQUEST"); pr != "" {
+				env.PullRequest = pr[strings.LastIndex(pr, "/")+1:]
+			}
+		}
+		env.Link = getenv("CIRCLE_BUILD_URL")
+	case AzurePipelines:
+		env.Workspace = getenv("BUILD_SOURCESDIRECTORY")
+		env.Repository = getenv("BUILD_REPOSITORY_NAME")
+		env.Commit = getenv("BUILD_SOURCEVERSION")
+		env.Branch = strings.TrimPrefix(getenv("SYSTEM_PULLREQUEST_SOURCEBRANCH"), "refs/heads/")
+		if env.Branch == "" {
+			env.Branch = getenv("BUILD_SOURCEBRANCHNAME")
+		}
+		// GitHub pull requests have a number; Azure Repos ones only an ID
+		env.PullRequest = getenv("SYSTEM_PULLREQUEST_PULLREQUESTNUMBER")
+		if env.PullRequest == "" {
+			env.PullRequest = getenv("SYSTEM_PULLREQUEST_PULLREQUESTID")
+		}
+		if collection, build := getenv("SYSTEM_COLLECTIONURI"), getenv("BUILD_BUILDID"); collection != "" && build != "" {
+			env.Link = fmt.Sprintf("%s%s/_build/results?buildId=%s", collection, url.PathEscape(getenv("SYSTEM_TEAMPROJECT")), build)
+		}
+	}
+	if env.Workspace == "" {
+		env.Workspace = "."
+	}
+	return env
+}
+
+// ParseProvider parses a provider name
+func ParseProvider(name string) (Provider, error) {
+	for _, p := range Providers {
+		if strings.EqualFold(name, string(p)) {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("unknown CI provider %q (supported: github, gitlab, circleci, azure)", name)
+}
+
+// PlanFileNames are the plan JSON files FindPlan looks for, most conventional first
+var PlanFileNames = []string{"plan.json", "tfplan.json", "terraform.tfplan.json", "*.tfplan.json", "*.plan.json"}
+
+// planSearchDepth is how many directories below the workspace FindPlan searches
+const planSearchDepth = 3
+
+// FindPlan returns the plan JSON file of a workspace: the first name of
+// PlanFileNames found, nearest the workspace root
+func FindPlan(workspace string) (string, error) {
+	type candidate struct {
+		path  string
+		rank  int
+		depth int
+	}
+	var found []candidate
+	root := filepath.Clean(workspace)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		depth := strings.Count(strings.TrimPrefix(path, root), string(filepath.Separator))
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules" || depth > planSearchDepth) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		for rank, pattern := range PlanFileNames {
+			if ok, _ := filepath.Match(pattern, d.Name()); ok {
+				found = append(found, candidate{path: path, rank: rank, depth: depth})
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to search %s for a plan: %w", workspace, err)
+	}
+	if len(found) == 0 {
+		return "", fmt.Errorf("no plan JSON (%s) in %s; pass --plan", strings.Join(PlanFileNames, ", "), workspace)
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].rank != found[j].rank {
+			return found[i].rank < found[j].rank
+		}
+		return found[i].depth < found[j].depth
+	})
+	return found[0].path, nil
+}
+
+// Level is the severity of an annotation
+type Level string
+
+const (
+	LevelError   Level = "error"
+	LevelWarning Level = "warning"
+	LevelNotice  Level = "notice"
+)
+
+// Annotation is one finding shown on the CI job
+type Annotation struct {
+	Level   Level
+	Title   string
+	Message string
+	File    string // Plan file the finding is about; empty for the job
+}
+
+// Output is a named value for later steps or jobs
+type Output struct {
+	Name  string
+	Value string
+}
+
+// Result is what a CI run publishes
+type Result struct {
+	Report      *integrations.Report
+	PlanFile    string
+	Annotations []Annotation
+	Outputs     []Output
+}
+
+// NewResult builds the annotations and outputs of an estimate
+// A policy deny is an error annotation; other issues are warnings.
+func NewResult(report *integrations.Report, issues []tcerrors.Issue, planFile string) *Result {
+	r := &Result{Report: report, PlanFile: planFile}
+	for _, issue := range issues {
+		level := LevelWarning
+		if issue.Code == tcerrors.CodePolicyDeny {
+			level = LevelError
+		}
+		message := issue.Message
+		if issue.Resource != "" && !strings.Contains(message, issue.Resource) {
+			message = issue.Resource + ": " + message
+		}
+		r.Annotations = append(r.Annotations, Annotation{Level: level, Title: string(issue.Code), Message: message, File: planFile})
+	}
+	summary := fmt.Sprintf("Monthly cost %s (P90 %s)", report.Money(report.MonthlyCostP50), report.Money(report.MonthlyCostP90))
+	if delta := report.Delta(); delta != "" {
+		summary += ", " + delta + " vs baseline"
+	}
+	r.Annotations = append(r.Annotations, Annotation{Level: LevelNotice, Title: "TerraCost", Message: summary, File: planFile})
+
+	r.Outputs = []Output{
+		{"monthly_cost_p50", report.MonthlyCostP50.StringFixed(2)},
+		{"monthly_cost_p90", report.MonthlyCostP90.StringFixed(2)},
+		{"currency", report.Currency},
+		{"confidence", strconv.FormatFloat(report.Confidence, 'f', 2, 64)},
+		{"is_incomplete", strconv.FormatBool(report.IsIncomplete)},
+		{"policy_decision", string(report.PolicyDecision)},
+	}
+	if report.BaselineP50 != nil {
+		r.Outputs = append(r.Outputs, Output{"monthly_cost_delta", report.MonthlyCostP50.Sub(*report.BaselineP50).StringFixed(2)})
+	}
+	return r
+}
+
+// Summary renders the result as Markdown, for job summaries
+func (r *Result) Summary() string {
+	report := r.Report
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## 💰 TerraCost: %s\n\n", report.Title)
+	sb.WriteString("| Metric | Value |\n|--------|-------|\n")
+	fmt.Fprintf(&sb, "| Monthly Cost (P50) | %s |\n", report.Money(report.MonthlyCostP50))
+	fmt.Fprintf(&sb, "| Monthly Cost (P90) | %s |\n", report.Money(report.MonthlyCostP90))
+	if delta := report.Delta(); delta != "" {
+		fmt.Fprintf(&sb, "| Change vs Baseline | %s |\n", delta)
+	}
+	fmt.Fprintf(&sb, "| Confidence | %.0f%% |\n", report.Confidence*100)
+	fmt.Fprintf(&sb, "| Policy | %s %s |\n", report.DecisionEmoji(), report.DecisionLabel())
+
+	if len(report.TopDrivers) > 0 {
+		sb.WriteString("\n### Top Cost Drivers\n\n")
+		for _, d := range report.TopDrivers {
+			fmt.Fprintf(&sb, "- %s\n", report.DriverLine(d))
+		}
+	}
+	if len(report.Violations)+len(report.Warnings) > 0 {
+		sb.WriteString("\n### Policy Findings\n\n")
+		for _, v := range report.Violations {
+			fmt.Fprintf(&sb, "- ❌ %s\n", v)
+		}
+		for _, w := range report.Warnings {
+			fmt.Fprintf(&sb, "- ⚠️ %s\n", w)
+		}
+	}
+	if report.Link != "" {
+		fmt.Fprintf(&sb, "\n[View job](%s)\n", report.Link)
+	}
+	return sb.String()
+}
+
+// Publisher writes a result in a provider's native formats
+type Publisher interface {
+	Publish(r *Result) error
+}
+
+// NewPublisher returns the publisher of a provider, reading its file paths
+// from getenv and writing log lines to stdout
+func NewPublisher(provider Provider, getenv func(string) string, stdout io.Writer) Publisher {
+	switch provider {
+	case GitLabCI:
+		return &GitLab{Stdout: stdout, CodeQualityFile: DefaultCodeQualityFile, DotenvFile: DefaultDotenvFile}
+	case CircleCI:
+		return &Circle{Stdout: stdout, BashEnv: getenv("BASH_ENV")}
+	case AzurePipelines:
+		dir := getenv("AGENT_TEMPDIRECTORY")
+		if dir == "" {
+			dir = os.TempDir()
+		}
+		return &Azure{Stdout: stdout, SummaryFile: filepath.Join(dir, DefaultSummaryFile)}
+	default:
+		return &GitHub{Stdout: stdout, OutputFile: getenv("GITHUB_OUTPUT"), SummaryFile: getenv("GITHUB_STEP_SUMMARY")}
+	}
+}
+
+// appendFile appends content to a file the runner reads after the step
+func appendFile(path, content string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
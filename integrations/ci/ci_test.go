@@ -0,0 +1,196 @@
+package ci
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/decision/policy"
+	"terraform-cost/integrations"
+	tcerrors "terraform-cost/pkg/errors"
+)
+
+func envFunc(vars map[string]string) func(string) string {
+	return func(name string) string { return vars[name] }
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		vars map[string]string
+		want *Environment
+	}{
+		{"outside CI", map[string]string{}, nil},
+		{"github pull request", map[string]string{
+			"GITHUB_ACTIONS": "true", "GITHUB_WORKSPACE": "/w", "GITHUB_REPOSITORY": "acme/infra", "GITHUB_SHA": "abc",
+			"GITHUB_HEAD_REF": "feature", "GITHUB_REF_NAME": "12/merge", "GITHUB_REF": "refs/pull/12/merge",
+			"GITHUB_SERVER_URL": "https://github.com", "GITHUB_RUN_ID": "99",
+		}, &Environment{Provider: GitHubActions, Workspace: "/w", Repository: "acme/infra", Branch: "feature", Commit: "abc",
+			PullRequest: "12", Link: "https://github.com/acme/infra/actions/runs/99"}},
+		{"gitlab merge request", map[string]string{
+			"GITLAB_CI": "true", "CI_PROJECT_DIR": "/builds/acme/infra", "CI_PROJECT_PATH": "acme/infra", "CI_COMMIT_SHA": "abc",
+			"CI_MERGE_REQUEST_SOURCE_BRANCH_NAME": "feature", "CI_COMMIT_REF_NAME": "main", "CI_MERGE_REQUEST_IID": "7",
+			"CI_PIPELINE_URL": "https://gitlab.com/acme/infra/-/pipelines/1",
+		}, &Environment{Provider: GitLabCI, Workspace: "/builds/acme/infra", Repository: "acme/infra", Branch: "feature", Commit: "abc",
+			PullRequest: "7", Link: "https://gitlab.com/acme/infra/-/pipelines/1"}},
+		{"circleci", map[string]string{
+			"CIRCLECI": "true", "CIRCLE_PROJECT_USERNAME": "acme", "CIRCLE_PROJECT_REPONAME": "infra", "CIRCLE_SHA1": "abc",
+			"CIRCLE_BRANCH": "feature", "CIRCLE_PULL_REQUEST": "https://github.com/acme/infra/pull/3",
+		}, &Environment{Provider: CircleCI, Workspace: ".", Repository: "acme/infra", Branch: "feature", Commit: "abc", PullRequest: "3"}},
+		{"azure pipelines pull request", map[string]string{
+			"TF_BUILD": "True", "BUILD_SOURCESDIRECTORY": "/a/1/s", "BUILD_REPOSITORY_NAME": "acme/infra", "BUILD_SOURCEVERSION": "abc",
+			"SYSTEM_PULLREQUEST_SOURCEBRANCH": "refs/heads/feature", "BUILD_SOURCEBRANCHNAME": "merge", "SYSTEM_PULLREQUEST_PULLREQUESTID": "42",
+			"SYSTEM_COLLECTIONURI": "https://dev.azure.com/acme/", "SYSTEM_TEAMPROJECT": "Cloud Infra", "BUILD_BUILDID": "7",
+		}, &Environment{Provider: AzurePipelines, Workspace: "/a/1/s", Repository: "acme/infra", Branch: "feature", Commit: "abc",
+			PullRequest: "42", Link: "https://dev.azure.com/acme/Cloud%20Infra/_build/results?buildId=7"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Detect(envFunc(tt.vars))
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("Detect = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindPlan(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"stacks/app/app.tfplan.json", "stacks/app/plan.json", ".terraform/plan.json", "a/b/c/d/plan.json"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("{}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := FindPlan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "stacks/app/plan.json"); got != want {
+		t.Errorf("FindPlan = %s, want %s", got, want)
+	}
+	if _, err := FindPlan(t.TempDir()); err == nil {
+		t.Error("FindPlan of an empty workspace succeeded")
+	}
+}
+
+func testResult() *Result {
+	baseline := decimal.NewFromInt(100)
+	report := &integrations.Report{
+		Title:          "acme/infra",
+		Currency:       "USD",
+		MonthlyCostP50: decimal.NewFromInt(125),
+		MonthlyCostP90: decimal.NewFromInt(150),
+		BaselineP50:    &baseline,
+		Confidence:     0.8,
+		PolicyDecision: policy.DecisionDeny,
+		Violations:     []string{"Monthly cost exceeds limit"},
+	}
+	issues := []tcerrors.Issue{
+		{Code: tcerrors.CodePolicyDeny, Message: "limit: Monthly cost exceeds limit"},
+		{Code: tcerrors.CodePriceNotFound, Message: "no AmazonEC2 price in us-east-1", Resource: "aws_instance.web"},
+	}
+	return NewResult(report, issues, "plan.json")
+}
+
+func TestNewResult(t *testing.T) {
+	r := testResult()
+	if len(r.Annotations) != 3 || r.Annotations[0].Level != LevelError || r.Annotations[1].Level != LevelWarning || r.Annotations[2].Level != LevelNotice {
+		t.Fatalf("annotations = %+v", r.Annotations)
+	}
+	if msg := r.Annotations[1].Message; msg != "aws_instance.web: no AmazonEC2 price in us-east-1" {
+		t.Errorf("price annotation = %q", msg)
+	}
+	outputs := make(map[string]string)
+	for _, o := range r.Outputs {
+		outputs[o.Name] = o.Value
+	}
+	if outputs["monthly_cost_p50"] != "125.00" || outputs["monthly_cost_delta"] != "25.00" || outputs["policy_decision"] != "deny" {
+		t.Errorf("outputs = %v", outputs)
+	}
+}
+
+func TestGitHubPublish(t *testing.T) {
+	dir := t.TempDir()
+	var stdout strings.Builder
+	g := &GitHub{Stdout: &stdout, OutputFile: filepath.Join(dir, "output"), SummaryFile: filepath.Join(dir, "summary")}
+	r := testResult()
+	r.Annotations[0].Message = "100% over\nlimit"
+	if err := g.Publish(r); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "::error file=plan.json,title=POLICY_DENY::100%25 over%0Alimit\n"; !strings.HasPrefix(stdout.String(), want) {
+		t.Errorf("workflow commands = %q, want prefix %q", stdout.String(), want)
+	}
+	output, _ := os.ReadFile(g.OutputFile)
+	if !strings.Contains(string(output), "monthly_cost_p50=125.00\n") {
+		t.Errorf("outputs = %q", output)
+	}
+	summary, _ := os.ReadFile(g.SummaryFile)
+	if !strings.Contains(string(summary), "+$25.00 (+25.0%)") || !strings.Contains(string(summary), "Monthly cost exceeds limit") {
+		t.Errorf("summary = %q", summary)
+	}
+}
+
+func TestGitLabPublish(t *testing.T) {
+	dir := t.TempDir()
+	var stdout strings.Builder
+	g := &GitLab{Stdout: &stdout, CodeQualityFile: filepath.Join(dir, "cq.json"), DotenvFile: filepath.Join(dir, "terracost.env")}
+	if err := g.Publish(testResult()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(g.CodeQualityFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var issues []codeQualityIssue
+	if err := json.Unmarshal(data, &issues); err != nil {
+		t.Fatal(err)
+	}
+	// The cost notice is logged but is not a finding
+	if len(issues) != 2 || issues[0].Severity != "blocker" || issues[1].Severity != "minor" || issues[0].Location.Path != "plan.json" {
+		t.Errorf("code quality issues = %+v", issues)
+	}
+	if issues[0].Fingerprint == issues[1].Fingerprint {
+		t.Error("fingerprints collide")
+	}
+	dotenv, _ := os.ReadFile(g.DotenvFile)
+	if !strings.Contains(string(dotenv), "monthly_cost_p90=150.00\n") {
+		t.Errorf("dotenv = %q", dotenv)
+	}
+}
+
+func TestAzurePublish(t *testing.T) {
+	var stdout strings.Builder
+	a := &Azure{Stdout: &stdout, SummaryFile: filepath.Join(t.TempDir(), DefaultSummaryFile)}
+	r := testResult()
+	r.Annotations[0].Message = "100% over\nlimit"
+	if err := a.Publish(r); err != nil {
+		t.Fatal(err)
+	}
+
+	log := stdout.String()
+	if want := "##vso[task.logissue type=error;code=POLICY_DENY;sourcepath=plan.json]100%AZP25 over%0Alimit\n"; !strings.HasPrefix(log, want) {
+		t.Errorf("logging commands = %q, want prefix %q", log, want)
+	}
+	if !strings.Contains(log, "##vso[task.setvariable variable=monthly_cost_p50;isOutput=true]125.00\n") {
+		t.Errorf("output variables missing from %q", log)
+	}
+	if !strings.Contains(log, "##vso[task.uploadsummary]"+a.SummaryFile+"\n") {
+		t.Errorf("summary upload missing from %q", log)
+	}
+	summary, _ := os.ReadFile(a.SummaryFile)
+	if !strings.Contains(string(summary), "Monthly cost exceeds limit") {
+		t.Errorf("summary = %q", summary)
+	}
+}
@@ -0,0 +1,32 @@
+package ci
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Circle publishes to CircleCI, which has no annotation format: findings go
+// to the step log and outputs are exported through $BASH_ENV to later steps
+type Circle struct {
+	Stdout  io.Writer
+	BashEnv string // Empty skips outputs
+}
+
+// Publish writes the annotations to the log and exports the outputs
+func (c *Circle) Publish(r *Result) error {
+	for _, a := range r.Annotations {
+		fmt.Fprintf(c.Stdout, "%s: [%s] %s\n", strings.ToUpper(string(a.Level)), a.Title, a.Message)
+	}
+	if c.BashEnv == "" {
+		return nil
+	}
+	var sb strings.Builder
+	for _, o := range r.Outputs {
+		fmt.Fprintf(&sb, "export TERRACOST_%s=%q\n", strings.ToUpper(o.Name), o.Value)
+	}
+	if err := appendFile(c.BashEnv, sb.String()); err != nil {
+		return fmt.Errorf("failed to export outputs: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package ci
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// GitHub publishes to GitHub Actions: workflow commands annotate the run,
+// outputs go to $GITHUB_OUTPUT and the summary to $GITHUB_STEP_SUMMARY
+type GitHub struct {
+	Stdout      io.Writer
+	OutputFile  string // Empty skips outputs
+	SummaryFile string // Empty skips the job summary
+}
+
+// Publish writes the annotations, outputs and job summary
+func (g *GitHub) Publish(r *Result) error {
+	for _, a := range r.Annotations {
+		props := []string{"title=" + escapeProperty(a.Title)}
+		if a.File != "" {
+			props = append([]string{"file=" + escapeProperty(a.File)}, props...)
+		}
+		fmt.Fprintf(g.Stdout, "::%s %s::%s\n", a.Level, strings.Join(props, ","), escapeData(a.Message))
+	}
+
+	if g.OutputFile != "" {
+		var sb strings.Builder
+		for _, o := range r.Outputs {
+			fmt.Fprintf(&sb, "%s=%s\n", o.Name, o.Value)
+		}
+		if err := appendFile(g.OutputFile, sb.String()); err != nil {
+			return fmt.Errorf("failed to write step outputs: %w", err)
+		}
+	}
+	if g.SummaryFile != "" {
+		if err := appendFile(g.SummaryFile, r.Summary()); err != nil {
+			return fmt.Errorf("failed to write job summary: %w", err)
+		}
+	}
+	return nil
+}
+
+// escapeData escapes a workflow command message
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a workflow command property value
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,89 @@
+package ci
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Report artifacts GitLab jobs declare under artifacts:reports
+const (
+	DefaultCodeQualityFile = "gl-code-quality-report.json" // reports:codequality
+	DefaultDotenvFile      = "terracost.env"               // reports:dotenv
+)
+
+// GitLab publishes to GitLab CI: findings as a Code Quality report shown on
+// the merge request, outputs as a dotenv report for later jobs
+type GitLab struct {
+	Stdout          io.Writer
+	CodeQualityFile string
+	DotenvFile      string
+}
+
+// codeQualityIssue is an entry of a GitLab Code Quality report
+type codeQualityIssue struct {
+	Description string              `json:"description"`
+	CheckName   string              `json:"check_name"`
+	Fingerprint string              `json:"fingerprint"`
+	Severity    string              `json:"severity"` // info, minor, major, critical or blocker
+	Location    codeQualityLocation `json:"location"`
+}
+
+type codeQualityLocation struct {
+	Path  string `json:"path"`
+	Lines struct {
+		Begin int `json:"begin"`
+	} `json:"lines"`
+}
+
+// codeQualitySeverity maps annotation levels to Code Quality severities
+var codeQualitySeverity = map[Level]string{
+	LevelError:   "blocker",
+	LevelWarning: "minor",
+	LevelNotice:  "info",
+}
+
+// Publish writes the Code Quality and dotenv reports, and the annotations to the job log
+func (g *GitLab) Publish(r *Result) error {
+	issues := make([]codeQualityIssue, 0, len(r.Annotations))
+	for _, a := range r.Annotations {
+		fmt.Fprintf(g.Stdout, "%s: [%s] %s\n", strings.ToUpper(string(a.Level)), a.Title, a.Message)
+		if a.Level == LevelNotice {
+			continue
+		}
+		sum := sha256.Sum256([]byte(a.Title + "\x00" + a.File + "\x00" + a.Message))
+		issue := codeQualityIssue{
+			Description: a.Message,
+			CheckName:   "terracost/" + a.Title,
+			Fingerprint: hex.EncodeToString(sum[:16]),
+			Severity:    codeQualitySeverity[a.Level],
+		}
+		issue.Location.Path = a.File
+		issue.Location.Lines.Begin = 1
+		issues = append(issues, issue)
+	}
+	if g.CodeQualityFile != "" {
+		data, err := json.MarshalIndent(issues, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(g.CodeQualityFile, data, 0o644); err != nil {
+			return fmt.Errorf("failed to write Code Quality report: %w", err)
+		}
+	}
+
+	if g.DotenvFile != "" {
+		var sb strings.Builder
+		for _, o := range r.Outputs {
+			fmt.Fprintf(&sb, "%s=%s\n", o.Name, o.Value)
+		}
+		if err := os.WriteFile(g.DotenvFile, []byte(sb.String()), 0o644); err != nil {
+			return fmt.Errorf("failed to write dotenv report: %w", err)
+		}
+	}
+	return nil
+}
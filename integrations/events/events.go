@@ -0,0 +1,239 @@
+// Package events publishes estimate lifecycle events to an event bus, so FinOps
+// data lakes and alerting pipelines consume results without polling the API
+//
+//	nats://nats:4222                   NATS, subjects terracost.<type>
+//	nats://nats:4222/finops.costs      NATS, subjects finops.costs.<type>
+//	kafka+http://rest-proxy:8082/costs Kafka through a REST Proxy (v2 API), topic costs
+//
+// Every event is one JSON object:
+//
+//	{
+//	  "id":           "7d0c…",               unique per event
+//	  "type":         "estimate.completed",  estimate.requested, estimate.completed or policy.evaluated
+//	  "version":      1,                     bumped on incompatible changes to data
+//	  "time":         "2024-06-01T12:00:00Z",
+//	  "request_id":   "3f2a…",               shared by the events of one estimate
+//	  "org_id":       "acme",                omitted without multi-tenancy
+//	  "project":      "payments", "branch": "main", "commit_sha": "…", "pull_request": "…",
+//	  "environment":  "prod",
+//	  "data":         { … }                  RequestedData, CompletedData or PolicyData
+//	}
+//
+// Kafka records are keyed by project so one project's events stay ordered.
+//
+// Neither bus uses its official client. Kafka is only reachable through a
+// Confluent REST Proxy, never the broker protocol. NATS is a minimal client of
+// the core protocol over plain TCP: no TLS, no JetStream, no cluster discovery.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"terraform-cost/decision/policy"
+)
+
+// Event types
+const (
+	EstimateRequested = "estimate.requested"
+	EstimateCompleted = "estimate.completed"
+	PolicyEvaluated   = "policy.evaluated"
+)
+
+// SchemaVersion is the version of the event schema
+const SchemaVersion = 1
+
+// Event is one lifecycle event of an estimate
+type Event struct {
+	ID          string      `json:"id"`
+	Type        string      `json:"type"`
+	Version     int         `json:"version"`
+	Time        time.Time   `json:"time"`
+	RequestID   string      `json:"request_id"`
+	OrgID       string      `json:"org_id,omitempty"`
+	Project     string      `json:"project,omitempty"`
+	Branch      string      `json:"branch,omitempty"`
+	CommitSHA   string      `json:"commit_sha,omitempty"`
+	PullRequest string      `json:"pull_request,omitempty"`
+	Environment string      `json:"environment,omitempty"`
+	Data        interface{} `json:"data"`
+}
+
+// RequestedData is the data of estimate.requested, sent before pricing starts
+type RequestedData struct {
+	ResourceCount int    `json:"resource_count"` // Resources in the plan
+	Currency      string `json:"currency"`
+	PricingDate   string `json:"pricing_date,omitempty"`
+}
+
+// CompletedData is the data of estimate.completed
+type CompletedData struct {
+	EstimationID   string  `json:"estimation_id,omitempty"` // Set when saved to history
+	Currency       string  `json:"currency"`
+	MonthlyCostP50 string  `json:"monthly_cost_p50"`
+	MonthlyCostP90 string  `json:"monthly_cost_p90"`
+	CarbonKgCO2    float64 `json:"carbon_kg_co2"`
+	Confidence     float64 `json:"confidence"`
+	IsIncomplete   bool    `json:"is_incomplete"`
+	ResourceCount  int     `json:"resource_count"`
+	DurationMS     int64   `json:"duration_ms"`
+}
+
+// PolicyData is the data of policy.evaluated
+type PolicyData struct {
+	EstimationID string             `json:"estimation_id,omitempty"`
+	Decision     string             `json:"decision"` // allow, warn or deny
+	Violations   []policy.Violation `json:"violations"`
+	Warnings     []policy.Warning   `json:"warnings"`
+}
+
+// New creates an event of a type with a fresh ID
+func New(eventType, requestID string, data interface{}) Event {
+	return Event{
+		ID:        uuid.NewString(),
+		Type:      eventType,
+		Version:   SchemaVersion,
+		Time:      time.Now().UTC(),
+		RequestID: requestID,
+		Data:      data,
+	}
+}
+
+// Publisher sends events to an event bus
+type Publisher interface {
+	Publish(ctx context.Context, events ...Event) error
+}
+
+// Parse returns the publisher for a target: a nats:// or kafka+http(s):// URL
+func Parse(target string) (Publisher, error) {
+	switch {
+	case strings.HasPrefix(target, "nats://"):
+		return NewNATS(target)
+	case strings.HasPrefix(target, "kafka+http://") || strings.HasPrefix(target, "kafka+https://"):
+		return NewKafkaREST(strings.TrimPrefix(target, "kafka+"))
+	default:
+		return nil, fmt.Errorf("invalid event bus %q (expected nats://host:port[/subject] or kafka+http(s)://rest-proxy/topic)", target)
+	}
+}
+
+// ParseAll parses every target
+func ParseAll(targets []string) ([]Publisher, error) {
+	publishers := make([]Publisher, 0, len(targets))
+	for _, target := range targets {
+		p, err := Parse(target)
+		if err != nil {
+			return nil, err
+		}
+		publishers = append(publishers, p)
+	}
+	return publishers, nil
+}
+
+// encode marshals an event
+func encode(e Event) ([]byte, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s event: %w", e.Type, err)
+	}
+	return data, nil
+}
+
+// =============================================================================
+// ASYNC DISPATCH
+// =============================================================================
+
+// DefaultQueueSize is how many events wait for publishing before new ones are dropped
+const DefaultQueueSize = 1000
+
+// Dispatcher publishes events in the background so estimates never wait on
+// the event bus; when the queue is full, events are dropped and counted
+type Dispatcher struct {
+	publishers []Publisher
+	timeout    time.Duration
+	queue      chan Event
+	done       chan struct{}
+
+	mu      sync.Mutex
+	dropped int
+	closed  bool
+	onError func(error)
+}
+
+// NewDispatcher starts publishing to every publisher; Close stops it
+func NewDispatcher(publishers []Publisher, queueSize int) *Dispatcher {
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+	d := &Dispatcher{
+		publishers: publishers,
+		timeout:    10 * time.Second,
+		queue:      make(chan Event, queueSize),
+		done:       make(chan struct{}),
+		onError:    func(error) {},
+	}
+	go d.run()
+	return d
+}
+
+// WithErrorHandler receives publish failures, e.g. for logging
+func (d *Dispatcher) WithErrorHandler(fn func(error)) *Dispatcher {
+	d.onError = fn
+	return d
+}
+
+// Emit queues events without blocking
+func (d *Dispatcher) Emit(events ...Event) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return
+	}
+	for _, e := range events {
+		select {
+		case d.queue <- e:
+		default:
+			d.dropped++
+		}
+	}
+}
+
+// Dropped returns how many events were dropped on a full queue
+func (d *Dispatcher) Dropped() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.dropped
+}
+
+// Close stops accepting events and waits for queued ones until ctx is done
+func (d *Dispatcher) Close(ctx context.Context) {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+	select {
+	case <-d.done:
+	case <-ctx.Done():
+	}
+}
+
+// run publishes queued events one at a time, in order
+func (d *Dispatcher) run() {
+	defer close(d.done)
+	for e := range d.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+		for _, p := range d.publishers {
+			if err := p.Publish(ctx, e); err != nil {
+				d.onError(fmt.Errorf("%s event: %w", e.Type, err))
+			}
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,157 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeNATS accepts one connection and records the subjects and payloads published on it
+func fakeNATS(t *testing.T) (addr string, published <-chan [2]string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	out := make(chan [2]string, 10)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n"))
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			fields := strings.Fields(line)
+			switch {
+			case len(fields) == 0:
+			case fields[0] == "PING":
+				conn.Write([]byte("PONG\r\n"))
+			case fields[0] == "PUB" && len(fields) == 3:
+				size, _ := strconv.Atoi(fields[2])
+				payload := make([]byte, size+2)
+				if _, err := io.ReadFull(r, payload); err != nil {
+					return
+				}
+				out <- [2]string{fields[1], string(payload[:size])}
+			}
+		}
+	}()
+	return ln.Addr().String(), out
+}
+
+func TestNATSPublish(t *testing.T) {
+	addr, published := fakeNATS(t)
+	p, err := Parse("nats://" + addr + "/finops/costs")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := New(EstimateCompleted, "req-1", CompletedData{Currency: "USD", MonthlyCostP50: "42.00"})
+	event.Project = "payments"
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.Publish(ctx, event); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	msg := <-published
+	if msg[0] != "finops.costs.estimate.completed" {
+		t.Errorf("subject = %s", msg[0])
+	}
+	var got Event
+	if err := json.Unmarshal([]byte(msg[1]), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Type != EstimateCompleted || got.Version != SchemaVersion || got.RequestID != "req-1" || got.Project != "payments" {
+		t.Errorf("event = %+v", got)
+	}
+}
+
+func TestKafkaRESTPublish(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/topics/costs" || r.Header.Get("Content-Type") != "application/vnd.kafka.json.v2+json" {
+			t.Errorf("%s with %s", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		if user, pass, _ := r.BasicAuth(); user != "svc" || pass != "secret" {
+			t.Errorf("credentials = %s:%s", user, pass)
+		}
+		var body struct {
+			Records []struct {
+				Key   string `json:"key"`
+				Value Event  `json:"value"`
+			} `json:"records"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.Records) != 1 {
+			t.Errorf("records = %+v, %v", body.Records, err)
+			return
+		}
+		if rec := body.Records[0]; rec.Key != "payments" || rec.Value.Type != PolicyEvaluated {
+			t.Errorf("record = %+v", rec)
+		}
+		w.Write([]byte(`{"offsets":[{"partition":0,"offset":7}]}`))
+	}))
+	defer srv.Close()
+
+	p, err := Parse(strings.Replace(srv.URL, "http://", "kafka+http://svc:secret@", 1) + "/costs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := New(PolicyEvaluated, "req-1", PolicyData{Decision: "deny"})
+	event.Project = "payments"
+	if err := p.Publish(context.Background(), event); err != nil {
+		t.Errorf("Publish: %v", err)
+	}
+}
+
+func TestParseRejectsUnknownBuses(t *testing.T) {
+	for _, target := range []string{"amqp://rabbit", "kafka+http://proxy:8082", "nats://"} {
+		if _, err := Parse(target); err == nil {
+			t.Errorf("Parse(%q) succeeded", target)
+		}
+	}
+}
+
+type recorder struct{ events chan Event }
+
+func (r *recorder) Publish(_ context.Context, events ...Event) error {
+	for _, e := range events {
+		r.events <- e
+	}
+	return nil
+}
+
+func TestDispatcherDrainsOnClose(t *testing.T) {
+	rec := &recorder{events: make(chan Event, 10)}
+	d := NewDispatcher([]Publisher{rec}, 10)
+	d.Emit(New(EstimateRequested, "a", RequestedData{}), New(EstimateCompleted, "a", CompletedData{}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	d.Close(ctx)
+	if len(rec.events) != 2 {
+		t.Fatalf("published %d events, want 2", len(rec.events))
+	}
+	if e := <-rec.events; e.Type != EstimateRequested {
+		t.Errorf("first event = %s, want events in order", e.Type)
+	}
+
+	d.Emit(New(EstimateRequested, "b", RequestedData{})) // After Close: ignored
+	if d.Dropped() != 0 {
+		t.Errorf("dropped = %d", d.Dropped())
+	}
+}
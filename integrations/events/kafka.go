@@ -0,0 +1,101 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// KafkaREST publishes events to a Kafka topic through a REST Proxy (v2 API)
+// Records are keyed by project so a project's events land on one partition.
+// Brokers are not dialled directly; clusters without a REST Proxy are not supported.
+type KafkaREST struct {
+	client   *http.Client
+	endpoint string
+	user     string
+	pass     string
+}
+
+// NewKafkaREST creates a publisher for http(s)://[user:pass@]rest-proxy[:port]/topic
+func NewKafkaREST(target string) (*KafkaREST, error) {
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid Kafka REST Proxy URL %q", target)
+	}
+	topic := strings.Trim(u.Path, "/")
+	if topic == "" || strings.Contains(topic, "/") {
+		return nil, fmt.Errorf("invalid Kafka REST Proxy URL %q: the path must name one topic", target)
+	}
+	k := &KafkaREST{client: &http.Client{Timeout: 10 * time.Second}}
+	if u.User != nil {
+		k.user = u.User.Username()
+		k.pass, _ = u.User.Password()
+		u.User = nil
+	}
+	u.Path = "/topics/" + url.PathEscape(topic)
+	k.endpoint = u.String()
+	return k, nil
+}
+
+// kafkaRecord is one record of a REST Proxy produce request
+type kafkaRecord struct {
+	Key   string          `json:"key,omitempty"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Publish produces the events as one batch of records
+func (k *KafkaREST) Publish(ctx context.Context, events ...Event) error {
+	records := make([]kafkaRecord, 0, len(events))
+	for _, e := range events {
+		value, err := encode(e)
+		if err != nil {
+			return err
+		}
+		records = append(records, kafkaRecord{Key: e.Project, Value: value})
+	}
+	body, err := json.Marshal(map[string]interface{}{"records": records})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+	if k.user != "" {
+		req.SetBasicAuth(k.user, k.pass)
+	}
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("kafka: %w", err)
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("kafka: REST Proxy returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	// A 200 can still carry per-record failures
+	var result struct {
+		Offsets []struct {
+			ErrorCode *int   `json:"error_code"`
+			Error     string `json:"error"`
+		} `json:"offsets"`
+	}
+	if json.Unmarshal(msg, &result) == nil {
+		for _, o := range result.Offsets {
+			if o.ErrorCode != nil {
+				return fmt.Errorf("kafka: record rejected: %s", o.Error)
+			}
+		}
+	}
+	return nil
+}
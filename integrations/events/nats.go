@@ -0,0 +1,175 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSubjectPrefix prefixes the NATS subjects of events without one in the URL
+const DefaultSubjectPrefix = "terracost"
+
+// NATS publishes events to NATS core subjects <prefix>.<event type>
+// It speaks the NATS client protocol over one connection, redialled after
+// failures, and flushes with PING/PONG so publishes are confirmed received.
+// Connections are plain TCP; servers requiring TLS are not supported.
+type NATS struct {
+	addr   string
+	prefix string
+	user   string
+	pass   string
+	token  string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewNATS creates a publisher for nats://[user:pass@|token@]host[:port][/prefix]
+func NewNATS(target string) (*NATS, error) {
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid NATS URL %q", target)
+	}
+	n := &NATS{addr: u.Host, prefix: strings.Trim(u.Path, "/")}
+	if u.Port() == "" {
+		n.addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	if n.prefix == "" {
+		n.prefix = DefaultSubjectPrefix
+	}
+	n.prefix = strings.ReplaceAll(n.prefix, "/", ".")
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			n.user, n.pass = u.User.Username(), pass
+		} else {
+			n.token = u.User.Username()
+		}
+	}
+	return n, nil
+}
+
+// Publish sends events and waits for the server to acknowledge them
+func (n *NATS) Publish(ctx context.Context, events ...Event) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if err := n.publish(ctx, events); err != nil {
+		// The connection's state is unknown; the next publish redials
+		n.close()
+		return fmt.Errorf("nats: %w", err)
+	}
+	return nil
+}
+
+// publish writes the events on the connection, dialling it first if needed
+func (n *NATS) publish(ctx context.Context, events []Event) error {
+	if n.conn == nil {
+		if err := n.connect(ctx); err != nil {
+			return err
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		n.conn.SetDeadline(deadline)
+	} else {
+		n.conn.SetDeadline(time.Time{})
+	}
+
+	var buf strings.Builder
+	for _, e := range events {
+		payload, err := encode(e)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&buf, "PUB %s.%s %d\r\n%s\r\n", n.prefix, e.Type, len(payload), payload)
+	}
+	buf.WriteString("PING\r\n")
+	if _, err := n.conn.Write([]byte(buf.String())); err != nil {
+		return err
+	}
+	return n.awaitPong()
+}
+
+// connect dials the server, reads its INFO and sends CONNECT
+func (n *NATS) connect(ctx context.Context) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	n.conn, n.reader = conn, bufio.NewReader(conn)
+
+	line, err := n.reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("unexpected greeting %q", strings.TrimSpace(line))
+	}
+
+	options := map[string]interface{}{
+		"verbose":  false,
+		"pedantic": false,
+		"name":     "terracost",
+		"lang":     "go",
+		"protocol": 1,
+	}
+	if n.token != "" {
+		options["auth_token"] = n.token
+	}
+	if n.user != "" {
+		options["user"], options["pass"] = n.user, n.pass
+	}
+	connect, _ := json.Marshal(options)
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", connect); err != nil {
+		return err
+	}
+	return n.awaitPong()
+}
+
+// awaitPong reads until the server's PONG, answering its PINGs
+func (n *NATS) awaitPong() error {
+	for {
+		line, err := n.reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := n.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("server error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+		// +OK and INFO updates need no reply
+	}
+}
+
+// close drops the connection
+func (n *NATS) close() {
+	if n.conn != nil {
+		n.conn.Close()
+		n.conn, n.reader = nil, nil
+	}
+}
+
+// Close closes the connection
+func (n *NATS) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.close()
+	return nil
+}
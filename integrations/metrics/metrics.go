@@ -0,0 +1,172 @@
+// Package metrics exports estimated cost as OpenMetrics, so dashboards of
+// estimated spend stay current without glue scripts
+//
+//	http://pushgateway:9091       Prometheus Pushgateway, one group per project and environment
+//	/var/lib/node_exporter/x.prom node_exporter textfile collector file
+//
+// Every estimate exports its monthly cost per service, carbon, confidence and
+// the time it was made, labelled with project and environment.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/decision/currency"
+	"terraform-cost/decision/estimation"
+)
+
+// Estimate is one project's estimate to export
+type Estimate struct {
+	Project     string
+	Environment string
+	Result      *estimation.EstimationResult
+	At          time.Time // When the estimate was made; zero is now
+}
+
+// Exporter publishes estimates to a metrics system
+type Exporter interface {
+	Export(ctx context.Context, estimates ...Estimate) error
+}
+
+// Parse returns the exporter for a target: an http(s) Pushgateway URL or a textfile path
+func Parse(target string) (Exporter, error) {
+	switch {
+	case strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://"):
+		return NewPushgateway(target), nil
+	case strings.HasSuffix(target, ".prom"):
+		return NewTextfile(target), nil
+	default:
+		return nil, fmt.Errorf("invalid metrics target %q (expected an http(s):// Pushgateway URL or a .prom file)", target)
+	}
+}
+
+// ParseAll parses every target
+func ParseAll(targets []string) ([]Exporter, error) {
+	exporters := make([]Exporter, 0, len(targets))
+	for _, target := range targets {
+		e, err := Parse(target)
+		if err != nil {
+			return nil, err
+		}
+		exporters = append(exporters, e)
+	}
+	return exporters, nil
+}
+
+// ExportAll exports estimates with every exporter and returns the failures
+func ExportAll(ctx context.Context, exporters []Exporter, estimates ...Estimate) []error {
+	var errs []error
+	for _, e := range exporters {
+		if err := e.Export(ctx, estimates...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// family is a metric and its samples
+type family struct {
+	name    string
+	help    string
+	samples []sample
+}
+
+type sample struct {
+	labels [][2]string
+	value  float64
+}
+
+// Write writes estimates in the OpenMetrics text format
+func Write(w io.Writer, estimates ...Estimate) error {
+	p50 := family{name: "terracost_monthly_cost_p50", help: "Estimated monthly cost by service, median"}
+	p90 := family{name: "terracost_monthly_cost_p90", help: "Estimated monthly cost by service, 90th percentile"}
+	carbon := family{name: "terracost_carbon_kg_co2", help: "Estimated monthly emissions in kg CO2e"}
+	confidence := family{name: "terracost_estimate_confidence", help: "Confidence of the estimate, 0 to 1"}
+	incomplete := family{name: "terracost_estimate_incomplete", help: "1 when some components could not be priced"}
+	timestamp := family{name: "terracost_estimate_timestamp_seconds", help: "When the estimate was made"}
+
+	for _, e := range estimates {
+		at := e.At
+		if at.IsZero() {
+			at = time.Now()
+		}
+		labels := [][2]string{{"project", e.Project}, {"environment", e.Environment}}
+		cur := currency.Normalize(e.Result.Currency)
+		for _, s := range serviceCosts(e.Result.CostDrivers) {
+			serviceLabels := append(append([][2]string{}, labels...), [2]string{"service", s.service}, [2]string{"currency", cur})
+			p50.samples = append(p50.samples, sample{serviceLabels, s.p50.InexactFloat64()})
+			p90.samples = append(p90.samples, sample{serviceLabels, s.p90.InexactFloat64()})
+		}
+		carbon.samples = append(carbon.samples, sample{labels, e.Result.CarbonKgCO2})
+		confidence.samples = append(confidence.samples, sample{labels, e.Result.Confidence})
+		incomplete.samples = append(incomplete.samples, sample{labels, boolValue(e.Result.IsIncomplete)})
+		timestamp.samples = append(timestamp.samples, sample{labels, float64(at.Unix())})
+	}
+
+	var sb strings.Builder
+	for _, f := range []family{p50, p90, carbon, confidence, incomplete, timestamp} {
+		fmt.Fprintf(&sb, "# TYPE %s gauge\n# HELP %s %s\n", f.name, f.name, f.help)
+		for _, s := range f.samples {
+			sb.WriteString(f.name)
+			sb.WriteByte('{')
+			for i, l := range s.labels {
+				if i > 0 {
+					sb.WriteByte(',')
+				}
+				fmt.Fprintf(&sb, "%s=\"%s\"", l[0], escapeLabel(l[1]))
+			}
+			sb.WriteString("} ")
+			sb.WriteString(strconv.FormatFloat(s.value, 'f', -1, 64))
+			sb.WriteByte('\n')
+		}
+	}
+	sb.WriteString("# EOF\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// serviceCost is the monthly cost of one service
+type serviceCost struct {
+	service  string
+	p50, p90 decimal.Decimal
+}
+
+// serviceCosts totals drivers by service, in service order
+func serviceCosts(drivers []estimation.CostDriver) []serviceCost {
+	byService := make(map[string]*serviceCost)
+	for _, d := range drivers {
+		s, ok := byService[d.Service]
+		if !ok {
+			s = &serviceCost{service: d.Service}
+			byService[d.Service] = s
+		}
+		s.p50 = s.p50.Add(d.MonthlyCostP50)
+		s.p90 = s.p90.Add(d.MonthlyCostP90)
+	}
+	costs := make([]serviceCost, 0, len(byService))
+	for _, s := range byService {
+		costs = append(costs, *s)
+	}
+	sort.Slice(costs, func(i, j int) bool { return costs[i].service < costs[j].service })
+	return costs
+}
+
+// escapeLabel escapes a label value
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/decision/estimation"
+)
+
+func testEstimate(project string) Estimate {
+	return Estimate{
+		Project:     project,
+		Environment: "prod",
+		At:          time.Unix(1700000000, 0),
+		Result: &estimation.EstimationResult{
+			Currency:    "USD",
+			CarbonKgCO2: 12.5,
+			Confidence:  0.8,
+			CostDrivers: []estimation.CostDriver{
+				{Service: "AmazonEC2", MonthlyCostP50: decimal.NewFromInt(60), MonthlyCostP90: decimal.NewFromInt(70)},
+				{Service: "AmazonEC2", MonthlyCostP50: decimal.NewFromFloat(3.5), MonthlyCostP90: decimal.NewFromInt(4)},
+				{Service: "AmazonRDS", MonthlyCostP50: decimal.NewFromInt(100), MonthlyCostP90: decimal.NewFromInt(120)},
+			},
+		},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var sb strings.Builder
+	if err := Write(&sb, testEstimate(`org/"app"`)); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	out := sb.String()
+	for _, want := range []string{
+		"# TYPE terracost_monthly_cost_p50 gauge\n",
+		`terracost_monthly_cost_p50{project="org/\"app\"",environment="prod",service="AmazonEC2",currency="USD"} 63.5`,
+		`terracost_monthly_cost_p90{project="org/\"app\"",environment="prod",service="AmazonRDS",currency="USD"} 120`,
+		`terracost_carbon_kg_co2{project="org/\"app\"",environment="prod"} 12.5`,
+		`terracost_estimate_incomplete{project="org/\"app\"",environment="prod"} 0`,
+		`terracost_estimate_timestamp_seconds{project="org/\"app\"",environment="prod"} 1700000000`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if !strings.HasSuffix(out, "# EOF\n") {
+		t.Errorf("output does not end with # EOF:\n%s", out)
+	}
+}
+
+func TestPushgateway(t *testing.T) {
+	var method, path, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		method, path, body = r.Method, r.URL.Path, string(data)
+	}))
+	defer server.Close()
+
+	if err := NewPushgateway(server.URL+"/").Export(context.Background(), testEstimate("org/app")); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if method != http.MethodPut {
+		t.Errorf("method = %s, want PUT", method)
+	}
+	if want := "/metrics/job/terracost/project@base64/b3JnL2FwcA/environment@base64/cHJvZA"; path != want {
+		t.Errorf("path = %s, want %s", path, want)
+	}
+	if !strings.Contains(body, "terracost_monthly_cost_p50{") {
+		t.Errorf("body has no cost samples:\n%s", body)
+	}
+}
+
+func TestTextfileKeepsEveryProject(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "terracost.prom")
+	exporter, err := Parse(path)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	ctx := context.Background()
+	if err := exporter.Export(ctx, testEstimate("api")); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if err := exporter.Export(ctx, testEstimate("web")); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, project := range []string{"api", "web"} {
+		if !strings.Contains(string(data), `terracost_carbon_kg_co2{project="`+project+`"`) {
+			t.Errorf("file missing project %s:\n%s", project, data)
+		}
+	}
+	if strings.Count(string(data), "# EOF") != 1 {
+		t.Errorf("file should hold one exposition:\n%s", data)
+	}
+}
+
+func TestParse(t *testing.T) {
+	if _, err := Parse("s3://bucket/metrics"); err == nil {
+		t.Error("expected an error for an unsupported target")
+	}
+}
@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultJob is the job label estimates are pushed under
+const DefaultJob = "terracost"
+
+// Pushgateway pushes estimates to a Prometheus Pushgateway
+// Each project and environment is its own group, replaced on every push so
+// services that no longer have cost disappear.
+type Pushgateway struct {
+	client *http.Client
+	url    string
+	job    string
+}
+
+// NewPushgateway pushes to the Pushgateway at url
+func NewPushgateway(url string) *Pushgateway {
+	return &Pushgateway{
+		client: &http.Client{Timeout: 10 * time.Second},
+		url:    strings.TrimSuffix(url, "/"),
+		job:    DefaultJob,
+	}
+}
+
+// WithJob overrides the job label
+func (p *Pushgateway) WithJob(job string) *Pushgateway {
+	p.job = job
+	return p
+}
+
+// Export pushes each estimate to its group
+func (p *Pushgateway) Export(ctx context.Context, estimates ...Estimate) error {
+	for _, e := range estimates {
+		var body bytes.Buffer
+		if err := Write(&body, e); err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, p.groupURL(e), &body)
+		if err != nil {
+			return err
+		}
+		// The Pushgateway parses the Prometheus text format, of which this output is a subset
+		req.Header.Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		resp, err := p.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("pushgateway: %w", err)
+		}
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("pushgateway returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+		}
+	}
+	return nil
+}
+
+// groupURL is the URL of an estimate's group; label values are base64 encoded
+// as they may contain slashes (org/repo)
+func (p *Pushgateway) groupURL(e Estimate) string {
+	return fmt.Sprintf("%s/metrics/job/%s/project@base64/%s/environment@base64/%s",
+		p.url, p.job, encodeLabel(e.Project), encodeLabel(e.Environment))
+}
+
+// encodeLabel encodes a grouping label value; "=" stands for the empty value
+func encodeLabel(v string) string {
+	if v == "" {
+		return "="
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(v))
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Textfile writes estimates to a file read by the node_exporter textfile collector
+// The file holds the latest estimate of every project and environment the
+// exporter has seen, and is replaced atomically so scrapes never see a partial file.
+type Textfile struct {
+	path string
+
+	mu     sync.Mutex
+	latest map[[2]string]Estimate // project, environment -> estimate
+}
+
+// NewTextfile writes to path, which should end in .prom
+func NewTextfile(path string) *Textfile {
+	return &Textfile{path: path, latest: make(map[[2]string]Estimate)}
+}
+
+// Export records the estimates and rewrites the file
+func (t *Textfile) Export(ctx context.Context, estimates ...Estimate) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, e := range estimates {
+		t.latest[[2]string{e.Project, e.Environment}] = e
+	}
+	keys := make([][2]string, 0, len(t.latest))
+	for k := range t.latest {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	all := make([]Estimate, len(keys))
+	for i, k := range keys {
+		all[i] = t.latest[k]
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, all...); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(t.path), ".terracost-*.prom.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", t.path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", t.path, err)
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), t.path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", t.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// Package notify builds notifiers from target URLs
+//
+//	slack://#channel                          bot token from SLACK_BOT_TOKEN
+//	slack://hooks.slack.com/services/T/B/X    Slack incoming webhook
+//	teams://example.webhook.office.com/...    Teams incoming or Workflows webhook
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"terraform-cost/integrations"
+	"terraform-cost/integrations/slack"
+	"terraform-cost/integrations/teams"
+)
+
+// SlackTokenEnv holds the bot token used by slack://channel targets
+const SlackTokenEnv = "SLACK_BOT_TOKEN"
+
+// Parse returns the notifier for a target URL
+func Parse(target string) (integrations.Notifier, error) {
+	scheme, rest, ok := strings.Cut(target, "://")
+	if !ok || rest == "" {
+		return nil, fmt.Errorf("invalid notification target %q (expected slack://... or teams://...)", target)
+	}
+
+	switch strings.ToLower(scheme) {
+	case "slack":
+		if strings.Contains(rest, "/") {
+			return slack.NewWebhookNotifier("https://" + rest), nil
+		}
+		token := os.Getenv(SlackTokenEnv)
+		if token == "" {
+			return nil, fmt.Errorf("%s requires %s to be set", target, SlackTokenEnv)
+		}
+		return slack.NewBotNotifier(token, strings.TrimPrefix(rest, "#")), nil
+	case "teams":
+		return teams.NewNotifier("https://" + rest), nil
+	default:
+		return nil, fmt.Errorf("unsupported notification target %q (supported: slack, teams)", scheme)
+	}
+}
+
+// ParseAll parses every target
+func ParseAll(targets []string) ([]integrations.Notifier, error) {
+	notifiers := make([]integrations.Notifier, 0, len(targets))
+	for _, target := range targets {
+		n, err := Parse(target)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, n)
+	}
+	return notifiers, nil
+}
+
+// Send delivers a report to every notifier and returns the failures
+func Send(ctx context.Context, notifiers []integrations.Notifier, report *integrations.Report) []error {
+	var errs []error
+	for _, n := range notifiers {
+		if err := n.Notify(ctx, report); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
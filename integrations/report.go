@@ -0,0 +1,154 @@
+// Package integrations formats estimation results for chat and CI systems
+// Each integration renders the same Report, so every channel shows the same facts.
+package integrations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/decision/currency"
+	"terraform-cost/decision/estimation"
+	"terraform-cost/decision/policy"
+)
+
+// DefaultTopDrivers is the number of cost drivers included in notifications
+const DefaultTopDrivers = 5
+
+// Notifier delivers a report to an external system
+type Notifier interface {
+	Notify(ctx context.Context, report *Report) error
+}
+
+// Report is the channel-neutral summary of an estimate
+type Report struct {
+	Title       string // e.g. project or plan name
+	Environment string
+	Link        string // optional link to the PR, pipeline or HTML report
+
+	Currency       string
+	MonthlyCostP50 decimal.Decimal
+	MonthlyCostP90 decimal.Decimal
+	BaselineP50    *decimal.Decimal // nil when no baseline is known
+	Confidence     float64
+	IsIncomplete   bool
+
+	TopDrivers []Driver
+
+	PolicyDecision policy.Decision // empty when policies were skipped
+	Violations     []string
+	Warnings       []string
+}
+
+// Driver is one line of the top cost drivers list
+type Driver struct {
+	Description    string
+	Quantity       int
+	MonthlyCostP50 decimal.Decimal
+	IsSymbolic     bool
+}
+
+// NewReport summarizes an estimate; policyResult and baseline may be nil
+func NewReport(title string, result *estimation.EstimationResult, policyResult *policy.EvaluationResult, baseline *policy.Baseline) *Report {
+	r := &Report{
+		Title:          title,
+		Environment:    result.AuditTrail.Environment,
+		Currency:       currency.Normalize(result.Currency),
+		MonthlyCostP50: result.MonthlyCostP50,
+		MonthlyCostP90: result.MonthlyCostP90,
+		Confidence:     result.Confidence,
+		IsIncomplete:   result.IsIncomplete,
+	}
+
+	for i, g := range result.CostGroups {
+		if i == DefaultTopDrivers {
+			break
+		}
+		r.TopDrivers = append(r.TopDrivers, Driver{
+			Description:    g.Description,
+			Quantity:       g.Quantity,
+			MonthlyCostP50: g.MonthlyCostP50,
+			IsSymbolic:     g.IsSymbolic,
+		})
+	}
+
+	if baseline != nil {
+		p50 := baseline.MonthlyCostP50
+		r.BaselineP50 = &p50
+	}
+
+	if policyResult != nil {
+		r.PolicyDecision = policyResult.Decision
+		for _, v := range policyResult.Violations {
+			r.Violations = append(r.Violations, v.Message)
+		}
+		for _, w := range policyResult.Warnings {
+			r.Warnings = append(r.Warnings, w.Message)
+		}
+	}
+	return r
+}
+
+// Money formats an amount in the report currency
+func (r *Report) Money(d decimal.Decimal) string {
+	return currency.Format(d, r.Currency, 2)
+}
+
+// Delta describes the change from the baseline ("+$120.00 (+12.0%)"), or "" without one
+func (r *Report) Delta() string {
+	if r.BaselineP50 == nil {
+		return ""
+	}
+	diff := r.MonthlyCostP50.Sub(*r.BaselineP50)
+	sign := "+"
+	if diff.IsNegative() {
+		sign = "-"
+	}
+	delta := sign + r.Money(diff.Abs())
+	if r.BaselineP50.IsPositive() {
+		pct := diff.Div(*r.BaselineP50).Mul(decimal.NewFromInt(100)).InexactFloat64()
+		delta += fmt.Sprintf(" (%+.1f%%)", pct)
+	}
+	return delta
+}
+
+// DecisionEmoji returns the verdict marker used across chat integrations
+func (r *Report) DecisionEmoji() string {
+	switch r.PolicyDecision {
+	case policy.DecisionPass:
+		return "✅"
+	case policy.DecisionWarn:
+		return "⚠️"
+	case policy.DecisionDeny:
+		return "❌"
+	default:
+		return "ℹ️"
+	}
+}
+
+// DecisionLabel returns the verdict in words
+func (r *Report) DecisionLabel() string {
+	switch r.PolicyDecision {
+	case policy.DecisionPass:
+		return "Pass"
+	case policy.DecisionWarn:
+		return "Warn"
+	case policy.DecisionDeny:
+		return "Deny"
+	default:
+		return "Not evaluated"
+	}
+}
+
+// DriverLine renders a driver as "2× EC2 m5.large — $140.16"
+func (r *Report) DriverLine(d Driver) string {
+	name := d.Description
+	if d.Quantity > 1 {
+		name = fmt.Sprintf("%d× %s", d.Quantity, name)
+	}
+	if d.IsSymbolic {
+		return name + " — unknown"
+	}
+	return name + " — " + r.Money(d.MonthlyCostP50)
+}
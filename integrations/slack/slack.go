@@ -0,0 +1,180 @@
+// Package slack posts estimation reports to Slack as Block Kit messages
+// Messages go either to an incoming webhook or, with a bot token, to any channel.
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"terraform-cost/integrations"
+)
+
+// PostMessageURL is the Web API endpoint used with bot tokens
+const PostMessageURL = "https://slack.com/api/chat.postMessage"
+
+// Message is a Block Kit message
+type Message struct {
+	Channel string  `json:"channel,omitempty"`
+	Text    string  `json:"text"` // notification fallback
+	Blocks  []Block `json:"blocks"`
+}
+
+// Block is a Block Kit layout block (header, section, divider, context)
+type Block struct {
+	Type     string `json:"type"`
+	Text     *Text  `json:"text,omitempty"`
+	Fields   []Text `json:"fields,omitempty"`
+	Elements []Text `json:"elements,omitempty"`
+}
+
+// Text is a plain_text or mrkdwn text object
+type Text struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func plain(s string) *Text    { return &Text{Type: "plain_text", Text: s} }
+func markdown(s string) *Text { return &Text{Type: "mrkdwn", Text: s} }
+
+// escape escapes the characters Slack treats as control sequences
+func escape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// BuildMessage renders a report as Block Kit
+func BuildMessage(r *integrations.Report) Message {
+	title := "💰 Cost estimate"
+	if r.Title != "" {
+		title += ": " + r.Title
+	}
+
+	fields := []Text{
+		*markdown("*Monthly (P50)*\n" + r.Money(r.MonthlyCostP50)),
+		*markdown("*Monthly (P90)*\n" + r.Money(r.MonthlyCostP90)),
+	}
+	if delta := r.Delta(); delta != "" {
+		fields = append(fields, *markdown("*Change vs baseline*\n" + delta))
+	}
+	fields = append(fields, *markdown(fmt.Sprintf("*Policy*\n%s %s", r.DecisionEmoji(), r.DecisionLabel())))
+
+	blocks := []Block{
+		{Type: "header", Text: plain(title)},
+		{Type: "section", Fields: fields},
+	}
+
+	if len(r.TopDrivers) > 0 {
+		var sb strings.Builder
+		sb.WriteString("*Top cost drivers*")
+		for _, d := range r.TopDrivers {
+			sb.WriteString("\n• " + escape(r.DriverLine(d)))
+		}
+		blocks = append(blocks, Block{Type: "divider"}, Block{Type: "section", Text: markdown(sb.String())})
+	}
+
+	if len(r.Violations) > 0 || len(r.Warnings) > 0 {
+		var sb strings.Builder
+		for _, v := range r.Violations {
+			sb.WriteString("❌ " + escape(v) + "\n")
+		}
+		for _, w := range r.Warnings {
+			sb.WriteString("⚠️ " + escape(w) + "\n")
+		}
+		blocks = append(blocks, Block{Type: "section", Text: markdown(strings.TrimSuffix(sb.String(), "\n"))})
+	}
+
+	footer := []Text{*markdown(fmt.Sprintf("Confidence %.0f%%", r.Confidence*100))}
+	if r.Environment != "" {
+		footer = append(footer, *markdown("Environment: " + escape(r.Environment)))
+	}
+	if r.IsIncomplete {
+		footer = append(footer, *markdown("⚠️ Some components could not be priced"))
+	}
+	if r.Link != "" {
+		footer = append(footer, *markdown(fmt.Sprintf("<%s|Details>", r.Link)))
+	}
+	blocks = append(blocks, Block{Type: "context", Elements: footer})
+
+	return Message{
+		Text:   fmt.Sprintf("%s — %s/month (%s)", title, r.Money(r.MonthlyCostP50), r.DecisionLabel()),
+		Blocks: blocks,
+	}
+}
+
+// Notifier posts reports to Slack
+type Notifier struct {
+	client  *http.Client
+	url     string
+	token   string // bot token; empty for incoming webhooks
+	channel string
+}
+
+// NewWebhookNotifier posts to an incoming webhook URL
+func NewWebhookNotifier(webhookURL string) *Notifier {
+	return &Notifier{client: &http.Client{Timeout: 10 * time.Second}, url: webhookURL}
+}
+
+// NewBotNotifier posts to a channel with a bot token (chat:write scope)
+func NewBotNotifier(token, channel string) *Notifier {
+	return &Notifier{
+		client:  &http.Client{Timeout: 10 * time.Second},
+		url:     PostMessageURL,
+		token:   token,
+		channel: channel,
+	}
+}
+
+// WithURL overrides the endpoint (tests, Slack Enterprise Grid proxies)
+func (n *Notifier) WithURL(url string) *Notifier {
+	n.url = url
+	return n
+}
+
+// Notify sends a report
+func (n *Notifier) Notify(ctx context.Context, r *integrations.Report) error {
+	msg := BuildMessage(r)
+	msg.Channel = n.channel
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode Slack message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post to Slack: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	// The Web API reports failures in the body with HTTP 200
+	if n.token != "" {
+		var result struct {
+			OK    bool   `json:"ok"`
+			Error string `json:"error"`
+		}
+		if err := json.Unmarshal(respBody, &result); err != nil {
+			return fmt.Errorf("invalid Slack response: %w", err)
+		}
+		if !result.OK {
+			return fmt.Errorf("slack rejected the message: %s", result.Error)
+		}
+	}
+	return nil
+}
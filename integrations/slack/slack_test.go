@@ -0,0 +1,79 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/decision/policy"
+	"terraform-cost/integrations"
+)
+
+func testReport() *integrations.Report {
+	baseline := decimal.NewFromInt(100)
+	return &integrations.Report{
+		Title:          "payments",
+		Currency:       "USD",
+		MonthlyCostP50: decimal.NewFromInt(125),
+		MonthlyCostP90: decimal.NewFromInt(150),
+		BaselineP50:    &baseline,
+		Confidence:     0.8,
+		TopDrivers:     []integrations.Driver{{Description: "EC2 <m5.large>", Quantity: 2, MonthlyCostP50: decimal.NewFromInt(125)}},
+		PolicyDecision: policy.DecisionDeny,
+		Violations:     []string{"Monthly cost exceeds limit"},
+	}
+}
+
+func TestBuildMessage(t *testing.T) {
+	msg := BuildMessage(testReport())
+
+	var sb strings.Builder
+	for _, b := range msg.Blocks {
+		if b.Text != nil {
+			sb.WriteString(b.Text.Text + "\n")
+		}
+		for _, f := range b.Fields {
+			sb.WriteString(f.Text + "\n")
+		}
+	}
+	out := sb.String()
+	for _, want := range []string{
+		"Cost estimate: payments",
+		"+$25.00 (+25.0%)",
+		"❌ Deny",
+		"2× EC2 &lt;m5.large&gt; — $125.00",
+		"Monthly cost exceeds limit",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("message missing %q:\n%s", want, out)
+		}
+	}
+	if msg.Blocks[0].Type != "header" || msg.Blocks[len(msg.Blocks)-1].Type != "context" {
+		t.Errorf("unexpected block layout: %+v", msg.Blocks)
+	}
+}
+
+func TestBotNotifierReportsAPIErrors(t *testing.T) {
+	var got Message
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer xoxb-test" {
+			t.Errorf("missing bot token")
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"ok":false,"error":"channel_not_found"}`))
+	}))
+	defer srv.Close()
+
+	err := NewBotNotifier("xoxb-test", "finops").WithURL(srv.URL).Notify(context.Background(), testReport())
+	if err == nil || !strings.Contains(err.Error(), "channel_not_found") {
+		t.Errorf("expected channel_not_found error, got %v", err)
+	}
+	if got.Channel != "finops" {
+		t.Errorf("channel = %q", got.Channel)
+	}
+}
@@ -0,0 +1,189 @@
+// Package teams posts estimation reports to Microsoft Teams as Adaptive Cards
+// Cards are delivered through a Teams incoming webhook or Workflows webhook URL.
+package teams
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"terraform-cost/integrations"
+)
+
+// AdaptiveCardVersion is the schema version emitted; 1.4 renders on all Teams clients
+const AdaptiveCardVersion = "1.4"
+
+// Message is the webhook payload wrapping an Adaptive Card
+type Message struct {
+	Type        string       `json:"type"`
+	Attachments []Attachment `json:"attachments"`
+}
+
+// Attachment carries the card
+type Attachment struct {
+	ContentType string `json:"contentType"`
+	Content     Card   `json:"content"`
+}
+
+// Card is an Adaptive Card
+type Card struct {
+	Schema  string   `json:"$schema"`
+	Type    string   `json:"type"`
+	Version string   `json:"version"`
+	Body    []any    `json:"body"`
+	Actions []Action `json:"actions,omitempty"`
+	MSTeams *MSTeams `json:"msteams,omitempty"`
+}
+
+// MSTeams holds Teams-specific card options
+type MSTeams struct {
+	Width string `json:"width,omitempty"`
+}
+
+// TextBlock is an Adaptive Card text element
+type TextBlock struct {
+	Type    string `json:"type"`
+	Text    string `json:"text"`
+	Size    string `json:"size,omitempty"`
+	Weight  string `json:"weight,omitempty"`
+	Color   string `json:"color,omitempty"`
+	Wrap    bool   `json:"wrap"`
+	Spacing string `json:"spacing,omitempty"`
+}
+
+// FactSet is a list of label/value pairs
+type FactSet struct {
+	Type  string `json:"type"`
+	Facts []Fact `json:"facts"`
+}
+
+// Fact is one FactSet row
+type Fact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// Action is a card button
+type Action struct {
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+func text(s string) TextBlock {
+	return TextBlock{Type: "TextBlock", Text: s, Wrap: true}
+}
+
+// BuildCard renders a report as an Adaptive Card message
+func BuildCard(r *integrations.Report) Message {
+	title := "💰 Cost estimate"
+	if r.Title != "" {
+		title += ": " + r.Title
+	}
+	heading := text(title)
+	heading.Size, heading.Weight = "Large", "Bolder"
+
+	facts := []Fact{
+		{Title: "Monthly (P50)", Value: r.Money(r.MonthlyCostP50)},
+		{Title: "Monthly (P90)", Value: r.Money(r.MonthlyCostP90)},
+	}
+	if delta := r.Delta(); delta != "" {
+		facts = append(facts, Fact{Title: "Change vs baseline", Value: delta})
+	}
+	facts = append(facts,
+		Fact{Title: "Policy", Value: r.DecisionEmoji() + " " + r.DecisionLabel()},
+		Fact{Title: "Confidence", Value: fmt.Sprintf("%.0f%%", r.Confidence*100)},
+	)
+	if r.Environment != "" {
+		facts = append(facts, Fact{Title: "Environment", Value: r.Environment})
+	}
+
+	body := []any{heading, FactSet{Type: "FactSet", Facts: facts}}
+
+	if len(r.TopDrivers) > 0 {
+		label := text("Top cost drivers")
+		label.Weight, label.Spacing = "Bolder", "Medium"
+		lines := make([]string, len(r.TopDrivers))
+		for i, d := range r.TopDrivers {
+			lines[i] = "- " + r.DriverLine(d)
+		}
+		body = append(body, label, text(strings.Join(lines, "\n")))
+	}
+
+	for _, v := range r.Violations {
+		block := text("❌ " + v)
+		block.Color = "Attention"
+		body = append(body, block)
+	}
+	for _, w := range r.Warnings {
+		block := text("⚠️ " + w)
+		block.Color = "Warning"
+		body = append(body, block)
+	}
+	if r.IsIncomplete {
+		block := text("Some components could not be priced; totals are incomplete.")
+		block.Color = "Warning"
+		body = append(body, block)
+	}
+
+	card := Card{
+		Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+		Type:    "AdaptiveCard",
+		Version: AdaptiveCardVersion,
+		Body:    body,
+		MSTeams: &MSTeams{Width: "Full"},
+	}
+	if r.Link != "" {
+		card.Actions = []Action{{Type: "Action.OpenUrl", Title: "View details", URL: r.Link}}
+	}
+
+	return Message{
+		Type: "message",
+		Attachments: []Attachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content:     card,
+		}},
+	}
+}
+
+// Notifier posts reports to a Teams webhook
+type Notifier struct {
+	client *http.Client
+	url    string
+}
+
+// NewNotifier creates a notifier for a Teams webhook URL
+func NewNotifier(webhookURL string) *Notifier {
+	return &Notifier{client: &http.Client{Timeout: 10 * time.Second}, url: webhookURL}
+}
+
+// Notify sends a report
+func (n *Notifier) Notify(ctx context.Context, r *integrations.Report) error {
+	body, err := json.Marshal(BuildCard(r))
+	if err != nil {
+		return fmt.Errorf("failed to encode Teams card: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post to Teams: %w", err)
+	}
+	defer resp.Body.Close()
+	// Incoming webhooks answer 200, Workflows webhooks 202
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		return fmt.Errorf("teams returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package teams
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/decision/policy"
+	"terraform-cost/integrations"
+)
+
+func TestBuildCard(t *testing.T) {
+	msg := BuildCard(&integrations.Report{
+		Title:          "payments",
+		Currency:       "EUR",
+		MonthlyCostP50: decimal.NewFromInt(90),
+		MonthlyCostP90: decimal.NewFromInt(110),
+		PolicyDecision: policy.DecisionWarn,
+		Warnings:       []string{"Cost grew 15%"},
+		Link:           "https://example.com/pr/1",
+	})
+
+	if len(msg.Attachments) != 1 || msg.Attachments[0].ContentType != "application/vnd.microsoft.card.adaptive" {
+		t.Fatalf("unexpected attachments %+v", msg.Attachments)
+	}
+	card := msg.Attachments[0].Content
+	if card.Version != AdaptiveCardVersion || len(card.Actions) != 1 {
+		t.Errorf("unexpected card %+v", card)
+	}
+
+	data, _ := json.Marshal(msg)
+	for _, want := range []string{`"$schema"`, "€90.00", "⚠️ Warn", "Cost grew 15%", `"color":"Warning"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("card missing %q", want)
+		}
+	}
+}
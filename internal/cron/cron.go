@@ -0,0 +1,188 @@
+// Package cron parses cron schedules for TerraCost's background jobs
+// Specs have five fields (minute, hour, day of month, month, day of week) with
+// *, lists, ranges and steps, or are one of @hourly, @daily, @weekly, @monthly
+// or @every <duration>. Like cron, a spec restricting both day of month and day
+// of week fires on days matching either.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron spec
+type Schedule struct {
+	spec   string
+	every  time.Duration // @every; the fields are unused
+	minute [60]bool
+	hour   [24]bool
+	dom    [32]bool
+	month  [13]bool
+	dow    [7]bool
+
+	domAny, dowAny bool // The field is *
+}
+
+var descriptors = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var weekdayNames = map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+
+// Parse parses a cron spec
+func Parse(spec string) (*Schedule, error) {
+	s := &Schedule{spec: spec}
+	trimmed := strings.TrimSpace(spec)
+	if rest, ok := strings.CutPrefix(trimmed, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil || d < time.Minute {
+			return nil, fmt.Errorf("invalid cron spec %q: @every needs a duration of at least 1m", spec)
+		}
+		s.every = d
+		return s, nil
+	}
+	if expanded, ok := descriptors[trimmed]; ok {
+		trimmed = expanded
+	}
+
+	fields := strings.Fields(trimmed)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron spec %q: want 5 fields (minute hour day-of-month month day-of-week)", spec)
+	}
+	set := func(field, name string, lo, hi int, names map[string]int, mark func(int)) error {
+		values, err := parseField(field, lo, hi, names)
+		if err != nil {
+			return fmt.Errorf("invalid cron spec %q: %s: %w", spec, name, err)
+		}
+		for _, v := range values {
+			mark(v)
+		}
+		return nil
+	}
+	if err := set(fields[0], "minute", 0, 59, nil, func(v int) { s.minute[v] = true }); err != nil {
+		return nil, err
+	}
+	if err := set(fields[1], "hour", 0, 23, nil, func(v int) { s.hour[v] = true }); err != nil {
+		return nil, err
+	}
+	if err := set(fields[2], "day of month", 1, 31, nil, func(v int) { s.dom[v] = true }); err != nil {
+		return nil, err
+	}
+	if err := set(fields[3], "month", 1, 12, monthNames, func(v int) { s.month[v] = true }); err != nil {
+		return nil, err
+	}
+	// 7 is Sunday as well as 0
+	if err := set(fields[4], "day of week", 0, 7, weekdayNames, func(v int) { s.dow[v%7] = true }); err != nil {
+		return nil, err
+	}
+	s.domAny = fields[2] == "*"
+	s.dowAny = fields[4] == "*"
+	return s, nil
+}
+
+// String returns the spec the schedule was parsed from
+func (s *Schedule) String() string {
+	return s.spec
+}
+
+// Next returns the first time after t the schedule fires, in t's location
+// It returns the zero time when nothing matches within five years (e.g. 30 February).
+func (s *Schedule) Next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Add(s.every)
+	}
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case !s.month[t.Month()]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !s.hour[t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !s.minute[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches applies cron's day rule: with both day fields restricted, either may match
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom, dow := s.dom[t.Day()], s.dow[t.Weekday()]
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// parseField expands a field (*, lists, ranges and steps) into the values it matches
+func parseField(field string, lo, hi int, names map[string]int) ([]int, error) {
+	value := func(s string) (int, error) {
+		if n, ok := names[strings.ToLower(s)]; ok {
+			return n, nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < lo || n > hi {
+			return 0, fmt.Errorf("%q is not between %d and %d", s, lo, hi)
+		}
+		return n, nil
+	}
+
+	var values []int
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid step %q", stepStr)
+			}
+			step = n
+		}
+
+		from, to := lo, hi
+		if rng != "*" {
+			start, end, isRange := strings.Cut(rng, "-")
+			var err error
+			if from, err = value(start); err != nil {
+				return nil, err
+			}
+			to = from
+			if isRange {
+				if to, err = value(end); err != nil {
+					return nil, err
+				}
+				if to < from {
+					return nil, fmt.Errorf("range %q is backwards", rng)
+				}
+			} else if hasStep {
+				to = hi // 5/15 means from 5 to the end in steps of 15
+			}
+		}
+		for v := from; v <= to; v += step {
+			values = append(values, v)
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,199 @@
+// Package health aggregates the health of the services a TerraCost server depends on
+// A ServiceRegistry checks every registered dependency on an interval and
+// keeps each one's status and latency, so readiness probes answer from the
+// last check instead of calling every dependency per probe. A server is ready
+// while all critical dependencies are up; non-critical ones are reported only.
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Dependency statuses
+const (
+	StatusUp      = "up"
+	StatusDown    = "down"
+	StatusUnknown = "unknown" // Not checked yet
+)
+
+// Defaults for registries created with zero values
+const (
+	DefaultInterval = 15 * time.Second
+	DefaultTimeout  = 5 * time.Second
+)
+
+// Check reports a dependency's health; a nil error is up
+type Check func(ctx context.Context) error
+
+// Dependency is a service the server needs
+type Dependency struct {
+	Name     string
+	Critical bool // The server is not ready while a critical dependency is down
+	Check    Check
+}
+
+// DependencyStatus is the outcome of a dependency's last check
+type DependencyStatus struct {
+	Name      string     `json:"name"`
+	Status    string     `json:"status"`
+	Critical  bool       `json:"critical"`
+	LatencyMS float64    `json:"latency_ms"`
+	Error     string     `json:"error,omitempty"`
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+	LastUpAt  *time.Time `json:"last_up_at,omitempty"`
+}
+
+// ServiceRegistry checks dependencies and keeps their latest status
+type ServiceRegistry struct {
+	interval time.Duration
+	timeout  time.Duration
+
+	mu       sync.RWMutex
+	deps     []Dependency
+	statuses map[string]DependencyStatus
+}
+
+// NewServiceRegistry creates a registry checking every interval, each check bounded by timeout
+func NewServiceRegistry(interval, timeout time.Duration) *ServiceRegistry {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &ServiceRegistry{interval: interval, timeout: timeout, statuses: make(map[string]DependencyStatus)}
+}
+
+// Register adds a dependency, replacing any of the same name
+func (r *ServiceRegistry) Register(dep Dependency) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, d := range r.deps {
+		if d.Name == dep.Name {
+			r.deps[i] = dep
+			r.statuses[dep.Name] = DependencyStatus{Name: dep.Name, Status: StatusUnknown, Critical: dep.Critical}
+			return
+		}
+	}
+	r.deps = append(r.deps, dep)
+	r.statuses[dep.Name] = DependencyStatus{Name: dep.Name, Status: StatusUnknown, Critical: dep.Critical}
+}
+
+// CheckAll checks every dependency concurrently and records the outcomes
+func (r *ServiceRegistry) CheckAll(ctx context.Context) {
+	r.mu.RLock()
+	deps := append([]Dependency(nil), r.deps...)
+	r.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, dep := range deps {
+		wg.Add(1)
+		go func(dep Dependency) {
+			defer wg.Done()
+			r.record(dep, r.check(ctx, dep))
+		}(dep)
+	}
+	wg.Wait()
+}
+
+// check runs one dependency's check within the timeout
+func (r *ServiceRegistry) check(ctx context.Context, dep Dependency) DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := dep.Check(ctx)
+	checked := time.Now().UTC()
+	status := DependencyStatus{
+		Name:      dep.Name,
+		Status:    StatusUp,
+		Critical:  dep.Critical,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+		CheckedAt: &checked,
+	}
+	if err != nil {
+		status.Status = StatusDown
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// record stores a check outcome, carrying over when the dependency was last up
+func (r *ServiceRegistry) record(dep Dependency, status DependencyStatus) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.statuses[dep.Name]; !ok {
+		return // Replaced while checking
+	}
+	if status.Status == StatusUp {
+		status.LastUpAt = status.CheckedAt
+	} else {
+		status.LastUpAt = r.statuses[dep.Name].LastUpAt
+	}
+	r.statuses[dep.Name] = status
+}
+
+// Statuses returns the latest status of every dependency, by name
+func (r *ServiceRegistry) Statuses() []DependencyStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	statuses := make([]DependencyStatus, 0, len(r.statuses))
+	for _, s := range r.statuses {
+		statuses = append(statuses, s)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// Ready reports whether every critical dependency was up at its last check
+func (r *ServiceRegistry) Ready() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, s := range r.statuses {
+		if s.Critical && s.Status != StatusUp {
+			return false
+		}
+	}
+	return true
+}
+
+// Run checks immediately and then every interval until ctx is cancelled
+func (r *ServiceRegistry) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		r.CheckAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// HTTPCheck checks a service's health endpoint; any 2xx response is up
+func HTTPCheck(client *http.Client, url string) Check {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("%s returned %s", url, resp.Status)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,73 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestServiceRegistryReadyFollowsCriticalDependencies(t *testing.T) {
+	var pricingErr, cacheErr error
+	r := NewServiceRegistry(0, 0)
+	r.Register(Dependency{Name: "pricing", Critical: true, Check: func(context.Context) error { return pricingErr }})
+	r.Register(Dependency{Name: "cache", Check: func(context.Context) error { return cacheErr }})
+
+	if r.Ready() {
+		t.Fatal("ready before the first check")
+	}
+
+	cacheErr = errors.New("connection refused")
+	r.CheckAll(context.Background())
+	if !r.Ready() {
+		t.Error("a non-critical dependency being down should not block readiness")
+	}
+	statuses := r.Statuses()
+	if len(statuses) != 2 || statuses[0].Name != "cache" || statuses[0].Status != StatusDown || statuses[0].Error == "" {
+		t.Fatalf("statuses = %+v", statuses)
+	}
+
+	pricingErr = errors.New("timeout")
+	r.CheckAll(context.Background())
+	if r.Ready() {
+		t.Error("ready while a critical dependency is down")
+	}
+	pricing := r.Statuses()[1]
+	if pricing.Status != StatusDown || pricing.LastUpAt == nil || pricing.CheckedAt == nil {
+		t.Errorf("pricing = %+v, want down with the last up time kept", pricing)
+	}
+	if !pricing.LastUpAt.Before(*pricing.CheckedAt) {
+		t.Error("last up time should predate the failing check")
+	}
+}
+
+func TestServiceRegistryCheckTimeout(t *testing.T) {
+	r := NewServiceRegistry(time.Minute, 10*time.Millisecond)
+	r.Register(Dependency{Name: "slow", Critical: true, Check: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	r.CheckAll(context.Background())
+	if s := r.Statuses()[0]; s.Status != StatusDown {
+		t.Errorf("slow dependency = %s, want down", s.Status)
+	}
+}
+
+func TestHTTPCheck(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	check := HTTPCheck(srv.Client(), srv.URL+"/health")
+	if err := check(context.Background()); err != nil {
+		t.Errorf("200: %v", err)
+	}
+	status = http.StatusServiceUnavailable
+	if err := check(context.Background()); err == nil {
+		t.Error("503 should be down")
+	}
+}
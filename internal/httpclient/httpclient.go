@@ -0,0 +1,270 @@
+// Package httpclient is the shared HTTP client for calls TerraCost makes to other services
+// Each attempt gets its own timeout, transient failures (connection errors,
+// 429 and 502-504) are retried with jittered exponential backoff, and a
+// circuit breaker per host fails calls fast once a service keeps failing, so
+// one unhealthy dependency costs an estimate milliseconds instead of its
+// whole timeout. Connections are pooled per host.
+//
+//	client := httpclient.New(httpclient.DefaultConfig())
+//	resp, err := client.Do(req) // errors.Is(err, httpclient.ErrCircuitOpen) while the host is failing
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"terraform-cost/telemetry"
+)
+
+// ErrCircuitOpen is returned without calling a host whose circuit is open
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// Config holds client settings
+type Config struct {
+	Timeout    time.Duration // Per attempt, until the response body is closed; 0 is unlimited
+	MaxRetries int           // Retries of transient failures; 0 disables them
+	MinBackoff time.Duration // Wait before the first retry, doubling per attempt
+	MaxBackoff time.Duration // Longest wait between attempts, also caps Retry-After
+	RetryPOST  bool          // POSTs are queries safe to repeat, e.g. OPA decisions
+
+	FailureThreshold int           // Consecutive failures that open a host's circuit; 0 disables the breaker
+	OpenTimeout      time.Duration // How long an open circuit fails fast before a trial call
+
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+}
+
+// DefaultConfig returns default client settings
+func DefaultConfig() *Config {
+	return &Config{
+		Timeout:    10 * time.Second,
+		MaxRetries: 2,
+		MinBackoff: 100 * time.Millisecond,
+		MaxBackoff: 2 * time.Second,
+
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+
+		MaxIdleConnsPerHost: 16,
+		IdleConnTimeout:     90 * time.Second,
+	}
+}
+
+// New creates a client with a resilient transport
+func New(config *Config) *http.Client {
+	return &http.Client{Transport: NewTransport(config, nil)}
+}
+
+// Transport is an http.RoundTripper adding timeouts, retries and circuit breaking
+type Transport struct {
+	config *Config
+	base   http.RoundTripper
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+// NewTransport wraps a base transport; nil uses a pooled copy of http.DefaultTransport
+func NewTransport(config *Config, base http.RoundTripper) *Transport {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	if base == nil {
+		pooled := http.DefaultTransport.(*http.Transport).Clone()
+		if config.MaxIdleConnsPerHost > 0 {
+			pooled.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+		}
+		if config.IdleConnTimeout > 0 {
+			pooled.IdleConnTimeout = config.IdleConnTimeout
+		}
+		base = pooled
+	}
+	return &Transport{config: config, base: base, breakers: make(map[string]*breaker)}
+}
+
+// RoundTrip sends a request, retrying transient failures while the host's circuit allows
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Calls made for an API request carry its ID, so they can be found in the callee's logs
+	if id := telemetry.RequestID(req.Context()); id != "" && req.Header.Get(telemetry.RequestIDHeader) == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set(telemetry.RequestIDHeader, id)
+	}
+	b := t.breaker(req.URL.Host)
+	for attempt := 0; ; attempt++ {
+		if !b.allow(time.Now()) {
+			return nil, fmt.Errorf("%s: %w", req.URL.Host, ErrCircuitOpen)
+		}
+
+		resp, err := t.send(req, attempt)
+		failed := err != nil || transient(resp.StatusCode)
+		b.record(!failed, time.Now())
+		if !failed || attempt >= t.config.MaxRetries || !t.replayable(req) || req.Context().Err() != nil {
+			return resp, err
+		}
+
+		var retryAfter time.Duration
+		if resp != nil {
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4096)) // Lets the connection be reused
+			resp.Body.Close()
+		}
+		select {
+		case <-time.After(t.backoff(attempt, retryAfter)):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// send sends one attempt within the per-attempt timeout
+func (t *Transport) send(req *http.Request, attempt int) (*http.Response, error) {
+	if attempt > 0 && req.Body != nil && req.Body != http.NoBody {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = body
+	}
+	if t.config.Timeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.config.Timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// The timeout covers reading the body too
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// replayable reports whether a request may be sent again
+func (t *Transport) replayable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	case http.MethodPost:
+		return t.config.RetryPOST
+	}
+	return false
+}
+
+// backoff returns the wait before the next attempt: the server's Retry-After if
+// it sent one, else exponential backoff with jitter
+func (t *Transport) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, t.config.MaxBackoff)
+	}
+	wait := t.config.MinBackoff << attempt
+	if wait <= 0 || wait > t.config.MaxBackoff {
+		wait = t.config.MaxBackoff
+	}
+	// Full jitter over the upper half spreads out callers retrying together
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+// breaker returns the circuit breaker of a host
+func (t *Transport) breaker(host string) *breaker {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b, ok := t.breakers[host]
+	if !ok {
+		b = &breaker{threshold: t.config.FailureThreshold, openTimeout: t.config.OpenTimeout}
+		t.breakers[host] = b
+	}
+	return b
+}
+
+// transient reports whether a response status is worth retrying
+func transient(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter reads a Retry-After header in seconds
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// cancelBody releases an attempt's timeout once its body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// =============================================================================
+// CIRCUIT BREAKER
+// =============================================================================
+
+// breaker opens after consecutive failures and, once the open timeout passes,
+// lets a single trial call through: success closes it, failure reopens it
+type breaker struct {
+	threshold   int
+	openTimeout time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	trial     bool // A trial call is in flight
+}
+
+// allow reports whether a call may be sent
+func (b *breaker) allow(now time.Time) bool {
+	if b.threshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return true
+	}
+	if now.Before(b.openUntil) || b.trial {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+// record counts a call's outcome
+func (b *breaker) record(ok bool, now time.Time) {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+	if ok {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = now.Add(b.openTimeout)
+	}
+}
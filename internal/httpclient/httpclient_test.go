@@ -0,0 +1,140 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"terraform-cost/telemetry"
+)
+
+func testConfig() *Config {
+	config := DefaultConfig()
+	config.MinBackoff = time.Millisecond
+	config.MaxBackoff = time.Millisecond
+	return config
+}
+
+func TestTransportRetriesTransientFailures(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	config := testConfig()
+	config.RetryPOST = true
+	resp, err := New(config).Post(srv.URL, "application/json", strings.NewReader(`{"input":{}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != `{"input":{}}` || calls.Load() != 3 {
+		t.Errorf("status %d after %d calls, body %q", resp.StatusCode, calls.Load(), body)
+	}
+
+	// POSTs aren't repeated unless they're safe to
+	calls.Store(0)
+	resp, err = New(testConfig()).Post(srv.URL, "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || calls.Load() != 1 {
+		t.Errorf("status %d after %d calls, want one 503", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestTransportCircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+	healthy := atomic.Bool{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	config := testConfig()
+	config.MaxRetries = 0
+	config.FailureThreshold = 2
+	config.OpenTimeout = 20 * time.Millisecond
+	client := New(config)
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if _, err := client.Get(srv.URL); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want circuit open", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("open circuit called the server: %d calls", calls.Load())
+	}
+
+	// After the open timeout a successful trial call closes the circuit
+	time.Sleep(30 * time.Millisecond)
+	healthy.Store(true)
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("call %d after recovery: %v", i, err)
+		}
+		resp.Body.Close()
+	}
+}
+
+func TestTransportAttemptTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	config := testConfig()
+	config.Timeout = 20 * time.Millisecond
+	config.MaxRetries = 1
+	start := time.Now()
+	if _, err := New(config).Get(srv.URL); err == nil {
+		t.Fatal("expected a timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("took %s; attempts should time out after 20ms", elapsed)
+	}
+}
+
+func TestTransportForwardsRequestID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get(telemetry.RequestIDHeader)))
+	}))
+	defer srv.Close()
+
+	req, _ := http.NewRequestWithContext(telemetry.WithRequestID(context.Background(), "req-7"), http.MethodGet, srv.URL, nil)
+	resp, err := New(testConfig()).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "req-7" || req.Header.Get(telemetry.RequestIDHeader) != "" {
+		t.Errorf("server saw %q; caller's request headers %v", body, req.Header)
+	}
+}
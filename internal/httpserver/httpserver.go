@@ -0,0 +1,162 @@
+// Package httpserver is the shared HTTP server bootstrap for TerraCost services
+// Servers get read, write and idle timeouts and a request body limit, and shut
+// down gracefully on SIGINT/SIGTERM: readiness reports draining first so load
+// balancers stop routing new requests, then in-flight requests finish.
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Config holds HTTP server settings
+type Config struct {
+	Addr              string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration // Keep-alive connections idle longer are closed
+	MaxBodySize       int64         // Larger request bodies fail to read; 0 is unlimited
+
+	DrainDelay      time.Duration // Readiness reports draining this long before the listener closes
+	ShutdownTimeout time.Duration // Longest in-flight requests get to finish once the listener closes
+}
+
+// DefaultConfig returns default server settings
+func DefaultConfig() *Config {
+	return &Config{
+		Addr:              ":8080",
+		ReadTimeout:       30 * time.Second,
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      60 * time.Second,
+		IdleTimeout:       120 * time.Second,
+		MaxBodySize:       10 * 1024 * 1024, // 10MB
+		DrainDelay:        5 * time.Second,
+		ShutdownTimeout:   30 * time.Second,
+	}
+}
+
+// Server is an HTTP server with graceful shutdown
+type Server struct {
+	config   *Config
+	server   *http.Server
+	draining atomic.Bool
+
+	mu    sync.Mutex
+	hooks []func(context.Context)
+}
+
+// New creates a server for a handler
+func New(handler http.Handler, config *Config) *Server {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	if config.MaxBodySize > 0 {
+		handler = limitBody(handler, config.MaxBodySize)
+	}
+	return &Server{
+		config: config,
+		server: &http.Server{
+			Addr:              config.Addr,
+			Handler:           handler,
+			ReadTimeout:       config.ReadTimeout,
+			ReadHeaderTimeout: config.ReadHeaderTimeout,
+			WriteTimeout:      config.WriteTimeout,
+			IdleTimeout:       config.IdleTimeout,
+		},
+	}
+}
+
+// limitBody caps the size of every request body
+func limitBody(next http.Handler, max int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, max)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Draining reports whether the server is shutting down; readiness checks should fail
+func (s *Server) Draining() bool {
+	return s.draining.Load()
+}
+
+// OnShutdown registers work to finish after in-flight requests, such as background workers
+// Hooks run in registration order with the shutdown deadline.
+func (s *Server) OnShutdown(hook func(ctx context.Context)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, hook)
+}
+
+// ListenAndServe serves on the configured address until Shutdown
+func (s *Server) ListenAndServe() error {
+	return s.ignoreClosed(s.server.ListenAndServe())
+}
+
+// Serve serves on an existing listener until Shutdown
+func (s *Server) Serve(lis net.Listener) error {
+	return s.ignoreClosed(s.server.Serve(lis))
+}
+
+func (s *Server) ignoreClosed(err error) error {
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown drains the server: readiness fails for the drain delay, then the
+// listener closes and in-flight requests finish until ctx ends
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.draining.Store(true)
+	if s.config.DrainDelay > 0 {
+		select {
+		case <-time.After(s.config.DrainDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	err := s.server.Shutdown(ctx)
+
+	s.mu.Lock()
+	hooks := s.hooks
+	s.mu.Unlock()
+	for _, hook := range hooks {
+		hook(ctx)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		// Requests still running past the deadline are cut off
+		s.server.Close()
+	}
+	return err
+}
+
+// Run serves until ctx ends or the process receives SIGINT or SIGTERM, then shuts down
+func (s *Server) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- s.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down server", "drain_delay", s.config.DrainDelay.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.config.DrainDelay+s.config.ShutdownTimeout)
+	defer cancel()
+	return s.Shutdown(shutdownCtx)
+}
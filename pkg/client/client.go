@@ -0,0 +1,398 @@
+// Package client is the Go client of the TerraCost REST API
+// Requests honor their context, transient failures (connection errors, 429 and
+// 5xx gateway errors) are retried with exponential backoff, and list methods
+// follow pagination to return every result. API errors keep their code:
+//
+//	c := client.New("https://terracost.example.com", os.Getenv("TERRACOST_API_KEY"))
+//	resp, err := c.Estimate(ctx, api.EstimateRequest{Plan: plan, Environment: "prod"})
+//	if tcerrors.CodeOf(err) == tcerrors.CodeParseFailed { ... }
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"terraform-cost/api"
+	"terraform-cost/db/clickhouse"
+	tcerrors "terraform-cost/pkg/errors"
+	"terraform-cost/telemetry"
+)
+
+// Client calls a TerraCost API server
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+	userAgent  string
+
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	pageSize   int
+}
+
+// New creates a client for a server; an empty API key sends no credentials
+func New(baseURL, apiKey string) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: 2 * time.Minute},
+		userAgent:  "terracost-go-client",
+		maxRetries: 3,
+		minBackoff: 500 * time.Millisecond,
+		maxBackoff: 10 * time.Second,
+		pageSize:   100,
+	}
+}
+
+// WithHTTPClient replaces the HTTP client, e.g. for custom transports or timeouts
+func (c *Client) WithHTTPClient(httpClient *http.Client) *Client {
+	c.httpClient = httpClient
+	return c
+}
+
+// WithUserAgent sets the User-Agent of requests
+func (c *Client) WithUserAgent(userAgent string) *Client {
+	c.userAgent = userAgent
+	return c
+}
+
+// WithRetries sets how often transient failures are retried and the backoff
+// between attempts, which doubles from min up to max; 0 retries disables them
+func (c *Client) WithRetries(maxRetries int, minBackoff, maxBackoff time.Duration) *Client {
+	c.maxRetries = maxRetries
+	c.minBackoff = minBackoff
+	c.maxBackoff = maxBackoff
+	return c
+}
+
+// WithPageSize sets how many results list methods fetch per request
+func (c *Client) WithPageSize(size int) *Client {
+	c.pageSize = size
+	return c
+}
+
+// =============================================================================
+// ENDPOINTS
+// =============================================================================
+
+// Estimate estimates the cost of a plan
+func (c *Client) Estimate(ctx context.Context, req api.EstimateRequest) (*api.EstimateResponse, error) {
+	var resp api.EstimateResponse
+	if _, err := c.do(ctx, http.MethodPost, "/api/v1/estimate", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Parse returns the resources and dependencies of a plan without pricing it
+func (c *Client) Parse(ctx context.Context, req api.ParseRequest) (*api.ParseResponse, error) {
+	var resp api.ParseResponse
+	if _, err := c.do(ctx, http.MethodPost, "/api/v1/parse", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// EvaluatePolicy estimates a plan and returns only the policy decision
+func (c *Client) EvaluatePolicy(ctx context.Context, req api.EstimateRequest) (*api.PolicyEvaluationResponse, error) {
+	var resp api.PolicyEvaluationResponse
+	if _, err := c.do(ctx, http.MethodPost, "/api/v1/policy/evaluate", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// PredictBatch predicts the usage of up to api.MaxBatchItems components in one request
+func (c *Client) PredictBatch(ctx context.Context, req api.PredictBatchRequest) (*api.PredictBatchResponse, error) {
+	var resp api.PredictBatchResponse
+	if _, err := c.do(ctx, http.MethodPost, "/api/v1/predict/batch", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// PriceBatch resolves rates, sending api.MaxBatchItems lookups per request
+func (c *Client) PriceBatch(ctx context.Context, req api.PriceBatchRequest) (*api.PriceBatchResponse, error) {
+	all := &api.PriceBatchResponse{Rates: make(map[string]api.PriceResult, len(req.Lookups))}
+	for start := 0; start < len(req.Lookups); start += api.MaxBatchItems {
+		chunk := req
+		chunk.Lookups = req.Lookups[start:min(start+api.MaxBatchItems, len(req.Lookups))]
+		var resp api.PriceBatchResponse
+		if _, err := c.do(ctx, http.MethodPost, "/api/v1/price/batch", nil, chunk, &resp); err != nil {
+			return nil, err
+		}
+		for id, rate := range resp.Rates {
+			all.Rates[id] = rate
+		}
+	}
+	return all, nil
+}
+
+// ListSnapshots lists the pricing snapshots of a region, newest first
+func (c *Client) ListSnapshots(ctx context.Context, cloud, region string) ([]api.SnapshotResponse, error) {
+	query := url.Values{}
+	if cloud != "" {
+		query.Set("cloud", cloud)
+	}
+	if region != "" {
+		query.Set("region", region)
+	}
+	return listAll[api.SnapshotResponse](ctx, c, "/api/v1/snapshots", query)
+}
+
+// EstimateFilter narrows ListEstimates; empty fields match everything
+type EstimateFilter struct {
+	Project     string // Required
+	Branch      string
+	Environment string
+}
+
+// ListEstimates lists a project's saved estimates, newest first
+func (c *Client) ListEstimates(ctx context.Context, filter EstimateFilter) ([]api.EstimationSummaryResponse, error) {
+	query := url.Values{"project": {filter.Project}}
+	if filter.Branch != "" {
+		query.Set("branch", filter.Branch)
+	}
+	if filter.Environment != "" {
+		query.Set("environment", filter.Environment)
+	}
+	return listAll[api.EstimationSummaryResponse](ctx, c, "/api/v1/estimates", query)
+}
+
+// DriversQuery selects a page of an estimate's cost drivers; empty fields are server defaults
+type DriversQuery struct {
+	Sort    string // cost, service, region or resource
+	Service string
+	Region  string
+	Tag     string // key=value, or key alone
+	Offset  int
+	Limit   int
+}
+
+// EstimateDrivers returns one page of a saved estimate's cost drivers
+func (c *Client) EstimateDrivers(ctx context.Context, estimationID string, q DriversQuery) (*api.DriversResponse, error) {
+	query := url.Values{}
+	for key, value := range map[string]string{"sort": q.Sort, "service": q.Service, "region": q.Region, "tag": q.Tag} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	if q.Offset > 0 {
+		query.Set("offset", strconv.Itoa(q.Offset))
+	}
+	if q.Limit > 0 {
+		query.Set("limit", strconv.Itoa(q.Limit))
+	}
+	var resp api.DriversResponse
+	path := "/api/v1/estimates/" + url.PathEscape(estimationID) + "/drivers"
+	if _, err := c.do(ctx, http.MethodGet, path, query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RequestApproval asks to override a denied estimate
+func (c *Client) RequestApproval(ctx context.Context, req api.ApprovalRequest) (*clickhouse.Approval, error) {
+	var resp clickhouse.Approval
+	if _, err := c.do(ctx, http.MethodPost, "/api/v1/approvals", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Approval returns an approval by ID
+func (c *Client) Approval(ctx context.Context, id string) (*clickhouse.Approval, error) {
+	var resp clickhouse.Approval
+	if _, err := c.do(ctx, http.MethodGet, "/api/v1/approvals/"+url.PathEscape(id), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// WaitForApproval polls an approval until it is decided or expires, or ctx is done
+func (c *Client) WaitForApproval(ctx context.Context, id string, interval time.Duration) (*clickhouse.Approval, error) {
+	for {
+		a, err := c.Approval(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if a.Status != clickhouse.ApprovalPending {
+			return a, nil
+		}
+		select {
+		case <-ctx.Done():
+			return a, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// listAll fetches every page of a list endpoint
+func listAll[T any](ctx context.Context, c *Client, path string, query url.Values) ([]T, error) {
+	var all []T
+	offset := 0
+	for {
+		page := url.Values{}
+		for k, v := range query {
+			page[k] = v
+		}
+		page.Set("limit", strconv.Itoa(c.pageSize))
+		if offset > 0 {
+			page.Set("offset", strconv.Itoa(offset))
+		}
+
+		var items []T
+		header, err := c.do(ctx, http.MethodGet, path, page, nil, &items)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+
+		next, err := strconv.Atoi(header.Get(api.NextOffsetHeader))
+		if err != nil || next <= offset || len(items) == 0 {
+			return all, nil
+		}
+		offset = next
+	}
+}
+
+// =============================================================================
+// TRANSPORT
+// =============================================================================
+
+// do sends a request, retrying transient failures, and decodes the JSON response into out
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) (http.Header, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, method, target, payload)
+		if err == nil && resp.StatusCode < 300 {
+			defer resp.Body.Close()
+			if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+				return nil, fmt.Errorf("failed to decode response: %w", err)
+			}
+			return resp.Header, nil
+		}
+
+		var retryAfter time.Duration
+		if err == nil {
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+			err = decodeError(resp)
+			if !retryable(resp.StatusCode) {
+				return nil, err
+			}
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if attempt >= c.maxRetries {
+			return nil, err
+		}
+
+		select {
+		case <-time.After(c.backoff(attempt, retryAfter)):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// send sends one attempt of a request
+func (c *Client) send(ctx context.Context, method, target string, payload []byte) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	if id := telemetry.RequestID(ctx); id != "" {
+		req.Header.Set(telemetry.RequestIDHeader, id)
+	}
+	return c.httpClient.Do(req)
+}
+
+// retryable reports whether a response status is worth retrying
+func retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns the wait before the next attempt: the server's Retry-After if
+// it sent one, else exponential backoff with jitter
+func (c *Client) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, c.maxBackoff)
+	}
+	wait := c.minBackoff << attempt
+	if wait <= 0 || wait > c.maxBackoff {
+		wait = c.maxBackoff
+	}
+	// Full jitter over the upper half spreads out clients retrying together
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+// parseRetryAfter reads a Retry-After header in seconds
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// decodeError reads an API error response; responses without a coded body get
+// the code of their status
+func decodeError(resp *http.Response) error {
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	requestID := resp.Header.Get(telemetry.RequestIDHeader)
+	var body tcerrors.Response
+	if err := json.Unmarshal(data, &body); err != nil || body.Error == "" {
+		message := strings.TrimSpace(string(data))
+		if message == "" {
+			message = http.StatusText(resp.StatusCode)
+		}
+		return &tcerrors.Error{Code: tcerrors.CodeForStatus(resp.StatusCode), Message: message, RequestID: requestID}
+	}
+	if body.Code == "" {
+		body.Code = tcerrors.CodeForStatus(resp.StatusCode)
+	}
+	if body.RequestID != "" {
+		requestID = body.RequestID
+	}
+	return &tcerrors.Error{Code: body.Code, Message: body.Error, RequestID: requestID}
+}
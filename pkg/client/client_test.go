@@ -0,0 +1,214 @@
+// Package client - API client tests
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"terraform-cost/api"
+	"terraform-cost/db/clickhouse"
+	tcerrors "terraform-cost/pkg/errors"
+	"terraform-cost/telemetry"
+)
+
+func newTestClient(url string) *Client {
+	return New(url, "secret").WithRetries(2, time.Millisecond, 5*time.Millisecond)
+}
+
+func TestEstimateRetriesTransientFailures(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		var req api.EstimateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Environment != "prod" {
+			t.Errorf("attempt %d: request = %+v, %v", attempts, req, err)
+		}
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(tcerrors.Response{Error: "not ready", Code: tcerrors.CodeUnavailable})
+			return
+		}
+		json.NewEncoder(w).Encode(api.EstimateResponse{MonthlyCostP50: "12.50"})
+	}))
+	defer srv.Close()
+
+	resp, err := newTestClient(srv.URL).Estimate(context.Background(), api.EstimateRequest{Environment: "prod"})
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	if resp.MonthlyCostP50 != "12.50" || attempts != 3 {
+		t.Errorf("cost = %s after %d attempts, want 12.50 after 3", resp.MonthlyCostP50, attempts)
+	}
+}
+
+func TestErrorsKeepTheirCode(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(tcerrors.Response{Error: "invalid plan: unexpected EOF", Code: tcerrors.CodeParseFailed})
+	}))
+	defer srv.Close()
+
+	_, err := newTestClient(srv.URL).Parse(context.Background(), api.ParseRequest{Plan: json.RawMessage(`{}`)})
+	if code := tcerrors.CodeOf(err); code != tcerrors.CodeParseFailed {
+		t.Errorf("code = %s (%v), want %s", code, err, tcerrors.CodeParseFailed)
+	}
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want client errors not retried", attempts)
+	}
+}
+
+func TestRetriesGiveUp(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	_, err := newTestClient(srv.URL).EvaluatePolicy(context.Background(), api.EstimateRequest{})
+	if err == nil || attempts != 3 {
+		t.Errorf("err = %v after %d attempts, want failure after 3", err, attempts)
+	}
+}
+
+func TestListSnapshotsFollowsPages(t *testing.T) {
+	const total = 5
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("region") != "eu-west-1" {
+			t.Errorf("region = %q", q.Get("region"))
+		}
+		offset, _ := strconv.Atoi(q.Get("offset"))
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		var page []api.SnapshotResponse
+		for i := offset; i < total && i < offset+limit; i++ {
+			page = append(page, api.SnapshotResponse{ID: strconv.Itoa(i)})
+		}
+		if offset+limit < total {
+			w.Header().Set(api.NextOffsetHeader, strconv.Itoa(offset+limit))
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer srv.Close()
+
+	snapshots, err := newTestClient(srv.URL).WithPageSize(2).ListSnapshots(context.Background(), "aws", "eu-west-1")
+	if err != nil {
+		t.Fatalf("ListSnapshots: %v", err)
+	}
+	if len(snapshots) != total {
+		t.Fatalf("got %d snapshots, want %d", len(snapshots), total)
+	}
+	for i, s := range snapshots {
+		if s.ID != strconv.Itoa(i) {
+			t.Errorf("snapshot %d = %s", i, s.ID)
+		}
+	}
+}
+
+func TestContextCancelsRetries(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	c := New(srv.URL, "").WithRetries(3, time.Millisecond, time.Minute)
+	start := time.Now()
+	_, err := c.ListEstimates(ctx, EstimateFilter{Project: "web"})
+	if err != context.DeadlineExceeded {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %s; retry wait ignored the context", elapsed)
+	}
+}
+
+func TestPriceBatchSplitsLargeBatches(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/api/v1/price/batch" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		var req api.PriceBatchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		if len(req.Lookups) > api.MaxBatchItems || req.PricingDate != "2024-06-01" {
+			t.Errorf("request %d: %d lookups for %q", requests, len(req.Lookups), req.PricingDate)
+		}
+		resp := api.PriceBatchResponse{Rates: make(map[string]api.PriceResult)}
+		for _, l := range req.Lookups {
+			resp.Rates[l.ID] = api.PriceResult{Found: true, Price: "0.0416"}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	req := api.PriceBatchRequest{PricingDate: "2024-06-01"}
+	for i := 0; i < api.MaxBatchItems+1; i++ {
+		req.Lookups = append(req.Lookups, api.PriceLookupInput{ID: strconv.Itoa(i), Cloud: "aws", Region: "us-east-1", Service: "AmazonEC2"})
+	}
+	resp, err := newTestClient(srv.URL).PriceBatch(context.Background(), req)
+	if err != nil {
+		t.Fatalf("PriceBatch: %v", err)
+	}
+	if requests != 2 || len(resp.Rates) != api.MaxBatchItems+1 {
+		t.Errorf("%d rates from %d requests, want %d from 2", len(resp.Rates), requests, api.MaxBatchItems+1)
+	}
+}
+
+func TestWaitForApprovalPollsUntilDecided(t *testing.T) {
+	polls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/approvals/42" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		polls++
+		a := clickhouse.Approval{Project: "payments", Status: clickhouse.ApprovalPending}
+		if polls == 3 {
+			a.Status, a.DecidedBy = clickhouse.ApprovalApproved, "finops@example.com"
+		}
+		json.NewEncoder(w).Encode(a)
+	}))
+	defer srv.Close()
+
+	a, err := newTestClient(srv.URL).WaitForApproval(context.Background(), "42", time.Millisecond)
+	if err != nil {
+		t.Fatalf("WaitForApproval: %v", err)
+	}
+	if polls != 3 || a.Status != clickhouse.ApprovalApproved || a.DecidedBy != "finops@example.com" {
+		t.Errorf("%d polls, approval %+v", polls, a)
+	}
+}
+
+func TestErrorsCarryTheRequestID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(telemetry.RequestIDHeader)
+		w.Header().Set(telemetry.RequestIDHeader, id)
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(tcerrors.Response{Error: "bad plan", Code: tcerrors.CodeParseFailed, RequestID: id})
+	}))
+	defer srv.Close()
+
+	ctx := telemetry.WithRequestID(context.Background(), "ci-run-42")
+	_, err := newTestClient(srv.URL).Estimate(ctx, api.EstimateRequest{})
+	var coded *tcerrors.Error
+	if !errors.As(err, &coded) || coded.RequestID != "ci-run-42" || !strings.Contains(err.Error(), "(request ci-run-42)") {
+		t.Errorf("err = %v", err)
+	}
+}
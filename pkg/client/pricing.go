@@ -0,0 +1,255 @@
+// Package client - Remote pricing store
+// A PricingStore prices estimates through a server's batch pricing endpoint,
+// so the CLI needs an API key instead of database credentials. Resolved rates
+// are cached on disk per set of active snapshots: snapshots never change, so
+// entries stay valid until a region's pricing is refreshed, when its cache
+// file is replaced.
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/api"
+	"terraform-cost/db/clickhouse"
+)
+
+// PricingStore resolves rates through a TerraCost server
+// Spot history and rate tiers aren't served remotely: spot capacity is priced
+// at the on-demand rate and tiered usage at the first tier.
+type PricingStore struct {
+	client   *Client
+	cacheDir string // Empty disables the disk cache
+
+	mu     sync.Mutex
+	caches map[string]*rateCache // By cloud and region
+}
+
+// rateCache is the cache file of one region's active snapshots
+type rateCache struct {
+	path  string // Empty when the region has no active snapshot
+	rates map[string]*clickhouse.ResolvedRate
+	dirty bool
+}
+
+// NewPricingStore creates a store resolving rates through a client's server
+func NewPricingStore(c *Client) *PricingStore {
+	return &PricingStore{client: c, caches: make(map[string]*rateCache)}
+}
+
+// WithCacheDir caches resolved rates under dir
+func (p *PricingStore) WithCacheDir(dir string) *PricingStore {
+	p.cacheDir = dir
+	return p
+}
+
+// DefaultCacheDir is the user's cache directory for a server's rates
+func DefaultCacheDir(baseURL string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	host := baseURL
+	if u, err := url.Parse(baseURL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	return filepath.Join(dir, "terracost", "pricing", strings.ReplaceAll(host, ":", "_"))
+}
+
+// ResolveRate resolves one rate
+func (p *PricingStore) ResolveRate(ctx context.Context, cloud clickhouse.CloudProvider, service, productFamily, region string, attrs map[string]string, unit, alias string) (*clickhouse.ResolvedRate, error) {
+	lookup := clickhouse.RateLookup{
+		Cloud:         cloud,
+		Service:       service,
+		ProductFamily: productFamily,
+		Region:        region,
+		Attributes:    attrs,
+		Unit:          unit,
+		Alias:         alias,
+	}
+	rates, err := p.ResolveRatesBatch(ctx, []clickhouse.RateLookup{lookup})
+	if err != nil {
+		return nil, err
+	}
+	return rates[lookup.Key()], nil
+}
+
+// ResolveRatesBatch resolves lookups from the cache, then the rest in one request per pricing date
+func (p *PricingStore) ResolveRatesBatch(ctx context.Context, lookups []clickhouse.RateLookup) (map[string]*clickhouse.ResolvedRate, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	rates := make(map[string]*clickhouse.ResolvedRate, len(lookups))
+	pending := make(map[time.Time][]clickhouse.RateLookup)
+	seen := make(map[string]bool, len(lookups))
+	for _, l := range lookups {
+		key := l.Key()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		// Dated lookups are priced from past snapshots; only current prices are cached
+		if l.At.IsZero() {
+			cache, err := p.cache(ctx, l.Cloud, l.Region)
+			if err != nil {
+				return nil, err
+			}
+			if rate, ok := cache.rates[key]; ok {
+				rates[key] = rate
+				continue
+			}
+		}
+		pending[l.At] = append(pending[l.At], l)
+	}
+
+	for at, batch := range pending {
+		resolved, err := p.fetch(ctx, at, batch)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range batch {
+			key := l.Key()
+			rates[key] = resolved[key]
+			if at.IsZero() {
+				if cache := p.caches[cacheKey(l.Cloud, l.Region)]; cache.path != "" {
+					cache.rates[key] = resolved[key]
+					cache.dirty = true
+				}
+			}
+		}
+	}
+	p.save()
+	return rates, nil
+}
+
+// ResolveSpotRate reports no spot history, so spot capacity is priced on demand
+func (p *PricingStore) ResolveSpotRate(ctx context.Context, cloud clickhouse.CloudProvider, region, instanceType, productDescription string, lookback time.Duration, at time.Time) (*clickhouse.SpotRate, error) {
+	return nil, nil
+}
+
+// fetch resolves lookups of one pricing date from the server
+func (p *PricingStore) fetch(ctx context.Context, at time.Time, lookups []clickhouse.RateLookup) (map[string]*clickhouse.ResolvedRate, error) {
+	req := api.PriceBatchRequest{Lookups: make([]api.PriceLookupInput, len(lookups))}
+	if !at.IsZero() {
+		req.PricingDate = at.UTC().Format(time.RFC3339)
+	}
+	for i, l := range lookups {
+		req.Lookups[i] = api.PriceLookupInput{
+			ID:            l.Key(),
+			Cloud:         string(l.Cloud),
+			Service:       l.Service,
+			ProductFamily: l.ProductFamily,
+			Region:        l.Region,
+			Attributes:    l.Attributes,
+			Unit:          l.Unit,
+			Alias:         l.Alias,
+		}
+	}
+	resp, err := p.client.PriceBatch(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("remote pricing failed: %w", err)
+	}
+
+	rates := make(map[string]*clickhouse.ResolvedRate, len(resp.Rates))
+	for key, r := range resp.Rates {
+		if !r.Found {
+			rates[key] = nil
+			continue
+		}
+		price, err := decimal.NewFromString(r.Price)
+		if err != nil {
+			return nil, fmt.Errorf("remote pricing returned an invalid price %q: %w", r.Price, err)
+		}
+		snapshotID, _ := uuid.Parse(r.SnapshotID)
+		rates[key] = &clickhouse.ResolvedRate{
+			Price:      price,
+			Currency:   r.Currency,
+			Confidence: r.Confidence,
+			SnapshotID: snapshotID,
+			Source:     r.Source,
+		}
+	}
+	return rates, nil
+}
+
+// cache returns the cache of a region's active snapshots, loading it on first use
+func (p *PricingStore) cache(ctx context.Context, cloud clickhouse.CloudProvider, region string) (*rateCache, error) {
+	key := cacheKey(cloud, region)
+	if cache, ok := p.caches[key]; ok {
+		return cache, nil
+	}
+	cache := &rateCache{rates: make(map[string]*clickhouse.ResolvedRate)}
+	if p.cacheDir == "" || strings.ContainsAny(key, `/\`) {
+		p.caches[key] = cache
+		return cache, nil
+	}
+
+	snapshots, err := p.client.ListSnapshots(ctx, string(cloud), region)
+	if err != nil {
+		return nil, fmt.Errorf("remote pricing failed: %w", err)
+	}
+	p.caches[key] = cache
+	var active []string
+	for _, s := range snapshots {
+		if s.IsActive {
+			active = append(active, s.ID+":"+s.Hash)
+		}
+	}
+	if len(active) == 0 {
+		return cache, nil
+	}
+	sort.Strings(active)
+	sum := sha256.Sum256([]byte(strings.Join(active, ",")))
+	cache.path = filepath.Join(p.cacheDir, fmt.Sprintf("%s_%s.json", key, hex.EncodeToString(sum[:8])))
+
+	// A missing or unreadable file is an empty cache
+	if data, err := os.ReadFile(cache.path); err == nil {
+		json.Unmarshal(data, &cache.rates)
+	}
+	return cache, nil
+}
+
+// save writes changed cache files and removes those of superseded snapshots
+// Caching is best effort: write failures leave rates to be fetched again.
+func (p *PricingStore) save() {
+	for key, cache := range p.caches {
+		if !cache.dirty {
+			continue
+		}
+		cache.dirty = false
+		data, err := json.Marshal(cache.rates)
+		if err != nil || os.MkdirAll(p.cacheDir, 0o755) != nil {
+			continue
+		}
+		tmp := cache.path + ".tmp"
+		if os.WriteFile(tmp, data, 0o644) != nil || os.Rename(tmp, cache.path) != nil {
+			os.Remove(tmp)
+			continue
+		}
+		stale, _ := filepath.Glob(filepath.Join(p.cacheDir, key+"_*.json"))
+		for _, path := range stale {
+			if path != cache.path {
+				os.Remove(path)
+			}
+		}
+	}
+}
+
+// cacheKey names a region's cache files
+func cacheKey(cloud clickhouse.CloudProvider, region string) string {
+	return string(cloud) + "_" + region
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"terraform-cost/api"
+	"terraform-cost/db/clickhouse"
+)
+
+func TestPricingStoreCachesBySnapshot(t *testing.T) {
+	snapshotHash := "abc123"
+	batches := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/snapshots":
+			json.NewEncoder(w).Encode([]api.SnapshotResponse{
+				{ID: "5f0c9d1e-0000-4000-8000-000000000001", Hash: snapshotHash, IsActive: true},
+				{ID: "5f0c9d1e-0000-4000-8000-000000000002", Hash: "old", IsActive: false},
+			})
+		case "/api/v1/price/batch":
+			batches++
+			var req api.PriceBatchRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			resp := api.PriceBatchResponse{Rates: make(map[string]api.PriceResult)}
+			for _, l := range req.Lookups {
+				if l.Attributes["instanceType"] == "t3.micro" {
+					resp.Rates[l.ID] = api.PriceResult{Found: true, Price: "0.0104", Currency: "USD", Confidence: 1}
+				} else {
+					resp.Rates[l.ID] = api.PriceResult{}
+				}
+			}
+			json.NewEncoder(w).Encode(resp)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	lookups := []clickhouse.RateLookup{
+		{Cloud: clickhouse.AWS, Region: "us-east-1", Service: "AmazonEC2", Attributes: map[string]string{"instanceType": "t3.micro"}, Unit: "Hrs"},
+		{Cloud: clickhouse.AWS, Region: "us-east-1", Service: "AmazonEC2", Attributes: map[string]string{"instanceType": "x9.huge"}, Unit: "Hrs"},
+	}
+	resolve := func() map[string]*clickhouse.ResolvedRate {
+		t.Helper()
+		rates, err := NewPricingStore(newTestClient(srv.URL)).WithCacheDir(dir).ResolveRatesBatch(context.Background(), lookups)
+		if err != nil {
+			t.Fatalf("ResolveRatesBatch: %v", err)
+		}
+		return rates
+	}
+
+	rates := resolve()
+	if rate := rates[lookups[0].Key()]; rate == nil || rate.Price.String() != "0.0104" {
+		t.Errorf("t3.micro rate = %+v", rate)
+	}
+	if rate, ok := rates[lookups[1].Key()]; !ok || rate != nil {
+		t.Errorf("unknown instance type should resolve to no rate, got %+v", rate)
+	}
+
+	// A new process reuses the cache, missing rates included
+	if rates := resolve(); rates[lookups[0].Key()] == nil || batches != 1 {
+		t.Errorf("cached resolution made %d batch requests, want 1", batches)
+	}
+
+	// Refreshed pricing replaces the region's cache file
+	snapshotHash = "def456"
+	resolve()
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if batches != 2 || len(files) != 1 {
+		t.Errorf("after a refresh: %d batch requests and %d cache files, want 2 and 1", batches, len(files))
+	}
+	if data, err := os.ReadFile(files[0]); err != nil || len(data) == 0 {
+		t.Errorf("cache file unreadable: %v", err)
+	}
+}
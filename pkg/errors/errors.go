@@ -0,0 +1,209 @@
+// Package errors is the TerraCost error model
+// Every error a client can act on carries a stable, machine-readable Code.
+// The API returns it next to the message, gRPC maps it to a status code and
+// the CLI to an exit code, so scripts never need to match on message text.
+package errors
+
+import (
+	_ "embed"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+)
+
+// Code identifies a kind of error; codes never change once released
+type Code string
+
+// Pipeline error codes
+const (
+	CodeParseFailed         Code = "PARSE_FAILED"         // The plan or configuration could not be parsed
+	CodeUnsupportedResource Code = "UNSUPPORTED_RESOURCE" // Resource types without a billing mapper
+	CodePriceNotFound       Code = "PRICE_NOT_FOUND"      // Components with no price in the snapshots used
+	CodePolicyDeny          Code = "POLICY_DENY"          // Policy evaluation denied the estimate
+	CodeSnapshotStale       Code = "SNAPSHOT_STALE"       // Prices came from a snapshot older than allowed
+	CodePlanTooLarge        Code = "PLAN_TOO_LARGE"       // The plan has more resources than the limit
+	CodePolicyWarn          Code = "POLICY_WARN"          // Policy evaluation warned on the estimate
+	CodeEstimateIncomplete  Code = "ESTIMATE_INCOMPLETE"  // Some costs are symbolic, so the total is a lower bound
+)
+
+// Request error codes
+const (
+	CodeInvalidRequest   Code = "INVALID_REQUEST"
+	CodeUnauthorized     Code = "UNAUTHORIZED"
+	CodeForbidden        Code = "FORBIDDEN"
+	CodeNotFound         Code = "NOT_FOUND"
+	CodeMethodNotAllowed Code = "METHOD_NOT_ALLOWED"
+	CodeRateLimited      Code = "RATE_LIMITED"
+	CodeNotImplemented   Code = "NOT_IMPLEMENTED"
+	CodeUnavailable      Code = "UNAVAILABLE"
+	CodeInternal         Code = "INTERNAL"
+)
+
+// Codes lists every code, for documentation and validation
+func Codes() []Code {
+	return []Code{
+		CodeParseFailed, CodeUnsupportedResource, CodePriceNotFound, CodePolicyDeny, CodeSnapshotStale, CodePlanTooLarge,
+		CodePolicyWarn, CodeEstimateIncomplete, CodeInvalidRequest, CodeUnauthorized, CodeForbidden, CodeNotFound, CodeMethodNotAllowed,
+		CodeRateLimited, CodeNotImplemented, CodeUnavailable, CodeInternal,
+	}
+}
+
+// httpStatus is the HTTP status of each code
+var httpStatus = map[Code]int{
+	CodeParseFailed:         http.StatusBadRequest,
+	CodeUnsupportedResource: http.StatusUnprocessableEntity,
+	CodePriceNotFound:       http.StatusUnprocessableEntity,
+	CodePolicyDeny:          http.StatusUnprocessableEntity,
+	CodeSnapshotStale:       http.StatusUnprocessableEntity,
+	CodePlanTooLarge:        http.StatusRequestEntityTooLarge,
+	CodePolicyWarn:          http.StatusUnprocessableEntity,
+	CodeEstimateIncomplete:  http.StatusUnprocessableEntity,
+	CodeInvalidRequest:      http.StatusBadRequest,
+	CodeUnauthorized:        http.StatusUnauthorized,
+	CodeForbidden:           http.StatusForbidden,
+	CodeNotFound:            http.StatusNotFound,
+	CodeMethodNotAllowed:    http.StatusMethodNotAllowed,
+	CodeRateLimited:         http.StatusTooManyRequests,
+	CodeNotImplemented:      http.StatusNotImplemented,
+	CodeUnavailable:         http.StatusServiceUnavailable,
+	CodeInternal:            http.StatusInternalServerError,
+}
+
+// HTTPStatus returns the HTTP status of a code; unknown codes are internal errors
+func (c Code) HTTPStatus() int {
+	if status, ok := httpStatus[c]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// CLI exit codes; 2 has always meant a policy deny
+const (
+	ExitOK                  = 0
+	ExitError               = 1
+	ExitPolicyDeny          = 2
+	ExitParseFailed         = 3
+	ExitUnsupportedResource = 4
+	ExitPriceNotFound       = 5
+	ExitSnapshotStale       = 6
+	ExitPolicyWarn          = 7
+	ExitEstimateIncomplete  = 8
+)
+
+// ExitCode returns the CLI exit code of a code
+func (c Code) ExitCode() int {
+	switch c {
+	case CodePolicyDeny:
+		return ExitPolicyDeny
+	case CodeParseFailed, CodePlanTooLarge:
+		return ExitParseFailed
+	case CodeUnsupportedResource:
+		return ExitUnsupportedResource
+	case CodePriceNotFound:
+		return ExitPriceNotFound
+	case CodeSnapshotStale:
+		return ExitSnapshotStale
+	case CodePolicyWarn:
+		return ExitPolicyWarn
+	case CodeEstimateIncomplete:
+		return ExitEstimateIncomplete
+	default:
+		return ExitError
+	}
+}
+
+// CodeForStatus returns the code of an HTTP error status, for errors raised by status alone
+func CodeForStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusRequestEntityTooLarge:
+		return CodePlanTooLarge
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusNotImplemented:
+		return CodeNotImplemented
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	default:
+		return CodeInternal
+	}
+}
+
+// Error is an error with a code
+type Error struct {
+	Code      Code
+	Message   string
+	Err       error  // Cause, if any
+	RequestID string // API request that failed, for finding it in server logs
+}
+
+func (e *Error) Error() string {
+	message := e.Message
+	if e.Err != nil && e.Message == "" {
+		message = e.Err.Error()
+	} else if e.Err != nil {
+		message = e.Message + ": " + e.Err.Error()
+	}
+	if e.RequestID != "" {
+		message += " (request " + e.RequestID + ")"
+	}
+	return message
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// New creates an error with a code
+func New(code Code, format string, args ...interface{}) error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Wrap gives an error a code; the message, if any, prefixes the cause's
+func Wrap(code Code, err error, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...), Err: err}
+}
+
+// CodeOf returns the code of the outermost coded error in err's chain, or CodeInternal
+func CodeOf(err error) Code {
+	var coded *Error
+	if stderrors.As(err, &coded) {
+		return coded.Code
+	}
+	return CodeInternal
+}
+
+// Response is the JSON body of an API error
+type Response struct {
+	Error     string `json:"error"` // Human-readable message
+	Code      Code   `json:"code"`
+	RequestID string `json:"request_id,omitempty"` // Also in the X-Request-ID header and server logs
+}
+
+// NewResponse returns the API response for an error
+func NewResponse(err error) Response {
+	return Response{Error: err.Error(), Code: CodeOf(err)}
+}
+
+// Issue is a problem with an estimate that did not stop it, such as a missing
+// price; clients can fail on the codes they care about
+type Issue struct {
+	Code     Code   `json:"code"`
+	Message  string `json:"message"`
+	Resource string `json:"resource,omitempty"` // Resource address or type the issue is about
+}
+
+// Schema is the JSON Schema of Response and Issue, listing every code
+//
+//go:embed error.schema.json
+var Schema []byte
@@ -0,0 +1,91 @@
+// Package errors - Error model tests
+package errors
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestSchemaListsEveryCode(t *testing.T) {
+	var schema struct {
+		Defs struct {
+			Code struct {
+				OneOf []struct {
+					Const Code `json:"const"`
+				} `json:"oneOf"`
+			} `json:"code"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(Schema, &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+	documented := make(map[Code]bool)
+	for _, c := range schema.Defs.Code.OneOf {
+		documented[c.Const] = true
+	}
+
+	for _, code := range Codes() {
+		if !documented[code] {
+			t.Errorf("%s is not in the schema", code)
+		}
+		if _, ok := httpStatus[code]; !ok {
+			t.Errorf("%s has no HTTP status", code)
+		}
+		delete(documented, code)
+	}
+	for code := range documented {
+		t.Errorf("schema documents unknown code %s", code)
+	}
+}
+
+func TestCodeOf(t *testing.T) {
+	cause := stderrors.New("unexpected token")
+	parseErr := Wrap(CodeParseFailed, cause, "invalid plan")
+	wrapped := fmt.Errorf("failed to parse terraform input: %w", parseErr)
+
+	if got := CodeOf(wrapped); got != CodeParseFailed {
+		t.Errorf("CodeOf = %s, want %s", got, CodeParseFailed)
+	}
+	if !stderrors.Is(wrapped, cause) {
+		t.Error("wrapped error lost its cause")
+	}
+	if got := parseErr.Error(); got != "invalid plan: unexpected token" {
+		t.Errorf("Error() = %q", got)
+	}
+	if got := CodeOf(cause); got != CodeInternal {
+		t.Errorf("CodeOf(uncoded) = %s, want %s", got, CodeInternal)
+	}
+	if Wrap(CodeParseFailed, nil, "") != nil {
+		t.Error("Wrap(nil) != nil")
+	}
+}
+
+func TestStatusAndExitCodes(t *testing.T) {
+	tests := []struct {
+		code   Code
+		status int
+		exit   int
+	}{
+		{CodeParseFailed, http.StatusBadRequest, ExitParseFailed},
+		{CodePolicyDeny, http.StatusUnprocessableEntity, ExitPolicyDeny},
+		{CodeUnsupportedResource, http.StatusUnprocessableEntity, ExitUnsupportedResource},
+		{CodePriceNotFound, http.StatusUnprocessableEntity, ExitPriceNotFound},
+		{CodeSnapshotStale, http.StatusUnprocessableEntity, ExitSnapshotStale},
+		{CodePlanTooLarge, http.StatusRequestEntityTooLarge, ExitParseFailed},
+		{CodePolicyWarn, http.StatusUnprocessableEntity, ExitPolicyWarn},
+		{CodeEstimateIncomplete, http.StatusUnprocessableEntity, ExitEstimateIncomplete},
+		{CodeInternal, http.StatusInternalServerError, ExitError},
+		{Code("UNKNOWN"), http.StatusInternalServerError, ExitError},
+	}
+	for _, tt := range tests {
+		if got := tt.code.HTTPStatus(); got != tt.status {
+			t.Errorf("%s.HTTPStatus() = %d, want %d", tt.code, got, tt.status)
+		}
+		if got := tt.code.ExitCode(); got != tt.exit {
+			t.Errorf("%s.ExitCode() = %d, want %d", tt.code, got, tt.exit)
+		}
+	}
+}
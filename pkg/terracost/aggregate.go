@@ -0,0 +1,44 @@
+// Package terracost - Aggregate estimates
+// Plans applied together are also judged together: their estimates are merged
+// and policy is evaluated against the total, so a cost limit holds across all
+// of them and not only per plan.
+package terracost
+
+import (
+	"context"
+
+	"terraform-cost/decision/estimation"
+	"terraform-cost/decision/policy"
+	tcerrors "terraform-cost/pkg/errors"
+)
+
+// Aggregate is the combined estimate of several plans
+type Aggregate struct {
+	Estimation *estimation.EstimationResult
+	Policy     *policy.EvaluationResult // nil without a policy engine
+	Issues     []tcerrors.Issue         // Policy denials and warnings of the total; each plan keeps its own issues
+}
+
+// Aggregate merges the estimates of several plans and evaluates policy against the total
+// req gives the environment, project, policies and baseline of the total.
+func (e *Estimator) Aggregate(ctx context.Context, results []*Result, req Request) (*Aggregate, error) {
+	estimates := make([]*estimation.EstimationResult, len(results))
+	unmapped := 0
+	for i, r := range results {
+		estimates[i] = r.Estimation
+		unmapped += r.Decomposition.UnmappedResources()
+	}
+	merged, err := estimation.Merge(unmapped, estimates...)
+	if err != nil {
+		return nil, err
+	}
+	policyResult, err := e.evaluate(ctx, merged, req)
+	if err != nil {
+		return nil, err
+	}
+	return &Aggregate{
+		Estimation: merged,
+		Policy:     policyResult,
+		Issues:     policyIssues(policyResult),
+	}, nil
+}
@@ -0,0 +1,53 @@
+// Package terracost - Aggregate tests
+package terracost
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/decision/policy"
+	tcerrors "terraform-cost/pkg/errors"
+)
+
+func TestAggregate(t *testing.T) {
+	estimator := NewEstimator(&flatStore{price: decimal.NewFromFloat(0.01)}).
+		WithPolicyEngine(policy.NewEngine())
+	ctx := context.Background()
+
+	var results []*Result
+	for i := 0; i < 2; i++ {
+		result, err := estimator.Estimate(ctx, parseTestPlan(t, estimator), Request{Environment: "prod"})
+		if err != nil {
+			t.Fatalf("Estimate: %v", err)
+		}
+		results = append(results, result)
+	}
+	single := results[0].Estimation.MonthlyCostP50
+
+	// A limit each plan stays under but the two together exceed
+	limit := single.Mul(decimal.NewFromFloat(1.5)).InexactFloat64()
+	agg, err := estimator.Aggregate(ctx, results, Request{
+		Environment: "prod",
+		Policies: []policy.Policy{{
+			ID: "limit", Name: "Cost Limit", Type: policy.PolicyTypeCostLimit,
+			Severity: policy.SeverityError, Threshold: limit, Enabled: true,
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Aggregate: %v", err)
+	}
+	if !agg.Estimation.MonthlyCostP50.Equal(single.Mul(decimal.NewFromInt(2))) {
+		t.Errorf("total = %s, want twice %s", agg.Estimation.MonthlyCostP50, single)
+	}
+	if got, want := len(agg.Estimation.CostDrivers), 2*len(results[0].Estimation.CostDrivers); got != want {
+		t.Errorf("drivers = %d, want %d", got, want)
+	}
+	if agg.Policy == nil || agg.Policy.Decision != policy.DecisionDeny {
+		t.Fatalf("policy result = %+v, want deny", agg.Policy)
+	}
+	if len(agg.Issues) != 1 || agg.Issues[0].Code != tcerrors.CodePolicyDeny {
+		t.Errorf("issues = %+v, want one POLICY_DENY", agg.Issues)
+	}
+}
@@ -0,0 +1,317 @@
+// Package terracost - Change diff
+// In diff mode an estimate prices what a plan leaves running, and the resources
+// it deletes and replaces are reported on their own: deletions as monthly
+// savings, replacements with the one-time churn of recreating them.
+package terracost
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/estimation"
+	"terraform-cost/decision/iac"
+)
+
+// Diff is what a plan's deletions and replacements do to cost
+type Diff struct {
+	Currency                    string            `json:"currency"`
+	MonthlySavingsFromDeletions decimal.Decimal   `json:"monthly_savings_from_deletions"`
+	Deletions                   []DeletedResource `json:"deletions"`
+	ReplacementChurnCost        decimal.Decimal   `json:"replacement_churn_cost"` // One-time
+	Replacements                []Replacement     `json:"replacements"`
+}
+
+// DeletedResource is a resource the plan destroys and the monthly cost it saves
+type DeletedResource struct {
+	Address        string          `json:"address"`
+	ResourceType   string          `json:"resource_type"`
+	MonthlyCostP50 decimal.Decimal `json:"monthly_cost_p50"`
+	MonthlyCostP90 decimal.Decimal `json:"monthly_cost_p90"`
+	Unpriced       int             `json:"unpriced,omitempty"` // Cost drivers without a price, not in the savings
+}
+
+// Replacement is a resource the plan destroys and recreates
+type Replacement struct {
+	Address             string          `json:"address"`
+	ResourceType        string          `json:"resource_type"`
+	CreateBeforeDestroy bool            `json:"create_before_destroy"`
+	ReplacedBy          []string        `json:"replaced_by,omitempty"` // Attributes forcing the replacement
+	MonthlyCostP50      decimal.Decimal `json:"monthly_cost_p50"`      // Of the new resource, in the estimate
+	OneTimeCost         decimal.Decimal `json:"one_time_cost"`
+	Charges             []ChurnCharge   `json:"charges,omitempty"`
+}
+
+// ChurnCharge is a one-time cost of replacing a resource
+type ChurnCharge struct {
+	Description string          `json:"description"`
+	Cost        decimal.Decimal `json:"cost"`
+	Assumption  string          `json:"assumption"`
+	Unpriced    bool            `json:"unpriced,omitempty"`
+}
+
+// churnSuffix marks the IDs of the components modelling replacement churn
+const churnSuffix = "-replace-churn"
+
+// splitDeletions separates the components of resources the plan deletes
+func splitDeletions(graph *iac.Graph, components []billing.BillingComponent) (kept, deleted []billing.BillingComponent) {
+	for _, comp := range components {
+		if node := graph.Nodes[comp.ResourceAddr]; node != nil && node.Change != nil && node.Change.Action == iac.ActionDelete {
+			deleted = append(deleted, comp)
+			continue
+		}
+		kept = append(kept, comp)
+	}
+	return kept, deleted
+}
+
+// diff prices a plan's deletions and the churn of its replacements
+// The deleted components are left out of result, the estimate of what remains.
+func (e *Estimator) diff(ctx context.Context, graph *iac.Graph, decomposition *billing.DecompositionResult, result *estimation.EstimationResult, kept, deleted []billing.BillingComponent, req Request) (*Diff, error) {
+	// Allowances and simulations belong to the estimate, not to what it removes
+	sideReq := req
+	sideReq.FreeTier, sideReq.Simulation, sideReq.IncludeCarbon, sideReq.IncludeFormulas = nil, nil, false, false
+
+	d := &Diff{
+		Currency:     result.Currency,
+		Deletions:    make([]DeletedResource, 0),
+		Replacements: make([]Replacement, 0),
+	}
+
+	if len(deleted) > 0 {
+		priced, err := e.price(ctx, deleted, decomposition, sideReq)
+		if err != nil {
+			return nil, err
+		}
+		byAddr := make(map[string]*DeletedResource)
+		for _, driver := range priced.CostDrivers {
+			del := byAddr[driver.ResourceAddr]
+			if del == nil {
+				del = &DeletedResource{Address: driver.ResourceAddr, ResourceType: resourceType(graph, driver.ResourceAddr)}
+				byAddr[driver.ResourceAddr] = del
+			}
+			if driver.IsSymbolic {
+				del.Unpriced++
+				continue
+			}
+			del.MonthlyCostP50 = del.MonthlyCostP50.Add(driver.MonthlyCostP50)
+			del.MonthlyCostP90 = del.MonthlyCostP90.Add(driver.MonthlyCostP90)
+		}
+		for _, del := range byAddr {
+			d.Deletions = append(d.Deletions, *del)
+			d.MonthlySavingsFromDeletions = d.MonthlySavingsFromDeletions.Add(del.MonthlyCostP50)
+		}
+		sort.Slice(d.Deletions, func(i, j int) bool {
+			if c := d.Deletions[i].MonthlyCostP50.Cmp(d.Deletions[j].MonthlyCostP50); c != 0 {
+				return c > 0
+			}
+			return d.Deletions[i].Address < d.Deletions[j].Address
+		})
+	}
+
+	// Replacements keep their monthly cost in the estimate; churn is priced on the side
+	replacements := make(map[string]*Replacement)
+	var churn []billing.BillingComponent
+	assumptions := make(map[string]ChurnCharge)
+	for _, comp := range kept {
+		node := graph.Nodes[comp.ResourceAddr]
+		if node == nil || node.Change == nil || node.Change.Action != iac.ActionReplace {
+			continue
+		}
+		if replacements[comp.ResourceAddr] == nil {
+			replacements[comp.ResourceAddr] = &Replacement{
+				Address:             comp.ResourceAddr,
+				ResourceType:        node.Resource.Type,
+				CreateBeforeDestroy: createBeforeDestroy(node.Change),
+				ReplacedBy:          replacePaths(node.Change),
+			}
+		}
+		r := replacements[comp.ResourceAddr]
+		for _, c := range churnComponents(comp, r.CreateBeforeDestroy, snapshotted(graph, comp.ResourceAddr)) {
+			if c.charge.Unpriced {
+				r.Charges = append(r.Charges, c.charge)
+				continue
+			}
+			churn = append(churn, c.component)
+			assumptions[c.component.ID] = c.charge
+		}
+	}
+	for _, driver := range result.CostDrivers {
+		if r := replacements[driver.ResourceAddr]; r != nil && !driver.IsSymbolic {
+			r.MonthlyCostP50 = r.MonthlyCostP50.Add(driver.MonthlyCostP50)
+		}
+	}
+	if len(churn) > 0 {
+		priced, err := e.price(ctx, churn, decomposition, sideReq)
+		if err != nil {
+			return nil, err
+		}
+		for _, driver := range priced.CostDrivers {
+			r := replacements[driver.ResourceAddr]
+			charge, ok := assumptions[driver.ComponentID]
+			if r == nil || !ok {
+				continue
+			}
+			if driver.IsSymbolic {
+				charge.Unpriced = true
+			} else {
+				charge.Cost = driver.MonthlyCostP50
+				r.OneTimeCost = r.OneTimeCost.Add(charge.Cost)
+			}
+			r.Charges = append(r.Charges, charge)
+		}
+	}
+	for _, r := range replacements {
+		d.Replacements = append(d.Replacements, *r)
+		d.ReplacementChurnCost = d.ReplacementChurnCost.Add(r.OneTimeCost)
+	}
+	sort.Slice(d.Replacements, func(i, j int) bool {
+		return d.Replacements[i].Address < d.Replacements[j].Address
+	})
+	return d, nil
+}
+
+// churnItem is a component modelling a one-time replacement cost and its charge;
+// unpriced charges have no component
+type churnItem struct {
+	component billing.BillingComponent
+	charge    ChurnCharge
+}
+
+// churnComponents models the one-time costs of replacing a component:
+//   - hourly capacity is billed for an extra hour, while the old and new
+//     resource run side by side (create before destroy) or because partial
+//     hours of both are billed in full
+//   - an EBS volume's snapshot lineage restarts, so its first snapshot is a
+//     full copy rather than an increment; without a snapshot in the plan
+//     this is only reported, unpriced
+func churnComponents(comp billing.BillingComponent, cbd, snapshotted bool) []churnItem {
+	var items []churnItem
+	if comp.BillingPeriod == billing.PeriodHourly {
+		assumption := "Partial hours of the old and new resource are each billed as a full hour"
+		if cbd {
+			assumption = "The new resource runs alongside the old one for up to an hour before it is destroyed"
+		}
+		items = append(items, churnItem{
+			component: churnComponent(comp, "overlap", comp.BillingPeriod, comp.ProductFamily, comp.UsageType, comp.Attributes, 1),
+			charge: ChurnCharge{
+				Description: fmt.Sprintf("One extra hour of %s", comp.Description),
+				Assumption:  assumption,
+			},
+		})
+	}
+	if comp.Cloud == "aws" && comp.Service == "AmazonEC2" && strings.HasPrefix(comp.UsageType, "EBS:VolumeUsage") {
+		sizeGB := comp.VarianceProfile.P50Usage
+		if !snapshotted {
+			return append(items, churnItem{charge: ChurnCharge{
+				Description: fmt.Sprintf("Full first snapshot of the new %.0f GB volume", sizeGB),
+				Assumption:  "Nothing in the plan snapshots the volume; if it is snapshotted elsewhere, a new volume's first snapshot stores every block",
+				Unpriced:    true,
+			}})
+		}
+		items = append(items, churnItem{
+			component: churnComponent(comp, "snapshot", billing.PeriodMonthly, "Storage Snapshot", "EBS:SnapshotUsage", map[string]string{}, sizeGB),
+			charge: ChurnCharge{
+				Description: fmt.Sprintf("Full first snapshot of the new %.0f GB volume", sizeGB),
+				Assumption:  "A new volume's first snapshot stores every block, for one month until increments replace it",
+			},
+		})
+	}
+	return items
+}
+
+// snapshotted reports whether the plan snapshots a resource's volumes: an
+// aws_ebs_snapshot refers to it, or a DLM lifecycle policy targets its tags
+func snapshotted(graph *iac.Graph, addr string) bool {
+	node := graph.Nodes[addr]
+	if node == nil {
+		return false
+	}
+	for _, dep := range node.Dependents {
+		if resourceType(graph, dep) == "aws_ebs_snapshot" {
+			return true
+		}
+	}
+	tags, _ := node.Resource.Attributes["tags_all"].(map[string]interface{})
+	if len(tags) == 0 {
+		tags, _ = node.Resource.Attributes["tags"].(map[string]interface{})
+	}
+	for _, policy := range graph.Nodes {
+		if policy.Resource.Type != "aws_dlm_lifecycle_policy" || (policy.Change != nil && policy.Change.Action == iac.ActionDelete) {
+			continue
+		}
+		details, _ := policy.Resource.Attributes["policy_details"].([]interface{})
+		for _, d := range details {
+			block, _ := d.(map[string]interface{})
+			if target, _ := block["target_tags"].(map[string]interface{}); len(target) > 0 && tagsMatch(tags, target) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// tagsMatch reports whether tags carry every target tag
+func tagsMatch(tags, target map[string]interface{}) bool {
+	for k, v := range target {
+		if tags[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// churnComponent is a component billing a fixed one-time quantity for a replaced component
+func churnComponent(comp billing.BillingComponent, kind string, period billing.BillingPeriod, productFamily, usageType string, attrs map[string]string, quantity float64) billing.BillingComponent {
+	return billing.BillingComponent{
+		ID:            comp.ID + churnSuffix + "-" + kind,
+		ResourceAddr:  comp.ResourceAddr,
+		Module:        comp.Module,
+		Cloud:         comp.Cloud,
+		Service:       comp.Service,
+		ProductFamily: productFamily,
+		Region:        comp.Region,
+		UsageType:     usageType,
+		BillingPeriod: period,
+		Attributes:    attrs,
+		Description:   comp.Description,
+		VarianceProfile: billing.VarianceProfile{
+			BaselineUsage: quantity,
+			MinUsage:      quantity,
+			MaxUsage:      quantity,
+			P50Usage:      quantity,
+			P90Usage:      quantity,
+			Confidence:    comp.VarianceProfile.Confidence,
+		},
+	}
+}
+
+// createBeforeDestroy reports whether a replacement creates the new resource first
+func createBeforeDestroy(change *iac.ResourceChange) bool {
+	return len(change.Actions) == 2 && change.Actions[0] == "create"
+}
+
+// replacePaths names the attributes forcing a replacement
+func replacePaths(change *iac.ResourceChange) []string {
+	var paths []string
+	for _, path := range change.ReplacePaths {
+		parts := make([]string, len(path))
+		for i, step := range path {
+			parts[i] = fmt.Sprint(step)
+		}
+		paths = append(paths, strings.Join(parts, "."))
+	}
+	return paths
+}
+
+// resourceType returns the type of a graph resource
+func resourceType(graph *iac.Graph, addr string) string {
+	if node := graph.Nodes[addr]; node != nil {
+		return node.Resource.Type
+	}
+	return ""
+}
@@ -0,0 +1,136 @@
+// Package terracost - Change diff tests
+package terracost
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/decision/iac"
+)
+
+const diffPlan = `{"format_version":"1.2","terraform_version":"1.6.0",
+"resource_changes":[
+ {"address":"aws_instance.web","mode":"managed","type":"aws_instance","name":"web","provider_name":"registry.terraform.io/hashicorp/aws",
+  "change":{"actions":["create"],"before":null,"after":{"instance_type":"t3.micro"}}},
+ {"address":"aws_instance.old","mode":"managed","type":"aws_instance","name":"old","provider_name":"registry.terraform.io/hashicorp/aws",
+  "change":{"actions":["delete"],"before":{"instance_type":"m5.large"},"after":null}},
+ {"address":"aws_nat_gateway.nat","mode":"managed","type":"aws_nat_gateway","name":"nat","provider_name":"registry.terraform.io/hashicorp/aws",
+  "change":{"actions":["create","delete"],"before":{"subnet_id":"subnet-a"},"after":{"subnet_id":"subnet-b"},"replace_paths":[["subnet_id"]]}},
+ {"address":"aws_ebs_volume.data","mode":"managed","type":"aws_ebs_volume","name":"data","provider_name":"registry.terraform.io/hashicorp/aws",
+  "change":{"actions":["delete","create"],"before":{"size":100,"type":"gp3"},"after":{"size":100,"type":"gp3","availability_zone":"us-east-1b","tags":{"backup":"daily"}}}}
+],
+"configuration":{"provider_config":{"aws":{"name":"aws","expressions":{"region":{"constant_value":"us-east-1"}}}}}}`
+
+func TestEstimateDiff(t *testing.T) {
+	estimator := NewEstimator(&flatStore{price: decimal.NewFromFloat(0.01)})
+	plan, err := estimator.Parse(context.Background(), iac.NewParser(), strings.NewReader(diffPlan))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	// Without diff mode deleted resources stay in the estimate
+	result, err := estimator.Estimate(context.Background(), plan, Request{})
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	if result.Diff != nil || !hasDriver(result, "aws_instance.old") {
+		t.Fatalf("diff = %+v, want nil and aws_instance.old priced", result.Diff)
+	}
+
+	result, err = estimator.Estimate(context.Background(), plan, Request{Diff: true})
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	if hasDriver(result, "aws_instance.old") {
+		t.Error("deleted aws_instance.old is in the estimate")
+	}
+	diff := result.Diff
+	if diff == nil {
+		t.Fatal("no diff")
+	}
+
+	if len(diff.Deletions) != 1 || diff.Deletions[0].Address != "aws_instance.old" || diff.Deletions[0].ResourceType != "aws_instance" {
+		t.Fatalf("deletions = %+v, want aws_instance.old", diff.Deletions)
+	}
+	if !diff.Deletions[0].MonthlyCostP50.IsPositive() || !diff.MonthlySavingsFromDeletions.Equal(diff.Deletions[0].MonthlyCostP50) {
+		t.Errorf("savings = %s, deletion = %s", diff.MonthlySavingsFromDeletions, diff.Deletions[0].MonthlyCostP50)
+	}
+
+	if len(diff.Replacements) != 2 {
+		t.Fatalf("replacements = %+v, want 2", diff.Replacements)
+	}
+	volume, nat := diff.Replacements[0], diff.Replacements[1]
+	if volume.Address != "aws_ebs_volume.data" || volume.CreateBeforeDestroy {
+		t.Errorf("volume replacement = %+v", volume)
+	}
+	// Nothing snapshots the volume, so its first full snapshot is only reported
+	if len(volume.Charges) != 1 || !volume.Charges[0].Unpriced || !volume.OneTimeCost.IsZero() {
+		t.Errorf("volume churn = %s %+v, want one unpriced charge", volume.OneTimeCost, volume.Charges)
+	}
+	if nat.Address != "aws_nat_gateway.nat" || !nat.CreateBeforeDestroy || len(nat.ReplacedBy) != 1 || nat.ReplacedBy[0] != "subnet_id" {
+		t.Errorf("nat replacement = %+v", nat)
+	}
+	// One overlapping NAT hour; data processing has no churn
+	if len(nat.Charges) != 1 || !nat.OneTimeCost.Equal(decimal.NewFromFloat(0.01)) {
+		t.Errorf("nat churn = %s %+v, want 0.01", nat.OneTimeCost, nat.Charges)
+	}
+	if !nat.MonthlyCostP50.IsPositive() {
+		t.Errorf("nat monthly cost = %s, want the new gateway's", nat.MonthlyCostP50)
+	}
+	if !diff.ReplacementChurnCost.Equal(decimal.NewFromFloat(0.01)) {
+		t.Errorf("churn = %s, want 0.01", diff.ReplacementChurnCost)
+	}
+
+	// With a DLM policy on its tags, the first snapshot of the new volume is a full 100 GB copy
+	dlm := `{"address":"aws_dlm_lifecycle_policy.backup","mode":"managed","type":"aws_dlm_lifecycle_policy","name":"backup","provider_name":"registry.terraform.io/hashicorp/aws",
+  "change":{"actions":["create"],"before":null,"after":{"policy_details":[{"resource_types":["VOLUME"],"target_tags":{"backup":"daily"}}]}}},
+ `
+	plan, err = estimator.Parse(context.Background(), iac.NewParser(), strings.NewReader(strings.Replace(diffPlan, `{"address":"aws_ebs_volume.data"`, dlm+`{"address":"aws_ebs_volume.data"`, 1)))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	result, err = estimator.Estimate(context.Background(), plan, Request{Diff: true})
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	volume = result.Diff.Replacements[0]
+	if len(volume.Charges) != 1 || volume.Charges[0].Unpriced || !volume.OneTimeCost.Equal(decimal.NewFromInt(1)) {
+		t.Errorf("volume churn = %s %+v, want 1.00", volume.OneTimeCost, volume.Charges)
+	}
+	if !result.Diff.ReplacementChurnCost.Equal(decimal.NewFromFloat(1.01)) {
+		t.Errorf("churn = %s, want 1.01", result.Diff.ReplacementChurnCost)
+	}
+}
+
+func TestSnapshotted(t *testing.T) {
+	volume := &iac.GraphNode{
+		Resource:   iac.ResourceNode{Address: "aws_ebs_volume.data", Type: "aws_ebs_volume", Attributes: map[string]interface{}{"tags": map[string]interface{}{"backup": "weekly"}}},
+		Dependents: []string{"aws_ebs_snapshot.data"},
+	}
+	snapshot := &iac.GraphNode{Resource: iac.ResourceNode{Address: "aws_ebs_snapshot.data", Type: "aws_ebs_snapshot"}}
+	graph := &iac.Graph{Nodes: map[string]*iac.GraphNode{volume.Resource.Address: volume, snapshot.Resource.Address: snapshot}}
+	if !snapshotted(graph, "aws_ebs_volume.data") {
+		t.Error("volume with an aws_ebs_snapshot not snapshotted")
+	}
+
+	volume.Dependents = nil
+	graph.Nodes["aws_dlm_lifecycle_policy.daily"] = &iac.GraphNode{Resource: iac.ResourceNode{
+		Type:       "aws_dlm_lifecycle_policy",
+		Attributes: map[string]interface{}{"policy_details": []interface{}{map[string]interface{}{"target_tags": map[string]interface{}{"backup": "daily"}}}},
+	}}
+	if snapshotted(graph, "aws_ebs_volume.data") {
+		t.Error("volume snapshotted by a DLM policy targeting other tags")
+	}
+}
+
+func hasDriver(result *Result, addr string) bool {
+	for _, d := range result.Estimation.CostDrivers {
+		if d.ResourceAddr == addr {
+			return true
+		}
+	}
+	return false
+}
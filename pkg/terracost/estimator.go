@@ -0,0 +1,424 @@
+// Package terracost is the embeddable TerraCost SDK
+// An Estimator runs the whole estimate pipeline (parse, decompose, predict usage,
+// price and evaluate policy) so Go programs can estimate plans without shelling
+// out to the CLI. The terracost CLI and the API servers are built on it.
+package terracost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"terraform-cost/decision/awsmeta"
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/billing/mappers/aws"
+	"terraform-cost/decision/billing/mappers/gcp"
+	"terraform-cost/decision/calibration"
+	"terraform-cost/decision/carbon"
+	"terraform-cost/decision/currency"
+	"terraform-cost/decision/discount"
+	"terraform-cost/decision/estimation"
+	"terraform-cost/decision/iac"
+	"terraform-cost/decision/network"
+	"terraform-cost/decision/optimize"
+	"terraform-cost/decision/policy"
+	"terraform-cost/decision/usage"
+	tcerrors "terraform-cost/pkg/errors"
+	"terraform-cost/telemetry"
+)
+
+// Estimator estimates the cost of infrastructure plans
+type Estimator struct {
+	pricing      estimation.PricingStore
+	billing      *billing.Engine
+	network      *network.Model
+	policy       *policy.Engine // nil skips policy evaluation
+	failOpen     bool           // Policy evaluation errors pass with a warning
+	carbon       carbon.CarbonStore
+	rates        *currency.Table
+	discounts    discount.Provider
+	aliasRoutes  *estimation.AliasRoutes
+	calibrator   *calibration.Calibrator
+	enricher     *awsmeta.Enricher
+	profiles     []usage.Profile
+	maxResources int
+
+	maxSnapshotAge time.Duration // Older snapshots are flagged; 0 disables
+}
+
+// NewEstimator creates an estimator pricing from a store, with the AWS and GCP mappers
+func NewEstimator(pricing estimation.PricingStore) *Estimator {
+	billingEngine := billing.NewEngine()
+	aws.RegisterAllMappers(billingEngine)
+	gcp.RegisterAllMappers(billingEngine)
+
+	return &Estimator{
+		pricing: pricing,
+		billing: billingEngine,
+		network: network.NewModel(),
+	}
+}
+
+// WithBillingEngine replaces the billing engine, e.g. to share one between estimators
+func (e *Estimator) WithBillingEngine(engine *billing.Engine) *Estimator {
+	e.billing = engine
+	return e
+}
+
+// WithMappers registers extra resource mappers, e.g. from plugins; they replace
+// built-in mappers of the same resource type
+func (e *Estimator) WithMappers(mappers ...billing.ResourceMapper) *Estimator {
+	e.billing.RegisterMappers(mappers...)
+	return e
+}
+
+// WithPlaceholderCosts prices resource types without a mapper at assumed
+// monthly costs instead of leaving them out of the estimate
+func (e *Estimator) WithPlaceholderCosts(costs billing.PlaceholderCosts) *Estimator {
+	e.billing.WithFallbackMapper(billing.NewPlaceholderMapper(costs))
+	return e
+}
+
+// WithNetworkModel replaces the data transfer model
+func (e *Estimator) WithNetworkModel(model *network.Model) *Estimator {
+	e.network = model
+	return e
+}
+
+// WithPolicyEngine evaluates estimates against an engine's policies
+func (e *Estimator) WithPolicyEngine(engine *policy.Engine) *Estimator {
+	e.policy = engine
+	return e
+}
+
+// WithPolicyFailOpen makes policy evaluation errors pass with a warning instead of failing the estimate
+func (e *Estimator) WithPolicyFailOpen(failOpen bool) *Estimator {
+	e.failOpen = failOpen
+	return e
+}
+
+// WithCarbonStore enables carbon estimates for requests with IncludeCarbon
+func (e *Estimator) WithCarbonStore(store carbon.CarbonStore) *Estimator {
+	e.carbon = store
+	return e
+}
+
+// WithExchangeRates enables requests in currencies other than USD
+func (e *Estimator) WithExchangeRates(rates *currency.Table) *Estimator {
+	e.rates = rates
+	return e
+}
+
+// WithDiscounts prices estimates net of negotiated discounts, keeping list prices alongside
+func (e *Estimator) WithDiscounts(provider discount.Provider) *Estimator {
+	e.discounts = provider
+	return e
+}
+
+// WithAliasRoutes prices environments and provider partitions from their own pricing aliases
+func (e *Estimator) WithAliasRoutes(routes *estimation.AliasRoutes) *Estimator {
+	e.aliasRoutes = routes
+	return e
+}
+
+// WithCalibrator replaces heuristic usage of running resources with their history
+func (e *Estimator) WithCalibrator(calibrator *calibration.Calibrator) *Estimator {
+	e.calibrator = calibrator
+	return e
+}
+
+// WithEnricher resolves AMI platforms, default root volumes and instance hardware
+// from AWS metadata
+func (e *Estimator) WithEnricher(enricher *awsmeta.Enricher) *Estimator {
+	e.enricher = enricher
+	return e
+}
+
+// WithUsageProfiles adds usage profiles for environments beyond dev, staging and prod
+func (e *Estimator) WithUsageProfiles(profiles ...usage.Profile) *Estimator {
+	e.profiles = append(e.profiles, profiles...)
+	return e
+}
+
+// WithMaxResources rejects plans with more resources; 0 is unlimited
+func (e *Estimator) WithMaxResources(max int) *Estimator {
+	e.maxResources = max
+	return e
+}
+
+// WithMaxSnapshotAge flags current estimates priced from snapshots fetched longer ago; 0 disables
+func (e *Estimator) WithMaxSnapshotAge(age time.Duration) *Estimator {
+	e.maxSnapshotAge = age
+	return e
+}
+
+// Request is what to estimate a parsed plan for
+type Request struct {
+	Environment     string      // Usage profile
+	Project         string      // Budgets covering the project are checked
+	Usage           *usage.File // Per-resource usage overrides
+	IncludeCarbon   bool
+	IncludeFormulas bool
+	AllocationTags  []string         // Tag keys for cost by tag
+	PricingDate     time.Time        // Prices from the snapshots valid then; zero is current
+	PricingAlias    string           // Prices from this alias's snapshots; empty routes by WithAliasRoutes
+	Timebase        billing.Timebase // Hours billed per month; default 730
+	ProvisionedAt   time.Time        // Prorates the first month in Estimation.FirstMonth; zero skips it
+	Currency        string           // Default USD
+	Simulation      *estimation.SimulationOptions
+	FreeTier        *estimation.FreeTier // Allowances subtracted from usage; nil ignores the free tier
+	RelaxedMatching bool                 // Price rate keys with no exact match from the nearest rate
+
+	// Region re-prices the plan as if deployed there (see ParseRegion); resources
+	// of other clouds keep their region
+	Region string
+
+	// Diff leaves deleted resources out of the estimate and reports them, and
+	// the one-time churn of replacements, in Result.Diff
+	Diff bool
+
+	// Policy
+	Policies []policy.Policy // Evaluated on top of the policy engine's
+	Baseline *policy.Baseline
+}
+
+// Result is the output of every pipeline stage
+type Result struct {
+	Graph         *iac.Graph
+	Decomposition *billing.DecompositionResult
+	Components    []billing.BillingComponent // With usage predictions applied
+	Estimation    *estimation.EstimationResult
+	Policy        *policy.EvaluationResult // nil without a policy engine
+	Optimization  *optimize.Report
+	Issues        []tcerrors.Issue // Unsupported resources, missing prices, stale snapshots and policy denials
+	Diff          *Diff            // With Request.Diff
+}
+
+// Parse parses a plan, enforcing the resource limit
+func (e *Estimator) Parse(ctx context.Context, parser iac.PlanParser, r io.Reader) (*iac.ParsedPlan, error) {
+	return e.parse(ctx, parser, func() (*iac.ParsedPlan, error) { return parser.Parse(r) })
+}
+
+// ParseFile parses a plan file, or a configuration directory with an HCL parser
+func (e *Estimator) ParseFile(ctx context.Context, parser iac.PlanParser, path string) (*iac.ParsedPlan, error) {
+	return e.parse(ctx, parser, func() (*iac.ParsedPlan, error) { return parser.ParseFile(path) })
+}
+
+func (e *Estimator) parse(ctx context.Context, parser iac.PlanParser, parse func() (*iac.ParsedPlan, error)) (*iac.ParsedPlan, error) {
+	// Terraform plans are streamed and stop at the limit; other formats are checked once parsed
+	if tf, ok := parser.(*iac.Parser); ok {
+		tf.MaxResources = e.maxResources
+	}
+	_, span := telemetry.StartSpan(ctx, "iac.parse", attribute.String("iac.format", formatOf(parser)))
+	plan, err := parse()
+	if err == nil {
+		err = iac.CheckResourceLimit(plan, e.maxResources)
+	}
+	telemetry.EndSpan(span, err)
+	if errors.Is(err, iac.ErrTooManyResources) {
+		return nil, tcerrors.Wrap(tcerrors.CodePlanTooLarge, err, "")
+	}
+	if err != nil {
+		return nil, tcerrors.Wrap(tcerrors.CodeParseFailed, err, "")
+	}
+	return plan, nil
+}
+
+// formatOf names a parser's plan format for telemetry
+func formatOf(parser iac.PlanParser) string {
+	switch parser.(type) {
+	case *iac.PulumiParser:
+		return iac.FormatPulumi
+	case *iac.CloudFormationParser:
+		return iac.FormatCloudFormation
+	case *iac.HCLParser:
+		return iac.FormatHCL
+	default:
+		return iac.FormatTerraform
+	}
+}
+
+// Estimate runs the pipeline on a parsed plan: build the graph, decompose it into
+// billing components, predict usage, price and evaluate policy
+func (e *Estimator) Estimate(ctx context.Context, plan *iac.ParsedPlan, req Request) (*Result, error) {
+	var region *Region
+	if req.Region != "" {
+		r, err := ParseRegion(req.Region)
+		if err != nil {
+			return nil, err
+		}
+		region = &r
+	}
+
+	graph, decomposition, enrichWarnings, err := e.decompose(ctx, plan, region)
+	if err != nil {
+		return nil, err
+	}
+
+	components, warnings, err := e.predict(ctx, graph, decomposition.Components, req)
+	if err != nil {
+		return nil, err
+	}
+	warnings = append(enrichWarnings, warnings...)
+
+	var deleted []billing.BillingComponent
+	if req.Diff {
+		components, deleted = splitDeletions(graph, components)
+	}
+	result, err := e.price(ctx, components, decomposition, req)
+	if err != nil {
+		return nil, err
+	}
+	result.Warnings = append(result.Warnings, warnings...)
+
+	policyResult, err := e.evaluate(ctx, result, req)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &Result{
+		Graph:         graph,
+		Decomposition: decomposition,
+		Components:    components,
+		Estimation:    result,
+		Policy:        policyResult,
+		Optimization:  optimize.Analyze(graph, components, result, req.Environment),
+	}
+	if req.Diff {
+		if run.Diff, err = e.diff(ctx, graph, decomposition, result, components, deleted, req); err != nil {
+			return nil, err
+		}
+	}
+	run.Issues = e.issues(ctx, run, req)
+	return run, nil
+}
+
+// decompose builds the infrastructure graph and its billing components, with data
+// transfer, returning the warnings of metadata enrichment. A non-nil region moves
+// the resources of its cloud there.
+func (e *Estimator) decompose(ctx context.Context, plan *iac.ParsedPlan, region *Region) (*iac.Graph, *billing.DecompositionResult, []string, error) {
+	_, graphSpan := telemetry.StartSpan(ctx, "iac.build_graph")
+	graph, err := iac.NewGraphBuilder().Build(plan)
+	telemetry.EndSpan(graphSpan, err)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to build infrastructure graph: %w", err)
+	}
+	if region != nil {
+		relocateGraph(graph, *region)
+	}
+
+	var warnings []string
+	if e.enricher != nil {
+		_, enrichSpan := telemetry.StartSpan(ctx, "awsmeta.enrich_graph")
+		warnings = e.enricher.EnrichGraph(ctx, graph)
+		enrichSpan.End()
+	}
+
+	_, decomposeSpan := telemetry.StartSpan(ctx, "billing.decompose", attribute.Int("iac.resources", graph.ResourceCount))
+	decomposition, err := e.billing.Decompose(graph)
+	telemetry.EndSpan(decomposeSpan, err)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to decompose resources: %w", err)
+	}
+
+	if e.enricher != nil {
+		_, enrichSpan := telemetry.StartSpan(ctx, "awsmeta.enrich_components")
+		warnings = append(warnings, e.enricher.EnrichComponents(ctx, decomposition.Components)...)
+		enrichSpan.End()
+	}
+
+	// Add data transfer inferred from the graph topology
+	_, networkSpan := telemetry.StartSpan(ctx, "network.transfer")
+	decomposition.Components = append(decomposition.Components, e.network.Components(graph)...)
+	networkSpan.End()
+
+	if region != nil && relocateComponents(decomposition.Components, *region) == 0 {
+		warnings = append(warnings, fmt.Sprintf("no regional %s resources to move to %s", region.Cloud, region.Name))
+	}
+
+	return graph, decomposition, warnings, nil
+}
+
+// predict calibrates and predicts component usage, returning the warnings of both
+func (e *Estimator) predict(ctx context.Context, graph *iac.Graph, components []billing.BillingComponent, req Request) ([]billing.BillingComponent, []string, error) {
+	var warnings []string
+	if e.calibrator != nil {
+		_, calibrateSpan := telemetry.StartSpan(ctx, "calibration.calibrate")
+		components, warnings = e.calibrator.Calibrate(ctx, graph, components)
+		calibrateSpan.End()
+	}
+
+	predictor := usage.NewPredictor(req.Environment).WithSchedules(usage.DetectSchedules(graph))
+	for _, profile := range e.profiles {
+		if err := predictor.RegisterProfile(profile); err != nil {
+			return nil, nil, err
+		}
+	}
+	if req.Usage != nil {
+		predictor.WithUsageFile(req.Usage)
+	}
+	_, predictSpan := telemetry.StartSpan(ctx, "usage.predict")
+	components, usageWarnings := predictor.Predict(components)
+	predictSpan.End()
+
+	return components, append(warnings, usageWarnings...), nil
+}
+
+// price estimates the cost of usage-predicted components
+func (e *Estimator) price(ctx context.Context, components []billing.BillingComponent, decomposition *billing.DecompositionResult, req Request) (*estimation.EstimationResult, error) {
+	engine := estimation.NewEngine(e.pricing).WithExchangeRates(e.rates).WithAliasRoutes(e.aliasRoutes)
+	if e.carbon != nil {
+		engine.WithCarbonStore(e.carbon)
+	}
+	if e.discounts != nil {
+		engine.WithDiscounts(e.discounts)
+	}
+	result, err := engine.Estimate(ctx, estimation.EstimationRequest{
+		Components:        components,
+		Environment:       req.Environment,
+		IncludeCarbon:     req.IncludeCarbon,
+		IncludeFormulas:   req.IncludeFormulas,
+		AllocationTags:    req.AllocationTags,
+		PricingDate:       req.PricingDate,
+		PricingAlias:      req.PricingAlias,
+		Timebase:          req.Timebase,
+		ProvisionedAt:     req.ProvisionedAt,
+		Currency:          req.Currency,
+		Simulation:        req.Simulation,
+		FreeTier:          req.FreeTier,
+		RelaxedMatching:   req.RelaxedMatching,
+		UnmappedResources: decomposition.UnmappedResources(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("estimation failed: %w", err)
+	}
+	return result, nil
+}
+
+// evaluate runs the policy engine on an estimate
+func (e *Estimator) evaluate(ctx context.Context, result *estimation.EstimationResult, req Request) (*policy.EvaluationResult, error) {
+	if e.policy == nil {
+		return nil, nil
+	}
+	policyResult, err := e.policy.Evaluate(ctx, policy.EvaluationRequest{
+		Estimation:     result,
+		Environment:    req.Environment,
+		Project:        req.Project,
+		CustomPolicies: req.Policies,
+		Baseline:       req.Baseline,
+	})
+	if err != nil && e.failOpen {
+		return &policy.EvaluationResult{
+			Decision: policy.DecisionPass,
+			Warnings: []policy.Warning{{Message: fmt.Sprintf("policy evaluation failed: %v", err)}},
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("policy evaluation failed: %w", err)
+	}
+	return policyResult, nil
+}
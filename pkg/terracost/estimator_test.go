@@ -0,0 +1,243 @@
+// Package terracost - Estimator tests
+package terracost
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/iac"
+	"terraform-cost/decision/policy"
+	tcerrors "terraform-cost/pkg/errors"
+)
+
+const testPlan = `{"format_version":"1.2","terraform_version":"1.6.0",
+"resource_changes":[
+ {"address":"aws_instance.web","mode":"managed","type":"aws_instance","name":"web","provider_name":"registry.terraform.io/hashicorp/aws",
+  "change":{"actions":["create"],"before":null,"after":{"instance_type":"t3.micro"}}},
+ {"address":"aws_eip.web","mode":"managed","type":"aws_eip","name":"web","provider_name":"registry.terraform.io/hashicorp/aws",
+  "change":{"actions":["create"],"before":null,"after":{"domain":"vpc"}}}
+],
+"configuration":{"provider_config":{"aws":{"name":"aws","expressions":{"region":{"constant_value":"us-east-1"}}}}}}`
+
+// flatStore prices every lookup at the same rate
+type flatStore struct{ price decimal.Decimal }
+
+func (s *flatStore) ResolveRate(ctx context.Context, cloud clickhouse.CloudProvider, service, productFamily, region string, attrs map[string]string, unit, alias string) (*clickhouse.ResolvedRate, error) {
+	return &clickhouse.ResolvedRate{Price: s.price, Currency: "USD", Confidence: 1, SnapshotID: uuid.New()}, nil
+}
+
+func (s *flatStore) ResolveRatesBatch(ctx context.Context, lookups []clickhouse.RateLookup) (map[string]*clickhouse.ResolvedRate, error) {
+	rates := make(map[string]*clickhouse.ResolvedRate, len(lookups))
+	for _, l := range lookups {
+		rates[l.Key()] = &clickhouse.ResolvedRate{Price: s.price, Currency: "USD", Confidence: 1, SnapshotID: uuid.New()}
+	}
+	return rates, nil
+}
+
+func (s *flatStore) ResolveSpotRate(ctx context.Context, cloud clickhouse.CloudProvider, region, instanceType, productDescription string, lookback time.Duration, at time.Time) (*clickhouse.SpotRate, error) {
+	return nil, nil
+}
+
+func parseTestPlan(t *testing.T, estimator *Estimator) *iac.ParsedPlan {
+	t.Helper()
+	plan, err := estimator.Parse(context.Background(), iac.NewParser(), strings.NewReader(testPlan))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return plan
+}
+
+func TestEstimate(t *testing.T) {
+	estimator := NewEstimator(&flatStore{price: decimal.NewFromFloat(0.01)}).
+		WithPolicyEngine(policy.NewEngine())
+	req := Request{
+		Environment: "prod",
+		Policies: []policy.Policy{{
+			ID: "limit", Name: "Cost Limit", Type: policy.PolicyTypeCostLimit,
+			Severity: policy.SeverityError, Threshold: 0.01, Enabled: true,
+		}},
+	}
+
+	result, err := estimator.Estimate(context.Background(), parseTestPlan(t, estimator), req)
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	if result.Graph.ResourceCount != 2 || len(result.Components) == 0 {
+		t.Errorf("resources = %d, components = %d", result.Graph.ResourceCount, len(result.Components))
+	}
+	if !result.Estimation.MonthlyCostP50.IsPositive() {
+		t.Errorf("monthly cost = %s, want positive", result.Estimation.MonthlyCostP50)
+	}
+	if result.Policy == nil || result.Policy.Decision != policy.DecisionDeny {
+		t.Errorf("policy result = %+v, want deny", result.Policy)
+	}
+	if result.Optimization == nil {
+		t.Error("no optimization report")
+	}
+	if !hasIssue(result.Issues, tcerrors.CodePolicyDeny) {
+		t.Errorf("issues = %+v, want %s", result.Issues, tcerrors.CodePolicyDeny)
+	}
+}
+
+func hasIssue(issues []tcerrors.Issue, code tcerrors.Code) bool {
+	for _, issue := range issues {
+		if issue.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// staleStore's snapshots were all fetched a week ago
+type staleStore struct{ flatStore }
+
+func (s *staleStore) GetSnapshot(ctx context.Context, id uuid.UUID) (*clickhouse.PricingSnapshot, error) {
+	return &clickhouse.PricingSnapshot{ID: id, FetchedAt: time.Now().Add(-7 * 24 * time.Hour)}, nil
+}
+
+func TestEstimateStaleSnapshot(t *testing.T) {
+	store := &staleStore{flatStore{price: decimal.NewFromFloat(0.01)}}
+	tests := []struct {
+		name   string
+		maxAge time.Duration
+		req    Request
+		want   bool
+	}{
+		{"older than max age", 24 * time.Hour, Request{}, true},
+		{"within max age", 30 * 24 * time.Hour, Request{}, false},
+		{"check disabled", 0, Request{}, false},
+		{"pricing date", 24 * time.Hour, Request{PricingDate: time.Now().Add(-30 * 24 * time.Hour)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			estimator := NewEstimator(store).WithMaxSnapshotAge(tt.maxAge)
+			tt.req.Environment = "dev"
+			result, err := estimator.Estimate(context.Background(), parseTestPlan(t, estimator), tt.req)
+			if err != nil {
+				t.Fatalf("Estimate: %v", err)
+			}
+			if got := hasIssue(result.Issues, tcerrors.CodeSnapshotStale); got != tt.want {
+				t.Errorf("stale snapshot issue = %v, want %v (issues %+v)", got, tt.want, result.Issues)
+			}
+		})
+	}
+}
+
+func TestEstimateWithoutPolicy(t *testing.T) {
+	estimator := NewEstimator(&flatStore{price: decimal.NewFromFloat(0.01)})
+	result, err := estimator.Estimate(context.Background(), parseTestPlan(t, estimator), Request{Environment: "dev"})
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	if result.Policy != nil {
+		t.Errorf("policy result = %+v, want none without a policy engine", result.Policy)
+	}
+}
+
+func TestParseResourceLimit(t *testing.T) {
+	estimator := NewEstimator(&flatStore{}).WithMaxResources(1)
+	_, err := estimator.Parse(context.Background(), iac.NewParser(), strings.NewReader(testPlan))
+	if !errors.Is(err, iac.ErrTooManyResources) {
+		t.Errorf("err = %v, want ErrTooManyResources", err)
+	}
+	if code := tcerrors.CodeOf(err); code != tcerrors.CodePlanTooLarge {
+		t.Errorf("code = %s, want %s", code, tcerrors.CodePlanTooLarge)
+	}
+}
+
+// regionStore prices lookups by region
+type regionStore struct {
+	flatStore
+	prices map[string]decimal.Decimal
+}
+
+func (s *regionStore) ResolveRatesBatch(ctx context.Context, lookups []clickhouse.RateLookup) (map[string]*clickhouse.ResolvedRate, error) {
+	rates := make(map[string]*clickhouse.ResolvedRate, len(lookups))
+	for _, l := range lookups {
+		if price, ok := s.prices[l.Region]; ok {
+			rates[l.Key()] = &clickhouse.ResolvedRate{Price: price, Currency: "USD", Confidence: 1}
+		}
+	}
+	return rates, nil
+}
+
+func TestEstimateProviderAliases(t *testing.T) {
+	const aliasPlan = `{"format_version":"1.2",
+"resource_changes":[
+ {"address":"aws_instance.east","mode":"managed","type":"aws_instance","name":"east","provider_name":"registry.terraform.io/hashicorp/aws",
+  "change":{"actions":["create"],"before":null,"after":{"instance_type":"t3.micro"}}},
+ {"address":"aws_instance.west","mode":"managed","type":"aws_instance","name":"west","provider_name":"registry.terraform.io/hashicorp/aws",
+  "change":{"actions":["create"],"before":null,"after":{"instance_type":"t3.micro"}}}
+],
+"configuration":{
+ "provider_config":{
+  "aws":{"name":"aws","expressions":{"region":{"constant_value":"us-east-1"}}},
+  "aws.usw2":{"name":"aws","alias":"usw2","expressions":{"region":{"constant_value":"us-west-2"}}}},
+ "root_module":{"resources":[
+  {"address":"aws_instance.east","provider_config_key":"aws"},
+  {"address":"aws_instance.west","provider_config_key":"aws.usw2"}]}}}`
+
+	store := &regionStore{prices: map[string]decimal.Decimal{
+		"us-east-1": decimal.NewFromFloat(0.01),
+		"us-west-2": decimal.NewFromFloat(0.02),
+	}}
+	estimator := NewEstimator(store)
+	plan, err := estimator.Parse(context.Background(), iac.NewParser(), strings.NewReader(aliasPlan))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	result, err := estimator.Estimate(context.Background(), plan, Request{})
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+
+	hourly := make(map[string]decimal.Decimal)
+	for _, d := range result.Estimation.CostDrivers {
+		if d.ProductFamily == "Compute Instance" {
+			hourly[d.ResourceAddr] = d.UnitPrice
+			if d.IsSymbolic {
+				t.Errorf("%s in %s is unpriced", d.ResourceAddr, d.Region)
+			}
+		}
+	}
+	if !hourly["aws_instance.east"].Equal(decimal.NewFromFloat(0.01)) || !hourly["aws_instance.west"].Equal(decimal.NewFromFloat(0.02)) {
+		t.Errorf("hourly prices = %v, want us-east-1 and us-west-2 rates", hourly)
+	}
+}
+
+func TestPolicyIssues(t *testing.T) {
+	violations := []policy.Violation{{PolicyID: "limit", Message: "over"}}
+	warnings := []policy.Warning{{PolicyID: "confidence", Message: "low"}}
+	tests := []struct {
+		name string
+		eval *policy.EvaluationResult
+		want []tcerrors.Code
+	}{
+		{"no policy", nil, nil},
+		{"pass", &policy.EvaluationResult{Decision: policy.DecisionPass}, nil},
+		{"warn", &policy.EvaluationResult{Decision: policy.DecisionWarn, Violations: violations, Warnings: warnings},
+			[]tcerrors.Code{tcerrors.CodePolicyWarn, tcerrors.CodePolicyWarn}},
+		{"deny", &policy.EvaluationResult{Decision: policy.DecisionDeny, Violations: violations, Warnings: warnings},
+			[]tcerrors.Code{tcerrors.CodePolicyDeny, tcerrors.CodePolicyWarn}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := policyIssues(tt.eval)
+			if len(issues) != len(tt.want) {
+				t.Fatalf("issues = %+v, want codes %v", issues, tt.want)
+			}
+			for i, code := range tt.want {
+				if issues[i].Code != code {
+					t.Errorf("issues[%d] = %s, want %s", i, issues[i].Code, code)
+				}
+			}
+		})
+	}
+}
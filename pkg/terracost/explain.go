@@ -0,0 +1,136 @@
+// Package terracost - Explain mode
+// An explanation traces one resource's cost through every stage of the
+// pipeline: the mapper and the attributes it read, the components it emitted,
+// their predicted usage and its assumptions, the rate key looked up, the
+// snapshot and price it matched, the formula, and what the resource weighs in
+// the estimate's confidence score.
+package terracost
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/estimation"
+	tcerrors "terraform-cost/pkg/errors"
+)
+
+// Explanation is the derivation of one resource's cost
+type Explanation struct {
+	Address      string `json:"address"`
+	ResourceType string `json:"resource_type"`
+	Provider     string `json:"provider,omitempty"`
+	Region       string `json:"region,omitempty"`
+	Action       string `json:"action,omitempty"`
+	Environment  string `json:"environment,omitempty"` // Usage profile
+
+	Mapper          string                 `json:"mapper,omitempty"` // Empty when no mapper handles the type
+	FallbackMapper  bool                   `json:"fallback_mapper,omitempty"`
+	Attributes      map[string]interface{} `json:"attributes"`                 // Resource attributes the mapper reads
+	UnsetAttributes []string               `json:"unset_attributes,omitempty"` // Attributes the mapper reads that the resource leaves unset
+	MappingErrors   []billing.MappingError `json:"mapping_errors,omitempty"`
+
+	Components []ComponentTrace `json:"components"`
+
+	Currency         string          `json:"currency"`
+	MonthlyCostP50   decimal.Decimal `json:"monthly_cost_p50"`
+	MonthlyCostP90   decimal.Decimal `json:"monthly_cost_p90"`
+	ConfidenceWeight float64         `json:"confidence_weight"` // Share of the estimate's confidence score the resource carries
+}
+
+// ComponentTrace is how one billing component of a resource was priced
+type ComponentTrace struct {
+	ID             string                  `json:"id"`
+	Description    string                  `json:"description"`
+	BillingPeriod  billing.BillingPeriod   `json:"billing_period"`
+	PurchaseOption billing.PurchaseOption  `json:"purchase_option,omitempty"`
+	Hardware       *billing.Hardware       `json:"hardware,omitempty"`
+	Usage          billing.VarianceProfile `json:"usage"` // Predicted usage and the assumptions behind it
+	RateKey        estimation.RateKeyHint  `json:"rate_key"`
+
+	// Price, snapshot, formula and confidence; nil when the component was not estimated
+	Driver           *estimation.CostDriver `json:"driver,omitempty"`
+	ConfidenceWeight float64                `json:"confidence_weight"`
+}
+
+// Explain traces the cost of one resource of an estimate
+// Formulas are only traced for estimates requested with IncludeFormulas.
+func (e *Estimator) Explain(result *Result, addr string) (*Explanation, error) {
+	node := result.Graph.Nodes[addr]
+	if node == nil {
+		return nil, tcerrors.New(tcerrors.CodeNotFound, "resource %s is not in the plan", addr)
+	}
+	est := result.Estimation
+
+	x := &Explanation{
+		Address:      addr,
+		ResourceType: node.Resource.Type,
+		Provider:     node.Provider,
+		Region:       node.Region,
+		Environment:  est.AuditTrail.Environment,
+		Attributes:   make(map[string]interface{}),
+		Components:   make([]ComponentTrace, 0),
+		Currency:     est.Currency,
+	}
+	if node.Change != nil {
+		x.Action = string(node.Change.Action)
+	}
+
+	if mapper, fallback := e.billing.MapperFor(node.Resource.Type); mapper != nil {
+		x.Mapper = fmt.Sprintf("%T", mapper)
+		x.FallbackMapper = fallback
+		for _, name := range mapper.SupportedAttributes() {
+			if v, ok := node.Resource.Attributes[name]; ok && v != nil {
+				x.Attributes[name] = v
+			} else {
+				x.UnsetAttributes = append(x.UnsetAttributes, name)
+			}
+		}
+		sort.Strings(x.UnsetAttributes)
+	}
+	for _, me := range result.Decomposition.MappingErrors {
+		if me.ResourceAddr == addr {
+			x.MappingErrors = append(x.MappingErrors, me)
+		}
+	}
+
+	drivers := make(map[string]int, len(est.CostDrivers))
+	for i, d := range est.CostDrivers {
+		drivers[d.ComponentID] = i
+	}
+	weights := estimation.ConfidenceWeights(est.CostDrivers)
+	for _, comp := range result.Components {
+		if comp.ResourceAddr != addr {
+			continue
+		}
+		trace := ComponentTrace{
+			ID:             comp.ID,
+			Description:    comp.Description,
+			BillingPeriod:  comp.BillingPeriod,
+			PurchaseOption: comp.PurchaseOption,
+			Hardware:       comp.Hardware,
+			Usage:          comp.VarianceProfile,
+			RateKey: estimation.RateKeyHint{
+				Cloud:         comp.Cloud,
+				Service:       comp.Service,
+				ProductFamily: comp.ProductFamily,
+				Region:        comp.Region,
+				Alias:         est.AuditTrail.AliasFor(comp.Region),
+				Attributes:    comp.Attributes,
+				Unit:          estimation.UsageUnit(comp.BillingPeriod),
+			},
+		}
+		if i, ok := drivers[comp.ID]; ok {
+			driver := est.CostDrivers[i]
+			trace.Driver = &driver
+			trace.ConfidenceWeight = weights[i]
+			x.MonthlyCostP50 = x.MonthlyCostP50.Add(driver.MonthlyCostP50)
+			x.MonthlyCostP90 = x.MonthlyCostP90.Add(driver.MonthlyCostP90)
+			x.ConfidenceWeight += weights[i]
+		}
+		x.Components = append(x.Components, trace)
+	}
+	return x, nil
+}
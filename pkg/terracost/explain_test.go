@@ -0,0 +1,58 @@
+// Package terracost - Explain mode tests
+package terracost
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	tcerrors "terraform-cost/pkg/errors"
+)
+
+func TestExplain(t *testing.T) {
+	estimator := NewEstimator(&flatStore{price: decimal.NewFromFloat(0.01)})
+	result, err := estimator.Estimate(context.Background(), parseTestPlan(t, estimator), Request{IncludeFormulas: true})
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+
+	x, err := estimator.Explain(result, "aws_instance.web")
+	if err != nil {
+		t.Fatalf("Explain: %v", err)
+	}
+	if x.ResourceType != "aws_instance" || x.Region != "us-east-1" || x.Mapper == "" || x.FallbackMapper {
+		t.Errorf("explanation = %+v", x)
+	}
+	if x.Attributes["instance_type"] != "t3.micro" {
+		t.Errorf("attributes = %v, want instance_type t3.micro", x.Attributes)
+	}
+	if len(x.Components) == 0 {
+		t.Fatal("no components")
+	}
+
+	total := decimal.Zero
+	for _, c := range x.Components {
+		if c.RateKey.Region != "us-east-1" || c.RateKey.Unit == "" || c.RateKey.Alias != "default" {
+			t.Errorf("%s rate key = %+v", c.ID, c.RateKey)
+		}
+		if c.Driver == nil {
+			t.Errorf("%s has no driver", c.ID)
+			continue
+		}
+		if c.Driver.Formula == "" {
+			t.Errorf("%s has no formula", c.ID)
+		}
+		total = total.Add(c.Driver.MonthlyCostP50)
+	}
+	if !total.Equal(x.MonthlyCostP50) || !x.MonthlyCostP50.IsPositive() {
+		t.Errorf("monthly cost = %s, components sum to %s", x.MonthlyCostP50, total)
+	}
+	if x.ConfidenceWeight <= 0 || x.ConfidenceWeight >= 1 {
+		t.Errorf("confidence weight = %v, want a share of the two resources", x.ConfidenceWeight)
+	}
+
+	if _, err := estimator.Explain(result, "aws_instance.missing"); tcerrors.CodeOf(err) != tcerrors.CodeNotFound {
+		t.Errorf("unknown address err = %v, want %s", err, tcerrors.CodeNotFound)
+	}
+}
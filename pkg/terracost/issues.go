@@ -0,0 +1,124 @@
+// Package terracost - Estimate issues
+// Problems that leave an estimate incomplete or untrustworthy without failing
+// it, each with a stable code so callers can decide which ones to fail on.
+package terracost
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/estimation"
+	"terraform-cost/decision/policy"
+	tcerrors "terraform-cost/pkg/errors"
+)
+
+// SnapshotStore looks up pricing snapshots; pricing stores implementing it
+// (clickhouse.Store) let the estimator flag stale prices
+type SnapshotStore interface {
+	GetSnapshot(ctx context.Context, id uuid.UUID) (*clickhouse.PricingSnapshot, error)
+}
+
+// issues lists the problems of an estimate
+func (e *Estimator) issues(ctx context.Context, result *Result, req Request) []tcerrors.Issue {
+	var issues []tcerrors.Issue
+	placeholders := make(map[string]bool)
+	for _, comp := range result.Decomposition.Components {
+		if billing.IsPlaceholder(comp) {
+			placeholders[comp.Attributes[billing.AttrResourceType]] = true
+		}
+	}
+	for _, resourceType := range result.Decomposition.UncoveredTypes {
+		message := fmt.Sprintf("no billing mapper for %s; its cost is not included", resourceType)
+		if placeholders[resourceType] {
+			message = fmt.Sprintf("no billing mapper for %s; a placeholder cost is included", resourceType)
+		}
+		issues = append(issues, tcerrors.Issue{
+			Code:     tcerrors.CodeUnsupportedResource,
+			Message:  message,
+			Resource: resourceType,
+		})
+	}
+	for _, d := range result.Estimation.CostDrivers {
+		if d.IsSymbolic && d.Reason == estimation.ReasonNoPricing {
+			issues = append(issues, tcerrors.Issue{
+				Code:     tcerrors.CodePriceNotFound,
+				Message:  fmt.Sprintf("no %s %s price in %s", d.Service, d.ProductFamily, d.Region),
+				Resource: d.ResourceAddr,
+			})
+		}
+	}
+	if est := result.Estimation; est.IsIncomplete {
+		issues = append(issues, tcerrors.Issue{
+			Code:    tcerrors.CodeEstimateIncomplete,
+			Message: fmt.Sprintf("%d of %d components have no cost; the total is a lower bound", est.ComponentsSymbolic, est.ComponentsProcessed),
+		})
+	}
+	issues = append(issues, e.staleSnapshots(ctx, result.Estimation, req)...)
+	return append(issues, policyIssues(result.Policy)...)
+}
+
+// policyIssues lists a deny's violations as POLICY_DENY, and a warn's
+// violations and every policy warning as POLICY_WARN
+func policyIssues(eval *policy.EvaluationResult) []tcerrors.Issue {
+	if eval == nil || eval.Decision == policy.DecisionPass {
+		return nil
+	}
+	code := tcerrors.CodePolicyDeny
+	if eval.Decision == policy.DecisionWarn {
+		code = tcerrors.CodePolicyWarn
+	}
+	var issues []tcerrors.Issue
+	for _, v := range eval.Violations {
+		issues = append(issues, tcerrors.Issue{
+			Code:    code,
+			Message: fmt.Sprintf("%s: %s", v.PolicyID, v.Message),
+		})
+	}
+	for _, w := range eval.Warnings {
+		issues = append(issues, tcerrors.Issue{
+			Code:    tcerrors.CodePolicyWarn,
+			Message: fmt.Sprintf("%s: %s", w.PolicyID, w.Message),
+		})
+	}
+	return issues
+}
+
+// staleSnapshots flags the snapshots of a current estimate fetched before the maximum age
+// Estimates as of a pricing date use old snapshots on purpose and are not checked.
+func (e *Estimator) staleSnapshots(ctx context.Context, result *estimation.EstimationResult, req Request) []tcerrors.Issue {
+	snapshots, ok := e.pricing.(SnapshotStore)
+	if !ok || e.maxSnapshotAge <= 0 || !req.PricingDate.IsZero() {
+		return nil
+	}
+	regions := make([]string, 0, len(result.AuditTrail.SnapshotsUsed))
+	for region := range result.AuditTrail.SnapshotsUsed {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	cutoff := time.Now().Add(-e.maxSnapshotAge)
+	var issues []tcerrors.Issue
+	for _, region := range regions {
+		id := result.AuditTrail.SnapshotsUsed[region]
+		snapshot, err := snapshots.GetSnapshot(ctx, id)
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("snapshot age not checked: %v", err))
+			continue
+		}
+		if snapshot != nil && snapshot.FetchedAt.Before(cutoff) {
+			issues = append(issues, tcerrors.Issue{
+				Code: tcerrors.CodeSnapshotStale,
+				Message: fmt.Sprintf("%s prices are from snapshot %s fetched %s, older than %s",
+					region, id, snapshot.FetchedAt.Format(time.RFC3339), e.maxSnapshotAge),
+				Resource: region,
+			})
+		}
+	}
+	return issues
+}
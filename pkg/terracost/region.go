@@ -0,0 +1,93 @@
+// Package terracost - Region relocation
+// What-if estimates of a plan deployed in another region: the regional
+// resources of the region's cloud are moved there before pricing, so rates,
+// carbon intensity and data transfer follow the new region.
+package terracost
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"terraform-cost/db/clickhouse"
+	"terraform-cost/decision/billing"
+	"terraform-cost/decision/iac"
+)
+
+// Region name shapes of each cloud, e.g. eu-west-1, europe-west1 and westeurope
+var (
+	awsRegionPattern   = regexp.MustCompile(`^[a-z]{2}(-gov|-iso[a-z]?)?-[a-z]+-\d+$`)
+	gcpRegionPattern   = regexp.MustCompile(`^[a-z]+-[a-z]+\d+$`)
+	azureRegionPattern = regexp.MustCompile(`^[a-z]+\d?$`)
+)
+
+// providerClouds maps Terraform provider names to clouds
+var providerClouds = map[string]clickhouse.CloudProvider{
+	"aws":         clickhouse.AWS,
+	"google":      clickhouse.GCP,
+	"google-beta": clickhouse.GCP,
+	"gcp":         clickhouse.GCP,
+	"azurerm":     clickhouse.Azure,
+	"azure":       clickhouse.Azure,
+}
+
+// Region is a target region of a what-if estimate
+type Region struct {
+	Cloud clickhouse.CloudProvider
+	Name  string
+}
+
+func (r Region) String() string {
+	return string(r.Cloud) + ":" + r.Name
+}
+
+// ParseRegion parses a target region, either cloud:region or a bare region
+// name whose cloud is inferred from its shape
+func ParseRegion(s string) (Region, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if cloud, name, ok := strings.Cut(s, ":"); ok {
+		switch c := clickhouse.CloudProvider(cloud); c {
+		case clickhouse.AWS, clickhouse.GCP, clickhouse.Azure:
+			if name == "" {
+				return Region{}, fmt.Errorf("invalid region %q: missing region name", s)
+			}
+			return Region{Cloud: c, Name: name}, nil
+		}
+		return Region{}, fmt.Errorf("invalid region %q: unknown cloud %q (aws, gcp, azure)", s, cloud)
+	}
+	switch {
+	case awsRegionPattern.MatchString(s):
+		return Region{Cloud: clickhouse.AWS, Name: s}, nil
+	case gcpRegionPattern.MatchString(s):
+		return Region{Cloud: clickhouse.GCP, Name: s}, nil
+	case azureRegionPattern.MatchString(s):
+		return Region{Cloud: clickhouse.Azure, Name: s}, nil
+	}
+	return Region{}, fmt.Errorf("invalid region %q: cloud not recognized, use cloud:region", s)
+}
+
+// relocateGraph moves the graph's resources of the region's cloud to the region,
+// so data transfer between them is no longer cross-region
+func relocateGraph(graph *iac.Graph, region Region) {
+	for _, node := range graph.Nodes {
+		if node.Region != "" && providerClouds[node.Provider] == region.Cloud {
+			node.Region = region.Name
+		}
+	}
+}
+
+// relocateComponents moves the regional components of the region's cloud to the
+// region, returning how many there are. Mappers may derive a component's region
+// from resource attributes (GCP zones) rather than the graph.
+func relocateComponents(components []billing.BillingComponent, region Region) int {
+	moved := 0
+	for i := range components {
+		comp := &components[i]
+		if comp.Region == "" || clickhouse.CloudProvider(comp.Cloud) != region.Cloud {
+			continue
+		}
+		comp.Region = region.Name
+		moved++
+	}
+	return moved
+}
@@ -0,0 +1,87 @@
+// Package terracost - Region relocation tests
+package terracost
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"terraform-cost/db/clickhouse"
+)
+
+func TestParseRegion(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Region
+		wantErr bool
+	}{
+		{"eu-west-1", Region{clickhouse.AWS, "eu-west-1"}, false},
+		{"us-gov-west-1", Region{clickhouse.AWS, "us-gov-west-1"}, false},
+		{"europe-west1", Region{clickhouse.GCP, "europe-west1"}, false},
+		{"northamerica-northeast1", Region{clickhouse.GCP, "northamerica-northeast1"}, false},
+		{"westeurope", Region{clickhouse.Azure, "westeurope"}, false},
+		{"eastus2", Region{clickhouse.Azure, "eastus2"}, false},
+		{" AWS:eu-central-1 ", Region{clickhouse.AWS, "eu-central-1"}, false},
+		{"gcp:", Region{}, true},
+		{"oci:us-ashburn-1", Region{}, true},
+		{"not a region", Region{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRegion(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRegion(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseRegion(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEstimateRegion(t *testing.T) {
+	estimator := NewEstimator(&flatStore{price: decimal.NewFromFloat(0.01)})
+	plan := parseTestPlan(t, estimator)
+
+	result, err := estimator.Estimate(context.Background(), plan, Request{Region: "eu-west-1"})
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	for _, d := range result.Estimation.CostDrivers {
+		if d.Region != "eu-west-1" {
+			t.Errorf("%s region = %q, want eu-west-1", d.ComponentID, d.Region)
+		}
+	}
+	for _, node := range result.Graph.Nodes {
+		if node.Region != "eu-west-1" {
+			t.Errorf("%s graph region = %q, want eu-west-1", node.Resource.Address, node.Region)
+		}
+	}
+
+	// Resources of other clouds stay where they are
+	result, err = estimator.Estimate(context.Background(), plan, Request{Region: "europe-west1"})
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	for _, d := range result.Estimation.CostDrivers {
+		if d.Region != "us-east-1" {
+			t.Errorf("%s region = %q, want us-east-1", d.ComponentID, d.Region)
+		}
+	}
+	if !hasWarning(result.Estimation.Warnings, "no regional gcp resources to move to europe-west1") {
+		t.Errorf("warnings = %v, want the unmoved region", result.Estimation.Warnings)
+	}
+
+	if _, err := estimator.Estimate(context.Background(), plan, Request{Region: "mars-1"}); err == nil {
+		t.Error("Estimate with an unknown region: want error")
+	}
+}
+
+func hasWarning(warnings []string, want string) bool {
+	for _, w := range warnings {
+		if w == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+// Package telemetry - Logging
+// Every subsystem logs through log/slog: SetupLogging picks the format and
+// level once for the binary, and request-scoped fields (request ID, plan hash,
+// resource counts) ride along in the context so each line of a request can be
+// correlated, with the trace ID when the request is traced.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Log formats
+const (
+	LogFormatConsole = "console" // key=value lines for terminals
+	LogFormatJSON    = "json"    // one JSON object per line for log pipelines
+)
+
+// RequestIDHeader carries the request ID in and out of the API
+const RequestIDHeader = "X-Request-ID"
+
+// LogConfig configures logging
+type LogConfig struct {
+	Format string    // console (default) or json
+	Level  string    // debug, info (default), warn or error
+	Output io.Writer // Default stderr
+}
+
+// SetupLogging makes a logger of the config the slog default
+func SetupLogging(cfg LogConfig) (*slog.Logger, error) {
+	var level slog.Level
+	if cfg.Level != "" {
+		if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+			return nil, fmt.Errorf("invalid log level %q: expected debug, info, warn or error", cfg.Level)
+		}
+	}
+	out := cfg.Output
+	if out == nil {
+		out = os.Stderr
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", LogFormatConsole:
+		handler = slog.NewTextHandler(out, opts)
+	case LogFormatJSON:
+		handler = slog.NewJSONHandler(out, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q: expected console or json", cfg.Format)
+	}
+	logger := slog.New(handler)
+	slog.SetDefault(logger)
+	return logger, nil
+}
+
+type loggerKey struct{}
+type requestIDKey struct{}
+
+// Logger returns the context's logger, with its request fields and trace ID
+func Logger(ctx context.Context) *slog.Logger {
+	logger, ok := ctx.Value(loggerKey{}).(*slog.Logger)
+	if !ok {
+		logger = slog.Default()
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		logger = logger.With("trace_id", sc.TraceID().String())
+	}
+	return logger
+}
+
+// WithLogFields adds fields to every line logged through the context's logger
+func WithLogFields(ctx context.Context, args ...any) context.Context {
+	logger, ok := ctx.Value(loggerKey{}).(*slog.Logger)
+	if !ok {
+		logger = slog.Default()
+	}
+	return context.WithValue(ctx, loggerKey{}, logger.With(args...))
+}
+
+// WithRequestID tags a context with a request ID, sent on with outgoing calls
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the ID of the request a context serves, or ""
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// LoggingMiddleware assigns each request an ID (the caller's X-Request-ID, or a
+// new one), returns it in the response, and logs the request once served
+func LoggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = uuid.NewString()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		ctx := WithLogFields(WithRequestID(r.Context(), id), "request_id", id)
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		level := slog.LevelInfo
+		if rec.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		Logger(ctx).Log(ctx, level, "request served",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"remote_addr", r.RemoteAddr,
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	})
+}
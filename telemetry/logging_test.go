@@ -0,0 +1,53 @@
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLoggingMiddlewareCarriesRequestFields(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	var out bytes.Buffer
+	if _, err := SetupLogging(LogConfig{Format: LogFormatJSON, Level: "debug", Output: &out}); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := LoggingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := WithLogFields(r.Context(), "plan_hash", "abc")
+		Logger(ctx).Debug("estimating")
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/estimate", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get(RequestIDHeader); got != "req-1" {
+		t.Errorf("response request ID = %q", got)
+	}
+	var lines []map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n")) {
+		var entry map[string]any
+		if err := json.Unmarshal(line, &entry); err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+		lines = append(lines, entry)
+	}
+	if len(lines) != 2 || lines[0]["plan_hash"] != "abc" || lines[0]["request_id"] != "req-1" ||
+		lines[1]["msg"] != "request served" || lines[1]["status"] != float64(http.StatusTeapot) {
+		t.Errorf("log lines %v", lines)
+	}
+}
+
+func TestSetupLoggingRejectsUnknownSettings(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	for _, cfg := range []LogConfig{{Format: "xml"}, {Level: "loud"}} {
+		if _, err := SetupLogging(cfg); err == nil {
+			t.Errorf("%+v: expected an error", cfg)
+		}
+	}
+}
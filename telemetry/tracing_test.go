@@ -0,0 +1,52 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestHTTPMiddlewareContinuesTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	if _, err := Setup(context.Background(), Config{}); err != nil {
+		t.Fatal(err)
+	}
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	var inner trace.SpanContext
+	handler := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, span := StartSpan(r.Context(), "estimation.estimate")
+		inner = span.SpanContext()
+		span.End()
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/estimate", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if inner.TraceID().String() != traceID {
+		t.Errorf("child span trace = %s, want %s", inner.TraceID(), traceID)
+	}
+	spans := recorder.Ended()
+	if len(spans) != 2 || spans[1].Name() != "POST /api/v1/estimate" {
+		t.Fatalf("unexpected spans %v", spans)
+	}
+	if spans[1].Status().Code.String() != "Error" {
+		t.Errorf("server span status = %v, want Error for 500", spans[1].Status())
+	}
+
+	// Outgoing calls carry the same trace
+	out, _ := http.NewRequest(http.MethodPost, "http://opa/v1/data", nil)
+	InjectHTTP(trace.ContextWithSpanContext(context.Background(), inner), out)
+	if got := out.Header.Get("traceparent"); len(got) < 35 || got[3:35] != traceID {
+		t.Errorf("traceparent = %q", got)
+	}
+}
@@ -0,0 +1,168 @@
+// Package tenant scopes requests to an organization and its projects
+// The tenant comes from signed auth claims and travels in the request context
+// down to storage, which namespaces estimates, budgets, jobs and snapshots by org.
+package tenant
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Tenant is the organization a request acts for
+type Tenant struct {
+	OrgID    string   `json:"org_id"`
+	Projects []string `json:"projects,omitempty"` // Projects the caller may use; empty allows all
+	Subject  string   `json:"sub,omitempty"`
+	Approver bool     `json:"approver,omitempty"` // May decide approvals of denied estimates
+}
+
+// AllowsProject reports whether the tenant may use a project
+// An empty project means the whole org, which only unrestricted tenants may use.
+func (t Tenant) AllowsProject(project string) bool {
+	if len(t.Projects) == 0 {
+		return true
+	}
+	for _, p := range t.Projects {
+		if p == project || p == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the tenant
+func NewContext(ctx context.Context, t Tenant) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext returns the request's tenant, if authenticated
+func FromContext(ctx context.Context) (Tenant, bool) {
+	t, ok := ctx.Value(contextKey{}).(Tenant)
+	return t, ok
+}
+
+// OrgID returns the request's organization; empty without a tenant (single-tenant mode)
+func OrgID(ctx context.Context) string {
+	t, _ := FromContext(ctx)
+	return t.OrgID
+}
+
+// =============================================================================
+// TOKENS
+// =============================================================================
+
+// ErrInvalidToken is returned for malformed, badly signed or expired tokens
+var ErrInvalidToken = errors.New("invalid token")
+
+// claims are the JWT claims a tenant is read from
+type claims struct {
+	Subject   string   `json:"sub"`
+	OrgID     string   `json:"org_id"`
+	Projects  []string `json:"projects"`
+	Approver  bool     `json:"approver,omitempty"`
+	ExpiresAt int64    `json:"exp"`
+	NotBefore int64    `json:"nbf"`
+}
+
+// Verifier checks HS256-signed JWTs carrying org_id and projects claims
+type Verifier struct {
+	secret []byte
+	now    func() time.Time
+}
+
+// NewVerifier creates a verifier for tokens signed with the shared secret
+func NewVerifier(secret []byte) *Verifier {
+	return &Verifier{secret: secret, now: time.Now}
+}
+
+// Verify checks a token's signature and validity window and returns its tenant
+func (v *Verifier) Verify(token string) (Tenant, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Tenant{}, fmt.Errorf("%w: expected header.payload.signature", ErrInvalidToken)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Tenant{}, err
+	}
+	if header.Alg != "HS256" {
+		return Tenant{}, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, v.sign(parts[0]+"."+parts[1])) {
+		return Tenant{}, fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return Tenant{}, err
+	}
+	now := v.now().Unix()
+	if c.ExpiresAt != 0 && now >= c.ExpiresAt {
+		return Tenant{}, fmt.Errorf("%w: expired", ErrInvalidToken)
+	}
+	if c.NotBefore != 0 && now < c.NotBefore {
+		return Tenant{}, fmt.Errorf("%w: not valid yet", ErrInvalidToken)
+	}
+	if c.OrgID == "" {
+		return Tenant{}, fmt.Errorf("%w: missing org_id claim", ErrInvalidToken)
+	}
+	return Tenant{OrgID: c.OrgID, Projects: c.Projects, Subject: c.Subject, Approver: c.Approver}, nil
+}
+
+// Sign issues a token for a tenant, valid for ttl (0: no expiry)
+func (v *Verifier) Sign(t Tenant, ttl time.Duration) (string, error) {
+	c := claims{Subject: t.Subject, OrgID: t.OrgID, Projects: t.Projects, Approver: t.Approver}
+	if ttl != 0 {
+		c.ExpiresAt = v.now().Add(ttl).Unix()
+	}
+	header, err := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(v.sign(unsigned)), nil
+}
+
+func (v *Verifier) sign(unsigned string) []byte {
+	mac := hmac.New(sha256.New, v.secret)
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
+
+func decodeSegment(segment string, into interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if err := json.Unmarshal(data, into); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return nil
+}
+
+// BearerToken extracts the token from an Authorization header value
+func BearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
@@ -0,0 +1,74 @@
+package tenant
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	v := NewVerifier([]byte("secret"))
+	v.now = func() time.Time { return now }
+
+	valid, err := v.Sign(Tenant{OrgID: "acme", Projects: []string{"web"}, Subject: "ci"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	approver, _ := v.Sign(Tenant{OrgID: "acme", Projects: []string{"web"}, Subject: "lead", Approver: true}, time.Hour)
+	noOrg, _ := v.Sign(Tenant{Subject: "ci"}, time.Hour)
+	expired, _ := v.Sign(Tenant{OrgID: "acme"}, -time.Minute)
+	other := NewVerifier([]byte("other"))
+	other.now = v.now
+	wrongKey, _ := other.Sign(Tenant{OrgID: "acme"}, time.Hour)
+	parts := strings.Split(valid, ".")
+	tampered := parts[0] + "." + strings.TrimRight(parts[1], "=") + "x." + parts[2]
+
+	tests := []struct {
+		name    string
+		token   string
+		wantOrg string
+	}{
+		{"valid", valid, "acme"},
+		{"approver", approver, "acme"},
+		{"missing org", noOrg, ""},
+		{"expired", expired, ""},
+		{"wrong key", wrongKey, ""},
+		{"tampered payload", tampered, ""},
+		{"malformed", "abc", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := v.Verify(tt.token)
+			if tt.wantOrg == "" {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("err = %v, want ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.OrgID != tt.wantOrg || !got.AllowsProject("web") || got.AllowsProject("api") || got.Approver != (tt.name == "approver") {
+				t.Errorf("tenant = %+v", got)
+			}
+		})
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := map[string]string{
+		"Bearer abc":  "abc",
+		"bearer  abc": "abc",
+		"Basic abc":   "",
+		"Bearer ":     "",
+		"":            "",
+	}
+	for header, want := range tests {
+		got, _ := BearerToken(header)
+		if got != want {
+			t.Errorf("BearerToken(%q) = %q, want %q", header, got, want)
+		}
+	}
+}